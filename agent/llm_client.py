@@ -5,9 +5,11 @@ Supports Claude (Anthropic), Gemini (Google), and OpenAI (GPT-4, Codex).
 """
 
 import os
+import json
 import time
 import logging
 from abc import ABC, abstractmethod
+from datetime import datetime, timezone
 from typing import Dict, Optional, Any
 from dataclasses import dataclass
 
@@ -70,6 +72,26 @@ class LLMClient(ABC):
                 )
                 time.sleep(wait_time)
     
+    def _record_usage(self, result: CompletionResult):
+        """Append a completion to the usage ledger that asc reads to enforce budgets."""
+        path = os.getenv("ASC_USAGE_FILE")
+        if not path:
+            return
+
+        record = {
+            "timestamp": datetime.now(timezone.utc).isoformat(),
+            "agent": os.getenv("AGENT_NAME", ""),
+            "model": result.model,
+            "tokens": result.tokens_used,
+            "cost_usd": result.cost_usd,
+        }
+        try:
+            os.makedirs(os.path.dirname(path), exist_ok=True)
+            with open(path, "a") as f:
+                f.write(json.dumps(record) + "\n")
+        except OSError as e:
+            self.logger.warning(f"Failed to record usage: {e}")
+    
     def get_stats(self) -> Dict[str, Any]:
         """Get usage statistics."""
         return {
@@ -155,6 +177,7 @@ class ClaudeClient(LLMClient):
                 f"Completion successful: {result.tokens_used} tokens, "
                 f"${result.cost_usd:.4f}"
             )
+            self._record_usage(result)
             return result
         except Exception as e:
             self.logger.error(f"Claude API error: {e}", exc_info=True)
@@ -250,6 +273,7 @@ class GeminiClient(LLMClient):
                 f"Completion successful: {result.tokens_used} tokens (est), "
                 f"${result.cost_usd:.4f}"
             )
+            self._record_usage(result)
             return result
         except Exception as e:
             self.logger.error(f"Gemini API error: {e}", exc_info=True)
@@ -328,6 +352,7 @@ class OpenAIClient(LLMClient):
                 f"Completion successful: {result.tokens_used} tokens, "
                 f"${result.cost_usd:.4f}"
             )
+            self._record_usage(result)
             return result
         except Exception as e:
             self.logger.error(f"OpenAI API error: {e}", exc_info=True)
@@ -6,6 +6,7 @@ and updates task status.
 """
 
 import json
+import os
 import subprocess
 import logging
 import requests
@@ -54,6 +55,8 @@ class HephaestusLoop:
         self.llm_client = llm_client
         self.playbook = playbook
         self.beads_db_path = Path(beads_db_path)
+        # Files are edited in the agent's own worktree when asc provisioned one
+        self.work_dir = Path(os.getenv("AGENT_WORKTREE") or beads_db_path)
         self.mcp_url = mcp_url.rstrip("/")
         self.heartbeat_manager = heartbeat_manager
         self.logger = logger
@@ -159,6 +162,7 @@ class HephaestusLoop:
             
             # Parse and execute action plan
             self._execute_action_plan(result.content, leases)
+            self._commit_work(task)
             
             # Update task status to complete
             self._update_task_status(task.id, "complete")
@@ -248,7 +252,7 @@ class HephaestusLoop:
         # Read leased files
         for lease in leases:
             try:
-                file_path = self.beads_db_path / lease.file_path
+                file_path = self.work_dir / lease.file_path
                 if file_path.exists():
                     with open(file_path, 'r', encoding='utf-8') as f:
                         context["files"][lease.file_path] = f.read()
@@ -258,7 +262,18 @@ class HephaestusLoop:
         return context
     
     def _get_system_prompt(self) -> str:
-        """Get the system prompt for the LLM."""
+        """Get the system prompt for the LLM.
+
+        Uses the versioned prompt asc rendered for this agent (AGENT_PROMPT_FILE)
+        when one is configured, falling back to the built-in prompt.
+        """
+        prompt_file = os.environ.get("AGENT_PROMPT_FILE")
+        if prompt_file:
+            try:
+                return Path(prompt_file).read_text()
+            except OSError as e:
+                self.logger.warning(f"Failed to read prompt {prompt_file}: {e}; using built-in prompt")
+
         return """You are an AI coding agent working on software development tasks.
 Your role is to analyze tasks, plan solutions, and execute file operations.
 
@@ -342,7 +357,7 @@ Be thorough but concise. Focus on delivering working code."""
                     )
                     continue
                 
-                full_path = self.beads_db_path / file_path
+                full_path = self.work_dir / file_path
                 
                 if action_type == "read":
                     # Already read in context building
@@ -365,6 +380,35 @@ Be thorough but concise. Focus on delivering working code."""
         except Exception as e:
             self.logger.error(f"Error executing action plan: {e}", exc_info=True)
     
+    def _commit_work(self, task: Task):
+        """Commit the task's changes to the agent's worktree branch."""
+        if not os.getenv("AGENT_WORKTREE"):
+            return
+        
+        try:
+            subprocess.run(
+                ["git", "add", "-A"],
+                cwd=self.work_dir,
+                capture_output=True,
+                timeout=30,
+                check=True
+            )
+            result = subprocess.run(
+                ["git", "commit", "-m", f"{task.id}: {task.title}\n\nAgent: {self.agent_name}"],
+                cwd=self.work_dir,
+                capture_output=True,
+                text=True,
+                timeout=30
+            )
+            
+            if result.returncode == 0:
+                self.logger.info(f"Committed work for task {task.id}")
+            elif "nothing to commit" not in result.stdout:
+                self.logger.warning(f"Failed to commit task {task.id}: {result.stderr}")
+                
+        except Exception as e:
+            self.logger.error(f"Error committing task {task.id}: {e}", exc_info=True)
+    
     def _update_task_status(self, task_id: str, status: str):
         """Update task status in beads."""
         try:
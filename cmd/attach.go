@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var (
+	attachLines int
+	attachStdin bool
+)
+
+// attachPollInterval is how often asc attach checks the log for new
+// output. It is a variable so tests do not wait.
+var attachPollInterval = 200 * time.Millisecond
+
+// attachTailBytes bounds how much of the end of a log is read for the
+// lines shown when attaching
+const attachTailBytes = 1024 * 1024
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <agent>",
+	Short: "Stream a running agent's output",
+	Long: `Print the last lines of an agent's output log and follow it as the agent
+writes to it, until Ctrl-C or the agent exits. mcp_agent_mail is attached
+to by its name. A log rotated while attached is followed from its start.
+
+With --stdin, what is typed is forwarded to the agent as its input. The
+agent must have been started with stdin = true in its asc.toml section;
+forwarding input is not supported on Windows.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runAttach,
+}
+
+func init() {
+	rootCmd.AddCommand(attachCmd)
+	attachCmd.Flags().IntVarP(&attachLines, "lines", "n", 20, "Number of lines to show before following (0 for the whole log)")
+	attachCmd.Flags().BoolVar(&attachStdin, "stdin", false, "Forward input to the agent")
+}
+
+// runAttach streams the output log of a managed process
+func runAttach(cmd *cobra.Command, args []string) {
+	name := args[0]
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		printError("Failed to initialize process manager", err)
+		osExit(1)
+		return
+	}
+	info, err := procManager.GetProcessInfo(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: no managed process named '%s'\n", name)
+		fmt.Fprintln(os.Stderr, "  Suggestion: Run 'asc status' to list the managed processes")
+		osExit(1)
+		return
+	}
+
+	if attachStdin {
+		stdin, err := process.OpenStdin(info)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot forward input: %v\n", err)
+			fmt.Fprintf(os.Stderr, "  Suggestion: Set stdin = true in [agent.%s] and restart the agent with 'asc up'\n", name)
+			osExit(1)
+			return
+		}
+		go func() {
+			defer stdin.Close()
+			_, _ = io.Copy(stdin, os.Stdin)
+		}()
+	}
+
+	ctx := commandContext(cmd)
+	if err := followLog(ctx, os.Stdout, procManager, name, attachLines); err != nil {
+		printError("Failed to read the log", err)
+		osExit(1)
+		return
+	}
+	if ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, i18n.T("attach.not_running", name))
+	}
+}
+
+// followLog writes the last lines of the log of the named process to w,
+// 0 for the whole log, then what is appended to it until ctx is done or
+// the process is no longer running
+func followLog(ctx context.Context, w io.Writer, procManager process.ProcessManager, name string, lines int) error {
+	info, err := procManager.GetProcessInfo(name)
+	if err != nil {
+		return err
+	}
+	offset, err := writeTail(w, info.LogFile, lines)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(attachPollInterval)
+	defer ticker.Stop()
+	for {
+		// Whether it runs is checked first, so output written just before
+		// it exits is still shown
+		running := false
+		if current, err := procManager.GetProcessInfo(name); err == nil {
+			info = current
+			running = process.Live(procManager, info)
+		}
+		if offset, err = copyLog(w, info.LogFile, offset); err != nil {
+			return err
+		}
+		if !running {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeTail writes the last lines of the log at path to w, or all of it
+// for 0, and returns the size of the log. A missing log is empty.
+func writeTail(w io.Writer, path string, lines int) (int64, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := stat.Size()
+	if lines == 0 {
+		_, err := io.Copy(w, io.LimitReader(file, size))
+		return size, err
+	}
+
+	start := size - attachTailBytes
+	if start < 0 {
+		start = 0
+	}
+	data := make([]byte, size-start)
+	if _, err := file.ReadAt(data, start); err != nil && err != io.EOF {
+		return 0, err
+	}
+	if start > 0 {
+		// Skip the partial line the read began in
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	var tail [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), attachTailBytes)
+	for scanner.Scan() {
+		tail = append(tail, append([]byte(nil), scanner.Bytes()...))
+		if len(tail) > lines {
+			tail = tail[1:]
+		}
+	}
+	for _, line := range tail {
+		if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
+			return 0, err
+		}
+	}
+	return size, nil
+}
+
+// copyLog writes what the log at path holds from offset on to w and
+// returns the new offset. A log emptied by rotation since is copied from
+// its start, and a missing one is waited for.
+func copyLog(w io.Writer, path string, offset int64) (int64, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return offset, nil
+	}
+	if err != nil {
+		return offset, err
+	}
+	defer file.Close()
+	if stat, err := file.Stat(); err == nil && stat.Size() < offset {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+	n, err := io.Copy(w, file)
+	return offset + n, err
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/process"
+)
+
+// TestAttachCommand_UnknownAgent tests attaching to a process asc does not manage
+func TestAttachCommand_UnknownAgent(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	exitCode, exitCalled := RunWithExitCapture(func() {
+		runAttach(attachCmd, []string{"ghost"})
+	})
+	capture.Stop()
+
+	if !exitCalled || exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d (called: %v)", exitCode, exitCalled)
+	}
+	if !strings.Contains(capture.GetStderr(), "asc status") {
+		t.Errorf("Expected a suggestion to run asc status, got:\n%s", capture.GetStderr())
+	}
+}
+
+// TestFollowLog_Tail tests that attaching to a stopped agent shows the end of its log
+func TestFollowLog_Tail(t *testing.T) {
+	env := NewTestEnvironment(t)
+	logPath := filepath.Join(env.LogDir, "agent-1.log")
+	if err := os.WriteFile(logPath, []byte("one\ntwo\nthree\nfour\n"), 0600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+	env.WritePIDFile("agent-1", fmt.Sprintf(`{"name": "agent-1", "pid": 1073741824, "command": "python", "log_file": %q}`, logPath))
+	procManager, err := process.NewManager(env.PIDDir, env.LogDir)
+	if err != nil {
+		t.Fatalf("Failed to create process manager: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := followLog(context.Background(), &out, procManager, "agent-1", 2); err != nil {
+		t.Fatalf("followLog failed: %v", err)
+	}
+	if got := out.String(); got != "three\nfour\n" {
+		t.Errorf("Expected the last two lines, got %q", got)
+	}
+
+	out.Reset()
+	if err := followLog(context.Background(), &out, procManager, "agent-1", 0); err != nil {
+		t.Fatalf("followLog failed: %v", err)
+	}
+	if got := out.String(); got != "one\ntwo\nthree\nfour\n" {
+		t.Errorf("Expected the whole log, got %q", got)
+	}
+}
+
+// TestFollowLog_Follow tests that attaching streams output until the agent exits
+func TestFollowLog_Follow(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Uses sh")
+	}
+	env := NewTestEnvironment(t)
+	oldInterval := attachPollInterval
+	attachPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { attachPollInterval = oldInterval })
+
+	procManager, err := process.NewManager(env.PIDDir, env.LogDir)
+	if err != nil {
+		t.Fatalf("Failed to create process manager: %v", err)
+	}
+	if _, err := procManager.Start("agent-1", "sh", []string{"-c", "echo first; sleep 0.2; echo second"}, nil); err != nil {
+		t.Fatalf("Failed to start process: %v", err)
+	}
+
+	var out bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := followLog(ctx, &out, procManager, "agent-1", 20); err != nil {
+		t.Fatalf("followLog failed: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Expected followLog to return when the agent exited")
+	}
+	if got := out.String(); !strings.Contains(got, "first\n") || !strings.Contains(got, "second\n") {
+		t.Errorf("Expected the agent's output, got %q", got)
+	}
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rand/asc/internal/audit"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	auditLines int
+	auditJSON  bool
+	auditUser  string
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log of state-changing actions",
+	Long: `Show who ran which state-changing asc action, with what arguments, and
+whether it succeeded.
+
+asc appends an entry to ~/.asc/audit.log for up, down, init, cleanup,
+check --install, doctor --fix, secrets and
+services commands, prompts add and rollback, pipeline advance and reset, budget resume,
+worktree merge and prune, tasks bulk, tasks template create, apply, delete, and run-due, sync github and jira, and agent restarts, kills, and task edits made from the TUI. Secrets in arguments and messages are masked before they are written.
+
+The log also holds the lifecycle events these cause, such as agents
+starting and fixes being applied; use asc events tail to filter them.`,
+	Run: runAudit,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.Flags().IntVarP(&auditLines, "lines", "n", 50, "Number of entries to show (0 for all)")
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "Output entries as JSON Lines")
+	auditCmd.Flags().StringVar(&auditUser, "user", "", "Only show actions by this user")
+}
+
+func runAudit(cmd *cobra.Command, args []string) {
+	entries, err := audit.Recent(0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		osExit(1)
+		return
+	}
+
+	if auditUser != "" {
+		filtered := []audit.Entry{}
+		for _, entry := range entries {
+			if entry.User == auditUser {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+	if auditLines > 0 && len(entries) > auditLines {
+		entries = entries[len(entries)-auditLines:]
+	}
+
+	if len(entries) == 0 {
+		fmt.Println(i18n.T("audit.none"))
+		return
+	}
+
+	for _, entry := range entries {
+		if auditJSON {
+			line, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			fmt.Println(string(line))
+			continue
+		}
+		fmt.Println(formatAuditEntry(entry))
+	}
+}
+
+// formatAuditEntry renders an entry as a single line:
+// timestamp user@host action args agent task → result: message
+func formatAuditEntry(entry audit.Entry) string {
+	who := entry.User
+	if entry.Host != "" {
+		who += "@" + entry.Host
+	}
+	line := fmt.Sprintf("%s  %s  %s", entry.Timestamp.Format("2006-01-02 15:04:05"), who, entry.Action)
+	if len(entry.Args) > 0 {
+		line += " " + strings.Join(entry.Args, " ")
+	}
+	for _, subject := range []string{entry.Agent, entry.Task} {
+		if subject != "" {
+			line += " " + subject
+		}
+	}
+	line += "  → " + entry.Result
+	if entry.Message != "" {
+		line += ": " + entry.Message
+	}
+	return line
+}
+
+// auditedCommands are the state-changing commands recorded in the audit
+// log, by command path without the root name. Commands whose effect
+// depends on a flag are handled in isAudited.
+var auditedCommands = map[string]bool{
+	"up":                     true,
+	"down":                   true,
+	"budget resume":          true,
+	"backup create":          true,
+	"backup restore":         true,
+	"cleanup":                true,
+	"config migrate":         true,
+	"config set":             true,
+	"init":                   true,
+	"daemon start":           true,
+	"daemon stop":            true,
+	"secrets init":           true,
+	"secrets encrypt":        true,
+	"secrets decrypt":        true,
+	"secrets rotate":         true,
+	"secrets inject":         true,
+	"pipeline advance":       true,
+	"pipeline reset":         true,
+	"reload":                 true,
+	"restart":                true,
+	"scale":                  true,
+	"upgrade":                true,
+	"prompts add":            true,
+	"prompts rollback":       true,
+	"services start":         true,
+	"services stop":          true,
+	"sync github":            true,
+	"sync jira":              true,
+	"tasks bulk":             true,
+	"tasks template create":  true,
+	"tasks template apply":   true,
+	"tasks template delete":  true,
+	"tasks template run-due": true,
+	"worktree merge":         true,
+	"worktree prune":         true,
+}
+
+// pendingAudit is the audit entry of the command in progress, written when
+// the command finishes (see finishAudit)
+var pendingAudit *audit.Entry
+
+// auditAction returns the command path without the root command's name,
+// e.g. "secrets rotate"
+func auditAction(cmd *cobra.Command) string {
+	return strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " ")
+}
+
+// isAudited reports whether running cmd with its current flags changes state
+func isAudited(cmd *cobra.Command) bool {
+	switch auditAction(cmd) {
+	case "check":
+		return checkInstall
+	case "doctor":
+		return doctorFix || len(doctorFixOnly) > 0 || doctorInteractive
+	}
+	return auditedCommands[auditAction(cmd)]
+}
+
+// beginAudit starts the audit entry for a state-changing command,
+// recording its positional arguments and the flags that were set. Dry
+// runs change nothing and are not recorded.
+func beginAudit(cmd *cobra.Command, args []string) {
+	if dryRun || !isAudited(cmd) {
+		return
+	}
+	recorded := append([]string{}, args...)
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		recorded = append(recorded, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+	})
+	entry := audit.NewEntry(auditAction(cmd), recorded...)
+	pendingAudit = &entry
+}
+
+// finishAudit writes the pending audit entry, if any, with the command's
+// result, and stops recording events and sending notifications. It is
+// called when the command returns and from osExit, and only the first
+// call writes.
+func finishAudit(err error) {
+	stopRecordingEvents()
+	stopNotifications()
+	if pendingAudit == nil {
+		return
+	}
+	entry := pendingAudit.Finish(err)
+	pendingAudit = nil
+	recordAudit(entry)
+}
+
+// recordAudit writes an entry to the audit log. Failing to write it is
+// logged but does not fail the action.
+func recordAudit(entry audit.Entry) {
+	if err := audit.Write(entry); err != nil {
+		logger.Warn("Failed to write audit log: %v", err)
+	}
+}
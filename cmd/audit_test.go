@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/audit"
+)
+
+// TestAuditCommandLifecycle tests that state-changing commands are
+// recorded once with their flags and result
+func TestAuditCommandLifecycle(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	oldFix := doctorFix
+	defer func() { doctorFix = oldFix; pendingAudit = nil }()
+
+	// Read-only commands are not recorded
+	doctorFix = false
+	beginAudit(doctorCmd, nil)
+	if pendingAudit != nil {
+		t.Fatal("doctor without --fix should not be audited")
+	}
+
+	if err := doctorCmd.Flags().Set("fix", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer doctorCmd.Flags().Set("fix", "false")
+	beginAudit(doctorCmd, nil)
+	if pendingAudit == nil {
+		t.Fatal("doctor --fix should be audited")
+	}
+	finishAudit(nil)
+	finishAudit(nil) // only the first call writes
+
+	entries, err := audit.Recent(0)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Action != "doctor" || entries[0].Result != audit.ResultSuccess {
+		t.Errorf("Unexpected audit entry: %+v", entries[0])
+	}
+	if len(entries[0].Args) != 1 || entries[0].Args[0] != "--fix=true" {
+		t.Errorf("Expected the --fix flag to be recorded, got %v", entries[0].Args)
+	}
+}
+
+// TestAuditCommand tests the audit command output and filters
+func TestAuditCommand(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	alice := audit.NewEntry("down").Finish(nil)
+	alice.User = "alice"
+	bob := audit.NewEntry("secrets rotate").Finish(nil)
+	bob.User = "bob"
+	for _, entry := range []audit.Entry{alice, bob} {
+		if err := audit.Write(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldUser, oldLines := auditUser, auditLines
+	defer func() { auditUser, auditLines = oldUser, oldLines }()
+	auditUser, auditLines = "bob", 0
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	exitCode, exitCalled := RunWithExitCapture(func() {
+		auditCmd.Run(auditCmd, []string{})
+	})
+	capture.Stop()
+
+	if exitCalled {
+		t.Fatalf("Expected successful completion, got exit code %d", exitCode)
+	}
+	stdout := capture.GetStdout()
+	if !strings.Contains(stdout, "bob") || !strings.Contains(stdout, "secrets rotate  → success") {
+		t.Errorf("Expected bob's action in output, got:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "alice") {
+		t.Errorf("Expected --user to filter out other users, got:\n%s", stdout)
+	}
+}
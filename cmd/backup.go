@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rand/asc/internal/backup"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/upgrade"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupOutput     string
+	backupIncludeKey bool
+	restoreForce     bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up and restore the state of the stack",
+	Long: `Archive the state of this project and of asc, to move it to another
+machine or recover from corruption.
+
+An archive holds asc.toml, .env.age, the overlays and encrypted secrets
+of profiles (asc.*.toml, .env.*.age), the beads database (the .beads
+directory of core.beads_db_path), and the state directory (~/.asc), less
+PID files, worktrees, migration backups, and lock files. The plaintext .env
+is never archived, and the age key that decrypts .env.age only with
+--include-key. A manifest lists the sha256 of every file, and restore
+refuses an archive that does not match it.`,
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Write a backup archive",
+	Long: `Write a tar.gz of the state of this project and of asc. The archive is
+named asc-backup-<time>.tar.gz in the current directory unless --output
+is given, and its sha256 is printed to check a copy against.
+
+Example:
+  asc backup create
+  asc backup create -o /mnt/backups/asc.tar.gz --include-key`,
+	Args: cobra.NoArgs,
+	RunE: runBackupCreate,
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Restore a backup archive",
+	Long: `Check a backup archive against its manifest, then restore its files:
+asc.toml and .env.age to the current directory, the beads database to the
+core.beads_db_path of the archived asc.toml, and the state to the state
+directory. Each file is replaced atomically; files already as archived
+are left alone, and other files are kept.
+
+The stack must be stopped. Files that exist and differ from the archive
+are only overwritten with --force.
+
+Example:
+  asc backup restore asc-backup-20250101-120000.tar.gz`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBackupRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+
+	backupCreateCmd.Flags().StringVarP(&backupOutput, "output", "o", "", "Path of the archive (default asc-backup-<time>.tar.gz)")
+	backupCreateCmd.Flags().BoolVar(&backupIncludeKey, "include-key", false, "Include the age key that decrypts .env.age")
+	backupRestoreCmd.Flags().BoolVar(&restoreForce, "force", false, "Overwrite files that differ from the archive")
+}
+
+func runBackupCreate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	stateDir, err := statedir.Dir()
+	if err != nil {
+		return err
+	}
+	output := backupOutput
+	if output == "" {
+		output = fmt.Sprintf("asc-backup-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+	loc := backup.Locations{ProjectDir: ".", StateDir: stateDir, BeadsDir: cfg.Core.BeadsDBPath}
+
+	if dryRun {
+		printDryRun("backup.dry_run.create", output, filepath.Join(cfg.Core.BeadsDBPath, ".beads"), stateDir)
+		return nil
+	}
+
+	if _, err := os.Stat(".env.age"); os.IsNotExist(err) {
+		if _, err := os.Stat(".env"); err == nil {
+			fmt.Fprintln(os.Stderr, i18n.T("backup.env_not_backed_up"))
+		}
+	}
+
+	// Written next to the output and renamed, so a failed backup leaves
+	// no partial archive behind
+	if err := os.MkdirAll(filepath.Dir(output), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", output, err)
+	}
+	defer os.Remove(tmp.Name())
+	sum := sha256.New()
+	manifest, err := backup.Create(io.MultiWriter(tmp, sum), loc, backup.Options{
+		IncludeKey: backupIncludeKey,
+		AscVersion: upgrade.Current(),
+		Exclude:    output,
+	})
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	fmt.Println(i18n.T("backup.created", len(manifest.Files), float64(manifest.Size())/(1024*1024), output))
+	fmt.Printf("  sha256: %s\n", hex.EncodeToString(sum.Sum(nil)))
+	if !backupIncludeKey {
+		fmt.Println(i18n.T("backup.key_not_included", filepath.Join(stateDir, backup.KeyFileName)))
+	}
+	return nil
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) error {
+	archive, err := backup.Open(args[0])
+	if errors.Is(err, backup.ErrIntegrity) {
+		return fmt.Errorf("%w\n  Suggestion: Nothing was restored; copy the archive again, and compare its sha256 with the one asc backup create printed", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", args[0], err)
+	}
+	defer archive.Close()
+
+	stateDir, err := statedir.Dir()
+	if err != nil {
+		return err
+	}
+	loc := backup.Locations{ProjectDir: ".", StateDir: stateDir, BeadsDir: archive.Manifest.BeadsDir(".")}
+	if err := checkStackStopped(); err != nil {
+		return err
+	}
+
+	changed, conflicts := archive.Changes(loc)
+	if dryRun {
+		for _, name := range changed {
+			target, _ := backup.Target(loc, name)
+			printDryRun("backup.dry_run.restore", target)
+		}
+		if len(changed) == 0 {
+			fmt.Println(i18n.T("backup.restore_unchanged"))
+		}
+		return nil
+	}
+	if len(conflicts) > 0 && !restoreForce {
+		return fmt.Errorf("%d file(s) differ from the archive, such as %s\n  Suggestion: Use --force to overwrite them, or --dry-run to list them", len(conflicts), conflicts[0])
+	}
+
+	restored, err := archive.Restore(loc, restoreForce)
+	if err != nil {
+		return fmt.Errorf("%w\n  Suggestion: %d file(s) were restored before the error; run the restore again with --force to finish it", err, len(restored))
+	}
+	fmt.Println(i18n.T("backup.restored", len(restored), archive.Manifest.CreatedAt.Local().Format("2006-01-02 15:04"), archive.Manifest.AscVersion))
+	if _, err := os.Stat(filepath.Join(stateDir, backup.KeyFileName)); os.IsNotExist(err) {
+		if _, err := os.Stat(".env.age"); err == nil {
+			fmt.Println(i18n.T("backup.no_key", stateDir))
+		}
+	}
+	return nil
+}
+
+// checkStackStopped returns an error while any process of the stack runs,
+// whose state a restore would pull out from under it
+func checkStackStopped() error {
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize process manager: %w", err)
+	}
+	infos, err := procManager.ListProcesses()
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if process.Live(procManager, info) {
+			return fmt.Errorf("%s is running\n  Suggestion: Run 'asc down' before restoring", info.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackupCommand(t *testing.T) {
+	// The original machine
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Chdir(env.TempDir)
+	env.WriteConfig(ValidConfig())
+	if err := os.MkdirAll(filepath.Join("project-repo", ".beads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("project-repo", ".beads", "issues.jsonl"), []byte(`{"id":"bd-1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(".asc", "playbooks", "coder"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(".asc", "playbooks", "coder", "playbook.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	oldOutput := backupOutput
+	backupOutput = archive
+	t.Cleanup(func() { backupOutput = oldOutput })
+	capture := NewCaptureOutput()
+	capture.Start()
+	err := runBackupCreate(backupCreateCmd, nil)
+	capture.Stop()
+	if err != nil {
+		t.Fatalf("runBackupCreate() error = %v", err)
+	}
+	if out := capture.GetStdout(); !strings.Contains(out, "sha256: ") || !strings.Contains(out, "age key is not included") {
+		t.Errorf("Expected the archive's hash and a note on the key, got:\n%s", out)
+	}
+
+	// A new machine
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(home)
+	capture = NewCaptureOutput()
+	capture.Start()
+	err = runBackupRestore(backupRestoreCmd, []string{archive})
+	capture.Stop()
+	if err != nil {
+		t.Fatalf("runBackupRestore() error = %v", err)
+	}
+	for _, path := range []string{"asc.toml", "project-repo/.beads/issues.jsonl", ".asc/playbooks/coder/playbook.json"} {
+		if _, err := os.Stat(filepath.Join(home, path)); err != nil {
+			t.Errorf("Expected %s restored: %v", path, err)
+		}
+	}
+
+	// A changed file is kept without --force
+	if err := os.WriteFile("asc.toml", []byte(MinimalConfig()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runBackupRestore(backupRestoreCmd, []string{archive}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected a changed asc.toml to need --force, got %v", err)
+	}
+}
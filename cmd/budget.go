@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rand/asc/internal/budget"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var budgetOverride bool
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Show spend against budgets and resume paused agents",
+	Long: `Show what agents have spent on LLM calls in the current budget period
+against the budgets configured in [budget] and with budget_usd on each agent.
+
+Agents record the estimated cost of every completion in ~/.asc/usage. While
+the stack is running, asc warns when spend crosses a warn_at fraction of a
+budget and pauses the offending agents when a budget is reached: agents over
+their own budget_usd, or every agent when the project budget is reached.
+Paused agents are stopped and not restarted until the next period or until
+they are resumed with 'asc budget resume'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		enforcer, err := newBudgetCommandEnforcer(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		report, err := enforcer.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Print(formatBudgetReport(report))
+		return nil
+	},
+}
+
+var budgetResumeCmd = &cobra.Command{
+	Use:   "resume <agent>",
+	Short: "Resume an agent paused for exceeding a budget",
+	Long: `Resume an agent paused for exceeding a budget.
+
+Without --override the agent is only resumed once it is back under its
+budgets, for example after budget_usd was raised in asc.toml. With --override
+the agent may exceed its budgets until the period ends. A running stack
+lets the agent run again on its next budget check.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		enforcer, err := newBudgetCommandEnforcer(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			if err := enforcer.CheckResume(args[0], budgetOverride); err != nil {
+				return err
+			}
+			if budgetOverride {
+				printDryRun("budget.dry_run.override", args[0])
+				return nil
+			}
+			printDryRun("budget.dry_run.resume", args[0])
+			return nil
+		}
+		if err := enforcer.Resume(args[0], budgetOverride); err != nil {
+			return err
+		}
+		if budgetOverride {
+			fmt.Println(i18n.T("budget.overridden", args[0]))
+			return nil
+		}
+		fmt.Println(i18n.T("budget.resumed", args[0]))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(budgetCmd)
+	budgetCmd.AddCommand(budgetResumeCmd)
+	budgetResumeCmd.Flags().BoolVar(&budgetOverride, "override", false, "Let the agent exceed its budgets until the period ends")
+}
+
+// newBudgetEnforcer creates the enforcer used by the budget command. It is
+// a variable so tests can point it at a temporary ledger and state file.
+var newBudgetEnforcer = func(cfg *config.Config) (*budget.Enforcer, error) {
+	return budget.NewEnforcer(*cfg, nil)
+}
+
+// newBudgetCommandEnforcer loads asc.toml and returns an enforcer that
+// reports and resumes without stopping agents
+func newBudgetCommandEnforcer(ctx context.Context) (*budget.Enforcer, error) {
+	cfg, err := config.LoadContext(ctx, config.DefaultConfigPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if !cfg.BudgetEnabled() {
+		return nil, fmt.Errorf("no budget is configured\n  Suggestion: Set project_usd in a [budget] section or budget_usd on an agent in asc.toml")
+	}
+	return newBudgetEnforcer(cfg)
+}
+
+// formatBudgetReport renders spend against each budget and the paused agents
+func formatBudgetReport(report budget.Report) string {
+	var out strings.Builder
+	if report.PeriodStart.IsZero() {
+		fmt.Fprintf(&out, "%s\n\n", i18n.T("budget.spend_to_date", report.TotalUSD))
+	} else {
+		fmt.Fprintf(&out, "%s\n\n", i18n.T("budget.spend_since", report.PeriodStart.Format("2006-01-02"), report.Period, report.TotalUSD))
+	}
+
+	for _, limit := range report.Limits {
+		percent := limit.SpentUSD / limit.LimitUSD * 100
+		marker := " "
+		switch {
+		case report.Overrides[limit.Scope]:
+			marker = "~"
+		case limit.SpentUSD >= limit.LimitUSD:
+			marker = "✗"
+		}
+		fmt.Fprintln(&out, i18n.T("budget.limit", marker, limit.Scope, limit.SpentUSD, limit.LimitUSD, percent))
+	}
+
+	if len(report.Paused) > 0 {
+		names := make([]string, 0, len(report.Paused))
+		for name := range report.Paused {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		out.WriteString("\n" + i18n.T("budget.paused") + "\n")
+		for _, name := range names {
+			pause := report.Paused[name]
+			fmt.Fprintln(&out, i18n.T("budget.paused_agent", name, pause.Scope, pause.PausedAt.Format("2006-01-02 15:04")))
+		}
+		out.WriteString("\n" + i18n.T("budget.resume_hint") + "\n")
+	}
+	return out.String()
+}
+
+// startBudget checks spend once, so agents already over budget are not
+// launched, then keeps enforcing budgets in the background. Returns nil
+// if no budget is configured or enforcement cannot be started.
+func startBudget(cfg *config.Config, procManager process.ProcessManager, ignore bool) *budget.Enforcer {
+	if !cfg.BudgetEnabled() {
+		return nil
+	}
+
+	enforcer, err := budget.NewEnforcer(*cfg, &agentController{cfg: cfg, procManager: procManager})
+	if err != nil {
+		logger.Error("Failed to start budget enforcement: %v", err)
+		fmt.Fprintln(os.Stderr, i18n.T("budget.enforcement_disabled", err))
+		return nil
+	}
+	if ignore {
+		fmt.Println(i18n.T("budget.ignored"))
+		enforcer.SetEnforce(false)
+	}
+	if err := enforcer.Check(); err != nil {
+		logger.Error("Budget check failed: %v", err)
+		fmt.Fprintln(os.Stderr, i18n.T("budget.check_failed", err))
+	}
+	enforcer.Start()
+	return enforcer
+}
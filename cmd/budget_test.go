@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/budget"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/usage"
+)
+
+const budgetTestConfig = `[core]
+beads_db_path = "./project-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+budget_usd = 1
+
+[budget]
+project_usd = 10
+`
+
+// TestBudgetCommands tests status and resume against a temporary ledger
+func TestBudgetCommands(t *testing.T) {
+	env := NewTestEnvironment(t)
+	env.WriteConfig(budgetTestConfig)
+	defer ChangeToTempDir(t, env.TempDir)()
+
+	usageDir := filepath.Join(env.TempDir, "usage")
+	statePath := filepath.Join(env.TempDir, "budget.json")
+	oldNew := newBudgetEnforcer
+	defer func() { newBudgetEnforcer = oldNew }()
+	newBudgetEnforcer = func(cfg *config.Config) (*budget.Enforcer, error) {
+		return budget.NewEnforcerWithPaths(*cfg, nil, usageDir, statePath), nil
+	}
+
+	record := usage.Record{Timestamp: time.Now(), Agent: "coder", CostUSD: 1.5}
+	if err := usage.Append(filepath.Join(usageDir, "coder.jsonl"), record); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	enforcer, _ := newBudgetEnforcer(&config.Config{
+		Budget: config.BudgetConfig{ProjectUSD: 10},
+		Agents: map[string]config.AgentConfig{"coder": {BudgetUSD: 1}},
+	})
+	if err := enforcer.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	run := func(fn func() error) (string, error) {
+		t.Helper()
+		capture := NewCaptureOutput()
+		capture.Start()
+		err := fn()
+		capture.Stop()
+		return capture.GetStdout(), err
+	}
+
+	out, err := run(func() error { return budgetCmd.RunE(budgetCmd, nil) })
+	if err != nil {
+		t.Fatalf("budget failed: %v", err)
+	}
+	if !strings.Contains(out, "✗ coder") || !strings.Contains(out, "Paused agents:") {
+		t.Errorf("Expected coder over budget and paused, got:\n%s", out)
+	}
+
+	budgetOverride = false
+	if _, err := run(func() error { return budgetResumeCmd.RunE(budgetResumeCmd, []string{"coder"}) }); err == nil || !strings.Contains(err.Error(), "--override") {
+		t.Errorf("Expected resume to require --override, got %v", err)
+	}
+
+	budgetOverride = true
+	defer func() { budgetOverride = false }()
+	out, err = run(func() error { return budgetResumeCmd.RunE(budgetResumeCmd, []string{"coder"}) })
+	if err != nil || !strings.Contains(out, "may exceed its budget") {
+		t.Errorf("Unexpected resume result: %q, %v", out, err)
+	}
+
+	out, _ = run(func() error { return budgetCmd.RunE(budgetCmd, nil) })
+	if strings.Contains(out, "Paused agents:") || !strings.Contains(out, "~ coder") {
+		t.Errorf("Expected coder resumed with an override, got:\n%s", out)
+	}
+}
+
+// TestBudgetCommandWithoutBudget tests the error when no budget is configured
+func TestBudgetCommandWithoutBudget(t *testing.T) {
+	env := NewTestEnvironment(t)
+	env.WriteConfig(strings.Replace(strings.Split(budgetTestConfig, "[budget]")[0], "budget_usd = 1\n", "", 1))
+	defer ChangeToTempDir(t, env.TempDir)()
+
+	err := budgetCmd.RunE(budgetCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "no budget is configured") {
+		t.Errorf("Expected a missing budget error, got %v", err)
+	}
+}
@@ -1,36 +1,91 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/rand/asc/internal/check"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
 )
 
-// osExit is a variable that can be mocked in tests
-var osExit = os.Exit
+// osExit is a variable that can be mocked in tests. It first records the
+// command in the audit log, exports its trace, and closes the logger so
+// that asc's recent log records are saved for asc logs --self.
+var osExit = func(code int) {
+	var err error
+	if code != 0 {
+		err = fmt.Errorf("exit status %d", code)
+	}
+	finishAudit(err)
+	finishTrace(err)
+	logger.Close()
+	os.Exit(code)
+}
+
+var (
+	checkInstall bool
+	noCheckCache bool
+)
+
+// runInstallCommand runs an installer attached to the terminal.
+// It is a variable so tests can avoid installing anything.
+var runInstallCommand = func(command []string) error {
+	c := exec.Command(command[0], command[1:]...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
 
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Verify environment setup and dependencies",
 	Long: `Verify that all required dependencies are installed and properly configured.
 This includes checking for required binaries (git, python3, uv, bd), 
-validating the asc.toml configuration file, and verifying API keys in .env file.`,
+validating the asc.toml configuration file, and verifying API keys in .env file.
+
+With --install, offers to install each missing tool using the detected
+package manager (brew, apt, dnf, or winget), or uv/pip for the Python tools,
+asking for confirmation before each one.
+
+Binary version probes are cached in ~/.asc/cache for a few minutes so
+repeated checks stay fast; use --no-cache to probe everything again.`,
 	Run: runCheck,
 }
 
 func init() {
 	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().BoolVar(&checkInstall, "install", false, "Offer to install missing dependencies")
+	checkCmd.Flags().BoolVar(&noCheckCache, "no-cache", false, "Ignore cached check results")
+}
+
+// newChecker creates the checker used by check and up, backed by the check
+// result cache unless --no-cache was given or the cache location is unknown
+func newChecker(configPath, envPath string) check.Checker {
+	if noCheckCache {
+		return check.NewChecker(configPath, envPath)
+	}
+	cachePath, err := check.DefaultCachePath()
+	if err != nil {
+		return check.NewChecker(configPath, envPath)
+	}
+	return check.NewCheckerWithCache(configPath, envPath, check.NewCache(cachePath, check.DefaultCacheTTL))
 }
 
 func runCheck(cmd *cobra.Command, args []string) {
 	// Default paths
 	configPath := "asc.toml"
-	envPath := ".env"
+	envPath := config.DefaultEnvPath()
 
 	// Create checker instance
-	checker := check.NewChecker(configPath, envPath)
+	checker := newChecker(configPath, envPath)
 
 	// Run all checks
 	results := checker.RunAll()
@@ -39,9 +94,68 @@ func runCheck(cmd *cobra.Command, args []string) {
 	output := check.FormatResults(results)
 	fmt.Println(output)
 
+	// Offer to install missing tools, then re-check
+	if checkInstall && installMissing(cmd.InOrStdin(), results) {
+		results = checker.RunAll()
+		fmt.Println(check.FormatResults(results))
+	}
+
 	// Exit with appropriate status code
 	if check.HasFailures(results) {
 		osExit(1)
 	}
 	osExit(0)
 }
+
+// installMissing asks for confirmation and installs each missing tool,
+// then prints a summary. Returns true if anything was installed.
+func installMissing(in io.Reader, results []check.CheckResult) bool {
+	missing := check.MissingTools(results)
+	if len(missing) == 0 {
+		fmt.Println(i18n.T("check.install.nothing"))
+		return false
+	}
+
+	manager := check.DetectPackageManager()
+	if manager == "" {
+		fmt.Println(i18n.T("check.install.no_package_manager"))
+	}
+
+	reader := bufio.NewReader(in)
+	summary := []string{}
+	installed := 0
+	for _, tool := range missing {
+		command, ok := check.InstallCommand(tool, manager)
+		if !ok {
+			summary = append(summary, i18n.T("check.install.no_installer", tool))
+			continue
+		}
+		if dryRun {
+			printDryRun("check.dry_run.install", tool, strings.Join(command, " "))
+			continue
+		}
+
+		fmt.Print(i18n.T("check.install.prompt", tool, strings.Join(command, " ")))
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(response)
+		if response != "y" && response != "Y" {
+			summary = append(summary, i18n.T("check.install.skipped", tool))
+			continue
+		}
+
+		if err := runInstallCommand(command); err != nil {
+			summary = append(summary, i18n.T("check.install.failed", tool, err))
+			continue
+		}
+		summary = append(summary, i18n.T("check.install.installed", tool))
+		installed++
+	}
+
+	fmt.Println("\n" + i18n.T("check.install.summary"))
+	for _, line := range summary {
+		fmt.Println(line)
+	}
+	fmt.Println()
+
+	return installed > 0
+}
@@ -3,7 +3,10 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/rand/asc/internal/check"
 )
 
 func TestCheckCommand_ValidEnvironment(t *testing.T) {
@@ -326,3 +329,34 @@ func TestCheckCommand_CustomPaths(t *testing.T) {
 func TestCheckCommand_ErrorReporting(t *testing.T) {
 	t.Skip("Output capture has timing issues with panic-based exit mocking. Error reporting is tested elsewhere.")
 }
+
+func TestInstallMissing(t *testing.T) {
+	// PATH without uv, bd, or any package manager: only the pip-based installs apply
+	mockBinDir := SetupMockBinaries(t, []string{"git", "python3"})
+
+	var ran [][]string
+	oldRun := runInstallCommand
+	runInstallCommand = func(command []string) error {
+		ran = append(ran, command)
+		return nil
+	}
+	defer func() { runInstallCommand = oldRun }()
+
+	results := []check.CheckResult{
+		{Name: "uv", Status: check.CheckFail},
+		{Name: "bd", Status: check.CheckFail},
+	}
+
+	var installed bool
+	WithMockPath(t, mockBinDir, func() {
+		// Accept uv, decline bd
+		installed = installMissing(strings.NewReader("y\nn\n"), results)
+	})
+
+	if !installed {
+		t.Error("Expected installMissing to report an install")
+	}
+	if len(ran) != 1 || strings.Join(ran[0], " ") != "python3 -m pip install --user uv" {
+		t.Errorf("Expected only the uv install to run, got %v", ran)
+	}
+}
@@ -1,19 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"time"
 
-	"github.com/spf13/cobra"
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/spf13/cobra"
 )
 
-var (
-	cleanupDays   int
-	cleanupDryRun bool
-)
+var cleanupDays int
 
 var cleanupCmd = &cobra.Command{
 	Use:   "cleanup",
@@ -28,33 +27,40 @@ By default, logs older than 30 days are removed.`,
 func init() {
 	rootCmd.AddCommand(cleanupCmd)
 	cleanupCmd.Flags().IntVar(&cleanupDays, "days", 30, "Remove logs older than this many days")
-	cleanupCmd.Flags().BoolVar(&cleanupDryRun, "dry-run", false, "Show what would be deleted without actually deleting")
 }
 
 func runCleanup(cmd *cobra.Command, args []string) {
-	homeDir, err := os.UserHomeDir()
+	logsDir, err := statedir.Path("logs")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to get home directory: %v\n", err)
+		printError("Failed to resolve state directory", err)
 		osExit(1)
 		return
 	}
 
-	logsDir := filepath.Join(homeDir, ".asc", "logs")
-
-	if cleanupDryRun {
-		fmt.Printf("Dry run: would remove logs older than %d days from %s\n", cleanupDays, logsDir)
-		// TODO: Implement dry run listing
+	maxAge := time.Duration(cleanupDays) * 24 * time.Hour
+	if dryRun {
+		oldLogs, err := logger.OldLogs(logsDir, maxAge)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			printError("Failed to list old logs", err)
+			osExit(1)
+			return
+		}
+		if len(oldLogs) == 0 {
+			fmt.Println(i18n.T("cleanup.dry_run.none", cleanupDays, logsDir))
+		}
+		for _, logPath := range oldLogs {
+			printDryRun("cleanup.dry_run.remove", logPath)
+		}
 		return
 	}
 
-	fmt.Printf("Cleaning up logs older than %d days from %s...\n", cleanupDays, logsDir)
+	fmt.Println(i18n.T("cleanup.cleaning", cleanupDays, logsDir))
 
-	maxAge := time.Duration(cleanupDays) * 24 * time.Hour
 	if err := logger.CleanupOldLogs(logsDir, maxAge); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to cleanup logs: %v\n", err)
+		printError("Failed to cleanup logs", err)
 		osExit(1)
 		return
 	}
 
-	fmt.Println("✓ Log cleanup completed")
+	fmt.Println(i18n.T("cleanup.done"))
 }
@@ -83,8 +83,8 @@ func TestCleanupCommand_DryRun(t *testing.T) {
 		t.Fatalf("Failed to set old log file time: %v", err)
 	}
 
-	cleanupDryRun = true
-	defer func() { cleanupDryRun = false }()
+	dryRun = true
+	defer func() { dryRun = false }()
 
 	capture := NewCaptureOutput()
 	capture.Start()
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var configStrict bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read, edit, and check asc.toml",
+	Long: `Read and edit asc.toml by key, so scripts can change the configuration
+without rewriting the file. Keys are dotted, such as core.beads_db_path or
+agent.coder.model. Edits keep the comments and layout of the file.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a value of asc.toml",
+	Long: `Print the value asc.toml sets for a key: strings as they are, and other
+values, including tables, as TOML. Defaults are not applied, so a key the
+file does not set is an error.
+
+Example:
+  asc config get core.beads_db_path
+  asc config get agent.coder`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in asc.toml",
+	Long: `Set a key of asc.toml, adding it, and its table, if the file does not set
+it. Only the value is rewritten, so comments and layout are kept.
+
+The value is read as TOML, such as 3, true, or ["planning", "testing"],
+and as a string otherwise; a key already set to a string stays one. The
+edited file must pass validation, or asc.toml is left unchanged.
+
+Example:
+  asc config set agent.coder.model claude
+  asc config set agent.coder.phases '["implementation", "testing"]'`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check asc.toml for errors",
+	Long: `Load a configuration file (default asc.toml) as asc up would, and report
+its errors and warnings, such as unknown keys. The exit code is 1 if it is
+invalid. With --strict, or core.strict = true, unknown keys are errors.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigValidate,
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of asc.toml",
+	Long: `Print the JSON Schema of asc.toml, generated from the settings asc reads,
+for editors and CI to check configuration files against. It is published
+as docs/asc.schema.json.
+
+Example:
+  asc config schema > asc.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: runConfigSchema,
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate [file]",
+	Short: "Upgrade a configuration file to the layout of this release",
+	Long: `Upgrade a configuration file (default asc.toml) written for an older
+release of asc to the layout of this one, in place, and record it as
+config_version. The file is first copied to <file>.<time>-v<version>.bak.
+Lines no migration changes, including comments, are kept; a file already
+in the current layout is left alone.
+
+asc migrates asc.toml before running any command. Run this for the files
+asc.toml includes and the overlays of profiles.
+
+Example:
+  asc config migrate
+  asc config migrate asc.prod.toml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigMigrate,
+}
+
+var configDiffCmd = &cobra.Command{
+	Use:   "diff <file>",
+	Short: "Compare asc.toml with another configuration",
+	Long: `List the keys whose values differ between asc.toml and another
+configuration file, ignoring comments, layout, and key order: "-" for the
+value in asc.toml, "+" for the value in <file>.
+
+Example:
+  asc config diff ../staging/asc.toml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configDiffCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configMigrateCmd)
+
+	configValidateCmd.Flags().BoolVar(&configStrict, "strict", false, "Reject unknown keys, as core.strict does")
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	data, err := readConfigFile(config.DefaultConfigPath())
+	if err != nil {
+		return err
+	}
+	value, err := config.GetValue(data, args[0])
+	if errors.Is(err, config.ErrKeyNotSet) {
+		return fmt.Errorf("%s is not set in %s\n  Suggestion: Its default applies; see docs/CONFIGURATION.md", args[0], config.DefaultConfigPath())
+	}
+	if err != nil {
+		return err
+	}
+	if s, ok := value.(string); ok {
+		fmt.Println(s)
+	} else {
+		fmt.Println(config.FormatValue(value))
+	}
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	path := config.DefaultConfigPath()
+	if err := checkEditable(path); err != nil {
+		return err
+	}
+	data, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	edited, err := config.SetValue(data, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	value, _ := config.GetValue(edited, args[0])
+
+	// The edit is validated as its own file before it replaces asc.toml
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(edited)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if _, err := config.LoadContext(commandContext(cmd), tmp.Name()); err != nil {
+		return fmt.Errorf("%s was not changed: %w", path, err)
+	}
+
+	if dryRun {
+		printDryRun("config.dry_run.set", args[0], config.FormatValue(value), path)
+		return nil
+	}
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Println(i18n.T("config.set", args[0], config.FormatValue(value), path))
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := config.DefaultConfigPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+	load := config.LoadContext
+	if configStrict {
+		load = config.LoadStrict
+	}
+	cfg, err := load(commandContext(cmd), path)
+	if err != nil {
+		return fmt.Errorf("%s is invalid: %w", path, err)
+	}
+	warnings, err := config.ValidateWithWarnings(cfg)
+	if err != nil {
+		return fmt.Errorf("%s is invalid: %w", path, err)
+	}
+	for _, warning := range warnings {
+		fmt.Printf("⚠ %s\n  Suggestion: %s\n", warning.Message, warning.Suggestion)
+	}
+	fmt.Println(i18n.T("config.valid", path))
+	return nil
+}
+
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	path := config.DefaultConfigPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if err := checkEditable(path); err != nil {
+		return err
+	}
+	if dryRun {
+		data, err := readConfigFile(path)
+		if err != nil {
+			return err
+		}
+		_, result, err := config.MigrateConfig(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if result.Migrated() {
+			printDryRun("config.dry_run.migrate", path, result.From, result.To)
+		} else {
+			fmt.Println(i18n.T("config.current_layout", path))
+		}
+		return nil
+	}
+
+	if _, err := readConfigFile(path); err != nil {
+		return err
+	}
+	result, err := config.MigrateConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if !result.Migrated() {
+		fmt.Println("✓ " + i18n.T("config.current_layout", path))
+		return nil
+	}
+	fmt.Println(i18n.T("config.migrated", path, result.From, result.To, result.Backup))
+	for _, m := range result.Applied {
+		fmt.Printf("  %d: %s\n", m.Version, m.Description)
+	}
+	return nil
+}
+
+func runConfigDiff(cmd *cobra.Command, args []string) error {
+	path := config.DefaultConfigPath()
+	ours, err := flattenConfigFile(path)
+	if err != nil {
+		return err
+	}
+	theirs, err := flattenConfigFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	all := map[string]any{}
+	for key, value := range ours {
+		all[key] = value
+	}
+	for key, value := range theirs {
+		all[key] = value
+	}
+	differ := 0
+	for _, key := range config.FlatKeys(all) {
+		a, inOurs := ours[key]
+		b, inTheirs := theirs[key]
+		if inOurs && inTheirs && reflect.DeepEqual(a, b) {
+			continue
+		}
+		if differ == 0 {
+			fmt.Printf("--- %s\n+++ %s\n", path, args[0])
+		}
+		differ++
+		if inOurs {
+			fmt.Printf("- %s = %s\n", key, config.FormatValue(a))
+		}
+		if inTheirs {
+			fmt.Printf("+ %s = %s\n", key, config.FormatValue(b))
+		}
+	}
+	if differ == 0 {
+		fmt.Println(i18n.T("config.no_differences", path, args[0]))
+	}
+	return nil
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+	data, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// readConfigFile reads a configuration file, with a hint if it is missing
+func readConfigFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("configuration file not found: %s\n  Suggestion: Run 'asc init' to create one, or run asc from the directory that has asc.toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// checkEditable refuses to edit the cached copy of a shared
+// configuration, which the next fetch replaces
+func checkEditable(path string) error {
+	if config.IsCachedSource(path) {
+		return fmt.Errorf("%s is the cached copy of a shared configuration\n  Suggestion: Change it at its source, or set config_source in a local asc.toml and override keys there", path)
+	}
+	return nil
+}
+
+// flattenConfigFile returns the values a configuration file sets by
+// dotted key
+func flattenConfigFile(path string) (map[string]any, error) {
+	data, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	flat, err := config.Flatten(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return flat, nil
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func TestConfigCommand(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Chdir(env.TempDir)
+	env.WriteConfig("# Managed by hand\n" + ValidConfig())
+
+	run := func(fn func(cmd *cobra.Command, args []string) error, args ...string) (string, error) {
+		t.Helper()
+		capture := NewCaptureOutput()
+		capture.Start()
+		err := fn(configCmd, args)
+		capture.Stop()
+		return capture.GetStdout(), err
+	}
+
+	if out, err := run(runConfigGet, "core.beads_db_path"); err != nil || out != "./project-repo\n" {
+		t.Errorf("config get = %q, %v", out, err)
+	}
+	if _, err := run(runConfigGet, "agent.test-agent.replicas"); err == nil {
+		t.Error("Expected an unset key to fail")
+	}
+
+	if _, err := run(runConfigSet, "agent.test-agent.model", "gemini"); err != nil {
+		t.Fatalf("config set error = %v", err)
+	}
+	data, _ := os.ReadFile("asc.toml")
+	if !strings.Contains(string(data), "# Managed by hand\n") || !strings.Contains(string(data), "model = \"gemini\"\n") {
+		t.Errorf("Expected the model set and the comment kept:\n%s", data)
+	}
+
+	// An edit that fails validation leaves asc.toml alone
+	if _, err := run(runConfigSet, "agent.test-agent.model", "no-such-model"); err == nil {
+		t.Error("Expected an invalid model to be refused")
+	}
+	if after, _ := os.ReadFile("asc.toml"); string(after) != string(data) {
+		t.Errorf("Expected asc.toml unchanged, got:\n%s", after)
+	}
+	if entries, _ := filepath.Glob(".asc.toml.tmp-*"); len(entries) > 0 {
+		t.Errorf("Expected no temporary file left, got %q", entries)
+	}
+
+	if out, err := run(runConfigValidate); err != nil || !strings.Contains(out, "asc.toml is valid") {
+		t.Errorf("config validate = %q, %v", out, err)
+	}
+	os.WriteFile("broken.toml", []byte(InvalidConfig()), 0644)
+	if _, err := run(runConfigValidate, "broken.toml"); err == nil {
+		t.Error("Expected an invalid file to fail validation")
+	}
+	typo := strings.Replace(ValidConfig(), `model = "claude"`, `model = "claude"`+"\nphasess = [\"testing\"]", 1)
+	os.WriteFile("typo.toml", []byte(typo), 0644)
+	if out, err := run(runConfigValidate, "typo.toml"); err != nil || !strings.Contains(out, "Did you mean phases?") {
+		t.Errorf("Expected an unknown key warning, got %q, %v", out, err)
+	}
+	configStrict = true
+	defer func() { configStrict = false }()
+	if _, err := run(runConfigValidate, "typo.toml"); err == nil {
+		t.Error("Expected --strict to reject an unknown key")
+	}
+	configStrict = false
+
+	other := strings.Replace(ValidConfig(), `model = "claude"`, `model = "claude"`+"\nreplicas = 2", 1)
+	os.WriteFile("other.toml", []byte(other), 0644)
+	out, err := run(runConfigDiff, "other.toml")
+	if err != nil {
+		t.Fatalf("config diff error = %v", err)
+	}
+	for _, want := range []string{`- agent.test-agent.model = 'gemini'`, `+ agent.test-agent.model = 'claude'`, `+ agent.test-agent.replicas = 2`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	if out, _ := run(runConfigDiff, "asc.toml"); !strings.Contains(out, "No differences") {
+		t.Errorf("Expected no differences with itself, got:\n%s", out)
+	}
+}
+
+func TestConfigMigrateCommand(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Chdir(env.TempDir)
+	env.WriteConfig(`[core]
+beads_db_path = "./project-repo"
+
+[services]
+mcp_url = "http://localhost:8765"
+
+[agents]
+agents = [{ name = "planner", model = "gemini", phases = ["planning"] }]
+`)
+
+	if _, err := config.Load("asc.toml"); err == nil || !strings.Contains(err.Error(), "agents is the layout of asc 0.9") {
+		t.Errorf("Expected loading the old layout to suggest migrating, got %v", err)
+	}
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	err := runConfigMigrate(configMigrateCmd, nil)
+	capture.Stop()
+	if err != nil {
+		t.Fatalf("config migrate error = %v", err)
+	}
+	if !strings.Contains(capture.GetStdout(), "Migrated asc.toml from config version 0 to 1") {
+		t.Errorf("Unexpected output:\n%s", capture.GetStdout())
+	}
+	if backups, _ := filepath.Glob("asc.toml.*-v0.bak"); len(backups) != 1 {
+		t.Errorf("Expected a backup of asc.toml, got %q", backups)
+	}
+	cfg, err := config.Load("asc.toml")
+	if err != nil {
+		t.Fatalf("Load() after migrating error = %v", err)
+	}
+	if cfg.ConfigVersion != config.CurrentConfigVersion || cfg.Agents["planner"].Model != "gemini" {
+		t.Errorf("Unexpected migrated config: %+v", cfg)
+	}
+
+	capture = NewCaptureOutput()
+	capture.Start()
+	err = runConfigMigrate(configMigrateCmd, nil)
+	capture.Stop()
+	if err != nil || !strings.Contains(capture.GetStdout(), "is in the layout of this release") {
+		t.Errorf("Expected migrating again to do nothing, got %q, %v", capture.GetStdout(), err)
+	}
+}
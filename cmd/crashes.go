@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var crashesCmd = &cobra.Command{
+	Use:   "crashes",
+	Short: "List and show the crash dumps of failed agents",
+	Long: `List and show the crash dumps asc saves in ~/.asc/crashes when an agent or
+mcp_agent_mail exits with a failure on its own: its exit status or the
+signal that killed it, when it started and exited, and the end of its
+output log. Processes stopped by asc are not crashes.
+
+How much of the log is kept and how many dumps are kept per agent is set
+in [logging.crashes] of asc.toml.`,
+}
+
+var crashesListCmd = &cobra.Command{
+	Use:   "list [agent]...",
+	Short: "List crash dumps, the newest first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		crashes, err := loadCrashes(args)
+		if err != nil {
+			return err
+		}
+		fmt.Print(formatCrashList(crashes))
+		return nil
+	},
+}
+
+var crashesShowCmd = &cobra.Command{
+	Use:   "show <id|agent>",
+	Short: "Show a crash dump, or the latest crash of an agent",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		crash, err := findCrash(args[0])
+		if err != nil {
+			return err
+		}
+		writeCrash(os.Stdout, crash)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(crashesCmd)
+	crashesCmd.AddCommand(crashesListCmd)
+	crashesCmd.AddCommand(crashesShowCmd)
+}
+
+// crashDir returns the directory crash dumps are saved in
+func crashDir() (string, error) {
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		return "", err
+	}
+	return procManager.CrashDir(), nil
+}
+
+// loadCrashes returns the saved crashes of the given agents, or of all,
+// the newest first
+func loadCrashes(agents []string) ([]*process.Crash, error) {
+	dir, err := crashDir()
+	if err != nil {
+		return nil, err
+	}
+	crashes, err := process.ListCrashes(dir)
+	if err != nil || len(agents) == 0 {
+		return crashes, err
+	}
+	wanted := make(map[string]bool, len(agents))
+	for _, agent := range agents {
+		wanted[agent] = true
+	}
+	selected := []*process.Crash{}
+	for _, crash := range crashes {
+		if wanted[crash.Name] {
+			selected = append(selected, crash)
+		}
+	}
+	return selected, nil
+}
+
+// findCrash returns the crash with the given ID, or the latest crash of
+// the agent of that name
+func findCrash(idOrAgent string) (*process.Crash, error) {
+	dir, err := crashDir()
+	if err != nil {
+		return nil, err
+	}
+	if crash, err := process.LoadCrash(dir, idOrAgent); err == nil {
+		return crash, nil
+	}
+	crashes, err := loadCrashes([]string{idOrAgent})
+	if err != nil {
+		return nil, err
+	}
+	if len(crashes) == 0 {
+		return nil, fmt.Errorf("no crash or agent with crashes named '%s'\n  Suggestion: Run 'asc crashes list' to list the crash dumps", idOrAgent)
+	}
+	return crashes[0], nil
+}
+
+// crashExit describes how a crashed process exited
+func crashExit(crash *process.Crash) string {
+	if crash.Signal != "" {
+		return crash.Signal
+	}
+	return i18n.T("crashes.exit_status", crash.ExitCode)
+}
+
+// formatCrashList formats crashes as a table
+func formatCrashList(crashes []*process.Crash) string {
+	if len(crashes) == 0 {
+		return i18n.T("crashes.none") + "\n"
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "%-40s %-20s %-20s %-12s %s\n", i18n.T("crashes.column.id"), i18n.T("crashes.column.agent"),
+		i18n.T("crashes.column.exited"), i18n.T("crashes.column.exit"), i18n.T("crashes.column.ran"))
+	for _, crash := range crashes {
+		fmt.Fprintf(&out, "%-40s %-20s %-20s %-12s %s\n", crash.ID, crash.Name,
+			crash.ExitedAt.Local().Format("2006-01-02 15:04:05"), crashExit(crash),
+			formatUptime(crash.ExitedAt.Sub(crash.StartedAt)))
+	}
+	return out.String()
+}
+
+// writeCrash writes a crash with the last output of the process
+func writeCrash(w io.Writer, crash *process.Crash) {
+	fmt.Fprintln(w, i18n.T("crashes.crash", crash.ID))
+	fmt.Fprintln(w, i18n.T("crashes.agent", crash.Name, crash.PID))
+	fmt.Fprintln(w, i18n.T("crashes.command", strings.Join(append([]string{crash.Command}, crash.Args...), " ")))
+	fmt.Fprintln(w, i18n.T("crashes.exit", crashExit(crash)))
+	fmt.Fprintln(w, i18n.T("crashes.started", crash.StartedAt.Local().Format("2006-01-02 15:04:05")))
+	fmt.Fprintln(w, i18n.T("crashes.exited", crash.ExitedAt.Local().Format("2006-01-02 15:04:05"), formatUptime(crash.ExitedAt.Sub(crash.StartedAt))))
+	fmt.Fprintln(w, i18n.T("crashes.restarts", crash.Restarts))
+	fmt.Fprintln(w, i18n.T("crashes.log", crash.LogFile))
+	if crash.LogTail == "" {
+		fmt.Fprintln(w, "\n"+i18n.T("crashes.no_output"))
+		return
+	}
+	fmt.Fprintln(w, "\n"+i18n.T("crashes.last_output"))
+	fmt.Fprint(w, crash.LogTail)
+	if !strings.HasSuffix(crash.LogTail, "\n") {
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCrashFile saves a crash dump as the process manager does
+func writeCrashFile(t *testing.T, dir, id, name string, exited time.Time, extra string) {
+	t.Helper()
+	data := fmt.Sprintf(`{"id": %q, "name": %q, "pid": 4242, "command": "python", "args": ["agent.py"], "exit_code": 2,
+		"started_at": %q, "exited_at": %q, "log_file": "/tmp/%s.log", "log_tail": "Traceback\nValueError: bad input\n"%s}`,
+		id, name, exited.Add(-5*time.Minute).Format(time.RFC3339Nano), exited.Format(time.RFC3339Nano), name, extra)
+	if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte(data), 0600); err != nil {
+		t.Fatalf("Failed to write crash: %v", err)
+	}
+}
+
+// TestCrashesCommands tests listing and showing crash dumps
+func TestCrashesCommands(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	dir := filepath.Join(env.TempDir, ".asc", "crashes")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	writeCrashFile(t, dir, "coder-old", "coder", now.Add(-time.Hour), "")
+	writeCrashFile(t, dir, "coder-new", "coder", now, `, "signal": "SIGSEGV", "exit_code": -1`)
+	writeCrashFile(t, dir, "reviewer-1", "reviewer", now.Add(-30*time.Minute), "")
+
+	crashes, err := loadCrashes(nil)
+	if err != nil {
+		t.Fatalf("loadCrashes failed: %v", err)
+	}
+	out := formatCrashList(crashes)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "coder-new") || !strings.HasPrefix(lines[2], "reviewer-1") {
+		t.Fatalf("Expected the crashes listed newest first, got:\n%s", out)
+	}
+	for _, want := range []string{"SIGSEGV", "status 2", "5m00s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, out)
+		}
+	}
+
+	crashes, err = loadCrashes([]string{"reviewer"})
+	if err != nil || len(crashes) != 1 || crashes[0].ID != "reviewer-1" {
+		t.Errorf("Expected the crashes of reviewer only, got %v, %v", crashes, err)
+	}
+
+	// By ID, or the latest crash of an agent
+	for arg, want := range map[string]string{"coder-old": "coder-old", "coder": "coder-new"} {
+		crash, err := findCrash(arg)
+		if err != nil {
+			t.Fatalf("findCrash(%s) failed: %v", arg, err)
+		}
+		if crash.ID != want {
+			t.Errorf("findCrash(%s) = %s, want %s", arg, crash.ID, want)
+		}
+	}
+	if _, err := findCrash("missing"); err == nil || !strings.Contains(err.Error(), "asc crashes list") {
+		t.Errorf("Expected an error suggesting asc crashes list, got %v", err)
+	}
+
+	var shown strings.Builder
+	crash, _ := findCrash("coder")
+	writeCrash(&shown, crash)
+	for _, want := range []string{"Crash coder-new", "coder (PID 4242)", "python agent.py", "Exit:     SIGSEGV", "(ran 5m00s)", "Last output:\nTraceback\nValueError: bad input\n"} {
+		if !strings.Contains(shown.String(), want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, shown.String())
+		}
+	}
+}
+
+// TestCrashesCommands_None tests listing without crash dumps
+func TestCrashesCommands_None(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	crashes, err := loadCrashes(nil)
+	if err != nil {
+		t.Fatalf("loadCrashes failed: %v", err)
+	}
+	if out := formatCrashList(crashes); out != "No crashes\n" {
+		t.Errorf("Unexpected output %q", out)
+	}
+}
@@ -0,0 +1,494 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rand/asc/internal/budget"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/daemon"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/pipeline"
+	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/spf13/cobra"
+)
+
+// daemonStartTimeout bounds how long asc daemon start waits for the
+// stack to come up
+const daemonStartTimeout = 60 * time.Second
+
+// daemonStopTimeout bounds how long asc daemon stop waits for the daemon
+// to stop its processes and exit
+const daemonStopTimeout = 60 * time.Second
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run the agent stack in the background",
+	Long: `Run the agent stack under a background supervisor instead of the TUI.
+
+The daemon starts the stack as asc up does, keeps running after the terminal
+that started it is closed, and restarts agents and mcp_agent_mail when they
+crash, by the restart policies of asc.toml. Agents the phase pipeline stopped
+or their budget paused are not restarted. It also creates the tasks of
+recurring task templates as they fall due (see asc tasks template), and runs
+asc doctor every [doctor] interval, notifying of new critical issues.`,
+}
+
+var daemonStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the agent stack in the background",
+	Long: `Start a background supervisor that runs the agent stack of the current
+directory, and wait for the stack to come up. Secrets are decrypted or
+resolved as for asc up; a passphrase-protected key needs ASC_KEY_PASSPHRASE.`,
+	Run: runDaemonStart,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the daemon and the agent stack",
+	Long:  `Stop the background supervisor, which stops every agent and mcp_agent_mail.`,
+	Run:   runDaemonStop,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the daemon and the processes it supervises",
+	Long:  `Report whether the background supervisor is running, and the state and restart count of each process it supervises.`,
+	Run:   runDaemonStatus,
+}
+
+// daemonRunCmd is the supervisor itself, started by asc daemon start
+var daemonRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Run the supervisor in the foreground",
+	Hidden: true,
+	Run:    runDaemonRun,
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStartCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonRunCmd)
+}
+
+// checkNoDaemon returns an error if the daemon runs the stack, which asc
+// up must then leave alone
+func checkNoDaemon() error {
+	stateDir, err := statedir.Dir()
+	if err != nil {
+		return err
+	}
+	if state, ok := daemon.Running(stateDir); ok {
+		return fmt.Errorf("the agent stack is run by asc daemon (PID %d)\n  Suggestion: Run 'asc daemon status' to check on it, or 'asc daemon stop' before 'asc up'", state.PID)
+	}
+	return nil
+}
+
+// runDaemonStart spawns the supervisor and waits for it to start the stack
+func runDaemonStart(cmd *cobra.Command, args []string) {
+	stateDir, err := statedir.Dir()
+	if err != nil {
+		printError("Failed to resolve state directory", err)
+		osExit(1)
+		return
+	}
+	if state, ok := daemon.Running(stateDir); ok {
+		fmt.Println(i18n.T("daemon.already_running", state.PID))
+		return
+	}
+
+	logsDir := filepath.Join(stateDir, "logs")
+	logPath := filepath.Join(logsDir, daemon.LogFileName)
+	if dryRun {
+		printDryRun("daemon.dry_run.start", logPath)
+		return
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		printError("Failed to start the daemon", err)
+		osExit(1)
+		return
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		printError("Failed to start the daemon", err)
+		osExit(1)
+		return
+	}
+	if err := os.MkdirAll(logsDir, 0700); err != nil {
+		printError("Failed to create log directory", err)
+		osExit(1)
+		return
+	}
+
+	fmt.Println(i18n.T("daemon.starting"))
+	p, err := daemon.Spawn(executable, []string{"daemon", "run"}, dir, logPath)
+	if err != nil {
+		printError("Failed to start the daemon", err)
+		osExit(1)
+		return
+	}
+	if err := waitForDaemon(stateDir, p, logPath, daemonStartTimeout); err != nil {
+		printError("Failed to start the daemon", err)
+		osExit(1)
+		return
+	}
+
+	fmt.Println(i18n.T("daemon.started", p.Pid))
+	fmt.Println(i18n.T("daemon.log", logPath))
+	fmt.Println(i18n.T("daemon.started_hint"))
+}
+
+// waitForDaemon waits until the supervisor p records that the stack is
+// up, and fails if it exits first or does not get there within timeout
+func waitForDaemon(stateDir string, p *os.Process, logPath string, timeout time.Duration) error {
+	exited := make(chan struct{})
+	go func() {
+		_, _ = p.Wait()
+		close(exited)
+	}()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	for {
+		if state, err := daemon.Read(stateDir); err == nil && state.PID == p.Pid && state.Ready {
+			return nil
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("the daemon exited while starting the stack\n  Suggestion: See %s for the cause", logPath)
+		case <-deadline:
+			return fmt.Errorf("the stack was not up within %s; the daemon (PID %d) is still starting it\n  Suggestion: See %s, and run 'asc daemon status' later", timeout, p.Pid, logPath)
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDaemonStop stops the supervisor, then any processes it left running
+func runDaemonStop(cmd *cobra.Command, args []string) {
+	stateDir, err := statedir.Dir()
+	if err != nil {
+		printError("Failed to resolve state directory", err)
+		osExit(1)
+		return
+	}
+	state, ok := daemon.Running(stateDir)
+	if !ok {
+		fmt.Println(i18n.T("daemon.not_running"))
+		return
+	}
+	if dryRun {
+		printDryRun("daemon.dry_run.stop", state.PID)
+		return
+	}
+
+	fmt.Println(i18n.T("daemon.stopping", state.PID))
+	if err := stopDaemon(commandContext(cmd), stateDir, state); err != nil {
+		printError("Failed to stop the daemon", err)
+		osExit(1)
+		return
+	}
+	fmt.Println(i18n.T("daemon.stopped"))
+}
+
+// stopDaemon stops the supervisor of state and the processes it left
+// behind, such as those of a supervisor that had to be killed
+func stopDaemon(ctx context.Context, stateDir string, state *daemon.State) error {
+	stopErr := daemon.Stop(state.PID, daemonStopTimeout)
+	if err := daemon.Clear(stateDir, state.PID); err != nil {
+		logger.Warn("%v", err)
+	}
+
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		return err
+	}
+	if err := procManager.StopAll(ctx); err != nil {
+		return err
+	}
+	return stopErr
+}
+
+// runDaemonStatus prints the supervisor and the processes it supervises
+func runDaemonStatus(cmd *cobra.Command, args []string) {
+	stateDir, err := statedir.Dir()
+	if err != nil {
+		printError("Failed to resolve state directory", err)
+		osExit(1)
+		return
+	}
+	state, ok := daemon.Running(stateDir)
+	if !ok {
+		fmt.Println(i18n.T("daemon.status.stopped"))
+		return
+	}
+
+	fmt.Println(i18n.T("daemon.status.running", state.PID))
+	fmt.Println(i18n.T("daemon.status.started", state.StartedAt.Format("2006-01-02 15:04:05"), time.Since(state.StartedAt).Round(time.Second)))
+	fmt.Println(i18n.T("daemon.status.directory", state.Dir))
+	fmt.Println(i18n.T("daemon.log", filepath.Join(stateDir, "logs", daemon.LogFileName)))
+	if !state.Ready {
+		fmt.Println(i18n.T("daemon.status.starting"))
+		return
+	}
+
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		printError("Failed to initialize process manager", err)
+		osExit(1)
+		return
+	}
+	processes, err := procManager.ListProcesses()
+	if err != nil {
+		printError("Failed to list processes", err)
+		osExit(1)
+		return
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].Name < processes[j].Name
+	})
+
+	fmt.Println(i18n.T("daemon.status.processes"))
+	for _, info := range processes {
+		status := i18n.T("daemon.process.stopped")
+		if process.Live(procManager, info) {
+			status = i18n.T("daemon.process.running", info.PID)
+		}
+		line := fmt.Sprintf("    %-20s %s", info.Name, status)
+		if n := state.Restarts[info.Name]; n > 0 {
+			line += i18n.T("daemon.process.restarted", n)
+		}
+		fmt.Println(line)
+	}
+}
+
+// runDaemonRun starts the stack and supervises it until asc daemon stop
+// sends SIGTERM, then stops it
+func runDaemonRun(cmd *cobra.Command, args []string) {
+	// Cancelled on SIGTERM, which ends supervision
+	ctx := commandContext(cmd)
+
+	if err := logger.Init(); err != nil {
+		printError("Failed to initialize logger", err)
+		osExit(1)
+	}
+	defer logger.Close()
+
+	// Outlive the terminal asc daemon start was run from, even where the
+	// new session does not detach from it
+	signal.Ignore(syscall.SIGHUP)
+
+	stateDir, err := statedir.Dir()
+	if err != nil {
+		printError("Failed to resolve state directory", err)
+		osExit(1)
+	}
+	if running, ok := daemon.Running(stateDir); ok && running.PID != os.Getpid() {
+		printError("Cannot start the daemon", fmt.Errorf("asc daemon is already running (PID %d)", running.PID))
+		osExit(1)
+	}
+	dir, _ := os.Getwd()
+	state := &daemon.State{PID: os.Getpid(), StartedAt: time.Now(), Dir: dir}
+	if err := daemon.Record(stateDir, state); err != nil {
+		printError("Cannot start the daemon", err)
+		osExit(1)
+	}
+	defer func() { _ = daemon.Clear(stateDir, state.PID) }()
+
+	cfg, procManager, logsDir := prepareStack(ctx, config.DefaultConfigPath(), config.DefaultEnvPath())
+	orch, enforcer, shipper := startStack(ctx, cfg, procManager, logsDir)
+
+	state.Ready = true
+	if err := daemon.Record(stateDir, state); err != nil {
+		logger.Error("%v", err)
+	}
+	logger.WithComponent("daemon").WithFields(logger.Fields{"pid": state.PID, "dir": dir}).Info("Supervising the agent stack")
+
+	// Count the restarts of the restart policies and of the supervisor
+	var restartsMu sync.Mutex
+	recordRestart := func(name string, err error) {
+		if err != nil {
+			return
+		}
+		restartsMu.Lock()
+		defer restartsMu.Unlock()
+		if state.Restarts == nil {
+			state.Restarts = make(map[string]int)
+		}
+		state.Restarts[name]++
+		if err := daemon.Record(stateDir, state); err != nil {
+			logger.Error("%v", err)
+		}
+	}
+	procManager.SetOnRestart(recordRestart)
+
+	go runTaskScheduler(ctx, cfg)
+	go runDoctorSchedule(ctx, cfg)
+
+	superviseStack(ctx, cfg, procManager, orch, enforcer, recordRestart)
+
+	// Stop without the context SIGTERM cancelled, so agents get their
+	// grace period
+	fmt.Println(i18n.T("up.shutting_down"))
+	logger.Info("Shutting down agent stack")
+	if orch != nil {
+		orch.Stop()
+	}
+	if enforcer != nil {
+		enforcer.Stop()
+	}
+	if err := procManager.StopAll(context.Background()); err != nil {
+		logger.Error("Error during shutdown: %v", err)
+		fmt.Fprintf(os.Stderr, "Error during shutdown: %v\n", err)
+	}
+	events.Publish(events.Event{Type: events.StackStopped, Message: "asc daemon stopped"})
+	fmt.Println(i18n.T("down.offline"))
+	logger.Info("Agent stack is offline")
+	if shipper != nil {
+		if err := shipper.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("up.ship_logs_failed", err))
+		}
+	}
+}
+
+// superviseStack supervises the stack until ctx is done, applying the
+// changes of asc.toml to its agents as they are saved or asc reload asks.
+// Supervision pauses while a reload stops and starts agents.
+func superviseStack(ctx context.Context, cfg *config.Config, procManager *process.Manager, orch *pipeline.Orchestrator, enforcer *budget.Enforcer, onRestart func(name string, err error)) {
+	// Without a watcher, the nil channels never deliver
+	var reloads <-chan *config.Config
+	var reloadErrs <-chan error
+	watcher, err := config.NewWatcher(config.DefaultConfigPath())
+	if err == nil {
+		if path, pathErr := config.ReloadRequestPath(); pathErr == nil {
+			watcher.WatchRequests(path)
+		}
+		err = watcher.Start()
+	}
+	if err != nil {
+		logger.Warn("Not reloading asc.toml on changes: %v", err)
+	} else {
+		defer watcher.Stop()
+		reloads, reloadErrs = watcher.Events(), watcher.Errors()
+	}
+
+	for {
+		supervisorCtx, stopSupervisor := context.WithCancel(ctx)
+		supervisor := newStackSupervisor(cfg, procManager, orch, enforcer)
+		supervisor.SetOnRestart(onRestart)
+		done := make(chan struct{})
+		go func() {
+			supervisor.Run(supervisorCtx)
+			close(done)
+		}()
+
+		var newCfg *config.Config
+		for newCfg == nil {
+			select {
+			case <-ctx.Done():
+				stopSupervisor()
+				<-done
+				return
+			case err := <-reloadErrs:
+				logger.Error("Not reloading asc.toml: %v", err)
+				recordReload(nil, err)
+			case newCfg = <-reloads:
+			}
+		}
+		stopSupervisor()
+		<-done
+
+		result := reloadAgents(ctx, cfg, newCfg, procManager, enforcer)
+		recordReload(result, nil)
+		logger.WithComponent("daemon").WithFields(logger.Fields{
+			"added":   result.AgentsAdded,
+			"removed": result.AgentsRemoved,
+			"changed": result.AgentsUpdated,
+		}).Info("Reloaded asc.toml")
+		for _, err := range result.Errors {
+			logger.Error("%v", err)
+		}
+		cfg = newCfg
+	}
+}
+
+// scaledDown reports whether agentCfg is an instance asc scale stopped
+// since the stack started
+func scaledDown(agentCfg config.AgentConfig) bool {
+	if agentCfg.Role == "" {
+		return false
+	}
+	path, err := config.ReplicasPath()
+	if err != nil {
+		return false
+	}
+	counts, err := config.LoadReplicas(path, config.DefaultConfigPath())
+	if err != nil {
+		return false
+	}
+	count, ok := counts[agentCfg.Role]
+	return ok && agentCfg.Replica > count
+}
+
+// newStackSupervisor supervises the MCP servers and the agents that
+// procManager does not, such as those another asc started since,
+// restarting them as asc up starts them. Once restarted, their restart
+// policies apply. Agents stopped by the phase pipeline or paused by their
+// budget, those with restart = "never", and instances scaled away by asc
+// scale are left stopped.
+func newStackSupervisor(cfg *config.Config, procManager *process.Manager, orch *pipeline.Orchestrator, enforcer *budget.Enforcer) *daemon.Supervisor {
+	names := managedMCPServers(cfg)
+	servers := len(names)
+	for name := range cfg.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names[servers:])
+
+	agents := &agentController{cfg: cfg, procManager: procManager, enforcer: enforcer}
+	start := func(name string) error {
+		if mcpCfg, ok := cfg.Services.MCPServer(name); ok {
+			mcpCmd, mcpArgs := parseCommand(mcpCfg.StartCommand)
+			_, err := procManager.Start(name, mcpCmd, mcpArgs, buildMCPEnv())
+			return err
+		}
+		return agents.StartAgent(name)
+	}
+
+	supervisor := daemon.NewSupervisor(procManager, start, names)
+	supervisor.SetFilter(func(name string) bool {
+		if procManager.Supervises(name) {
+			return false
+		}
+		if _, ok := cfg.Services.MCPServer(name); ok {
+			return true
+		}
+		if agentRestartPolicy(cfg.Agents[name]).Mode == process.RestartNever {
+			return false
+		}
+		if scaledDown(cfg.Agents[name]) {
+			return false
+		}
+		if enforcer != nil && enforcer.IsPaused(name) {
+			return false
+		}
+		if orch != nil {
+			return orch.IsAgentActive(name)
+		}
+		return !pipeline.Manages(cfg.Pipeline, cfg.Agents[name])
+	})
+	return supervisor
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/daemon"
+)
+
+// recordDaemon records the test process as a running daemon
+func recordDaemon(t *testing.T, env *TestEnvironment) {
+	t.Helper()
+	state := &daemon.State{
+		PID:       os.Getpid(),
+		StartedAt: time.Now().Add(-time.Hour),
+		Dir:       env.TempDir,
+		Ready:     true,
+		Restarts:  map[string]int{"mcp_agent_mail": 2},
+	}
+	if err := daemon.Record(filepath.Dir(env.PIDDir), state); err != nil {
+		t.Fatalf("Failed to record daemon: %v", err)
+	}
+}
+
+// TestDaemonStatusCommand_Stopped tests status without a daemon
+func TestDaemonStatusCommand_Stopped(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	runDaemonStatus(daemonStatusCmd, []string{})
+	capture.Stop()
+
+	if !strings.Contains(capture.GetStdout(), "asc daemon: ○ stopped") {
+		t.Errorf("Expected stopped status, got: %s", capture.GetStdout())
+	}
+}
+
+// TestDaemonStatusCommand_Running tests status of a running daemon,
+// listing its processes and their restarts
+func TestDaemonStatusCommand_Running(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	recordDaemon(t, env)
+	env.WritePIDFile("mcp_agent_mail", `{"name": "mcp_agent_mail", "pid": 1073741824, "command": "python"}`)
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	runDaemonStatus(daemonStatusCmd, []string{})
+	capture.Stop()
+
+	output := capture.GetStdout()
+	for _, want := range []string{
+		"asc daemon: ● running",
+		"Directory: " + env.TempDir,
+		"daemon.log",
+		"mcp_agent_mail",
+		"○ stopped, restarted 2 time(s)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected status to contain %q, got: %s", want, output)
+		}
+	}
+}
+
+// TestCheckNoDaemon tests that asc up refuses to run beside the daemon
+func TestCheckNoDaemon(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	if err := checkNoDaemon(); err != nil {
+		t.Fatalf("Expected no error without a daemon, got %v", err)
+	}
+
+	recordDaemon(t, env)
+	err := checkNoDaemon()
+	if err == nil || !strings.Contains(err.Error(), "asc daemon stop") {
+		t.Errorf("Expected an error suggesting asc daemon stop, got %v", err)
+	}
+}
+
+// TestDaemonStartCommand_AlreadyRunning tests that a second daemon is not
+// started
+func TestDaemonStartCommand_AlreadyRunning(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	recordDaemon(t, env)
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	runDaemonStart(daemonStartCmd, []string{})
+	capture.Stop()
+
+	if !strings.Contains(capture.GetStdout(), "already running") {
+		t.Errorf("Expected already running message, got: %s", capture.GetStdout())
+	}
+}
@@ -1,20 +1,40 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/spf13/cobra"
+	"github.com/rand/asc/internal/config"
 	"github.com/rand/asc/internal/doctor"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/logger"
+	"github.com/spf13/cobra"
 )
 
 var (
-	doctorFix     bool
-	doctorVerbose bool
-	doctorJSON    bool
+	doctorFix         bool
+	doctorFixOnly     []string
+	doctorInteractive bool
+	doctorVerifyKeys  bool
+	doctorWatch       time.Duration
+	doctorVerbose     bool
+	doctorJSON        bool
+	doctorFormat      string
+
+	doctorHistoryLimit int
+	doctorHistoryJSON  bool
+	doctorDiffJSON     bool
 )
 
+// doctorInput is where asc doctor --interactive reads its answers
+var doctorInput io.Reader = os.Stdin
+
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
 	Short: "Diagnose and fix common issues with the agent stack",
@@ -22,22 +42,67 @@ var doctorCmd = &cobra.Command{
 
 The doctor command checks for:
 - Configuration problems
-- Corrupted state (PIDs, logs)
+- Corrupted state (PIDs, logs, the beads database and its stale locks)
 - Permission issues
 - Resource problems
-- Network connectivity
+- Network connectivity (MCP servers, port conflicts, model provider APIs)
 - Agent health issues
 
-Use --fix to automatically remediate detected issues where possible.`,
-	Run: runDoctor,
+Check plugins add checks of your own: executables in ~/.asc/checks, and
+the commands of [doctor.checks.<name>] sections of asc.toml. Each prints
+the issues it finds as a JSON array of objects with id, title, severity,
+category, description, impact, remediation, and fix, a shell command
+--fix runs to fix the issue.
+
+Use --fix to automatically remediate detected issues where possible.
+--fix-only fixes the issues with the given IDs, or patterns of them, and
+--interactive asks before each fix. With --dry-run, doctor shows what each
+fix would do without changing anything.
+
+--verify-keys also checks the API keys of .env with Anthropic, OpenAI, and
+Google, making one authenticated request to each provider that has a key.
+Keys a provider rejects are reported by the name of their variable; the
+keys themselves are never printed.
+
+Each report is saved to ~/.asc/doctor-history; a text report ends with
+how many issues are new, recurring, and fixed since the last run. asc
+doctor history lists the saved reports with their trend, and asc doctor
+diff shows the issues that differ between two of them. --format junit
+prints the report as JUnit XML for CI, a failing test case per issue.
+
+--watch runs the diagnostics every interval until interrupted, printing
+each report and notifying [notify] endpoints of critical issues the run
+before did not report. asc daemon runs them the same way every [doctor]
+interval.
+
+Examples:
+  asc doctor --fix --dry-run
+  asc doctor --fix-only env-permissions,pid-orphaned-*
+  asc doctor --fix --interactive
+  asc doctor --verify-keys
+  asc doctor --watch 5m
+  asc doctor --format junit > doctor.xml`,
+	Run:         runDoctor,
+	Annotations: map[string]string{skipMigrationAnnotation: "true"},
 }
 
 func init() {
 	rootCmd.AddCommand(doctorCmd)
 
 	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Automatically fix issues where possible")
+	doctorCmd.Flags().StringSliceVar(&doctorFixOnly, "fix-only", nil, "Fix only the issues with these IDs or patterns, e.g. pid-orphaned-* (implies --fix)")
+	doctorCmd.Flags().BoolVar(&doctorInteractive, "interactive", false, "Ask before applying each fix (implies --fix)")
+	doctorCmd.Flags().BoolVar(&doctorVerifyKeys, "verify-keys", false, "Verify the API keys of .env with their providers")
+	doctorCmd.Flags().DurationVar(&doctorWatch, "watch", 0, "Run the diagnostics every interval until interrupted, e.g. 5m")
 	doctorCmd.Flags().BoolVar(&doctorVerbose, "verbose", false, "Show detailed diagnostic information")
 	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Output results in JSON format")
+	doctorCmd.Flags().StringVar(&doctorFormat, "format", "text", "Output format: text, json, or junit")
+
+	doctorCmd.AddCommand(doctorHistoryCmd)
+	doctorCmd.AddCommand(doctorDiffCmd)
+	doctorHistoryCmd.Flags().IntVar(&doctorHistoryLimit, "limit", 20, "Number of reports to list, the newest first; 0 for all")
+	doctorHistoryCmd.Flags().BoolVar(&doctorHistoryJSON, "json", false, "Output the reports as JSON")
+	doctorDiffCmd.Flags().BoolVar(&doctorDiffJSON, "json", false, "Output the difference as JSON")
 }
 
 func runDoctor(cmd *cobra.Command, args []string) {
@@ -45,53 +110,300 @@ func runDoctor(cmd *cobra.Command, args []string) {
 
 	// Default paths
 	configPath := "asc.toml"
-	envPath := ".env"
+	envPath := config.DefaultEnvPath()
 
 	// Create doctor instance
 	doc, err := doctor.NewDoctor(configPath, envPath)
 	if err != nil {
 		logger.Error("Failed to initialize doctor: %v", err)
-		fmt.Fprintf(os.Stderr, "Error: Failed to initialize doctor: %v\n", err)
+		printError("Failed to initialize doctor", err)
+		osExit(1)
+	}
+	doc.SetVerifyKeys(doctorVerifyKeys)
+	if err := checkDoctorFormat(); err != nil {
+		printError("Invalid output format", err)
 		osExit(1)
+		return
+	}
+	fix := doctorFix || len(doctorFixOnly) > 0 || doctorInteractive
+
+	if doctorWatch != 0 {
+		if err := checkDoctorWatch(fix); err != nil {
+			printError("Cannot watch", err)
+			osExit(1)
+			return
+		}
+		runDoctorWatch(commandContext(cmd), doc)
+		osExit(0)
+		return
 	}
 
 	// Run diagnostics
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(commandContext(cmd))
 	if err != nil {
 		logger.Error("Failed to run diagnostics: %v", err)
-		fmt.Fprintf(os.Stderr, "Error: Failed to run diagnostics: %v\n", err)
+		printError("Failed to run diagnostics", err)
 		osExit(1)
 	}
 
-	// Apply fixes if requested
-	if doctorFix {
+	// Apply fixes if requested, or list them for a dry run
+	opts := doctor.FixOptions{Only: doctorFixOnly}
+	if fix {
+		warnUnmatchedFixes(report, doctorFixOnly)
+	}
+	if dryRun {
+		printDoctorDryRun(doc, report, opts)
+	} else if fix {
+		if doctorInteractive {
+			opts.Confirm = confirmFix(doctorOutput(), doctorInput)
+		}
 		logger.Info("Applying automatic fixes...")
-		fixReport, err := doc.ApplyFixes(report)
+		fixReport, err := doc.ApplyFixesWith(commandContext(cmd), report, opts)
 		if err != nil {
 			logger.Error("Failed to apply fixes: %v", err)
-			fmt.Fprintf(os.Stderr, "Error: Failed to apply fixes: %v\n", err)
+			printError("Failed to apply fixes", err)
 			osExit(1)
 		}
 		report.FixesApplied = fixReport
+
+		for _, fix := range fixReport {
+			event := events.Event{Type: events.FixApplied, Message: fix.IssueID + ": " + fix.Message}
+			if !fix.Success {
+				event = events.Event{Type: events.FixApplied, Message: fix.IssueID, Err: fmt.Errorf("%s", fix.Message)}
+			}
+			events.Publish(event)
+		}
+	}
+	var previous *doctor.DiagnosticReport
+	if !dryRun {
+		publishCriticalIssues(commandContext(cmd), report)
+		previous = saveDoctorReport(doc, report)
 	}
 
 	// Output results
-	if doctorJSON {
+	printDoctorReport(report)
+	if previous != nil && doctorReportFormat() == "text" {
+		diff := doctor.Diff(previous, report)
+		fmt.Println(i18n.T("doctor.since_last_run",
+			previous.RunAt.Local().Format("2006-01-02 15:04:05"), len(diff.New), len(diff.Recurring), len(diff.Fixed)))
+	}
+
+	// Exit with appropriate code
+	if report.HasCriticalIssues() {
+		osExit(1)
+	}
+	osExit(0)
+}
+
+// printDoctorReport prints a report in the format of --format or --json
+func printDoctorReport(report *doctor.DiagnosticReport) {
+	switch doctorReportFormat() {
+	case "json":
 		output, err := report.ToJSON()
 		if err != nil {
 			logger.Error("Failed to format JSON output: %v", err)
-			fmt.Fprintf(os.Stderr, "Error: Failed to format JSON output: %v\n", err)
+			printError("Failed to format JSON output", err)
+			osExit(1)
+		}
+		fmt.Println(output)
+	case "junit":
+		output, err := report.ToJUnit()
+		if err != nil {
+			logger.Error("Failed to format JUnit output: %v", err)
+			printError("Failed to format JUnit output", err)
 			osExit(1)
 		}
 		fmt.Println(output)
-	} else {
+	default:
 		output := report.Format(doctorVerbose)
 		fmt.Println(output)
 	}
+}
+
+// doctorReportFormat returns the format asc doctor prints its report in:
+// json with --json, or that of --format
+func doctorReportFormat() string {
+	if doctorJSON {
+		return "json"
+	}
+	if doctorFormat == "" {
+		return "text"
+	}
+	return doctorFormat
+}
 
-	// Exit with appropriate code
-	if report.HasCriticalIssues() {
+// checkDoctorFormat returns an error if --format is unknown, or is not
+// the JSON --json asks for
+func checkDoctorFormat() error {
+	switch doctorFormat {
+	case "", "text", "json", "junit":
+	default:
+		return fmt.Errorf("unknown format '%s'\n  Suggestion: Use --format text, json, or junit", doctorFormat)
+	}
+	if doctorJSON && doctorFormat != "" && doctorFormat != "text" && doctorFormat != "json" {
+		return fmt.Errorf("--json cannot be combined with --format %s\n  Suggestion: Use one of them", doctorFormat)
+	}
+	return nil
+}
+
+// saveDoctorReport saves report to the history and returns the report
+// saved before it, if any. A report that cannot be saved is logged.
+func saveDoctorReport(doc *doctor.Doctor, report *doctor.DiagnosticReport) *doctor.DiagnosticReport {
+	dir := doc.HistoryDir()
+	var previous *doctor.DiagnosticReport
+	if ids, err := doctor.ListHistory(dir); err == nil && len(ids) > 0 {
+		previous, _ = doctor.LoadReport(dir, ids[0])
+	}
+	if _, err := doctor.SaveReport(dir, report, doctor.DefaultHistoryKept); err != nil {
+		logger.Warn("Failed to save doctor report: %v", err)
+	}
+	return previous
+}
+
+// checkDoctorWatch returns an error if --watch is given an interval too
+// short, or flags that need someone to act on each report
+func checkDoctorWatch(fix bool) error {
+	if doctorWatch < config.MinDoctorInterval {
+		return fmt.Errorf("--watch must be at least %s, got %s\n  Suggestion: Use a longer interval, e.g. --watch 5m", config.MinDoctorInterval, doctorWatch)
+	}
+	if fix || dryRun {
+		return fmt.Errorf("--watch cannot be combined with --fix, --fix-only, --interactive, or --dry-run\n  Suggestion: Watch for issues, then run 'asc doctor --fix' once to fix them")
+	}
+	return nil
+}
+
+// runDoctorWatch runs the diagnostics every --watch interval until
+// interrupted, printing each report and notifying of the critical issues
+// the run before did not report
+func runDoctorWatch(ctx context.Context, doc *doctor.Doctor) {
+	fmt.Fprintln(doctorOutput(), i18n.T("doctor.watching", doctorWatch, doc.HistoryDir()))
+	err := doc.Watch(ctx, doctorWatch, func(report *doctor.DiagnosticReport, newCritical []doctor.Issue) {
+		printDoctorReport(report)
+		for _, issue := range newCritical {
+			fmt.Fprintln(doctorOutput(), i18n.T("doctor.new_critical", issue.Title, issue.ID))
+		}
+		publishDoctorCritical(ctx, newCritical)
+	})
+	if err != nil {
+		logger.Error("Failed to run diagnostics: %v", err)
+		printError("Failed to run diagnostics", err)
 		osExit(1)
 	}
-	osExit(0)
+}
+
+// publishCriticalIssues publishes a doctor.critical event for each
+// critical issue that no fix resolved, sending them to [notify.webhook]
+func publishCriticalIssues(ctx context.Context, report *doctor.DiagnosticReport) {
+	fixed := make(map[string]bool)
+	for _, fix := range report.FixesApplied {
+		if fix.Success {
+			fixed[fix.IssueID] = true
+		}
+	}
+	var critical []doctor.Issue
+	for _, issue := range report.Issues {
+		if issue.Severity == doctor.SeverityCritical && !fixed[issue.ID] {
+			critical = append(critical, issue)
+		}
+	}
+	publishDoctorCritical(ctx, critical)
+}
+
+// runDoctorSchedule runs the diagnostics for asc daemon every [doctor]
+// interval until ctx is done, notifying of the critical issues the run
+// before did not report
+func runDoctorSchedule(ctx context.Context, cfg *config.Config) {
+	if cfg.Doctor.Interval == 0 {
+		return
+	}
+	doc, err := doctor.NewDoctor(config.DefaultConfigPath(), config.DefaultEnvPath())
+	if err != nil {
+		logger.Warn("Not running periodic diagnostics: %v", err)
+		return
+	}
+	log := logger.WithComponent("doctor")
+	err = doc.Watch(ctx, cfg.Doctor.Interval, func(report *doctor.DiagnosticReport, newCritical []doctor.Issue) {
+		log.WithFields(logger.Fields{"issues": len(report.Issues), "new_critical": len(newCritical)}).Info("Ran periodic diagnostics: %s", report.HealthSummary)
+		for _, issue := range newCritical {
+			log.Warn("New critical issue %s: %s", issue.ID, issue.Title)
+		}
+		publishDoctorCritical(ctx, newCritical)
+	})
+	if err != nil {
+		log.Error("Periodic diagnostics stopped: %v", err)
+	}
+}
+
+// publishDoctorCritical publishes a doctor.critical event for each of the
+// critical issues
+func publishDoctorCritical(ctx context.Context, critical []doctor.Issue) {
+	if len(critical) == 0 {
+		return
+	}
+	startNotificationsFromConfig(ctx)
+	for _, issue := range critical {
+		events.Publish(events.Event{Type: events.DoctorCritical, Message: issue.ID + ": " + issue.Title, Time: issue.DetectedAt})
+	}
+}
+
+// doctorOutput returns where asc doctor writes what is not its report:
+// stderr with --json or --format junit, so the report on stdout stays
+// valid
+func doctorOutput() io.Writer {
+	if doctorReportFormat() != "text" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// printDoctorDryRun lists what each fix --fix would apply would do
+func printDoctorDryRun(doc *doctor.Doctor, report *doctor.DiagnosticReport, opts doctor.FixOptions) {
+	out := doctorOutput()
+	fixable := 0
+	for _, issue := range report.Issues {
+		if !opts.Selects(issue.ID) {
+			continue
+		}
+		if action, ok := doc.DescribeFix(issue); ok {
+			fprintDryRun(out, "doctor.dry_run.fix", action, issue.ID)
+			fixable++
+		}
+	}
+	if fixable == 0 {
+		fmt.Fprintln(out, i18n.T("doctor.dry_run.none"))
+	}
+}
+
+// warnUnmatchedFixes warns about the IDs of --fix-only that match none of
+// the issues found
+func warnUnmatchedFixes(report *doctor.DiagnosticReport, only []string) {
+	for _, pattern := range only {
+		selector := doctor.FixOptions{Only: []string{pattern}}
+		matched := false
+		for _, issue := range report.Issues {
+			if selector.Selects(issue.ID) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fmt.Fprintln(os.Stderr, i18n.T("doctor.fix_unmatched", pattern))
+		}
+	}
+}
+
+// confirmFix returns a FixOptions.Confirm asking on out whether to apply
+// each fix and reading the answer from in. Only yes applies a fix; the end
+// of the input answers no to the rest.
+func confirmFix(out io.Writer, in io.Reader) func(issue doctor.Issue, action string) bool {
+	answers := bufio.NewScanner(in)
+	return func(issue doctor.Issue, action string) bool {
+		fmt.Fprint(out, i18n.T("doctor.confirm_fix", issue.Title, action))
+		if !answers.Scan() {
+			fmt.Fprintln(out)
+			return false
+		}
+		answer := strings.ToLower(strings.TrimSpace(answers.Text()))
+		return answer == "y" || answer == "yes"
+	}
 }
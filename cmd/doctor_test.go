@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/doctor"
 )
 
 // TestDoctorCommand tests the doctor command workflow
@@ -1063,3 +1066,55 @@ func TestDoctorCommand_HealthySystem(t *testing.T) {
 		// Note: May still have info-level issues
 	}
 }
+
+// TestDoctorFixSelection tests the dry run of selected fixes and asking
+// before each fix
+func TestDoctorFixSelection(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	doc, err := doctor.NewDoctor(env.ConfigPath, filepath.Join(env.TempDir, ".env"))
+	if err != nil {
+		t.Fatalf("Failed to create doctor: %v", err)
+	}
+	report := &doctor.DiagnosticReport{Issues: []doctor.Issue{
+		{ID: "dir-missing-logs", Title: "Missing logs directory", AutoFixable: true},
+		{ID: "plugin-vpn-down", Title: "VPN is down", AutoFixable: true, FixCommand: "vpn up"},
+	}}
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	printDoctorDryRun(doc, report, doctor.FixOptions{Only: []string{"plugin-*"}})
+	warnUnmatchedFixes(report, []string{"plugin-*", "pid-orphaned-coder"})
+	capture.Stop()
+	if stdout := capture.GetStdout(); !strings.Contains(stdout, "Dry run: would run 'vpn up' in") || strings.Contains(stdout, "logs") {
+		t.Errorf("Expected the dry run of the selected fix only, got:\n%s", stdout)
+	}
+	if stderr := capture.GetStderr(); !strings.Contains(stderr, "No issue matches pid-orphaned-coder") || strings.Contains(stderr, "plugin-*") {
+		t.Errorf("Expected a warning for the ID matching no issue, got:\n%s", stderr)
+	}
+
+	var out strings.Builder
+	confirm := confirmFix(&out, strings.NewReader("y\nno\n"))
+	if !confirm(report.Issues[0], "create it") || confirm(report.Issues[1], "run it") || confirm(report.Issues[0], "again") {
+		t.Error("Expected yes to apply a fix, and no or the end of the input to skip it")
+	}
+	if !strings.Contains(out.String(), "Missing logs directory\n  Fix: create it\nApply this fix? [y/N]") {
+		t.Errorf("Unexpected prompt:\n%s", out.String())
+	}
+}
+
+func TestCheckDoctorWatch(t *testing.T) {
+	defer func(watch time.Duration) { doctorWatch = watch }(doctorWatch)
+
+	doctorWatch = 5 * time.Minute
+	if err := checkDoctorWatch(false); err != nil {
+		t.Errorf("checkDoctorWatch() error = %v", err)
+	}
+	if err := checkDoctorWatch(true); err == nil || !strings.Contains(err.Error(), "--fix") {
+		t.Errorf("Expected --watch with --fix refused, got %v", err)
+	}
+	doctorWatch = time.Second
+	if err := checkDoctorWatch(false); err == nil {
+		t.Error("Expected an interval below the minimum refused")
+	}
+}
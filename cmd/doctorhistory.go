@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rand/asc/internal/doctor"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/spf13/cobra"
+)
+
+var doctorHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the saved doctor reports and their trend",
+	Long: `List the reports asc doctor saved in ~/.asc/doctor-history, the newest first,
+with how many issues each found, how many were critical, and how many were
+new or fixed since the report before it. A trend line compares the newest
+report listed with the oldest.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctorHistory,
+}
+
+var doctorDiffCmd = &cobra.Command{
+	Use:   "diff [run1] [run2]",
+	Short: "Show the issues new, recurring, and fixed between two doctor reports",
+	Long: `Compare two reports of asc doctor history: the issues run2 found that run1
+did not, those both found, and those run1 found that run2 no longer does.
+Reports are named by their ID, or by the start of it. Without run2, run1
+is compared with the newest report; without either, the two newest
+reports are compared.
+
+Examples:
+  asc doctor diff
+  asc doctor diff 20260301T12
+  asc doctor diff 20260301T120000.000Z 20260302T120000.000Z`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runDoctorDiff,
+}
+
+// doctorHistoryDir returns the directory doctor reports are saved in
+func doctorHistoryDir() (string, error) {
+	dir, err := statedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, doctor.HistoryDirName), nil
+}
+
+func runDoctorHistory(cmd *cobra.Command, args []string) error {
+	dir, err := doctorHistoryDir()
+	if err != nil {
+		return err
+	}
+	entries, err := doctor.History(dir, doctorHistoryLimit)
+	if err != nil {
+		return err
+	}
+	if doctorHistoryJSON {
+		for _, entry := range entries {
+			line, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(line))
+		}
+		return nil
+	}
+	fmt.Print(formatDoctorHistory(entries))
+	return nil
+}
+
+// formatDoctorHistory formats history entries as a table, followed by the
+// trend from the oldest to the newest
+func formatDoctorHistory(entries []doctor.HistoryEntry) string {
+	if len(entries) == 0 {
+		return i18n.T("doctor.history.none") + "\n"
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "%-22s %-20s %-7s %-9s %-5s %s\n", i18n.T("doctor.history.column.id"), i18n.T("doctor.history.column.run_at"),
+		i18n.T("doctor.history.column.issues"), i18n.T("doctor.history.column.critical"), i18n.T("doctor.history.column.new"), i18n.T("doctor.history.column.fixed"))
+	for _, entry := range entries {
+		fmt.Fprintf(&out, "%-22s %-20s %-7d %-9d %-5s %s\n", entry.ID,
+			entry.RunAt.Local().Format("2006-01-02 15:04:05"), entry.Issues, entry.Critical,
+			fmt.Sprintf("+%d", entry.New), fmt.Sprintf("-%d", entry.Fixed))
+	}
+	if len(entries) < 2 {
+		return out.String()
+	}
+
+	newest, oldest := entries[0], entries[len(entries)-1]
+	trend := i18n.T("doctor.history.steady")
+	switch {
+	case newest.Critical < oldest.Critical || (newest.Critical == oldest.Critical && newest.Issues < oldest.Issues):
+		trend = i18n.T("doctor.history.improving")
+	case newest.Critical > oldest.Critical || newest.Issues > oldest.Issues:
+		trend = i18n.T("doctor.history.worsening")
+	}
+	fmt.Fprintf(&out, "\n%s\n", i18n.T("doctor.history.trend",
+		len(entries), trend, oldest.Issues, newest.Issues, oldest.Critical, newest.Critical))
+	return out.String()
+}
+
+func runDoctorDiff(cmd *cobra.Command, args []string) error {
+	dir, err := doctorHistoryDir()
+	if err != nil {
+		return err
+	}
+	from, to, err := findDiffReports(dir, args)
+	if err != nil {
+		return err
+	}
+	diff := doctor.Diff(from, to)
+	if doctorDiffJSON {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	writeDoctorDiff(os.Stdout, diff)
+	return nil
+}
+
+// findDiffReports returns the reports asc doctor diff compares, by its
+// arguments
+func findDiffReports(dir string, args []string) (from, to *doctor.DiagnosticReport, err error) {
+	ids, err := doctor.ListHistory(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch len(args) {
+	case 0:
+		if len(ids) < 2 {
+			return nil, nil, errors.New("fewer than two doctor reports are saved\n  Suggestion: Run 'asc doctor' again to save another")
+		}
+		args = []string{ids[1], ids[0]}
+	case 1:
+		if len(ids) == 0 {
+			return nil, nil, errors.New("no doctor reports are saved\n  Suggestion: Run 'asc doctor' to save one")
+		}
+		args = append(args, ids[0])
+	}
+	if from, err = doctor.FindReport(dir, args[0]); err != nil {
+		return nil, nil, err
+	}
+	if to, err = doctor.FindReport(dir, args[1]); err != nil {
+		return nil, nil, err
+	}
+	return from, to, nil
+}
+
+// writeDoctorDiff writes the issues new, recurring, and fixed between two
+// reports
+func writeDoctorDiff(w io.Writer, diff *doctor.ReportDiff) {
+	fmt.Fprintln(w, i18n.T("doctor.diff.comparing", diff.From, diff.To))
+	for _, section := range []struct {
+		title, mark string
+		issues      []doctor.Issue
+	}{
+		{i18n.T("doctor.diff.new"), "✗", diff.New},
+		{i18n.T("doctor.diff.recurring"), "•", diff.Recurring},
+		{i18n.T("doctor.diff.fixed"), "✓", diff.Fixed},
+	} {
+		fmt.Fprintf(w, "\n%s (%d):\n", section.title, len(section.issues))
+		if len(section.issues) == 0 {
+			fmt.Fprintln(w, i18n.T("doctor.diff.none"))
+		}
+		for _, issue := range section.issues {
+			fmt.Fprintf(w, "  %s [%s] %s: %s\n", section.mark, issue.Severity, issue.ID, issue.Title)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/doctor"
+)
+
+func TestDoctorHistoryAndDiff(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir, err := doctorHistoryDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(dir, home) || filepath.Base(dir) != doctor.HistoryDirName {
+		t.Fatalf("Unexpected history directory %s", dir)
+	}
+	if _, _, err := findDiffReports(dir, nil); err == nil {
+		t.Error("Expected diff without reports to fail")
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, issues := range [][]doctor.Issue{
+		{{ID: "logs-large", Severity: doctor.SeverityLow, Title: "Large log directory"}, {ID: "config-missing", Severity: doctor.SeverityCritical, Title: "Configuration file not found"}},
+		{{ID: "logs-large", Severity: doctor.SeverityLow, Title: "Large log directory"}},
+	} {
+		if _, err := doctor.SaveReport(dir, &doctor.DiagnosticReport{RunAt: start.Add(time.Duration(i) * time.Hour), Issues: issues}, doctor.DefaultHistoryKept); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := doctor.History(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := formatDoctorHistory(entries)
+	if !strings.Contains(history, "Trend over 2 reports: improving (issues 2 → 1, critical 1 → 0)") {
+		t.Errorf("Unexpected history:\n%s", history)
+	}
+
+	from, to, err := findDiffReports(dir, nil)
+	if err != nil {
+		t.Fatalf("findDiffReports() error = %v", err)
+	}
+	var out strings.Builder
+	writeDoctorDiff(&out, doctor.Diff(from, to))
+	for _, want := range []string{"New (0):\n  none", "Recurring (1):\n  • [low] logs-large", "Fixed (1):\n  ✓ [critical] config-missing"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the diff to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestCheckDoctorFormat(t *testing.T) {
+	defer func(format string, json bool) { doctorFormat, doctorJSON = format, json }(doctorFormat, doctorJSON)
+
+	doctorFormat, doctorJSON = "junit", false
+	if err := checkDoctorFormat(); err != nil || doctorReportFormat() != "junit" {
+		t.Errorf("checkDoctorFormat() = %v, format %s", err, doctorReportFormat())
+	}
+	doctorJSON = true
+	if err := checkDoctorFormat(); err == nil {
+		t.Error("Expected --json with --format junit refused")
+	}
+	doctorFormat, doctorJSON = "yaml", false
+	if err := checkDoctorFormat(); err == nil {
+		t.Error("Expected an unknown format refused")
+	}
+}
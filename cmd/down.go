@@ -1,18 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 
-	"github.com/spf13/cobra"
+	"github.com/rand/asc/internal/daemon"
+	"github.com/rand/asc/internal/docker"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/spf13/cobra"
 )
 
 var downCmd = &cobra.Command{
 	Use:   "down",
 	Short: "Gracefully shut down all agents and services",
 	Long: `Stop the agent stack by:
+- Stopping asc daemon, if it runs the stack
 - Stopping all agent processes
 - Stopping the mcp_agent_mail service
 - Cleaning up PID files
@@ -25,44 +31,80 @@ func init() {
 }
 
 func runDown(cmd *cobra.Command, args []string) {
+	// Stop the daemon first, or it would restart what is stopped below
+	if stateDir, err := statedir.Dir(); err == nil {
+		if state, ok := daemon.Running(stateDir); ok {
+			if dryRun {
+				printDryRun("down.dry_run.stop_daemon", state.PID)
+			} else {
+				fmt.Println(i18n.T("daemon.stopping", state.PID))
+				if err := daemon.Stop(state.PID, daemonStopTimeout); err != nil {
+					fmt.Fprintln(os.Stderr, i18n.T("warning", err))
+				}
+				_ = daemon.Clear(stateDir, state.PID)
+			}
+		}
+	}
+
 	// Initialize process manager with ~/.asc/pids and ~/.asc/logs
-	homeDir, err := os.UserHomeDir()
+	procManager, err := process.NewDefaultManager()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to get home directory: %v\n", err)
+		printError("Failed to initialize process manager", err)
 		osExit(1)
 	}
 
-	pidsDir := filepath.Join(homeDir, ".asc", "pids")
-	logsDir := filepath.Join(homeDir, ".asc", "logs")
-
-	procManager, err := process.NewManager(pidsDir, logsDir)
+	// Plan from the PID files, so processes that already exited are only
+	// cleaned up and running down again is harmless
+	steps, err := process.Plan(procManager, nil)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to initialize process manager: %v\n", err)
+		printError("Failed to list processes", err)
 		osExit(1)
 	}
 
-	// List all managed processes
-	processes, err := procManager.ListProcesses()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to list processes: %v\n", err)
-		osExit(1)
+	if len(steps) == 0 {
+		fmt.Println(i18n.T("down.no_processes"))
+		fmt.Println(i18n.T("down.offline"))
+		return
 	}
 
-	if len(processes) == 0 {
-		fmt.Println("No running processes found")
-		fmt.Println("Agent stack is offline")
+	if dryRun {
+		for _, step := range steps {
+			printDryRun("dry_run.action", describeStep(step))
+		}
 		return
 	}
 
-	fmt.Printf("Shutting down %d process(es)...\n", len(processes))
+	fmt.Println(i18n.T("down.shutting_down", len(steps)))
+	printPlan(steps)
+	startNotificationsFromConfig(commandContext(cmd))
 
 	// Stop all processes using process manager
 	// This will handle both agents and mcp_agent_mail service
-	if err := procManager.StopAll(); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Some processes failed to stop cleanly: %v\n", err)
+	ctx := commandContext(cmd)
+	processes, _ := procManager.ListProcesses()
+	stopErr := procManager.StopAll(ctx)
+	if stopErr != nil {
+		fmt.Fprintln(os.Stderr, i18n.T("down.stop_failed", stopErr))
 		// Continue anyway to print confirmation
 	}
+	removeContainers(ctx, processes)
+	events.Publish(events.Event{Type: events.StackStopped, Message: "asc down", Err: stopErr})
 
 	// Print confirmation message
-	fmt.Println("Agent stack is offline")
+	fmt.Println(i18n.T("down.offline"))
+}
+
+// removeContainers removes the containers of the docker runtime agents
+// among processes. docker run removes a container once it exits, but one
+// whose docker CLI was killed after its stop grace period keeps running.
+func removeContainers(ctx context.Context, processes []*process.ProcessInfo) {
+	for _, info := range processes {
+		agent, ok := docker.AgentOf(info.Command, info.Args)
+		if !ok {
+			continue
+		}
+		if err := docker.Remove(ctx, agent); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("warning", err))
+		}
+	}
 }
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/audit"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+// eventsPollInterval is how often asc events tail --follow reads what was
+// appended to the audit log
+const eventsPollInterval = 500 * time.Millisecond
+
+var (
+	eventsLines  int
+	eventsTypes  []string
+	eventsAgent  string
+	eventsSince  string
+	eventsUntil  string
+	eventsFollow bool
+	eventsJSON   bool
+)
+
+// stopEventAudit stops recording the events of the command in the audit
+// log; nil while they are not recorded
+var stopEventAudit func()
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Show lifecycle events of agents, tasks, fixes, and secrets",
+	Long: `Show what happened to the stack: agents started, stopped, crashed, and
+restarted, tasks created, updated, and deleted, fixes applied by asc doctor
+--fix, and secrets encrypted and decrypted.
+
+Events are appended to ~/.asc/audit.log as JSON Lines, next to the actions
+shown by asc audit, by every asc command that causes them.`,
+}
+
+var eventsTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Show the latest events, and optionally follow new ones",
+	Long: `Show the latest events in the audit log, filtered by type, agent, and time.
+
+--type takes event types such as agent.crashed, or the part before the dot,
+such as agent, for all events of agents; give it more than once, or
+comma-separated, for several. asc audit actions are of type action.
+--since and --until take a time ("2026-03-01", "2026-03-01 14:30", or
+RFC 3339) or a duration before now, such as 2h.`,
+	Example: `  asc events tail
+  asc events tail --type agent.crashed --since 24h
+  asc events tail --agent coder --follow`,
+	Args: cobra.NoArgs,
+	RunE: runEventsTail,
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+	eventsCmd.AddCommand(eventsTailCmd)
+	eventsTailCmd.Flags().IntVarP(&eventsLines, "lines", "n", 20, "Number of events to show before following (0 for all)")
+	eventsTailCmd.Flags().StringSliceVar(&eventsTypes, "type", nil, "Only show events of these types, e.g. agent.crashed or agent")
+	eventsTailCmd.Flags().StringVar(&eventsAgent, "agent", "", "Only show events of this agent")
+	eventsTailCmd.Flags().StringVar(&eventsSince, "since", "", "Only show events at or after this time, or this long ago (e.g. 2h)")
+	eventsTailCmd.Flags().StringVar(&eventsUntil, "until", "", "Only show events before this time, or this long ago")
+	eventsTailCmd.Flags().BoolVarP(&eventsFollow, "follow", "f", false, "Keep showing new events until interrupted")
+	eventsTailCmd.Flags().BoolVar(&eventsJSON, "json", false, "Output events as JSON Lines")
+}
+
+// recordEvents records the events published while the command runs in the
+// audit log, once. Dry runs cause no events and record none.
+func recordEvents() {
+	if dryRun || stopEventAudit != nil {
+		return
+	}
+	stopEventAudit = events.RecordAudit()
+}
+
+// stopRecordingEvents stops recording events started by recordEvents
+func stopRecordingEvents() {
+	if stopEventAudit != nil {
+		stopEventAudit()
+		stopEventAudit = nil
+	}
+}
+
+func runEventsTail(cmd *cobra.Command, args []string) error {
+	filter, err := eventsFilter(time.Now())
+	if err != nil {
+		return err
+	}
+	path, err := audit.DefaultPath()
+	if err != nil {
+		return err
+	}
+	return tailEvents(commandContext(cmd), os.Stdout, path, filter, eventsLines, eventsFollow)
+}
+
+// eventsFilter builds the filter of the tail flags, with durations counted
+// back from now
+func eventsFilter(now time.Time) (events.Filter, error) {
+	filter := events.Filter{Agent: eventsAgent}
+	for _, t := range eventsTypes {
+		if t = strings.TrimSpace(t); t != "" {
+			filter.Types = append(filter.Types, t)
+		}
+	}
+	var err error
+	if filter.Since, err = parseEventTime(eventsSince, now); err != nil {
+		return filter, fmt.Errorf("invalid --since: %w", err)
+	}
+	if filter.Until, err = parseEventTime(eventsUntil, now); err != nil {
+		return filter, fmt.Errorf("invalid --until: %w", err)
+	}
+	return filter, nil
+}
+
+// parseEventTime parses a time, in local time unless it says otherwise, or
+// a duration before now. An empty value is the zero time.
+func parseEventTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is neither a time such as 2026-03-01 14:30 nor a duration such as 2h", value)
+}
+
+// tailEvents writes the last lines entries of the audit log at path that
+// the filter selects, 0 for all, then with follow those appended until ctx
+// is done
+func tailEvents(ctx context.Context, w io.Writer, path string, filter events.Filter, lines int, follow bool) error {
+	entries, offset, err := audit.ReadFrom(path, 0)
+	if err != nil {
+		return err
+	}
+	var selected []audit.Entry
+	for _, entry := range entries {
+		if filter.Match(entry) {
+			selected = append(selected, entry)
+		}
+	}
+	if lines > 0 && len(selected) > lines {
+		selected = selected[len(selected)-lines:]
+	}
+	if len(selected) == 0 && !follow {
+		fmt.Fprintln(w, i18n.T("events.none"))
+		return nil
+	}
+	for _, entry := range selected {
+		writeEvent(w, entry)
+	}
+	if !follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(eventsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		entries, offset, err = audit.ReadFrom(path, offset)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if filter.Match(entry) {
+				writeEvent(w, entry)
+			}
+		}
+	}
+}
+
+// writeEvent writes an entry of the audit log as a line of text, or of
+// JSON with --json
+func writeEvent(w io.Writer, entry audit.Entry) {
+	if eventsJSON {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		fmt.Fprintln(w, string(line))
+		return
+	}
+	fmt.Fprintln(w, formatAuditEntry(entry))
+}
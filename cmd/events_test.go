@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/audit"
+	"github.com/rand/asc/internal/events"
+)
+
+// TestEventsTail tests that tail shows the last matching entries
+func TestEventsTail(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	path, err := audit.DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for _, event := range []events.Event{
+		{Type: events.AgentStarted, Agent: "coder", Message: "PID 1", Time: now.Add(-3 * time.Hour)},
+		{Type: events.AgentCrashed, Agent: "coder", Err: errors.New("exit status 2"), Time: now.Add(-time.Hour)},
+		{Type: events.TaskCreated, Task: "bd-7", Message: "Write docs", Time: now.Add(-time.Minute)},
+		{Type: events.AgentStarted, Agent: "reviewer", Message: "PID 2", Time: now},
+	} {
+		if err := audit.Write(event.Entry()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter events.Filter
+		lines  int
+		want   []string
+	}{
+		{"last lines", events.Filter{}, 2, []string{"task.created bd-7", "agent.started reviewer"}},
+		{"type prefix", events.Filter{Types: []string{"agent"}}, 0, []string{"agent.started coder", "agent.crashed coder", "agent.started reviewer"}},
+		{"agent and since", events.Filter{Agent: "coder", Since: now.Add(-2 * time.Hour)}, 0, []string{"agent.crashed coder  → failure: exit status 2"}},
+		{"nothing", events.Filter{Types: []string{events.FixApplied}}, 0, []string{"No events"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := tailEvents(context.Background(), &out, path, tt.filter, tt.lines, false); err != nil {
+				t.Fatalf("tailEvents failed: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("Expected %d lines, got %q", len(tt.want), out.String())
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(lines[i], want) {
+					t.Errorf("Line %d = %q, want it to contain %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestEventsTailFollow tests that events recorded while following are shown
+func TestEventsTailFollow(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	path, err := audit.DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recordEvents()
+	defer stopRecordingEvents()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- tailEvents(ctx, &out, path, events.Filter{Types: []string{"agent"}}, 0, true)
+	}()
+
+	events.Publish(events.Event{Type: events.TaskDeleted, Task: "bd-1"})
+	events.Publish(events.Event{Type: events.AgentRestarted, Agent: "coder", Message: "PID 9, restart 1"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "agent.restarted coder") && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("tailEvents failed: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "agent.restarted coder") || strings.Contains(got, "bd-1") {
+		t.Errorf("Expected only the restart to be followed, got %q", got)
+	}
+}
+
+func TestParseEventTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"2h", now.Add(-2 * time.Hour), false},
+		{"2026-02-28", time.Date(2026, 2, 28, 0, 0, 0, 0, time.Local), false},
+		{"2026-02-28 14:30", time.Date(2026, 2, 28, 14, 30, 0, 0, time.Local), false},
+		{"2026-02-28T14:30:00Z", time.Date(2026, 2, 28, 14, 30, 0, 0, time.UTC), false},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseEventTime(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEventTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseEventTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to write and read from different
+// goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/docker"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <agent|service> -- <command> [args...]",
+	Short: "Run a command with an agent's environment",
+	Long: `Run a one-off command with the environment an agent or MCP server of
+asc.toml is started with: the variables asc sets for it, its rendered
+prompt and worktree, and the secrets of .env, .env.age, and [secrets.env].
+.env.age is decrypted in memory, so no plaintext is left on disk, and
+then the agent's env_file and extra_env. The command runs in the agent's
+workdir, or the current directory, as the agents do, and its exit code
+is passed through.
+
+For an agent with runtime = "docker", the command runs in a new container
+of the agent's image, with its volumes and network. The agent's own
+container is left running.
+
+Useful for reproducing an agent's failure by hand.
+
+Example:
+  asc exec main-coder -- env                   # Show what the agent sees
+  asc exec main-coder -- python agent.py --once
+  asc exec mcp_agent_mail -- sh`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, argv := args[0], args[1:]
+		cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !isExecTarget(cfg, name) {
+			return fmt.Errorf("no agent or service named '%s' in asc.toml\n  Suggestion: Use one of: %s", name, strings.Join(execTargets(cfg), ", "))
+		}
+
+		if dryRun {
+			printDryRun("exec.dry_run.run", strings.Join(argv, " "), name)
+			return nil
+		}
+
+		if err := loadStackSecrets(commandContext(cmd), cfg, config.DefaultEnvPath()); err != nil {
+			return err
+		}
+		argv, env, err := execCommand(cfg, name, argv)
+		if err != nil {
+			return err
+		}
+		if workdir := cfg.Agents[name].Workdir; workdir != "" {
+			if err := os.Chdir(workdir); err != nil {
+				return fmt.Errorf("working directory of %s: %w", name, err)
+			}
+		}
+		code, err := runForeground(argv, env)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			osExit(code)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+}
+
+// execTargets returns the names asc exec accepts: the agents, then the
+// MCP servers of cfg
+func execTargets(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Agents))
+	for name := range cfg.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(names, cfg.Services.MCPServerNames()...)
+}
+
+// isExecTarget reports whether name is an agent or MCP server of cfg
+func isExecTarget(cfg *config.Config, name string) bool {
+	for _, target := range execTargets(cfg) {
+		if target == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadStackSecrets adds the secrets asc up gives the stack to the
+// environment: those of envPath, or of envPath.age decrypted in memory,
+// unless asc secrets inject provided them, and then those of
+// [secrets.env]
+func loadStackSecrets(ctx context.Context, cfg *config.Config, envPath string) error {
+	if os.Getenv(secrets.InjectedEnvVar) == "" {
+		if _, err := os.Stat(envPath); err == nil {
+			if err := config.LoadEnv(envPath); err != nil {
+				return fmt.Errorf("failed to load environment: %w", err)
+			}
+		} else if _, err := os.Stat(envPath + ".age"); err == nil {
+			logger.Debug("Decrypting secrets from %s.age in memory", envPath)
+			plaintext, err := secrets.NewManager().DecryptToMemory(envPath + ".age")
+			if err != nil {
+				return fmt.Errorf("decryption failed: %w", err)
+			}
+			vars, err := config.ParseEnv(bytes.NewReader(plaintext))
+			if err != nil {
+				return fmt.Errorf("failed to parse %s.age: %w", envPath, err)
+			}
+			for _, v := range vars {
+				name, value, _ := strings.Cut(v, "=")
+				if err := os.Setenv(name, value); err != nil {
+					return fmt.Errorf("failed to set environment variable %s: %w", name, err)
+				}
+			}
+		}
+	}
+	if _, err := resolveSecretEnv(ctx, cfg.Secrets.Env); err != nil {
+		return err
+	}
+	logger.RegisterSecretsFromEnv()
+	return nil
+}
+
+// execCommand returns the command line and environment that run argv as
+// the agent or MCP server name of cfg would be run
+func execCommand(cfg *config.Config, name string, argv []string) ([]string, []string, error) {
+	agentCfg, ok := cfg.Agents[name]
+	if !ok {
+		return argv, buildMCPEnv(), nil
+	}
+
+	env := buildAgentEnv(name, agentCfg, cfg)
+	promptEnv, err := config.PromptEnv(name, agentCfg, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	env = append(env, promptEnv...)
+	worktreeEnv, err := config.WorktreeEnv(name, agentCfg, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	env = append(env, worktreeEnv...)
+	ownEnv, err := config.AgentEnv(name, agentCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	env = append(env, ownEnv...)
+	if !agentCfg.UsesDocker() {
+		return argv, env, nil
+	}
+
+	// The container is labelled apart from the agent's, so restarting the
+	// agent does not remove it
+	spec := config.DockerSpec(name, agentCfg)
+	spec.Agent = name + "-exec"
+	spec.Interactive = true
+	spec.TTY = term.IsTerminal(os.Stdin.Fd())
+	command, args, env, err := docker.Command(spec, argv, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append([]string{command}, args...), env, nil
+}
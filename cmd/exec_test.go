@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/config"
+)
+
+func TestExecCommand(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cfg := &config.Config{
+		Core: config.CoreConfig{BeadsDBPath: "./project-repo"},
+		Services: config.ServicesConfig{
+			MCPAgentMail: config.MCPConfig{URL: "http://localhost:8765"},
+		},
+		Agents: map[string]config.AgentConfig{
+			"coder":     {Command: "python agent.py", Model: "claude"},
+			"sandboxed": {Command: "python agent.py", Model: "claude", Runtime: "docker", Docker: config.DockerConfig{Image: "python:3.12"}},
+		},
+	}
+
+	if got := execTargets(cfg); !slices.Equal(got, []string{"coder", "sandboxed", "mcp_agent_mail"}) {
+		t.Errorf("execTargets() = %q", got)
+	}
+	if isExecTarget(cfg, "ghost") {
+		t.Error("Expected an unknown name not to be a target")
+	}
+
+	argv, env, err := execCommand(cfg, "coder", []string{"env"})
+	if err != nil {
+		t.Fatalf("execCommand() error = %v", err)
+	}
+	if !slices.Equal(argv, []string{"env"}) {
+		t.Errorf("Expected the command to run as it is, got %q", argv)
+	}
+	if !slices.Contains(env, "AGENT_NAME=coder") || !slices.Contains(env, "MCP_MAIL_URL=http://localhost:8765") {
+		t.Errorf("Expected the agent's variables in the environment, got %q", env)
+	}
+
+	// A docker agent's command runs in a container of its own
+	argv, _, err = execCommand(cfg, "sandboxed", []string{"sh"})
+	if err != nil {
+		t.Fatalf("execCommand() error = %v", err)
+	}
+	line := strings.Join(argv, " ")
+	if !strings.HasPrefix(line, "docker run ") || !strings.Contains(line, "asc.agent=sandboxed-exec") || !strings.HasSuffix(line, "python:3.12 sh") {
+		t.Errorf("Expected a docker run of the agent's image, got %q", line)
+	}
+
+	if _, env, _ := execCommand(cfg, "mcp_agent_mail", []string{"sh"}); slices.ContainsFunc(env, func(v string) bool { return strings.HasPrefix(v, "AGENT_NAME=") }) {
+		t.Error("Expected no agent variables for an MCP server")
+	}
+}
+
+func TestLoadStackSecrets(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("EXEC_TEST_KEY", "")
+	if err := os.WriteFile(".env", []byte("EXEC_TEST_KEY=sk-exec-test\n"), 0600); err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+
+	if err := loadStackSecrets(context.Background(), &config.Config{}, ".env"); err != nil {
+		t.Fatalf("loadStackSecrets() error = %v", err)
+	}
+	if got := os.Getenv("EXEC_TEST_KEY"); got != "sk-exec-test" {
+		t.Errorf("EXEC_TEST_KEY = %q, want the value of .env", got)
+	}
+
+	code, err := runForeground([]string{"sh", "-c", `test "$EXEC_TEST_KEY" = sk-exec-test`}, os.Environ())
+	if err != nil || code != 0 {
+		t.Errorf("runForeground() = %d, %v, want the secret in the command's environment", code, err)
+	}
+}
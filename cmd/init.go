@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/tui"
 )
 
@@ -10,6 +14,7 @@ var (
 	templateFlag      string
 	listTemplatesFlag bool
 	saveTemplateFlag  string
+	templateVarFlags  []string
 )
 
 var initCmd = &cobra.Command{
@@ -25,7 +30,13 @@ var initCmd = &cobra.Command{
 Templates:
   --template=solo   Single agent for individual development
   --template=team   Planner, coder, and tester agents
-  --template=swarm  Multiple agents per phase for parallel work`,
+  --template=swarm  Multiple agents per phase for parallel work
+
+Custom templates are read from ~/.asc/templates/<name>.toml, and may
+declare variables in a header of #! lines, which the wizard asks for.
+--var sets them instead:
+
+  asc init --template my-org-standard --var team=platform`,
 	Run: runInit,
 }
 
@@ -34,6 +45,7 @@ func init() {
 	initCmd.Flags().StringVar(&templateFlag, "template", "", "Use a predefined template (solo, team, swarm)")
 	initCmd.Flags().BoolVar(&listTemplatesFlag, "list-templates", false, "List all available templates")
 	initCmd.Flags().StringVar(&saveTemplateFlag, "save-template", "", "Save current config as a custom template")
+	initCmd.Flags().StringArrayVar(&templateVarFlags, "var", nil, "Set a variable of the template, name=value (repeatable)")
 }
 
 func runInit(cmd *cobra.Command, args []string) {
@@ -49,23 +61,61 @@ func runInit(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	values, err := parseTemplateVars(templateFlag, templateVarFlags)
+	if err != nil {
+		cmd.PrintErrf("Error: %v\n", err)
+		return
+	}
+
 	// Launch the interactive setup wizard with optional template
 	wizard := tui.NewWizard()
 	if templateFlag != "" {
 		wizard.SetTemplate(templateFlag)
 	}
+	wizard.SetTemplateValues(values)
 	if err := wizard.Run(); err != nil {
 		cmd.PrintErrf("Error running setup wizard: %v\n", err)
 		return
 	}
 }
 
+// parseTemplateVars returns the name=value settings of --var, which must
+// be variables the template declares
+func parseTemplateVars(templateName string, settings []string) (map[string]string, error) {
+	values := make(map[string]string)
+	if len(settings) == 0 {
+		return values, nil
+	}
+	if templateName == "" {
+		return nil, fmt.Errorf("--var sets variables of a template; choose one with --template")
+	}
+	tmpl, err := config.FindTemplate(templateName)
+	if err != nil {
+		return nil, err
+	}
+	declared := make(map[string]bool)
+	for _, v := range tmpl.Vars {
+		declared[v.Name] = true
+	}
+	for _, setting := range settings {
+		name, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --var %q: use name=value", setting)
+		}
+		if !declared[name] {
+			return nil, fmt.Errorf("template %s has no variable %s", templateName, name)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
 func listTemplates(cmd *cobra.Command) {
-	cmd.Println("Available templates:")
+	cmd.Println(i18n.T("init.templates.available"))
 	cmd.Println()
 
 	// List built-in templates
-	cmd.Println("Built-in templates:")
+	cmd.Println(i18n.T("init.templates.builtin"))
 	for _, tmpl := range config.ListTemplates() {
 		cmd.Printf("  %s - %s\n", tmpl.Name, tmpl.Description)
 	}
@@ -73,15 +123,22 @@ func listTemplates(cmd *cobra.Command) {
 	// List custom templates
 	customTemplates, err := config.ListCustomTemplates()
 	if err != nil {
-		cmd.PrintErrf("Warning: Failed to load custom templates: %v\n", err)
+		cmd.PrintErrln(i18n.T("warning", i18n.T("init.templates.custom_failed", err)))
 		return
 	}
 
 	if len(customTemplates) > 0 {
 		cmd.Println()
-		cmd.Println("Custom templates:")
+		cmd.Println(i18n.T("init.templates.custom"))
 		for _, tmpl := range customTemplates {
 			cmd.Printf("  %s - %s\n", tmpl.Name, tmpl.Description)
+			for _, v := range tmpl.Vars {
+				if v.Default != "" {
+					cmd.Printf("      --var %s=<%s> (%s)\n", v.Name, v.Prompt, i18n.T("init.templates.default", v.Default))
+				} else {
+					cmd.Printf("      --var %s=<%s>\n", v.Name, v.Prompt)
+				}
+			}
 		}
 	}
 }
@@ -92,5 +149,5 @@ func saveTemplate(cmd *cobra.Command) {
 		cmd.PrintErrf("Error saving template: %v\n", err)
 		return
 	}
-	cmd.Printf("Template '%s' saved successfully\n", saveTemplateFlag)
+	cmd.Println(i18n.T("init.template_saved", saveTemplateFlag))
 }
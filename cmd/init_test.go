@@ -948,3 +948,33 @@ func TestInitCommandOutputFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTemplateVars(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	templatesDir := filepath.Join(home, ".asc", "templates")
+	os.MkdirAll(templatesDir, 0755)
+	template := "#! [[var]]\n#! name = \"team\"\n[core]\nbeads_db_path = \"./{{.team}}\"\n"
+	if err := os.WriteFile(filepath.Join(templatesDir, "acme.toml"), []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := parseTemplateVars("acme", []string{"team=a=b"})
+	if err != nil || values["team"] != "a=b" {
+		t.Errorf("parseTemplateVars() = %v, %v", values, err)
+	}
+	for _, tt := range []struct {
+		template string
+		settings []string
+		wantErr  string
+	}{
+		{"", []string{"team=web"}, "choose one with --template"},
+		{"acme", []string{"team"}, "use name=value"},
+		{"acme", []string{"region=eu"}, "has no variable region"},
+		{"team", []string{"team=web"}, "has no variable team"},
+	} {
+		if _, err := parseTemplateVars(tt.template, tt.settings); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseTemplateVars(%q, %v) error = %v, want %q", tt.template, tt.settings, err, tt.wantErr)
+		}
+	}
+}
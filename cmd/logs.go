@@ -0,0 +1,423 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsSelf    bool
+	logsLines   int
+	logsJSON    bool
+	logsFollow  bool
+	logsSince   time.Duration
+	logsGrep    string
+	logsNoColor bool
+)
+
+// logsPollInterval is how often asc logs -f checks the logs for new
+// lines. It is a variable so tests do not wait.
+var logsPollInterval = 200 * time.Millisecond
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [agent...]",
+	Short: "Show the agents' logs, or asc's own",
+	Long: `Show the output logs of the named agents and services, or of mcp_agent_mail
+and every agent in asc.toml, each line prefixed with the name of its agent
+in a color of its own, like docker compose logs. The last lines of each log
+are shown, then with -f the lines the agents write are shown as they write
+them, until Ctrl-C. A log rotated while followed is followed from its
+start.
+
+--since shows only the lines written within the given time, by the
+timestamps the lines start with (asc's logger, Python's logging, RFC 3339,
+or a JSON or logfmt time field); lines without one, such as the rest of a
+stack trace, go with the line before them. --grep shows only the lines
+matching a regular expression.
+
+With --self, asc prints its own recent records from an in-memory buffer that
+is saved to ~/.asc/logs/recent.jsonl when each command exits (or to a file in
+the temp directory if ~/.asc is not writable). The buffer is kept even when
+the log file could not be opened, so startup failures can be inspected too.
+
+Examples:
+  asc logs -f                        # Follow every agent
+  asc logs planner tester --since 10m
+  asc logs -f --grep 'ERROR|Traceback'
+  asc logs --self`,
+	Run: runLogs,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().BoolVar(&logsSelf, "self", false, "Show asc's own recent log records")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 50, "Number of lines to show from each log, or records with --self (0 for all)")
+	logsCmd.Flags().BoolVar(&logsJSON, "json", false, "Output records as JSON Lines (with --self)")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow the logs as the agents write them")
+	logsCmd.Flags().DurationVar(&logsSince, "since", 0, "Show only lines written within this time, e.g. 10m")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "", "Show only lines matching this regular expression")
+	logsCmd.Flags().BoolVar(&logsNoColor, "no-color", false, "Do not color the agent names")
+}
+
+func runLogs(cmd *cobra.Command, args []string) {
+	opts, err := logsOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		osExit(1)
+		return
+	}
+	if logsSelf {
+		if logsFollow || len(args) > 0 {
+			fmt.Fprintln(os.Stderr, "Error: --self cannot be combined with --follow or agent names")
+			osExit(1)
+			return
+		}
+		runSelfLogs(opts)
+		return
+	}
+
+	sources, err := agentLogSources(commandContext(cmd), args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "  Suggestion: Run 'asc status' to list the managed processes, or 'asc logs --self' for asc's own log")
+		osExit(1)
+		return
+	}
+	if len(sources) == 0 {
+		fmt.Println(i18n.T("logs.none"))
+		return
+	}
+	if err := writeAgentLogs(commandContext(cmd), os.Stdout, sources, opts); err != nil {
+		printError("Failed to read the logs", err)
+		osExit(1)
+	}
+}
+
+// agentLogOptions selects and formats the lines asc logs shows
+type agentLogOptions struct {
+	lines  int            // Lines shown from the end of each log; 0 for all
+	since  time.Time      // Lines written before are not shown; zero for all
+	grep   *regexp.Regexp // Lines not matching are not shown; nil for all
+	follow bool
+	color  bool
+}
+
+// logsOptions returns the options of the flags
+func logsOptions() (agentLogOptions, error) {
+	opts := agentLogOptions{lines: logsLines, follow: logsFollow}
+	if logsLines < 0 {
+		return opts, fmt.Errorf("--lines must not be negative")
+	}
+	if logsSince < 0 {
+		return opts, fmt.Errorf("--since must not be negative")
+	}
+	if logsSince > 0 {
+		opts.since = time.Now().Add(-logsSince)
+	}
+	if logsGrep != "" {
+		re, err := regexp.Compile(logsGrep)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --grep: %w", err)
+		}
+		opts.grep = re
+	}
+	opts.color = !logsNoColor && os.Getenv("NO_COLOR") == "" && term.IsTerminal(os.Stdout.Fd())
+	return opts, nil
+}
+
+// runSelfLogs prints asc's own recent records
+func runSelfLogs(opts agentLogOptions) {
+	var entries []logger.LogEntry
+	for _, entry := range logger.RecentHistory() {
+		if !opts.since.IsZero() {
+			if at, ok := parseLogTime(entry.Timestamp); ok && at.Before(opts.since) {
+				continue
+			}
+		}
+		if opts.grep != nil && !opts.grep.MatchString(logger.FormatEntryText(entry)) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if logsLines > 0 && len(entries) > logsLines {
+		entries = entries[len(entries)-logsLines:]
+	}
+
+	if len(entries) == 0 {
+		fmt.Println(i18n.T("logs.no_records"))
+		return
+	}
+
+	for _, entry := range entries {
+		if logsJSON {
+			line, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			fmt.Println(string(line))
+			continue
+		}
+		fmt.Println(logger.FormatEntryText(entry))
+	}
+}
+
+// logSource is the log of one agent or service followed by asc logs
+type logSource struct {
+	name    string
+	path    string
+	prefix  string
+	offset  int64  // Where the lines not yet read start
+	partial []byte // Start of a line whose end has not been written yet
+	shown   bool   // Whether the last line with a timestamp was recent enough for --since
+}
+
+// agentLogSources returns the logs of the named agents and services, or of
+// the MCP servers asc starts and every agent in asc.toml, falling back to
+// the managed processes without a config. A named log that does not exist
+// is an error; the others are skipped.
+func agentLogSources(ctx context.Context, names []string) ([]*logSource, error) {
+	named := len(names) > 0
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		return nil, err
+	}
+	if !named {
+		names = defaultLogNames(ctx, procManager)
+	}
+
+	var sources []*logSource
+	for _, name := range names {
+		path, err := statedir.Path("logs", name+".log")
+		if err != nil {
+			return nil, err
+		}
+		if info, err := procManager.GetProcessInfo(name); err == nil && info.LogFile != "" {
+			path = info.LogFile
+		}
+		if _, err := os.Stat(path); err != nil {
+			if named {
+				return nil, fmt.Errorf("no log for '%s'", name)
+			}
+			continue
+		}
+		sources = append(sources, &logSource{name: name, path: path, shown: true})
+	}
+	return sources, nil
+}
+
+// defaultLogNames returns the MCP servers asc starts and the agents of
+// asc.toml, or the managed processes if it cannot be loaded
+func defaultLogNames(ctx context.Context, procManager process.ProcessManager) []string {
+	var names []string
+	if cfg, err := config.LoadContext(ctx, config.DefaultConfigPath()); err == nil {
+		names = managedMCPServers(cfg)
+		var agents []string
+		for name := range cfg.Agents {
+			agents = append(agents, name)
+		}
+		sort.Strings(agents)
+		return append(names, agents...)
+	}
+	processes, _ := procManager.ListProcesses()
+	for _, info := range processes {
+		names = append(names, info.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// logColors are the ANSI colors of the agents' prefixes, in turn
+var logColors = []string{"36", "33", "32", "35", "34", "96", "93", "92", "95", "94"}
+
+// writeAgentLogs writes the last lines of each log to w, each prefixed
+// with its name, then with opts.follow the lines written to them until ctx
+// is done
+func writeAgentLogs(ctx context.Context, w io.Writer, sources []*logSource, opts agentLogOptions) error {
+	width := 0
+	for _, src := range sources {
+		width = max(width, len(src.name))
+	}
+	for i, src := range sources {
+		src.prefix = fmt.Sprintf("%-*s | ", width, src.name)
+		if opts.color {
+			src.prefix = "\033[" + logColors[i%len(logColors)] + "m" + src.prefix + "\033[0m"
+		}
+	}
+
+	for _, src := range sources {
+		lines, err := src.read(opts, !opts.follow)
+		if err != nil {
+			return err
+		}
+		if opts.lines > 0 && len(lines) > opts.lines {
+			lines = lines[len(lines)-opts.lines:]
+		}
+		if err := src.write(w, lines); err != nil {
+			return err
+		}
+	}
+	if !opts.follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(logsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		for _, src := range sources {
+			lines, err := src.read(opts, false)
+			if err != nil {
+				return err
+			}
+			if err := src.write(w, lines); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// read returns the lines written to the log since the last read that opts
+// selects. A line whose end has not been written yet is kept for the next
+// read, unless final. A log emptied by rotation since is read from its
+// start, and a missing one is waited for.
+func (s *logSource) read(opts agentLogOptions, final bool) ([]string, error) {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() < s.offset {
+		s.offset, s.partial = 0, nil
+	}
+	if s.offset == 0 && !opts.since.IsZero() && stat.ModTime().Before(opts.since) {
+		// Nothing in a log last written before --since is recent enough
+		s.offset = stat.Size()
+		return nil, nil
+	}
+	if _, err := file.Seek(s.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	reader := bufio.NewReader(file)
+	for {
+		chunk, err := reader.ReadBytes('\n')
+		s.offset += int64(len(chunk))
+		s.partial = append(s.partial, chunk...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return lines, err
+		}
+		if line, ok := s.take(opts); ok {
+			lines = append(lines, line)
+		}
+	}
+	if final && len(s.partial) > 0 {
+		if line, ok := s.take(opts); ok {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// take returns the line read, and whether opts selects it
+func (s *logSource) take(opts agentLogOptions) (string, bool) {
+	line := strings.TrimRight(string(s.partial), "\r\n")
+	s.partial = s.partial[:0]
+	if !opts.since.IsZero() {
+		if at, ok := lineTime(line); ok {
+			s.shown = !at.Before(opts.since)
+		}
+		if !s.shown {
+			return "", false
+		}
+	}
+	if opts.grep != nil && !opts.grep.MatchString(line) {
+		return "", false
+	}
+	return line, true
+}
+
+// write writes lines to w with the source's prefix
+func (s *logSource) write(w io.Writer, lines []string) error {
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(w, "%s%s\n", s.prefix, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logTimeLayouts are the layouts of the local times log lines start with:
+// asc's logger and Python's logging, with and without milliseconds
+var logTimeLayouts = []string{logger.TimestampLayout, "2006-01-02 15:04:05,000", "2006-01-02 15:04:05"}
+
+// lineTime returns the time a log line was written, from the timestamp it
+// starts with or its JSON or logfmt time field
+func lineTime(line string) (time.Time, bool) {
+	if strings.HasPrefix(line, "{") {
+		var fields map[string]any
+		if json.Unmarshal([]byte(line), &fields) != nil {
+			return time.Time{}, false
+		}
+		for _, key := range []string{"timestamp", "time", "ts"} {
+			if value, ok := fields[key].(string); ok {
+				return parseLogTime(value)
+			}
+		}
+		return time.Time{}, false
+	}
+	if rest, ok := strings.CutPrefix(line, "ts="); ok {
+		line = strings.TrimPrefix(rest, `"`)
+	}
+	return parseLogTime(strings.TrimPrefix(line, "["))
+}
+
+// parseLogTime parses the timestamp s starts with
+func parseLogTime(s string) (time.Time, bool) {
+	token := s
+	if end := strings.IndexAny(s, " ]\""); end >= 0 {
+		token = s[:end]
+	}
+	if t, err := time.Parse(time.RFC3339Nano, token); err == nil {
+		return t, true
+	}
+	for _, layout := range logTimeLayouts {
+		if len(s) < len(layout) {
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, s[:len(layout)], time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLogsCommand_Self tests that asc logs --self prints saved records
+func TestLogsCommand_Self(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Setenv("TMPDIR", env.TempDir)
+
+	recentPath := filepath.Join(env.TempDir, ".asc", "logs", "recent.jsonl")
+	if err := os.MkdirAll(filepath.Dir(recentPath), 0700); err != nil {
+		t.Fatalf("Failed to create log directory: %v", err)
+	}
+	records := `{"timestamp":"2025-01-02T03:04:05.000Z","level":"INFO","message":"Starting agent planner"}
+{"timestamp":"2025-01-02T03:04:06.000Z","level":"ERROR","message":"Failed to start agent","component":"process"}
+`
+	if err := os.WriteFile(recentPath, []byte(records), 0600); err != nil {
+		t.Fatalf("Failed to write recent records: %v", err)
+	}
+
+	oldSelf, oldLines := logsSelf, logsLines
+	defer func() { logsSelf, logsLines = oldSelf, oldLines }()
+	logsSelf, logsLines = true, 0
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	exitCode, exitCalled := RunWithExitCapture(func() {
+		logsCmd.Run(logsCmd, []string{})
+	})
+	capture.Stop()
+
+	if exitCalled {
+		t.Fatalf("Expected successful completion, got exit code %d", exitCode)
+	}
+
+	// Records from this process follow the saved ones
+	stdout := capture.GetStdout()
+	first := strings.Index(stdout, "[INFO] Starting agent planner")
+	second := strings.Index(stdout, "[ERROR] Failed to start agent {component=process}")
+	if first < 0 || second < first {
+		t.Errorf("Expected the saved records in order, got:\n%s", stdout)
+	}
+}
+
+// TestLogsCommand_UnknownAgent tests that asc logs fails for an agent without a log
+func TestLogsCommand_UnknownAgent(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	oldSelf := logsSelf
+	defer func() { logsSelf = oldSelf }()
+	logsSelf = false
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	exitCode, exitCalled := RunWithExitCapture(func() {
+		logsCmd.Run(logsCmd, []string{"ghost"})
+	})
+	capture.Stop()
+
+	if !exitCalled || exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d (called: %v)", exitCode, exitCalled)
+	}
+	if !strings.Contains(capture.GetStderr(), "no log for 'ghost'") {
+		t.Errorf("Expected the missing log to be named, got:\n%s", capture.GetStderr())
+	}
+}
+
+// TestWriteAgentLogs tests that the agents' logs are shown with their names, filtered
+func TestWriteAgentLogs(t *testing.T) {
+	env := NewTestEnvironment(t)
+	now := time.Now()
+	stamp := func(ago time.Duration) string { return now.Add(-ago).Format("2006-01-02 15:04:05,000") }
+	planner := filepath.Join(env.LogDir, "planner.log")
+	tester := filepath.Join(env.LogDir, "tester.log")
+	os.WriteFile(planner, []byte(stamp(time.Hour)+" [INFO] old work\n"+stamp(time.Minute)+" [ERROR] failed\nTraceback line\n"+stamp(time.Second)+" [INFO] retrying\n"), 0600)
+	os.WriteFile(tester, []byte("plain one\nplain two\nplain three"), 0600)
+	sources := []*logSource{{name: "planner", path: planner, shown: true}, {name: "tester", path: tester, shown: true}}
+
+	var out bytes.Buffer
+	if err := writeAgentLogs(context.Background(), &out, sources, agentLogOptions{lines: 2}); err != nil {
+		t.Fatalf("writeAgentLogs failed: %v", err)
+	}
+	want := "planner | Traceback line\nplanner | " + stamp(time.Second) + " [INFO] retrying\ntester  | plain two\ntester  | plain three\n"
+	if out.String() != want {
+		t.Errorf("Expected the last two lines of each log, got:\n%s", out.String())
+	}
+
+	// --since keeps the lines after the time with what follows them, and
+	// --grep the lines matching
+	for _, src := range sources {
+		src.offset, src.shown = 0, true
+	}
+	out.Reset()
+	opts := agentLogOptions{since: now.Add(-10 * time.Minute), grep: regexp.MustCompile(`ERROR|Traceback|plain`)}
+	if err := writeAgentLogs(context.Background(), &out, sources, opts); err != nil {
+		t.Fatalf("writeAgentLogs failed: %v", err)
+	}
+	got := out.String()
+	if strings.Contains(got, "old work") || !strings.Contains(got, "[ERROR] failed") || !strings.Contains(got, "planner | Traceback line") || strings.Contains(got, "retrying") {
+		t.Errorf("Expected the recent matching lines of planner, got:\n%s", got)
+	}
+	if !strings.Contains(got, "tester  | plain one") {
+		t.Errorf("Expected a log without timestamps written since to be shown, got:\n%s", got)
+	}
+}
+
+// TestWriteAgentLogs_Follow tests that asc logs -f shows lines as they are written
+func TestWriteAgentLogs_Follow(t *testing.T) {
+	env := NewTestEnvironment(t)
+	oldInterval := logsPollInterval
+	logsPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { logsPollInterval = oldInterval })
+
+	path := filepath.Join(env.LogDir, "planner.log")
+	os.WriteFile(path, []byte("before\n"), 0600)
+	sources := []*logSource{{name: "planner", path: path, shown: true}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out syncBuffer
+	done := make(chan error)
+	go func() { done <- writeAgentLogs(ctx, &out, sources, agentLogOptions{follow: true, color: true}) }()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("half")
+	time.Sleep(50 * time.Millisecond)
+	file.WriteString(" and half\n")
+	file.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "half and half") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("writeAgentLogs failed: %v", err)
+	}
+	want := "\033[36mplanner | \033[0mbefore\n\033[36mplanner | \033[0mhalf and half\n"
+	if out.String() != want {
+		t.Errorf("Expected the lines with colored prefixes, got %q", out.String())
+	}
+}
+
+// TestLineTime tests reading the time a log line was written
+func TestLineTime(t *testing.T) {
+	local := time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local)
+	tests := []struct {
+		line string
+		want time.Time
+		ok   bool
+	}{
+		{"2025-01-02 03:04:05,000 [INFO] agent: started", local, true},
+		{"[2025-01-02 03:04:05.000] [INFO] started", local, true},
+		{"2025-01-02T03:04:05Z started", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), true},
+		{`{"timestamp":"2025-01-02T03:04:05Z","message":"started"}`, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), true},
+		{`ts="2025-01-02 03:04:05.000" level=info msg=started`, local, true},
+		{"  File \"agent.py\", line 3", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := lineTime(tt.line)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("lineTime(%q) = %v, %v; want %v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+// notifiers send the events of the command to the endpoints of [notify]
+// once a configuration setting them is loaded
+var notifiers []*notify.Notifier
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Check the notifications of [notify]",
+	Long: `asc sends notifications of events such as agent crashes, stuck tasks,
+critical issues found by asc doctor, and the stack starting and stopping to
+the webhook, Slack, and Discord endpoints configured in [notify] of asc.toml.`,
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send a test notification to every configured endpoint",
+	Long: `Send a test notification to each endpoint configured in [notify], to check
+its URL, proxy, and templates. Exits with 1 if any endpoint fails.`,
+	Args: cobra.NoArgs,
+	Run:  runNotifyTest,
+}
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.AddCommand(notifyTestCmd)
+}
+
+func runNotifyTest(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		printError("Failed to load configuration", err)
+		osExit(1)
+		return
+	}
+	configured, err := notify.FromConfig(cfg.Notify)
+	failed := err != nil
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+	}
+	if len(configured) == 0 && !failed {
+		fmt.Println(i18n.T("notify.none"))
+		return
+	}
+
+	payload := notify.NewPayload(events.Event{Type: notify.TestEvent, Message: "Test notification from asc", Time: time.Now()})
+	for _, n := range configured {
+		name := n.Sender().Name()
+		if dryRun {
+			printDryRun("notify.dry_run.send", name)
+			continue
+		}
+		if err := n.Sender().Send(payload); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", name, err)
+			failed = true
+			continue
+		}
+		fmt.Println(i18n.T("notify.sent", name))
+	}
+	if failed {
+		osExit(1)
+	}
+}
+
+// startNotifications starts sending the events of the command to the
+// endpoints configured in [notify]. Dry runs cause no events and notify
+// nobody. Failures are reported but never block the command.
+func startNotifications(cfg *config.Config) {
+	if dryRun || notifiers != nil {
+		return
+	}
+	configured, err := notify.FromConfig(cfg.Notify)
+	if err != nil {
+		logger.Error("Failed to start notifications: %v", err)
+		fmt.Fprintln(os.Stderr, i18n.T("notify.disabled", err))
+	}
+	for _, n := range configured {
+		n.Start()
+	}
+	notifiers = configured
+}
+
+// startNotificationsFromConfig starts notifications for commands that do
+// not otherwise need asc.toml, such as asc down. An invalid or missing
+// configuration disables them silently; those commands report it if it
+// matters.
+func startNotificationsFromConfig(ctx context.Context) {
+	if dryRun {
+		return
+	}
+	if cfg, err := config.LoadContext(ctx, config.DefaultConfigPath()); err == nil {
+		startNotifications(cfg)
+	}
+}
+
+// stopNotifications sends the notifications still queued and stops
+// notifying
+func stopNotifications() {
+	for _, n := range notifiers {
+		if err := n.Close(); err != nil {
+			logger.Warn("Failed to send notifications: %v", err)
+		}
+	}
+	notifiers = nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/doctor"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/notify"
+)
+
+// TestNotifyCriticalIssues tests that the critical issues asc doctor
+// leaves unresolved are posted to the webhook
+func TestNotifyCriticalIssues(t *testing.T) {
+	var mu sync.Mutex
+	var payloads []notify.Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Invalid payload: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	startNotifications(&config.Config{Notify: config.NotifyConfig{Webhook: config.WebhookConfig{
+		URL:     server.URL,
+		Events:  []string{events.DoctorCritical, events.StackStopped},
+		Timeout: time.Second,
+	}}})
+	if len(notifiers) != 1 {
+		t.Fatal("Expected notifications to start")
+	}
+	defer stopNotifications()
+
+	report := &doctor.DiagnosticReport{
+		Issues: []doctor.Issue{
+			{ID: "missing-config", Title: "asc.toml not found", Severity: doctor.SeverityCritical},
+			{ID: "stale-pid", Title: "Stale PID file", Severity: doctor.SeverityCritical},
+			{ID: "old-logs", Title: "Old log files", Severity: doctor.SeverityLow},
+		},
+		FixesApplied: []doctor.FixResult{{IssueID: "stale-pid", Success: true}},
+	}
+	publishCriticalIssues(context.Background(), report)
+	events.Publish(events.Event{Type: events.AgentStarted, Agent: "coder"})
+	stopNotifications()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 1 || payloads[0].Event != events.DoctorCritical || payloads[0].Message != "missing-config: asc.toml not found" {
+		t.Errorf("Expected only the unresolved critical issue, got %+v", payloads)
+	}
+}
+
+// TestNotificationsDisabled tests that nothing is started without a URL
+func TestNotificationsDisabled(t *testing.T) {
+	startNotifications(&config.Config{})
+	if len(notifiers) != 0 {
+		t.Error("Expected no notifiers without URLs")
+	}
+}
+
+// TestNotifyTestCommand tests that asc notify test sends to every
+// configured endpoint and fails if one does
+func TestNotifyTestCommand(t *testing.T) {
+	var mu sync.Mutex
+	var texts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		texts = append(texts, body["text"]+body["content"])
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	env := NewTestEnvironment(t)
+	defer ChangeToTempDir(t, env.TempDir)()
+	base := strings.Split(budgetTestConfig, "[budget]")[0]
+
+	env.WriteConfig(base + fmt.Sprintf("\n[notify.slack]\nurl = %q\n", server.URL+"/slack"))
+	capture := NewCaptureOutput()
+	capture.Start()
+	exitCode, exitCalled := RunWithExitCapture(func() { runNotifyTest(notifyTestCmd, nil) })
+	capture.Stop()
+	if exitCalled {
+		t.Fatalf("Expected success, got exit code %d: %s", exitCode, capture.GetStderr())
+	}
+	if !strings.Contains(capture.GetStdout(), "✓ slack") || len(texts) != 1 || !strings.Contains(texts[0], "Test notification from asc") {
+		t.Errorf("Expected a test message to Slack, got %q and %q", capture.GetStdout(), texts)
+	}
+
+	env.WriteConfig(base + fmt.Sprintf("\n[notify.slack]\nurl = %q\n\n[notify.discord]\nurl = %q\n", server.URL+"/slack", server.URL+"/broken"))
+	capture = NewCaptureOutput()
+	capture.Start()
+	exitCode, _ = RunWithExitCapture(func() { runNotifyTest(notifyTestCmd, nil) })
+	capture.Stop()
+	if exitCode != 1 || !strings.Contains(capture.GetStderr(), "✗ discord") {
+		t.Errorf("Expected the Discord failure to exit with 1, got %d: %s", exitCode, capture.GetStderr())
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rand/asc/internal/budget"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/pipeline"
+	"github.com/rand/asc/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline",
+	Short: "Show and control the phase pipeline",
+	Long: `Show and control the phase pipeline configured in [pipeline].
+
+While the stack is running, asc moves the project through the configured
+phases in order. A phase completes when its gate is met by the beads tasks
+in that phase (by default: at least one task, all of them done), and only
+the agents that handle the current phase run. Agents that handle none of
+the pipeline's phases always run.
+
+Progress is kept in ~/.asc/pipeline.json, so a restarted stack resumes in
+the same phase. Changes made with advance and reset are picked up by a
+running stack on its next gate evaluation.`,
+}
+
+var pipelineStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the current phase and gate progress",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		orch, err := newPipelineCommandOrchestrator(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		status, err := orch.Evaluate(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		fmt.Print(formatPipelineStatus(status))
+		return nil
+	},
+}
+
+var pipelineAdvanceCmd = &cobra.Command{
+	Use:   "advance",
+	Short: "Complete the current phase without waiting for its gate",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		orch, err := newPipelineCommandOrchestrator(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			from, to, err := orch.PlanAdvance()
+			if err != nil {
+				return err
+			}
+			if to == "" {
+				printDryRun("pipeline.dry_run.complete_last", from)
+				return nil
+			}
+			printDryRun("pipeline.dry_run.advance", from, to)
+			return nil
+		}
+		state, err := orch.Advance()
+		if err != nil {
+			return err
+		}
+		if state.Finished {
+			fmt.Println(i18n.T("pipeline.completed"))
+			return nil
+		}
+		fmt.Println(i18n.T("pipeline.advanced", state.Phase))
+		return nil
+	},
+}
+
+var pipelineResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Return the pipeline to its first phase",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		orch, err := newPipelineCommandOrchestrator(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			printDryRun("pipeline.dry_run.reset", orch.FirstPhase())
+			return nil
+		}
+		state, err := orch.Reset()
+		if err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("pipeline.reset", state.Phase))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pipelineCmd)
+	pipelineCmd.AddCommand(pipelineStatusCmd)
+	pipelineCmd.AddCommand(pipelineAdvanceCmd)
+	pipelineCmd.AddCommand(pipelineResetCmd)
+}
+
+// newPipelineOrchestrator creates the orchestrator used by the pipeline
+// command. It is a variable so tests can point it at a temporary state
+// file and fake tasks.
+var newPipelineOrchestrator = func(cfg *config.Config) (*pipeline.Orchestrator, error) {
+	beadsClient, err := newBeadsClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return pipeline.NewOrchestrator(*cfg, beadsClient, nil)
+}
+
+// newPipelineCommandOrchestrator loads asc.toml and returns an orchestrator
+// that tracks phases without starting or stopping agents
+func newPipelineCommandOrchestrator(ctx context.Context) (*pipeline.Orchestrator, error) {
+	cfg, err := config.LoadContext(ctx, config.DefaultConfigPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if !cfg.Pipeline.Enabled() {
+		return nil, fmt.Errorf("no phase pipeline is configured\n  Suggestion: Add a [pipeline] section with phases = [...] to asc.toml")
+	}
+	return newPipelineOrchestrator(cfg)
+}
+
+// formatPipelineStatus renders the phases with their gate progress
+func formatPipelineStatus(status pipeline.Status) string {
+	var out strings.Builder
+	if status.Finished {
+		out.WriteString(i18n.T("pipeline.status.complete") + "\n\n")
+	} else {
+		out.WriteString(i18n.T("pipeline.status.current", status.Phase))
+		if !status.StartedAt.IsZero() {
+			out.WriteString(i18n.T("pipeline.status.since", status.StartedAt.Format("2006-01-02 15:04")))
+		}
+		out.WriteString("\n\n")
+	}
+
+	completed := make(map[string]bool)
+	for _, record := range status.Completed {
+		completed[record.Phase] = true
+	}
+	for _, p := range status.Progress {
+		marker := " "
+		switch {
+		case completed[p.Phase]:
+			marker = "✓"
+		case p.Phase == status.Phase:
+			marker = "▶"
+		}
+		gate := i18n.T("pipeline.status.needs_done", p.Required)
+		if p.Total < p.MinTasks {
+			gate = i18n.T("pipeline.status.needs_tasks", p.MinTasks)
+		}
+		if p.GateMet {
+			gate = i18n.T("pipeline.status.gate_met")
+		}
+		fmt.Fprintf(&out, "%s %-16s %s  %s\n", marker, p.Phase, i18n.T("pipeline.status.tasks_done", p.Done, p.Total), gate)
+	}
+
+	if len(status.ActiveAgents) > 0 {
+		fmt.Fprintf(&out, "\n%s\n", i18n.T("pipeline.status.active_agents", strings.Join(status.ActiveAgents, ", ")))
+	}
+	return out.String()
+}
+
+// startPipeline starts the phase orchestrator when [pipeline] is
+// configured. Returns nil if the pipeline is disabled or cannot be
+// started, in which case the agents it manages do not run. Agents paused
+// by enforcer are not started with their phase.
+func startPipeline(cfg *config.Config, procManager process.ProcessManager, enforcer *budget.Enforcer) *pipeline.Orchestrator {
+	if !cfg.Pipeline.Enabled() {
+		return nil
+	}
+
+	var orch *pipeline.Orchestrator
+	beadsClient, err := newBeadsClient(cfg)
+	if err == nil {
+		orch, err = pipeline.NewOrchestrator(*cfg, beadsClient, &agentController{cfg: cfg, procManager: procManager, enforcer: enforcer})
+	}
+	if err != nil {
+		logger.Error("Failed to start the phase pipeline: %v", err)
+		fmt.Fprintln(os.Stderr, i18n.T("pipeline.disabled", err))
+		return nil
+	}
+	orch.Start()
+	return orch
+}
+
+// agentController starts and stops agents for the phase pipeline and the
+// budget enforcer the same way asc up starts them
+type agentController struct {
+	cfg         *config.Config
+	procManager process.ProcessManager
+	enforcer    *budget.Enforcer // Refuses to start paused agents; nil if budgets are off
+}
+
+func (c *agentController) StartAgent(name string) error {
+	agentCfg, ok := c.cfg.Agents[name]
+	if !ok {
+		return fmt.Errorf("agent '%s' not found in config", name)
+	}
+	if c.enforcer != nil && c.enforcer.IsPaused(name) {
+		return fmt.Errorf("agent '%s' is paused for exceeding its budget", name)
+	}
+	_, err := startAgent(name, agentCfg, c.cfg, c.procManager)
+	return err
+}
+
+func (c *agentController) StopAgent(name string) error {
+	info, err := c.procManager.GetProcessInfo(name)
+	if err != nil {
+		// Not started, nothing to stop
+		return nil
+	}
+	return c.procManager.Stop(context.Background(), info.PID)
+}
+
+func (c *agentController) IsAgentRunning(name string) bool {
+	info, err := c.procManager.GetProcessInfo(name)
+	return err == nil && process.Live(c.procManager, info)
+}
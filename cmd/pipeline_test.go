@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/pipeline"
+)
+
+const pipelineTestConfig = `[core]
+beads_db_path = "./project-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.planner]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+
+[pipeline]
+phases = ["planning", "implementation"]
+`
+
+// TestPipelineCommands tests status and advance against a temporary state file
+func TestPipelineCommands(t *testing.T) {
+	env := NewTestEnvironment(t)
+	env.WriteConfig(pipelineTestConfig)
+	defer ChangeToTempDir(t, env.TempDir)()
+
+	tasks := &mockBeadsClient{tasks: []beads.Task{
+		{ID: "1", Title: "Plan it", Status: "open", Phase: "planning"},
+	}}
+	statePath := filepath.Join(env.TempDir, "pipeline.json")
+	oldNew := newPipelineOrchestrator
+	defer func() { newPipelineOrchestrator = oldNew }()
+	newPipelineOrchestrator = func(cfg *config.Config) (*pipeline.Orchestrator, error) {
+		return pipeline.NewOrchestratorWithStatePath(*cfg, tasks, nil, statePath), nil
+	}
+
+	run := func(fn func() error) string {
+		t.Helper()
+		capture := NewCaptureOutput()
+		capture.Start()
+		err := fn()
+		capture.Stop()
+		if err != nil {
+			t.Fatalf("Command failed: %v", err)
+		}
+		return capture.GetStdout()
+	}
+
+	status := run(func() error { return pipelineStatusCmd.RunE(pipelineStatusCmd, nil) })
+	if !strings.Contains(status, "Current phase: planning") || !strings.Contains(status, "▶ planning") {
+		t.Errorf("Unexpected status output:\n%s", status)
+	}
+	if !strings.Contains(status, "0/1 tasks done  needs 1 done") || !strings.Contains(status, "Active agents: planner") {
+		t.Errorf("Expected gate progress and active agents, got:\n%s", status)
+	}
+
+	if out := run(func() error { return pipelineAdvanceCmd.RunE(pipelineAdvanceCmd, nil) }); !strings.Contains(out, "Advanced to phase implementation") {
+		t.Errorf("Unexpected advance output: %s", out)
+	}
+	status = run(func() error { return pipelineStatusCmd.RunE(pipelineStatusCmd, nil) })
+	if !strings.Contains(status, "✓ planning") || !strings.Contains(status, "Active agents: coder") {
+		t.Errorf("Expected planning completed after advance, got:\n%s", status)
+	}
+}
+
+// TestPipelineCommandWithoutPipeline tests the error when [pipeline] is missing
+func TestPipelineCommandWithoutPipeline(t *testing.T) {
+	env := NewTestEnvironment(t)
+	env.WriteConfig(strings.Split(pipelineTestConfig, "[pipeline]")[0])
+	defer ChangeToTempDir(t, env.TempDir)()
+
+	err := pipelineStatusCmd.RunE(pipelineStatusCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "no phase pipeline is configured") {
+		t.Errorf("Expected a missing pipeline error, got %v", err)
+	}
+}
@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/prompts"
+	"github.com/spf13/cobra"
+)
+
+var (
+	promptsMessage string
+	promptsRender  bool
+	promptsAgent   string
+)
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Manage versioned agent prompts",
+	Long: `Store, version, and template the system prompts given to agents.
+
+Every change to a prompt is kept as a numbered version under
+~/.asc/prompts/<name>/, so prompts can be compared and rolled back.
+Agents reference a prompt in asc.toml by name (the current version) or
+pin a version with name@N:
+
+  [agent.main-planner]
+  prompt = "planner@3"
+
+Prompts are Go templates with access to {{.AgentName}}, {{.Model}},
+{{.Phases}}, {{.BeadsDBPath}} and {{.MCPURL}}, plus the join, upper and
+lower functions. asc renders the prompt when it starts an agent and passes
+its path in AGENT_PROMPT_FILE.
+
+Workflow:
+  1. asc prompts add planner planner.md -m "Initial prompt"
+  2. Set prompt = "planner" on an agent in asc.toml
+  3. asc prompts add planner planner.md -m "Ask for smaller tasks"
+  4. asc prompts diff planner           # Compare previous and current
+  5. asc prompts rollback planner 1     # Restore version 1 if needed`,
+}
+
+var promptsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored prompts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := prompts.NewDefaultStore()
+		if err != nil {
+			return err
+		}
+
+		names, err := store.List()
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Println(i18n.T("prompts.none"))
+			fmt.Println("\n" + i18n.T("prompts.none_hint"))
+			return nil
+		}
+
+		for _, name := range names {
+			history, current, err := store.History(name)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%-20s v%d (%s)\n", name, current, i18n.T("prompts.versions", len(history)))
+		}
+		return nil
+	},
+}
+
+var promptsAddCmd = &cobra.Command{
+	Use:   "add <name> <file>",
+	Short: "Add a new version of a prompt from a file",
+	Long: `Add the contents of a file as a new version of a prompt, creating the
+prompt if needed. The new version becomes current, so agents that reference
+the prompt by name pick it up the next time they start.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, file := args[0], args[1]
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		// Catch template errors before the agent starts
+		if err := prompts.Validate(string(content)); err != nil {
+			return err
+		}
+
+		store, err := prompts.NewDefaultStore()
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			next, unchanged, err := nextPromptVersion(store, name, string(content))
+			if err != nil {
+				return err
+			}
+			if unchanged {
+				fmt.Println(i18n.T("prompts.dry_run.unchanged", name))
+				return nil
+			}
+			printDryRun("prompts.dry_run.add", file, name, next)
+			return nil
+		}
+		version, err := store.Add(name, content, promptsMessage)
+		if errors.Is(err, prompts.ErrUnchanged) {
+			fmt.Println(i18n.T("prompts.unchanged", name))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(i18n.T("prompts.added", name, version.Number))
+		return nil
+	},
+}
+
+var promptsShowCmd = &cobra.Command{
+	Use:   "show <name[@version]>",
+	Short: "Print a prompt version",
+	Long: `Print the current version of a prompt, or a specific one with name@N.
+
+With --render, the prompt is rendered as a template for the given agent in
+asc.toml, exactly as that agent would receive it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := prompts.NewDefaultStore()
+		if err != nil {
+			return err
+		}
+
+		if promptsRender {
+			data := prompts.TemplateData{AgentName: promptsAgent}
+			if promptsAgent != "" {
+				cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+				if err != nil {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
+				agentCfg, ok := cfg.Agents[promptsAgent]
+				if !ok {
+					return fmt.Errorf("agent '%s' not found in config", promptsAgent)
+				}
+				data = config.PromptData(promptsAgent, agentCfg, cfg)
+			}
+			rendered, _, err := store.RenderRef(args[0], data)
+			if err != nil {
+				return err
+			}
+			fmt.Print(rendered)
+			return nil
+		}
+
+		name, version, err := prompts.ParseRef(args[0])
+		if err != nil {
+			return err
+		}
+		content, _, err := store.Get(name, version)
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+		return nil
+	},
+}
+
+var promptsHistoryCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "Show the versions of a prompt",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := prompts.NewDefaultStore()
+		if err != nil {
+			return err
+		}
+		history, current, err := store.History(args[0])
+		if err != nil {
+			return err
+		}
+
+		for i := len(history) - 1; i >= 0; i-- {
+			version := history[i]
+			marker := " "
+			if version.Number == current {
+				marker = "*"
+			}
+			line := fmt.Sprintf("%s v%-3d %s  %s", marker, version.Number, version.CreatedAt.Format("2006-01-02 15:04:05"), version.Author)
+			if version.Message != "" {
+				line += "  " + version.Message
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+var promptsDiffCmd = &cobra.Command{
+	Use:   "diff <name> [from] [to]",
+	Short: "Compare two versions of a prompt",
+	Long: `Show a unified diff between two versions of a prompt.
+
+With no versions, the current version is compared with the one before it.
+With one version, that version is compared with the current one.`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		store, err := prompts.NewDefaultStore()
+		if err != nil {
+			return err
+		}
+		_, current, err := store.History(name)
+		if err != nil {
+			return err
+		}
+
+		from, to := current-1, current
+		if len(args) > 1 {
+			if from, err = parsePromptVersion(args[1]); err != nil {
+				return err
+			}
+		}
+		if len(args) > 2 {
+			if to, err = parsePromptVersion(args[2]); err != nil {
+				return err
+			}
+		}
+		if from < 1 {
+			fmt.Println(i18n.T("prompts.one_version", name))
+			return nil
+		}
+
+		a, _, err := store.Get(name, from)
+		if err != nil {
+			return err
+		}
+		b, _, err := store.Get(name, to)
+		if err != nil {
+			return err
+		}
+
+		diff := prompts.Diff(fmt.Sprintf("%s@%d", name, from), fmt.Sprintf("%s@%d", name, to), a, b)
+		if diff == "" {
+			fmt.Println(i18n.T("prompts.identical", from, to, name))
+			return nil
+		}
+		fmt.Print(diff)
+		return nil
+	},
+}
+
+var promptsRollbackCmd = &cobra.Command{
+	Use:   "rollback <name> <version>",
+	Short: "Restore an earlier version of a prompt",
+	Long: `Restore an earlier version of a prompt by adding its content as a new
+current version. The history is kept intact, so a rollback can itself be
+rolled back.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, err := parsePromptVersion(args[1])
+		if err != nil {
+			return err
+		}
+		store, err := prompts.NewDefaultStore()
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			content, _, err := store.Get(args[0], version)
+			if err != nil {
+				return err
+			}
+			next, unchanged, err := nextPromptVersion(store, args[0], content)
+			if err != nil {
+				return err
+			}
+			if unchanged {
+				fmt.Println(i18n.T("prompts.already_current", version, args[0]))
+				return nil
+			}
+			printDryRun("prompts.dry_run.restore", args[0], version, next)
+			return nil
+		}
+
+		restored, err := store.Rollback(args[0], version)
+		if errors.Is(err, prompts.ErrUnchanged) {
+			fmt.Println(i18n.T("prompts.already_current", version, args[0]))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(i18n.T("prompts.restored", args[0], version, restored.Number))
+		return nil
+	},
+}
+
+// nextPromptVersion returns the number adding content to the named prompt
+// would give its new version, or unchanged if content is already current
+func nextPromptVersion(store *prompts.Store, name, content string) (next int, unchanged bool, err error) {
+	versions, _, err := store.History(name)
+	if errors.Is(err, prompts.ErrNotFound) {
+		return 1, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	current, _, err := store.Get(name, 0)
+	if err != nil {
+		return 0, false, err
+	}
+	return len(versions) + 1, current == content, nil
+}
+
+// parsePromptVersion parses a version argument such as "3" or "v3"
+func parsePromptVersion(arg string) (int, error) {
+	version, err := strconv.Atoi(strings.TrimPrefix(arg, "v"))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid version '%s'\n  Suggestion: Use a version number from 'asc prompts history'", arg)
+	}
+	return version, nil
+}
+
+func init() {
+	rootCmd.AddCommand(promptsCmd)
+	promptsCmd.AddCommand(promptsListCmd)
+	promptsCmd.AddCommand(promptsAddCmd)
+	promptsCmd.AddCommand(promptsShowCmd)
+	promptsCmd.AddCommand(promptsHistoryCmd)
+	promptsCmd.AddCommand(promptsDiffCmd)
+	promptsCmd.AddCommand(promptsRollbackCmd)
+
+	promptsAddCmd.Flags().StringVarP(&promptsMessage, "message", "m", "", "Describe the change")
+	promptsShowCmd.Flags().BoolVar(&promptsRender, "render", false, "Render the prompt as a template")
+	promptsShowCmd.Flags().StringVar(&promptsAgent, "agent", "", "Agent from asc.toml to render the prompt for (with --render)")
+}
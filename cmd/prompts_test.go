@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPromptsCommands tests adding, diffing, and rolling back a prompt
+func TestPromptsCommands(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	file := filepath.Join(env.TempDir, "planner.md")
+	run := func(cmd func() error) string {
+		t.Helper()
+		capture := NewCaptureOutput()
+		capture.Start()
+		err := cmd()
+		capture.Stop()
+		if err != nil {
+			t.Fatalf("Command failed: %v", err)
+		}
+		return capture.GetStdout()
+	}
+	add := func(content string) string {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return run(func() error { return promptsAddCmd.RunE(promptsAddCmd, []string{"planner", file}) })
+	}
+
+	if out := add("Plan for {{.AgentName}}.\n"); !strings.Contains(out, "Added planner version 1") {
+		t.Errorf("Unexpected add output: %s", out)
+	}
+	if out := add("Plan small tasks for {{.AgentName}}.\n"); !strings.Contains(out, "Added planner version 2") {
+		t.Errorf("Unexpected add output: %s", out)
+	}
+	if out := add("Plan small tasks for {{.AgentName}}.\n"); !strings.Contains(out, "unchanged") {
+		t.Errorf("Expected identical content to be skipped, got: %s", out)
+	}
+
+	diff := run(func() error { return promptsDiffCmd.RunE(promptsDiffCmd, []string{"planner"}) })
+	if !strings.Contains(diff, "-Plan for {{.AgentName}}.") || !strings.Contains(diff, "+Plan small tasks") {
+		t.Errorf("Unexpected diff output:\n%s", diff)
+	}
+
+	if out := run(func() error { return promptsRollbackCmd.RunE(promptsRollbackCmd, []string{"planner", "1"}) }); !strings.Contains(out, "as version 3") {
+		t.Errorf("Unexpected rollback output: %s", out)
+	}
+
+	history := run(func() error { return promptsHistoryCmd.RunE(promptsHistoryCmd, []string{"planner"}) })
+	if !strings.Contains(history, "* v3") || !strings.Contains(history, "Rollback to version 1") {
+		t.Errorf("Unexpected history output:\n%s", history)
+	}
+
+	oldRender, oldAgent := promptsRender, promptsAgent
+	defer func() { promptsRender, promptsAgent = oldRender, oldAgent }()
+	promptsRender = true
+	promptsAgent = ""
+	if out := run(func() error { return promptsShowCmd.RunE(promptsShowCmd, []string{"planner@2"}) }); out != "Plan small tasks for .\n" {
+		t.Errorf("Unexpected rendered prompt: %q", out)
+	}
+}
+
+// TestPromptsAddInvalidTemplate tests that broken templates are rejected
+func TestPromptsAddInvalidTemplate(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	file := filepath.Join(env.TempDir, "broken.md")
+	if err := os.WriteFile(file, []byte("Hello {{.AgentName"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := promptsAddCmd.RunE(promptsAddCmd, []string{"broken", file})
+	if err == nil || !strings.Contains(err.Error(), "invalid prompt template") {
+		t.Errorf("Expected an invalid template error, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/budget"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/daemon"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/pipeline"
+	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/spf13/cobra"
+)
+
+// reloadTimeout bounds how long asc reload waits for the running stack to
+// apply asc.toml, which includes the grace periods of the agents it
+// restarts
+const reloadTimeout = 2 * time.Minute
+
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Apply the changes of asc.toml to the running agents",
+	Long: `Ask the running agent stack, of asc up or asc daemon, to reload asc.toml
+and apply the changes to its agents, then report what changed. Agents
+removed from asc.toml are stopped, running agents whose settings changed
+are restarted with them, and new agents are started, as asc up starts
+them. The other agents keep running.
+
+The stack also reloads when asc.toml, a file it includes, or the overlay
+of the profile is saved; asc reload is for file systems that do not
+report changes, such as some network and container mounts. A file that
+fails to load leaves the agents alone. Changes to the services, the phase
+pipeline, budgets, and [logging] take effect at the next asc up.
+
+Example:
+  asc reload`,
+	Args: cobra.NoArgs,
+	RunE: runReload,
+}
+
+func init() {
+	rootCmd.AddCommand(reloadCmd)
+}
+
+func runReload(cmd *cobra.Command, args []string) error {
+	configPath := config.DefaultConfigPath()
+	if _, err := config.LoadContext(commandContext(cmd), configPath); err != nil {
+		return fmt.Errorf("%s was not reloaded: %w", configPath, err)
+	}
+	if !stackRunning() {
+		return fmt.Errorf("no agent stack is running\n  Suggestion: Run 'asc up' or 'asc daemon start', which read %s as they start", configPath)
+	}
+	if dryRun {
+		printDryRun("reload.dry_run.reload", configPath)
+		return nil
+	}
+
+	requestPath, err := config.ReloadRequestPath()
+	if err != nil {
+		return err
+	}
+	statusPath, err := config.ReloadStatusPath()
+	if err != nil {
+		return err
+	}
+	requested := time.Now()
+	if err := config.RequestReload(requestPath); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("reload.reloading", configPath))
+	status, err := waitForReload(commandContext(cmd), statusPath, requested, reloadTimeout)
+	if err != nil {
+		return err
+	}
+
+	if len(status.Errors) > 0 {
+		return fmt.Errorf("reload of %s failed:\n  %s", configPath, strings.Join(status.Errors, "\n  "))
+	}
+	if len(status.Added)+len(status.Removed)+len(status.Updated) == 0 {
+		fmt.Println(i18n.T("reload.unchanged", configPath))
+		return nil
+	}
+	fmt.Println(i18n.T("reload.reloaded", configPath))
+	for _, change := range []struct {
+		label string
+		names []string
+	}{{"reload.added", status.Added}, {"reload.removed", status.Removed}, {"reload.changed", status.Updated}} {
+		if len(change.names) > 0 {
+			fmt.Printf("  %s: %s\n", i18n.T(change.label), strings.Join(change.names, ", "))
+		}
+	}
+	return nil
+}
+
+// stackRunning reports whether asc daemon runs the stack, or asc up runs
+// any of its processes
+func stackRunning() bool {
+	if stateDir, err := statedir.Dir(); err == nil {
+		if _, ok := daemon.Running(stateDir); ok {
+			return true
+		}
+	}
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		return false
+	}
+	infos, err := procManager.ListProcesses()
+	if err != nil {
+		return false
+	}
+	for _, info := range infos {
+		if process.Live(procManager, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForReload waits for the running stack to record a reload at or after
+// requested, and fails if it does not within timeout
+func waitForReload(ctx context.Context, statusPath string, requested time.Time, timeout time.Duration) (*config.ReloadStatus, error) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	for {
+		status, err := config.ReadReloadStatus(statusPath)
+		if err == nil && !status.At.Before(requested) {
+			return status, nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			return nil, fmt.Errorf("the agent stack did not reload within %s\n  Suggestion: See the TUI, or ~/.asc/logs/%s under asc daemon, for what happened", timeout, daemon.LogFileName)
+		case <-ticker.C:
+		}
+	}
+}
+
+// reloadAgents applies newCfg to the agents of a stack running oldCfg, as
+// config.ReloadManager does for the TUI: the agents newCfg removes are
+// stopped, the running ones it changes are restarted, and those it adds
+// are started, all as asc up starts them
+func reloadAgents(ctx context.Context, oldCfg, newCfg *config.Config, procManager *process.Manager, enforcer *budget.Enforcer) *config.ReloadResult {
+	result := config.DiffAgents(oldCfg, newCfg)
+	if !result.Changed() {
+		return result
+	}
+	if err := applyProcessSettings(newCfg, procManager); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+	agents := &agentController{cfg: newCfg, procManager: procManager, enforcer: enforcer}
+
+	for _, name := range result.AgentsRemoved {
+		if err := procManager.StopNamed(ctx, name); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("failed to stop agent %s: %w", name, err))
+		}
+	}
+
+	// Agents are started in the order asc up starts them
+	start := make(map[string]bool)
+	for _, name := range result.AgentsUpdated {
+		if _, ok := process.Running(procManager, name); !ok {
+			continue
+		}
+		if err := procManager.StopNamed(ctx, name); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("failed to stop agent %s for update: %w", name, err))
+			continue
+		}
+		start[name] = true
+	}
+	for _, name := range result.AgentsAdded {
+		if !pipeline.Manages(newCfg.Pipeline, newCfg.Agents[name]) {
+			start[name] = true
+		}
+	}
+	order, err := newCfg.StartOrder()
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return result
+	}
+	for _, name := range order {
+		if !start[name] {
+			continue
+		}
+		if err := agents.StartAgent(name); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("failed to start agent %s: %w", name, err))
+		}
+	}
+	return result
+}
+
+// reloadStartAgent returns how a config reload of the TUI starts agents:
+// as asc up does, with the process settings of the reloaded asc.toml. New
+// agents the phase pipeline manages start with their phase instead.
+func reloadStartAgent(procManager *process.Manager, orch *pipeline.Orchestrator, enforcer *budget.Enforcer) func(name string, cfg *config.Config) error {
+	return func(name string, cfg *config.Config) error {
+		if pipeline.Manages(cfg.Pipeline, cfg.Agents[name]) && (orch == nil || !orch.IsAgentActive(name)) {
+			return nil
+		}
+		if err := applyProcessSettings(cfg, procManager); err != nil {
+			return err
+		}
+		agents := &agentController{cfg: cfg, procManager: procManager, enforcer: enforcer}
+		return agents.StartAgent(name)
+	}
+}
+
+// recordReload records the outcome of a reload for asc reload to report
+func recordReload(result *config.ReloadResult, err error) {
+	path, pathErr := config.ReloadStatusPath()
+	if pathErr != nil {
+		return
+	}
+	if recordErr := config.RecordReload(path, result, err); recordErr != nil {
+		logger.Warn("Failed to record config reload: %v", recordErr)
+	}
+}
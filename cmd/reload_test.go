@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/process"
+)
+
+const reloadConfig = `[core]
+beads_db_path = "./project-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+
+[agent.keep]
+command = "sleep 30"
+model = "claude"
+phases = ["planning"]
+
+[agent.change]
+command = "sleep 30"
+model = "claude"
+phases = ["implementation"]
+
+[agent.drop]
+command = "sleep 30"
+model = "claude"
+phases = ["testing"]
+`
+
+func TestReloadAgents(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Chdir(env.TempDir)
+	env.WriteConfig(reloadConfig)
+	oldCfg, err := config.Load(env.ConfigPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { procManager.StopAll(context.Background()) })
+
+	before := make(map[string]int)
+	for _, name := range []string{"keep", "change", "drop"} {
+		pid, err := startAgent(name, oldCfg.Agents[name], oldCfg, procManager)
+		if err != nil {
+			t.Fatalf("startAgent(%s) error = %v", name, err)
+		}
+		before[name] = pid
+	}
+
+	edited := strings.Replace(reloadConfig, "model = \"claude\"\nphases = [\"implementation\"]", "model = \"gemini\"\nphases = [\"implementation\"]", 1)
+	edited = strings.Replace(edited, "[agent.drop]", "[agent.fresh]", 1)
+	env.WriteConfig(edited)
+	newCfg, err := config.Load(env.ConfigPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	result := reloadAgents(context.Background(), oldCfg, newCfg, procManager, nil)
+	if len(result.Errors) > 0 {
+		t.Fatalf("reloadAgents() errors = %v", result.Errors)
+	}
+	if !slices.Equal(result.AgentsAdded, []string{"fresh"}) || !slices.Equal(result.AgentsRemoved, []string{"drop"}) || !slices.Equal(result.AgentsUpdated, []string{"change"}) {
+		t.Errorf("reloadAgents() = %+v", result)
+	}
+
+	if pid, ok := process.Running(procManager, "keep"); !ok || pid != before["keep"] {
+		t.Errorf("Expected keep left running as PID %d, got %d", before["keep"], pid)
+	}
+	if pid, ok := process.Running(procManager, "change"); !ok || pid == before["change"] {
+		t.Errorf("Expected change restarted, got PID %d (was %d)", pid, before["change"])
+	}
+	if _, ok := process.Running(procManager, "drop"); ok {
+		t.Error("Expected drop stopped")
+	}
+	if _, ok := process.Running(procManager, "fresh"); !ok {
+		t.Error("Expected fresh started")
+	}
+}
+
+func TestWaitForReload(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	statusPath, err := config.ReloadStatusPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A reload recorded before the request is not its outcome
+	if err := config.RecordReload(statusPath, &config.ReloadResult{AgentsAdded: []string{"old"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	requested := time.Now()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		config.RecordReload(statusPath, &config.ReloadResult{AgentsUpdated: []string{"coder"}}, nil)
+	}()
+	status, err := waitForReload(context.Background(), statusPath, requested, 5*time.Second)
+	if err != nil {
+		t.Fatalf("waitForReload() error = %v", err)
+	}
+	if !slices.Equal(status.Updated, []string{"coder"}) {
+		t.Errorf("waitForReload() = %+v", status)
+	}
+
+	if _, err := waitForReload(context.Background(), statusPath, time.Now().Add(time.Hour), 300*time.Millisecond); err == nil {
+		t.Error("Expected waiting for a reload nothing records to time out")
+	}
+}
+
+func TestReloadCommand_NoStack(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Chdir(env.TempDir)
+	env.WriteConfig(reloadConfig)
+
+	if err := runReload(reloadCmd, nil); err == nil || !strings.Contains(err.Error(), "no agent stack is running") {
+		t.Errorf("Expected reloading without a stack to fail, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/readiness"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restartAll     bool
+	restartRolling bool
+)
+
+var restartCmd = &cobra.Command{
+	Use:   "restart [agent...]",
+	Short: "Restart agents without restarting the stack",
+	Long: `Stop the named agents and start them again as asc up starts them, with
+their current asc.toml settings, leaving the rest of the stack running. An
+MCP server of asc.toml is restarted by its name, and every instance of an
+agent with replicas by the agent's; --all restarts every running agent.
+
+By default the agents are all stopped, then started again in the order
+asc up starts them. With --rolling they are restarted one at a time, each
+once the one before it passes its ready_check, so the others keep working
+and keep their leases meanwhile. An agent without a ready_check is ready
+once started.
+
+Example:
+  asc restart main-coder              # Restart one agent
+  asc restart --all --rolling         # Restart every agent, one at a time`,
+	RunE: runRestart,
+}
+
+func init() {
+	rootCmd.AddCommand(restartCmd)
+	restartCmd.Flags().BoolVar(&restartAll, "all", false, "Restart every running agent")
+	restartCmd.Flags().BoolVar(&restartRolling, "rolling", false, "Restart one at a time, waiting for each to be ready")
+}
+
+func runRestart(cmd *cobra.Command, args []string) error {
+	switch {
+	case restartAll && len(args) > 0:
+		return fmt.Errorf("--all restarts every agent; do not name agents too")
+	case !restartAll && len(args) == 0:
+		return fmt.Errorf("no agents named\n  Suggestion: Name the agents to restart, or use --all")
+	}
+
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize process manager: %w", err)
+	}
+	names, err := restartTargets(cfg, procManager, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println(i18n.T("restart.none"))
+		return nil
+	}
+
+	if dryRun {
+		for _, name := range names {
+			printDryRun("restart.dry_run.restart", name)
+			if check, ok := restartReadyCheck(cfg, name); ok && restartRolling {
+				printDryRun("up.dry_run.wait_ready", name, check)
+			}
+		}
+		return nil
+	}
+
+	ctx := commandContext(cmd)
+	if err := loadStackSecrets(ctx, cfg, config.DefaultEnvPath()); err != nil {
+		return err
+	}
+	setResourceLimits(cfg, procManager)
+	setStopPolicies(cfg, procManager)
+	setStdin(cfg, procManager)
+	setLaunch(cfg, procManager)
+	setLogRotation(cfg, procManager)
+	setCrashCapture(cfg, procManager)
+
+	if restartRolling {
+		for _, name := range names {
+			if err := restartProcess(ctx, cfg, procManager, name); err != nil {
+				return err
+			}
+			if err := waitRestarted(ctx, cfg, procManager, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, name := range names {
+		fmt.Println(i18n.T("restart.stopping", name))
+		if err := procManager.StopNamed(ctx, name); err != nil {
+			return fmt.Errorf("failed to stop %s: %w", name, err)
+		}
+	}
+	for _, name := range names {
+		if err := startRestarted(cfg, procManager, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restartTargets returns the processes asc restart restarts, in the order
+// asc up starts them: the MCP servers and agents named, with the instances
+// of an agent with replicas, or every running agent for none
+func restartTargets(cfg *config.Config, procManager process.ProcessManager, names []string) ([]string, error) {
+	order, err := cfg.StartOrder()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		var running []string
+		for _, name := range order {
+			if _, ok := process.Running(procManager, name); ok {
+				running = append(running, name)
+			}
+		}
+		return running, nil
+	}
+
+	named := make(map[string]bool)
+	for _, name := range names {
+		if _, ok := cfg.Roles[name]; ok {
+			for _, instance := range cfg.Instances(name) {
+				named[instance] = true
+			}
+			continue
+		}
+		_, isAgent := cfg.Agents[name]
+		server, isServer := cfg.Services.MCPServer(name)
+		switch {
+		case !isAgent && !isServer:
+			return nil, fmt.Errorf("no agent or service named '%s' in asc.toml\n  Suggestion: Use one of: %s", name, strings.Join(execTargets(cfg), ", "))
+		case isServer && server.StartCommand == "":
+			return nil, fmt.Errorf("%s is not started by asc\n  Suggestion: Set start_command in [services.%s] for asc to manage it", name, name)
+		}
+		named[name] = true
+	}
+	var targets []string
+	for _, name := range append(managedMCPServers(cfg), order...) {
+		if named[name] {
+			targets = append(targets, name)
+		}
+	}
+	return targets, nil
+}
+
+// restartProcess stops the named agent or MCP server and starts it again
+func restartProcess(ctx context.Context, cfg *config.Config, procManager *process.Manager, name string) error {
+	fmt.Println(i18n.T("restart.restarting", name))
+	if err := procManager.StopNamed(ctx, name); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", name, err)
+	}
+	return startRestarted(cfg, procManager, name)
+}
+
+// startRestarted starts the named agent or MCP server as asc up does. A
+// process the stack's own restart policy started again meanwhile is kept.
+func startRestarted(cfg *config.Config, procManager *process.Manager, name string) error {
+	var pid int
+	var err error
+	if mcpCfg, ok := cfg.Services.MCPServer(name); ok {
+		mcpCmd, mcpArgs := parseCommand(mcpCfg.StartCommand)
+		pid, err = procManager.Start(name, mcpCmd, mcpArgs, buildMCPEnv())
+	} else {
+		pid, err = startAgent(name, cfg.Agents[name], cfg, procManager)
+	}
+	if err != nil {
+		if running, ok := process.Running(procManager, name); ok {
+			logger.WithFields(logger.Fields{"process": name, "pid": running}).Info("Process was started again by another asc")
+			pid, err = running, nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start %s: %w\n  Suggestion: Check ~/.asc/logs/%s.log", name, err, name)
+	}
+	fmt.Println(i18n.T("restart.started", name, pid))
+	return nil
+}
+
+// waitRestarted waits for the named agent or MCP server to pass its ready
+// check
+func waitRestarted(ctx context.Context, cfg *config.Config, procManager *process.Manager, name string) error {
+	check, ok := restartReadyCheck(cfg, name)
+	if !ok {
+		return nil
+	}
+	fmt.Println(i18n.T("up.service_waiting", name, check))
+	if err := waitReady(ctx, procManager, name, check); err != nil {
+		return fmt.Errorf("%s did not become ready: %w\n  Suggestion: Check ~/.asc/logs/%s.log; the agents after it were not restarted", name, err, name)
+	}
+	fmt.Println(i18n.T("up.service_ready", name))
+	return nil
+}
+
+// restartReadyCheck returns how to tell the named agent or MCP server is
+// ready; ok is false for an agent without a ready_check
+func restartReadyCheck(cfg *config.Config, name string) (check readiness.Check, ok bool) {
+	if mcpCfg, ok := cfg.Services.MCPServer(name); ok {
+		return mcpReadyCheck(mcpCfg), true
+	}
+	if agentCfg := cfg.Agents[name]; agentCfg.ReadyCheck.IsSet() {
+		return readyCheck(agentCfg.ReadyCheck), true
+	}
+	return readiness.Check{}, false
+}
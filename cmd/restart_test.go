@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/process"
+)
+
+// restartConfig has an agent that logs when it is ready and one that
+// depends on it
+const restartConfig = `[core]
+beads_db_path = "./project-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+
+[services.mcp_remote]
+url = "https://mail.example.com"
+
+[agent.first]
+command = "sh -c 'echo ready; sleep 30'"
+model = "claude"
+phases = ["planning"]
+
+[agent.first.ready_check]
+log_regex = "ready"
+timeout = "5s"
+
+[agent.second]
+command = "sleep 30"
+model = "claude"
+phases = ["planning"]
+depends_on = ["first"]
+`
+
+func TestRestartTargets(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Chdir(env.TempDir)
+	env.WriteConfig(restartConfig)
+	cfg, err := config.Load(env.ConfigPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Named agents are restarted in the order asc up starts them
+	if got, err := restartTargets(cfg, procManager, []string{"second", "first"}); err != nil || !slices.Equal(got, []string{"first", "second"}) {
+		t.Errorf("restartTargets() = %q, %v", got, err)
+	}
+	if _, err := restartTargets(cfg, procManager, []string{"ghost"}); err == nil || !strings.Contains(err.Error(), "no agent or service named 'ghost'") {
+		t.Errorf("Expected an unknown name to fail, got %v", err)
+	}
+	if _, err := restartTargets(cfg, procManager, []string{"mcp_remote"}); err == nil || !strings.Contains(err.Error(), "not started by asc") {
+		t.Errorf("Expected an MCP server asc does not start to fail, got %v", err)
+	}
+
+	// --all restarts only what runs
+	if got, err := restartTargets(cfg, procManager, nil); err != nil || len(got) != 0 {
+		t.Errorf("restartTargets() = %q, %v, want no running agents", got, err)
+	}
+}
+
+func TestRestartCommand_Rolling(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Chdir(env.TempDir)
+	env.WriteConfig(restartConfig)
+	cfg, err := config.Load(env.ConfigPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		procManager.StopNamed(context.Background(), "first")
+		procManager.StopNamed(context.Background(), "second")
+	})
+
+	before := make(map[string]int)
+	for _, name := range []string{"first", "second"} {
+		pid, err := startAgent(name, cfg.Agents[name], cfg, procManager)
+		if err != nil {
+			t.Fatalf("startAgent(%s) error = %v", name, err)
+		}
+		before[name] = pid
+	}
+
+	oldAll, oldRolling := restartAll, restartRolling
+	restartAll, restartRolling = true, true
+	t.Cleanup(func() { restartAll, restartRolling = oldAll, oldRolling })
+
+	// A dry run lists the restarts and restarts nothing
+	dryRun = true
+	if err := rootCmd.PersistentPreRunE(restartCmd, nil); err != nil {
+		dryRun = false
+		t.Fatalf("Expected asc restart to accept --dry-run, got %v", err)
+	}
+	capture := NewCaptureOutput()
+	capture.Start()
+	err = runRestart(restartCmd, nil)
+	capture.Stop()
+	dryRun = false
+	if err != nil {
+		t.Fatalf("runRestart() error = %v", err)
+	}
+	if out := capture.GetStdout(); !strings.Contains(out, "Dry run: would restart first") || !strings.Contains(out, "Dry run: would wait until first is ready") {
+		t.Errorf("Expected a dry run, got:\n%s", out)
+	}
+	if pid, _ := process.Running(procManager, "first"); pid != before["first"] {
+		t.Errorf("Expected the dry run to leave first running as %d, got %d", before["first"], pid)
+	}
+
+	capture = NewCaptureOutput()
+	capture.Start()
+	err = runRestart(restartCmd, nil)
+	capture.Stop()
+	if err != nil {
+		t.Fatalf("runRestart() error = %v", err)
+	}
+
+	for _, name := range []string{"first", "second"} {
+		pid, ok := process.Running(procManager, name)
+		if !ok || pid == before[name] {
+			t.Errorf("Expected %s running under a new PID, got %d (was %d)", name, pid, before[name])
+		}
+	}
+	out := capture.GetStdout()
+	if ready, second := strings.Index(out, "✓ first ready"), strings.Index(out, "Restarting second"); ready < 0 || second < ready {
+		t.Errorf("Expected second restarted once first was ready, got:\n%s", out)
+	}
+}
+
+func TestRestartCommand_NoTargets(t *testing.T) {
+	oldAll := restartAll
+	restartAll = false
+	t.Cleanup(func() { restartAll = oldAll })
+
+	if err := runRestart(restartCmd, nil); err == nil || !strings.Contains(err.Error(), "--all") {
+		t.Errorf("Expected restarting nothing to suggest --all, got %v", err)
+	}
+}
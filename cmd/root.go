@@ -1,37 +1,515 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	_ "github.com/charmbracelet/bubbles"
 	_ "github.com/charmbracelet/bubbletea"
 	_ "github.com/charmbracelet/lipgloss"
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+	ascerrors "github.com/rand/asc/internal/errors"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/mcp"
+	"github.com/rand/asc/internal/migrate"
+	"github.com/rand/asc/internal/proxy"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/upgrade"
 	"github.com/spf13/cobra"
 	_ "github.com/spf13/viper"
-	"github.com/rand/asc/internal/logger"
 )
 
 var (
-	verbose bool
+	verbosity int
+	logLevel  string
+	language  string
+	dryRun    bool
+	profile   string
+	cfgFile   string
 )
 
+// dryRunCommands are the state-changing commands (see auditedCommands)
+// that honor --dry-run by printing the actions they would take instead of
+// taking them. The others refuse to run with it.
+var dryRunCommands = map[string]bool{
+	"up":                     true,
+	"down":                   true,
+	"budget resume":          true,
+	"backup create":          true,
+	"backup restore":         true,
+	"check":                  true,
+	"cleanup":                true,
+	"config migrate":         true,
+	"config set":             true,
+	"doctor":                 true,
+	"daemon start":           true,
+	"daemon stop":            true,
+	"secrets init":           true,
+	"secrets encrypt":        true,
+	"secrets decrypt":        true,
+	"secrets rotate":         true,
+	"secrets inject":         true,
+	"pipeline advance":       true,
+	"pipeline reset":         true,
+	"prompts add":            true,
+	"prompts rollback":       true,
+	"reload":                 true,
+	"restart":                true,
+	"scale":                  true,
+	"services start":         true,
+	"services stop":          true,
+	"sync github":            true,
+	"sync jira":              true,
+	"tasks bulk":             true,
+	"tasks template create":  true,
+	"tasks template apply":   true,
+	"tasks template delete":  true,
+	"tasks template run-due": true,
+	"worktree merge":         true,
+	"worktree prune":         true,
+	"upgrade":                true,
+}
+
+// logLevelEnvVar is the environment variable that sets the default log level
+const logLevelEnvVar = "ASC_LOG_LEVEL"
+
 var rootCmd = &cobra.Command{
 	Use:   "asc",
 	Short: "Agent Stack Controller - Orchestrate your AI coding agent colony",
 	Long: `asc is a command-line orchestration tool that manages a local colony of AI coding agents.
 It provides developers with a mission control interface for starting, monitoring, and 
 coordinating headless background agents that work collaboratively on software development tasks.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		if verbose {
-			logger.SetLevel(logger.DEBUG)
+	Version: upgrade.Current(),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Mask API keys already present in the environment
+		logger.RegisterSecretsFromEnv()
+
+		// Every invocation is traced under its own correlation ID, and
+		// as a span of its own
+		logger.StartCorrelation()
+		beginTrace(cmd)
+
+		// --lang overrides ASC_LANG and the locale
+		lang := language
+		if lang == "" {
+			lang = i18n.Detect()
+		}
+		if err := i18n.SetLanguage(lang); err != nil {
+			return fmt.Errorf("invalid --lang: %w", err)
+		}
+
+		level, ok, err := resolveLogLevel("")
+		if err != nil {
+			return err
 		}
+		if ok {
+			logger.SetLevel(level)
+		}
+		if levels := componentLevels(nil); len(levels) > 0 {
+			logger.SetComponentLevels(levels)
+		}
+
+		// --profile overrides ASC_PROFILE, and is passed on to the
+		// processes asc starts through it
+		if profile != "" {
+			if err := config.ValidateProfile(profile); err != nil {
+				return err
+			}
+			os.Setenv(config.ProfileEnvVar, profile)
+		}
+
+		if dryRun && isAudited(cmd) && !dryRunCommands[auditAction(cmd)] {
+			return fmt.Errorf("asc %s does not support --dry-run\n  Suggestion: Run it without --dry-run, or see 'asc %s --help' for what it changes", auditAction(cmd), auditAction(cmd))
+		}
+
+		if err := migrateState(cmd); err != nil {
+			return err
+		}
+		if err := resolveConfig(cmd); err != nil {
+			return err
+		}
+		migrateConfig(cmd)
+
+		beginAudit(cmd, args)
+		recordEvents()
+		return nil
 	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		finishAudit(nil)
+		finishTrace(nil)
+	},
+}
+
+// skipMigrationAnnotation marks commands that run against state of any
+// schema version, such as asc doctor, which reports one it cannot read
+const skipMigrationAnnotation = "asc.skip-migration"
+
+// migrateState brings the state directory up to the schema of this
+// release before the command reads it, or under --dry-run reports that it
+// would. An unusable state directory is left for the command itself to
+// report.
+func migrateState(cmd *cobra.Command) error {
+	if cmd.Annotations[skipMigrationAnnotation] != "" {
+		return nil
+	}
+	dir, err := statedir.Dir()
+	if err != nil {
+		return nil
+	}
+
+	if dryRun {
+		version, err := migrate.Check(dir)
+		if err != nil {
+			return err
+		}
+		if version < migrate.CurrentVersion {
+			printDryRun("root.dry_run.migrate_state", dir, version, migrate.CurrentVersion)
+		}
+		return nil
+	}
+
+	result, err := migrate.Run(dir)
+	if err != nil {
+		return err
+	}
+	if result.Migrated() {
+		logger.Info("Migrated state directory %s from schema %d to %d, backup in %s", dir, result.From, result.To, result.Backup)
+		fmt.Fprintln(os.Stderr, i18n.T("root.migrated_state", dir, result.Backup))
+	}
+	return nil
+}
+
+// resolveConfig points config.DefaultConfigPath at the file --config, or
+// ASC_CONFIG, names. A URL is fetched into the cache of shared
+// configurations, and the cached copy is read, so the processes asc starts
+// read the same file.
+func resolveConfig(cmd *cobra.Command) error {
+	source := cfgFile
+	if source == "" {
+		source = os.Getenv(config.ConfigEnvVar)
+	}
+	if !config.IsRemoteSource(source) {
+		if cfgFile != "" {
+			os.Setenv(config.ConfigEnvVar, cfgFile)
+		}
+		return nil
+	}
+
+	remote, err := config.FetchSource(commandContext(cmd), config.SourceConfig{URL: source, PublicKey: os.Getenv(config.ConfigKeyEnvVar)})
+	if err != nil {
+		return err
+	}
+	if remote.Stale != nil {
+		logger.Warn("Failed to refresh %s, using the copy fetched %s: %v", source, remote.FetchedAt.Format(time.RFC3339), remote.Stale)
+		fmt.Fprintln(os.Stderr, i18n.T("root.source_stale", source, remote.FetchedAt.Format(time.RFC3339)))
+	}
+	os.Setenv(config.ConfigEnvVar, remote.Path)
+	return nil
+}
+
+// migrateConfig upgrades asc.toml to the layout of this release before
+// the command reads it, or under --dry-run reports that it would. A file
+// that fails to migrate is left for config.Load to report, with a hint to
+// run asc config migrate, which explains why.
+func migrateConfig(cmd *cobra.Command) {
+	if cmd == configMigrateCmd {
+		return
+	}
+	path := config.DefaultConfigPath()
+	if config.IsCachedSource(path) {
+		return // Shared configurations are migrated at their source
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	if dryRun {
+		if _, result, err := config.MigrateConfig(data); err == nil && result.Migrated() {
+			printDryRun("config.dry_run.migrate", path, result.From, config.CurrentConfigVersion)
+		}
+		return
+	}
+
+	result, err := config.MigrateConfigFile(path)
+	if err != nil {
+		logger.Debug("Did not migrate %s: %v", path, err)
+		return
+	}
+	if result.Migrated() {
+		logger.Info("Migrated %s from config version %d to %d, backup in %s", path, result.From, result.To, result.Backup)
+		fmt.Fprintln(os.Stderr, i18n.T("root.migrated_config", path, result.Backup))
+	}
 }
 
-// Execute runs the root command
+// printDryRun prints an action a command would take, for --dry-run, as
+// the message of key in the catalog formatted with args. An action
+// described elsewhere is printed with the key dry_run.action.
+func printDryRun(key string, args ...interface{}) {
+	fprintDryRun(os.Stdout, key, args...)
+}
+
+// fprintDryRun is printDryRun writing to w
+func fprintDryRun(w io.Writer, key string, args ...interface{}) {
+	fmt.Fprintln(w, i18n.T("dry_run", i18n.T(key, args...)))
+}
+
+// Execute runs the root command. The first Ctrl-C or SIGTERM cancels the
+// command's context, so in-flight bd, git, and HTTP calls are abandoned and
+// processes being stopped skip their grace period; a second one exits
+// immediately.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop() // Restore the default handlers for the second signal
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	// Commands that return an error skip PersistentPostRun
+	finishAudit(err)
+	finishTrace(err)
+	return err
+}
+
+// commandContext returns the context Execute cancels on interrupt, or a
+// background context for commands run directly, as tests do
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// resolveLogLevel determines the log level from, in order of precedence,
+// the --log-level flag, the -v/-vv shorthand, the global level of the
+// ASC_LOG_LEVEL environment variable, and the given config value. ok is
+// false when none of them is set.
+func resolveLogLevel(configLevel string) (level logger.LogLevel, ok bool, err error) {
+	if logLevel != "" {
+		level, err = logger.ParseLevel(logLevel)
+		if err != nil {
+			return level, false, fmt.Errorf("invalid --log-level: %w", err)
+		}
+		return level, true, nil
+	}
+
+	switch {
+	case verbosity >= 2:
+		return logger.TRACE, true, nil
+	case verbosity == 1:
+		return logger.DEBUG, true, nil
+	}
+
+	if env := os.Getenv(logLevelEnvVar); env != "" {
+		level, hasLevel, _, err := logger.ParseLevelSpec(env)
+		if err != nil {
+			return level, false, fmt.Errorf("invalid %s: %w", logLevelEnvVar, err)
+		}
+		if hasLevel {
+			return level, true, nil
+		}
+	}
+
+	if configLevel != "" {
+		level, err = logger.ParseLevel(configLevel)
+		if err != nil {
+			return level, false, err
+		}
+		return level, true, nil
+	}
+
+	return logger.INFO, false, nil
+}
+
+// componentLevels returns the per-component levels of the ASC_LOG_LEVEL
+// environment variable, e.g. "info,mcp=debug", over the given config
+// overrides. An invalid value is reported by resolveLogLevel and ignored
+// here.
+func componentLevels(configLevels map[string]string) map[string]logger.LogLevel {
+	levels := make(map[string]logger.LogLevel, len(configLevels))
+	for component, name := range configLevels {
+		if level, err := logger.ParseLevel(name); err == nil {
+			levels[component] = level
+		}
+	}
+	if _, _, envLevels, err := logger.ParseLevelSpec(os.Getenv(logLevelEnvVar)); err == nil {
+		for component, level := range envLevels {
+			levels[component] = level
+		}
+	}
+	return levels
+}
+
+// stderrSink writes log records to stderr when [logging] stderr is set. It
+// is registered once and reconfigured by later configs.
+var stderrSink *logger.WriterSink
+
+// muteStderrLog stops or resumes log records on stderr, e.g. while the TUI
+// owns the terminal
+func muteStderrLog(muted bool) {
+	if stderrSink != nil {
+		stderrSink.SetMuted(muted)
+	}
+}
+
+// applyLoggingConfig applies the [logging] section of a loaded configuration
+// to the default logger, including any syslog or journald sinks. The config
+// level only takes effect when neither a flag nor ASC_LOG_LEVEL was given.
+// Invalid values are rejected by config validation, so parse errors here
+// leave the current settings unchanged.
+func applyLoggingConfig(cfg *config.Config) {
+	if format, err := logger.ParseFormat(cfg.Logging.Format); err == nil {
+		logger.SetFormat(format)
+	}
+	if level, ok, err := resolveLogLevel(cfg.Logging.Level); err == nil && ok {
+		logger.SetLevel(level)
+	}
+	if levels := componentLevels(cfg.Logging.Levels); len(levels) > 0 {
+		logger.SetComponentLevels(levels)
+	}
+	if cfg.Logging.Stderr != "" {
+		format, _ := logger.ParseFormat(cfg.Logging.Format)
+		level, _ := logger.ParseLevel(cfg.Logging.Stderr)
+		if stderrSink == nil {
+			stderrSink = logger.NewStderrSink(format, level)
+			logger.AddSink(stderrSink)
+		} else {
+			stderrSink.Configure(format, level)
+		}
+	}
+
+	// Optional sinks; failures are reported but never block the command
+	if cfg.Logging.Syslog.Enabled {
+		sink, err := logger.NewSyslogSink(cfg.Logging.Syslog.Network, cfg.Logging.Syslog.Address, cfg.Logging.Syslog.Tag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("root.syslog_disabled", err))
+		} else {
+			logger.AddSink(sink)
+		}
+	}
+	if cfg.Logging.Journald.Enabled {
+		sink, err := logger.NewJournaldSink(cfg.Logging.Journald.Identifier)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("root.journald_disabled", err))
+		} else {
+			logger.AddSink(sink)
+		}
+	}
+}
+
+// printError reports a failed step on stderr: what went wrong and, when
+// known, how to fix it, rather than the raw error chain
+func printError(step string, err error) {
+	fmt.Fprint(os.Stderr, ascerrors.FormatCLI(fmt.Errorf("%s: %w", step, err)))
+}
+
+// newMCPClient creates the MCP client for a loaded configuration: a
+// router over mcp_agent_mail and the other [services.mcp_*] servers,
+// sending each agent's requests to the server of its mcp_server. It fails
+// if the TLS files of a server cannot be loaded.
+func newMCPClient(cfg *config.Config) (*mcp.Router, error) {
+	var servers []mcp.Server
+	for _, name := range cfg.Services.MCPServerNames() {
+		mcpCfg, _ := cfg.Services.MCPServer(name)
+		client, err := newMCPServerClient(name, mcpCfg)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, mcp.Server{Name: name, Client: client})
+	}
+	return mcp.NewRouter(servers, cfg.MCPRoutes()), nil
+}
+
+// newMCPServerClient creates the client of the MCP server of the
+// [services.<name>] section, over HTTP or gRPC as its protocol says,
+// honoring its proxy override, timeouts, retries, circuit breaker, TLS
+// files, bearer token, compression, batching, and the queue of messages
+// sent while the server is unavailable, kept in ~/.asc/mcp. It fails if
+// the TLS files cannot be loaded.
+func newMCPServerClient(name string, mcpCfg config.MCPConfig) (mcp.MCPClient, error) {
+	settings, err := proxy.ForService(mcpCfg.Proxy)
+	if err != nil {
+		// Rejected by config validation; fall back to the environment
+		settings = proxy.FromEnvironment()
+	}
+	tlsConfig, err := mcp.NewTLSConfig(mcpCfg.CAFile, mcpCfg.CertFile, mcpCfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("services.%s: %w", name, err)
+	}
+	token := mcpCfg.Token()
+	logger.RegisterSecret(token)
+	var outbox *mcp.Outbox
+	if size := mcpCfg.OutboxSize(); size > 0 {
+		path, err := mcp.DefaultOutboxPath()
+		if name != config.DefaultMCPServer {
+			path, err = mcp.ServerOutboxPath(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		outbox = mcp.NewOutbox(path, size)
+	}
+	threshold := mcpCfg.BreakerFailures()
+	if threshold == 0 {
+		threshold = -1 // Disabled
+	}
+	opts := mcp.Options{
+		ConnectTimeout:   mcpCfg.ConnectTimeout,
+		ReadTimeout:      mcpCfg.ReadTimeout,
+		MaxRetries:       mcpCfg.Retries(),
+		RetryBackoff:     mcpCfg.RetryBackoff,
+		MaxRetryBackoff:  mcpCfg.MaxRetryBackoff,
+		Proxy:            settings,
+		TLS:              tlsConfig,
+		Token:            token,
+		Outbox:           outbox,
+		BreakerThreshold: threshold,
+		BreakerCooldown:  mcpCfg.BreakerCooldown,
+		Compression:      mcpCfg.Compression,
+		BatchWindow:      mcpCfg.BatchWindow,
+		BatchSize:        mcpCfg.BatchSize,
+	}
+	if mcpCfg.Protocol == config.ProtocolGRPC {
+		client, err := mcp.NewGRPCClient(mcpCfg.URL, opts)
+		if err != nil {
+			return nil, fmt.Errorf("services.%s: %w", name, err)
+		}
+		return client, nil
+	}
+	return mcp.NewHTTPClientWithOptions(mcpCfg.URL, opts), nil
+}
+
+// newBeadsClient creates the beads client of core.beads_mode for a loaded
+// configuration, refreshing every 5 seconds and recording the history of
+// tasks in ~/.asc/history
+func newBeadsClient(cfg *config.Config) (beads.BeadsClient, error) {
+	client, err := beads.New(cfg.Core.BeadsDBPath, 5*time.Second, cfg.Core.BeadsMode)
+	if err != nil {
+		return nil, err
+	}
+	if historyClient, ok := client.(beads.HistoryClient); ok {
+		if history, err := beads.DefaultHistory(); err == nil {
+			historyClient.SetHistory(history)
+		}
+	}
+	return client, nil
 }
 
 func init() {
 	// Global flags
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging (debug level)")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase log verbosity (-v for debug, -vv for trace)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Log level: trace, debug, info, warn, error (overrides ASC_LOG_LEVEL and [logging] level)")
+	rootCmd.PersistentFlags().StringVar(&language, "lang", "", "Language of output: de, en, es (overrides ASC_LANG and the locale)")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the actions a command would take without taking them")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Configuration profile, e.g. prod (overrides ASC_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Configuration file, or https:// or git+ URL of a shared one (default asc.toml; overrides ASC_CONFIG)")
 }
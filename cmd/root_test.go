@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/spf13/cobra"
+)
+
+// TestResolveLogLevel tests the precedence of the log level sources
+func TestResolveLogLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		flag        string
+		verbosity   int
+		env         string
+		configLevel string
+		want        logger.LogLevel
+		wantOK      bool
+		wantErr     bool
+	}{
+		{name: "nothing set", want: logger.INFO, wantOK: false},
+		{name: "config only", configLevel: "warn", want: logger.WARN, wantOK: true},
+		{name: "env overrides config", env: "error", configLevel: "warn", want: logger.ERROR, wantOK: true},
+		{name: "-v overrides env", verbosity: 1, env: "error", want: logger.DEBUG, wantOK: true},
+		{name: "-vv selects trace", verbosity: 2, want: logger.TRACE, wantOK: true},
+		{name: "flag overrides everything", flag: "warn", verbosity: 2, env: "debug", configLevel: "error", want: logger.WARN, wantOK: true},
+		{name: "invalid flag", flag: "loud", wantErr: true},
+		{name: "env component levels only", env: "mcp=debug", configLevel: "warn", want: logger.WARN, wantOK: true},
+		{name: "env level with components", env: "error,mcp=debug", configLevel: "warn", want: logger.ERROR, wantOK: true},
+		{name: "invalid env", env: "loud", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origLevel, origVerbosity := logLevel, verbosity
+			defer func() { logLevel, verbosity = origLevel, origVerbosity }()
+
+			logLevel = tt.flag
+			verbosity = tt.verbosity
+			t.Setenv(logLevelEnvVar, tt.env)
+
+			got, ok, err := resolveLogLevel(tt.configLevel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveLogLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolveLogLevel() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestComponentLevels tests that ASC_LOG_LEVEL component levels override
+// [logging.levels]
+func TestComponentLevels(t *testing.T) {
+	t.Setenv(logLevelEnvVar, "info,mcp=trace")
+	got := componentLevels(map[string]string{"mcp": "warn", "process": "debug", "beads": "loud"})
+	want := map[string]logger.LogLevel{"mcp": logger.TRACE, "process": logger.DEBUG}
+	if len(got) != len(want) || got["mcp"] != want["mcp"] || got["process"] != want["process"] {
+		t.Errorf("componentLevels() = %v, want %v", got, want)
+	}
+}
+
+// TestDryRunUnsupported tests that state-changing commands without a dry
+// run refuse --dry-run instead of changing anything
+func TestDryRunUnsupported(t *testing.T) {
+	t.Setenv(statedir.EnvVar, t.TempDir())
+	dryRun = true
+	defer func() { dryRun = false }()
+
+	err := rootCmd.PersistentPreRunE(initCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "asc init does not support --dry-run") {
+		t.Errorf("Expected asc init to refuse --dry-run, got %v", err)
+	}
+	for _, cmd := range []*cobra.Command{downCmd, restartCmd, scaleCmd, tasksBulkCmd, syncGitHubCmd, syncJiraCmd,
+		tasksTemplateCreateCmd, tasksTemplateApplyCmd, tasksTemplateDeleteCmd, tasksTemplateRunDueCmd} {
+		if err := rootCmd.PersistentPreRunE(cmd, nil); err != nil {
+			t.Errorf("Expected asc %s to accept --dry-run, got %v", auditAction(cmd), err)
+		}
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	t.Setenv(statedir.EnvVar, t.TempDir())
+	t.Setenv(config.ConfigEnvVar, "")
+
+	cfgFile = "stacks/prod.toml"
+	defer func() { cfgFile = "" }()
+	if err := resolveConfig(configGetCmd); err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+	if path := config.DefaultConfigPath(); path != "stacks/prod.toml" {
+		t.Errorf("Expected --config to name the configuration file, got %s", path)
+	}
+
+	cfgFile = "http://127.0.0.1:1/asc.toml"
+	if err := resolveConfig(configGetCmd); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("Expected an unsigned HTTP source to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/pipeline"
+	"github.com/rand/asc/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var scaleCmd = &cobra.Command{
+	Use:   "scale <agent>=<replicas>...",
+	Short: "Change how many instances of an agent run",
+	Long: `Set the number of instances of an agent with replicas in asc.toml, and
+start or stop instances to match, leaving the rest of the stack running.
+The instances of [agent.coder] are named coder-1 to coder-N; each is told
+its role and number in AGENT_ROLE and AGENT_REPLICA. Scaling down stops
+the highest-numbered instances first.
+
+The count is recorded in ~/.asc/replicas.json for this asc.toml only, and
+overrides its replicas, so asc up and asc restart keep it. Scale to the replicas of
+asc.toml to go back to them.
+
+Example:
+  asc scale coder=5                  # Run coder-1 to coder-5
+  asc scale coder=2 reviewer=0       # Stop all but two coders, and every reviewer`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runScale,
+}
+
+func init() {
+	rootCmd.AddCommand(scaleCmd)
+}
+
+func runScale(cmd *cobra.Command, args []string) error {
+	counts, err := parseScaleArgs(args)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	roles := make([]string, 0, len(counts))
+	for role := range counts {
+		if _, ok := cfg.Roles[role]; !ok {
+			if _, ok := cfg.Agents[role]; ok {
+				return fmt.Errorf("agent '%s' has no replicas\n  Suggestion: Set replicas in [agent.%s] to scale it", role, role)
+			}
+			return fmt.Errorf("no agent named '%s' in asc.toml", role)
+		}
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize process manager: %w", err)
+	}
+
+	if dryRun {
+		for _, role := range roles {
+			for _, name := range scaledAway(procManager, role, counts[role]) {
+				printDryRun("scale.dry_run.stop", name)
+			}
+			for i := 1; i <= counts[role]; i++ {
+				name := config.InstanceName(role, i)
+				if _, ok := process.Running(procManager, name); !ok {
+					printDryRun("scale.dry_run.start", name, cfg.Roles[role].Model, cfg.Roles[role].Command)
+				}
+			}
+		}
+		return nil
+	}
+
+	path, err := config.ReplicasPath()
+	if err != nil {
+		return err
+	}
+	recorded, err := config.LoadReplicas(path, config.DefaultConfigPath())
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		recorded[role] = counts[role]
+	}
+	if err := config.SaveReplicas(path, config.DefaultConfigPath(), recorded); err != nil {
+		return err
+	}
+	// Load the instances as the new counts make them
+	cfg, err = config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	ctx := commandContext(cmd)
+	if err := loadStackSecrets(ctx, cfg, config.DefaultEnvPath()); err != nil {
+		return err
+	}
+	setResourceLimits(cfg, procManager)
+	setStopPolicies(cfg, procManager)
+	setStdin(cfg, procManager)
+	setLogRotation(cfg, procManager)
+	setCrashCapture(cfg, procManager)
+
+	for _, role := range roles {
+		if err := scaleRole(ctx, cfg, procManager, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseScaleArgs parses the <agent>=<replicas> arguments of asc scale
+func parseScaleArgs(args []string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, arg := range args {
+		role, value, ok := strings.Cut(arg, "=")
+		replicas, err := strconv.Atoi(value)
+		if !ok || role == "" || err != nil || replicas < 0 {
+			return nil, fmt.Errorf("invalid scale '%s'\n  Suggestion: Use <agent>=<replicas>, e.g. coder=3", arg)
+		}
+		counts[role] = replicas
+	}
+	return counts, nil
+}
+
+// scaleRole stops the running instances of role that cfg no longer has,
+// highest first, then starts those it has that are not running
+func scaleRole(ctx context.Context, cfg *config.Config, procManager *process.Manager, role string) error {
+	instances := cfg.Instances(role)
+	for _, name := range scaledAway(procManager, role, len(instances)) {
+		fmt.Println(i18n.T("restart.stopping", name))
+		if err := procManager.StopNamed(ctx, name); err != nil {
+			return fmt.Errorf("failed to stop %s: %w", name, err)
+		}
+	}
+
+	for _, name := range instances {
+		if _, ok := process.Running(procManager, name); ok {
+			continue
+		}
+		agentCfg := cfg.Agents[name]
+		if pipeline.Manages(cfg.Pipeline, agentCfg) {
+			fmt.Println(i18n.T("scale.waits_for_phase", name))
+			continue
+		}
+		pid, err := startAgent(name, agentCfg, cfg, procManager)
+		if err != nil {
+			return fmt.Errorf("failed to start %s: %w\n  Suggestion: Check ~/.asc/logs/%s.log", name, err, name)
+		}
+		fmt.Println(i18n.T("restart.started", name, pid))
+	}
+	fmt.Println(i18n.T("scale.scaled", role, len(instances)))
+	logger.WithFields(logger.Fields{"role": role, "replicas": len(instances)}).Info("Scaled agent")
+	return nil
+}
+
+// scaledAway returns the running instances of role numbered above
+// replicas, highest first
+func scaledAway(procManager process.ProcessManager, role string, replicas int) []string {
+	infos, err := procManager.ListProcesses()
+	if err != nil {
+		return nil
+	}
+	var numbers []int
+	for _, info := range infos {
+		name := info.Name
+		rest, ok := strings.CutPrefix(name, role+"-")
+		if !ok {
+			continue
+		}
+		if i, err := strconv.Atoi(rest); err == nil && i > replicas && config.InstanceName(role, i) == name {
+			if process.Live(procManager, info) {
+				numbers = append(numbers, i)
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+	names := make([]string, len(numbers))
+	for i, n := range numbers {
+		names[i] = config.InstanceName(role, n)
+	}
+	return names
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/process"
+)
+
+// scaleConfig has an agent with replicas and one without
+const scaleConfig = `[core]
+beads_db_path = "./project-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+
+[agent.coder]
+command = "sleep 30"
+model = "claude"
+phases = ["implementation"]
+replicas = 2
+
+[agent.planner]
+command = "sleep 30"
+model = "claude"
+phases = ["planning"]
+`
+
+func TestParseScaleArgs(t *testing.T) {
+	counts, err := parseScaleArgs([]string{"coder=5", "reviewer=0"})
+	if err != nil || counts["coder"] != 5 || counts["reviewer"] != 0 {
+		t.Errorf("parseScaleArgs() = %v, %v", counts, err)
+	}
+	for _, arg := range []string{"coder", "coder=", "=3", "coder=-1", "coder=many"} {
+		if _, err := parseScaleArgs([]string{arg}); err == nil {
+			t.Errorf("Expected %q to be invalid", arg)
+		}
+	}
+}
+
+func TestScaleCommand(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Chdir(env.TempDir)
+	env.WriteConfig(scaleConfig)
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for _, name := range []string{"coder-1", "coder-2", "coder-3"} {
+			procManager.StopNamed(context.Background(), name)
+		}
+	})
+
+	scale := func(args ...string) string {
+		t.Helper()
+		capture := NewCaptureOutput()
+		capture.Start()
+		err := runScale(scaleCmd, args)
+		capture.Stop()
+		if err != nil {
+			t.Fatalf("runScale(%q) error = %v", args, err)
+		}
+		return capture.GetStdout()
+	}
+	running := func() []string {
+		var names []string
+		for _, name := range []string{"coder-1", "coder-2", "coder-3"} {
+			if _, ok := process.Running(procManager, name); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	// A dry run lists the instances it would start and starts none
+	dryRun = true
+	if err := rootCmd.PersistentPreRunE(scaleCmd, nil); err != nil {
+		dryRun = false
+		t.Fatalf("Expected asc scale to accept --dry-run, got %v", err)
+	}
+	out := scale("coder=3")
+	dryRun = false
+	if !strings.Contains(out, "Dry run: would start coder-3") || len(running()) != 0 {
+		t.Errorf("Expected a dry run, got %q running:\n%s", running(), out)
+	}
+
+	scale("coder=3")
+	if got := running(); !slices.Equal(got, []string{"coder-1", "coder-2", "coder-3"}) {
+		t.Errorf("Expected three coders running, got %q", got)
+	}
+	cfg, err := config.Load(env.ConfigPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Instances("coder"); len(got) != 3 {
+		t.Errorf("Expected the count to be kept for asc up, got %q", got)
+	}
+	if env := buildAgentEnv("coder-3", cfg.Agents["coder-3"], cfg); !slices.Contains(env, "AGENT_ROLE=coder") || !slices.Contains(env, "AGENT_REPLICA=3") {
+		t.Errorf("Expected the instance told its role and number, got %q", env)
+	}
+
+	// Scaling down stops the highest-numbered instances
+	pid, _ := process.Running(procManager, "coder-1")
+	out = scale("coder=1")
+	if got := running(); !slices.Equal(got, []string{"coder-1"}) {
+		t.Errorf("Expected only coder-1 running, got %q", got)
+	}
+	if again, _ := process.Running(procManager, "coder-1"); again != pid {
+		t.Error("Expected the instance kept not to be restarted")
+	}
+	if third, second := strings.Index(out, "Stopping coder-3"), strings.Index(out, "Stopping coder-2"); third < 0 || second < third {
+		t.Errorf("Expected coder-3 stopped before coder-2, got:\n%s", out)
+	}
+
+	if err := runScale(scaleCmd, []string{"planner=2"}); err == nil || !strings.Contains(err.Error(), "Set replicas in [agent.planner]") {
+		t.Errorf("Expected scaling an agent without replicas to fail, got %v", err)
+	}
+}
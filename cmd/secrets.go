@@ -1,10 +1,21 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/secrets"
 )
 
@@ -14,7 +25,9 @@ var secretsCmd = &cobra.Command{
 	Long: `Securely manage API keys and secrets using age encryption.
 
 Age (https://github.com/FiloSottile/age) provides simple, secure file encryption.
-This command helps you encrypt your .env file so you can safely commit it to git.
+It is built into asc, so the age tools do not need to be installed, and files
+encrypted here can still be read by them. This command helps you encrypt
+your .env file so you can safely commit it to git.
 
 Workflow:
   1. asc secrets init          # Generate age key
@@ -22,6 +35,7 @@ Workflow:
   3. asc secrets encrypt        # Encrypt .env → .env.age
   4. git add .env.age           # Commit encrypted file
   5. asc secrets decrypt        # Decrypt when needed
+     or asc secrets inject -- asc up  # Decrypt in memory only
 
 The age key is stored in ~/.asc/age.key and should NEVER be committed to git.`,
 }
@@ -31,35 +45,65 @@ var secretsInitCmd = &cobra.Command{
 	Short: "Initialize age encryption (generate key)",
 	Long: `Generate a new age encryption key for secrets management.
 
-The key will be stored in ~/.asc/age.key with restrictive permissions (0600).
+The key will be stored in ~/.asc/age.key, readable only by you (mode 0600,
+or an owner-only access control list on Windows).
 This key is used to encrypt and decrypt your .env files.
 
+With --keychain the private key is stored in the OS keychain (macOS
+Keychain, Secret Service on Linux, or Windows Credential Manager) and
+~/.asc/age.key only records the public key and where to find it. The
+other secrets commands then read the key from the keychain.
+
+With --passphrase the key file is encrypted with a passphrase, so a
+stolen copy is useless without it. The other secrets commands ask for
+the passphrase, or read it from ASC_KEY_PASSPHRASE when there is no
+terminal to ask on.
+
 IMPORTANT: Keep this key safe and NEVER commit it to git!`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		manager := secrets.NewManager()
 
+		if secretsKeychain && secretsPassphrase {
+			return fmt.Errorf("--keychain and --passphrase cannot be used together\n  Suggestion: The OS keychain already protects the key; choose one")
+		}
+
+		location := manager.GetKeyPath()
+		if secretsKeychain {
+			location = i18n.T("secrets.keychain")
+		}
+
+		if dryRun {
+			if manager.KeyExists() {
+				printDryRun("secrets.dry_run.replace_key", manager.GetKeyPath(), location)
+			} else {
+				printDryRun("secrets.dry_run.generate_key", location)
+			}
+			return nil
+		}
+
 		if manager.KeyExists() {
-			fmt.Println("⚠ Age key already exists at", manager.GetKeyPath())
-			fmt.Print("Do you want to overwrite it? (y/N): ")
+			fmt.Println(i18n.T("secrets.key_exists", manager.GetKeyPath()))
+			fmt.Print(i18n.T("secrets.overwrite_prompt"))
 			var response string
 			fmt.Scanln(&response)
 			if response != "y" && response != "Y" {
-				fmt.Println("Aborted.")
+				fmt.Println(i18n.T("secrets.aborted"))
 				return nil
 			}
 		}
 
-		if !manager.IsAgeInstalled() {
-			fmt.Println("✗ age is not installed")
-			fmt.Println("\nInstall age:")
-			fmt.Println("  macOS:   brew install age")
-			fmt.Println("  Linux:   apt install age  (or download from https://github.com/FiloSottile/age)")
-			fmt.Println("  Windows: scoop install age")
-			return fmt.Errorf("age not installed")
+		generate := manager.GenerateKey
+		if secretsKeychain {
+			generate = manager.GenerateKeyInKeychain
+		} else if secretsPassphrase {
+			passphrase, err := secrets.NewPassphrase()
+			if err != nil {
+				return err
+			}
+			generate = func() error { return manager.GenerateProtectedKey(passphrase) }
 		}
-
-		fmt.Println("Generating age key...")
-		if err := manager.GenerateKey(); err != nil {
+		fmt.Println(i18n.T("secrets.generating"))
+		if err := generate(); err != nil {
 			return fmt.Errorf("failed to generate key: %w", err)
 		}
 
@@ -68,11 +112,18 @@ IMPORTANT: Keep this key safe and NEVER commit it to git!`,
 			return fmt.Errorf("failed to get public key: %w", err)
 		}
 
-		fmt.Println("✓ Age key generated successfully")
-		fmt.Println("\nKey location:", manager.GetKeyPath())
-		fmt.Println("Public key:", pubKey)
-		fmt.Println("\n⚠ IMPORTANT: Keep your key safe and NEVER commit it to git!")
-		fmt.Println("✓ The key file has been set to permissions 0600")
+		fmt.Println(i18n.T("secrets.generated"))
+		fmt.Println("\n" + i18n.T("secrets.key_location", location))
+		fmt.Println(i18n.T("secrets.public_key", pubKey))
+		fmt.Println("\n" + i18n.T("secrets.keep_safe"))
+		if secretsKeychain {
+			fmt.Println(i18n.T("secrets.protected_keychain"))
+		} else if secretsPassphrase {
+			fmt.Println(i18n.T("secrets.protected_passphrase"))
+			fmt.Println(i18n.T("secrets.passphrase_unrecoverable"))
+		} else {
+			fmt.Println(i18n.T("secrets.protected_file"))
+		}
 
 		return nil
 	},
@@ -86,23 +137,30 @@ var secretsEncryptCmd = &cobra.Command{
 By default, encrypts .env to .env.age. You can specify a different file.
 
 The encrypted .env.age file is safe to commit to git, while .env should
-be added to .gitignore.
+be added to .gitignore. Before encrypting, the project is scanned for
+plaintext API keys that git tracks or does not ignore.
+
+The file is encrypted to your age key and to the public keys listed in
+the [secrets] section of asc.toml, so each team member can decrypt it
+with their own key:
+
+  [secrets]
+  recipients = ["age1...", "age1..."]
 
 Example:
   asc secrets encrypt           # Encrypts .env → .env.age
   asc secrets encrypt .env.prod # Encrypts .env.prod → .env.prod.age`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		manager := secrets.NewManager()
-
-		if !manager.IsAgeInstalled() {
-			return fmt.Errorf("age is not installed. Run 'asc secrets init' for installation instructions")
+		manager, err := newSecretsManager(commandContext(cmd))
+		if err != nil {
+			return err
 		}
 
 		if !manager.KeyExists() {
 			return fmt.Errorf("age key not found. Run 'asc secrets init' first")
 		}
 
-		envPath := ".env"
+		envPath := config.DefaultEnvPath()
 		if len(args) > 0 {
 			envPath = args[0]
 		}
@@ -112,19 +170,33 @@ Example:
 			return fmt.Errorf("file %s not found", envPath)
 		}
 
+		warnPlaintextSecrets()
+
+		if dryRun {
+			if err := manager.ValidateEnvFile(envPath); err != nil {
+				fmt.Println("⚠ " + i18n.T("warning", err))
+			}
+			recipients, err := manager.Recipients()
+			if err != nil {
+				return err
+			}
+			printDryRun("secrets.dry_run.encrypt", envPath, envPath, len(recipients))
+			return nil
+		}
+
 		// Validate env file structure
 		if err := manager.ValidateEnvFile(envPath); err != nil {
-			fmt.Printf("⚠ Warning: %v\n", err)
-			fmt.Print("Continue anyway? (y/N): ")
+			fmt.Println("⚠ " + i18n.T("warning", err))
+			fmt.Print(i18n.T("secrets.continue_anyway_prompt"))
 			var response string
 			fmt.Scanln(&response)
 			if response != "y" && response != "Y" {
-				fmt.Println("Aborted.")
+				fmt.Println(i18n.T("secrets.aborted"))
 				return nil
 			}
 		}
 
-		fmt.Printf("Encrypting %s...\n", envPath)
+		fmt.Println(i18n.T("secrets.encrypting", envPath))
 		if err := manager.EncryptEnv(envPath); err != nil {
 			return fmt.Errorf("encryption failed: %w", err)
 		}
@@ -140,7 +212,7 @@ var secretsDecryptCmd = &cobra.Command{
 
 By default, decrypts .env.age to .env. You can specify a different file.
 
-The decrypted file will have restrictive permissions (0600) set automatically.
+The decrypted file is made readable only by you automatically.
 
 Example:
   asc secrets decrypt           # Decrypts .env.age → .env
@@ -148,20 +220,25 @@ Example:
 	RunE: func(cmd *cobra.Command, args []string) error {
 		manager := secrets.NewManager()
 
-		if !manager.IsAgeInstalled() {
-			return fmt.Errorf("age is not installed. Run 'asc secrets init' for installation instructions")
-		}
-
 		if !manager.KeyExists() {
 			return fmt.Errorf("age key not found at %s", manager.GetKeyPath())
 		}
 
-		envPath := ".env"
+		envPath := config.DefaultEnvPath()
 		if len(args) > 0 {
 			envPath = args[0]
 		}
 
-		fmt.Printf("Decrypting %s.age...\n", envPath)
+		if dryRun {
+			if _, err := os.Stat(envPath); err == nil {
+				printDryRun("secrets.dry_run.decrypt_replace", envPath, envPath)
+			} else {
+				printDryRun("secrets.dry_run.decrypt", envPath, envPath)
+			}
+			return nil
+		}
+
+		fmt.Println(i18n.T("secrets.decrypting", envPath))
 		if err := manager.DecryptEnv(envPath); err != nil {
 			return fmt.Errorf("decryption failed: %w", err)
 		}
@@ -173,38 +250,76 @@ Example:
 var secretsStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show secrets management status",
-	Long:  `Display the current status of secrets management including key location and encrypted files.`,
+	Long:  `Display the current status of secrets management including key location, recipients, and encrypted files.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		manager := secrets.NewManager()
+		cfg, err := loadSecretsConfig(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		manager := secrets.NewManagerWithRecipients(cfg.Recipients)
 
-		fmt.Println("Secrets Management Status")
-		fmt.Println("========================")
+		title := i18n.T("secrets.status.title")
+		fmt.Println(title)
+		fmt.Println(strings.Repeat("=", utf8.RuneCountInString(title)))
 		fmt.Println()
 
-		// Check age installation
-		if manager.IsAgeInstalled() {
-			fmt.Println("✓ age is installed")
-		} else {
-			fmt.Println("✗ age is NOT installed")
-			fmt.Println("  Install: brew install age (macOS) or see https://github.com/FiloSottile/age")
-		}
+		fmt.Println(i18n.T("secrets.status.builtin"))
 
 		// Check key
-		if manager.KeyExists() {
-			fmt.Println("✓ Age key exists at", manager.GetKeyPath())
+		if manager.InKeychain() {
+			fmt.Println(i18n.T("secrets.status.key_keychain", manager.GetKeyPath()))
+			if pubKey, err := manager.GetPublicKey(); err == nil {
+				fmt.Println(i18n.T("secrets.status.public_key", pubKey))
+			}
+		} else if manager.IsProtected() {
+			fmt.Println(i18n.T("secrets.status.key_protected", manager.GetKeyPath()))
+		} else if manager.KeyExists() {
+			fmt.Println(i18n.T("secrets.status.key_exists", manager.GetKeyPath()))
 			if pubKey, err := manager.GetPublicKey(); err == nil {
-				fmt.Println("  Public key:", pubKey)
+				fmt.Println(i18n.T("secrets.status.public_key", pubKey))
 			}
 		} else {
-			fmt.Println("✗ Age key NOT found")
-			fmt.Println("  Run: asc secrets init")
+			fmt.Println(i18n.T("secrets.status.key_missing"))
+			fmt.Println(i18n.T("secrets.status.run", "asc secrets init"))
+		}
+		if cfg.RotationDays > 0 && manager.KeyExists() {
+			if due, age, err := manager.RotationDue(cfg.RotationDays); err == nil {
+				if due {
+					fmt.Println("⚠ " + i18n.T("secrets.rotation_due", keyAgeDays(age), cfg.RotationDays))
+					fmt.Println(i18n.T("secrets.status.run", "asc secrets rotate --auto"))
+				} else {
+					fmt.Println(i18n.T("secrets.status.key_age", keyAgeDays(age), cfg.RotationDays))
+				}
+			}
 		}
 
 		fmt.Println()
 
+		// Files are encrypted to the local key and the team's keys. The
+		// public key of a protected key file is not shown, since reading
+		// it would need the passphrase.
+		if manager.IsProtected() {
+			fmt.Println(i18n.T("secrets.status.recipients"))
+			fmt.Println(i18n.T("secrets.status.own_key_protected"))
+			for _, recipient := range cfg.Recipients {
+				fmt.Println("  ✓", recipient)
+			}
+			fmt.Println()
+		} else if recipients, err := manager.Recipients(); err == nil {
+			fmt.Println(i18n.T("secrets.status.recipients"))
+			for i, recipient := range recipients {
+				if i == 0 {
+					fmt.Println("  ✓", recipient, i18n.T("secrets.status.own_key"))
+				} else {
+					fmt.Println("  ✓", recipient)
+				}
+			}
+			fmt.Println()
+		}
+
 		// Check for encrypted files
 		encryptedFiles := []string{".env.age", ".env.prod.age", ".env.staging.age"}
-		fmt.Println("Encrypted Files:")
+		fmt.Println(i18n.T("secrets.status.encrypted"))
 		foundAny := false
 		for _, file := range encryptedFiles {
 			if _, err := os.Stat(file); err == nil {
@@ -213,23 +328,23 @@ var secretsStatusCmd = &cobra.Command{
 			}
 		}
 		if !foundAny {
-			fmt.Println("  (none found)")
+			fmt.Println(i18n.T("secrets.status.none_found"))
 		}
 
 		fmt.Println()
 
 		// Check for unencrypted files
 		unencryptedFiles := []string{".env", ".env.prod", ".env.staging"}
-		fmt.Println("Unencrypted Files:")
+		fmt.Println(i18n.T("secrets.status.unencrypted"))
 		foundAny = false
 		for _, file := range unencryptedFiles {
 			if _, err := os.Stat(file); err == nil {
-				fmt.Println("  ⚠", file, "(should be encrypted and gitignored)")
+				fmt.Println("  ⚠", file, i18n.T("secrets.status.should_encrypt"))
 				foundAny = true
 			}
 		}
 		if !foundAny {
-			fmt.Println("  (none found)")
+			fmt.Println(i18n.T("secrets.status.none_found"))
 		}
 
 		return nil
@@ -244,41 +359,79 @@ var secretsRotateCmd = &cobra.Command{
 This is useful if you suspect your key has been compromised or as part
 of regular security maintenance.
 
-The old key will be backed up to ~/.asc/age.key.old`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		manager := secrets.NewManager()
+The old key will be backed up to ~/.asc/age.key.old. The .age files git
+tracks, and any at the top of the project, are re-encrypted to the new
+key and the recipients in asc.toml; give team members your new public
+key so they can update their configuration. Each rotation is recorded
+in ~/.asc/secrets-history.json.
+
+With --auto the key is rotated without asking, and only once it is older
+than rotation_days in the [secrets] section of asc.toml, so the command
+can be run on a schedule:
+
+  [secrets]
+  rotation_days = 90
 
-		if !manager.IsAgeInstalled() {
-			return fmt.Errorf("age is not installed")
+Example:
+  asc secrets rotate          # Rotate now
+  asc secrets rotate --auto   # Rotate if the key is due, e.g. from cron`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSecretsConfig(commandContext(cmd))
+		if err != nil {
+			return err
 		}
+		manager := secrets.NewManagerWithRecipients(cfg.Recipients)
 
 		if !manager.KeyExists() {
 			return fmt.Errorf("no existing key to rotate")
 		}
 
-		fmt.Println("⚠ This will generate a new key and re-encrypt all files")
-		fmt.Print("Continue? (y/N): ")
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("Aborted.")
+		if secretsRotateAuto {
+			if cfg.RotationDays == 0 {
+				return fmt.Errorf("no key rotation policy configured\n  Suggestion: Set rotation_days in the [secrets] section of asc.toml")
+			}
+			due, age, err := manager.RotationDue(cfg.RotationDays)
+			if err != nil {
+				return err
+			}
+			if !due {
+				fmt.Println(i18n.T("secrets.rotation_not_due", keyAgeDays(age), cfg.RotationDays))
+				return nil
+			}
+			fmt.Println(i18n.T("secrets.rotation_due", keyAgeDays(age), cfg.RotationDays))
+		}
+
+		encryptedFiles := secrets.EncryptedFiles(".")
+
+		if dryRun {
+			printDryRun("secrets.dry_run.back_up_key", manager.GetKeyPath(), manager.GetKeyPath())
+			printDryRun("secrets.dry_run.new_key", manager.GetKeyPath())
+			for _, file := range encryptedFiles {
+				printDryRun("secrets.dry_run.reencrypt", file)
+			}
 			return nil
 		}
 
-		// Find encrypted files
-		encryptedFiles := []string{}
-		candidates := []string{".env.age", ".env.prod.age", ".env.staging.age"}
-		for _, file := range candidates {
-			if _, err := os.Stat(file); err == nil {
-				encryptedFiles = append(encryptedFiles, file)
+		if !secretsRotateAuto {
+			fmt.Println(i18n.T("secrets.rotate_warning"))
+			fmt.Print(i18n.T("secrets.continue_prompt"))
+			var response string
+			fmt.Scanln(&response)
+			if response != "y" && response != "Y" {
+				fmt.Println(i18n.T("secrets.aborted"))
+				return nil
 			}
 		}
 
 		if len(encryptedFiles) == 0 {
-			fmt.Println("No encrypted files found to re-encrypt")
+			fmt.Println(i18n.T("secrets.nothing_to_reencrypt"))
 		}
 
-		if err := manager.RotateKey(encryptedFiles); err != nil {
+		rotate := manager.RotateKey
+		if secretsRotateAuto {
+			rotate = manager.RotateKeyAutomatically
+		}
+		if err := rotate(encryptedFiles); err != nil {
 			return fmt.Errorf("key rotation failed: %w", err)
 		}
 
@@ -286,6 +439,150 @@ The old key will be backed up to ~/.asc/age.key.old`,
 	},
 }
 
+var (
+	secretsKeychain   bool
+	secretsPassphrase bool
+	secretsRotateAuto bool
+	secretsInjectFile string
+)
+
+var secretsInjectCmd = &cobra.Command{
+	Use:   "inject [--file .env] -- <command> [args...]",
+	Short: "Run a command with decrypted secrets in its environment",
+	Long: `Decrypt your encrypted secrets file in memory and run a command with
+its variables added to the environment. The plaintext is never written to
+disk, so no .env file is left behind on shared machines.
+
+asc up run under inject uses the injected secrets instead of decrypting
+.env.age to .env. The command's exit code is passed through.
+
+Example:
+  asc secrets inject -- asc up                # Decrypts .env.age for asc up
+  asc secrets inject --file .env.prod -- make deploy`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager := secrets.NewManager()
+
+		if !manager.KeyExists() {
+			return fmt.Errorf("age key not found at %s", manager.GetKeyPath())
+		}
+
+		if !cmd.Flags().Changed("file") {
+			secretsInjectFile = config.DefaultEnvPath()
+		}
+		encPath := secretsInjectFile + ".age"
+		if _, err := os.Stat(encPath); os.IsNotExist(err) {
+			return fmt.Errorf("encrypted file %s not found\n  Suggestion: Run 'asc secrets encrypt %s' first", encPath, secretsInjectFile)
+		}
+
+		if dryRun {
+			printDryRun("secrets.dry_run.inject", encPath, strings.Join(args, " "))
+			return nil
+		}
+
+		plaintext, err := manager.DecryptToMemory(encPath)
+		if err != nil {
+			return fmt.Errorf("decryption failed: %w", err)
+		}
+		vars, err := config.ParseEnv(bytes.NewReader(plaintext))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", encPath, err)
+		}
+
+		code, err := runInjected(args, append(vars, secrets.InjectedEnvVar+"="+encPath))
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			osExit(code)
+		}
+		return nil
+	},
+}
+
+// runInjected runs the command with vars added to the environment and
+// returns its exit code. The command shares the terminal, so it gets
+// Ctrl-C itself; SIGTERM sent to asc is passed on to it.
+func runInjected(args []string, vars []string) (int, error) {
+	return runForeground(args, append(os.Environ(), vars...))
+}
+
+// runForeground runs the command with env as its whole environment,
+// sharing the terminal as runInjected does, and returns its exit code
+func runForeground(args []string, env []string) (int, error) {
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = env
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	if err := child.Start(); err != nil {
+		return 0, fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			child.Process.Signal(sig)
+		}
+	}()
+
+	err := child.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+	return 0, nil
+}
+
+// newSecretsManager returns a secrets manager that encrypts to the
+// recipients in asc.toml. Secrets can be set up before asc.toml exists,
+// so a missing file means there are no recipients besides the local key.
+func newSecretsManager(ctx context.Context) (*secrets.Manager, error) {
+	cfg, err := loadSecretsConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.NewManagerWithRecipients(cfg.Recipients), nil
+}
+
+// loadSecretsConfig returns the [secrets] section of asc.toml, or the
+// defaults if there is no asc.toml yet
+func loadSecretsConfig(ctx context.Context) (config.SecretsConfig, error) {
+	configPath := config.DefaultConfigPath()
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return config.SecretsConfig{}, nil
+	}
+	cfg, err := config.LoadContext(ctx, configPath)
+	if err != nil {
+		return config.SecretsConfig{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg.Secrets, nil
+}
+
+// keyAgeDays returns an age in whole days
+func keyAgeDays(age time.Duration) int {
+	return int(age / (24 * time.Hour))
+}
+
+// warnPlaintextSecrets warns about plaintext secrets that are, or could
+// be, committed to git, since encrypting them does not help if the
+// plaintext is pushed too
+func warnPlaintextSecrets() {
+	findings, err := secrets.Scan(".")
+	if err != nil || len(findings) == 0 {
+		return
+	}
+	for _, finding := range findings {
+		fmt.Println("⚠ " + i18n.T("warning", finding))
+	}
+	fmt.Println(i18n.T("secrets.plaintext_hint"))
+}
+
 func init() {
 	rootCmd.AddCommand(secretsCmd)
 	secretsCmd.AddCommand(secretsInitCmd)
@@ -293,4 +590,12 @@ func init() {
 	secretsCmd.AddCommand(secretsDecryptCmd)
 	secretsCmd.AddCommand(secretsStatusCmd)
 	secretsCmd.AddCommand(secretsRotateCmd)
+	secretsCmd.AddCommand(secretsInjectCmd)
+
+	secretsRotateCmd.Flags().BoolVar(&secretsRotateAuto, "auto", false, "Rotate without asking, only if the key is older than rotation_days")
+	secretsInitCmd.Flags().BoolVar(&secretsKeychain, "keychain", false, "Store the private key in the OS keychain instead of a file")
+	secretsInitCmd.Flags().BoolVar(&secretsPassphrase, "passphrase", false, "Encrypt the key file with a passphrase")
+	secretsInjectCmd.Flags().StringVarP(&secretsInjectFile, "file", "f", "", "Secrets file whose encrypted .age version is injected (default .env, or .env.<profile>)")
+	// Flags after the command belong to it
+	secretsInjectCmd.Flags().SetInterspersed(false)
 }
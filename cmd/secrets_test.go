@@ -2,19 +2,15 @@ package cmd
 
 import (
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/rand/asc/internal/secrets"
 )
 
 // TestSecretsInitCommand_Success tests successful key generation
 func TestSecretsInitCommand_Success(t *testing.T) {
-	// Skip if age is not installed
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -55,10 +51,6 @@ func TestSecretsInitCommand_Success(t *testing.T) {
 
 // TestSecretsInitCommand_AlreadyExists tests behavior when key already exists
 func TestSecretsInitCommand_AlreadyExists(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -90,40 +82,8 @@ func TestSecretsInitCommand_AlreadyExists(t *testing.T) {
 	capture.Stop()
 }
 
-// TestSecretsInitCommand_NoAge tests behavior when age is not installed
-func TestSecretsInitCommand_NoAge(t *testing.T) {
-	if isAgeInstalled() {
-		t.Skip("age is installed, skipping no-age test")
-	}
-
-	env := NewTestEnvironment(t)
-	restore := ChangeToTempDir(t, env.TempDir)
-	defer restore()
-
-	os.Setenv("HOME", env.TempDir)
-	defer os.Unsetenv("HOME")
-
-	oldArgs := os.Args
-	os.Args = []string{"asc", "secrets", "init"}
-	defer func() { os.Args = oldArgs }()
-
-	err := secretsInitCmd.RunE(secretsInitCmd, []string{})
-
-	if err == nil {
-		t.Error("Expected error when age is not installed")
-	}
-
-	if !strings.Contains(err.Error(), "age not installed") {
-		t.Errorf("Expected 'age not installed' error, got: %v", err)
-	}
-}
-
 // TestSecretsEncryptCommand_Success tests successful encryption
 func TestSecretsEncryptCommand_Success(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -175,10 +135,6 @@ GOOGLE_API_KEY=test-789
 
 // TestSecretsEncryptCommand_CustomFile tests encryption with custom file
 func TestSecretsEncryptCommand_CustomFile(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -221,10 +177,6 @@ GOOGLE_API_KEY=prod-789
 
 // TestSecretsEncryptCommand_MissingFile tests encryption with missing file
 func TestSecretsEncryptCommand_MissingFile(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -257,10 +209,6 @@ func TestSecretsEncryptCommand_MissingFile(t *testing.T) {
 
 // TestSecretsEncryptCommand_NoKey tests encryption without key
 func TestSecretsEncryptCommand_NoKey(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -286,42 +234,8 @@ func TestSecretsEncryptCommand_NoKey(t *testing.T) {
 	}
 }
 
-// TestSecretsEncryptCommand_NoAge tests encryption without age installed
-func TestSecretsEncryptCommand_NoAge(t *testing.T) {
-	if isAgeInstalled() {
-		t.Skip("age is installed, skipping no-age test")
-	}
-
-	env := NewTestEnvironment(t)
-	restore := ChangeToTempDir(t, env.TempDir)
-	defer restore()
-
-	os.Setenv("HOME", env.TempDir)
-	defer os.Unsetenv("HOME")
-
-	env.WriteEnv(ValidEnv())
-
-	oldArgs := os.Args
-	os.Args = []string{"asc", "secrets", "encrypt"}
-	defer func() { os.Args = oldArgs }()
-
-	err := secretsEncryptCmd.RunE(secretsEncryptCmd, []string{})
-
-	if err == nil {
-		t.Error("Expected error when age is not installed")
-	}
-
-	if !strings.Contains(err.Error(), "age is not installed") {
-		t.Errorf("Expected 'age is not installed' error, got: %v", err)
-	}
-}
-
 // TestSecretsDecryptCommand_Success tests successful decryption
 func TestSecretsDecryptCommand_Success(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -386,10 +300,6 @@ GOOGLE_API_KEY=test-789
 
 // TestSecretsDecryptCommand_CustomFile tests decryption with custom file
 func TestSecretsDecryptCommand_CustomFile(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -438,10 +348,6 @@ GOOGLE_API_KEY=staging-789
 
 // TestSecretsDecryptCommand_MissingFile tests decryption with missing encrypted file
 func TestSecretsDecryptCommand_MissingFile(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -474,10 +380,6 @@ func TestSecretsDecryptCommand_MissingFile(t *testing.T) {
 
 // TestSecretsDecryptCommand_NoKey tests decryption without key
 func TestSecretsDecryptCommand_NoKey(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -503,36 +405,6 @@ func TestSecretsDecryptCommand_NoKey(t *testing.T) {
 	}
 }
 
-// TestSecretsDecryptCommand_NoAge tests decryption without age installed
-func TestSecretsDecryptCommand_NoAge(t *testing.T) {
-	if isAgeInstalled() {
-		t.Skip("age is installed, skipping no-age test")
-	}
-
-	env := NewTestEnvironment(t)
-	restore := ChangeToTempDir(t, env.TempDir)
-	defer restore()
-
-	os.Setenv("HOME", env.TempDir)
-	defer os.Unsetenv("HOME")
-
-	os.WriteFile(filepath.Join(env.TempDir, ".env.age"), []byte("encrypted"), 0600)
-
-	oldArgs := os.Args
-	os.Args = []string{"asc", "secrets", "decrypt"}
-	defer func() { os.Args = oldArgs }()
-
-	err := secretsDecryptCmd.RunE(secretsDecryptCmd, []string{})
-
-	if err == nil {
-		t.Error("Expected error when age is not installed")
-	}
-
-	if !strings.Contains(err.Error(), "age is not installed") {
-		t.Errorf("Expected 'age is not installed' error, got: %v", err)
-	}
-}
-
 // TestSecretsStatusCommand tests the status command
 func TestSecretsStatusCommand(t *testing.T) {
 	env := NewTestEnvironment(t)
@@ -562,24 +434,13 @@ func TestSecretsStatusCommand(t *testing.T) {
 		t.Errorf("Expected status header in output, got: %s", output)
 	}
 
-	// Should show age installation status
-	if isAgeInstalled() {
-		if !strings.Contains(output, "age is installed") {
-			t.Error("Expected age installed message")
-		}
-	} else {
-		if !strings.Contains(output, "age is NOT installed") {
-			t.Error("Expected age not installed message")
-		}
+	if !strings.Contains(output, "age encryption is built in") {
+		t.Error("Expected built-in age message")
 	}
 }
 
 // TestSecretsStatusCommand_WithKey tests status with existing key
 func TestSecretsStatusCommand_WithKey(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -618,10 +479,6 @@ func TestSecretsStatusCommand_WithKey(t *testing.T) {
 
 // TestSecretsStatusCommand_WithEncryptedFiles tests status with encrypted files
 func TestSecretsStatusCommand_WithEncryptedFiles(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -665,10 +522,6 @@ func TestSecretsStatusCommand_WithEncryptedFiles(t *testing.T) {
 
 // TestSecretsRotateCommand tests key rotation
 func TestSecretsRotateCommand(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -703,10 +556,6 @@ func TestSecretsRotateCommand(t *testing.T) {
 
 // TestSecretsRotateCommand_NoKey tests rotation without existing key
 func TestSecretsRotateCommand_NoKey(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -727,10 +576,6 @@ func TestSecretsRotateCommand_NoKey(t *testing.T) {
 
 // TestSecretsEncryptCommand_InvalidEnvFile tests encryption with invalid env file
 func TestSecretsEncryptCommand_InvalidEnvFile(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -762,14 +607,45 @@ func TestSecretsEncryptCommand_InvalidEnvFile(t *testing.T) {
 	}
 }
 
-// Helper function to check if age is installed
-func isAgeInstalled() bool {
-	_, err := exec.LookPath("age")
+// TestSecretsInjectCommand tests running a command with decrypted secrets
+func TestSecretsInjectCommand(t *testing.T) {
+	env := NewTestEnvironment(t)
+	restore := ChangeToTempDir(t, env.TempDir)
+	defer restore()
+
+	os.Setenv("HOME", env.TempDir)
+	defer os.Unsetenv("HOME")
+
+	manager := secrets.NewManager()
+	if err := manager.GenerateKey(); err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	env.WriteEnv("CLAUDE_API_KEY=\"sk-test-123\"\n")
+	if err := manager.Encrypt(".env", ".env.age"); err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	os.Remove(".env")
+
+	err := secretsInjectCmd.RunE(secretsInjectCmd, []string{"sh", "-c", `printf '%s %s' "$CLAUDE_API_KEY" "$` + secrets.InjectedEnvVar + `" > injected.txt`})
 	if err != nil {
-		return false
+		t.Fatalf("secrets inject failed: %v", err)
+	}
+
+	if got := env.ReadFile(filepath.Join(env.TempDir, "injected.txt")); got != "sk-test-123 .env.age" {
+		t.Errorf("Expected the command to see the decrypted secrets, got %q", got)
+	}
+	if _, err := os.Stat(".env"); !os.IsNotExist(err) {
+		t.Error("Expected no .env file to be written")
+	}
+
+	// The command's exit code is passed through
+	code, err := runInjected([]string{"sh", "-c", "exit 3"}, nil)
+	if err != nil || code != 3 {
+		t.Errorf("runInjected() = %d, %v, want exit code 3", code, err)
+	}
+	if _, err := runInjected([]string{"asc-no-such-command"}, nil); err == nil {
+		t.Error("Expected an error for a missing command")
 	}
-	_, err = exec.LookPath("age-keygen")
-	return err == nil
 }
 
 // TestSecretsCommand_Structure tests the command structure
@@ -903,10 +779,6 @@ func TestSecretsRotateCommand_Structure(t *testing.T) {
 
 // TestSecretsEncryptCommand_ValidationWarning tests validation warning flow
 func TestSecretsEncryptCommand_ValidationWarning(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -1057,10 +929,6 @@ func TestSecretsCommand_Subcommands(t *testing.T) {
 
 // TestSecretsInitCommand_KeyPathCreation tests that key directory is created
 func TestSecretsInitCommand_KeyPathCreation(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -1092,10 +960,6 @@ func TestSecretsInitCommand_KeyPathCreation(t *testing.T) {
 
 // TestSecretsEncryptCommand_FilePermissions tests that encrypted files have correct permissions
 func TestSecretsEncryptCommand_FilePermissions(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -1133,10 +997,6 @@ func TestSecretsEncryptCommand_FilePermissions(t *testing.T) {
 
 // TestSecretsDecryptCommand_FilePermissions tests that decrypted files have secure permissions
 func TestSecretsDecryptCommand_FilePermissions(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -1185,10 +1045,6 @@ func TestSecretsDecryptCommand_FilePermissions(t *testing.T) {
 
 // TestSecretsInitCommand_PublicKeyDisplay tests that public key is displayed after generation
 func TestSecretsInitCommand_PublicKeyDisplay(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -1223,10 +1079,6 @@ func TestSecretsInitCommand_PublicKeyDisplay(t *testing.T) {
 
 // TestSecretsEncryptCommand_OutputMessages tests encryption output messages
 func TestSecretsEncryptCommand_OutputMessages(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -1272,10 +1124,6 @@ func TestSecretsEncryptCommand_OutputMessages(t *testing.T) {
 
 // TestSecretsDecryptCommand_OutputMessages tests decryption output messages
 func TestSecretsDecryptCommand_OutputMessages(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -1356,10 +1204,6 @@ func TestSecretsStatusCommand_NoEncryptedFiles(t *testing.T) {
 
 // TestSecretsStatusCommand_MultipleFiles tests status with multiple encrypted files
 func TestSecretsStatusCommand_MultipleFiles(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -1409,10 +1253,6 @@ func TestSecretsStatusCommand_MultipleFiles(t *testing.T) {
 
 // TestSecretsInitCommand_KeyPermissions tests that generated key has secure permissions
 func TestSecretsInitCommand_KeyPermissions(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -1445,10 +1285,6 @@ func TestSecretsInitCommand_KeyPermissions(t *testing.T) {
 
 // TestSecretsCommand_Integration tests full workflow
 func TestSecretsCommand_Integration(t *testing.T) {
-	if !isAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
-
 	env := NewTestEnvironment(t)
 	restore := ChangeToTempDir(t, env.TempDir)
 	defer restore()
@@ -3,12 +3,13 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
-	"github.com/spf13/cobra"
 	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/spf13/cobra"
 )
 
 // osExit is a variable that can be mocked in tests (shared with other cmd files)
@@ -51,39 +52,33 @@ func init() {
 
 // getProcessManager creates a process manager instance with default directories
 func getProcessManager() (*process.Manager, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	pidDir := filepath.Join(homeDir, ".asc", "pids")
-	logDir := filepath.Join(homeDir, ".asc", "logs")
-
-	return process.NewManager(pidDir, logDir)
+	return process.NewDefaultManager()
 }
 
 // runServicesStart starts the mcp_agent_mail service
 func runServicesStart(cmd *cobra.Command, args []string) {
 	// Load configuration
-	cfg, err := config.Load(config.DefaultConfigPath())
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to load configuration: %v\n", err)
+		printError("Failed to load configuration", err)
 		osExit(1)
 		return
 	}
+	applyLoggingConfig(cfg)
+	startTelemetry(cfg)
 
 	// Create process manager
 	pm, err := getProcessManager()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to create process manager: %v\n", err)
+		printError("Failed to create process manager", err)
 		osExit(1)
 		return
 	}
 
 	// Check if service is already running
 	info, err := pm.GetProcessInfo("mcp_agent_mail")
-	if err == nil && pm.IsRunning(info.PID) {
-		fmt.Printf("mcp_agent_mail is already running (PID %d)\n", info.PID)
+	if err == nil && process.Live(pm, info) {
+		fmt.Println(i18n.T("services.already_running", info.PID))
 		osExit(0)
 		return
 	}
@@ -99,20 +94,25 @@ func runServicesStart(cmd *cobra.Command, args []string) {
 	command := cmdParts[0]
 	cmdArgs := cmdParts[1:]
 
+	if dryRun {
+		printDryRun("services.dry_run.start", strings.Join(cmdParts, " "))
+		return
+	}
+
 	// Start the service
+	pm.SetLogRotation("mcp_agent_mail", logRotation(cfg.Logging.Rotation))
 	pid, err := pm.Start("mcp_agent_mail", command, cmdArgs, nil)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to start mcp_agent_mail: %v\n", err)
+		printError("Failed to start mcp_agent_mail", err)
 		osExit(1)
 		return
 	}
 
-	fmt.Printf("✓ mcp_agent_mail started (PID %d)\n", pid)
-	fmt.Printf("  URL: %s\n", cfg.Services.MCPAgentMail.URL)
+	fmt.Println(i18n.T("services.started", pid))
+	fmt.Println(i18n.T("services.url", cfg.Services.MCPAgentMail.URL))
 	
-	homeDir, _ := os.UserHomeDir()
-	logPath := filepath.Join(homeDir, ".asc", "logs", "mcp_agent_mail.log")
-	fmt.Printf("  Log: %s\n", logPath)
+	logPath, _ := statedir.Path("logs", "mcp_agent_mail.log")
+	fmt.Println(i18n.T("daemon.log", logPath))
 }
 
 // runServicesStop stops the mcp_agent_mail service
@@ -120,7 +120,7 @@ func runServicesStop(cmd *cobra.Command, args []string) {
 	// Create process manager
 	pm, err := getProcessManager()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to create process manager: %v\n", err)
+		printError("Failed to create process manager", err)
 		osExit(1)
 		return
 	}
@@ -134,30 +134,37 @@ func runServicesStop(cmd *cobra.Command, args []string) {
 	}
 
 	// Check if process is actually running
-	if !pm.IsRunning(info.PID) {
+	if !process.Live(pm, info) {
 		fmt.Fprintf(os.Stderr, "Error: mcp_agent_mail is not running (stale PID file)\n")
 		// Clean up stale PID file
-		homeDir, _ := os.UserHomeDir()
-		pidFile := filepath.Join(homeDir, ".asc", "pids", "mcp_agent_mail.json")
-		os.Remove(pidFile)
+		pidFile, _ := statedir.Path("pids", "mcp_agent_mail.json")
+		if dryRun {
+			printDryRun("cleanup.dry_run.remove", pidFile)
+		} else {
+			os.Remove(pidFile)
+		}
 		osExit(1)
 		return
 	}
 
+	if dryRun {
+		printDryRun("services.dry_run.stop", info.PID)
+		return
+	}
+
 	// Stop the service
-	fmt.Printf("Stopping mcp_agent_mail (PID %d)...\n", info.PID)
-	if err := pm.Stop(info.PID); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to stop mcp_agent_mail: %v\n", err)
+	fmt.Println(i18n.T("services.stopping", info.PID))
+	if err := pm.Stop(commandContext(cmd), info.PID); err != nil {
+		printError("Failed to stop mcp_agent_mail", err)
 		osExit(1)
 		return
 	}
 
 	// Clean up PID file
-	homeDir, _ := os.UserHomeDir()
-	pidFile := filepath.Join(homeDir, ".asc", "pids", "mcp_agent_mail.json")
+	pidFile, _ := statedir.Path("pids", "mcp_agent_mail.json")
 	os.Remove(pidFile)
 
-	fmt.Println("✓ mcp_agent_mail stopped")
+	fmt.Println(i18n.T("services.stopped"))
 }
 
 // runServicesStatus checks if the mcp_agent_mail service is running
@@ -165,7 +172,7 @@ func runServicesStatus(cmd *cobra.Command, args []string) {
 	// Create process manager
 	pm, err := getProcessManager()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to create process manager: %v\n", err)
+		printError("Failed to create process manager", err)
 		osExit(1)
 		return
 	}
@@ -173,21 +180,20 @@ func runServicesStatus(cmd *cobra.Command, args []string) {
 	// Get process info
 	info, err := pm.GetProcessInfo("mcp_agent_mail")
 	if err != nil {
-		fmt.Println("mcp_agent_mail: ○ stopped")
+		fmt.Println(i18n.T("services.status.stopped"))
 		osExit(0)
 		return
 	}
 
 	// Check if process is running
-	if pm.IsRunning(info.PID) {
-		fmt.Printf("mcp_agent_mail: ● running (PID %d)\n", info.PID)
-		fmt.Printf("  Started: %s\n", info.StartedAt.Format("2006-01-02 15:04:05"))
-		fmt.Printf("  Log: %s\n", info.LogFile)
+	if process.Live(pm, info) {
+		fmt.Println(i18n.T("services.status.running", info.PID))
+		fmt.Println(i18n.T("services.status.started", info.StartedAt.Format("2006-01-02 15:04:05")))
+		fmt.Println(i18n.T("daemon.log", info.LogFile))
 	} else {
-		fmt.Println("mcp_agent_mail: ○ stopped (stale PID file)")
+		fmt.Println(i18n.T("services.status.stale"))
 		// Clean up stale PID file
-		homeDir, _ := os.UserHomeDir()
-		pidFile := filepath.Join(homeDir, ".asc", "pids", "mcp_agent_mail.json")
+		pidFile, _ := statedir.Path("pids", "mcp_agent_mail.json")
 		os.Remove(pidFile)
 	}
 }
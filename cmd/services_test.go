@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -452,7 +453,12 @@ func TestServicesCommand_Integration(t *testing.T) {
 	
 	// Create a mock python that sleeps for a bit
 	pythonPath := filepath.Join(mockBinDir, "python")
-	pythonScript := "#!/bin/sh\nsleep 10\n"
+	// sleep is run by its path, as PATH holds only the mock binaries
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	pythonScript := fmt.Sprintf("#!/bin/sh\n%s 10\n", sleepPath)
 	if err := os.WriteFile(pythonPath, []byte(pythonScript), 0755); err != nil {
 		t.Fatalf("Failed to create mock python: %v", err)
 	}
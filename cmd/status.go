@@ -0,0 +1,472 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/mcp"
+	"github.com/rand/asc/internal/pipeline"
+	"github.com/rand/asc/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusJSON     bool
+	statusWatch    bool
+	statusInterval time.Duration
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List managed processes with their memory and CPU usage",
+	Long: `List mcp_agent_mail and the agents with whether they are running, the
+outcome of their health checks, the memory and CPU they use, and the
+resource limits (max_memory_mb, cpu_limit) they were started with. CPU
+usage is measured over half a second.
+
+While the TUI runs, the state of its WebSocket event stream from
+mcp_agent_mail is shown too: connected, degraded (dropped and
+reconnecting), or disconnected.
+
+With --json, the state of the stack is output as one JSON object for
+scripts and CI jobs: the agents and services of asc.toml, the tasks by
+status, whether each MCP server answers, and "healthy", which is true
+when every agent and service runs, none fails its health checks, and
+every MCP server answers. With --watch, the status is shown again every
+--interval until Ctrl-C, one JSON object a line with --json.
+
+Example:
+  asc status --json | jq -e .healthy   # Exits 1 unless the stack is healthy
+  asc status --watch --interval 5s`,
+	Args: cobra.NoArgs,
+	Run:  runStatus,
+}
+
+// statusSampleInterval is how long CPU usage is measured over. It is a
+// variable so tests do not wait.
+var statusSampleInterval = 500 * time.Millisecond
+
+// statusProbeTimeout bounds asking the MCP servers and beads for the
+// state of the stack
+const statusProbeTimeout = 5 * time.Second
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output the state of the stack as JSON")
+	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "Show the status again every --interval until Ctrl-C")
+	statusCmd.Flags().DurationVarP(&statusInterval, "interval", "d", 2*time.Second, "Time between refreshes with --watch")
+}
+
+// processStatus is one row of asc status
+type processStatus struct {
+	info    *process.ProcessInfo
+	running bool
+	usage   process.Usage
+	cpu     float64 // Percent of one core
+	err     error   // Why usage could not be read
+}
+
+// Component statuses of asc status --json
+const (
+	componentRunning = "running"
+	componentStopped = "stopped"
+	componentPending = "pending" // Waits for its pipeline phase
+)
+
+// stackStatus is the state of the stack asc status --json outputs
+type stackStatus struct {
+	Time     time.Time         `json:"time"`
+	Healthy  bool              `json:"healthy"`
+	Agents   []componentStatus `json:"agents"`
+	Services []componentStatus `json:"services"`
+	Tasks    *taskSummary      `json:"tasks,omitempty"`
+	MCP      mcpHealth         `json:"mcp"`
+}
+
+// componentStatus is the state of an agent or service
+type componentStatus struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`           // componentRunning, componentStopped, or componentPending
+	Health      string     `json:"health,omitempty"` // Outcome of its health checks, if it has any
+	PID         int        `json:"pid,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	Restarts    int        `json:"restarts"`
+	MemoryBytes uint64     `json:"memory_bytes,omitempty"`
+	CPUPercent  float64    `json:"cpu_percent"`
+	Limits      string     `json:"limits,omitempty"`
+	State       string     `json:"state,omitempty"`        // What the agent reports to its MCP server: idle, working, error, or offline
+	CurrentTask string     `json:"current_task,omitempty"` // Task the agent reports working on
+	Error       string     `json:"error,omitempty"`        // Why usage could not be read, or its last failed health check
+}
+
+// taskSummary counts the tasks in beads
+type taskSummary struct {
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"by_status"`
+	Error    string         `json:"error,omitempty"` // Why the tasks could not be listed
+}
+
+// mcpHealth is whether the MCP servers answer, and the state of the TUI's
+// event stream while the TUI runs
+type mcpHealth struct {
+	Servers []mcpServerHealth `json:"servers"`
+	Stream  *mcp.StreamStatus `json:"stream,omitempty"`
+}
+
+// mcpServerHealth is whether an MCP server answers
+type mcpServerHealth struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+// runStatus lists the managed processes with their resource usage, once
+// or every --interval
+func runStatus(cmd *cobra.Command, args []string) {
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		printError("Failed to initialize process manager", err)
+		osExit(1)
+		return
+	}
+	if statusWatch && statusInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --interval must be positive")
+		osExit(1)
+		return
+	}
+
+	// The stack is described by asc.toml when there is one, and by the
+	// managed processes otherwise
+	var cfg *config.Config
+	if statusJSON {
+		if _, err := os.Stat(config.DefaultConfigPath()); err == nil {
+			if cfg, err = config.LoadContext(commandContext(cmd), config.DefaultConfigPath()); err != nil {
+				printError("Failed to load configuration", err)
+				osExit(1)
+				return
+			}
+		}
+	}
+
+	ctx := commandContext(cmd)
+	clear := statusWatch && !statusJSON && term.IsTerminal(os.Stdout.Fd())
+	for {
+		statuses, err := sampleStatus(procManager)
+		if err != nil {
+			printError("Failed to list processes", err)
+			osExit(1)
+			return
+		}
+		if statusJSON {
+			stack := newStackStatus(cfg, statuses, time.Now())
+			stack.probe(ctx, cfg)
+			line, err := json.Marshal(stack)
+			if err != nil {
+				printError("Failed to encode status", err)
+				osExit(1)
+				return
+			}
+			fmt.Println(string(line))
+		} else {
+			if clear {
+				fmt.Print("\033[H\033[2J")
+			}
+			fmt.Print(formatStatus(statuses))
+			if stream, ok := tuiStreamStatus(); ok {
+				fmt.Print(formatStreamStatus(stream, time.Now()))
+			}
+		}
+
+		if !statusWatch {
+			return
+		}
+		sleepContext(ctx, statusInterval)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// sampleStatus reads the state and resource usage of the managed
+// processes, sorted by name
+func sampleStatus(procManager *process.Manager) ([]processStatus, error) {
+	processes, err := procManager.ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].Name < processes[j].Name
+	})
+
+	statuses := make([]processStatus, len(processes))
+	running := false
+	for i, info := range processes {
+		statuses[i] = processStatus{info: info, running: process.Live(procManager, info)}
+		if statuses[i].running {
+			statuses[i].usage, statuses[i].err = procManager.Usage(info)
+			running = true
+		}
+	}
+
+	// Sample CPU time again to turn it into a rate
+	started := time.Now()
+	if running {
+		time.Sleep(statusSampleInterval)
+	}
+	for i := range statuses {
+		s := &statuses[i]
+		if !s.running || s.err != nil {
+			continue
+		}
+		usage, err := procManager.Usage(s.info)
+		if err != nil {
+			s.err = err
+			continue
+		}
+		if elapsed := time.Since(started); elapsed > 0 {
+			s.cpu = float64(usage.CPUTime-s.usage.CPUTime) / float64(elapsed) * 100
+		}
+		s.usage = usage
+	}
+	return statuses, nil
+}
+
+// tuiStreamStatus returns the state of the TUI's MCP event stream, which
+// the TUI records; the record of a TUI that exited is left out
+func tuiStreamStatus() (mcp.StreamStatus, bool) {
+	path, err := mcp.DefaultStreamStatePath()
+	if err != nil {
+		return mcp.StreamStatus{}, false
+	}
+	stream, err := mcp.ReadStreamStatus(path)
+	if err != nil || !process.Alive(stream.PID) {
+		return mcp.StreamStatus{}, false
+	}
+	return stream, true
+}
+
+// newStackStatus returns the state of the agents and services of cfg from
+// the managed processes. Without a config, processes named mcp_* are the
+// services and the others the agents.
+func newStackStatus(cfg *config.Config, statuses []processStatus, now time.Time) stackStatus {
+	stack := stackStatus{Time: now, Agents: []componentStatus{}, Services: []componentStatus{}}
+	byName := make(map[string]processStatus)
+	for _, s := range statuses {
+		byName[s.info.Name] = s
+	}
+
+	isService := func(name string) bool { return strings.HasPrefix(name, "mcp_") }
+	if cfg != nil {
+		for _, name := range managedMCPServers(cfg) {
+			stack.Services = append(stack.Services, componentOf(name, byName))
+		}
+		names := make([]string, 0, len(cfg.Agents))
+		for name := range cfg.Agents {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			component := componentOf(name, byName)
+			if component.Status == componentStopped && pipeline.Manages(cfg.Pipeline, cfg.Agents[name]) {
+				component.Status = componentPending
+			}
+			stack.Agents = append(stack.Agents, component)
+		}
+		isService = func(name string) bool {
+			_, ok := cfg.Services.MCPServer(name)
+			return ok
+		}
+	}
+
+	// Processes asc manages that asc.toml no longer names are listed too
+	listed := make(map[string]bool)
+	for _, component := range append(stack.Agents, stack.Services...) {
+		listed[component.Name] = true
+	}
+	for _, s := range statuses {
+		if listed[s.info.Name] {
+			continue
+		}
+		if isService(s.info.Name) {
+			stack.Services = append(stack.Services, componentOf(s.info.Name, byName))
+		} else {
+			stack.Agents = append(stack.Agents, componentOf(s.info.Name, byName))
+		}
+	}
+
+	if stream, ok := tuiStreamStatus(); ok {
+		stack.MCP.Stream = &stream
+	}
+	stack.MCP.Servers = []mcpServerHealth{}
+	stack.Healthy = stack.healthy()
+	return stack
+}
+
+// componentOf returns the state of the agent or service name from its
+// process, if it has one
+func componentOf(name string, byName map[string]processStatus) componentStatus {
+	component := componentStatus{Name: name, Status: componentStopped}
+	s, ok := byName[name]
+	if !ok {
+		return component
+	}
+	if s.info.Limits != nil {
+		component.Limits = s.info.Limits.String()
+	}
+	component.Restarts = s.info.Restarts
+	if !s.running {
+		return component
+	}
+
+	startedAt := s.info.StartedAt
+	component.Status = componentRunning
+	component.PID = s.info.PID
+	component.StartedAt = &startedAt
+	if s.info.Health != nil {
+		component.Health = string(s.info.Health.Status)
+		if s.info.Health.Status != process.HealthHealthy {
+			component.Error = s.info.Health.Error
+		}
+	}
+	if s.err != nil {
+		component.Error = s.err.Error()
+	} else {
+		component.MemoryBytes = s.usage.MemoryBytes
+		component.CPUPercent = s.cpu
+	}
+	return component
+}
+
+// probe asks the MCP servers of cfg what the agents report and beads for
+// its tasks, and updates whether the stack is healthy
+func (s *stackStatus) probe(ctx context.Context, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, statusProbeTimeout)
+	defer cancel()
+
+	states := make(map[string]mcp.AgentStatus)
+	for _, name := range cfg.Services.MCPServerNames() {
+		mcpCfg, _ := cfg.Services.MCPServer(name)
+		health := mcpServerHealth{Name: name, URL: mcpCfg.URL}
+		client, err := newMCPServerClient(name, mcpCfg)
+		if err == nil {
+			var agents []mcp.AgentStatus
+			agents, err = client.GetAllAgentStatuses(ctx, 2*time.Minute)
+			for _, agent := range agents {
+				states[agent.Name] = agent
+			}
+		}
+		if err != nil {
+			health.Error = err.Error()
+		} else {
+			health.Reachable = true
+		}
+		s.MCP.Servers = append(s.MCP.Servers, health)
+	}
+	for i := range s.Agents {
+		if state, ok := states[s.Agents[i].Name]; ok {
+			s.Agents[i].State = string(state.State)
+			s.Agents[i].CurrentTask = state.CurrentTask
+		}
+	}
+
+	s.Tasks = &taskSummary{ByStatus: map[string]int{}}
+	client, err := newBeadsClient(cfg)
+	if err == nil {
+		var tasks []beads.Task
+		tasks, err = client.GetTasks(ctx, nil)
+		for _, task := range tasks {
+			s.Tasks.Total++
+			s.Tasks.ByStatus[task.Status]++
+		}
+	}
+	if err != nil {
+		s.Tasks.Error = err.Error()
+	}
+
+	s.Healthy = s.healthy()
+}
+
+// healthy reports whether every agent and service runs, other than agents
+// waiting for their phase, none fails its health checks, and every MCP
+// server answers
+func (s *stackStatus) healthy() bool {
+	for _, component := range append(append([]componentStatus(nil), s.Agents...), s.Services...) {
+		if component.Status == componentStopped {
+			return false
+		}
+		if component.Health == string(process.HealthDegraded) || component.Health == string(process.HealthUnhealthy) {
+			return false
+		}
+	}
+	for _, server := range s.MCP.Servers {
+		if !server.Reachable {
+			return false
+		}
+	}
+	return true
+}
+
+// formatStreamStatus formats the state of the TUI's MCP event stream
+func formatStreamStatus(stream mcp.StreamStatus, now time.Time) string {
+	since := now.Sub(stream.Since).Round(time.Second)
+	switch stream.State {
+	case mcp.StreamConnected:
+		return "\n" + i18n.T("status.stream.connected", since) + "\n"
+	case mcp.StreamDegraded:
+		return "\n" + i18n.T("status.stream.degraded", since, stream.Attempts, stream.LastErr) + "\n"
+	default:
+		if stream.LastErr == "" {
+			return "\n" + i18n.T("status.stream.disconnected", since) + "\n"
+		}
+		return "\n" + i18n.T("status.stream.polling", since, stream.LastErr) + "\n"
+	}
+}
+
+// formatStatus formats the rows of asc status as a table
+func formatStatus(statuses []processStatus) string {
+	if len(statuses) == 0 {
+		return i18n.T("status.none") + "\n"
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%-20s %-10s %-10s %-8s %-10s %-7s %s\n", i18n.T("status.column.name"), i18n.T("status.column.status"), i18n.T("status.column.health"), "PID", i18n.T("status.column.memory"), "CPU", i18n.T("status.column.limits"))
+	for _, s := range statuses {
+		limits := i18n.T("status.limits.none")
+		if s.info.Limits != nil {
+			limits = s.info.Limits.String()
+		}
+		if !s.running {
+			fmt.Fprintf(&out, "%-20s %-10s %-10s %-8s %-10s %-7s %s\n", s.info.Name, "○ "+i18n.T("status.stopped"), "-", "-", "-", "-", limits)
+			continue
+		}
+		health := "-"
+		if s.info.Health != nil {
+			health = string(s.info.Health.Status)
+		}
+		memory, cpu := "?", "?"
+		if s.err == nil {
+			memory = fmt.Sprintf("%.1f MB", float64(s.usage.MemoryBytes)/(1024*1024))
+			cpu = fmt.Sprintf("%.1f%%", s.cpu)
+		}
+		fmt.Fprintf(&out, "%-20s %-10s %-10s %-8d %-10s %-7s %s\n", s.info.Name, "● "+i18n.T("status.running"), health, s.info.PID, memory, cpu, limits)
+	}
+	for _, s := range statuses {
+		if s.running && s.info.Health != nil && s.info.Health.Error != "" && s.info.Health.Status != process.HealthHealthy {
+			fmt.Fprintf(&out, "\n%s\n", i18n.T("status.health_failures", s.info.Name, s.info.Health.Failures, s.info.Health.Error))
+		}
+	}
+	return out.String()
+}
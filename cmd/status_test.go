@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/process"
+)
+
+// TestStatusCommand_NoProcesses tests status without managed processes
+func TestStatusCommand_NoProcesses(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	runStatus(statusCmd, []string{})
+	capture.Stop()
+
+	if !strings.Contains(capture.GetStdout(), "No managed processes") {
+		t.Errorf("Expected no processes, got: %s", capture.GetStdout())
+	}
+}
+
+// TestStatusCommand_Usage tests status of a running process with limits
+// and of a stopped one
+func TestStatusCommand_Usage(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	oldInterval := statusSampleInterval
+	statusSampleInterval = 0
+	t.Cleanup(func() { statusSampleInterval = oldInterval })
+
+	env.WritePIDFile("agent-1", fmt.Sprintf(`{"name": "agent-1", "pid": %d, "command": "python", "limits": {"max_memory_mb": 512, "cpu_limit": 1.5},
+		"health": {"status": "degraded", "failures": 3, "error": "exit status 1"}}`, os.Getpid()))
+	env.WritePIDFile("mcp_agent_mail", `{"name": "mcp_agent_mail", "pid": 1073741824, "command": "python"}`)
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	runStatus(statusCmd, []string{})
+	capture.Stop()
+
+	lines := strings.Split(capture.GetStdout(), "\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected a header and two processes, got: %s", capture.GetStdout())
+	}
+	agent, mcp := lines[1], lines[2]
+	for _, want := range []string{"agent-1", "● running", "degraded", fmt.Sprint(os.Getpid()), " MB", "%", "512 MB, 1.5 CPU"} {
+		if !strings.Contains(agent, want) {
+			t.Errorf("Expected %q in agent row, got: %s", want, agent)
+		}
+	}
+	for _, want := range []string{"mcp_agent_mail", "○ stopped", "none"} {
+		if !strings.Contains(mcp, want) {
+			t.Errorf("Expected %q in mcp_agent_mail row, got: %s", want, mcp)
+		}
+	}
+	if !strings.Contains(capture.GetStdout(), "agent-1: 3 failed health check(s) in a row: exit status 1") {
+		t.Errorf("Expected the failing health check to be explained, got: %s", capture.GetStdout())
+	}
+}
+
+// TestStatusCommand_EventStream tests the state of the TUI's MCP event
+// stream, shown only while the TUI runs
+func TestStatusCommand_EventStream(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	streamPath := filepath.Join(env.TempDir, ".asc", "mcp", "stream.json")
+	if err := os.MkdirAll(filepath.Dir(streamPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	since := time.Now().Add(-time.Minute).Format(time.RFC3339Nano)
+	for _, tt := range []struct {
+		pid  int
+		want string
+	}{
+		{os.Getpid(), "MCP event stream: ◐ degraded for 1m0s, reconnecting (3 failed attempt(s)): connection refused"},
+		{1073741824, ""},
+	} {
+		record := fmt.Sprintf(`{"state": "degraded", "since": %q, "attempts": 3, "last_error": "connection refused", "pid": %d}`, since, tt.pid)
+		if err := os.WriteFile(streamPath, []byte(record), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		capture := NewCaptureOutput()
+		capture.Start()
+		runStatus(statusCmd, []string{})
+		capture.Stop()
+
+		out := capture.GetStdout()
+		if tt.want == "" && strings.Contains(out, "MCP event stream") {
+			t.Errorf("Expected the stream of an exited TUI to be left out, got: %s", out)
+		}
+		if tt.want != "" && !strings.Contains(out, tt.want) {
+			t.Errorf("Expected %q, got: %s", tt.want, out)
+		}
+	}
+}
+
+// TestStatusCommand_JSON tests the state of the stack as JSON, watched
+// until the command is cancelled
+func TestStatusCommand_JSON(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+	t.Chdir(env.TempDir)
+	oldInterval, oldJSON, oldWatch := statusSampleInterval, statusJSON, statusWatch
+	statusSampleInterval, statusJSON, statusWatch = 0, true, true
+	t.Cleanup(func() { statusSampleInterval, statusJSON, statusWatch = oldInterval, oldJSON, oldWatch })
+
+	env.WritePIDFile("agent-1", fmt.Sprintf(`{"name": "agent-1", "pid": %d, "command": "python", "restarts": 2}`, os.Getpid()))
+	env.WritePIDFile("mcp_agent_mail", `{"name": "mcp_agent_mail", "pid": 1073741824, "command": "python"}`)
+
+	// Ctrl-C during the first wait ends watching
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	statusCmd.SetContext(ctx)
+	t.Cleanup(func() { statusCmd.SetContext(context.Background()) })
+
+	capture := NewCaptureOutput()
+	capture.Start()
+	runStatus(statusCmd, []string{})
+	capture.Stop()
+
+	var stack stackStatus
+	if err := json.Unmarshal([]byte(capture.GetStdout()), &stack); err != nil {
+		t.Fatalf("Expected one JSON object, got %v: %s", err, capture.GetStdout())
+	}
+	if stack.Healthy {
+		t.Error("Expected a stack with a stopped service not to be healthy")
+	}
+	if len(stack.Agents) != 1 || stack.Agents[0].Status != componentRunning || stack.Agents[0].PID != os.Getpid() || stack.Agents[0].Restarts != 2 {
+		t.Errorf("Unexpected agents: %+v", stack.Agents)
+	}
+	if len(stack.Services) != 1 || stack.Services[0].Name != "mcp_agent_mail" || stack.Services[0].Status != componentStopped {
+		t.Errorf("Unexpected services: %+v", stack.Services)
+	}
+}
+
+// TestStackStatus tests the agents and services of asc.toml, and what
+// the MCP servers answer
+func TestStackStatus(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"name": "coder", "state": "working", "current_task": "bd-7", "last_seen": %q}]`, time.Now().Format(time.RFC3339Nano))
+	}))
+	defer ts.Close()
+
+	noRetries := 0
+	cfg := &config.Config{
+		Core: config.CoreConfig{BeadsDBPath: t.TempDir()},
+		Services: config.ServicesConfig{
+			MCPAgentMail: config.MCPConfig{URL: ts.URL, StartCommand: "python -m mcp_agent_mail.server"},
+			MCPServers: map[string]config.MCPConfig{
+				"mcp_remote": {URL: "http://127.0.0.1:1", MaxRetries: &noRetries},
+			},
+		},
+		Agents: map[string]config.AgentConfig{
+			"coder":    {Command: "python agent.py", Phases: []string{"implementation"}},
+			"reviewer": {Command: "python agent.py", Phases: []string{"review"}},
+		},
+		Pipeline: config.PipelineConfig{Phases: []string{"review"}},
+	}
+	statuses := []processStatus{
+		{info: &process.ProcessInfo{Name: "coder", PID: 42, Health: &process.Health{Status: process.HealthHealthy}}, running: true},
+		{info: &process.ProcessInfo{Name: "mcp_agent_mail", PID: 43}, running: true},
+		{info: &process.ProcessInfo{Name: "retired", PID: 44}, running: true},
+	}
+
+	stack := newStackStatus(cfg, statuses, time.Now())
+	var names []string
+	for _, agent := range stack.Agents {
+		names = append(names, agent.Name+":"+agent.Status)
+	}
+	if got := strings.Join(names, " "); got != "coder:running reviewer:pending retired:running" {
+		t.Errorf("Agents = %s", got)
+	}
+	if !stack.Healthy {
+		t.Error("Expected the stack to be healthy before the MCP servers are asked")
+	}
+
+	stack.probe(context.Background(), cfg)
+	if stack.Agents[0].State != "working" || stack.Agents[0].CurrentTask != "bd-7" {
+		t.Errorf("Expected what coder reports to its MCP server, got %+v", stack.Agents[0])
+	}
+	if len(stack.MCP.Servers) != 2 || !stack.MCP.Servers[0].Reachable || stack.MCP.Servers[1].Reachable {
+		t.Errorf("Expected mcp_agent_mail to answer and mcp_remote not to, got %+v", stack.MCP.Servers)
+	}
+	if stack.Healthy {
+		t.Error("Expected a stack with an MCP server that does not answer not to be healthy")
+	}
+	if stack.Tasks == nil {
+		t.Error("Expected the tasks to be reported")
+	}
+}
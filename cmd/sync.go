@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/tasksync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncRepo     string
+	syncJQL      string
+	syncConflict string
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync beads tasks with an issue tracker",
+}
+
+var syncGitHubCmd = &cobra.Command{
+	Use:   "github",
+	Short: "Sync beads tasks with the issues of a GitHub repository, both ways",
+	Long: `Sync the beads tasks of core.beads_db_path with the issues of a GitHub
+repository, so the team can triage in GitHub without keeping a second
+source of truth.
+
+Open tasks without an issue become issues, and open issues without a task
+become tasks (only those labeled sync.github.label, if set). For linked
+tasks and issues, a change made on either side since the last sync is
+applied to the other:
+
+  title      the task's title and the issue's
+  status     closed tasks close their issue, closed issues close their
+             task, and reopened issues reopen it
+  assignee   the task's assignee and the issue's, mapped to GitHub logins
+             with [sync.github.assignees]
+  labels     merged: labels added or removed on either side are added or
+             removed on both
+
+A title, status, or assignee changed on both sides is a conflict, which
+--conflict (or sync.github.conflict) resolves: github keeps the issue's
+change, beads the task's, and skip leaves both unchanged and reports the
+conflict again until one side is changed to match.
+
+The token is read from $GITHUB_TOKEN (or sync.github.token_env). Links are
+kept in ~/.asc/sync; deleting a task or an issue stops syncing the other.`,
+	Example: `  asc sync github --repo acme/web
+  asc sync github --conflict skip --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runSyncGitHub,
+}
+
+var syncJiraCmd = &cobra.Command{
+	Use:   "jira",
+	Short: "Import the Jira issues of a JQL query as beads tasks, and transition them",
+	Long: `Import the Jira issues of a JQL query as beads tasks of core.beads_db_path,
+and push the status of the tasks back to Jira, so agents can be driven
+from an existing backlog.
+
+Issues of the query (--jql or sync.jira.jql) without a task become tasks,
+titled, assigned, and labeled as in Jira. Jira stays the source of the
+backlog: on each sync, the titles, assignees, and labels of linked tasks
+are updated from their issue, and an issue whose status changed in Jira
+updates its task. A task whose status changed transitions its issue to
+the Jira status sync.jira.statuses maps it to (by default open is "To
+Do", in_progress "In Progress", and closed "Done"), through a transition
+of the issue's workflow. Jira statuses not mapped are synced by category:
+done ones close the task, and the others leave it open.
+
+A status changed on both sides is a conflict, which --conflict (or
+sync.jira.conflict) resolves: jira keeps the issue's status, beads the
+task's, and skip leaves both unchanged and reports the conflict again
+until one side is changed to match.
+
+The API token is read from $JIRA_API_TOKEN (or sync.jira.token_env); set
+sync.jira.email for a Jira Cloud token, or leave it empty for a Data
+Center personal access token. Links are kept in ~/.asc/sync; issues that
+leave the query keep syncing with their task.`,
+	Example: `  asc sync jira
+  asc sync jira --jql 'project = WEB AND sprint in openSprints()'
+  asc sync jira --conflict beads --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runSyncJira,
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.AddCommand(syncGitHubCmd)
+	syncCmd.AddCommand(syncJiraCmd)
+	syncGitHubCmd.Flags().StringVar(&syncRepo, "repo", "", "Repository as owner/name (default: sync.github.repo)")
+	syncGitHubCmd.Flags().StringVar(&syncConflict, "conflict", "", "Conflict rule: github, beads, or skip (default: sync.github.conflict)")
+	syncJiraCmd.Flags().StringVar(&syncJQL, "jql", "", "JQL query of the issues to import (default: sync.jira.jql)")
+	syncJiraCmd.Flags().StringVar(&syncConflict, "conflict", "", "Conflict rule: jira, beads, or skip (default: sync.jira.conflict)")
+}
+
+func runSyncGitHub(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	github := cfg.Sync.GitHub
+	conflict, err := conflictRule("github", github.Conflict)
+	if err != nil {
+		return err
+	}
+	tracker, err := tasksync.NewGitHub(github, syncRepo)
+	if err != nil {
+		return err
+	}
+	return runSync(cmd, cfg, tracker, tasksync.Options{
+		Conflict:  conflict,
+		Label:     github.Label,
+		Assignees: github.Assignees,
+	})
+}
+
+func runSyncJira(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	jira := cfg.Sync.Jira
+	conflict, err := conflictRule("jira", jira.Conflict)
+	if err != nil {
+		return err
+	}
+	tracker, err := tasksync.NewJira(jira, syncJQL)
+	if err != nil {
+		return err
+	}
+	return runSync(cmd, cfg, tracker, tasksync.Options{
+		Conflict:  conflict,
+		Assignees: jira.Assignees,
+	})
+}
+
+// conflictRule returns the rule of --conflict, or else of the configured
+// one, named after the tracker whose change wins
+func conflictRule(tracker, configured string) (string, error) {
+	conflict := configured
+	if syncConflict != "" {
+		conflict = syncConflict
+	}
+	switch conflict {
+	case tracker:
+		return tasksync.ConflictIssue, nil
+	case "beads":
+		return tasksync.ConflictTask, nil
+	case "skip":
+		return tasksync.ConflictSkip, nil
+	}
+	return "", fmt.Errorf("--conflict must be %s, beads, or skip, got '%s'", tracker, conflict)
+}
+
+// runSync syncs the tasks with a tracker and prints the report
+func runSync(cmd *cobra.Command, cfg *config.Config, tracker tasksync.Tracker, options tasksync.Options) error {
+	client, err := newBeadsClient(cfg)
+	if err != nil {
+		return err
+	}
+	options.DryRun = dryRun
+	report, err := tasksync.Sync(commandContext(cmd), client, tracker, options)
+	if report != nil {
+		fmt.Print(formatSyncReport(report, dryRun))
+	}
+	return err
+}
+
+// formatSyncReport formats the actions and conflicts of a sync, then a
+// summary
+func formatSyncReport(report *tasksync.Report, dryRun bool) string {
+	var out strings.Builder
+	for _, action := range report.Actions {
+		if dryRun {
+			fprintDryRun(&out, "dry_run.action", action)
+		} else {
+			fmt.Fprintf(&out, "✓ %s\n", action)
+		}
+	}
+	for _, conflict := range report.Conflicts {
+		fmt.Fprintf(&out, "⚠ %s\n", conflict)
+	}
+	if len(report.Actions) == 0 && len(report.Conflicts) == 0 {
+		out.WriteString(i18n.T("sync.in_sync") + "\n")
+	}
+	fmt.Fprintln(&out, i18n.T("sync.summary", report.Linked, len(report.Actions), len(report.Conflicts)))
+	return out.String()
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/tasksync"
+)
+
+func TestFormatSyncReport(t *testing.T) {
+	if got := formatSyncReport(&tasksync.Report{Linked: 3}, false); !strings.Contains(got, "Already in sync") || !strings.Contains(got, "3 linked task(s)") {
+		t.Errorf("Unexpected report:\n%s", got)
+	}
+	report := &tasksync.Report{
+		Actions:   []tasksync.Action{{Kind: tasksync.UpdateIssue, Task: "bd-1", Issue: "#12", Detail: "closed"}},
+		Conflicts: []tasksync.Conflict{{Task: "bd-1", Issue: "#12", Field: "title", TaskValue: "A", IssueValue: "B", Rule: tasksync.ConflictSkip}},
+	}
+	got := formatSyncReport(report, true)
+	for _, want := range []string{"Dry run: would update issue #12 from bd-1: closed", "⚠ bd-1 and issue #12 both changed title", "left both unchanged", "1 conflict(s)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the report, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestSyncGitHub(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	var created []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `[]`)
+		case http.MethodPost:
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			created = append(created, body)
+			fmt.Fprintf(w, `{"number":%d,"title":%q,"state":"open"}`, len(created), body["title"])
+		}
+	}))
+	defer server.Close()
+
+	env := NewTestEnvironment(t)
+	defer ChangeToTempDir(t, env.TempDir)()
+	t.Setenv(statedir.EnvVar, t.TempDir())
+	t.Setenv("GITHUB_TOKEN", "secret")
+	base := strings.Replace(strings.Split(budgetTestConfig, "[budget]")[0], `"./project-repo"`, `"."`, 1)
+	env.WriteConfig(base + fmt.Sprintf("\n[sync.github]\nrepo = \"acme/web\"\napi_url = %q\nlabel = \"asc\"\n", server.URL))
+
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+echo '[{"id":"bd-1","title":"API","status":"open"},{"id":"bd-2","title":"Done","status":"closed"}]'
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	defer func() { syncRepo, syncConflict = "", "" }()
+
+	dryRun = true
+	if err := rootCmd.PersistentPreRunE(syncGitHubCmd, nil); err != nil {
+		dryRun = false
+		t.Fatalf("Expected asc sync github to accept --dry-run, got %v", err)
+	}
+	output := NewCaptureOutput()
+	output.Start()
+	err := runSyncGitHub(syncGitHubCmd, nil)
+	output.Stop()
+	dryRun = false
+	if err != nil {
+		t.Fatalf("runSyncGitHub() error = %v", err)
+	}
+	if !strings.Contains(output.GetStdout(), "Dry run: would create issue for bd-1: API") || len(created) != 0 {
+		t.Errorf("Expected a dry run, got:\n%s", output.GetStdout())
+	}
+
+	output = NewCaptureOutput()
+	output.Start()
+	err = runSyncGitHub(syncGitHubCmd, nil)
+	output.Stop()
+	if err != nil {
+		t.Fatalf("runSyncGitHub() error = %v", err)
+	}
+	if !strings.Contains(output.GetStdout(), "✓ create issue for bd-1: API") || len(created) != 1 || created[0]["labels"].([]interface{})[0] != "asc" {
+		t.Errorf("Expected bd-1 to be exported with the sync label, got %v:\n%s", created, output.GetStdout())
+	}
+
+	syncConflict = "newest"
+	if err := runSyncGitHub(syncGitHubCmd, nil); err == nil || !strings.Contains(err.Error(), "--conflict") {
+		t.Errorf("Expected an invalid conflict rule to fail, got %v", err)
+	}
+	syncConflict, syncRepo = "", "acme"
+	if err := runSyncGitHub(syncGitHubCmd, nil); err == nil || !strings.Contains(err.Error(), "owner/name") {
+		t.Errorf("Expected an invalid repository to fail, got %v", err)
+	}
+}
+
+func TestSyncJira(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	var searches []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		searches = append(searches, fmt.Sprint(body["jql"]))
+		fmt.Fprint(w, `{"total":1,"issues":[{"key":"WEB-1","fields":{"summary":"Login","status":{"name":"To Do","statusCategory":{"key":"new"}}}}]}`)
+	}))
+	defer server.Close()
+
+	env := NewTestEnvironment(t)
+	defer ChangeToTempDir(t, env.TempDir)()
+	t.Setenv(statedir.EnvVar, t.TempDir())
+	t.Setenv("JIRA_API_TOKEN", "secret")
+	base := strings.Replace(strings.Split(budgetTestConfig, "[budget]")[0], `"./project-repo"`, `"."`, 1)
+	env.WriteConfig(base + fmt.Sprintf("\n[sync.jira]\nurl = %q\njql = \"project = WEB\"\n", server.URL))
+
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	script := `#!/bin/sh
+echo "$@" >> "` + argsFile + `"
+case "$2" in
+create) echo "{\"id\":\"bd-9\",\"title\":\"$3\",\"status\":\"open\"}" ;;
+*) echo '[{"id":"bd-1","title":"API","status":"open"}]' ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	defer func() { syncJQL, syncConflict = "", "" }()
+
+	syncJQL = "project = WEB AND sprint in openSprints()"
+	dryRun = true
+	if err := rootCmd.PersistentPreRunE(syncJiraCmd, nil); err != nil {
+		dryRun = false
+		t.Fatalf("Expected asc sync jira to accept --dry-run, got %v", err)
+	}
+	output := NewCaptureOutput()
+	output.Start()
+	err := runSyncJira(syncJiraCmd, nil)
+	output.Stop()
+	dryRun = false
+	if err != nil {
+		t.Fatalf("runSyncJira() error = %v", err)
+	}
+	if !strings.Contains(output.GetStdout(), "Dry run: would create task for issue WEB-1: Login") {
+		t.Errorf("Expected a dry run, got:\n%s", output.GetStdout())
+	}
+	if args, _ := os.ReadFile(argsFile); strings.Contains(string(args), "create") {
+		t.Errorf("Expected the dry run not to create tasks, got:\n%s", args)
+	}
+
+	output = NewCaptureOutput()
+	output.Start()
+	err = runSyncJira(syncJiraCmd, nil)
+	output.Stop()
+	if err != nil {
+		t.Fatalf("runSyncJira() error = %v", err)
+	}
+	if !strings.Contains(output.GetStdout(), "✓ create task for issue WEB-1: Login") || strings.Contains(output.GetStdout(), "create issue") {
+		t.Errorf("Expected WEB-1 to be imported and bd-1 not exported, got:\n%s", output.GetStdout())
+	}
+	if len(searches) == 0 || searches[0] != syncJQL {
+		t.Errorf("Expected --jql to be searched, got %v", searches)
+	}
+	if args, _ := os.ReadFile(argsFile); !strings.Contains(string(args), "create Login") {
+		t.Errorf("Expected a task to be created, got:\n%s", args)
+	}
+
+	syncConflict = "github"
+	if err := runSyncJira(syncJiraCmd, nil); err == nil || !strings.Contains(err.Error(), "--conflict must be jira, beads, or skip") {
+		t.Errorf("Expected an invalid conflict rule to fail, got %v", err)
+	}
+}
@@ -0,0 +1,583 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tasksQuery      string
+	tasksJSON       bool
+	tasksBulkFormat string
+)
+
+var tasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "List and change the beads tasks of core.beads_db_path",
+}
+
+var tasksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tasks, optionally those matching a query",
+	Long: `List the tasks of the beads repository in core.beads_db_path, all of them
+or those matching --query.
+
+A query is made of space-separated terms, all of which a task must match:
+
+  status:open              the status is open (status:open,in_progress for either)
+  label:backend            the task has the label backend
+  priority>=2              the priority compares so (also >, <, <=, =, and !=)
+  assignee:""              the task is unassigned
+  title:"login page"       the title contains the words
+  -label:frontend          negates a term
+  crash                    the title or ID contains the word
+
+The fields are id, title, status, phase, assignee, label, and priority.
+Priorities range from 0, the highest, to 4. The TUI filters its task pane
+with the same queries (press f).`,
+	Example: `  asc tasks list
+  asc tasks list --query "status:open label:backend priority<=1"
+  asc tasks list --query "-status:closed assignee:\"\"" --json`,
+	Args: cobra.NoArgs,
+	RunE: runTasksList,
+}
+
+var tasksBulkCmd = &cobra.Command{
+	Use:   "bulk <create|update|close>",
+	Short: "Create, update, or close many tasks read from stdin",
+	Long: `Create, update, or close the tasks read from stdin, as JSON (an array
+or JSON Lines of objects) or CSV with a header row. The fields are id,
+title, status, phase, priority, labels, and assignee; labels are a JSON
+array, or comma-separated in CSV.
+
+  create   creates a task of each record, which needs a title and may
+           set phase, priority, labels, and assignee
+  update   sets the fields given on the task of each record's id, with
+           labels replacing the task's; an empty CSV cell leaves its
+           field unchanged
+  close    closes the task of each record's id
+
+With the SQLite client (see core.beads_mode) each batch is one
+transaction: all of it applies or none does. With bd, updates with the
+same fields share one bd update, closes one bd close, and creation stops
+at the first task bd fails to create.`,
+	Example: `  asc tasks bulk create < sprint.json
+  asc tasks bulk create --format csv < sprint.csv
+  echo '{"id":"bd-4","status":"in_progress"}' | asc tasks bulk update
+  asc tasks list --query status:open --json | asc tasks bulk close`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"create", "update", "close"},
+	RunE:      runTasksBulk,
+}
+
+var tasksHistoryCmd = &cobra.Command{
+	Use:   "history <id>",
+	Short: "Show who changed the status and assignee of a task, and when",
+	Long: `Show the recorded history of a task: its creation, each status transition
+and reassignment, and its deletion, with when it happened and who made it.
+
+Changes made through asc (the TUI, asc tasks, asc sync) are recorded as
+they are made, by the agent of $AGENT_NAME or else the user. Changes made
+with bd, as agents do, are recorded when asc next lists the tasks, and
+attributed to the task's assignee; they are marked with *. The history is
+kept in ~/.asc/history, so it covers the changes since asc first listed
+the tasks on this machine.`,
+	Example: `  asc tasks history bd-12
+  asc tasks history bd-12 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTasksHistory,
+}
+
+var tasksWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Print task changes as they happen",
+	Long: `Print each task that is created, updated, or deleted in the beads
+repository in core.beads_db_path as it happens, until interrupted.
+
+With bd, the tasks are listed again each time bd writes a change to the
+JSONL files of .beads; with the SQLite client (see core.beads_mode), each
+time the database or its write-ahead log is written. Only the tasks that
+changed are printed.`,
+	Example: `  asc tasks watch
+  asc tasks watch --json | jq -c 'select(.task.status == "closed")'`,
+	Args: cobra.NoArgs,
+	RunE: runTasksWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(tasksCmd)
+	tasksCmd.AddCommand(tasksListCmd)
+	tasksCmd.AddCommand(tasksBulkCmd)
+	tasksCmd.AddCommand(tasksHistoryCmd)
+	tasksCmd.AddCommand(tasksWatchCmd)
+	tasksListCmd.Flags().StringVarP(&tasksQuery, "query", "q", "", "Only list tasks matching this query, e.g. \"status:open label:backend\"")
+	tasksListCmd.Flags().BoolVar(&tasksJSON, "json", false, "Output tasks as JSON Lines")
+	tasksBulkCmd.Flags().StringVar(&tasksBulkFormat, "format", "", "Input format: json or csv (default: detected from the input)")
+	tasksHistoryCmd.Flags().BoolVar(&tasksJSON, "json", false, "Output events as JSON Lines")
+	tasksWatchCmd.Flags().BoolVar(&tasksJSON, "json", false, "Output changes as JSON Lines")
+}
+
+func runTasksList(cmd *cobra.Command, args []string) error {
+	// Reject an invalid query before running anything
+	if _, err := beads.ParseQuery(tasksQuery); err != nil {
+		return err
+	}
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newBeadsClient(cfg)
+	if err != nil {
+		return err
+	}
+	tasks, err := listTasks(cmd, client, tasksQuery)
+	if err != nil {
+		return err
+	}
+
+	if tasksJSON {
+		for _, task := range tasks {
+			line, err := json.Marshal(task)
+			if err != nil {
+				continue
+			}
+			fmt.Println(string(line))
+		}
+		return nil
+	}
+	fmt.Print(formatTaskList(tasks))
+	return nil
+}
+
+// listTasks returns the tasks of client matching query
+func listTasks(cmd *cobra.Command, client beads.BeadsClient, query string) ([]beads.Task, error) {
+	if queryClient, ok := client.(beads.QueryClient); ok {
+		return queryClient.GetTasksFiltered(commandContext(cmd), query)
+	}
+	q, err := beads.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := client.GetTasks(commandContext(cmd), nil)
+	if err != nil {
+		return nil, err
+	}
+	return beads.FilterTasks(tasks, q), nil
+}
+
+// formatTaskList formats tasks as a table, with their labels after the
+// title
+func formatTaskList(tasks []beads.Task) string {
+	if len(tasks) == 0 {
+		return i18n.T("tasks.none") + "\n"
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "%-12s %-4s %-12s %-16s %-14s %s\n", "ID", i18n.T("tasks.column.priority"), i18n.T("status.column.status"), i18n.T("tasks.column.phase"), i18n.T("tasks.column.assignee"), i18n.T("tasks.column.title"))
+	for _, task := range tasks {
+		title := task.Title
+		if len(task.Labels) > 0 {
+			title += " [" + strings.Join(task.Labels, ", ") + "]"
+		}
+		fmt.Fprintf(&out, "%-12s %-4s %-12s %-16s %-14s %s\n", task.ID, fmt.Sprintf("P%d", task.Priority),
+			task.Status, task.Phase, task.Assignee, title)
+	}
+	return out.String()
+}
+
+func runTasksHistory(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newBeadsClient(cfg)
+	if err != nil {
+		return err
+	}
+	historyClient, ok := client.(beads.HistoryClient)
+	if !ok {
+		return fmt.Errorf("the beads client does not record task history")
+	}
+	// Record the changes made with bd since the tasks were last listed
+	if _, err := client.GetTasks(commandContext(cmd), nil); err != nil {
+		return err
+	}
+	events, err := historyClient.TaskHistory(commandContext(cmd), args[0])
+	if err != nil {
+		return err
+	}
+
+	if tasksJSON {
+		for _, event := range events {
+			line, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Println(string(line))
+		}
+		return nil
+	}
+	fmt.Print(formatTaskHistory(args[0], events))
+	return nil
+}
+
+// formatTaskHistory formats the events of a task as a table, oldest
+// first, marking those seen in beads
+func formatTaskHistory(id string, events []beads.TaskEvent) string {
+	if len(events) == 0 {
+		return i18n.T("tasks.history.none", id) + "\n"
+	}
+	var out strings.Builder
+	seen := false
+	fmt.Fprintf(&out, "%-19s  %-14s %s\n", i18n.T("tasks.column.time"), i18n.T("tasks.column.actor"), i18n.T("tasks.column.change"))
+	for _, event := range events {
+		actor := event.Actor
+		if actor == "" {
+			actor = i18n.T("tasks.history.unknown")
+		}
+		if event.Source == beads.SourceBeads {
+			actor += "*"
+			seen = true
+		}
+		fmt.Fprintf(&out, "%-19s  %-14s %s\n", event.Time.Local().Format(time.DateTime), actor, event.Change())
+	}
+	if seen {
+		out.WriteString(i18n.T("tasks.history.seen") + "\n")
+	}
+	return out.String()
+}
+
+// bulkRecord is a record of asc tasks bulk input. Fields left out are
+// nil.
+type bulkRecord struct {
+	ID       string   `json:"id"`
+	Title    *string  `json:"title"`
+	Status   *string  `json:"status"`
+	Phase    *string  `json:"phase"`
+	Priority *int     `json:"priority"`
+	Labels   []string `json:"labels"`
+	Assignee *string  `json:"assignee"`
+}
+
+func runTasksBulk(cmd *cobra.Command, args []string) error {
+	action := args[0]
+	if action != "create" && action != "update" && action != "close" {
+		return fmt.Errorf("unknown bulk action '%s' (want create, update, or close)", action)
+	}
+	records, err := readBulkRecords(cmd.InOrStdin(), tasksBulkFormat)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return errors.New("no tasks on stdin")
+	}
+	// Reject invalid input before changing anything
+	var creates []beads.NewTask
+	var updates []beads.BulkUpdate
+	var ids []string
+	switch action {
+	case "create":
+		creates, err = bulkCreates(records)
+	case "update":
+		updates, err = bulkUpdates(records)
+	case "close":
+		ids, err = bulkIDs(records)
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		for _, task := range creates {
+			printDryRun("tasks.dry_run.create", task.Title)
+		}
+		for _, update := range updates {
+			printDryRun("tasks.dry_run.update", update.ID)
+		}
+		for _, id := range ids {
+			printDryRun("tasks.dry_run.close", id)
+		}
+		return nil
+	}
+
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newBeadsClient(cfg)
+	if err != nil {
+		return err
+	}
+	bulk, ok := client.(beads.BulkClient)
+	if !ok {
+		return errors.New("the beads client does not support bulk operations")
+	}
+	ctx := commandContext(cmd)
+	switch action {
+	case "create":
+		created, err := bulk.CreateTasks(ctx, creates)
+		for _, task := range created {
+			fmt.Printf("%-12s %s\n", task.ID, task.Title)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("tasks.created", len(created)))
+	case "update":
+		if err := bulk.UpdateTasks(ctx, updates); err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("tasks.updated", len(updates)))
+	case "close":
+		if err := bulk.CloseTasks(ctx, ids); err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("tasks.closed", len(ids)))
+	}
+	return nil
+}
+
+// readBulkRecords reads the records of r in format, json or csv, or the
+// one its first character suggests if format is empty
+func readBulkRecords(r io.Reader, format string) ([]bulkRecord, error) {
+	reader := bufio.NewReader(r)
+	if format == "" {
+		format = "csv"
+		for {
+			b, err := reader.ReadByte()
+			if err != nil {
+				break
+			}
+			if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+				continue
+			}
+			if b == '[' || b == '{' {
+				format = "json"
+			}
+			_ = reader.UnreadByte()
+			break
+		}
+	}
+	switch format {
+	case "json":
+		return readBulkJSON(reader)
+	case "csv":
+		return readBulkCSV(reader)
+	}
+	return nil, fmt.Errorf("unknown format '%s' (want json or csv)", format)
+}
+
+// readBulkJSON reads a JSON array of records, or JSON Lines of them
+func readBulkJSON(r *bufio.Reader) ([]bulkRecord, error) {
+	decoder := json.NewDecoder(r)
+	var records []bulkRecord
+	for {
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid JSON input: %w", err)
+		}
+		var batch []bulkRecord
+		if strings.HasPrefix(strings.TrimSpace(string(value)), "[") {
+			if err := json.Unmarshal(value, &batch); err != nil {
+				return nil, fmt.Errorf("invalid JSON input: %w", err)
+			}
+		} else {
+			var record bulkRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return nil, fmt.Errorf("invalid JSON input: record %d: %w", len(records)+1, err)
+			}
+			batch = []bulkRecord{record}
+		}
+		records = append(records, batch...)
+	}
+}
+
+// readBulkCSV reads CSV records with a header row naming their fields
+func readBulkCSV(r *bufio.Reader) ([]bulkRecord, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV input: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	header := rows[0]
+	for _, column := range header {
+		switch strings.TrimSpace(strings.ToLower(column)) {
+		case "id", "title", "status", "phase", "priority", "labels", "assignee":
+		default:
+			return nil, fmt.Errorf("unknown CSV column '%s' (want id, title, status, phase, priority, labels, or assignee)", column)
+		}
+	}
+	records := make([]bulkRecord, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		var record bulkRecord
+		for i, value := range row {
+			value := strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			switch strings.TrimSpace(strings.ToLower(header[i])) {
+			case "id":
+				record.ID = value
+			case "title":
+				record.Title = &value
+			case "status":
+				record.Status = &value
+			case "phase":
+				record.Phase = &value
+			case "assignee":
+				record.Assignee = &value
+			case "priority":
+				priority, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid priority '%s'", line+2, value)
+				}
+				record.Priority = &priority
+			case "labels":
+				for _, label := range strings.Split(value, ",") {
+					if label = strings.TrimSpace(label); label != "" {
+						record.Labels = append(record.Labels, label)
+					}
+				}
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// validatePriority rejects priorities outside bd's range of 0 to 4
+func validatePriority(record int, priority *int) error {
+	if priority != nil && (*priority < 0 || *priority > 4) {
+		return fmt.Errorf("record %d: priority must be 0 to 4, got %d", record, *priority)
+	}
+	return nil
+}
+
+// bulkCreates returns the tasks to create of records
+func bulkCreates(records []bulkRecord) ([]beads.NewTask, error) {
+	tasks := make([]beads.NewTask, 0, len(records))
+	for i, record := range records {
+		if record.Title == nil || strings.TrimSpace(*record.Title) == "" {
+			return nil, fmt.Errorf("record %d: a title is required to create a task", i+1)
+		}
+		if record.ID != "" || record.Status != nil {
+			return nil, fmt.Errorf("record %d: new tasks cannot set id or status", i+1)
+		}
+		if err := validatePriority(i+1, record.Priority); err != nil {
+			return nil, err
+		}
+		task := beads.NewTask{Title: *record.Title, Priority: record.Priority, Labels: record.Labels}
+		if record.Phase != nil {
+			task.Phase = *record.Phase
+		}
+		if record.Assignee != nil {
+			task.Assignee = *record.Assignee
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// bulkUpdates returns the updates of records
+func bulkUpdates(records []bulkRecord) ([]beads.BulkUpdate, error) {
+	updates := make([]beads.BulkUpdate, 0, len(records))
+	for i, record := range records {
+		if record.ID == "" {
+			return nil, fmt.Errorf("record %d: an id is required to update a task", i+1)
+		}
+		if err := validatePriority(i+1, record.Priority); err != nil {
+			return nil, err
+		}
+		update := beads.TaskUpdate{Title: record.Title, Status: record.Status, Phase: record.Phase, Priority: record.Priority, Assignee: record.Assignee}
+		if record.Labels != nil {
+			labels := record.Labels
+			update.Labels = &labels
+		}
+		if update == (beads.TaskUpdate{}) {
+			return nil, fmt.Errorf("record %d: nothing to update on task %s", i+1, record.ID)
+		}
+		updates = append(updates, beads.BulkUpdate{ID: record.ID, TaskUpdate: update})
+	}
+	return updates, nil
+}
+
+// bulkIDs returns the task IDs of records
+func bulkIDs(records []bulkRecord) ([]string, error) {
+	ids := make([]string, 0, len(records))
+	for i, record := range records {
+		if record.ID == "" {
+			return nil, fmt.Errorf("record %d: an id is required to close a task", i+1)
+		}
+		ids = append(ids, record.ID)
+	}
+	return ids, nil
+}
+
+func runTasksWatch(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newBeadsClient(cfg)
+	if err != nil {
+		return err
+	}
+	watcher, ok := client.(beads.TaskWatcher)
+	if !ok {
+		return fmt.Errorf("the beads client cannot watch task changes")
+	}
+	changes, err := watcher.Watch(commandContext(cmd))
+	if err != nil {
+		return fmt.Errorf("failed to watch tasks: %w", err)
+	}
+
+	// The first batch is the tasks as they are, not changes
+	first := true
+	for batch := range changes {
+		if first {
+			first = false
+			if !tasksJSON {
+				fmt.Println(i18n.T("tasks.watching", len(batch), cfg.Core.BeadsDBPath))
+			}
+			continue
+		}
+		for _, change := range batch {
+			if tasksJSON {
+				line, err := json.Marshal(change)
+				if err != nil {
+					continue
+				}
+				fmt.Println(string(line))
+				continue
+			}
+			fmt.Print(formatTaskChange(change, time.Now()))
+		}
+	}
+	return nil
+}
+
+// formatTaskChange formats a task change as one line, e.g.
+// "15:04:05  updated  bd-1  in_progress  Fix login @coder"
+func formatTaskChange(change beads.TaskChange, now time.Time) string {
+	task := change.Task
+	line := fmt.Sprintf("%s  %-7s  %-10s %-11s %s", now.Format("15:04:05"), change.Kind, task.ID, task.Status, task.Title)
+	if task.Assignee != "" {
+		line += " @" + task.Assignee
+	}
+	return line + "\n"
+}
@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/statedir"
+)
+
+func TestFormatTaskList(t *testing.T) {
+	if got := formatTaskList(nil); got != "No tasks\n" {
+		t.Errorf("formatTaskList(nil) = %q", got)
+	}
+	got := formatTaskList([]beads.Task{{ID: "bd-1", Title: "API", Status: "open", Phase: "implementation", Priority: 1, Labels: []string{"backend", "auth"}, Assignee: "coder"}})
+	for _, want := range []string{"ID", "PRI", "bd-1", "P1", "implementation", "coder", "API [backend, auth]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the list, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestTasksList(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	env := NewTestEnvironment(t)
+	defer ChangeToTempDir(t, env.TempDir)()
+	t.Setenv(statedir.EnvVar, t.TempDir())
+	env.WriteConfig(strings.Replace(strings.Split(budgetTestConfig, "[budget]")[0], `"./project-repo"`, `"."`, 1))
+
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+echo '[{"id":"bd-1","title":"API","status":"open","priority":1,"labels":["backend"]},{"id":"bd-2","title":"UI","status":"open","priority":3,"labels":["frontend"]}]'
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	defer func() { tasksQuery, tasksJSON = "", false }()
+
+	tasksQuery = "label:backend"
+	output := NewCaptureOutput()
+	output.Start()
+	err := runTasksList(tasksListCmd, nil)
+	output.Stop()
+	if err != nil {
+		t.Fatalf("runTasksList() error = %v", err)
+	}
+	if stdout := output.GetStdout(); !strings.Contains(stdout, "bd-1") || strings.Contains(stdout, "bd-2") {
+		t.Errorf("Expected only bd-1, got:\n%s", stdout)
+	}
+
+	tasksQuery, tasksJSON = "priority>=2", true
+	output = NewCaptureOutput()
+	output.Start()
+	err = runTasksList(tasksListCmd, nil)
+	output.Stop()
+	if err != nil {
+		t.Fatalf("runTasksList() error = %v", err)
+	}
+	if stdout := output.GetStdout(); strings.TrimSpace(stdout) != `{"id":"bd-2","title":"UI","status":"open","phase":"","priority":3,"labels":["frontend"]}` {
+		t.Errorf("Expected bd-2 as JSON, got %q", stdout)
+	}
+
+	tasksQuery, tasksJSON = "owner:me", false
+	if err := runTasksList(tasksListCmd, nil); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("Expected an invalid query to fail, got %v", err)
+	}
+}
+
+func TestReadBulkRecords(t *testing.T) {
+	array := `[{"title":"Schema","priority":1,"labels":["db"]},{"title":"API"}]`
+	jsonLines := "{\"title\":\"Schema\",\"priority\":1,\"labels\":[\"db\"]}\n{\"title\":\"API\"}\n"
+	csvInput := "title,priority,labels\n\"Schema\",1,db\nAPI,,\n"
+	for name, input := range map[string]string{"array": array, "json lines": jsonLines, "csv": csvInput} {
+		records, err := readBulkRecords(strings.NewReader(input), "")
+		if err != nil {
+			t.Fatalf("%s: readBulkRecords() error = %v", name, err)
+		}
+		tasks, err := bulkCreates(records)
+		if err != nil {
+			t.Fatalf("%s: bulkCreates() error = %v", name, err)
+		}
+		if len(tasks) != 2 || tasks[0].Title != "Schema" || *tasks[0].Priority != 1 || len(tasks[0].Labels) != 1 ||
+			tasks[1].Title != "API" || tasks[1].Priority != nil || tasks[1].Labels != nil {
+			t.Errorf("%s: unexpected tasks %+v", name, tasks)
+		}
+	}
+
+	records, err := readBulkRecords(strings.NewReader("id,status,phase\nbd-1,closed,\nbd-2,,review\n"), "csv")
+	if err != nil {
+		t.Fatalf("readBulkRecords() error = %v", err)
+	}
+	updates, err := bulkUpdates(records)
+	if err != nil {
+		t.Fatalf("bulkUpdates() error = %v", err)
+	}
+	if len(updates) != 2 || *updates[0].Status != "closed" || updates[0].Phase != nil || updates[1].Status != nil || *updates[1].Phase != "review" {
+		t.Errorf("Expected empty cells to leave fields unchanged, got %+v", updates)
+	}
+
+	for input, want := range map[string]string{
+		"owner\nme\n":           "unknown CSV column",
+		"title,priority\nA,x\n": "line 2: invalid priority",
+		`[{"title":`:            "invalid JSON input",
+	} {
+		if _, err := readBulkRecords(strings.NewReader(input), ""); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("readBulkRecords(%q) error = %v, want %q", input, err, want)
+		}
+	}
+	if _, err := bulkCreates([]bulkRecord{{ID: "bd-1"}}); err == nil {
+		t.Error("Expected a task without a title to be rejected")
+	}
+	nine := 9
+	if _, err := bulkUpdates([]bulkRecord{{ID: "bd-1", Priority: &nine}}); err == nil || !strings.Contains(err.Error(), "0 to 4") {
+		t.Errorf("Expected an out-of-range priority to be rejected, got %v", err)
+	}
+	if _, err := bulkUpdates([]bulkRecord{{ID: "bd-1"}}); err == nil || !strings.Contains(err.Error(), "nothing to update") {
+		t.Errorf("Expected an empty update to be rejected, got %v", err)
+	}
+	if _, err := bulkIDs([]bulkRecord{{}}); err == nil {
+		t.Error("Expected a close without an id to be rejected")
+	}
+}
+
+func TestTasksBulk(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	env := NewTestEnvironment(t)
+	defer ChangeToTempDir(t, env.TempDir)()
+	t.Setenv(statedir.EnvVar, t.TempDir())
+	env.WriteConfig(strings.Replace(strings.Split(budgetTestConfig, "[budget]")[0], `"./project-repo"`, `"."`, 1))
+
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	script := `#!/bin/sh
+echo "$@" >> "` + argsFile + `"
+if [ "$2" = "create" ]; then
+	echo "{\"id\":\"bd-$(wc -l < "` + argsFile + `" | tr -d ' ')\",\"title\":\"$3\",\"status\":\"open\"}"
+fi
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	run := func(action, input string) (string, error) {
+		t.Helper()
+		tasksBulkCmd.SetIn(strings.NewReader(input))
+		defer tasksBulkCmd.SetIn(nil)
+		output := NewCaptureOutput()
+		output.Start()
+		err := runTasksBulk(tasksBulkCmd, []string{action})
+		output.Stop()
+		return output.GetStdout(), err
+	}
+
+	stdout, err := run("create", "title,labels\nSchema,\"db,backend\"\nAPI,\n")
+	if err != nil {
+		t.Fatalf("runTasksBulk(create) error = %v", err)
+	}
+	if !strings.Contains(stdout, "bd-1") || !strings.Contains(stdout, "bd-2") || !strings.Contains(stdout, "Created 2 task(s)") {
+		t.Errorf("Expected the created tasks, got:\n%s", stdout)
+	}
+	if _, err := run("close", `[{"id":"bd-1"},{"id":"bd-2"}]`); err != nil {
+		t.Fatalf("runTasksBulk(close) error = %v", err)
+	}
+
+	dryRun = true
+	if err := rootCmd.PersistentPreRunE(tasksBulkCmd, nil); err != nil {
+		dryRun = false
+		t.Fatalf("Expected asc tasks bulk to accept --dry-run, got %v", err)
+	}
+	stdout, err = run("close", `{"id":"bd-3"}`)
+	dryRun = false
+	if err != nil || !strings.Contains(stdout, "Dry run: would close task bd-3") {
+		t.Errorf("Expected a dry run, got %v:\n%s", err, stdout)
+	}
+	if _, err := run("update", `{"title":"No ID"}`); err == nil || !strings.Contains(err.Error(), "an id is required") {
+		t.Errorf("Expected an update without an id to fail, got %v", err)
+	}
+	if _, err := run("delete", `{"id":"bd-1"}`); err == nil {
+		t.Error("Expected an unknown action to fail")
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--json create Schema --labels db,backend\n--json create API\nclose bd-1 bd-2\n"
+	if string(data) != want {
+		t.Errorf("bd ran with\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestFormatTaskHistory(t *testing.T) {
+	if got := formatTaskHistory("bd-1", nil); got != "No recorded history for bd-1\n" {
+		t.Errorf("formatTaskHistory(nil) = %q", got)
+	}
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	got := formatTaskHistory("bd-1", []beads.TaskEvent{
+		{Time: at, Task: "bd-1", Kind: beads.EventCreated, To: "open", Actor: "alice", Source: beads.SourceAsc},
+		{Time: at, Task: "bd-1", Kind: beads.EventAssignee, To: "coder", Actor: "coder", Source: beads.SourceBeads},
+		{Time: at, Task: "bd-1", Kind: beads.EventStatus, From: "open", To: "closed", Source: beads.SourceBeads},
+	})
+	for _, want := range []string{"TIME", "alice", "created (open)", "coder*", "assignee (none) → coder", "unknown*", "status open → closed", "made with bd"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the history, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestTasksHistory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	env := NewTestEnvironment(t)
+	defer ChangeToTempDir(t, env.TempDir)()
+	t.Setenv(statedir.EnvVar, t.TempDir())
+	t.Setenv("AGENT_NAME", "")
+	env.WriteConfig(strings.Replace(strings.Split(budgetTestConfig, "[budget]")[0], `"./project-repo"`, `"."`, 1))
+
+	binDir := t.TempDir()
+	tasksFile := filepath.Join(binDir, "tasks.json")
+	script := `#!/bin/sh
+if [ "$2" = "list" ]; then
+	cat "` + tasksFile + `"
+fi
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	setTasks := func(tasks string) {
+		if err := os.WriteFile(tasksFile, []byte(tasks), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func() (string, error) {
+		output := NewCaptureOutput()
+		output.Start()
+		err := runTasksHistory(tasksHistoryCmd, []string{"bd-1"})
+		output.Stop()
+		return output.GetStdout(), err
+	}
+
+	setTasks(`[{"id":"bd-1","title":"API","status":"open"}]`)
+	if stdout, err := run(); err != nil || !strings.Contains(stdout, "No recorded history for bd-1") {
+		t.Fatalf("Expected no history yet, got %v:\n%s", err, stdout)
+	}
+
+	// An agent claims the task with bd
+	setTasks(`[{"id":"bd-1","title":"API","status":"in_progress","assignee":"coder"}]`)
+	stdout, err := run()
+	if err != nil {
+		t.Fatalf("runTasksHistory() error = %v", err)
+	}
+	for _, want := range []string{"coder*", "status open → in_progress", "assignee (none) → coder"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in the history, got:\n%s", want, stdout)
+		}
+	}
+
+	// The user closes it through asc
+	client, err := newBeadsClient(&config.Config{Core: config.CoreConfig{BeadsDBPath: ".", BeadsMode: beads.ModeCLI}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := beads.StatusClosed
+	if err := client.UpdateTask(context.Background(), "bd-1", beads.TaskUpdate{Status: &closed}); err != nil {
+		t.Fatal(err)
+	}
+	tasksJSON = true
+	defer func() { tasksJSON = false }()
+	setTasks(`[{"id":"bd-1","title":"API","status":"closed","assignee":"coder"}]`)
+	stdout, err = run()
+	if err != nil {
+		t.Fatalf("runTasksHistory(--json) error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], `"from":"in_progress","to":"closed","actor":"`+beads.Actor()+`","source":"asc"`) {
+		t.Errorf("Expected the close to be recorded once, through asc, got:\n%s", stdout)
+	}
+}
+
+func TestFormatTaskChange(t *testing.T) {
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	got := formatTaskChange(beads.TaskChange{Kind: beads.ChangeUpdated, Task: beads.Task{ID: "bd-1", Title: "API", Status: "in_progress", Assignee: "coder"}}, at)
+	if want := "10:00:00  updated  bd-1       in_progress API @coder\n"; got != want {
+		t.Errorf("formatTaskChange() = %q, want %q", got, want)
+	}
+}
+
+func TestTasksWatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	env := NewTestEnvironment(t)
+	defer ChangeToTempDir(t, env.TempDir)()
+	t.Setenv(statedir.EnvVar, t.TempDir())
+	env.WriteConfig(strings.Replace(strings.Split(budgetTestConfig, "[budget]")[0], `"./project-repo"`, `"."`, 1))
+	dataDir := filepath.Join(env.TempDir, beads.DataDirName)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	binDir := t.TempDir()
+	tasksFile := filepath.Join(binDir, "tasks.json")
+	script := `#!/bin/sh
+if [ "$2" = "list" ]; then
+	cat "` + tasksFile + `"
+fi
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	if err := os.WriteFile(tasksFile, []byte(`[{"id":"bd-1","title":"API","status":"open"},{"id":"bd-2","title":"Docs","status":"open"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tasksWatchCmd.SetContext(ctx)
+	defer tasksWatchCmd.SetContext(context.Background())
+	go func() {
+		// An agent claims a task with bd, which writes it to the JSONL file
+		time.Sleep(300 * time.Millisecond)
+		os.WriteFile(tasksFile, []byte(`[{"id":"bd-1","title":"API","status":"in_progress","assignee":"coder"},{"id":"bd-2","title":"Docs","status":"open"}]`), 0644)
+		os.WriteFile(filepath.Join(dataDir, "issues.jsonl"), []byte("{}\n"), 0644)
+		time.Sleep(time.Second)
+		cancel()
+	}()
+
+	output := NewCaptureOutput()
+	output.Start()
+	err := runTasksWatch(tasksWatchCmd, nil)
+	output.Stop()
+	if err != nil {
+		t.Fatalf("runTasksWatch() error = %v", err)
+	}
+	stdout := output.GetStdout()
+	if !strings.Contains(stdout, "Watching 2 tasks") || !strings.Contains(stdout, "updated  bd-1       in_progress API @coder") {
+		t.Errorf("Expected the claim of bd-1, got:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "bd-2") {
+		t.Errorf("Expected only the changed task, got:\n%s", stdout)
+	}
+}
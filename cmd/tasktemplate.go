@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/tasktemplate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateFile        string
+	templateDescription string
+	templateTitles      []string
+	templatePhase       string
+	templatePriority    int
+	templateLabels      []string
+	templateAssignee    string
+	templateParams      []string
+	templateSchedule    string
+)
+
+var tasksTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Create tasks from parameterized templates, once or on a schedule",
+	Long: `Keep templates of tasks in ~/.asc/templates/tasks and create their tasks
+with the values of their parameters. Titles, phases, labels, and assignees
+may refer to parameters as {{.name}}, and to the built-in {{.date}} and
+{{.time}} of creation.
+
+A template with a schedule, a cron expression such as "0 2 * * *" or one
+of @hourly, @daily, @nightly (02:00), @weekly, and @monthly, recurs: asc
+daemon creates its tasks as it falls due, and asc tasks template run-due
+does so from cron or another scheduler.`,
+}
+
+var tasksTemplateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create or replace a task template",
+	Long: `Create the template name, or replace it, from flags or from a JSON file.
+
+With --title, the template creates a task of each title, all with the
+phase, priority, labels, and assignee of the flags. --param declares a
+parameter, with its default after '='; one without a default must be set
+when the template is applied. Templates with a --schedule need defaults
+for all of them.
+
+With --file (- for stdin), the template is read as JSON:
+
+  {
+    "description": "Release checklist",
+    "params": {"version": ""},
+    "tasks": [
+      {"title": "Tag v{{.version}}", "priority": 1, "labels": ["release"]},
+      {"title": "Announce v{{.version}}", "phase": "review"}
+    ]
+  }`,
+	Example: `  asc tasks template create triage --title "Triage flaky tests ({{.date}})" --label flaky --schedule @nightly
+  asc tasks template create bugfix --title "Fix {{.area}}: {{.bug}}" --param area=backend --param bug
+  asc tasks template create release --file release.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTasksTemplateCreate,
+}
+
+var tasksTemplateApplyCmd = &cobra.Command{
+	Use:   "apply <name>",
+	Short: "Create the tasks of a template",
+	Example: `  asc tasks template apply bugfix --param bug="login fails on Safari"
+  asc tasks template apply release --param version=1.4 --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTasksTemplateApply,
+}
+
+var tasksTemplateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List task templates, with when recurring ones next run",
+	Args:  cobra.NoArgs,
+	RunE:  runTasksTemplateList,
+}
+
+var tasksTemplateDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a task template",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTasksTemplateDelete,
+}
+
+var tasksTemplateRunDueCmd = &cobra.Command{
+	Use:   "run-due",
+	Short: "Create the tasks of the recurring templates that are due",
+	Long: `Create the tasks of each recurring template whose schedule has passed
+since it last ran, with its default parameters. Runs missed while nothing
+checked are made up by one run. A template is first due when its
+schedule next passes after it is first checked.
+
+asc daemon does this every minute; without it, run this from cron, e.g.
+
+  * * * * * cd /path/to/project && asc tasks template run-due`,
+	Args: cobra.NoArgs,
+	RunE: runTasksTemplateRunDue,
+}
+
+func init() {
+	tasksCmd.AddCommand(tasksTemplateCmd)
+	tasksTemplateCmd.AddCommand(tasksTemplateCreateCmd)
+	tasksTemplateCmd.AddCommand(tasksTemplateApplyCmd)
+	tasksTemplateCmd.AddCommand(tasksTemplateListCmd)
+	tasksTemplateCmd.AddCommand(tasksTemplateDeleteCmd)
+	tasksTemplateCmd.AddCommand(tasksTemplateRunDueCmd)
+	tasksTemplateCreateCmd.Flags().StringVar(&templateFile, "file", "", "Read the template as JSON from this file (- for stdin)")
+	tasksTemplateCreateCmd.Flags().StringVar(&templateDescription, "description", "", "Description of the template")
+	tasksTemplateCreateCmd.Flags().StringArrayVar(&templateTitles, "title", nil, "Title of a task to create (repeatable)")
+	tasksTemplateCreateCmd.Flags().StringVar(&templatePhase, "phase", "", "Phase of the tasks")
+	tasksTemplateCreateCmd.Flags().IntVar(&templatePriority, "priority", -1, "Priority of the tasks, 0 (highest) to 4 (default: bd's)")
+	tasksTemplateCreateCmd.Flags().StringSliceVar(&templateLabels, "label", nil, "Labels of the tasks (repeatable or comma-separated)")
+	tasksTemplateCreateCmd.Flags().StringVar(&templateAssignee, "assignee", "", "Assignee of the tasks")
+	tasksTemplateCreateCmd.Flags().StringArrayVar(&templateParams, "param", nil, "Declare a parameter, as name or name=default (repeatable)")
+	tasksTemplateCreateCmd.Flags().StringVar(&templateSchedule, "schedule", "", "Cron expression of when the tasks recur, e.g. \"0 2 * * *\" or @nightly")
+	tasksTemplateApplyCmd.Flags().StringArrayVar(&templateParams, "param", nil, "Set a parameter, as name=value (repeatable)")
+}
+
+// parseTemplateParams parses name=value parameters. Without '=', a
+// parameter has no value, which requireValue rejects.
+func parseTemplateParams(params []string, requireValue bool) (map[string]string, error) {
+	values := make(map[string]string, len(params))
+	for _, param := range params {
+		name, value, ok := strings.Cut(param, "=")
+		name = strings.TrimSpace(name)
+		if name == "" || (requireValue && !ok) {
+			return nil, fmt.Errorf("invalid --param '%s': want name=value", param)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// readTemplate returns the template name of --file or of the flags
+func readTemplate(cmd *cobra.Command, name string) (*tasktemplate.Template, error) {
+	if templateFile != "" {
+		if len(templateTitles) > 0 {
+			return nil, errors.New("use --file or --title, not both")
+		}
+		var r io.Reader = cmd.InOrStdin()
+		if templateFile != "-" {
+			file, err := os.Open(templateFile)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			r = file
+		}
+		var tmpl tasktemplate.Template
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&tmpl); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", templateFile, err)
+		}
+		tmpl.Name = name
+		if cmd.Flags().Changed("description") {
+			tmpl.Description = templateDescription
+		}
+		if cmd.Flags().Changed("schedule") {
+			tmpl.Schedule = templateSchedule
+		}
+		return &tmpl, nil
+	}
+
+	if len(templateTitles) == 0 {
+		return nil, errors.New("give the tasks of the template with --title or --file")
+	}
+	params, err := parseTemplateParams(templateParams, false)
+	if err != nil {
+		return nil, err
+	}
+	var priority *int
+	if cmd.Flags().Changed("priority") {
+		if templatePriority < 0 || templatePriority > 4 {
+			return nil, fmt.Errorf("--priority must be 0 to 4, got %d", templatePriority)
+		}
+		value := templatePriority
+		priority = &value
+	}
+	tmpl := &tasktemplate.Template{Name: name, Description: templateDescription, Schedule: templateSchedule}
+	if len(params) > 0 {
+		tmpl.Params = params
+	}
+	for _, title := range templateTitles {
+		tmpl.Tasks = append(tmpl.Tasks, beads.NewTask{Title: title, Phase: templatePhase, Priority: priority, Labels: templateLabels, Assignee: templateAssignee})
+	}
+	return tmpl, nil
+}
+
+func runTasksTemplateCreate(cmd *cobra.Command, args []string) error {
+	tmpl, err := readTemplate(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Validate(); err != nil {
+		return err
+	}
+	dir, err := tasktemplate.Dir()
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		printDryRun("tasktemplate.dry_run.save", tmpl.Name, len(tmpl.Tasks), dir)
+		return nil
+	}
+	if err := tasktemplate.Save(dir, tmpl); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("tasktemplate.saved", tmpl.Name, len(tmpl.Tasks)))
+	if next := tmpl.NextRun(time.Now()); !next.IsZero() {
+		fmt.Println(i18n.T("tasktemplate.recurs", tmpl.Schedule, next.Format("2006-01-02 15:04")))
+	}
+	return nil
+}
+
+func runTasksTemplateApply(cmd *cobra.Command, args []string) error {
+	values, err := parseTemplateParams(templateParams, true)
+	if err != nil {
+		return err
+	}
+	dir, err := tasktemplate.Dir()
+	if err != nil {
+		return err
+	}
+	tmpl, err := tasktemplate.Load(dir, args[0])
+	if err != nil {
+		return err
+	}
+	tasks, err := tmpl.Render(values, time.Now())
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		for _, task := range tasks {
+			printDryRun("tasks.dry_run.create", task.Title)
+		}
+		return nil
+	}
+
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newBeadsClient(cfg)
+	if err != nil {
+		return err
+	}
+	bulk, ok := client.(beads.BulkClient)
+	if !ok {
+		return errors.New("the beads client does not support bulk operations")
+	}
+	created, err := bulk.CreateTasks(commandContext(cmd), tasks)
+	for _, task := range created {
+		fmt.Printf("%-12s %s\n", task.ID, task.Title)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("tasktemplate.created", len(created), tmpl.Name))
+	return nil
+}
+
+func runTasksTemplateList(cmd *cobra.Command, args []string) error {
+	dir, err := tasktemplate.Dir()
+	if err != nil {
+		return err
+	}
+	templates, err := tasktemplate.List(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatTaskTemplates(templates, time.Now()))
+	return nil
+}
+
+// formatTaskTemplates formats templates as a table with their parameters
+// and when recurring ones next run after now
+func formatTaskTemplates(templates []*tasktemplate.Template, now time.Time) string {
+	if len(templates) == 0 {
+		return i18n.T("tasktemplate.none") + "\n"
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "%-16s %-5s %-24s %-16s %s\n", i18n.T("status.column.name"), i18n.T("tasktemplate.column.tasks"), i18n.T("tasktemplate.column.params"), i18n.T("tasktemplate.column.next_run"), i18n.T("tasktemplate.column.description"))
+	for _, tmpl := range templates {
+		params := make([]string, 0, len(tmpl.Params))
+		for name, value := range tmpl.Params {
+			if value == "" {
+				params = append(params, name)
+			} else {
+				params = append(params, name+"="+value)
+			}
+		}
+		sort.Strings(params)
+		next := "-"
+		if at := tmpl.NextRun(now); !at.IsZero() {
+			next = at.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(&out, "%-16s %-5d %-24s %-16s %s\n", tmpl.Name, len(tmpl.Tasks), orDash(strings.Join(params, ",")), next, tmpl.Description)
+	}
+	return out.String()
+}
+
+// orDash returns value, or "-" if it is empty
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func runTasksTemplateDelete(cmd *cobra.Command, args []string) error {
+	dir, err := tasktemplate.Dir()
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		printDryRun("tasktemplate.dry_run.delete", args[0])
+		return nil
+	}
+	if err := tasktemplate.Delete(dir, args[0]); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("tasktemplate.deleted", args[0]))
+	return nil
+}
+
+// taskSchedulerInterval is how often the daemon checks for recurring task
+// templates that are due
+const taskSchedulerInterval = time.Minute
+
+// runTaskScheduler creates the tasks of recurring templates as they fall
+// due until ctx is done. Without a beads client that creates tasks in
+// bulk, recurring templates are left to asc tasks template run-due.
+func runTaskScheduler(ctx context.Context, cfg *config.Config) {
+	dir, err := tasktemplate.Dir()
+	if err != nil {
+		return
+	}
+	client, err := newBeadsClient(cfg)
+	if err != nil {
+		logger.Warn("Not running recurring task templates: %v", err)
+		return
+	}
+	if bulk, ok := client.(beads.BulkClient); ok {
+		tasktemplate.Scheduler(ctx, bulk, dir, taskSchedulerInterval)
+	}
+}
+
+func runTasksTemplateRunDue(cmd *cobra.Command, args []string) error {
+	dir, err := tasktemplate.Dir()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := newBeadsClient(cfg)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		pending, err := tasktemplate.Pending(dir, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, run := range pending {
+			printDryRun("tasktemplate.dry_run.run", len(run.Tasks), run.Template, run.Due.Format("2006-01-02 15:04"))
+		}
+		return nil
+	}
+	bulk, ok := client.(beads.BulkClient)
+	if !ok {
+		return errors.New("the beads client does not support bulk operations")
+	}
+	runs, err := tasktemplate.RunDue(commandContext(cmd), bulk, dir, time.Now())
+	failed := 0
+	for _, run := range runs {
+		for _, task := range run.Tasks {
+			fmt.Printf("%-12s %s\n", task.ID, task.Title)
+		}
+		if run.Err != nil {
+			failed++
+			fmt.Fprintln(os.Stderr, i18n.T("tasktemplate.run_failed", run.Template, run.Due.Format("2006-01-02 15:04"), run.Err))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d recurring template(s) failed to create their tasks", failed)
+	}
+	return nil
+}
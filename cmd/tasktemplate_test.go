@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/tasktemplate"
+)
+
+func TestParseTemplateParams(t *testing.T) {
+	params, err := parseTemplateParams([]string{"area=backend", "bug", "title=a=b"}, false)
+	if err != nil || params["area"] != "backend" || params["bug"] != "" || params["title"] != "a=b" {
+		t.Errorf("parseTemplateParams() = %v, %v", params, err)
+	}
+	if _, err := parseTemplateParams([]string{"bug"}, true); err == nil || !strings.Contains(err.Error(), "want name=value") {
+		t.Errorf("Expected a value to be required, got %v", err)
+	}
+	if _, err := parseTemplateParams([]string{"=x"}, false); err == nil {
+		t.Error("Expected an error for a parameter without a name")
+	}
+}
+
+func TestFormatTaskTemplates(t *testing.T) {
+	if got := formatTaskTemplates(nil, time.Now()); !strings.Contains(got, "No task templates") {
+		t.Errorf("formatTaskTemplates(nil) = %q", got)
+	}
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+	got := formatTaskTemplates([]*tasktemplate.Template{
+		{Name: "bugfix", Params: map[string]string{"area": "backend", "bug": ""}, Tasks: []beads.NewTask{{Title: "Fix {{.bug}}"}}},
+		{Name: "triage", Description: "Nightly triage", Schedule: "@nightly", Tasks: []beads.NewTask{{Title: "Triage"}, {Title: "Report"}}},
+	}, now)
+	for _, want := range []string{"NEXT RUN", "area=backend,bug", "2026-03-02 02:00", "Nightly triage"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the list, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestTasksTemplate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	env := NewTestEnvironment(t)
+	defer ChangeToTempDir(t, env.TempDir)()
+	t.Setenv(statedir.EnvVar, t.TempDir())
+	env.WriteConfig(strings.Replace(strings.Split(budgetTestConfig, "[budget]")[0], `"./project-repo"`, `"."`, 1))
+
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	script := `#!/bin/sh
+echo "$@" >> "` + argsFile + `"
+if [ "$2" = "create" ]; then
+	echo "{\"id\":\"bd-$(wc -l < "` + argsFile + `" | tr -d ' ')\",\"title\":\"$3\",\"status\":\"open\"}"
+fi
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	defer func() {
+		templateTitles, templateLabels, templateParams, templateSchedule, templateFile = nil, nil, nil, "", ""
+	}()
+	capture := func(run func() error) (string, error) {
+		t.Helper()
+		output := NewCaptureOutput()
+		output.Start()
+		err := run()
+		output.Stop()
+		return output.GetStdout(), err
+	}
+
+	templateTitles = []string{"Fix {{.area}}: {{.bug}}", "Test the fix of {{.bug}}"}
+	templateLabels = []string{"bug"}
+	templateParams = []string{"area=backend", "bug"}
+	stdout, err := capture(func() error { return runTasksTemplateCreate(tasksTemplateCreateCmd, []string{"bugfix"}) })
+	if err != nil || !strings.Contains(stdout, "Saved task template bugfix with 2 task(s)") {
+		t.Fatalf("runTasksTemplateCreate() = %v:\n%s", err, stdout)
+	}
+
+	// Recurring templates need defaults for their parameters
+	templateTitles, templateLabels, templateParams, templateSchedule = []string{"Triage {{.area}}"}, nil, []string{"area"}, "@nightly"
+	if _, err := capture(func() error { return runTasksTemplateCreate(tasksTemplateCreateCmd, []string{"triage"}) }); err == nil || !strings.Contains(err.Error(), "needs a default") {
+		t.Errorf("Expected a recurring template without defaults to be rejected, got %v", err)
+	}
+	templateTitles, templateParams = []string{"Triage flaky tests ({{.date}})"}, nil
+	stdout, err = capture(func() error { return runTasksTemplateCreate(tasksTemplateCreateCmd, []string{"triage"}) })
+	if err != nil || !strings.Contains(stdout, "Recurs @nightly; next run") {
+		t.Fatalf("runTasksTemplateCreate(--schedule) = %v:\n%s", err, stdout)
+	}
+
+	stdout, err = capture(func() error { return runTasksTemplateList(tasksTemplateListCmd, nil) })
+	if err != nil || !strings.Contains(stdout, "bugfix") || !strings.Contains(stdout, "triage") {
+		t.Errorf("runTasksTemplateList() = %v:\n%s", err, stdout)
+	}
+
+	templateParams = nil
+	if _, err := capture(func() error { return runTasksTemplateApply(tasksTemplateApplyCmd, []string{"bugfix"}) }); err == nil || !strings.Contains(err.Error(), "needs a value for bug") {
+		t.Errorf("Expected the required parameter to be asked for, got %v", err)
+	}
+	templateParams = []string{"bug=login fails"}
+	dryRun = true
+	if err := rootCmd.PersistentPreRunE(tasksTemplateApplyCmd, nil); err != nil {
+		dryRun = false
+		t.Fatalf("Expected asc tasks template apply to accept --dry-run, got %v", err)
+	}
+	stdout, err = capture(func() error { return runTasksTemplateApply(tasksTemplateApplyCmd, []string{"bugfix"}) })
+	dryRun = false
+	if err != nil || !strings.Contains(stdout, `Dry run: would create task "Fix backend: login fails"`) {
+		t.Errorf("Expected a dry run, got %v:\n%s", err, stdout)
+	}
+	if _, err := os.Stat(argsFile); err == nil {
+		t.Error("Expected the dry run not to run bd")
+	}
+	stdout, err = capture(func() error { return runTasksTemplateApply(tasksTemplateApplyCmd, []string{"bugfix"}) })
+	if err != nil || !strings.Contains(stdout, "Created 2 task(s) from bugfix") {
+		t.Fatalf("runTasksTemplateApply() = %v:\n%s", err, stdout)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if !strings.Contains(string(args), "create Fix backend: login fails --labels bug") || !strings.Contains(string(args), "create Test the fix of login fails") {
+		t.Errorf("Expected bd create of the rendered tasks, got:\n%s", args)
+	}
+
+	// The first check of a recurring template only records it
+	if stdout, err := capture(func() error { return runTasksTemplateRunDue(tasksTemplateRunDueCmd, nil) }); err != nil || stdout != "" {
+		t.Errorf("runTasksTemplateRunDue() = %v:\n%s", err, stdout)
+	}
+
+	if _, err := capture(func() error { return runTasksTemplateDelete(tasksTemplateDeleteCmd, []string{"bugfix"}) }); err != nil {
+		t.Fatalf("runTasksTemplateDelete() error = %v", err)
+	}
+	if _, err := capture(func() error { return runTasksTemplateApply(tasksTemplateApplyCmd, []string{"bugfix"}) }); err == nil || !strings.Contains(err.Error(), "no task template named 'bugfix'") {
+		t.Errorf("Expected the deleted template to be gone, got %v", err)
+	}
+}
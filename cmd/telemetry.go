@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/proxy"
+	"github.com/rand/asc/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+// otlpEndpointEnvVar is the standard OpenTelemetry variable telemetry.endpoint
+// defaults to
+const otlpEndpointEnvVar = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+// telemetryShutdownTimeout bounds how long exporting the last spans may
+// delay the exit of a command
+const telemetryShutdownTimeout = 5 * time.Second
+
+var (
+	// commandSpan is the span of the command in progress, ended when the
+	// command finishes (see finishTrace)
+	commandSpan *telemetry.Span
+
+	// telemetryExporter exports spans once a configuration enabling
+	// [telemetry] is loaded
+	telemetryExporter *telemetry.Exporter
+)
+
+// beginTrace starts the span of the command and puts it in the command's
+// context, so the spans of what it does are its children
+func beginTrace(cmd *cobra.Command) {
+	name := auditAction(cmd)
+	ctx, span := telemetry.StartCommand(commandContext(cmd), "asc "+name, telemetry.Attrs{
+		"asc.command":        name,
+		"asc.correlation_id": logger.CorrelationID(),
+	})
+	commandSpan = span
+	cmd.SetContext(ctx)
+}
+
+// finishTrace ends the span of the command, if any, with its result, and
+// exports what is left. It is called when the command returns and from
+// osExit, and only the first call ends the span.
+func finishTrace(err error) {
+	if commandSpan == nil {
+		return
+	}
+	commandSpan.SetError(err)
+	commandSpan.End()
+	commandSpan = nil
+
+	done := make(chan error, 1)
+	go func() { done <- telemetry.Shutdown() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			logger.Warn("Failed to export traces: %v", err)
+		}
+	case <-time.After(telemetryShutdownTimeout):
+		logger.Warn("Gave up exporting traces after %s", telemetryShutdownTimeout)
+	}
+	telemetryExporter = nil
+}
+
+// startTelemetry starts exporting spans, and asc's log records if
+// telemetry.logs is set, when [telemetry] is enabled. The spans of the
+// command so far are exported too. Failures are reported but never block
+// the command.
+func startTelemetry(cfg *config.Config) {
+	telemetryCfg := cfg.Telemetry
+	if !telemetryCfg.Enabled || telemetryExporter != nil {
+		return
+	}
+
+	endpoint := telemetryCfg.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv(otlpEndpointEnvVar)
+	}
+	if endpoint == "" {
+		endpoint = telemetry.DefaultEndpoint
+	}
+
+	headers := make(map[string]string, len(telemetryCfg.Headers)+1)
+	for k, v := range telemetryCfg.Headers {
+		headers[k] = v
+	}
+	if telemetryCfg.AuthTokenEnv != "" {
+		if token := os.Getenv(telemetryCfg.AuthTokenEnv); token != "" {
+			headers["Authorization"] = "Bearer " + token
+		}
+	}
+
+	proxySettings, err := proxy.ForService(telemetryCfg.Proxy)
+	if err != nil {
+		proxySettings = proxy.FromEnvironment()
+	}
+
+	exporter, err := telemetry.NewExporter(telemetry.Options{
+		Endpoint:      endpoint,
+		ServiceName:   telemetryCfg.ServiceName,
+		Resource:      telemetry.Attrs{"asc.workspace": cfg.Logging.Ship.Workspace},
+		Headers:       headers,
+		FlushInterval: telemetryCfg.FlushInterval,
+		HTTPClient:    &http.Client{Timeout: 10 * time.Second, Transport: proxySettings.Transport()},
+	})
+	if err != nil {
+		logger.Error("Failed to start telemetry export: %v", err)
+		fmt.Fprintln(os.Stderr, i18n.T("telemetry.disabled", err))
+		return
+	}
+
+	telemetryExporter = exporter
+	telemetry.Configure(exporter)
+	if telemetryCfg.Logs {
+		logger.AddSink(exporter.LogSink())
+	}
+	logger.WithFields(logger.Fields{"endpoint": endpoint}).Info("Telemetry export enabled")
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// TestTelemetryExport tests that a command's span is exported, with its
+// result, to the configured OTLP endpoint
+func TestTelemetryExport(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/v1/traces" {
+			bodies = append(bodies, string(data))
+			auth = r.Header.Get("Authorization")
+		}
+	}))
+	defer server.Close()
+	t.Setenv("ASC_TEST_OTEL_TOKEN", "secret")
+
+	parent := &cobra.Command{Use: "asc"}
+	child := &cobra.Command{Use: "services"}
+	parent.AddCommand(child)
+	beginTrace(child)
+
+	cfg := &config.Config{Telemetry: config.TelemetryConfig{
+		Enabled:      true,
+		Endpoint:     server.URL,
+		ServiceName:  "asc",
+		AuthTokenEnv: "ASC_TEST_OTEL_TOKEN",
+	}}
+	startTelemetry(cfg)
+	if telemetryExporter == nil {
+		t.Fatal("Expected telemetry export to start")
+	}
+	finishTrace(errors.New("exit status 1"))
+	finishTrace(nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("Expected 1 export, got %d", len(bodies))
+	}
+	for _, want := range []string{`"name":"asc services"`, `"message":"exit status 1"`, `"asc.command"`} {
+		if !strings.Contains(bodies[0], want) {
+			t.Errorf("Expected %s in export, got %s", want, bodies[0])
+		}
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected the bearer token from auth_token_env, got %q", auth)
+	}
+	if telemetryExporter != nil || commandSpan != nil {
+		t.Error("Expected finishTrace to shut telemetry down")
+	}
+}
+
+// TestTelemetryDisabled tests that nothing is exported without [telemetry]
+func TestTelemetryDisabled(t *testing.T) {
+	beginTrace(&cobra.Command{Use: "asc"})
+	startTelemetry(&config.Config{})
+	if telemetryExporter != nil {
+		t.Error("Expected no exporter when telemetry is disabled")
+	}
+	finishTrace(nil)
+}
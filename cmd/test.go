@@ -1,13 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/rand/asc/internal/beads"
 	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/mcp"
 )
 
@@ -20,80 +21,133 @@ and verifies that both systems are responding properly.`,
 	Run: runTest,
 }
 
+// testStepTimeout bounds each step of asc test, including the polling of
+// steps 3 and 4; testCleanupTimeout bounds deleting the test task
+const (
+	testStepTimeout    = 30 * time.Second
+	testCleanupTimeout = 10 * time.Second
+)
+
 func init() {
 	rootCmd.AddCommand(testCmd)
 }
 
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
 // runTest executes the end-to-end test flow
 func runTest(cmd *cobra.Command, args []string) {
-	fmt.Println("Running agent stack test...")
+	fmt.Println(i18n.T("test.running"))
 	fmt.Println()
 
 	// Load configuration
-	cfg, err := config.Load(config.DefaultConfigPath())
+	cfg, err := config.LoadContext(commandContext(cmd), config.DefaultConfigPath())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to load configuration: %v\n", err)
+		printError("Failed to load configuration", err)
 		fmt.Fprintf(os.Stderr, "Solution: Ensure asc.toml exists and is valid\n")
 		os.Exit(1)
 	}
+	applyLoggingConfig(cfg)
+	startTelemetry(cfg)
+
+	if dryRun {
+		printDryRun("test.dry_run.create", cfg.Core.BeadsDBPath)
+		printDryRun("test.dry_run.send", cfg.Services.MCPAgentMail.URL)
+		printDryRun("test.dry_run.delete")
+		return
+	}
 
 	// Initialize clients
-	beadsClient := beads.NewClient(cfg.Core.BeadsDBPath, 5*time.Second)
-	mcpClient := mcp.NewHTTPClient(cfg.Services.MCPAgentMail.URL)
+	beadsClient, err := newBeadsClient(cfg)
+	if err != nil {
+		printError("Failed to open beads database", err)
+		fmt.Fprintf(os.Stderr, "Solution: Set core.beads_mode to auto or cli, or check beads_db_path\n")
+		os.Exit(1)
+	}
+	mcpClient, err := newMCPClient(cfg)
+	if err != nil {
+		printError("Failed to create MCP client", err)
+		fmt.Fprintf(os.Stderr, "Solution: Check ca_file, cert_file, and key_file under [services.mcp_agent_mail]\n")
+		os.Exit(1)
+	}
+
+	// Every step runs under its own timeout, and Ctrl-C cancels the step in
+	// flight. Cleanup gets a fresh context so it still runs after Ctrl-C.
+	ctx := commandContext(cmd)
+	cleanupTestTask := func(id string) error {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), testCleanupTimeout)
+		defer cancel()
+		return beadsClient.DeleteTask(cleanupCtx, id)
+	}
 
 	// Test 1: Create test beads task
-	fmt.Print("1. Creating test beads task... ")
-	testTask, err := beadsClient.CreateTask("asc test task")
+	fmt.Print("1. " + i18n.T("test.step.create"))
+	stepCtx, cancel := context.WithTimeout(ctx, testStepTimeout)
+	testTask, err := beadsClient.CreateTask(stepCtx, "asc test task")
+	cancel()
 	if err != nil {
-		fmt.Println("✗ FAILED")
+		fmt.Println(i18n.T("test.failed"))
 		fmt.Fprintf(os.Stderr, "   Error: %v\n", err)
 		fmt.Fprintf(os.Stderr, "   Solution: Ensure 'bd' CLI is installed and beads_db_path is correct\n")
 		os.Exit(1)
 	}
-	fmt.Printf("✓ OK (ID: %s)\n", testTask.ID)
+	fmt.Println(i18n.T("test.ok_id", testTask.ID))
 
 	// Test 2: Send test message to MCP server
-	fmt.Print("2. Sending test message to MCP server... ")
+	fmt.Print("2. " + i18n.T("test.step.send"))
 	testMessage := mcp.Message{
 		Timestamp: time.Now(),
 		Type:      mcp.TypeMessage,
 		Source:    "asc-test",
 		Content:   "Test message from asc test command",
 	}
-	err = mcpClient.SendMessage(testMessage)
+	stepCtx, cancel = context.WithTimeout(ctx, testStepTimeout)
+	err = mcpClient.SendMessage(stepCtx, testMessage)
+	cancel()
 	if err != nil {
-		fmt.Println("✗ FAILED")
+		fmt.Println(i18n.T("test.failed"))
 		fmt.Fprintf(os.Stderr, "   Error: %v\n", err)
 		fmt.Fprintf(os.Stderr, "   Solution: Ensure mcp_agent_mail server is running (try 'asc services start')\n")
 		
 		// Clean up test task before exiting
-		fmt.Print("   Cleaning up test task... ")
-		if cleanupErr := beadsClient.DeleteTask(testTask.ID); cleanupErr != nil {
-			fmt.Printf("✗ (failed: %v)\n", cleanupErr)
+		fmt.Print(i18n.T("test.cleaning_task"))
+		if cleanupErr := cleanupTestTask(testTask.ID); cleanupErr != nil {
+			fmt.Println(i18n.T("test.cleanup_failed", cleanupErr))
 		} else {
 			fmt.Println("✓")
 		}
 		os.Exit(1)
 	}
-	fmt.Println("✓ OK")
+	fmt.Println(i18n.T("test.ok"))
 
 	// Test 3: Poll beads to confirm task exists
-	fmt.Print("3. Verifying beads task retrieval... ")
+	fmt.Print("3. " + i18n.T("test.step.verify_task"))
 	success := false
-	timeout := 30 * time.Second
+	timeout := testStepTimeout
 	pollInterval := 1 * time.Second
-	deadline := time.Now().Add(timeout)
+	stepCtx, cancel = context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	for time.Now().Before(deadline) {
-		tasks, err := beadsClient.GetTasks([]string{"open", "in_progress", "done"})
+	for stepCtx.Err() == nil {
+		tasks, err := beadsClient.GetTasks(stepCtx, []string{"open", "in_progress", "done"})
+		if err != nil && stepCtx.Err() != nil {
+			break // Timed out or interrupted, reported below
+		}
 		if err != nil {
-			fmt.Println("✗ FAILED")
+			fmt.Println(i18n.T("test.failed"))
 			fmt.Fprintf(os.Stderr, "   Error: %v\n", err)
 			
 			// Clean up test task before exiting
-			fmt.Print("   Cleaning up test task... ")
-			if cleanupErr := beadsClient.DeleteTask(testTask.ID); cleanupErr != nil {
-				fmt.Printf("✗ (failed: %v)\n", cleanupErr)
+			fmt.Print(i18n.T("test.cleaning_task"))
+			if cleanupErr := cleanupTestTask(testTask.ID); cleanupErr != nil {
+				fmt.Println(i18n.T("test.cleanup_failed", cleanupErr))
 			} else {
 				fmt.Println("✓")
 			}
@@ -112,40 +166,55 @@ func runTest(cmd *cobra.Command, args []string) {
 			break
 		}
 
-		time.Sleep(pollInterval)
+		sleepContext(stepCtx, pollInterval)
 	}
 
 	if !success {
-		fmt.Println("✗ FAILED (timeout)")
-		fmt.Fprintf(os.Stderr, "   Error: Test task not found in beads database after %v\n", timeout)
+		if ctx.Err() != nil {
+			fmt.Println(i18n.T("test.interrupted"))
+		} else {
+			fmt.Println(i18n.T("test.timeout"))
+			fmt.Fprintf(os.Stderr, "   Error: Test task not found in beads database after %v\n", timeout)
+		}
 		
 		// Clean up test task before exiting
-		fmt.Print("   Cleaning up test task... ")
-		if cleanupErr := beadsClient.DeleteTask(testTask.ID); cleanupErr != nil {
-			fmt.Printf("✗ (failed: %v)\n", cleanupErr)
+		fmt.Print(i18n.T("test.cleaning_task"))
+		if cleanupErr := cleanupTestTask(testTask.ID); cleanupErr != nil {
+			fmt.Println(i18n.T("test.cleanup_failed", cleanupErr))
 		} else {
 			fmt.Println("✓")
 		}
 		os.Exit(1)
 	}
-	fmt.Println("✓ OK")
+	fmt.Println(i18n.T("test.ok"))
 
 	// Test 4: Poll MCP to confirm message was received
-	fmt.Print("4. Verifying MCP message retrieval... ")
+	fmt.Print("4. " + i18n.T("test.step.verify_message"))
 	success = false
-	deadline = time.Now().Add(timeout)
-	messageCheckTime := testMessage.Timestamp.Add(-1 * time.Second) // Check slightly before to ensure we catch it
+	stepCtx, cancel = context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// Ask the server only for test messages, from slightly before ours to
+	// ensure we catch it
+	query := mcp.MessageQuery{
+		Sources: []string{testMessage.Source},
+		Types:   []mcp.MessageType{testMessage.Type},
+		Since:   testMessage.Timestamp.Add(-1 * time.Second),
+	}
 
-	for time.Now().Before(deadline) {
-		messages, err := mcpClient.GetMessages(messageCheckTime)
+	for stepCtx.Err() == nil {
+		page, err := mcpClient.QueryMessages(stepCtx, query)
+		messages := page.Messages
+		if err != nil && stepCtx.Err() != nil {
+			break // Timed out or interrupted, reported below
+		}
 		if err != nil {
-			fmt.Println("✗ FAILED")
+			fmt.Println(i18n.T("test.failed"))
 			fmt.Fprintf(os.Stderr, "   Error: %v\n", err)
 			
 			// Clean up test artifacts before exiting
-			fmt.Print("   Cleaning up test task... ")
-			if cleanupErr := beadsClient.DeleteTask(testTask.ID); cleanupErr != nil {
-				fmt.Printf("✗ (failed: %v)\n", cleanupErr)
+			fmt.Print(i18n.T("test.cleaning_task"))
+			if cleanupErr := cleanupTestTask(testTask.ID); cleanupErr != nil {
+				fmt.Println(i18n.T("test.cleanup_failed", cleanupErr))
 			} else {
 				fmt.Println("✓")
 			}
@@ -164,45 +233,49 @@ func runTest(cmd *cobra.Command, args []string) {
 			break
 		}
 
-		time.Sleep(pollInterval)
+		sleepContext(stepCtx, pollInterval)
 	}
 
 	if !success {
-		fmt.Println("✗ FAILED (timeout)")
-		fmt.Fprintf(os.Stderr, "   Error: Test message not found in MCP server after %v\n", timeout)
+		if ctx.Err() != nil {
+			fmt.Println(i18n.T("test.interrupted"))
+		} else {
+			fmt.Println(i18n.T("test.timeout"))
+			fmt.Fprintf(os.Stderr, "   Error: Test message not found in MCP server after %v\n", timeout)
+		}
 		
 		// Clean up test task before exiting
-		fmt.Print("   Cleaning up test task... ")
-		if cleanupErr := beadsClient.DeleteTask(testTask.ID); cleanupErr != nil {
-			fmt.Printf("✗ (failed: %v)\n", cleanupErr)
+		fmt.Print(i18n.T("test.cleaning_task"))
+		if cleanupErr := cleanupTestTask(testTask.ID); cleanupErr != nil {
+			fmt.Println(i18n.T("test.cleanup_failed", cleanupErr))
 		} else {
 			fmt.Println("✓")
 		}
 		os.Exit(1)
 	}
-	fmt.Println("✓ OK")
+	fmt.Println(i18n.T("test.ok"))
 
 	// Test 5: Clean up test artifacts
-	fmt.Print("5. Cleaning up test artifacts... ")
+	fmt.Print("5. " + i18n.T("test.step.cleanup"))
 	
 	// Delete test task
-	if err := beadsClient.DeleteTask(testTask.ID); err != nil {
-		fmt.Println("✗ FAILED")
+	if err := cleanupTestTask(testTask.ID); err != nil {
+		fmt.Println(i18n.T("test.failed"))
 		fmt.Fprintf(os.Stderr, "   Error: Failed to delete test task: %v\n", err)
 		fmt.Fprintf(os.Stderr, "   Note: You may need to manually delete task '%s'\n", testTask.ID)
 		os.Exit(1)
 	}
 	
-	fmt.Println("✓ OK")
+	fmt.Println(i18n.T("test.ok"))
 
 	// All tests passed
 	fmt.Println()
-	fmt.Println("✓ Stack is healthy")
+	fmt.Println(i18n.T("test.healthy"))
 	fmt.Println()
-	fmt.Println("All components are communicating correctly:")
-	fmt.Println("  • beads task database is accessible")
-	fmt.Println("  • mcp_agent_mail server is responding")
-	fmt.Println("  • Message passing is working")
+	fmt.Println(i18n.T("test.summary"))
+	fmt.Println(i18n.T("test.summary.beads"))
+	fmt.Println(i18n.T("test.summary.mcp"))
+	fmt.Println(i18n.T("test.summary.messages"))
 	
 	os.Exit(0)
 }
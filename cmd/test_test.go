@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -20,7 +21,7 @@ type mockBeadsClient struct {
 	deleteDelay time.Duration
 }
 
-func (m *mockBeadsClient) GetTasks(statuses []string) ([]beads.Task, error) {
+func (m *mockBeadsClient) GetTasks(ctx context.Context, statuses []string) ([]beads.Task, error) {
 	if m.getDelay > 0 {
 		time.Sleep(m.getDelay)
 	}
@@ -30,7 +31,7 @@ func (m *mockBeadsClient) GetTasks(statuses []string) ([]beads.Task, error) {
 	return m.tasks, nil
 }
 
-func (m *mockBeadsClient) CreateTask(title string) (beads.Task, error) {
+func (m *mockBeadsClient) CreateTask(ctx context.Context, title string) (beads.Task, error) {
 	if m.createDelay > 0 {
 		time.Sleep(m.createDelay)
 	}
@@ -46,11 +47,11 @@ func (m *mockBeadsClient) CreateTask(title string) (beads.Task, error) {
 	return task, nil
 }
 
-func (m *mockBeadsClient) UpdateTask(id string, updates beads.TaskUpdate) error {
+func (m *mockBeadsClient) UpdateTask(ctx context.Context, id string, updates beads.TaskUpdate) error {
 	return nil
 }
 
-func (m *mockBeadsClient) DeleteTask(id string) error {
+func (m *mockBeadsClient) DeleteTask(ctx context.Context, id string) error {
 	if m.deleteDelay > 0 {
 		time.Sleep(m.deleteDelay)
 	}
@@ -67,7 +68,7 @@ func (m *mockBeadsClient) DeleteTask(id string) error {
 	return nil
 }
 
-func (m *mockBeadsClient) Refresh() error {
+func (m *mockBeadsClient) Refresh(ctx context.Context) error {
 	return nil
 }
 
@@ -80,7 +81,7 @@ type mockMCPClient struct {
 	getDelay    time.Duration
 }
 
-func (m *mockMCPClient) GetMessages(since time.Time) ([]mcp.Message, error) {
+func (m *mockMCPClient) GetMessages(ctx context.Context, since time.Time) ([]mcp.Message, error) {
 	if m.getDelay > 0 {
 		time.Sleep(m.getDelay)
 	}
@@ -96,7 +97,7 @@ func (m *mockMCPClient) GetMessages(since time.Time) ([]mcp.Message, error) {
 	return result, nil
 }
 
-func (m *mockMCPClient) SendMessage(msg mcp.Message) error {
+func (m *mockMCPClient) SendMessage(ctx context.Context, msg mcp.Message) error {
 	if m.sendDelay > 0 {
 		time.Sleep(m.sendDelay)
 	}
@@ -107,15 +108,15 @@ func (m *mockMCPClient) SendMessage(msg mcp.Message) error {
 	return nil
 }
 
-func (m *mockMCPClient) GetAgentStatus(agentName string) (mcp.AgentStatus, error) {
+func (m *mockMCPClient) GetAgentStatus(ctx context.Context, agentName string) (mcp.AgentStatus, error) {
 	return mcp.AgentStatus{}, nil
 }
 
-func (m *mockMCPClient) GetAllAgentStatuses(offlineThreshold time.Duration) ([]mcp.AgentStatus, error) {
+func (m *mockMCPClient) GetAllAgentStatuses(ctx context.Context, offlineThreshold time.Duration) ([]mcp.AgentStatus, error) {
 	return nil, nil
 }
 
-func (m *mockMCPClient) ReleaseAgentLeases(agentName string) error {
+func (m *mockMCPClient) ReleaseAgentLeases(ctx context.Context, agentName string) error {
 	return nil
 }
 
@@ -147,7 +148,7 @@ func TestRunTest_Success(t *testing.T) {
 	// For now, test the logic flow
 	
 	// Test 1: Create task
-	task, err := beadsClient.CreateTask("asc test task")
+	task, err := beadsClient.CreateTask(context.Background(), "asc test task")
 	if err != nil {
 		t.Fatalf("Failed to create task: %v", err)
 	}
@@ -162,13 +163,13 @@ func TestRunTest_Success(t *testing.T) {
 		Source:    "asc-test",
 		Content:   "Test message from asc test command",
 	}
-	err = mcpClient.SendMessage(testMessage)
+	err = mcpClient.SendMessage(context.Background(), testMessage)
 	if err != nil {
 		t.Fatalf("Failed to send message: %v", err)
 	}
 	
 	// Test 3: Verify task retrieval
-	tasks, err := beadsClient.GetTasks([]string{"open", "in_progress", "done"})
+	tasks, err := beadsClient.GetTasks(context.Background(), []string{"open", "in_progress", "done"})
 	if err != nil {
 		t.Fatalf("Failed to get tasks: %v", err)
 	}
@@ -184,7 +185,7 @@ func TestRunTest_Success(t *testing.T) {
 	}
 	
 	// Test 4: Verify message retrieval
-	messages, err := mcpClient.GetMessages(testMessage.Timestamp.Add(-1 * time.Second))
+	messages, err := mcpClient.GetMessages(context.Background(), testMessage.Timestamp.Add(-1 * time.Second))
 	if err != nil {
 		t.Fatalf("Failed to get messages: %v", err)
 	}
@@ -200,7 +201,7 @@ func TestRunTest_Success(t *testing.T) {
 	}
 	
 	// Test 5: Cleanup
-	err = beadsClient.DeleteTask("test-task-123")
+	err = beadsClient.DeleteTask(context.Background(), "test-task-123")
 	if err != nil {
 		t.Fatalf("Failed to delete task: %v", err)
 	}
@@ -225,7 +226,7 @@ func TestRunTest_CreateTaskError(t *testing.T) {
 		createErr: fmt.Errorf("bd create failed: command not found"),
 	}
 	
-	_, err := beadsClient.CreateTask("asc test task")
+	_, err := beadsClient.CreateTask(context.Background(), "asc test task")
 	if err == nil {
 		t.Error("Expected error when creating task")
 	}
@@ -247,7 +248,7 @@ func TestRunTest_SendMessageError(t *testing.T) {
 		Content:   "Test message",
 	}
 	
-	err := mcpClient.SendMessage(testMessage)
+	err := mcpClient.SendMessage(context.Background(), testMessage)
 	if err == nil {
 		t.Error("Expected error when sending message")
 	}
@@ -264,7 +265,7 @@ func TestRunTest_TaskRetrievalTimeout(t *testing.T) {
 	}
 	
 	// Create a task
-	task, err := beadsClient.CreateTask("asc test task")
+	task, err := beadsClient.CreateTask(context.Background(), "asc test task")
 	if err != nil {
 		t.Fatalf("Failed to create task: %v", err)
 	}
@@ -273,7 +274,7 @@ func TestRunTest_TaskRetrievalTimeout(t *testing.T) {
 	beadsClient.tasks = []beads.Task{}
 	
 	// Try to retrieve tasks
-	tasks, err := beadsClient.GetTasks([]string{"open", "in_progress", "done"})
+	tasks, err := beadsClient.GetTasks(context.Background(), []string{"open", "in_progress", "done"})
 	if err != nil {
 		t.Fatalf("Failed to get tasks: %v", err)
 	}
@@ -305,13 +306,13 @@ func TestRunTest_MessageRetrievalTimeout(t *testing.T) {
 		Source:    "asc-test",
 		Content:   "Test message",
 	}
-	err := mcpClient.SendMessage(testMessage)
+	err := mcpClient.SendMessage(context.Background(), testMessage)
 	if err != nil {
 		t.Fatalf("Failed to send message: %v", err)
 	}
 	
 	// Try to retrieve messages with a future timestamp (should not find it)
-	messages, err := mcpClient.GetMessages(time.Now().Add(1 * time.Hour))
+	messages, err := mcpClient.GetMessages(context.Background(), time.Now().Add(1 * time.Hour))
 	if err != nil {
 		t.Fatalf("Failed to get messages: %v", err)
 	}
@@ -327,7 +328,7 @@ func TestRunTest_CleanupAfterError(t *testing.T) {
 	beadsClient := &mockBeadsClient{}
 	
 	// Create a task
-	task, err := beadsClient.CreateTask("asc test task")
+	task, err := beadsClient.CreateTask(context.Background(), "asc test task")
 	if err != nil {
 		t.Fatalf("Failed to create task: %v", err)
 	}
@@ -338,7 +339,7 @@ func TestRunTest_CleanupAfterError(t *testing.T) {
 	}
 	
 	// Simulate cleanup
-	err = beadsClient.DeleteTask(task.ID)
+	err = beadsClient.DeleteTask(context.Background(), task.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete task: %v", err)
 	}
@@ -356,13 +357,13 @@ func TestRunTest_DeleteTaskError(t *testing.T) {
 	}
 	
 	// Create a task
-	task, err := beadsClient.CreateTask("asc test task")
+	task, err := beadsClient.CreateTask(context.Background(), "asc test task")
 	if err != nil {
 		t.Fatalf("Failed to create task: %v", err)
 	}
 	
 	// Try to delete the task
-	err = beadsClient.DeleteTask(task.ID)
+	err = beadsClient.DeleteTask(context.Background(), task.ID)
 	if err == nil {
 		t.Error("Expected error when deleting task")
 	}
@@ -377,7 +378,7 @@ func TestRunTest_GetTasksError(t *testing.T) {
 		getErr: fmt.Errorf("bd list failed: database locked"),
 	}
 	
-	_, err := beadsClient.GetTasks([]string{"open"})
+	_, err := beadsClient.GetTasks(context.Background(), []string{"open"})
 	if err == nil {
 		t.Error("Expected error when getting tasks")
 	}
@@ -392,7 +393,7 @@ func TestRunTest_GetMessagesError(t *testing.T) {
 		getErr: fmt.Errorf("HTTP 500: Internal Server Error"),
 	}
 	
-	_, err := mcpClient.GetMessages(time.Now())
+	_, err := mcpClient.GetMessages(context.Background(), time.Now())
 	if err == nil {
 		t.Error("Expected error when getting messages")
 	}
@@ -409,7 +410,7 @@ func TestRunTest_PollingBehavior(t *testing.T) {
 	}
 	
 	// Create a task
-	task, err := beadsClient.CreateTask("asc test task")
+	task, err := beadsClient.CreateTask(context.Background(), "asc test task")
 	if err != nil {
 		t.Fatalf("Failed to create task: %v", err)
 	}
@@ -420,7 +421,7 @@ func TestRunTest_PollingBehavior(t *testing.T) {
 	found := false
 	
 	for attempts < maxAttempts {
-		tasks, err := beadsClient.GetTasks([]string{"open", "in_progress", "done"})
+		tasks, err := beadsClient.GetTasks(context.Background(), []string{"open", "in_progress", "done"})
 		if err != nil {
 			t.Fatalf("Failed to get tasks: %v", err)
 		}
@@ -469,7 +470,7 @@ func TestRunTest_MessageTimestampFiltering(t *testing.T) {
 	
 	// Get messages from 5 minutes ago
 	since := time.Now().Add(-5 * time.Minute)
-	messages, err := mcpClient.GetMessages(since)
+	messages, err := mcpClient.GetMessages(context.Background(), since)
 	if err != nil {
 		t.Fatalf("Failed to get messages: %v", err)
 	}
@@ -494,13 +495,13 @@ func TestRunTest_MultipleTasksInList(t *testing.T) {
 	}
 	
 	// Create test task
-	testTask, err := beadsClient.CreateTask("asc test task")
+	testTask, err := beadsClient.CreateTask(context.Background(), "asc test task")
 	if err != nil {
 		t.Fatalf("Failed to create task: %v", err)
 	}
 	
 	// Get all tasks
-	tasks, err := beadsClient.GetTasks([]string{"open", "in_progress", "done"})
+	tasks, err := beadsClient.GetTasks(context.Background(), []string{"open", "in_progress", "done"})
 	if err != nil {
 		t.Fatalf("Failed to get tasks: %v", err)
 	}
@@ -528,7 +529,7 @@ func TestRunTest_EmptyTaskTitle(t *testing.T) {
 	beadsClient := &mockBeadsClient{}
 	
 	// Create task with empty title (should still work in mock)
-	task, err := beadsClient.CreateTask("")
+	task, err := beadsClient.CreateTask(context.Background(), "")
 	if err != nil {
 		t.Fatalf("Failed to create task: %v", err)
 	}
@@ -571,14 +572,14 @@ func TestRunTest_MessageTypes(t *testing.T) {
 	}
 	
 	for _, msg := range messages {
-		err := mcpClient.SendMessage(msg)
+		err := mcpClient.SendMessage(context.Background(), msg)
 		if err != nil {
 			t.Fatalf("Failed to send message: %v", err)
 		}
 	}
 	
 	// Retrieve all messages
-	allMessages, err := mcpClient.GetMessages(time.Now().Add(-1 * time.Minute))
+	allMessages, err := mcpClient.GetMessages(context.Background(), time.Now().Add(-1 * time.Minute))
 	if err != nil {
 		t.Fatalf("Failed to get messages: %v", err)
 	}
@@ -611,7 +612,7 @@ func TestRunTest_ConcurrentOperations(t *testing.T) {
 	var task beads.Task
 	
 	go func() {
-		task, taskErr = beadsClient.CreateTask("asc test task")
+		task, taskErr = beadsClient.CreateTask(context.Background(), "asc test task")
 		done <- true
 	}()
 	
@@ -622,7 +623,7 @@ func TestRunTest_ConcurrentOperations(t *testing.T) {
 			Source:    "asc-test",
 			Content:   "Test message",
 		}
-		msgErr = mcpClient.SendMessage(msg)
+		msgErr = mcpClient.SendMessage(context.Background(), msg)
 		done <- true
 	}()
 	
@@ -658,7 +659,7 @@ func TestRunTest_SlowOperations(t *testing.T) {
 	start := time.Now()
 	
 	// Create task
-	task, err := beadsClient.CreateTask("asc test task")
+	task, err := beadsClient.CreateTask(context.Background(), "asc test task")
 	if err != nil {
 		t.Fatalf("Failed to create task: %v", err)
 	}
@@ -670,25 +671,25 @@ func TestRunTest_SlowOperations(t *testing.T) {
 		Source:    "asc-test",
 		Content:   "Test message",
 	}
-	err = mcpClient.SendMessage(msg)
+	err = mcpClient.SendMessage(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("Failed to send message: %v", err)
 	}
 	
 	// Get tasks
-	_, err = beadsClient.GetTasks([]string{"open"})
+	_, err = beadsClient.GetTasks(context.Background(), []string{"open"})
 	if err != nil {
 		t.Fatalf("Failed to get tasks: %v", err)
 	}
 	
 	// Get messages
-	_, err = mcpClient.GetMessages(time.Now().Add(-1 * time.Minute))
+	_, err = mcpClient.GetMessages(context.Background(), time.Now().Add(-1 * time.Minute))
 	if err != nil {
 		t.Fatalf("Failed to get messages: %v", err)
 	}
 	
 	// Delete task
-	err = beadsClient.DeleteTask(task.ID)
+	err = beadsClient.DeleteTask(context.Background(), task.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete task: %v", err)
 	}
@@ -713,7 +714,7 @@ func TestRunTest_TaskStatusFiltering(t *testing.T) {
 	}
 	
 	// Get only open and in_progress tasks
-	tasks, err := beadsClient.GetTasks([]string{"open", "in_progress"})
+	tasks, err := beadsClient.GetTasks(context.Background(), []string{"open", "in_progress"})
 	if err != nil {
 		t.Fatalf("Failed to get tasks: %v", err)
 	}
@@ -782,7 +783,7 @@ func TestTestCommand_Workflow(t *testing.T) {
 	// Step 1: Task creation
 	t.Run("CreateTask", func(t *testing.T) {
 		client := &mockBeadsClient{}
-		task, err := client.CreateTask("asc test task")
+		task, err := client.CreateTask(context.Background(), "asc test task")
 		if err != nil {
 			t.Fatalf("Failed to create task: %v", err)
 		}
@@ -803,7 +804,7 @@ func TestTestCommand_Workflow(t *testing.T) {
 			Source:    "asc-test",
 			Content:   "Test message from asc test command",
 		}
-		err := client.SendMessage(msg)
+		err := client.SendMessage(context.Background(), msg)
 		if err != nil {
 			t.Fatalf("Failed to send message: %v", err)
 		}
@@ -815,9 +816,9 @@ func TestTestCommand_Workflow(t *testing.T) {
 	// Step 3: Task verification
 	t.Run("VerifyTask", func(t *testing.T) {
 		client := &mockBeadsClient{}
-		task, _ := client.CreateTask("asc test task")
+		task, _ := client.CreateTask(context.Background(), "asc test task")
 		
-		tasks, err := client.GetTasks([]string{"open", "in_progress", "done"})
+		tasks, err := client.GetTasks(context.Background(), []string{"open", "in_progress", "done"})
 		if err != nil {
 			t.Fatalf("Failed to get tasks: %v", err)
 		}
@@ -843,9 +844,9 @@ func TestTestCommand_Workflow(t *testing.T) {
 			Source:    "asc-test",
 			Content:   "Test message",
 		}
-		client.SendMessage(msg)
+		client.SendMessage(context.Background(), msg)
 		
-		messages, err := client.GetMessages(msg.Timestamp.Add(-1 * time.Second))
+		messages, err := client.GetMessages(context.Background(), msg.Timestamp.Add(-1 * time.Second))
 		if err != nil {
 			t.Fatalf("Failed to get messages: %v", err)
 		}
@@ -865,9 +866,9 @@ func TestTestCommand_Workflow(t *testing.T) {
 	// Step 5: Cleanup
 	t.Run("Cleanup", func(t *testing.T) {
 		client := &mockBeadsClient{}
-		task, _ := client.CreateTask("asc test task")
+		task, _ := client.CreateTask(context.Background(), "asc test task")
 		
-		err := client.DeleteTask(task.ID)
+		err := client.DeleteTask(context.Background(), task.ID)
 		if err != nil {
 			t.Fatalf("Failed to delete task: %v", err)
 		}
@@ -884,7 +885,7 @@ func TestTestCommand_ErrorScenarios(t *testing.T) {
 		client := &mockBeadsClient{
 			createErr: fmt.Errorf("bd: command not found"),
 		}
-		_, err := client.CreateTask("test task")
+		_, err := client.CreateTask(context.Background(), "test task")
 		if err == nil {
 			t.Error("Expected error when bd command not found")
 		}
@@ -900,7 +901,7 @@ func TestTestCommand_ErrorScenarios(t *testing.T) {
 			Source:    "test",
 			Content:   "test",
 		}
-		err := client.SendMessage(msg)
+		err := client.SendMessage(context.Background(), msg)
 		if err == nil {
 			t.Error("Expected error when connection refused")
 		}
@@ -910,7 +911,7 @@ func TestTestCommand_ErrorScenarios(t *testing.T) {
 		client := &mockBeadsClient{
 			getErr: fmt.Errorf("database locked"),
 		}
-		_, err := client.GetTasks([]string{"open"})
+		_, err := client.GetTasks(context.Background(), []string{"open"})
 		if err == nil {
 			t.Error("Expected error when database locked")
 		}
@@ -920,7 +921,7 @@ func TestTestCommand_ErrorScenarios(t *testing.T) {
 		client := &mockMCPClient{
 			getErr: fmt.Errorf("server error"),
 		}
-		_, err := client.GetMessages(time.Now())
+		_, err := client.GetMessages(context.Background(), time.Now())
 		if err == nil {
 			t.Error("Expected error when server error")
 		}
@@ -930,8 +931,8 @@ func TestTestCommand_ErrorScenarios(t *testing.T) {
 		client := &mockBeadsClient{
 			deleteErr: fmt.Errorf("permission denied"),
 		}
-		client.CreateTask("test task")
-		err := client.DeleteTask("test-task-123")
+		client.CreateTask(context.Background(), "test task")
+		err := client.DeleteTask(context.Background(), "test-task-123")
 		if err == nil {
 			t.Error("Expected error when permission denied")
 		}
@@ -946,7 +947,7 @@ func TestTestCommand_TimeoutScenarios(t *testing.T) {
 		}
 		
 		// Create a task but don't add it to the list
-		task, _ := client.CreateTask("test task")
+		task, _ := client.CreateTask(context.Background(), "test task")
 		client.tasks = []beads.Task{} // Clear the list
 		
 		// Simulate polling with timeout
@@ -956,7 +957,7 @@ func TestTestCommand_TimeoutScenarios(t *testing.T) {
 		found := false
 		
 		for time.Now().Before(deadline) {
-			tasks, _ := client.GetTasks([]string{"open"})
+			tasks, _ := client.GetTasks(context.Background(), []string{"open"})
 			for _, t := range tasks {
 				if t.ID == task.ID {
 					found = true
@@ -987,7 +988,7 @@ func TestTestCommand_TimeoutScenarios(t *testing.T) {
 		checkTime := time.Now()
 		
 		for time.Now().Before(deadline) {
-			messages, _ := client.GetMessages(checkTime)
+			messages, _ := client.GetMessages(context.Background(), checkTime)
 			for _, msg := range messages {
 				if msg.Source == "asc-test" {
 					found = true
@@ -1012,7 +1013,7 @@ func TestTestCommand_CleanupBehavior(t *testing.T) {
 		client := &mockBeadsClient{}
 		
 		// Create task
-		task, err := client.CreateTask("test task")
+		task, err := client.CreateTask(context.Background(), "test task")
 		if err != nil {
 			t.Fatalf("Failed to create task: %v", err)
 		}
@@ -1023,7 +1024,7 @@ func TestTestCommand_CleanupBehavior(t *testing.T) {
 		}
 		
 		// Cleanup
-		err = client.DeleteTask(task.ID)
+		err = client.DeleteTask(context.Background(), task.ID)
 		if err != nil {
 			t.Fatalf("Failed to delete task: %v", err)
 		}
@@ -1038,18 +1039,18 @@ func TestTestCommand_CleanupBehavior(t *testing.T) {
 		client := &mockBeadsClient{}
 		
 		// Create task
-		task, _ := client.CreateTask("test task")
+		task, _ := client.CreateTask(context.Background(), "test task")
 		
 		// Simulate error in GetTasks
 		client.getErr = fmt.Errorf("database error")
-		_, err := client.GetTasks([]string{"open"})
+		_, err := client.GetTasks(context.Background(), []string{"open"})
 		if err == nil {
 			t.Error("Expected error from GetTasks")
 		}
 		
 		// Cleanup should still work
 		client.getErr = nil // Reset error
-		err = client.DeleteTask(task.ID)
+		err = client.DeleteTask(context.Background(), task.ID)
 		if err != nil {
 			t.Errorf("Cleanup should succeed: %v", err)
 		}
@@ -1060,24 +1061,24 @@ func TestTestCommand_CleanupBehavior(t *testing.T) {
 		mcpClient := &mockMCPClient{}
 		
 		// Create task and send message
-		task, _ := beadsClient.CreateTask("test task")
+		task, _ := beadsClient.CreateTask(context.Background(), "test task")
 		msg := mcp.Message{
 			Timestamp: time.Now(),
 			Type:      mcp.TypeMessage,
 			Source:    "test",
 			Content:   "test",
 		}
-		mcpClient.SendMessage(msg)
+		mcpClient.SendMessage(context.Background(), msg)
 		
 		// Simulate error in GetMessages
 		mcpClient.getErr = fmt.Errorf("server error")
-		_, err := mcpClient.GetMessages(time.Now())
+		_, err := mcpClient.GetMessages(context.Background(), time.Now())
 		if err == nil {
 			t.Error("Expected error from GetMessages")
 		}
 		
 		// Cleanup should still work
-		err = beadsClient.DeleteTask(task.ID)
+		err = beadsClient.DeleteTask(context.Background(), task.ID)
 		if err != nil {
 			t.Errorf("Cleanup should succeed: %v", err)
 		}
@@ -1089,10 +1090,10 @@ func TestTestCommand_CleanupBehavior(t *testing.T) {
 		}
 		
 		// Create task
-		task, _ := client.CreateTask("test task")
+		task, _ := client.CreateTask(context.Background(), "test task")
 		
 		// Try to cleanup
-		err := client.DeleteTask(task.ID)
+		err := client.DeleteTask(context.Background(), task.ID)
 		if err == nil {
 			t.Error("Expected error during cleanup")
 		}
@@ -1112,7 +1113,7 @@ func TestTestCommand_ResultReporting(t *testing.T) {
 		mcpClient := &mockMCPClient{}
 		
 		// Execute all steps
-		task, err := beadsClient.CreateTask("asc test task")
+		task, err := beadsClient.CreateTask(context.Background(), "asc test task")
 		if err != nil {
 			t.Fatalf("Step 1 failed: %v", err)
 		}
@@ -1123,12 +1124,12 @@ func TestTestCommand_ResultReporting(t *testing.T) {
 			Source:    "asc-test",
 			Content:   "Test message",
 		}
-		err = mcpClient.SendMessage(msg)
+		err = mcpClient.SendMessage(context.Background(), msg)
 		if err != nil {
 			t.Fatalf("Step 2 failed: %v", err)
 		}
 		
-		tasks, err := beadsClient.GetTasks([]string{"open", "in_progress", "done"})
+		tasks, err := beadsClient.GetTasks(context.Background(), []string{"open", "in_progress", "done"})
 		if err != nil {
 			t.Fatalf("Step 3 failed: %v", err)
 		}
@@ -1136,7 +1137,7 @@ func TestTestCommand_ResultReporting(t *testing.T) {
 			t.Fatal("Step 3 failed: no tasks found")
 		}
 		
-		messages, err := mcpClient.GetMessages(msg.Timestamp.Add(-1 * time.Second))
+		messages, err := mcpClient.GetMessages(context.Background(), msg.Timestamp.Add(-1 * time.Second))
 		if err != nil {
 			t.Fatalf("Step 4 failed: %v", err)
 		}
@@ -1144,7 +1145,7 @@ func TestTestCommand_ResultReporting(t *testing.T) {
 			t.Fatal("Step 4 failed: no messages found")
 		}
 		
-		err = beadsClient.DeleteTask(task.ID)
+		err = beadsClient.DeleteTask(context.Background(), task.ID)
 		if err != nil {
 			t.Fatalf("Step 5 failed: %v", err)
 		}
@@ -1159,7 +1160,7 @@ func TestTestCommand_ResultReporting(t *testing.T) {
 			createErr: fmt.Errorf("bd: command not found"),
 		}
 		
-		_, err := client.CreateTask("asc test task")
+		_, err := client.CreateTask(context.Background(), "asc test task")
 		if err == nil {
 			t.Error("Expected test to fail")
 		}
@@ -1213,7 +1214,7 @@ func TestMockClients_Comprehensive(t *testing.T) {
 		client := &mockBeadsClient{}
 		
 		// Test CreateTask
-		task, err := client.CreateTask("test")
+		task, err := client.CreateTask(context.Background(), "test")
 		if err != nil {
 			t.Errorf("CreateTask failed: %v", err)
 		}
@@ -1222,7 +1223,7 @@ func TestMockClients_Comprehensive(t *testing.T) {
 		}
 		
 		// Test GetTasks
-		tasks, err := client.GetTasks([]string{"open"})
+		tasks, err := client.GetTasks(context.Background(), []string{"open"})
 		if err != nil {
 			t.Errorf("GetTasks failed: %v", err)
 		}
@@ -1231,19 +1232,19 @@ func TestMockClients_Comprehensive(t *testing.T) {
 		}
 		
 		// Test UpdateTask
-		err = client.UpdateTask(task.ID, beads.TaskUpdate{})
+		err = client.UpdateTask(context.Background(), task.ID, beads.TaskUpdate{})
 		if err != nil {
 			t.Errorf("UpdateTask failed: %v", err)
 		}
 		
 		// Test DeleteTask
-		err = client.DeleteTask(task.ID)
+		err = client.DeleteTask(context.Background(), task.ID)
 		if err != nil {
 			t.Errorf("DeleteTask failed: %v", err)
 		}
 		
 		// Test Refresh
-		err = client.Refresh()
+		err = client.Refresh(context.Background())
 		if err != nil {
 			t.Errorf("Refresh failed: %v", err)
 		}
@@ -1259,13 +1260,13 @@ func TestMockClients_Comprehensive(t *testing.T) {
 			Source:    "test",
 			Content:   "test",
 		}
-		err := client.SendMessage(msg)
+		err := client.SendMessage(context.Background(), msg)
 		if err != nil {
 			t.Errorf("SendMessage failed: %v", err)
 		}
 		
 		// Test GetMessages
-		messages, err := client.GetMessages(time.Now().Add(-1 * time.Minute))
+		messages, err := client.GetMessages(context.Background(), time.Now().Add(-1 * time.Minute))
 		if err != nil {
 			t.Errorf("GetMessages failed: %v", err)
 		}
@@ -1274,19 +1275,19 @@ func TestMockClients_Comprehensive(t *testing.T) {
 		}
 		
 		// Test GetAgentStatus
-		_, err = client.GetAgentStatus("test-agent")
+		_, err = client.GetAgentStatus(context.Background(), "test-agent")
 		if err != nil {
 			t.Errorf("GetAgentStatus failed: %v", err)
 		}
 		
 		// Test GetAllAgentStatuses
-		_, err = client.GetAllAgentStatuses(2 * time.Minute)
+		_, err = client.GetAllAgentStatuses(context.Background(), 2 * time.Minute)
 		if err != nil {
 			t.Errorf("GetAllAgentStatuses failed: %v", err)
 		}
 		
 		// Test ReleaseAgentLeases
-		err = client.ReleaseAgentLeases("test-agent")
+		err = client.ReleaseAgentLeases(context.Background(), "test-agent")
 		if err != nil {
 			t.Errorf("ReleaseAgentLeases failed: %v", err)
 		}
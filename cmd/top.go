@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/process"
+	"github.com/spf13/cobra"
+)
+
+var (
+	topInterval   time.Duration
+	topIterations int
+)
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show live resource usage of the agents",
+	Long: `Show mcp_agent_mail and the agents with the CPU and memory they use, how
+long they have run, how often they were restarted, and how fast they write
+to their logs, refreshed every second until Ctrl-C.
+
+CPU and memory are measured as for asc status. RESTARTS counts the restarts
+by restart policies and health checks since asc up started the process.
+LOG/S is how fast the output log grew over the last refresh.`,
+	Run: runTop,
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+	topCmd.Flags().DurationVarP(&topInterval, "interval", "d", time.Second, "Time between refreshes")
+	topCmd.Flags().IntVarP(&topIterations, "iterations", "n", 0, "Refreshes to show before exiting (0 for no limit)")
+}
+
+// topSample is what asc top last read of a process, to turn the totals it
+// reads next into rates
+type topSample struct {
+	pid     int
+	cpuTime time.Duration
+	logSize int64
+	at      time.Time
+}
+
+// topRow is one row of asc top
+type topRow struct {
+	info    *process.ProcessInfo
+	running bool
+	usage   process.Usage
+	cpu     float64 // Percent of one core
+	logRate float64 // Bytes written to the log per second
+	err     error   // Why usage could not be read
+}
+
+// runTop shows the resource usage of the managed processes until
+// interrupted
+func runTop(cmd *cobra.Command, args []string) {
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		printError("Failed to initialize process manager", err)
+		osExit(1)
+		return
+	}
+	if topInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --interval must be positive")
+		osExit(1)
+		return
+	}
+
+	ctx := commandContext(cmd)
+	clear := term.IsTerminal(os.Stdout.Fd())
+	samples := make(map[string]topSample)
+	if _, err := sampleTop(procManager, samples, time.Now()); err != nil {
+		printError("Failed to list processes", err)
+		osExit(1)
+		return
+	}
+	for i := 0; topIterations == 0 || i < topIterations; i++ {
+		sleepContext(ctx, topInterval)
+		if ctx.Err() != nil {
+			return
+		}
+		now := time.Now()
+		rows, err := sampleTop(procManager, samples, now)
+		if err != nil {
+			printError("Failed to list processes", err)
+			osExit(1)
+			return
+		}
+		if clear {
+			fmt.Print("\033[H\033[2J")
+		}
+		fmt.Print(formatTop(rows, now))
+	}
+}
+
+// sampleTop reads the usage of the managed processes and turns it into
+// rates since the samples taken last, which it replaces
+func sampleTop(procManager *process.Manager, samples map[string]topSample, now time.Time) ([]topRow, error) {
+	processes, err := procManager.ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].Name < processes[j].Name
+	})
+
+	rows := make([]topRow, 0, len(processes))
+	seen := make(map[string]bool, len(processes))
+	for _, info := range processes {
+		seen[info.Name] = true
+		row := topRow{info: info, running: process.Live(procManager, info)}
+		if !row.running {
+			delete(samples, info.Name)
+			rows = append(rows, row)
+			continue
+		}
+		row.usage, row.err = procManager.Usage(info)
+		logSize := fileSize(info.LogFile)
+
+		// Rates need an earlier sample of the same process
+		previous, ok := samples[info.Name]
+		if ok && previous.pid == info.PID {
+			if elapsed := now.Sub(previous.at); elapsed > 0 {
+				if row.err == nil {
+					row.cpu = float64(row.usage.CPUTime-previous.cpuTime) / float64(elapsed) * 100
+				}
+				// A log emptied by rotation grew by its size
+				grown := logSize - previous.logSize
+				if grown < 0 {
+					grown = logSize
+				}
+				row.logRate = float64(grown) / elapsed.Seconds()
+			}
+		}
+		samples[info.Name] = topSample{pid: info.PID, cpuTime: row.usage.CPUTime, logSize: logSize, at: now}
+		rows = append(rows, row)
+	}
+	for name := range samples {
+		if !seen[name] {
+			delete(samples, name)
+		}
+	}
+	return rows, nil
+}
+
+// fileSize returns the size of the file at path, or 0 if it is missing
+func fileSize(path string) int64 {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return stat.Size()
+}
+
+// formatTop formats the rows of asc top as a table below a summary line
+func formatTop(rows []topRow, now time.Time) string {
+	running := 0
+	for _, row := range rows {
+		if row.running {
+			running++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "asc top - %s\n\n", i18n.T("top.title", now.Format("15:04:05"), running, len(rows)))
+	if len(rows) == 0 {
+		out.WriteString(i18n.T("status.none") + "\n")
+		return out.String()
+	}
+	fmt.Fprintf(&out, "%-20s %-8s %-7s %-10s %-10s %-8s %s\n", i18n.T("status.column.name"), "PID", "CPU", i18n.T("status.column.memory"), i18n.T("top.column.uptime"), i18n.T("top.column.restarts"), i18n.T("top.column.log_rate"))
+	for _, row := range rows {
+		restarts := fmt.Sprint(row.info.Restarts)
+		if !row.running {
+			fmt.Fprintf(&out, "%-20s %-8s %-7s %-10s %-10s %-8s %s\n", row.info.Name, i18n.T("status.stopped"), "-", "-", "-", restarts, "-")
+			continue
+		}
+		cpu, memory := "?", "?"
+		if row.err == nil {
+			cpu = fmt.Sprintf("%.1f%%", row.cpu)
+			memory = fmt.Sprintf("%.1f MB", float64(row.usage.MemoryBytes)/(1024*1024))
+		}
+		fmt.Fprintf(&out, "%-20s %-8d %-7s %-10s %-10s %-8s %s\n", row.info.Name, row.info.PID, cpu, memory,
+			formatUptime(now.Sub(row.info.StartedAt)), restarts, formatRate(row.logRate))
+	}
+	return out.String()
+}
+
+// formatUptime formats how long a process has run with its two largest
+// units, e.g. "3d04h", "2h05m", or "42s"
+func formatUptime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Truncate(time.Second)
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%02dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", d/time.Hour, d%time.Hour/time.Minute)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%02ds", d/time.Minute, d%time.Minute/time.Second)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+// formatRate formats a rate in bytes per second, e.g. "1.5 KB/s"
+func formatRate(bytesPerSecond float64) string {
+	switch {
+	case bytesPerSecond >= 1024*1024:
+		return fmt.Sprintf("%.1f MB/s", bytesPerSecond/(1024*1024))
+	case bytesPerSecond >= 1024:
+		return fmt.Sprintf("%.1f KB/s", bytesPerSecond/1024)
+	}
+	return fmt.Sprintf("%.0f B/s", bytesPerSecond)
+}
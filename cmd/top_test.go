@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/process"
+)
+
+// TestSampleTop tests that asc top turns two samples into rates
+func TestSampleTop(t *testing.T) {
+	env := NewTestEnvironment(t)
+	logPath := filepath.Join(env.LogDir, "agent-1.log")
+	if err := os.WriteFile(logPath, []byte("starting\n"), 0600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+	started := time.Now().Add(-90 * time.Minute)
+	env.WritePIDFile("agent-1", fmt.Sprintf(`{"name": "agent-1", "pid": %d, "command": "python", "log_file": %q, "started_at": %q, "restarts": 2}`,
+		os.Getpid(), logPath, started.Format(time.RFC3339Nano)))
+	env.WritePIDFile("mcp_agent_mail", `{"name": "mcp_agent_mail", "pid": 1073741824, "command": "python"}`)
+	procManager, err := process.NewManager(env.PIDDir, env.LogDir)
+	if err != nil {
+		t.Fatalf("Failed to create process manager: %v", err)
+	}
+
+	samples := make(map[string]topSample)
+	now := time.Now()
+	if _, err := sampleTop(procManager, samples, now); err != nil {
+		t.Fatalf("sampleTop failed: %v", err)
+	}
+	// 2 KB written over the next two seconds
+	if err := os.WriteFile(logPath, []byte("starting\n"+strings.Repeat("x", 2048)), 0600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+	now = now.Add(2 * time.Second)
+	rows, err := sampleTop(procManager, samples, now)
+	if err != nil {
+		t.Fatalf("sampleTop failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0].info.Name != "agent-1" || !rows[0].running || rows[1].running {
+		t.Fatalf("Expected a running agent and a stopped mcp_agent_mail, got %+v", rows)
+	}
+	if rows[0].logRate != 1024 {
+		t.Errorf("Expected a log rate of 1024 B/s, got %v", rows[0].logRate)
+	}
+
+	out := formatTop(rows, now)
+	lines := strings.Split(out, "\n")
+	if !strings.Contains(lines[0], "1 of 2 process(es) running") {
+		t.Errorf("Unexpected summary line: %s", lines[0])
+	}
+	for _, want := range []string{"agent-1", fmt.Sprint(os.Getpid()), " MB", "%", "1h30m", " 2 ", "1.0 KB/s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(out, "mcp_agent_mail       stopped") {
+		t.Errorf("Expected mcp_agent_mail to be stopped, got:\n%s", out)
+	}
+}
+
+// TestFormatUptime tests the uptimes asc top shows
+func TestFormatUptime(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{42 * time.Second, "42s"},
+		{4*time.Minute + 12*time.Second, "4m12s"},
+		{2*time.Hour + 5*time.Minute, "2h05m"},
+		{3*24*time.Hour + 4*time.Hour + 30*time.Minute, "3d04h"},
+		{-time.Second, "0s"},
+	}
+	for _, tt := range tests {
+		if got := formatUptime(tt.d); got != tt.want {
+			t.Errorf("formatUptime(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -1,26 +1,44 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 
-	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/budget"
 	"github.com/rand/asc/internal/check"
 	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/logship"
 	"github.com/rand/asc/internal/mcp"
+	"github.com/rand/asc/internal/pipeline"
 	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/proxy"
+	"github.com/rand/asc/internal/readiness"
 	"github.com/rand/asc/internal/secrets"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/telemetry"
 	"github.com/rand/asc/internal/tui"
+	"github.com/rand/asc/internal/usage"
 )
 
 var (
-	debugMode bool
+	debugMode    bool
+	ignoreBudget bool
 )
 
 var upCmd = &cobra.Command{
@@ -28,8 +46,8 @@ var upCmd = &cobra.Command{
 	Short: "Start all agents and services with TUI dashboard",
 	Long: `Start the agent stack by:
 - Running dependency checks
-- Starting the mcp_agent_mail service
-- Launching all configured agents
+- Starting the mcp_agent_mail service and waiting until it is ready
+- Launching all configured agents, each after the agents it depends on
 - Opening the TUI dashboard for monitoring`,
 	Run: runUp,
 }
@@ -37,12 +55,65 @@ var upCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(upCmd)
 	upCmd.Flags().BoolVar(&debugMode, "debug", false, "Enable debug mode with verbose output")
+	upCmd.Flags().BoolVar(&noCheckCache, "no-cache", false, "Ignore cached dependency check results")
+	upCmd.Flags().BoolVar(&ignoreBudget, "ignore-budget", false, "Warn about budgets but do not pause agents that exceed them")
+}
+
+// secretResolveTimeout bounds how long fetching the secrets of
+// [secrets.env] from their providers may take
+var secretResolveTimeout = 30 * time.Second
+
+// resolveSecretEnv sets the variables of [secrets.env] that are not
+// already set, so a .env file or an exported variable overrides its
+// reference, fetching them from their secret providers. A dry run only
+// prints the references it would fetch and reports them as pending.
+func resolveSecretEnv(ctx context.Context, env map[string]string) (pending bool, err error) {
+	refs := make(map[string]string)
+	for name, ref := range env {
+		if os.Getenv(name) == "" {
+			refs[name] = ref
+		}
+	}
+	if len(refs) == 0 {
+		return false, nil
+	}
+
+	if dryRun {
+		names := make([]string, 0, len(refs))
+		for name := range refs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			printDryRun("up.dry_run.fetch_secret", name, refs[name])
+		}
+		return true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretResolveTimeout)
+	defer cancel()
+	logger.Debug("Fetching %d secrets from secret providers", len(refs))
+	vars, err := secrets.ResolveEnv(ctx, refs)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve [secrets.env]: %w", err)
+	}
+	for _, v := range vars {
+		name, value, _ := strings.Cut(v, "=")
+		logger.RegisterSecret(value)
+		if err := os.Setenv(name, value); err != nil {
+			return false, fmt.Errorf("failed to set environment variable %s: %w", name, err)
+		}
+	}
+	return false, nil
 }
 
 func runUp(cmd *cobra.Command, args []string) {
+	// Cancelled on SIGTERM, so shutdown then skips the grace period
+	ctx := commandContext(cmd)
+
 	// Initialize logger
 	if err := logger.Init(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		printError("Failed to initialize logger", err)
 		osExit(1)
 	}
 	defer logger.Close()
@@ -55,33 +126,94 @@ func runUp(cmd *cobra.Command, args []string) {
 	}
 
 	// Default paths
-	configPath := "asc.toml"
-	envPath := ".env"
+	configPath := config.DefaultConfigPath()
+	envPath := config.DefaultEnvPath()
 
-	logger.Debug("Starting asc up command with config=%s, env=%s", configPath, envPath)
+	logger.WithFields(logger.Fields{"config": configPath, "env": envPath, "profile": config.Profile()}).Debug("Starting asc up command")
 
-	// Step 0: Auto-decrypt secrets if needed
-	if _, err := os.Stat(envPath); os.IsNotExist(err) {
+	// The daemon owns the stack's processes while it runs
+	if err := checkNoDaemon(); err != nil {
+		printError("Cannot start the agent stack", err)
+		osExit(1)
+	}
+
+	cfg, procManager, logsDir := prepareStack(ctx, configPath, envPath)
+	if dryRun {
+		return
+	}
+
+	orch, enforcer, shipper := startStack(ctx, cfg, procManager, logsDir)
+
+	// Step 7: Initialize and run TUI (handled in subtask 16.3)
+	logger.Debug("Initializing TUI dashboard")
+	if err := runTUI(cfg, procManager, orch, enforcer, debugMode); err != nil {
+		logger.Error("TUI error: %v", err)
+		fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
+		// Clean up: stop all processes
+		_ = procManager.StopAll(ctx)
+		osExit(1)
+	}
+
+	// Clean up on exit
+	if orch != nil {
+		orch.Stop()
+	}
+	if enforcer != nil {
+		enforcer.Stop()
+	}
+	fmt.Println("\n" + i18n.T("up.shutting_down"))
+	logger.Info("Shutting down agent stack")
+	processes, _ := procManager.ListProcesses()
+	if err := procManager.StopAll(ctx); err != nil {
+		logger.Error("Error during shutdown: %v", err)
+		fmt.Fprintf(os.Stderr, "Error during shutdown: %v\n", err)
+	}
+	removeContainers(ctx, processes)
+	events.Publish(events.Event{Type: events.StackStopped, Message: "asc up exited"})
+	fmt.Println(i18n.T("down.offline"))
+	logger.Info("Agent stack is offline")
+	if shipper != nil {
+		if err := shipper.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("up.ship_logs_failed", err))
+		}
+	}
+}
+
+// prepareStack takes the steps asc up and the daemon share before
+// starting processes: decrypting secrets, checking dependencies, loading
+// the configuration and environment, and reconciling with the processes
+// an earlier run left behind. It exits on failure.
+func prepareStack(ctx context.Context, configPath, envPath string) (*config.Config, *process.Manager, string) {
+	// Step 0: Auto-decrypt secrets if needed. Under asc secrets inject
+	// they are already in the environment and stay off the disk.
+	decryptPending := false
+	injected := os.Getenv(secrets.InjectedEnvVar) != ""
+	if _, err := os.Stat(envPath); os.IsNotExist(err) && !injected {
 		// Check if encrypted version exists
-		if _, err := os.Stat(envPath + ".age"); err == nil {
-			fmt.Println("🔐 Decrypting secrets...")
+		if _, err := os.Stat(envPath + ".age"); err == nil && dryRun {
+			printDryRun("secrets.dry_run.decrypt", envPath, envPath)
+			decryptPending = true
+		} else if err == nil {
+			fmt.Println(i18n.T("up.decrypting_secrets"))
 			logger.Debug("Decrypting secrets from %s.age", envPath)
 			secretsManager := secrets.NewManager()
 			if err := secretsManager.DecryptEnv(envPath); err != nil {
 				logger.Error("Failed to decrypt secrets: %v", err)
-				fmt.Fprintf(os.Stderr, "Failed to decrypt secrets: %v\n", err)
+				printError("Failed to decrypt secrets", err)
 				fmt.Fprintln(os.Stderr, "Run 'asc secrets decrypt' manually or 'asc init' to set up encryption.")
 				osExit(1)
 			}
-			fmt.Println("✓ Secrets decrypted")
+			fmt.Println(i18n.T("up.secrets_decrypted"))
 			logger.Debug("Secrets decrypted successfully")
 		}
 	}
 
 	// Step 1: Run silent dependency check
 	logger.Debug("Running dependency checks")
-	checker := check.NewChecker(configPath, envPath)
+	checker := newChecker(configPath, envPath)
+	_, checkSpan := telemetry.Start(ctx, "up.check", nil)
 	results := checker.RunAll()
+	checkSpan.End()
 
 	if debugMode {
 		for _, result := range results {
@@ -94,19 +226,35 @@ func runUp(cmd *cobra.Command, args []string) {
 
 	if check.HasFailures(results) {
 		logger.Error("Dependency check failed")
-		fmt.Fprintln(os.Stderr, "Dependency check failed. Run 'asc check' for details.")
+		fmt.Fprintln(os.Stderr, i18n.T("up.dependency_check_failed"))
 		osExit(1)
 	}
 	logger.Debug("All dependency checks passed")
 
 	// Step 2: Load configuration from asc.toml
 	logger.Debug("Loading configuration from %s", configPath)
-	cfg, err := config.Load(configPath)
+	_, loadSpan := telemetry.Start(ctx, "up.load_config", telemetry.Attrs{"asc.config": configPath})
+	cfg, err := config.LoadContext(ctx, configPath)
+	loadSpan.SetError(err)
+	loadSpan.End()
 	if err != nil {
 		logger.Error("Failed to load configuration: %v", err)
-		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		printError("Failed to load configuration", err)
 		osExit(1)
 	}
+	for _, unknown := range cfg.UnknownKeys {
+		fmt.Fprintln(os.Stderr, i18n.T("up.unknown_key", unknown))
+		logger.Warn("Ignoring %s", unknown)
+	}
+	if cfg.Remote != nil && cfg.Remote.Stale != nil {
+		fmt.Fprintln(os.Stderr, i18n.T("up.source_stale", cfg.Remote.URL, cfg.Remote.FetchedAt.Format(time.RFC3339)))
+		logger.Warn("Failed to refresh config_source %s: %v", cfg.Remote.URL, cfg.Remote.Stale)
+	}
+	if !debugMode {
+		applyLoggingConfig(cfg)
+	}
+	startTelemetry(cfg)
+	startNotifications(cfg)
 	if debugMode {
 		logger.WithFields(logger.Fields{
 			"agents":   len(cfg.Agents),
@@ -115,79 +263,214 @@ func runUp(cmd *cobra.Command, args []string) {
 		}).Debug("Configuration loaded successfully")
 	}
 
-	// Step 3: Load environment variables from .env
+	// Step 3: Load environment variables from .env, unless a dry run
+	// left it encrypted, asc secrets inject provided them, or they are
+	// fetched from the secret providers of [secrets.env]
 	logger.Debug("Loading environment variables from %s", envPath)
-	if err := config.LoadAndValidateEnv(envPath); err != nil {
+	loadEnv := config.LoadEnv
+	if _, err := os.Stat(envPath); os.IsNotExist(err) && (injected || len(cfg.Secrets.Env) > 0) {
+		logger.Debug("Using secrets from the environment and [secrets.env] instead of %s", envPath)
+		loadEnv = func(string) error { return nil }
+	}
+	err = loadEnv(envPath)
+	if err == nil {
+		var resolvePending bool
+		resolvePending, err = resolveSecretEnv(ctx, cfg.Secrets.Env)
+		decryptPending = decryptPending || resolvePending
+	}
+	if err == nil {
+		err = config.ValidateEnv()
+	}
+	if err != nil && !decryptPending {
 		logger.Error("Failed to load environment: %v", err)
-		fmt.Fprintf(os.Stderr, "Failed to load environment: %v\n", err)
+		printError("Failed to load environment", err)
 		osExit(1)
 	}
+	logger.RegisterSecretsFromEnv()
 	logger.Debug("Environment variables loaded successfully")
 
 	// Step 4: Initialize process manager with ~/.asc/pids and ~/.asc/logs
-	homeDir, err := os.UserHomeDir()
+	stateDir, err := statedir.Dir()
 	if err != nil {
-		logger.Error("Failed to get home directory: %v", err)
-		fmt.Fprintf(os.Stderr, "Failed to get home directory: %v\n", err)
+		logger.Error("Failed to resolve state directory: %v", err)
+		printError("Failed to resolve state directory", err)
 		osExit(1)
 	}
 
-	pidsDir := filepath.Join(homeDir, ".asc", "pids")
-	logsDir := filepath.Join(homeDir, ".asc", "logs")
+	pidsDir := filepath.Join(stateDir, "pids")
+	logsDir := filepath.Join(stateDir, "logs")
 
-	logger.Debug("Initializing process manager with pids=%s, logs=%s", pidsDir, logsDir)
+	logger.WithFields(logger.Fields{"pids": pidsDir, "logs": logsDir}).Debug("Initializing process manager")
 	procManager, err := process.NewManager(pidsDir, logsDir)
 	if err != nil {
 		logger.Error("Failed to initialize process manager: %v", err)
-		fmt.Fprintf(os.Stderr, "Failed to initialize process manager: %v\n", err)
+		printError("Failed to initialize process manager", err)
 		osExit(1)
 	}
 
-	// Step 5: Start mcp_agent_mail service
-	fmt.Println("Starting mcp_agent_mail service...")
-	mcpEnv := buildMCPEnv()
-	mcpCmd, mcpArgs := parseCommand(cfg.Services.MCPAgentMail.StartCommand)
-	logger.WithFields(logger.Fields{
-		"command": mcpCmd,
-		"args":    mcpArgs,
-	}).Debug("Starting mcp_agent_mail service")
-	_, err = procManager.Start("mcp_agent_mail", mcpCmd, mcpArgs, mcpEnv)
+	// Restart crashed processes by the restart policies of asc.toml
+	if err := applyProcessSettings(cfg, procManager); err != nil {
+		logger.Error("Failed to set health checks: %v", err)
+		printError("Failed to set health checks", err)
+		osExit(1)
+	}
+
+	// Step 4a: Reconcile with what an earlier run left behind, so running
+	// asc up again after a partial failure adopts the processes still
+	// running instead of failing on them
+	reconcileCtx, reconcileSpan := telemetry.Start(ctx, "up.reconcile", nil)
+	err = reconcileUp(reconcileCtx, cfg, procManager)
+	reconcileSpan.SetError(err)
+	reconcileSpan.End()
 	if err != nil {
-		logger.Error("Failed to start mcp_agent_mail: %v", err)
-		fmt.Fprintf(os.Stderr, "Failed to start mcp_agent_mail: %v\n", err)
+		logger.Error("Failed to reconcile running processes: %v", err)
+		printError("Failed to reconcile running processes", err)
 		osExit(1)
 	}
-	logger.Info("mcp_agent_mail service started successfully")
+
+	return cfg, procManager, logsDir
+}
+
+// startStack starts mcp_agent_mail, the other MCP servers, and the agents, with the budget
+// enforcer, phase pipeline, and log shipping that run alongside them. It
+// exits on failure, stopping any processes it started.
+func startStack(ctx context.Context, cfg *config.Config, procManager *process.Manager, logsDir string) (*pipeline.Orchestrator, *budget.Enforcer, *logship.Shipper) {
+	// Step 5: Start mcp_agent_mail and the other MCP servers, and wait
+	// until they are ready, so agents do not race them on a cold start
+	for _, name := range managedMCPServers(cfg) {
+		startMCPServer(ctx, cfg, procManager, name)
+	}
+
+	// Step 5a: Enforce spend budgets, so agents already over budget are
+	// not launched
+	enforcer := startBudget(cfg, procManager, ignoreBudget)
 
 	// Step 6: Launch agent processes (handled in subtask 16.2)
 	logger.Debug("Launching agent processes")
-	if err := launchAgents(cfg, procManager); err != nil {
+	launchCtx, launchSpan := telemetry.Start(ctx, "up.launch_agents", telemetry.Attrs{"asc.agents": len(cfg.Agents)})
+	err := launchAgents(launchCtx, cfg, procManager, enforcer)
+	launchSpan.SetError(err)
+	launchSpan.End()
+	if err != nil {
 		logger.Error("Failed to launch agents: %v", err)
-		fmt.Fprintf(os.Stderr, "Failed to launch agents: %v\n", err)
+		printError("Failed to launch agents", err)
 		// Clean up: stop mcp_agent_mail
-		_ = procManager.StopAll()
+		_ = procManager.StopAll(ctx)
 		osExit(1)
 	}
 
-	// Step 7: Initialize and run TUI (handled in subtask 16.3)
-	logger.Debug("Initializing TUI dashboard")
-	if err := runTUI(cfg, procManager, debugMode); err != nil {
-		logger.Error("TUI error: %v", err)
-		fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
-		// Clean up: stop all processes
-		_ = procManager.StopAll()
+	// Step 6a: Run the phase pipeline, which starts the agents of the
+	// current phase
+	orch := startPipeline(cfg, procManager, enforcer)
+
+	// Step 6b: Forward asc and agent logs to a central store if configured
+	shipper := startLogShipping(cfg, logsDir)
+
+	events.Publish(events.Event{Type: events.StackStarted, Message: fmt.Sprintf("%d agents", len(cfg.Agents))})
+	return orch, enforcer, shipper
+}
+
+// managedMCPServers returns the MCP servers asc starts: mcp_agent_mail,
+// then the other [services.mcp_*] servers with a start_command
+func managedMCPServers(cfg *config.Config) []string {
+	var names []string
+	for _, name := range cfg.Services.MCPServerNames() {
+		if server, _ := cfg.Services.MCPServer(name); server.StartCommand != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// startMCPServer starts the MCP server of the [services.<name>] section,
+// unless it is already running, and waits until it is ready. It exits on
+// failure, stopping any processes it started.
+func startMCPServer(ctx context.Context, cfg *config.Config, procManager *process.Manager, name string) {
+	mcpCfg, _ := cfg.Services.MCPServer(name)
+	if pid, ok := process.Running(procManager, name); ok {
+		fmt.Println(i18n.T("up.service_running", name, pid))
+		logger.WithFields(logger.Fields{"process": name, "pid": pid}).Info("Adopting running %s service", name)
+	} else {
+		fmt.Println(i18n.T("up.service_starting", name))
+		mcpEnv := buildMCPEnv()
+		mcpCmd, mcpArgs := parseCommand(mcpCfg.StartCommand)
+		logger.WithFields(logger.Fields{
+			"command": mcpCmd,
+			"args":    mcpArgs,
+		}).Debug("Starting %s service", name)
+		if _, err := procManager.Start(name, mcpCmd, mcpArgs, mcpEnv); err != nil {
+			logger.Error("Failed to start %s: %v", name, err)
+			printError("Failed to start "+name, err)
+			_ = procManager.StopAll(ctx)
+			osExit(1)
+		}
+		logger.Info("%s service started successfully", name)
+	}
+
+	mcpCheck := mcpReadyCheck(mcpCfg)
+	fmt.Println(i18n.T("up.service_waiting", name, mcpCheck))
+	if err := waitReady(ctx, procManager, name, mcpCheck); err != nil {
+		logger.Error("%s did not become ready: %v", name, err)
+		printError(name+" did not become ready", fmt.Errorf("%w\n  Suggestion: Check ~/.asc/logs/%s.log, or raise services.%s.ready_check.timeout", err, name, name))
+		_ = procManager.StopAll(ctx)
 		osExit(1)
 	}
+	fmt.Println(i18n.T("up.service_ready", name))
+}
 
-	// Clean up on exit
-	fmt.Println("\nShutting down agent stack...")
-	logger.Info("Shutting down agent stack")
-	if err := procManager.StopAll(); err != nil {
-		logger.Error("Error during shutdown: %v", err)
-		fmt.Fprintf(os.Stderr, "Error during shutdown: %v\n", err)
+// startLogShipping starts forwarding asc's log records and every agent's log
+// file when [logging.ship] is enabled. Returns nil if shipping is disabled or
+// cannot be started; the stack runs normally either way.
+func startLogShipping(cfg *config.Config, logsDir string) *logship.Shipper {
+	shipCfg := cfg.Logging.Ship
+	if !shipCfg.Enabled {
+		return nil
 	}
-	fmt.Println("Agent stack is offline")
-	logger.Info("Agent stack is offline")
+
+	labels := map[string]string{"workspace": shipCfg.Workspace}
+	for k, v := range shipCfg.Labels {
+		labels[k] = v
+	}
+
+	token := ""
+	if shipCfg.AuthTokenEnv != "" {
+		token = os.Getenv(shipCfg.AuthTokenEnv)
+	}
+
+	proxySettings, err := proxy.ForService(shipCfg.Proxy)
+	if err != nil {
+		proxySettings = proxy.FromEnvironment()
+	}
+
+	shipper, err := logship.New(logship.Options{
+		Backend:       shipCfg.Backend,
+		URL:           shipCfg.URL,
+		Index:         shipCfg.Index,
+		AuthToken:     token,
+		Labels:        labels,
+		BatchSize:     shipCfg.BatchSize,
+		FlushInterval: shipCfg.FlushInterval,
+		HTTPClient:    &http.Client{Timeout: 10 * time.Second, Transport: proxySettings.Transport()},
+	})
+	if err != nil {
+		logger.Error("Failed to start log shipping: %v", err)
+		fmt.Fprintln(os.Stderr, i18n.T("up.ship_logs_disabled", err))
+		return nil
+	}
+
+	logger.AddSink(shipper.Sink())
+	for _, name := range managedMCPServers(cfg) {
+		shipper.TailFile(filepath.Join(logsDir, name+".log"), name)
+	}
+	for agentName := range cfg.Agents {
+		shipper.TailFile(filepath.Join(logsDir, agentName+".log"), agentName)
+	}
+
+	logger.WithFields(logger.Fields{
+		"backend": shipCfg.Backend,
+		"url":     shipCfg.URL,
+	}).Info("Log shipping enabled")
+	return shipper
 }
 
 // parseCommand parses a command string into command and args
@@ -230,60 +513,506 @@ func buildMCPEnv() []string {
 	return env
 }
 
-// launchAgents starts all configured agent processes
-func launchAgents(cfg *config.Config, procManager process.ProcessManager) error {
-	fmt.Printf("Launching %d agent(s)...\n", len(cfg.Agents))
+// launchAgents starts all configured agent processes, each after the
+// agents it depends on are ready (see config.StartOrder). When the phase
+// pipeline is enabled, agents it manages are left for the orchestrator to
+// start with their phase (see startPipeline). Agents paused by enforcer
+// for exceeding a budget are not started.
+func launchAgents(ctx context.Context, cfg *config.Config, procManager process.ProcessManager, enforcer *budget.Enforcer) error {
+	fmt.Println(i18n.T("up.launching", len(cfg.Agents)))
 	logger.Info("Launching %d agent(s)", len(cfg.Agents))
 
-	// Iterate through agents in config
-	for agentName, agentCfg := range cfg.Agents {
-		fmt.Printf("  Starting agent: %s (model: %s)...\n", agentName, agentCfg.Model)
-		logger.WithFields(logger.Fields{
-			"agent": agentName,
-			"model": agentCfg.Model,
-			"phases": agentCfg.Phases,
-		}).Info("Starting agent")
+	order, err := cfg.StartOrder()
+	if err != nil {
+		return err
+	}
 
-		// Build environment variables for this agent
-		agentEnv := buildAgentEnv(agentName, agentCfg, cfg)
+	// Agents this run started or adopted, and those known to be ready
+	started := make(map[string]bool)
+	ready := make(map[string]bool)
 
-		if debugMode {
+	// Iterate through agents in dependency order
+	for _, agentName := range order {
+		agentCfg := cfg.Agents[agentName]
+		if pipeline.Manages(cfg.Pipeline, agentCfg) {
+			fmt.Println(i18n.T("up.agent_pipeline", agentName))
 			logger.WithFields(logger.Fields{
 				"agent": agentName,
-				"env_count": len(agentEnv),
-			}).Debug("Built agent environment variables")
+				"phases": agentCfg.Phases,
+			}).Info("Deferring agent start to the phase pipeline")
+			continue
+		}
+		if enforcer != nil && enforcer.IsPaused(agentName) {
+			fmt.Println(i18n.T("up.agent_paused", agentName))
+			logger.WithFields(logger.Fields{"agent": agentName}).Warn("Not starting agent paused by its budget")
+			continue
 		}
 
-		// Parse command into command and args
-		cmd, args := parseCommand(agentCfg.Command)
+		if pid, ok := process.Running(procManager, agentName); ok {
+			fmt.Println(i18n.T("up.agent_running", agentName, pid))
+			logger.WithFields(logger.Fields{"agent": agentName, "pid": pid}).Info("Adopting running agent")
+			started[agentName] = true
+			continue
+		}
 
-		logger.WithFields(logger.Fields{
-			"agent": agentName,
-			"command": cmd,
-			"args": args,
-		}).Debug("Parsed agent command")
+		if err := waitDependencies(ctx, agentName, agentCfg, cfg, procManager, started, ready); err != nil {
+			return err
+		}
 
-		// Start the agent using process manager
-		pid, err := procManager.Start(agentName, cmd, args, agentEnv)
-		if err != nil {
-			logger.WithFields(logger.Fields{
-				"agent": agentName,
-			}).Error("Failed to start agent: %v", err)
+		fmt.Println(i18n.T("up.agent_starting", agentName, agentCfg.Model))
+		if _, err := startAgent(agentName, agentCfg, cfg, procManager); err != nil {
 			return fmt.Errorf("failed to start agent '%s': %w", agentName, err)
 		}
+		started[agentName] = true
+		fmt.Println(i18n.T("up.agent_started", agentName))
+	}
+
+	fmt.Println(i18n.T("up.agents_started"))
+	logger.Info("All agents started successfully")
+	return nil
+}
+
+// waitDependencies waits for the agents agentName depends on to pass their
+// ready checks, recording those that did in ready. A dependency without a
+// ready check is ready once started; one asc up did not start, because the
+// pipeline or a budget holds it back, is not waited for.
+func waitDependencies(ctx context.Context, agentName string, agentCfg config.AgentConfig, cfg *config.Config, procManager process.ProcessManager, started, ready map[string]bool) error {
+	for _, dep := range agentCfg.DependsOn {
+		depCfg, ok := cfg.Agents[dep]
+		if !ok || ready[dep] {
+			continue // mcp_agent_mail is ready before any agent starts
+		}
+		if !started[dep] {
+			fmt.Println(i18n.T("up.dependency_not_started", agentName, dep))
+			logger.WithFields(logger.Fields{"agent": agentName, "dependency": dep}).Warn("Starting agent without its dependency")
+			continue
+		}
+		if !depCfg.ReadyCheck.IsSet() {
+			ready[dep] = true
+			continue
+		}
+
+		check := readyCheck(depCfg.ReadyCheck)
+		fmt.Println(i18n.T("up.agent_waiting", dep, check))
+		if err := waitReady(ctx, procManager, dep, check); err != nil {
+			return fmt.Errorf("agent '%s' did not become ready for '%s': %w\n  Suggestion: Check ~/.asc/logs/%s.log, or raise agent.%s.ready_check.timeout", dep, agentName, err, dep, dep)
+		}
+		fmt.Println(i18n.T("up.agent_ready", dep))
+		ready[dep] = true
+	}
+	return nil
+}
+
+// waitReady waits for the named process to pass check, failing early if
+// it exits
+func waitReady(ctx context.Context, procManager process.ProcessManager, name string, check readiness.Check) error {
+	info, err := procManager.GetProcessInfo(name)
+	if err != nil {
+		return err
+	}
+	check.LogFile = info.LogFile
+	check.LogOffset = info.LogOffset
+
+	logger.WithFields(logger.Fields{"process": name, "check": check.String()}).Debug("Waiting for process to be ready")
+	ctx, span := telemetry.Start(ctx, "up.wait_ready", telemetry.Attrs{"process.name": name, "readiness.check": check.String()})
+	defer span.End()
+	err = readiness.Wait(ctx, check, func() bool { return procManager.IsRunning(info.PID) })
+	span.SetError(err)
+	return err
+}
+
+// mcpReadyCheck returns how to tell mcp_agent_mail is ready: its
+// ready_check, or by default the port of its URL accepting connections
+func mcpReadyCheck(mcp config.MCPConfig) readiness.Check {
+	if mcp.ReadyCheck.IsSet() {
+		return readyCheck(mcp.ReadyCheck)
+	}
+	check := readiness.Check{Timeout: mcp.ReadyCheck.Timeout}
+	u, err := url.Parse(mcp.URL)
+	if err != nil || u.Hostname() == "" {
+		check.URL = mcp.URL
+		return check
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" || u.Scheme == "wss" {
+			port = "443"
+		}
+	}
+	check.Address = net.JoinHostPort(u.Hostname(), port)
+	return check
+}
 
-		fmt.Printf("  ✓ Agent %s started\n", agentName)
+// readyCheck converts a [ready_check] section; its log pattern was
+// validated when the configuration was loaded
+func readyCheck(rc config.ReadyCheckConfig) readiness.Check {
+	check := readiness.Check{URL: rc.URL, Timeout: rc.Timeout}
+	if rc.Port != 0 {
+		check.Address = net.JoinHostPort("localhost", strconv.Itoa(rc.Port))
+	}
+	if rc.LogRegex != "" {
+		check.LogPattern = regexp.MustCompile(rc.LogRegex)
+	}
+	return check
+}
+
+// reconcileUp prints the plan that brings the recorded processes in line
+// with the configuration, stops the processes it no longer has, such as
+// agents removed from asc.toml since the last run, and adopts those still
+// running that it has, so their restart policies and health checks apply.
+// The others are started by the steps that follow.
+func reconcileUp(ctx context.Context, cfg *config.Config, procManager *process.Manager) error {
+	desired := managedMCPServers(cfg)
+	servers := len(desired)
+	for name := range cfg.Agents {
+		desired = append(desired, name)
+	}
+	sort.Strings(desired[servers:])
+
+	steps, err := process.Plan(procManager, desired)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		printUpDryRun(cfg, steps)
+		return nil
+	}
+	printPlan(steps)
+
+	for _, step := range steps {
+		if step.Action == process.ActionKeep {
+			if _, err := procManager.Adopt(step.Name); err != nil {
+				// Exited since it was planned; it is started again
+				logger.WithFields(logger.Fields{"process": step.Name, "pid": step.PID}).Warn("Failed to adopt running process: %v", err)
+			}
+			continue
+		}
+		if step.Action != process.ActionStop && step.Action != process.ActionClean {
+			continue
+		}
+		logger.WithFields(logger.Fields{
+			"process": step.Name,
+			"pid":     step.PID,
+			"action":  step.Action,
+		}).Info("Removing process not in the configuration")
+		if err := procManager.StopNamed(ctx, step.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printPlan prints the steps of a reconcile plan
+func printPlan(steps []process.Step) {
+	if len(steps) == 0 {
+		return
+	}
+	fmt.Println(i18n.T("plan.title"))
+	for _, step := range steps {
+		fmt.Printf("  %s\n", describeStep(step))
+	}
+}
+
+// describeStep describes a step of a reconcile plan as Step.String does,
+// in the language of the output
+func describeStep(step process.Step) string {
+	notRunning := i18n.T("plan.pid_not_running", step.PID)
+	if step.Reused {
+		notRunning = i18n.T("plan.pid_reused", step.PID)
+	}
+	switch {
+	case step.Action == process.ActionKeep:
+		return i18n.T("plan.keep", step.Name, step.PID)
+	case step.Action == process.ActionStop:
+		return i18n.T("plan.stop", step.Name, step.PID)
+	case step.Action == process.ActionClean:
+		return i18n.T("plan.clean", step.Name, notRunning)
+	case step.Action == process.ActionStart && step.PID != 0:
+		return i18n.T("plan.start_stale", step.Name, notRunning)
+	case step.Action == process.ActionStart:
+		return i18n.T("plan.start", step.Name)
+	}
+	return step.String()
+}
+
+// printUpDryRun prints the plan of asc up under --dry-run, with the
+// command each process would be started with. Budgets are not checked, so
+// an agent over its budget is listed but would not be started.
+func printUpDryRun(cfg *config.Config, steps []process.Step) {
+	for _, step := range steps {
+		if step.Action != process.ActionStart {
+			printDryRun("dry_run.action", describeStep(step))
+			continue
+		}
+		if mcpCfg, ok := cfg.Services.MCPServer(step.Name); ok {
+			printDryRun("up.dry_run.start_service", describeStep(step), mcpCfg.StartCommand)
+			printDryRun("up.dry_run.wait_ready", step.Name, mcpReadyCheck(mcpCfg))
+			continue
+		}
+		agentCfg := cfg.Agents[step.Name]
+		if pipeline.Manages(cfg.Pipeline, agentCfg) {
+			printDryRun("up.dry_run.start_with_phase", describeStep(step), agentCfg.Model, agentCfg.Command)
+			continue
+		}
+		if agentCfg.UsesDocker() {
+			printDryRun("up.dry_run.start_in_docker", describeStep(step), agentCfg.Model, agentCfg.Docker.Image, agentCfg.Command)
+			continue
+		}
+		printDryRun("up.dry_run.start_agent", describeStep(step), agentCfg.Model, agentCfg.Command)
+	}
+	printDryRun("up.dry_run.open_tui")
+}
+
+// startAgent starts a single agent process and returns its PID
+func startAgent(agentName string, agentCfg config.AgentConfig, cfg *config.Config, procManager process.ProcessManager) (int, error) {
+	logger.WithFields(logger.Fields{
+		"agent": agentName,
+		"model": agentCfg.Model,
+		"phases": agentCfg.Phases,
+	}).Info("Starting agent")
+
+	// Build environment variables for this agent
+	agentEnv := buildAgentEnv(agentName, agentCfg, cfg)
+
+	// Point the agent at its rendered prompt, if one is configured
+	promptEnv, err := config.PromptEnv(agentName, agentCfg, cfg)
+	if err != nil {
 		logger.WithFields(logger.Fields{
 			"agent": agentName,
-			"pid": pid,
-		}).Info("Agent started successfully")
+		}).Error("Failed to render agent prompt: %v", err)
+		return 0, err
 	}
+	agentEnv = append(agentEnv, promptEnv...)
 
-	fmt.Println("All agents started successfully")
-	logger.Info("All agents started successfully")
+	// Give the agent its own worktree of the project repository, if enabled
+	worktreeEnv, err := config.WorktreeEnv(agentName, agentCfg, cfg)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"agent": agentName,
+		}).Error("Failed to provision agent worktree: %v", err)
+		return 0, err
+	}
+	agentEnv = append(agentEnv, worktreeEnv...)
+
+	// Add the variables of its env_file and extra_env, over those asc sets
+	ownEnv, err := config.AgentEnv(agentName, agentCfg)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"agent": agentName,
+		}).Error("Failed to read agent environment: %v", err)
+		return 0, err
+	}
+	agentEnv = append(agentEnv, ownEnv...)
+
+	if debugMode {
+		logger.WithFields(logger.Fields{
+			"agent": agentName,
+			"env_count": len(agentEnv),
+		}).Debug("Built agent environment variables")
+	}
+
+	// Parse command into command and args
+	cmd, args := parseCommand(agentCfg.Command)
+
+	logger.WithFields(logger.Fields{
+		"agent": agentName,
+		"command": cmd,
+		"args": args,
+	}).Debug("Parsed agent command")
+
+	// Run it in its container, for the docker runtime
+	cmd, args, agentEnv, err = config.RuntimeCommand(agentName, agentCfg, cmd, args, agentEnv)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"agent": agentName,
+		}).Error("Failed to prepare agent container: %v", err)
+		return 0, err
+	}
+
+	// Start the agent using process manager
+	pid, err := procManager.Start(agentName, cmd, args, agentEnv)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"agent": agentName,
+		}).Error("Failed to start agent: %v", err)
+		return 0, err
+	}
+
+	logger.WithFields(logger.Fields{
+		"agent": agentName,
+		"pid": pid,
+	}).Info("Agent started successfully")
+	return pid, nil
+}
+
+// mcpRestartPolicy restarts the MCP servers, which have no restart settings
+// of its own, as agents are restarted by default
+var mcpRestartPolicy = process.RestartPolicy{Mode: process.RestartOnFailure, MaxRetries: 5, Backoff: time.Second}
+
+// setRestartPolicies applies the restart policies of asc.toml to the
+// processes procManager starts
+func setRestartPolicies(cfg *config.Config, procManager *process.Manager) {
+	for _, name := range managedMCPServers(cfg) {
+		procManager.SetRestartPolicy(name, mcpRestartPolicy)
+	}
+	for name, agent := range cfg.Agents {
+		procManager.SetRestartPolicy(name, agentRestartPolicy(agent))
+	}
+}
+
+// agentRestartPolicy returns the restart policy of an agent
+func agentRestartPolicy(agent config.AgentConfig) process.RestartPolicy {
+	return process.RestartPolicy{
+		Mode:       process.RestartMode(strings.ToLower(agent.Restart)),
+		MaxRetries: agent.MaxRestarts,
+		Backoff:    agent.RestartBackoff,
+	}
+}
+
+// applyProcessSettings applies the settings of asc.toml for how procManager
+// runs the processes: restart policies, resource limits, stop policies,
+// health checks, stdin, log rotation, and crash capture
+func applyProcessSettings(cfg *config.Config, procManager *process.Manager) error {
+	setRestartPolicies(cfg, procManager)
+	setResourceLimits(cfg, procManager)
+	setStopPolicies(cfg, procManager)
+	if err := setHealthChecks(cfg, procManager); err != nil {
+		return err
+	}
+	setStdin(cfg, procManager)
+	setLaunch(cfg, procManager)
+	setLogRotation(cfg, procManager)
+	setCrashCapture(cfg, procManager)
+	return nil
+}
+
+// setResourceLimits applies the memory and CPU limits of asc.toml to the
+// agents procManager starts
+func setResourceLimits(cfg *config.Config, procManager *process.Manager) {
+	for name, agent := range cfg.Agents {
+		procManager.SetLimits(name, process.Limits{MaxMemoryMB: agent.MaxMemoryMB, CPULimit: agent.CPULimit})
+	}
+}
+
+// setStopPolicies applies the stop signals, grace periods, and pre_stop
+// hooks of asc.toml to the agents
+func setStopPolicies(cfg *config.Config, procManager *process.Manager) {
+	for name, agent := range cfg.Agents {
+		procManager.SetStopPolicy(name, process.StopPolicy{
+			Signal:      agent.StopSignal,
+			GracePeriod: agent.StopGracePeriod,
+			PreStop:     agent.PreStop,
+		})
+	}
+}
+
+// setStdin lets the agents with stdin = true in asc.toml read input
+// forwarded by asc attach --stdin
+func setStdin(cfg *config.Config, procManager *process.Manager) {
+	for name, agent := range cfg.Agents {
+		procManager.SetStdin(name, agent.Stdin)
+	}
+}
+
+// setLaunch applies the working directories and umasks of asc.toml to
+// the agents
+func setLaunch(cfg *config.Config, procManager *process.Manager) {
+	for name, agent := range cfg.Agents {
+		procManager.SetLaunch(name, process.Launch{Dir: agent.Workdir, Umask: agent.UmaskMode()})
+	}
+}
+
+// setHealthChecks applies the health checks of asc.toml to the agents.
+// Heartbeat checks ask mcp_agent_mail when the agent last reported in.
+func setHealthChecks(cfg *config.Config, procManager *process.Manager) error {
+	var client mcp.MCPClient
+	for name, agent := range cfg.Agents {
+		hc := agent.HealthCheck
+		if !hc.IsSet() {
+			continue
+		}
+		check := process.HealthCheck{
+			Interval:      hc.Interval,
+			Timeout:       hc.Timeout,
+			Retries:       hc.Retries,
+			Action:        process.HealthAction(strings.ToLower(hc.Action)),
+			NotifyCommand: hc.NotifyCommand,
+		}
+		switch {
+		case hc.Command != "":
+			check.Probe = process.ExecProbe(hc.Command)
+		case hc.URL != "":
+			check.Probe = process.HTTPProbe(hc.URL)
+		case hc.Heartbeat:
+			if client == nil {
+				mcpClient, err := newMCPClient(cfg)
+				if err != nil {
+					return fmt.Errorf("agent %s: heartbeat health check: %w", name, err)
+				}
+				client = mcpClient
+			}
+			check.Probe = heartbeatProbe(client, hc.MaxHeartbeatAge)
+		}
+		procManager.SetHealthCheck(name, check)
+	}
 	return nil
 }
 
+// heartbeatProbe passes while the agent's last heartbeat to mcp_agent_mail
+// is at most maxAge old, or the agent started less than maxAge ago. It fails while mcp_agent_mail cannot be reached,
+// since the heartbeat cannot be confirmed.
+func heartbeatProbe(client mcp.MCPClient, maxAge time.Duration) process.Probe {
+	return func(ctx context.Context, info *process.ProcessInfo) error {
+		status, err := client.GetAgentStatus(ctx, info.Name)
+		if err != nil {
+			return err
+		}
+		// A new agent has maxAge to report in
+		last := status.LastSeen
+		if last.Before(info.StartedAt) {
+			last = info.StartedAt
+		}
+		if age := time.Since(last); age > maxAge {
+			return fmt.Errorf("no heartbeat for %s", age.Round(time.Second))
+		}
+		return nil
+	}
+}
+
+// setLogRotation applies the log rotation of asc.toml to the processes
+// procManager starts
+func setLogRotation(cfg *config.Config, procManager *process.Manager) {
+	for _, name := range managedMCPServers(cfg) {
+		procManager.SetLogRotation(name, logRotation(cfg.Logging.Rotation))
+	}
+	for name, agent := range cfg.Agents {
+		procManager.SetLogRotation(name, logRotation(cfg.LogRotationFor(agent)))
+	}
+}
+
+// setCrashCapture applies [logging.crashes] to the crash dumps of the
+// processes procManager starts
+func setCrashCapture(cfg *config.Config, procManager *process.Manager) {
+	procManager.SetCrashCapture(process.CrashCapture{
+		LogBytes: int64(cfg.Logging.Crashes.LogKB) * 1024,
+		MaxFiles: cfg.Logging.Crashes.MaxFiles,
+	})
+}
+
+// logRotation converts a [logging.rotation] section for process.Manager
+func logRotation(rotation config.LogRotationConfig) process.LogRotation {
+	r := process.LogRotation{
+		MaxFiles: rotation.MaxFiles,
+		MaxAge:   time.Duration(rotation.MaxAgeDays) * 24 * time.Hour,
+		Compress: rotation.Compress != nil && *rotation.Compress,
+	}
+	if rotation.MaxSizeMB > 0 {
+		r.MaxSize = int64(rotation.MaxSizeMB) * 1024 * 1024
+	}
+	return r
+}
+
 // buildAgentEnv builds environment variables for an agent process
 func buildAgentEnv(agentName string, agentCfg config.AgentConfig, cfg *config.Config) []string {
 	// Start with all current environment variables (includes API keys from .env)
@@ -303,29 +1032,52 @@ func buildAgentEnv(agentName string, agentCfg config.AgentConfig, cfg *config.Co
 	}
 	env = append(env, fmt.Sprintf("AGENT_PHASES=%s", phases))
 
+	// Tell an instance of an agent with replicas which one it is
+	if agentCfg.Role != "" {
+		env = append(env, fmt.Sprintf("AGENT_ROLE=%s", agentCfg.Role))
+		env = append(env, fmt.Sprintf("AGENT_REPLICA=%d", agentCfg.Replica))
+	}
+
 	// Add MCP and beads configuration
-	env = append(env, fmt.Sprintf("MCP_MAIL_URL=%s", cfg.Services.MCPAgentMail.URL))
+	_, mcpServer := cfg.MCPServerFor(agentCfg)
+	env = append(env, fmt.Sprintf("MCP_MAIL_URL=%s", mcpServer.URL))
 	env = append(env, fmt.Sprintf("BEADS_DB_PATH=%s", cfg.Core.BeadsDBPath))
 
+	// Point the agent at its usage ledger for budget enforcement
+	env = append(env, usage.Env(agentName)...)
+
 	return env
 }
 
 // runTUI initializes and runs the TUI dashboard
-func runTUI(cfg *config.Config, procManager process.ProcessManager, debug bool) error {
+func runTUI(cfg *config.Config, procManager *process.Manager, orch *pipeline.Orchestrator, enforcer *budget.Enforcer, debug bool) error {
 	// Clear terminal screen
 	fmt.Print("\033[H\033[2J")
 
-	logger.Debug("Initializing beads client with path=%s", cfg.Core.BeadsDBPath)
+	logger.WithFields(logger.Fields{"path": cfg.Core.BeadsDBPath}).Debug("Initializing beads client")
 	// Initialize beads client with 5 second refresh interval
-	beadsClient := beads.NewClient(cfg.Core.BeadsDBPath, 5*time.Second)
+	beadsClient, err := newBeadsClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open beads database: %w", err)
+	}
 
-	logger.Debug("Initializing MCP client with url=%s", cfg.Services.MCPAgentMail.URL)
+	logger.WithFields(logger.Fields{"url": cfg.Services.MCPAgentMail.URL}).Debug("Initializing MCP client")
 	// Initialize MCP client
-	mcpClient := mcp.NewHTTPClient(cfg.Services.MCPAgentMail.URL)
+	mcpClient, err := newMCPClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create MCP client: %w", err)
+	}
 
 	// Create bubbletea Model with config and clients
 	model := tui.NewModel(*cfg, beadsClient, mcpClient, procManager)
 	model.SetDebugMode(debug)
+	model.SetStartAgent(reloadStartAgent(procManager, orch, enforcer))
+	if orch != nil {
+		model.SetPipeline(orch)
+	}
+	if enforcer != nil {
+		model.SetBudget(enforcer)
+	}
 
 	logger.Info("Starting TUI dashboard")
 	// Start TUI event loop with tea.NewProgram
@@ -335,8 +1087,11 @@ func runTUI(cfg *config.Config, procManager process.ProcessManager, debug bool)
 		tea.WithMouseCellMotion(), // Enable mouse support
 	)
 
-	// Run the program and handle exit
+	// Run the program and handle exit; log records on stderr would be
+	// drawn over the dashboard
+	muteStderrLog(true)
 	finalModel, err := program.Run()
+	muteStderrLog(false)
 	if err != nil {
 		logger.Error("TUI error: %v", err)
 		return fmt.Errorf("TUI error: %w", err)
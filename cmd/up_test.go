@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/rand/asc/internal/config"
 	"github.com/rand/asc/internal/process"
@@ -163,6 +166,36 @@ func TestBuildAgentEnv(t *testing.T) {
 	}
 }
 
+// TestBuildAgentEnv_MCPServer tests that an agent gets the URL of the MCP server it is routed to
+func TestBuildAgentEnv_MCPServer(t *testing.T) {
+	cfg := &config.Config{
+		Services: config.ServicesConfig{
+			MCPAgentMail: config.MCPConfig{URL: "http://localhost:8765", StartCommand: "python -m mcp_agent_mail.server"},
+			MCPServers: map[string]config.MCPConfig{
+				"mcp_shard_b": {URL: "http://localhost:8766", StartCommand: "python -m mcp_agent_mail.server --port 8766"},
+				"mcp_remote":  {URL: "https://mail.example.com"},
+			},
+		},
+		Agents: map[string]config.AgentConfig{
+			"tester": {Command: "python agent_adapter.py", MCPServer: "mcp_shard_b"},
+		},
+	}
+
+	found := false
+	for _, e := range buildAgentEnv("tester", cfg.Agents["tester"], cfg) {
+		if e == "MCP_MAIL_URL=http://localhost:8766" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("buildAgentEnv() did not set MCP_MAIL_URL to the agent's MCP server")
+	}
+
+	if got := managedMCPServers(cfg); !reflect.DeepEqual(got, []string{"mcp_agent_mail", "mcp_shard_b"}) {
+		t.Errorf("managedMCPServers() = %v, want the servers with a start_command, the default first", got)
+	}
+}
+
 // TestUpCommand_DependencyCheckFailure tests up command when dependency check fails
 func TestUpCommand_DependencyCheckFailure(t *testing.T) {
 	// Create test environment
@@ -325,7 +358,7 @@ func TestLaunchAgents_StartFailure(t *testing.T) {
 	}
 	
 	// Try to launch agents - should fail
-	err = launchAgents(cfg, procManager)
+	err = launchAgents(context.Background(), cfg, procManager, nil)
 	if err == nil {
 		t.Error("Expected launchAgents to fail with invalid command, but it succeeded")
 	}
@@ -336,6 +369,146 @@ func TestLaunchAgents_StartFailure(t *testing.T) {
 	}
 }
 
+// TestLaunchAgents_AdoptsRunningAgent tests that running up again keeps an
+// agent that is still running instead of failing on it
+func TestLaunchAgents_AdoptsRunningAgent(t *testing.T) {
+	env := NewTestEnvironment(t)
+	
+	procManager, err := process.NewManager(env.PIDDir, env.LogDir)
+	if err != nil {
+		t.Fatalf("Failed to create process manager: %v", err)
+	}
+	defer procManager.StopAll(context.Background())
+	
+	pid, err := procManager.Start("test-agent", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to start process: %v", err)
+	}
+	
+	// The command would fail if it were started
+	cfg := &config.Config{
+		Agents: map[string]config.AgentConfig{
+			"test-agent": {
+				Command: "/nonexistent/command/that/does/not/exist",
+				Model:   "claude",
+				Phases:  []string{"planning"},
+			},
+		},
+	}
+	
+	if err := launchAgents(context.Background(), cfg, procManager, nil); err != nil {
+		t.Fatalf("Expected the running agent to be adopted, got: %v", err)
+	}
+	if info, err := procManager.GetProcessInfo("test-agent"); err != nil || info.PID != pid {
+		t.Errorf("Expected the running agent to be kept, got %+v, %v", info, err)
+	}
+}
+
+// TestLaunchAgents_WaitsForDependencies tests that an agent starts only
+// once the agent it depends on passed its ready check
+func TestLaunchAgents_WaitsForDependencies(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	procManager, err := process.NewManager(env.PIDDir, env.LogDir)
+	if err != nil {
+		t.Fatalf("Failed to create process manager: %v", err)
+	}
+	defer procManager.StopAll(context.Background())
+
+	cfg := &config.Config{
+		Agents: map[string]config.AgentConfig{
+			"coder": {
+				Command:   "sleep 10",
+				Model:     "claude",
+				Phases:    []string{"implementation"},
+				DependsOn: []string{"indexer", "mcp_agent_mail"},
+			},
+			"indexer": {
+				Command:    "sh -c 'sleep 0.3; echo index built; sleep 10'",
+				Model:      "claude",
+				Phases:     []string{"planning"},
+				ReadyCheck: config.ReadyCheckConfig{LogRegex: "index built", Timeout: 5 * time.Second},
+			},
+		},
+	}
+
+	if err := launchAgents(context.Background(), cfg, procManager, nil); err != nil {
+		t.Fatalf("launchAgents failed: %v", err)
+	}
+	indexer, err := procManager.GetProcessInfo("indexer")
+	if err != nil {
+		t.Fatalf("Expected indexer to be started: %v", err)
+	}
+	coder, err := procManager.GetProcessInfo("coder")
+	if err != nil {
+		t.Fatalf("Expected coder to be started: %v", err)
+	}
+	if coder.StartedAt.Sub(indexer.StartedAt) < 300*time.Millisecond {
+		t.Errorf("Expected coder to start after indexer was ready, started %v later", coder.StartedAt.Sub(indexer.StartedAt))
+	}
+}
+
+// TestLaunchAgents_DependencyNotReady tests that agents depending on an
+// agent that exits before it is ready are not started
+func TestLaunchAgents_DependencyNotReady(t *testing.T) {
+	env := NewTestEnvironment(t)
+	t.Setenv("HOME", env.TempDir)
+
+	procManager, err := process.NewManager(env.PIDDir, env.LogDir)
+	if err != nil {
+		t.Fatalf("Failed to create process manager: %v", err)
+	}
+	defer procManager.StopAll(context.Background())
+
+	cfg := &config.Config{
+		Agents: map[string]config.AgentConfig{
+			"coder": {
+				Command:   "sleep 10",
+				Model:     "claude",
+				Phases:    []string{"implementation"},
+				DependsOn: []string{"indexer"},
+			},
+			"indexer": {
+				Command:    "sh -c 'exit 1'",
+				Model:      "claude",
+				Phases:     []string{"planning"},
+				ReadyCheck: config.ReadyCheckConfig{Port: 1, Timeout: 5 * time.Second},
+			},
+		},
+	}
+
+	err = launchAgents(context.Background(), cfg, procManager, nil)
+	if err == nil || !strings.Contains(err.Error(), "agent 'indexer' did not become ready for 'coder'") {
+		t.Fatalf("Expected a readiness error, got %v", err)
+	}
+	if _, err := procManager.GetProcessInfo("coder"); err == nil {
+		t.Error("Expected coder not to be started")
+	}
+}
+
+// TestMCPReadyCheck tests how mcp_agent_mail readiness is checked
+func TestMCPReadyCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		mcp  config.MCPConfig
+		want string
+	}{
+		{"URL port", config.MCPConfig{URL: "http://localhost:8765"}, "port localhost:8765"},
+		{"default https port", config.MCPConfig{URL: "https://mail.example.com/mcp"}, "port mail.example.com:443"},
+		{"configured port", config.MCPConfig{URL: "http://localhost:8765", ReadyCheck: config.ReadyCheckConfig{Port: 9000}}, "port localhost:9000"},
+		{"configured URL", config.MCPConfig{URL: "http://localhost:8765", ReadyCheck: config.ReadyCheckConfig{URL: "http://localhost:8765/health"}}, "URL http://localhost:8765/health"},
+		{"configured log", config.MCPConfig{ReadyCheck: config.ReadyCheckConfig{LogRegex: "Uvicorn running"}}, `log line matching "Uvicorn running"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mcpReadyCheck(tt.mcp).String(); got != tt.want {
+				t.Errorf("mcpReadyCheck() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestLaunchAgents_MultipleAgents tests launching multiple agents
 func TestLaunchAgents_MultipleAgents(t *testing.T) {
 	// Skip this test as it requires actual process execution
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/upgrade"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeChannel  string
+	upgradeCheck    bool
+	upgradeForce    bool
+	upgradeInsecure bool
+)
+
+// upgradeAPIURL is the GitHub API asc upgrade looks releases up with
+var upgradeAPIURL = upgrade.DefaultAPIURL
+
+// upgradeExecutable returns the path of the binary asc upgrade replaces
+var upgradeExecutable = func() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade asc to its newest release",
+	Long: `Replace this asc binary with the newest GitHub release of its channel:
+stable for releases, or beta for pre-releases too. The download is checked
+against the release's checksums, and the checksums against the release
+signing key, before it replaces the binary. A build without the signing
+key refuses to upgrade unless --insecure is given. The binary is replaced
+atomically, so an interrupted upgrade leaves the old one in place.
+
+Running stacks keep the binary they started with until restarted.
+
+Example:
+  asc upgrade --check                # Report whether a newer release exists
+  asc upgrade                        # Install the newest stable release
+  asc upgrade --channel beta         # Install the newest pre-release`,
+	Args: cobra.NoArgs,
+	RunE: runUpgrade,
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+	upgradeCmd.Flags().StringVar(&upgradeChannel, "channel", upgrade.ChannelStable, "Release channel: stable or beta")
+	upgradeCmd.Flags().BoolVar(&upgradeCheck, "check", false, "Only report whether a newer release exists")
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Install the release even if it is not newer, or over a build from source")
+	upgradeCmd.Flags().BoolVar(&upgradeInsecure, "insecure", false, "Install the release with only its checksum verified if this build has no release signing key")
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	if err := upgrade.ValidateChannel(upgradeChannel); err != nil {
+		return err
+	}
+	ctx := commandContext(cmd)
+	client, err := upgrade.NewClient(upgradeAPIURL)
+	if err != nil {
+		return err
+	}
+	client.AllowUnsigned = upgradeInsecure
+	release, err := client.Latest(ctx, upgradeChannel)
+	if err != nil {
+		return fmt.Errorf("%w\n  Suggestion: Check your network connection, or set GITHUB_TOKEN if the GitHub API rate limit was hit", err)
+	}
+	current := upgrade.Current()
+	recordUpgradeCheck(release)
+	newer := upgrade.Compare(release.Version, current) > 0
+
+	if upgradeCheck {
+		if newer {
+			fmt.Println(i18n.T("upgrade.available", release.Version, current))
+			fmt.Println(i18n.T("upgrade.available_hint", upgradeChannel))
+		} else {
+			fmt.Println(i18n.T("upgrade.up_to_date", current, upgradeChannel, release.Version))
+		}
+		return nil
+	}
+	switch {
+	case current == upgrade.DevVersion && !upgradeForce:
+		return fmt.Errorf("this asc was built from source, so it has no release to upgrade from\n  Suggestion: Use --force to replace it with asc %s", release.Version)
+	case !newer && !upgradeForce:
+		fmt.Println(i18n.T("upgrade.up_to_date", current, upgradeChannel, release.Version))
+		return nil
+	}
+
+	executable, err := upgradeExecutable()
+	if err != nil {
+		return fmt.Errorf("failed to find the asc binary: %w", err)
+	}
+	if dryRun {
+		printDryRun("upgrade.dry_run.download", release.Version, runtime.GOOS, runtime.GOARCH)
+		printDryRun("upgrade.dry_run.replace", executable)
+		return nil
+	}
+
+	fmt.Println(i18n.T("upgrade.downloading", release.Version))
+	binary, signed, err := client.Download(ctx, release)
+	if errors.Is(err, upgrade.ErrNoPublicKey) {
+		return fmt.Errorf("%w, so the binary was not replaced\n  Suggestion: Download the release from %s, or use --insecure to install it with only its checksum verified", err, release.URL)
+	}
+	if err != nil {
+		return fmt.Errorf("%w\n  Suggestion: The binary was not replaced; download it from %s", err, release.URL)
+	}
+	if !signed {
+		fmt.Fprintln(os.Stderr, i18n.T("warning", i18n.T("upgrade.unsigned")))
+	}
+	if err := upgrade.Replace(executable, binary); err != nil {
+		return fmt.Errorf("%w\n  Suggestion: Check that you can write to %s, or download the release from %s", err, filepath.Dir(executable), release.URL)
+	}
+	logger.WithFields(logger.Fields{"from": current, "to": release.Version, "path": executable}).Info("Upgraded asc")
+	fmt.Println(i18n.T("upgrade.upgraded", current, release.Version))
+	fmt.Println(i18n.T("upgrade.restart_hint"))
+	return nil
+}
+
+// recordUpgradeCheck records the newest release looked up, for asc
+// doctor's version check
+func recordUpgradeCheck(release *upgrade.Release) {
+	path, err := upgrade.CheckPath()
+	if err != nil {
+		return
+	}
+	check := &upgrade.Check{Channel: upgradeChannel, Latest: release.Version, CheckedAt: time.Now()}
+	if err := upgrade.SaveCheck(path, check); err != nil {
+		logger.Debug("Failed to record release check: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/upgrade"
+)
+
+// fakeReleases serves v1.1.0 of asc, whose binary is binary
+func fakeReleases(t *testing.T, binary string) {
+	t.Helper()
+	name := upgrade.AssetName(runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256([]byte(binary))
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/" + upgrade.Repo + "/releases":
+			json.NewEncoder(w).Encode([]upgrade.Release{{Version: "v1.1.0", Assets: []upgrade.Asset{
+				{Name: name, URL: server.URL + "/binary"},
+				{Name: upgrade.ChecksumsAsset, URL: server.URL + "/checksums"},
+			}}})
+		case "/binary":
+			fmt.Fprint(w, binary)
+		case "/checksums":
+			fmt.Fprintf(w, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	oldURL, oldExecutable := upgradeAPIURL, upgradeExecutable
+	oldChannel, oldCheck, oldForce, oldInsecure := upgradeChannel, upgradeCheck, upgradeForce, upgradeInsecure
+	oldVersion, oldKey := upgrade.Version, upgrade.PublicKey
+	t.Cleanup(func() {
+		upgradeAPIURL, upgradeExecutable = oldURL, oldExecutable
+		upgradeChannel, upgradeCheck, upgradeForce, upgradeInsecure = oldChannel, oldCheck, oldForce, oldInsecure
+		upgrade.Version, upgrade.PublicKey = oldVersion, oldKey
+	})
+	upgradeAPIURL = server.URL
+	upgradeChannel, upgradeCheck, upgradeForce, upgradeInsecure = upgrade.ChannelStable, false, false, false
+	upgrade.PublicKey = ""
+}
+
+func TestUpgradeCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fakeReleases(t, "asc v1.1.0")
+	executable := filepath.Join(t.TempDir(), "asc")
+	if err := os.WriteFile(executable, []byte("asc v1.0.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	upgradeExecutable = func() (string, error) { return executable, nil }
+	upgrade.Version = "v1.0.0"
+
+	upgradeCheck = true
+	capture := NewCaptureOutput()
+	capture.Start()
+	err := runUpgrade(upgradeCmd, nil)
+	capture.Stop()
+	if err != nil || !strings.Contains(capture.GetStdout(), "asc v1.1.0 is available") {
+		t.Errorf("runUpgrade(--check) = %v, output:\n%s", err, capture.GetStdout())
+	}
+	if data, _ := os.ReadFile(executable); string(data) != "asc v1.0.0" {
+		t.Error("Expected --check not to replace the binary")
+	}
+	// asc doctor reuses the check
+	path, _ := upgrade.CheckPath()
+	if check, err := upgrade.LoadCheck(path); err != nil || check.Latest != "v1.1.0" {
+		t.Errorf("Expected the check recorded, got %+v, %v", check, err)
+	}
+
+	// The fake releases are unsigned, and this build has no signing key
+	upgradeCheck = false
+	if err := runUpgrade(upgradeCmd, nil); err == nil || !strings.Contains(err.Error(), "--insecure") {
+		t.Errorf("Expected an unverifiable release refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(executable); string(data) != "asc v1.0.0" {
+		t.Error("Expected the binary kept without a verified signature")
+	}
+
+	upgradeInsecure = true
+	capture = NewCaptureOutput()
+	capture.Start()
+	err = runUpgrade(upgradeCmd, nil)
+	capture.Stop()
+	if err != nil {
+		t.Fatalf("runUpgrade(--insecure) error = %v", err)
+	}
+	if data, _ := os.ReadFile(executable); string(data) != "asc v1.1.0" {
+		t.Errorf("Expected the binary replaced, got %q", data)
+	}
+
+	upgrade.Version = "v1.1.0"
+	capture = NewCaptureOutput()
+	capture.Start()
+	err = runUpgrade(upgradeCmd, nil)
+	capture.Stop()
+	if err != nil || !strings.Contains(capture.GetStdout(), "up to date") {
+		t.Errorf("Expected the newest release to be up to date, got %v:\n%s", err, capture.GetStdout())
+	}
+}
+
+func TestUpgradeCommand_FromSource(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fakeReleases(t, "asc v1.1.0")
+	upgrade.Version = upgrade.DevVersion
+
+	if err := runUpgrade(upgradeCmd, nil); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected upgrading a build from source to need --force, got %v", err)
+	}
+
+	upgradeChannel = "nightly"
+	if err := runUpgrade(upgradeCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid channel") {
+		t.Errorf("Expected an unknown channel to fail, got %v", err)
+	}
+}
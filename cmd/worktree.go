@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var worktreeForce bool
+
+var worktreeCmd = &cobra.Command{
+	Use:   "worktree",
+	Short: "Manage the git worktrees of agents",
+	Long: `Manage the git worktrees asc provisions for agents.
+
+With [worktree] enabled in asc.toml, every agent gets its own worktree of
+the project repository (core.beads_db_path) when it starts, on a branch
+named <branch_prefix><agent>. Agents edit files and commit each finished
+task there, so parallel agents never edit the same checkout. Merge an
+agent's branch back with 'asc worktree merge' and remove worktrees that are
+no longer needed with 'asc worktree prune'.`,
+}
+
+var worktreeStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List agent worktrees with their unmerged commits and changes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newWorktreeCommandManager(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		statuses, err := manager.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Print(formatWorktreeStatus(statuses))
+		return nil
+	},
+}
+
+var worktreeMergeCmd = &cobra.Command{
+	Use:   "merge <agent>...",
+	Short: "Merge agent branches into their base branch",
+	Long: `Merge each agent's branch into the branch its worktree was created
+from, which must be checked out in the project repository. A worktree with
+uncommitted changes is not merged, and a merge that conflicts is aborted
+and left to be done by hand.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newWorktreeCommandManager(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return printMergeDryRun(manager, args)
+		}
+		for _, agent := range args {
+			merged, err := manager.Merge(agent)
+			if err != nil {
+				return err
+			}
+			if merged == 0 {
+				fmt.Println(i18n.T("worktree.nothing_to_merge", agent))
+				continue
+			}
+			fmt.Println(i18n.T("worktree.merged", merged, agent))
+		}
+		return nil
+	},
+}
+
+var worktreePruneCmd = &cobra.Command{
+	Use:   "prune [agent]...",
+	Short: "Remove agent worktrees and their merged branches",
+	Long: `Remove the worktrees of the given agents, or of every agent, and delete
+their branches once they are merged. Worktrees of running agents and
+worktrees with uncommitted changes are kept; --force removes them anyway
+and deletes unmerged branches too.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newWorktreeCommandManager(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+
+		agents := args
+		if !worktreeForce {
+			if agents, err = idleWorktreeAgents(manager, args); err != nil {
+				return err
+			}
+			if len(agents) == 0 {
+				fmt.Println(i18n.T("worktree.nothing_to_prune"))
+				return nil
+			}
+		}
+
+		if dryRun {
+			return printPruneDryRun(manager, agents, worktreeForce)
+		}
+
+		results, err := manager.Prune(agents, worktreeForce)
+		for _, result := range results {
+			if result.BranchDeleted {
+				fmt.Println(i18n.T("worktree.removed_branch", result.Agent))
+			} else {
+				fmt.Println(i18n.T("worktree.removed", result.Agent))
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(worktreeCmd)
+	worktreeCmd.AddCommand(worktreeStatusCmd)
+	worktreeCmd.AddCommand(worktreeMergeCmd)
+	worktreeCmd.AddCommand(worktreePruneCmd)
+	worktreePruneCmd.Flags().BoolVar(&worktreeForce, "force", false, "Remove worktrees of running agents and with uncommitted changes, and delete unmerged branches")
+}
+
+// newWorktreeManager creates the manager used by the worktree command. It
+// is a variable so tests can point it at a temporary state file.
+var newWorktreeManager = config.NewWorktreeManager
+
+// worktreeAgentRunning reports whether an agent process is running. It is
+// a variable so tests do not depend on ~/.asc/pids.
+var worktreeAgentRunning = func(agent string) bool {
+	procManager, err := process.NewDefaultManager()
+	if err != nil {
+		return false
+	}
+	info, err := procManager.GetProcessInfo(agent)
+	return err == nil && process.Live(procManager, info)
+}
+
+// newWorktreeCommandManager loads asc.toml and returns the worktree manager
+func newWorktreeCommandManager(ctx context.Context) (*worktree.Manager, error) {
+	cfg, err := config.LoadContext(ctx, config.DefaultConfigPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return newWorktreeManager(cfg)
+}
+
+// idleWorktreeAgents returns the agents among those given (or all agents
+// with a worktree) that are not running, reporting the ones skipped
+func idleWorktreeAgents(manager *worktree.Manager, agents []string) ([]string, error) {
+	if len(agents) == 0 {
+		statuses, err := manager.Status()
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			agents = append(agents, status.Agent)
+		}
+	}
+
+	idle := []string{}
+	for _, agent := range agents {
+		if worktreeAgentRunning(agent) {
+			fmt.Println(i18n.T("worktree.skipping_running", agent))
+			continue
+		}
+		idle = append(idle, agent)
+	}
+	return idle, nil
+}
+
+// worktreeStatuses returns the status of each given agent's worktree, or
+// of every worktree if no agents are given
+func worktreeStatuses(manager *worktree.Manager, agents []string) ([]worktree.Status, error) {
+	statuses, err := manager.Status()
+	if err != nil || len(agents) == 0 {
+		return statuses, err
+	}
+
+	byAgent := make(map[string]worktree.Status, len(statuses))
+	for _, status := range statuses {
+		byAgent[status.Agent] = status
+	}
+	selected := []worktree.Status{}
+	for _, agent := range agents {
+		status, ok := byAgent[agent]
+		if !ok {
+			return nil, fmt.Errorf("agent '%s' has no worktree", agent)
+		}
+		selected = append(selected, status)
+	}
+	return selected, nil
+}
+
+// printMergeDryRun prints what merging the agents' branches would do, for
+// --dry-run
+func printMergeDryRun(manager *worktree.Manager, agents []string) error {
+	statuses, err := worktreeStatuses(manager, agents)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		switch {
+		case s.Changes > 0:
+			fmt.Println(i18n.T("worktree.dry_run.uncommitted", s.Agent, s.Changes))
+		case s.Ahead == 0:
+			fmt.Println(i18n.T("worktree.nothing_to_merge", s.Agent))
+		default:
+			printDryRun("worktree.dry_run.merge", s.Ahead, s.Branch, s.Base)
+		}
+	}
+	return nil
+}
+
+// printPruneDryRun prints what pruning the agents' worktrees would remove,
+// for --dry-run
+func printPruneDryRun(manager *worktree.Manager, agents []string, force bool) error {
+	statuses, err := worktreeStatuses(manager, agents)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		if s.Changes > 0 && !force {
+			fmt.Println(i18n.T("worktree.dry_run.kept", s.Agent, s.Changes))
+			continue
+		}
+		if s.Exists {
+			printDryRun("worktree.dry_run.remove", s.Path, s.Agent)
+		}
+		if force || s.Ahead == 0 {
+			printDryRun("worktree.dry_run.delete_branch", s.Branch)
+		} else {
+			printDryRun("worktree.dry_run.keep_branch", s.Branch, s.Ahead)
+		}
+	}
+	return nil
+}
+
+// formatWorktreeStatus renders one line per agent worktree
+func formatWorktreeStatus(statuses []worktree.Status) string {
+	if len(statuses) == 0 {
+		return i18n.T("worktree.none") + "\n"
+	}
+
+	var out strings.Builder
+	for _, s := range statuses {
+		state := i18n.T("worktree.state", s.Ahead, s.Behind, s.Base)
+		if s.Changes > 0 {
+			state += i18n.T("worktree.state.uncommitted", s.Changes)
+		}
+		path := s.Path
+		if !s.Exists {
+			path += " " + i18n.T("worktree.missing")
+		}
+		fmt.Fprintf(&out, "%-16s %-24s %s\n", s.Agent, s.Branch, state)
+		fmt.Fprintf(&out, "%-16s %s\n", "", path)
+	}
+	return out.String()
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/worktree"
+)
+
+const worktreeTestConfig = `[core]
+beads_db_path = "%s"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+
+[worktree]
+enabled = true
+`
+
+// TestWorktreeCommands provisions a worktree and runs status, merge and prune
+func TestWorktreeCommands(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "asc test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "asc test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	env := NewTestEnvironment(t)
+	repo := filepath.Join(env.TempDir, "project-repo")
+	gitRun := func(dir string, args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	os.MkdirAll(repo, 0755)
+	gitRun(repo, "init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0644)
+	gitRun(repo, "add", "-A")
+	gitRun(repo, "commit", "-q", "-m", "Initial commit")
+
+	env.WriteConfig(fmt.Sprintf(worktreeTestConfig, repo))
+	defer ChangeToTempDir(t, env.TempDir)()
+
+	statePath := filepath.Join(env.TempDir, "worktrees.json")
+	oldNew, oldRunning := newWorktreeManager, worktreeAgentRunning
+	defer func() { newWorktreeManager, worktreeAgentRunning = oldNew, oldRunning }()
+	newWorktreeManager = func(cfg *config.Config) (*worktree.Manager, error) {
+		return worktree.NewManagerWithStatePath(cfg.Core.BeadsDBPath, filepath.Join(env.TempDir, "worktrees"), cfg.Worktree.BranchPrefix, "", statePath), nil
+	}
+	running := true
+	worktreeAgentRunning = func(agent string) bool { return running }
+
+	cfg, err := config.Load(config.DefaultConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	manager, _ := newWorktreeManager(cfg)
+	record, err := manager.Provision("coder")
+	if err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+	os.WriteFile(filepath.Join(record.Path, "feature.go"), []byte("package feature\n"), 0644)
+	gitRun(record.Path, "add", "-A")
+	gitRun(record.Path, "commit", "-q", "-m", "Add feature")
+
+	run := func(fn func() error) (string, error) {
+		t.Helper()
+		capture := NewCaptureOutput()
+		capture.Start()
+		err := fn()
+		capture.Stop()
+		return capture.GetStdout(), err
+	}
+
+	out, err := run(func() error { return worktreeStatusCmd.RunE(worktreeStatusCmd, nil) })
+	if err != nil || !strings.Contains(out, "asc/coder") || !strings.Contains(out, "1 ahead, 0 behind main") {
+		t.Errorf("Unexpected status: %q, %v", out, err)
+	}
+
+	out, err = run(func() error { return worktreeMergeCmd.RunE(worktreeMergeCmd, []string{"coder"}) })
+	if err != nil || !strings.Contains(out, "Merged 1 commit(s) from agent coder") {
+		t.Errorf("Unexpected merge result: %q, %v", out, err)
+	}
+
+	// A running agent's worktree is kept
+	out, _ = run(func() error { return worktreePruneCmd.RunE(worktreePruneCmd, nil) })
+	if !strings.Contains(out, "Skipping agent coder") {
+		t.Errorf("Expected prune to skip the running agent, got %q", out)
+	}
+
+	running = false
+	out, err = run(func() error { return worktreePruneCmd.RunE(worktreePruneCmd, nil) })
+	if err != nil || !strings.Contains(out, "Removed worktree and branch of agent coder") {
+		t.Errorf("Unexpected prune result: %q, %v", out, err)
+	}
+}
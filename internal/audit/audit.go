@@ -0,0 +1,208 @@
+// Package audit records state-changing asc actions (starting and stopping
+// the stack, agent restarts, task edits, fixes, secrets operations) in an
+// append-only JSON Lines file at ~/.asc/audit.log, so that operators
+// sharing a host can see who did what and whether it worked. The lifecycle
+// events of package events, such as an agent crashing, are recorded there
+// too, as entries of their event type.
+//
+// Example usage:
+//
+//	entry := audit.NewEntry("secrets rotate")
+//	err := rotate()
+//	if werr := audit.Write(entry.Finish(err)); werr != nil {
+//	    logger.Warn("Failed to write audit log: %v", werr)
+//	}
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/statedir"
+)
+
+// Results recorded for an action
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// TypeAction is the type of entries of actions run by a user, as opposed
+// to lifecycle events such as "agent.started"
+const TypeAction = "action"
+
+// Entry is a single audited action
+type Entry struct {
+	Timestamp     time.Time `json:"timestamp"` // When the action started
+	Type          string    `json:"type"`      // TypeAction, or the type of an event
+	User          string    `json:"user"`
+	Host          string    `json:"host,omitempty"`
+	Action        string    `json:"action"` // e.g. "up", "secrets rotate", "tui restart agent"
+	Args          []string  `json:"args,omitempty"`
+	Agent         string    `json:"agent,omitempty"` // The agent an event is about
+	Task          string    `json:"task,omitempty"`  // The task an event is about
+	Result        string    `json:"result"`
+	Message       string    `json:"message,omitempty"` // Error on failure, optional detail on success
+	CorrelationID string    `json:"correlation_id,omitempty"`
+}
+
+// NewEntry starts an audit entry for action, stamped with the current time,
+// user, and host
+func NewEntry(action string, args ...string) Entry {
+	host, _ := os.Hostname()
+	return Entry{
+		Timestamp: time.Now(),
+		Type:      TypeAction,
+		User:      currentUser(),
+		Host:      host,
+		Action:    action,
+		Args:      args,
+	}
+}
+
+// Finish returns a copy of the entry with the result of the action: success
+// if err is nil, otherwise failure with the error as the message. It also
+// records the correlation ID of the action in progress.
+func (e Entry) Finish(err error) Entry {
+	if err != nil {
+		e.Result = ResultFailure
+		e.Message = err.Error()
+	} else {
+		e.Result = ResultSuccess
+	}
+	e.CorrelationID = logger.CorrelationID()
+	return e
+}
+
+// Redacted returns a copy of the entry with secrets masked in its
+// arguments and message (see logger.Redact)
+func (e Entry) Redacted() Entry {
+	if len(e.Args) > 0 {
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = logger.Redact(arg)
+		}
+		e.Args = args
+	}
+	e.Message = logger.Redact(e.Message)
+	return e
+}
+
+// DefaultPath returns ~/.asc/audit.log
+func DefaultPath() (string, error) {
+	return statedir.Path("audit.log")
+}
+
+// Write appends the entry to the default audit log
+func Write(entry Entry) error {
+	path, err := DefaultPath()
+	if err != nil {
+		return err
+	}
+	return Append(path, entry)
+}
+
+// Append redacts the entry and appends it as one line to the audit log at
+// path. The file is only ever opened for appending, with owner-only
+// permissions.
+func Append(path string, entry Entry) error {
+	line, err := json.Marshal(entry.Redacted())
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries of the audit log at path, oldest first. A
+// missing file has no entries; lines that cannot be decoded are skipped.
+func Read(path string) ([]Entry, error) {
+	entries, _, err := ReadFrom(path, 0)
+	return entries, err
+}
+
+// ReadFrom returns the entries of the audit log at path that were appended
+// at or after offset, and the offset to read the next from, so the log can
+// be followed. A line still being written is left for the next read.
+// Entries written before entries had a type are actions.
+func ReadFrom(path string, offset int64) ([]Entry, int64, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []Entry{}, offset, nil
+	}
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	entries := []Entry{}
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return entries, offset, nil
+		}
+		if err != nil {
+			return entries, offset, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		offset += int64(len(line))
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		if entry.Type == "" {
+			entry.Type = TypeAction
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// Recent returns the last n entries of the default audit log, or all of
+// them if n is zero or negative
+func Recent(n int) ([]Entry, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := Read(path)
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, err
+}
+
+// currentUser returns the name of the user running asc
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	if name := os.Getenv("USERNAME"); name != "" {
+		return name
+	}
+	return "unknown"
+}
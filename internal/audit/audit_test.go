@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".asc", "audit.log")
+
+	if entries, err := Read(path); err != nil || len(entries) != 0 {
+		t.Fatalf("Read() of a missing log = %v, %v, want no entries", entries, err)
+	}
+
+	if err := Append(path, NewEntry("up", "--debug=true").Finish(nil)); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := Append(path, NewEntry("secrets rotate").Finish(errors.New("age not found"))); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected audit log at %s: %v", path, err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Audit log mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Read() returned %d entries, want 2", len(entries))
+	}
+
+	up := entries[0]
+	if up.Action != "up" || up.Result != ResultSuccess || len(up.Args) != 1 || up.Args[0] != "--debug=true" {
+		t.Errorf("Unexpected first entry: %+v", up)
+	}
+	if up.User == "" || up.Timestamp.IsZero() {
+		t.Errorf("Expected user and timestamp to be recorded, got %+v", up)
+	}
+
+	rotate := entries[1]
+	if rotate.Result != ResultFailure || rotate.Message != "age not found" {
+		t.Errorf("Unexpected second entry: %+v", rotate)
+	}
+}
+
+func TestAppendRedactsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger.RegisterSecret("audit-secret-value-123")
+
+	entry := NewEntry("secrets encrypt", "--key=audit-secret-value-123").Finish(errors.New("bad key audit-secret-value-123"))
+	if err := Append(path, entry); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "audit-secret-value-123") {
+		t.Errorf("Secret written to the audit log: %s", data)
+	}
+	if !strings.Contains(string(data), logger.RedactedPlaceholder) {
+		t.Errorf("Expected redaction placeholder in the audit log: %s", data)
+	}
+}
+
+func TestRecent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	for _, action := range []string{"up", "down", "up"} {
+		if err := Write(NewEntry(action).Finish(nil)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	entries, err := Recent(2)
+	if err != nil {
+		t.Fatalf("Recent() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != "down" || entries[1].Action != "up" {
+		t.Errorf("Recent(2) = %+v, want the last two entries", entries)
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".asc", "audit.log")); err != nil {
+		t.Errorf("Expected Write to use ~/.asc/audit.log: %v", err)
+	}
+}
+
+func TestReadFrom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	// An entry of an older asc, without a type, and a line being written
+	legacy := `{"timestamp":"2026-01-02T15:04:05Z","user":"alice","action":"up","result":"success"}` + "\n"
+	if err := os.WriteFile(path, []byte(legacy+`{"timestamp":`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	entries, offset, err := ReadFrom(path, 0)
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Type != TypeAction || offset != int64(len(legacy)) {
+		t.Fatalf("ReadFrom() = %+v, %d, want the complete legacy entry as an action", entries, offset)
+	}
+
+	// The partial line is read once it is complete
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteString(`"2026-01-02T15:05:00Z","type":"agent.stopped","agent":"coder","user":"bob","action":"agent.stopped","result":"success"}` + "\n")
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, next, err := ReadFrom(path, offset)
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Type != "agent.stopped" || entries[0].Agent != "coder" || next <= offset {
+		t.Errorf("ReadFrom(%d) = %+v, %d, want the appended event", offset, entries, next)
+	}
+	if entries, _, _ := ReadFrom(path, next); len(entries) != 0 {
+		t.Errorf("Expected nothing after the last entry, got %+v", entries)
+	}
+}
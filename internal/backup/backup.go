@@ -0,0 +1,538 @@
+// Package backup archives the state of an asc project, so it can be moved
+// to another machine or recovered after corruption. An archive is a
+// tar.gz of the project's asc.toml and .env.age, the beads database (the
+// .beads directory of the beads repository), and the state directory,
+// less the PID files of running processes, worktrees, migration backups,
+// lock files, and the age key. It ends with manifest.json, which lists the
+// size and sha256 of every file and a hash over them all, so a truncated
+// or altered archive is refused before anything is restored.
+//
+// Example usage:
+//
+//	manifest, err := backup.Create(out, backup.Locations{ProjectDir: ".", StateDir: stateDir, BeadsDir: beadsDir}, backup.Options{})
+//	...
+//	archive, err := backup.Open(path)
+//	if err != nil {
+//	    return err // errors.Is(err, backup.ErrIntegrity) for a damaged archive
+//	}
+//	defer archive.Close()
+//	restored, err := archive.Restore(locations, false)
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestName is the last entry of an archive
+const ManifestName = "manifest.json"
+
+// FormatVersion is the version of the archive layout this release writes
+// and reads
+const FormatVersion = 1
+
+// Prefixes of the entries of an archive, by where they are restored to
+const (
+	ProjectPrefix = "project/" // The project directory
+	BeadsPrefix   = "beads/"   // The .beads directory of the beads repository
+	StatePrefix   = "state/"   // The state directory
+)
+
+// ProjectFiles are the files of the project directory archived, when they
+// exist, as glob patterns: asc.toml and the overlays and encrypted secrets
+// of its profiles. The plaintext .env files are not.
+var ProjectFiles = []string{"asc.toml", "asc.*.toml", ".env.age", ".env.*.age"}
+
+// KeyFileName is the age key in the state directory, archived only with
+// Options.IncludeKey, as it decrypts .env.age
+const KeyFileName = "age.key"
+
+// excludedState are the subdirectories of the state directory left out:
+// the PID files of processes that will not run on the restored machine,
+// worktrees, which are git checkouts asc provisions again, and migration
+// backups
+var excludedState = map[string]bool{
+	"pids":      true,
+	"worktrees": true,
+	"backups":   true,
+}
+
+// excludedFiles are the lock files left out, wherever they are
+var excludedFiles = map[string]bool{
+	".lock":         true,
+	".migrate.lock": true,
+}
+
+// ErrIntegrity is wrapped by the errors of Open for an archive whose
+// contents do not match its manifest
+var ErrIntegrity = errors.New("backup archive failed its integrity check")
+
+// Locations are the directories archived, and restored to
+type Locations struct {
+	ProjectDir string // Holds asc.toml
+	StateDir   string // Normally ~/.asc
+	BeadsDir   string // The beads repository (core.beads_db_path); empty to leave the database out
+}
+
+// Options change what Create archives
+type Options struct {
+	IncludeKey bool   // Archive the age key of the state directory
+	AscVersion string // Recorded in the manifest
+	Exclude    string // A file left out, such as the archive being written
+}
+
+// Manifest describes the contents of an archive
+type Manifest struct {
+	Format     int       `json:"format"`
+	CreatedAt  time.Time `json:"created_at"`
+	AscVersion string    `json:"asc_version,omitempty"`
+	Files      []File    `json:"files"`
+
+	// BeadsDBPath is the beads repository, relative to the project
+	// directory when it is inside it
+	BeadsDBPath string `json:"beads_db_path,omitempty"`
+
+	// Hash is the sha256 of the "<sha256>  <path>" line of every file, in
+	// the order of Files
+	Hash string `json:"hash"`
+}
+
+// File is an archived file
+type File struct {
+	Path   string      `json:"path"`
+	Size   int64       `json:"size"`
+	Mode   os.FileMode `json:"mode"`
+	SHA256 string      `json:"sha256"`
+}
+
+// Size returns the total size of the files of m
+func (m *Manifest) Size() int64 {
+	var size int64
+	for _, file := range m.Files {
+		size += file.Size
+	}
+	return size
+}
+
+// hash returns the hash over files, as in Manifest.Hash
+func hash(files []File) string {
+	sum := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(sum, "%s  %s\n", file.SHA256, file.Path)
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// Create writes the archive of loc to w and returns its manifest
+func Create(w io.Writer, loc Locations, opts Options) (*Manifest, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest := &Manifest{
+		Format:      FormatVersion,
+		CreatedAt:   time.Now().UTC(),
+		AscVersion:  opts.AscVersion,
+		BeadsDBPath: relativeTo(loc.ProjectDir, loc.BeadsDir),
+	}
+	excluded := ""
+	if opts.Exclude != "" {
+		excluded, _ = filepath.Abs(opts.Exclude)
+	}
+	add := func(name, src string) error {
+		if abs, _ := filepath.Abs(src); abs == excluded {
+			return nil
+		}
+		file, err := addFile(tw, name, src)
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", src, err)
+		}
+		manifest.Files = append(manifest.Files, file)
+		return nil
+	}
+
+	for _, pattern := range ProjectFiles {
+		matches, err := filepath.Glob(filepath.Join(loc.ProjectDir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, src := range matches {
+			if err := add(ProjectPrefix+filepath.Base(src), src); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if loc.BeadsDir != "" {
+		err := walk(filepath.Join(loc.BeadsDir, ".beads"), func(rel, src string) error {
+			return add(BeadsPrefix+rel, src)
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := walk(loc.StateDir, func(rel, src string) error {
+		if rel == KeyFileName && !opts.IncludeKey {
+			return nil
+		}
+		return add(StatePrefix+rel, src)
+	}, excludedState)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.Hash = hash(manifest.Files)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	header := &tar.Header{Name: ManifestName, Mode: 0600, Size: int64(len(data)), ModTime: manifest.CreatedAt, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// relativeTo returns path relative to dir if it is inside it, and path
+// otherwise
+func relativeTo(dir, path string) string {
+	if path == "" {
+		return ""
+	}
+	absDir, errDir := filepath.Abs(dir)
+	absPath, errPath := filepath.Abs(path)
+	if errDir != nil || errPath != nil {
+		return path
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return absPath
+	}
+	return filepath.ToSlash(rel)
+}
+
+// BeadsDir returns the beads repository of the archive for a project
+// restored to projectDir, or "" if the archive has no beads database
+func (m *Manifest) BeadsDir(projectDir string) string {
+	switch {
+	case m.BeadsDBPath == "":
+		return ""
+	case filepath.IsAbs(m.BeadsDBPath):
+		return m.BeadsDBPath
+	}
+	return filepath.Join(projectDir, filepath.FromSlash(m.BeadsDBPath))
+}
+
+// walk calls fn with the slash-separated path relative to dir of every
+// regular file under dir, skipping the top-level subdirectories in skip
+// and the lock files. A missing dir has no files.
+func walk(dir string, fn func(rel, src string) error, skip map[string]bool) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(dir, func(src string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, src)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			if skip[rel] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Sockets, symlinks, and the like are not state
+		if !entry.Type().IsRegular() || excludedFiles[entry.Name()] {
+			return nil
+		}
+		return fn(rel, src)
+	})
+}
+
+// addFile writes the file src to tw as name, returning its manifest entry
+func addFile(tw *tar.Writer, name, src string) (File, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return File{}, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return File{}, err
+	}
+	header := &tar.Header{Name: name, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime(), Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return File{}, err
+	}
+	sum := sha256.New()
+	// A file that grows while it is archived is cut at its size when it
+	// was opened; tar requires the size up front
+	written, err := io.Copy(io.MultiWriter(tw, sum), io.LimitReader(in, info.Size()))
+	if err != nil {
+		return File{}, err
+	}
+	if written != info.Size() {
+		return File{}, fmt.Errorf("file shrank while it was archived")
+	}
+	return File{Path: name, Size: info.Size(), Mode: info.Mode().Perm(), SHA256: hex.EncodeToString(sum.Sum(nil))}, nil
+}
+
+// Archive is an archive extracted to a staging directory and verified
+// against its manifest
+type Archive struct {
+	Manifest *Manifest
+	staging  string
+}
+
+// Open extracts the archive at path to a temporary directory and checks
+// every file against the manifest. Close removes the directory.
+func Open(path string) (*Archive, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	staging, err := os.MkdirTemp("", "asc-restore-*")
+	if err != nil {
+		return nil, err
+	}
+	archive := &Archive{staging: staging}
+	if err := archive.extract(in); err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return archive, nil
+}
+
+// Close removes the files extracted by Open
+func (a *Archive) Close() error {
+	return os.RemoveAll(a.staging)
+}
+
+// extract reads the archive from r to the staging directory and verifies it
+func (a *Archive) extract(r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: not a gzip archive: %v", ErrIntegrity, err)
+	}
+	tr := tar.NewReader(gz)
+	sums := make(map[string]string)
+	var manifest []byte
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrIntegrity, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return fmt.Errorf("%w: unexpected entry %s", ErrIntegrity, header.Name)
+		}
+		if header.Name == ManifestName {
+			if manifest, err = io.ReadAll(tr); err != nil {
+				return fmt.Errorf("%w: %v", ErrIntegrity, err)
+			}
+			continue
+		}
+		if !validName(header.Name) {
+			return fmt.Errorf("%w: unexpected entry %s", ErrIntegrity, header.Name)
+		}
+		sum, err := a.stage(header.Name, tr)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrIntegrity, err)
+		}
+		sums[header.Name] = sum
+	}
+	if manifest == nil {
+		return fmt.Errorf("%w: no %s, the archive is truncated", ErrIntegrity, ManifestName)
+	}
+
+	a.Manifest = &Manifest{}
+	if err := json.Unmarshal(manifest, a.Manifest); err != nil {
+		return fmt.Errorf("%w: invalid %s: %v", ErrIntegrity, ManifestName, err)
+	}
+	if a.Manifest.Format > FormatVersion {
+		return fmt.Errorf("archive format %d was written by a newer version of asc; this release reads up to %d", a.Manifest.Format, FormatVersion)
+	}
+	if hash(a.Manifest.Files) != a.Manifest.Hash {
+		return fmt.Errorf("%w: the manifest does not match its hash", ErrIntegrity)
+	}
+	for _, file := range a.Manifest.Files {
+		sum, ok := sums[file.Path]
+		if !ok {
+			return fmt.Errorf("%w: %s is missing", ErrIntegrity, file.Path)
+		}
+		if sum != file.SHA256 {
+			return fmt.Errorf("%w: %s does not match its checksum", ErrIntegrity, file.Path)
+		}
+		delete(sums, file.Path)
+	}
+	for name := range sums {
+		return fmt.Errorf("%w: %s is not in the manifest", ErrIntegrity, name)
+	}
+	return nil
+}
+
+// validName reports whether name is a relative, clean path under one of
+// the archive's prefixes
+func validName(name string) bool {
+	if path.Clean(name) != name || path.IsAbs(name) || strings.HasPrefix(name, "../") || strings.Contains(name, "/../") {
+		return false
+	}
+	for _, prefix := range []string{ProjectPrefix, BeadsPrefix, StatePrefix} {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stage writes the entry name read from r to the staging directory,
+// returning its sha256
+func (a *Archive) stage(name string, r io.Reader) (string, error) {
+	dest := filepath.Join(a.staging, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return "", err
+	}
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, sum), r); err != nil {
+		out.Close()
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// Target returns where the archived file name is restored to in loc, or
+// false if loc has no place for it, as for the beads database when
+// loc.BeadsDir is empty
+func Target(loc Locations, name string) (string, bool) {
+	switch {
+	case strings.HasPrefix(name, ProjectPrefix):
+		return filepath.Join(loc.ProjectDir, filepath.FromSlash(strings.TrimPrefix(name, ProjectPrefix))), true
+	case strings.HasPrefix(name, BeadsPrefix) && loc.BeadsDir != "":
+		return filepath.Join(loc.BeadsDir, ".beads", filepath.FromSlash(strings.TrimPrefix(name, BeadsPrefix))), true
+	case strings.HasPrefix(name, StatePrefix):
+		return filepath.Join(loc.StateDir, filepath.FromSlash(strings.TrimPrefix(name, StatePrefix))), true
+	}
+	return "", false
+}
+
+// Changes returns the files of the archive that differ from those in loc,
+// and of those the ones that exist, which Restore overwrites only if
+// asked to
+func (a *Archive) Changes(loc Locations) (changed, conflicts []string) {
+	for _, file := range a.Manifest.Files {
+		target, ok := Target(loc, file.Path)
+		if !ok {
+			continue
+		}
+		sum, err := fileSum(target)
+		switch {
+		case err != nil:
+			changed = append(changed, file.Path)
+		case sum != file.SHA256:
+			changed = append(changed, file.Path)
+			conflicts = append(conflicts, file.Path)
+		}
+	}
+	return changed, conflicts
+}
+
+// Restore writes the files of the archive to loc, each atomically, and
+// returns those it wrote. Files already as archived are left alone.
+// Unless overwrite is set, it writes nothing if any other file would be
+// replaced.
+func (a *Archive) Restore(loc Locations, overwrite bool) ([]string, error) {
+	changed, conflicts := a.Changes(loc)
+	if len(conflicts) > 0 && !overwrite {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("%d file(s) would be overwritten, such as %s", len(conflicts), conflicts[0])
+	}
+	modes := make(map[string]os.FileMode)
+	for _, file := range a.Manifest.Files {
+		modes[file.Path] = file.Mode
+	}
+	for i, name := range changed {
+		target, _ := Target(loc, name)
+		if err := install(filepath.Join(a.staging, filepath.FromSlash(name)), target, modes[name]); err != nil {
+			return changed[:i], fmt.Errorf("failed to restore %s: %w", target, err)
+		}
+	}
+	return changed, nil
+}
+
+// install copies src to dest through a temporary file renamed over it
+func install(src, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// fileSum returns the sha256 of the file at path
+func fileSum(path string) (string, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	sum := sha256.New()
+	if _, err := io.Copy(sum, in); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
@@ -0,0 +1,274 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeFiles creates the files of contents, by path relative to dir
+func writeFiles(t *testing.T, dir string, contents map[string]string) {
+	t.Helper()
+	for name, content := range contents {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newLocations(t *testing.T) Locations {
+	root := t.TempDir()
+	return Locations{
+		ProjectDir: filepath.Join(root, "project"),
+		StateDir:   filepath.Join(root, "state"),
+		BeadsDir:   filepath.Join(root, "project", "repo"),
+	}
+}
+
+func createArchive(t *testing.T, opts Options) (string, *Manifest) {
+	t.Helper()
+	src := newLocations(t)
+	writeFiles(t, src.ProjectDir, map[string]string{
+		"asc.toml":          "[core]\n",
+		"asc.prod.toml":     "[core]\n",
+		".env.age":          "encrypted",
+		".env.prod.age":     "encrypted",
+		".env":              "CLAUDE_API_KEY=sk-plaintext",
+		".env.prod":         "CLAUDE_API_KEY=sk-plaintext",
+		"repo/.beads/bd.db": "tasks",
+		"repo/README.md":    "not beads",
+	})
+	writeFiles(t, src.StateDir, map[string]string{
+		"age.key":                       "AGE-SECRET-KEY",
+		"schema.json":                   `{"version": 1}`,
+		"playbooks/coder/playbook.json": "{}",
+		"logs/coder.log":                "log",
+		"pids/coder.json":               "{}",
+		"worktrees/repo/coder/file":     "checkout",
+		".lock":                         "",
+	})
+
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	manifest, err := Create(out, src, opts)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return path, manifest
+}
+
+func TestCreate(t *testing.T) {
+	_, manifest := createArchive(t, Options{AscVersion: "v1.0.0"})
+	var names []string
+	for _, file := range manifest.Files {
+		names = append(names, file.Path)
+	}
+	slices.Sort(names)
+	want := []string{
+		"beads/bd.db",
+		"project/.env.age",
+		"project/.env.prod.age",
+		"project/asc.prod.toml",
+		"project/asc.toml",
+		"state/logs/coder.log",
+		"state/playbooks/coder/playbook.json",
+		"state/schema.json",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("Archived %q, want %q", names, want)
+	}
+	if manifest.Hash != hash(manifest.Files) || manifest.AscVersion != "v1.0.0" || manifest.BeadsDBPath != "repo" {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+
+	_, manifest = createArchive(t, Options{IncludeKey: true})
+	if !slices.ContainsFunc(manifest.Files, func(f File) bool { return f.Path == "state/age.key" }) {
+		t.Error("Expected --include-key to archive the age key")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	path, _ := createArchive(t, Options{})
+	archive, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer archive.Close()
+
+	dst := newLocations(t)
+	restored, err := archive.Restore(dst, false)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if len(restored) != len(archive.Manifest.Files) {
+		t.Errorf("Restored %q, want every file", restored)
+	}
+	data, err := os.ReadFile(filepath.Join(dst.BeadsDir, ".beads", "bd.db"))
+	if err != nil || string(data) != "tasks" {
+		t.Errorf("Expected the beads database restored, got %q, %v", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(dst.StateDir, "schema.json")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the state restored with its permissions, got %v", err)
+	}
+
+	// Restoring again changes nothing
+	if restored, err := archive.Restore(dst, false); err != nil || len(restored) != 0 {
+		t.Errorf("Restore() again = %q, %v", restored, err)
+	}
+
+	// A changed file is only overwritten when asked to
+	writeFiles(t, dst.ProjectDir, map[string]string{"asc.toml": "[core]\nchanged = true\n"})
+	if _, err := archive.Restore(dst, false); err == nil {
+		t.Error("Expected a changed file not to be overwritten")
+	}
+	if restored, err := archive.Restore(dst, true); err != nil || !slices.Equal(restored, []string{"project/asc.toml"}) {
+		t.Errorf("Restore(overwrite) = %q, %v", restored, err)
+	}
+}
+
+// rewrite copies the archive at path, passing each entry through edit,
+// which returns the contents to write or nil to drop the entry
+func rewrite(t *testing.T, path string, edit func(name string, data []byte) []byte) string {
+	t.Helper()
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	var buf bytes.Buffer
+	gzOut := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzOut)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		if data = edit(header.Name, data); data == nil {
+			continue
+		}
+		header.Size = int64(len(data))
+		tw.WriteHeader(header)
+		tw.Write(data)
+	}
+	tw.Close()
+	gzOut.Close()
+
+	out := filepath.Join(t.TempDir(), "edited.tar.gz")
+	if err := os.WriteFile(out, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestOpen_Integrity(t *testing.T) {
+	path, _ := createArchive(t, Options{})
+
+	tests := map[string]func(name string, data []byte) []byte{
+		"tampered": func(name string, data []byte) []byte {
+			if name == "project/asc.toml" {
+				return []byte("[core]\nevil = true\n")
+			}
+			return data
+		},
+		"truncated": func(name string, data []byte) []byte {
+			if name == ManifestName {
+				return nil
+			}
+			return data
+		},
+		"missing file": func(name string, data []byte) []byte {
+			if name == "beads/bd.db" {
+				return nil
+			}
+			return data
+		},
+	}
+	for name, edit := range tests {
+		t.Run(name, func(t *testing.T) {
+			archive, err := Open(rewrite(t, path, edit))
+			if err == nil {
+				archive.Close()
+			}
+			if !errors.Is(err, ErrIntegrity) {
+				t.Errorf("Open() error = %v, want an integrity error", err)
+			}
+		})
+	}
+
+	t.Run("escaping path", func(t *testing.T) {
+		edited := rewrite(t, path, func(name string, data []byte) []byte { return data })
+		appendEntry(t, edited, "state/../../outside", "x")
+		archive, err := Open(edited)
+		if err == nil {
+			archive.Close()
+		}
+		if !errors.Is(err, ErrIntegrity) {
+			t.Errorf("Open() error = %v, want an integrity error", err)
+		}
+	})
+}
+
+// appendEntry rewrites the archive at path with an entry added before
+// its manifest
+func appendEntry(t *testing.T, path, name, content string) {
+	t.Helper()
+	var manifest []byte
+	stripped := rewrite(t, path, func(entry string, data []byte) []byte {
+		if entry == ManifestName {
+			manifest = data
+			return nil
+		}
+		return data
+	})
+	data, err := os.ReadFile(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, _ := gzip.NewReader(bytes.NewReader(data))
+	tr := tar.NewReader(gz)
+	var buf bytes.Buffer
+	gzOut := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzOut)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		body, _ := io.ReadAll(tr)
+		tw.WriteHeader(header)
+		tw.Write(body)
+	}
+	for _, entry := range []struct{ name, content string }{{name, content}, {ManifestName, string(manifest)}} {
+		tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0600, Size: int64(len(entry.content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(entry.content))
+	}
+	tw.Close()
+	gzOut.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,156 @@
+package beads
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/telemetry"
+)
+
+// NewTask is a task to create in bulk: its title, and the fields to set
+// other than bd's defaults (an open task of priority 2)
+type NewTask struct {
+	Title    string   `json:"title"`
+	Phase    string   `json:"phase,omitempty"`
+	Priority *int     `json:"priority,omitempty"`
+	Labels   []string `json:"labels,omitempty"`
+	Assignee string   `json:"assignee,omitempty"`
+}
+
+// BulkUpdate is an update of the task with the given ID
+type BulkUpdate struct {
+	ID string
+	TaskUpdate
+}
+
+// BulkClient is implemented by the beads clients that change many tasks
+// in one call. The clients of this package implement it: the SQLite
+// client applies each call in a single transaction, all or nothing, while
+// the bd client groups tasks into as few bd runs as it can and stops at
+// the first that fails.
+type BulkClient interface {
+	// CreateTasks creates tasks, returning them with their IDs in order
+	CreateTasks(ctx context.Context, tasks []NewTask) ([]Task, error)
+	// UpdateTasks applies updates in order
+	UpdateTasks(ctx context.Context, updates []BulkUpdate) error
+	// CloseTasks closes the tasks with the given IDs
+	CloseTasks(ctx context.Context, ids []string) error
+}
+
+// createFlags returns the bd create flags of the fields of spec other than
+// its title
+func createFlags(spec NewTask) []string {
+	var flags []string
+	if spec.Phase != "" {
+		flags = append(flags, "--phase", spec.Phase)
+	}
+	if spec.Priority != nil {
+		flags = append(flags, "--priority", strconv.Itoa(*spec.Priority))
+	}
+	if len(spec.Labels) > 0 {
+		flags = append(flags, "--labels", strings.Join(spec.Labels, ","))
+	}
+	if spec.Assignee != "" {
+		flags = append(flags, "--assignee", spec.Assignee)
+	}
+	return flags
+}
+
+// validateNewTasks rejects tasks without a title before any is created
+func validateNewTasks(tasks []NewTask) error {
+	for i, task := range tasks {
+		if strings.TrimSpace(task.Title) == "" {
+			return fmt.Errorf("task %d has no title", i+1)
+		}
+	}
+	return nil
+}
+
+// CreateTasks creates tasks using the bd CLI, one bd create each, as bd
+// cannot create several at once. Returns the tasks created before the
+// first that failed with its error.
+func (c *Client) CreateTasks(ctx context.Context, tasks []NewTask) ([]Task, error) {
+	if err := validateNewTasks(tasks); err != nil {
+		return nil, err
+	}
+	created := make([]Task, 0, len(tasks))
+	for _, spec := range tasks {
+		task, err := c.createTask(ctx, spec)
+		if err != nil {
+			return created, fmt.Errorf("failed to create task %d of %d (%s): %w", len(created)+1, len(tasks), spec.Title, err)
+		}
+		created = append(created, task)
+	}
+	return created, nil
+}
+
+// UpdateTasks applies updates using the bd CLI, with one bd update for
+// the tasks of each distinct update, in the order each first appears
+func (c *Client) UpdateTasks(ctx context.Context, updates []BulkUpdate) (err error) {
+	ctx, end := c.trace(ctx, "beads.bulk_update", telemetry.Attrs{"beads.task_count": len(updates)})
+	defer end(&err)
+
+	var order []string
+	groups := make(map[string][]string)
+	specs := make(map[string]TaskUpdate)
+	for _, update := range updates {
+		key := strings.Join(updateFlags(update.TaskUpdate), "\x00")
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+			specs[key] = update.TaskUpdate
+		}
+		groups[key] = append(groups[key], update.ID)
+	}
+	for _, key := range order {
+		var flags []string
+		if key != "" {
+			flags = strings.Split(key, "\x00")
+		}
+		ids := groups[key]
+		if err := c.runBulk(ctx, "update", append(append([]string{}, ids...), flags...)); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			events.Publish(events.Event{Type: events.TaskUpdated, Task: id, Message: strings.Join(flags, " ")})
+			c.history.updated(BulkUpdate{ID: id, TaskUpdate: specs[key]})
+		}
+	}
+	return nil
+}
+
+// CloseTasks closes the tasks with the given IDs with one bd close
+func (c *Client) CloseTasks(ctx context.Context, ids []string) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+	ctx, end := c.trace(ctx, "beads.bulk_close", telemetry.Attrs{"beads.task_count": len(ids)})
+	defer end(&err)
+	if err := c.runBulk(ctx, "close", ids); err != nil {
+		return err
+	}
+	status := StatusClosed
+	for _, id := range ids {
+		events.Publish(events.Event{Type: events.TaskUpdated, Task: id, Message: "--status " + StatusClosed})
+		c.history.updated(BulkUpdate{ID: id, TaskUpdate: TaskUpdate{Status: &status}})
+	}
+	return nil
+}
+
+// runBulk runs a bd subcommand on several tasks
+func (c *Client) runBulk(ctx context.Context, subcommand string, args []string) error {
+	cmd := c.command(ctx, "bd", append([]string{subcommand}, args...)...)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("bd %s interrupted: %w", subcommand, ctx.Err())
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return c.withHint(fmt.Errorf("bd %s failed: %w (stderr: %s)", subcommand, err, string(exitErr.Stderr)))
+		}
+		return c.withHint(fmt.Errorf("bd %s failed: %w", subcommand, err))
+	}
+	return nil
+}
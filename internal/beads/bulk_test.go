@@ -0,0 +1,177 @@
+package beads
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientBulk(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	// A bd that records its arguments and creates tasks numbered by the
+	// runs so far, failing on a title of "fail"
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	script := `#!/bin/sh
+echo "$@" >> "` + argsFile + `"
+if [ "$2" = "create" ]; then
+	if [ "$3" = "fail" ]; then
+		exit 1
+	fi
+	echo "{\"id\":\"bd-$(wc -l < "` + argsFile + `" | tr -d ' ')\",\"title\":\"$3\",\"status\":\"open\"}"
+fi
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	client := NewClient(t.TempDir(), time.Second)
+	ctx := context.Background()
+	high := 1
+	created, err := client.CreateTasks(ctx, []NewTask{
+		{Title: "Schema", Priority: &high, Labels: []string{"db", "backend"}},
+		{Title: "API", Phase: "implementation", Assignee: "coder"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTasks() error = %v", err)
+	}
+	if len(created) != 2 || created[0].ID != "bd-1" || created[1].ID != "bd-2" {
+		t.Errorf("Expected bd-1 and bd-2, got %+v", created)
+	}
+
+	inProgress, phase := "in_progress", "testing"
+	if err := client.UpdateTasks(ctx, []BulkUpdate{
+		{ID: "bd-1", TaskUpdate: TaskUpdate{Status: &inProgress}},
+		{ID: "bd-2", TaskUpdate: TaskUpdate{Phase: &phase}},
+		{ID: "bd-3", TaskUpdate: TaskUpdate{Status: &inProgress}},
+	}); err != nil {
+		t.Fatalf("UpdateTasks() error = %v", err)
+	}
+	if err := client.CloseTasks(ctx, []string{"bd-1", "bd-2"}); err != nil {
+		t.Fatalf("CloseTasks() error = %v", err)
+	}
+
+	created, err = client.CreateTasks(ctx, []NewTask{{Title: "Docs"}, {Title: "fail"}, {Title: "Never"}})
+	if err == nil || !strings.Contains(err.Error(), "task 2 of 3 (fail)") {
+		t.Errorf("Expected the second task to fail, got %v", err)
+	}
+	if len(created) != 1 || created[0].Title != "Docs" {
+		t.Errorf("Expected the tasks created before the failure, got %+v", created)
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--json create Schema --priority 1 --labels db,backend\n" +
+		"--json create API --phase implementation --assignee coder\n" +
+		"update bd-1 bd-3 --status in_progress\n" +
+		"update bd-2 --phase testing\n" +
+		"close bd-1 bd-2\n" +
+		"--json create Docs\n" +
+		"--json create fail\n"
+	if string(data) != want {
+		t.Errorf("bd ran with\n%s\nwant\n%s", data, want)
+	}
+
+	if _, err := client.CreateTasks(ctx, []NewTask{{Title: " "}}); err == nil || !strings.Contains(err.Error(), "task 1 has no title") {
+		t.Errorf("Expected a task without a title to be rejected, got %v", err)
+	}
+}
+
+func TestSQLiteClient_Bulk(t *testing.T) {
+	db := seededDatabase()
+	db.labels = [][2]string{}
+	client, err := OpenSQLite(newFakeDatabase(t, db), time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	high := 0
+	created, err := client.CreateTasks(ctx, []NewTask{
+		{Title: "Schema", Priority: &high, Labels: []string{"db"}},
+		{Title: "API", Phase: "implementation"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTasks() error = %v", err)
+	}
+	if len(created) != 2 || created[0].ID != "asc-11" || created[1].ID != "asc-12" || created[0].Priority != 0 || created[1].Priority != 2 {
+		t.Fatalf("Expected asc-11 and asc-12 with their priorities, got %+v", created)
+	}
+
+	urgent, phase := 1, "testing"
+	if err := client.UpdateTasks(ctx, []BulkUpdate{
+		{ID: "asc-11", TaskUpdate: TaskUpdate{Priority: &urgent, Labels: &[]string{"db", "schema"}}},
+		{ID: "asc-12", TaskUpdate: TaskUpdate{Phase: &phase}},
+	}); err != nil {
+		t.Fatalf("UpdateTasks() error = %v", err)
+	}
+	if err := client.CloseTasks(ctx, []string{"asc-1", "asc-2"}); err != nil {
+		t.Fatalf("CloseTasks() error = %v", err)
+	}
+
+	tasks, err := client.GetTasks(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+	byID := map[string]Task{}
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	if task := byID["asc-11"]; task.Priority != 1 || !reflect.DeepEqual(task.Labels, []string{"db", "schema"}) {
+		t.Errorf("Expected asc-11 to be labeled and reprioritized, got %+v", task)
+	}
+	if byID["asc-12"].Phase != "testing" || byID["asc-1"].Status != StatusClosed || byID["asc-2"].Status != StatusClosed {
+		t.Errorf("Expected the updates and closes to apply, got %+v", tasks)
+	}
+	for _, id := range []string{"asc-11", "asc-12"} {
+		if !db.dirty[id] {
+			t.Errorf("Expected %s to be marked dirty", id)
+		}
+	}
+
+	// A batch with a missing task changes nothing
+	if err := client.CloseTasks(ctx, []string{"asc-12", "asc-99"}); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	if _, err := client.CreateTasks(ctx, []NewTask{{Title: "Kept?"}, {Title: ""}}); err == nil {
+		t.Error("Expected a task without a title to fail the batch")
+	}
+	tasks, err = client.GetTasks(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+	for _, task := range tasks {
+		if task.ID == "asc-12" && task.Status == StatusClosed || task.Title == "Kept?" {
+			t.Errorf("Expected the failed batches to change nothing, got %+v", task)
+		}
+	}
+	if len(tasks) != 5 {
+		t.Errorf("Expected 5 tasks, got %d", len(tasks))
+	}
+}
+
+func TestSQLiteClient_BulkLabelsWithoutTable(t *testing.T) {
+	client, err := OpenSQLite(newFakeDatabase(t, seededDatabase()), time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.createTasks(context.Background(), []NewTask{{Title: "Tagged", Labels: []string{"db"}}})
+	if err == nil || !strings.Contains(err.Error(), "no labels table") {
+		t.Errorf("Expected labels to need the labels table, got %v", err)
+	}
+}
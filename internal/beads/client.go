@@ -1,11 +1,12 @@
 // Package beads provides a client for interacting with the beads task database.
-// It wraps the bd CLI tool and provides a Go interface for task management
+// It wraps the bd CLI tool, or reads and writes the beads SQLite database
+// directly (see New), and provides a Go interface for task management
 // operations including creating, reading, updating, and deleting tasks.
 //
 // Example usage:
 //
 //	client := beads.NewClient("./project-repo", 5*time.Second)
-//	tasks, err := client.GetTasks([]string{"open", "in_progress"})
+//	tasks, err := client.GetTasks(ctx, []string{"open", "in_progress"})
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
@@ -15,32 +16,54 @@
 package beads
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	ascerrors "github.com/rand/asc/internal/errors"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/fswatch"
 	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/telemetry"
 )
 
+// commandWaitDelay bounds how long a cancelled command's output is drained
+const commandWaitDelay = 2 * time.Second
+
+// beadsLog tags every record written by this package with the beads component
+var beadsLog = logger.WithComponent("beads")
+
 // BeadsClient defines the interface for interacting with the beads task database.
+// Calls are abandoned when ctx is cancelled; those of the bd client run a
+// subprocess that is killed then.
 type BeadsClient interface {
-	GetTasks(statuses []string) ([]Task, error)
-	CreateTask(title string) (Task, error)
-	UpdateTask(id string, updates TaskUpdate) error
-	DeleteTask(id string) error
-	Refresh() error
+	GetTasks(ctx context.Context, statuses []string) ([]Task, error)
+	CreateTask(ctx context.Context, title string) (Task, error)
+	UpdateTask(ctx context.Context, id string, updates TaskUpdate) error
+	DeleteTask(ctx context.Context, id string) error
+	Refresh(ctx context.Context) error
 }
 
 // Task represents a beads task with its metadata including
-// ID, title, status, phase, and optional assignee.
+// ID, title, status, phase, priority (0 is the highest), labels,
+// optional assignee, and the IDs of the tasks it depends on, which
+// block it until they are closed.
 type Task struct {
-	ID       string `json:"id"`
-	Title    string `json:"title"`
-	Status   string `json:"status"`
-	Phase    string `json:"phase"`
-	Assignee string `json:"assignee,omitempty"`
+	ID           string   `json:"id"`
+	Title        string   `json:"title"`
+	Status       string   `json:"status"`
+	Phase        string   `json:"phase"`
+	Priority     int      `json:"priority"`
+	Labels       []string `json:"labels,omitempty"`
+	Assignee     string   `json:"assignee,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
 }
 
 // TaskUpdate represents fields that can be updated on a task.
@@ -49,7 +72,9 @@ type TaskUpdate struct {
 	Title    *string `json:"title,omitempty"`
 	Status   *string `json:"status,omitempty"`
 	Phase    *string `json:"phase,omitempty"`
-	Assignee *string `json:"assignee,omitempty"`
+	Priority *int      `json:"priority,omitempty"`
+	Labels   *[]string `json:"labels,omitempty"` // Replaces all the labels of the task
+	Assignee *string   `json:"assignee,omitempty"`
 }
 
 // Client implements the BeadsClient interface using the bd CLI tool.
@@ -57,6 +82,7 @@ type TaskUpdate struct {
 type Client struct {
 	dbPath         string        // Path to the beads database repository
 	refreshInterval time.Duration // Interval for periodic refresh operations
+	history         *History      // Records the changes of tasks, if set
 }
 
 // NewClient creates a new beads client with the specified database path
@@ -76,7 +102,9 @@ func NewClient(dbPath string, refreshInterval time.Duration) *Client {
 // GetTasks retrieves tasks filtered by status using the bd CLI.
 // If statuses is empty, all tasks are returned. Returns an error if
 // the bd command fails or output cannot be parsed.
-func (c *Client) GetTasks(statuses []string) ([]Task, error) {
+func (c *Client) GetTasks(ctx context.Context, statuses []string) (_ []Task, err error) {
+	ctx, end := c.trace(ctx, "beads.list")
+	defer end(&err)
 	args := []string{"--json", "list"}
 	
 	// Add status filters if provided
@@ -84,59 +112,67 @@ func (c *Client) GetTasks(statuses []string) ([]Task, error) {
 		args = append(args, "--status", strings.Join(statuses, ","))
 	}
 	
-	logger.WithFields(logger.Fields{
+	beadsLog.WithFields(logger.Fields{
 		"command": "bd",
 		"args":    args,
 		"db_path": c.dbPath,
 	}).Debug("Executing beads query")
 	
-	cmd := exec.Command("bd", args...)
-	if c.dbPath != "" {
-		cmd.Dir = c.dbPath
-	}
+	cmd := c.command(ctx, "bd", args...)
 	
 	output, err := cmd.Output()
 	if err != nil {
-		logger.WithFields(logger.Fields{
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("bd list interrupted: %w", ctx.Err())
+		}
+		beadsLog.WithFields(logger.Fields{
 			"command": "bd",
 			"args":    args,
 		}).Error("Beads query failed: %v", err)
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("bd list failed: %w (stderr: %s)", err, string(exitErr.Stderr))
+			return nil, c.withHint(fmt.Errorf("bd list failed: %w (stderr: %s)", err, string(exitErr.Stderr)))
 		}
-		return nil, fmt.Errorf("bd list failed: %w", err)
+		return nil, c.withHint(fmt.Errorf("bd list failed: %w", err))
 	}
 	
 	var tasks []Task
 	if len(output) > 0 {
 		if err := json.Unmarshal(output, &tasks); err != nil {
-			logger.Error("Failed to parse beads output: %v", err)
+			beadsLog.Error("Failed to parse beads output: %v", err)
 			return nil, fmt.Errorf("failed to parse bd output: %w", err)
 		}
 	}
 	
-	logger.WithFields(logger.Fields{
+	beadsLog.WithFields(logger.Fields{
 		"task_count": len(tasks),
 		"statuses":   statuses,
 	}).Debug("Beads query completed successfully")
 	
+	c.history.observe(tasks, len(statuses) == 0)
 	return tasks, nil
 }
 
 // CreateTask creates a new task with the given title using the bd CLI.
 // Returns the created task with its assigned ID, or an error if creation fails.
-func (c *Client) CreateTask(title string) (Task, error) {
-	cmd := exec.Command("bd", "--json", "create", title)
-	if c.dbPath != "" {
-		cmd.Dir = c.dbPath
-	}
+func (c *Client) CreateTask(ctx context.Context, title string) (Task, error) {
+	return c.createTask(ctx, NewTask{Title: title})
+}
+
+// createTask creates a task of spec using the bd CLI
+func (c *Client) createTask(ctx context.Context, spec NewTask) (_ Task, err error) {
+	ctx, end := c.trace(ctx, "beads.create")
+	defer end(&err)
+	cmd := c.command(ctx, "bd", append([]string{"--json", "create", spec.Title}, createFlags(spec)...)...)
 	
 	output, err := cmd.Output()
 	if err != nil {
+		if ctx.Err() != nil {
+			return Task{}, fmt.Errorf("bd create interrupted: %w", ctx.Err())
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return Task{}, fmt.Errorf("bd create failed: %w (stderr: %s)", err, string(exitErr.Stderr))
+			return Task{}, c.withHint(fmt.Errorf("bd create failed: %w (stderr: %s)", err, string(exitErr.Stderr)))
 		}
-		return Task{}, fmt.Errorf("bd create failed: %w", err)
+		return Task{}, c.withHint(fmt.Errorf("bd create failed: %w", err))
 	}
 	
 	var task Task
@@ -144,96 +180,181 @@ func (c *Client) CreateTask(title string) (Task, error) {
 		return Task{}, fmt.Errorf("failed to parse bd output: %w", err)
 	}
 	
+	events.Publish(events.Event{Type: events.TaskCreated, Task: task.ID, Message: spec.Title})
+	c.history.created(task)
 	return task, nil
 }
 
 // UpdateTask updates a task with the given ID using the bd CLI.
 // Only non-nil fields in the TaskUpdate struct will be updated.
 // Returns an error if the update fails.
-func (c *Client) UpdateTask(id string, updates TaskUpdate) error {
-	args := []string{"update", id}
+func (c *Client) UpdateTask(ctx context.Context, id string, updates TaskUpdate) (err error) {
+	ctx, end := c.trace(ctx, "beads.update", telemetry.Attrs{"beads.task_id": id})
+	defer end(&err)
+	args := append([]string{"update", id}, updateFlags(updates)...)
 	
+	cmd := c.command(ctx, "bd", args...)
+	
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("bd update interrupted: %w", ctx.Err())
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return c.withHint(fmt.Errorf("bd update failed: %w (stderr: %s)", err, string(exitErr.Stderr)))
+		}
+		return c.withHint(fmt.Errorf("bd update failed: %w", err))
+	}
+	
+	events.Publish(events.Event{Type: events.TaskUpdated, Task: id, Message: strings.Join(args[2:], " ")})
+	c.history.updated(BulkUpdate{ID: id, TaskUpdate: updates})
+	return nil
+}
+
+// updateFlags returns the bd update flags of the non-nil fields of updates
+func updateFlags(updates TaskUpdate) []string {
+	var flags []string
 	if updates.Title != nil {
-		args = append(args, "--title", *updates.Title)
+		flags = append(flags, "--title", *updates.Title)
 	}
 	if updates.Status != nil {
-		args = append(args, "--status", *updates.Status)
+		flags = append(flags, "--status", *updates.Status)
 	}
 	if updates.Phase != nil {
-		args = append(args, "--phase", *updates.Phase)
+		flags = append(flags, "--phase", *updates.Phase)
 	}
-	if updates.Assignee != nil {
-		args = append(args, "--assignee", *updates.Assignee)
+	if updates.Priority != nil {
+		flags = append(flags, "--priority", strconv.Itoa(*updates.Priority))
 	}
-	
-	cmd := exec.Command("bd", args...)
-	if c.dbPath != "" {
-		cmd.Dir = c.dbPath
+	if updates.Labels != nil {
+		flags = append(flags, "--labels", strings.Join(*updates.Labels, ","))
 	}
-	
-	if err := cmd.Run(); err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return fmt.Errorf("bd update failed: %w (stderr: %s)", err, string(exitErr.Stderr))
-		}
-		return fmt.Errorf("bd update failed: %w", err)
+	if updates.Assignee != nil {
+		flags = append(flags, "--assignee", *updates.Assignee)
 	}
-	
-	return nil
+	return flags
 }
 
 // DeleteTask deletes a task with the given ID using the bd CLI.
 // Returns an error if the deletion fails or the task doesn't exist.
-func (c *Client) DeleteTask(id string) error {
-	cmd := exec.Command("bd", "delete", id)
-	if c.dbPath != "" {
-		cmd.Dir = c.dbPath
-	}
+func (c *Client) DeleteTask(ctx context.Context, id string) (err error) {
+	ctx, end := c.trace(ctx, "beads.delete", telemetry.Attrs{"beads.task_id": id})
+	defer end(&err)
+	cmd := c.command(ctx, "bd", "delete", id)
 	
 	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("bd delete interrupted: %w", ctx.Err())
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return fmt.Errorf("bd delete failed: %w (stderr: %s)", err, string(exitErr.Stderr))
+			return c.withHint(fmt.Errorf("bd delete failed: %w (stderr: %s)", err, string(exitErr.Stderr)))
 		}
-		return fmt.Errorf("bd delete failed: %w", err)
+		return c.withHint(fmt.Errorf("bd delete failed: %w", err))
 	}
 	
+	events.Publish(events.Event{Type: events.TaskDeleted, Task: id})
+	c.history.deleted(id)
 	return nil
 }
 
 // Refresh executes git pull on the beads repository to sync with remote changes.
 // Returns an error if the pull fails or encounters merge conflicts.
-func (c *Client) Refresh() error {
+func (c *Client) Refresh(ctx context.Context) (err error) {
 	if c.dbPath == "" {
 		return fmt.Errorf("dbPath not configured")
 	}
+	ctx, end := c.trace(ctx, "beads.refresh")
+	defer end(&err)
 	
-	logger.WithFields(logger.Fields{
+	beadsLog.WithFields(logger.Fields{
 		"db_path": c.dbPath,
 	}).Debug("Executing git pull on beads repository")
 	
-	cmd := exec.Command("git", "pull")
-	cmd.Dir = c.dbPath
+	cmd := c.command(ctx, "git", "pull")
 	
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("git pull interrupted: %w", ctx.Err())
+		}
 		// Check if it's a merge conflict
 		if strings.Contains(string(output), "CONFLICT") {
-			logger.WithFields(logger.Fields{
+			beadsLog.WithFields(logger.Fields{
 				"db_path": c.dbPath,
 				"output":  string(output),
 			}).Error("Git pull failed with merge conflict")
 			return fmt.Errorf("git pull failed with merge conflict: %s", string(output))
 		}
-		logger.WithFields(logger.Fields{
+		beadsLog.WithFields(logger.Fields{
 			"db_path": c.dbPath,
 			"output":  string(output),
 		}).Error("Git pull failed: %v", err)
 		return fmt.Errorf("git pull failed: %w (output: %s)", err, string(output))
 	}
 	
-	logger.WithFields(logger.Fields{
+	beadsLog.WithFields(logger.Fields{
 		"db_path": c.dbPath,
 		"output":  string(output),
 	}).Debug("Git pull completed successfully")
 	
 	return nil
 }
+
+// DataDirName is the directory of the beads repository bd keeps its data in
+const DataDirName = ".beads"
+
+// watchDebounce is how long task changes must settle before they are reported
+const watchDebounce = 200 * time.Millisecond
+
+// WatchFiles reports changes to the tasks, whether made by bd or pulled
+// in by git. It watches the JSONL files in the .beads directory, which bd
+// writes every change to, rather than its database, which bd also writes
+// while only reading tasks. Returns an error if the directory does not
+// exist, in which case callers poll instead.
+func (c *Client) WatchFiles() (*fswatch.Watcher, error) {
+	if c.dbPath == "" {
+		return nil, fmt.Errorf("dbPath not configured")
+	}
+	return fswatch.NewWithFilter(watchDebounce, func(path string) bool {
+		return filepath.Ext(path) == ".jsonl"
+	}, filepath.Join(c.dbPath, DataDirName))
+}
+
+// trace starts the span of a bd or git call, returning the function that
+// ends it with the call's result
+func (c *Client) trace(ctx context.Context, name string, attrs ...telemetry.Attrs) (context.Context, func(*error)) {
+	ctx, span := telemetry.StartKind(ctx, name, telemetry.KindClient, telemetry.Attrs{"beads.db_path": c.dbPath})
+	for _, a := range attrs {
+		span.SetAttributes(a)
+	}
+	return ctx, func(err *error) {
+		span.SetError(*err)
+		span.End()
+	}
+}
+
+// withHint attaches how to fix a failed bd command to its error
+func (c *Client) withHint(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return ascerrors.WithHint(err, "Install beads and make sure bd is in your PATH. Run 'asc check' for details")
+	}
+	return ascerrors.WithHint(err, fmt.Sprintf("Check that core.beads_db_path in asc.toml (%s) is a beads repository; run 'bd init' there to create one", c.dbPath))
+}
+
+// command builds an exec.Cmd that runs in the beads repository and carries
+// the current correlation ID in its environment, so bd's own output can be
+// matched with the asc action that triggered it. The subprocess is killed
+// when ctx is cancelled.
+func (c *Client) command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	// Kill the whole process group on cancellation, and bound the wait
+	// just in case
+	killGroupOnCancel(cmd)
+	cmd.WaitDelay = commandWaitDelay
+	if c.dbPath != "" {
+		cmd.Dir = c.dbPath
+	}
+	if id := logger.CorrelationID(); id != "" {
+		cmd.Env = append(os.Environ(), logger.CorrelationIDEnvVar+"="+id)
+	}
+	return cmd
+}
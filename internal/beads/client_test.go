@@ -1,7 +1,12 @@
 package beads
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -190,7 +195,7 @@ func TestGetTasks_EmptyStatuses(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
 	// This will fail because bd is not installed, but we're testing the code path
-	_, err := client.GetTasks([]string{})
+	_, err := client.GetTasks(context.Background(), []string{})
 	
 	// We expect an error since bd is likely not installed in test environment
 	if err == nil {
@@ -207,7 +212,7 @@ func TestGetTasks_WithStatuses(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
 	statuses := []string{"open", "in_progress"}
-	_, err := client.GetTasks(statuses)
+	_, err := client.GetTasks(context.Background(), statuses)
 	
 	// We expect an error since bd is likely not installed in test environment
 	if err == nil {
@@ -223,7 +228,7 @@ func TestGetTasks_WithStatuses(t *testing.T) {
 func TestGetTasks_SingleStatus(t *testing.T) {
 	client := NewClient("/test/path", 5*time.Second)
 	
-	_, err := client.GetTasks([]string{"open"})
+	_, err := client.GetTasks(context.Background(), []string{"open"})
 	
 	// We expect an error since bd is likely not installed
 	if err != nil {
@@ -248,7 +253,7 @@ func TestGetTasks_MultipleStatuses(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			client := NewClient("", 5*time.Second)
-			_, err := client.GetTasks(tt.statuses)
+			_, err := client.GetTasks(context.Background(), tt.statuses)
 			
 			// Error expected since bd not installed
 			if err != nil && err.Error() == "" {
@@ -261,7 +266,7 @@ func TestGetTasks_MultipleStatuses(t *testing.T) {
 func TestCreateTask_ErrorHandling(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
-	_, err := client.CreateTask("Test task")
+	_, err := client.CreateTask(context.Background(), "Test task")
 	
 	// We expect an error since bd is likely not installed
 	if err == nil {
@@ -280,7 +285,7 @@ func TestCreateTask_ErrorHandling(t *testing.T) {
 func TestCreateTask_EmptyTitle(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
-	_, err := client.CreateTask("")
+	_, err := client.CreateTask(context.Background(), "")
 	
 	// Error expected
 	if err != nil && err.Error() == "" {
@@ -292,7 +297,7 @@ func TestCreateTask_LongTitle(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
 	longTitle := "This is a very long task title that contains many words and characters to test how the system handles long input strings"
-	_, err := client.CreateTask(longTitle)
+	_, err := client.CreateTask(context.Background(), longTitle)
 	
 	// Error expected since bd not installed
 	if err != nil && err.Error() == "" {
@@ -315,7 +320,7 @@ func TestUpdateTask_AllFields(t *testing.T) {
 		Assignee: &assignee,
 	}
 	
-	err := client.UpdateTask("task-123", update)
+	err := client.UpdateTask(context.Background(), "task-123", update)
 	
 	// Error expected since bd not installed
 	if err != nil && err.Error() == "" {
@@ -357,7 +362,7 @@ func TestUpdateTask_SingleField(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			client := NewClient("", 5*time.Second)
-			err := client.UpdateTask("task-123", tt.update)
+			err := client.UpdateTask(context.Background(), "task-123", tt.update)
 			
 			// Error expected since bd not installed
 			if err != nil && err.Error() == "" {
@@ -371,7 +376,7 @@ func TestUpdateTask_EmptyUpdate(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
 	update := TaskUpdate{}
-	err := client.UpdateTask("task-123", update)
+	err := client.UpdateTask(context.Background(), "task-123", update)
 	
 	// Error expected since bd not installed
 	if err != nil && err.Error() == "" {
@@ -382,7 +387,7 @@ func TestUpdateTask_EmptyUpdate(t *testing.T) {
 func TestDeleteTask_ErrorHandling(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
-	err := client.DeleteTask("task-123")
+	err := client.DeleteTask(context.Background(), "task-123")
 	
 	// Error expected since bd not installed
 	if err != nil && err.Error() == "" {
@@ -393,7 +398,7 @@ func TestDeleteTask_ErrorHandling(t *testing.T) {
 func TestDeleteTask_EmptyID(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
-	err := client.DeleteTask("")
+	err := client.DeleteTask(context.Background(), "")
 	
 	// Error expected
 	if err != nil && err.Error() == "" {
@@ -404,7 +409,7 @@ func TestDeleteTask_EmptyID(t *testing.T) {
 func TestDeleteTask_InvalidID(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
-	err := client.DeleteTask("nonexistent-task")
+	err := client.DeleteTask(context.Background(), "nonexistent-task")
 	
 	// Error expected since bd not installed or task doesn't exist
 	if err != nil && err.Error() == "" {
@@ -415,7 +420,7 @@ func TestDeleteTask_InvalidID(t *testing.T) {
 func TestRefresh_EmptyPath(t *testing.T) {
 	client := NewClient("", 5*time.Second)
 	
-	err := client.Refresh()
+	err := client.Refresh(context.Background())
 	
 	// Should return error about missing dbPath
 	if err == nil {
@@ -429,7 +434,7 @@ func TestRefresh_EmptyPath(t *testing.T) {
 func TestRefresh_InvalidPath(t *testing.T) {
 	client := NewClient("/nonexistent/path", 5*time.Second)
 	
-	err := client.Refresh()
+	err := client.Refresh(context.Background())
 	
 	// Should return error about git pull failure
 	if err == nil {
@@ -441,7 +446,7 @@ func TestRefresh_ValidPath(t *testing.T) {
 	// Use current directory which should have git
 	client := NewClient(".", 5*time.Second)
 	
-	err := client.Refresh()
+	err := client.Refresh(context.Background())
 	
 	// May succeed or fail depending on git state, but should not panic
 	if err != nil {
@@ -602,3 +607,66 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestGetTasks_Cancelled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	// A bd that hangs, like one stuck on a lock
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nsleep 30\n"
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	client := NewClient(t.TempDir(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetTasks(ctx, []string{"open"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetTasks took %v after its context expired", elapsed)
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	repo := t.TempDir()
+	dataDir := filepath.Join(repo, DataDirName)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := NewClient(repo, time.Second).WatchFiles()
+	if err != nil {
+		t.Fatalf("WatchFiles() error = %v", err)
+	}
+	defer watcher.Close()
+
+	// bd writes its database while only listing tasks
+	if err := os.WriteFile(filepath.Join(dataDir, "beads.db"), []byte("db"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-watcher.Changes():
+		t.Fatal("Expected database writes to be ignored")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(filepath.Join(dataDir, "issues.jsonl"), []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-watcher.Changes():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a change to issues.jsonl to be reported")
+	}
+
+	if _, err := NewClient(t.TempDir(), time.Second).WatchFiles(); err == nil {
+		t.Error("Expected an error for a repository without a .beads directory")
+	}
+}
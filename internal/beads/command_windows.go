@@ -0,0 +1,10 @@
+//go:build windows
+
+package beads
+
+import "os/exec"
+
+// killGroupOnCancel keeps exec's default of killing the process on
+// cancellation; Windows has no process group to signal, and WaitDelay
+// bounds the wait for helpers still holding the output open
+func killGroupOnCancel(cmd *exec.Cmd) {}
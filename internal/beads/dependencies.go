@@ -0,0 +1,133 @@
+package beads
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/telemetry"
+)
+
+// StatusClosed is the status of done tasks, which block no others
+const StatusClosed = "closed"
+
+// StatusBlocked is the status bd gives a task marked as blocked, whether
+// or not a dependency blocks it
+const StatusBlocked = "blocked"
+
+// DependencyClient is implemented by the beads clients that record which
+// tasks a task depends on. The clients of this package implement it.
+type DependencyClient interface {
+	// AddDependency records that the task id waits for blockerID
+	AddDependency(ctx context.Context, id, blockerID string) error
+	// RemoveDependency removes the dependency of id on blockerID
+	RemoveDependency(ctx context.Context, id, blockerID string) error
+	// GetBlockers returns the tasks that block id, in the order of its
+	// dependencies
+	GetBlockers(ctx context.Context, id string) ([]Task, error)
+}
+
+// Blockers returns the IDs of the dependencies of task that are among
+// tasks and not closed. Dependencies missing from tasks, such as closed
+// tasks when listing open ones, block nothing.
+func Blockers(task Task, tasks []Task) []string {
+	if len(task.Dependencies) == 0 {
+		return nil
+	}
+	statuses := make(map[string]string, len(tasks))
+	for _, t := range tasks {
+		statuses[t.ID] = t.Status
+	}
+	var blockers []string
+	for _, id := range task.Dependencies {
+		if status, ok := statuses[id]; ok && status != StatusClosed {
+			blockers = append(blockers, id)
+		}
+	}
+	return blockers
+}
+
+// BlockedTasks returns the blockers of every task among tasks that is
+// blocked by another, by task ID
+func BlockedTasks(tasks []Task) map[string][]string {
+	blocked := make(map[string][]string)
+	for _, task := range tasks {
+		if blockers := Blockers(task, tasks); len(blockers) > 0 {
+			blocked[task.ID] = blockers
+		}
+	}
+	return blocked
+}
+
+// IsBlocked reports whether task is marked as blocked or waits for a task
+// among tasks
+func IsBlocked(task Task, tasks []Task) bool {
+	return task.Status == StatusBlocked || len(Blockers(task, tasks)) > 0
+}
+
+// getBlockers returns the tasks blocking id, listed by client
+func getBlockers(ctx context.Context, client BeadsClient, id string) ([]Task, error) {
+	tasks, err := client.GetTasks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	task, ok := byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	var blockers []Task
+	for _, blockerID := range Blockers(task, tasks) {
+		blockers = append(blockers, byID[blockerID])
+	}
+	return blockers, nil
+}
+
+// AddDependency records that the task id waits for blockerID using the bd
+// CLI (bd dep add)
+func (c *Client) AddDependency(ctx context.Context, id, blockerID string) (err error) {
+	ctx, end := c.trace(ctx, "beads.dep_add", telemetry.Attrs{"beads.task_id": id, "beads.blocker_id": blockerID})
+	defer end(&err)
+	if err := c.runDep(ctx, "add", id, blockerID); err != nil {
+		return err
+	}
+	events.Publish(events.Event{Type: events.TaskUpdated, Task: id, Message: "blocked by " + blockerID})
+	return nil
+}
+
+// RemoveDependency removes the dependency of id on blockerID using the bd
+// CLI (bd dep remove)
+func (c *Client) RemoveDependency(ctx context.Context, id, blockerID string) (err error) {
+	ctx, end := c.trace(ctx, "beads.dep_remove", telemetry.Attrs{"beads.task_id": id, "beads.blocker_id": blockerID})
+	defer end(&err)
+	if err := c.runDep(ctx, "remove", id, blockerID); err != nil {
+		return err
+	}
+	events.Publish(events.Event{Type: events.TaskUpdated, Task: id, Message: "no longer blocked by " + blockerID})
+	return nil
+}
+
+// GetBlockers returns the tasks that block id, listed with the bd CLI.
+// Returns ErrTaskNotFound if there is no such task.
+func (c *Client) GetBlockers(ctx context.Context, id string) ([]Task, error) {
+	return getBlockers(ctx, c, id)
+}
+
+// runDep runs bd dep with a subcommand
+func (c *Client) runDep(ctx context.Context, subcommand, id, blockerID string) error {
+	cmd := c.command(ctx, "bd", "dep", subcommand, id, blockerID)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("bd dep %s interrupted: %w", subcommand, ctx.Err())
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return c.withHint(fmt.Errorf("bd dep %s failed: %w (stderr: %s)", subcommand, err, string(exitErr.Stderr)))
+		}
+		return c.withHint(fmt.Errorf("bd dep %s failed: %w", subcommand, err))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package beads
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestBlockers(t *testing.T) {
+	tasks := []Task{
+		{ID: "bd-1", Status: "open"},
+		{ID: "bd-2", Status: "closed"},
+		{ID: "bd-3", Status: "in_progress", Dependencies: []string{"bd-1", "bd-2", "bd-9"}},
+		{ID: "bd-4", Status: "open", Dependencies: []string{"bd-2"}},
+		{ID: "bd-5", Status: "blocked"},
+	}
+
+	if got := Blockers(tasks[2], tasks); !reflect.DeepEqual(got, []string{"bd-1"}) {
+		t.Errorf("Blockers() = %v, want only the open dependency", got)
+	}
+	if got := Blockers(tasks[3], tasks); got != nil {
+		t.Errorf("Blockers() = %v, want none for a closed dependency", got)
+	}
+
+	want := map[string][]string{"bd-3": {"bd-1"}}
+	if got := BlockedTasks(tasks); !reflect.DeepEqual(got, want) {
+		t.Errorf("BlockedTasks() = %v, want %v", got, want)
+	}
+
+	for i, blocked := range []bool{false, false, true, false, true} {
+		if got := IsBlocked(tasks[i], tasks); got != blocked {
+			t.Errorf("IsBlocked(%s) = %v, want %v", tasks[i].ID, got, blocked)
+		}
+	}
+}
+
+func TestClientDependencies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	// A bd that records its arguments and lists two tasks, the second
+	// waiting for the first
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	script := `#!/bin/sh
+echo "$@" >> "` + argsFile + `"
+if [ "$2" = "list" ]; then
+	echo '[{"id":"bd-1","title":"Schema","status":"open"},{"id":"bd-2","title":"API","status":"open","dependencies":["bd-1"]}]'
+fi
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	client := NewClient(t.TempDir(), time.Second)
+	ctx := context.Background()
+	if err := client.AddDependency(ctx, "bd-2", "bd-1"); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+	if err := client.RemoveDependency(ctx, "bd-2", "bd-1"); err != nil {
+		t.Fatalf("RemoveDependency() error = %v", err)
+	}
+	blockers, err := client.GetBlockers(ctx, "bd-2")
+	if err != nil {
+		t.Fatalf("GetBlockers() error = %v", err)
+	}
+	if len(blockers) != 1 || blockers[0].ID != "bd-1" || blockers[0].Title != "Schema" {
+		t.Errorf("Expected bd-1 to block bd-2, got %+v", blockers)
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "dep add bd-2 bd-1\ndep remove bd-2 bd-1\n--json list\n"
+	if string(data) != want {
+		t.Errorf("bd ran with\n%s\nwant\n%s", data, want)
+	}
+}
@@ -1,12 +1,17 @@
 package beads
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	ascerrors "github.com/rand/asc/internal/errors"
 )
 
 // TestNewClient_ErrorPaths tests error handling in client creation
@@ -104,7 +109,7 @@ func TestGetTasks_ErrorPaths(t *testing.T) {
 				return
 			}
 
-			tasks, err := client.GetTasks(tt.statuses)
+			tasks, err := client.GetTasks(context.Background(), tt.statuses)
 
 			if tt.expectError {
 				if err == nil {
@@ -175,14 +180,14 @@ func TestCreateTask_ErrorPaths(t *testing.T) {
 				return
 			}
 
-			task, err := client.CreateTask(tt.title)
+			task, err := client.CreateTask(context.Background(), tt.title)
 
 			if tt.expectError {
 				if err == nil {
 					t.Error("Expected error but got nil")
 					// Clean up if task was created
 					if task.ID != "" {
-						client.DeleteTask(task.ID)
+						client.DeleteTask(context.Background(), task.ID)
 					}
 				} else if tt.errorMsg != "" && !contains(err.Error(), tt.errorMsg) {
 					t.Errorf("Expected error to contain %q, got: %v", tt.errorMsg, err)
@@ -245,7 +250,7 @@ func TestUpdateTask_ErrorPaths(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := client.UpdateTask(tt.taskID, tt.updates)
+			err := client.UpdateTask(context.Background(), tt.taskID, tt.updates)
 
 			if tt.expectError {
 				if err == nil {
@@ -303,7 +308,7 @@ func TestDeleteTask_ErrorPaths(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := client.DeleteTask(tt.taskID)
+			err := client.DeleteTask(context.Background(), tt.taskID)
 
 			if tt.expectError {
 				if err == nil {
@@ -365,7 +370,7 @@ func TestRefresh_ErrorPaths(t *testing.T) {
 				return
 			}
 
-			err := client.Refresh()
+			err := client.Refresh(context.Background())
 
 			if tt.expectError {
 				if err == nil {
@@ -396,7 +401,7 @@ func TestCommandExecution_ErrorPaths(t *testing.T) {
 	}
 
 	// Try to get tasks - should fail because bd is not found
-	_, err := client.GetTasks([]string{"open"})
+	_, err := client.GetTasks(context.Background(), []string{"open"})
 	if err == nil {
 		t.Error("Expected error when bd command is not in PATH")
 	}
@@ -419,7 +424,7 @@ func TestConcurrentOperations(t *testing.T) {
 	done := make(chan error, 10)
 	for i := 0; i < 10; i++ {
 		go func(i int) {
-			_, err := client.GetTasks([]string{"open"})
+			_, err := client.GetTasks(context.Background(), []string{"open"})
 			done <- err
 		}(i)
 	}
@@ -440,7 +445,7 @@ func TestErrorWrapping(t *testing.T) {
 	}
 
 	// Try an operation that should fail
-	_, err := client.GetTasks([]string{"open"})
+	_, err := client.GetTasks(context.Background(), []string{"open"})
 	if err == nil {
 		t.Skip("Expected error for nonexistent path")
 		return
@@ -458,6 +463,21 @@ func TestErrorWrapping(t *testing.T) {
 	}
 }
 
+// TestErrorHints tests that failed bd commands say how to fix them
+func TestErrorHints(t *testing.T) {
+	client := NewClient("/path/to/repo", 5*time.Second)
+
+	missing := client.withHint(fmt.Errorf("bd list failed: %w", exec.ErrNotFound))
+	if hint := ascerrors.Hint(missing); !strings.Contains(hint, "PATH") {
+		t.Errorf("Expected a hint to install bd, got %q", hint)
+	}
+
+	failed := client.withHint(errors.New("bd list failed: exit status 1"))
+	if hint := ascerrors.Hint(failed); !strings.Contains(hint, "/path/to/repo") {
+		t.Errorf("Expected a hint naming the beads repository, got %q", hint)
+	}
+}
+
 // TestPanicRecovery tests that panics are handled gracefully
 func TestPanicRecovery(t *testing.T) {
 	defer func() {
@@ -478,12 +498,12 @@ func TestPanicRecovery(t *testing.T) {
 	}
 
 	// Try operations that might panic
-	client.GetTasks(nil)
-	client.GetTasks([]string{})
-	client.CreateTask("")
-	client.UpdateTask("", TaskUpdate{})
-	client.DeleteTask("")
-	client.Refresh()
+	client.GetTasks(context.Background(), nil)
+	client.GetTasks(context.Background(), []string{})
+	client.CreateTask(context.Background(), "")
+	client.UpdateTask(context.Background(), "", TaskUpdate{})
+	client.DeleteTask(context.Background(), "")
+	client.Refresh(context.Background())
 }
 
 // TestInvalidInput tests handling of invalid input
@@ -501,7 +521,7 @@ func TestInvalidInput(t *testing.T) {
 		{
 			name: "get tasks with null bytes in status",
 			fn: func() error {
-				_, err := client.GetTasks([]string{"open\x00"})
+				_, err := client.GetTasks(context.Background(), []string{"open\x00"})
 				return err
 			},
 		},
@@ -509,7 +529,7 @@ func TestInvalidInput(t *testing.T) {
 			name: "create task with extremely long title",
 			fn: func() error {
 				longTitle := string(make([]byte, 100000))
-				_, err := client.CreateTask(longTitle)
+				_, err := client.CreateTask(context.Background(), longTitle)
 				return err
 			},
 		},
@@ -517,13 +537,13 @@ func TestInvalidInput(t *testing.T) {
 			name: "update task with SQL injection attempt",
 			fn: func() error {
 				status := "done"
-				return client.UpdateTask("'; DROP TABLE tasks; --", TaskUpdate{Status: &status})
+				return client.UpdateTask(context.Background(), "'; DROP TABLE tasks; --", TaskUpdate{Status: &status})
 			},
 		},
 		{
 			name: "delete task with command injection attempt",
 			fn: func() error {
-				return client.DeleteTask("test; rm -rf /")
+				return client.DeleteTask(context.Background(), "test; rm -rf /")
 			},
 		},
 	}
@@ -0,0 +1,322 @@
+package beads
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// Kinds of task events
+const (
+	EventCreated  = "created"
+	EventStatus   = "status"
+	EventAssignee = "assignee"
+	EventDeleted  = "deleted"
+)
+
+// Sources of task events
+const (
+	SourceAsc   = "asc"   // Made through asc, by Actor
+	SourceBeads = "beads" // Seen in the database, made with bd; Actor is the task's assignee
+)
+
+// TaskEvent is a recorded change of a task: its creation, a status
+// transition, a reassignment, or its deletion
+type TaskEvent struct {
+	Time   time.Time `json:"time"`
+	Task   string    `json:"task"`
+	Kind   string    `json:"kind"` // One of the kinds above
+	From   string    `json:"from,omitempty"`
+	To     string    `json:"to,omitempty"`
+	Actor  string    `json:"actor,omitempty"` // The agent or user who made the change
+	Source string    `json:"source"`          // One of the sources above
+}
+
+// Change describes the change, e.g. "status open → closed"
+func (e TaskEvent) Change() string {
+	switch e.Kind {
+	case EventCreated:
+		return fmt.Sprintf("created (%s)", e.To)
+	case EventDeleted:
+		return "deleted"
+	}
+	return fmt.Sprintf("%s %s → %s", e.Kind, orNone(e.From), orNone(e.To))
+}
+
+// orNone returns value, or "(none)" if it is empty
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// HistoryClient is implemented by the clients that record the history of
+// tasks: the bd and SQLite clients
+type HistoryClient interface {
+	// SetHistory records the changes the client makes or sees in history
+	SetHistory(history *History)
+	// TaskHistory returns the recorded changes of a task, oldest first
+	TaskHistory(ctx context.Context, id string) ([]TaskEvent, error)
+}
+
+// History is the log of the changes of tasks, kept as JSON Lines with a
+// snapshot of the last known status and assignee of each task. Changes
+// made through asc are recorded as they are made; those made with bd,
+// such as by agents, are recorded when a client next lists the tasks and
+// sees them. It is safe for concurrent use by several processes.
+type History struct {
+	dir string
+	now func() time.Time
+}
+
+// historySnapshot is the last known state of the tasks
+type historySnapshot struct {
+	Seeded bool                     `json:"seeded"` // A full listing was seen; tasks new to it were created since
+	Tasks  map[string]historyFields `json:"tasks"`
+}
+
+// historyFields is the tracked fields of a task
+type historyFields struct {
+	Status   string `json:"status"`
+	Assignee string `json:"assignee,omitempty"`
+}
+
+// NewHistory returns the history kept in dir
+func NewHistory(dir string) *History {
+	return &History{dir: dir, now: time.Now}
+}
+
+// DefaultHistory returns the history kept in ~/.asc/history
+func DefaultHistory() (*History, error) {
+	dir, err := statedir.Path("history")
+	if err != nil {
+		return nil, err
+	}
+	return NewHistory(dir), nil
+}
+
+// Events returns the recorded changes of the task id, or of all tasks if
+// id is empty, oldest first. Lines that cannot be decoded are skipped.
+func (h *History) Events(id string) ([]TaskEvent, error) {
+	file, err := os.Open(filepath.Join(h.dir, "tasks.jsonl"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []TaskEvent{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open task history: %w", err)
+	}
+	defer file.Close()
+
+	events := []TaskEvent{}
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, fmt.Errorf("failed to read task history: %w", err)
+		}
+		var event TaskEvent
+		if json.Unmarshal(line, &event) != nil || (id != "" && event.Task != id) {
+			continue
+		}
+		events = append(events, event)
+	}
+}
+
+// created records a task created through asc
+func (h *History) created(task Task) {
+	h.change(func(snapshot *historySnapshot, now time.Time) []TaskEvent {
+		snapshot.Tasks[task.ID] = historyFields{Status: task.Status, Assignee: task.Assignee}
+		return []TaskEvent{{Time: now, Task: task.ID, Kind: EventCreated, To: task.Status, Actor: Actor(), Source: SourceAsc}}
+	})
+}
+
+// updated records the status and assignee updates of tasks made through
+// asc
+func (h *History) updated(updates ...BulkUpdate) {
+	h.change(func(snapshot *historySnapshot, now time.Time) []TaskEvent {
+		var events []TaskEvent
+		actor := Actor()
+		for _, update := range updates {
+			last := snapshot.Tasks[update.ID]
+			target := last
+			if update.Status != nil {
+				target.Status = *update.Status
+			}
+			if update.Assignee != nil {
+				target.Assignee = *update.Assignee
+			}
+			events = append(events, transitions(update.ID, last, target, now, actor, SourceAsc)...)
+			snapshot.Tasks[update.ID] = target
+		}
+		return events
+	})
+}
+
+// deleted records a task deleted through asc
+func (h *History) deleted(id string) {
+	h.change(func(snapshot *historySnapshot, now time.Time) []TaskEvent {
+		last := snapshot.Tasks[id]
+		delete(snapshot.Tasks, id)
+		return []TaskEvent{{Time: now, Task: id, Kind: EventDeleted, From: last.Status, Actor: Actor(), Source: SourceAsc}}
+	})
+}
+
+// observe records the changes seen in a listing of tasks since the last
+// one. A complete listing, of the tasks of every status, also shows which
+// tasks were created and deleted; the first only records what it sees.
+func (h *History) observe(tasks []Task, complete bool) {
+	h.change(func(snapshot *historySnapshot, now time.Time) []TaskEvent {
+		var events []TaskEvent
+		seen := make(map[string]bool, len(tasks))
+		for _, task := range tasks {
+			seen[task.ID] = true
+			target := historyFields{Status: task.Status, Assignee: task.Assignee}
+			last, known := snapshot.Tasks[task.ID]
+			switch {
+			case known:
+				if last.Status == "" {
+					// Updated through asc before its status was seen
+					last.Status = target.Status
+				}
+				events = append(events, transitions(task.ID, last, target, now, task.Assignee, SourceBeads)...)
+			case snapshot.Seeded:
+				events = append(events, TaskEvent{Time: now, Task: task.ID, Kind: EventCreated, To: task.Status, Actor: task.Assignee, Source: SourceBeads})
+			}
+			snapshot.Tasks[task.ID] = target
+		}
+		if complete {
+			ids := make([]string, 0, len(snapshot.Tasks))
+			for id := range snapshot.Tasks {
+				if !seen[id] {
+					ids = append(ids, id)
+				}
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				events = append(events, TaskEvent{Time: now, Task: id, Kind: EventDeleted, From: snapshot.Tasks[id].Status, Source: SourceBeads})
+				delete(snapshot.Tasks, id)
+			}
+			snapshot.Seeded = true
+		}
+		return events
+	})
+}
+
+// transitions returns the events of a task going from last to target
+func transitions(id string, last, target historyFields, now time.Time, actor, source string) []TaskEvent {
+	var events []TaskEvent
+	if target.Status != last.Status {
+		events = append(events, TaskEvent{Time: now, Task: id, Kind: EventStatus, From: last.Status, To: target.Status, Actor: actor, Source: source})
+	}
+	if target.Assignee != last.Assignee {
+		events = append(events, TaskEvent{Time: now, Task: id, Kind: EventAssignee, From: last.Assignee, To: target.Assignee, Actor: actor, Source: source})
+	}
+	return events
+}
+
+// change applies apply to the snapshot under the history's lock, then
+// appends the events it returns and saves the snapshot. Failures are
+// logged rather than returned: the history must not fail the change of a
+// task.
+func (h *History) change(apply func(snapshot *historySnapshot, now time.Time) []TaskEvent) {
+	if h == nil {
+		return
+	}
+	if err := h.apply(apply); err != nil {
+		beadsLog.WithFields(logger.Fields{"dir": h.dir}).Warn("Failed to record task history: %v", err)
+	}
+}
+
+func (h *History) apply(apply func(snapshot *historySnapshot, now time.Time) []TaskEvent) error {
+	unlock, err := statefile.Lock(filepath.Join(h.dir, "tasks.lock"))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	snapshotPath := filepath.Join(h.dir, "tasks.json")
+	var snapshot historySnapshot
+	if err := statefile.ReadJSON(snapshotPath, &snapshot); err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, statefile.ErrCorrupt) {
+		return err
+	}
+	if snapshot.Tasks == nil {
+		snapshot.Tasks = make(map[string]historyFields)
+	}
+	before, _ := json.Marshal(snapshot)
+	events := apply(&snapshot, h.now().UTC())
+
+	if len(events) > 0 {
+		var lines []byte
+		for _, event := range events {
+			line, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			lines = append(append(lines, line...), '\n')
+		}
+		file, err := os.OpenFile(filepath.Join(h.dir, "tasks.jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(lines)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if after, _ := json.Marshal(snapshot); string(after) == string(before) {
+		return nil
+	}
+	return statefile.WriteJSON(snapshotPath, snapshot, 0600)
+}
+
+// Actor returns who changes tasks through this process: the agent of
+// $AGENT_NAME, which asc sets for the agents it starts, or else the user
+func Actor() string {
+	if name := os.Getenv("AGENT_NAME"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	if name := os.Getenv("USERNAME"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// SetHistory records the changes of tasks the client makes or sees in
+// history
+func (c *Client) SetHistory(history *History) {
+	c.history = history
+}
+
+// TaskHistory returns the recorded changes of the task id, oldest first
+func (c *Client) TaskHistory(ctx context.Context, id string) ([]TaskEvent, error) {
+	if c.history == nil {
+		return nil, errors.New("task history is not recorded by this client")
+	}
+	return c.history.Events(id)
+}
@@ -0,0 +1,85 @@
+package beads
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	t.Setenv("AGENT_NAME", "coder")
+	history := NewHistory(t.TempDir())
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	history.now = func() time.Time { return now }
+
+	// The first listing only records the tasks it sees
+	history.observe([]Task{{ID: "asc-1", Status: "open"}, {ID: "asc-2", Status: "in_progress", Assignee: "tester"}}, true)
+	history.created(Task{ID: "asc-3", Status: "open"})
+	status, assignee := "in_progress", "coder"
+	history.updated(BulkUpdate{ID: "asc-1", TaskUpdate: TaskUpdate{Status: &status, Assignee: &assignee}})
+	history.observe([]Task{
+		{ID: "asc-1", Status: "in_progress", Assignee: "coder"},
+		{ID: "asc-2", Status: "closed", Assignee: "tester"},
+		{ID: "asc-3", Status: "open"},
+		{ID: "asc-4", Status: "open"},
+	}, true)
+	// A listing of some statuses does not show deleted tasks
+	history.observe([]Task{{ID: "asc-4", Status: "open"}}, false)
+	history.observe([]Task{{ID: "asc-1", Status: "in_progress", Assignee: "coder"}, {ID: "asc-2", Status: "closed", Assignee: "tester"}, {ID: "asc-4", Status: "open"}}, true)
+	history.deleted("asc-4")
+
+	events, err := history.Events("")
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	want := []TaskEvent{
+		{Time: now, Task: "asc-3", Kind: EventCreated, To: "open", Actor: "coder", Source: SourceAsc},
+		{Time: now, Task: "asc-1", Kind: EventStatus, From: "open", To: "in_progress", Actor: "coder", Source: SourceAsc},
+		{Time: now, Task: "asc-1", Kind: EventAssignee, To: "coder", Actor: "coder", Source: SourceAsc},
+		{Time: now, Task: "asc-2", Kind: EventStatus, From: "in_progress", To: "closed", Actor: "tester", Source: SourceBeads},
+		{Time: now, Task: "asc-4", Kind: EventCreated, To: "open", Source: SourceBeads},
+		{Time: now, Task: "asc-3", Kind: EventDeleted, From: "open", Source: SourceBeads},
+		{Time: now, Task: "asc-4", Kind: EventDeleted, From: "open", Actor: "coder", Source: SourceAsc},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Events() =\n%+v\nwant\n%+v", events, want)
+	}
+	if events, _ := history.Events("asc-1"); len(events) != 2 {
+		t.Errorf("Expected the 2 events of asc-1, got %+v", events)
+	}
+}
+
+func TestSQLiteClient_History(t *testing.T) {
+	t.Setenv("AGENT_NAME", "")
+	client, err := OpenSQLite(newFakeDatabase(t, seededDatabase()), time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+	if _, err := client.TaskHistory(ctx, "asc-1"); err == nil || !strings.Contains(err.Error(), "not recorded") {
+		t.Errorf("Expected an error without a history, got %v", err)
+	}
+
+	client.SetHistory(NewHistory(t.TempDir()))
+	if _, err := client.GetTasks(ctx, nil); err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+	closed := StatusClosed
+	if err := client.UpdateTask(ctx, "asc-2", TaskUpdate{Status: &closed}); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	if _, err := client.GetTasks(ctx, nil); err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+
+	events, err := client.TaskHistory(ctx, "asc-2")
+	if err != nil {
+		t.Fatalf("TaskHistory() error = %v", err)
+	}
+	if len(events) != 1 || events[0].From != "in_progress" || events[0].To != StatusClosed || events[0].Actor != Actor() || events[0].Source != SourceAsc {
+		t.Errorf("Expected asc-2 to be closed through asc, got %+v", events)
+	}
+}
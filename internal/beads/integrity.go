@@ -0,0 +1,198 @@
+package beads
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rand/asc/internal/process"
+)
+
+// sqliteShell is the command CheckIntegrity queries the database with when
+// no SQLite driver is linked into asc
+const sqliteShell = "sqlite3"
+
+// ErrNoSQLite is returned by CheckIntegrity when no SQLite driver is linked
+// into asc and sqlite3 is not installed
+var ErrNoSQLite = errors.New("no SQLite driver is linked into asc and sqlite3 is not installed")
+
+// maxIntegrityProblems bounds the problems PRAGMA integrity_check reports
+const maxIntegrityProblems = 10
+
+// daemonLockFiles are the files of the .beads directory bd's daemon holds
+// while it runs, each recording its PID
+var daemonLockFiles = []string{"daemon.lock", "daemon.pid"}
+
+// Integrity is what CheckIntegrity found in the beads database
+type Integrity struct {
+	Path      string   // Path of the database file
+	Problems  []string // What PRAGMA integrity_check reported; none when the database is intact
+	BDVersion string   // Version of bd recorded in bd's metadata table; "" if none is
+}
+
+// CheckIntegrity runs PRAGMA integrity_check on the beads database of the
+// repository at dbPath and, if the database is intact, reads the version
+// of bd that it records. It queries with the SQLite driver linked into
+// asc, or else with sqlite3, and returns ErrNoSQLite without either.
+func CheckIntegrity(ctx context.Context, dbPath string) (*Integrity, error) {
+	path, err := FindDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	query, closeDB, err := openQuerier(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	result := &Integrity{Path: path}
+	rows, err := query(ctx, fmt.Sprintf("PRAGMA integrity_check(%d)", maxIntegrityProblems))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check %s: %w", path, err)
+	}
+	if len(rows) != 1 || rows[0] != "ok" {
+		result.Problems = rows
+		return result, nil
+	}
+
+	tables, err := query(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'metadata'")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(tables) > 0 {
+		versions, err := query(ctx, "SELECT value FROM metadata WHERE key = 'bd_version'")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the bd version of %s: %w", path, err)
+		}
+		if len(versions) > 0 {
+			result.BDVersion = versions[0]
+		}
+	}
+	return result, nil
+}
+
+// querier runs a query and returns the first column of its rows
+type querier func(ctx context.Context, query string) ([]string, error)
+
+// openQuerier opens the database at path with the linked SQLite driver, or
+// with sqlite3 if there is none, read-only
+func openQuerier(path string) (querier, func() error, error) {
+	if driver := sqliteDriver(); driver != "" {
+		db, err := sql.Open(driver, path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		db.SetMaxOpenConns(1)
+		if _, err := db.Exec(fmt.Sprintf("PRAGMA busy_timeout = %d", sqliteBusyTimeout.Milliseconds())); err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		return func(ctx context.Context, query string) ([]string, error) {
+			rows, err := db.QueryContext(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			defer rows.Close()
+			var values []string
+			for rows.Next() {
+				var value sql.NullString
+				if err := rows.Scan(&value); err != nil {
+					return nil, err
+				}
+				values = append(values, value.String)
+			}
+			return values, rows.Err()
+		}, db.Close, nil
+	}
+
+	if _, err := exec.LookPath(sqliteShell); err != nil {
+		return nil, nil, ErrNoSQLite
+	}
+	return func(ctx context.Context, query string) ([]string, error) {
+		cmd := exec.CommandContext(ctx, sqliteShell, "-readonly", "-batch", "-noheader",
+			"-cmd", fmt.Sprintf(".timeout %d", sqliteBusyTimeout.Milliseconds()), path, query+";")
+		killGroupOnCancel(cmd)
+		out, err := cmd.Output()
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		if err != nil {
+			return nil, err
+		}
+		var values []string
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if line != "" {
+				values = append(values, line)
+			}
+		}
+		return values, nil
+	}, func() error { return nil }, nil
+}
+
+// StaleLock is a lock file of bd's daemon left behind by a daemon that is
+// no longer running
+type StaleLock struct {
+	Path string
+	PID  int // PID of the daemon that held it
+}
+
+// FindStaleLocks returns the lock files of bd's daemon in the .beads
+// directory of the repository at dbPath whose daemon is no longer running.
+// A lock file without a PID is left alone, as are SQLite's journals, which
+// SQLite recovers itself when the database is next opened.
+func FindStaleLocks(dbPath string) []StaleLock {
+	var stale []StaleLock
+	for _, name := range daemonLockFiles {
+		path := filepath.Join(dbPath, DataDirName, name)
+		if pid, ok := lockPID(path); ok && !process.Alive(pid) {
+			stale = append(stale, StaleLock{Path: path, PID: pid})
+		}
+	}
+	return stale
+}
+
+// ClearStaleLock removes the lock file at path if the daemon that held it
+// is still not running, so that a daemon started since it was found keeps
+// its lock. A lock file already removed is not an error.
+func ClearStaleLock(path string) error {
+	pid, ok := lockPID(path)
+	if !ok {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("%s records no PID", path)
+	}
+	if process.Alive(pid) {
+		return fmt.Errorf("%s is held by running process %d", path, pid)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// lockPID reads the PID a lock file of bd's daemon records, either as a
+// number or as the pid field of a JSON object
+func lockPID(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	var lock struct {
+		PID int `json:"pid"`
+	}
+	if json.Unmarshal(data, &lock) == nil && lock.PID > 0 {
+		return lock.PID, true
+	}
+	if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && pid > 0 {
+		return pid, true
+	}
+	return 0, false
+}
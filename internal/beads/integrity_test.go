@@ -0,0 +1,84 @@
+package beads
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	tests := []struct {
+		name         string
+		db           *fakeDatabase
+		wantProblems []string
+		wantVersion  string
+	}{
+		{"intact", &fakeDatabase{bdVersion: "0.21.5"}, nil, "0.21.5"},
+		{"without metadata", &fakeDatabase{}, nil, ""},
+		{"corrupted", &fakeDatabase{bdVersion: "0.21.5", problems: []string{"*** in database main ***", "Page 4 is never used"}},
+			[]string{"*** in database main ***", "Page 4 is never used"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeDatabase(t, tt.db)
+			result, err := CheckIntegrity(context.Background(), repo)
+			if err != nil {
+				t.Fatalf("CheckIntegrity() error = %v", err)
+			}
+			if result.Path != filepath.Join(repo, DataDirName, "beads.db") {
+				t.Errorf("Path = %s", result.Path)
+			}
+			if !reflect.DeepEqual(result.Problems, tt.wantProblems) || result.BDVersion != tt.wantVersion {
+				t.Errorf("CheckIntegrity() = %+v", result)
+			}
+		})
+	}
+
+	if _, err := CheckIntegrity(context.Background(), t.TempDir()); err == nil {
+		t.Error("Expected an error for a repository without a database")
+	}
+}
+
+func TestStaleLocks(t *testing.T) {
+	repo := t.TempDir()
+	dataDir := filepath.Join(repo, DataDirName)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if locks := FindStaleLocks(repo); len(locks) != 0 {
+		t.Errorf("Expected no stale locks without lock files, got %+v", locks)
+	}
+
+	// A lock of a daemon that exited, one of a running daemon, and one
+	// without a PID
+	lockPath := filepath.Join(dataDir, "daemon.lock")
+	pidPath := filepath.Join(dataDir, "daemon.pid")
+	os.WriteFile(lockPath, []byte(`{"pid":999999,"started_at":"2026-03-01T12:00:00Z"}`), 0644)
+	os.WriteFile(pidPath, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644)
+
+	locks := FindStaleLocks(repo)
+	if len(locks) != 1 || locks[0].Path != lockPath || locks[0].PID != 999999 {
+		t.Fatalf("FindStaleLocks() = %+v", locks)
+	}
+	if err := ClearStaleLock(pidPath); err == nil {
+		t.Error("Expected the lock of a running daemon kept")
+	}
+	if err := ClearStaleLock(lockPath); err != nil {
+		t.Fatalf("ClearStaleLock() error = %v", err)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("Expected the stale lock removed")
+	}
+	if err := ClearStaleLock(lockPath); err != nil {
+		t.Errorf("Expected a lock already removed ignored, got %v", err)
+	}
+
+	os.WriteFile(pidPath, []byte("locked"), 0644)
+	if locks := FindStaleLocks(repo); len(locks) != 0 {
+		t.Errorf("Expected a lock without a PID left alone, got %+v", locks)
+	}
+}
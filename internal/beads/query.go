@@ -0,0 +1,274 @@
+package beads
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QueryClient is implemented by the beads clients that list the tasks
+// matching a query. The clients of this package implement it.
+type QueryClient interface {
+	// GetTasksFiltered returns the tasks matching query (see ParseQuery)
+	GetTasksFiltered(ctx context.Context, query string) ([]Task, error)
+}
+
+// queryFields are the fields of tasks a query can test
+var queryFields = []string{"id", "title", "status", "phase", "assignee", "label", "priority"}
+
+// queryOperators are the operators between a field and its value, longest
+// first so that >= is not read as >
+var queryOperators = []string{">=", "<=", "!=", ":", "=", ">", "<"}
+
+// Query selects tasks. The zero Query selects every task.
+type Query struct {
+	text  string
+	terms []queryTerm
+}
+
+// queryTerm is a condition of a query: a field compared with values, or a
+// word the title or ID contains when field is ""
+type queryTerm struct {
+	field  string
+	op     string
+	values []string // Alternatives, lowercased
+	number int      // The value of priority comparisons
+	negate bool
+}
+
+// ParseQuery parses a query of space-separated terms, all of which a task
+// must match:
+//
+//	status:open           the status is open (status:open,in_progress for either)
+//	label:backend         the task has the label backend
+//	priority>=2           the priority compares so (also >, <, <=, =, and !=)
+//	assignee:""           the task is unassigned
+//	title:"login page"    the title contains the words
+//	-label:frontend       negates a term
+//	crash                 the title or ID contains the word
+//
+// The fields are id, title, status, phase, assignee, label, and priority;
+// values are compared regardless of case. Priorities range from 0, the
+// highest, to 4.
+func ParseQuery(query string) (Query, error) {
+	tokens, err := splitQuery(query)
+	if err != nil {
+		return Query{}, err
+	}
+	q := Query{text: strings.TrimSpace(query)}
+	for _, token := range tokens {
+		term, err := parseTerm(token)
+		if err != nil {
+			return Query{}, err
+		}
+		q.terms = append(q.terms, term)
+	}
+	return q, nil
+}
+
+// splitQuery splits a query at spaces outside double quotes, removing the
+// quotes
+func splitQuery(query string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	inToken, quoted := false, false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inToken = true
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in query '%s'", query)
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
+
+// parseTerm parses a term of a query
+func parseTerm(token string) (queryTerm, error) {
+	term := queryTerm{}
+	if len(token) > 1 && token[0] == '-' {
+		term.negate = true
+		token = token[1:]
+	}
+
+	name := token
+	if i := strings.IndexAny(token, ":=!<>"); i > 0 {
+		name = token[:i]
+	}
+	op := ""
+	for _, candidate := range queryOperators {
+		if strings.HasPrefix(token[len(name):], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		term.values = []string{strings.ToLower(token)}
+		return term, nil
+	}
+
+	term.field = strings.ToLower(name)
+	if term.field == "labels" {
+		term.field = "label"
+	}
+	if !isQueryField(term.field) {
+		return term, fmt.Errorf("unknown field '%s' in query, expected one of %s", name, strings.Join(queryFields, ", "))
+	}
+	value := token[len(name)+len(op):]
+	term.op = op
+	if op == "!=" {
+		term.op, term.negate = "=", !term.negate
+	}
+
+	if term.field == "priority" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return term, fmt.Errorf("priority in query must be a number, got '%s'", value)
+		}
+		term.number = n
+		return term, nil
+	}
+	if term.op != ":" && term.op != "=" {
+		return term, fmt.Errorf("%s in query cannot be compared with %s, only priority can", name, op)
+	}
+	for _, alternative := range strings.Split(value, ",") {
+		term.values = append(term.values, strings.ToLower(alternative))
+	}
+	return term, nil
+}
+
+func isQueryField(field string) bool {
+	for _, f := range queryFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the text the query was parsed from
+func (q Query) String() string {
+	return q.text
+}
+
+// Empty reports whether the query selects every task
+func (q Query) Empty() bool {
+	return len(q.terms) == 0
+}
+
+// Match reports whether task matches every term of the query
+func (q Query) Match(task Task) bool {
+	for _, term := range q.terms {
+		if term.match(task) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t queryTerm) match(task Task) bool {
+	switch t.field {
+	case "":
+		return strings.Contains(strings.ToLower(task.Title), t.values[0]) || strings.Contains(strings.ToLower(task.ID), t.values[0])
+	case "priority":
+		switch t.op {
+		case ">=":
+			return task.Priority >= t.number
+		case "<=":
+			return task.Priority <= t.number
+		case ">":
+			return task.Priority > t.number
+		case "<":
+			return task.Priority < t.number
+		}
+		return task.Priority == t.number
+	case "title":
+		return t.any(func(value string) bool { return strings.Contains(strings.ToLower(task.Title), value) })
+	case "label":
+		return t.any(func(value string) bool {
+			for _, label := range task.Labels {
+				if strings.ToLower(label) == value {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	field := map[string]string{"id": task.ID, "status": task.Status, "phase": task.Phase, "assignee": task.Assignee}[t.field]
+	return t.any(func(value string) bool { return strings.ToLower(field) == value })
+}
+
+// any reports whether one of the values of the term satisfies fn
+func (t queryTerm) any(fn func(value string) bool) bool {
+	for _, value := range t.values {
+		if fn(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// statuses returns the statuses a task must have to match, so they can be
+// listed alone, or nil if the query does not restrict them
+func (q Query) statuses() []string {
+	for _, term := range q.terms {
+		if term.field == "status" && !term.negate {
+			return term.values
+		}
+	}
+	return nil
+}
+
+// FilterTasks returns the tasks matching q, in order
+func FilterTasks(tasks []Task, q Query) []Task {
+	if q.Empty() {
+		return tasks
+	}
+	var matched []Task
+	for _, task := range tasks {
+		if q.Match(task) {
+			matched = append(matched, task)
+		}
+	}
+	return matched
+}
+
+// getTasksFiltered returns the tasks listed by client that match query
+func getTasksFiltered(ctx context.Context, client BeadsClient, query string) ([]Task, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := client.GetTasks(ctx, q.statuses())
+	if err != nil {
+		return nil, err
+	}
+	return FilterTasks(tasks, q), nil
+}
+
+// GetTasksFiltered returns the tasks matching query (see ParseQuery),
+// listed with the bd CLI
+func (c *Client) GetTasksFiltered(ctx context.Context, query string) ([]Task, error) {
+	return getTasksFiltered(ctx, c, query)
+}
+
+// GetTasksFiltered returns the tasks matching query (see ParseQuery),
+// read from the database
+func (c *SQLiteClient) GetTasksFiltered(ctx context.Context, query string) ([]Task, error) {
+	return getTasksFiltered(ctx, c, query)
+}
@@ -0,0 +1,121 @@
+package beads
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	tasks := []Task{
+		{ID: "bd-1", Title: "Fix login page", Status: "open", Phase: "implementation", Priority: 1, Labels: []string{"backend", "auth"}},
+		{ID: "bd-2", Title: "Write docs", Status: "in_progress", Phase: "documentation", Priority: 3, Labels: []string{"docs"}, Assignee: "writer"},
+		{ID: "bd-3", Title: "Crash on start", Status: "closed", Phase: "testing", Priority: 0, Labels: []string{"Backend"}},
+		{ID: "bd-4", Title: "Frontend polish", Status: "open", Phase: "implementation", Priority: 2, Labels: []string{"frontend"}},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"empty", "", []string{"bd-1", "bd-2", "bd-3", "bd-4"}},
+		{"status", "status:open", []string{"bd-1", "bd-4"}},
+		{"several statuses", "status:open,in_progress", []string{"bd-1", "bd-2", "bd-4"}},
+		{"label ignoring case", "label:backend", []string{"bd-1", "bd-3"}},
+		{"labels alias", "labels:docs", []string{"bd-2"}},
+		{"priority at least", "priority>=2", []string{"bd-2", "bd-4"}},
+		{"priority below", "priority<1", []string{"bd-3"}},
+		{"priority equal", "priority=3", []string{"bd-2"}},
+		{"priority not", "priority!=3", []string{"bd-1", "bd-3", "bd-4"}},
+		{"all terms", "status:open label:backend priority>=1", []string{"bd-1"}},
+		{"negated", "-label:backend", []string{"bd-2", "bd-4"}},
+		{"not equal", "status!=closed", []string{"bd-1", "bd-2", "bd-4"}},
+		{"unassigned", `assignee:""`, []string{"bd-1", "bd-3", "bd-4"}},
+		{"assignee", "assignee:Writer", []string{"bd-2"}},
+		{"phase", "phase:implementation", []string{"bd-1", "bd-4"}},
+		{"quoted title", `title:"login page"`, []string{"bd-1"}},
+		{"word", "crash", []string{"bd-3"}},
+		{"word in ID", "bd-4", []string{"bd-4"}},
+		{"id", "id:bd-2,bd-3", []string{"bd-2", "bd-3"}},
+		{"field case", "Status:OPEN", []string{"bd-1", "bd-4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", tt.query, err)
+			}
+			var got []string
+			for _, task := range FilterTasks(tasks, q) {
+				got = append(got, task.ID)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("ParseQuery(%q) matched %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{"owner:me", "unknown field 'owner'"},
+		{"priority>=high", "priority in query must be a number"},
+		{"status>open", "only priority"},
+		{`title:"login`, "unterminated quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if _, err := ParseQuery(tt.query); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseQuery(%q) error = %v, want one containing %q", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetTasksFiltered(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	// A bd that records its arguments and lists two open tasks
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	script := `#!/bin/sh
+echo "$@" >> "` + argsFile + `"
+echo '[{"id":"bd-1","title":"API","status":"open","priority":1,"labels":["backend"]},{"id":"bd-2","title":"UI","status":"open","priority":3,"labels":["frontend"]}]'
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	client := NewClient(t.TempDir(), time.Second)
+	tasks, err := client.GetTasksFiltered(context.Background(), "status:open label:backend")
+	if err != nil {
+		t.Fatalf("GetTasksFiltered() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "bd-1" || tasks[0].Priority != 1 {
+		t.Errorf("Expected bd-1, got %+v", tasks)
+	}
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "--json list --status open\n" {
+		t.Errorf("Expected the status to be passed to bd, got %q", data)
+	}
+
+	if _, err := client.GetTasksFiltered(context.Background(), "owner:me"); err == nil {
+		t.Error("Expected an error for an invalid query")
+	}
+}
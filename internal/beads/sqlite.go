@@ -0,0 +1,703 @@
+package beads
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/telemetry"
+
+	// SQLite in pure Go, registered as "sqlite", so asc builds without cgo
+	_ "modernc.org/sqlite"
+)
+
+// Modes of choosing the client of the beads repository (core.beads_mode)
+const (
+	ModeAuto   = "auto"   // The SQLite client when the database can be opened, falling back to bd
+	ModeSQLite = "sqlite" // The SQLite client only
+	ModeCLI    = "cli"    // bd only
+)
+
+// sqliteDriverNames are the database/sql names SQLite drivers register
+// under, such as modernc.org/sqlite's and mattn/go-sqlite3's
+var sqliteDriverNames = []string{"sqlite", "sqlite3"}
+
+// ErrNoSQLiteDriver is returned by OpenSQLite when no SQLite driver is
+// linked into asc
+var ErrNoSQLiteDriver = errors.New("no SQLite driver is linked into asc")
+
+// ErrTaskNotFound is returned for a task ID that is not in the beads
+// database, by the SQLite client and by GetBlockers
+var ErrTaskNotFound = errors.New("task not found")
+
+// sqliteBusyTimeout is how long a query waits for bd to release a lock on
+// the database
+const sqliteBusyTimeout = 5 * time.Second
+
+// defaultIDPrefix is the prefix of task IDs in a database without tasks
+const defaultIDPrefix = "bd"
+
+// defaultPriority is the priority bd gives new tasks
+const defaultPriority = 2
+
+// New creates the client of mode for the beads repository at dbPath. In
+// auto mode it is the SQLite client, falling back to bd for each call that
+// fails, when the database can be opened, and the bd client otherwise.
+// Only sqlite mode returns an error, when the database cannot be opened.
+func New(dbPath string, refreshInterval time.Duration, mode string) (BeadsClient, error) {
+	switch mode {
+	case ModeCLI:
+		return NewClient(dbPath, refreshInterval), nil
+	case ModeSQLite:
+		return OpenSQLite(dbPath, refreshInterval)
+	}
+	client, err := OpenSQLite(dbPath, refreshInterval)
+	if err != nil {
+		beadsLog.WithFields(logger.Fields{"db_path": dbPath}).Debug("Using bd, the beads database cannot be opened: %v", err)
+		return NewClient(dbPath, refreshInterval), nil
+	}
+	client.fallback = true
+	return client, nil
+}
+
+// SQLiteClient implements the BeadsClient interface by reading and writing
+// the beads database in the .beads directory directly, rather than running
+// bd for every call. It uses modernc.org/sqlite, linked into asc. It
+// expects bd's issues table, with the id, title, status, phase, priority,
+// assignee, created_at, and updated_at columns, and marks the tasks it
+// changes in bd's dirty_issues table, when there is one, so bd exports
+// them to JSONL. Labels and dependencies, those of type blocks, are read
+// from bd's labels and dependencies tables, when there are. Refresh and
+// Watch work as for the bd client.
+type SQLiteClient struct {
+	*Client // Runs git pull, watches .beads, and runs bd when falling back
+
+	db       *sql.DB
+	path     string // Path of the database file
+	dirty    bool   // The database has bd's dirty_issues table
+	deps     bool   // The database has bd's dependencies table
+	labels   bool   // The database has bd's labels table
+	fallback bool   // Run bd when a query fails
+}
+
+// OpenSQLite opens the beads database of the repository at dbPath. Returns
+// an error if no SQLite driver is linked into asc, or if the database is
+// missing or lacks the expected issues table.
+func OpenSQLite(dbPath string, refreshInterval time.Duration) (*SQLiteClient, error) {
+	driver := sqliteDriver()
+	if driver == "" {
+		return nil, ErrNoSQLiteDriver
+	}
+	path, err := FindDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	// One connection, so the busy timeout set below applies to every query
+	db.SetMaxOpenConns(1)
+	client := &SQLiteClient{Client: NewClient(dbPath, refreshInterval), db: db, path: path}
+	if err := client.init(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s is not a beads database: %w", path, err)
+	}
+	return client, nil
+}
+
+// init checks the schema of the database
+func (c *SQLiteClient) init() error {
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteBusyTimeout)
+	defer cancel()
+	if _, err := c.db.ExecContext(ctx, fmt.Sprintf("PRAGMA busy_timeout = %d", sqliteBusyTimeout.Milliseconds())); err != nil {
+		return err
+	}
+	rows, err := c.db.QueryContext(ctx, "SELECT id, title, status, phase, priority, assignee, created_at, updated_at FROM issues LIMIT 0")
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	c.dirty = c.hasTable(ctx, "dirty_issues")
+	c.deps = c.hasTable(ctx, "dependencies")
+	c.labels = c.hasTable(ctx, "labels")
+	return nil
+}
+
+// hasTable reports whether the database has the table name
+func (c *SQLiteClient) hasTable(ctx context.Context, name string) bool {
+	return c.db.QueryRowContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&name) == nil
+}
+
+// sqliteDriver returns the name of a linked SQLite driver, or "" for none
+func sqliteDriver() string {
+	drivers := sql.Drivers()
+	for _, name := range sqliteDriverNames {
+		for _, driver := range drivers {
+			if driver == name {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// FindDatabase returns the path of the database bd keeps in the .beads
+// directory of the repository at dbPath: beads.db, or else the only .db
+// file there
+func FindDatabase(dbPath string) (string, error) {
+	dataDir := filepath.Join(dbPath, DataDirName)
+	preferred := filepath.Join(dataDir, "beads.db")
+	if _, err := os.Stat(preferred); err == nil {
+		return preferred, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dataDir, "*.db"))
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no beads database in %s", dataDir)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("several databases in %s: %s", dataDir, strings.Join(matches, ", "))
+}
+
+// Close closes the database
+func (c *SQLiteClient) Close() error {
+	return c.db.Close()
+}
+
+// GetTasks retrieves tasks filtered by status from the database, oldest
+// first. If statuses is empty, all tasks are returned.
+func (c *SQLiteClient) GetTasks(ctx context.Context, statuses []string) ([]Task, error) {
+	tasks, err := c.getTasks(ctx, statuses)
+	if err != nil && c.fallBack(ctx, "list", err) {
+		return c.Client.GetTasks(ctx, statuses)
+	}
+	if err == nil {
+		c.history.observe(tasks, len(statuses) == 0)
+	}
+	return tasks, err
+}
+
+func (c *SQLiteClient) getTasks(ctx context.Context, statuses []string) (_ []Task, err error) {
+	ctx, end := c.trace(ctx, "beads.list")
+	defer end(&err)
+
+	query := fmt.Sprintf("SELECT id, title, status, COALESCE(phase, ''), COALESCE(priority, %d), COALESCE(assignee, '') FROM issues", defaultPriority)
+	args := make([]interface{}, len(statuses))
+	if len(statuses) > 0 {
+		for i, status := range statuses {
+			args[i] = status
+		}
+		query += " WHERE status IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ") + ")"
+	}
+	query += " ORDER BY created_at, id"
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", c.path, err)
+	}
+	defer rows.Close()
+	var tasks []Task
+	for rows.Next() {
+		var task Task
+		if err := rows.Scan(&task.ID, &task.Title, &task.Status, &task.Phase, &task.Priority, &task.Assignee); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", c.path, err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", c.path, err)
+	}
+	rows.Close()
+	if err := c.readLabels(ctx, tasks); err != nil {
+		return nil, fmt.Errorf("failed to read labels from %s: %w", c.path, err)
+	}
+	if err := c.readDependencies(ctx, tasks); err != nil {
+		return nil, fmt.Errorf("failed to read dependencies from %s: %w", c.path, err)
+	}
+	return tasks, nil
+}
+
+// readLabels sets the labels of tasks, in alphabetical order
+func (c *SQLiteClient) readLabels(ctx context.Context, tasks []Task) error {
+	if !c.labels || len(tasks) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		index[task.ID] = i
+	}
+	rows, err := c.db.QueryContext(ctx, "SELECT issue_id, label FROM labels ORDER BY label")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, label string
+		if err := rows.Scan(&id, &label); err != nil {
+			return err
+		}
+		if i, ok := index[id]; ok {
+			tasks[i].Labels = append(tasks[i].Labels, label)
+		}
+	}
+	return rows.Err()
+}
+
+// readDependencies sets the dependencies of tasks
+func (c *SQLiteClient) readDependencies(ctx context.Context, tasks []Task) error {
+	if !c.deps || len(tasks) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		index[task.ID] = i
+	}
+	rows, err := c.db.QueryContext(ctx, "SELECT issue_id, depends_on_id FROM dependencies WHERE type = 'blocks' ORDER BY created_at, depends_on_id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, blockerID string
+		if err := rows.Scan(&id, &blockerID); err != nil {
+			return err
+		}
+		if i, ok := index[id]; ok {
+			tasks[i].Dependencies = append(tasks[i].Dependencies, blockerID)
+		}
+	}
+	return rows.Err()
+}
+
+// CreateTask creates an open task with the given title, with the next ID
+// of the database's prefix, such as bd-43
+func (c *SQLiteClient) CreateTask(ctx context.Context, title string) (Task, error) {
+	task, err := c.createTask(ctx, title)
+	if err != nil && c.fallBack(ctx, "create", err) {
+		return c.Client.CreateTask(ctx, title)
+	}
+	if err == nil {
+		events.Publish(events.Event{Type: events.TaskCreated, Task: task.ID, Message: title})
+		c.history.created(task)
+	}
+	return task, err
+}
+
+func (c *SQLiteClient) createTask(ctx context.Context, title string) (_ Task, err error) {
+	ctx, end := c.trace(ctx, "beads.create")
+	defer end(&err)
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Task{}, err
+	}
+	defer tx.Rollback()
+
+	task, err := c.insertTask(ctx, tx, NewTask{Title: title})
+	if err != nil {
+		return Task{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Task{}, fmt.Errorf("failed to create task: %w", err)
+	}
+	return task, nil
+}
+
+// insertTask inserts a task of spec with the next ID in a transaction
+func (c *SQLiteClient) insertTask(ctx context.Context, tx *sql.Tx, spec NewTask) (Task, error) {
+	if len(spec.Labels) > 0 && !c.labels {
+		return Task{}, fmt.Errorf("cannot label task %q: the beads database has no labels table", spec.Title)
+	}
+	id, err := nextID(ctx, tx)
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to allocate a task ID: %w", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	task := Task{ID: id, Title: spec.Title, Status: "open", Phase: spec.Phase, Priority: defaultPriority, Labels: spec.Labels, Assignee: spec.Assignee}
+	if spec.Priority != nil {
+		task.Priority = *spec.Priority
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO issues (id, title, status, phase, priority, assignee, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		task.ID, task.Title, task.Status, task.Phase, task.Priority, task.Assignee, now, now); err != nil {
+		return Task{}, fmt.Errorf("failed to create task: %w", err)
+	}
+	for _, label := range task.Labels {
+		if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO labels (issue_id, label) VALUES (?, ?)", id, label); err != nil {
+			return Task{}, fmt.Errorf("failed to label task %s: %w", id, err)
+		}
+	}
+	if err := c.markDirty(ctx, tx, id); err != nil {
+		return Task{}, err
+	}
+	return task, nil
+}
+
+// nextID returns the ID after the highest of the database, in the prefix
+// of that one
+func nextID(ctx context.Context, tx *sql.Tx) (string, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM issues")
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	prefix, highest := defaultIDPrefix, 0
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", err
+		}
+		i := strings.LastIndex(id, "-")
+		if i < 0 {
+			continue
+		}
+		if n, err := strconv.Atoi(id[i+1:]); err == nil && n > highest {
+			prefix, highest = id[:i], n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%d", prefix, highest+1), nil
+}
+
+// UpdateTask updates the non-nil fields of updates on the task with the
+// given ID. Returns ErrTaskNotFound if there is no such task.
+func (c *SQLiteClient) UpdateTask(ctx context.Context, id string, updates TaskUpdate) error {
+	err := c.updateTask(ctx, id, updates)
+	if err != nil && c.fallBack(ctx, "update", err) {
+		return c.Client.UpdateTask(ctx, id, updates)
+	}
+	if err == nil {
+		events.Publish(events.Event{Type: events.TaskUpdated, Task: id, Message: strings.Join(updateFlags(updates), " ")})
+		c.history.updated(BulkUpdate{ID: id, TaskUpdate: updates})
+	}
+	return err
+}
+
+func (c *SQLiteClient) updateTask(ctx context.Context, id string, updates TaskUpdate) (err error) {
+	ctx, end := c.trace(ctx, "beads.update", telemetry.Attrs{"beads.task_id": id})
+	defer end(&err)
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := c.updateRow(ctx, tx, id, updates); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// updateRow updates the non-nil fields of updates on a task in a
+// transaction
+func (c *SQLiteClient) updateRow(ctx context.Context, tx *sql.Tx, id string, updates TaskUpdate) error {
+	fields := map[string]interface{}{}
+	for column, value := range map[string]*string{"title": updates.Title, "status": updates.Status, "phase": updates.Phase, "assignee": updates.Assignee} {
+		if value != nil {
+			fields[column] = *value
+		}
+	}
+	if updates.Priority != nil {
+		fields["priority"] = *updates.Priority
+	}
+	columns := make([]string, 0, len(fields))
+	for column := range fields {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	var set []string
+	var args []interface{}
+	for _, column := range columns {
+		set = append(set, column+" = ?")
+		args = append(args, fields[column])
+	}
+	set = append(set, "updated_at = ?")
+	args = append(args, time.Now().UTC().Format(time.RFC3339Nano), id)
+
+	result, err := tx.ExecContext(ctx, "UPDATE issues SET "+strings.Join(set, ", ")+" WHERE id = ?", args...)
+	if err != nil {
+		return fmt.Errorf("failed to update task %s: %w", id, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	if updates.Labels != nil {
+		if !c.labels {
+			return fmt.Errorf("cannot label task %s: the beads database has no labels table", id)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM labels WHERE issue_id = ?", id); err != nil {
+			return fmt.Errorf("failed to label task %s: %w", id, err)
+		}
+		for _, label := range *updates.Labels {
+			if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO labels (issue_id, label) VALUES (?, ?)", id, label); err != nil {
+				return fmt.Errorf("failed to label task %s: %w", id, err)
+			}
+		}
+	}
+	return c.markDirty(ctx, tx, id)
+}
+
+// DeleteTask deletes the task with the given ID. Returns ErrTaskNotFound
+// if there is no such task.
+func (c *SQLiteClient) DeleteTask(ctx context.Context, id string) error {
+	err := c.deleteTask(ctx, id)
+	if err != nil && c.fallBack(ctx, "delete", err) {
+		return c.Client.DeleteTask(ctx, id)
+	}
+	if err == nil {
+		events.Publish(events.Event{Type: events.TaskDeleted, Task: id})
+		c.history.deleted(id)
+	}
+	return err
+}
+
+func (c *SQLiteClient) deleteTask(ctx context.Context, id string) (err error) {
+	ctx, end := c.trace(ctx, "beads.delete", telemetry.Attrs{"beads.task_id": id})
+	defer end(&err)
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx, "DELETE FROM issues WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task %s: %w", id, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	if c.deps {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM dependencies WHERE issue_id = ? OR depends_on_id = ?", id, id); err != nil {
+			return fmt.Errorf("failed to delete the dependencies of task %s: %w", id, err)
+		}
+	}
+	if c.labels {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM labels WHERE issue_id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete the labels of task %s: %w", id, err)
+		}
+	}
+	if err := c.markDirty(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddDependency records that the task id waits for blockerID. Returns
+// ErrTaskNotFound if either task does not exist.
+func (c *SQLiteClient) AddDependency(ctx context.Context, id, blockerID string) error {
+	err := c.addDependency(ctx, id, blockerID)
+	if err != nil && c.fallBack(ctx, "dep add", err) {
+		return c.Client.AddDependency(ctx, id, blockerID)
+	}
+	if err == nil {
+		events.Publish(events.Event{Type: events.TaskUpdated, Task: id, Message: "blocked by " + blockerID})
+	}
+	return err
+}
+
+func (c *SQLiteClient) addDependency(ctx context.Context, id, blockerID string) (err error) {
+	ctx, end := c.trace(ctx, "beads.dep_add", telemetry.Attrs{"beads.task_id": id, "beads.blocker_id": blockerID})
+	defer end(&err)
+
+	if id == blockerID {
+		return fmt.Errorf("task %s cannot depend on itself", id)
+	}
+	if !c.deps {
+		return fmt.Errorf("%s has no dependencies table", c.path)
+	}
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, taskID := range []string{id, blockerID} {
+		var found string
+		err := tx.QueryRowContext(ctx, "SELECT id FROM issues WHERE id = ?", taskID).Scan(&found)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query %s: %w", c.path, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO dependencies (issue_id, depends_on_id, type, created_at) VALUES (?, ?, 'blocks', ?)",
+		id, blockerID, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("failed to add dependency of task %s: %w", id, err)
+	}
+	if err := c.markDirty(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveDependency removes the dependency of id on blockerID, if any
+func (c *SQLiteClient) RemoveDependency(ctx context.Context, id, blockerID string) error {
+	err := c.removeDependency(ctx, id, blockerID)
+	if err != nil && c.fallBack(ctx, "dep remove", err) {
+		return c.Client.RemoveDependency(ctx, id, blockerID)
+	}
+	if err == nil {
+		events.Publish(events.Event{Type: events.TaskUpdated, Task: id, Message: "no longer blocked by " + blockerID})
+	}
+	return err
+}
+
+func (c *SQLiteClient) removeDependency(ctx context.Context, id, blockerID string) (err error) {
+	ctx, end := c.trace(ctx, "beads.dep_remove", telemetry.Attrs{"beads.task_id": id, "beads.blocker_id": blockerID})
+	defer end(&err)
+
+	if !c.deps {
+		return fmt.Errorf("%s has no dependencies table", c.path)
+	}
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM dependencies WHERE issue_id = ? AND depends_on_id = ?", id, blockerID); err != nil {
+		return fmt.Errorf("failed to remove dependency of task %s: %w", id, err)
+	}
+	if err := c.markDirty(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetBlockers returns the tasks that block id, read from the database.
+// Returns ErrTaskNotFound if there is no such task.
+func (c *SQLiteClient) GetBlockers(ctx context.Context, id string) ([]Task, error) {
+	return getBlockers(ctx, c, id)
+}
+
+// CreateTasks creates tasks in one transaction: either all of them, or
+// none if one fails
+func (c *SQLiteClient) CreateTasks(ctx context.Context, tasks []NewTask) ([]Task, error) {
+	created, err := c.createTasks(ctx, tasks)
+	if err != nil && c.fallBack(ctx, "bulk create", err) {
+		return c.Client.CreateTasks(ctx, tasks)
+	}
+	for _, task := range created {
+		events.Publish(events.Event{Type: events.TaskCreated, Task: task.ID, Message: task.Title})
+		c.history.created(task)
+	}
+	return created, err
+}
+
+func (c *SQLiteClient) createTasks(ctx context.Context, tasks []NewTask) (_ []Task, err error) {
+	if err := validateNewTasks(tasks); err != nil {
+		return nil, err
+	}
+	ctx, end := c.trace(ctx, "beads.bulk_create", telemetry.Attrs{"beads.task_count": len(tasks)})
+	defer end(&err)
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	created := make([]Task, 0, len(tasks))
+	for _, spec := range tasks {
+		task, err := c.insertTask(ctx, tx, spec)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, task)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create tasks: %w", err)
+	}
+	return created, nil
+}
+
+// UpdateTasks applies updates in one transaction: either all of them, or
+// none if one fails. Returns ErrTaskNotFound if a task does not exist.
+func (c *SQLiteClient) UpdateTasks(ctx context.Context, updates []BulkUpdate) error {
+	err := c.updateTasks(ctx, updates)
+	if err != nil && c.fallBack(ctx, "bulk update", err) {
+		return c.Client.UpdateTasks(ctx, updates)
+	}
+	if err == nil {
+		for _, update := range updates {
+			events.Publish(events.Event{Type: events.TaskUpdated, Task: update.ID, Message: strings.Join(updateFlags(update.TaskUpdate), " ")})
+		}
+		c.history.updated(updates...)
+	}
+	return err
+}
+
+func (c *SQLiteClient) updateTasks(ctx context.Context, updates []BulkUpdate) (err error) {
+	ctx, end := c.trace(ctx, "beads.bulk_update", telemetry.Attrs{"beads.task_count": len(updates)})
+	defer end(&err)
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, update := range updates {
+		if err := c.updateRow(ctx, tx, update.ID, update.TaskUpdate); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CloseTasks closes the tasks with the given IDs in one transaction.
+// Returns ErrTaskNotFound, closing none, if a task does not exist.
+func (c *SQLiteClient) CloseTasks(ctx context.Context, ids []string) error {
+	status := StatusClosed
+	updates := make([]BulkUpdate, len(ids))
+	for i, id := range ids {
+		updates[i] = BulkUpdate{ID: id, TaskUpdate: TaskUpdate{Status: &status}}
+	}
+	err := c.updateTasks(ctx, updates)
+	if err != nil && c.fallBack(ctx, "bulk close", err) {
+		return c.Client.CloseTasks(ctx, ids)
+	}
+	if err == nil {
+		for _, id := range ids {
+			events.Publish(events.Event{Type: events.TaskUpdated, Task: id, Message: "--status " + StatusClosed})
+		}
+		c.history.updated(updates...)
+	}
+	return err
+}
+
+// markDirty records that a task changed in bd's dirty_issues table, so bd
+// exports it to the JSONL files git syncs
+func (c *SQLiteClient) markDirty(ctx context.Context, tx *sql.Tx, id string) error {
+	if !c.dirty {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO dirty_issues (issue_id) VALUES (?)", id); err != nil {
+		return fmt.Errorf("failed to mark task %s for export: %w", id, err)
+	}
+	return nil
+}
+
+// fallBack reports whether a call that failed with err is run with bd
+// instead: in auto mode, unless ctx is done or the task does not exist
+func (c *SQLiteClient) fallBack(ctx context.Context, call string, err error) bool {
+	if !c.fallback || ctx.Err() != nil || errors.Is(err, ErrTaskNotFound) {
+		return false
+	}
+	beadsLog.WithFields(logger.Fields{"db_path": c.dbPath, "call": call}).Warn("Beads database query failed, running bd instead: %v", err)
+	return true
+}
@@ -0,0 +1,862 @@
+package beads
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/events"
+)
+
+// fakeSQLite is a database/sql driver that answers the queries of
+// SQLiteClient from memory, standing in for modernc.org/sqlite so tests
+// can inject failures and inspect what was written. Tests use it unless
+// they call useSQLite.
+type fakeSQLite struct{}
+
+// fakeSQLiteDriver is the name fakeSQLite is registered under
+const fakeSQLiteDriver = "fakesqlite"
+
+// fakeDatabases are the databases of fakeSQLite by path
+var (
+	fakeDatabasesMu sync.Mutex
+	fakeDatabases   = map[string]*fakeDatabase{}
+)
+
+func init() {
+	sql.Register(fakeSQLiteDriver, fakeSQLite{})
+	sqliteDriverNames = []string{fakeSQLiteDriver}
+}
+
+// useSQLite makes the test use modernc.org/sqlite rather than fakeSQLite
+func useSQLite(t *testing.T) {
+	t.Helper()
+	names := sqliteDriverNames
+	sqliteDriverNames = []string{"sqlite"}
+	t.Cleanup(func() { sqliteDriverNames = names })
+}
+
+// fakeDatabase is a beads database: its issues table, in insertion order,
+// its dirty_issues table, nil if it has none, and its dependencies table
+type fakeDatabase struct {
+	mu           sync.Mutex
+	issues       []fakeIssue
+	dirty        map[string]bool
+	dependencies [][2]string // Issue and the issue it depends on, in insertion order
+	hasDeps      bool        // Has the dependencies table
+	labels       [][2]string // Issue and label; nil for no labels table
+	noIssues     bool        // Lacks the issues table
+	fail         error       // Fails every query but the schema check
+	problems     []string    // What PRAGMA integrity_check reports; nil for an intact database
+	bdVersion    string      // bd_version of bd's metadata table; "" for no metadata table
+}
+
+type fakeIssue struct {
+	id, title, status string
+	phase, assignee   driver.Value // string or nil for NULL
+	priority          driver.Value // int64 or nil for NULL
+	createdAt         string
+}
+
+// newFakeDatabase creates the database file in the .beads directory of a
+// new repository, returning the repository
+func newFakeDatabase(t *testing.T, db *fakeDatabase) string {
+	t.Helper()
+	repo := t.TempDir()
+	path := filepath.Join(repo, DataDirName, "beads.db")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	fakeDatabasesMu.Lock()
+	fakeDatabases[path] = db
+	fakeDatabasesMu.Unlock()
+	t.Cleanup(func() {
+		fakeDatabasesMu.Lock()
+		delete(fakeDatabases, path)
+		fakeDatabasesMu.Unlock()
+	})
+	return repo
+}
+
+func (fakeSQLite) Open(name string) (driver.Conn, error) {
+	fakeDatabasesMu.Lock()
+	defer fakeDatabasesMu.Unlock()
+	db, ok := fakeDatabases[name]
+	if !ok {
+		return nil, fmt.Errorf("unable to open database file: %s", name)
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct {
+	db           *fakeDatabase
+	snapshot     []fakeIssue // Issues at the start of the transaction in progress
+	snapshotDeps [][2]string
+	snapshotTags [][2]string
+	inTx         bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.snapshot = append([]fakeIssue{}, c.db.issues...)
+	c.snapshotDeps = append([][2]string{}, c.db.dependencies...)
+	if c.db.labels != nil {
+		c.snapshotTags = append([][2]string{}, c.db.labels...)
+	}
+	c.inTx = true
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.inTx = false
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if c.inTx {
+		c.db.issues = c.snapshot
+		c.db.dependencies = c.snapshotDeps
+		c.db.labels = c.snapshotTags
+		c.inTx = false
+	}
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "PRAGMA"):
+		return driver.RowsAffected(0), nil
+	case db.fail != nil:
+		return nil, db.fail
+	case strings.HasPrefix(s.query, "INSERT INTO issues"):
+		db.issues = append(db.issues, fakeIssue{id: args[0].(string), title: args[1].(string), status: args[2].(string),
+			phase: args[3], priority: args[4], assignee: args[5], createdAt: args[6].(string)})
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "INSERT OR IGNORE INTO dirty_issues"):
+		db.dirty[args[0].(string)] = true
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "UPDATE issues SET "):
+		set := strings.TrimPrefix(s.query[:strings.Index(s.query, " WHERE")], "UPDATE issues SET ")
+		id := args[len(args)-1].(string)
+		for i := range db.issues {
+			if db.issues[i].id != id {
+				continue
+			}
+			for j, assignment := range strings.Split(set, ", ") {
+				switch strings.TrimSuffix(assignment, " = ?") {
+				case "title":
+					db.issues[i].title = args[j].(string)
+				case "status":
+					db.issues[i].status = args[j].(string)
+				case "phase":
+					db.issues[i].phase = args[j]
+				case "priority":
+					db.issues[i].priority = args[j]
+				case "assignee":
+					db.issues[i].assignee = args[j]
+				}
+			}
+			return driver.RowsAffected(1), nil
+		}
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "INSERT OR IGNORE INTO dependencies"):
+		dep := [2]string{args[0].(string), args[1].(string)}
+		for _, existing := range db.dependencies {
+			if existing == dep {
+				return driver.RowsAffected(0), nil
+			}
+		}
+		db.dependencies = append(db.dependencies, dep)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE FROM dependencies"):
+		either := strings.Contains(s.query, " OR ")
+		var kept [][2]string
+		for _, dep := range db.dependencies {
+			matches := dep[0] == args[0].(string) && dep[1] == args[1].(string)
+			if either {
+				matches = dep[0] == args[0].(string) || dep[1] == args[1].(string)
+			}
+			if !matches {
+				kept = append(kept, dep)
+			}
+		}
+		affected := len(db.dependencies) - len(kept)
+		db.dependencies = kept
+		return driver.RowsAffected(affected), nil
+	case strings.HasPrefix(s.query, "INSERT OR IGNORE INTO labels"):
+		db.labels = append(db.labels, [2]string{args[0].(string), args[1].(string)})
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE FROM labels"):
+		var kept [][2]string
+		for _, label := range db.labels {
+			if label[0] != args[0].(string) {
+				kept = append(kept, label)
+			}
+		}
+		db.labels = kept
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "DELETE FROM issues"):
+		for i, issue := range db.issues {
+			if issue.id == args[0].(string) {
+				db.issues = append(db.issues[:i], db.issues[i+1:]...)
+				return driver.RowsAffected(1), nil
+			}
+		}
+		return driver.RowsAffected(0), nil
+	}
+	return nil, fmt.Errorf("unexpected statement: %s", s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db := s.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	switch {
+	case strings.HasSuffix(s.query, "FROM issues LIMIT 0"):
+		if db.noIssues {
+			return nil, errors.New("no such table: issues")
+		}
+		return &fakeRows{columns: []string{"id", "title", "status", "phase", "assignee", "created_at", "updated_at"}}, nil
+	case strings.HasPrefix(s.query, "PRAGMA integrity_check"):
+		rows := &fakeRows{columns: []string{"integrity_check"}, values: [][]driver.Value{{"ok"}}}
+		if db.problems != nil {
+			rows.values = nil
+			for _, problem := range db.problems {
+				rows.values = append(rows.values, []driver.Value{problem})
+			}
+		}
+		return rows, nil
+	case strings.HasSuffix(s.query, "name = 'metadata'"):
+		rows := &fakeRows{columns: []string{"name"}}
+		if db.bdVersion != "" {
+			rows.values = [][]driver.Value{{"metadata"}}
+		}
+		return rows, nil
+	case strings.HasPrefix(s.query, "SELECT value FROM metadata"):
+		return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{db.bdVersion}}}, nil
+	case strings.Contains(s.query, "FROM sqlite_master"):
+		rows := &fakeRows{columns: []string{"name"}}
+		if (args[0] == "dirty_issues" && db.dirty != nil) || (args[0] == "dependencies" && db.hasDeps) || (args[0] == "labels" && db.labels != nil) {
+			rows.values = [][]driver.Value{{args[0]}}
+		}
+		return rows, nil
+	case db.fail != nil:
+		return nil, db.fail
+	case strings.HasPrefix(s.query, "SELECT id FROM issues"):
+		rows := &fakeRows{columns: []string{"id"}}
+		for _, issue := range db.issues {
+			if len(args) == 0 || issue.id == args[0] {
+				rows.values = append(rows.values, []driver.Value{issue.id})
+			}
+		}
+		return rows, nil
+	case strings.HasPrefix(s.query, "SELECT issue_id, label FROM labels"):
+		labels := append([][2]string{}, db.labels...)
+		sort.SliceStable(labels, func(i, j int) bool { return labels[i][1] < labels[j][1] })
+		rows := &fakeRows{columns: []string{"issue_id", "label"}}
+		for _, label := range labels {
+			rows.values = append(rows.values, []driver.Value{label[0], label[1]})
+		}
+		return rows, nil
+	case strings.HasPrefix(s.query, "SELECT issue_id, depends_on_id FROM dependencies"):
+		rows := &fakeRows{columns: []string{"issue_id", "depends_on_id"}}
+		for _, dep := range db.dependencies {
+			rows.values = append(rows.values, []driver.Value{dep[0], dep[1]})
+		}
+		return rows, nil
+	case strings.HasPrefix(s.query, "SELECT id, title, status, COALESCE(phase, ''), COALESCE(priority, 2), COALESCE(assignee, '') FROM issues"):
+		statuses := map[string]bool{}
+		for _, arg := range args {
+			statuses[arg.(string)] = true
+		}
+		issues := append([]fakeIssue{}, db.issues...)
+		sort.SliceStable(issues, func(i, j int) bool {
+			if issues[i].createdAt != issues[j].createdAt {
+				return issues[i].createdAt < issues[j].createdAt
+			}
+			return issues[i].id < issues[j].id
+		})
+		rows := &fakeRows{columns: []string{"id", "title", "status", "phase", "priority", "assignee"}}
+		for _, issue := range issues {
+			if len(statuses) > 0 && !statuses[issue.status] {
+				continue
+			}
+			priority := issue.priority
+			if priority == nil {
+				priority = int64(2)
+			}
+			rows.values = append(rows.values, []driver.Value{issue.id, issue.title, issue.status, coalesce(issue.phase), priority, coalesce(issue.assignee)})
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", s.query)
+}
+
+func coalesce(value driver.Value) driver.Value {
+	if value == nil {
+		return ""
+	}
+	return value
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// seededDatabase has three tasks of the asc prefix, one with NULL phase and
+// assignee, and bd's dirty_issues table
+func seededDatabase() *fakeDatabase {
+	return &fakeDatabase{
+		issues: []fakeIssue{
+			{id: "asc-2", title: "Review docs", status: "in_progress", phase: "review", priority: int64(1), assignee: "reviewer", createdAt: "2026-03-01T11:00:00Z"},
+			{id: "asc-1", title: "Write docs", status: "open", phase: "implementation", priority: int64(3), assignee: "", createdAt: "2026-03-01T10:00:00Z"},
+			{id: "asc-10", title: "Triage", status: "closed", createdAt: "2026-03-01T12:00:00Z"},
+		},
+		dirty: map[string]bool{},
+	}
+}
+
+func TestFindDatabase(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    string
+		wantErr string
+	}{
+		{"beads.db", []string{"beads.db", "other.db", "issues.jsonl"}, "beads.db", ""},
+		{"only database", []string{"asc.db", "issues.jsonl"}, "asc.db", ""},
+		{"none", []string{"issues.jsonl"}, "", "no beads database"},
+		{"several", []string{"a.db", "b.db"}, "", "several databases"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := t.TempDir()
+			dataDir := filepath.Join(repo, DataDirName)
+			if err := os.MkdirAll(dataDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dataDir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := FindDatabase(repo)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindDatabase() error = %v", err)
+			}
+			if got != filepath.Join(dataDir, tt.want) {
+				t.Errorf("FindDatabase() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenSQLite(t *testing.T) {
+	if _, err := OpenSQLite(t.TempDir(), time.Second); err == nil || !strings.Contains(err.Error(), "no beads database") {
+		t.Errorf("Expected an error for a repository without a database, got %v", err)
+	}
+
+	repo := newFakeDatabase(t, &fakeDatabase{noIssues: true})
+	if _, err := OpenSQLite(repo, time.Second); err == nil || !strings.Contains(err.Error(), "not a beads database") {
+		t.Errorf("Expected an error for a database without issues, got %v", err)
+	}
+
+	repo = newFakeDatabase(t, &fakeDatabase{})
+	client, err := OpenSQLite(repo, time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+	if client.dirty {
+		t.Error("Expected no dirty_issues table")
+	}
+}
+
+func TestNew(t *testing.T) {
+	repo := newFakeDatabase(t, seededDatabase())
+	empty := t.TempDir()
+
+	tests := []struct {
+		name    string
+		dbPath  string
+		mode    string
+		want    string
+		wantErr bool
+	}{
+		{"auto with a database", repo, ModeAuto, "sqlite", false},
+		{"auto without a database", empty, ModeAuto, "cli", false},
+		{"sqlite with a database", repo, ModeSQLite, "sqlite", false},
+		{"sqlite without a database", empty, ModeSQLite, "", true},
+		{"cli", repo, ModeCLI, "cli", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.dbPath, time.Second, tt.mode)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			switch c := client.(type) {
+			case *SQLiteClient:
+				defer c.Close()
+				if tt.want != "sqlite" {
+					t.Errorf("Expected the bd client, got the SQLite client")
+				}
+				if c.fallback != (tt.mode == ModeAuto) {
+					t.Errorf("fallback = %v in %s mode", c.fallback, tt.mode)
+				}
+			case *Client:
+				if tt.want != "cli" {
+					t.Errorf("Expected the SQLite client, got the bd client")
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteClient(t *testing.T) {
+	db := seededDatabase()
+	client, err := OpenSQLite(newFakeDatabase(t, db), time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var published []events.Event
+	unsubscribe := events.Subscribe(func(event events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		published = append(published, event)
+	})
+	defer unsubscribe()
+
+	tasks, err := client.GetTasks(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+	if len(tasks) != 3 || tasks[0].ID != "asc-1" || tasks[1].ID != "asc-2" || tasks[2].ID != "asc-10" {
+		t.Fatalf("Expected all tasks, oldest first, got %+v", tasks)
+	}
+	if !reflect.DeepEqual(tasks[1], Task{ID: "asc-2", Title: "Review docs", Status: "in_progress", Phase: "review", Priority: 1, Assignee: "reviewer"}) {
+		t.Errorf("Unexpected task %+v", tasks[1])
+	}
+	if tasks[2].Phase != "" || tasks[2].Assignee != "" || tasks[2].Priority != 2 {
+		t.Errorf("Expected NULL phase and assignee to be empty and NULL priority the default, got %+v", tasks[2])
+	}
+
+	tasks, err = client.GetTasks(ctx, []string{"open", "in_progress"})
+	if err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Expected the open and in-progress tasks, got %+v", tasks)
+	}
+
+	task, err := client.CreateTask(ctx, "Fix the build")
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if !reflect.DeepEqual(task, Task{ID: "asc-11", Title: "Fix the build", Status: "open", Priority: 2}) {
+		t.Errorf("Expected the next ID of the prefix, got %+v", task)
+	}
+
+	status, assignee := "in_progress", "coder"
+	if err := client.UpdateTask(ctx, task.ID, TaskUpdate{Status: &status, Assignee: &assignee}); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	tasks, _ = client.GetTasks(ctx, []string{"in_progress"})
+	if len(tasks) != 2 || tasks[1].ID != task.ID || tasks[1].Assignee != "coder" || tasks[1].Title != "Fix the build" {
+		t.Errorf("Expected the task to be updated, got %+v", tasks)
+	}
+
+	if err := client.DeleteTask(ctx, "asc-1"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	tasks, _ = client.GetTasks(ctx, nil)
+	if len(tasks) != 3 || tasks[0].ID != "asc-2" {
+		t.Errorf("Expected the task to be deleted, got %+v", tasks)
+	}
+
+	if err := client.UpdateTask(ctx, "asc-99", TaskUpdate{Status: &status}); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound updating a missing task, got %v", err)
+	}
+	if err := client.DeleteTask(ctx, "asc-99"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound deleting a missing task, got %v", err)
+	}
+
+	if !db.dirty["asc-11"] || !db.dirty["asc-1"] || len(db.dirty) != 2 {
+		t.Errorf("Expected the changed tasks to be marked for export, got %v", db.dirty)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []events.Event{
+		{Type: events.TaskCreated, Task: "asc-11", Message: "Fix the build"},
+		{Type: events.TaskUpdated, Task: "asc-11", Message: "--status in_progress --assignee coder"},
+		{Type: events.TaskDeleted, Task: "asc-1"},
+	}
+	if len(published) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), published)
+	}
+	for i, event := range published {
+		if event.Type != want[i].Type || event.Task != want[i].Task || event.Message != want[i].Message {
+			t.Errorf("Event %d = %+v, want %+v", i, event, want[i])
+		}
+	}
+}
+
+func TestSQLiteClient_Dependencies(t *testing.T) {
+	db := seededDatabase()
+	db.hasDeps = true
+	db.dependencies = [][2]string{{"asc-2", "asc-1"}}
+	client, err := OpenSQLite(newFakeDatabase(t, db), time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	tasks, err := client.GetTasks(ctx, []string{"in_progress"})
+	if err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+	if len(tasks) != 1 || !reflect.DeepEqual(tasks[0].Dependencies, []string{"asc-1"}) {
+		t.Fatalf("Expected the dependency to be read, got %+v", tasks)
+	}
+
+	if err := client.AddDependency(ctx, "asc-2", "asc-10"); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+	blockers, err := client.GetBlockers(ctx, "asc-2")
+	if err != nil {
+		t.Fatalf("GetBlockers() error = %v", err)
+	}
+	if len(blockers) != 1 || blockers[0].ID != "asc-1" {
+		t.Errorf("Expected the open dependency to block, not the closed one, got %+v", blockers)
+	}
+	if !db.dirty["asc-2"] {
+		t.Error("Expected the task to be marked for export")
+	}
+
+	if err := client.AddDependency(ctx, "asc-2", "asc-99"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound depending on a missing task, got %v", err)
+	}
+	if err := client.AddDependency(ctx, "asc-2", "asc-2"); err == nil {
+		t.Error("Expected an error for a task depending on itself")
+	}
+	if _, err := client.GetBlockers(ctx, "asc-99"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound for the blockers of a missing task, got %v", err)
+	}
+
+	if err := client.RemoveDependency(ctx, "asc-2", "asc-1"); err != nil {
+		t.Fatalf("RemoveDependency() error = %v", err)
+	}
+	if blockers, _ := client.GetBlockers(ctx, "asc-2"); len(blockers) != 0 {
+		t.Errorf("Expected no blockers, got %+v", blockers)
+	}
+
+	if err := client.DeleteTask(ctx, "asc-10"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if len(db.dependencies) != 0 {
+		t.Errorf("Expected the dependencies of the deleted task to be deleted, got %v", db.dependencies)
+	}
+
+	// Without bd's dependencies table, sqlite mode cannot record them
+	client, err = OpenSQLite(newFakeDatabase(t, seededDatabase()), time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+	if err := client.AddDependency(ctx, "asc-2", "asc-1"); err == nil || !strings.Contains(err.Error(), "no dependencies table") {
+		t.Errorf("Expected an error without a dependencies table, got %v", err)
+	}
+}
+
+func TestSQLiteClient_Labels(t *testing.T) {
+	db := seededDatabase()
+	db.labels = [][2]string{{"asc-1", "docs"}, {"asc-2", "docs"}, {"asc-1", "backend"}}
+	client, err := OpenSQLite(newFakeDatabase(t, db), time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	tasks, err := client.GetTasksFiltered(ctx, "label:backend priority>=3")
+	if err != nil {
+		t.Fatalf("GetTasksFiltered() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "asc-1" || !reflect.DeepEqual(tasks[0].Labels, []string{"backend", "docs"}) {
+		t.Fatalf("Expected asc-1 with its labels in order, got %+v", tasks)
+	}
+
+	if err := client.DeleteTask(ctx, "asc-1"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if len(db.labels) != 1 {
+		t.Errorf("Expected the labels of the deleted task to be deleted, got %v", db.labels)
+	}
+}
+
+func TestSQLiteClient_EmptyDatabase(t *testing.T) {
+	client, err := OpenSQLite(newFakeDatabase(t, &fakeDatabase{}), time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+
+	task, err := client.CreateTask(context.Background(), "First task")
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if task.ID != "bd-1" {
+		t.Errorf("Expected the first ID of bd's default prefix, got %s", task.ID)
+	}
+}
+
+func TestSQLiteClient_Fallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bd is a shell script")
+	}
+
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho '[{\"id\":\"bd-7\",\"title\":\"From bd\",\"status\":\"open\",\"phase\":\"planning\"}]'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	db := seededDatabase()
+	repo := newFakeDatabase(t, db)
+	ctx := context.Background()
+
+	auto, err := New(repo, time.Second, ModeAuto)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer auto.(*SQLiteClient).Close()
+	strict, err := New(repo, time.Second, ModeSQLite)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer strict.(*SQLiteClient).Close()
+
+	db.mu.Lock()
+	db.fail = errors.New("database is locked")
+	db.mu.Unlock()
+
+	tasks, err := auto.GetTasks(ctx, nil)
+	if err != nil {
+		t.Fatalf("Expected auto mode to fall back to bd, got %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "bd-7" {
+		t.Errorf("Expected the tasks of bd, got %+v", tasks)
+	}
+
+	if _, err := strict.GetTasks(ctx, nil); err == nil || !strings.Contains(err.Error(), "database is locked") {
+		t.Errorf("Expected sqlite mode to fail, got %v", err)
+	}
+
+	// Interrupted calls are not run again with bd
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := auto.GetTasks(cancelled, nil); err == nil {
+		t.Error("Expected a cancelled call to fail")
+	}
+}
+
+// bdSchema is the schema of bd's database, with the phase column asc keeps
+// its tasks' phases in
+const bdSchema = `
+CREATE TABLE issues (
+	id TEXT PRIMARY KEY,
+	content_hash TEXT,
+	title TEXT NOT NULL CHECK(length(title) <= 500),
+	description TEXT NOT NULL DEFAULT '',
+	design TEXT NOT NULL DEFAULT '',
+	acceptance_criteria TEXT NOT NULL DEFAULT '',
+	notes TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'open',
+	phase TEXT,
+	priority INTEGER NOT NULL DEFAULT 2 CHECK(priority >= 0 AND priority <= 4),
+	issue_type TEXT NOT NULL DEFAULT 'task',
+	assignee TEXT,
+	estimated_minutes INTEGER,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	closed_at DATETIME,
+	external_ref TEXT
+);
+CREATE TABLE dependencies (
+	issue_id TEXT NOT NULL,
+	depends_on_id TEXT NOT NULL,
+	type TEXT NOT NULL DEFAULT 'blocks',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	created_by TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (issue_id, depends_on_id),
+	FOREIGN KEY (issue_id) REFERENCES issues(id) ON DELETE CASCADE,
+	FOREIGN KEY (depends_on_id) REFERENCES issues(id) ON DELETE CASCADE
+);
+CREATE TABLE labels (
+	issue_id TEXT NOT NULL,
+	label TEXT NOT NULL,
+	PRIMARY KEY (issue_id, label),
+	FOREIGN KEY (issue_id) REFERENCES issues(id) ON DELETE CASCADE
+);
+CREATE TABLE dirty_issues (
+	issue_id TEXT PRIMARY KEY,
+	marked_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE metadata (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+INSERT INTO metadata (key, value) VALUES ('bd_version', '0.21.5');
+INSERT INTO issues (id, title, status, phase, priority, assignee, created_at, updated_at) VALUES
+	('asc-1', 'Write docs', 'open', 'implementation', 3, NULL, '2026-03-01T10:00:00Z', '2026-03-01T10:00:00Z'),
+	('asc-2', 'Review docs', 'in_progress', 'review', 1, 'reviewer', '2026-03-01T11:00:00Z', '2026-03-01T11:00:00Z');
+INSERT INTO labels (issue_id, label) VALUES ('asc-2', 'docs');
+`
+
+func TestSQLiteClient_RealDatabase(t *testing.T) {
+	useSQLite(t)
+	repo := t.TempDir()
+	path := filepath.Join(repo, DataDirName, "beads.db")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(bdSchema); err != nil {
+		t.Fatalf("Failed to create the bd schema: %v", err)
+	}
+	db.Close()
+
+	client, err := OpenSQLite(repo, time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+	if !client.dirty || !client.deps || !client.labels {
+		t.Fatalf("Expected bd's tables found, got %+v", client)
+	}
+	ctx := context.Background()
+
+	priority := 0
+	created, err := client.CreateTasks(ctx, []NewTask{{Title: "Fix the build", Phase: "testing", Priority: &priority, Labels: []string{"ci"}}})
+	if err != nil {
+		t.Fatalf("CreateTasks() error = %v", err)
+	}
+	if err := client.AddDependency(ctx, created[0].ID, "asc-1"); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+	status := StatusClosed
+	if err := client.UpdateTask(ctx, "asc-2", TaskUpdate{Status: &status}); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+
+	tasks, err := client.GetTasks(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+	want := []Task{
+		{ID: "asc-1", Title: "Write docs", Status: "open", Phase: "implementation", Priority: 3},
+		{ID: "asc-2", Title: "Review docs", Status: StatusClosed, Phase: "review", Priority: 1, Labels: []string{"docs"}, Assignee: "reviewer"},
+		{ID: "asc-3", Title: "Fix the build", Status: "open", Phase: "testing", Priority: 0, Labels: []string{"ci"}, Dependencies: []string{"asc-1"}},
+	}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("GetTasks() = %+v\nwant %+v", tasks, want)
+	}
+
+	if err := client.DeleteTask(ctx, "asc-1"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if tasks, _ := client.GetTasks(ctx, []string{"open"}); len(tasks) != 1 || tasks[0].Dependencies != nil {
+		t.Errorf("Expected the task and its dependencies deleted, got %+v", tasks)
+	}
+
+	// The changed tasks are exported by bd, and the database is intact
+	db, err = sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var dirty int
+	if err := db.QueryRow("SELECT COUNT(*) FROM dirty_issues").Scan(&dirty); err != nil || dirty != 3 {
+		t.Errorf("Expected 3 tasks marked for export, got %d (%v)", dirty, err)
+	}
+	integrity, err := CheckIntegrity(ctx, repo)
+	if err != nil {
+		t.Fatalf("CheckIntegrity() error = %v", err)
+	}
+	if integrity.Problems != nil || integrity.BDVersion != "0.21.5" {
+		t.Errorf("CheckIntegrity() = %+v", integrity)
+	}
+}
@@ -0,0 +1,183 @@
+package beads
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// Kinds of task changes
+const (
+	ChangeCreated = "created" // The task is new, or was not seen before
+	ChangeUpdated = "updated" // A field of the task changed
+	ChangeDeleted = "deleted" // The task is gone; Task is its last known state
+)
+
+// TaskChange is a change of a task reported by Watch
+type TaskChange struct {
+	Kind string `json:"kind"` // One of the kinds above
+	Task Task   `json:"task"`
+}
+
+// TaskWatcher is implemented by the clients that can report task changes
+// as they happen: the bd and SQLite clients
+type TaskWatcher interface {
+	// Watch lists the tasks of every status, sends them as one batch of
+	// created changes, then sends a batch of the changes since each time
+	// tasks change. The channel is closed when ctx is done. Returns an
+	// error if changes cannot be watched, in which case callers poll.
+	Watch(ctx context.Context) (<-chan []TaskChange, error)
+}
+
+// sqlitePollInterval is how often the SQLite client checks its database
+// for writes. A var so tests can shorten it.
+var sqlitePollInterval = 250 * time.Millisecond
+
+// Watch reports task changes, listing the tasks again each time bd writes
+// a change to the JSONL files of the .beads directory
+func (c *Client) Watch(ctx context.Context) (<-chan []TaskChange, error) {
+	watcher, err := c.WatchFiles()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := watchTasks(ctx, c.GetTasks, watcher.Changes())
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		watcher.Close()
+	}()
+	return changes, nil
+}
+
+// Watch reports task changes, listing the tasks again each time the
+// database or its write-ahead log is written. They are polled rather than
+// watched: SQLite writes the log in place, which not every platform
+// reports as it happens.
+func (c *SQLiteClient) Watch(ctx context.Context) (<-chan []TaskChange, error) {
+	return watchTasks(ctx, c.GetTasks, pollFiles(ctx, sqlitePollInterval, c.path, c.path+"-wal"))
+}
+
+// watchTasks lists the tasks with list, then again on each trigger,
+// sending the changes between listings. A listing that fails is logged
+// and skipped; the next trigger lists again.
+func watchTasks(ctx context.Context, list func(ctx context.Context, statuses []string) ([]Task, error), triggers <-chan struct{}) (<-chan []TaskChange, error) {
+	tasks, err := list(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan []TaskChange)
+	go func() {
+		defer close(out)
+		known := make(map[string]Task)
+		pending := diffTasks(known, tasks)
+		for {
+			if len(pending) > 0 {
+				select {
+				case out <- pending:
+				case <-ctx.Done():
+					return
+				}
+				pending = nil
+			}
+			select {
+			case _, ok := <-triggers:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			tasks, err := list(ctx, nil)
+			if err != nil {
+				if ctx.Err() == nil {
+					beadsLog.WithFields(logger.Fields{"error": err.Error()}).Warn("Failed to list tasks after a change")
+				}
+				continue
+			}
+			pending = diffTasks(known, tasks)
+		}
+	}()
+	return out, nil
+}
+
+// diffTasks returns the changes from the known tasks to tasks, in the
+// order of tasks and then the deleted ones by ID, and makes tasks known
+func diffTasks(known map[string]Task, tasks []Task) []TaskChange {
+	var changes []TaskChange
+	seen := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		seen[task.ID] = true
+		last, ok := known[task.ID]
+		switch {
+		case !ok:
+			changes = append(changes, TaskChange{Kind: ChangeCreated, Task: task})
+		case !reflect.DeepEqual(last, task):
+			changes = append(changes, TaskChange{Kind: ChangeUpdated, Task: task})
+		}
+		known[task.ID] = task
+	}
+	var gone []string
+	for id := range known {
+		if !seen[id] {
+			gone = append(gone, id)
+		}
+	}
+	sort.Strings(gone)
+	for _, id := range gone {
+		changes = append(changes, TaskChange{Kind: ChangeDeleted, Task: known[id]})
+		delete(known, id)
+	}
+	return changes
+}
+
+// pollFiles checks the size and modification time of paths every
+// interval, sending on the returned channel when any of them changes, is
+// created, or is removed. Checks that find a change while the last is
+// still unreceived are coalesced. Stops when ctx is done.
+func pollFiles(ctx context.Context, interval time.Duration, paths ...string) <-chan struct{} {
+	type fileState struct {
+		size    int64
+		modTime time.Time
+		exists  bool
+	}
+	stat := func() []fileState {
+		states := make([]fileState, len(paths))
+		for i, path := range paths {
+			if info, err := os.Stat(path); err == nil {
+				states[i] = fileState{size: info.Size(), modTime: info.ModTime(), exists: true}
+			}
+		}
+		return states
+	}
+
+	// The files are first checked before returning, so a write made while
+	// the tasks are first listed is not missed
+	changes := make(chan struct{}, 1)
+	last := stat()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			if states := stat(); !reflect.DeepEqual(states, last) {
+				last = states
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changes
+}
@@ -0,0 +1,95 @@
+package beads
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffTasks(t *testing.T) {
+	known := make(map[string]Task)
+	changes := diffTasks(known, []Task{{ID: "asc-1", Status: "open"}, {ID: "asc-2", Status: "open"}})
+	want := []TaskChange{
+		{Kind: ChangeCreated, Task: Task{ID: "asc-1", Status: "open"}},
+		{Kind: ChangeCreated, Task: Task{ID: "asc-2", Status: "open"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("diffTasks() = %+v, want %+v", changes, want)
+	}
+
+	changes = diffTasks(known, []Task{{ID: "asc-2", Status: "open", Labels: []string{"docs"}}, {ID: "asc-3", Status: "open"}})
+	want = []TaskChange{
+		{Kind: ChangeUpdated, Task: Task{ID: "asc-2", Status: "open", Labels: []string{"docs"}}},
+		{Kind: ChangeCreated, Task: Task{ID: "asc-3", Status: "open"}},
+		{Kind: ChangeDeleted, Task: Task{ID: "asc-1", Status: "open"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("diffTasks() = %+v, want %+v", changes, want)
+	}
+
+	if changes := diffTasks(known, []Task{{ID: "asc-2", Status: "open", Labels: []string{"docs"}}, {ID: "asc-3", Status: "open"}}); len(changes) != 0 {
+		t.Errorf("Expected no changes for the same tasks, got %+v", changes)
+	}
+}
+
+func TestSQLiteClient_Watch(t *testing.T) {
+	interval := sqlitePollInterval
+	sqlitePollInterval = 10 * time.Millisecond
+	defer func() { sqlitePollInterval = interval }()
+
+	db := seededDatabase()
+	client, err := OpenSQLite(newFakeDatabase(t, db), time.Second)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer client.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := client.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	next := func() []TaskChange {
+		t.Helper()
+		select {
+		case batch := <-changes:
+			return batch
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for task changes")
+			return nil
+		}
+	}
+
+	batch := next()
+	if len(batch) != 3 || batch[0].Kind != ChangeCreated || batch[0].Task.ID != "asc-1" {
+		t.Fatalf("Expected every task as created first, got %+v", batch)
+	}
+
+	// bd closes a task and deletes another, writing the write-ahead log
+	db.mu.Lock()
+	db.issues[0].status = StatusClosed
+	db.issues = db.issues[:2]
+	db.mu.Unlock()
+	if err := os.WriteFile(filepath.Join(filepath.Dir(client.path), "beads.db-wal"), []byte("wal"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	batch = next()
+	if len(batch) != 2 || batch[0].Kind != ChangeUpdated || batch[0].Task.ID != "asc-2" || batch[0].Task.Status != StatusClosed ||
+		batch[1].Kind != ChangeDeleted || batch[1].Task.ID != "asc-10" {
+		t.Errorf("Expected asc-2 closed and asc-10 deleted, got %+v", batch)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Error("Expected no more changes once the context is done")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the channel to be closed once the context is done")
+	}
+}
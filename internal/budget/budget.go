@@ -0,0 +1,527 @@
+// Package budget enforces the spend budgets configured in [budget] and on
+// each agent (budget_usd). Spend is read from the usage ledger the agents
+// write (see package usage). Crossing a warn_at fraction of a budget emits
+// a warning; reaching the budget pauses the offending agents, which are
+// stopped and not restarted until the next budget period or until they are
+// resumed with asc budget resume. Every warning and pause is also sent to
+// the configured notification hooks.
+//
+// Example usage:
+//
+//	enforcer, err := budget.NewEnforcer(cfg, controller)
+//	if err != nil {
+//	    return err
+//	}
+//	enforcer.Start()
+//	defer enforcer.Stop()
+//
+//	for event := range enforcer.Events() {
+//	    fmt.Printf("%s: %s\n", event.Type, event.Message)
+//	}
+package budget
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/proxy"
+	"github.com/rand/asc/internal/usage"
+)
+
+// budgetLog tags every record written by this package with the budget component
+var budgetLog = logger.WithComponent("budget")
+
+// ProjectScope is the scope of the project-wide budget; every other scope
+// is an agent name
+const ProjectScope = "project"
+
+// notifyTimeout bounds each notification hook
+const notifyTimeout = 10 * time.Second
+
+// EventType identifies a budget event
+type EventType string
+
+const (
+	EventWarning  EventType = "budget_warning"  // Spend crossed a warn_at fraction of a budget
+	EventExceeded EventType = "budget_exceeded" // Spend reached a budget
+	EventPaused   EventType = "agent_paused"    // An agent was paused for exceeding a budget
+	EventError    EventType = "error"           // A budget check failed
+)
+
+// Event describes a budget warning or pause
+type Event struct {
+	Type      EventType `json:"type"`
+	Scope     string    `json:"scope"`           // ProjectScope or an agent name
+	Agent     string    `json:"agent,omitempty"` // The paused agent
+	SpentUSD  float64   `json:"spent_usd"`
+	LimitUSD  float64   `json:"limit_usd"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Controller stops agent processes by name
+type Controller interface {
+	StopAgent(name string) error
+}
+
+// Limit is a budget and the spend counted against it
+type Limit struct {
+	Scope    string
+	SpentUSD float64
+	LimitUSD float64
+}
+
+// Report is a snapshot of spend against every configured budget
+type Report struct {
+	Period      string
+	PeriodStart time.Time // Zero for the "total" period
+	TotalUSD    float64
+	Spend       map[string]float64 // Per agent
+	Limits      []Limit            // Project budget first, then agents by name
+	Paused      map[string]Pause
+	Overrides   map[string]bool
+}
+
+// eventBufferSize is how many events are kept for a slow reader before new
+// ones are dropped (they are still logged)
+const eventBufferSize = 100
+
+// Enforcer checks spend against budgets and pauses agents that exceed them
+type Enforcer struct {
+	cfg       config.BudgetConfig
+	agents    map[string]config.AgentConfig
+	control   Controller
+	usageDir  string
+	statePath string
+	enforce   bool
+	client    *http.Client
+
+	mu     sync.RWMutex
+	paused map[string]bool
+
+	events   chan Event
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewEnforcer creates an enforcer that reads the ledger in ~/.asc/usage
+// and keeps its state in ~/.asc/budget.json
+func NewEnforcer(cfg config.Config, control Controller) (*Enforcer, error) {
+	usageDir, err := usage.Dir()
+	if err != nil {
+		return nil, err
+	}
+	statePath, err := DefaultStatePath()
+	if err != nil {
+		return nil, err
+	}
+	return NewEnforcerWithPaths(cfg, control, usageDir, statePath), nil
+}
+
+// NewEnforcerWithPaths creates an enforcer with an explicit ledger
+// directory and state file. A nil control records pauses without stopping
+// agents, which is how the asc budget command uses it.
+func NewEnforcerWithPaths(cfg config.Config, control Controller, usageDir, statePath string) *Enforcer {
+	return &Enforcer{
+		cfg:       cfg.Budget,
+		agents:    cfg.Agents,
+		control:   control,
+		usageDir:  usageDir,
+		statePath: statePath,
+		enforce:   true,
+		client:    &http.Client{Timeout: notifyTimeout, Transport: proxy.FromEnvironment().Transport()},
+		paused:    make(map[string]bool),
+		events:    make(chan Event, eventBufferSize),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// SetEnforce turns pausing on or off. With enforcement off (asc up
+// --ignore-budget) warnings are still emitted but no agent is paused, and
+// agents paused earlier are allowed to run.
+func (e *Enforcer) SetEnforce(enforce bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.enforce = enforce
+}
+
+// Events returns the channel on which warnings and pauses are delivered
+func (e *Enforcer) Events() <-chan Event {
+	return e.events
+}
+
+// Start begins checking spend every budget.check_interval
+func (e *Enforcer) Start() {
+	e.wg.Add(1)
+	go e.loop()
+	budgetLog.Info("Budget enforcement started (period: %s)", e.cfg.Period)
+}
+
+// Stop stops the check loop. Paused agents stay paused.
+func (e *Enforcer) Stop() {
+	close(e.stopChan)
+	e.wg.Wait()
+	budgetLog.Info("Budget enforcement stopped")
+}
+
+func (e *Enforcer) loop() {
+	defer e.wg.Done()
+
+	interval := e.cfg.CheckInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			logger.StartCorrelation()
+			if err := e.Check(); err != nil {
+				budgetLog.Warn("Budget check failed: %v", err)
+				e.emit(Event{Type: EventError, Message: err.Error()})
+			}
+		case <-e.stopChan:
+			return
+		}
+	}
+}
+
+// Check sums the current period's spend, emits warnings for newly crossed
+// thresholds, and pauses the agents of every budget that has been reached.
+// Agents with an override are never paused. The state file is re-read
+// first so resumes made with asc budget take effect on the next check.
+func (e *Enforcer) Check() error {
+	state, err := e.loadState(time.Now())
+	if err != nil {
+		return err
+	}
+	spend, err := usage.ReadSpend(e.usageDir, state.PeriodStart)
+	if err != nil {
+		return err
+	}
+
+	e.mu.RLock()
+	enforce := e.enforce
+	e.mu.RUnlock()
+
+	for _, limit := range e.limits(spend) {
+		e.warn(&state, limit)
+		if limit.SpentUSD < limit.LimitUSD || !enforce {
+			continue
+		}
+		for _, name := range e.scopeAgents(limit.Scope) {
+			if _, ok := state.Paused[name]; ok || state.Overrides[name] {
+				continue
+			}
+			e.pause(&state, name, limit)
+		}
+	}
+
+	if err := SaveState(e.statePath, state); err != nil {
+		return err
+	}
+
+	paused := make(map[string]bool, len(state.Paused))
+	for name := range state.Paused {
+		paused[name] = true
+	}
+	e.mu.Lock()
+	e.paused = paused
+	e.mu.Unlock()
+	return nil
+}
+
+// IsPaused reports whether an agent is paused as of the last Check
+func (e *Enforcer) IsPaused(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.enforce && e.paused[name]
+}
+
+// Status reports the current period's spend against every budget
+func (e *Enforcer) Status() (Report, error) {
+	state, err := e.loadState(time.Now())
+	if err != nil {
+		return Report{}, err
+	}
+	spend, err := usage.ReadSpend(e.usageDir, state.PeriodStart)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		Period:      e.cfg.Period,
+		PeriodStart: state.PeriodStart,
+		Spend:       spend,
+		Limits:      e.limits(spend),
+		Paused:      state.Paused,
+		Overrides:   state.Overrides,
+	}
+	for _, spent := range spend {
+		report.TotalUSD += spent
+	}
+	return report, nil
+}
+
+// Resume clears an agent's pause. Without override it fails while the
+// agent's own budget or the project budget is still exceeded, since the
+// next check would pause it again; with override the agent may exceed its
+// budgets until the period ends.
+func (e *Enforcer) Resume(name string, override bool) error {
+	state, err := e.checkResume(name, override)
+	if err != nil {
+		return err
+	}
+
+	delete(state.Paused, name)
+	if override {
+		state.Overrides[name] = true
+	}
+	budgetLog.WithFields(logger.Fields{"agent": name, "override": override}).Info("Agent resumed")
+	return SaveState(e.statePath, state)
+}
+
+// CheckResume returns the error Resume would, without resuming the agent
+func (e *Enforcer) CheckResume(name string, override bool) error {
+	_, err := e.checkResume(name, override)
+	return err
+}
+
+// checkResume loads the state and checks that the agent may be resumed
+func (e *Enforcer) checkResume(name string, override bool) (State, error) {
+	if _, ok := e.agents[name]; !ok {
+		return State{}, fmt.Errorf("agent '%s' not found in config", name)
+	}
+	state, err := e.loadState(time.Now())
+	if err != nil {
+		return State{}, err
+	}
+	if _, paused := state.Paused[name]; !paused && !override {
+		return State{}, fmt.Errorf("agent '%s' is not paused", name)
+	}
+
+	if !override {
+		spend, err := usage.ReadSpend(e.usageDir, state.PeriodStart)
+		if err != nil {
+			return State{}, err
+		}
+		for _, limit := range e.limits(spend) {
+			if limit.SpentUSD >= limit.LimitUSD && (limit.Scope == ProjectScope || limit.Scope == name) {
+				return State{}, fmt.Errorf("agent '%s' is still over the %s budget ($%.2f of $%.2f)\n  Suggestion: Raise the budget in asc.toml or run 'asc budget resume %s --override'",
+					name, limit.Scope, limit.SpentUSD, limit.LimitUSD, name)
+			}
+		}
+	}
+	return state, nil
+}
+
+// loadState reads the state file, starting a fresh state when the budget
+// period has rolled over since it was written
+func (e *Enforcer) loadState(now time.Time) (State, error) {
+	state, err := LoadState(e.statePath)
+	if err != nil {
+		return State{}, err
+	}
+	start := PeriodStart(strings.ToLower(e.cfg.Period), now)
+	if !state.PeriodStart.Equal(start) {
+		if len(state.Paused) > 0 {
+			budgetLog.Info("New budget period; clearing %d paused agent(s)", len(state.Paused))
+		}
+		state = State{PeriodStart: start}
+	}
+	if state.Warned == nil {
+		state.Warned = make(map[string]float64)
+	}
+	if state.Paused == nil {
+		state.Paused = make(map[string]Pause)
+	}
+	if state.Overrides == nil {
+		state.Overrides = make(map[string]bool)
+	}
+	return state, nil
+}
+
+// limits returns the configured budgets with their spend: the project
+// budget first, then each agent budget by agent name
+func (e *Enforcer) limits(spend map[string]float64) []Limit {
+	var limits []Limit
+	if e.cfg.ProjectUSD > 0 {
+		total := 0.0
+		for _, spent := range spend {
+			total += spent
+		}
+		limits = append(limits, Limit{Scope: ProjectScope, SpentUSD: total, LimitUSD: e.cfg.ProjectUSD})
+	}
+	for _, name := range e.agentNames() {
+		if limit := e.agents[name].BudgetUSD; limit > 0 {
+			limits = append(limits, Limit{Scope: name, SpentUSD: spend[name], LimitUSD: limit})
+		}
+	}
+	return limits
+}
+
+// scopeAgents returns the agents a budget applies to
+func (e *Enforcer) scopeAgents(scope string) []string {
+	if scope == ProjectScope {
+		return e.agentNames()
+	}
+	return []string{scope}
+}
+
+// warn emits a warning for the highest threshold newly crossed by a
+// budget, and an exceeded event the first time the budget is reached.
+// Each is reported once per period.
+func (e *Enforcer) warn(state *State, limit Limit) {
+	fraction := limit.SpentUSD / limit.LimitUSD
+	reported := state.Warned[limit.Scope]
+
+	if fraction >= 1 {
+		if reported < 1 {
+			state.Warned[limit.Scope] = 1
+			e.emit(Event{
+				Type:     EventExceeded,
+				Scope:    limit.Scope,
+				SpentUSD: limit.SpentUSD,
+				LimitUSD: limit.LimitUSD,
+				Message:  fmt.Sprintf("%s budget exceeded: $%.2f of $%.2f", scopeLabel(limit.Scope), limit.SpentUSD, limit.LimitUSD),
+			})
+		}
+		return
+	}
+
+	crossed := 0.0
+	for _, threshold := range e.cfg.WarnAt {
+		if fraction >= threshold && threshold > crossed {
+			crossed = threshold
+		}
+	}
+	if crossed <= reported {
+		return
+	}
+	state.Warned[limit.Scope] = crossed
+	e.emit(Event{
+		Type:     EventWarning,
+		Scope:    limit.Scope,
+		SpentUSD: limit.SpentUSD,
+		LimitUSD: limit.LimitUSD,
+		Message:  fmt.Sprintf("%s budget at %.0f%%: $%.2f of $%.2f", scopeLabel(limit.Scope), fraction*100, limit.SpentUSD, limit.LimitUSD),
+	})
+}
+
+// pause records an agent as paused and stops it
+func (e *Enforcer) pause(state *State, name string, limit Limit) {
+	state.Paused[name] = Pause{
+		Scope:    limit.Scope,
+		SpentUSD: limit.SpentUSD,
+		LimitUSD: limit.LimitUSD,
+		PausedAt: time.Now(),
+	}
+	if e.control != nil {
+		if err := e.control.StopAgent(name); err != nil {
+			budgetLog.WithFields(logger.Fields{"agent": name}).Error("Failed to stop agent over budget: %v", err)
+			e.emit(Event{Type: EventError, Scope: limit.Scope, Agent: name, Message: fmt.Sprintf("Failed to pause %s: %v", name, err)})
+		}
+	}
+	e.emit(Event{
+		Type:     EventPaused,
+		Scope:    limit.Scope,
+		Agent:    name,
+		SpentUSD: limit.SpentUSD,
+		LimitUSD: limit.LimitUSD,
+		Message:  fmt.Sprintf("Agent %s paused: %s budget exceeded", name, scopeLabel(limit.Scope)),
+	})
+}
+
+// emit logs an event, runs the notification hooks, and delivers the event
+// without blocking the enforcer
+func (e *Enforcer) emit(event Event) {
+	event.Timestamp = time.Now()
+	entry := budgetLog.WithFields(logger.Fields{
+		"event": string(event.Type),
+		"scope": event.Scope,
+		"agent": event.Agent,
+	})
+	if event.Type == EventWarning {
+		entry.Warn("%s", event.Message)
+	} else {
+		entry.Info("%s", event.Message)
+	}
+
+	if event.Type != EventError {
+		e.notify(event)
+	}
+
+	select {
+	case e.events <- event:
+	default:
+	}
+}
+
+// notify runs budget.notify_command and posts to budget.notify_url.
+// Hook failures are logged and do not stop enforcement.
+func (e *Enforcer) notify(event Event) {
+	if e.cfg.NotifyCommand != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		cmd := exec.CommandContext(ctx, "sh", "-c", e.cfg.NotifyCommand)
+		cmd.Env = append(os.Environ(),
+			fmt.Sprintf("ASC_BUDGET_EVENT=%s", event.Type),
+			fmt.Sprintf("ASC_BUDGET_SCOPE=%s", event.Scope),
+			fmt.Sprintf("ASC_BUDGET_AGENT=%s", event.Agent),
+			fmt.Sprintf("ASC_BUDGET_SPENT=%.2f", event.SpentUSD),
+			fmt.Sprintf("ASC_BUDGET_LIMIT=%.2f", event.LimitUSD),
+			fmt.Sprintf("ASC_BUDGET_MESSAGE=%s", event.Message),
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			budgetLog.Warn("Budget notify_command failed: %v: %s", err, strings.TrimSpace(string(output)))
+		}
+		cancel()
+	}
+
+	if e.cfg.NotifyURL != "" {
+		body, err := json.Marshal(event)
+		if err != nil {
+			budgetLog.Warn("Failed to encode budget notification: %v", err)
+			return
+		}
+		resp, err := e.client.Post(e.cfg.NotifyURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			budgetLog.Warn("Budget notify_url failed: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			budgetLog.Warn("Budget notify_url returned %s", resp.Status)
+		}
+	}
+}
+
+// agentNames returns the configured agent names, sorted
+func (e *Enforcer) agentNames() []string {
+	names := make([]string, 0, len(e.agents))
+	for name := range e.agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scopeLabel names a scope in messages
+func scopeLabel(scope string) string {
+	if scope == ProjectScope {
+		return "Project"
+	}
+	return fmt.Sprintf("Agent %s", scope)
+}
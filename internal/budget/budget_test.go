@@ -0,0 +1,235 @@
+package budget
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/usage"
+)
+
+// mockController records stopped agents
+type mockController struct {
+	stopped []string
+}
+
+func (m *mockController) StopAgent(name string) error {
+	m.stopped = append(m.stopped, name)
+	return nil
+}
+
+func testConfig() config.Config {
+	return config.Config{
+		Budget: config.BudgetConfig{
+			ProjectUSD: 10,
+			Period:     "monthly",
+			WarnAt:     []float64{0.5, 0.8},
+		},
+		Agents: map[string]config.AgentConfig{
+			"coder":  {BudgetUSD: 2},
+			"tester": {},
+		},
+	}
+}
+
+func spend(t *testing.T, dir, agent string, cost float64) {
+	t.Helper()
+	record := usage.Record{Timestamp: time.Now(), Agent: agent, CostUSD: cost}
+	if err := usage.Append(filepath.Join(dir, agent+".jsonl"), record); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+}
+
+func drain(e *Enforcer) []EventType {
+	var types []EventType
+	for {
+		select {
+		case event := <-e.events:
+			types = append(types, event.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestCheckWarnsAndPauses(t *testing.T) {
+	dir := t.TempDir()
+	control := &mockController{}
+	e := NewEnforcerWithPaths(testConfig(), control, dir, filepath.Join(dir, "budget.json"))
+
+	// 60% of the coder budget warns once at the 50% threshold
+	spend(t, dir, "coder", 1.2)
+	if err := e.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if events := drain(e); len(events) != 1 || events[0] != EventWarning {
+		t.Errorf("Events = %v, want one warning", events)
+	}
+	if err := e.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if events := drain(e); len(events) != 0 {
+		t.Errorf("Expected no repeated warning, got %v", events)
+	}
+
+	// Reaching the coder budget pauses only coder
+	spend(t, dir, "coder", 1.0)
+	if err := e.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !e.IsPaused("coder") || e.IsPaused("tester") {
+		t.Error("Expected coder paused and tester running")
+	}
+	if len(control.stopped) != 1 || control.stopped[0] != "coder" {
+		t.Errorf("Stopped = %v, want [coder]", control.stopped)
+	}
+	events := drain(e)
+	if len(events) != 2 || events[0] != EventExceeded || events[1] != EventPaused {
+		t.Errorf("Events = %v, want exceeded and paused", events)
+	}
+
+	// Reaching the project budget pauses everyone else
+	spend(t, dir, "tester", 8)
+	if err := e.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !e.IsPaused("tester") {
+		t.Error("Expected tester paused by the project budget")
+	}
+	if len(control.stopped) != 2 {
+		t.Errorf("Expected coder not to be stopped twice, got %v", control.stopped)
+	}
+}
+
+func TestResume(t *testing.T) {
+	dir := t.TempDir()
+	e := NewEnforcerWithPaths(testConfig(), nil, dir, filepath.Join(dir, "budget.json"))
+
+	if err := e.Resume("tester", false); err == nil {
+		t.Error("Resume() of an agent that is not paused should fail")
+	}
+	if err := e.Resume("unknown", true); err == nil {
+		t.Error("Resume() of an unknown agent should fail")
+	}
+
+	spend(t, dir, "coder", 3)
+	if err := e.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if err := e.Resume("coder", false); err == nil {
+		t.Error("Resume() without override should fail while over budget")
+	}
+	if err := e.CheckResume("coder", false); err == nil {
+		t.Error("CheckResume() without override should fail while over budget")
+	}
+	if err := e.CheckResume("coder", true); err != nil || !e.IsPaused("coder") {
+		t.Errorf("CheckResume() with override = %v, and should leave the agent paused", err)
+	}
+	if err := e.Resume("coder", true); err != nil {
+		t.Fatalf("Resume() with override error = %v", err)
+	}
+
+	// The override holds for the rest of the period
+	if err := e.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if e.IsPaused("coder") {
+		t.Error("Expected an overridden agent not to be paused again")
+	}
+	report, err := e.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !report.Overrides["coder"] || report.TotalUSD != 3 || len(report.Limits) != 2 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+func TestSetEnforceOff(t *testing.T) {
+	dir := t.TempDir()
+	control := &mockController{}
+	e := NewEnforcerWithPaths(testConfig(), control, dir, filepath.Join(dir, "budget.json"))
+	e.SetEnforce(false)
+
+	spend(t, dir, "coder", 5)
+	if err := e.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if e.IsPaused("coder") || len(control.stopped) != 0 {
+		t.Error("Expected no pause with enforcement off")
+	}
+	// The project budget is at 50% and the coder budget is exceeded
+	events := drain(e)
+	if len(events) != 2 || events[0] != EventWarning || events[1] != EventExceeded {
+		t.Errorf("Events = %v, want warning and exceeded", events)
+	}
+}
+
+func TestNewPeriodClearsPauses(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "budget.json")
+	stale := State{
+		PeriodStart: time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local),
+		Paused:      map[string]Pause{"coder": {Scope: "coder"}},
+	}
+	if err := SaveState(statePath, stale); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	e := NewEnforcerWithPaths(testConfig(), nil, dir, statePath)
+	if err := e.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if e.IsPaused("coder") {
+		t.Error("Expected pauses from an earlier period to be cleared")
+	}
+}
+
+func TestNotifyURL(t *testing.T) {
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cfg := testConfig()
+	cfg.Budget.NotifyURL = server.URL
+	e := NewEnforcerWithPaths(cfg, nil, dir, filepath.Join(dir, "budget.json"))
+
+	spend(t, dir, "tester", 6)
+	if err := e.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	select {
+	case event := <-received:
+		if event.Type != EventWarning || event.Scope != ProjectScope || event.SpentUSD != 6 {
+			t.Errorf("Unexpected notification: %+v", event)
+		}
+	default:
+		t.Error("Expected a notification to be posted")
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	now := time.Date(2024, 3, 15, 13, 30, 0, 0, time.UTC)
+	tests := []struct {
+		period string
+		want   time.Time
+	}{
+		{"daily", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"monthly", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"total", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := PeriodStart(tt.period, now); !got.Equal(tt.want) {
+			t.Errorf("PeriodStart(%q) = %v, want %v", tt.period, got, tt.want)
+		}
+	}
+}
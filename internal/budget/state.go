@@ -0,0 +1,72 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// State is the enforcement state for the current budget period, saved so
+// pauses and overrides survive a restart of the stack
+type State struct {
+	PeriodStart time.Time          `json:"period_start"`        // Start of the period the state applies to
+	Warned      map[string]float64 `json:"warned,omitempty"`    // Highest warn_at fraction reported, per scope
+	Paused      map[string]Pause   `json:"paused,omitempty"`    // Paused agents
+	Overrides   map[string]bool    `json:"overrides,omitempty"` // Agents allowed to run over budget until the period ends
+}
+
+// Pause records why an agent was paused
+type Pause struct {
+	Scope    string    `json:"scope"` // ProjectScope or the agent's own name
+	SpentUSD float64   `json:"spent_usd"`
+	LimitUSD float64   `json:"limit_usd"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+// DefaultStatePath returns ~/.asc/budget.json
+func DefaultStatePath() (string, error) {
+	return statedir.Path("budget.json")
+}
+
+// LoadState reads the budget state from path. A missing file is not an
+// error and yields the zero State.
+func LoadState(path string) (State, error) {
+	var state State
+	err := statefile.ReadJSON(path, &state)
+	if os.IsNotExist(err) {
+		return State{}, nil
+	}
+	if errors.Is(err, statefile.ErrCorrupt) {
+		return State{}, fmt.Errorf("%v\n  Suggestion: Pauses and overrides were cleared; spend is recounted from the usage logs", err)
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("failed to read budget state: %w", err)
+	}
+	return state, nil
+}
+
+// SaveState writes the budget state to path, replacing it atomically
+func SaveState(path string, state State) error {
+	if err := statefile.WriteJSON(path, state, 0600); err != nil {
+		return fmt.Errorf("failed to write budget state: %w", err)
+	}
+	return nil
+}
+
+// PeriodStart returns the start of the budget period containing now:
+// local midnight for "daily", the first of the month for "monthly", and
+// the zero time for "total" (spend is never reset)
+func PeriodStart(period string, now time.Time) time.Time {
+	switch period {
+	case "daily":
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	case "total":
+		return time.Time{}
+	default:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+}
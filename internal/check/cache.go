@@ -0,0 +1,126 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/statedir"
+)
+
+// DefaultCacheTTL is how long cached check results stay valid. It is short
+// so that a rapid up/down/test loop reuses results while a tool upgraded a
+// few minutes ago is re-probed.
+const DefaultCacheTTL = 5 * time.Minute
+
+// cachedResult is a check result with the time it was stored
+type cachedResult struct {
+	Result   CheckResult `json:"result"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// Cache keeps the results of expensive checks, such as running a binary to
+// read its version, in a JSON file so later asc invocations can reuse them.
+// A nil *Cache is valid and caches nothing.
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	ttl     time.Duration
+	entries map[string]cachedResult
+	dirty   bool
+}
+
+// DefaultCachePath returns ~/.asc/cache/checks.json
+func DefaultCachePath() (string, error) {
+	return statedir.Path("cache", "checks.json")
+}
+
+// NewCache loads the cache stored at path, dropping expired entries. A
+// missing or unreadable file yields an empty cache.
+func NewCache(path string, ttl time.Duration) *Cache {
+	c := &Cache{
+		path:    path,
+		ttl:     ttl,
+		entries: make(map[string]cachedResult),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var stored map[string]cachedResult
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return c
+	}
+	for key, entry := range stored {
+		if time.Since(entry.StoredAt) < ttl {
+			c.entries[key] = entry
+		}
+	}
+	return c
+}
+
+// Get returns the cached result for key if it has not expired
+func (c *Cache) Get(key string) (CheckResult, bool) {
+	if c == nil || key == "" {
+		return CheckResult{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.StoredAt) >= c.ttl {
+		return CheckResult{}, false
+	}
+	return entry.Result, true
+}
+
+// Put stores a result under key
+func (c *Cache) Put(key string, result CheckResult) {
+	if c == nil || key == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cachedResult{Result: result, StoredAt: time.Now()}
+	c.dirty = true
+}
+
+// Save writes the cache to disk if it changed since it was loaded
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode check cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write check cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// versionCacheKey identifies a version check by the binary's resolved path,
+// size, and modification time, so that upgrading or replacing the binary
+// invalidates the cached result. Returns "" if the binary cannot be found.
+func versionCacheKey(path, constraint string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("version:%s:%d:%d:%s:%s", path, info.Size(), info.ModTime().UnixNano(), constraint, i18n.Language())
+}
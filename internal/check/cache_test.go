@@ -0,0 +1,89 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "checks.json")
+	cache := NewCache(path, time.Minute)
+
+	if _, ok := cache.Get("git"); ok {
+		t.Fatal("Expected an empty cache")
+	}
+
+	want := CheckResult{Name: "git", Status: CheckPass, Message: "Binary 'git' version 2.43.0 satisfies >=2"}
+	cache.Put("git", want)
+	if got, ok := cache.Get("git"); !ok || got != want {
+		t.Errorf("Get() = %+v, %v, want %+v", got, ok, want)
+	}
+
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected cache file at %s: %v", path, err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Cache file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	if got, ok := NewCache(path, time.Minute).Get("git"); !ok || got != want {
+		t.Errorf("Reloaded Get() = %+v, %v, want %+v", got, ok, want)
+	}
+
+	// Entries older than the TTL are dropped
+	if _, ok := NewCache(path, time.Nanosecond).Get("git"); ok {
+		t.Error("Expected expired entry to be ignored")
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var cache *Cache
+	cache.Put("git", CheckResult{Name: "git"})
+	if _, ok := cache.Get("git"); ok {
+		t.Error("A nil cache should never return results")
+	}
+	if err := cache.Save(); err != nil {
+		t.Errorf("Save() on a nil cache error = %v", err)
+	}
+}
+
+func TestCheckVersionUsesCache(t *testing.T) {
+	fakeBinary(t, "asc-fake-tool", "asc-fake-tool version 1.4.2")
+	cache := NewCache(filepath.Join(t.TempDir(), "checks.json"), time.Minute)
+	checker := &DefaultChecker{cache: cache}
+
+	first := checker.CheckVersion("asc-fake-tool", ">=1.4")
+	if first.Status != CheckPass {
+		t.Fatalf("CheckVersion() status = %s, want pass (%s)", first.Status, first.Message)
+	}
+
+	// Break the binary in place, keeping its size and modification time,
+	// so only a cached result can still pass
+	path, err := filepath.Abs(filepath.Join(filepath.SplitList(os.Getenv("PATH"))[0], "asc-fake-tool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho 'asc-fake-tool version 0.0.1'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := checker.CheckVersion("asc-fake-tool", ">=1.4"); got != first {
+		t.Errorf("Expected cached result %+v, got %+v", first, got)
+	}
+	if got := (&DefaultChecker{}).CheckVersion("asc-fake-tool", ">=1.4"); got.Status != CheckFail {
+		t.Errorf("Without a cache the binary should be probed again, got %+v", got)
+	}
+}
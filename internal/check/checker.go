@@ -17,8 +17,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/secrets"
 	"github.com/spf13/viper"
 )
 
@@ -37,6 +43,7 @@ type CheckResult struct {
 	Name    string
 	Status  CheckStatus
 	Message string
+	Hint    string // Optional remediation shown when the check does not pass
 }
 
 // Checker defines the interface for dependency checking
@@ -45,6 +52,9 @@ type Checker interface {
 	CheckFile(path string) CheckResult
 	CheckConfig() CheckResult
 	CheckEnv(keys []string) CheckResult
+	CheckVersion(name, constraint string) CheckResult
+	CheckCustom(check CustomCheck) CheckResult
+	CheckProxy() CheckResult
 	RunAll() []CheckResult
 }
 
@@ -53,6 +63,7 @@ type Checker interface {
 type DefaultChecker struct {
 	configPath string // Path to asc.toml configuration file
 	envPath    string // Path to .env environment file
+	cache      *Cache // Optional cache for expensive checks; nil disables caching
 }
 
 // NewChecker creates a new DefaultChecker instance with the specified
@@ -68,6 +79,22 @@ func NewChecker(configPath, envPath string) Checker {
 	}
 }
 
+// NewCheckerWithCache creates a DefaultChecker that reuses unexpired
+// results of expensive checks (binary version probes) from cache and
+// saves new ones to it after RunAll.
+//
+// Example:
+//
+//	cachePath, _ := check.DefaultCachePath()
+//	checker := check.NewCheckerWithCache("asc.toml", ".env", check.NewCache(cachePath, check.DefaultCacheTTL))
+func NewCheckerWithCache(configPath, envPath string, cache *Cache) Checker {
+	return &DefaultChecker{
+		configPath: configPath,
+		envPath:    envPath,
+		cache:      cache,
+	}
+}
+
 // CheckBinary checks if a binary exists in the system PATH.
 // Returns CheckPass if found, CheckFail otherwise.
 func (c *DefaultChecker) CheckBinary(name string) CheckResult {
@@ -76,13 +103,13 @@ func (c *DefaultChecker) CheckBinary(name string) CheckResult {
 		return CheckResult{
 			Name:    name,
 			Status:  CheckFail,
-			Message: fmt.Sprintf("Binary '%s' not found in PATH", name),
+			Message: i18n.T("check.binary.missing", name),
 		}
 	}
 	return CheckResult{
 		Name:    name,
 		Status:  CheckPass,
-		Message: fmt.Sprintf("Binary '%s' found", name),
+		Message: i18n.T("check.binary.found", name),
 	}
 }
 
@@ -103,13 +130,13 @@ func (c *DefaultChecker) CheckFile(path string) CheckResult {
 			return CheckResult{
 				Name:    filepath.Base(path),
 				Status:  CheckFail,
-				Message: fmt.Sprintf("File '%s' does not exist", path),
+				Message: i18n.T("check.file.missing", path),
 			}
 		}
 		return CheckResult{
 			Name:    filepath.Base(path),
 			Status:  CheckFail,
-			Message: fmt.Sprintf("Cannot access file '%s': %v", path, err),
+			Message: i18n.T("check.file.inaccessible", path, err),
 		}
 	}
 
@@ -117,7 +144,7 @@ func (c *DefaultChecker) CheckFile(path string) CheckResult {
 		return CheckResult{
 			Name:    filepath.Base(path),
 			Status:  CheckFail,
-			Message: fmt.Sprintf("'%s' is a directory, not a file", path),
+			Message: i18n.T("check.file.directory", path),
 		}
 	}
 
@@ -127,7 +154,7 @@ func (c *DefaultChecker) CheckFile(path string) CheckResult {
 		return CheckResult{
 			Name:    filepath.Base(path),
 			Status:  CheckFail,
-			Message: fmt.Sprintf("File '%s' is not readable: %v", path, err),
+			Message: i18n.T("check.file.unreadable", path, err),
 		}
 	}
 	file.Close()
@@ -135,7 +162,7 @@ func (c *DefaultChecker) CheckFile(path string) CheckResult {
 	return CheckResult{
 		Name:    filepath.Base(path),
 		Status:  CheckPass,
-		Message: fmt.Sprintf("File '%s' exists and is readable", path),
+		Message: i18n.T("check.file.ok", path),
 	}
 }
 
@@ -148,7 +175,7 @@ func (c *DefaultChecker) CheckConfig() CheckResult {
 		return CheckResult{
 			Name:    "asc.toml",
 			Status:  CheckFail,
-			Message: fmt.Sprintf("Config file not found at '%s'", c.configPath),
+			Message: i18n.T("check.config.missing", c.configPath),
 		}
 	}
 
@@ -161,7 +188,7 @@ func (c *DefaultChecker) CheckConfig() CheckResult {
 		return CheckResult{
 			Name:    "asc.toml",
 			Status:  CheckFail,
-			Message: fmt.Sprintf("Invalid TOML syntax: %v", err),
+			Message: i18n.T("check.config.invalid_toml", err),
 		}
 	}
 
@@ -170,7 +197,7 @@ func (c *DefaultChecker) CheckConfig() CheckResult {
 		return CheckResult{
 			Name:    "asc.toml",
 			Status:  CheckFail,
-			Message: "Missing required field: core.beads_db_path",
+			Message: i18n.T("check.config.no_beads_db_path"),
 		}
 	}
 
@@ -178,14 +205,14 @@ func (c *DefaultChecker) CheckConfig() CheckResult {
 		return CheckResult{
 			Name:    "asc.toml",
 			Status:  CheckWarn,
-			Message: "Missing services.mcp_agent_mail configuration",
+			Message: i18n.T("check.config.no_mcp_agent_mail"),
 		}
 	}
 
 	return CheckResult{
 		Name:    "asc.toml",
 		Status:  CheckPass,
-		Message: "Configuration file is valid",
+		Message: i18n.T("check.config.ok"),
 	}
 }
 
@@ -195,11 +222,15 @@ func (c *DefaultChecker) CheckConfig() CheckResult {
 func (c *DefaultChecker) CheckEnv(keys []string) CheckResult {
 	// First check if .env file exists
 	fileResult := c.CheckFile(c.envPath)
+	if fileResult.Status == CheckFail && os.Getenv(secrets.InjectedEnvVar) != "" {
+		// asc secrets inject passed the keys in the environment instead
+		return checkEnvVars(keys, i18n.T("check.env.injected", os.Getenv(secrets.InjectedEnvVar)))
+	}
 	if fileResult.Status == CheckFail {
 		return CheckResult{
 			Name:    ".env",
 			Status:  CheckFail,
-			Message: fmt.Sprintf(".env file not found at '%s'", c.envPath),
+			Message: i18n.T("check.env.missing", c.envPath),
 		}
 	}
 
@@ -209,7 +240,7 @@ func (c *DefaultChecker) CheckEnv(keys []string) CheckResult {
 		return CheckResult{
 			Name:    ".env",
 			Status:  CheckFail,
-			Message: fmt.Sprintf("Cannot read .env file: %v", err),
+			Message: i18n.T("check.env.unreadable", err),
 		}
 	}
 
@@ -240,54 +271,259 @@ func (c *DefaultChecker) CheckEnv(keys []string) CheckResult {
 		return CheckResult{
 			Name:    ".env",
 			Status:  CheckWarn,
-			Message: fmt.Sprintf("Missing API keys: %v", missingKeys),
+			Message: i18n.T("check.env.missing_keys", missingKeys),
 		}
 	}
 
 	return CheckResult{
 		Name:    ".env",
 		Status:  CheckPass,
-		Message: "All required API keys present",
+		Message: i18n.T("check.env.ok"),
 	}
 }
 
-// RunAll runs all dependency checks including binaries, configuration,
-// and environment variables. Returns a slice of CheckResult for each check.
+// checkEnvVars checks that keys are set in the environment, which source
+// describes
+func checkEnvVars(keys []string, source string) CheckResult {
+	missingKeys := []string{}
+	for _, key := range keys {
+		if os.Getenv(key) == "" {
+			missingKeys = append(missingKeys, key)
+		}
+	}
+	if len(missingKeys) > 0 {
+		return CheckResult{
+			Name:    ".env",
+			Status:  CheckWarn,
+			Message: i18n.T("check.env.missing_keys", missingKeys),
+		}
+	}
+	return CheckResult{
+		Name:    ".env",
+		Status:  CheckPass,
+		Message: i18n.T("check.env.ok_from", source),
+	}
+}
+
+// checkEnvWithRefs checks the .env file like CheckEnv. Without one, keys
+// referenced in [secrets.env], which asc up fetches from secret providers
+// at startup, count as present, and the others must be in the environment.
+func (c *DefaultChecker) checkEnvWithRefs(keys, referenced []string) CheckResult {
+	if len(referenced) == 0 || c.CheckFile(c.envPath).Status != CheckFail {
+		return c.CheckEnv(keys)
+	}
+	unreferenced := []string{}
+	for _, key := range keys {
+		if !containsString(referenced, key) {
+			unreferenced = append(unreferenced, key)
+		}
+	}
+	return checkEnvVars(unreferenced, i18n.T("check.env.resolved"))
+}
+
+// checkTimeout bounds how long a single check may run before RunAll
+// reports it as timed out. A variable so tests can shorten it.
+var checkTimeout = 10 * time.Second
+
+// checkSpec is a named check that RunAll executes. Optional checks that
+// time out are reported as warnings instead of failures.
+type checkSpec struct {
+	name     string
+	optional bool
+	run      func() CheckResult
+}
+
+// RunAll runs all dependency checks including binaries (with the version
+// constraints from [requirements]), configuration, environment variables,
+// proxy settings, and user-defined [check.custom.*] commands. Independent checks run concurrently, each bounded by a
+// timeout, and the results are returned in a fixed order regardless of
+// which check finishes first.
 func (c *DefaultChecker) RunAll() []CheckResult {
-	results := []CheckResult{}
+	checks := []checkSpec{}
+	requirements, customChecks, secretRefs := c.loadSettings()
 
 	// Check required binaries
 	binaries := []string{"git", "python3", "uv", "bd"}
 	for _, binary := range binaries {
-		results = append(results, c.CheckBinary(binary))
+		checks = append(checks, checkSpec{
+			name: binary,
+			run:  func() CheckResult { return c.checkRequirement(binary, requirements) },
+		})
 	}
 
 	// Check optional binaries
-	dockerResult := c.CheckBinary("docker")
-	if dockerResult.Status == CheckFail {
-		dockerResult.Status = CheckWarn
-		dockerResult.Message = "Docker not found (optional)"
+	checks = append(checks, checkSpec{
+		name:     "docker",
+		optional: true,
+		run: func() CheckResult {
+			return optionalResult(c.checkRequirement("docker", requirements), "Docker not found (optional)")
+		},
+	})
+
+	// Check any other binaries listed under [requirements]
+	extra := []string{}
+	for name := range requirements {
+		if !containsString(binaries, name) && name != "docker" {
+			extra = append(extra, name)
+		}
 	}
-	results = append(results, dockerResult)
-
-	// Check age for secrets management
-	ageResult := c.CheckBinary("age")
-	if ageResult.Status == CheckFail {
-		ageResult.Status = CheckWarn
-		ageResult.Message = "age not found (recommended for secrets management)"
+	sort.Strings(extra)
+	for _, binary := range extra {
+		checks = append(checks, checkSpec{
+			name: binary,
+			run:  func() CheckResult { return c.checkRequirement(binary, requirements) },
+		})
 	}
-	results = append(results, ageResult)
 
 	// Check configuration file
-	results = append(results, c.CheckConfig())
+	checks = append(checks, checkSpec{name: "asc.toml", run: c.CheckConfig})
 
 	// Check environment file
 	requiredKeys := []string{"CLAUDE_API_KEY", "OPENAI_API_KEY", "GOOGLE_API_KEY"}
-	results = append(results, c.CheckEnv(requiredKeys))
+	checks = append(checks, checkSpec{
+		name: ".env",
+		run:  func() CheckResult { return c.checkEnvWithRefs(requiredKeys, secretRefs) },
+	})
+
+	// Report the proxy configuration used for network connections
+	checks = append(checks, checkSpec{name: "proxy", run: c.CheckProxy})
+
+	// Check user-defined [check.custom.*] entries
+	for _, custom := range customChecks {
+		checks = append(checks, checkSpec{
+			name:     custom.Name,
+			optional: custom.optional(),
+			run:      func() CheckResult { return c.CheckCustom(custom) },
+		})
+	}
+
+	results := runChecks(checks, checkTimeout)
+
+	// A cache that cannot be written only costs a re-probe next time
+	_ = c.cache.Save()
 
 	return results
 }
 
+// loadSettings reads the [requirements] table, mapping binary names to
+// version constraints, the [check.custom.*] sections, and the names of the
+// variables in [secrets.env] of the configuration file. Custom checks are
+// sorted by name. A missing or invalid configuration yields no settings;
+// CheckConfig reports those problems.
+func (c *DefaultChecker) loadSettings() (map[string]string, []CustomCheck, []string) {
+	v := viper.New()
+	v.SetConfigFile(c.configPath)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, nil
+	}
+
+	requirements := v.GetStringMapString("requirements")
+
+	secretRefs := []string{}
+	for name := range v.GetStringMapString("secrets.env") {
+		secretRefs = append(secretRefs, strings.ToUpper(name))
+	}
+
+	var custom map[string]CustomCheck
+	if err := v.UnmarshalKey("check.custom", &custom); err != nil {
+		return requirements, nil, secretRefs
+	}
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	customChecks := make([]CustomCheck, 0, len(names))
+	for _, name := range names {
+		cc := custom[name]
+		cc.Name = name
+		customChecks = append(customChecks, cc)
+	}
+	return requirements, customChecks, secretRefs
+}
+
+// checkRequirement checks a binary, verifying its version when a
+// constraint for it is configured
+func (c *DefaultChecker) checkRequirement(name string, requirements map[string]string) CheckResult {
+	if constraint, ok := requirements[name]; ok {
+		return c.CheckVersion(name, constraint)
+	}
+	return c.CheckBinary(name)
+}
+
+// optionalResult downgrades a failed check of an optional binary to a
+// warning, using missingMessage when the binary is not installed at all
+func optionalResult(result CheckResult, missingMessage string) CheckResult {
+	if result.Status != CheckFail {
+		return result
+	}
+	result.Status = CheckWarn
+	if _, err := exec.LookPath(result.Name); err != nil {
+		result.Message = missingMessage
+	} else {
+		result.Message += " (optional)"
+	}
+	return result
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// runChecks executes checks concurrently and returns their results in the
+// order the checks were given. A check that does not finish within timeout
+// is reported as failed (or as a warning if optional); its goroutine is left
+// to finish in the background.
+func runChecks(checks []checkSpec, timeout time.Duration) []CheckResult {
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, spec := range checks {
+		wg.Add(1)
+		go func(i int, spec checkSpec) {
+			defer wg.Done()
+			results[i] = runCheck(spec, timeout)
+		}(i, spec)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// runCheck runs a single check, giving up after timeout
+func runCheck(spec checkSpec, timeout time.Duration) CheckResult {
+	done := make(chan CheckResult, 1) // Buffered so a late check does not block forever
+	go func() {
+		done <- spec.run()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		return result
+	case <-timer.C:
+		status := CheckFail
+		if spec.optional {
+			status = CheckWarn
+		}
+		return CheckResult{
+			Name:    spec.name,
+			Status:  status,
+			Message: i18n.T("check.timed_out", timeout),
+		}
+	}
+}
+
 // FormatResults formats check results as a styled table using lipgloss.
 // It color-codes results (green for pass, red for fail, yellow for warn)
 // and returns a formatted string suitable for terminal output.
@@ -300,14 +536,14 @@ func FormatResults(results []CheckResult) string {
 	
 	// Build table
 	var output string
-	output += headerStyle.Render("Dependency Checks") + "\n\n"
+	output += headerStyle.Render(i18n.T("check.title")) + "\n\n"
 	
 	// Column widths
 	nameWidth := 20
 	statusWidth := 10
 	
 	// Header row
-	output += fmt.Sprintf("%-*s %-*s %s\n", nameWidth, "Component", statusWidth, "Status", "Message")
+	output += fmt.Sprintf("%-*s %-*s %s\n", nameWidth, i18n.T("check.column.component"), statusWidth, i18n.T("check.column.status"), i18n.T("check.column.message"))
 	output += lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(
 		fmt.Sprintf("%s %s %s\n", 
 			lipgloss.NewStyle().Width(nameWidth).Render("─────────────────────"),
@@ -320,17 +556,20 @@ func FormatResults(results []CheckResult) string {
 		var statusStr string
 		switch result.Status {
 		case CheckPass:
-			statusStr = passStyle.Render("✓ PASS")
+			statusStr = passStyle.Render(i18n.T("check.status.pass"))
 		case CheckFail:
-			statusStr = failStyle.Render("✗ FAIL")
+			statusStr = failStyle.Render(i18n.T("check.status.fail"))
 		case CheckWarn:
-			statusStr = warnStyle.Render("⚠ WARN")
+			statusStr = warnStyle.Render(i18n.T("check.status.warn"))
 		}
 		
 		output += fmt.Sprintf("%-*s %-*s %s\n", 
 			nameWidth, result.Name, 
 			statusWidth+10, statusStr, // +10 for ANSI color codes
 			result.Message)
+		if result.Hint != "" && result.Status != CheckPass {
+			output += fmt.Sprintf("%-*s %-*s → %s\n", nameWidth, "", statusWidth, "", result.Hint)
+		}
 	}
 	
 	return output
@@ -1,10 +1,14 @@
 package check
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/secrets"
 )
 
 func TestCheckBinary(t *testing.T) {
@@ -192,6 +196,31 @@ func TestCheckEnvMissingFile(t *testing.T) {
 	}
 }
 
+func TestCheckEnvWithRefs(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	checker := &DefaultChecker{envPath: "/nonexistent/.env"}
+
+	if result := checker.checkEnvWithRefs([]string{"CLAUDE_API_KEY", "OPENAI_API_KEY"}, []string{"CLAUDE_API_KEY"}); result.Status != CheckPass {
+		t.Errorf("Expected referenced and exported keys to pass without a .env file, got %v: %s", result.Status, result.Message)
+	}
+	if result := checker.checkEnvWithRefs([]string{"CLAUDE_API_KEY"}, nil); result.Status != CheckFail {
+		t.Errorf("Expected a missing .env file to fail without references, got %v", result.Status)
+	}
+}
+
+func TestCheckEnvInjected(t *testing.T) {
+	t.Setenv(secrets.InjectedEnvVar, ".env.age")
+	t.Setenv("CLAUDE_API_KEY", "sk-test")
+
+	checker := NewChecker("", "/nonexistent/.env")
+	if result := checker.CheckEnv([]string{"CLAUDE_API_KEY"}); result.Status != CheckPass {
+		t.Errorf("CheckEnv() with injected keys should pass, got %v: %s", result.Status, result.Message)
+	}
+	if result := checker.CheckEnv([]string{"CLAUDE_API_KEY", "ASC_TEST_MISSING_KEY"}); result.Status != CheckWarn {
+		t.Errorf("CheckEnv() with a key missing from the environment should warn, got %v", result.Status)
+	}
+}
+
 func TestRunAll(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -418,3 +447,59 @@ func TestCheckResult(t *testing.T) {
 		t.Errorf("Message = %v, want Test message", result.Message)
 	}
 }
+
+func TestRunChecksConcurrentAndOrdered(t *testing.T) {
+	delays := []time.Duration{60 * time.Millisecond, 10 * time.Millisecond, 40 * time.Millisecond}
+	checks := make([]checkSpec, len(delays))
+	for i, delay := range delays {
+		name := fmt.Sprintf("check-%d", i)
+		checks[i] = checkSpec{
+			name: name,
+			run: func() CheckResult {
+				time.Sleep(delay)
+				return CheckResult{Name: name, Status: CheckPass}
+			},
+		}
+	}
+
+	start := time.Now()
+	results := runChecks(checks, time.Second)
+	elapsed := time.Since(start)
+
+	// Sequential execution would take the sum of the delays (110ms)
+	if elapsed >= 100*time.Millisecond {
+		t.Errorf("runChecks took %v, expected checks to run concurrently", elapsed)
+	}
+	for i, result := range results {
+		if want := fmt.Sprintf("check-%d", i); result.Name != want {
+			t.Errorf("results[%d].Name = %s, want %s", i, result.Name, want)
+		}
+	}
+}
+
+func TestRunChecksTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	slow := func() CheckResult {
+		<-block
+		return CheckResult{Status: CheckPass}
+	}
+	checks := []checkSpec{
+		{name: "required", run: slow},
+		{name: "optional", optional: true, run: slow},
+		{name: "fast", run: func() CheckResult { return CheckResult{Name: "fast", Status: CheckPass} }},
+	}
+
+	results := runChecks(checks, 20*time.Millisecond)
+
+	if results[0].Status != CheckFail || !strings.Contains(results[0].Message, "timed out") {
+		t.Errorf("Expected required check to fail with timeout, got %+v", results[0])
+	}
+	if results[1].Status != CheckWarn {
+		t.Errorf("Expected optional check to warn on timeout, got %+v", results[1])
+	}
+	if results[2].Status != CheckPass {
+		t.Errorf("Expected fast check to pass, got %+v", results[2])
+	}
+}
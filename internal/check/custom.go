@@ -0,0 +1,83 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/rand/asc/internal/i18n"
+)
+
+// CustomCheck is a user-defined check from a [check.custom.<name>] section
+// of asc.toml. The command is run through the shell and passes when it
+// exits with ExitCode.
+type CustomCheck struct {
+	Name     string `mapstructure:"-"`
+	Command  string `mapstructure:"command"`
+	ExitCode int    `mapstructure:"exit_code"`
+	Severity string `mapstructure:"severity"` // "error" (default) or "warn"
+	Hint     string `mapstructure:"hint"`
+}
+
+// optional reports whether a failure of the check is only a warning
+func (cc CustomCheck) optional() bool {
+	severity := strings.ToLower(cc.Severity)
+	return severity == "warn" || severity == "warning"
+}
+
+// CheckCustom runs a user-defined check. The command is killed if it runs
+// longer than the per-check timeout. A failing check reports CheckFail, or
+// CheckWarn when its severity is "warn", and carries the configured hint.
+func (c *DefaultChecker) CheckCustom(cc CustomCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "sh", "-c", cc.Command).CombinedOutput()
+
+	exitCode := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || ctx.Err() != nil {
+			return c.customFailure(cc, i18n.T("check.custom.not_run", cc.Command, err))
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	if exitCode != cc.ExitCode {
+		message := i18n.T("check.custom.exit_code", cc.Command, exitCode, cc.ExitCode)
+		if out := strings.TrimSpace(string(output)); out != "" {
+			message += fmt.Sprintf(": %s", firstLine(out))
+		}
+		return c.customFailure(cc, message)
+	}
+
+	return CheckResult{
+		Name:    cc.Name,
+		Status:  CheckPass,
+		Message: i18n.T("check.custom.ok", cc.Command),
+	}
+}
+
+// customFailure builds the result of a custom check that did not pass
+func (c *DefaultChecker) customFailure(cc CustomCheck, message string) CheckResult {
+	status := CheckFail
+	if cc.optional() {
+		status = CheckWarn
+	}
+	return CheckResult{
+		Name:    cc.Name,
+		Status:  status,
+		Message: message,
+		Hint:    cc.Hint,
+	}
+}
+
+// firstLine returns s up to its first newline
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
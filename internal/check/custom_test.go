@@ -0,0 +1,85 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckCustom(t *testing.T) {
+	checker := &DefaultChecker{}
+
+	tests := []struct {
+		name       string
+		check      CustomCheck
+		wantStatus CheckStatus
+		wantHint   bool
+	}{
+		{"success", CustomCheck{Name: "ok", Command: "true"}, CheckPass, false},
+		{"expected non-zero exit", CustomCheck{Name: "absent", Command: "exit 3", ExitCode: 3}, CheckPass, false},
+		{"failure", CustomCheck{Name: "vpn", Command: "exit 1", Hint: "Connect to the VPN"}, CheckFail, true},
+		{"warn severity", CustomCheck{Name: "license", Command: "exit 1", Severity: "warn", Hint: "Start the license server"}, CheckWarn, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checker.CheckCustom(tt.check)
+			if result.Name != tt.check.Name {
+				t.Errorf("Name = %s, want %s", result.Name, tt.check.Name)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s (%s)", result.Status, tt.wantStatus, result.Message)
+			}
+			if (result.Hint != "") != tt.wantHint {
+				t.Errorf("Hint = %q, wantHint %v", result.Hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestCheckCustomReportsOutput(t *testing.T) {
+	checker := &DefaultChecker{}
+	result := checker.CheckCustom(CustomCheck{Name: "probe", Command: "echo 'connection refused'; exit 2"})
+
+	if !strings.Contains(result.Message, "exited with 2") || !strings.Contains(result.Message, "connection refused") {
+		t.Errorf("Expected exit code and output in message, got %q", result.Message)
+	}
+}
+
+func TestRunAllCustomChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "asc.toml")
+	configContent := `[core]
+beads_db_path = "./test-repo"
+
+[check.custom.vpn]
+command = "exit 1"
+hint = "Connect to the corporate VPN"
+
+[check.custom.alpha]
+command = "true"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	results := NewChecker(configPath, filepath.Join(tmpDir, ".env")).RunAll()
+
+	// Custom checks come last, sorted by name
+	if len(results) < 2 {
+		t.Fatalf("Expected custom check results, got %d results", len(results))
+	}
+	alpha, vpn := results[len(results)-2], results[len(results)-1]
+	if alpha.Name != "alpha" || alpha.Status != CheckPass {
+		t.Errorf("Expected passing alpha check, got %+v", alpha)
+	}
+	if vpn.Name != "vpn" || vpn.Status != CheckFail || vpn.Hint != "Connect to the corporate VPN" {
+		t.Errorf("Expected failing vpn check with hint, got %+v", vpn)
+	}
+
+	output := FormatResults(results)
+	if !strings.Contains(output, "Connect to the corporate VPN") {
+		t.Error("Expected hint in formatted output")
+	}
+}
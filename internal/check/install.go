@@ -0,0 +1,143 @@
+package check
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// Package managers that InstallCommand knows how to drive
+const (
+	ManagerBrew   = "brew"
+	ManagerApt    = "apt"
+	ManagerDnf    = "dnf"
+	ManagerWinget = "winget"
+)
+
+// managerBinaries maps each package manager to the binary that runs it
+var managerBinaries = map[string]string{
+	ManagerBrew:   "brew",
+	ManagerApt:    "apt-get",
+	ManagerDnf:    "dnf",
+	ManagerWinget: "winget",
+}
+
+// systemPackages maps a tool to its package name for each system package
+// manager. Tools missing from a manager's column are not installable with it.
+var systemPackages = map[string]map[string]string{
+	"git": {
+		ManagerBrew: "git", ManagerApt: "git", ManagerDnf: "git", ManagerWinget: "Git.Git",
+	},
+	"python3": {
+		ManagerBrew: "python3", ManagerApt: "python3", ManagerDnf: "python3", ManagerWinget: "Python.Python.3.12",
+	},
+	"age": {
+		ManagerBrew: "age", ManagerApt: "age", ManagerDnf: "age", ManagerWinget: "FiloSottile.age",
+	},
+	"docker": {
+		ManagerBrew: "docker", ManagerApt: "docker.io", ManagerDnf: "docker", ManagerWinget: "Docker.DockerDesktop",
+	},
+	"uv": {
+		ManagerBrew: "uv", ManagerWinget: "astral-sh.uv",
+	},
+}
+
+// pythonPackages maps tools distributed as Python packages to their PyPI
+// names. They are installed with uv when available, otherwise with pip.
+var pythonPackages = map[string]string{
+	"uv": "uv",
+	"bd": "beads-cli",
+}
+
+// InstallOrder lists the installable tools so that prerequisites come
+// first: python3 before the pip-installed tools, uv before bd.
+var InstallOrder = []string{"git", "python3", "uv", "bd", "age", "docker"}
+
+// DetectPackageManager returns the system package manager available on
+// this machine (brew on macOS; apt or dnf on Linux, falling back to brew;
+// winget on Windows), or "" if none is found.
+func DetectPackageManager() string {
+	var candidates []string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = []string{ManagerBrew}
+	case "linux":
+		candidates = []string{ManagerApt, ManagerDnf, ManagerBrew}
+	case "windows":
+		candidates = []string{ManagerWinget}
+	}
+
+	for _, manager := range candidates {
+		if _, err := exec.LookPath(managerBinaries[manager]); err == nil {
+			return manager
+		}
+	}
+	return ""
+}
+
+// InstallCommand returns the command line that installs tool with the given
+// package manager, and false if asc does not know how to install it there.
+// System packages take precedence; Python tools fall back to uv or pip.
+// apt and dnf commands are prefixed with sudo when not running as root.
+func InstallCommand(tool, manager string) ([]string, bool) {
+	if pkg, ok := systemPackages[tool][manager]; ok {
+		switch manager {
+		case ManagerBrew:
+			if tool == "docker" {
+				return []string{"brew", "install", "--cask", pkg}, true
+			}
+			return []string{"brew", "install", pkg}, true
+		case ManagerApt:
+			return withSudo([]string{"apt-get", "install", "-y", pkg}), true
+		case ManagerDnf:
+			return withSudo([]string{"dnf", "install", "-y", pkg}), true
+		case ManagerWinget:
+			return []string{"winget", "install", "--id", pkg, "-e"}, true
+		}
+	}
+
+	if pkg, ok := pythonPackages[tool]; ok {
+		if tool != "uv" {
+			if _, err := exec.LookPath("uv"); err == nil {
+				return []string{"uv", "tool", "install", pkg}, true
+			}
+		}
+		return []string{"python3", "-m", "pip", "install", "--user", pkg}, true
+	}
+
+	return nil, false
+}
+
+// withSudo prefixes a command with sudo unless running as root or sudo is
+// unavailable
+func withSudo(command []string) []string {
+	if os.Geteuid() == 0 {
+		return command
+	}
+	if _, err := exec.LookPath("sudo"); err != nil {
+		return command
+	}
+	return append([]string{"sudo"}, command...)
+}
+
+// MissingTools returns the installable tools, in InstallOrder, whose check
+// did not pass because the binary is not in PATH
+func MissingTools(results []CheckResult) []string {
+	failed := make(map[string]bool)
+	for _, result := range results {
+		if result.Status != CheckPass {
+			failed[result.Name] = true
+		}
+	}
+
+	missing := []string{}
+	for _, tool := range InstallOrder {
+		if !failed[tool] {
+			continue
+		}
+		if _, err := exec.LookPath(tool); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	return missing
+}
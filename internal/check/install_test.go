@@ -0,0 +1,72 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstallCommand(t *testing.T) {
+	// Keep uv and sudo out of PATH so the expected commands are stable
+	t.Setenv("PATH", t.TempDir())
+
+	tests := []struct {
+		tool    string
+		manager string
+		want    []string
+		wantOK  bool
+	}{
+		{"git", ManagerBrew, []string{"brew", "install", "git"}, true},
+		{"docker", ManagerBrew, []string{"brew", "install", "--cask", "docker"}, true},
+		{"age", ManagerWinget, []string{"winget", "install", "--id", "FiloSottile.age", "-e"}, true},
+		{"uv", ManagerBrew, []string{"brew", "install", "uv"}, true},
+		{"uv", ManagerApt, []string{"python3", "-m", "pip", "install", "--user", "uv"}, true},
+		{"bd", ManagerDnf, []string{"python3", "-m", "pip", "install", "--user", "beads-cli"}, true},
+		{"bd", "", []string{"python3", "-m", "pip", "install", "--user", "beads-cli"}, true},
+		{"git", "", nil, false},
+		{"unknown-tool", ManagerBrew, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool+"/"+tt.manager, func(t *testing.T) {
+			got, ok := InstallCommand(tt.tool, tt.manager)
+			if ok != tt.wantOK {
+				t.Fatalf("InstallCommand(%s, %s) ok = %v, want %v", tt.tool, tt.manager, ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InstallCommand(%s, %s) = %v, want %v", tt.tool, tt.manager, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallCommandSystemManagerSudo(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	got, ok := InstallCommand("git", ManagerApt)
+	if !ok {
+		t.Fatal("Expected apt install command for git")
+	}
+	// Without sudo in PATH the command is run as-is, even when not root
+	want := []string{"apt-get", "install", "-y", "git"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InstallCommand(git, apt) = %v, want %v", got, want)
+	}
+}
+
+func TestMissingTools(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	results := []CheckResult{
+		{Name: "docker", Status: CheckWarn},
+		{Name: "git", Status: CheckFail},
+		{Name: "asc.toml", Status: CheckFail},
+		{Name: "python3", Status: CheckPass},
+		{Name: "bd", Status: CheckFail},
+	}
+
+	got := MissingTools(results)
+	want := []string{"git", "bd", "docker"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MissingTools() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package check
+
+import (
+	"net/url"
+
+	"github.com/spf13/viper"
+
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/proxy"
+)
+
+// CheckProxy reports the proxy configuration asc's network clients will
+// use (HTTP_PROXY, HTTPS_PROXY, NO_PROXY, and the
+// services.mcp_agent_mail.proxy override) and whether the MCP server is
+// reached through it. Returns CheckFail if a proxy URL is invalid.
+func (c *DefaultChecker) CheckProxy() CheckResult {
+	var mcpURL, override string
+	v := viper.New()
+	v.SetConfigFile(c.configPath)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err == nil {
+		mcpURL = v.GetString("services.mcp_agent_mail.url")
+		override = v.GetString("services.mcp_agent_mail.proxy")
+	}
+
+	settings, err := proxy.ForService(override)
+	if err != nil {
+		return CheckResult{
+			Name:    "proxy",
+			Status:  CheckFail,
+			Message: i18n.T("check.proxy.invalid_override", err),
+			Hint:    i18n.T("check.proxy.invalid_override_hint"),
+		}
+	}
+	if err := settings.Validate(); err != nil {
+		return CheckResult{
+			Name:    "proxy",
+			Status:  CheckFail,
+			Message: i18n.T("check.proxy.invalid_env", err),
+			Hint:    i18n.T("check.proxy.invalid_env_hint"),
+		}
+	}
+
+	message := i18n.T("check.proxy.ok", settings.Describe())
+	if target, err := url.Parse(mcpURL); err == nil && mcpURL != "" {
+		via, _ := settings.ProxyURL(target)
+		if via != nil {
+			message += i18n.T("check.proxy.via", via.Redacted())
+		} else if settings.IsSet() {
+			message += i18n.T("check.proxy.direct")
+		}
+	}
+
+	return CheckResult{
+		Name:    "proxy",
+		Status:  CheckPass,
+		Message: message,
+	}
+}
@@ -0,0 +1,229 @@
+package check
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rand/asc/internal/i18n"
+)
+
+// Version is a semantic version. Missing minor or patch components in the
+// source string are treated as zero.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// versionPattern finds the first dotted version number in a string, with an
+// optional leading "v" (e.g. "Python 3.11.4", "v1.1.1", "uv 0.4.18 (abc)")
+var versionPattern = regexp.MustCompile(`v?(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// ParseVersion extracts the first version number found in s, such as the
+// output of "python3 --version"
+func ParseVersion(s string) (Version, error) {
+	match := versionPattern.FindStringSubmatch(s)
+	if match == nil {
+		return Version{}, fmt.Errorf("no version number found in %q", strings.TrimSpace(s))
+	}
+
+	parts := make([]int, 3)
+	for i, part := range match[1:] {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version number %q: %w", match[0], err)
+		}
+		parts[i] = n
+	}
+	return Version{Major: parts[0], Minor: parts[1], Patch: parts[2]}, nil
+}
+
+// String returns the version as "major.minor.patch"
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0, or 1 when v is lower than, equal to, or higher than other
+func (v Version) Compare(other Version) int {
+	a := []int{v.Major, v.Minor, v.Patch}
+	b := []int{other.Major, other.Minor, other.Patch}
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// constraintClause is a single comparison such as ">=3.10"
+type constraintClause struct {
+	op      string
+	version Version
+}
+
+// Constraint is a set of comma-separated version comparisons that must all
+// hold, e.g. ">=3.10, <4". Supported operators are =, !=, >, >=, <, <=,
+// ^ (same major version) and ~ (same major and minor version).
+type Constraint struct {
+	raw     string
+	clauses []constraintClause
+}
+
+// constraintOperators is in match order: longer operators before their prefixes
+var constraintOperators = []string{">=", "<=", "!=", "==", ">", "<", "=", "^", "~"}
+
+// ParseConstraint parses a version constraint string
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: strings.TrimSpace(s)}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		op := "="
+		for _, candidate := range constraintOperators {
+			if strings.HasPrefix(part, candidate) {
+				op = candidate
+				part = strings.TrimSpace(part[len(candidate):])
+				break
+			}
+		}
+		if op == "==" {
+			op = "="
+		}
+
+		if versionPattern.FindString(part) != part {
+			return Constraint{}, fmt.Errorf("invalid version constraint %q", s)
+		}
+		version, err := ParseVersion(part)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		c.clauses = append(c.clauses, constraintClause{op: op, version: version})
+	}
+
+	if len(c.clauses) == 0 {
+		return Constraint{}, fmt.Errorf("empty version constraint")
+	}
+	return c, nil
+}
+
+// Check reports whether v satisfies every clause of the constraint
+func (c Constraint) Check(v Version) bool {
+	for _, clause := range c.clauses {
+		cmp := v.Compare(clause.version)
+		var ok bool
+		switch clause.op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case "^":
+			ok = cmp >= 0 && v.Major == clause.version.Major
+		case "~":
+			ok = cmp >= 0 && v.Major == clause.version.Major && v.Minor == clause.version.Minor
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the constraint as written in the configuration
+func (c Constraint) String() string {
+	return c.raw
+}
+
+// versionOutput runs "<binary> --version" and returns its combined output
+func versionOutput(binary string) (string, error) {
+	out, err := exec.Command(binary, "--version").CombinedOutput()
+	return string(out), err
+}
+
+// CheckVersion checks that a binary exists in PATH and that the version it
+// reports with --version satisfies constraint (see ParseConstraint).
+// Returns CheckFail if the binary is missing or too old/new, and CheckWarn
+// if its version cannot be determined. With a cache, a pass or fail for
+// the same unchanged binary is reused instead of running it again.
+func (c *DefaultChecker) CheckVersion(name, constraint string) CheckResult {
+	parsed, err := ParseConstraint(constraint)
+	if err != nil {
+		return CheckResult{
+			Name:    name,
+			Status:  CheckFail,
+			Message: i18n.T("check.version.invalid", name, err),
+		}
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return c.CheckBinary(name)
+	}
+
+	key := versionCacheKey(path, parsed.String())
+	if result, ok := c.cache.Get(key); ok {
+		return result
+	}
+
+	result := probeVersion(name, parsed)
+	// Warnings mean the probe itself failed, which may be transient
+	if result.Status != CheckWarn {
+		c.cache.Put(key, result)
+	}
+	return result
+}
+
+// probeVersion runs the binary to read its version and compares it with
+// the constraint
+func probeVersion(name string, parsed Constraint) CheckResult {
+	output, err := versionOutput(name)
+	if err != nil {
+		return CheckResult{
+			Name:    name,
+			Status:  CheckWarn,
+			Message: i18n.T("check.version.unknown", name, err),
+		}
+	}
+
+	version, err := ParseVersion(output)
+	if err != nil {
+		return CheckResult{
+			Name:    name,
+			Status:  CheckWarn,
+			Message: i18n.T("check.version.unknown", name, err),
+		}
+	}
+
+	if !parsed.Check(version) {
+		return CheckResult{
+			Name:    name,
+			Status:  CheckFail,
+			Message: i18n.T("check.version.unsatisfied", name, version, parsed),
+		}
+	}
+
+	return CheckResult{
+		Name:    name,
+		Status:  CheckPass,
+		Message: i18n.T("check.version.ok", name, version, parsed),
+	}
+}
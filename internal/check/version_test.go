@@ -0,0 +1,146 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output  string
+		want    Version
+		wantErr bool
+	}{
+		{"Python 3.11.4", Version{3, 11, 4}, false},
+		{"uv 0.4.18 (Homebrew 2024-10-01)", Version{0, 4, 18}, false},
+		{"v1.1.1", Version{1, 1, 1}, false},
+		{"Docker version 24.0.7, build afdd53b", Version{24, 0, 7}, false},
+		{"bd version 0.9", Version{0, 9, 0}, false},
+		{"no version here", Version{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			got, err := ParseVersion(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersion(%q) error = %v, wantErr %v", tt.output, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseVersion(%q) = %v, want %v", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{">=3.10", "3.11.4", true},
+		{">=3.10", "3.9.18", false},
+		{">=3.10, <4", "4.0.0", false},
+		{"<=1.2", "1.2.0", true},
+		{">0.4", "0.4.0", false},
+		{"=1.1.1", "1.1.1", true},
+		{"1.1.1", "1.1.2", false},
+		{"!=0.9.0", "0.9.0", false},
+		{"^1.2", "1.9.0", true},
+		{"^1.2", "2.0.0", false},
+		{"~1.2", "1.2.9", true},
+		{"~1.2", "1.3.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseConstraint(%q) failed: %v", tt.constraint, err)
+			}
+			v, _ := ParseVersion(tt.version)
+			if got := c.Check(v); got != tt.want {
+				t.Errorf("%q.Check(%s) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, constraint := range []string{"", ">=", ">=3.x", "latest"} {
+		if _, err := ParseConstraint(constraint); err == nil {
+			t.Errorf("ParseConstraint(%q) expected error", constraint)
+		}
+	}
+}
+
+// fakeBinary writes an executable named name to a temp dir that prints
+// output for --version, and puts that dir first on PATH
+func fakeBinary(t *testing.T, name, output string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho '" + output + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake binary: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestCheckVersion(t *testing.T) {
+	fakeBinary(t, "asc-fake-tool", "asc-fake-tool version 1.4.2")
+	checker := &DefaultChecker{}
+
+	tests := []struct {
+		name       string
+		binary     string
+		constraint string
+		wantStatus CheckStatus
+	}{
+		{"satisfied", "asc-fake-tool", ">=1.4", CheckPass},
+		{"too old", "asc-fake-tool", ">=2.0", CheckFail},
+		{"invalid constraint", "asc-fake-tool", "newest", CheckFail},
+		{"missing binary", "asc-missing-tool", ">=1.0", CheckFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checker.CheckVersion(tt.binary, tt.constraint)
+			if result.Status != tt.wantStatus {
+				t.Errorf("CheckVersion(%s, %q) status = %s, want %s (%s)", tt.binary, tt.constraint, result.Status, tt.wantStatus, result.Message)
+			}
+		})
+	}
+}
+
+func TestRunAllRequirements(t *testing.T) {
+	fakeBinary(t, "asc-fake-tool", "asc-fake-tool 0.3.0")
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "asc.toml")
+	configContent := `[core]
+beads_db_path = "./test-repo"
+
+[requirements]
+asc-fake-tool = ">=0.5"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	results := NewChecker(configPath, filepath.Join(tmpDir, ".env")).RunAll()
+
+	var found *CheckResult
+	for i := range results {
+		if results[i].Name == "asc-fake-tool" {
+			found = &results[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected a result for the binary listed under [requirements]")
+	}
+	if found.Status != CheckFail || !strings.Contains(found.Message, "0.3.0") {
+		t.Errorf("Expected version mismatch failure, got %+v", *found)
+	}
+}
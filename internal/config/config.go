@@ -14,12 +14,387 @@
 //	}
 package config
 
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Config represents the complete asc configuration loaded from asc.toml.
 // It contains core settings, service configurations, and agent definitions.
 type Config struct {
+	// ConfigVersion is the layout version of the file (see
+	// CurrentConfigVersion); files without one predate versioning
+	ConfigVersion int `mapstructure:"config_version"`
+
+	// ConfigSource is the shared configuration this file extends
+	ConfigSource SourceConfig `mapstructure:"config_source"`
+
 	Core     CoreConfig                `mapstructure:"core"`
 	Services ServicesConfig            `mapstructure:"services"`
 	Agents   map[string]AgentConfig    `mapstructure:"agent"`
+	Logging  LoggingConfig             `mapstructure:"logging"`
+
+	// Requirements maps binary names to version constraints verified by
+	// asc check, e.g. python3 = ">=3.10"
+	Requirements map[string]string `mapstructure:"requirements"`
+
+	// Check holds project-specific checks run alongside the built-in ones
+	Check CheckConfig `mapstructure:"check"`
+
+	// Doctor holds project-specific checks run by asc doctor
+	Doctor DoctorConfig `mapstructure:"doctor"`
+
+	// Pipeline advances the project through phases as their tasks complete
+	Pipeline PipelineConfig `mapstructure:"pipeline"`
+
+	// Budget limits what agents may spend on LLM calls
+	Budget BudgetConfig `mapstructure:"budget"`
+
+	// Worktree gives agents isolated checkouts of the project repository
+	Worktree WorktreeConfig `mapstructure:"worktree"`
+
+	// TUI configures the dashboard
+	TUI TUIConfig `mapstructure:"tui"`
+
+	// Secrets configures who encrypted .env files can be read by
+	Secrets SecretsConfig `mapstructure:"secrets"`
+
+	// Telemetry exports traces of commands, agents, and requests over OTLP
+	Telemetry TelemetryConfig `mapstructure:"telemetry"`
+
+	// Notify posts critical events, such as agent crashes, to a webhook,
+	// Slack, or Discord
+	Notify NotifyConfig `mapstructure:"notify"`
+
+	// Sync mirrors beads tasks to an issue tracker, and back
+	Sync SyncConfig `mapstructure:"sync"`
+
+	// Roles are the [agent.<name>] sections with replicas, as written; their
+	// instances are in Agents (see ExpandReplicas)
+	Roles map[string]AgentConfig `mapstructure:"-"`
+
+	// UnknownKeys are the keys of asc.toml no setting has, such as
+	// misspelled ones; Load rejects them under core.strict
+	UnknownKeys []UnknownKey `mapstructure:"-"`
+
+	// Sources are the files the configuration was read from: asc.toml,
+	// the files it includes, and the overlay of the profile
+	Sources []string `mapstructure:"-"`
+
+	// Remote is where the shared configuration of config_source was
+	// fetched from, or nil
+	Remote *RemoteSource `mapstructure:"-"`
+}
+
+// SyncConfig configures asc sync
+type SyncConfig struct {
+	GitHub GitHubSyncConfig `mapstructure:"github"` // GitHub Issues, synced by asc sync github
+	Jira   JiraSyncConfig   `mapstructure:"jira"`   // Jira issues, imported and transitioned by asc sync jira
+}
+
+// GitHubSyncConfig configures the two-way sync of beads tasks with the
+// issues of a GitHub repository. Status, assignee, and labels are mapped
+// both ways; a field changed on both sides since the last sync is a
+// conflict, which Conflict resolves: github or beads keeps the change of
+// that side, and skip leaves both unchanged and reports it. Assignees maps
+// beads assignees, such as agent names, to GitHub logins.
+type GitHubSyncConfig struct {
+	Repo      string            `mapstructure:"repo"`      // owner/name of the repository (or --repo)
+	TokenEnv  string            `mapstructure:"token_env"` // Name of the env var holding the API token (default: GITHUB_TOKEN)
+	APIURL    string            `mapstructure:"api_url"`   // REST API base URL, for GitHub Enterprise (default: https://api.github.com)
+	Label     string            `mapstructure:"label"`     // Only import issues with this label, and add it to exported ones (default: import all issues)
+	Conflict  string            `mapstructure:"conflict"`  // Side that wins a field changed on both: github, beads, or skip (default: github)
+	Assignees map[string]string `mapstructure:"assignees"` // GitHub logins by beads assignee, e.g. coder = "octocat"
+	Timeout   time.Duration     `mapstructure:"timeout"`   // Timeout of each API request (default: 30s)
+	Proxy     string            `mapstructure:"proxy"`     // Proxy override: a proxy URL, or "direct" to bypass HTTP(S)_PROXY
+}
+
+// JiraSyncConfig configures the import of the Jira issues of a JQL query
+// as beads tasks. Jira stays the source of the backlog: titles, assignees,
+// and labels are only read from it, and the status of a task is pushed
+// back by transitioning its issue to the Jira status Statuses maps it to.
+// With Email set, the token is sent with basic auth, as Jira Cloud
+// expects; otherwise it is a bearer personal access token (Data Center).
+type JiraSyncConfig struct {
+	URL       string            `mapstructure:"url"`       // Base URL of the Jira site, e.g. "https://acme.atlassian.net"
+	JQL       string            `mapstructure:"jql"`       // Query selecting the issues to import (or --jql), e.g. "project = WEB AND labels = agents"
+	Email     string            `mapstructure:"email"`     // Account email, for Jira Cloud API tokens
+	TokenEnv  string            `mapstructure:"token_env"` // Name of the env var holding the API token (default: JIRA_API_TOKEN)
+	Statuses  map[string]string `mapstructure:"statuses"`  // Jira statuses by beads status (default: open = "To Do", in_progress = "In Progress", closed = "Done")
+	Conflict  string            `mapstructure:"conflict"`  // Side that wins a status changed on both: jira, beads, or skip (default: jira)
+	Assignees map[string]string `mapstructure:"assignees"` // Jira display names by beads assignee, e.g. coder = "Ada Lovelace"
+	Timeout   time.Duration     `mapstructure:"timeout"`   // Timeout of each API request (default: 30s)
+	Proxy     string            `mapstructure:"proxy"`     // Proxy override: a proxy URL, or "direct" to bypass HTTP(S)_PROXY
+}
+
+// NotifyConfig configures notifications of events
+type NotifyConfig struct {
+	Webhook WebhookConfig `mapstructure:"webhook"` // JSON POSTs to an HTTP endpoint
+	Slack   ChatConfig    `mapstructure:"slack"`   // Messages to a Slack incoming webhook
+	Discord ChatConfig    `mapstructure:"discord"` // Messages to a Discord webhook
+}
+
+// WebhookConfig configures the webhook that receives a JSON payload for
+// each selected event, such as an agent crash, a critical issue found by
+// asc doctor, or the stack starting and stopping. It is tool-agnostic;
+// point it at a relay that formats messages for a chat tool or pager.
+type WebhookConfig struct {
+	URL       string            `mapstructure:"url"`        // Endpoint the payloads are posted to; notifications are off without it
+	URLEnv    string            `mapstructure:"url_env"`    // Name of the env var holding the URL instead, for URLs that embed a token
+	Events    []string          `mapstructure:"events"`     // Event types, or their prefix such as "agent" (default: agent.crashed, task.stuck, doctor.critical, stack.started, stack.stopped)
+	Headers   map[string]string `mapstructure:"headers"`    // Headers sent with every payload
+	RateLimit int               `mapstructure:"rate_limit"` // Payloads per minute at most; -1 for no limit (default: 10)
+	Timeout   time.Duration     `mapstructure:"timeout"`    // Timeout of each POST, e.g. "10s" (default: 10s)
+	Proxy     string            `mapstructure:"proxy"`      // Proxy override: a proxy URL, or "direct" to bypass HTTP(S)_PROXY
+}
+
+// ChatConfig configures the Slack or Discord webhook messages are posted
+// to for each selected event. Templates override the text of the messages
+// of an event type, keyed by the type with underscores for dots, such as
+// agent_crashed, or default for the types without their own; they are Go
+// templates over the fields of the webhook payload, such as {{.Agent}}.
+// Events beyond RateLimit a minute are dropped, and counted in the next
+// message.
+type ChatConfig struct {
+	URL       string            `mapstructure:"url"`        // Incoming webhook URL; messages are off without it
+	URLEnv    string            `mapstructure:"url_env"`    // Name of the env var holding the URL instead
+	Events    []string          `mapstructure:"events"`     // Event types, or their prefix (default: as for notify.webhook)
+	Templates map[string]string `mapstructure:"templates"`  // Message templates by event type
+	Username  string            `mapstructure:"username"`   // Name the messages are posted as (default: "asc")
+	RateLimit int               `mapstructure:"rate_limit"` // Messages per minute at most; -1 for no limit (default: 10)
+	Timeout   time.Duration     `mapstructure:"timeout"`    // Timeout of each POST (default: 10s)
+	Proxy     string            `mapstructure:"proxy"`      // Proxy override: a proxy URL, or "direct" to bypass HTTP(S)_PROXY
+}
+
+// TelemetryConfig configures OpenTelemetry tracing. Commands, agent
+// processes, beads calls, and MCP requests are recorded as spans and
+// exported over OTLP/HTTP to a collector, such as the OpenTelemetry
+// Collector, Jaeger, or Grafana Tempo. With Logs, asc's own log records
+// are exported too, in the trace of the command that wrote them.
+type TelemetryConfig struct {
+	Enabled       bool              `mapstructure:"enabled"`        // Export traces
+	Endpoint      string            `mapstructure:"endpoint"`       // OTLP/HTTP base URL (default: OTEL_EXPORTER_OTLP_ENDPOINT, or "http://localhost:4318")
+	ServiceName   string            `mapstructure:"service_name"`   // service.name of the exported resource (default: "asc")
+	Headers       map[string]string `mapstructure:"headers"`        // Headers sent with every export, e.g. a tenant ID
+	AuthTokenEnv  string            `mapstructure:"auth_token_env"` // Name of the env var holding a bearer token
+	Logs          bool              `mapstructure:"logs"`           // Also export asc's log records
+	FlushInterval time.Duration     `mapstructure:"flush_interval"` // Maximum delay before an export, e.g. "5s" (default: 5s)
+	Proxy         string            `mapstructure:"proxy"`          // Proxy override: a proxy URL, or "direct" to bypass HTTP(S)_PROXY
+}
+
+// SecretsConfig configures asc secrets. Files are encrypted to the local
+// age key and to each of Recipients, so every team member can decrypt
+// them with their own key. Env references secrets kept in a cloud secret
+// manager, such as CLAUDE_API_KEY = "aws-sm://prod/asc/claude", which asc
+// up resolves before starting agents. RotationDays is the key rotation
+// policy enforced by asc secrets rotate --auto and reported by asc doctor.
+type SecretsConfig struct {
+	Recipients   []string          `mapstructure:"recipients"`    // age public keys (age1...) of team members
+	Env          map[string]string `mapstructure:"env"`           // Environment variables set from secret references (aws-sm://, gcp-sm://)
+	RotationDays int               `mapstructure:"rotation_days"` // Maximum age of the age key in days (default: 0, no policy)
+}
+
+// TUIConfig configures the dashboard. Messages beyond message_history are
+// moved from memory to ~/.asc/history/messages.jsonl and read back when
+// the log is scrolled to them.
+type TUIConfig struct {
+	MessageHistory int `mapstructure:"message_history"` // Messages kept in memory (default: 1000)
+}
+
+// WorktreeConfig gives each agent its own git worktree of the project
+// repository (core.beads_db_path) on a branch of its own, so parallel
+// agents do not edit the same checkout. Agents opt out with worktree =
+// false.
+type WorktreeConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`       // Provision a worktree when each agent starts
+	Dir          string `mapstructure:"dir"`           // Where worktrees are created (default: ~/.asc/worktrees)
+	BranchPrefix string `mapstructure:"branch_prefix"` // Prefix of agent branch names (default: "asc/")
+	Base         string `mapstructure:"base"`          // Branch agent branches start from (default: the repository's current branch)
+}
+
+// UsesWorktree reports whether an agent gets its own worktree
+func (c *Config) UsesWorktree(agent AgentConfig) bool {
+	return c.Worktree.Enabled && (agent.Worktree == nil || *agent.Worktree)
+}
+
+// BudgetConfig configures spend budgets. Agents report the estimated cost
+// of each LLM call to the usage ledger; when spend crosses a warn_at
+// fraction of a budget asc warns, and when it exceeds the budget the
+// offending agents are paused (stopped and not restarted) until the next
+// period or an override. Per-agent budgets are set with budget_usd on the
+// agent.
+type BudgetConfig struct {
+	ProjectUSD    float64       `mapstructure:"project_usd"`    // Limit for all agents together; 0 for none
+	Period        string        `mapstructure:"period"`         // "daily", "monthly", or "total" (default: "monthly")
+	WarnAt        []float64     `mapstructure:"warn_at"`        // Fractions of a budget that trigger warnings (default: [0.8])
+	CheckInterval time.Duration `mapstructure:"check_interval"` // How often spend is checked, e.g. "1m" (default: 1m)
+	NotifyCommand string        `mapstructure:"notify_command"` // Shell command run for each warning or pause, with ASC_BUDGET_* set
+	NotifyURL     string        `mapstructure:"notify_url"`     // URL that receives each warning or pause as a JSON POST
+}
+
+// BudgetEnabled reports whether a project or agent budget is configured
+func (c *Config) BudgetEnabled() bool {
+	if c.Budget.ProjectUSD > 0 {
+		return true
+	}
+	for _, agent := range c.Agents {
+		if agent.BudgetUSD > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// PipelineConfig configures the phase pipeline: the project moves through
+// Phases in order, each phase completes when its gate is met, and only the
+// agents that handle the current phase run. An empty Phases list disables
+// the pipeline and every agent runs all the time.
+type PipelineConfig struct {
+	Phases   []string              `mapstructure:"phases"`   // Ordered phases, e.g. ["planning", "implementation", "testing", "review"]
+	Interval time.Duration         `mapstructure:"interval"` // How often gates are evaluated, e.g. "30s" (default: 30s)
+	Gates    map[string]GateConfig `mapstructure:"gates"`    // Per-phase completion gates keyed by phase name
+}
+
+// GateConfig defines when a pipeline phase is complete.
+type GateConfig struct {
+	MinTasks   int     `mapstructure:"min_tasks"`  // Tasks the phase must have before it can complete (default: 1)
+	Completion float64 `mapstructure:"completion"` // Fraction of the phase's tasks that must be done, 0-1 (default: 1)
+}
+
+// Enabled reports whether the phase pipeline is configured
+func (p PipelineConfig) Enabled() bool {
+	return len(p.Phases) > 0
+}
+
+// Gate returns the gate of a phase with defaults applied
+func (p PipelineConfig) Gate(phase string) GateConfig {
+	gate := p.Gates[strings.ToLower(phase)]
+	if gate.MinTasks < 1 {
+		gate.MinTasks = 1
+	}
+	if gate.Completion == 0 {
+		gate.Completion = 1
+	}
+	return gate
+}
+
+// CheckConfig configures additional dependency checks.
+type CheckConfig struct {
+	Custom map[string]CustomCheckConfig `mapstructure:"custom"` // User-defined checks keyed by name
+}
+
+// CustomCheckConfig defines a user-defined check: a shell command whose
+// exit code determines whether the check passes.
+type CustomCheckConfig struct {
+	Command  string `mapstructure:"command"`   // Shell command to run, e.g. "nc -z license.internal 27000"
+	ExitCode int    `mapstructure:"exit_code"` // Exit code that means success (default: 0)
+	Severity string `mapstructure:"severity"`  // "error" fails the check, "warn" only warns (default: "error")
+	Hint     string `mapstructure:"hint"`      // Shown when the check does not pass
+}
+
+// DoctorConfig configures asc doctor.
+type DoctorConfig struct {
+	Checks   map[string]DoctorCheckConfig `mapstructure:"checks"`   // Check plugins keyed by name, run with those of ~/.asc/checks
+	Interval time.Duration                `mapstructure:"interval"` // How often asc daemon runs the diagnostics, e.g. "30m" (default: never)
+}
+
+// MinDoctorInterval is the shortest interval of periodic diagnostics
+const MinDoctorInterval = 10 * time.Second
+
+// DoctorCheckConfig defines a check plugin: a shell command that prints
+// the issues it finds as JSON, for asc doctor to report and fix.
+type DoctorCheckConfig struct {
+	Command string        `mapstructure:"command"` // Shell command to run, e.g. "./scripts/check-vpn.sh"
+	Timeout time.Duration `mapstructure:"timeout"` // How long the command may run (default: 30s)
+}
+
+// LoggingConfig controls how asc writes its own log records.
+type LoggingConfig struct {
+	Format string `mapstructure:"format"` // Log record format: "text", "json", or "logfmt" (default: "text")
+	Level  string `mapstructure:"level"`  // Minimum level: "trace", "debug", "info", "warn", "error" (default: "info")
+
+	Levels map[string]string `mapstructure:"levels"` // Per-component level overrides, e.g. mcp = "debug"
+	Stderr string            `mapstructure:"stderr"` // Minimum level also written to stderr, "" for none (default: "")
+
+	Syslog   SyslogConfig   `mapstructure:"syslog"`   // Optional syslog output
+	Journald JournaldConfig `mapstructure:"journald"` // Optional systemd-journald output (Linux only)
+	Ship     ShipConfig     `mapstructure:"ship"`     // Optional forwarding to Loki or Elasticsearch
+
+	Rotation LogRotationConfig `mapstructure:"rotation"` // Rotation of agent and mcp_agent_mail output logs
+	Crashes  CrashesConfig     `mapstructure:"crashes"`  // Crash dumps of agents that fail
+}
+
+// CrashesConfig bounds the crash dumps saved in ~/.asc/crashes when an
+// agent or mcp_agent_mail exits with a failure: how and when it exited and
+// the end of its output log, listed with asc crashes.
+type CrashesConfig struct {
+	LogKB    int `mapstructure:"log_kb"`    // KB of the end of the output log kept (default: 64)
+	MaxFiles int `mapstructure:"max_files"` // Crash dumps kept per agent (default: 20)
+}
+
+// LogRotationConfig bounds the logs agents and mcp_agent_mail write their
+// output to in ~/.asc/logs. A log larger than max_size_mb is copied to
+// <name>.log.1, gzipped when compress is set, and emptied; older copies
+// move up to .2 and so on, and those beyond max_files or older than
+// max_age_days are removed. Agents override it field by field in
+// [agent.<name>.log_rotation].
+type LogRotationConfig struct {
+	MaxSizeMB  int   `mapstructure:"max_size_mb"`  // Size a log is rotated at; -1 to never rotate (default: 10)
+	MaxFiles   int   `mapstructure:"max_files"`    // Rotated copies kept (default: 5)
+	MaxAgeDays int   `mapstructure:"max_age_days"` // Days rotated copies are kept; 0 for no limit (default: 0)
+	Compress   *bool `mapstructure:"compress"`     // gzip rotated copies (default: false)
+}
+
+// LogRotationFor returns the log rotation of an agent: [logging.rotation]
+// with the fields the agent sets overriding it
+func (c *Config) LogRotationFor(agent AgentConfig) LogRotationConfig {
+	rotation := c.Logging.Rotation
+	if agent.LogRotation.MaxSizeMB != 0 {
+		rotation.MaxSizeMB = agent.LogRotation.MaxSizeMB
+	}
+	if agent.LogRotation.MaxFiles != 0 {
+		rotation.MaxFiles = agent.LogRotation.MaxFiles
+	}
+	if agent.LogRotation.MaxAgeDays != 0 {
+		rotation.MaxAgeDays = agent.LogRotation.MaxAgeDays
+	}
+	if agent.LogRotation.Compress != nil {
+		rotation.Compress = agent.LogRotation.Compress
+	}
+	return rotation
+}
+
+// ShipConfig configures batched shipping of asc and agent logs to a
+// central log store.
+type ShipConfig struct {
+	Enabled       bool              `mapstructure:"enabled"`        // Ship logs while the stack is running
+	Backend       string            `mapstructure:"backend"`        // "loki" or "elasticsearch"
+	URL           string            `mapstructure:"url"`            // Base URL, e.g. "http://loki:3100"
+	Index         string            `mapstructure:"index"`          // Elasticsearch index (default: "asc-logs")
+	AuthTokenEnv  string            `mapstructure:"auth_token_env"` // Name of the env var holding a bearer token
+	Workspace     string            `mapstructure:"workspace"`      // Workspace label (default: current directory name)
+	Labels        map[string]string `mapstructure:"labels"`         // Extra labels attached to every record
+	BatchSize     int               `mapstructure:"batch_size"`     // Records per push (default: 100)
+	FlushInterval time.Duration     `mapstructure:"flush_interval"` // Maximum delay before a push, e.g. "5s" (default: 5s)
+	Proxy         string            `mapstructure:"proxy"`          // Proxy override: a proxy URL, or "direct" to bypass HTTP(S)_PROXY
+}
+
+// SyslogConfig configures forwarding of log records to a syslog daemon.
+type SyslogConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // Forward records to syslog
+	Network string `mapstructure:"network"` // "" for the local daemon, or "udp"/"tcp" for a remote one
+	Address string `mapstructure:"address"` // Remote "host:port"; required when network is set
+	Tag     string `mapstructure:"tag"`     // Program name attached to messages (default: "asc")
+}
+
+// JournaldConfig configures forwarding of log records to systemd-journald.
+type JournaldConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`    // Forward records to the local journal
+	Identifier string `mapstructure:"identifier"` // SYSLOG_IDENTIFIER for records (default: "asc")
 }
 
 // CoreConfig contains core system configuration including paths to
@@ -27,19 +402,156 @@ type Config struct {
 type CoreConfig struct {
 	BeadsDBPath     string `mapstructure:"beads_db_path"`     // Path to the beads task database repository
 	AutoRecovery    *bool  `mapstructure:"auto_recovery"`     // Enable automatic agent recovery (default: true if nil)
+	BeadsMode       string `mapstructure:"beads_mode"`        // How to access the beads database: auto, sqlite, or cli (default: auto)
+	Strict          bool   `mapstructure:"strict"`            // Reject unknown keys instead of warning about them (default: false)
 }
 
 // ServicesConfig contains configuration for external services that
 // the agent stack depends on, such as the MCP agent mail server.
 type ServicesConfig struct {
 	MCPAgentMail MCPConfig `mapstructure:"mcp_agent_mail"` // MCP agent mail server configuration
+
+	// MCPServers are the MCP servers of the other [services.mcp_*]
+	// sections, by section name, that agents are routed to with mcp_server
+	MCPServers map[string]MCPConfig `mapstructure:"-"`
+}
+
+// DefaultMCPServer is the MCP server of agents that do not set mcp_server
+const DefaultMCPServer = "mcp_agent_mail"
+
+// MCPServerNames returns the names of the configured MCP servers,
+// mcp_agent_mail first and the others sorted
+func (s ServicesConfig) MCPServerNames() []string {
+	names := make([]string, 0, len(s.MCPServers)+1)
+	for name := range s.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultMCPServer}, names...)
+}
+
+// MCPServer returns the MCP server of the [services.<name>] section, and
+// whether it is configured
+func (s ServicesConfig) MCPServer(name string) (MCPConfig, bool) {
+	if name == DefaultMCPServer {
+		return s.MCPAgentMail, true
+	}
+	server, ok := s.MCPServers[name]
+	return server, ok
+}
+
+// MCPServerFor returns the name and configuration of the MCP server the
+// agent's mailbox is on
+func (c *Config) MCPServerFor(agent AgentConfig) (string, MCPConfig) {
+	if server, ok := c.Services.MCPServer(agent.MCPServer); ok && agent.MCPServer != "" {
+		return agent.MCPServer, server
+	}
+	return DefaultMCPServer, c.Services.MCPAgentMail
+}
+
+// MCPRoutes returns the MCP server of each agent routed away from
+// mcp_agent_mail, by agent name
+func (c *Config) MCPRoutes() map[string]string {
+	routes := make(map[string]string)
+	for name, agent := range c.Agents {
+		if server, _ := c.MCPServerFor(agent); server != DefaultMCPServer {
+			routes[name] = server
+		}
+	}
+	return routes
 }
 
 // MCPConfig contains MCP agent mail server configuration including
 // the command to start the server and its HTTP endpoint URL.
 type MCPConfig struct {
-	StartCommand string `mapstructure:"start_command"` // Command to start the MCP server (e.g., "python -m mcp_agent_mail.server")
-	URL          string `mapstructure:"url"`           // HTTP endpoint URL (e.g., "http://localhost:8765")
+	StartCommand string `mapstructure:"start_command"` // Command to start the MCP server (e.g., "python -m mcp_agent_mail.server"); servers besides mcp_agent_mail without one are not started by asc
+	URL          string `mapstructure:"url"`           // HTTP endpoint URL (e.g., "http://localhost:8765"); for gRPC, the server's host and port, with https for TLS
+	Protocol     string `mapstructure:"protocol"`      // Transport to the server: "http" or "grpc" (default: http)
+	Proxy        string `mapstructure:"proxy"`         // Proxy override: a proxy URL, or "direct" to bypass HTTP(S)_PROXY
+
+	ConnectTimeout  time.Duration `mapstructure:"connect_timeout"`   // Limit for connecting to the server, e.g. "2s" (default: 2s)
+	ReadTimeout     time.Duration `mapstructure:"read_timeout"`      // Limit for the server's full response, e.g. "5s" (default: 5s)
+	MaxRetries      *int          `mapstructure:"max_retries"`       // Retries after a failed request; 0 for none (default: 3)
+	RetryBackoff    time.Duration `mapstructure:"retry_backoff"`     // Delay before the first retry, doubling with each retry, e.g. "1s" (default: 1s)
+	MaxRetryBackoff time.Duration `mapstructure:"max_retry_backoff"` // Cap of the delay between retries, e.g. "10s" (default: 10s)
+
+	BreakerThreshold *int          `mapstructure:"breaker_threshold"` // Failed requests in a row that mark the server degraded; 0 disables (default: 5)
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown"`  // How long a degraded server's requests fail fast, e.g. "30s" (default: 30s)
+
+	CAFile   string `mapstructure:"ca_file"`   // PEM bundle of CAs to trust for an https url, besides the system's
+	CertFile string `mapstructure:"cert_file"` // Client certificate for mutual TLS, with key_file
+	KeyFile  string `mapstructure:"key_file"`  // Private key of cert_file
+	TokenEnv string `mapstructure:"token_env"` // Environment variable holding the bearer token (default: MCP_AGENT_MAIL_TOKEN)
+
+	QueueSize *int `mapstructure:"queue_size"` // Messages queued while the server is unavailable; 0 disables the queue (default: 1000)
+
+	Compression string        `mapstructure:"compression"`  // Compression of requests: "gzip" or "zstd" (default: none)
+	BatchWindow time.Duration `mapstructure:"batch_window"` // How long a message waits to be sent with others in one request, e.g. "20ms" (default: 0, each at once)
+	BatchSize   int           `mapstructure:"batch_size"`   // Most messages sent in one request (default: 100)
+
+	ReadyCheck ReadyCheckConfig `mapstructure:"ready_check"` // When the server is ready for agents (default: its URL's port accepts connections)
+}
+
+// Transports of an MCP server's protocol
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+// ReadyCheckConfig says when a started process is ready for the processes
+// that depend on it: once it accepts connections on port, answers url
+// with a status below 500, or writes a line matching log_regex to its log.
+// At most one of them is set.
+type ReadyCheckConfig struct {
+	Port     int           `mapstructure:"port"`      // Port on localhost that accepts connections once ready
+	URL      string        `mapstructure:"url"`       // URL that answers once ready, e.g. "http://localhost:8080/health"
+	LogRegex string        `mapstructure:"log_regex"` // Regular expression a log line written once ready matches
+	Timeout  time.Duration `mapstructure:"timeout"`   // Limit for becoming ready, e.g. "60s" (default: 30s)
+}
+
+// IsSet reports whether a port, URL, or log pattern is configured
+func (r ReadyCheckConfig) IsSet() bool {
+	return r.Port != 0 || r.URL != "" || r.LogRegex != ""
+}
+
+// Retries returns the configured number of retries, or the default of 3
+func (m MCPConfig) Retries() int {
+	if m.MaxRetries == nil {
+		return 3
+	}
+	return *m.MaxRetries
+}
+
+// DefaultMCPTokenEnv is the environment variable the MCP bearer token is
+// read from unless token_env names another
+const DefaultMCPTokenEnv = "MCP_AGENT_MAIL_TOKEN"
+
+// Token returns the bearer token for the MCP server from the environment
+// variable token_env, set in the shell, .env, or [secrets.env], or "" to
+// send none
+func (m MCPConfig) Token() string {
+	name := m.TokenEnv
+	if name == "" {
+		name = DefaultMCPTokenEnv
+	}
+	return os.Getenv(name)
+}
+
+// OutboxSize returns the configured queue size, or the default of 1000
+func (m MCPConfig) OutboxSize() int {
+	if m.QueueSize == nil {
+		return 1000
+	}
+	return *m.QueueSize
+}
+
+// BreakerFailures returns the configured breaker threshold, or the
+// default of 5
+func (m MCPConfig) BreakerFailures() int {
+	if m.BreakerThreshold == nil {
+		return 5
+	}
+	return *m.BreakerThreshold
 }
 
 // AgentConfig contains configuration for a single agent including
@@ -48,4 +560,159 @@ type AgentConfig struct {
 	Command string   `mapstructure:"command"` // Command to execute the agent (e.g., "python agent_adapter.py")
 	Model   string   `mapstructure:"model"`   // LLM model: "claude", "gemini", "gpt-4", "codex"
 	Phases  []string `mapstructure:"phases"`  // Workflow phases: "planning", "implementation", "testing", etc.
+	Prompt  string   `mapstructure:"prompt"`  // Versioned system prompt from asc prompts: "name" (current) or "name@version"
+
+	BudgetUSD float64 `mapstructure:"budget_usd"` // Spend limit for this agent per budget period; 0 for none
+	Worktree  *bool   `mapstructure:"worktree"`   // Own git worktree when [worktree] is enabled (default: true)
+
+	// Where the agent runs and the variables it alone gets, for runtime = "process"
+	Workdir  string            `mapstructure:"workdir"`   // Working directory, e.g. a separate checkout, which must exist (default: that of asc)
+	EnvFile  string            `mapstructure:"env_file"`  // .env file of variables for this agent, read as it starts
+	ExtraEnv map[string]string `mapstructure:"extra_env"` // Variables for this agent, over those of env_file; names are upper-cased
+	Umask    string            `mapstructure:"umask"`     // Octal mask of the permissions of files the agent creates, e.g. "0027" (default: that of asc)
+
+	// Restart policy for when the agent exits on its own
+	Restart        string        `mapstructure:"restart"`         // "always", "on-failure", or "never" (default: "on-failure")
+	MaxRestarts    int           `mapstructure:"max_restarts"`    // Restarts in a row before giving up; -1 for no limit (default: 5)
+	RestartBackoff time.Duration `mapstructure:"restart_backoff"` // Delay before the first restart, doubling up to 5m (default: 1s)
+
+	// Graceful shutdown by asc down and when asc up exits
+	StopSignal      string        `mapstructure:"stop_signal"`       // Signal asking the agent to exit: "SIGTERM", "SIGINT", "SIGHUP", "SIGQUIT", "SIGUSR1", "SIGUSR2" (default: "SIGTERM")
+	StopGracePeriod time.Duration `mapstructure:"stop_grace_period"` // How long the agent may take to exit before it is killed, e.g. "30s" (default: 5s)
+	PreStop         string        `mapstructure:"pre_stop"`          // Shell command run before the stop signal is sent, e.g. to release leases
+
+	// Resource limits, enforced with cgroups v2 on Linux and job objects on Windows
+	MaxMemoryMB int     `mapstructure:"max_memory_mb"` // Memory limit in MB, above which the agent is killed; 0 for none
+	CPULimit    float64 `mapstructure:"cpu_limit"`     // CPU cores the agent is throttled to, e.g. 0.5; 0 for none
+
+	LogRotation LogRotationConfig `mapstructure:"log_rotation"` // Overrides of [logging.rotation] for this agent's log
+
+	// Startup ordering; mcp_agent_mail is always ready before agents start
+	DependsOn  []string         `mapstructure:"depends_on"`  // Agents that must be started and ready before this one
+	ReadyCheck ReadyCheckConfig `mapstructure:"ready_check"` // When this agent is ready for the agents depending on it
+
+	HealthCheck HealthCheckConfig `mapstructure:"health_check"` // Periodic check of the running agent
+
+	Stdin bool `mapstructure:"stdin"` // Read input forwarded by asc attach --stdin (not supported on Windows)
+
+	MCPServer string `mapstructure:"mcp_server"` // [services.mcp_*] section of the MCP server the agent's mailbox is on (default: mcp_agent_mail)
+
+	Runtime string       `mapstructure:"runtime"` // "process" to run the command on the host, or "docker" to run it in a container (default: "process")
+	Docker  DockerConfig `mapstructure:"docker"`  // The container of an agent with runtime = "docker"
+
+	// Instances of the agent run, named <name>-1 to <name>-N, as changed by
+	// asc scale; 0 for a single agent named <name>
+	Replicas int `mapstructure:"replicas"`
+
+	// Set on the instances of an agent with replicas
+	Role    string `mapstructure:"-"` // Name of the [agent.<name>] section
+	Replica int    `mapstructure:"-"` // Number of the instance, from 1
+}
+
+// UsesDocker reports whether the agent runs in a container
+func (a AgentConfig) UsesDocker() bool {
+	return strings.EqualFold(a.Runtime, "docker")
+}
+
+// UmaskMode returns the umask the agent runs with, or nil for that of asc
+func (a AgentConfig) UmaskMode() *os.FileMode {
+	mask, err := strconv.ParseUint(a.Umask, 8, 32)
+	if a.Umask == "" || err != nil {
+		return nil
+	}
+	mode := os.FileMode(mask)
+	return &mode
+}
+
+// DockerConfig is the container an agent with runtime = "docker" runs in,
+// started with the docker CLI. The agent's command runs in it, or the
+// image's own command if the agent has none. The paths asc gives the
+// agent, such as its worktree, are mounted at the same place.
+type DockerConfig struct {
+	Image   string   `mapstructure:"image"`   // Image to run, e.g. "python:3.12-slim"
+	Volumes []string `mapstructure:"volumes"` // Mounts as "host:container[:options]", e.g. "./src:/src:ro"
+	Env     []string `mapstructure:"env"`     // Extra variables: "NAME" passes the host's value through, "NAME=value" sets one
+	Network string   `mapstructure:"network"` // Network to join, e.g. "host" (default: docker's bridge network)
+}
+
+// IsSet reports whether any docker setting is configured
+func (d DockerConfig) IsSet() bool {
+	return d.Image != "" || len(d.Volumes) > 0 || len(d.Env) > 0 || d.Network != ""
+}
+
+// HealthCheckConfig checks a running agent every interval: it is healthy
+// while command exits with status 0, url answers with a 2xx or 3xx status,
+// or, with heartbeat set, its last heartbeat to mcp_agent_mail is recent.
+// Exactly one of them is set. After retries failed checks in a row the
+// agent is restarted, marked degraded, or marked degraded with
+// notify_command run, as action says.
+type HealthCheckConfig struct {
+	Command   string `mapstructure:"command"`   // Shell command that exits with status 0 while healthy
+	URL       string `mapstructure:"url"`       // URL that answers with a 2xx or 3xx status while healthy
+	Heartbeat bool   `mapstructure:"heartbeat"` // Healthy while the agent's MCP heartbeat is at most max_heartbeat_age old
+
+	MaxHeartbeatAge time.Duration `mapstructure:"max_heartbeat_age"` // Age of the last heartbeat that fails the check (default: 2m)
+	Interval        time.Duration `mapstructure:"interval"`          // Time between checks (default: 30s)
+	Timeout         time.Duration `mapstructure:"timeout"`           // Limit for one check (default: 10s)
+	Retries         int           `mapstructure:"retries"`           // Failed checks in a row before action is taken (default: 3)
+	Action          string        `mapstructure:"action"`            // "restart", "notify", or "degrade" (default: "restart")
+	NotifyCommand   string        `mapstructure:"notify_command"`    // Shell command run by the notify action, with ASC_HEALTH_* set
+}
+
+// IsSet reports whether a command, URL, or heartbeat check is configured
+func (h HealthCheckConfig) IsSet() bool {
+	return h.Command != "" || h.URL != "" || h.Heartbeat
+}
+
+// StartOrder returns the names of the agents in the order asc up starts
+// them: each after the agents it depends on, and otherwise by name. It
+// fails if agents depend on each other in a cycle.
+func (c *Config) StartOrder() ([]string, error) {
+	names := make([]string, 0, len(c.Agents))
+	for name := range c.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	order := make([]string, 0, len(names))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, p := range path {
+				if p == name {
+					path = path[i:]
+					break
+				}
+			}
+			return fmt.Errorf("agents depend on each other in a cycle: %s -> %s", strings.Join(path, " -> "), name)
+		}
+		state[name] = visiting
+		deps := append([]string(nil), c.Agents[name].DependsOn...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if _, ok := c.Agents[dep]; !ok {
+				continue // mcp_agent_mail, which is started first
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
 }
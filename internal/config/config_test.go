@@ -1,9 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfigStructure(t *testing.T) {
@@ -233,6 +238,29 @@ func TestValidateAgent(t *testing.T) {
 			wantError: true,
 			errorMsg:  "at least one phase is required",
 		},
+		{
+			name:      "pinned prompt",
+			agentName: "test-agent",
+			agent: AgentConfig{
+				Command: "echo",
+				Model:   "claude",
+				Phases:  []string{"planning"},
+				Prompt:  "planner@2",
+			},
+			wantError: false,
+		},
+		{
+			name:      "invalid prompt version",
+			agentName: "test-agent",
+			agent: AgentConfig{
+				Command: "echo",
+				Model:   "claude",
+				Phases:  []string{"planning"},
+				Prompt:  "planner@latest",
+			},
+			wantError: true,
+			errorMsg:  "invalid prompt version",
+		},
 	}
 
 	for _, tt := range tests {
@@ -449,6 +477,1746 @@ phases = ["implementation"]
 	}
 }
 
+func TestLoggingConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name       string
+		logging    string
+		wantFormat string
+		wantErr    bool
+	}{
+		{"default format", "", "text", false},
+		{"json format", "\n[logging]\nformat = \"json\"\n", "json", false},
+		{"logfmt format", "\n[logging]\nformat = \"logfmt\"\n", "logfmt", false},
+		{"invalid format", "\n[logging]\nformat = \"xml\"\n", "", true},
+		{"invalid level", "\n[logging]\nlevel = \"loud\"\n", "", true},
+		{"syslog local", "\n[logging.syslog]\nenabled = true\n", "text", false},
+		{"syslog remote without address", "\n[logging.syslog]\nenabled = true\nnetwork = \"udp\"\n", "", true},
+		{"ship loki", "\n[logging.ship]\nenabled = true\nbackend = \"loki\"\nurl = \"http://loki:3100\"\nflush_interval = \"10s\"\n", "text", false},
+		{"ship unknown backend", "\n[logging.ship]\nenabled = true\nbackend = \"splunk\"\nurl = \"http://x\"\n", "", true},
+		{"ship without url", "\n[logging.ship]\nenabled = true\nbackend = \"elasticsearch\"\n", "", true},
+		{"syslog invalid network", "\n[logging.syslog]\nenabled = true\nnetwork = \"http\"\naddress = \"x:1\"\n", "", true},
+		{"component levels", "\n[logging.levels]\nmcp = \"debug\"\nprocess = \"warn\"\n", "text", false},
+		{"invalid component level", "\n[logging.levels]\nmcp = \"chatty\"\n", "", true},
+		{"stderr level", "\n[logging]\nstderr = \"warn\"\n", "text", false},
+		{"invalid stderr level", "\n[logging]\nstderr = \"all\"\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.logging), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "logging.") {
+					t.Errorf("Expected logging validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if cfg.Logging.Format != tt.wantFormat {
+				t.Errorf("Logging.Format = %s, want %s", cfg.Logging.Format, tt.wantFormat)
+			}
+			if cfg.Logging.Level != "info" {
+				t.Errorf("Logging.Level = %s, want info", cfg.Logging.Level)
+			}
+		})
+	}
+}
+
+func TestCustomCheckConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name         string
+		checks       string
+		wantSeverity string
+		wantErr      bool
+	}{
+		{"default severity", "\n[check.custom.vpn]\ncommand = \"ping -c1 vpn.internal\"\n", "error", false},
+		{"warn severity", "\n[check.custom.vpn]\ncommand = \"ping -c1 vpn.internal\"\nseverity = \"warn\"\n", "warn", false},
+		{"missing command", "\n[check.custom.vpn]\nhint = \"Connect\"\n", "", true},
+		{"invalid severity", "\n[check.custom.vpn]\ncommand = \"true\"\nseverity = \"fatal\"\n", "", true},
+		{"invalid exit code", "\n[check.custom.vpn]\ncommand = \"true\"\nexit_code = 300\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.checks), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "check.custom.vpn") {
+					t.Errorf("Expected custom check validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if got := cfg.Check.Custom["vpn"].Severity; got != tt.wantSeverity {
+				t.Errorf("Severity = %s, want %s", got, tt.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestPipelineConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name     string
+		pipeline string
+		wantErr  bool
+	}{
+		{"disabled", "", false},
+		{"phases with gate", "\n[pipeline]\nphases = [\"planning\", \"testing\"]\ninterval = \"10s\"\n\n[pipeline.gates.testing]\nmin_tasks = 3\ncompletion = 0.8\n", false},
+		{"invalid phase", "\n[pipeline]\nphases = [\"planning\", \"shipping\"]\n", true},
+		{"duplicate phase", "\n[pipeline]\nphases = [\"planning\", \"planning\"]\n", true},
+		{"gate for unknown phase", "\n[pipeline]\nphases = [\"planning\"]\n\n[pipeline.gates.review]\nmin_tasks = 1\n", true},
+		{"completion out of range", "\n[pipeline]\nphases = [\"planning\"]\n\n[pipeline.gates.planning]\ncompletion = 1.5\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.pipeline), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "pipeline.") {
+					t.Errorf("Expected pipeline validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if tt.pipeline == "" {
+				if cfg.Pipeline.Enabled() {
+					t.Error("Expected the pipeline to be disabled")
+				}
+				return
+			}
+			if gate := cfg.Pipeline.Gate("testing"); gate.MinTasks != 3 || gate.Completion != 0.8 {
+				t.Errorf("Unexpected testing gate: %+v", gate)
+			}
+			if gate := cfg.Pipeline.Gate("planning"); gate.MinTasks != 1 || gate.Completion != 1 {
+				t.Errorf("Expected default gate for planning, got %+v", gate)
+			}
+			if cfg.Pipeline.Interval != 10*time.Second {
+				t.Errorf("Interval = %v, want 10s", cfg.Pipeline.Interval)
+			}
+		})
+	}
+}
+
+func TestBudgetConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+budget_usd = 5
+`
+
+	tests := []struct {
+		name    string
+		budget  string
+		wantErr bool
+	}{
+		{"agent budget only", "", false},
+		{"project budget", "\n[budget]\nproject_usd = 50\nperiod = \"daily\"\nwarn_at = [0.5, 0.9]\nnotify_url = \"https://hooks.example.com/asc\"\n", false},
+		{"negative budget", "\n[budget]\nproject_usd = -1\n", true},
+		{"unknown period", "\n[budget]\nperiod = \"weekly\"\n", true},
+		{"warn_at out of range", "\n[budget]\nwarn_at = [1.2]\n", true},
+		{"notify_url not http", "\n[budget]\nnotify_url = \"ftp://example.com\"\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.budget), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "budget.") {
+					t.Errorf("Expected budget validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if !cfg.BudgetEnabled() || cfg.Agents["test-agent"].BudgetUSD != 5 {
+				t.Errorf("Expected the agent budget to be loaded, got %+v", cfg.Agents["test-agent"])
+			}
+			if tt.budget == "" {
+				if cfg.Budget.Period != "monthly" || len(cfg.Budget.WarnAt) != 1 || cfg.Budget.CheckInterval != time.Minute {
+					t.Errorf("Expected budget defaults, got %+v", cfg.Budget)
+				}
+				return
+			}
+			if cfg.Budget.ProjectUSD != 50 || cfg.Budget.Period != "daily" || len(cfg.Budget.WarnAt) != 2 {
+				t.Errorf("Unexpected budget: %+v", cfg.Budget)
+			}
+		})
+	}
+}
+
+func TestAgentRestartConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name    string
+		restart string
+		wantErr bool
+	}{
+		{"defaults", "", false},
+		{"always without limit", "restart = \"always\"\nmax_restarts = -1\nrestart_backoff = \"10s\"\n", false},
+		{"unknown policy", "restart = \"sometimes\"\n", true},
+		{"max_restarts below -1", "max_restarts = -2\n", true},
+		{"negative backoff", "restart_backoff = \"-1s\"\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.restart), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "agent 'test-agent'") {
+					t.Errorf("Expected restart validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			agent := cfg.Agents["test-agent"]
+			if tt.restart == "" {
+				if agent.Restart != "on-failure" || agent.MaxRestarts != 5 || agent.RestartBackoff != time.Second {
+					t.Errorf("Expected restart defaults, got %+v", agent)
+				}
+				return
+			}
+			if agent.Restart != "always" || agent.MaxRestarts != -1 || agent.RestartBackoff != 10*time.Second {
+				t.Errorf("Unexpected restart policy: %+v", agent)
+			}
+		})
+	}
+}
+
+func TestAgentResourceLimitsConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name       string
+		limits     string
+		wantMemory int
+		wantCPU    float64
+		wantErr    bool
+	}{
+		{"no limits", "", 0, 0, false},
+		{"both limits", "max_memory_mb = 512\ncpu_limit = 1.5\n", 512, 1.5, false},
+		{"negative memory", "max_memory_mb = -1\n", 0, 0, true},
+		{"negative cpu", "cpu_limit = -0.5\n", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.limits), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "agent 'test-agent'") {
+					t.Errorf("Expected resource limit validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			agent := cfg.Agents["test-agent"]
+			if agent.MaxMemoryMB != tt.wantMemory || agent.CPULimit != tt.wantCPU {
+				t.Errorf("Expected limits %d MB, %g CPU, got %d MB, %g CPU", tt.wantMemory, tt.wantCPU, agent.MaxMemoryMB, agent.CPULimit)
+			}
+		})
+	}
+}
+
+func TestAgentEnvConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	checkout := filepath.Join(home, "checkout")
+	os.MkdirAll(checkout, 0755)
+	envFile := filepath.Join(checkout, ".env.agent")
+	if err := os.WriteFile(envFile, []byte("API_BASE=https://file.example.com\nREGION=eu\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	settings := `workdir = "~/checkout"
+env_file = "~/checkout/.env.agent"
+umask = "0027"
+
+[agent.test-agent.extra_env]
+api_base = "https://extra.example.com"
+`
+	if err := os.WriteFile(configPath, []byte(base+settings), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	agent := cfg.Agents["test-agent"]
+	if agent.Workdir != checkout || agent.EnvFile != envFile {
+		t.Errorf("Expected expanded paths, got workdir %s, env_file %s", agent.Workdir, agent.EnvFile)
+	}
+	if mask := agent.UmaskMode(); mask == nil || *mask != 0027 {
+		t.Errorf("Expected umask 0027, got %v", mask)
+	}
+	env, err := AgentEnv("test-agent", agent)
+	if err != nil {
+		t.Fatalf("AgentEnv() error = %v", err)
+	}
+	want := []string{"API_BASE=https://file.example.com", "REGION=eu", "API_BASE=https://extra.example.com"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("AgentEnv() = %v, want %v", env, want)
+	}
+
+	agent.EnvFile = filepath.Join(home, "missing.env")
+	if _, err := AgentEnv("test-agent", agent); err == nil || !contains(err.Error(), "env_file") {
+		t.Errorf("Expected a missing env_file to fail, got %v", err)
+	}
+	if (AgentConfig{}).UmaskMode() != nil {
+		t.Error("Expected no umask by default")
+	}
+
+	for _, invalid := range []string{"umask = \"0999\"\n", "umask = \"01777\"\n", "[agent.test-agent.extra_env]\n\"bad-name\" = \"x\"\n"} {
+		if err := os.WriteFile(configPath, []byte(base+invalid), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(configPath); err == nil || !contains(err.Error(), "agent 'test-agent'") {
+			t.Errorf("Expected %q to fail validation, got %v", invalid, err)
+		}
+	}
+}
+
+func TestAgentStopConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name       string
+		stop       string
+		wantSignal string
+		wantGrace  time.Duration
+		wantErr    string
+	}{
+		{"defaults", "", "", 0, ""},
+		{
+			"graceful",
+			"stop_signal = \"SIGINT\"\nstop_grace_period = \"30s\"\npre_stop = \"bd release --all\"\n",
+			"SIGINT", 30 * time.Second, "",
+		},
+		{"lowercase signal", "stop_signal = \"sigusr1\"\n", "sigusr1", 0, ""},
+		{"unsupported signal", "stop_signal = \"SIGKILL\"\n", "", 0, "unsupported stop_signal 'SIGKILL'"},
+		{"negative grace period", "stop_grace_period = \"-1s\"\n", "", 0, "stop_grace_period must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.stop), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			agent := cfg.Agents["test-agent"]
+			if agent.StopSignal != tt.wantSignal || agent.StopGracePeriod != tt.wantGrace {
+				t.Errorf("Expected stop_signal %q and stop_grace_period %v, got %q and %v", tt.wantSignal, tt.wantGrace, agent.StopSignal, agent.StopGracePeriod)
+			}
+		})
+	}
+}
+
+func TestAgentHealthCheckConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name    string
+		check   string
+		want    HealthCheckConfig
+		wantErr string
+	}{
+		{"none", "", HealthCheckConfig{}, ""},
+		{
+			"command defaults",
+			"[agent.test-agent.health_check]\ncommand = \"test -f /tmp/ok\"\n",
+			HealthCheckConfig{Command: "test -f /tmp/ok", Interval: 30 * time.Second, Timeout: 10 * time.Second, Retries: 3, Action: "restart"},
+			"",
+		},
+		{
+			"heartbeat",
+			"[agent.test-agent.health_check]\nheartbeat = true\ninterval = \"1m\"\naction = \"degrade\"\n",
+			HealthCheckConfig{Heartbeat: true, MaxHeartbeatAge: 2 * time.Minute, Interval: time.Minute, Timeout: 10 * time.Second, Retries: 3, Action: "degrade"},
+			"",
+		},
+		{
+			"two probes",
+			"[agent.test-agent.health_check]\ncommand = \"true\"\nheartbeat = true\n",
+			HealthCheckConfig{}, "set only one of command, url, and heartbeat",
+		},
+		{
+			"action without probe",
+			"[agent.test-agent.health_check]\naction = \"degrade\"\n",
+			HealthCheckConfig{}, "set one of command, url, and heartbeat",
+		},
+		{
+			"notify without command",
+			"[agent.test-agent.health_check]\nurl = \"http://localhost:9000/health\"\naction = \"notify\"\n",
+			HealthCheckConfig{}, "action 'notify' requires notify_command",
+		},
+		{
+			"unsupported action",
+			"[agent.test-agent.health_check]\ncommand = \"true\"\naction = \"reboot\"\n",
+			HealthCheckConfig{}, "unsupported action 'reboot'",
+		},
+		{
+			"invalid url",
+			"[agent.test-agent.health_check]\nurl = \"localhost:9000\"\n",
+			HealthCheckConfig{}, "health_check.url must be an http or https URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.check), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if got := cfg.Agents["test-agent"].HealthCheck; got != tt.want {
+				t.Errorf("Expected health_check %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAgentDockerConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake docker CLI is a shell script")
+	}
+	// Validation looks the docker CLI up in PATH
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake docker: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name       string
+		agent      string
+		wantDocker bool
+		wantErr    string
+	}{
+		{"process by default", "command = \"echo\"\n", false, ""},
+		{
+			"docker without command",
+			"runtime = \"docker\"\n[agent.test-agent.docker]\nimage = \"python:3.12\"\nvolumes = [\"./src:/src:ro\", \"cache:/root/.cache\"]\nenv = [\"CLAUDE_API_KEY\", \"MODE=test\"]\nnetwork = \"host\"\n",
+			true, "",
+		},
+		{
+			"command not on the host",
+			"command = \"agent-only-in-image run\"\nruntime = \"docker\"\n[agent.test-agent.docker]\nimage = \"agent:latest\"\n",
+			true, "",
+		},
+		{"unsupported runtime", "command = \"echo\"\nruntime = \"vm\"\n", false, "unsupported runtime 'vm'"},
+		{"docker without image", "runtime = \"docker\"\n", false, "docker.image is required"},
+		{
+			"docker settings without runtime",
+			"command = \"echo\"\n[agent.test-agent.docker]\nimage = \"python:3.12\"\n",
+			false, "docker settings need runtime = \"docker\"",
+		},
+		{
+			"relative container path",
+			"runtime = \"docker\"\n[agent.test-agent.docker]\nimage = \"python:3.12\"\nvolumes = [\"./src:src\"]\n",
+			false, "must mount at an absolute path",
+		},
+		{
+			"volume without container path",
+			"runtime = \"docker\"\n[agent.test-agent.docker]\nimage = \"python:3.12\"\nvolumes = [\"./src\"]\n",
+			false, "must have the form host:container[:options]",
+		},
+		{
+			"invalid env",
+			"runtime = \"docker\"\n[agent.test-agent.docker]\nimage = \"python:3.12\"\nenv = [\"1BAD=x\"]\n",
+			false, "env '1BAD=x' must be NAME or NAME=value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.agent), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			agent := cfg.Agents["test-agent"]
+			if got := agent.UsesDocker(); got != tt.wantDocker {
+				t.Errorf("Expected UsesDocker() = %v, got %v (runtime %q)", tt.wantDocker, got, agent.Runtime)
+			}
+			if !tt.wantDocker && agent.Runtime != "process" {
+				t.Errorf("Expected runtime to default to process, got %q", agent.Runtime)
+			}
+		})
+	}
+}
+
+func TestLogRotationConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+`
+	off := false
+	agent := `
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name    string
+		config  string
+		want    LogRotationConfig
+		wantErr string
+	}{
+		{"defaults", base + agent, LogRotationConfig{MaxSizeMB: 10, MaxFiles: 5}, ""},
+		{
+			"agent override",
+			base + "\n[logging.rotation]\nmax_size_mb = 50\nmax_age_days = 7\ncompress = true\n" + agent + "\n[agent.test-agent.log_rotation]\nmax_files = 2\ncompress = false\n",
+			LogRotationConfig{MaxSizeMB: 50, MaxFiles: 2, MaxAgeDays: 7, Compress: &off},
+			"",
+		},
+		{"never rotate", base + "\n[logging.rotation]\nmax_size_mb = -1\n" + agent, LogRotationConfig{MaxSizeMB: -1, MaxFiles: 5}, ""},
+		{"invalid size", base + "\n[logging.rotation]\nmax_size_mb = -2\n" + agent, LogRotationConfig{}, "logging.rotation.max_size_mb"},
+		{"invalid agent max_files", base + agent + "\n[agent.test-agent.log_rotation]\nmax_files = -1\n", LogRotationConfig{}, "agent 'test-agent'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			got := cfg.LogRotationFor(cfg.Agents["test-agent"])
+			if got.MaxSizeMB != tt.want.MaxSizeMB || got.MaxFiles != tt.want.MaxFiles || got.MaxAgeDays != tt.want.MaxAgeDays ||
+				(got.Compress == nil) != (tt.want.Compress == nil) || (got.Compress != nil && *got.Compress != *tt.want.Compress) {
+				t.Errorf("LogRotationFor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrashesConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name    string
+		config  string
+		want    CrashesConfig
+		wantErr string
+	}{
+		{"defaults", base, CrashesConfig{LogKB: 64, MaxFiles: 20}, ""},
+		{"set", base + "\n[logging.crashes]\nlog_kb = 8\nmax_files = 3\n", CrashesConfig{LogKB: 8, MaxFiles: 3}, ""},
+		{"invalid log_kb", base + "\n[logging.crashes]\nlog_kb = -1\n", CrashesConfig{}, "logging.crashes.log_kb"},
+		{"invalid max_files", base + "\n[logging.crashes]\nmax_files = -1\n", CrashesConfig{}, "logging.crashes.max_files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if cfg.Logging.Crashes != tt.want {
+				t.Errorf("Logging.Crashes = %+v, want %+v", cfg.Logging.Crashes, tt.want)
+			}
+		})
+	}
+}
+
+func TestTelemetryConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name    string
+		config  string
+		want    TelemetryConfig
+		wantErr string
+	}{
+		{"defaults", base, TelemetryConfig{ServiceName: "asc"}, ""},
+		{"set", base + `
+[telemetry]
+enabled = true
+endpoint = "https://otel.example.com:4318"
+service_name = "asc-ci"
+headers = { "X-Scope-OrgID" = "team-a" }
+auth_token_env = "OTEL_TOKEN"
+logs = true
+flush_interval = "2s"
+`, TelemetryConfig{
+			Enabled:       true,
+			Endpoint:      "https://otel.example.com:4318",
+			ServiceName:   "asc-ci",
+			Headers:       map[string]string{"x-scope-orgid": "team-a"},
+			AuthTokenEnv:  "OTEL_TOKEN",
+			Logs:          true,
+			FlushInterval: 2 * time.Second,
+		}, ""},
+		{"invalid endpoint", base + "\n[telemetry]\nenabled = true\nendpoint = \"localhost:4318\"\n", TelemetryConfig{}, "telemetry.endpoint"},
+		{"invalid flush_interval", base + "\n[telemetry]\nenabled = true\nflush_interval = \"-1s\"\n", TelemetryConfig{}, "telemetry.flush_interval"},
+		{"invalid proxy", base + "\n[telemetry]\nenabled = true\nproxy = \"::bad\"\n", TelemetryConfig{}, "telemetry.proxy"},
+		{"disabled is not validated", base + "\n[telemetry]\nendpoint = \"localhost:4318\"\n", TelemetryConfig{Endpoint: "localhost:4318", ServiceName: "asc"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Telemetry, tt.want) {
+				t.Errorf("Telemetry = %+v, want %+v", cfg.Telemetry, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+	defaultEvents := []string{"agent.crashed", "task.stuck", "doctor.critical", "stack.started", "stack.stopped"}
+	defaultChat := ChatConfig{Events: defaultEvents, Username: "asc", RateLimit: 10, Timeout: 10 * time.Second}
+
+	tests := []struct {
+		name    string
+		config  string
+		want    NotifyConfig
+		wantErr string
+	}{
+		{"defaults", base, NotifyConfig{
+			Webhook: WebhookConfig{Events: defaultEvents, RateLimit: 10, Timeout: 10 * time.Second},
+			Slack:   defaultChat,
+			Discord: defaultChat,
+		}, ""},
+		{"set", base + `
+[notify.webhook]
+url = "https://hooks.example.com/asc"
+events = ["agent", "doctor.critical"]
+headers = { "X-Team" = "platform" }
+rate_limit = -1
+timeout = "3s"
+
+[notify.slack]
+url_env = "SLACK_WEBHOOK_URL"
+events = ["agent.crashed"]
+username = "asc-ci"
+rate_limit = 5
+
+[notify.slack.templates]
+agent_crashed = "{{.Agent}} is down"
+
+[notify.discord]
+url = "https://discord.com/api/webhooks/1/abc"
+`, NotifyConfig{
+			Webhook: WebhookConfig{
+				URL:       "https://hooks.example.com/asc",
+				Events:    []string{"agent", "doctor.critical"},
+				Headers:   map[string]string{"x-team": "platform"},
+				RateLimit: -1,
+				Timeout:   3 * time.Second,
+			},
+			Slack: ChatConfig{
+				URLEnv:    "SLACK_WEBHOOK_URL",
+				Events:    []string{"agent.crashed"},
+				Templates: map[string]string{"agent_crashed": "{{.Agent}} is down"},
+				Username:  "asc-ci",
+				RateLimit: 5,
+				Timeout:   10 * time.Second,
+			},
+			Discord: ChatConfig{URL: "https://discord.com/api/webhooks/1/abc", Events: defaultEvents, Username: "asc", RateLimit: 10, Timeout: 10 * time.Second},
+		}, ""},
+		{"invalid url", base + "\n[notify.webhook]\nurl = \"hooks.example.com\"\n", NotifyConfig{}, "notify.webhook.url"},
+		{"url and url_env", base + "\n[notify.webhook]\nurl = \"https://hooks.example.com\"\nurl_env = \"ASC_WEBHOOK_URL\"\n", NotifyConfig{}, "not both"},
+		{"empty event", base + "\n[notify.webhook]\nevents = [\"agent.crashed\", \"\"]\n", NotifyConfig{}, "notify.webhook.events"},
+		{"invalid rate_limit", base + "\n[notify.discord]\nrate_limit = -2\n", NotifyConfig{}, "notify.discord.rate_limit"},
+		{"invalid timeout", base + "\n[notify.webhook]\ntimeout = \"-1s\"\n", NotifyConfig{}, "notify.webhook.timeout"},
+		{"invalid proxy", base + "\n[notify.slack]\nproxy = \"::bad\"\n", NotifyConfig{}, "notify.slack.proxy"},
+		{"invalid template", base + "\n[notify.slack.templates]\ndefault = \"{{.Agent\"\n", NotifyConfig{}, "notify.slack.templates.default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Notify, tt.want) {
+				t.Errorf("Notify = %+v, want %+v", cfg.Notify, tt.want)
+			}
+		})
+	}
+}
+
+func TestBeadsModeConfig(t *testing.T) {
+	services := `
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+	tests := []struct {
+		name    string
+		mode    string
+		want    string
+		wantErr string
+	}{
+		{"default", "", "auto", ""},
+		{"sqlite", "sqlite", "sqlite", ""},
+		{"cli", "cli", "cli", ""},
+		{"invalid", "native", "", "core.beads_mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := "[core]\nbeads_db_path = \"./test-repo\"\n"
+			if tt.mode != "" {
+				config += "beads_mode = \"" + tt.mode + "\"\n"
+			}
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(config+services), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if cfg.Core.BeadsMode != tt.want {
+				t.Errorf("BeadsMode = %q, want %q", cfg.Core.BeadsMode, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitHubSyncConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+	tests := []struct {
+		name    string
+		section string
+		wantErr string
+	}{
+		{"defaults", "", ""},
+		{"full", "repo = \"acme/web\"\nlabel = \"asc\"\nconflict = \"skip\"\n\n[sync.github.assignees]\ncoder = \"octocat\"\n", ""},
+		{"invalid repo", "repo = \"acme\"\n", "sync.github.repo"},
+		{"invalid conflict", "conflict = \"newest\"\n", "sync.github.conflict"},
+		{"invalid api url", "api_url = \"api.github.com\"\n", "sync.github.api_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+"\n[sync.github]\n"+tt.section), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			github := cfg.Sync.GitHub
+			if github.TokenEnv != "GITHUB_TOKEN" || github.APIURL != "https://api.github.com" || github.Timeout != 30*time.Second {
+				t.Errorf("Expected the defaults, got %+v", github)
+			}
+			if tt.name == "full" && (github.Repo != "acme/web" || github.Conflict != "skip" || github.Assignees["coder"] != "octocat") {
+				t.Errorf("Expected the configured settings, got %+v", github)
+			}
+			if tt.name == "defaults" && github.Conflict != "github" {
+				t.Errorf("Conflict = %q, want github", github.Conflict)
+			}
+		})
+	}
+}
+
+func TestJiraSyncConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+	tests := []struct {
+		name    string
+		section string
+		wantErr string
+	}{
+		{"defaults", "", ""},
+		{"full", "url = \"https://acme.atlassian.net\"\njql = \"project = WEB\"\nemail = \"ci@acme.dev\"\nconflict = \"beads\"\n\n[sync.jira.statuses]\nopen = \"Backlog\"\nclosed = \"Shipped\"\n", ""},
+		{"invalid url", "url = \"acme.atlassian.net\"\n", "sync.jira.url"},
+		{"invalid conflict", "conflict = \"github\"\n", "sync.jira.conflict"},
+		{"unknown status", "[sync.jira.statuses]\nreview = \"In Review\"\n", "unknown beads status 'review'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+"\n[sync.jira]\n"+tt.section), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			jira := cfg.Sync.Jira
+			if jira.TokenEnv != "JIRA_API_TOKEN" || jira.Timeout != 30*time.Second {
+				t.Errorf("Expected the defaults, got %+v", jira)
+			}
+			if tt.name == "full" && (jira.JQL != "project = WEB" || jira.Conflict != "beads" || jira.Statuses["closed"] != "Shipped" || jira.Statuses["in_progress"] != "") {
+				t.Errorf("Expected the configured settings, got %+v", jira)
+			}
+			if tt.name == "defaults" && (jira.Conflict != "jira" || jira.Statuses["in_progress"] != "In Progress") {
+				t.Errorf("Expected the default conflict rule and statuses, got %+v", jira)
+			}
+		})
+	}
+}
+
+func TestStartupDependenciesConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+`
+	agent := func(name, extra string) string {
+		return fmt.Sprintf("\n[agent.%s]\ncommand = \"echo\"\nmodel = \"claude\"\nphases = [\"planning\"]\n%s", name, extra)
+	}
+
+	tests := []struct {
+		name      string
+		config    string
+		wantOrder []string
+		wantErr   string
+	}{
+		{"by name", base + agent("b", "") + agent("a", ""), []string{"a", "b"}, ""},
+		{
+			"dependencies first",
+			base + agent("coder", `depends_on = ["planner", "mcp_agent_mail"]`) +
+				agent("planner", `depends_on = ["indexer"]`) +
+				agent("indexer", "[agent.indexer.ready_check]\nlog_regex = \"index built\"\n"),
+			[]string{"indexer", "planner", "coder"},
+			"",
+		},
+		{"unknown dependency", base + agent("coder", `depends_on = ["planer"]`), nil, "unknown agent 'planer'"},
+		{"self dependency", base + agent("coder", `depends_on = ["coder"]`), nil, "lists the agent itself"},
+		{"cycle", base + agent("a", `depends_on = ["b"]`) + agent("b", `depends_on = ["a"]`), nil, "a -> b -> a"},
+		{"two checks", base + agent("a", "[agent.a.ready_check]\nport = 8080\nurl = \"http://localhost:8080\"\n"), nil, "set only one of"},
+		{"invalid port", base + agent("a", "[agent.a.ready_check]\nport = 70000\n"), nil, "ready_check.port"},
+		{"invalid regex", base + agent("a", "[agent.a.ready_check]\nlog_regex = \"(\"\n"), nil, "ready_check.log_regex"},
+		{"invalid mcp url", base + "\n[services.mcp_agent_mail.ready_check]\nurl = \"localhost:8765\"\n" + agent("a", ""), nil, "services.mcp_agent_mail.ready_check.url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			order, err := cfg.StartOrder()
+			if err != nil || strings.Join(order, ",") != strings.Join(tt.wantOrder, ",") {
+				t.Errorf("StartOrder() = %v, %v, want %v", order, err, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestReadyCheckDefaults(t *testing.T) {
+	configContent := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.indexer]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+
+[agent.indexer.ready_check]
+port = 9000
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+`
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+
+	if cfg.Services.MCPAgentMail.ReadyCheck.Timeout != 30*time.Second {
+		t.Errorf("Expected mcp_agent_mail ready timeout of 30s, got %v", cfg.Services.MCPAgentMail.ReadyCheck.Timeout)
+	}
+	if got := cfg.Agents["indexer"].ReadyCheck; got.Port != 9000 || got.Timeout != 30*time.Second {
+		t.Errorf("Unexpected indexer ready check %+v", got)
+	}
+	if cfg.Agents["coder"].ReadyCheck.IsSet() {
+		t.Error("Expected coder to have no ready check")
+	}
+}
+
+func TestWorktreeConfig(t *testing.T) {
+	configContent := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+
+[agent.planner]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+worktree = false
+
+[worktree]
+enabled = true
+`
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	if cfg.Worktree.BranchPrefix != "asc/" {
+		t.Errorf("BranchPrefix = %q, want default asc/", cfg.Worktree.BranchPrefix)
+	}
+	if !cfg.UsesWorktree(cfg.Agents["coder"]) || cfg.UsesWorktree(cfg.Agents["planner"]) {
+		t.Error("Expected coder to use a worktree and planner to opt out")
+	}
+
+	invalid := strings.Replace(configContent, "enabled = true", "enabled = true\nbranch_prefix = \"agents..\"", 1)
+	if err := os.WriteFile(configPath, []byte(invalid), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !contains(err.Error(), "worktree.branch_prefix") {
+		t.Errorf("Expected a branch_prefix validation error, got %v", err)
+	}
+}
+
+func TestSecretsConfig(t *testing.T) {
+	configContent := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+
+[secrets]
+recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+rotation_days = 90
+
+[secrets.env]
+CLAUDE_API_KEY = "aws-sm://prod/asc/claude"
+`
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	if len(cfg.Secrets.Recipients) != 1 {
+		t.Errorf("Expected 1 recipient, got %v", cfg.Secrets.Recipients)
+	}
+	if cfg.Secrets.Env["CLAUDE_API_KEY"] != "aws-sm://prod/asc/claude" {
+		t.Errorf("Expected the reference under its upper-case name, got %v", cfg.Secrets.Env)
+	}
+	if cfg.Secrets.RotationDays != 90 {
+		t.Errorf("Expected rotation_days 90, got %d", cfg.Secrets.RotationDays)
+	}
+
+	invalid := strings.Replace(configContent, `recipients = ["`, `recipients = ["ssh-rsa AAAA", "`, 1)
+	if err := os.WriteFile(configPath, []byte(invalid), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !contains(err.Error(), "secrets.recipients[0]") {
+		t.Errorf("Expected a recipient validation error, got %v", err)
+	}
+
+	invalid = strings.Replace(configContent, `"aws-sm://prod/asc/claude"`, `"sk-plain-key"`, 1)
+	if err := os.WriteFile(configPath, []byte(invalid), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !contains(err.Error(), "secrets.env.CLAUDE_API_KEY") {
+		t.Errorf("Expected a secret reference validation error, got %v", err)
+	}
+
+	invalid = strings.Replace(configContent, "rotation_days = 90", "rotation_days = -1", 1)
+	if err := os.WriteFile(configPath, []byte(invalid), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil || !contains(err.Error(), "secrets.rotation_days") {
+		t.Errorf("Expected a rotation_days validation error, got %v", err)
+	}
+}
+
+func TestMCPTimeoutConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+`
+	agent := `
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name        string
+		settings    string
+		wantErr     bool
+		wantConnect time.Duration
+		wantRead    time.Duration
+		wantRetries int
+	}{
+		{"defaults", "", false, 2 * time.Second, 5 * time.Second, 3},
+		{"custom", "connect_timeout = \"500ms\"\nread_timeout = \"30s\"\nmax_retries = 1\nretry_backoff = \"2s\"\n", false, 500 * time.Millisecond, 30 * time.Second, 1},
+		{"no retries", "max_retries = 0\n", false, 2 * time.Second, 5 * time.Second, 0},
+		{"negative timeout", "read_timeout = \"-1s\"\n", true, 0, 0, 0},
+		{"negative retries", "max_retries = -1\n", true, 0, 0, 0},
+		{"backoff over its cap", "retry_backoff = \"5s\"\nmax_retry_backoff = \"2s\"\n", true, 0, 0, 0},
+		{"negative breaker threshold", "breaker_threshold = -1\n", true, 0, 0, 0},
+		{"negative breaker cooldown", "breaker_cooldown = \"-1s\"\n", true, 0, 0, 0},
+		{"negative queue size", "queue_size = -1\n", true, 0, 0, 0},
+		{"batching with compression", "compression = \"zstd\"\nbatch_window = \"20ms\"\nbatch_size = 50\n", false, 2 * time.Second, 5 * time.Second, 3},
+		{"negative batch window", "batch_window = \"-1ms\"\n", true, 0, 0, 0},
+		{"unknown compression", "compression = \"brotli\"\n", true, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.settings+agent), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "services.mcp_agent_mail") {
+					t.Errorf("Expected services.mcp_agent_mail validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			mcp := cfg.Services.MCPAgentMail
+			if mcp.ConnectTimeout != tt.wantConnect || mcp.ReadTimeout != tt.wantRead || mcp.Retries() != tt.wantRetries {
+				t.Errorf("Unexpected MCP settings: connect %v, read %v, retries %d", mcp.ConnectTimeout, mcp.ReadTimeout, mcp.Retries())
+			}
+		})
+	}
+}
+
+func TestMCPBreakerConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+`
+	agent := `
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name          string
+		settings      string
+		wantMax       time.Duration
+		wantThreshold int
+		wantCooldown  time.Duration
+	}{
+		{"defaults", "", 10 * time.Second, 5, 30 * time.Second},
+		{"custom", "max_retry_backoff = \"1m\"\nbreaker_threshold = 3\nbreaker_cooldown = \"2m\"\n", time.Minute, 3, 2 * time.Minute},
+		{"disabled", "breaker_threshold = 0\n", 10 * time.Second, 0, 30 * time.Second},
+		{"long backoff raises the cap", "retry_backoff = \"20s\"\n", 20 * time.Second, 5, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.settings+agent), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			mcp := cfg.Services.MCPAgentMail
+			if mcp.MaxRetryBackoff != tt.wantMax || mcp.BreakerFailures() != tt.wantThreshold || mcp.BreakerCooldown != tt.wantCooldown {
+				t.Errorf("Unexpected MCP settings: max backoff %v, breaker threshold %d, cooldown %v", mcp.MaxRetryBackoff, mcp.BreakerFailures(), mcp.BreakerCooldown)
+			}
+		})
+	}
+}
+
+func TestMCPProtocolConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+`
+	agent := `
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+		wantErr  bool
+	}{
+		{"default", "", ProtocolHTTP, false},
+		{"grpc", "protocol = \"grpc\"\n", ProtocolGRPC, false},
+		{"unknown", "protocol = \"quic\"\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.settings+agent), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "services.mcp_agent_mail.protocol") {
+					t.Errorf("Expected services.mcp_agent_mail.protocol error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if got := cfg.Services.MCPAgentMail.Protocol; got != tt.want {
+				t.Errorf("Protocol = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMCPServersConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+
+[services.mcp_shard_b]
+url = "http://localhost:8766"
+start_command = "python -m mcp_agent_mail.server --port 8766"
+read_timeout = "10s"
+
+[agent.planner]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+
+[agent.tester]
+command = "echo"
+model = "claude"
+phases = ["testing"]
+mcp_server = "mcp_shard_b"
+`
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	if err := os.WriteFile(configPath, []byte(base), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+
+	if names := cfg.Services.MCPServerNames(); len(names) != 2 || names[0] != DefaultMCPServer || names[1] != "mcp_shard_b" {
+		t.Errorf("MCPServerNames() = %v, want mcp_agent_mail and mcp_shard_b", names)
+	}
+	shard, ok := cfg.Services.MCPServer("mcp_shard_b")
+	if !ok || shard.URL != "http://localhost:8766" || shard.ReadTimeout != 10*time.Second || shard.ConnectTimeout != 2*time.Second {
+		t.Errorf("MCPServer(mcp_shard_b) = %+v, %v; want its settings with defaults", shard, ok)
+	}
+	if name, server := cfg.MCPServerFor(cfg.Agents["tester"]); name != "mcp_shard_b" || server.URL != shard.URL {
+		t.Errorf("MCPServerFor(tester) = %s, want mcp_shard_b", name)
+	}
+	if name, _ := cfg.MCPServerFor(cfg.Agents["planner"]); name != DefaultMCPServer {
+		t.Errorf("MCPServerFor(planner) = %s, want mcp_agent_mail", name)
+	}
+	if routes := cfg.MCPRoutes(); len(routes) != 1 || routes["tester"] != "mcp_shard_b" {
+		t.Errorf("MCPRoutes() = %v, want tester on mcp_shard_b", routes)
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr string
+	}{
+		{"unknown server", `mcp_server = "mcp_shard_b"`, `mcp_server = "mcp_shard_c"`, "unknown MCP server 'mcp_shard_c'"},
+		{"server without url", `url = "http://localhost:8766"`, ``, "services.mcp_shard_b.url is required"},
+		{"invalid server setting", `read_timeout = "10s"`, `read_timeout = "-1s"`, "services.mcp_shard_b: connect_timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(strings.Replace(base, tt.from, tt.to, 1)), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+			if _, err := Load(configPath); err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMCPAuthConfig(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, []byte("ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	agent := `
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"https with CA", "url = \"https://mcp.example:8765\"\nca_file = \"" + filepath.ToSlash(caFile) + "\"\ntoken_env = \"MCP_TEST_TOKEN\"\n", ""},
+		{"CA over http", "url = \"http://localhost:8765\"\nca_file = \"" + filepath.ToSlash(caFile) + "\"\n", "need an https url"},
+		{"certificate without key", "url = \"https://mcp.example:8765\"\ncert_file = \"" + filepath.ToSlash(caFile) + "\"\n", "cert_file and key_file must be set together"},
+		{"missing CA", "url = \"https://mcp.example:8765\"\nca_file = \"" + filepath.ToSlash(filepath.Join(dir, "missing.pem")) + "\"\n", "services.mcp_agent_mail.ca_file"},
+		{"invalid token variable", "url = \"http://localhost:8765\"\ntoken_env = \"MCP-TOKEN\"\n", "services.mcp_agent_mail.token_env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			config := "[core]\nbeads_db_path = \"./test-repo\"\n\n[services.mcp_agent_mail]\nstart_command = \"python -m mcp_agent_mail.server\"\n" + tt.settings + agent
+			if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			t.Setenv("MCP_TEST_TOKEN", "s3cret-token")
+			if mcp := cfg.Services.MCPAgentMail; mcp.CAFile != caFile || mcp.Token() != "s3cret-token" {
+				t.Errorf("Unexpected MCP settings: ca_file %s, token %q", mcp.CAFile, mcp.Token())
+			}
+		})
+	}
+}
+
+func TestTUIConfig(t *testing.T) {
+	base := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+start_command = "python -m mcp_agent_mail.server"
+url = "http://localhost:8765"
+
+[agent.test-agent]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`
+
+	tests := []struct {
+		name        string
+		section     string
+		wantErr     bool
+		wantHistory int
+	}{
+		{"defaults", "", false, 1000},
+		{"custom", "\n[tui]\nmessage_history = 250\n", false, 250},
+		{"negative", "\n[tui]\nmessage_history = -1\n", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "asc.toml")
+			if err := os.WriteFile(configPath, []byte(base+tt.section), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "tui.message_history") {
+					t.Errorf("Expected tui.message_history validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error loading config: %v", err)
+			}
+			if cfg.TUI.MessageHistory != tt.wantHistory {
+				t.Errorf("MessageHistory = %d, want %d", cfg.TUI.MessageHistory, tt.wantHistory)
+			}
+		})
+	}
+}
+
+func TestReplicasConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	write := func(agents string) {
+		t.Helper()
+		content := `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+` + agents
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test config: %v", err)
+		}
+	}
+
+	write(`
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+replicas = 3
+
+[agent.reviewer]
+command = "echo"
+model = "claude"
+phases = ["review"]
+depends_on = ["coder"]
+`)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	if got := cfg.Instances("coder"); !reflect.DeepEqual(got, []string{"coder-1", "coder-2", "coder-3"}) {
+		t.Errorf("Instances(coder) = %q", got)
+	}
+	if _, ok := cfg.Agents["coder"]; ok {
+		t.Error("Expected an agent with replicas to run only as its instances")
+	}
+	if agent := cfg.Agents["coder-2"]; agent.Role != "coder" || agent.Replica != 2 || agent.Command != "echo" {
+		t.Errorf("coder-2 = %+v, want the second instance of coder", agent)
+	}
+	if got := cfg.Agents["reviewer"].DependsOn; !reflect.DeepEqual(got, []string{"coder-1", "coder-2", "coder-3"}) {
+		t.Errorf("Expected depending on a role to depend on its instances, got %q", got)
+	}
+
+	// The counts asc scale records override asc.toml
+	path, err := ReplicasPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveReplicas(path, configPath, map[string]int{"coder": 1}); err != nil {
+		t.Fatalf("SaveReplicas() error = %v", err)
+	}
+	if cfg, err = Load(configPath); err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	if got := cfg.Instances("coder"); !reflect.DeepEqual(got, []string{"coder-1"}) {
+		t.Errorf("Instances(coder) = %q, want the recorded count", got)
+	}
+
+	// They are kept by file: another project's coder is not scaled
+	other := filepath.Join(t.TempDir(), "asc.toml")
+	data, _ := os.ReadFile(configPath)
+	if err := os.WriteFile(other, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(other); err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	if got := cfg.Instances("coder"); len(got) != 3 {
+		t.Errorf("Instances(coder) = %q, want the replicas of the other file", got)
+	}
+	if err := SaveReplicas(path, configPath, map[string]int{}); err != nil {
+		t.Fatalf("SaveReplicas() error = %v", err)
+	}
+
+	write(`
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+replicas = -1
+`)
+	if _, err := Load(configPath); err == nil || !contains(err.Error(), "must not be negative") {
+		t.Errorf("Expected negative replicas to fail, got %v", err)
+	}
+
+	write(`
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+replicas = 2
+
+[agent.coder-2]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+`)
+	if _, err := Load(configPath); err == nil || !contains(err.Error(), "has the name of an instance") {
+		t.Errorf("Expected an agent named as an instance to fail, got %v", err)
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || 
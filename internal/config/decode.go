@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// decodeHook converts strings as viper's Unmarshal does: to durations, and
+// split at commas to slices
+var decodeHook = mapstructure.ComposeDecodeHookFunc(
+	mapstructure.StringToTimeDurationHookFunc(),
+	func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() != reflect.String || t.Kind() != reflect.Slice {
+			return data, nil
+		}
+		if data.(string) == "" {
+			return []string{}, nil
+		}
+		return strings.Split(data.(string), ","), nil
+	},
+)
+
+// decodeSettings decodes settings, the keys of asc.toml, into cfg as
+// viper's Unmarshal does. mapstructure visits every field of a struct it
+// decodes a table into, while asc.toml sets few of the many settings of
+// an agent, so tables are decoded here by their keys and only the values
+// in them by mapstructure.
+func decodeSettings(settings map[string]any, cfg *Config) error {
+	return decodeTable("", settings, reflect.ValueOf(cfg).Elem())
+}
+
+// decodeTable decodes the keys of table into the struct out, named by
+// name in errors. Keys no field has are left to unknownKeys.
+func decodeTable(name string, table map[string]any, out reflect.Value) error {
+	fields := configFields(out.Type())
+	for key, value := range table {
+		field, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if err := decodeValue(strings.TrimPrefix(name+"."+key, "."), value, out.FieldByIndex(field.Index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeValue decodes value into out, named by name in errors
+func decodeValue(name string, value any, out reflect.Value) error {
+	table, isTable := value.(map[string]any)
+	switch {
+	case isTable && out.Kind() == reflect.Struct:
+		return decodeTable(name, table, out)
+	case isTable && out.Kind() == reflect.Map && out.Type().Key().Kind() == reflect.String && out.Type().Elem().Kind() == reflect.Struct:
+		if out.IsNil() {
+			out.Set(reflect.MakeMapWithSize(out.Type(), len(table)))
+		}
+		for key, item := range table {
+			elem := reflect.New(out.Type().Elem()).Elem()
+			if err := decodeValue(name+"."+key, item, elem); err != nil {
+				return err
+			}
+			out.SetMapIndex(reflect.ValueOf(key).Convert(out.Type().Key()), elem)
+		}
+		return nil
+	case value != nil && reflect.TypeOf(value) == out.Type():
+		out.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		DecodeHook:       decodeHook,
+		Result:           out.Addr().Interface(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestDecodeSettings(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"asc.toml": `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+read_timeout = "3s"
+max_retries = 0
+
+[budget]
+project_usd = 25
+warn_at = "0.5"
+
+[pipeline]
+phases = ["planning", "implementation"]
+interval = "1m"
+
+[pipeline.gates.planning]
+min_tasks = 2
+completion = 1
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = "planning,implementation"
+worktree = false
+extra_env = { LEVEL = "debug" }
+
+[agent.coder.health_check]
+url = "http://localhost:9000/health"
+interval = "15s"
+retries = "5"
+`,
+	})
+
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(dir, "asc.toml"))
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	var want Config
+	if err := v.Unmarshal(&want); err != nil {
+		t.Fatal(err)
+	}
+	var got Config
+	if err := decodeSettings(v.AllSettings(), &got); err != nil {
+		t.Fatalf("decodeSettings() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeSettings() = %+v\nwant as viper decodes it: %+v", got, want)
+	}
+
+	// Errors name the key of the value that cannot be decoded
+	err := decodeSettings(map[string]any{"agent": map[string]any{"coder": map[string]any{"budget_usd": "lots"}}}, &got)
+	if err == nil || !strings.Contains(err.Error(), "agent.coder.budget_usd") {
+		t.Errorf("Expected an error naming agent.coder.budget_usd, got %v", err)
+	}
+}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// ErrKeyNotSet is returned by GetValue for a key asc.toml does not set
+var ErrKeyNotSet = errors.New("key not set")
+
+// keyPart is a part of a dotted key accepted by GetValue and SetValue
+var keyPart = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// splitKey splits a dotted key such as agent.coder.model into its parts
+func splitKey(key string) ([]string, error) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts {
+		if !keyPart.MatchString(part) {
+			return nil, fmt.Errorf("invalid key %q: use dotted names such as agent.coder.model", key)
+		}
+	}
+	return parts, nil
+}
+
+// lookup returns the value at path in a decoded TOML document
+func lookup(doc map[string]any, path []string) (any, bool) {
+	var value any = doc
+	for _, part := range path {
+		table, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = table[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// GetValue returns the value data, a TOML document, sets for a dotted key:
+// a string, int64, float64, bool, time, []any, or map[string]any for a
+// table. It returns ErrKeyNotSet if the key is not set; defaults are not
+// applied.
+func GetValue(data []byte, key string) (any, error) {
+	path, err := splitKey(key)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	value, ok := lookup(doc, path)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, ErrKeyNotSet)
+	}
+	return value, nil
+}
+
+// FormatValue renders a value GetValue returned as TOML, with tables
+// inline
+func FormatValue(value any) string {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.SetTablesInline(true)
+	if err := enc.Encode(map[string]any{"v": value}); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(buf.String(), "v = "))
+}
+
+// Flatten returns the values data, a TOML document, sets by dotted key.
+// Tables are flattened into their keys; arrays, including arrays of
+// tables, are values of their own.
+func Flatten(data []byte) (map[string]any, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return flattenMap(doc), nil
+}
+
+// FlatKeys returns the keys of a flattened document in order
+func FlatKeys(flat map[string]any) []string {
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// expression is a top-level key/value or table header of a TOML document,
+// located by byte offsets
+type expression struct {
+	table      bool     // A [table] header; key/values have table false
+	arrayTable bool     // An [[array.table]] header
+	key        []string // The full key, including the table of a key/value
+	start      int      // Offset of the first key, or of a comment line
+	keyEnd     int      // Offset after the last key
+	comment    int      // Offset of a trailing comment, or -1
+	commentEnd int
+}
+
+// scanExpressions returns the key/values and table headers of data, in
+// order, and the offsets at which comment lines start
+func scanExpressions(data []byte) ([]expression, []int, error) {
+	p := unstable.Parser{KeepComments: true}
+	p.Reset(data)
+	var exprs []expression
+	var comments []int
+	var table []string
+	for p.NextExpression() {
+		node := p.Expression()
+		if node.Kind == unstable.Comment {
+			comments = append(comments, int(node.Raw.Offset))
+			continue
+		}
+		expr := expression{comment: -1}
+		var key []string
+		for it := node.Key(); it.Next(); {
+			k := it.Node()
+			if len(key) == 0 {
+				expr.start = int(k.Raw.Offset)
+			}
+			key = append(key, string(k.Data))
+			expr.keyEnd = int(k.Raw.Offset + k.Raw.Length)
+		}
+		switch node.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			expr.table = true
+			expr.arrayTable = node.Kind == unstable.ArrayTable
+			table = key
+			expr.key = key
+		case unstable.KeyValue:
+			expr.key = append(append([]string{}, table...), key...)
+		default:
+			continue
+		}
+		if next := node.Next(); next != nil && next.Valid() && next.Kind == unstable.Comment {
+			expr.comment = int(next.Raw.Offset)
+			expr.commentEnd = int(next.Raw.Offset + next.Raw.Length)
+		}
+		exprs = append(exprs, expr)
+	}
+	if err := p.Error(); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return exprs, comments, nil
+}
+
+// valueRange returns the offsets of the value of the key/value exprs[i]
+func valueRange(data []byte, exprs []expression, comments []int, i int) (int, int) {
+	start := exprs[i].keyEnd
+	for start < len(data) && (data[start] == ' ' || data[start] == '\t' || data[start] == '=') {
+		start++
+	}
+	if exprs[i].comment >= 0 {
+		return start, start + len(bytes.TrimRight(data[start:exprs[i].comment], " \t"))
+	}
+	// The value runs until the next expression, less the whitespace and
+	// brackets of a table header between them, which no value ends with
+	end := len(data)
+	if i+1 < len(exprs) {
+		end = exprs[i+1].start
+	}
+	for _, comment := range comments {
+		if comment > start && comment < end {
+			end = comment
+			break
+		}
+	}
+	return start, start + len(bytes.TrimRight(data[start:end], " \t\r\n["))
+}
+
+// lineEnd returns the offset at which the line of exprs[i] ends, less its
+// newline
+func lineEnd(data []byte, exprs []expression, comments []int, i int) int {
+	if exprs[i].comment >= 0 {
+		return exprs[i].commentEnd
+	}
+	if exprs[i].table {
+		end := exprs[i].keyEnd
+		for end < len(data) && (data[end] == ' ' || data[end] == '\t' || data[end] == ']') {
+			end++
+		}
+		return end
+	}
+	_, end := valueRange(data, exprs, comments, i)
+	return end
+}
+
+// SetValue returns data, a TOML document, with a dotted key set to value.
+// Only the value is rewritten, so comments and formatting are kept; a key
+// that is not set is added to its table, which is added if needed.
+//
+// value is TOML, such as 3, true, or ["a", "b"], and otherwise a string.
+// It is always a string for a key already set to one.
+func SetValue(data []byte, key, value string) ([]byte, error) {
+	path, err := splitKey(key)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	current, set := lookup(doc, path)
+	if _, ok := current.(map[string]any); ok {
+		return nil, fmt.Errorf("%s is a table; set one of its keys, such as %s.<name>", key, key)
+	}
+	encoded := encodeValue(value, current)
+
+	exprs, comments, err := scanExpressions(data)
+	if err != nil {
+		return nil, err
+	}
+	parent := path[:len(path)-1]
+	var edited []byte
+	insertAfter := -1
+	inParent := len(parent) == 0 // Key/values before any table header are in the root
+	for i, expr := range exprs {
+		if expr.table {
+			inParent = !expr.arrayTable && slices.Equal(expr.key, parent)
+		} else if slices.Equal(expr.key, path) {
+			start, end := valueRange(data, exprs, comments, i)
+			edited = splice(data, start, end, encoded)
+			break
+		}
+		if inParent {
+			insertAfter = lineEnd(data, exprs, comments, i)
+		}
+	}
+
+	switch {
+	case edited != nil:
+	case set:
+		return nil, fmt.Errorf("%s is set inside an inline table or array; edit it by hand", key)
+	case insertAfter >= 0:
+		edited = splice(data, insertAfter, insertAfter, "\n"+path[len(path)-1]+" = "+encoded)
+	case len(parent) == 0:
+		edited = splice(data, 0, 0, path[0]+" = "+encoded+"\n")
+	default:
+		edited = append([]byte{}, data...)
+		if len(edited) > 0 && !bytes.HasSuffix(edited, []byte("\n")) {
+			edited = append(edited, '\n')
+		}
+		if len(edited) > 0 {
+			edited = append(edited, '\n')
+		}
+		edited = append(edited, fmt.Sprintf("[%s]\n%s = %s\n", strings.Join(parent, "."), path[len(path)-1], encoded)...)
+	}
+
+	// Adding a table that dotted keys or an inline table already define
+	// is not valid TOML
+	var check map[string]any
+	if err := toml.Unmarshal(edited, &check); err != nil {
+		return nil, fmt.Errorf("cannot set %s without restructuring the file; edit it by hand", key)
+	}
+	if _, ok := lookup(check, path); !ok {
+		return nil, fmt.Errorf("cannot set %s without restructuring the file; edit it by hand", key)
+	}
+	return edited, nil
+}
+
+// encodeValue returns value as TOML. value is used as is if it is TOML,
+// and quoted as a string otherwise or if current, the value it replaces,
+// is a string that value is not.
+func encodeValue(value string, current any) string {
+	if strings.TrimSpace(value) == "" {
+		return `""`
+	}
+	var parsed map[string]any
+	if err := toml.Unmarshal([]byte("v = "+value), &parsed); err == nil && len(parsed) == 1 {
+		if _, isString := current.(string); !isString {
+			return strings.TrimSpace(value)
+		}
+		if _, ok := parsed["v"].(string); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return quoteString(value)
+}
+
+// quoteString returns s as a TOML basic string
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// splice returns data with data[start:end] replaced by s
+func splice(data []byte, start, end int, s string) []byte {
+	out := make([]byte, 0, len(data)+len(s))
+	out = append(out, data[:start]...)
+	out = append(out, s...)
+	return append(out, data[end:]...)
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const editConfig = `# Stack configuration
+[core]
+beads_db_path = "./project-repo" # Where tasks live
+
+[agent.coder]
+command = "python agent_adapter.py"
+model = "claude"
+phases = [
+  "implementation", # Writes code
+  "testing",
+]
+# Restart it when it crashes
+restart = "on-failure"
+docker = { image = "python:3.12" }
+
+[[pipeline.stages]]
+name = "build"
+`
+
+func TestGetValue(t *testing.T) {
+	tests := map[string]string{
+		"core.beads_db_path": `'./project-repo'`,
+		"agent.coder.phases": `['implementation', 'testing']`,
+		"agent.coder.docker": `{image = 'python:3.12'}`,
+	}
+	for key, want := range tests {
+		value, err := GetValue([]byte(editConfig), key)
+		if err != nil {
+			t.Fatalf("GetValue(%s) error = %v", key, err)
+		}
+		if got := FormatValue(value); got != want {
+			t.Errorf("GetValue(%s) = %s, want %s", key, got, want)
+		}
+	}
+	if _, err := GetValue([]byte(editConfig), "agent.coder.replicas"); !errors.Is(err, ErrKeyNotSet) {
+		t.Errorf("Expected an unset key to be reported, got %v", err)
+	}
+	if _, err := GetValue([]byte(editConfig), "agent..model"); err == nil {
+		t.Error("Expected an invalid key to fail")
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       string // A line of the result
+	}{
+		{"core.beads_db_path", "/srv/repo", `beads_db_path = "/srv/repo" # Where tasks live`},
+		{"agent.coder.model", "gpt-4", `model = "gpt-4"`},
+		{"agent.coder.model", "42", `model = "42"`},
+		{"agent.coder.phases", `["planning"]`, `phases = ["planning"]`},
+		{"agent.coder.restart", "always", `restart = "always"`},
+		{"agent.coder.replicas", "3", `replicas = 3`},
+		{"agent.planner.model", "gemini", `model = "gemini"`},
+		{"core.mode", `say "hi"`, `mode = "say \"hi\""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			edited, err := SetValue([]byte(editConfig), tt.key, tt.value)
+			if err != nil {
+				t.Fatalf("SetValue() error = %v", err)
+			}
+			out := string(edited)
+			if !strings.Contains(out, "\n"+tt.want+"\n") {
+				t.Errorf("Expected the line %q in:\n%s", tt.want, out)
+			}
+			for _, comment := range []string{"# Stack configuration", "# Restart it when it crashes"} {
+				if !strings.Contains(out, comment) {
+					t.Errorf("Expected %q kept in:\n%s", comment, out)
+				}
+			}
+			if _, err := GetValue(edited, tt.key); err != nil {
+				t.Errorf("GetValue() after SetValue() error = %v", err)
+			}
+		})
+	}
+
+	// Keys added to a table follow its last key
+	edited, err := SetValue([]byte(editConfig), "agent.coder.replicas", "3")
+	if err != nil || !strings.Contains(string(edited), "docker = { image = \"python:3.12\" }\nreplicas = 3\n\n[[pipeline.stages]]") {
+		t.Errorf("Unexpected insertion:\n%s", edited)
+	}
+
+	for _, key := range []string{"agent.coder", "agent.coder.docker.image", "pipeline.stages.name"} {
+		if _, err := SetValue([]byte(editConfig), key, "x"); err == nil {
+			t.Errorf("Expected SetValue(%s) to fail", key)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	a, err := Flatten([]byte("[core]\nbeads_db_path = \"a\"\n[agent.coder]\nmodel = \"claude\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := FlatKeys(a)
+	if strings.Join(keys, ",") != "agent.coder.model,core.beads_db_path" {
+		t.Errorf("FlatKeys() = %q", keys)
+	}
+}
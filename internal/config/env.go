@@ -2,9 +2,18 @@ package config
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/docker"
+	"github.com/rand/asc/internal/prompts"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/worktree"
 )
 
 // RequiredAPIKeys lists the API keys that must be present in the .env file
@@ -17,29 +26,24 @@ var RequiredAPIKeys = []string{
 }
 
 // DefaultEnvPath returns the default path for the .env file.
-// This is typically ".env" in the current working directory.
+// This is typically ".env" in the current working directory, or
+// .env.<profile> under a profile (see ProfileEnvPath).
 func DefaultEnvPath() string {
-	return ".env"
+	return ProfileEnvPath(Profile())
 }
 
 // GetDefaultPIDDir returns the default directory for storing process ID files.
-// This is typically "~/.asc/pids" in the user's home directory.
+// This is typically "~/.asc/pids"; see statedir for how the state
+// directory is resolved.
 func GetDefaultPIDDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-	return fmt.Sprintf("%s/.asc/pids", home), nil
+	return statedir.Path("pids")
 }
 
 // GetDefaultLogDir returns the default directory for storing log files.
-// This is typically "~/.asc/logs" in the user's home directory.
+// This is typically "~/.asc/logs"; see statedir for how the state
+// directory is resolved.
 func GetDefaultLogDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-	return fmt.Sprintf("%s/.asc/logs", home), nil
+	return statedir.Path("logs")
 }
 
 // LoadEnv reads the .env file and loads API keys into the environment.
@@ -65,8 +69,31 @@ func LoadEnv(envPath string) error {
 	}
 	defer file.Close()
 
+	vars, err := ParseEnv(file)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range vars {
+		key, value, _ := strings.Cut(v, "=")
+
+		// Set environment variable
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set environment variable %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// ParseEnv parses .env content in KEY=VALUE format, skipping comments and
+// empty lines and removing quotes around values. It returns the variables
+// in the KEY=VALUE form of os.Environ, in the order they appear.
+func ParseEnv(r io.Reader) ([]string, error) {
+	var vars []string
+
 	// Parse the file line by line
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	lineNum := 0
 	for scanner.Scan() {
 		lineNum++
@@ -80,7 +107,7 @@ func LoadEnv(envPath string) error {
 		// Parse KEY=VALUE format
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
-			return fmt.Errorf("invalid format at line %d: expected KEY=VALUE", lineNum)
+			return nil, fmt.Errorf("invalid format at line %d: expected KEY=VALUE", lineNum)
 		}
 
 		key := strings.TrimSpace(parts[0])
@@ -89,17 +116,14 @@ func LoadEnv(envPath string) error {
 		// Remove quotes if present
 		value = strings.Trim(value, `"'`)
 
-		// Set environment variable
-		if err := os.Setenv(key, value); err != nil {
-			return fmt.Errorf("failed to set environment variable %s: %w", key, err)
-		}
+		vars = append(vars, key+"="+value)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading environment file: %w", err)
+		return nil, fmt.Errorf("error reading environment file: %w", err)
 	}
 
-	return nil
+	return vars, nil
 }
 
 // ValidateEnv checks that all required API keys are present in the environment.
@@ -135,3 +159,113 @@ func LoadAndValidateEnv(envPath string) error {
 
 	return nil
 }
+
+// AgentEnv returns the variables an agent alone gets: those of its
+// env_file, then its extra_env, sorted by name, so that extra_env wins.
+// Returns no variables if the agent has neither.
+func AgentEnv(agentName string, agent AgentConfig) ([]string, error) {
+	var env []string
+	if agent.EnvFile != "" {
+		file, err := os.Open(agent.EnvFile)
+		if err != nil {
+			return nil, fmt.Errorf("agent '%s': env_file: %w\n  Suggestion: Create the file or remove env_file from [agent.%s]", agentName, err, agentName)
+		}
+		defer file.Close()
+		if env, err = ParseEnv(file); err != nil {
+			return nil, fmt.Errorf("agent '%s': env_file %s: %w", agentName, agent.EnvFile, err)
+		}
+	}
+	names := make([]string, 0, len(agent.ExtraEnv))
+	for name := range agent.ExtraEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+agent.ExtraEnv[name])
+	}
+	return env, nil
+}
+
+// PromptEnv renders the versioned prompt an agent references (see
+// AgentConfig.Prompt) and returns the AGENT_PROMPT_FILE and AGENT_PROMPT
+// variables that point the agent at it. Returns no variables if the agent
+// has no prompt configured.
+func PromptEnv(agentName string, agent AgentConfig, cfg *Config) ([]string, error) {
+	return prompts.AgentEnv(agent.Prompt, PromptData(agentName, agent, cfg))
+}
+
+// PromptData returns the values available to an agent's prompt template
+func PromptData(agentName string, agent AgentConfig, cfg *Config) prompts.TemplateData {
+	_, mcpServer := cfg.MCPServerFor(agent)
+	return prompts.TemplateData{
+		AgentName:   agentName,
+		Model:       agent.Model,
+		Phases:      agent.Phases,
+		BeadsDBPath: cfg.Core.BeadsDBPath,
+		MCPURL:      mcpServer.URL,
+	}
+}
+
+// NewWorktreeManager returns the manager of the agent worktrees of the
+// project repository, configured by [worktree]
+func NewWorktreeManager(cfg *Config) (*worktree.Manager, error) {
+	return worktree.NewManager(cfg.Core.BeadsDBPath, cfg.Worktree.Dir, cfg.Worktree.BranchPrefix, cfg.Worktree.Base)
+}
+
+// WorktreeEnv provisions an agent's worktree when [worktree] is enabled and
+// returns the AGENT_WORKTREE variable that points the agent at it. Returns
+// no variables if the agent does not use a worktree.
+func WorktreeEnv(agentName string, agent AgentConfig, cfg *Config) ([]string, error) {
+	if !cfg.UsesWorktree(agent) {
+		return nil, nil
+	}
+	manager, err := NewWorktreeManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	record, err := manager.Provision(agentName)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("%s=%s", worktree.EnvVar, record.Path)}, nil
+}
+
+// dockerRemoveTimeout bounds removing the containers an agent left behind
+// before it is started again
+const dockerRemoveTimeout = 30 * time.Second
+
+// RuntimeCommand returns the command line and environment an agent is
+// started with under its runtime. For the process runtime they are command,
+// args, and env as they are. For docker they are the docker CLI running
+// them in the agent's container, and containers of the agent left behind
+// by an earlier run are removed first.
+func RuntimeCommand(agentName string, agent AgentConfig, command string, args, env []string) (string, []string, []string, error) {
+	if !agent.UsesDocker() {
+		return command, args, env, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerRemoveTimeout)
+	defer cancel()
+	if err := docker.Remove(ctx, agentName); err != nil {
+		return "", nil, nil, fmt.Errorf("%w\n  Suggestion: Check that the Docker daemon is running with 'docker info'", err)
+	}
+
+	var argv []string
+	if command != "" {
+		argv = append([]string{command}, args...)
+	}
+	return docker.Command(DockerSpec(agentName, agent), argv, env)
+}
+
+// DockerSpec returns the container an agent with runtime = "docker" runs in
+func DockerSpec(agentName string, agent AgentConfig) docker.Spec {
+	return docker.Spec{
+		Agent:       agentName,
+		Image:       agent.Docker.Image,
+		Volumes:     agent.Docker.Volumes,
+		Env:         agent.Docker.Env,
+		Network:     agent.Docker.Network,
+		Interactive: agent.Stdin,
+		MemoryMB:    agent.MaxMemoryMB,
+		CPUs:        agent.CPULimit,
+	}
+}
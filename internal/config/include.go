@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+)
+
+// IncludeKey is the top-level key listing the files a configuration file
+// includes, as paths or glob patterns relative to it
+const IncludeKey = "include"
+
+// includeLine matches the line of the include key
+var includeLine = regexp.MustCompile(`^\s*include\s*=`)
+
+// applyIncludes merges the files the include key of v lists under v, which
+// was read from path: the including file's own keys win, and two included
+// files may not set the same key. Included files may include others. The
+// files read are appended to sources, for errors to point into.
+func applyIncludes(v *viper.Viper, path string, sources *[]string) (*viper.Viper, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	included, err := loadIncludes(v, path, map[string]bool{abs: true}, sources)
+	if err != nil || included == nil {
+		return v, err
+	}
+	merged := viper.New()
+	merged.SetConfigType("toml")
+	if err := merged.MergeConfigMap(included); err != nil {
+		return nil, err
+	}
+	if err := merged.MergeConfigMap(v.AllSettings()); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// loadIncludes returns the settings of the files the include key of v,
+// read from path, lists, merged in order, or nil if it has none. active
+// holds the files being included, to detect cycles.
+func loadIncludes(v *viper.Viper, path string, active map[string]bool, sources *[]string) (map[string]any, error) {
+	if !v.IsSet(IncludeKey) {
+		return nil, nil
+	}
+	at := path
+	if line := findLine(path, includeLine.MatchString); line > 0 {
+		at = fmt.Sprintf("%s:%d", path, line)
+	}
+	patterns, ok := v.Get(IncludeKey).([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: include must be an array of paths, such as include = [\"agents/*.toml\"]", at)
+	}
+
+	merged := viper.New()
+	owners := map[string]string{}
+	for _, p := range patterns {
+		pattern, ok := p.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: include must be an array of paths, such as include = [\"agents/*.toml\"]", at)
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid include pattern %q: %w", at, p, err)
+		}
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return nil, ascerrors.WithHint(fmt.Errorf("%s: included file %s not found", at, pattern), "Fix the path in include, relative to the including file, or remove it")
+		}
+		sort.Strings(matches)
+
+		for _, match := range matches {
+			abs, err := filepath.Abs(match)
+			if err != nil {
+				return nil, err
+			}
+			if active[abs] {
+				return nil, fmt.Errorf("%s: including %s again makes a cycle", at, match)
+			}
+			settings, err := loadIncluded(match, active, sources)
+			if err != nil {
+				return nil, fmt.Errorf("%w (included from %s)", err, at)
+			}
+			for key := range flattenMap(settings) {
+				if key == IncludeKey {
+					continue
+				}
+				if owner, ok := owners[key]; ok && owner != match {
+					return nil, ascerrors.WithHint(fmt.Errorf("%s: %s is set by both %s and %s", at, key, owner, match), "Set it in one of them, or in the including file, which overrides both")
+				}
+				owners[key] = match
+			}
+			if err := merged.MergeConfigMap(settings); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return merged.AllSettings(), nil
+}
+
+// loadIncluded returns the settings of an included file, with its own
+// includes merged under them
+func loadIncluded(path string, active map[string]bool, sources *[]string) (map[string]any, error) {
+	abs, _ := filepath.Abs(path)
+	active[abs] = true
+	defer delete(active, abs)
+	*sources = append(*sources, path)
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, readError(path, err)
+	}
+	included, err := loadIncludes(v, path, active, sources)
+	if err != nil {
+		return nil, err
+	}
+	settings := v.AllSettings()
+	delete(settings, IncludeKey)
+	if included == nil {
+		return settings, nil
+	}
+	merged := viper.New()
+	merged.MergeConfigMap(included)
+	merged.MergeConfigMap(settings)
+	return merged.AllSettings(), nil
+}
+
+// readError returns the error of reading the configuration file at path,
+// with the line of a syntax error
+func readError(path string, err error) error {
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, _ := decodeErr.Position()
+		return fmt.Errorf("failed to read %s:%d: %w", path, row, err)
+	}
+	return fmt.Errorf("failed to read %s: %w", path, err)
+}
+
+// flattenMap returns the values of a nested map by dotted key, as Flatten
+// does for a TOML document
+func flattenMap(doc map[string]any) map[string]any {
+	flat := make(map[string]any)
+	var walk func(prefix string, table map[string]any)
+	walk = func(prefix string, table map[string]any) {
+		for name, value := range table {
+			if sub, ok := value.(map[string]any); ok && len(sub) > 0 {
+				walk(prefix+name+".", sub)
+				continue
+			}
+			flat[prefix+name] = value
+		}
+	}
+	walk("", doc)
+	return flat
+}
+
+// findLine returns the number of the first line of the file at path that
+// match reports true for, or 0 if there is none
+func findLine(path string, match func(line string) bool) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		if match(scanner.Text()) {
+			return n
+		}
+	}
+	return 0
+}
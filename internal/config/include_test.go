@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigFiles creates the files of contents, by path relative to dir
+func writeConfigFiles(t *testing.T, dir string, contents map[string]string) {
+	t.Helper()
+	for name, content := range contents {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const includingConfig = `include = ["agents/*.toml"]
+
+[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+
+[agent.coder]
+model = "gemini"
+`
+
+func TestIncludes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"asc.toml": includingConfig,
+		"agents/coder.toml": `include = ["../shared/planner.toml"]
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+`,
+		"agents/reviewer.toml": `[agent.reviewer]
+command = "echo"
+model = "claude"
+phases = ["review"]
+`,
+		"shared/planner.toml": `[agent.planner]
+command = "echo"
+model = "claude"
+phases = ["planning"]
+`,
+	})
+
+	cfg, err := Load(filepath.Join(dir, "asc.toml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Agents) != 3 {
+		t.Errorf("Expected the agents of every included file, got %d", len(cfg.Agents))
+	}
+	if coder := cfg.Agents["coder"]; coder.Model != "gemini" || coder.Command != "echo" {
+		t.Errorf("Expected the including file to override the included one, got %+v", coder)
+	}
+
+	errorTests := map[string]struct {
+		files map[string]string
+		want  string
+	}{
+		"not an array": {
+			map[string]string{"asc.toml": "include = \"a.toml\"\n", "a.toml": ""},
+			"include must be an array",
+		},
+		"not found": {
+			map[string]string{"asc.toml": "# Agents\ninclude = [\"agents.toml\"]\n"},
+			"asc.toml:2: included file",
+		},
+		"cycle": {
+			map[string]string{"asc.toml": "include = [\"a.toml\"]\n", "a.toml": "include = [\"asc.toml\"]\n"},
+			"makes a cycle",
+		},
+		"conflict": {
+			map[string]string{
+				"asc.toml": "include = [\"a.toml\", \"b.toml\"]\n",
+				"a.toml":   "[agent.coder]\nmodel = \"claude\"\n",
+				"b.toml":   "[agent.coder]\nmodel = \"gemini\"\n",
+			},
+			"agent.coder.model is set by both",
+		},
+		"syntax": {
+			map[string]string{"asc.toml": "include = [\"a.toml\"]\n", "a.toml": "[agent.coder\n"},
+			"(included from",
+		},
+	}
+	for name, tt := range errorTests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfigFiles(t, dir, tt.files)
+			_, err := Load(filepath.Join(dir, "asc.toml"))
+			if err == nil {
+				t.Fatal("Expected Load() to fail")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
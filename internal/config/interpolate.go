@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+)
+
+// envRef matches a reference to an environment variable in a value,
+// ${NAME} or ${NAME:-default}, or the escape $${, which stands for ${
+var envRef = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// interpolate replaces the references to environment variables in the
+// string values of settings, in place. A variable that is not set and has
+// no default is an error, pointing at the first line of sources that
+// refers to it.
+func interpolate(settings map[string]any, sources []string) error {
+	var missing string
+	var expand func(value any) any
+	expand = func(value any) any {
+		switch value := value.(type) {
+		case string:
+			return envRef.ReplaceAllStringFunc(value, func(ref string) string {
+				if ref == "$${" {
+					return "${"
+				}
+				match := envRef.FindStringSubmatch(ref)
+				if v, ok := os.LookupEnv(match[1]); ok {
+					return v
+				}
+				if strings.Contains(ref, ":-") {
+					return match[2]
+				}
+				if missing == "" {
+					missing = match[1]
+				}
+				return ref
+			})
+		case map[string]any:
+			for key, v := range value {
+				value[key] = expand(v)
+			}
+		case []any:
+			for i, v := range value {
+				value[i] = expand(v)
+			}
+		}
+		return value
+	}
+	expand(settings)
+	if missing == "" {
+		return nil
+	}
+
+	at := sources[0]
+	for _, source := range sources {
+		if line := findLine(source, func(line string) bool { return refersTo(line, missing) }); line > 0 {
+			at = fmt.Sprintf("%s:%d", source, line)
+			break
+		}
+	}
+	return ascerrors.WithHint(
+		fmt.Errorf("%s: environment variable %s is not set", at, missing),
+		fmt.Sprintf("Set %s, give it a default with ${%s:-value}, or write $${%s} to keep the reference as is", missing, missing, missing))
+}
+
+// refersTo reports whether line refers to the environment variable name
+func refersTo(line, name string) bool {
+	for _, match := range envRef.FindAllStringSubmatch(line, -1) {
+		if match[1] == name {
+			return true
+		}
+	}
+	return false
+}
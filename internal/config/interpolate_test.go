@@ -0,0 +1,53 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+	t.Setenv("ASC_TEST_MAIL_URL", "http://mail.internal:8765")
+	t.Setenv("ASC_TEST_MODEL", "gemini")
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"asc.toml": `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+url = "${ASC_TEST_MAIL_URL}"
+
+[agent.coder]
+command = "echo --name $${AGENT_NAME} --level ${ASC_TEST_LEVEL:-info}"
+model = "${ASC_TEST_MODEL}"
+phases = ["${ASC_TEST_PHASE:-implementation}"]
+`,
+	})
+
+	cfg, err := Load(filepath.Join(dir, "asc.toml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Services.MCPAgentMail.URL != "http://mail.internal:8765" {
+		t.Errorf("Expected the URL from the environment, got %s", cfg.Services.MCPAgentMail.URL)
+	}
+	coder := cfg.Agents["coder"]
+	if coder.Model != "gemini" || coder.Command != "echo --name ${AGENT_NAME} --level info" || coder.Phases[0] != "implementation" {
+		t.Errorf("Unexpected agent %+v", coder)
+	}
+
+	writeConfigFiles(t, dir, map[string]string{
+		"asc.toml": "include = [\"agents.toml\"]\n\n[core]\nbeads_db_path = \"./test-repo\"\n",
+		"agents.toml": `[agent.coder]
+command = "echo"
+model = "${ASC_TEST_UNSET}"
+phases = ["implementation"]
+`,
+	})
+	_, err = Load(filepath.Join(dir, "asc.toml"))
+	if err == nil || !strings.Contains(err.Error(), "agents.toml:3: environment variable ASC_TEST_UNSET is not set") {
+		t.Errorf("Expected the unset variable reported at its line, got %v", err)
+	}
+}
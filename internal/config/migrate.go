@@ -0,0 +1,407 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	toml "github.com/pelletier/go-toml/v2"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+)
+
+// ConfigVersionKey is the top-level key asc.toml records its layout
+// version in
+const ConfigVersionKey = "config_version"
+
+// CurrentConfigVersion is the layout version of asc.toml this release
+// reads and writes
+const CurrentConfigVersion = 1
+
+// legacyAdapterCommand is the command asc 0.9 ran every agent with
+const legacyAdapterCommand = "python agent_adapter.py"
+
+// ErrNewerConfig is wrapped by the errors of loading or migrating a file
+// written for a newer release of asc
+var ErrNewerConfig = errors.New("configuration file was written for a newer version of asc")
+
+// ConfigMigration upgrades the layout of asc.toml to Version from the
+// version before it. Apply returns data unchanged if the file does not use
+// the old layout.
+type ConfigMigration struct {
+	Version     int
+	Description string
+	Apply       func(data []byte) ([]byte, error)
+}
+
+// configMigrations are applied in order; each one's Version is one more
+// than the last
+var configMigrations = []ConfigMigration{
+	{
+		Version:     1,
+		Description: "Move [agents] agents = [...] to [agent.<name>] sections and [services] mcp_url to [services.mcp_agent_mail]",
+		Apply:       migrateAgentsArray,
+	},
+}
+
+// MigrationResult describes what MigrateConfig did
+type MigrationResult struct {
+	From    int               // Version found; 0 for a file without config_version
+	To      int               // Version after migrating
+	Applied []ConfigMigration // Migrations that changed the file, oldest first
+	Backup  string            // Copy of the file before it was migrated, if it was
+}
+
+// Migrated reports whether any migration changed the file
+func (r *MigrationResult) Migrated() bool {
+	return len(r.Applied) > 0
+}
+
+// FileConfigVersion returns the config_version data, a TOML document,
+// records: 0 if it has none, and an error wrapping ErrNewerConfig if it is
+// newer than CurrentConfigVersion
+func FileConfigVersion(data []byte) (int, error) {
+	value, err := GetValue(data, ConfigVersionKey)
+	if errors.Is(err, ErrKeyNotSet) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	version, ok := value.(int64)
+	if !ok || version < 0 {
+		return 0, fmt.Errorf("%s must be a whole number, not %s", ConfigVersionKey, FormatValue(value))
+	}
+	if version > CurrentConfigVersion {
+		return int(version), newerConfigError(int(version))
+	}
+	return int(version), nil
+}
+
+// newerConfigError returns the error for a file of a newer config version
+func newerConfigError(version int) error {
+	return ascerrors.WithHint(
+		fmt.Errorf("%w: it has %s = %d, this release reads up to %d", ErrNewerConfig, ConfigVersionKey, version, CurrentConfigVersion),
+		"Upgrade asc, or restore the backup of asc.toml that the newer release made when it migrated the file")
+}
+
+// MigrateConfig returns data, a TOML document, upgraded to
+// CurrentConfigVersion. A file no migration changes is returned as it is,
+// without a config_version added, so files already in the current layout
+// are never rewritten.
+func MigrateConfig(data []byte) ([]byte, *MigrationResult, error) {
+	from, err := FileConfigVersion(data)
+	if err != nil {
+		return data, nil, err
+	}
+	result := &MigrationResult{From: from, To: from}
+	migrated := data
+	for _, m := range configMigrations {
+		if m.Version <= from {
+			continue
+		}
+		out, err := m.Apply(migrated)
+		if err != nil {
+			return data, result, fmt.Errorf("failed to migrate to config version %d (%s): %w", m.Version, m.Description, err)
+		}
+		if string(out) != string(migrated) {
+			result.Applied = append(result.Applied, m)
+		}
+		migrated = out
+	}
+	if !result.Migrated() {
+		return data, result, nil
+	}
+
+	result.To = CurrentConfigVersion
+	if from == 0 {
+		return append([]byte(fmt.Sprintf("%s = %d\n\n", ConfigVersionKey, CurrentConfigVersion)), migrated...), result, nil
+	}
+	migrated, err = SetValue(migrated, ConfigVersionKey, strconv.Itoa(CurrentConfigVersion))
+	if err != nil {
+		return data, result, err
+	}
+	return migrated, result, nil
+}
+
+// MigrateConfigFile upgrades the file at path in place, copying it to
+// <path>.<time>-v<version>.bak first if any migration changes it
+func MigrateConfigFile(path string) (*MigrationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	migrated, result, err := MigrateConfig(data)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", path, err)
+	}
+	if !result.Migrated() {
+		return result, nil
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	backup := fmt.Sprintf("%s.%s-v%d.bak", path, time.Now().Format("20060102T150405"), result.From)
+	if err := os.WriteFile(backup, data, perm); err != nil {
+		return result, fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	result.Backup = backup
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return result, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	_, err = tmp.Write(migrated)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// checkLayout rejects settings, a decoded asc.toml, of a newer config
+// version or of a layout a migration upgrades, which would otherwise load
+// as unknown keys and no agents
+func checkLayout(settings map[string]any, sources []string) error {
+	if version, ok := settings[ConfigVersionKey].(int64); ok && version > CurrentConfigVersion {
+		return newerConfigError(int(version))
+	}
+	for _, legacy := range []struct {
+		key  string
+		path []string
+	}{{"agents", []string{"agents"}}, {"mcp_url", []string{"services", "mcp_url"}}} {
+		if _, set := lookup(settings, legacy.path); !set {
+			continue
+		}
+		at := keyLocation(legacy.key, sources)
+		file, _, _ := strings.Cut(at, ":")
+		return ascerrors.WithHint(
+			fmt.Errorf("%s: %s is the layout of asc 0.9", at, strings.Join(legacy.path, ".")),
+			fmt.Sprintf("Run 'asc config migrate %s' to upgrade the file, keeping a backup", file))
+	}
+	return nil
+}
+
+// migrateAgentsArray upgrades the layout of asc 0.9: agents were entries
+// of [agents] agents = [...], and the MCP server was [services] mcp_url.
+// Those lines are removed and the settings written to [agent.<name>] and
+// [services.mcp_agent_mail]; other lines and comments are kept.
+func migrateAgentsArray(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	legacyAgents, hasAgents := doc["agents"]
+	mcpURL, hasURL := lookup(doc, []string{"services", "mcp_url"})
+	if !hasAgents && !hasURL {
+		return data, nil
+	}
+
+	var agents []map[string]any
+	if hasAgents {
+		table, ok := legacyAgents.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("agents must be a table with an agents array")
+		}
+		for key := range table {
+			if key != "agents" {
+				return nil, fmt.Errorf("agents.%s has no place in the new layout; move it to an [agent.<name>] section by hand", key)
+			}
+		}
+		entries, _ := table["agents"].([]any)
+		for i, entry := range entries {
+			agent, ok := entry.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("agents.agents[%d] is not a table", i)
+			}
+			name, _ := agent["name"].(string)
+			if name == "" {
+				return nil, fmt.Errorf("agents.agents[%d] has no name", i)
+			}
+			if _, exists := lookup(doc, []string{"agent", name}); exists {
+				return nil, fmt.Errorf("agent %s is defined in both [agents] and [agent.%s]", name, name)
+			}
+			agents = append(agents, agent)
+		}
+	}
+
+	// The old lines are removed last to first, so earlier offsets hold
+	exprs, comments, err := scanExpressions(data)
+	if err != nil {
+		return nil, err
+	}
+	type span struct{ start, end int }
+	var remove []span
+	for i, expr := range exprs {
+		if !expr.table || expr.arrayTable {
+			continue
+		}
+		end := i
+		for end+1 < len(exprs) && !exprs[end+1].table {
+			end++
+		}
+		switch {
+		case slices.Equal(expr.key, []string{"agents"}):
+			remove = append(remove, span{lineStart(data, expr.start), lineAfter(data, lineEnd(data, exprs, comments, end))})
+		case slices.Equal(expr.key, []string{"services"}):
+			// [services] goes too if mcp_url was all it set
+			for j := i + 1; j <= end; j++ {
+				if !slices.Equal(exprs[j].key, []string{"services", "mcp_url"}) {
+					continue
+				}
+				start := lineStart(data, exprs[j].start)
+				if end == i+1 {
+					start = lineStart(data, expr.start)
+				}
+				remove = append(remove, span{start, lineAfter(data, lineEnd(data, exprs, comments, j))})
+			}
+		}
+	}
+	out := append([]byte{}, data...)
+	for i := len(remove) - 1; i >= 0; i-- {
+		out = splice(out, remove[i].start, remove[i].end, "")
+	}
+
+	if hasURL {
+		if _, set := lookup(doc, []string{"services", DefaultMCPServer, "url"}); !set {
+			if out, err = SetValue(out, "services."+DefaultMCPServer+".url", fmt.Sprint(mcpURL)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(agents) > 0 {
+		var b strings.Builder
+		for _, agent := range agents {
+			b.WriteString("\n[agent." + tomlKey(agent["name"].(string)) + "]\n")
+			if _, ok := agent["command"]; !ok {
+				agent["command"] = legacyAdapterCommand
+			}
+			for _, key := range agentKeyOrder(agent) {
+				b.WriteString(tomlKey(key) + " = " + formatTOML(agent[key]) + "\n")
+			}
+		}
+		out = append(trimTrailingNewlines(out), b.String()...)
+	}
+
+	var check map[string]any
+	if err := toml.Unmarshal(out, &check); err != nil {
+		return nil, fmt.Errorf("the migrated file is not valid TOML: %w", err)
+	}
+	_, agentsLeft := check["agents"]
+	_, urlLeft := lookup(check, []string{"services", "mcp_url"})
+	if agentsLeft || urlLeft {
+		return nil, fmt.Errorf("agents or services.mcp_url is set in a way that cannot be moved; move it by hand")
+	}
+	return out, nil
+}
+
+// agentKeyOrder returns the keys of an agent of the old layout, less its
+// name, with command, model, and phases first as asc init writes them
+func agentKeyOrder(agent map[string]any) []string {
+	first := []string{"command", "model", "phases"}
+	var keys, rest []string
+	for _, key := range first {
+		if _, ok := agent[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range agent {
+		if key != "name" && !slices.Contains(first, key) {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// tomlKey returns key bare if TOML allows it, and quoted otherwise
+func tomlKey(key string) string {
+	if keyPart.MatchString(key) {
+		return key
+	}
+	return quoteString(key)
+}
+
+// formatTOML returns a decoded TOML value as TOML, with strings in double
+// quotes as asc init writes them
+func formatTOML(value any) string {
+	switch v := value.(type) {
+	case string:
+		return quoteString(v)
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatTOML(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = tomlKey(key) + " = " + formatTOML(v[key])
+		}
+		return "{ " + strings.Join(items, ", ") + " }"
+	}
+	return FormatValue(value)
+}
+
+// lineStart returns the offset at which the line holding offset starts
+func lineStart(data []byte, offset int) int {
+	for offset > 0 && data[offset-1] != '\n' {
+		offset--
+	}
+	return offset
+}
+
+// lineAfter returns the offset after the newline at or after offset, and
+// after the blank lines that follow it
+func lineAfter(data []byte, offset int) int {
+	for offset < len(data) && data[offset] != '\n' {
+		offset++
+	}
+	if offset < len(data) {
+		offset++
+	}
+	for next := offset; next < len(data); next++ {
+		if data[next] == '\n' {
+			offset = next + 1
+		} else if data[next] != ' ' && data[next] != '\t' && data[next] != '\r' {
+			break
+		}
+	}
+	return offset
+}
+
+// trimTrailingNewlines returns data ending in exactly one newline, or
+// empty
+func trimTrailingNewlines(data []byte) []byte {
+	for len(data) > 0 && (data[len(data)-1] == '\n' || data[len(data)-1] == '\r') {
+		data = data[:len(data)-1]
+	}
+	if len(data) == 0 {
+		return data
+	}
+	return append(data, '\n')
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// legacyConfig is the layout of asc 0.9, as docs/UPGRADE_GUIDE.md shows it
+const legacyConfig = `# Project stack
+[core]
+beads_db_path = "./project"
+
+[services]
+mcp_url = "http://localhost:9000" # Local server
+
+[agents]
+agents = [
+  { name = "planner", model = "gemini", phases = ["planning"] },
+  { name = "coder", model = "claude", phases = ["implementation"], command = "echo" }
+]
+
+[logging]
+level = "debug"
+`
+
+func TestMigrateConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+
+	migrated, result, err := MigrateConfig([]byte(legacyConfig))
+	if err != nil {
+		t.Fatalf("MigrateConfig() error = %v", err)
+	}
+	if result.From != 0 || result.To != CurrentConfigVersion || !result.Migrated() {
+		t.Errorf("MigrateConfig() result = %+v", result)
+	}
+	for _, want := range []string{"config_version = 1\n", "# Project stack\n", "[agent.planner]\ncommand = \"python agent_adapter.py\"\nmodel = \"gemini\"\n", "[logging]\nlevel = \"debug\"\n"} {
+		if !strings.Contains(string(migrated), want) {
+			t.Errorf("Expected %q in the migrated file:\n%s", want, migrated)
+		}
+	}
+	for _, gone := range []string{"[agents]", "mcp_url", "[services]\n"} {
+		if strings.Contains(string(migrated), gone) {
+			t.Errorf("Expected %q removed from the migrated file:\n%s", gone, migrated)
+		}
+	}
+
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	if err := os.WriteFile(configPath, migrated, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() of the migrated file error = %v", err)
+	}
+	if cfg.Services.MCPAgentMail.URL != "http://localhost:9000" || cfg.Agents["coder"].Command != "echo" || len(cfg.UnknownKeys) > 0 {
+		t.Errorf("Unexpected migrated config: %+v", cfg)
+	}
+
+	// Files in the current layout are not rewritten
+	current := []byte(profileConfig)
+	out, result, err := MigrateConfig(current)
+	if err != nil || result.Migrated() || string(out) != string(current) {
+		t.Errorf("Expected the current layout left alone, got %+v, %v:\n%s", result, err, out)
+	}
+
+	if _, _, err := MigrateConfig([]byte("config_version = 99\n")); !errors.Is(err, ErrNewerConfig) {
+		t.Errorf("Expected a newer config version to be refused, got %v", err)
+	}
+
+	conflict := legacyConfig + "\n[agent.planner]\ncommand = \"echo\"\n"
+	if _, _, err := MigrateConfig([]byte(conflict)); err == nil {
+		t.Error("Expected an agent defined in both layouts to fail")
+	}
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "asc.toml")
+	if err := os.WriteFile(configPath, []byte(legacyConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(configPath)
+	if err == nil || !strings.Contains(err.Error(), configPath+":8: agents is the layout of asc 0.9") {
+		t.Errorf("Expected Load() to reject the old layout, got %v", err)
+	}
+
+	result, err := MigrateConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("MigrateConfigFile() error = %v", err)
+	}
+	backup, err := os.ReadFile(result.Backup)
+	if err != nil || string(backup) != legacyConfig {
+		t.Errorf("Expected the backup to hold the old file, got %v", err)
+	}
+	if info, err := os.Stat(configPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the mode of the file kept, got %v, %v", info.Mode(), err)
+	}
+	if _, err := Load(configPath); err != nil {
+		t.Errorf("Load() after migrating error = %v", err)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(dir, ".asc.toml.tmp-*")); len(entries) > 0 {
+		t.Errorf("Expected no temporary file left, got %q", entries)
+	}
+
+	if result, err := MigrateConfigFile(configPath); err != nil || result.Migrated() {
+		t.Errorf("Expected a migrated file to be left alone, got %+v, %v", result, err)
+	}
+
+	if err := os.WriteFile(configPath, []byte("config_version = 2\n"+profileConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(configPath); !errors.Is(err, ErrNewerConfig) {
+		t.Errorf("Expected Load() to refuse a newer config version, got %v", err)
+	}
+}
@@ -1,18 +1,36 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
+	"filippo.io/age"
 	"github.com/spf13/viper"
+
+	"github.com/rand/asc/internal/docker"
+	ascerrors "github.com/rand/asc/internal/errors"
+	"github.com/rand/asc/internal/prompts"
+	"github.com/rand/asc/internal/proxy"
+	"github.com/rand/asc/internal/secrets"
 )
 
 // DefaultConfigPath returns the default path for the asc.toml configuration file.
-// This is typically "asc.toml" in the current working directory.
+// This is typically "asc.toml" in the current working directory, or the file
+// ASC_CONFIG (--config) names.
 func DefaultConfigPath() string {
+	if path := os.Getenv(ConfigEnvVar); path != "" && !IsRemoteSource(path) {
+		return path
+	}
 	return "asc.toml"
 }
 
@@ -27,6 +45,22 @@ func DefaultConfigPath() string {
 //	    log.Fatalf("Failed to load config: %v", err)
 //	}
 func Load(configPath string) (*Config, error) {
+	return LoadContext(context.Background(), configPath)
+}
+
+// LoadContext is Load fetching the shared configuration config_source
+// names, if it is due to be fetched, with ctx, so that cancelling ctx stops
+// waiting for it
+func LoadContext(ctx context.Context, configPath string) (*Config, error) {
+	return load(ctx, configPath, false)
+}
+
+// LoadStrict is LoadContext rejecting unknown keys, as core.strict does
+func LoadStrict(ctx context.Context, configPath string) (*Config, error) {
+	return load(ctx, configPath, true)
+}
+
+func load(ctx context.Context, configPath string, strict bool) (*Config, error) {
 	// Set up viper
 	v := viper.New()
 	v.SetConfigFile(configPath)
@@ -34,23 +68,80 @@ func Load(configPath string) (*Config, error) {
 
 	// Check if file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("configuration file not found: %s", configPath)
+		return nil, ascerrors.WithHint(fmt.Errorf("configuration file not found: %s", configPath), "Run 'asc init' to create one, or run asc from the directory that has asc.toml")
 	}
 
 	// Read the config file
 	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, readError(configPath, err)
+	}
+
+	// Included files are merged under the file
+	sources := []string{configPath}
+	v, err := applyIncludes(v, configPath, &sources)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both are merged over the shared configuration config_source names
+	var remote *RemoteSource
+	if v, remote, err = applySource(ctx, v, configPath, &sources); err != nil {
+		return nil, err
+	}
+
+	// The selected profile overrides the file
+	if profile := Profile(); profile != "" {
+		if err := applyProfile(v, configPath, profile); err != nil {
+			return nil, err
+		}
+		sources = append(sources, ProfilePath(configPath, profile))
+	}
+
+	// Files of an older layout are migrated before they are read
+	settings := v.AllSettings()
+	if err := checkLayout(settings, sources); err != nil {
+		return nil, err
+	}
+
+	// ${NAME} references in values are replaced from the environment
+	if err := interpolate(settings, sources); err != nil {
+		return nil, err
 	}
 
 	// Parse into Config struct
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
+	if err := decodeSettings(settings, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// Keys no setting has are misspelled or misplaced
+	cfg.Sources = sources
+	cfg.Remote = remote
+	cfg.UnknownKeys = unknownKeys(settings, sources)
+	if len(cfg.UnknownKeys) > 0 && (strict || cfg.Core.Strict) {
+		return nil, unknownKeysError(cfg.UnknownKeys)
+	}
+
+	// The other [services.mcp_*] sections are further MCP servers
+	if err := loadMCPServers(settings, &cfg); err != nil {
+		return nil, err
+	}
+
 	// Apply defaults
 	applyDefaults(&cfg)
 
+	// Agents with replicas run as instances, as many as asc scale last set
+	// for this file
+	counts := map[string]int{}
+	if path, err := ReplicasPath(); err == nil {
+		if counts, err = LoadReplicas(path, configPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := ExpandReplicas(&cfg, counts); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
 	// Validate required fields
 	if err := validate(&cfg); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -59,6 +150,26 @@ func Load(configPath string) (*Config, error) {
 	return &cfg, nil
 }
 
+// loadMCPServers reads the [services.mcp_*] sections besides
+// mcp_agent_mail into Services.MCPServers
+func loadMCPServers(settings map[string]any, cfg *Config) error {
+	services, _ := settings["services"].(map[string]any)
+	for key, value := range services {
+		if !strings.HasPrefix(key, "mcp_") || key == DefaultMCPServer {
+			continue
+		}
+		var server MCPConfig
+		if err := decodeValue("services."+key, value, reflect.ValueOf(&server).Elem()); err != nil {
+			return fmt.Errorf("failed to parse services.%s: %w", key, err)
+		}
+		if cfg.Services.MCPServers == nil {
+			cfg.Services.MCPServers = make(map[string]MCPConfig)
+		}
+		cfg.Services.MCPServers[key] = server
+	}
+	return nil
+}
+
 // applyDefaults sets default values for optional configuration fields
 func applyDefaults(cfg *Config) {
 	// Default beads DB path
@@ -66,6 +177,11 @@ func applyDefaults(cfg *Config) {
 		cfg.Core.BeadsDBPath = "./project-repo"
 	}
 
+	// Default to the SQLite beads client, falling back to bd
+	if cfg.Core.BeadsMode == "" {
+		cfg.Core.BeadsMode = "auto"
+	}
+
 	// Default auto-recovery to true (enabled by default)
 	// Note: In TOML, if the field is not specified, it defaults to false (zero value for bool)
 	// We want it enabled by default, so we need to check if it was explicitly set
@@ -82,6 +198,205 @@ func applyDefaults(cfg *Config) {
 	if cfg.Services.MCPAgentMail.StartCommand == "" {
 		cfg.Services.MCPAgentMail.StartCommand = "python -m mcp_agent_mail.server"
 	}
+
+	// Default MCP client timeouts, retry backoff, and readiness timeout
+	applyMCPDefaults(&cfg.Services.MCPAgentMail)
+	for name, server := range cfg.Services.MCPServers {
+		applyMCPDefaults(&server)
+		cfg.Services.MCPServers[name] = server
+	}
+
+	// Default readiness timeouts
+	for name, agent := range cfg.Agents {
+		if agent.ReadyCheck.IsSet() && agent.ReadyCheck.Timeout == 0 {
+			agent.ReadyCheck.Timeout = defaultReadyTimeout
+		}
+		applyHealthCheckDefaults(&agent.HealthCheck)
+		if agent.Runtime == "" {
+			agent.Runtime = "process"
+		}
+		cfg.Agents[name] = agent
+	}
+
+	// Default log format
+	if cfg.Logging.Format == "" {
+		cfg.Logging.Format = "text"
+	}
+
+	// Default log level
+	if cfg.Logging.Level == "" {
+		cfg.Logging.Level = "info"
+	}
+
+	// Default output log rotation
+	if cfg.Logging.Rotation.MaxSizeMB == 0 {
+		cfg.Logging.Rotation.MaxSizeMB = 10
+	}
+	if cfg.Logging.Rotation.MaxFiles == 0 {
+		cfg.Logging.Rotation.MaxFiles = 5
+	}
+
+	// Default crash dumps
+	if cfg.Logging.Crashes.LogKB == 0 {
+		cfg.Logging.Crashes.LogKB = 64
+	}
+	if cfg.Logging.Crashes.MaxFiles == 0 {
+		cfg.Logging.Crashes.MaxFiles = 20
+	}
+
+	// Default syslog tag and journald identifier
+	if cfg.Logging.Syslog.Tag == "" {
+		cfg.Logging.Syslog.Tag = "asc"
+	}
+	if cfg.Logging.Journald.Identifier == "" {
+		cfg.Logging.Journald.Identifier = "asc"
+	}
+
+	// Default custom check severity
+	for name, check := range cfg.Check.Custom {
+		if check.Severity == "" {
+			check.Severity = "error"
+			cfg.Check.Custom[name] = check
+		}
+	}
+
+	// Default agent restart policy
+	for name, agent := range cfg.Agents {
+		if agent.Restart == "" {
+			agent.Restart = "on-failure"
+		}
+		if agent.MaxRestarts == 0 {
+			agent.MaxRestarts = 5
+		}
+		if agent.RestartBackoff == 0 {
+			agent.RestartBackoff = time.Second
+		}
+		cfg.Agents[name] = agent
+	}
+
+	// Default pipeline gate evaluation interval
+	if cfg.Pipeline.Interval == 0 {
+		cfg.Pipeline.Interval = 30 * time.Second
+	}
+
+	// Default budget period, warning threshold, and check interval
+	if cfg.Budget.Period == "" {
+		cfg.Budget.Period = "monthly"
+	}
+	if len(cfg.Budget.WarnAt) == 0 {
+		cfg.Budget.WarnAt = []float64{0.8}
+	}
+	if cfg.Budget.CheckInterval == 0 {
+		cfg.Budget.CheckInterval = time.Minute
+	}
+
+	// Default worktree branch prefix
+	if cfg.Worktree.BranchPrefix == "" {
+		cfg.Worktree.BranchPrefix = "asc/"
+	}
+
+	// Default in-memory message history
+	if cfg.TUI.MessageHistory == 0 {
+		cfg.TUI.MessageHistory = 1000
+	}
+
+	// Default telemetry service name
+	if cfg.Telemetry.ServiceName == "" {
+		cfg.Telemetry.ServiceName = "asc"
+	}
+
+	// Default notification events, rate limits, and timeouts
+	defaultEvents := []string{"agent.crashed", "task.stuck", "doctor.critical", "stack.started", "stack.stopped"}
+	webhook := &cfg.Notify.Webhook
+	if len(webhook.Events) == 0 {
+		webhook.Events = defaultEvents
+	}
+	if webhook.RateLimit == 0 {
+		webhook.RateLimit = 10
+	}
+	if webhook.Timeout == 0 {
+		webhook.Timeout = 10 * time.Second
+	}
+	for _, chat := range []*ChatConfig{&cfg.Notify.Slack, &cfg.Notify.Discord} {
+		if len(chat.Events) == 0 {
+			chat.Events = defaultEvents
+		}
+		if chat.Username == "" {
+			chat.Username = "asc"
+		}
+		if chat.RateLimit == 0 {
+			chat.RateLimit = 10
+		}
+		if chat.Timeout == 0 {
+			chat.Timeout = 10 * time.Second
+		}
+	}
+
+	// Default GitHub sync token, API, conflict rule, and timeout
+	github := &cfg.Sync.GitHub
+	if github.TokenEnv == "" {
+		github.TokenEnv = "GITHUB_TOKEN"
+	}
+	if github.APIURL == "" {
+		github.APIURL = "https://api.github.com"
+	}
+	if github.Conflict == "" {
+		github.Conflict = "github"
+	}
+	if github.Timeout == 0 {
+		github.Timeout = 30 * time.Second
+	}
+
+	// Default Jira sync token, statuses, conflict rule, and timeout
+	jira := &cfg.Sync.Jira
+	if jira.TokenEnv == "" {
+		jira.TokenEnv = "JIRA_API_TOKEN"
+	}
+	if len(jira.Statuses) == 0 {
+		jira.Statuses = map[string]string{"open": "To Do", "in_progress": "In Progress", "closed": "Done"}
+	}
+	if jira.Conflict == "" {
+		jira.Conflict = "jira"
+	}
+	if jira.Timeout == 0 {
+		jira.Timeout = 30 * time.Second
+	}
+
+	// Default log shipping workspace label
+	if cfg.Logging.Ship.Workspace == "" {
+		if wd, err := os.Getwd(); err == nil {
+			cfg.Logging.Ship.Workspace = filepath.Base(wd)
+		}
+	}
+}
+
+// applyMCPDefaults sets the default timeouts and retry backoff of an MCP
+// server's client, and how long it has to become ready
+func applyMCPDefaults(mcp *MCPConfig) {
+	if mcp.Protocol == "" {
+		mcp.Protocol = ProtocolHTTP
+	}
+	if mcp.ConnectTimeout == 0 {
+		mcp.ConnectTimeout = 2 * time.Second
+	}
+	if mcp.ReadTimeout == 0 {
+		mcp.ReadTimeout = 5 * time.Second
+	}
+	if mcp.RetryBackoff == 0 {
+		mcp.RetryBackoff = time.Second
+	}
+	if mcp.MaxRetryBackoff == 0 {
+		mcp.MaxRetryBackoff = 10 * time.Second
+		if mcp.RetryBackoff > mcp.MaxRetryBackoff {
+			mcp.MaxRetryBackoff = mcp.RetryBackoff
+		}
+	}
+	if mcp.BreakerCooldown == 0 {
+		mcp.BreakerCooldown = 30 * time.Second
+	}
+	if mcp.ReadyCheck.Timeout == 0 {
+		mcp.ReadyCheck.Timeout = defaultReadyTimeout
+	}
 }
 
 // validate checks that all required configuration fields are present and valid
@@ -97,6 +412,11 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("invalid beads_db_path: %w", err)
 	}
 	cfg.Core.BeadsDBPath = beadsPath
+	switch cfg.Core.BeadsMode {
+	case "", "auto", "sqlite", "cli":
+	default:
+		return fmt.Errorf("core.beads_mode must be auto, sqlite, or cli, got '%s'", cfg.Core.BeadsMode)
+	}
 
 	// Validate MCP configuration
 	if cfg.Services.MCPAgentMail.StartCommand == "" {
@@ -105,6 +425,85 @@ func validate(cfg *Config) error {
 	if cfg.Services.MCPAgentMail.URL == "" {
 		return fmt.Errorf("services.mcp_agent_mail.url is required")
 	}
+	if err := validateMCPServer(DefaultMCPServer, &cfg.Services.MCPAgentMail); err != nil {
+		return err
+	}
+	for name, server := range cfg.Services.MCPServers {
+		if _, ok := cfg.Agents[name]; ok {
+			return fmt.Errorf("services.%s: an agent has the same name as the MCP server", name)
+		}
+		if server.URL == "" {
+			return fmt.Errorf("services.%s.url is required", name)
+		}
+		if err := validateMCPServer(name, &server); err != nil {
+			return err
+		}
+		cfg.Services.MCPServers[name] = server
+	}
+
+	// Validate logging configuration
+	if err := validateLogging(cfg.Logging); err != nil {
+		return err
+	}
+
+	// Validate custom checks
+	if err := validateCustomChecks(cfg.Check.Custom); err != nil {
+		return err
+	}
+	for name, check := range cfg.Doctor.Checks {
+		if strings.TrimSpace(check.Command) == "" {
+			return fmt.Errorf("doctor.checks.%s: command is required", name)
+		}
+		if check.Timeout < 0 {
+			return fmt.Errorf("doctor.checks.%s: timeout must not be negative", name)
+		}
+	}
+	if cfg.Doctor.Interval < 0 || (cfg.Doctor.Interval > 0 && cfg.Doctor.Interval < MinDoctorInterval) {
+		return fmt.Errorf("doctor.interval must be at least %s, got %s\n  Suggestion: Use a longer interval, e.g. \"30m\", or remove it to run asc doctor only on demand", MinDoctorInterval, cfg.Doctor.Interval)
+	}
+
+	// Validate the phase pipeline
+	if err := validatePipeline(cfg.Pipeline); err != nil {
+		return err
+	}
+
+	// Validate budgets
+	if err := validateBudget(cfg.Budget); err != nil {
+		return err
+	}
+
+	// Validate worktrees
+	if err := validateWorktree(&cfg.Worktree); err != nil {
+		return err
+	}
+
+	// Validate secrets recipients and references
+	if err := validateSecrets(&cfg.Secrets); err != nil {
+		return err
+	}
+
+	// Validate telemetry export
+	if err := validateTelemetry(cfg.Telemetry); err != nil {
+		return err
+	}
+
+	// Validate notifications
+	if err := validateNotify(cfg.Notify); err != nil {
+		return err
+	}
+
+	// Validate task sync
+	if err := validateGitHubSync(cfg.Sync.GitHub); err != nil {
+		return err
+	}
+	if err := validateJiraSync(cfg.Sync.Jira); err != nil {
+		return err
+	}
+
+	// Validate the dashboard settings
+	if cfg.TUI.MessageHistory < 0 {
+		return fmt.Errorf("tui.message_history must not be negative")
+	}
 
 	// Validate agents
 	if len(cfg.Agents) == 0 {
@@ -126,6 +525,18 @@ func validate(cfg *Config) error {
 		if err := validateAgent(name, agent); err != nil {
 			return err
 		}
+		if err := expandAgentEnv(name, &agent); err != nil {
+			return err
+		}
+		cfg.Agents[name] = agent
+		if _, ok := cfg.Services.MCPServer(agent.MCPServer); agent.MCPServer != "" && !ok {
+			return fmt.Errorf("agent '%s': mcp_server references unknown MCP server '%s'\n  Suggestion: Use mcp_agent_mail or a server defined in a [services.mcp_<name>] section", name, agent.MCPServer)
+		}
+	}
+
+	// Validate startup dependencies between agents
+	if err := validateDependencies(cfg); err != nil {
+		return err
 	}
 
 	return nil
@@ -133,20 +544,34 @@ func validate(cfg *Config) error {
 
 // validateAgent validates a single agent configuration with detailed error messages and suggestions
 func validateAgent(name string, agent AgentConfig) error {
-	// Validate command is present
-	if agent.Command == "" {
-		return fmt.Errorf("agent '%s': command is required", name)
+	if agent.Runtime != "" && !containsFold([]string{"process", "docker"}, agent.Runtime) {
+		return fmt.Errorf("agent '%s': unsupported runtime '%s'\n  Valid runtimes: process, docker", name, agent.Runtime)
 	}
+	if agent.UsesDocker() {
+		// The command runs in the container, or the image's own
+		if err := validateDocker(fmt.Sprintf("agent '%s': docker", name), agent.Docker); err != nil {
+			return err
+		}
+	} else {
+		if agent.Docker.IsSet() {
+			return fmt.Errorf("agent '%s': docker settings need runtime = \"docker\"", name)
+		}
 
-	// Validate command exists in PATH
-	cmdParts := strings.Fields(agent.Command)
-	if len(cmdParts) == 0 {
-		return fmt.Errorf("agent '%s': command is empty", name)
-	}
-	
-	cmdName := cmdParts[0]
-	if _, err := exec.LookPath(cmdName); err != nil {
-		return fmt.Errorf("agent '%s': command '%s' not found in PATH\n  Suggestion: Install the required binary or check your PATH environment variable", name, cmdName)
+		// Validate command is present
+		if agent.Command == "" {
+			return fmt.Errorf("agent '%s': command is required", name)
+		}
+
+		// Validate command exists in PATH
+		cmdParts := strings.Fields(agent.Command)
+		if len(cmdParts) == 0 {
+			return fmt.Errorf("agent '%s': command is empty", name)
+		}
+
+		cmdName := cmdParts[0]
+		if _, err := exec.LookPath(cmdName); err != nil {
+			return fmt.Errorf("agent '%s': command '%s' not found in PATH\n  Suggestion: Install the required binary or check your PATH environment variable", name, cmdName)
+		}
 	}
 
 	// Validate model is present
@@ -186,9 +611,630 @@ func validateAgent(name string, agent AgentConfig) error {
 		}
 	}
 
+	// Validate the prompt reference; the prompt itself is resolved at start
+	if agent.Prompt != "" {
+		if _, _, err := prompts.ParseRef(agent.Prompt); err != nil {
+			return fmt.Errorf("agent '%s': %w", name, err)
+		}
+	}
+
+	if agent.BudgetUSD < 0 {
+		return fmt.Errorf("agent '%s': budget_usd must not be negative", name)
+	}
+
+	if agent.Restart != "" && !containsFold([]string{"always", "on-failure", "never"}, agent.Restart) {
+		return fmt.Errorf("agent '%s': unsupported restart policy '%s'\n  Valid policies: always, on-failure, never", name, agent.Restart)
+	}
+	if agent.MaxRestarts < -1 {
+		return fmt.Errorf("agent '%s': max_restarts must be -1 (no limit) or more", name)
+	}
+	if agent.RestartBackoff < 0 {
+		return fmt.Errorf("agent '%s': restart_backoff must not be negative", name)
+	}
+	if agent.StopSignal != "" && !containsFold(stopSignals, agent.StopSignal) {
+		return fmt.Errorf("agent '%s': unsupported stop_signal '%s'\n  Valid signals: %s", name, agent.StopSignal, strings.Join(stopSignals, ", "))
+	}
+	if agent.StopGracePeriod < 0 {
+		return fmt.Errorf("agent '%s': stop_grace_period must not be negative", name)
+	}
+	if agent.MaxMemoryMB < 0 {
+		return fmt.Errorf("agent '%s': max_memory_mb must not be negative", name)
+	}
+	if agent.CPULimit < 0 {
+		return fmt.Errorf("agent '%s': cpu_limit must not be negative", name)
+	}
+	if err := validateLogRotation(fmt.Sprintf("agent '%s': log_rotation", name), agent.LogRotation); err != nil {
+		return err
+	}
+	if err := validateReadyCheck(fmt.Sprintf("agent '%s': ready_check", name), agent.ReadyCheck); err != nil {
+		return err
+	}
+	if err := validateHealthCheck(fmt.Sprintf("agent '%s': health_check", name), agent.HealthCheck); err != nil {
+		return err
+	}
+
+	if agent.UsesDocker() && (agent.Workdir != "" || agent.EnvFile != "" || len(agent.ExtraEnv) > 0 || agent.Umask != "") {
+		return fmt.Errorf("agent '%s': workdir, env_file, extra_env, and umask need runtime = \"process\"\n  Suggestion: Use docker.volumes and docker.env for a container", name)
+	}
+	if agent.Umask != "" {
+		if mask, err := strconv.ParseUint(agent.Umask, 8, 32); err != nil || mask > 0777 {
+			return fmt.Errorf("agent '%s': umask '%s' is not an octal mask from 0000 to 0777, e.g. \"0027\"", name, agent.Umask)
+		}
+	}
+	for variable := range agent.ExtraEnv {
+		if !envName.MatchString(variable) {
+			return fmt.Errorf("agent '%s': extra_env.%s is not an environment variable name", name, variable)
+		}
+	}
+
 	return nil
 }
 
+// expandAgentEnv expands the workdir and env_file paths of an agent, and
+// upper-cases the names in its extra_env, since TOML keys are read in
+// lower case. Whether the paths exist is checked as the agent starts.
+func expandAgentEnv(name string, agent *AgentConfig) error {
+	for _, file := range []struct {
+		key  string
+		path *string
+	}{{"workdir", &agent.Workdir}, {"env_file", &agent.EnvFile}} {
+		if *file.path == "" {
+			continue
+		}
+		path, err := expandPath(*file.path)
+		if err != nil {
+			return fmt.Errorf("agent '%s': invalid %s: %w", name, file.key, err)
+		}
+		*file.path = path
+	}
+	if len(agent.ExtraEnv) > 0 {
+		env := make(map[string]string, len(agent.ExtraEnv))
+		for variable, value := range agent.ExtraEnv {
+			env[strings.ToUpper(variable)] = value
+		}
+		agent.ExtraEnv = env
+	}
+	return nil
+}
+
+// validateDocker validates the container of an agent, named by section in
+// errors
+func validateDocker(section string, cfg DockerConfig) error {
+	if cfg.Image == "" {
+		return fmt.Errorf("%s.image is required with runtime = \"docker\"", section)
+	}
+	if _, err := exec.LookPath(docker.Binary); err != nil {
+		return fmt.Errorf("%s: %s not found in PATH\n  Suggestion: Install Docker or check your PATH environment variable", section, docker.Binary)
+	}
+	for _, volume := range cfg.Volumes {
+		if err := docker.ValidateVolume(volume); err != nil {
+			return fmt.Errorf("%s: %w", section, err)
+		}
+	}
+	for _, entry := range cfg.Env {
+		if err := docker.ValidateEnv(entry); err != nil {
+			return fmt.Errorf("%s: %w", section, err)
+		}
+	}
+	return nil
+}
+
+// stopSignals are the signals an agent's stop_signal may name
+var stopSignals = []string{"SIGTERM", "SIGINT", "SIGHUP", "SIGQUIT", "SIGUSR1", "SIGUSR2"}
+
+// defaultReadyTimeout is how long a ready check waits by default
+const defaultReadyTimeout = 30 * time.Second
+
+// validateReadyCheck validates a ready check section, named by section in
+// errors
+func validateReadyCheck(section string, check ReadyCheckConfig) error {
+	set := 0
+	for _, isSet := range []bool{check.Port != 0, check.URL != "", check.LogRegex != ""} {
+		if isSet {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("%s: set only one of port, url, and log_regex", section)
+	}
+	if check.Port < 0 || check.Port > 65535 {
+		return fmt.Errorf("%s.port must be between 1 and 65535", section)
+	}
+	if check.URL != "" {
+		if u, err := url.Parse(check.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s.url must be an http or https URL, got '%s'", section, check.URL)
+		}
+	}
+	if check.LogRegex != "" {
+		if _, err := regexp.Compile(check.LogRegex); err != nil {
+			return fmt.Errorf("%s.log_regex is not a valid regular expression: %w", section, err)
+		}
+	}
+	if check.Timeout < 0 {
+		return fmt.Errorf("%s.timeout must not be negative", section)
+	}
+	return nil
+}
+
+// applyHealthCheckDefaults sets the defaults of a configured health check
+func applyHealthCheckDefaults(check *HealthCheckConfig) {
+	if !check.IsSet() {
+		return
+	}
+	if check.Heartbeat && check.MaxHeartbeatAge == 0 {
+		check.MaxHeartbeatAge = 2 * time.Minute
+	}
+	if check.Interval == 0 {
+		check.Interval = 30 * time.Second
+	}
+	if check.Timeout == 0 {
+		check.Timeout = 10 * time.Second
+	}
+	if check.Retries == 0 {
+		check.Retries = 3
+	}
+	if check.Action == "" {
+		check.Action = "restart"
+	}
+}
+
+// validateHealthCheck validates a health check section, named by section
+// in errors
+func validateHealthCheck(section string, check HealthCheckConfig) error {
+	set := 0
+	for _, isSet := range []bool{check.Command != "", check.URL != "", check.Heartbeat} {
+		if isSet {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("%s: set only one of command, url, and heartbeat", section)
+	}
+	if set == 0 {
+		if check.Action != "" || check.NotifyCommand != "" {
+			return fmt.Errorf("%s: set one of command, url, and heartbeat", section)
+		}
+		return nil
+	}
+	if check.URL != "" {
+		if u, err := url.Parse(check.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s.url must be an http or https URL, got '%s'", section, check.URL)
+		}
+	}
+	if check.Interval < 0 || check.Timeout < 0 || check.MaxHeartbeatAge < 0 {
+		return fmt.Errorf("%s: interval, timeout, and max_heartbeat_age must not be negative", section)
+	}
+	if check.Retries < 0 {
+		return fmt.Errorf("%s.retries must not be negative", section)
+	}
+	if !containsFold([]string{"restart", "notify", "degrade"}, check.Action) {
+		return fmt.Errorf("%s: unsupported action '%s'\n  Valid actions: restart, notify, degrade", section, check.Action)
+	}
+	if strings.EqualFold(check.Action, "notify") && check.NotifyCommand == "" {
+		return fmt.Errorf("%s: action 'notify' requires notify_command", section)
+	}
+	return nil
+}
+
+// validateDependencies checks that agents depend only on other agents or
+// mcp_agent_mail, and not on each other in a cycle
+func validateDependencies(cfg *Config) error {
+	for name, agent := range cfg.Agents {
+		for _, dep := range agent.DependsOn {
+			if dep == name {
+				return fmt.Errorf("agent '%s': depends_on lists the agent itself", name)
+			}
+			if _, ok := cfg.Agents[dep]; !ok && dep != "mcp_agent_mail" {
+				return fmt.Errorf("agent '%s': depends_on references unknown agent '%s'\n  Suggestion: List agents defined in [agent.<name>] sections, or mcp_agent_mail", name, dep)
+			}
+		}
+	}
+	if _, err := cfg.StartOrder(); err != nil {
+		return fmt.Errorf("%w\n  Suggestion: Remove one of the depends_on entries in the cycle", err)
+	}
+	return nil
+}
+
+// validateLogRotation validates a log rotation section, named by section
+// in errors
+func validateLogRotation(section string, rotation LogRotationConfig) error {
+	if rotation.MaxSizeMB < -1 {
+		return fmt.Errorf("%s.max_size_mb must be -1 (never rotate) or more", section)
+	}
+	if rotation.MaxFiles < 0 {
+		return fmt.Errorf("%s.max_files must not be negative", section)
+	}
+	if rotation.MaxAgeDays < 0 {
+		return fmt.Errorf("%s.max_age_days must not be negative", section)
+	}
+	return nil
+}
+
+// validateLogging validates the [logging] section
+func validateLogging(logging LoggingConfig) error {
+	validFormats := []string{"text", "json", "logfmt"}
+	if logging.Format != "" && !containsFold(validFormats, logging.Format) {
+		return fmt.Errorf("logging.format: unsupported format '%s'\n  Valid formats: %s",
+			logging.Format, strings.Join(validFormats, ", "))
+	}
+
+	validLevels := []string{"trace", "debug", "info", "warn", "warning", "error"}
+	if logging.Level != "" && !containsFold(validLevels, logging.Level) {
+		return fmt.Errorf("logging.level: unsupported level '%s'\n  Valid levels: trace, debug, info, warn, error",
+			logging.Level)
+	}
+
+	if logging.Stderr != "" && !containsFold(validLevels, logging.Stderr) {
+		return fmt.Errorf("logging.stderr: unsupported level '%s'\n  Valid levels: trace, debug, info, warn, error",
+			logging.Stderr)
+	}
+
+	for component, level := range logging.Levels {
+		if !containsFold(validLevels, level) {
+			return fmt.Errorf("logging.levels.%s: unsupported level '%s'\n  Valid levels: trace, debug, info, warn, error",
+				component, level)
+		}
+	}
+
+	if err := validateLogRotation("logging.rotation", logging.Rotation); err != nil {
+		return err
+	}
+	if logging.Crashes.LogKB < 0 {
+		return fmt.Errorf("logging.crashes.log_kb must not be negative")
+	}
+	if logging.Crashes.MaxFiles < 0 {
+		return fmt.Errorf("logging.crashes.max_files must not be negative")
+	}
+
+	if logging.Syslog.Enabled {
+		switch logging.Syslog.Network {
+		case "":
+		case "udp", "tcp":
+			if logging.Syslog.Address == "" {
+				return fmt.Errorf("logging.syslog.address is required when network is '%s'", logging.Syslog.Network)
+			}
+		default:
+			return fmt.Errorf("logging.syslog.network: unsupported network '%s'\n  Valid networks: udp, tcp (or empty for the local daemon)",
+				logging.Syslog.Network)
+		}
+	}
+
+	if logging.Ship.Enabled {
+		if logging.Ship.Backend != "loki" && logging.Ship.Backend != "elasticsearch" {
+			return fmt.Errorf("logging.ship.backend: unsupported backend '%s'\n  Valid backends: loki, elasticsearch",
+				logging.Ship.Backend)
+		}
+		if logging.Ship.URL == "" {
+			return fmt.Errorf("logging.ship.url is required when log shipping is enabled")
+		}
+		if logging.Ship.BatchSize < 0 || logging.Ship.FlushInterval < 0 {
+			return fmt.Errorf("logging.ship: batch_size and flush_interval must not be negative")
+		}
+		if _, err := proxy.ForService(logging.Ship.Proxy); err != nil {
+			return fmt.Errorf("logging.ship.proxy: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateTelemetry validates the [telemetry] section
+func validateTelemetry(telemetry TelemetryConfig) error {
+	if !telemetry.Enabled {
+		return nil
+	}
+	if telemetry.Endpoint != "" && !strings.HasPrefix(telemetry.Endpoint, "http://") && !strings.HasPrefix(telemetry.Endpoint, "https://") {
+		return fmt.Errorf("telemetry.endpoint: '%s' must be an http:// or https:// URL", telemetry.Endpoint)
+	}
+	if telemetry.FlushInterval < 0 {
+		return fmt.Errorf("telemetry.flush_interval must not be negative")
+	}
+	if _, err := proxy.ForService(telemetry.Proxy); err != nil {
+		return fmt.Errorf("telemetry.proxy: %w", err)
+	}
+	return nil
+}
+
+// validateNotify validates the [notify] section
+func validateNotify(notify NotifyConfig) error {
+	webhook := notify.Webhook
+	if err := validateNotifier("notify.webhook", webhook.URL, webhook.URLEnv, webhook.Events, webhook.RateLimit, webhook.Timeout, webhook.Proxy); err != nil {
+		return err
+	}
+	for name, chat := range map[string]ChatConfig{"notify.slack": notify.Slack, "notify.discord": notify.Discord} {
+		if err := validateNotifier(name, chat.URL, chat.URLEnv, chat.Events, chat.RateLimit, chat.Timeout, chat.Proxy); err != nil {
+			return err
+		}
+		for key, text := range chat.Templates {
+			if _, err := template.New(key).Parse(text); err != nil {
+				return fmt.Errorf("%s.templates.%s: %w", name, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNotifier validates the settings every [notify.*] section has
+func validateNotifier(name, url, urlEnv string, events []string, rateLimit int, timeout time.Duration, proxyOverride string) error {
+	if url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("%s.url: '%s' must be an http:// or https:// URL", name, url)
+	}
+	if url != "" && urlEnv != "" {
+		return fmt.Errorf("%s: set url or url_env, not both", name)
+	}
+	for _, event := range events {
+		if strings.TrimSpace(event) == "" {
+			return fmt.Errorf("%s.events must not contain empty event types", name)
+		}
+	}
+	if rateLimit < -1 {
+		return fmt.Errorf("%s.rate_limit must be -1 (no limit) or more", name)
+	}
+	if timeout < 0 {
+		return fmt.Errorf("%s.timeout must not be negative", name)
+	}
+	if _, err := proxy.ForService(proxyOverride); err != nil {
+		return fmt.Errorf("%s.proxy: %w", name, err)
+	}
+	return nil
+}
+
+// validateGitHubSync validates the [sync.github] section
+func validateGitHubSync(github GitHubSyncConfig) error {
+	if github.Repo != "" {
+		if owner, name, ok := strings.Cut(github.Repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("sync.github.repo: '%s' must be owner/name", github.Repo)
+		}
+	}
+	if github.APIURL != "" && !strings.HasPrefix(github.APIURL, "http://") && !strings.HasPrefix(github.APIURL, "https://") {
+		return fmt.Errorf("sync.github.api_url: '%s' must be an http:// or https:// URL", github.APIURL)
+	}
+	switch github.Conflict {
+	case "", "github", "beads", "skip":
+	default:
+		return fmt.Errorf("sync.github.conflict must be github, beads, or skip, got '%s'", github.Conflict)
+	}
+	if github.Timeout < 0 {
+		return fmt.Errorf("sync.github.timeout must not be negative")
+	}
+	if _, err := proxy.ForService(github.Proxy); err != nil {
+		return fmt.Errorf("sync.github.proxy: %w", err)
+	}
+	return nil
+}
+
+// validateJiraSync validates the [sync.jira] section
+func validateJiraSync(jira JiraSyncConfig) error {
+	if jira.URL != "" && !strings.HasPrefix(jira.URL, "http://") && !strings.HasPrefix(jira.URL, "https://") {
+		return fmt.Errorf("sync.jira.url: '%s' must be an http:// or https:// URL", jira.URL)
+	}
+	for status, name := range jira.Statuses {
+		switch status {
+		case "open", "in_progress", "blocked", "closed":
+		default:
+			return fmt.Errorf("sync.jira.statuses: unknown beads status '%s'\n  Valid statuses: open, in_progress, blocked, closed", status)
+		}
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("sync.jira.statuses.%s must name a Jira status", status)
+		}
+	}
+	switch jira.Conflict {
+	case "", "jira", "beads", "skip":
+	default:
+		return fmt.Errorf("sync.jira.conflict must be jira, beads, or skip, got '%s'", jira.Conflict)
+	}
+	if jira.Timeout < 0 {
+		return fmt.Errorf("sync.jira.timeout must not be negative")
+	}
+	if _, err := proxy.ForService(jira.Proxy); err != nil {
+		return fmt.Errorf("sync.jira.proxy: %w", err)
+	}
+	return nil
+}
+
+// validateCustomChecks validates the [check.custom.*] sections
+func validateCustomChecks(checks map[string]CustomCheckConfig) error {
+	for name, check := range checks {
+		if strings.TrimSpace(check.Command) == "" {
+			return fmt.Errorf("check.custom.%s: command is required", name)
+		}
+		if check.Severity != "" && !containsFold([]string{"error", "warn", "warning"}, check.Severity) {
+			return fmt.Errorf("check.custom.%s: unsupported severity '%s'\n  Valid severities: error, warn",
+				name, check.Severity)
+		}
+		if check.ExitCode < 0 || check.ExitCode > 255 {
+			return fmt.Errorf("check.custom.%s: exit_code must be between 0 and 255", name)
+		}
+	}
+	return nil
+}
+
+// validatePipeline validates the [pipeline] section
+func validatePipeline(pipeline PipelineConfig) error {
+	seen := make(map[string]bool)
+	for _, phase := range pipeline.Phases {
+		if !isValidPhase(phase) {
+			return fmt.Errorf("pipeline.phases: invalid phase '%s'\n  Suggestion: Use phases that agents can handle, e.g. planning, implementation, testing, review", phase)
+		}
+		if seen[strings.ToLower(phase)] {
+			return fmt.Errorf("pipeline.phases: phase '%s' is listed more than once", phase)
+		}
+		seen[strings.ToLower(phase)] = true
+	}
+	for phase, gate := range pipeline.Gates {
+		if !seen[strings.ToLower(phase)] {
+			return fmt.Errorf("pipeline.gates.%s: phase is not in pipeline.phases", phase)
+		}
+		if gate.MinTasks < 0 {
+			return fmt.Errorf("pipeline.gates.%s: min_tasks must not be negative", phase)
+		}
+		if gate.Completion < 0 || gate.Completion > 1 {
+			return fmt.Errorf("pipeline.gates.%s: completion must be between 0 and 1", phase)
+		}
+	}
+	if pipeline.Interval < 0 {
+		return fmt.Errorf("pipeline.interval must not be negative")
+	}
+	return nil
+}
+
+// validateMCPServer checks the [services.<name>] section of an MCP
+// server, expanding the paths of its TLS files
+func validateMCPServer(name string, mcp *MCPConfig) error {
+	section := "services." + name
+	switch mcp.Protocol {
+	case "", ProtocolHTTP, ProtocolGRPC:
+	default:
+		return fmt.Errorf("%s.protocol must be %q or %q, got %q", section, ProtocolHTTP, ProtocolGRPC, mcp.Protocol)
+	}
+	if _, err := proxy.ForService(mcp.Proxy); err != nil {
+		return fmt.Errorf("%s.proxy: %w", section, err)
+	}
+	if err := validateMCPTimeouts(section, *mcp); err != nil {
+		return err
+	}
+	if err := validateMCPAuth(section, mcp); err != nil {
+		return err
+	}
+	return validateReadyCheck(section+".ready_check", mcp.ReadyCheck)
+}
+
+// validateMCPTimeouts checks the MCP client's timeouts, retries, queue,
+// compression, and batching
+func validateMCPTimeouts(section string, mcp MCPConfig) error {
+	if mcp.ConnectTimeout < 0 || mcp.ReadTimeout < 0 || mcp.RetryBackoff < 0 || mcp.MaxRetryBackoff < 0 || mcp.BreakerCooldown < 0 {
+		return fmt.Errorf("%s: connect_timeout, read_timeout, retry_backoff, max_retry_backoff, and breaker_cooldown must not be negative", section)
+	}
+	if mcp.MaxRetryBackoff > 0 && mcp.MaxRetryBackoff < mcp.RetryBackoff {
+		return fmt.Errorf("%s.max_retry_backoff (%v) must not be less than retry_backoff (%v)", section, mcp.MaxRetryBackoff, mcp.RetryBackoff)
+	}
+	if mcp.MaxRetries != nil && *mcp.MaxRetries < 0 {
+		return fmt.Errorf("%s.max_retries must not be negative", section)
+	}
+	if mcp.BreakerThreshold != nil && *mcp.BreakerThreshold < 0 {
+		return fmt.Errorf("%s.breaker_threshold must not be negative", section)
+	}
+	if mcp.QueueSize != nil && *mcp.QueueSize < 0 {
+		return fmt.Errorf("%s.queue_size must not be negative", section)
+	}
+	if mcp.BatchWindow < 0 || mcp.BatchSize < 0 {
+		return fmt.Errorf("%s: batch_window and batch_size must not be negative", section)
+	}
+	switch mcp.Compression {
+	case "", "gzip", "zstd":
+	default:
+		return fmt.Errorf("%s.compression must be \"gzip\" or \"zstd\", got %q", section, mcp.Compression)
+	}
+	return nil
+}
+
+// envName matches the names of environment variables
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateMCPAuth checks the TLS files and token variable of the MCP
+// client, expanding the paths of the files
+func validateMCPAuth(section string, mcp *MCPConfig) error {
+	if (mcp.CertFile == "") != (mcp.KeyFile == "") {
+		return fmt.Errorf("%s: cert_file and key_file must be set together", section)
+	}
+	if (mcp.CAFile != "" || mcp.CertFile != "") && !strings.HasPrefix(mcp.URL, "https://") {
+		return fmt.Errorf("%s: ca_file, cert_file, and key_file need an https url, got '%s'", section, mcp.URL)
+	}
+	for _, file := range []struct {
+		key  string
+		path *string
+	}{{"ca_file", &mcp.CAFile}, {"cert_file", &mcp.CertFile}, {"key_file", &mcp.KeyFile}} {
+		if *file.path == "" {
+			continue
+		}
+		path, err := expandPath(*file.path)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", section, file.key, err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("%s.%s: %w", section, file.key, err)
+		}
+		*file.path = path
+	}
+	if mcp.TokenEnv != "" && !envName.MatchString(mcp.TokenEnv) {
+		return fmt.Errorf("%s.token_env: '%s' is not an environment variable name", section, mcp.TokenEnv)
+	}
+	return nil
+}
+
+// validateBudget validates the [budget] section
+func validateBudget(budget BudgetConfig) error {
+	if budget.ProjectUSD < 0 {
+		return fmt.Errorf("budget.project_usd must not be negative")
+	}
+	if budget.Period != "" && !containsFold([]string{"daily", "monthly", "total"}, budget.Period) {
+		return fmt.Errorf("budget.period: unsupported period '%s'\n  Valid periods: daily, monthly, total", budget.Period)
+	}
+	for _, fraction := range budget.WarnAt {
+		if fraction <= 0 || fraction >= 1 {
+			return fmt.Errorf("budget.warn_at: %v must be between 0 and 1 (e.g. 0.8 warns at 80%%)", fraction)
+		}
+	}
+	if budget.CheckInterval < 0 {
+		return fmt.Errorf("budget.check_interval must not be negative")
+	}
+	if budget.NotifyURL != "" {
+		if u, err := url.Parse(budget.NotifyURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("budget.notify_url must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+// validateWorktree validates the [worktree] section and expands its dir
+func validateWorktree(worktree *WorktreeConfig) error {
+	if strings.ContainsAny(worktree.BranchPrefix, " ~^:?*[\\") || strings.Contains(worktree.BranchPrefix, "..") {
+		return fmt.Errorf("worktree.branch_prefix '%s' is not valid in a git branch name", worktree.BranchPrefix)
+	}
+	if worktree.Dir != "" {
+		dir, err := expandPath(worktree.Dir)
+		if err != nil {
+			return fmt.Errorf("invalid worktree.dir: %w", err)
+		}
+		worktree.Dir = dir
+	}
+	return nil
+}
+
+// validateSecrets validates the [secrets] section. Variable names in
+// [secrets.env] are upper-cased, since TOML keys are read in lower case.
+func validateSecrets(cfg *SecretsConfig) error {
+	for i, recipient := range cfg.Recipients {
+		if _, err := age.ParseX25519Recipient(recipient); err != nil {
+			return fmt.Errorf("secrets.recipients[%d]: '%s' is not an age public key\n  Suggestion: Use the public key shown by 'asc secrets status', which starts with age1", i, recipient)
+		}
+	}
+
+	if cfg.RotationDays < 0 {
+		return fmt.Errorf("secrets.rotation_days must not be negative\n  Suggestion: Set it to the maximum key age in days, or 0 for no rotation policy")
+	}
+
+	env := make(map[string]string, len(cfg.Env))
+	for name, ref := range cfg.Env {
+		if !secrets.IsReference(ref) {
+			return fmt.Errorf("secrets.env.%s: '%s' is not a secret reference\n  Suggestion: Use aws-sm://<secret-id> or gcp-sm://<project>/<secret>; keep plain values in .env", strings.ToUpper(name), ref)
+		}
+		env[strings.ToUpper(name)] = ref
+	}
+	cfg.Env = env
+	return nil
+}
+
+// containsFold reports whether values contains s, ignoring case
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
 // isValidModel checks if the model name is supported
 func isValidModel(model string) bool {
 	supportedModels := map[string]bool{
@@ -201,22 +1247,24 @@ func isValidModel(model string) bool {
 	return supportedModels[strings.ToLower(model)]
 }
 
+// phaseSet holds the phases an agent may declare
+var phaseSet = map[string]bool{
+	"planning":       true,
+	"design":         true,
+	"implementation": true,
+	"coding":         true,
+	"testing":        true,
+	"review":         true,
+	"refactor":       true,
+	"documentation":  true,
+	"debugging":      true,
+	"optimization":   true,
+	"deployment":     true,
+}
+
 // isValidPhase checks if the phase name is valid
 func isValidPhase(phase string) bool {
-	validPhases := map[string]bool{
-		"planning":       true,
-		"design":         true,
-		"implementation": true,
-		"coding":         true,
-		"testing":        true,
-		"review":         true,
-		"refactor":       true,
-		"documentation":  true,
-		"debugging":      true,
-		"optimization":   true,
-		"deployment":     true,
-	}
-	return validPhases[strings.ToLower(phase)]
+	return phaseSet[strings.ToLower(phase)]
 }
 
 // findClosestPhase finds the closest matching phase using simple string similarity
@@ -291,6 +1339,24 @@ func ValidateWithWarnings(cfg *Config) ([]ValidationWarning, error) {
 	}
 	
 	// Check for common configuration issues that are warnings, not errors
+
+	for _, unknown := range cfg.UnknownKeys {
+		suggestion := "Remove it, or fix its name; see docs/CONFIGURATION.md for the keys asc reads"
+		if unknown.Suggestion != "" {
+			suggestion = fmt.Sprintf("Did you mean %s?", unknown.Suggestion)
+		}
+		warnings = append(warnings, ValidationWarning{
+			Message:    fmt.Sprintf("%s: unknown key %s is ignored", unknown.At, unknown.Key),
+			Suggestion: suggestion,
+		})
+	}
+	
+	if cfg.Remote != nil && cfg.Remote.Stale != nil {
+		warnings = append(warnings, ValidationWarning{
+			Message:    fmt.Sprintf("config_source %s could not be fetched (%v); using the copy fetched %s", cfg.Remote.URL, cfg.Remote.Stale, cfg.Remote.FetchedAt.Format(time.RFC3339)),
+			Suggestion: "Check the URL and your network",
+		})
+	}
 	
 	// Warn if all agents use the same model
 	modelCounts := make(map[string]int)
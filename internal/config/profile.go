@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+)
+
+// ProfileEnvVar is the environment variable that selects the profile, as
+// --profile does. Processes asc starts inherit it, so the daemon and
+// agents run under the same profile.
+const ProfileEnvVar = "ASC_PROFILE"
+
+// profileName is the form of a profile name
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Profile returns the selected profile, or "" for none
+func Profile() string {
+	return os.Getenv(ProfileEnvVar)
+}
+
+// ValidateProfile returns an error if name is not a valid profile name
+func ValidateProfile(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile %q: use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// ProfilePath returns the overlay file of a profile next to configPath,
+// e.g. asc.prod.toml for asc.toml
+func ProfilePath(configPath, profile string) string {
+	ext := filepath.Ext(configPath)
+	return strings.TrimSuffix(configPath, ext) + "." + profile + ext
+}
+
+// ProfileEnvPath returns the secrets file of a profile, .env.<profile>,
+// or .env without one. Its encrypted version is the same path with .age
+// appended.
+func ProfileEnvPath(profile string) string {
+	if profile == "" {
+		return ".env"
+	}
+	return ".env." + profile
+}
+
+// Profiles returns the profiles defined for configPath, by [profile.<name>]
+// sections or overlay files
+func Profiles(configPath string) []string {
+	seen := map[string]bool{}
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err == nil {
+		for name := range v.GetStringMap("profile") {
+			seen[name] = true
+		}
+	}
+	ext := filepath.Ext(configPath)
+	prefix := strings.TrimSuffix(filepath.Base(configPath), ext) + "."
+	matches, _ := filepath.Glob(ProfilePath(configPath, "*"))
+	for _, match := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), prefix), ext)
+		if profileName.MatchString(name) {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile merges the overrides of a profile into v, read from
+// configPath: first its [profile.<name>] section, then its overlay file.
+// A profile with neither is an error.
+func applyProfile(v *viper.Viper, configPath, profile string) error {
+	if err := ValidateProfile(profile); err != nil {
+		return err
+	}
+	found := false
+	key := "profile." + strings.ToLower(profile)
+	if v.IsSet(key) {
+		if err := v.MergeConfigMap(v.GetStringMap(key)); err != nil {
+			return fmt.Errorf("failed to apply [%s]: %w", key, err)
+		}
+		found = true
+	}
+
+	overlay := ProfilePath(configPath, profile)
+	if f, err := os.Open(overlay); err == nil {
+		err = v.MergeConfig(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", overlay, err)
+		}
+		found = true
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", overlay, err)
+	}
+
+	if !found {
+		hint := fmt.Sprintf("Add a [profile.%s] section to %s or create %s", profile, configPath, overlay)
+		if names := Profiles(configPath); len(names) > 0 {
+			hint = fmt.Sprintf("Defined profiles: %s. %s", strings.Join(names, ", "), hint)
+		}
+		return ascerrors.WithHint(fmt.Errorf("profile %q not found", profile), hint)
+	}
+	return nil
+}
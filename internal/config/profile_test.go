@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const profileConfig = `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+
+[profile.staging.services.mcp_agent_mail]
+url = "https://mail.staging.example.com"
+
+[profile.prod.services.mcp_agent_mail]
+url = "https://mail.example.com"
+
+[profile.prod.agent.coder]
+replicas = 3
+`
+
+func TestProfiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "asc.toml")
+	if err := os.WriteFile(configPath, []byte(profileConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	overlay := "[agent.coder]\nmodel = \"gemini\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "asc.prod.toml"), []byte(overlay), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "asc.dev.toml"), []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(ProfileEnvVar, "")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Services.MCPAgentMail.URL != "http://localhost:8765" || len(cfg.Agents) != 1 {
+		t.Errorf("Expected no overrides without a profile, got %+v", cfg.Services.MCPAgentMail)
+	}
+
+	// The section applies, then the overlay file
+	t.Setenv(ProfileEnvVar, "prod")
+	cfg, err = Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Services.MCPAgentMail.URL != "https://mail.example.com" {
+		t.Errorf("Expected the prod URL, got %s", cfg.Services.MCPAgentMail.URL)
+	}
+	if len(cfg.Instances("coder")) != 3 {
+		t.Errorf("Expected 3 coder instances, got %v", cfg.Instances("coder"))
+	}
+	for _, name := range cfg.Instances("coder") {
+		if agent := cfg.Agents[name]; agent.Model != "gemini" || agent.Command != "echo" {
+			t.Errorf("Expected %s to keep its command with the overlay's model, got %+v", name, agent)
+		}
+	}
+
+	t.Setenv(ProfileEnvVar, "qa")
+	_, err = Load(configPath)
+	if err == nil || !strings.Contains(err.Error(), `profile "qa" not found`) {
+		t.Errorf("Expected an unknown profile to fail, got %v", err)
+	}
+
+	if got := Profiles(configPath); !slices.Equal(got, []string{"dev", "prod", "staging"}) {
+		t.Errorf("Profiles() = %q", got)
+	}
+	if got := ProfileEnvPath("prod"); got != ".env.prod" {
+		t.Errorf("ProfileEnvPath() = %s", got)
+	}
+	if err := ValidateProfile("../prod"); err == nil {
+		t.Error("Expected an invalid profile name to fail")
+	}
+}
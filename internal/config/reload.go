@@ -1,8 +1,17 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+	"github.com/rand/asc/internal/usage"
 )
 
 // ReloadManager handles configuration reload logic and agent lifecycle management
@@ -10,6 +19,10 @@ type ReloadManager struct {
 	currentConfig  *Config
 	processManager ProcessManager
 	envVars        map[string]string // Environment variables (API keys, etc.)
+
+	// start starts an agent as asc up does, with its resource limits and
+	// stop policy; nil to start it with processManager (see SetStartAgent)
+	start func(name string, config *Config) error
 }
 
 // ProcessManager interface for managing agent processes
@@ -55,6 +68,12 @@ func NewReloadManager(currentConfig *Config, procManager ProcessManager, envVars
 	}
 }
 
+// SetStartAgent sets how agents are started, as asc up starts them, instead
+// of with the process manager alone
+func (rm *ReloadManager) SetStartAgent(start func(name string, config *Config) error) {
+	rm.start = start
+}
+
 // ReloadResult contains information about what changed during a reload
 type ReloadResult struct {
 	AgentsAdded   []string
@@ -63,8 +82,17 @@ type ReloadResult struct {
 	Errors        []error
 }
 
-// Reload compares the new configuration with the current one and applies changes
-func (rm *ReloadManager) Reload(newConfig *Config) (*ReloadResult, error) {
+// Changed reports whether the reload added, removed, or updated agents
+func (r *ReloadResult) Changed() bool {
+	return len(r.AgentsAdded)+len(r.AgentsRemoved)+len(r.AgentsUpdated) > 0
+}
+
+// DiffAgents returns the agents newConfig adds to, removes from, and
+// changes in oldConfig, by name. An agent changes with its own settings,
+// other than replicas and depends_on, which only matter to the agents
+// started with it, and with the URL of its MCP server or the beads
+// database it is given.
+func DiffAgents(oldConfig, newConfig *Config) *ReloadResult {
 	result := &ReloadResult{
 		AgentsAdded:   []string{},
 		AgentsRemoved: []string{},
@@ -73,7 +101,7 @@ func (rm *ReloadManager) Reload(newConfig *Config) (*ReloadResult, error) {
 	}
 
 	// Find agents that were removed
-	for oldName := range rm.currentConfig.Agents {
+	for oldName := range oldConfig.Agents {
 		if _, exists := newConfig.Agents[oldName]; !exists {
 			result.AgentsRemoved = append(result.AgentsRemoved, oldName)
 		}
@@ -81,17 +109,30 @@ func (rm *ReloadManager) Reload(newConfig *Config) (*ReloadResult, error) {
 
 	// Find agents that were added or updated
 	for newName, newAgent := range newConfig.Agents {
-		oldAgent, exists := rm.currentConfig.Agents[newName]
-		
+		oldAgent, exists := oldConfig.Agents[newName]
 		if !exists {
-			// New agent
 			result.AgentsAdded = append(result.AgentsAdded, newName)
-		} else if rm.agentConfigChanged(oldAgent, newAgent) {
-			// Agent configuration changed
+			continue
+		}
+		_, oldServer := oldConfig.MCPServerFor(oldAgent)
+		_, newServer := newConfig.MCPServerFor(newAgent)
+		if agentChanged(oldAgent, newAgent) || oldServer.URL != newServer.URL || oldConfig.Core.BeadsDBPath != newConfig.Core.BeadsDBPath {
 			result.AgentsUpdated = append(result.AgentsUpdated, newName)
 		}
 	}
 
+	sort.Strings(result.AgentsAdded)
+	sort.Strings(result.AgentsRemoved)
+	sort.Strings(result.AgentsUpdated)
+	return result
+}
+
+// Reload compares the new configuration with the current one and applies
+// changes: it stops the removed agents, restarts the changed ones, and
+// starts the added ones, leaving the others running
+func (rm *ReloadManager) Reload(newConfig *Config) (*ReloadResult, error) {
+	result := DiffAgents(rm.currentConfig, newConfig)
+
 	// Apply changes: stop removed agents
 	for _, agentName := range result.AgentsRemoved {
 		if err := rm.stopAgent(agentName); err != nil {
@@ -99,8 +140,12 @@ func (rm *ReloadManager) Reload(newConfig *Config) (*ReloadResult, error) {
 		}
 	}
 
-	// Apply changes: restart updated agents
+	// Apply changes: restart updated agents, leaving those stopped, such as
+	// by the phase pipeline, to start with the new settings later
 	for _, agentName := range result.AgentsUpdated {
+		if !rm.isRunning(agentName) {
+			continue
+		}
 		if err := rm.stopAgent(agentName); err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("failed to stop agent %s for update: %w", agentName, err))
 			continue
@@ -126,18 +171,25 @@ func (rm *ReloadManager) Reload(newConfig *Config) (*ReloadResult, error) {
 
 // agentConfigChanged checks if an agent's configuration has changed
 func (rm *ReloadManager) agentConfigChanged(old, new AgentConfig) bool {
-	// Check if command changed
-	if old.Command != new.Command {
-		return true
-	}
+	return agentChanged(old, new)
+}
 
-	// Check if model changed
-	if old.Model != new.Model {
+// agentChanged reports whether an agent must be restarted to run with new
+// instead of old: the order of phases, the case of runtime, and the
+// settings ordering its start do not matter
+func agentChanged(old, new AgentConfig) bool {
+	// Check if phases changed
+	if len(old.Phases) != len(new.Phases) {
 		return true
 	}
 
-	// Check if phases changed
-	if len(old.Phases) != len(new.Phases) {
+	// Check the other settings
+	a, b := old, new
+	a.Phases, b.Phases = nil, nil
+	a.Runtime, b.Runtime = strings.ToLower(a.Runtime), strings.ToLower(b.Runtime)
+	a.Replicas, b.Replicas = 0, 0
+	a.DependsOn, b.DependsOn = nil, nil
+	if !reflect.DeepEqual(a, b) {
 		return true
 	}
 
@@ -156,6 +208,12 @@ func (rm *ReloadManager) agentConfigChanged(old, new AgentConfig) bool {
 	return false
 }
 
+// isRunning reports whether the agent is running
+func (rm *ReloadManager) isRunning(agentName string) bool {
+	info, err := rm.processManager.GetProcessInfo(agentName)
+	return err == nil && rm.processManager.IsRunning(info.GetPID())
+}
+
 // stopAgent stops a running agent
 func (rm *ReloadManager) stopAgent(agentName string) error {
 	// Get process info
@@ -178,30 +236,61 @@ func (rm *ReloadManager) stopAgent(agentName string) error {
 func (rm *ReloadManager) startAgent(agentName string, agentConfig AgentConfig, config *Config) error {
 	// Parse command and args
 	cmdParts := strings.Fields(agentConfig.Command)
-	if len(cmdParts) == 0 {
+	if len(cmdParts) == 0 && !agentConfig.UsesDocker() {
 		return fmt.Errorf("empty command")
 	}
 
-	command := cmdParts[0]
-	args := cmdParts[1:]
+	var command string
+	var args []string
+	if len(cmdParts) > 0 {
+		command = cmdParts[0]
+		args = cmdParts[1:]
+	}
+
+	if rm.start != nil {
+		return rm.start(agentName, config)
+	}
 
 	// Build environment variables
 	env := rm.buildAgentEnv(agentName, agentConfig, config)
+	promptEnv, err := PromptEnv(agentName, agentConfig, config)
+	if err != nil {
+		return err
+	}
+	env = append(env, promptEnv...)
+	worktreeEnv, err := WorktreeEnv(agentName, agentConfig, config)
+	if err != nil {
+		return err
+	}
+	env = append(env, worktreeEnv...)
+	ownEnv, err := AgentEnv(agentName, agentConfig)
+	if err != nil {
+		return err
+	}
+	env = append(env, ownEnv...)
+
+	// Run it in its container, for the docker runtime
+	command, args, env, err = RuntimeCommand(agentName, agentConfig, command, args, env)
+	if err != nil {
+		return err
+	}
 
 	// Start the process
-	_, err := rm.processManager.Start(agentName, command, args, env)
+	_, err = rm.processManager.Start(agentName, command, args, env)
 	return err
 }
 
 // buildAgentEnv builds the environment variables for an agent
 func (rm *ReloadManager) buildAgentEnv(agentName string, agentConfig AgentConfig, config *Config) []string {
+	_, mcpServer := config.MCPServerFor(agentConfig)
 	env := []string{
 		fmt.Sprintf("AGENT_NAME=%s", agentName),
 		fmt.Sprintf("AGENT_MODEL=%s", agentConfig.Model),
 		fmt.Sprintf("AGENT_PHASES=%s", strings.Join(agentConfig.Phases, ",")),
-		fmt.Sprintf("MCP_MAIL_URL=%s", config.Services.MCPAgentMail.URL),
+		fmt.Sprintf("MCP_MAIL_URL=%s", mcpServer.URL),
 		fmt.Sprintf("BEADS_DB_PATH=%s", config.Core.BeadsDBPath),
 	}
+	env = append(env, usage.Env(agentName)...)
 
 	// Add API keys from environment
 	for key, value := range rm.envVars {
@@ -215,3 +304,66 @@ func (rm *ReloadManager) buildAgentEnv(agentName string, agentConfig AgentConfig
 func (rm *ReloadManager) GetCurrentConfig() *Config {
 	return rm.currentConfig
 }
+
+// ReloadRequestPath returns the file asc reload writes to ask the running
+// stack to reload asc.toml, ~/.asc/reload.request
+func ReloadRequestPath() (string, error) {
+	return statedir.Path("reload.request")
+}
+
+// ReloadStatusPath returns where the running stack records the outcome of
+// its last reload, ~/.asc/reload.json
+func ReloadStatusPath() (string, error) {
+	return statedir.Path("reload.json")
+}
+
+// RequestReload asks the stack watching path, by Watcher.WatchRequests,
+// to reload its configuration
+func RequestReload(path string) error {
+	if err := statefile.WriteJSON(path, map[string]time.Time{"requested_at": time.Now()}, 0600); err != nil {
+		return fmt.Errorf("failed to request a reload: %w", err)
+	}
+	return nil
+}
+
+// ReloadStatus is the outcome of a reload of the running stack
+type ReloadStatus struct {
+	At      time.Time `json:"at"`
+	Added   []string  `json:"added,omitempty"`
+	Removed []string  `json:"removed,omitempty"`
+	Updated []string  `json:"updated,omitempty"`
+	// Errors are those of loading the configuration, which leaves the
+	// agents alone, and of stopping and starting agents
+	Errors []string `json:"errors,omitempty"`
+}
+
+// RecordReload writes the outcome of a reload to path: its result, or err
+// if the configuration could not be loaded
+func RecordReload(path string, result *ReloadResult, err error) error {
+	status := ReloadStatus{At: time.Now()}
+	if err != nil {
+		status.Errors = []string{err.Error()}
+	}
+	if result != nil {
+		status.Added = result.AgentsAdded
+		status.Removed = result.AgentsRemoved
+		status.Updated = result.AgentsUpdated
+		for _, err := range result.Errors {
+			status.Errors = append(status.Errors, err.Error())
+		}
+	}
+	return statefile.WriteJSON(path, status, 0600)
+}
+
+// ReadReloadStatus reads the outcome of the last reload from path. The
+// error satisfies os.IsNotExist if the stack has not reloaded.
+func ReadReloadStatus(path string) (*ReloadStatus, error) {
+	var status ReloadStatus
+	if err := statefile.ReadJSON(path, &status); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read reload status: %w", err)
+	}
+	return &status, nil
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -566,3 +568,72 @@ func TestReloadManager_BuildAgentEnv(t *testing.T) {
 		}
 	}
 }
+
+func TestDiffAgents(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			Core: CoreConfig{BeadsDBPath: "./test"},
+			Services: ServicesConfig{
+				MCPAgentMail: MCPConfig{URL: "http://localhost:8765"},
+			},
+			Agents: map[string]AgentConfig{
+				"coder-1":  {Command: "echo", Model: "claude", Phases: []string{"implementation"}, Replicas: 2, Role: "coder", Replica: 1},
+				"coder-2":  {Command: "echo", Model: "claude", Phases: []string{"implementation"}, Replicas: 2, Role: "coder", Replica: 2},
+				"reviewer": {Command: "echo", Model: "claude", Phases: []string{"review"}, DependsOn: []string{"coder-1", "coder-2"}},
+			},
+		}
+	}
+
+	// More replicas add an instance, without restarting the others or
+	// the agents depending on them
+	scaled := base()
+	for name, agent := range scaled.Agents {
+		if agent.Role == "coder" {
+			agent.Replicas = 3
+			scaled.Agents[name] = agent
+		}
+	}
+	scaled.Agents["coder-3"] = AgentConfig{Command: "echo", Model: "claude", Phases: []string{"implementation"}, Replicas: 3, Role: "coder", Replica: 3}
+	reviewer := scaled.Agents["reviewer"]
+	reviewer.DependsOn = []string{"coder-1", "coder-2", "coder-3"}
+	scaled.Agents["reviewer"] = reviewer
+	result := DiffAgents(base(), scaled)
+	if !slices.Equal(result.AgentsAdded, []string{"coder-3"}) || len(result.AgentsRemoved)+len(result.AgentsUpdated) > 0 {
+		t.Errorf("DiffAgents() = %+v, want coder-3 added", result)
+	}
+
+	// Settings beyond command, model, and phases change an agent
+	limited := base()
+	reviewer = limited.Agents["reviewer"]
+	reviewer.MaxMemoryMB = 512
+	limited.Agents["reviewer"] = reviewer
+	if result := DiffAgents(base(), limited); !slices.Equal(result.AgentsUpdated, []string{"reviewer"}) {
+		t.Errorf("DiffAgents() = %+v, want reviewer updated", result)
+	}
+
+	// So do the MCP server and beads database the agents are given
+	moved := base()
+	moved.Services.MCPAgentMail.URL = "http://localhost:9000"
+	if result := DiffAgents(base(), moved); !slices.Equal(result.AgentsUpdated, []string{"coder-1", "coder-2", "reviewer"}) {
+		t.Errorf("DiffAgents() = %+v, want every agent updated", result)
+	}
+	if result := DiffAgents(base(), base()); result.Changed() {
+		t.Errorf("DiffAgents() = %+v, want no changes", result)
+	}
+}
+
+func TestRecordReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reload.json")
+	result := &ReloadResult{AgentsAdded: []string{"fresh"}, Errors: []error{fmt.Errorf("failed to start agent fresh")}}
+	if err := RecordReload(path, result, nil); err != nil {
+		t.Fatalf("RecordReload() error = %v", err)
+	}
+	status, err := ReadReloadStatus(path)
+	if err != nil {
+		t.Fatalf("ReadReloadStatus() error = %v", err)
+	}
+	if !slices.Equal(status.Added, []string{"fresh"}) || !slices.Equal(status.Errors, []string{"failed to start agent fresh"}) || status.At.IsZero() {
+		t.Errorf("ReadReloadStatus() = %+v", status)
+	}
+}
+
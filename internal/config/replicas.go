@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// ReplicasPath returns where asc scale records the replica counts that
+// override asc.toml, ~/.asc/replicas.json
+func ReplicasPath() (string, error) {
+	return statedir.Path("replicas.json")
+}
+
+// replicasKey returns the key of the counts of the configuration file
+// configPath in the replicas file: its absolute path, so that scaling one
+// project's agents leaves the roles of the same name elsewhere alone
+func replicasKey(configPath string) string {
+	if abs, err := filepath.Abs(configPath); err == nil {
+		return abs
+	}
+	return filepath.Clean(configPath)
+}
+
+// loadAllReplicas reads the replica counts of every configuration file
+func loadAllReplicas(path string) (map[string]map[string]int, error) {
+	all := make(map[string]map[string]int)
+	err := statefile.ReadJSON(path, &all)
+	if os.IsNotExist(err) {
+		return map[string]map[string]int{}, nil
+	}
+	if errors.Is(err, statefile.ErrCorrupt) {
+		return map[string]map[string]int{}, fmt.Errorf("%v\n  Suggestion: Run 'asc scale' again for the roles that were scaled", err)
+	}
+	if err != nil {
+		return map[string]map[string]int{}, fmt.Errorf("failed to read replica counts: %w", err)
+	}
+	return all, nil
+}
+
+// LoadReplicas reads the replica counts asc scale recorded in path for the
+// configuration file configPath, by role. A missing file is not an error
+// and yields no counts.
+func LoadReplicas(path, configPath string) (map[string]int, error) {
+	all, err := loadAllReplicas(path)
+	if err != nil {
+		return map[string]int{}, err
+	}
+	counts := all[replicasKey(configPath)]
+	if counts == nil {
+		counts = map[string]int{}
+	}
+	return counts, nil
+}
+
+// SaveReplicas records the replica counts by role of the configuration
+// file configPath in path, keeping those of other files, and replaces it
+// atomically
+func SaveReplicas(path, configPath string, counts map[string]int) error {
+	unlock, err := statefile.Lock(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock replica counts: %w", err)
+	}
+	defer unlock()
+	all, err := loadAllReplicas(path)
+	if err != nil {
+		return err
+	}
+	if len(counts) == 0 {
+		delete(all, replicasKey(configPath))
+	} else {
+		all[replicasKey(configPath)] = counts
+	}
+	if err := statefile.WriteJSON(path, all, 0600); err != nil {
+		return fmt.Errorf("failed to write replica counts: %w", err)
+	}
+	return nil
+}
+
+// InstanceName returns the name of the replica-th instance of role
+func InstanceName(role string, replica int) string {
+	return fmt.Sprintf("%s-%d", role, replica)
+}
+
+// ExpandReplicas replaces each agent with replicas by its instances, and
+// records it in Roles. An instance is a copy of the agent with Role and
+// Replica set. counts overrides the replicas of asc.toml by role, as
+// asc scale records them. Depending on a role depends on all its
+// instances.
+func ExpandReplicas(cfg *Config, counts map[string]int) error {
+	for name, agent := range cfg.Agents {
+		if agent.Replicas == 0 {
+			continue
+		}
+		if agent.Replicas < 0 {
+			return fmt.Errorf("agent '%s': replicas must not be negative", name)
+		}
+		if cfg.Roles == nil {
+			cfg.Roles = make(map[string]AgentConfig)
+		}
+		cfg.Roles[name] = agent
+		delete(cfg.Agents, name)
+	}
+
+	for role, agent := range cfg.Roles {
+		replicas := agent.Replicas
+		if count, ok := counts[role]; ok {
+			replicas = count
+		}
+		for i := 1; i <= replicas; i++ {
+			name := InstanceName(role, i)
+			if _, ok := cfg.Agents[name]; ok {
+				return fmt.Errorf("agent '%s' has the name of an instance of agent '%s'\n  Suggestion: Rename [agent.%s]", name, role, name)
+			}
+			instance := agent
+			instance.Role = role
+			instance.Replica = i
+			cfg.Agents[name] = instance
+		}
+	}
+
+	for name, agent := range cfg.Agents {
+		var deps []string
+		for _, dep := range agent.DependsOn {
+			if _, ok := cfg.Roles[dep]; ok {
+				deps = append(deps, cfg.Instances(dep)...)
+				continue
+			}
+			deps = append(deps, dep)
+		}
+		agent.DependsOn = deps
+		cfg.Agents[name] = agent
+	}
+	return nil
+}
+
+// Instances returns the names of the instances of role, by replica
+func (c *Config) Instances(role string) []string {
+	var names []string
+	for name, agent := range c.Agents {
+		if agent.Role == role {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return c.Agents[names[i]].Replica < c.Agents[names[j]].Replica
+	})
+	return names
+}
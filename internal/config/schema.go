@@ -0,0 +1,251 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+)
+
+// SchemaID identifies the JSON Schema of asc.toml, published as
+// docs/asc.schema.json
+const SchemaID = "https://github.com/rand/asc/blob/main/docs/asc.schema.json"
+
+// mcpSectionPattern matches the further [services.mcp_*] sections
+const mcpSectionPattern = "^mcp_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// configFieldsCache holds what configFields returned for each struct type,
+// as every load looks the fields of each table up
+var configFieldsCache sync.Map
+
+// configFields returns the fields of a config struct by their keys, the
+// names of their mapstructure tags. The map is shared and must not be
+// changed.
+func configFields(t reflect.Type) map[string]reflect.StructField {
+	if fields, ok := configFieldsCache.Load(t); ok {
+		return fields.(map[string]reflect.StructField)
+	}
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	configFieldsCache.Store(t, fields)
+	return fields
+}
+
+// Schema returns the JSON Schema of asc.toml, generated from Config
+func Schema() map[string]any {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	properties := schema["properties"].(map[string]any)
+	properties[IncludeKey] = map[string]any{
+		"type":        "array",
+		"items":       map[string]any{"type": "string"},
+		"description": "Files merged into this one, as paths or glob patterns relative to it",
+	}
+	properties["profile"] = map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"$ref": "#"},
+		"description":          "Overrides of the profiles selected with --profile, by name",
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaID
+	schema["title"] = "asc.toml"
+	return schema
+}
+
+// typeSchema returns the JSON Schema of a value of type t
+func typeSchema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return map[string]any{"type": "string", "description": `Duration, such as "30s" or "5m"`}
+	case t.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		properties := make(map[string]any)
+		for name, field := range configFields(t) {
+			properties[name] = typeSchema(field.Type)
+		}
+		schema := map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+		if t == reflect.TypeOf(ServicesConfig{}) {
+			schema["patternProperties"] = map[string]any{mcpSectionPattern: typeSchema(reflect.TypeOf(MCPConfig{}))}
+		}
+		return schema
+	}
+	return map[string]any{}
+}
+
+// UnknownKey is a key of asc.toml that no setting has
+type UnknownKey struct {
+	Key        string // Dotted key, e.g. agent.coder.phasess
+	At         string // File and line that set it, e.g. asc.toml:12
+	Suggestion string // The closest known key, or ""
+}
+
+// String returns the key with where it was set and the suggestion, e.g.
+// "asc.toml:12: unknown key agent.coder.phasess (did you mean phases?)"
+func (u UnknownKey) String() string {
+	s := fmt.Sprintf("%s: unknown key %s", u.At, u.Key)
+	if u.Suggestion != "" {
+		s += fmt.Sprintf(" (did you mean %s?)", u.Suggestion)
+	}
+	return s
+}
+
+// unknownKeys returns the keys of settings, a decoded asc.toml, that no
+// setting of Config has, located in sources
+func unknownKeys(settings map[string]any, sources []string) []UnknownKey {
+	var unknown []UnknownKey
+	var check func(value any, t reflect.Type, path []string)
+	check = func(value any, t reflect.Type, path []string) {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			if table, ok := value.(map[string]any); ok {
+				for key, v := range table {
+					check(v, t.Elem(), append(path, key))
+				}
+			}
+		case reflect.Slice:
+			if items, ok := value.([]any); ok {
+				for _, item := range items {
+					check(item, t.Elem(), path)
+				}
+			}
+		case reflect.Struct:
+			table, ok := value.(map[string]any)
+			if !ok || t == durationType {
+				return
+			}
+			fields := configFields(t)
+			for key, v := range table {
+				field, known := fields[key]
+				fieldType := field.Type
+				switch {
+				case known:
+				case t == reflect.TypeOf(ServicesConfig{}) && strings.HasPrefix(key, "mcp_"):
+					fieldType, known = reflect.TypeOf(MCPConfig{}), true
+				case len(path) == 0 && key == IncludeKey:
+					continue
+				case len(path) == 0 && key == "profile":
+					if profiles, ok := v.(map[string]any); ok {
+						for name, overrides := range profiles {
+							check(overrides, t, []string{"profile", name})
+						}
+					}
+					continue
+				}
+				keyPath := append(append([]string{}, path...), key)
+				if !known {
+					names := make([]string, 0, len(fields))
+					for name := range fields {
+						names = append(names, name)
+					}
+					unknown = append(unknown, UnknownKey{
+						Key:        strings.Join(keyPath, "."),
+						At:         keyLocation(key, sources),
+						Suggestion: closestName(key, names),
+					})
+					continue
+				}
+				check(v, fieldType, keyPath)
+			}
+		}
+	}
+	check(settings, reflect.TypeOf(Config{}), nil)
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Key < unknown[j].Key })
+	return unknown
+}
+
+// keyLocation returns the file and line of sources that first sets key,
+// or the first source
+func keyLocation(key string, sources []string) string {
+	assignment := regexp.MustCompile(`^\s*(?:[A-Za-z0-9_-]+\.)*` + regexp.QuoteMeta(key) + `\s*=`)
+	header := regexp.MustCompile(`^\s*\[+[^\]]*\b` + regexp.QuoteMeta(key) + `\s*\]`)
+	for _, source := range sources {
+		line := findLine(source, func(line string) bool {
+			return assignment.MatchString(strings.ToLower(line)) || header.MatchString(strings.ToLower(line))
+		})
+		if line > 0 {
+			return fmt.Sprintf("%s:%d", source, line)
+		}
+	}
+	return sources[0]
+}
+
+// closestName returns the name closest to s, if it is close enough to be
+// a typo of it
+func closestName(s string, names []string) string {
+	best, bestDistance := "", len(s)/3+2
+	sort.Strings(names)
+	for _, name := range names {
+		if d := editDistance(s, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// unknownKeysError returns the error of strict validation for unknown keys
+func unknownKeysError(unknown []UnknownKey) error {
+	lines := make([]string, len(unknown))
+	for i, u := range unknown {
+		lines[i] = u.String()
+	}
+	return ascerrors.WithHint(
+		fmt.Errorf("config validation failed:\n  %s", strings.Join(lines, "\n  ")),
+		"Fix or remove the keys; set core.strict = false to only warn about them")
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	toml "github.com/pelletier/go-toml/v2"
+)
+
+const typoConfig = `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+
+[services.mcp_search]
+url = "http://localhost:8766"
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phasess = ["implementation"]
+phases = ["testing"]
+
+[profile.prod.agent.coder]
+modle = "gemini"
+`
+
+func TestUnknownKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	if err := os.WriteFile(configPath, []byte(typoConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []UnknownKey{
+		{Key: "agent.coder.phasess", At: configPath + ":13", Suggestion: "phases"},
+		{Key: "profile.prod.agent.coder.modle", At: configPath + ":17", Suggestion: "model"},
+	}
+	if len(cfg.UnknownKeys) != len(want) {
+		t.Fatalf("UnknownKeys = %v, want %v", cfg.UnknownKeys, want)
+	}
+	for i := range want {
+		if cfg.UnknownKeys[i] != want[i] {
+			t.Errorf("UnknownKeys[%d] = %+v, want %+v", i, cfg.UnknownKeys[i], want[i])
+		}
+	}
+	warnings, err := ValidateWithWarnings(cfg)
+	if err != nil {
+		t.Fatalf("ValidateWithWarnings() error = %v", err)
+	}
+	found := false
+	for _, w := range warnings {
+		if strings.Contains(w.Message, "unknown key agent.coder.phasess") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a warning for agent.coder.phasess, got %v", warnings)
+	}
+
+	_, err = LoadStrict(context.Background(), configPath)
+	if err == nil || !strings.Contains(err.Error(), "agent.coder.phasess (did you mean phases?)") {
+		t.Errorf("Expected LoadStrict() to reject the typo, got %v", err)
+	}
+
+	strict := "[core]\nstrict = true\n" + strings.TrimPrefix(typoConfig, "[core]\n")
+	if err := os.WriteFile(configPath, []byte(strict), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(configPath); err == nil {
+		t.Error("Expected core.strict to reject unknown keys")
+	}
+}
+
+func TestTemplatesHaveNoUnknownKeys(t *testing.T) {
+	for _, templateType := range []TemplateType{TemplateSolo, TemplateTeam, TemplateSwarm} {
+		tmpl, err := GetTemplate(templateType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var settings map[string]any
+		if err := toml.Unmarshal([]byte(tmpl.Content), &settings); err != nil {
+			t.Fatalf("%s: %v", templateType, err)
+		}
+		if unknown := unknownKeys(settings, []string{"asc.toml"}); len(unknown) > 0 {
+			t.Errorf("%s template has unknown keys: %v", templateType, unknown)
+		}
+	}
+}
+
+func TestSchemaIsPublished(t *testing.T) {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, err := os.ReadFile(filepath.Join("..", "..", "docs", "asc.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(published) != string(data)+"\n" {
+		t.Error("docs/asc.schema.json is out of date; run 'make schema'")
+	}
+
+	properties := Schema()["properties"].(map[string]any)
+	for _, key := range []string{"core", "services", "agent", IncludeKey, "profile"} {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("Expected the schema to have %s", key)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package config
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+	"github.com/rand/asc/internal/proxy"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// ConfigEnvVar names the configuration file, or the URL of a shared one,
+// asc reads instead of ./asc.toml. --config sets it.
+const ConfigEnvVar = "ASC_CONFIG"
+
+// ConfigKeyEnvVar is the public key a configuration named by URL in
+// ConfigEnvVar must be signed with, as config_source.public_key is for
+// config_source.url
+const ConfigKeyEnvVar = "ASC_CONFIG_PUBLIC_KEY"
+
+// SourceCacheDirName is the subdirectory of the state directory shared
+// configurations are cached in
+const SourceCacheDirName = "config-cache"
+
+// defaultSourceRefresh is how long a fetched shared configuration is used
+// before it is fetched again
+const defaultSourceRefresh = 5 * time.Minute
+
+// sourceFetchTimeout bounds fetching a shared configuration, including
+// cloning its git repository
+const sourceFetchTimeout = time.Minute
+
+// SourceConfig names a shared configuration asc.toml extends, kept by a
+// team in one place: asc.toml's own keys, and the files it includes,
+// override those of the shared file
+type SourceConfig struct {
+	// URL is https://host/asc.toml, or a file in a git repository:
+	// git+https://host/org/repo.git?ref=main#path/asc.toml (the path
+	// defaults to asc.toml, and the ref to the default branch)
+	URL string `mapstructure:"url"`
+
+	// PublicKey is the base64 ed25519 key the file must be signed with.
+	// The signature, base64, is fetched from <url>.sig, or for git from
+	// <path>.sig in the repository.
+	PublicKey string `mapstructure:"public_key"`
+
+	// Refresh is how long a fetched copy is used before it is fetched
+	// again (default 5m)
+	Refresh time.Duration `mapstructure:"refresh"`
+}
+
+// RemoteSource describes the shared configuration a Config was read from
+type RemoteSource struct {
+	URL       string    // Where it is fetched from
+	Path      string    // The cached copy that was read
+	FetchedAt time.Time // When the cached copy was fetched
+	Signed    bool      // Whether its signature was verified
+
+	// Stale is why the copy could not be refreshed, if it could not; the
+	// copy fetched last is used until it can be
+	Stale error
+}
+
+// sourceMeta is the record of a cached shared configuration
+type sourceMeta struct {
+	URL       string    `json:"url"`
+	File      string    `json:"file"` // The cached copy, relative to the cache directory
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// IsRemoteSource reports whether s is the URL of a shared configuration
+// rather than a file path
+func IsRemoteSource(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "git+")
+}
+
+// IsCachedSource reports whether path is in the cache of shared
+// configurations, so edits to it would be lost at the next fetch
+func IsCachedSource(path string) bool {
+	cacheDir, err := statedir.Path(SourceCacheDirName)
+	if err != nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(cacheDir, abs)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// FetchSource returns the cached copy of a shared configuration, fetching
+// it first if the copy is older than src.Refresh. If it cannot be fetched,
+// the copy fetched last is used, with Stale set, as long as its signature
+// still verifies.
+func FetchSource(ctx context.Context, src SourceConfig) (*RemoteSource, error) {
+	if src.URL == "" {
+		return nil, fmt.Errorf("config_source.url is required")
+	}
+	if strings.HasPrefix(src.URL, "http://") && src.PublicKey == "" {
+		return nil, ascerrors.WithHint(
+			fmt.Errorf("config_source %s is plain HTTP and not signed", src.URL),
+			"Serve it over HTTPS, or sign it and set config_source.public_key")
+	}
+	refresh := src.Refresh
+	if refresh <= 0 {
+		refresh = defaultSourceRefresh
+	}
+	sum := sha256.Sum256([]byte(src.URL))
+	dir, err := statedir.Path(SourceCacheDirName, hex.EncodeToString(sum[:8]))
+	if err != nil {
+		return nil, err
+	}
+	metaPath := filepath.Join(dir, "source.json")
+
+	var meta sourceMeta
+	cached := statefile.ReadJSON(metaPath, &meta) == nil && meta.URL == src.URL && meta.File != ""
+	if cached {
+		if _, err := os.Stat(filepath.Join(dir, meta.File)); err != nil {
+			cached = false
+		}
+	}
+
+	var fetchErr error
+	if !cached || time.Since(meta.FetchedAt) >= refresh {
+		ctx, cancel := context.WithTimeout(ctx, sourceFetchTimeout)
+		defer cancel()
+		var file string
+		if file, fetchErr = fetchSource(ctx, src, dir); fetchErr == nil {
+			meta = sourceMeta{URL: src.URL, File: file, FetchedAt: time.Now()}
+			if err := statefile.WriteJSON(metaPath, meta, 0600); err != nil {
+				return nil, err
+			}
+		} else if !cached {
+			return nil, ascerrors.WithHint(
+				fmt.Errorf("failed to fetch config_source %s: %w", src.URL, fetchErr),
+				"Check the URL and your network; once fetched, a copy is kept for when the source cannot be reached")
+		}
+	}
+
+	remote := &RemoteSource{URL: src.URL, Path: filepath.Join(dir, meta.File), FetchedAt: meta.FetchedAt, Stale: fetchErr}
+	if src.PublicKey != "" {
+		if err := verifySource(src.PublicKey, remote.Path); err != nil {
+			return nil, fmt.Errorf("config_source %s: %w", src.URL, err)
+		}
+		remote.Signed = true
+	}
+	return remote, nil
+}
+
+// fetchSource fetches a shared configuration, and its signature if it must
+// be signed, into dir, and returns the file to read, relative to dir
+func fetchSource(ctx context.Context, src SourceConfig, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(src.URL, "git+") {
+		return fetchGitSource(ctx, src, dir)
+	}
+
+	u, err := url.Parse(src.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	file := path.Base(u.Path)
+	if file == "." || file == "/" || !strings.HasSuffix(file, ".toml") {
+		file = "asc.toml"
+	}
+	data, err := httpGet(ctx, src.URL)
+	if err != nil {
+		return "", err
+	}
+	var signature []byte
+	if src.PublicKey != "" {
+		sigURL := *u
+		sigURL.Path += ".sig"
+		if signature, err = httpGet(ctx, sigURL.String()); err != nil {
+			return "", fmt.Errorf("failed to fetch signature: %w", err)
+		}
+		if err := verifySignature(src.PublicKey, data, signature); err != nil {
+			return "", err
+		}
+	}
+
+	// The signature is kept so the copy can be verified again when it is
+	// read, and written before the file it signs
+	if signature != nil {
+		if err := writeCached(filepath.Join(dir, file+".sig"), signature); err != nil {
+			return "", err
+		}
+	}
+	if err := writeCached(filepath.Join(dir, file), data); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// fetchGitSource clones, or updates, the repository of a git+ URL in
+// dir/repo, and returns the file to read, relative to dir
+func fetchGitSource(ctx context.Context, src SourceConfig, dir string) (string, error) {
+	u, err := url.Parse(strings.TrimPrefix(src.URL, "git+"))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	ref := u.Query().Get("ref")
+	file := u.Fragment
+	if file == "" {
+		file = "asc.toml"
+	}
+	if !filepath.IsLocal(file) {
+		return "", fmt.Errorf("path %s is outside the repository", file)
+	}
+	u.RawQuery, u.Fragment = "", ""
+
+	repo := filepath.Join(dir, "repo")
+	target := ref
+	if target == "" {
+		target = "HEAD"
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".git")); err != nil {
+		os.RemoveAll(repo)
+		if err := runGit(ctx, dir, "init", "--quiet", "repo"); err != nil {
+			return "", err
+		}
+		if err := runGit(ctx, repo, "remote", "add", "origin", u.String()); err != nil {
+			return "", err
+		}
+	}
+	if err := runGit(ctx, repo, "fetch", "--quiet", "--depth", "1", "origin", target); err != nil {
+		return "", err
+	}
+	if err := runGit(ctx, repo, "checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+
+	rel := filepath.Join("repo", file)
+	if _, err := os.Stat(filepath.Join(dir, rel)); err != nil {
+		return "", fmt.Errorf("%s is not in the repository", file)
+	}
+	return rel, nil
+}
+
+// runGit runs git in dir
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// httpGet returns the body of a successful GET of rawURL, through the
+// proxy of the environment
+func httpGet(ctx context.Context, rawURL string) ([]byte, error) {
+	settings, err := proxy.ForService("")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: settings.Transport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
+
+// writeCached atomically replaces a file of the cache
+func writeCached(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// verifySource checks the file at path against its signature in
+// <path>.sig
+func verifySource(publicKey, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	signature, err := os.ReadFile(path + ".sig")
+	if err != nil {
+		return fmt.Errorf("not signed: %w", err)
+	}
+	return verifySignature(publicKey, data, signature)
+}
+
+// verifySignature checks signature, base64, is the ed25519 signature of
+// data by publicKey, base64
+func verifySignature(publicKey string, data, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key: must be a base64 ed25519 public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(key, data, sig) {
+		return ascerrors.WithHint(
+			fmt.Errorf("signature does not match the public key"),
+			"The file was changed after it was signed, or signed with another key; sign it again, or fix the public key")
+	}
+	return nil
+}
+
+// applySource merges the shared configuration the config_source of v
+// names under v, which was read from path, fetching it with ctx if it is
+// due, and appends it to sources
+func applySource(ctx context.Context, v *viper.Viper, path string, sources *[]string) (*viper.Viper, *RemoteSource, error) {
+	if !v.IsSet("config_source") {
+		return v, nil, nil
+	}
+	var src SourceConfig
+	if err := v.UnmarshalKey("config_source", &src); err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to parse config_source: %w", path, err)
+	}
+	remote, err := FetchSource(ctx, src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	abs, _ := filepath.Abs(path)
+	read := len(*sources)
+	settings, err := loadIncluded(remote.Path, map[string]bool{abs: true}, sources)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w (config_source of %s)", err, path)
+	}
+	if remote.Signed {
+		// The signature of the shared file covers none of the files it
+		// includes, so each must be signed with the same key
+		for _, included := range (*sources)[read+1:] {
+			if err := verifySource(src.PublicKey, included); err != nil {
+				return nil, nil, ascerrors.WithHint(
+					fmt.Errorf("config_source %s: included file %s: %w", src.URL, included, err),
+					"Sign every file the shared file includes, as <file>.sig beside it, with the key of config_source.public_key")
+			}
+		}
+	}
+	if _, ok := settings["config_source"]; ok {
+		return nil, nil, fmt.Errorf("%s: config_source may only be set in the local file", src.URL)
+	}
+	merged := viper.New()
+	merged.SetConfigType("toml")
+	if err := merged.MergeConfigMap(settings); err != nil {
+		return nil, nil, err
+	}
+	if err := merged.MergeConfigMap(v.AllSettings()); err != nil {
+		return nil, nil, err
+	}
+	return merged, remote, nil
+}
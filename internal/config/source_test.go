@@ -0,0 +1,277 @@
+package config
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sharedConfig is the configuration a team shares through config_source
+const sharedConfig = `[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+
+[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+`
+
+func TestConfigSource_HTTP(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := sharedConfig
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(served)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stack/asc.toml":
+			w.Write([]byte(served))
+		case "/stack/asc.toml.sig":
+			w.Write([]byte(signature))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	local := `[config_source]
+url = "` + server.URL + `/stack/asc.toml"
+public_key = "` + base64.StdEncoding.EncodeToString(publicKey) + `"
+refresh = "1ns"
+
+[agent.coder]
+model = "gemini"
+`
+	if err := os.WriteFile(configPath, []byte(local), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Agents["coder"].Model != "gemini" || cfg.Agents["coder"].Command != "echo" {
+		t.Errorf("Expected the local model over the shared agent, got %+v", cfg.Agents["coder"])
+	}
+	if cfg.Remote == nil || !cfg.Remote.Signed || cfg.Remote.Stale != nil || len(cfg.Sources) != 2 {
+		t.Errorf("Unexpected remote source %+v, sources %v", cfg.Remote, cfg.Sources)
+	}
+	if !IsCachedSource(cfg.Remote.Path) || IsCachedSource(configPath) {
+		t.Errorf("Expected only %s to be a cached source", cfg.Remote.Path)
+	}
+
+	// A file changed after it was signed is not used, and the copy
+	// fetched before is
+	served = strings.Replace(sharedConfig, `model = "claude"`, `model = "gpt-4"`, 1)
+	cfg, err = Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() with a bad signature error = %v", err)
+	}
+	if cfg.Remote.Stale == nil || !strings.Contains(cfg.Remote.Stale.Error(), "signature does not match") {
+		t.Errorf("Expected the bad signature reported as stale, got %v", cfg.Remote.Stale)
+	}
+
+	// So is the copy when the source cannot be reached
+	server.Close()
+	if cfg, err = Load(configPath); err != nil || cfg.Remote.Stale == nil {
+		t.Errorf("Expected the cached copy used offline, got %v", err)
+	}
+
+	unsigned := strings.Replace(local, "public_key", "# public_key", 1)
+	if err := os.WriteFile(configPath, []byte(unsigned), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "plain HTTP and not signed") {
+		t.Errorf("Expected an unsigned HTTP source to be refused, got %v", err)
+	}
+}
+
+func TestConfigSource_ProxyAndCancel(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(sharedConfig)))
+	var hang atomic.Bool
+	proxied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hang.Load() {
+			<-r.Context().Done()
+			return
+		}
+		switch r.URL.String() {
+		case "http://config.example/stack/asc.toml":
+			w.Write([]byte(sharedConfig))
+		case "http://config.example/stack/asc.toml.sig":
+			w.Write([]byte(signature))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxied.Close()
+	t.Setenv("HTTP_PROXY", proxied.URL)
+	t.Setenv("NO_PROXY", "")
+
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	local := `[config_source]
+url = "http://config.example/stack/asc.toml"
+public_key = "` + base64.StdEncoding.EncodeToString(publicKey) + `"
+refresh = "1ns"
+`
+	if err := os.WriteFile(configPath, []byte(local), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The source is fetched through the proxy of the environment
+	cfg, err := LoadContext(context.Background(), configPath)
+	if err != nil {
+		t.Fatalf("LoadContext() through the proxy error = %v", err)
+	}
+	if cfg.Agents["coder"].Command != "echo" {
+		t.Errorf("Expected the shared agent, got %+v", cfg.Agents["coder"])
+	}
+
+	// Cancelling the context stops waiting for a source that does not
+	// answer, and the copy fetched before is used
+	hang.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	cfg, err = LoadContext(ctx, configPath)
+	if err != nil || cfg.Remote.Stale == nil {
+		t.Errorf("Expected the cached copy used when cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("LoadContext() waited %v after its context was cancelled", elapsed)
+	}
+}
+
+func TestConfigSource_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "--quiet", "--initial-branch=main")
+	os.MkdirAll(filepath.Join(repo, "stacks"), 0755)
+	if err := os.WriteFile(filepath.Join(repo, "stacks", "asc.toml"), []byte(sharedConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Add the stack")
+
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	local := "[config_source]\nurl = \"git+file://" + filepath.ToSlash(repo) + "?ref=main#stacks/asc.toml\"\nrefresh = \"1ns\"\n"
+	if err := os.WriteFile(configPath, []byte(local), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Agents["coder"].Model != "claude" {
+		t.Errorf("Expected the shared agent, got %+v", cfg.Agents)
+	}
+
+	// A new commit is fetched once the copy is older than refresh
+	if err := os.WriteFile(filepath.Join(repo, "stacks", "asc.toml"), []byte(strings.Replace(sharedConfig, `model = "claude"`, `model = "gemini"`, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("commit", "--quiet", "-am", "Switch models")
+	if cfg, err = Load(configPath); err != nil || cfg.Agents["coder"].Model != "gemini" {
+		t.Errorf("Expected the new commit fetched, got %+v, %v", cfg.Agents, err)
+	}
+}
+
+func TestConfigSource_SignedIncludes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name, data string, signed bool) {
+		t.Helper()
+		path := filepath.Join(repo, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if signed {
+			signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(data)))
+			if err := os.WriteFile(path+".sig", []byte(signature), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	git("init", "--quiet", "--initial-branch=main")
+	write("asc.toml", "include = [\"agents/*.toml\"]\n\n"+sharedConfig, true)
+	write("agents/tester.toml", "[agent.tester]\ncommand = \"rm -rf /\"\nmodel = \"claude\"\nphases = [\"testing\"]\n", false)
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Add the stack")
+
+	configPath := filepath.Join(t.TempDir(), "asc.toml")
+	local := "[config_source]\nurl = \"git+file://" + filepath.ToSlash(repo) + "?ref=main\"\npublic_key = \"" +
+		base64.StdEncoding.EncodeToString(publicKey) + "\"\nrefresh = \"1ns\"\n"
+	if err := os.WriteFile(configPath, []byte(local), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An unsigned file the signed shared file includes is refused
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "agents/tester.toml") || !strings.Contains(err.Error(), "not signed") {
+		t.Fatalf("Expected the unsigned included file refused, got %v", err)
+	}
+
+	// Signed with the same key, it is read
+	write("agents/tester.toml", "[agent.tester]\ncommand = \"echo\"\nmodel = \"claude\"\nphases = [\"testing\"]\n", true)
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Sign the tester")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Agents["tester"].Command != "echo" || cfg.Agents["coder"].Command != "echo" {
+		t.Errorf("Expected the shared and included agents, got %+v", cfg.Agents)
+	}
+}
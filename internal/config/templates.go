@@ -4,6 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	toml "github.com/pelletier/go-toml/v2"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+	"github.com/rand/asc/internal/statedir"
 )
 
 // Template represents a configuration template
@@ -11,8 +19,23 @@ type Template struct {
 	Name        string
 	Description string
 	Content     string
+	Vars        []TemplateVar // Variables asc init asks for, in order
+}
+
+// TemplateVar is a variable a custom template declares in its header
+type TemplateVar struct {
+	Name    string `toml:"name"`
+	Prompt  string `toml:"prompt"`  // Question asc init asks; defaults to the name
+	Default string `toml:"default"` // Value when none is given; without one, a value is required
 }
 
+// templateHeaderPrefix starts the lines of the header of a custom template
+const templateHeaderPrefix = "#!"
+
+// templateVarName matches the names of template variables, which
+// templates refer to as {{.name}}
+var templateVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // TemplateType represents the type of template
 type TemplateType string
 
@@ -41,7 +64,9 @@ func getSoloTemplate() *Template {
 	return &Template{
 		Name:        "solo",
 		Description: "Single agent setup for individual development",
-		Content: `[core]
+		Content: `config_version = 1
+
+[core]
 beads_db_path = "./project-repo"
 
 [services.mcp_agent_mail]
@@ -61,7 +86,9 @@ func getTeamTemplate() *Template {
 	return &Template{
 		Name:        "team",
 		Description: "Team setup with planner, coder, and tester agents",
-		Content: `[core]
+		Content: `config_version = 1
+
+[core]
 beads_db_path = "./project-repo"
 
 [services.mcp_agent_mail]
@@ -91,7 +118,9 @@ func getSwarmTemplate() *Template {
 	return &Template{
 		Name:        "swarm",
 		Description: "Swarm setup with multiple agents per phase for parallel work",
-		Content: `[core]
+		Content: `config_version = 1
+
+[core]
 beads_db_path = "./project-repo"
 
 [services.mcp_agent_mail]
@@ -173,12 +202,7 @@ func LoadCustomTemplate(path string) (*Template, error) {
 		return nil, fmt.Errorf("failed to read template: %w", err)
 	}
 
-	name := filepath.Base(path)
-	return &Template{
-		Name:        name,
-		Description: "Custom template",
-		Content:     string(content),
-	}, nil
+	return ParseTemplate(filepath.Base(path), string(content))
 }
 
 // SaveCustomTemplate saves the current configuration as a custom template
@@ -190,12 +214,10 @@ func SaveCustomTemplate(configPath, templateName string) error {
 	}
 
 	// Get templates directory
-	home, err := os.UserHomeDir()
+	templatesDir, err := statedir.Path("templates")
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
-
-	templatesDir := filepath.Join(home, ".asc", "templates")
 	if err := os.MkdirAll(templatesDir, 0755); err != nil {
 		return fmt.Errorf("failed to create templates directory: %w", err)
 	}
@@ -211,12 +233,10 @@ func SaveCustomTemplate(configPath, templateName string) error {
 
 // ListCustomTemplates returns all custom templates from ~/.asc/templates
 func ListCustomTemplates() ([]Template, error) {
-	home, err := os.UserHomeDir()
+	templatesDir, err := statedir.Path("templates")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, err
 	}
-
-	templatesDir := filepath.Join(home, ".asc", "templates")
 	
 	// Check if directory exists
 	if _, err := os.Stat(templatesDir); os.IsNotExist(err) {
@@ -242,11 +262,11 @@ func ListCustomTemplates() ([]Template, error) {
 		}
 
 		name := entry.Name()[:len(entry.Name())-5] // Remove .toml extension
-		templates = append(templates, Template{
-			Name:        name,
-			Description: "Custom template",
-			Content:     string(content),
-		})
+		tmpl, err := ParseTemplate(name, string(content))
+		if err != nil {
+			tmpl = &Template{Name: name, Description: fmt.Sprintf("Invalid template: %v", err), Content: string(content)}
+		}
+		templates = append(templates, *tmpl)
 	}
 
 	return templates, nil
@@ -254,22 +274,124 @@ func ListCustomTemplates() ([]Template, error) {
 
 // LoadCustomTemplateByName loads a custom template by name from ~/.asc/templates
 func LoadCustomTemplateByName(name string) (*Template, error) {
-	home, err := os.UserHomeDir()
+	templatesDir, err := statedir.Path("templates")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, err
 	}
-
-	templatesDir := filepath.Join(home, ".asc", "templates")
 	templatePath := filepath.Join(templatesDir, name+".toml")
 	
 	content, err := os.ReadFile(templatePath)
+	if os.IsNotExist(err) {
+		return nil, ascerrors.WithHint(fmt.Errorf("no template named %s", name),
+			fmt.Sprintf("Run 'asc init --list-templates' to see the templates; custom ones are read from %s", templatePath))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read template: %w", err)
 	}
 
-	return &Template{
-		Name:        name,
-		Description: "Custom template",
-		Content:     string(content),
-	}, nil
+	return ParseTemplate(name, string(content))
+}
+
+// FindTemplate returns the built-in template of a name, or else the custom
+// one in ~/.asc/templates
+func FindTemplate(name string) (*Template, error) {
+	switch TemplateType(name) {
+	case TemplateSolo, TemplateTeam, TemplateSwarm:
+		return GetTemplate(TemplateType(name))
+	}
+	return LoadCustomTemplateByName(name)
+}
+
+// ParseTemplate returns the custom template name with content, reading its
+// header: the lines starting with #! at the top of the file, which are
+// TOML setting its description and declaring its variables.
+//
+//	#! description = "Acme standard stack"
+//	#! [[var]]
+//	#! name = "team"
+//	#! prompt = "Team name"
+//	#! default = "platform"
+//	[agent.{{.team}}-coder]
+func ParseTemplate(name, content string) (*Template, error) {
+	header, _ := splitTemplateHeader(content)
+	var meta struct {
+		Description string        `toml:"description"`
+		Vars        []TemplateVar `toml:"var"`
+	}
+	decoder := toml.NewDecoder(strings.NewReader(header))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&meta); err != nil {
+		return nil, fmt.Errorf("invalid header of template %s: %w", name, err)
+	}
+	seen := make(map[string]bool)
+	for i, v := range meta.Vars {
+		if !templateVarName.MatchString(v.Name) {
+			return nil, fmt.Errorf("invalid header of template %s: variable name %q must be letters, digits, and underscores", name, v.Name)
+		}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("invalid header of template %s: variable %s is declared twice", name, v.Name)
+		}
+		seen[v.Name] = true
+		if v.Prompt == "" {
+			meta.Vars[i].Prompt = v.Name
+		}
+	}
+	if meta.Description == "" {
+		meta.Description = "Custom template"
+	}
+	return &Template{Name: name, Description: meta.Description, Content: content, Vars: meta.Vars}, nil
+}
+
+// Render returns the configuration the template generates: its content,
+// less the header, with {{.name}} replaced by the values of its variables,
+// or their defaults. {{toml .name}} writes a value as a quoted TOML
+// string.
+func (t *Template) Render(values map[string]string) (string, error) {
+	data := make(map[string]string)
+	declared := make(map[string]bool)
+	for _, v := range t.Vars {
+		declared[v.Name] = true
+		value := values[v.Name]
+		if value == "" {
+			value = v.Default
+		}
+		if value == "" {
+			return "", ascerrors.WithHint(fmt.Errorf("template %s needs a value for %s (%s)", t.Name, v.Name, v.Prompt),
+				fmt.Sprintf("Pass --var %s=<value> to asc init", v.Name))
+		}
+		data[v.Name] = value
+	}
+	for name := range values {
+		if !declared[name] {
+			return "", fmt.Errorf("template %s has no variable %s", t.Name, name)
+		}
+	}
+
+	_, body := splitTemplateHeader(t.Content)
+	tmpl, err := template.New(t.Name).Option("missingkey=error").Funcs(template.FuncMap{"toml": quoteString}).Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %s: %w", t.Name, err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", t.Name, err)
+	}
+	var check map[string]any
+	if err := toml.Unmarshal([]byte(out.String()), &check); err != nil {
+		return "", fmt.Errorf("template %s does not render valid TOML: %w", t.Name, err)
+	}
+	return out.String(), nil
+}
+
+// splitTemplateHeader returns the header of a template, without its #!
+// prefixes, and the rest of it
+func splitTemplateHeader(content string) (header, body string) {
+	var lines []string
+	rest := content
+	for strings.HasPrefix(rest, templateHeaderPrefix) {
+		line, after, _ := strings.Cut(rest, "\n")
+		lines = append(lines, strings.TrimPrefix(strings.TrimPrefix(line, templateHeaderPrefix), " "))
+		rest = after
+	}
+	return strings.Join(lines, "\n"), rest
 }
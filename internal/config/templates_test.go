@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/rand/asc/internal/statedir"
 )
 
 func TestGetTemplate(t *testing.T) {
@@ -459,3 +462,79 @@ func TestListCustomTemplates_WithNonTomlFiles(t *testing.T) {
 		}
 	}
 }
+
+// orgTemplate is a custom template declaring variables in its header
+const orgTemplate = `#! description = "Acme standard stack"
+#! [[var]]
+#! name = "team"
+#! prompt = "Team name"
+#! [[var]]
+#! name = "model"
+#! default = "claude"
+[core]
+beads_db_path = "./{{.team}}-repo"
+
+[agent.{{.team}}-coder]
+command = "echo"
+model = {{toml .model}}
+phases = ["implementation"]
+`
+
+func TestParseAndRenderTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("acme", orgTemplate)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if tmpl.Description != "Acme standard stack" || len(tmpl.Vars) != 2 {
+		t.Fatalf("ParseTemplate() = %+v", tmpl)
+	}
+	if tmpl.Vars[0] != (TemplateVar{Name: "team", Prompt: "Team name"}) || tmpl.Vars[1] != (TemplateVar{Name: "model", Prompt: "model", Default: "claude"}) {
+		t.Errorf("Unexpected variables %+v", tmpl.Vars)
+	}
+
+	content, err := tmpl.Render(map[string]string{"team": "web"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(content, "#!") || !strings.Contains(content, "[agent.web-coder]") || !strings.Contains(content, `model = "claude"`) {
+		t.Errorf("Unexpected rendered template:\n%s", content)
+	}
+
+	if _, err := tmpl.Render(nil); err == nil || !strings.Contains(err.Error(), "needs a value for team") {
+		t.Errorf("Expected a required variable to be asked for, got %v", err)
+	}
+	if _, err := tmpl.Render(map[string]string{"team": "web", "region": "eu"}); err == nil {
+		t.Error("Expected an undeclared variable to be refused")
+	}
+	if _, err := tmpl.Render(map[string]string{"team": "web]\n["}); err == nil {
+		t.Error("Expected a value breaking the TOML to be refused")
+	}
+
+	for _, header := range []string{"#! colour = \"blue\"\n", "#! [[var]]\n#! name = \"my-var\"\n"} {
+		if _, err := ParseTemplate("bad", header+"[core]\n"); err == nil {
+			t.Errorf("Expected header %q to be refused", header)
+		}
+	}
+}
+
+func TestFindTemplate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	templatesDir, err := statedir.Path("templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(templatesDir, 0755)
+	if err := os.WriteFile(filepath.Join(templatesDir, "acme.toml"), []byte(orgTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if tmpl, err := FindTemplate("team"); err != nil || tmpl.Name != "team" {
+		t.Errorf("FindTemplate(team) = %v, %v", tmpl, err)
+	}
+	if tmpl, err := FindTemplate("acme"); err != nil || len(tmpl.Vars) != 2 {
+		t.Errorf("FindTemplate(acme) = %v, %v", tmpl, err)
+	}
+	if _, err := FindTemplate("missing"); err == nil || !strings.Contains(err.Error(), "no template named missing") {
+		t.Errorf("Expected an unknown template to fail, got %v", err)
+	}
+}
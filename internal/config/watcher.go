@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -17,6 +19,15 @@ type Watcher struct {
 	stopCh     chan struct{}
 	eventCh    chan *Config // Channel for sending reload events
 	running    bool
+
+	// files are the files whose changes reload the configuration: those
+	// it was read from, and the reload request file. Their directories
+	// are watched, as editors and asc config set replace files by
+	// renaming new ones over them.
+	files map[string]bool
+
+	// errCh receives the errors of loading a changed configuration
+	errCh chan error
 }
 
 // ReloadCallback is called when the configuration file changes
@@ -37,6 +48,8 @@ func NewWatcher(configPath string) (*Watcher, error) {
 		stopCh:     make(chan struct{}),
 		eventCh:    make(chan *Config, 10), // Buffered channel for reload events
 		running:    false,
+		files:      make(map[string]bool),
+		errCh:      make(chan error, 10),
 	}
 
 	return w, nil
@@ -47,6 +60,22 @@ func (w *Watcher) Events() <-chan *Config {
 	return w.eventCh
 }
 
+// Errors returns the channel for receiving the errors of loading a
+// changed configuration, which is not reloaded
+func (w *Watcher) Errors() <-chan error {
+	return w.errCh
+}
+
+// WatchRequests also reloads the configuration when path, such as
+// ReloadRequestPath, is written by RequestReload. Call it before Start.
+func (w *Watcher) WatchRequests(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if abs, err := filepath.Abs(path); err == nil {
+		w.files[abs] = true
+	}
+}
+
 // OnReload registers a callback to be called when the configuration changes
 func (w *Watcher) OnReload(callback ReloadCallback) {
 	w.mu.Lock()
@@ -64,8 +93,16 @@ func (w *Watcher) Start() error {
 	w.running = true
 	w.mu.Unlock()
 
-	// Add the config file to the watcher
-	if err := w.watcher.Add(w.configPath); err != nil {
+	// Watch the config file, and the files it includes
+	sources := []string{w.configPath}
+	if cfg, err := Load(w.configPath); err == nil {
+		sources = cfg.Sources
+	}
+	err := w.watchFiles(sources)
+	if _, statErr := os.Stat(w.configPath); statErr != nil {
+		err = statErr
+	}
+	if err != nil {
 		w.mu.Lock()
 		w.running = false
 		w.mu.Unlock()
@@ -107,6 +144,9 @@ func (w *Watcher) watchLoop() {
 
 			// We're interested in Write and Create events
 			// Some editors create a new file and rename it, so we watch for both
+			if !w.watches(event.Name) {
+				continue
+			}
 			if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
 				// Debounce: reset timer on each event
 				if debounceTimer != nil {
@@ -139,11 +179,22 @@ func (w *Watcher) handleConfigChange() {
 	// Load the new configuration
 	newConfig, err := Load(w.configPath)
 	if err != nil {
-		// Log error but don't stop watching
-		fmt.Printf("failed to reload config: %v\n", err)
+		// Report the error but don't stop watching
+		select {
+		case w.errCh <- err:
+		default:
+		}
 		return
 	}
 
+	// Files newly included are watched from now on
+	if err := w.watchFiles(newConfig.Sources); err != nil {
+		select {
+		case w.errCh <- err:
+		default:
+		}
+	}
+
 	// Send event to channel (non-blocking)
 	select {
 	case w.eventCh <- newConfig:
@@ -164,3 +215,40 @@ func (w *Watcher) handleConfigChange() {
 		}
 	}
 }
+
+// watchFiles adds paths to the files whose changes reload the
+// configuration, and watches their directories
+func (w *Watcher) watchFiles(paths []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dirs := make(map[string]bool)
+	for path := range w.files {
+		dirs[filepath.Dir(path)] = true
+	}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		w.files[abs] = true
+		dirs[filepath.Dir(abs)] = true
+	}
+	for dir := range dirs {
+		if err := w.watcher.Add(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watches reports whether a change of the file at path reloads the
+// configuration
+func (w *Watcher) watches(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.files[abs]
+}
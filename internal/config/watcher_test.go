@@ -413,3 +413,81 @@ phases = ["planning"]
 	// Stop again - should not panic
 	watcher.Stop()
 }
+
+func TestWatcher_IncludesAndRequests(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "asc.toml")
+	writeConfigFiles(t, dir, map[string]string{
+		"asc.toml": `include = ["agents/*.toml"]
+
+[core]
+beads_db_path = "./test-repo"
+
+[services.mcp_agent_mail]
+url = "http://localhost:8765"
+`,
+		"agents/coder.toml": `[agent.coder]
+command = "echo"
+model = "claude"
+phases = ["implementation"]
+`,
+	})
+	requestPath := filepath.Join(t.TempDir(), "reload.request")
+
+	watcher, err := NewWatcher(configPath)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	defer watcher.Stop()
+	watcher.WatchRequests(requestPath)
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("Failed to start watcher: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	wait := func(what string) *Config {
+		t.Helper()
+		select {
+		case cfg := <-watcher.Events():
+			return cfg
+		case err := <-watcher.Errors():
+			t.Fatalf("%s: reload failed: %v", what, err)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: timeout waiting for config reload event", what)
+		}
+		return nil
+	}
+
+	// An included file replaced by renaming a new one over it
+	tmp := filepath.Join(dir, "agents", ".coder.toml.swp")
+	edited := "[agent.coder]\ncommand = \"echo\"\nmodel = \"gemini\"\nphases = [\"implementation\"]\n"
+	if err := os.WriteFile(tmp, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "agents", "coder.toml")); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := wait("include"); cfg.Agents["coder"].Model != "gemini" {
+		t.Errorf("Expected the included change, got %+v", cfg.Agents["coder"])
+	}
+
+	if err := RequestReload(requestPath); err != nil {
+		t.Fatal(err)
+	}
+	wait("request")
+
+	// A file that fails to load is reported, and not reloaded
+	if err := os.WriteFile(configPath, []byte("[core\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-watcher.Errors():
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	case cfg := <-watcher.Events():
+		t.Errorf("Expected an invalid file not to reload, got %+v", cfg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for the reload error")
+	}
+}
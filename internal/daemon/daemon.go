@@ -0,0 +1,143 @@
+// Package daemon runs the agent stack in a long-lived background process,
+// the supervisor, which owns the agent processes, restarts those that
+// crash, and keeps running after the terminal that started it is closed.
+// The supervisor records itself in daemon.json in the state directory,
+// which is how asc daemon stop and asc daemon status find it.
+//
+// Example usage:
+//
+//	p, err := daemon.Spawn(executable, []string{"daemon", "run"}, dir, logPath)
+//	if err != nil {
+//	    return err
+//	}
+//
+//	// Later, from any asc process...
+//	if state, ok := daemon.Running(stateDir); ok {
+//	    err := daemon.Stop(state.PID, 30*time.Second)
+//	}
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// StateFileName is the file in the state directory the running
+// supervisor is recorded in
+const StateFileName = "daemon.json"
+
+// LogFileName is the file in the log directory the supervisor's output is
+// appended to
+const LogFileName = "daemon.log"
+
+// State describes the running supervisor
+type State struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	Dir       string    `json:"dir"` // Working directory, holding asc.toml
+	// Ready is set once the stack is started, so asc daemon start knows
+	// the supervisor got past its checks
+	Ready bool `json:"ready"`
+	// Restarts counts the restarts of each crashed process
+	Restarts map[string]int `json:"restarts,omitempty"`
+}
+
+// Read returns the recorded supervisor of the state directory. The error
+// satisfies os.IsNotExist if none is recorded.
+func Read(stateDir string) (*State, error) {
+	var state State
+	if err := statefile.ReadJSON(filepath.Join(stateDir, StateFileName), &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Running returns the recorded supervisor if it is still running. A
+// supervisor that was killed leaves its record behind; it is ignored.
+func Running(stateDir string) (*State, bool) {
+	state, err := Read(stateDir)
+	if err != nil || !process.Alive(state.PID) {
+		return nil, false
+	}
+	return state, true
+}
+
+// Record writes the state of the running supervisor
+func Record(stateDir string, state *State) error {
+	if err := statefile.WriteJSON(filepath.Join(stateDir, StateFileName), state, 0600); err != nil {
+		return fmt.Errorf("failed to record daemon state: %w", err)
+	}
+	return nil
+}
+
+// Clear removes the record of the supervisor with the given PID. The
+// record of another supervisor, started since, is left alone.
+func Clear(stateDir string, pid int) error {
+	state, err := Read(stateDir)
+	if err != nil || state.PID != pid {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(stateDir, StateFileName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove daemon state: %w", err)
+	}
+	return nil
+}
+
+// Spawn starts executable with args in dir as a daemon: in a session of
+// its own, without a terminal or standard input, and with its output
+// appended to logPath. It inherits the environment, so secrets exported or
+// injected for the command starting it reach the stack. The returned
+// process can be waited on to notice the daemon failing to start.
+func Spawn(executable string, args []string, dir, logPath string) (*os.Process, error) {
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open daemon log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = dir
+	cmd.Stdin = nil
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start daemon: %w", err)
+	}
+	return cmd.Process, nil
+}
+
+// pollInterval is how often Stop checks whether the supervisor exited
+const pollInterval = 100 * time.Millisecond
+
+// Stop asks the supervisor with the given PID to stop the stack and exit,
+// and waits up to timeout for it to. A supervisor that does not exit in
+// time is killed, leaving its processes for the caller to stop.
+func Stop(pid int, timeout time.Duration) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find daemon: %w", err)
+	}
+	if err := terminate(p); err != nil {
+		return fmt.Errorf("failed to signal daemon (PID %d): %w", pid, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for process.Alive(pid) {
+		if time.Now().After(deadline) {
+			if err := p.Kill(); err != nil {
+				return fmt.Errorf("failed to kill daemon (PID %d): %w", pid, err)
+			}
+			return fmt.Errorf("daemon (PID %d) did not exit within %s and was killed", pid, timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package daemon
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRecordAndRunning(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, ok := Running(dir); ok {
+		t.Fatal("Expected no daemon before one is recorded")
+	}
+	if _, err := Read(dir); !os.IsNotExist(err) {
+		t.Fatalf("Expected a not-exist error, got %v", err)
+	}
+
+	state := &State{PID: os.Getpid(), StartedAt: time.Now(), Dir: "/work", Restarts: map[string]int{"agent-1": 2}}
+	if err := Record(dir, state); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	got, ok := Running(dir)
+	if !ok {
+		t.Fatal("Expected the recorded daemon to be running")
+	}
+	if got.PID != state.PID || got.Dir != "/work" || got.Restarts["agent-1"] != 2 {
+		t.Errorf("Unexpected state: %+v", got)
+	}
+
+	// Another daemon's record is left alone
+	if err := Clear(dir, state.PID+1); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, ok := Running(dir); !ok {
+		t.Fatal("Expected Clear of another PID to keep the record")
+	}
+	if err := Clear(dir, state.PID); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, err := Read(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the record to be removed, got %v", err)
+	}
+}
+
+func TestRunningIgnoresExitedDaemon(t *testing.T) {
+	dir := t.TempDir()
+	// PIDs this large are not in use
+	if err := Record(dir, &State{PID: 1 << 30, StartedAt: time.Now()}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if _, ok := Running(dir); ok {
+		t.Error("Expected the record of an exited daemon to be ignored")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detach starts cmd in a new session, so it has no controlling terminal
+// and is not sent SIGHUP when the terminal that started it is closed
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true,
+	}
+}
+
+// terminate asks the supervisor to stop the stack and exit
+func terminate(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// detach starts cmd without a console, in a process group of its own, so
+// closing the console that started it does not end it
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.DETACHED_PROCESS | windows.CREATE_NEW_PROCESS_GROUP,
+	}
+}
+
+// terminate ends the supervisor. Windows cannot deliver SIGTERM, so the
+// supervisor is killed and its processes are left for the caller to stop.
+func terminate(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,187 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/process"
+)
+
+// daemonLog tags every record written by this package with the daemon component
+var daemonLog = logger.WithComponent("daemon")
+
+// DefaultCheckInterval is how often the supervisor looks for crashed
+// processes
+const DefaultCheckInterval = 2 * time.Second
+
+// stableAfter is how long a restarted process must run for its next
+// crash to be restarted without delay
+const stableAfter = time.Minute
+
+// maxBackoff bounds the delay between restarts of a process that keeps
+// crashing
+const maxBackoff = 5 * time.Minute
+
+// StartFunc starts the named process the way asc up does
+type StartFunc func(name string) error
+
+// Supervisor restarts supervised processes that are expected to run but
+// are not, backing off while a process keeps crashing soon after being
+// restarted
+type Supervisor struct {
+	pm       process.ProcessManager
+	start    StartFunc
+	names    []string
+	interval time.Duration
+
+	// isActive reports whether a process is expected to be running;
+	// those it rejects, such as agents stopped by the phase pipeline or
+	// paused by their budget, are not restarted (see SetFilter)
+	isActive func(name string) bool
+
+	// onRestart is called after each restart attempt (see SetOnRestart)
+	onRestart func(name string, err error)
+
+	mu       sync.Mutex
+	restarts map[string]*restartState
+}
+
+// restartState tracks the restarts of one process
+type restartState struct {
+	count    int       // Successful restarts
+	failures int       // Crashes soon after a restart, or failed restarts, in a row
+	last     time.Time // Last restart attempt
+	next     time.Time // Earliest time of the next one
+}
+
+// NewSupervisor creates a supervisor of the named processes, restarting
+// them with start
+func NewSupervisor(pm process.ProcessManager, start StartFunc, names []string) *Supervisor {
+	return &Supervisor{
+		pm:       pm,
+		start:    start,
+		names:    names,
+		interval: DefaultCheckInterval,
+		isActive: func(string) bool { return true },
+		restarts: make(map[string]*restartState),
+	}
+}
+
+// SetInterval sets how often the supervisor checks its processes
+func (s *Supervisor) SetInterval(interval time.Duration) {
+	s.interval = interval
+}
+
+// SetFilter sets which processes are expected to be running
+func (s *Supervisor) SetFilter(isActive func(name string) bool) {
+	s.isActive = isActive
+}
+
+// SetOnRestart sets a function called after each restart attempt, with
+// the error of a restart that failed
+func (s *Supervisor) SetOnRestart(onRestart func(name string, err error)) {
+	s.onRestart = onRestart
+}
+
+// Run checks the processes every interval until ctx is done
+func (s *Supervisor) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check(time.Now())
+		}
+	}
+}
+
+// Restarts returns how many times each process was restarted
+func (s *Supervisor) Restarts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	restarts := make(map[string]int, len(s.restarts))
+	for name, state := range s.restarts {
+		if state.count > 0 {
+			restarts[name] = state.count
+		}
+	}
+	return restarts
+}
+
+// check restarts the expected processes that are not running and are not
+// backing off
+func (s *Supervisor) check(now time.Time) {
+	for _, name := range s.names {
+		if !s.isActive(name) {
+			continue
+		}
+		if _, ok := process.Running(s.pm, name); ok {
+			continue
+		}
+		s.restart(name, now)
+	}
+}
+
+// restart starts a process that is not running, unless it is backing off
+func (s *Supervisor) restart(name string, now time.Time) {
+	s.mu.Lock()
+	state, ok := s.restarts[name]
+	if !ok {
+		state = &restartState{}
+		s.restarts[name] = state
+	}
+	if now.Before(state.next) {
+		s.mu.Unlock()
+		return
+	}
+	// A process that crashed soon after its last restart backs off
+	if !state.last.IsZero() && now.Sub(state.last) < stableAfter {
+		state.failures++
+	} else {
+		state.failures = 0
+	}
+	state.last = now
+	s.mu.Unlock()
+
+	daemonLog.WithFields(logger.Fields{"process": name}).Warn("Process is not running, restarting it")
+	err := s.start(name)
+
+	s.mu.Lock()
+	if err == nil {
+		state.count++
+	} else {
+		state.failures++
+	}
+	state.next = now.Add(backoff(state.failures))
+	s.mu.Unlock()
+
+	if err != nil {
+		daemonLog.WithFields(logger.Fields{"process": name}).Error("Failed to restart process: %v", err)
+	} else {
+		daemonLog.WithFields(logger.Fields{"process": name}).Info("Restarted process")
+	}
+	if s.onRestart != nil {
+		s.onRestart(name, err)
+	}
+}
+
+// backoff returns the delay before the next restart of a process after
+// failures crashes or failed restarts in a row: none for the first, then
+// doubling from one second up to maxBackoff
+func backoff(failures int) time.Duration {
+	if failures == 0 {
+		return 0
+	}
+	delay := time.Second
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
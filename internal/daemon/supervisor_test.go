@@ -0,0 +1,205 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/process"
+)
+
+// fakeProcessManager records processes as running until they are crashed
+type fakeProcessManager struct {
+	processes map[string]*process.ProcessInfo
+	running   map[int]bool
+	nextPID   int
+}
+
+func newFakeProcessManager() *fakeProcessManager {
+	return &fakeProcessManager{
+		processes: make(map[string]*process.ProcessInfo),
+		running:   make(map[int]bool),
+		nextPID:   100000,
+	}
+}
+
+func (m *fakeProcessManager) Start(name string, command string, args []string, env []string) (int, error) {
+	m.nextPID++
+	m.processes[name] = &process.ProcessInfo{Name: name, PID: m.nextPID}
+	m.running[m.nextPID] = true
+	return m.nextPID, nil
+}
+
+func (m *fakeProcessManager) crash(name string) {
+	m.running[m.processes[name].PID] = false
+}
+
+func (m *fakeProcessManager) Stop(ctx context.Context, pid int) error {
+	m.running[pid] = false
+	return nil
+}
+
+func (m *fakeProcessManager) StopAll(ctx context.Context) error {
+	return nil
+}
+
+func (m *fakeProcessManager) IsRunning(pid int) bool {
+	return m.running[pid]
+}
+
+func (m *fakeProcessManager) GetStatus(pid int) process.ProcessStatus {
+	if m.running[pid] {
+		return process.StatusRunning
+	}
+	return process.StatusStopped
+}
+
+func (m *fakeProcessManager) GetProcessInfo(name string) (*process.ProcessInfo, error) {
+	if info, ok := m.processes[name]; ok {
+		return info, nil
+	}
+	return nil, fmt.Errorf("process not found: %s", name)
+}
+
+func (m *fakeProcessManager) ListProcesses() ([]*process.ProcessInfo, error) {
+	var list []*process.ProcessInfo
+	for _, info := range m.processes {
+		list = append(list, info)
+	}
+	return list, nil
+}
+
+func newTestSupervisor(pm *fakeProcessManager, names ...string) (*Supervisor, *int) {
+	starts := 0
+	start := func(name string) error {
+		starts++
+		_, err := pm.Start(name, "agent", nil, nil)
+		return err
+	}
+	return NewSupervisor(pm, start, names), &starts
+}
+
+func TestSupervisorRestartsCrashedProcess(t *testing.T) {
+	pm := newFakeProcessManager()
+	pm.Start("agent-1", "agent", nil, nil)
+	pm.Start("agent-2", "agent", nil, nil)
+	s, starts := newTestSupervisor(pm, "agent-1", "agent-2")
+
+	now := time.Now()
+	s.check(now)
+	if *starts != 0 {
+		t.Fatalf("Expected no restarts of running processes, got %d", *starts)
+	}
+
+	pm.crash("agent-1")
+	s.check(now)
+	if *starts != 1 {
+		t.Fatalf("Expected crashed process to be restarted, got %d starts", *starts)
+	}
+	if _, ok := process.Running(pm, "agent-1"); !ok {
+		t.Error("Expected agent-1 to be running again")
+	}
+	if got := s.Restarts(); got["agent-1"] != 1 || got["agent-2"] != 0 {
+		t.Errorf("Unexpected restart counts: %v", got)
+	}
+}
+
+func TestSupervisorBacksOff(t *testing.T) {
+	pm := newFakeProcessManager()
+	pm.Start("agent-1", "agent", nil, nil)
+	s, starts := newTestSupervisor(pm, "agent-1")
+
+	now := time.Now()
+	pm.crash("agent-1")
+	s.check(now)
+
+	// Crashing again right after the restart delays the next one
+	pm.crash("agent-1")
+	now = now.Add(time.Second / 2)
+	s.check(now)
+	if *starts != 2 {
+		t.Fatalf("Expected the second crash to be restarted, got %d starts", *starts)
+	}
+	pm.crash("agent-1")
+	s.check(now.Add(time.Second / 2))
+	if *starts != 2 {
+		t.Fatalf("Expected the restart to wait for the backoff, got %d starts", *starts)
+	}
+	now = now.Add(3 * time.Second)
+	s.check(now)
+	if *starts != 3 {
+		t.Fatalf("Expected restart after the backoff, got %d starts", *starts)
+	}
+
+	// A process that ran for a while is restarted without delay
+	pm.crash("agent-1")
+	now = now.Add(2 * stableAfter)
+	s.check(now)
+	if *starts != 4 {
+		t.Fatalf("Expected immediate restart of a stable process, got %d starts", *starts)
+	}
+}
+
+func TestSupervisorFilter(t *testing.T) {
+	pm := newFakeProcessManager()
+	pm.Start("agent-1", "agent", nil, nil)
+	pm.Start("paused", "agent", nil, nil)
+	s, starts := newTestSupervisor(pm, "agent-1", "paused")
+	s.SetFilter(func(name string) bool { return name != "paused" })
+
+	var restarted []string
+	s.SetOnRestart(func(name string, err error) {
+		restarted = append(restarted, name)
+	})
+
+	pm.crash("agent-1")
+	pm.crash("paused")
+	s.check(time.Now())
+	if *starts != 1 || len(restarted) != 1 || restarted[0] != "agent-1" {
+		t.Errorf("Expected only agent-1 to be restarted, got %v", restarted)
+	}
+}
+
+func TestSupervisorFailedRestart(t *testing.T) {
+	pm := newFakeProcessManager()
+	attempts := 0
+	s := NewSupervisor(pm, func(name string) error {
+		attempts++
+		return fmt.Errorf("command not found")
+	}, []string{"agent-1"})
+
+	var restartErr error
+	s.SetOnRestart(func(name string, err error) { restartErr = err })
+
+	now := time.Now()
+	s.check(now)
+	if restartErr == nil {
+		t.Fatal("Expected the failed restart to be reported")
+	}
+	s.check(now.Add(time.Second / 2))
+	if attempts != 1 {
+		t.Errorf("Expected a failed restart to back off, got %d attempts", attempts)
+	}
+	if got := s.Restarts(); len(got) != 0 {
+		t.Errorf("Expected failed restarts not to count, got %v", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{20, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
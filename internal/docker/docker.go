@@ -0,0 +1,266 @@
+// Package docker runs agents in containers. An agent with runtime =
+// "docker" is started as the docker CLI running its container in the
+// foreground, so the process manager logs, signals, restarts, and checks
+// it like any other agent: docker run forwards the stop signal to the
+// container and removes the container once it exits. Containers are
+// labelled with the agent they run, so ones left behind by a docker CLI
+// that was killed can be found and removed.
+//
+// Example usage:
+//
+//	spec := docker.Spec{Agent: "main-coder", Image: "python:3.12", Volumes: []string{"./src:/src"}}
+//	command, args, env, err := docker.Command(spec, []string{"python", "agent.py"}, env)
+//	if err != nil {
+//	    return err
+//	}
+//	pid, err := procManager.Start("main-coder", command, args, env)
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/prompts"
+	"github.com/rand/asc/internal/usage"
+	"github.com/rand/asc/internal/worktree"
+)
+
+// Binary is the docker CLI asc runs containers with
+const Binary = "docker"
+
+// Label is the container label naming the agent a container runs
+const Label = "asc.agent"
+
+// hostAlias is the name containers reach the host by when they do not
+// share its network
+const hostAlias = "host.docker.internal"
+
+// Spec is the container an agent runs in
+type Spec struct {
+	Agent       string   // Name of the agent, set as the container's label
+	Image       string   // Image to run
+	Volumes     []string // Mounts as "host:container[:options]"; a relative host path is resolved against the working directory
+	Env         []string // Extra variables, passed through from the host by name or set as "NAME=value"
+	Network     string   // Network to join, e.g. "host" (default: docker's bridge network)
+	Interactive bool     // Keep the container's stdin open, for asc attach --stdin
+	TTY         bool     // Give the container a terminal, for asc exec from one
+	MemoryMB    int      // Memory limit of the container in MB; 0 for none
+	CPUs        float64  // CPU cores the container is throttled to; 0 for none
+}
+
+// agentEnvVars are the variables asc sets for an agent, passed through to
+// its container
+var agentEnvVars = []string{
+	"AGENT_NAME", "AGENT_MODEL", "AGENT_PHASES", "AGENT_ROLE", "AGENT_REPLICA", "MCP_MAIL_URL", "BEADS_DB_PATH",
+	prompts.PromptFileEnvVar, prompts.PromptRefEnvVar, worktree.EnvVar,
+	usage.FileEnvVar,
+}
+
+// pathEnvVars are the variables that name a host path an agent uses. The
+// path is mounted into the container at the same place: a directory
+// itself, a file with the directory it is in.
+var pathEnvVars = []string{"BEADS_DB_PATH", worktree.EnvVar, prompts.PromptFileEnvVar, usage.FileEnvVar}
+
+// envNamePattern matches the names of environment variables
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Command returns the docker command line that runs argv in the
+// container of spec, or the image's own command for an empty argv, and
+// the environment to start it with: env with the host paths it names
+// made absolute, as they are mounted, and with a local MCP_MAIL_URL
+// pointed at the host.
+func Command(spec Spec, argv, env []string) (string, []string, []string, error) {
+	env = append([]string(nil), env...)
+	values := make(map[string]string)
+	for _, entry := range env {
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			values[name] = value
+		}
+	}
+
+	args := []string{"run", "--rm", "--init", "--label", Label + "=" + spec.Agent}
+	if spec.Interactive {
+		args = append(args, "--interactive")
+	}
+	if spec.TTY {
+		args = append(args, "--tty")
+	}
+	if spec.Network != "" {
+		args = append(args, "--network", spec.Network)
+	}
+	if spec.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", spec.MemoryMB))
+	}
+	if spec.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(spec.CPUs, 'f', -1, 64))
+	}
+
+	for _, volume := range spec.Volumes {
+		host, rest, _ := splitVolume(volume)
+		if isHostPath(host) {
+			abs, err := filepath.Abs(host)
+			if err != nil {
+				return "", nil, nil, fmt.Errorf("failed to resolve volume '%s': %w", volume, err)
+			}
+			host = abs
+		}
+		args = append(args, "--volume", host+":"+rest)
+	}
+
+	mounted := make(map[string]bool)
+	for _, name := range pathEnvVars {
+		path := values[name]
+		if path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+		dir := abs
+		if stat, err := os.Stat(abs); err != nil || !stat.IsDir() {
+			dir = filepath.Dir(abs)
+			// Created here, or docker would create it owned by root
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return "", nil, nil, fmt.Errorf("failed to create %s: %w", dir, err)
+			}
+		}
+		if !mounted[dir] {
+			mounted[dir] = true
+			args = append(args, "--volume", dir+":"+dir)
+		}
+		if abs != path {
+			env = append(env, name+"="+abs)
+		}
+	}
+
+	// localhost in the container is the container itself
+	if spec.Network != "host" {
+		if mailURL, ok := hostURL(values["MCP_MAIL_URL"]); ok {
+			args = append(args, "--add-host", hostAlias+":host-gateway")
+			env = append(env, "MCP_MAIL_URL="+mailURL)
+		}
+	}
+
+	// Variables are passed by name, so their values are not in the
+	// command line saved with the process
+	for _, name := range agentEnvVars {
+		if _, ok := values[name]; ok {
+			args = append(args, "--env", name)
+		}
+	}
+	// The process manager sets the correlation ID when it starts the CLI;
+	// docker leaves out a variable that is not set
+	args = append(args, "--env", logger.CorrelationIDEnvVar)
+	for _, entry := range spec.Env {
+		args = append(args, "--env", entry)
+	}
+
+	args = append(args, spec.Image)
+	args = append(args, argv...)
+	return Binary, args, env, nil
+}
+
+// splitVolume splits a volume at the colon after its host part, which
+// may start with a Windows drive letter such as "C:\"
+func splitVolume(volume string) (string, string, bool) {
+	start := 0
+	if len(volume) > 2 && volume[1] == ':' && (volume[2] == '\\' || volume[2] == '/') {
+		start = 2
+	}
+	i := strings.Index(volume[start:], ":")
+	if i < 0 {
+		return volume, "", false
+	}
+	return volume[:start+i], volume[start+i+1:], true
+}
+
+// isHostPath reports whether the host part of a volume is a path rather
+// than the name of a docker volume
+func isHostPath(host string) bool {
+	return host == "." || host == ".." || strings.ContainsAny(host, `/\`)
+}
+
+// hostURL returns url with a localhost host replaced by the name
+// containers reach the host by, and whether it was replaced
+func hostURL(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+	default:
+		return "", false
+	}
+	port := u.Port()
+	u.Host = hostAlias
+	if port != "" {
+		u.Host += ":" + port
+	}
+	return u.String(), true
+}
+
+// AgentOf returns the agent a command line returned by Command runs, and
+// whether it is such a command line
+func AgentOf(command string, args []string) (string, bool) {
+	if command != Binary || len(args) == 0 || args[0] != "run" {
+		return "", false
+	}
+	for i := 1; i+1 < len(args); i++ {
+		if args[i] == "--label" {
+			if agent, ok := strings.CutPrefix(args[i+1], Label+"="); ok {
+				return agent, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Remove force-removes the containers of an agent, such as one that kept
+// running after the docker CLI running it was killed
+func Remove(ctx context.Context, agent string) error {
+	output, err := exec.CommandContext(ctx, Binary, "ps", "--all", "--quiet", "--filter", "label="+Label+"="+agent).Output()
+	if err != nil {
+		return fmt.Errorf("failed to list the containers of %s: %w", agent, err)
+	}
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return nil
+	}
+	if output, err := exec.CommandContext(ctx, Binary, append([]string{"rm", "--force"}, ids...)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to remove the containers of %s: %w: %s", agent, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ValidateVolume checks that a volume has the form
+// "host:container[:options]" with an absolute container path
+func ValidateVolume(volume string) error {
+	host, rest, ok := splitVolume(volume)
+	container, _, _ := strings.Cut(rest, ":")
+	if !ok || host == "" || container == "" || strings.Count(rest, ":") > 1 {
+		return fmt.Errorf("volume '%s' must have the form host:container[:options]", volume)
+	}
+	if !strings.HasPrefix(container, "/") {
+		return fmt.Errorf("volume '%s' must mount at an absolute path in the container", volume)
+	}
+	return nil
+}
+
+// ValidateEnv checks that an env entry is "NAME" or "NAME=value"
+func ValidateEnv(entry string) error {
+	name, _, _ := strings.Cut(entry, "=")
+	if !envNamePattern.MatchString(name) {
+		return fmt.Errorf("env '%s' must be NAME or NAME=value", entry)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Mkdir("repo", 0755); err != nil {
+		t.Fatalf("Failed to create repo: %v", err)
+	}
+	abs, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatalf("Abs failed: %v", err)
+	}
+
+	spec := Spec{
+		Agent:       "coder",
+		Image:       "python:3.12",
+		Volumes:     []string{"./src:/src:ro", "cache:/root/.cache"},
+		Env:         []string{"CLAUDE_API_KEY", "MODE=test"},
+		Interactive: true,
+		TTY:         true,
+	}
+	env := []string{
+		"PATH=/usr/bin",
+		"AGENT_NAME=coder",
+		"MCP_MAIL_URL=http://localhost:8765",
+		"BEADS_DB_PATH=./repo",
+		"ASC_USAGE_FILE=" + filepath.Join(dir, "usage", "coder.jsonl"),
+	}
+	command, args, gotEnv, err := Command(spec, []string{"python", "agent.py"}, env)
+	if err != nil {
+		t.Fatalf("Command failed: %v", err)
+	}
+	if command != Binary {
+		t.Errorf("Expected the docker CLI, got %q", command)
+	}
+	usageDir := filepath.Join(dir, "usage")
+	want := []string{
+		"run", "--rm", "--init", "--label", "asc.agent=coder", "--interactive", "--tty",
+		"--volume", filepath.Join(abs, "src") + ":/src:ro",
+		"--volume", "cache:/root/.cache",
+		"--volume", filepath.Join(abs, "repo") + ":" + filepath.Join(abs, "repo"),
+		"--volume", usageDir + ":" + usageDir,
+		"--add-host", "host.docker.internal:host-gateway",
+		"--env", "AGENT_NAME", "--env", "MCP_MAIL_URL", "--env", "BEADS_DB_PATH", "--env", "ASC_USAGE_FILE",
+		"--env", "ASC_CORRELATION_ID", "--env", "CLAUDE_API_KEY", "--env", "MODE=test",
+		"python:3.12", "python", "agent.py",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Unexpected arguments:\n got %q\nwant %q", args, want)
+	}
+	if _, err := os.Stat(usageDir); err != nil {
+		t.Errorf("Expected the directory of a mounted file to be created: %v", err)
+	}
+
+	// The last value of a variable is the one the process sees
+	last := func(name string) string {
+		value := ""
+		for _, entry := range gotEnv {
+			if v, ok := strings.CutPrefix(entry, name+"="); ok {
+				value = v
+			}
+		}
+		return value
+	}
+	if got := last("BEADS_DB_PATH"); got != filepath.Join(abs, "repo") {
+		t.Errorf("Expected BEADS_DB_PATH made absolute, got %q", got)
+	}
+	if got := last("MCP_MAIL_URL"); got != "http://host.docker.internal:8765" {
+		t.Errorf("Expected MCP_MAIL_URL pointed at the host, got %q", got)
+	}
+}
+
+func TestCommand_HostNetwork(t *testing.T) {
+	spec := Spec{Agent: "coder", Image: "agent:latest", Network: "host", MemoryMB: 512, CPUs: 1.5}
+	_, args, env, err := Command(spec, nil, []string{"MCP_MAIL_URL=http://localhost:8765"})
+	if err != nil {
+		t.Fatalf("Command failed: %v", err)
+	}
+	want := []string{
+		"run", "--rm", "--init", "--label", "asc.agent=coder", "--network", "host", "--memory", "512m", "--cpus", "1.5",
+		"--env", "MCP_MAIL_URL", "--env", "ASC_CORRELATION_ID", "agent:latest",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Unexpected arguments:\n got %q\nwant %q", args, want)
+	}
+	if len(env) != 1 {
+		t.Errorf("Expected the environment unchanged on the host network, got %q", env)
+	}
+}
+
+func TestAgentOf(t *testing.T) {
+	_, args, _, err := Command(Spec{Agent: "reviewer", Image: "agent:latest"}, nil, nil)
+	if err != nil {
+		t.Fatalf("Command failed: %v", err)
+	}
+	if agent, ok := AgentOf(Binary, args); !ok || agent != "reviewer" {
+		t.Errorf("AgentOf = %q, %v; want reviewer, true", agent, ok)
+	}
+	if _, ok := AgentOf("python", []string{"run", "--label", "asc.agent=reviewer"}); ok {
+		t.Error("Expected a command other than docker not to run an agent container")
+	}
+	if _, ok := AgentOf(Binary, []string{"ps", "--label", "asc.agent=reviewer"}); ok {
+		t.Error("Expected a docker command other than run not to run an agent container")
+	}
+}
+
+func TestValidateVolume(t *testing.T) {
+	tests := []struct {
+		volume  string
+		wantErr string
+	}{
+		{"./src:/src", ""},
+		{"/data:/data:ro", ""},
+		{"cache:/root/.cache", ""},
+		{`C:\work:/work`, ""},
+		{"./src", "must have the form"},
+		{":/src", "must have the form"},
+		{"./src:/src:ro:extra", "must have the form"},
+		{"./src:src", "absolute path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.volume, func(t *testing.T) {
+			err := ValidateVolume(tt.volume)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	for _, entry := range []string{"CLAUDE_API_KEY", "MODE=test", "_X=a=b", "EMPTY="} {
+		if err := ValidateEnv(entry); err != nil {
+			t.Errorf("ValidateEnv(%q) failed: %v", entry, err)
+		}
+	}
+	for _, entry := range []string{"", "=value", "1BAD", "BAD-NAME=x"} {
+		if err := ValidateEnv(entry); err == nil {
+			t.Errorf("Expected ValidateEnv(%q) to fail", entry)
+		}
+	}
+}
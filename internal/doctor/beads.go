@@ -0,0 +1,192 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/check"
+	"github.com/spf13/viper"
+)
+
+// beadsCheckTimeout bounds checking the beads database, which waits for
+// bd to release its locks and reads every page
+const beadsCheckTimeout = 30 * time.Second
+
+// defaultBeadsPath is core.beads_db_path when asc.toml does not set it
+const defaultBeadsPath = "./project-repo"
+
+// beadsPath returns the beads repository of asc.toml (core.beads_db_path),
+// resolved as asc resolves it when loading the configuration, or "" if
+// asc.toml cannot be read
+func (d *Doctor) beadsPath() string {
+	v := viper.New()
+	v.SetConfigFile(d.configPath)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		// Config issues already reported in checkConfiguration
+		return ""
+	}
+	path := v.GetString("core.beads_db_path")
+	if path == "" {
+		path = defaultBeadsPath
+	}
+	abs, err := filepath.Abs(expandHome(path))
+	if err != nil {
+		return ""
+	}
+	return abs
+}
+
+// installedBDVersion returns what the installed bd prints for --version
+func installedBDVersion(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "bd", "--version").Output()
+	return string(out), err
+}
+
+// checkBeads checks the beads repository for lock files bd's daemon left
+// behind, runs SQLite's integrity check on its database, and checks the
+// installed bd is not older than the bd that last wrote the database. A
+// repository not yet initialized is not checked.
+func (d *Doctor) checkBeads(ctx context.Context, report *DiagnosticReport) {
+	dbPath := d.beadsPath()
+	if dbPath == "" {
+		return
+	}
+	if info, err := os.Stat(filepath.Join(dbPath, beads.DataDirName)); err != nil || !info.IsDir() {
+		return
+	}
+
+	for _, lock := range beads.FindStaleLocks(dbPath) {
+		report.Issues = append(report.Issues, Issue{
+			ID:          "beads-lock-stale-" + filepath.Base(lock.Path),
+			Category:    CategoryState,
+			Severity:    SeverityMedium,
+			Title:       "Stale beads lock file",
+			Description: fmt.Sprintf("%s was left by bd's daemon, process %d, which is no longer running", lock.Path, lock.PID),
+			Impact:      "bd may refuse to start its daemon, or wait for a lock no process holds",
+			Remediation: fmt.Sprintf("Delete the stale lock file: rm %s", lock.Path),
+			AutoFixable: true,
+			DetectedAt:  time.Now(),
+		})
+	}
+
+	if d.checkIntegrity == nil {
+		return
+	}
+	if _, err := beads.FindDatabase(dbPath); err != nil {
+		// Without a database bd works from the JSONL files alone
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, beadsCheckTimeout)
+	defer cancel()
+	integrity, err := d.checkIntegrity(ctx, dbPath)
+	switch {
+	case errors.Is(err, beads.ErrNoSQLite):
+		report.Issues = append(report.Issues, Issue{
+			ID:          "beads-integrity-unchecked",
+			Category:    CategoryState,
+			Severity:    SeverityInfo,
+			Title:       "Beads database not checked",
+			Description: fmt.Sprintf("Cannot check the beads database of %s: %v", dbPath, err),
+			Impact:      "A corrupted task database goes unnoticed until bd fails on it",
+			Remediation: "Install sqlite3 for asc doctor to check the beads database",
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+		return
+	case err != nil:
+		report.Issues = append(report.Issues, Issue{
+			ID:          "beads-db-unreadable",
+			Category:    CategoryState,
+			Severity:    SeverityHigh,
+			Title:       "Beads database cannot be read",
+			Description: err.Error(),
+			Impact:      "Agents cannot list or update their tasks",
+			Remediation: "Stop any process holding the database locked, such as a hung bd, and check it is a SQLite database",
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+		return
+	case len(integrity.Problems) > 0:
+		report.Issues = append(report.Issues, Issue{
+			ID:          "beads-db-corrupted",
+			Category:    CategoryState,
+			Severity:    SeverityCritical,
+			Title:       "Beads database is corrupted",
+			Description: fmt.Sprintf("SQLite's integrity check of %s reported: %s", integrity.Path, strings.Join(integrity.Problems, "; ")),
+			Impact:      "Tasks may be lost or misread, and further writes may spread the damage",
+			Remediation: fmt.Sprintf("Stop asc, move the database aside (mv %s %s.corrupt), and rebuild it from the JSONL files git syncs: bd import -i %s",
+				integrity.Path, integrity.Path, filepath.Join(dbPath, beads.DataDirName, "issues.jsonl")),
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+		return
+	}
+
+	d.checkBeadsSchema(ctx, integrity, report)
+}
+
+// checkBeadsSchema compares the version of bd that last wrote the database
+// with the installed bd. Only major and minor versions are compared, as
+// bd changes its schema in neither patch releases.
+func (d *Doctor) checkBeadsSchema(ctx context.Context, integrity *beads.Integrity, report *DiagnosticReport) {
+	if integrity.BDVersion == "" || d.bdVersion == nil {
+		return
+	}
+	output, err := d.bdVersion(ctx)
+	if err != nil {
+		// A missing bd is reported by checkResources
+		return
+	}
+	installed, err := check.ParseVersion(output)
+	if err != nil {
+		return
+	}
+	recorded, err := check.ParseVersion(integrity.BDVersion)
+	if err != nil {
+		return
+	}
+
+	switch (check.Version{Major: recorded.Major, Minor: recorded.Minor}).Compare(check.Version{Major: installed.Major, Minor: installed.Minor}) {
+	case 1:
+		report.Issues = append(report.Issues, Issue{
+			ID:          "beads-schema-newer",
+			Category:    CategoryState,
+			Severity:    SeverityHigh,
+			Title:       "Beads database written by a newer bd",
+			Description: fmt.Sprintf("%s was written by bd %s, the installed bd is %s", integrity.Path, recorded, installed),
+			Impact:      "bd may fail on, or damage, a schema it does not know",
+			Remediation: fmt.Sprintf("Upgrade bd to %d.%d or later", recorded.Major, recorded.Minor),
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+	case -1:
+		report.Issues = append(report.Issues, Issue{
+			ID:          "beads-schema-outdated",
+			Category:    CategoryState,
+			Severity:    SeverityLow,
+			Title:       "Beads database written by an older bd",
+			Description: fmt.Sprintf("%s was written by bd %s, the installed bd is %s", integrity.Path, recorded, installed),
+			Impact:      "The database keeps the older schema until bd migrates it",
+			Remediation: fmt.Sprintf("Run 'bd migrate' in %s", filepath.Dir(filepath.Dir(integrity.Path))),
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+	}
+}
+
+// fixStaleLock removes a lock file of bd's daemon, if its daemon has not
+// started again since it was found
+func (d *Doctor) fixStaleLock(path string) (bool, string) {
+	if err := beads.ClearStaleLock(path); err != nil {
+		return false, fmt.Sprintf("Failed to remove lock file: %v", err)
+	}
+	return true, fmt.Sprintf("Removed stale lock file %s", path)
+}
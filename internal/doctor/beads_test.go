@@ -0,0 +1,93 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rand/asc/internal/beads"
+)
+
+func TestCheckBeads(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := filepath.Join(tmpDir, "project-repo")
+	dataDir := filepath.Join(repo, beads.DataDirName)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	dbFile := filepath.Join(dataDir, "beads.db")
+	os.WriteFile(dbFile, nil, 0644)
+	lockPath := filepath.Join(dataDir, "daemon.lock")
+	os.WriteFile(lockPath, []byte(`{"pid":999999}`), 0644)
+	configPath := filepath.Join(tmpDir, "asc.toml")
+	os.WriteFile(configPath, []byte(fmt.Sprintf("[core]\nbeads_db_path = %q\n", repo)), 0644)
+
+	tests := []struct {
+		name      string
+		integrity *beads.Integrity
+		err       error
+		bd        string
+		want      map[string]IssueSeverity
+	}{
+		{"intact", &beads.Integrity{Path: dbFile, BDVersion: "0.21.5"}, nil, "bd version 0.21.2 (dev)", nil},
+		{"corrupted", &beads.Integrity{Path: dbFile, Problems: []string{"Page 4 is never used"}}, nil, "bd version 0.21.2", map[string]IssueSeverity{"beads-db-corrupted": SeverityCritical}},
+		{"unreadable", nil, errors.New("database is locked"), "bd version 0.21.2", map[string]IssueSeverity{"beads-db-unreadable": SeverityHigh}},
+		{"no sqlite", nil, beads.ErrNoSQLite, "bd version 0.21.2", map[string]IssueSeverity{"beads-integrity-unchecked": SeverityInfo}},
+		{"newer schema", &beads.Integrity{Path: dbFile, BDVersion: "0.22.0"}, nil, "bd version 0.21.2", map[string]IssueSeverity{"beads-schema-newer": SeverityHigh}},
+		{"older schema", &beads.Integrity{Path: dbFile, BDVersion: "0.20.1"}, nil, "bd version 0.21.2", map[string]IssueSeverity{"beads-schema-outdated": SeverityLow}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Doctor{
+				configPath: configPath,
+				checkIntegrity: func(ctx context.Context, dbPath string) (*beads.Integrity, error) {
+					if dbPath != repo {
+						t.Errorf("Checked %s, want %s", dbPath, repo)
+					}
+					return tt.integrity, tt.err
+				},
+				bdVersion: func(ctx context.Context) (string, error) { return tt.bd, nil },
+			}
+			report := &DiagnosticReport{}
+			doc.checkBeads(context.Background(), report)
+
+			want := map[string]IssueSeverity{"beads-lock-stale-daemon.lock": SeverityMedium}
+			for id, severity := range tt.want {
+				want[id] = severity
+			}
+			if len(report.Issues) != len(want) {
+				t.Fatalf("Expected issues %v, got %+v", want, report.Issues)
+			}
+			for _, issue := range report.Issues {
+				if severity, ok := want[issue.ID]; !ok || issue.Severity != severity {
+					t.Errorf("Unexpected issue %+v", issue)
+				}
+			}
+		})
+	}
+
+	// The fix removes the lock of the daemon that exited
+	doc := &Doctor{configPath: configPath}
+	issue := Issue{ID: "beads-lock-stale-daemon.lock", AutoFixable: true}
+	if action, ok := doc.DescribeFix(issue); !ok || action != fmt.Sprintf("remove the stale lock file %s, unless its daemon has started again", lockPath) {
+		t.Errorf("DescribeFix() = %q, %v", action, ok)
+	}
+	if ok, message := doc.fixFor(issue).apply(context.Background()); !ok {
+		t.Fatalf("Fix failed: %s", message)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("Expected the stale lock removed")
+	}
+
+	// A repository bd has not initialized is not checked
+	os.RemoveAll(dataDir)
+	report := &DiagnosticReport{}
+	doc.checkBeads(context.Background(), report)
+	if len(report.Issues) != 0 {
+		t.Errorf("Expected no issues without a .beads directory, got %+v", report.Issues)
+	}
+}
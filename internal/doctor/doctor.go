@@ -4,17 +4,31 @@
 package doctor
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
-	"syscall"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
-	"github.com/spf13/viper"
+	"github.com/rand/asc/internal/audit"
+	"github.com/rand/asc/internal/beads"
 	"github.com/rand/asc/internal/check"
+	"github.com/rand/asc/internal/fsperm"
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/migrate"
 	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/secrets"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+	"github.com/rand/asc/internal/upgrade"
+	"github.com/spf13/viper"
 )
 
 // IssueSeverity represents the severity level of a detected issue
@@ -38,6 +52,8 @@ const (
 	CategoryResources     IssueCategory = "resources"
 	CategoryNetwork       IssueCategory = "network"
 	CategoryAgent         IssueCategory = "agent"
+	CategorySecurity      IssueCategory = "security"
+	CategoryVersion       IssueCategory = "version"
 )
 
 // Issue represents a detected problem
@@ -51,6 +67,9 @@ type Issue struct {
 	Remediation string        `json:"remediation"`
 	AutoFixable bool          `json:"auto_fixable"`
 	DetectedAt  time.Time     `json:"detected_at"`
+
+	// FixCommand is the shell command a check plugin gave to fix the issue
+	FixCommand string `json:"fix_command,omitempty"`
 }
 
 // FixResult represents the result of applying a fix
@@ -63,37 +82,86 @@ type FixResult struct {
 
 // DiagnosticReport contains all detected issues and fix results
 type DiagnosticReport struct {
+	ID            string       `json:"id,omitempty"` // Set in the history (see SaveReport)
 	RunAt         time.Time    `json:"run_at"`
 	Issues        []Issue      `json:"issues"`
 	FixesApplied  []FixResult  `json:"fixes_applied,omitempty"`
 	HealthSummary string       `json:"health_summary"`
+
+	// RecentLogs holds asc's own most recent log records (see logger.RecentHistory)
+	RecentLogs []logger.LogEntry `json:"recent_logs,omitempty"`
+
+	// AuditLog holds the most recent audited actions, with secrets masked
+	AuditLog []audit.Entry `json:"audit_log,omitempty"`
 }
 
+// recentLogsShown is the number of recent log records in the verbose report
+const recentLogsShown = 20
+
+// auditEntriesIncluded is the number of audit log entries in the report
+const auditEntriesIncluded = 100
+
 // Doctor performs diagnostics and remediation
 type Doctor struct {
 	configPath string
 	envPath    string
 	checker    check.Checker
-	homeDir    string
+	stateDir   string // asc state directory, normally ~/.asc
+	stateFrom  statedir.Source
+
+	// releases returns the newest release of a channel, as recorded at
+	// path (see upgrade.Client.Refresh)
+	releases func(ctx context.Context, path, channel string) (*upgrade.Check, error)
+
+	// probeProvider requests the API endpoint of a model provider; nil
+	// skips checking the providers
+	probeProvider func(ctx context.Context, endpoint string) error
+
+	// verifyKeys has the API keys of .env verified with verifyKey, which
+	// returns the HTTP status a provider answers a request with a key
+	verifyKeys bool
+	verifyKey  func(ctx context.Context, provider modelProvider, key string) (int, error)
+
+	// checkIntegrity checks the beads database of a repository, and
+	// bdVersion returns what the installed bd prints for --version; nil
+	// skips checking the database and its schema
+	checkIntegrity func(ctx context.Context, dbPath string) (*beads.Integrity, error)
+	bdVersion      func(ctx context.Context) (string, error)
 }
 
+// versionCheckTimeout bounds looking up the newest release of asc
+const versionCheckTimeout = 5 * time.Second
+
 // NewDoctor creates a new Doctor instance
 func NewDoctor(configPath, envPath string) (*Doctor, error) {
-	homeDir, err := os.UserHomeDir()
+	stateDir, stateFrom, err := statedir.Resolve()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, err
 	}
 	
 	return &Doctor{
 		configPath: configPath,
 		envPath:    envPath,
 		checker:    check.NewChecker(configPath, envPath),
-		homeDir:    homeDir,
+		stateDir:   stateDir,
+		stateFrom:  stateFrom,
+		releases: func(ctx context.Context, path, channel string) (*upgrade.Check, error) {
+			client, err := upgrade.NewClient("")
+			if err != nil {
+				return nil, err
+			}
+			return client.Refresh(ctx, path, channel)
+		},
+		probeProvider:  probeHTTPS,
+		verifyKey:      verifyKey,
+		checkIntegrity: beads.CheckIntegrity,
+		bdVersion:      installedBDVersion,
 	}, nil
 }
 
-// RunDiagnostics performs all diagnostic checks
-func (d *Doctor) RunDiagnostics() (*DiagnosticReport, error) {
+// RunDiagnostics performs all diagnostic checks. It stops between checks
+// once ctx is done and returns the context's error.
+func (d *Doctor) RunDiagnostics(ctx context.Context) (*DiagnosticReport, error) {
 	logger.Info("Running comprehensive diagnostics...")
 	
 	report := &DiagnosticReport{
@@ -102,17 +170,34 @@ func (d *Doctor) RunDiagnostics() (*DiagnosticReport, error) {
 	}
 	
 	// Run all diagnostic checks
-	d.checkConfiguration(report)
-	d.checkState(report)
-	d.checkPermissions(report)
-	d.checkResources(report)
-	d.checkNetwork(report)
-	d.checkAgents(report)
+	checks := []func(*DiagnosticReport){
+		d.checkConfiguration,
+		d.checkSecrets,
+		d.checkKeyRotation,
+		d.checkState,
+		func(report *DiagnosticReport) { d.checkBeads(ctx, report) },
+		d.checkPermissions,
+		d.checkResources,
+		d.checkNetwork,
+		func(report *DiagnosticReport) { d.checkKeys(ctx, report) },
+		d.checkAgents,
+		d.checkCrashes,
+		d.checkVersion,
+		func(report *DiagnosticReport) { d.checkPlugins(ctx, report) },
+	}
+	for _, run := range checks {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("diagnostics interrupted: %w", err)
+		}
+		run(report)
+	}
 	
 	// Generate health summary
 	report.HealthSummary = d.generateHealthSummary(report)
 	
 	logger.Info("Diagnostics complete: found %d issue(s)", len(report.Issues))
+	report.RecentLogs = logger.RecentHistory()
+	report.AuditLog = d.recentAudit()
 	return report, nil
 }
 
@@ -165,32 +250,160 @@ func (d *Doctor) checkConfiguration(report *DiagnosticReport) {
 		})
 	} else {
 		// Check .env permissions
-		info, err := os.Stat(d.envPath)
-		if err == nil {
-			mode := info.Mode().Perm()
-			if mode&0077 != 0 {
-				report.Issues = append(report.Issues, Issue{
-					ID:          "env-permissions",
-					Category:    CategoryPermissions,
-					Severity:    SeverityMedium,
-					Title:       "Insecure .env file permissions",
-					Description: fmt.Sprintf(".env file has permissions %o (should be 0600)", mode),
-					Impact:      "API keys may be readable by other users",
-					Remediation: "Run 'chmod 600 .env' to secure the file",
-					AutoFixable: true,
-					DetectedAt:  time.Now(),
-				})
-			}
+		private, detail, err := fsperm.CheckPrivate(d.envPath)
+		if err == nil && !private {
+			report.Issues = append(report.Issues, Issue{
+				ID:          "env-permissions",
+				Category:    CategoryPermissions,
+				Severity:    SeverityMedium,
+				Title:       "Insecure .env file permissions",
+				Description: fmt.Sprintf(".env file is not private: %s", detail),
+				Impact:      "API keys may be readable by other users",
+				Remediation: fmt.Sprintf("Run '%s' to secure the file", fsperm.Remedy(d.envPath)),
+				AutoFixable: true,
+				DetectedAt:  time.Now(),
+			})
+		}
+	}
+}
+
+// checkSecrets looks for plaintext API keys that are, or could be,
+// committed to the project's git repository
+func (d *Doctor) checkSecrets(report *DiagnosticReport) {
+	findings, err := secrets.Scan(d.projectDir())
+	if err != nil {
+		logger.Warn("Failed to scan for plaintext secrets: %v", err)
+		return
+	}
+	
+	for _, finding := range findings {
+		switch {
+		case finding.Tracked && finding.Ignore != "":
+			report.Issues = append(report.Issues, Issue{
+				ID:          "secret-tracked-" + finding.Path,
+				Category:    CategorySecurity,
+				Severity:    SeverityHigh,
+				Title:       "Plaintext secrets committed to git",
+				Description: finding.String(),
+				Impact:      "Anyone with access to the repository can read the API keys, including in its history",
+				Remediation: fmt.Sprintf("Run 'git rm --cached %s', add it to .gitignore, rotate the keys it holds, and commit 'asc secrets encrypt' output instead", finding.Path),
+				AutoFixable: true,
+				DetectedAt:  time.Now(),
+			})
+		case finding.Ignore != "":
+			report.Issues = append(report.Issues, Issue{
+				ID:          "secret-unignored-" + finding.Path,
+				Category:    CategorySecurity,
+				Severity:    SeverityHigh,
+				Title:       "Plaintext secrets not ignored by git",
+				Description: finding.String(),
+				Impact:      "The API keys will be committed by the next 'git add'",
+				Remediation: fmt.Sprintf("Add %s to .gitignore", finding.Ignore),
+				AutoFixable: true,
+				DetectedAt:  time.Now(),
+			})
+		default:
+			report.Issues = append(report.Issues, Issue{
+				ID:          fmt.Sprintf("secret-plaintext-%s:%d", finding.Path, finding.Line),
+				Category:    CategorySecurity,
+				Severity:    SeverityHigh,
+				Title:       "Plaintext API key in project file",
+				Description: finding.String(),
+				Impact:      "The API key is readable by anyone with access to the file or the repository",
+				Remediation: "Move the key to .env and encrypt it with 'asc secrets encrypt', or reference a secret manager in [secrets.env]; rotate it if it was committed",
+				AutoFixable: false,
+				DetectedAt:  time.Now(),
+			})
 		}
 	}
 }
 
+// checkKeyRotation warns when the age key is older than the rotation
+// policy in asc.toml
+func (d *Doctor) checkKeyRotation(report *DiagnosticReport) {
+	v := viper.New()
+	v.SetConfigFile(d.configPath)
+	v.SetConfigType("toml")
+	
+	if err := v.ReadInConfig(); err != nil {
+		// Config issues already reported
+		return
+	}
+	rotationDays := v.GetInt("secrets.rotation_days")
+	manager := secrets.NewManagerWithKeyPath(filepath.Join(d.stateDir, "age.key"))
+	if rotationDays <= 0 || !manager.KeyExists() {
+		return
+	}
+	
+	due, age, err := manager.RotationDue(rotationDays)
+	if err != nil || !due {
+		return
+	}
+	report.Issues = append(report.Issues, Issue{
+		ID:          "secret-key-rotation-due",
+		Category:    CategorySecurity,
+		Severity:    SeverityMedium,
+		Title:       "Age key is past its rotation policy",
+		Description: fmt.Sprintf("The age key is %d days old; secrets.rotation_days is %d", int(age.Hours()/24), rotationDays),
+		Impact:      "A leaked key would still decrypt the secrets",
+		Remediation: "Run 'asc secrets rotate --auto', or schedule it to run daily",
+		AutoFixable: false,
+		DetectedAt:  time.Now(),
+	})
+}
+
+// checkVersion warns when a newer release of asc is out, on the channel
+// asc upgrade last checked. GitHub is asked at most once a day; a build
+// from source is not checked.
+func (d *Doctor) checkVersion(report *DiagnosticReport) {
+	current := upgrade.Current()
+	if current == upgrade.DevVersion || d.releases == nil {
+		return
+	}
+	path := filepath.Join(d.stateDir, "upgrade.json")
+	channel := upgrade.ChannelStable
+	if check, err := upgrade.LoadCheck(path); err == nil && check.Channel != "" {
+		channel = check.Channel
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), versionCheckTimeout)
+	defer cancel()
+	check, err := d.releases(ctx, path, channel)
+	if err != nil {
+		// Offline; the network checks report it
+		logger.Debug("Failed to look up the newest release: %v", err)
+		return
+	}
+	if upgrade.Compare(check.Latest, current) <= 0 {
+		return
+	}
+	report.Issues = append(report.Issues, Issue{
+		ID:          "asc-outdated",
+		Category:    CategoryVersion,
+		Severity:    SeverityLow,
+		Title:       "Newer asc release available",
+		Description: fmt.Sprintf("asc %s is installed; the newest %s release is %s", current, channel, check.Latest),
+		Impact:      "Fixes and features of the newer release are missing",
+		Remediation: fmt.Sprintf("Run 'asc upgrade --channel %s'", channel),
+		AutoFixable: false,
+		DetectedAt:  time.Now(),
+	})
+}
+
+// projectDir returns the directory holding asc.toml
+func (d *Doctor) projectDir() string {
+	return filepath.Dir(d.configPath)
+}
+
 // checkState validates PID files, logs, and other state
 func (d *Doctor) checkState(report *DiagnosticReport) {
-	ascDir := filepath.Join(d.homeDir, ".asc")
+	ascDir := d.stateDir
 	pidDir := filepath.Join(ascDir, "pids")
 	logDir := filepath.Join(ascDir, "logs")
 	
+	// Check the state was written by this release's layout
+	d.checkSchema(report)
+	
 	// Check for orphaned PID files
 	if _, err := os.Stat(pidDir); err == nil {
 		files, err := os.ReadDir(pidDir)
@@ -204,29 +417,40 @@ func (d *Doctor) checkState(report *DiagnosticReport) {
 					}
 					
 					var procInfo process.ProcessInfo
-					if err := json.Unmarshal(data, &procInfo); err != nil {
+					err = json.Unmarshal(data, &procInfo)
+					if err == nil {
+						err = procInfo.Validate()
+					}
+					if err != nil {
 						report.Issues = append(report.Issues, Issue{
 							ID:          fmt.Sprintf("pid-corrupted-%s", file.Name()),
 							Category:    CategoryState,
 							Severity:    SeverityMedium,
 							Title:       "Corrupted PID file",
-							Description: fmt.Sprintf("PID file '%s' contains invalid JSON", file.Name()),
+							Description: fmt.Sprintf("PID file '%s' is invalid: %v", file.Name(), err),
 							Impact:      "Cannot track process status",
-							Remediation: fmt.Sprintf("Delete the corrupted file: rm %s", pidPath),
+							Remediation: fmt.Sprintf("Move the corrupted file aside: mv %s %s/", pidPath, statefile.QuarantineDir(pidDir)),
 							AutoFixable: true,
 							DetectedAt:  time.Now(),
 						})
 						continue
 					}
 					
-					// Check if process is actually running
-					if !isProcessRunning(procInfo.PID) {
+					// Check if process is actually running, and is the
+					// process asc started rather than a later one given
+					// its PID; a running one is adopted by the next asc up
+					running := isProcessRunning(procInfo.PID)
+					if !running || !process.SameProcess(&procInfo) {
+						description := fmt.Sprintf("PID file exists for '%s' but process %d is not running", procInfo.Name, procInfo.PID)
+						if running {
+							description = fmt.Sprintf("PID file exists for '%s' but PID %d belongs to another process now", procInfo.Name, procInfo.PID)
+						}
 						report.Issues = append(report.Issues, Issue{
 							ID:          fmt.Sprintf("pid-orphaned-%s", procInfo.Name),
 							Category:    CategoryState,
 							Severity:    SeverityLow,
 							Title:       "Orphaned PID file",
-							Description: fmt.Sprintf("PID file exists for '%s' but process %d is not running", procInfo.Name, procInfo.PID),
+							Description: description,
 							Impact:      "Stale state may cause confusion",
 							Remediation: fmt.Sprintf("Delete the orphaned file: rm %s", pidPath),
 							AutoFixable: true,
@@ -249,7 +473,7 @@ func (d *Doctor) checkState(report *DiagnosticReport) {
 				Title:       "Large log directory",
 				Description: fmt.Sprintf("Log directory is %.2f MB", float64(size)/(1024*1024)),
 				Impact:      "Consuming excessive disk space",
-				Remediation: "Clean old logs: find ~/.asc/logs -name '*.log' -mtime +7 -delete",
+				Remediation: "Clean old logs: asc cleanup --days 7, or lower max_size_mb and max_files in [logging.rotation]",
 				AutoFixable: true,
 				DetectedAt:  time.Now(),
 			})
@@ -257,9 +481,64 @@ func (d *Doctor) checkState(report *DiagnosticReport) {
 	}
 }
 
+// checkSchema reports a state directory whose layout is older than this
+// release, which asc migrates on its next run, or newer, which it cannot read
+func (d *Doctor) checkSchema(report *DiagnosticReport) {
+	if _, err := os.Stat(d.stateDir); err != nil {
+		return
+	}
+	version, err := migrate.Version(d.stateDir)
+	if err != nil {
+		return
+	}
+	
+	switch {
+	case version < migrate.CurrentVersion:
+		report.Issues = append(report.Issues, Issue{
+			ID:          "state-schema-outdated",
+			Category:    CategoryState,
+			Severity:    SeverityMedium,
+			Title:       "State directory written by an older version",
+			Description: fmt.Sprintf("State directory %s has schema %d, this release uses %d", d.stateDir, version, migrate.CurrentVersion),
+			Impact:      "Files in older formats may be reported as corrupted",
+			Remediation: fmt.Sprintf("Run any asc command, or asc doctor --fix, to migrate it (a backup is kept in %s)", filepath.Join(d.stateDir, migrate.BackupDirName)),
+			AutoFixable: true,
+			DetectedAt:  time.Now(),
+		})
+	case version > migrate.CurrentVersion:
+		report.Issues = append(report.Issues, Issue{
+			ID:          "state-schema-newer",
+			Category:    CategoryState,
+			Severity:    SeverityCritical,
+			Title:       "State directory written by a newer version",
+			Description: fmt.Sprintf("State directory %s has schema %d, this release supports up to %d", d.stateDir, version, migrate.CurrentVersion),
+			Impact:      "asc commands refuse to run rather than misread or overwrite newer state",
+			Remediation: fmt.Sprintf("Upgrade asc, or set %s to use a separate state directory with this release", statedir.EnvVar),
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+	}
+}
+
 // checkPermissions validates file and directory permissions
 func (d *Doctor) checkPermissions(report *DiagnosticReport) {
-	ascDir := filepath.Join(d.homeDir, ".asc")
+	ascDir := d.stateDir
+	
+	// Without a usable home directory state lives next to the project,
+	// so it is lost with the checkout or container
+	if d.stateFrom == statedir.SourceWorkDir {
+		report.Issues = append(report.Issues, Issue{
+			ID:          "state-dir-workdir",
+			Category:    CategoryPermissions,
+			Severity:    SeverityInfo,
+			Title:       "State stored in the working directory",
+			Description: fmt.Sprintf("The home directory is unset or not writable, so state is kept in %s", ascDir),
+			Impact:      "PID files, logs, and pipeline state are only found when asc runs from this directory",
+			Remediation: fmt.Sprintf("Set %s to a persistent, writable directory", statedir.EnvVar),
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+	}
 	
 	// Check if .asc directory exists and is writable
 	if info, err := os.Stat(ascDir); err == nil {
@@ -268,10 +547,10 @@ func (d *Doctor) checkPermissions(report *DiagnosticReport) {
 				ID:          "asc-not-dir",
 				Category:    CategoryPermissions,
 				Severity:    SeverityCritical,
-				Title:       "~/.asc is not a directory",
-				Description: "~/.asc exists but is a file, not a directory",
+				Title:       "State directory is not a directory",
+				Description: fmt.Sprintf("%s exists but is a file, not a directory", ascDir),
 				Impact:      "Cannot store state, logs, or PIDs",
-				Remediation: "Remove the file and recreate: rm ~/.asc && mkdir ~/.asc",
+				Remediation: fmt.Sprintf("Remove the file and recreate it as a directory: %s", ascDir),
 				AutoFixable: true,
 				DetectedAt:  time.Now(),
 			})
@@ -283,10 +562,10 @@ func (d *Doctor) checkPermissions(report *DiagnosticReport) {
 					ID:          "asc-not-writable",
 					Category:    CategoryPermissions,
 					Severity:    SeverityCritical,
-					Title:       "~/.asc directory not writable",
-					Description: fmt.Sprintf("Cannot write to ~/.asc: %v", err),
+					Title:       "State directory not writable",
+					Description: fmt.Sprintf("Cannot write to %s: %v", ascDir, err),
 					Impact:      "Cannot store state, logs, or PIDs",
-					Remediation: "Fix permissions: chmod 755 ~/.asc",
+					Remediation: fmt.Sprintf("Make %s writable by your user, or set %s to a writable directory", ascDir, statedir.EnvVar),
 					AutoFixable: true,
 					DetectedAt:  time.Now(),
 				})
@@ -305,9 +584,9 @@ func (d *Doctor) checkPermissions(report *DiagnosticReport) {
 				Category:    CategoryPermissions,
 				Severity:    SeverityMedium,
 				Title:       fmt.Sprintf("Missing %s directory", subdir),
-				Description: fmt.Sprintf("Directory ~/.asc/%s does not exist", subdir),
+				Description: fmt.Sprintf("Directory %s does not exist", dirPath),
 				Impact:      fmt.Sprintf("Cannot store %s", subdir),
-				Remediation: fmt.Sprintf("Create directory: mkdir -p ~/.asc/%s", subdir),
+				Remediation: fmt.Sprintf("Create directory: %s", dirPath),
 				AutoFixable: true,
 				DetectedAt:  time.Now(),
 			})
@@ -318,7 +597,7 @@ func (d *Doctor) checkPermissions(report *DiagnosticReport) {
 // checkResources validates system resources
 func (d *Doctor) checkResources(report *DiagnosticReport) {
 	// Check disk space
-	ascDir := filepath.Join(d.homeDir, ".asc")
+	ascDir := d.stateDir
 	if info, err := os.Stat(ascDir); err == nil && info.IsDir() {
 		// Get available disk space (simplified check)
 		// In production, use syscall.Statfs or similar
@@ -329,7 +608,7 @@ func (d *Doctor) checkResources(report *DiagnosticReport) {
 				Category:    CategoryResources,
 				Severity:    SeverityMedium,
 				Title:       "High disk usage",
-				Description: fmt.Sprintf("~/.asc directory is using %.2f MB", float64(size)/(1024*1024)),
+				Description: fmt.Sprintf("%s is using %.2f MB", ascDir, float64(size)/(1024*1024)),
 				Impact:      "May run out of disk space",
 				Remediation: "Clean up old logs and playbooks",
 				AutoFixable: false,
@@ -375,19 +654,52 @@ func (d *Doctor) checkNetwork(report *DiagnosticReport) {
 		return
 	}
 	
-	// Check MCP server connectivity (basic check)
-	mcpURL := v.GetString("services.mcp_agent_mail.url")
-	if mcpURL != "" {
-		// Note: We don't actually make HTTP requests here to avoid dependencies
-		// This is a placeholder for where you'd check connectivity
+	// Check the MCP servers accept connections, mcp_agent_mail and the
+	// other [services.mcp_*] servers agents are routed to
+	servers := []string{"mcp_agent_mail"}
+	var others []string
+	for name := range v.GetStringMap("services") {
+		if strings.HasPrefix(name, "mcp_") && name != "mcp_agent_mail" {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	servers = append(servers, others...)
+	for _, name := range servers {
+		if mcpURL := v.GetString("services." + name + ".url"); mcpURL != "" {
+			d.checkMCPServer(v, name, mcpURL, report)
+		}
+	}
+	
+	// Check no other process holds the ports of the stack
+	d.checkPorts(v, servers, report)
+	
+	// Check the APIs of the agents' model providers can be reached
+	d.checkProviders(v, report)
+	
+	// Report the detected proxy configuration
+	proxyResult := d.checker.CheckProxy()
+	if proxyResult.Status == check.CheckFail {
 		report.Issues = append(report.Issues, Issue{
-			ID:          "network-check-info",
+			ID:          "network-proxy-invalid",
+			Category:    CategoryNetwork,
+			Severity:    SeverityHigh,
+			Title:       "Invalid proxy configuration",
+			Description: proxyResult.Message,
+			Impact:      "Connections to the MCP server and log shipping endpoints will fail",
+			Remediation: proxyResult.Hint,
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+	} else {
+		report.Issues = append(report.Issues, Issue{
+			ID:          "network-proxy-info",
 			Category:    CategoryNetwork,
 			Severity:    SeverityInfo,
-			Title:       "Network connectivity check",
-			Description: fmt.Sprintf("MCP server configured at %s", mcpURL),
+			Title:       "Proxy configuration",
+			Description: proxyResult.Message,
 			Impact:      "None",
-			Remediation: "Verify MCP server is accessible: curl " + mcpURL + "/health",
+			Remediation: "Set services.mcp_agent_mail.proxy in asc.toml to override HTTP(S)_PROXY for the MCP server",
 			AutoFixable: false,
 			DetectedAt:  time.Now(),
 		})
@@ -412,9 +724,9 @@ func (d *Doctor) checkAgents(report *DiagnosticReport) {
 	for agentName := range agents {
 		agentKey := fmt.Sprintf("agent.%s", agentName)
 		
-		// Check if agent command exists
+		// Check if agent command exists; a container may run its image's own
 		command := v.GetString(agentKey + ".command")
-		if command == "" {
+		if command == "" && !strings.EqualFold(v.GetString(agentKey+".runtime"), "docker") {
 			report.Issues = append(report.Issues, Issue{
 				ID:          fmt.Sprintf("agent-no-command-%s", agentName),
 				Category:    CategoryAgent,
@@ -452,6 +764,40 @@ func (d *Doctor) checkAgents(report *DiagnosticReport) {
 			})
 		}
 		
+		// Check that its working directory and env_file exist
+		for _, file := range []struct {
+			key, id, what string
+			dir           bool
+		}{{"workdir", "workdir", "working directory", true}, {"env_file", "env-file", "env_file", false}} {
+			path := v.GetString(agentKey + "." + file.key)
+			if path == "" {
+				continue
+			}
+			problem := ""
+			if stat, err := os.Stat(expandHome(path)); err != nil {
+				problem = "does not exist"
+			} else if stat.IsDir() != file.dir {
+				problem = "is not a file"
+				if file.dir {
+					problem = "is not a directory"
+				}
+			}
+			if problem == "" {
+				continue
+			}
+			report.Issues = append(report.Issues, Issue{
+				ID:          fmt.Sprintf("agent-%s-missing-%s", file.id, agentName),
+				Category:    CategoryAgent,
+				Severity:    SeverityCritical,
+				Title:       fmt.Sprintf("Agent '%s' has a missing %s", agentName, file.what),
+				Description: fmt.Sprintf("The %s '%s' %s", file.key, path, problem),
+				Impact:      "Agent cannot be started",
+				Remediation: fmt.Sprintf("Create %s, or fix %s in [agent.%s] in asc.toml", path, file.key, agentName),
+				AutoFixable: false,
+				DetectedAt:  time.Now(),
+			})
+		}
+		
 		// Check if phases are defined
 		phases := v.GetStringSlice(agentKey + ".phases")
 		if len(phases) == 0 {
@@ -470,40 +816,124 @@ func (d *Doctor) checkAgents(report *DiagnosticReport) {
 	}
 }
 
-// ApplyFixes attempts to automatically fix issues
-func (d *Doctor) ApplyFixes(report *DiagnosticReport) ([]FixResult, error) {
+// expandHome expands ~ and environment variables in a path of asc.toml,
+// as the agents' paths are expanded when it is loaded
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return os.ExpandEnv(path)
+}
+
+// recentCrashWindow is how far back checkCrashes looks for crashes
+const recentCrashWindow = 24 * time.Hour
+
+// checkCrashes summarizes the agents that crashed recently, from the
+// crash dumps in the state directory
+func (d *Doctor) checkCrashes(report *DiagnosticReport) {
+	crashes, err := process.ListCrashes(filepath.Join(d.stateDir, "crashes"))
+	if err != nil {
+		return
+	}
+
+	// Crashes are listed newest first, so the first of an agent is its last
+	since := time.Now().Add(-recentCrashWindow)
+	counts := make(map[string]int)
+	latest := make(map[string]*process.Crash)
+	var names []string
+	for _, crash := range crashes {
+		if crash.ExitedAt.Before(since) {
+			continue
+		}
+		if counts[crash.Name] == 0 {
+			latest[crash.Name] = crash
+			names = append(names, crash.Name)
+		}
+		counts[crash.Name]++
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		crash := latest[name]
+		exit := fmt.Sprintf("exit status %d", crash.ExitCode)
+		if crash.Signal != "" {
+			exit = "killed by " + crash.Signal
+		}
+		severity := SeverityLow
+		if counts[name] >= 3 {
+			severity = SeverityMedium
+		}
+		report.Issues = append(report.Issues, Issue{
+			ID:          fmt.Sprintf("agent-crashes-%s", name),
+			Category:    CategoryAgent,
+			Severity:    severity,
+			Title:       fmt.Sprintf("Agent '%s' crashed %d time(s) in the last 24 hours", name, counts[name]),
+			Description: fmt.Sprintf("Last crash %s at %s: %s", crash.ID, crash.ExitedAt.Local().Format("2006-01-02 15:04:05"), exit),
+			Impact:      "The agent stops working until it is restarted, and may lose work in progress",
+			Remediation: fmt.Sprintf("Run 'asc crashes show %s' for its last output", crash.ID),
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+	}
+}
+
+// FixOptions selects the fixes ApplyFixesWith applies
+type FixOptions struct {
+	// Only lists the IDs of the issues to fix, or patterns of them such as
+	// "pid-orphaned-*"; every fixable issue is fixed if it is empty
+	Only []string
+
+	// Confirm is asked before each fix, with the issue and what its fix
+	// does, and the fix is skipped unless it returns true; nil for none
+	Confirm func(issue Issue, action string) bool
+}
+
+// Selects reports whether the issue with the given ID is among those the
+// options fix
+func (o FixOptions) Selects(id string) bool {
+	if len(o.Only) == 0 {
+		return true
+	}
+	for _, pattern := range o.Only {
+		if matched, err := path.Match(pattern, id); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyFixes attempts to automatically fix issues. Once ctx is done no
+// further fixes are started, and the fixes applied so far are returned
+// with the context's error.
+func (d *Doctor) ApplyFixes(ctx context.Context, report *DiagnosticReport) ([]FixResult, error) {
+	return d.ApplyFixesWith(ctx, report, FixOptions{})
+}
+
+// ApplyFixesWith is ApplyFixes for the issues opts selects, applying each
+// fix opts confirms
+func (d *Doctor) ApplyFixesWith(ctx context.Context, report *DiagnosticReport, opts FixOptions) ([]FixResult, error) {
 	results := []FixResult{}
 	
 	for _, issue := range report.Issues {
-		if !issue.AutoFixable {
+		if !issue.AutoFixable || !opts.Selects(issue.ID) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, fmt.Errorf("fixes interrupted: %w", err)
+		}
+		f := d.fixFor(issue)
+		if f == nil {
+			continue
+		}
+		if opts.Confirm != nil && !opts.Confirm(issue, f.action) {
+			logger.Info("Skipped fix of %s", issue.Title)
 			continue
 		}
 		
 		logger.Info("Attempting to fix: %s", issue.Title)
-		
-		var success bool
-		var message string
-		
-		switch issue.ID {
-		case "env-permissions":
-			success, message = d.fixEnvPermissions()
-		case "asc-not-dir":
-			success, message = d.fixAscNotDir()
-		case "asc-not-writable":
-			success, message = d.fixAscNotWritable()
-		case "logs-large":
-			success, message = d.fixLargeLogs()
-		default:
-			if len(issue.ID) > 13 && issue.ID[:13] == "pid-corrupted" {
-				success, message = d.fixCorruptedPID(issue.ID)
-			} else if len(issue.ID) > 13 && issue.ID[:13] == "pid-orphaned-" {
-				success, message = d.fixOrphanedPID(issue.ID)
-			} else if len(issue.ID) > 12 && issue.ID[:12] == "dir-missing-" {
-				success, message = d.fixMissingDir(issue.ID)
-			} else {
-				continue
-			}
-		}
+		success, message := f.apply(ctx)
 		
 		results = append(results, FixResult{
 			IssueID:   issue.ID,
@@ -522,16 +952,79 @@ func (d *Doctor) ApplyFixes(report *DiagnosticReport) ([]FixResult, error) {
 	return results, nil
 }
 
+// DescribeFix returns what the fix of an issue does, in the words of a
+// dry run, such as "remove the PID file ~/.asc/pids/coder.json", and
+// whether the issue has a fix
+func (d *Doctor) DescribeFix(issue Issue) (string, bool) {
+	if !issue.AutoFixable {
+		return "", false
+	}
+	f := d.fixFor(issue)
+	if f == nil {
+		return "", false
+	}
+	return f.action, true
+}
+
+// fix is what --fix does about an issue
+type fix struct {
+	action string                                  // What it does, for dry runs and confirmation
+	apply  func(ctx context.Context) (bool, string) // Whether it worked, and what it did
+}
+
+// fixFor returns the fix of an issue, or nil if it has none
+func (d *Doctor) fixFor(issue Issue) *fix {
+	noContext := func(apply func() (bool, string)) func(context.Context) (bool, string) {
+		return func(context.Context) (bool, string) { return apply() }
+	}
+	switch {
+	case issue.FixCommand != "":
+		return &fix{
+			action: fmt.Sprintf("run '%s' in %s", issue.FixCommand, d.projectDir()),
+			apply:  func(ctx context.Context) (bool, string) { return d.fixWithCommand(ctx, issue.FixCommand) },
+		}
+	case issue.ID == "env-permissions":
+		return &fix{fmt.Sprintf("restrict %s to its owner, as '%s' does", d.envPath, fsperm.Remedy(d.envPath)), noContext(d.fixEnvPermissions)}
+	case issue.ID == "asc-not-dir":
+		return &fix{fmt.Sprintf("remove the file %s and create a directory in its place", d.stateDir), noContext(d.fixAscNotDir)}
+	case issue.ID == "asc-not-writable":
+		return &fix{fmt.Sprintf("make %s writable by its owner", d.stateDir), noContext(d.fixAscNotWritable)}
+	case issue.ID == "logs-large":
+		old := d.oldLogs()
+		return &fix{fmt.Sprintf("delete %d log file(s) older than 7 days from %s", len(old), filepath.Join(d.stateDir, "logs")), noContext(d.fixLargeLogs)}
+	case issue.ID == "state-schema-outdated":
+		return &fix{fmt.Sprintf("migrate the state in %s to schema %d, with a backup in %s", d.stateDir, migrate.CurrentVersion, filepath.Join(d.stateDir, migrate.BackupDirName)), noContext(d.fixOutdatedSchema)}
+	case strings.HasPrefix(issue.ID, "pid-corrupted-"):
+		pidPath := filepath.Join(d.stateDir, "pids", strings.TrimPrefix(issue.ID, "pid-corrupted-"))
+		return &fix{fmt.Sprintf("move %s to %s", pidPath, statefile.QuarantineDir(filepath.Dir(pidPath))), noContext(func() (bool, string) { return d.fixCorruptedPID(issue.ID) })}
+	case strings.HasPrefix(issue.ID, "pid-orphaned-"):
+		pidPath := filepath.Join(d.stateDir, "pids", strings.TrimPrefix(issue.ID, "pid-orphaned-")+".json")
+		return &fix{fmt.Sprintf("remove the PID file %s", pidPath), noContext(func() (bool, string) { return d.fixOrphanedPID(issue.ID) })}
+	case strings.HasPrefix(issue.ID, "beads-lock-stale-"):
+		lockPath := filepath.Join(d.beadsPath(), beads.DataDirName, filepath.Base(strings.TrimPrefix(issue.ID, "beads-lock-stale-")))
+		return &fix{fmt.Sprintf("remove the stale lock file %s, unless its daemon has started again", lockPath), noContext(func() (bool, string) { return d.fixStaleLock(lockPath) })}
+	case strings.HasPrefix(issue.ID, "dir-missing-"):
+		return &fix{fmt.Sprintf("create the directory %s", filepath.Join(d.stateDir, strings.TrimPrefix(issue.ID, "dir-missing-"))), noContext(func() (bool, string) { return d.fixMissingDir(issue.ID) })}
+	case strings.HasPrefix(issue.ID, "secret-tracked-"):
+		file := strings.TrimPrefix(issue.ID, "secret-tracked-")
+		return &fix{fmt.Sprintf("add /%s to %s; the file stays tracked", file, filepath.Join(d.projectDir(), ".gitignore")), noContext(func() (bool, string) { return d.fixTrackedSecret(file) })}
+	case strings.HasPrefix(issue.ID, "secret-unignored-"):
+		file := strings.TrimPrefix(issue.ID, "secret-unignored-")
+		return &fix{fmt.Sprintf("add /%s to %s", file, filepath.Join(d.projectDir(), ".gitignore")), noContext(func() (bool, string) { return d.fixUnignoredSecret(file) })}
+	}
+	return nil
+}
+
 // Fix functions
 func (d *Doctor) fixEnvPermissions() (bool, string) {
-	if err := os.Chmod(d.envPath, 0600); err != nil {
+	if err := fsperm.MakePrivate(d.envPath); err != nil {
 		return false, fmt.Sprintf("Failed to change permissions: %v", err)
 	}
-	return true, "Set .env permissions to 0600"
+	return true, "Restricted .env to its owner"
 }
 
 func (d *Doctor) fixAscNotDir() (bool, string) {
-	ascDir := filepath.Join(d.homeDir, ".asc")
+	ascDir := d.stateDir
 	if err := os.Remove(ascDir); err != nil {
 		return false, fmt.Sprintf("Failed to remove file: %v", err)
 	}
@@ -542,53 +1035,67 @@ func (d *Doctor) fixAscNotDir() (bool, string) {
 }
 
 func (d *Doctor) fixAscNotWritable() (bool, string) {
-	ascDir := filepath.Join(d.homeDir, ".asc")
-	if err := os.Chmod(ascDir, 0755); err != nil {
+	ascDir := d.stateDir
+	if err := fsperm.MakeWritable(ascDir); err != nil {
 		return false, fmt.Sprintf("Failed to change permissions: %v", err)
 	}
-	return true, "Set ~/.asc permissions to 0755"
+	return true, fmt.Sprintf("Made %s writable", ascDir)
 }
 
 func (d *Doctor) fixLargeLogs() (bool, string) {
-	logDir := filepath.Join(d.homeDir, ".asc", "logs")
-	
-	// Delete logs older than 7 days
 	deleted := 0
-	err := filepath.Walk(logDir, func(path string, info os.FileInfo, err error) error {
+	for _, path := range d.oldLogs() {
+		if err := os.Remove(path); err == nil {
+			deleted++
+		}
+	}
+	return true, fmt.Sprintf("Deleted %d old log files", deleted)
+}
+
+// oldLogs returns the log files of the state directory older than 7 days
+func (d *Doctor) oldLogs() []string {
+	var old []string
+	filepath.Walk(filepath.Join(d.stateDir, "logs"), func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
-		if !info.IsDir() && filepath.Ext(path) == ".log" {
-			if time.Since(info.ModTime()) > 7*24*time.Hour {
-				if err := os.Remove(path); err == nil {
-					deleted++
-				}
-			}
+		if !info.IsDir() && logger.IsLogFile(info.Name()) && time.Since(info.ModTime()) > 7*24*time.Hour {
+			old = append(old, path)
 		}
 		return nil
 	})
-	
+	return old
+}
+
+func (d *Doctor) fixOutdatedSchema() (bool, string) {
+	result, err := migrate.Run(d.stateDir)
 	if err != nil {
-		return false, fmt.Sprintf("Failed to clean logs: %v", err)
+		return false, fmt.Sprintf("Failed to migrate state: %v", err)
 	}
-	return true, fmt.Sprintf("Deleted %d old log files", deleted)
+	return true, fmt.Sprintf("Migrated state from schema %d to %d, backup in %s", result.From, result.To, result.Backup)
 }
 
 func (d *Doctor) fixCorruptedPID(issueID string) (bool, string) {
 	// Extract filename from issue ID
 	filename := issueID[14:] // Skip "pid-corrupted-"
-	pidPath := filepath.Join(d.homeDir, ".asc", "pids", filename)
+	pidPath := filepath.Join(d.stateDir, "pids", filename)
 	
-	if err := os.Remove(pidPath); err != nil {
-		return false, fmt.Sprintf("Failed to remove file: %v", err)
+	// Keep the file for inspection rather than deleting it
+	moved, err := statefile.Quarantine(pidPath)
+	if errors.Is(err, os.ErrNotExist) {
+		// Migrating the state directory quarantines corrupted PID files too
+		return true, "Corrupted PID file was already moved aside"
+	}
+	if err != nil {
+		return false, fmt.Sprintf("Failed to move file: %v", err)
 	}
-	return true, "Removed corrupted PID file"
+	return true, fmt.Sprintf("Moved corrupted PID file to %s", moved)
 }
 
 func (d *Doctor) fixOrphanedPID(issueID string) (bool, string) {
 	// Extract agent name from issue ID
 	agentName := issueID[13:] // Skip "pid-orphaned-"
-	pidPath := filepath.Join(d.homeDir, ".asc", "pids", agentName+".json")
+	pidPath := filepath.Join(d.stateDir, "pids", agentName+".json")
 	
 	if err := os.Remove(pidPath); err != nil {
 		return false, fmt.Sprintf("Failed to remove file: %v", err)
@@ -599,18 +1106,34 @@ func (d *Doctor) fixOrphanedPID(issueID string) (bool, string) {
 func (d *Doctor) fixMissingDir(issueID string) (bool, string) {
 	// Extract directory name from issue ID
 	dirName := issueID[12:] // Skip "dir-missing-"
-	dirPath := filepath.Join(d.homeDir, ".asc", dirName)
+	dirPath := filepath.Join(d.stateDir, dirName)
 	
 	if err := os.MkdirAll(dirPath, 0755); err != nil {
 		return false, fmt.Sprintf("Failed to create directory: %v", err)
 	}
-	return true, fmt.Sprintf("Created directory ~/.asc/%s", dirName)
+	return true, fmt.Sprintf("Created directory %s", dirPath)
+}
+
+func (d *Doctor) fixUnignoredSecret(path string) (bool, string) {
+	if err := secrets.AddToGitignore(d.projectDir(), "/"+path); err != nil {
+		return false, fmt.Sprintf("Failed to update .gitignore: %v", err)
+	}
+	return true, fmt.Sprintf("Added /%s to .gitignore", path)
+}
+
+func (d *Doctor) fixTrackedSecret(path string) (bool, string) {
+	if err := secrets.AddToGitignore(d.projectDir(), "/"+path); err != nil {
+		return false, fmt.Sprintf("Failed to update .gitignore: %v", err)
+	}
+	// Untracking the file and rotating its keys are left to the user: the
+	// first changes the next commit, and the keys are in the history anyway
+	return true, fmt.Sprintf("Added /%s to .gitignore; run 'git rm --cached %s' and rotate the keys it holds", path, path)
 }
 
 // generateHealthSummary creates a summary of the diagnostic results
 func (d *Doctor) generateHealthSummary(report *DiagnosticReport) string {
 	if len(report.Issues) == 0 {
-		return "✓ All checks passed - system is healthy"
+		return i18n.T("doctor.healthy")
 	}
 	
 	criticalCount := 0
@@ -631,19 +1154,19 @@ func (d *Doctor) generateHealthSummary(report *DiagnosticReport) string {
 		}
 	}
 	
-	summary := fmt.Sprintf("Found %d issue(s): ", len(report.Issues))
+	summary := i18n.T("doctor.found_issues", len(report.Issues))
 	parts := []string{}
 	if criticalCount > 0 {
-		parts = append(parts, fmt.Sprintf("%d critical", criticalCount))
+		parts = append(parts, i18n.T("doctor.count.critical", criticalCount))
 	}
 	if highCount > 0 {
-		parts = append(parts, fmt.Sprintf("%d high", highCount))
+		parts = append(parts, i18n.T("doctor.count.high", highCount))
 	}
 	if mediumCount > 0 {
-		parts = append(parts, fmt.Sprintf("%d medium", mediumCount))
+		parts = append(parts, i18n.T("doctor.count.medium", mediumCount))
 	}
 	if lowCount > 0 {
-		parts = append(parts, fmt.Sprintf("%d low", lowCount))
+		parts = append(parts, i18n.T("doctor.count.low", lowCount))
 	}
 	
 	for i, part := range parts {
@@ -679,15 +1202,15 @@ func (r *DiagnosticReport) ToJSON() (string, error) {
 func (r *DiagnosticReport) Format(verbose bool) string {
 	output := "\n"
 	output += "╔════════════════════════════════════════════════════════════════╗\n"
-	output += "║              ASC DOCTOR - DIAGNOSTIC REPORT                    ║\n"
+	output += formatTitle(i18n.T("doctor.title"))
 	output += "╚════════════════════════════════════════════════════════════════╝\n\n"
 	
-	output += fmt.Sprintf("Run at: %s\n", r.RunAt.Format("2006-01-02 15:04:05"))
-	output += fmt.Sprintf("Status: %s\n\n", r.HealthSummary)
+	output += i18n.T("doctor.run_at", r.RunAt.Format("2006-01-02 15:04:05")) + "\n"
+	output += i18n.T("doctor.status", r.HealthSummary) + "\n\n"
 	
 	if len(r.Issues) == 0 {
-		output += "✓ No issues detected\n"
-		return output
+		output += i18n.T("doctor.no_issues") + "\n"
+		return output + r.formatRecentLogs(verbose)
 	}
 	
 	// Group issues by severity
@@ -711,7 +1234,7 @@ func (r *DiagnosticReport) Format(verbose bool) string {
 			continue
 		}
 		
-		output += fmt.Sprintf("─── %s SEVERITY (%d) ───\n\n", severity, len(issues))
+		output += i18n.T("doctor.severity_heading", i18n.T("doctor.severity."+string(severity)), len(issues)) + "\n\n"
 		
 		for i, issue := range issues {
 			icon := "●"
@@ -729,17 +1252,17 @@ func (r *DiagnosticReport) Format(verbose bool) string {
 			}
 			
 			output += fmt.Sprintf("%s %s\n", icon, issue.Title)
-			output += fmt.Sprintf("  Category: %s\n", issue.Category)
+			output += i18n.T("doctor.category", issue.Category) + "\n"
 			
 			if verbose {
-				output += fmt.Sprintf("  Description: %s\n", issue.Description)
-				output += fmt.Sprintf("  Impact: %s\n", issue.Impact)
+				output += i18n.T("doctor.description", issue.Description) + "\n"
+				output += i18n.T("doctor.impact", issue.Impact) + "\n"
 			}
 			
-			output += fmt.Sprintf("  Remediation: %s\n", issue.Remediation)
+			output += i18n.T("doctor.remediation", issue.Remediation) + "\n"
 			
 			if issue.AutoFixable {
-				output += "  ✓ Auto-fixable with --fix flag\n"
+				output += i18n.T("doctor.auto_fixable") + "\n"
 			}
 			
 			if i < len(issues)-1 {
@@ -751,7 +1274,7 @@ func (r *DiagnosticReport) Format(verbose bool) string {
 	
 	// Display fix results if any
 	if len(r.FixesApplied) > 0 {
-		output += "─── FIXES APPLIED ───\n\n"
+		output += i18n.T("doctor.fixes_applied") + "\n\n"
 		for _, fix := range r.FixesApplied {
 			icon := "✓"
 			if !fix.Success {
@@ -762,19 +1285,59 @@ func (r *DiagnosticReport) Format(verbose bool) string {
 		output += "\n"
 	}
 	
+	return output + r.formatRecentLogs(verbose)
+}
+
+// formatTitle renders the title line of the report box, which is 64
+// columns wide inside
+func formatTitle(title string) string {
+	const width, indent = 64, 14
+	pad := width - indent - utf8.RuneCountInString(title)
+	if pad < 0 {
+		pad = 0
+	}
+	return "║" + strings.Repeat(" ", indent) + title + strings.Repeat(" ", pad) + "║\n"
+}
+
+// formatRecentLogs renders the last recentLogsShown records of asc's own
+// log in verbose mode
+func (r *DiagnosticReport) formatRecentLogs(verbose bool) string {
+	if !verbose || len(r.RecentLogs) == 0 {
+		return ""
+	}
+	
+	entries := r.RecentLogs
+	if len(entries) > recentLogsShown {
+		entries = entries[len(entries)-recentLogsShown:]
+	}
+	
+	output := "\n" + i18n.T("doctor.recent_logs", len(entries)) + "\n\n"
+	for _, entry := range entries {
+		output += logger.FormatEntryText(entry) + "\n"
+	}
+	output += "\n" + i18n.T("doctor.more_logs") + "\n"
 	return output
 }
 
-// Helper functions
-func isProcessRunning(pid int) bool {
-	process, err := os.FindProcess(pid)
+// recentAudit returns the last auditEntriesIncluded audited actions,
+// redacted again in case secrets were registered since they were written
+func (d *Doctor) recentAudit() []audit.Entry {
+	entries, err := audit.Read(filepath.Join(d.stateDir, "audit.log"))
 	if err != nil {
-		return false
+		logger.Warn("Failed to read audit log: %v", err)
 	}
-	
-	// Send signal 0 to check if process exists
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
+	if len(entries) > auditEntriesIncluded {
+		entries = entries[len(entries)-auditEntriesIncluded:]
+	}
+	for i := range entries {
+		entries[i] = entries[i].Redacted()
+	}
+	return entries
+}
+
+// Helper functions
+func isProcessRunning(pid int) bool {
+	return process.Alive(pid)
 }
 
 func getDirSize(path string) (int64, error) {
@@ -1,15 +1,25 @@
 package doctor
 
 import (
+	"context"
+	"errors"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/migrate"
 	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/secrets"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/upgrade"
 )
 
 func TestNewDoctor(t *testing.T) {
@@ -31,6 +41,85 @@ func TestNewDoctor(t *testing.T) {
 	}
 }
 
+func TestRunDiagnosticsCancelled(t *testing.T) {
+	doc, _ := NewDoctor("asc.toml", ".env")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := doc.RunDiagnostics(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if _, err := doc.ApplyFixes(ctx, &DiagnosticReport{Issues: []Issue{{AutoFixable: true}}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestCheckPermissions_WorkDirState(t *testing.T) {
+	doc := &Doctor{stateDir: filepath.Join(t.TempDir(), ".asc"), stateFrom: statedir.SourceWorkDir}
+	report := &DiagnosticReport{}
+	doc.checkPermissions(report)
+
+	for _, issue := range report.Issues {
+		if issue.ID == "state-dir-workdir" {
+			if issue.Severity != SeverityInfo || !strings.Contains(issue.Remediation, statedir.EnvVar) {
+				t.Errorf("Unexpected issue: %+v", issue)
+			}
+			return
+		}
+	}
+	t.Errorf("Expected a state-dir-workdir issue, got %+v", report.Issues)
+}
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"unversioned", "", "state-schema-outdated"},
+		{"current", fmt.Sprintf(`{"version": %d}`, migrate.CurrentVersion), ""},
+		{"newer", `{"version": 99}`, "state-schema-newer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateDir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(stateDir, "pids"), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if tt.schema != "" {
+				if err := os.WriteFile(filepath.Join(stateDir, migrate.SchemaFileName), []byte(tt.schema), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			doc := &Doctor{stateDir: stateDir}
+			report := &DiagnosticReport{}
+			doc.checkSchema(report)
+
+			if tt.want == "" {
+				if len(report.Issues) != 0 {
+					t.Errorf("Expected no issues, got %+v", report.Issues)
+				}
+				return
+			}
+			if len(report.Issues) != 1 || report.Issues[0].ID != tt.want {
+				t.Fatalf("Expected a %s issue, got %+v", tt.want, report.Issues)
+			}
+
+			if report.Issues[0].AutoFixable {
+				fixes, err := doc.ApplyFixes(context.Background(), report)
+				if err != nil || len(fixes) != 1 || !fixes[0].Success {
+					t.Fatalf("Expected the migration to succeed, got %+v, %v", fixes, err)
+				}
+				if version, _ := migrate.Version(stateDir); version != migrate.CurrentVersion {
+					t.Errorf("Expected schema %d after the fix, got %d", migrate.CurrentVersion, version)
+				}
+			}
+		})
+	}
+}
+
 func TestDiagnosticReport_HasCriticalIssues(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -189,6 +278,44 @@ func TestDiagnosticReport_Format(t *testing.T) {
 	}
 }
 
+func TestDiagnosticReport_FormatTranslated(t *testing.T) {
+	if err := i18n.SetLanguage("es"); err != nil {
+		t.Fatal(err)
+	}
+	defer i18n.SetLanguage(i18n.DefaultLanguage)
+
+	report := &DiagnosticReport{
+		RunAt: time.Now(),
+		Issues: []Issue{
+			{
+				ID:          "test-high",
+				Category:    CategoryConfiguration,
+				Severity:    SeverityHigh,
+				Title:       "High Issue",
+				Remediation: "Fix it",
+				DetectedAt:  time.Now(),
+			},
+		},
+	}
+	report.HealthSummary = (&Doctor{}).generateHealthSummary(report)
+
+	output := report.Format(false)
+	for _, want := range []string{"INFORME DE DIAGNÓSTICO", "GRAVEDAD alta (1)", "Solución: Fix it", "Se encontraron 1 problema(s): 1 alto(s)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected translated output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	// JSON keys and severity values are not translated
+	data, err := report.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(data, `"severity": "high"`) || !strings.Contains(data, `"remediation"`) {
+		t.Errorf("Expected untranslated JSON, got %s", data)
+	}
+}
+
 func TestIsProcessRunning(t *testing.T) {
 	// Test with current process (should be running)
 	currentPID := os.Getpid()
@@ -329,10 +456,10 @@ url = "http://localhost:8765"
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	// Run diagnostics
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run diagnostics: %v", err)
 	}
@@ -354,7 +481,7 @@ url = "http://localhost:8765"
 	}
 	
 	// Apply fixes
-	fixes, err := doc.ApplyFixes(report)
+	fixes, err := doc.ApplyFixes(context.Background(), report)
 	if err != nil {
 		t.Fatalf("Failed to apply fixes: %v", err)
 	}
@@ -441,10 +568,10 @@ beads_db_path = "./repo"
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	// Run diagnostics
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run diagnostics: %v", err)
 	}
@@ -466,7 +593,7 @@ beads_db_path = "./repo"
 	}
 	
 	// Apply fixes
-	fixes, err := doc.ApplyFixes(report)
+	fixes, err := doc.ApplyFixes(context.Background(), report)
 	if err != nil {
 		t.Fatalf("Failed to apply fixes: %v", err)
 	}
@@ -553,10 +680,10 @@ beads_db_path = "./repo"
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	// Run diagnostics
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run diagnostics: %v", err)
 	}
@@ -577,7 +704,7 @@ beads_db_path = "./repo"
 	// This is expected behavior
 	
 	// Apply fixes anyway to test the cleanup
-	fixes, err := doc.ApplyFixes(report)
+	fixes, err := doc.ApplyFixes(context.Background(), report)
 	if err != nil {
 		t.Fatalf("Failed to apply fixes: %v", err)
 	}
@@ -609,7 +736,7 @@ beads_db_path = "./repo"
 // TestRecoveryFromPermissionIssues tests recovery from permission issues
 func TestRecoveryFromPermissionIssues(t *testing.T) {
 	// Skip on Windows as permission model is different
-	if os.Getenv("GOOS") == "windows" {
+	if runtime.GOOS == "windows" {
 		t.Skip("Skipping permission test on Windows")
 	}
 	
@@ -638,7 +765,7 @@ beads_db_path = "./repo"
 	}
 	
 	// Run diagnostics
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run diagnostics: %v", err)
 	}
@@ -660,7 +787,7 @@ beads_db_path = "./repo"
 	}
 	
 	// Apply fixes
-	fixes, err := doc.ApplyFixes(report)
+	fixes, err := doc.ApplyFixes(context.Background(), report)
 	if err != nil {
 		t.Fatalf("Failed to apply fixes: %v", err)
 	}
@@ -723,10 +850,10 @@ beads_db_path = "./repo"
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	// Run diagnostics (directories don't exist yet)
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run diagnostics: %v", err)
 	}
@@ -753,7 +880,7 @@ beads_db_path = "./repo"
 	}
 	
 	// Apply fixes
-	fixes, err := doc.ApplyFixes(report)
+	fixes, err := doc.ApplyFixes(context.Background(), report)
 	if err != nil {
 		t.Fatalf("Failed to apply fixes: %v", err)
 	}
@@ -786,6 +913,54 @@ beads_db_path = "./repo"
 	}
 }
 
+// TestApplyFixesWith tests selecting fixes by ID and confirming each
+func TestApplyFixesWith(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	doc, err := NewDoctor(filepath.Join(tmpDir, "asc.toml"), filepath.Join(tmpDir, ".env"))
+	if err != nil {
+		t.Fatalf("Failed to create doctor: %v", err)
+	}
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
+	report := &DiagnosticReport{Issues: []Issue{
+		{ID: "dir-missing-logs", Title: "Missing logs directory", AutoFixable: true},
+		{ID: "dir-missing-pids", Title: "Missing pids directory", AutoFixable: true},
+		{ID: "dir-missing-playbooks", Title: "Missing playbooks directory", AutoFixable: true},
+		{ID: "config-invalid", Title: "Invalid configuration"},
+	}}
+
+	if action, ok := doc.DescribeFix(report.Issues[0]); !ok || action != "create the directory "+filepath.Join(doc.stateDir, "logs") {
+		t.Errorf("DescribeFix() = %q, %v", action, ok)
+	}
+	if _, ok := doc.DescribeFix(report.Issues[3]); ok {
+		t.Error("Expected no fix for an issue that is not auto-fixable")
+	}
+
+	var asked []string
+	opts := FixOptions{
+		Only: []string{"dir-missing-p*"},
+		Confirm: func(issue Issue, action string) bool {
+			asked = append(asked, issue.ID)
+			return issue.ID == "dir-missing-pids"
+		},
+	}
+	results, err := doc.ApplyFixesWith(context.Background(), report, opts)
+	if err != nil {
+		t.Fatalf("ApplyFixesWith() error = %v", err)
+	}
+	if strings.Join(asked, ",") != "dir-missing-pids,dir-missing-playbooks" {
+		t.Errorf("Expected to be asked about the selected fixes only, got %v", asked)
+	}
+	if len(results) != 1 || results[0].IssueID != "dir-missing-pids" || !results[0].Success {
+		t.Errorf("ApplyFixesWith() = %+v", results)
+	}
+	for dir, want := range map[string]bool{"pids": true, "logs": false, "playbooks": false} {
+		if _, err := os.Stat(filepath.Join(doc.stateDir, dir)); (err == nil) != want {
+			t.Errorf("Expected %s created: %v", dir, want)
+		}
+	}
+}
+
 // TestCheckAgents_WithRunningAgents tests checkAgents with running agents
 func TestCheckAgents_WithRunningAgents(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -846,6 +1021,14 @@ beads_db_path = "./repo"
 [agent.no-command-agent]
 model = "claude"
 phases = ["planning"]
+
+[agent.image-command-agent]
+model = "claude"
+phases = ["planning"]
+runtime = "docker"
+
+[agent.image-command-agent.docker]
+image = "agent:latest"
 `
 	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
 		t.Fatalf("Failed to create config: %v", err)
@@ -886,6 +1069,11 @@ phases = ["planning"]
 	if !foundIssue {
 		t.Error("Missing command issue was not detected")
 	}
+	for _, issue := range report.Issues {
+		if issue.ID == "agent-no-command-image-command-agent" {
+			t.Error("Expected a docker agent to run its image's command without one")
+		}
+	}
 }
 
 // TestCheckAgents_WithInvalidModel tests checkAgents with invalid model
@@ -997,6 +1185,56 @@ model = "claude"
 	}
 }
 
+// TestCheckAgents_WithMissingPaths tests checkAgents with a workdir and
+// env_file that do not exist
+func TestCheckAgents_WithMissingPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	os.MkdirAll(filepath.Join(tmpDir, "checkout"), 0755)
+
+	configPath := filepath.Join(tmpDir, "asc.toml")
+	configContent := `[core]
+beads_db_path = "./repo"
+
+[agent.present]
+command = "python agent.py"
+model = "claude"
+phases = ["planning"]
+workdir = "~/checkout"
+
+[agent.absent]
+command = "python agent.py"
+model = "claude"
+phases = ["planning"]
+workdir = "` + filepath.Join(tmpDir, "gone") + `"
+env_file = "` + filepath.Join(tmpDir, "checkout") + `"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	doc, err := NewDoctor(configPath, filepath.Join(tmpDir, ".env"))
+	if err != nil {
+		t.Fatalf("Failed to create doctor: %v", err)
+	}
+	report := &DiagnosticReport{RunAt: time.Now(), Issues: []Issue{}}
+	doc.checkAgents(report)
+
+	found := make(map[string]Issue)
+	for _, issue := range report.Issues {
+		found[issue.ID] = issue
+	}
+	if issue, ok := found["agent-workdir-missing-absent"]; !ok || issue.Severity != SeverityCritical || !strings.Contains(issue.Description, "does not exist") {
+		t.Errorf("Expected the missing workdir reported, got %+v", report.Issues)
+	}
+	if issue, ok := found["agent-env-file-missing-absent"]; !ok || !strings.Contains(issue.Description, "is not a file") {
+		t.Errorf("Expected the env_file that is a directory reported, got %+v", report.Issues)
+	}
+	if _, ok := found["agent-workdir-missing-present"]; ok {
+		t.Error("Expected the workdir under ~ to be found")
+	}
+}
+
 // TestCheckAgents_WithMultipleAgents tests checkAgents with multiple agents
 func TestCheckAgents_WithMultipleAgents(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -1406,6 +1644,37 @@ func TestDiagnosticReport_Format_VerboseMode(t *testing.T) {
 	}
 }
 
+// TestDiagnosticReport_Format_RecentLogs tests that asc's recent log records
+// are shown in verbose mode only
+func TestDiagnosticReport_Format_RecentLogs(t *testing.T) {
+	report := &DiagnosticReport{
+		RunAt:         time.Now(),
+		Issues:        []Issue{},
+		HealthSummary: "All systems healthy",
+	}
+	for i := 0; i < recentLogsShown+5; i++ {
+		report.RecentLogs = append(report.RecentLogs, logger.LogEntry{
+			Level:   "INFO",
+			Message: fmt.Sprintf("record %d", i),
+		})
+	}
+	
+	if output := report.Format(false); strings.Contains(output, "RECENT ASC LOGS") {
+		t.Error("Normal output should not include recent logs")
+	}
+	
+	output := report.Format(true)
+	if !strings.Contains(output, fmt.Sprintf("RECENT ASC LOGS (last %d)", recentLogsShown)) {
+		t.Errorf("Verbose output should include recent logs, got:\n%s", output)
+	}
+	if strings.Contains(output, "record 4\n") {
+		t.Error("Verbose output should only show the newest records")
+	}
+	if !strings.Contains(output, fmt.Sprintf("record %d", recentLogsShown+4)) {
+		t.Error("Verbose output should show the newest record")
+	}
+}
+
 // TestDiagnosticReport_Format_WithFixResults tests formatting with fix results
 func TestDiagnosticReport_Format_WithFixResults(t *testing.T) {
 	report := &DiagnosticReport{
@@ -1611,10 +1880,10 @@ beads_db_path = "./repo"
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	// Run diagnostics
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run diagnostics: %v", err)
 	}
@@ -1625,7 +1894,7 @@ beads_db_path = "./repo"
 	}
 	
 	// Apply all fixes
-	fixes, err := doc.ApplyFixes(report)
+	fixes, err := doc.ApplyFixes(context.Background(), report)
 	if err != nil {
 		t.Fatalf("Failed to apply fixes: %v", err)
 	}
@@ -1643,7 +1912,7 @@ beads_db_path = "./repo"
 	}
 	
 	// Run diagnostics again - should have fewer issues
-	report2, err := doc.RunDiagnostics()
+	report2, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run second diagnostics: %v", err)
 	}
@@ -1871,7 +2140,7 @@ beads_db_path = "./repo"
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	report := &DiagnosticReport{
 		RunAt:  time.Now(),
@@ -1915,10 +2184,10 @@ func TestFixAscNotDir(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	// Run diagnostics to detect issue
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run diagnostics: %v", err)
 	}
@@ -1937,7 +2206,7 @@ func TestFixAscNotDir(t *testing.T) {
 	}
 	
 	// Apply fix
-	fixes, err := doc.ApplyFixes(report)
+	fixes, err := doc.ApplyFixes(context.Background(), report)
 	if err != nil {
 		t.Fatalf("Failed to apply fixes: %v", err)
 	}
@@ -1972,7 +2241,7 @@ func TestFixAscNotDir(t *testing.T) {
 // TestFixAscNotWritable tests fixing .asc when it's not writable
 func TestFixAscNotWritable(t *testing.T) {
 	// Skip on systems where we can't test permissions
-	if os.Getenv("GOOS") == "windows" {
+	if runtime.GOOS == "windows" {
 		t.Skip("Skipping permission test on Windows")
 	}
 	
@@ -2003,10 +2272,10 @@ func TestFixAscNotWritable(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	// Run diagnostics
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run diagnostics: %v", err)
 	}
@@ -2025,7 +2294,7 @@ func TestFixAscNotWritable(t *testing.T) {
 	}
 	
 	// Apply fix
-	fixes, err := doc.ApplyFixes(report)
+	fixes, err := doc.ApplyFixes(context.Background(), report)
 	if err != nil {
 		t.Fatalf("Failed to apply fixes: %v", err)
 	}
@@ -2096,7 +2365,7 @@ func TestFixLargeLogs(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	// Manually call fixLargeLogs
 	success, message := doc.fixLargeLogs()
@@ -2166,10 +2435,10 @@ phases = ["planning"]
 	if err != nil {
 		t.Fatalf("Failed to create doctor: %v", err)
 	}
-	doc.homeDir = tmpDir
+	doc.stateDir = filepath.Join(tmpDir, ".asc")
 	
 	// Run full diagnostics
-	report, err := doc.RunDiagnostics()
+	report, err := doc.RunDiagnostics(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to run diagnostics: %v", err)
 	}
@@ -2193,3 +2462,162 @@ phases = ["planning"]
 		t.Errorf("Expected 0 critical issues with proper setup, got %d", criticalCount)
 	}
 }
+
+func TestCheckSecrets(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmpDir := t.TempDir()
+	if out, err := exec.Command("git", "-C", tmpDir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	key := "sk-ant-" + strings.Repeat("x1", 16)
+	os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("CLAUDE_API_KEY="+key+"\n"), 0600)
+	os.WriteFile(filepath.Join(tmpDir, "asc.toml"), []byte("[core]\napi_key = \""+key+"\"\n"), 0644)
+
+	doc := &Doctor{configPath: filepath.Join(tmpDir, "asc.toml"), stateDir: filepath.Join(tmpDir, ".asc")}
+	report := &DiagnosticReport{}
+	doc.checkSecrets(report)
+
+	ids := map[string]Issue{}
+	for _, issue := range report.Issues {
+		ids[issue.ID] = issue
+		if issue.Category != CategorySecurity || issue.Severity != SeverityHigh {
+			t.Errorf("Expected a high severity security issue, got %+v", issue)
+		}
+	}
+	if issue, ok := ids["secret-unignored-.env"]; !ok || !issue.AutoFixable {
+		t.Errorf("Expected an auto-fixable issue for .env, got %+v", report.Issues)
+	}
+	if issue, ok := ids["secret-plaintext-asc.toml:2"]; !ok || issue.AutoFixable {
+		t.Errorf("Expected an issue for the key in asc.toml, got %+v", report.Issues)
+	}
+
+	results, err := doc.ApplyFixes(context.Background(), report)
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("ApplyFixes() = %+v, %v", results, err)
+	}
+	gitignore, _ := os.ReadFile(filepath.Join(tmpDir, ".gitignore"))
+	if string(gitignore) != "/.env\n" {
+		t.Errorf(".gitignore = %q", gitignore)
+	}
+
+	report = &DiagnosticReport{}
+	doc.checkSecrets(report)
+	if len(report.Issues) != 1 {
+		t.Errorf("Expected only the asc.toml issue after fixing, got %+v", report.Issues)
+	}
+}
+
+func TestCheckKeyRotation(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateDir := filepath.Join(tmpDir, ".asc")
+	configPath := filepath.Join(tmpDir, "asc.toml")
+	os.WriteFile(configPath, []byte("[secrets]\nrotation_days = 30\n"), 0644)
+	if err := secrets.NewManagerWithKeyPath(filepath.Join(stateDir, "age.key")).GenerateKey(); err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	doc := &Doctor{configPath: configPath, stateDir: stateDir}
+	report := &DiagnosticReport{}
+	doc.checkKeyRotation(report)
+	if len(report.Issues) != 0 {
+		t.Errorf("Expected no issues for a new key, got %+v", report.Issues)
+	}
+
+	keyPath := filepath.Join(stateDir, "age.key")
+	data, _ := os.ReadFile(keyPath)
+	created := time.Now().Add(-45 * 24 * time.Hour).Format(time.RFC3339)
+	lines := strings.SplitN(string(data), "\n", 2)
+	os.WriteFile(keyPath, []byte("# created: "+created+"\n"+lines[1]), 0600)
+
+	doc.checkKeyRotation(report)
+	if len(report.Issues) != 1 || report.Issues[0].ID != "secret-key-rotation-due" || !strings.Contains(report.Issues[0].Description, "45 days old") {
+		t.Errorf("Expected a rotation due issue, got %+v", report.Issues)
+	}
+}
+
+// TestCheckCrashes tests that recent crashes are summarized per agent
+func TestCheckCrashes(t *testing.T) {
+	stateDir := t.TempDir()
+	crashDir := filepath.Join(stateDir, "crashes")
+	if err := os.MkdirAll(crashDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	crashes := []process.Crash{
+		{ID: "coder-1", Name: "coder", ExitCode: 1, ExitedAt: now.Add(-3 * time.Hour)},
+		{ID: "coder-2", Name: "coder", ExitCode: 1, ExitedAt: now.Add(-2 * time.Hour)},
+		{ID: "coder-3", Name: "coder", ExitCode: -1, Signal: "SIGKILL", ExitedAt: now.Add(-time.Hour)},
+		{ID: "reviewer-1", Name: "reviewer", ExitCode: 2, ExitedAt: now.Add(-time.Minute)},
+		{ID: "planner-1", Name: "planner", ExitCode: 1, ExitedAt: now.Add(-48 * time.Hour)},
+	}
+	for _, crash := range crashes {
+		data, _ := json.Marshal(crash)
+		if err := os.WriteFile(filepath.Join(crashDir, crash.ID+".json"), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := &DiagnosticReport{}
+	(&Doctor{stateDir: stateDir}).checkCrashes(report)
+	if len(report.Issues) != 2 {
+		t.Fatalf("Expected issues for the 2 agents that crashed in the last day, got %+v", report.Issues)
+	}
+	coder, reviewer := report.Issues[0], report.Issues[1]
+	if coder.ID != "agent-crashes-coder" || coder.Severity != SeverityMedium ||
+		!strings.Contains(coder.Title, "3 time(s)") || !strings.Contains(coder.Description, "coder-3") ||
+		!strings.Contains(coder.Description, "killed by SIGKILL") || !strings.Contains(coder.Remediation, "asc crashes show coder-3") {
+		t.Errorf("Unexpected issue for coder: %+v", coder)
+	}
+	if reviewer.ID != "agent-crashes-reviewer" || reviewer.Severity != SeverityLow || !strings.Contains(reviewer.Description, "exit status 2") {
+		t.Errorf("Unexpected issue for reviewer: %+v", reviewer)
+	}
+
+	// No crash directory, no issues
+	report = &DiagnosticReport{}
+	(&Doctor{stateDir: t.TempDir()}).checkCrashes(report)
+	if len(report.Issues) != 0 {
+		t.Errorf("Expected no issues without crashes, got %+v", report.Issues)
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	oldVersion := upgrade.Version
+	t.Cleanup(func() { upgrade.Version = oldVersion })
+	stateDir := t.TempDir()
+	if err := upgrade.SaveCheck(filepath.Join(stateDir, "upgrade.json"), &upgrade.Check{Channel: upgrade.ChannelBeta}); err != nil {
+		t.Fatal(err)
+	}
+
+	var asked string
+	doc := &Doctor{stateDir: stateDir, releases: func(ctx context.Context, path, channel string) (*upgrade.Check, error) {
+		asked = channel
+		return &upgrade.Check{Channel: channel, Latest: "v1.3.0-beta.1"}, nil
+	}}
+
+	upgrade.Version = "v1.2.0"
+	report := &DiagnosticReport{}
+	doc.checkVersion(report)
+	if len(report.Issues) != 1 || report.Issues[0].ID != "asc-outdated" {
+		t.Fatalf("Expected an outdated version to be reported, got %+v", report.Issues)
+	}
+	if asked != upgrade.ChannelBeta || !strings.Contains(report.Issues[0].Remediation, "--channel beta") {
+		t.Errorf("Expected the channel asc upgrade last checked, got %q: %+v", asked, report.Issues[0])
+	}
+
+	upgrade.Version = "v1.3.0"
+	report = &DiagnosticReport{}
+	doc.checkVersion(report)
+	if len(report.Issues) != 0 {
+		t.Errorf("Expected no issues for the newest version, got %+v", report.Issues)
+	}
+
+	// A build from source is not checked
+	upgrade.Version = upgrade.DevVersion
+	asked = ""
+	doc.checkVersion(&DiagnosticReport{})
+	if asked != "" {
+		t.Error("Expected no release lookup for a build from source")
+	}
+}
@@ -0,0 +1,278 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// HistoryDirName is the directory of the asc state directory holding the
+// reports of asc doctor --watch and of the daemon's periodic runs
+const HistoryDirName = "doctor-history"
+
+// DefaultHistoryKept is how many reports the history keeps, the oldest
+// removed first
+const DefaultHistoryKept = 500
+
+// historyIDFormat formats the time of a report into its ID
+const historyIDFormat = "20060102T150405.000Z"
+
+// HistoryDir returns the directory of the report history
+func (d *Doctor) HistoryDir() string {
+	return filepath.Join(d.stateDir, HistoryDirName)
+}
+
+// SaveReport saves report to dir as <ID>.json, where its ID is the time it
+// ran, and removes the oldest reports beyond the newest keep. Its recent
+// log records and audit log are not saved; they belong to bug reports.
+func SaveReport(dir string, report *DiagnosticReport, keep int) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create doctor history directory: %w", err)
+	}
+	saved := *report
+	saved.ID = report.RunAt.UTC().Format(historyIDFormat)
+	saved.RecentLogs, saved.AuditLog = nil, nil
+	data, err := json.MarshalIndent(&saved, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, saved.ID+".json"), data, 0600); err != nil {
+		return "", err
+	}
+
+	ids, err := ListHistory(dir)
+	if err != nil {
+		return saved.ID, err
+	}
+	for i := keep; i < len(ids); i++ {
+		if err := os.Remove(filepath.Join(dir, ids[i]+".json")); err != nil && !os.IsNotExist(err) {
+			return saved.ID, err
+		}
+	}
+	return saved.ID, nil
+}
+
+// ListHistory returns the IDs of the reports saved in dir, the newest
+// first. A missing directory holds none.
+func ListHistory(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read doctor history: %w", err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		if _, err := time.Parse(historyIDFormat, id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// LoadReport returns the report with the given ID saved in dir
+func LoadReport(dir, id string) (*DiagnosticReport, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid report ID '%s'", id)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no report '%s'", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var report DiagnosticReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", id, err)
+	}
+	report.ID = id
+	return &report, nil
+}
+
+// NewCritical returns the critical issues of report that previous did not
+// report as critical, or all of them when there is no previous report
+func NewCritical(previous, report *DiagnosticReport) []Issue {
+	known := make(map[string]bool)
+	if previous != nil {
+		for _, issue := range previous.Issues {
+			if issue.Severity == SeverityCritical {
+				known[issue.ID] = true
+			}
+		}
+	}
+	var issues []Issue
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityCritical && !known[issue.ID] {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// Watch runs the diagnostics now and then every interval until ctx is
+// done, saving each report to the history, and calls onReport with it
+// and the critical issues the run before it did not report. The run
+// before the first is the newest of the history, so a restarted watch
+// does not report the same issues again. A report that cannot be saved
+// is logged.
+func (d *Doctor) Watch(ctx context.Context, interval time.Duration, onReport func(report *DiagnosticReport, newCritical []Issue)) error {
+	dir := d.HistoryDir()
+	var previous *DiagnosticReport
+	if ids, err := ListHistory(dir); err == nil && len(ids) > 0 {
+		previous, _ = LoadReport(dir, ids[0])
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := d.RunDiagnostics(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := SaveReport(dir, report, DefaultHistoryKept); err != nil {
+			logger.Warn("Failed to save doctor report: %v", err)
+		}
+		onReport(report, NewCritical(previous, report))
+		previous = report
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReportDiff is how the issues of two reports differ, by issue ID
+type ReportDiff struct {
+	From      string  `json:"from"`      // ID of the earlier report
+	To        string  `json:"to"`        // ID of the later report
+	New       []Issue `json:"new"`       // Issues of To that From did not report
+	Recurring []Issue `json:"recurring"` // Issues both reported, as To reports them
+	Fixed     []Issue `json:"fixed"`     // Issues of From that To did not report
+}
+
+// Diff returns which issues of to are new since from, which both report,
+// and which of from to no longer reports
+func Diff(from, to *DiagnosticReport) *ReportDiff {
+	diff := &ReportDiff{From: from.ID, To: to.ID, New: []Issue{}, Recurring: []Issue{}, Fixed: []Issue{}}
+	before := make(map[string]bool, len(from.Issues))
+	for _, issue := range from.Issues {
+		before[issue.ID] = true
+	}
+	after := make(map[string]bool, len(to.Issues))
+	for _, issue := range to.Issues {
+		after[issue.ID] = true
+		if before[issue.ID] {
+			diff.Recurring = append(diff.Recurring, issue)
+		} else {
+			diff.New = append(diff.New, issue)
+		}
+	}
+	for _, issue := range from.Issues {
+		if !after[issue.ID] {
+			diff.Fixed = append(diff.Fixed, issue)
+		}
+	}
+	return diff
+}
+
+// HistoryEntry summarizes a saved report and how it differs from the
+// report before it
+type HistoryEntry struct {
+	ID            string    `json:"id"`
+	RunAt         time.Time `json:"run_at"`
+	HealthSummary string    `json:"health_summary"`
+	Issues        int       `json:"issues"`
+	Critical      int       `json:"critical"`
+	New           int       `json:"new"`   // Issues the report before did not report; all for the oldest
+	Fixed         int       `json:"fixed"` // Issues of the report before this one no longer reports
+}
+
+// History summarizes the newest limit reports saved in dir, or all of
+// them if limit is 0, the newest first. Reports that cannot be read are
+// skipped.
+func History(dir string, limit int) ([]HistoryEntry, error) {
+	ids, err := ListHistory(dir)
+	if err != nil {
+		return nil, err
+	}
+	// Read one more, the report before the oldest listed
+	if limit > 0 && len(ids) > limit+1 {
+		ids = ids[:limit+1]
+	}
+	var reports []*DiagnosticReport
+	for _, id := range ids {
+		if report, err := LoadReport(dir, id); err == nil {
+			reports = append(reports, report)
+		}
+	}
+
+	entries := []HistoryEntry{}
+	for i, report := range reports {
+		if limit > 0 && i == limit {
+			break
+		}
+		entry := HistoryEntry{
+			ID:            report.ID,
+			RunAt:         report.RunAt,
+			HealthSummary: report.HealthSummary,
+			Issues:        len(report.Issues),
+			New:           len(report.Issues),
+		}
+		for _, issue := range report.Issues {
+			if issue.Severity == SeverityCritical {
+				entry.Critical++
+			}
+		}
+		if i+1 < len(reports) {
+			diff := Diff(reports[i+1], report)
+			entry.New, entry.Fixed = len(diff.New), len(diff.Fixed)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// FindReport returns the report saved in dir whose ID is ref, or the only
+// one whose ID starts with it, such as "20260301T12"
+func FindReport(dir, ref string) (*DiagnosticReport, error) {
+	ids, err := ListHistory(dir)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, id := range ids {
+		if id == ref {
+			return LoadReport(dir, id)
+		}
+		if strings.HasPrefix(id, ref) {
+			matches = append(matches, id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no doctor report '%s'\n  Suggestion: Run 'asc doctor history' to list the saved reports", ref)
+	case 1:
+		return LoadReport(dir, matches[0])
+	default:
+		return nil, fmt.Errorf("'%s' matches %d doctor reports\n  Suggestion: Give more of the report ID, e.g. %s", ref, len(matches), matches[0])
+	}
+}
@@ -0,0 +1,137 @@
+package doctor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+func TestSaveReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), HistoryDirName)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		report := &DiagnosticReport{
+			RunAt:      start.Add(time.Duration(i) * time.Minute),
+			Issues:     []Issue{{ID: "logs-large", Severity: SeverityLow}},
+			RecentLogs: []logger.LogEntry{{Message: "diagnostics"}},
+		}
+		if _, err := SaveReport(dir, report, 2); err != nil {
+			t.Fatalf("SaveReport() error = %v", err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "notes.json"), []byte("{}"), 0600)
+
+	ids, err := ListHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != "20260301T120200.000Z" || ids[1] != "20260301T120100.000Z" {
+		t.Fatalf("Expected the two newest reports, newest first, got %v", ids)
+	}
+
+	report, err := LoadReport(dir, ids[0])
+	if err != nil {
+		t.Fatalf("LoadReport() error = %v", err)
+	}
+	if report.ID != ids[0] || len(report.Issues) != 1 || len(report.RecentLogs) != 0 {
+		t.Errorf("Unexpected saved report %+v", report)
+	}
+	if _, err := LoadReport(dir, "../secrets"); err == nil {
+		t.Error("Expected an ID with a path refused")
+	}
+}
+
+func TestNewCritical(t *testing.T) {
+	previous := &DiagnosticReport{Issues: []Issue{
+		{ID: "config-missing", Severity: SeverityCritical},
+		{ID: "agent-workdir-missing-coder", Severity: SeverityHigh},
+	}}
+	report := &DiagnosticReport{Issues: []Issue{
+		{ID: "config-missing", Severity: SeverityCritical},
+		{ID: "agent-workdir-missing-coder", Severity: SeverityCritical},
+		{ID: "logs-large", Severity: SeverityLow},
+	}}
+
+	issues := NewCritical(previous, report)
+	if len(issues) != 1 || issues[0].ID != "agent-workdir-missing-coder" {
+		t.Errorf("NewCritical() = %+v", issues)
+	}
+	if issues := NewCritical(nil, report); len(issues) != 2 {
+		t.Errorf("Expected every critical issue new without a previous report, got %+v", issues)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	doc, err := NewDoctor(filepath.Join(tmpDir, "asc.toml"), filepath.Join(tmpDir, ".env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.releases, doc.probeProvider = nil, nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var reported [][]Issue
+	err = doc.Watch(ctx, 10*time.Millisecond, func(report *DiagnosticReport, newCritical []Issue) {
+		reported = append(reported, newCritical)
+		if len(reported) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// The missing asc.toml is only new to the first run
+	if len(reported) != 2 || len(reported[0]) == 0 || len(reported[1]) != 0 {
+		t.Errorf("Expected critical issues new only to the first run, got %+v", reported)
+	}
+	if ids, _ := ListHistory(doc.HistoryDir()); len(ids) == 0 {
+		t.Error("Expected the reports saved to the history")
+	}
+}
+
+func TestDiffAndHistory(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	runs := [][]Issue{
+		{{ID: "logs-large", Severity: SeverityLow}, {ID: "network-mcp-unreachable", Severity: SeverityHigh}},
+		{{ID: "logs-large", Severity: SeverityLow}, {ID: "config-missing", Severity: SeverityCritical}},
+		{{ID: "logs-large", Severity: SeverityLow}},
+	}
+	for i, issues := range runs {
+		if _, err := SaveReport(dir, &DiagnosticReport{RunAt: start.Add(time.Duration(i) * time.Hour), Issues: issues}, DefaultHistoryKept); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	from, err := FindReport(dir, "20260301T12")
+	if err != nil {
+		t.Fatalf("FindReport() error = %v", err)
+	}
+	to, err := FindReport(dir, "20260301T13")
+	if err != nil {
+		t.Fatalf("FindReport() error = %v", err)
+	}
+	diff := Diff(from, to)
+	if len(diff.New) != 1 || diff.New[0].ID != "config-missing" || len(diff.Recurring) != 1 || len(diff.Fixed) != 1 || diff.Fixed[0].ID != "network-mcp-unreachable" {
+		t.Errorf("Diff() = %+v", diff)
+	}
+	if _, err := FindReport(dir, "20260301T1"); err == nil || !strings.Contains(err.Error(), "matches 3") {
+		t.Errorf("Expected an ambiguous ID refused, got %v", err)
+	}
+
+	entries, err := History(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Fixed != 1 || entries[0].New != 0 || entries[1].Critical != 1 || entries[1].New != 1 {
+		t.Errorf("History() = %+v", entries)
+	}
+}
@@ -0,0 +1,95 @@
+package doctor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+)
+
+// junitCategories are the categories a JUnit report has a test suite for,
+// whether or not they have issues, so CI counts the checks that passed
+var junitCategories = []IssueCategory{
+	CategoryConfiguration,
+	CategoryState,
+	CategoryPermissions,
+	CategoryResources,
+	CategoryNetwork,
+	CategoryAgent,
+	CategorySecurity,
+	CategoryVersion,
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ToJUnit converts the report to JUnit XML for CI, with a test suite per
+// category and a test case per issue. Issues of info severity pass; the
+// others fail, with their severity as the failure type. A category
+// without issues has a single passing case.
+func (r *DiagnosticReport) ToJUnit() (string, error) {
+	byCategory := make(map[IssueCategory][]Issue)
+	categories := append([]IssueCategory{}, junitCategories...)
+	for _, issue := range r.Issues {
+		if _, ok := byCategory[issue.Category]; !ok && !slices.Contains(junitCategories, issue.Category) {
+			categories = append(categories, issue.Category)
+		}
+		byCategory[issue.Category] = append(byCategory[issue.Category], issue)
+	}
+
+	timestamp := r.RunAt.UTC().Format("2006-01-02T15:04:05")
+	suites := junitTestSuites{Name: "asc doctor"}
+	for _, category := range categories {
+		suite := junitTestSuite{Name: string(category), Timestamp: timestamp}
+		className := "asc.doctor." + string(category)
+		for _, issue := range byCategory[category] {
+			tc := junitTestCase{Name: fmt.Sprintf("%s: %s", issue.ID, issue.Title), ClassName: className}
+			details := fmt.Sprintf("%s\nImpact: %s\nRemediation: %s", issue.Description, issue.Impact, issue.Remediation)
+			if issue.Severity == SeverityInfo {
+				tc.SystemOut = details
+			} else {
+				tc.Failure = &junitFailure{Type: string(issue.Severity), Message: issue.Title, Text: details}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		if len(suite.Cases) == 0 {
+			suite.Cases = append(suite.Cases, junitTestCase{Name: "no issues", ClassName: className})
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data), nil
+}
@@ -0,0 +1,44 @@
+package doctor
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToJUnit(t *testing.T) {
+	report := &DiagnosticReport{RunAt: time.Now(), Issues: []Issue{
+		{ID: "config-missing", Category: CategoryConfiguration, Severity: SeverityCritical, Title: "Configuration file not found", Remediation: "Run 'asc init'"},
+		{ID: "network-proxy-info", Category: CategoryNetwork, Severity: SeverityInfo, Title: "Proxy configuration"},
+		{ID: "plugin-vpn-down", Category: CategoryPlugin, Severity: SeverityHigh, Title: "VPN is down"},
+	}}
+	output, err := report.ToJUnit()
+	if err != nil {
+		t.Fatalf("ToJUnit() error = %v", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal([]byte(output), &suites); err != nil {
+		t.Fatalf("ToJUnit() is not XML: %v\n%s", err, output)
+	}
+	if suites.Failures != 2 || len(suites.Suites) != len(junitCategories)+1 {
+		t.Errorf("Expected 2 failures in %d suites, got %d in %d", len(junitCategories)+1, suites.Failures, len(suites.Suites))
+	}
+	for _, suite := range suites.Suites {
+		switch suite.Name {
+		case "configuration":
+			if suite.Failures != 1 || suite.Cases[0].Failure.Type != "critical" || !strings.Contains(suite.Cases[0].Failure.Text, "asc init") {
+				t.Errorf("Unexpected configuration suite %+v", suite)
+			}
+		case "network":
+			if suite.Failures != 0 || suite.Cases[0].SystemOut == "" {
+				t.Errorf("Expected the info issue passed, got %+v", suite)
+			}
+		case "state":
+			if suite.Tests != 1 || suite.Cases[0].Name != "no issues" {
+				t.Errorf("Expected a passing case for a category without issues, got %+v", suite)
+			}
+		}
+	}
+}
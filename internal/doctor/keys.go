@@ -0,0 +1,153 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/logger"
+)
+
+// keyCheckTimeout bounds the requests verifying the API keys
+const keyCheckTimeout = 10 * time.Second
+
+// SetVerifyKeys sets whether RunDiagnostics verifies the API keys of .env
+// with their providers, which makes an authenticated request to each
+func (d *Doctor) SetVerifyKeys(verify bool) {
+	d.verifyKeys = verify
+}
+
+// verifyKey makes the least request to provider that needs its API key,
+// listing its models, and returns the HTTP status of the response
+func verifyKey(ctx context.Context, provider modelProvider, key string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.KeysURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	provider.Auth(req, key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// envKeys returns the variables of the .env file
+func (d *Doctor) envKeys() (map[string]string, error) {
+	f, err := os.Open(d.envPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	vars, err := config.ParseEnv(f)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]string, len(vars))
+	for _, v := range vars {
+		name, value, _ := strings.Cut(v, "=")
+		keys[name] = value
+	}
+	return keys, nil
+}
+
+// checkKeys verifies the API keys of .env with their providers, at the
+// same time, when SetVerifyKeys asked for it. Keys that are missing are
+// reported by checkConfiguration. The keys themselves are never reported.
+func (d *Doctor) checkKeys(ctx context.Context, report *DiagnosticReport) {
+	if !d.verifyKeys || d.verifyKey == nil {
+		return
+	}
+	keys, err := d.envKeys()
+	if err != nil {
+		logger.Debug("Not verifying API keys: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, keyCheckTimeout)
+	defer cancel()
+	statuses := make([]int, len(modelProviders))
+	errs := make([]error, len(modelProviders))
+	var wg sync.WaitGroup
+	for i, provider := range modelProviders {
+		if keys[provider.KeyEnv] == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i], errs[i] = d.verifyKey(ctx, provider, keys[provider.KeyEnv])
+		}()
+	}
+	wg.Wait()
+
+	var verified []string
+	for i, provider := range modelProviders {
+		status, err := statuses[i], errs[i]
+		switch {
+		case keys[provider.KeyEnv] == "":
+		case err != nil:
+			report.Issues = append(report.Issues, Issue{
+				ID:          "api-key-unverified-" + provider.ID,
+				Category:    CategoryConfiguration,
+				Severity:    SeverityMedium,
+				Title:       fmt.Sprintf("%s could not be verified", provider.KeyEnv),
+				Description: fmt.Sprintf("Requesting %s failed: %v", provider.KeysURL, err),
+				Impact:      "Whether the key is valid is not known",
+				Remediation: providerRemediation(provider.Endpoint, err),
+				AutoFixable: false,
+				DetectedAt:  time.Now(),
+			})
+		case status == http.StatusBadRequest || status == http.StatusUnauthorized || status == http.StatusForbidden:
+			// Google answers a key it does not know with 400
+			report.Issues = append(report.Issues, Issue{
+				ID:          "api-key-invalid-" + provider.ID,
+				Category:    CategoryConfiguration,
+				Severity:    SeverityCritical,
+				Title:       fmt.Sprintf("%s rejected %s", provider.Name, provider.KeyEnv),
+				Description: fmt.Sprintf("%s answered %d %s to a request with the key of %s", provider.Name, status, http.StatusText(status), d.envPath),
+				Impact:      fmt.Sprintf("Agents using %s models fail to authenticate", provider.Name),
+				Remediation: fmt.Sprintf("The key is wrong, expired, or revoked; create a new %s API key and replace %s in %s", provider.Name, provider.KeyEnv, d.envPath),
+				AutoFixable: false,
+				DetectedAt:  time.Now(),
+			})
+		case status >= 500:
+			report.Issues = append(report.Issues, Issue{
+				ID:          "api-key-unverified-" + provider.ID,
+				Category:    CategoryConfiguration,
+				Severity:    SeverityLow,
+				Title:       fmt.Sprintf("%s could not be verified", provider.KeyEnv),
+				Description: fmt.Sprintf("%s answered %d %s", provider.Name, status, http.StatusText(status)),
+				Impact:      "Whether the key is valid is not known",
+				Remediation: "Run 'asc doctor --verify-keys' again later",
+				AutoFixable: false,
+				DetectedAt:  time.Now(),
+			})
+		default:
+			// A key that is rate limited was still accepted
+			verified = append(verified, provider.KeyEnv)
+		}
+	}
+	if len(verified) == 0 {
+		return
+	}
+	sort.Strings(verified)
+	report.Issues = append(report.Issues, Issue{
+		ID:          "api-keys-verified",
+		Category:    CategoryConfiguration,
+		Severity:    SeverityInfo,
+		Title:       "API keys verified",
+		Description: fmt.Sprintf("The providers accepted %s", strings.Join(verified, ", ")),
+		Impact:      "None",
+		Remediation: "None needed",
+		AutoFixable: false,
+		DetectedAt:  time.Now(),
+	})
+}
@@ -0,0 +1,80 @@
+package doctor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCheckKeys(t *testing.T) {
+	envPath := filepath.Join(t.TempDir(), ".env")
+	env := "CLAUDE_API_KEY=sk-ant-expired\nOPENAI_API_KEY=sk-valid\n"
+	if err := os.WriteFile(envPath, []byte(env), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	verified := make(map[string]string)
+	doc := &Doctor{envPath: envPath, verifyKey: func(ctx context.Context, provider modelProvider, key string) (int, error) {
+		mu.Lock()
+		verified[provider.KeyEnv] = key
+		mu.Unlock()
+		if provider.ID == "anthropic" {
+			return http.StatusUnauthorized, nil
+		}
+		return http.StatusOK, nil
+	}}
+
+	report := &DiagnosticReport{}
+	doc.checkKeys(context.Background(), report)
+	if len(report.Issues) != 0 || len(verified) != 0 {
+		t.Fatalf("Expected keys verified only with SetVerifyKeys, got %+v", report.Issues)
+	}
+
+	doc.SetVerifyKeys(true)
+	doc.checkKeys(context.Background(), report)
+	if len(verified) != 2 || verified["CLAUDE_API_KEY"] != "sk-ant-expired" {
+		t.Errorf("Expected the two keys of .env verified, got %v", verified)
+	}
+	issues := make(map[string]Issue)
+	for _, issue := range report.Issues {
+		issues[issue.ID] = issue
+		if strings.Contains(issue.Title+issue.Description+issue.Remediation, "sk-") {
+			t.Errorf("Issue %s reports the key: %+v", issue.ID, issue)
+		}
+	}
+	if issue, ok := issues["api-key-invalid-anthropic"]; !ok || issue.Severity != SeverityCritical || !strings.Contains(issue.Title, "CLAUDE_API_KEY") {
+		t.Errorf("Expected the Anthropic key reported invalid, got %+v", report.Issues)
+	}
+	if issue, ok := issues["api-keys-verified"]; !ok || !strings.Contains(issue.Description, "OPENAI_API_KEY") {
+		t.Errorf("Expected the OpenAI key reported verified, got %+v", report.Issues)
+	}
+	if _, ok := issues["api-key-invalid-google"]; ok {
+		t.Error("Expected the missing Google key not verified")
+	}
+}
+
+func TestVerifyKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "good" || r.Header.Get("anthropic-version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	provider := modelProviders[0]
+	provider.KeysURL = server.URL + "/v1/models"
+	for key, want := range map[string]int{"good": http.StatusOK, "bad": http.StatusUnauthorized} {
+		status, err := verifyKey(context.Background(), provider, key)
+		if err != nil || status != want {
+			t.Errorf("verifyKey(%s) = %d, %v, want %d", key, status, err, want)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/process"
+	"github.com/spf13/viper"
+)
+
+// dialTimeout bounds connecting to an MCP server or a configured port
+const dialTimeout = 2 * time.Second
+
+// providerCheckTimeout bounds the HTTPS requests to the model providers
+const providerCheckTimeout = 5 * time.Second
+
+// modelProvider is a provider of models whose API agents reach over HTTPS
+type modelProvider struct {
+	ID       string // Used in issue IDs
+	Name     string
+	Endpoint string
+	Models   []string // Values of an agent's model served by the provider
+
+	KeyEnv  string                              // Variable of .env holding the API key
+	KeysURL string                              // Endpoint listing the models, requested to verify the key
+	Auth    func(req *http.Request, key string) // Authenticates a request with the key
+}
+
+// modelProviders are the providers of the models agents may use
+var modelProviders = []modelProvider{
+	{
+		ID: "anthropic", Name: "Anthropic", Endpoint: "https://api.anthropic.com", Models: []string{"claude"},
+		KeyEnv: "CLAUDE_API_KEY", KeysURL: "https://api.anthropic.com/v1/models",
+		Auth: func(req *http.Request, key string) {
+			req.Header.Set("x-api-key", key)
+			req.Header.Set("anthropic-version", "2023-06-01")
+		},
+	},
+	{
+		ID: "google", Name: "Google", Endpoint: "https://generativelanguage.googleapis.com", Models: []string{"gemini"},
+		KeyEnv: "GOOGLE_API_KEY", KeysURL: "https://generativelanguage.googleapis.com/v1beta/models",
+		Auth: func(req *http.Request, key string) { req.Header.Set("x-goog-api-key", key) },
+	},
+	{
+		ID: "openai", Name: "OpenAI", Endpoint: "https://api.openai.com", Models: []string{"gpt-4", "codex", "openai"},
+		KeyEnv: "OPENAI_API_KEY", KeysURL: "https://api.openai.com/v1/models",
+		Auth: func(req *http.Request, key string) { req.Header.Set("Authorization", "Bearer "+key) },
+	},
+}
+
+// probeHTTPS requests endpoint through the proxy of the environment, if
+// any. Any response, whatever its status, shows the endpoint is reachable.
+func probeHTTPS(ctx context.Context, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// dialAddress connects to a "host:port" address and closes the connection
+func dialAddress(address string) error {
+	conn, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// urlAddress returns the "host:port" address of a URL, with the default
+// port of its scheme if it has none
+func urlAddress(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid URL %q", rawURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" || u.Scheme == "wss" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// isLocalHost reports whether host is this machine
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// ascRunning reports whether the process asc started under name, as its
+// PID file records, is still running
+func (d *Doctor) ascRunning(name string) bool {
+	data, err := os.ReadFile(filepath.Join(d.stateDir, "pids", name+".json"))
+	if err != nil {
+		return false
+	}
+	var info process.ProcessInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return false
+	}
+	return isProcessRunning(info.PID) && process.SameProcess(&info)
+}
+
+// checkMCPServer connects to the MCP server of the [services.<name>]
+// section. A local server asc starts is only connected to while asc runs
+// it; until then checkPorts reports another process holding its port.
+func (d *Doctor) checkMCPServer(v *viper.Viper, name, rawURL string, report *DiagnosticReport) {
+	address, err := urlAddress(rawURL)
+	if err != nil {
+		// Invalid URLs are reported by checkConfiguration
+		return
+	}
+	host, port, _ := net.SplitHostPort(address)
+	managed := v.GetString("services."+name+".start_command") != ""
+	if managed && isLocalHost(host) && !d.ascRunning(name) {
+		return
+	}
+	err = dialAddress(address)
+	if err == nil {
+		return
+	}
+
+	id := "network-mcp-unreachable"
+	if name != "mcp_agent_mail" {
+		id += "-" + name
+	}
+	remediation := fmt.Sprintf("Start the server at %s, or correct services.%s.url in asc.toml; check that no firewall or VPN blocks port %s", host, name, port)
+	if managed {
+		remediation = fmt.Sprintf("asc started the server but it does not accept connections; check %s and restart the stack: asc down && asc up",
+			filepath.Join(d.stateDir, "logs", name+".log"))
+	}
+	report.Issues = append(report.Issues, Issue{
+		ID:          id,
+		Category:    CategoryNetwork,
+		Severity:    SeverityHigh,
+		Title:       fmt.Sprintf("MCP server %s is unreachable", name),
+		Description: fmt.Sprintf("Connecting to %s (%s) failed: %v", address, rawURL, err),
+		Impact:      "Agents cannot send or receive messages, and asc up fails waiting for the server",
+		Remediation: remediation,
+		AutoFixable: false,
+		DetectedAt:  time.Now(),
+	})
+}
+
+// configuredPort is a port on this machine a process asc starts listens on
+type configuredPort struct {
+	Port  int
+	Owner string // Name of the process
+	Key   string // Key of asc.toml setting it
+}
+
+// configuredPorts returns the ports on this machine that the MCP servers
+// asc starts, and the agents with a ready_check port, listen on
+func configuredPorts(v *viper.Viper, servers []string) []configuredPort {
+	var ports []configuredPort
+	for _, name := range servers {
+		key := "services." + name
+		if v.GetString(key+".start_command") == "" {
+			continue
+		}
+		if address, err := urlAddress(v.GetString(key + ".url")); err == nil {
+			host, port, _ := net.SplitHostPort(address)
+			if n, err := strconv.Atoi(port); err == nil && isLocalHost(host) {
+				ports = append(ports, configuredPort{Port: n, Owner: name, Key: key + ".url"})
+			}
+		}
+		if port := v.GetInt(key + ".ready_check.port"); port != 0 {
+			ports = append(ports, configuredPort{Port: port, Owner: name, Key: key + ".ready_check.port"})
+		}
+	}
+
+	agents := make([]string, 0, len(v.GetStringMap("agent")))
+	for name := range v.GetStringMap("agent") {
+		agents = append(agents, name)
+	}
+	sort.Strings(agents)
+	for _, name := range agents {
+		key := "agent." + name + ".ready_check.port"
+		if port := v.GetInt(key); port != 0 {
+			ports = append(ports, configuredPort{Port: port, Owner: name, Key: key})
+		}
+	}
+	return ports
+}
+
+// checkPorts reports the configured ports another process already
+// listens on while the process asc starts on them is not running
+func (d *Doctor) checkPorts(v *viper.Viper, servers []string, report *DiagnosticReport) {
+	seen := make(map[int]bool)
+	for _, p := range configuredPorts(v, servers) {
+		if seen[p.Port] || d.ascRunning(p.Owner) {
+			continue
+		}
+		seen[p.Port] = true
+		if dialAddress(net.JoinHostPort("localhost", strconv.Itoa(p.Port))) != nil {
+			continue
+		}
+		report.Issues = append(report.Issues, Issue{
+			ID:          fmt.Sprintf("network-port-in-use-%d", p.Port),
+			Category:    CategoryNetwork,
+			Severity:    SeverityHigh,
+			Title:       fmt.Sprintf("Port %d is already in use", p.Port),
+			Description: fmt.Sprintf("Port %d (%s) accepts connections, but asc is not running %s", p.Port, p.Key, p.Owner),
+			Impact:      fmt.Sprintf("%s cannot listen on the port, and asc up may take the other process for it", p.Owner),
+			Remediation: fmt.Sprintf("Find the process with 'lsof -i :%d' and stop it, or change %s in asc.toml", p.Port, p.Key),
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+	}
+}
+
+// checkProviders requests the API endpoint of each model provider the
+// agents use, at the same time, and reports those that cannot be reached
+func (d *Doctor) checkProviders(v *viper.Viper, report *DiagnosticReport) {
+	if d.probeProvider == nil {
+		return
+	}
+	users := make(map[string][]string) // Agents of each provider, by ID
+	for name := range v.GetStringMap("agent") {
+		model := strings.ToLower(v.GetString("agent." + name + ".model"))
+		for _, provider := range modelProviders {
+			for _, m := range provider.Models {
+				if m == model {
+					users[provider.ID] = append(users[provider.ID], name)
+				}
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerCheckTimeout)
+	defer cancel()
+	errs := make([]error, len(modelProviders))
+	var wg sync.WaitGroup
+	for i, provider := range modelProviders {
+		if len(users[provider.ID]) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.probeProvider(ctx, provider.Endpoint)
+		}()
+	}
+	wg.Wait()
+
+	for i, provider := range modelProviders {
+		if errs[i] == nil {
+			continue
+		}
+		agents := users[provider.ID]
+		sort.Strings(agents)
+		report.Issues = append(report.Issues, Issue{
+			ID:          "network-provider-unreachable-" + provider.ID,
+			Category:    CategoryNetwork,
+			Severity:    SeverityHigh,
+			Title:       fmt.Sprintf("%s API is unreachable", provider.Name),
+			Description: fmt.Sprintf("HTTPS request to %s failed: %v", provider.Endpoint, errs[i]),
+			Impact:      fmt.Sprintf("Agents %s cannot reach their model", strings.Join(agents, ", ")),
+			Remediation: providerRemediation(provider.Endpoint, errs[i]),
+			AutoFixable: false,
+			DetectedAt:  time.Now(),
+		})
+	}
+}
+
+// providerRemediation returns what to do about an HTTPS request to a
+// provider's endpoint that failed with err
+func providerRemediation(endpoint string, err error) string {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return fmt.Sprintf("The host of %s does not resolve; check DNS, or set HTTPS_PROXY if this network reaches the internet only through a proxy", endpoint)
+	case errors.As(err, &certErr), errors.As(err, &authorityErr):
+		return fmt.Sprintf("The certificate of %s is not trusted, as happens behind a proxy that inspects TLS; add the proxy's CA to the system trust store or point SSL_CERT_FILE at it", endpoint)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Sprintf("The request to %s timed out; check that a firewall allows outbound port 443, or set HTTPS_PROXY", endpoint)
+	default:
+		return fmt.Sprintf("Check outbound HTTPS with: curl -I %s", endpoint)
+	}
+}
@@ -0,0 +1,120 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rand/asc/internal/check"
+)
+
+// freePort returns a port on localhost that nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+func TestCheckNetwork_Reachability(t *testing.T) {
+	held, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Close()
+	heldPort := held.Addr().(*net.TCPAddr).Port
+	closedPort := freePort(t)
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "asc.toml")
+	config := fmt.Sprintf(`[services.mcp_agent_mail]
+url = "http://127.0.0.1:%d"
+
+[services.mcp_tools]
+start_command = "tools-server"
+url = "http://localhost:%d"
+
+[agent.coder]
+command = "echo"
+model = "claude"
+
+[agent.tester]
+command = "echo"
+model = "gemini"
+`, closedPort, heldPort)
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var probed []string
+	envPath := filepath.Join(tmpDir, ".env")
+	doc := &Doctor{
+		configPath: configPath,
+		envPath:    envPath,
+		checker:    check.NewChecker(configPath, envPath),
+		stateDir:   filepath.Join(tmpDir, ".asc"),
+		probeProvider: func(ctx context.Context, endpoint string) error {
+			mu.Lock()
+			probed = append(probed, endpoint)
+			mu.Unlock()
+			if strings.Contains(endpoint, "anthropic") {
+				return &net.DNSError{Err: "no such host", Name: "api.anthropic.com", IsNotFound: true}
+			}
+			return nil
+		},
+	}
+	report := &DiagnosticReport{}
+	doc.checkNetwork(report)
+
+	issues := make(map[string]Issue)
+	for _, issue := range report.Issues {
+		issues[issue.ID] = issue
+	}
+	if issue, ok := issues["network-mcp-unreachable"]; !ok || issue.Severity != SeverityHigh {
+		t.Errorf("Expected mcp_agent_mail reported unreachable, got %+v", report.Issues)
+	}
+	// asc starts mcp_tools, so only the process holding its port is reported
+	if _, ok := issues["network-mcp-unreachable-mcp_tools"]; ok {
+		t.Error("Expected mcp_tools, which is not running, not connected to")
+	}
+	if issue, ok := issues[fmt.Sprintf("network-port-in-use-%d", heldPort)]; !ok || !strings.Contains(issue.Remediation, "services.mcp_tools.url") {
+		t.Errorf("Expected port %d reported in use, got %+v", heldPort, report.Issues)
+	}
+	if issue, ok := issues["network-provider-unreachable-anthropic"]; !ok || !strings.Contains(issue.Remediation, "DNS") || !strings.Contains(issue.Impact, "coder") {
+		t.Errorf("Expected Anthropic reported unreachable, got %+v", report.Issues)
+	}
+	if _, ok := issues["network-provider-unreachable-google"]; ok {
+		t.Error("Expected Google reported reachable")
+	}
+	if len(probed) != 2 {
+		t.Errorf("Expected only the providers of the agents' models probed, got %v", probed)
+	}
+}
+
+func TestProviderRemediation(t *testing.T) {
+	timeout := &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&net.DNSError{Err: "no such host", Name: "example.com"}, "DNS"},
+		{fmt.Errorf("Head: %w", timeout), "firewall"},
+		{errors.New("connection reset"), "curl -I"},
+	}
+	for _, tt := range tests {
+		if got := providerRemediation("https://example.com", tt.err); !strings.Contains(got, tt.want) {
+			t.Errorf("providerRemediation(%v) = %q, want it to mention %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+	"github.com/spf13/viper"
+)
+
+// PluginDirName is the directory of the asc state directory holding check
+// plugins: executables asc doctor runs as checks of its own
+const PluginDirName = "checks"
+
+// defaultPluginTimeout is how long a check plugin may run by default
+const defaultPluginTimeout = 30 * time.Second
+
+// pluginWaitDelay bounds how long the output of a plugin that timed out is
+// drained, since processes it started may hold it open
+const pluginWaitDelay = time.Second
+
+// CategoryPlugin is the category of issues a check plugin does not
+// categorize
+const CategoryPlugin IssueCategory = "plugin"
+
+// Plugin is a check asc doctor runs besides its own: an executable of
+// ~/.asc/checks, or a [doctor.checks.<name>] command of asc.toml. It prints
+// the issues it finds as JSON on its standard output (see PluginIssue),
+// either as an array or as an object with an "issues" array, and nothing
+// or an empty array if it finds none.
+type Plugin struct {
+	Name    string
+	Path    string        // Executable of ~/.asc/checks
+	Command string        // Shell command of asc.toml, run instead of Path
+	Timeout time.Duration // How long it may run; 0 for defaultPluginTimeout
+}
+
+// PluginIssue is an issue as a check plugin reports it. Its ID is made
+// unique by the name of the plugin, as plugin-<plugin>-<id>.
+type PluginIssue struct {
+	ID          string `json:"id"`
+	Category    string `json:"category,omitempty"` // One of the IssueCategory values (default: "plugin")
+	Severity    string `json:"severity,omitempty"` // One of the IssueSeverity values (default: "medium")
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Impact      string `json:"impact,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+	Fix         string `json:"fix,omitempty"` // Shell command asc doctor --fix runs to fix it
+}
+
+// pluginIssueID matches the IDs a check plugin may give its issues
+var pluginIssueID = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Plugins returns the check plugins of asc.toml, sorted by name, then the
+// executables of the plugin directory not named the same. Files that are
+// hidden or not executable are skipped.
+func (d *Doctor) Plugins() ([]Plugin, error) {
+	var plugins []Plugin
+	names := make(map[string]bool)
+
+	v := viper.New()
+	v.SetConfigFile(d.configPath)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err == nil {
+		var checks map[string]struct {
+			Command string
+			Timeout time.Duration
+		}
+		if err := v.UnmarshalKey("doctor.checks", &checks); err != nil {
+			return nil, fmt.Errorf("doctor.checks: %w", err)
+		}
+		for name, check := range checks {
+			plugins = append(plugins, Plugin{Name: name, Command: check.Command, Timeout: check.Timeout})
+			names[name] = true
+		}
+		sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	}
+
+	entries, err := os.ReadDir(filepath.Join(d.stateDir, PluginDirName))
+	if errors.Is(err, os.ErrNotExist) {
+		return plugins, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || names[name] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		plugins = append(plugins, Plugin{Name: name, Path: filepath.Join(d.stateDir, PluginDirName, entry.Name())})
+		names[name] = true
+	}
+	return plugins, nil
+}
+
+// checkPlugins runs the check plugins and adds the issues they report. A
+// plugin that fails, or prints what is not its issues, is reported as an
+// issue itself.
+func (d *Doctor) checkPlugins(ctx context.Context, report *DiagnosticReport) {
+	plugins, err := d.Plugins()
+	if err != nil {
+		report.Issues = append(report.Issues, pluginFailure("plugins", fmt.Sprintf("Check plugins could not be listed: %v", err)))
+		return
+	}
+	for _, plugin := range plugins {
+		if ctx.Err() != nil {
+			return
+		}
+		issues, err := d.runPlugin(ctx, plugin)
+		if err != nil {
+			logger.Warn("Check plugin %s failed: %v", plugin.Name, err)
+			report.Issues = append(report.Issues, pluginFailure(plugin.Name, err.Error()))
+			continue
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+}
+
+// runPlugin runs a check plugin and returns the issues it reports
+func (d *Doctor) runPlugin(ctx context.Context, plugin Plugin) ([]Issue, error) {
+	timeout := plugin.Timeout
+	if timeout == 0 {
+		timeout = defaultPluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := d.pluginCommand(ctx, plugin.Path, plugin.Command)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("did not finish within %s", timeout)
+	}
+
+	// The exit status of a plugin that reports its issues does not matter
+	reported, err := parsePluginOutput(stdout.Bytes())
+	if err != nil || (runErr != nil && len(reported) == 0) {
+		if runErr == nil {
+			return nil, err
+		}
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return nil, fmt.Errorf("%v: %s", runErr, firstLine(detail))
+		}
+		return nil, runErr
+	}
+
+	issues := make([]Issue, 0, len(reported))
+	for i, pi := range reported {
+		issue, err := pi.issue(plugin.Name)
+		if err != nil {
+			return nil, fmt.Errorf("issue %d: %w", i+1, err)
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// pluginCommand returns the command running a check plugin, or a fix,
+// from the directory of asc.toml, with the paths of asc in its environment
+func (d *Doctor) pluginCommand(ctx context.Context, path, command string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if command != "" {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	} else {
+		cmd = exec.CommandContext(ctx, path)
+	}
+	cmd.Dir = d.projectDir()
+	cmd.WaitDelay = pluginWaitDelay
+	configPath, _ := filepath.Abs(d.configPath)
+	envPath, _ := filepath.Abs(d.envPath)
+	cmd.Env = append(os.Environ(),
+		"ASC_CONFIG="+configPath,
+		"ASC_ENV_FILE="+envPath,
+		"ASC_STATE_DIR="+d.stateDir,
+	)
+	return cmd
+}
+
+// parsePluginOutput parses the issues a check plugin prints
+func parsePluginOutput(output []byte) ([]PluginIssue, error) {
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return nil, nil
+	}
+	var issues []PluginIssue
+	if output[0] == '{' {
+		var wrapped struct {
+			Issues []PluginIssue `json:"issues"`
+		}
+		if err := json.Unmarshal(output, &wrapped); err != nil {
+			return nil, fmt.Errorf("output is not JSON issues: %w", err)
+		}
+		return wrapped.Issues, nil
+	}
+	if err := json.Unmarshal(output, &issues); err != nil {
+		return nil, fmt.Errorf("output is not JSON issues: %w", err)
+	}
+	return issues, nil
+}
+
+// issue returns the Issue a plugin of the given name reports
+func (pi PluginIssue) issue(plugin string) (Issue, error) {
+	if !pluginIssueID.MatchString(pi.ID) {
+		return Issue{}, fmt.Errorf("id %q must be lower-case letters, digits, and - _ .", pi.ID)
+	}
+	if pi.Title == "" {
+		return Issue{}, fmt.Errorf("%s has no title", pi.ID)
+	}
+	severity := SeverityMedium
+	if pi.Severity != "" {
+		severity = IssueSeverity(strings.ToLower(pi.Severity))
+		switch severity {
+		case SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo:
+		default:
+			return Issue{}, fmt.Errorf("%s has unknown severity %q", pi.ID, pi.Severity)
+		}
+	}
+	category := CategoryPlugin
+	if pi.Category != "" {
+		category = IssueCategory(strings.ToLower(pi.Category))
+	}
+	remediation := pi.Remediation
+	if remediation == "" && pi.Fix != "" {
+		remediation = fmt.Sprintf("Run 'asc doctor --fix', which runs: %s", pi.Fix)
+	}
+	return Issue{
+		ID:          fmt.Sprintf("plugin-%s-%s", plugin, pi.ID),
+		Category:    category,
+		Severity:    severity,
+		Title:       pi.Title,
+		Description: pi.Description,
+		Impact:      pi.Impact,
+		Remediation: remediation,
+		AutoFixable: pi.Fix != "",
+		FixCommand:  pi.Fix,
+		DetectedAt:  time.Now(),
+	}, nil
+}
+
+// pluginFailure returns the issue reporting a check plugin that failed
+func pluginFailure(plugin, message string) Issue {
+	return Issue{
+		ID:          fmt.Sprintf("plugin-failed-%s", plugin),
+		Category:    CategoryPlugin,
+		Severity:    SeverityMedium,
+		Title:       fmt.Sprintf("Check plugin '%s' failed", plugin),
+		Description: message,
+		Impact:      "What the plugin checks is not known",
+		Remediation: fmt.Sprintf("Fix the plugin in ~/.asc/%s or [doctor.checks.%s] of asc.toml; it must print its issues as JSON", PluginDirName, plugin),
+		AutoFixable: false,
+		DetectedAt:  time.Now(),
+	}
+}
+
+// fixWithCommand runs the fix command a check plugin gave an issue
+func (d *Doctor) fixWithCommand(ctx context.Context, command string) (bool, string) {
+	ctx, cancel := context.WithTimeout(ctx, defaultPluginTimeout)
+	defer cancel()
+	output, err := d.pluginCommand(ctx, "", command).CombinedOutput()
+	if err != nil {
+		if detail := strings.TrimSpace(string(output)); detail != "" {
+			return false, fmt.Sprintf("'%s' failed: %v: %s", command, err, firstLine(detail))
+		}
+		return false, fmt.Sprintf("'%s' failed: %v", command, err)
+	}
+	return true, fmt.Sprintf("Ran '%s'", command)
+}
+
+// firstLine returns s up to its first newline
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+//go:build !windows
+
+package doctor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckPlugins(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ASC_STATE_DIR", "")
+	project := t.TempDir()
+
+	pluginDir := filepath.Join(home, ".asc", PluginDirName)
+	os.MkdirAll(pluginDir, 0755)
+	plugins := map[string]string{
+		"vpn.sh": `#!/bin/sh
+test -f "$ASC_CONFIG" || exit 3
+echo '[{"id": "vpn-down", "severity": "critical", "category": "network", "title": "VPN is not connected", "fix": "touch vpn-up"}]'`,
+		"broken.sh":  "#!/bin/sh\necho 'not json'\n",
+		"quiet.sh":   "#!/bin/sh\nexit 0\n",
+		"license.sh": "#!/bin/sh\necho 'from the directory, not asc.toml'\n",
+		"notes.txt":  "not executable",
+	}
+	for name, content := range plugins {
+		mode := os.FileMode(0755)
+		if strings.HasSuffix(name, ".txt") {
+			mode = 0644
+		}
+		if err := os.WriteFile(filepath.Join(pluginDir, name), []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configPath := filepath.Join(project, "asc.toml")
+	configContent := `[doctor.checks.license]
+command = "echo '{\"issues\": [{\"id\": \"expired\", \"title\": \"License expires soon\", \"severity\": \"low\"}]}'"
+
+[doctor.checks.slow]
+command = "sleep 5"
+timeout = "100ms"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := NewDoctor(configPath, filepath.Join(project, ".env"))
+	if err != nil {
+		t.Fatalf("Failed to create doctor: %v", err)
+	}
+	listed, err := doc.Plugins()
+	if err != nil {
+		t.Fatalf("Plugins() error = %v", err)
+	}
+	var names []string
+	for _, plugin := range listed {
+		names = append(names, plugin.Name)
+	}
+	if strings.Join(names, ",") != "license,slow,broken,quiet,vpn" {
+		t.Errorf("Plugins() = %v", names)
+	}
+
+	report := &DiagnosticReport{RunAt: time.Now(), Issues: []Issue{}}
+	doc.checkPlugins(context.Background(), report)
+	found := make(map[string]Issue)
+	for _, issue := range report.Issues {
+		found[issue.ID] = issue
+	}
+	vpn, ok := found["plugin-vpn-vpn-down"]
+	if !ok || vpn.Severity != SeverityCritical || vpn.Category != CategoryNetwork || !vpn.AutoFixable || vpn.FixCommand != "touch vpn-up" {
+		t.Errorf("Expected the issue of vpn, got %+v", report.Issues)
+	}
+	if issue, ok := found["plugin-license-expired"]; !ok || issue.Severity != SeverityLow || issue.Category != CategoryPlugin || issue.AutoFixable {
+		t.Errorf("Expected the issue of license from asc.toml, got %+v", report.Issues)
+	}
+	if issue, ok := found["plugin-failed-broken"]; !ok || !strings.Contains(issue.Description, "not JSON issues") {
+		t.Errorf("Expected broken reported as failed, got %+v", report.Issues)
+	}
+	if issue, ok := found["plugin-failed-slow"]; !ok || !strings.Contains(issue.Description, "did not finish within 100ms") {
+		t.Errorf("Expected slow reported as timed out, got %+v", report.Issues)
+	}
+	if len(report.Issues) != 4 {
+		t.Errorf("Expected 4 issues, got %d: %+v", len(report.Issues), report.Issues)
+	}
+
+	results, err := doc.ApplyFixes(context.Background(), &DiagnosticReport{Issues: []Issue{vpn}})
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("ApplyFixes() = %+v, %v", results, err)
+	}
+	if _, err := os.Stat(filepath.Join(project, "vpn-up")); err != nil {
+		t.Errorf("Expected the fix run in the project directory: %v", err)
+	}
+}
+
+func TestPluginIssue_Invalid(t *testing.T) {
+	for _, pi := range []PluginIssue{
+		{ID: "", Title: "No id"},
+		{ID: "Bad ID", Title: "Spaces"},
+		{ID: "untitled"},
+		{ID: "loud", Title: "Loud", Severity: "urgent"},
+	} {
+		if _, err := pi.issue("test"); err == nil {
+			t.Errorf("Expected %+v to be refused", pi)
+		}
+	}
+}
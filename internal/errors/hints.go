@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// suggestionPrefix starts the suggestion line of validation errors, such
+// as those of config.Load
+const suggestionPrefix = "Suggestion:"
+
+// hintedError attaches a hint to an error without changing its message,
+// so callers matching on error text or types are unaffected
+type hintedError struct {
+	err  error
+	hint string
+}
+
+func (e *hintedError) Error() string { return e.err.Error() }
+
+func (e *hintedError) Unwrap() error { return e.err }
+
+// WithHint attaches a hint telling the user how to fix err, such as the
+// command to run or the setting to check. The message of err is kept. It
+// returns nil if err is nil.
+func WithHint(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+	return &hintedError{err: err, hint: hint}
+}
+
+// Hint returns how to fix err, or an empty string if nothing is known.
+// It is, in order: the outermost hint attached with WithHint, the
+// solution of an ASCError, the suggestion line of a validation error, or
+// a hint for the underlying system error, such as a refused connection
+// or a missing command.
+func Hint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var hinted *hintedError
+	if stderrors.As(err, &hinted) {
+		return hinted.hint
+	}
+
+	var ascErr *ASCError
+	if stderrors.As(err, &ascErr) && ascErr.Solution != "" {
+		return ascErr.Solution
+	}
+
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, suggestionPrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, suggestionPrefix))
+		}
+	}
+
+	return systemHint(err)
+}
+
+// systemHint returns a generic hint for common causes of failure
+func systemHint(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case stderrors.Is(err, exec.ErrNotFound):
+		return "Install the missing command and make sure it is in your PATH. Run 'asc check' to see what is missing"
+	case stderrors.Is(err, syscall.ECONNREFUSED):
+		return "Nothing is listening at that address. Check that the service is running with 'asc services status'"
+	case stderrors.As(err, &dnsErr):
+		return "Check the host name in asc.toml and your network connection"
+	case stderrors.Is(err, context.DeadlineExceeded), stderrors.As(err, &netErr) && netErr.Timeout():
+		return "The service did not respond in time. Check that it is running and not overloaded"
+	case stderrors.Is(err, os.ErrPermission):
+		return "Check the permissions of the file or directory. 'asc doctor --fix' repairs asc's own files"
+	}
+	return ""
+}
+
+// Summary returns the message of err without its hint: the message of an
+// ASCError, or the error text with any suggestion line removed
+func Summary(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var ascErr *ASCError
+	if stderrors.As(err, &ascErr) && err == error(ascErr) {
+		return ascErr.Message
+	}
+
+	var lines []string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), suggestionPrefix) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// From returns err as an ASCError, with the hint of err as its solution.
+// An ASCError is returned as is.
+func From(err error) *ASCError {
+	if err == nil {
+		return nil
+	}
+	if ascErr, ok := err.(*ASCError); ok {
+		return ascErr
+	}
+	return &ASCError{
+		Message:  Summary(err),
+		Solution: Hint(err),
+	}
+}
+
+// FormatCLI formats any error for CLI output, showing what went wrong and
+// how to fix it rather than the raw error chain
+func FormatCLI(err error) string {
+	if err == nil {
+		return ""
+	}
+	return From(err).FormatCLI()
+}
+
+// FormatTUI formats any error for the TUI
+func FormatTUI(err error) string {
+	if err == nil {
+		return ""
+	}
+	return From(err).FormatTUI()
+}
@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestWithHint(t *testing.T) {
+	if WithHint(nil, "hint") != nil {
+		t.Error("Expected WithHint(nil) to return nil")
+	}
+
+	base := errors.New("connection refused")
+	err := WithHint(base, "Start the server")
+	if err.Error() != "connection refused" {
+		t.Errorf("Expected the message to be kept, got %q", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Error("Expected the hinted error to unwrap to the original")
+	}
+
+	// The outermost hint wins
+	outer := WithHint(fmt.Errorf("failed to load tasks: %w", err), "Check beads")
+	if got := Hint(outer); got != "Check beads" {
+		t.Errorf("Hint() = %q, want the outermost hint", got)
+	}
+}
+
+func TestHint(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"attached", fmt.Errorf("failed: %w", WithHint(errors.New("x"), "Do this")), "Do this"},
+		{"ASCError solution", fmt.Errorf("failed: %w", NewUserError("bad flag")), "Run 'asc --help' for usage information"},
+		{"suggestion line", errors.New("agent 'a': unsupported model 'x'\n  Supported models: claude\n  Suggestion: Use one of the supported models"), "Use one of the supported models"},
+		{"missing command", fmt.Errorf("bd list: %w", exec.ErrNotFound), "Run 'asc check'"},
+		{"connection refused", fmt.Errorf("request failed: %w", syscall.ECONNREFUSED), "asc services status"},
+		{"permission", fmt.Errorf("open: %w", os.ErrPermission), "asc doctor --fix"},
+		{"unknown", errors.New("something else"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hint(tt.err)
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("Hint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	err := errors.New("invalid config\n  Supported models: claude\n  Suggestion: Fix it")
+	if got := Summary(err); got != "invalid config\n  Supported models: claude" {
+		t.Errorf("Summary() = %q", got)
+	}
+	if got := Summary(NewUserError("bad flag")); got != "bad flag" {
+		t.Errorf("Summary() = %q, want the ASCError message", got)
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	err := WithHint(fmt.Errorf("failed to get messages: %w", syscall.ECONNREFUSED), "Start mcp_agent_mail with 'asc services start'")
+
+	cli := FormatCLI(err)
+	if !strings.Contains(cli, "Error: failed to get messages") || !strings.Contains(cli, "Solution: Start mcp_agent_mail") {
+		t.Errorf("Unexpected CLI format: %q", cli)
+	}
+
+	tui := FormatTUI(err)
+	if !strings.Contains(tui, "[ERROR] failed to get messages") || !strings.Contains(tui, "✓ Start mcp_agent_mail") {
+		t.Errorf("Unexpected TUI format: %q", tui)
+	}
+
+	if FormatCLI(nil) != "" || FormatTUI(nil) != "" {
+		t.Error("Expected nil errors to format as empty")
+	}
+}
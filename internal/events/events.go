@@ -0,0 +1,164 @@
+// Package events is asc's event bus. Lifecycle actions, such as an agent
+// starting, stopping, or crashing, a task being created, a fix being
+// applied, secrets being decrypted, or the stack starting and stopping,
+// are published as events, which subscribers receive as they happen.
+// Commands record the events they publish in the audit log (see
+// RecordAudit), where asc events tail reads them back.
+//
+// Example usage:
+//
+//	events.Publish(events.Event{Type: events.AgentStarted, Agent: "coder", Message: "PID 4242"})
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/audit"
+	"github.com/rand/asc/internal/logger"
+)
+
+// Types of events
+const (
+	AgentStarted     = "agent.started"
+	AgentStopped     = "agent.stopped"
+	AgentCrashed     = "agent.crashed"
+	AgentRestarted   = "agent.restarted"
+	TaskCreated      = "task.created"
+	TaskUpdated      = "task.updated"
+	TaskDeleted      = "task.deleted"
+	TaskStuck        = "task.stuck"
+	FixApplied       = "fix.applied"
+	SecretsEncrypted = "secrets.encrypted"
+	SecretsDecrypted = "secrets.decrypted"
+	StackStarted     = "stack.started"
+	StackStopped     = "stack.stopped"
+	DoctorCritical   = "doctor.critical"
+)
+
+// Event is something that happened to the stack
+type Event struct {
+	Type    string    // One of the types above
+	Agent   string    // The agent, or mcp_agent_mail, the event is about
+	Task    string    // The beads task the event is about
+	Message string    // What happened, e.g. "PID 4242"
+	Err     error     // Why the action failed, for events of failed actions
+	Time    time.Time // When it happened (default: when published)
+}
+
+// Entry returns the audit log entry of the event
+func (e Event) Entry() audit.Entry {
+	entry := audit.NewEntry(e.Type).Finish(e.Err)
+	entry.Type = e.Type
+	entry.Agent = e.Agent
+	entry.Task = e.Task
+	if !e.Time.IsZero() {
+		entry.Timestamp = e.Time
+	}
+	switch {
+	case e.Err == nil:
+		entry.Message = e.Message
+	case e.Message != "":
+		entry.Message = e.Message + ": " + e.Err.Error()
+	}
+	return entry
+}
+
+// Bus delivers published events to its subscribers, in the order they
+// were published. It is safe for concurrent use.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[int]func(Event)
+	next        int
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[int]func(Event))}
+}
+
+// Subscribe calls fn with every event published from now on, until the
+// returned function is called
+func (b *Bus) Subscribe(fn func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subscribers[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+	}
+}
+
+// Publish delivers an event to the subscribers. They are called in turn
+// by the publishing goroutine, while no other event is delivered.
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, fn := range b.subscribers {
+		fn(event)
+	}
+}
+
+// defaultBus is the bus of the package-level functions
+var defaultBus = NewBus()
+
+// Subscribe subscribes to the events published on the default bus
+func Subscribe(fn func(Event)) (unsubscribe func()) {
+	return defaultBus.Subscribe(fn)
+}
+
+// Publish publishes an event on the default bus
+func Publish(event Event) {
+	defaultBus.Publish(event)
+}
+
+// RecordAudit appends the events published on the default bus from now on
+// to the audit log, until the returned function is called. Failing to
+// write an event is logged.
+func RecordAudit() (stop func()) {
+	return Subscribe(func(event Event) {
+		if err := audit.Write(event.Entry()); err != nil {
+			logger.Warn("Failed to write event to the audit log: %v", err)
+		}
+	})
+}
+
+// Filter selects entries of the audit log
+type Filter struct {
+	Types []string  // Event types, or their prefix before the dot, such as "agent" (empty for all)
+	Agent string    // Only entries about this agent
+	Since time.Time // Only entries at or after this time (zero for no bound)
+	Until time.Time // Only entries before this time (zero for no bound)
+}
+
+// Match reports whether the filter selects an entry
+func (f Filter) Match(entry audit.Entry) bool {
+	if f.Agent != "" && entry.Agent != f.Agent {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Timestamp.Before(f.Until) {
+		return false
+	}
+	return len(f.Types) == 0 || MatchType(f.Types, entry.Type)
+}
+
+// MatchType reports whether eventType is one of types, or starts with one
+// of them followed by a dot, as agent.crashed does with agent
+func MatchType(types []string, eventType string) bool {
+	for _, t := range types {
+		if eventType == t || strings.HasPrefix(eventType, t+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/audit"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var mu sync.Mutex
+	var got []Event
+	unsubscribe := bus.Subscribe(func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, event)
+	})
+
+	bus.Publish(Event{Type: AgentStarted, Agent: "coder"})
+	bus.Publish(Event{Type: AgentStopped, Agent: "coder"})
+	unsubscribe()
+	bus.Publish(Event{Type: AgentStarted, Agent: "reviewer"})
+
+	if len(got) != 2 || got[0].Type != AgentStarted || got[1].Type != AgentStopped {
+		t.Fatalf("Expected the two events published while subscribed, in order, got %+v", got)
+	}
+	if got[0].Time.IsZero() {
+		t.Error("Expected the time to be defaulted")
+	}
+}
+
+func TestEventEntry(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := Event{Type: TaskCreated, Task: "bd-12", Message: "Write docs", Time: at}.Entry()
+	if entry.Type != TaskCreated || entry.Action != TaskCreated || entry.Task != "bd-12" ||
+		entry.Result != audit.ResultSuccess || entry.Message != "Write docs" || !entry.Timestamp.Equal(at) {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+	if entry.User == "" {
+		t.Error("Expected the user to be recorded")
+	}
+
+	entry = Event{Type: FixApplied, Message: "stale-pid", Err: errors.New("permission denied")}.Entry()
+	if entry.Result != audit.ResultFailure || entry.Message != "stale-pid: permission denied" {
+		t.Errorf("Unexpected entry of a failure %+v", entry)
+	}
+}
+
+func TestRecordAudit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	stop := RecordAudit()
+	Publish(Event{Type: AgentCrashed, Agent: "coder", Err: errors.New("exit status 2")})
+	stop()
+	Publish(Event{Type: AgentStarted, Agent: "coder"})
+
+	entries, err := audit.Read(filepath.Join(home, ".asc", "audit.log"))
+	if err != nil {
+		t.Fatalf("Failed to read the audit log: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Type != AgentCrashed || entries[0].Agent != "coder" || entries[0].Message != "exit status 2" {
+		t.Errorf("Expected the crash in the audit log, got %+v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".asc", "audit.log")); err != nil {
+		t.Errorf("Expected ~/.asc/audit.log: %v", err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	started := audit.Entry{Type: AgentStarted, Agent: "coder", Timestamp: now.Add(-2 * time.Hour)}
+	crashed := audit.Entry{Type: AgentCrashed, Agent: "reviewer", Timestamp: now.Add(-time.Minute)}
+	created := audit.Entry{Type: TaskCreated, Task: "bd-1", Timestamp: now}
+	action := audit.Entry{Type: audit.TypeAction, Action: "up", Timestamp: now}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []audit.Entry
+	}{
+		{"all", Filter{}, []audit.Entry{started, crashed, created, action}},
+		{"type", Filter{Types: []string{AgentCrashed}}, []audit.Entry{crashed}},
+		{"type prefix", Filter{Types: []string{"agent"}}, []audit.Entry{started, crashed}},
+		{"several types", Filter{Types: []string{"task", audit.TypeAction}}, []audit.Entry{created, action}},
+		{"not a prefix of a word", Filter{Types: []string{"agent.start"}}, nil},
+		{"agent", Filter{Agent: "coder"}, []audit.Entry{started}},
+		{"since", Filter{Since: now.Add(-time.Hour)}, []audit.Entry{crashed, created, action}},
+		{"until", Filter{Until: now.Add(-time.Minute)}, []audit.Entry{started}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []audit.Entry
+			for _, entry := range []audit.Entry{started, crashed, created, action} {
+				if tt.filter.Match(entry) {
+					got = append(got, entry)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Matched %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i].Type != tt.want[i].Type || !got[i].Timestamp.Equal(tt.want[i].Timestamp) {
+					t.Errorf("Entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+// Package fsperm checks and restricts who can access the files asc keeps
+// secrets in, such as .env and the age key, and repairs access to the
+// directories asc keeps its state in. On Unix it uses the permission
+// bits. On Windows, where the permission bits only record the read-only
+// attribute and every file reports mode 0666, it reads and writes the
+// file's access control list instead, so checks do not flag every file as
+// readable by others.
+//
+// Example usage:
+//
+//	private, detail, err := fsperm.CheckPrivate(".env")
+//	if err == nil && !private {
+//	    fmt.Printf(".env is not private (%s); run: %s\n", detail, fsperm.Remedy(".env"))
+//	}
+package fsperm
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package fsperm
+
+import (
+	"fmt"
+	"os"
+)
+
+// CheckPrivate reports whether only the owner of path can access it. When
+// others can, detail describes their access.
+func CheckPrivate(path string) (private bool, detail string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, "", err
+	}
+	mode := info.Mode().Perm()
+	if mode&0077 != 0 {
+		return false, fmt.Sprintf("permissions %04o, should be 0600", mode), nil
+	}
+	return true, "", nil
+}
+
+// MakePrivate restricts path to its owner by setting its mode to 0600
+func MakePrivate(path string) error {
+	return os.Chmod(path, 0600)
+}
+
+// MakeWritable lets the owner of directory path create files in it by
+// setting its mode to 0755
+func MakeWritable(path string) error {
+	return os.Chmod(path, 0755)
+}
+
+// Remedy returns the shell command that restricts path to its owner
+func Remedy(path string) string {
+	return fmt.Sprintf("chmod 600 %s", path)
+}
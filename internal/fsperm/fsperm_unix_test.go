@@ -0,0 +1,51 @@
+//go:build !windows
+
+package fsperm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckAndMakePrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("CLAUDE_API_KEY=sk-test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	private, detail, err := CheckPrivate(path)
+	if err != nil || private || !strings.Contains(detail, "0644") {
+		t.Errorf("CheckPrivate() = %v, %q, %v", private, detail, err)
+	}
+
+	if err := MakePrivate(path); err != nil {
+		t.Fatalf("MakePrivate() error = %v", err)
+	}
+	if private, _, err := CheckPrivate(path); err != nil || !private {
+		t.Errorf("CheckPrivate() after MakePrivate = %v, %v", private, err)
+	}
+
+	if _, _, err := CheckPrivate(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+}
+
+func TestMakeWritable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".asc")
+	if err := os.Mkdir(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MakeWritable(dir); err != nil {
+		t.Fatalf("MakeWritable() error = %v", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0755 {
+		t.Errorf("Expected mode 0755, got %04o", mode)
+	}
+}
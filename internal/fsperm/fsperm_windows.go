@@ -0,0 +1,146 @@
+//go:build windows
+
+package fsperm
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// dataAccess is the part of an access mask that reads or changes a file's
+// contents
+const dataAccess = windows.FILE_READ_DATA | windows.FILE_WRITE_DATA | windows.FILE_APPEND_DATA |
+	windows.GENERIC_READ | windows.GENERIC_WRITE | windows.GENERIC_ALL
+
+// CheckPrivate reports whether only the owner of path, SYSTEM, and the
+// Administrators group can read or write it. When others can, detail names
+// them.
+func CheckPrivate(path string) (private bool, detail string, err error) {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read the access control list of %s: %w", path, err)
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read the owner of %s: %w", path, err)
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read the access control list of %s: %w", path, err)
+	}
+	if dacl == nil {
+		return false, "no access control list, so everyone has access", nil
+	}
+
+	var others []string
+	for i := uint32(0); i < uint32(dacl.AceCount); i++ {
+		var ace *windows.ACCESS_ALLOWED_ACE
+		if err := windows.GetAce(dacl, i, &ace); err != nil {
+			return false, "", fmt.Errorf("failed to read the access control list of %s: %w", path, err)
+		}
+		if ace.Header.AceType != windows.ACCESS_ALLOWED_ACE_TYPE || ace.Mask&dataAccess == 0 {
+			continue
+		}
+		sid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+		if sid.Equals(owner) || sid.IsWellKnown(windows.WinLocalSystemSid) || sid.IsWellKnown(windows.WinBuiltinAdministratorsSid) {
+			continue
+		}
+		others = append(others, accountName(sid))
+	}
+	if len(others) > 0 {
+		return false, fmt.Sprintf("readable by %s", strings.Join(others, ", ")), nil
+	}
+	return true, "", nil
+}
+
+// MakePrivate restricts path to its owner by replacing its access control
+// list with one granting only the owner access, and stops it inheriting
+// entries from its directory
+func MakePrivate(path string) error {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return fmt.Errorf("failed to read the owner of %s: %w", path, err)
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return fmt.Errorf("failed to read the owner of %s: %w", path, err)
+	}
+
+	acl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{{
+		AccessPermissions: windows.GENERIC_ALL,
+		AccessMode:        windows.SET_ACCESS,
+		Inheritance:       windows.NO_INHERITANCE,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  windows.TRUSTEE_IS_USER,
+			TrusteeValue: windows.TrusteeValueFromSID(owner),
+		},
+	}}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build access control list: %w", err)
+	}
+	if err := windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION, nil, nil, acl, nil); err != nil {
+		return fmt.Errorf("failed to set the access control list of %s: %w", path, err)
+	}
+	return nil
+}
+
+// MakeWritable lets the current user create files in directory path by
+// adding an entry granting them full control of it, and of what is created
+// in it, to its access control list
+func MakeWritable(path string) error {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return fmt.Errorf("failed to look up the current user: %w", err)
+	}
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return fmt.Errorf("failed to read the access control list of %s: %w", path, err)
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return fmt.Errorf("failed to read the access control list of %s: %w", path, err)
+	}
+
+	acl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{{
+		AccessPermissions: windows.GENERIC_ALL,
+		AccessMode:        windows.GRANT_ACCESS,
+		Inheritance:       windows.SUB_CONTAINERS_AND_OBJECTS_INHERIT,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  windows.TRUSTEE_IS_USER,
+			TrusteeValue: windows.TrusteeValueFromSID(user.User.Sid),
+		},
+	}}, dacl)
+	if err != nil {
+		return fmt.Errorf("failed to build access control list: %w", err)
+	}
+	if err := windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION, nil, nil, acl, nil); err != nil {
+		return fmt.Errorf("failed to set the access control list of %s: %w", path, err)
+	}
+	return nil
+}
+
+// Remedy returns the command that restricts path to its owner
+func Remedy(path string) string {
+	return fmt.Sprintf(`icacls "%s" /inheritance:r /grant:r "%%USERNAME%%:F"`, path)
+}
+
+// accountName returns DOMAIN\name for a SID, or the SID string if it
+// cannot be looked up
+func accountName(sid *windows.SID) string {
+	account, domain, _, err := sid.LookupAccount("")
+	if err != nil {
+		return sid.String()
+	}
+	if domain == "" {
+		return account
+	}
+	return domain + `\` + account
+}
@@ -0,0 +1,133 @@
+// Package fswatch reports changes to files and directories. Changes are
+// debounced, so a burst of writes, such as bd rewriting its database or a
+// git pull touching many files, is reported once when it settles, and
+// changes that arrive before the last one is received are coalesced.
+//
+// Example usage:
+//
+//	w, err := fswatch.New(200*time.Millisecond, dir)
+//	if err != nil {
+//	    return err // Fall back to polling
+//	}
+//	defer w.Close()
+//	for range w.Changes() {
+//	    refresh()
+//	}
+package fswatch
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher reports changes to a set of watched paths
+type Watcher struct {
+	fs       *fsnotify.Watcher
+	debounce time.Duration
+	match    func(path string) bool
+	changes  chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// New watches the given paths, reporting changes once they have been
+// quiet for debounce. A watched directory reports changes to the files
+// in it but not in its subdirectories. Paths that do not exist are
+// skipped; if none of them exist New returns an error.
+func New(debounce time.Duration, paths ...string) (*Watcher, error) {
+	return NewWithFilter(debounce, nil, paths...)
+}
+
+// NewWithFilter is like New but only reports changes to the files match
+// accepts, so files that change without mattering to the caller, such as
+// lock files or a database's journal, do not trigger a refresh. A nil
+// match accepts every file.
+func NewWithFilter(debounce time.Duration, match func(path string) bool, paths ...string) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	watched := 0
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := fs.Add(path); err != nil {
+			fs.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		watched++
+	}
+	if watched == 0 {
+		fs.Close()
+		return nil, fmt.Errorf("none of %v exist", paths)
+	}
+
+	w := &Watcher{
+		fs:       fs,
+		debounce: debounce,
+		match:    match,
+		changes:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	go w.loop()
+	return w, nil
+}
+
+// Changes returns the channel changes are reported on. It is closed when
+// the watcher is closed.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops watching. It is safe to call more than once.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.fs.Close()
+	})
+	return err
+}
+
+// loop debounces filesystem events into change notifications
+func (w *Watcher) loop() {
+	defer close(w.changes)
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if w.match != nil && !w.match(event.Name) {
+				continue
+			}
+			timer.Reset(w.debounce)
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			// An overflow may have lost events, so report a change to
+			// have the caller re-read everything
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			select {
+			case w.changes <- struct{}{}:
+			default:
+				// A change is already pending
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
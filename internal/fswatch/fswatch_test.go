@@ -0,0 +1,100 @@
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherDebouncesChanges(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New(50*time.Millisecond, dir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+
+	// A burst of writes is reported once
+	for i := 0; i < 5; i++ {
+		if err := os.WriteFile(filepath.Join(dir, "issues.jsonl"), []byte{byte(i)}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case <-w.Changes():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a change to be reported")
+	}
+	select {
+	case <-w.Changes():
+		t.Error("Expected the burst to be reported once")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// A later write is reported again
+	if err := os.Remove(filepath.Join(dir, "issues.jsonl")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.Changes():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the removal to be reported")
+	}
+}
+
+func TestWatcherFilter(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWithFilter(20*time.Millisecond, func(path string) bool {
+		return filepath.Ext(path) == ".jsonl"
+	}, dir)
+	if err != nil {
+		t.Fatalf("NewWithFilter() error = %v", err)
+	}
+	defer w.Close()
+
+	if err := os.WriteFile(filepath.Join(dir, "beads.db-wal"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.Changes():
+		t.Fatal("Expected changes to filtered files to be ignored")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "issues.jsonl"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.Changes():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a change to be reported")
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	w, err := New(10*time.Millisecond, t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	w.Close()
+
+	select {
+	case _, ok := <-w.Changes():
+		if ok {
+			t.Error("Expected the changes channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the changes channel to be closed")
+	}
+}
+
+func TestNewWithoutExistingPaths(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := New(10*time.Millisecond, missing); err == nil {
+		t.Error("Expected an error when no path exists")
+	}
+}
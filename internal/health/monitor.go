@@ -16,18 +16,27 @@
 package health
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/events"
 	"github.com/rand/asc/internal/logger"
 	"github.com/rand/asc/internal/mcp"
 	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/usage"
+	"github.com/rand/asc/internal/worktree"
 )
 
+// healthLog tags every record written by this package with the health component
+var healthLog = logger.WithComponent("health")
+
 // HealthIssueType represents the type of health issue detected
 type HealthIssueType string
 
@@ -52,6 +61,7 @@ type AgentHealthState struct {
 	LastHeartbeat   time.Time
 	LastTask        string
 	TaskStartTime   time.Time
+	StuckReported   bool // A task.stuck event was published for LastTask
 	ProcessRunning  bool
 	LastCheckTime   time.Time
 	ConsecutiveFails int
@@ -65,6 +75,9 @@ type RecoveryAction struct {
 	Timestamp   time.Time
 	Success     bool
 	ErrorMsg    string
+	
+	// CorrelationID identifies the health check cycle that took the action
+	CorrelationID string
 }
 
 // Monitor provides comprehensive health monitoring for agents
@@ -88,9 +101,16 @@ type Monitor struct {
 	stuckTaskTimeout    time.Duration
 	autoRecoveryEnabled bool
 	
-	// Control
+	// isActive reports whether an agent is expected to be running; agents
+	// it rejects are neither checked nor restarted (see SetAgentFilter)
+	isActive func(agentName string) bool
+	
+	// Control; ctx is cancelled by Stop to abandon in-flight MCP calls and
+	// process stops
 	stopChan chan struct{}
 	wg       sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
 	
 	// Health log
 	healthLogger *logger.Logger
@@ -107,22 +127,21 @@ type RecoveryStats struct {
 // NewMonitor creates a new health monitor with the given clients and configuration
 func NewMonitor(mcpClient mcp.MCPClient, procManager process.ProcessManager, cfg config.Config) (*Monitor, error) {
 	// Create health log file
-	homeDir, err := os.UserHomeDir()
+	logDir, err := statedir.Path("logs")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, err
 	}
-	
-	logDir := filepath.Join(homeDir, ".asc", "logs")
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 	
 	healthLogPath := filepath.Join(logDir, "health.log")
-	healthLogger, err := logger.NewLogger(healthLogPath, 10*1024*1024, 5, logger.INFO)
+	healthLogger, err := logger.NewLogger(healthLogPath, 10*1024*1024, 5, logger.GetLevel())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create health logger: %w", err)
 	}
 	
+	ctx, cancel := context.WithCancel(context.Background())
 	m := &Monitor{
 		mcpClient:           mcpClient,
 		procManager:         procManager,
@@ -136,6 +155,8 @@ func NewMonitor(mcpClient mcp.MCPClient, procManager process.ProcessManager, cfg
 		stuckTaskTimeout:    30 * time.Minute,
 		autoRecoveryEnabled: true, // Enabled by default, can be disabled via SetAutoRecovery()
 		stopChan:            make(chan struct{}),
+		ctx:                 ctx,
+		cancel:              cancel,
 		healthLogger:        healthLogger,
 	}
 	
@@ -174,18 +195,19 @@ func (m *Monitor) logHealth(level logger.LogLevel, format string, args ...interf
 func (m *Monitor) Start() {
 	m.wg.Add(1)
 	go m.monitorLoop()
-	logger.Info("Health monitor started")
+	healthLog.Info("Health monitor started")
 	m.logHealth(logger.INFO, "Health monitor started")
 }
 
 // Stop stops the health monitoring loop
 func (m *Monitor) Stop() {
+	m.cancel()
 	close(m.stopChan)
 	m.wg.Wait()
 	if m.healthLogger != nil {
 		m.healthLogger.Close()
 	}
-	logger.Info("Health monitor stopped")
+	healthLog.Info("Health monitor stopped")
 }
 
 // monitorLoop runs the periodic health check
@@ -196,30 +218,51 @@ func (m *Monitor) monitorLoop() {
 	defer ticker.Stop()
 	
 	// Run initial check immediately
-	m.performHealthCheck()
+	m.runHealthCheck()
 	
 	for {
 		select {
 		case <-ticker.C:
-			m.performHealthCheck()
+			m.runHealthCheck()
 		case <-m.stopChan:
 			return
 		}
 	}
 }
 
+// CheckNow runs a health check immediately instead of waiting for the
+// next one, for example when an agent process has just started or exited
+func (m *Monitor) CheckNow() {
+	m.runHealthCheck()
+}
+
+// runHealthCheck runs one health check, bounded by the check interval so a
+// hung MCP server or agent cannot stall the monitor
+func (m *Monitor) runHealthCheck() {
+	ctx, cancel := context.WithTimeout(m.ctx, m.checkInterval)
+	defer cancel()
+	m.performHealthCheck(ctx)
+}
+
 // performHealthCheck executes a complete health check on all agents
-func (m *Monitor) performHealthCheck() {
+func (m *Monitor) performHealthCheck(ctx context.Context) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	
+	// Each check is its own reconcile cycle so its MCP requests, recovery
+	// actions, and log records can be traced together
+	cycleID := logger.StartCorrelation()
+	if m.healthLogger != nil {
+		m.healthLogger.WithCorrelationID(cycleID)
+	}
+	
 	now := time.Now()
 	newIssues := []HealthIssue{}
 	
 	// Get current agent statuses from MCP
-	statuses, err := m.mcpClient.GetAllAgentStatuses(m.unresponsiveTimeout)
+	statuses, err := m.mcpClient.GetAllAgentStatuses(ctx, m.unresponsiveTimeout)
 	if err != nil {
-		logger.Warn("Failed to get agent statuses during health check: %v", err)
+		healthLog.Warn("Failed to get agent statuses during health check: %v", err)
 		m.logHealth(logger.WARN, "Failed to get agent statuses: %v", err)
 		return
 	}
@@ -232,6 +275,12 @@ func (m *Monitor) performHealthCheck() {
 	
 	// Check each configured agent
 	for agentName, state := range m.agentStates {
+		if m.isActive != nil && !m.isActive(agentName) {
+			state.ProcessRunning = false
+			state.LastCheckTime = now
+			continue
+		}
+		
 		// Get process info
 		procInfo, err := m.procManager.GetProcessInfo(agentName)
 		processRunning := err == nil && m.procManager.IsRunning(procInfo.PID)
@@ -277,6 +326,7 @@ func (m *Monitor) performHealthCheck() {
 				if state.LastTask != mcpStatus.CurrentTask {
 					state.LastTask = mcpStatus.CurrentTask
 					state.TaskStartTime = now
+					state.StuckReported = false
 				} else if !state.TaskStartTime.IsZero() {
 					taskDuration := now.Sub(state.TaskStartTime)
 					if taskDuration > m.stuckTaskTimeout {
@@ -289,12 +339,17 @@ func (m *Monitor) performHealthCheck() {
 						}
 						newIssues = append(newIssues, issue)
 						m.logHealth(logger.WARN, "Agent %s stuck: working on task %s for %v", agentName, mcpStatus.CurrentTask, taskDuration.Round(time.Minute))
+						if !state.StuckReported {
+							state.StuckReported = true
+							events.Publish(events.Event{Type: events.TaskStuck, Agent: agentName, Task: mcpStatus.CurrentTask, Message: fmt.Sprintf("working on it for %v", taskDuration.Round(time.Minute))})
+						}
 					}
 				}
 			} else {
 				// Agent is idle or in another state, reset task tracking
 				state.LastTask = ""
 				state.TaskStartTime = time.Time{}
+				state.StuckReported = false
 			}
 		} else {
 			// No MCP status found - agent might not have sent heartbeat yet
@@ -317,12 +372,12 @@ func (m *Monitor) performHealthCheck() {
 	
 	// Log summary if there are issues
 	if len(newIssues) > 0 {
-		logger.Warn("Health check found %d issue(s)", len(newIssues))
+		healthLog.Warn("Health check found %d issue(s)", len(newIssues))
 		
 		// Attempt automatic recovery if enabled
-		m.attemptRecovery()
+		m.attemptRecovery(ctx)
 	} else {
-		logger.Debug("Health check: all agents healthy")
+		healthLog.Debug("Health check: all agents healthy")
 	}
 }
 
@@ -407,10 +462,19 @@ func (m *Monitor) SetAutoRecovery(enabled bool) {
 	defer m.mu.Unlock()
 	m.autoRecoveryEnabled = enabled
 	status := map[bool]string{true: "enabled", false: "disabled"}[enabled]
-	logger.Info("Auto-recovery %s", status)
+	healthLog.Info("Auto-recovery %s", status)
 	m.logHealth(logger.INFO, "Auto-recovery %s", status)
 }
 
+// SetAgentFilter limits health checks and recovery to the agents for which
+// isActive returns true, e.g. those the phase pipeline has activated.
+// Agents that are deliberately stopped are then not reported as crashed.
+func (m *Monitor) SetAgentFilter(isActive func(agentName string) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.isActive = isActive
+}
+
 // IsAutoRecoveryEnabled returns whether automatic recovery is enabled
 func (m *Monitor) IsAutoRecoveryEnabled() bool {
 	m.mu.RLock()
@@ -445,7 +509,7 @@ func (m *Monitor) GetRecoveryStats(agentName string) *RecoveryStats {
 }
 
 // attemptRecovery attempts to recover from health issues
-func (m *Monitor) attemptRecovery() {
+func (m *Monitor) attemptRecovery(ctx context.Context) {
 	if !m.autoRecoveryEnabled {
 		return
 	}
@@ -458,19 +522,24 @@ func (m *Monitor) attemptRecovery() {
 		
 		// Check if we're in backoff period
 		if now.Before(stats.BackoffUntil) {
-			logger.Debug("Skipping recovery for %s: in backoff period", issue.AgentName)
+			healthLog.WithFields(logger.Fields{"agent": issue.AgentName}).Debug("Skipping recovery: in backoff period")
 			continue
 		}
 		
 		// Attempt recovery based on issue type
 		switch issue.Type {
 		case IssueCrashed:
+			// The process manager restarts the agents it supervises by
+			// their restart policy
+			if supervisor, ok := m.procManager.(interface{ Supervises(string) bool }); ok && supervisor.Supervises(issue.AgentName) {
+				continue
+			}
 			m.recoverCrashedAgent(issue.AgentName, stats)
 		case IssueStuck:
-			m.recoverStuckAgent(issue.AgentName, stats)
+			m.recoverStuckAgent(ctx, issue.AgentName, stats)
 		case IssueUnresponsive:
 			// For unresponsive agents, try restarting if process is still running
-			m.recoverUnresponsiveAgent(issue.AgentName, stats)
+			m.recoverUnresponsiveAgent(ctx, issue.AgentName, stats)
 		}
 	}
 }
@@ -492,7 +561,7 @@ func (m *Monitor) getOrCreateRecoveryStats(agentName string) *RecoveryStats {
 
 // recoverCrashedAgent attempts to restart a crashed agent
 func (m *Monitor) recoverCrashedAgent(agentName string, stats *RecoveryStats) {
-	logger.Info("Attempting to restart crashed agent: %s", agentName)
+	healthLog.WithFields(logger.Fields{"agent": agentName}).Info("Attempting to restart crashed agent")
 	m.logHealth(logger.INFO, "Attempting to restart crashed agent: %s", agentName)
 	
 	// Get agent config
@@ -505,44 +574,60 @@ func (m *Monitor) recoverCrashedAgent(agentName string, stats *RecoveryStats) {
 	// Build environment variables
 	env := m.buildAgentEnv(agentName, agentConfig)
 	
+	// Run it in its container, for the docker runtime, which runs the
+	// words of the command
+	command, args := agentConfig.Command, []string{}
+	if agentConfig.UsesDocker() {
+		command = ""
+		if fields := strings.Fields(agentConfig.Command); len(fields) > 0 {
+			command, args = fields[0], fields[1:]
+		}
+	}
+	command, args, env, err := config.RuntimeCommand(agentName, agentConfig, command, args, env)
+	if err != nil {
+		m.recordRecoveryAction(agentName, "restart", "crashed", false, err.Error())
+		m.updateRecoveryStats(stats, false)
+		return
+	}
+
 	// Start the agent process
-	pid, err := m.procManager.Start(agentName, agentConfig.Command, []string{}, env)
+	pid, err := m.procManager.Start(agentName, command, args, env)
 	if err != nil {
 		m.recordRecoveryAction(agentName, "restart", "crashed", false, err.Error())
 		m.updateRecoveryStats(stats, false)
 		return
 	}
 	
-	logger.Info("Successfully restarted agent %s with PID %d", agentName, pid)
+	healthLog.WithFields(logger.Fields{"agent": agentName, "pid": pid, "command": command}).Info("Successfully restarted agent")
 	m.logHealth(logger.INFO, "Successfully restarted agent %s with PID %d", agentName, pid)
 	m.recordRecoveryAction(agentName, "restart", "crashed", true, "")
 	m.updateRecoveryStats(stats, true)
 }
 
 // recoverStuckAgent attempts to recover a stuck agent by releasing leases
-func (m *Monitor) recoverStuckAgent(agentName string, stats *RecoveryStats) {
-	logger.Info("Attempting to recover stuck agent: %s", agentName)
+func (m *Monitor) recoverStuckAgent(ctx context.Context, agentName string, stats *RecoveryStats) {
+	healthLog.WithFields(logger.Fields{"agent": agentName}).Info("Attempting to recover stuck agent")
 	m.logHealth(logger.INFO, "Attempting to recover stuck agent: %s", agentName)
 	
 	// Try to release file leases via MCP
-	err := m.mcpClient.ReleaseAgentLeases(agentName)
+	err := m.mcpClient.ReleaseAgentLeases(ctx, agentName)
 	if err != nil {
-		logger.Error("Failed to release leases for stuck agent %s: %v", agentName, err)
+		healthLog.WithFields(logger.Fields{"agent": agentName}).Error("Failed to release leases for stuck agent: %v", err)
 		m.logHealth(logger.ERROR, "Failed to release leases for stuck agent %s: %v", agentName, err)
 		m.recordRecoveryAction(agentName, "release_leases", "stuck", false, err.Error())
 		m.updateRecoveryStats(stats, false)
 		return
 	}
 	
-	logger.Info("Successfully released leases for stuck agent %s", agentName)
+	healthLog.WithFields(logger.Fields{"agent": agentName}).Info("Successfully released leases for stuck agent")
 	m.logHealth(logger.INFO, "Successfully released leases for stuck agent %s", agentName)
 	m.recordRecoveryAction(agentName, "release_leases", "stuck", true, "")
 	m.updateRecoveryStats(stats, true)
 }
 
 // recoverUnresponsiveAgent attempts to restart an unresponsive agent
-func (m *Monitor) recoverUnresponsiveAgent(agentName string, stats *RecoveryStats) {
-	logger.Info("Attempting to recover unresponsive agent: %s", agentName)
+func (m *Monitor) recoverUnresponsiveAgent(ctx context.Context, agentName string, stats *RecoveryStats) {
+	healthLog.WithFields(logger.Fields{"agent": agentName}).Info("Attempting to recover unresponsive agent")
 	m.logHealth(logger.INFO, "Attempting to recover unresponsive agent: %s", agentName)
 	
 	// Get process info
@@ -554,7 +639,7 @@ func (m *Monitor) recoverUnresponsiveAgent(agentName string, stats *RecoveryStat
 	}
 	
 	// Stop the unresponsive process
-	if err := m.procManager.Stop(procInfo.PID); err != nil {
+	if err := m.procManager.Stop(ctx, procInfo.PID); err != nil {
 		m.recordRecoveryAction(agentName, "restart", "unresponsive", false, fmt.Sprintf("failed to stop: %v", err))
 		m.updateRecoveryStats(stats, false)
 		return
@@ -569,13 +654,18 @@ func (m *Monitor) recoverUnresponsiveAgent(agentName string, stats *RecoveryStat
 
 // buildAgentEnv builds environment variables for an agent
 func (m *Monitor) buildAgentEnv(agentName string, agentConfig config.AgentConfig) []string {
+	_, mcpServer := m.config.MCPServerFor(agentConfig)
 	env := []string{
 		fmt.Sprintf("AGENT_NAME=%s", agentName),
 		fmt.Sprintf("AGENT_MODEL=%s", agentConfig.Model),
 		fmt.Sprintf("AGENT_PHASES=%s", joinPhases(agentConfig.Phases)),
-		fmt.Sprintf("MCP_MAIL_URL=%s", m.config.Services.MCPAgentMail.URL),
+		fmt.Sprintf("MCP_MAIL_URL=%s", mcpServer.URL),
 		fmt.Sprintf("BEADS_DB_PATH=%s", m.config.Core.BeadsDBPath),
 	}
+	env = append(env, usage.Env(agentName)...)
+	if m.config.UsesWorktree(agentConfig) {
+		env = append(env, worktree.Env(agentName, m.config.Core.BeadsDBPath)...)
+	}
 	
 	// Add API keys from environment
 	if apiKey := os.Getenv("CLAUDE_API_KEY"); apiKey != "" {
@@ -588,6 +678,17 @@ func (m *Monitor) buildAgentEnv(agentName string, agentConfig config.AgentConfig
 		env = append(env, fmt.Sprintf("GOOGLE_API_KEY=%s", apiKey))
 	}
 	
+	promptEnv, err := config.PromptEnv(agentName, agentConfig, &m.config)
+	if err != nil {
+		healthLog.WithFields(logger.Fields{"agent": agentName}).Error("Agent restarts without its prompt: %v", err)
+	}
+	env = append(env, promptEnv...)
+	ownEnv, err := config.AgentEnv(agentName, agentConfig)
+	if err != nil {
+		healthLog.WithFields(logger.Fields{"agent": agentName}).Error("Agent restarts without its env_file: %v", err)
+	}
+	env = append(env, ownEnv...)
+	
 	return env
 }
 
@@ -612,6 +713,8 @@ func (m *Monitor) recordRecoveryAction(agentName, action, reason string, success
 		Timestamp: time.Now(),
 		Success:   success,
 		ErrorMsg:  errorMsg,
+		
+		CorrelationID: logger.CorrelationID(),
 	}
 	
 	m.recoveryActions = append(m.recoveryActions, recoveryAction)
@@ -645,7 +748,7 @@ func (m *Monitor) updateRecoveryStats(stats *RecoveryStats, success bool) {
 			backoffMinutes = 15
 		}
 		stats.BackoffUntil = time.Now().Add(time.Duration(backoffMinutes) * time.Minute)
-		logger.Warn("Recovery failed, backing off for %d minutes", backoffMinutes)
+		healthLog.Warn("Recovery failed, backing off for %d minutes", backoffMinutes)
 	}
 }
 
@@ -1,11 +1,13 @@
 package health
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/events"
 	"github.com/rand/asc/internal/mcp"
 	"github.com/rand/asc/internal/process"
 )
@@ -16,19 +18,19 @@ type mockMCPClient struct {
 	err      error
 }
 
-func (m *mockMCPClient) GetMessages(since time.Time) ([]mcp.Message, error) {
+func (m *mockMCPClient) GetMessages(ctx context.Context, since time.Time) ([]mcp.Message, error) {
 	return nil, nil
 }
 
-func (m *mockMCPClient) SendMessage(msg mcp.Message) error {
+func (m *mockMCPClient) SendMessage(ctx context.Context, msg mcp.Message) error {
 	return nil
 }
 
-func (m *mockMCPClient) GetAgentStatus(agentName string) (mcp.AgentStatus, error) {
+func (m *mockMCPClient) GetAgentStatus(ctx context.Context, agentName string) (mcp.AgentStatus, error) {
 	return mcp.AgentStatus{}, nil
 }
 
-func (m *mockMCPClient) GetAllAgentStatuses(offlineThreshold time.Duration) ([]mcp.AgentStatus, error) {
+func (m *mockMCPClient) GetAllAgentStatuses(ctx context.Context, offlineThreshold time.Duration) ([]mcp.AgentStatus, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -45,11 +47,11 @@ func (m *mockProcessManager) Start(name string, command string, args []string, e
 	return 0, nil
 }
 
-func (m *mockProcessManager) Stop(pid int) error {
+func (m *mockProcessManager) Stop(ctx context.Context, pid int) error {
 	return nil
 }
 
-func (m *mockProcessManager) StopAll() error {
+func (m *mockProcessManager) StopAll(ctx context.Context) error {
 	return nil
 }
 
@@ -138,7 +140,7 @@ func TestDetectCrashedAgent(t *testing.T) {
 	defer monitor.Stop()
 
 	// Perform health check
-	monitor.performHealthCheck()
+	monitor.performHealthCheck(context.Background())
 
 	issues := monitor.GetHealthIssues()
 	if len(issues) != 1 {
@@ -196,7 +198,7 @@ func TestDetectUnresponsiveAgent(t *testing.T) {
 	defer monitor.Stop()
 
 	// Perform health check
-	monitor.performHealthCheck()
+	monitor.performHealthCheck(context.Background())
 
 	issues := monitor.GetHealthIssues()
 	if len(issues) != 1 {
@@ -254,8 +256,16 @@ func TestDetectStuckAgent(t *testing.T) {
 	monitor.agentStates["stuck-agent"].TaskStartTime = time.Now().Add(-45 * time.Minute)
 	monitor.mu.Unlock()
 
+	var stuck []events.Event
+	unsubscribe := events.Subscribe(func(event events.Event) {
+		if event.Type == events.TaskStuck {
+			stuck = append(stuck, event)
+		}
+	})
+	defer unsubscribe()
+
 	// Perform health check
-	monitor.performHealthCheck()
+	monitor.performHealthCheck(context.Background())
 
 	issues := monitor.GetHealthIssues()
 	if len(issues) != 1 {
@@ -265,6 +275,12 @@ func TestDetectStuckAgent(t *testing.T) {
 	if issues[0].Type != IssueStuck {
 		t.Errorf("Expected stuck issue, got %s", issues[0].Type)
 	}
+
+	// The event is published once per task, however long it stays stuck
+	monitor.performHealthCheck(context.Background())
+	if len(stuck) != 1 || stuck[0].Agent != "stuck-agent" || stuck[0].Task != "task-123" {
+		t.Errorf("Expected one task.stuck event for task-123, got %+v", stuck)
+	}
 }
 
 func TestHealthyAgents(t *testing.T) {
@@ -307,7 +323,7 @@ func TestHealthyAgents(t *testing.T) {
 	defer monitor.Stop()
 
 	// Perform health check
-	monitor.performHealthCheck()
+	monitor.performHealthCheck(context.Background())
 
 	issues := monitor.GetHealthIssues()
 	if len(issues) != 0 {
@@ -596,6 +612,41 @@ func TestGetRecoveryStats(t *testing.T) {
 }
 
 // Add ReleaseAgentLeases to mock MCP client
-func (m *mockMCPClient) ReleaseAgentLeases(agentName string) error {
+func (m *mockMCPClient) ReleaseAgentLeases(ctx context.Context, agentName string) error {
 	return nil
 }
+
+func TestAgentFilter(t *testing.T) {
+	cfg := config.Config{
+		Agents: map[string]config.AgentConfig{
+			"stopped-agent": {
+				Command: "python",
+				Model:   "claude",
+				Phases:  []string{"testing"},
+			},
+		},
+	}
+
+	mcpClient := &mockMCPClient{statuses: []mcp.AgentStatus{}}
+	procManager := &mockProcessManager{
+		processes: make(map[string]*process.ProcessInfo),
+		running:   make(map[int]bool),
+	}
+
+	monitor, err := NewMonitor(mcpClient, procManager, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	defer monitor.Stop()
+
+	// An agent deliberately stopped by the pipeline is not a crash
+	monitor.SetAgentFilter(func(agentName string) bool { return false })
+	monitor.performHealthCheck(context.Background())
+
+	if issues := monitor.GetHealthIssues(); len(issues) != 0 {
+		t.Errorf("Expected no issues for an inactive agent, got %v", issues)
+	}
+	if actions := monitor.GetRecoveryActions(); len(actions) != 0 {
+		t.Errorf("Expected no recovery for an inactive agent, got %v", actions)
+	}
+}
@@ -0,0 +1,501 @@
+package i18n
+
+// catalogDE is the German catalog
+var catalogDE = map[string]string{
+	// asc up and asc down
+	"up.dependency_check_failed": "Abhängigkeitsprüfung fehlgeschlagen. Details mit 'asc check'.",
+	"down.no_processes":          "Keine laufenden Prozesse gefunden",
+	"down.offline":               "Agent-Stack ist offline",
+	"down.shutting_down":         "%d Prozess(e) werden beendet...",
+
+	// asc doctor reports
+	"doctor.title":             "ASC DOCTOR - DIAGNOSEBERICHT",
+	"doctor.run_at":            "Ausgeführt: %s",
+	"doctor.status":            "Status: %s",
+	"doctor.no_issues":         "✓ Keine Probleme gefunden",
+	"doctor.severity_heading":  "─── SCHWEREGRAD %s (%d) ───",
+	"doctor.category":          "  Kategorie: %s",
+	"doctor.description":       "  Beschreibung: %s",
+	"doctor.impact":            "  Auswirkung: %s",
+	"doctor.remediation":       "  Behebung: %s",
+	"doctor.auto_fixable":      "  ✓ Mit --fix automatisch behebbar",
+	"doctor.fixes_applied":     "─── ANGEWANDTE KORREKTUREN ───",
+	"doctor.recent_logs":       "─── LETZTE ASC-LOGS (letzte %d) ───",
+	"doctor.more_logs":         "Mehr mit 'asc logs --self'.",
+	"doctor.healthy":           "✓ Alle Prüfungen bestanden - System ist in Ordnung",
+	"doctor.found_issues":      "%d Problem(e) gefunden: ",
+	"doctor.count.critical":    "%d kritisch",
+	"doctor.count.high":        "%d hoch",
+	"doctor.count.medium":      "%d mittel",
+	"doctor.count.low":         "%d niedrig",
+	"doctor.severity.critical": "kritisch",
+	"doctor.severity.high":     "hoch",
+	"doctor.severity.medium":   "mittel",
+	"doctor.severity.low":      "niedrig",
+	"doctor.severity.info":     "Info",
+
+	// TUI dashboard
+	"tui.agents.title":             "Agentenstatus",
+	"tui.agents.hint":              "1-9:wählen p:pausieren k:beenden R:neustarten l:Logs",
+	"tui.agents.loading":           "Agentenstatus wird geladen...",
+	"tui.agents.none":              "Keine Agenten konfiguriert",
+	"tui.agents.pipeline_complete": " · Pipeline abgeschlossen",
+	"tui.agents.phase":             " · Phase: %s",
+	"tui.tasks.title":              "Aufgaben",
+	"tui.tasks.hint":               "↑↓:wählen c:übernehmen v:anzeigen h:Verlauf n:neu f:filtern",
+	"tui.tasks.loading":            "Aufgaben werden geladen...",
+	"tui.tasks.none":               "Keine offenen oder laufenden Aufgaben",
+	"tui.tasks.blocked_by":         "(blockiert durch %s)",
+	"tui.logs.title":               "MCP-Protokoll",
+	"tui.logs.hint":                "/:suchen a:Agent m:Typ x:zurücksetzen e:exportieren PgUp/PgDn/End:blättern",
+	"tui.logs.none":                "Noch keine Nachrichten",
+	"tui.logs.newer":               " (↑%d neuere)",
+	"tui.footer.quit":              " beenden | ",
+	"tui.footer.refresh":           " aktualisieren | ",
+	"tui.footer.test":              " testen",
+
+	// Messages of every command
+	"dry_run":                    "Probelauf: würde %s",
+	"dry_run.action":             "%s",
+	"warning":                    "Warnung: %v",
+	"root.dry_run.migrate_state": "%s von Zustandsschema %d auf %d migrieren",
+	"root.migrated_state":        "%s in das Zustandsformat dieser Version migriert (Sicherung in %s)",
+	"root.source_stale":          "⚠ %s konnte nicht abgerufen werden; die am %s abgerufene Kopie wird verwendet",
+	"root.migrated_config":       "%s in das Konfigurationsformat dieser Version migriert (Sicherung in %s)",
+	"root.syslog_disabled":       "Warnung: Protokollierung an syslog deaktiviert: %v",
+	"root.journald_disabled":     "Warnung: Protokollierung an journald deaktiviert: %v",
+
+	// asc attach
+	"attach.not_running": "%s läuft nicht",
+
+	// asc audit
+	"audit.none": "Keine protokollierten Aktionen",
+
+	// asc backup
+	"backup.env_not_backed_up": "Warnung: .env wird nicht gesichert; mit 'asc secrets encrypt' werden seine Geheimnisse als .env.age gesichert",
+	"backup.created":           "✓ %d Datei(en), %.1f MB, nach %s gesichert",
+	"backup.dry_run.create":    "%s mit asc.toml, .env.age, %s und %s schreiben",
+	"backup.dry_run.restore":   "%s wiederherstellen",
+	"backup.key_not_included":  "  Der age-Schlüssel ist nicht enthalten; kopieren Sie %s separat, um .env.age anderswo zu entschlüsseln",
+	"backup.restore_unchanged": "Probelauf: alle Dateien entsprechen bereits dem Archiv",
+	"backup.restored":          "✓ %d Datei(en) aus der Sicherung vom %s wiederhergestellt (asc %s)",
+	"backup.no_key":            "  Kein age-Schlüssel in %s; kopieren Sie ihn vom ursprünglichen Rechner, um .env.age zu entschlüsseln",
+
+	// asc budget
+	"budget.dry_run.override":     "Agent %s sein Budget bis zum Ende des Zeitraums überschreiten lassen",
+	"budget.dry_run.resume":       "Agent %s fortsetzen",
+	"budget.overridden":           "✓ Agent %s darf sein Budget bis zum Ende des Zeitraums überschreiten",
+	"budget.resumed":              "✓ Agent %s fortgesetzt",
+	"budget.spend_to_date":        "Bisherige Ausgaben: $%.2f",
+	"budget.spend_since":          "Ausgaben seit %s (%s): $%.2f",
+	"budget.limit":                "%s %-16s $%8.2f von $%8.2f  %3.0f%%",
+	"budget.paused":               "Pausierte Agenten:",
+	"budget.paused_agent":         "  %s (Budget %s, seit %s)",
+	"budget.resume_hint":          "Mit 'asc budget resume <agent>' wird ein Agent fortgesetzt",
+	"budget.enforcement_disabled": "Warnung: Budgetdurchsetzung deaktiviert: %v",
+	"budget.ignored":              "Budgets werden nicht durchgesetzt (--ignore-budget); Warnungen werden weiterhin gesendet",
+	"budget.check_failed":         "Warnung: Budgetprüfung fehlgeschlagen: %v",
+
+	// asc check
+	"check.title":                       "Abhängigkeitsprüfungen",
+	"check.column.component":            "Komponente",
+	"check.column.status":               "Status",
+	"check.column.message":              "Meldung",
+	"check.status.pass":                 "✓ OK",
+	"check.status.fail":                 "✗ FEHLER",
+	"check.status.warn":                 "⚠ WARNUNG",
+	"check.binary.missing":              "Programm '%s' nicht im PATH gefunden",
+	"check.binary.found":                "Programm '%s' gefunden",
+	"check.file.missing":                "Datei '%s' existiert nicht",
+	"check.file.inaccessible":           "Kein Zugriff auf Datei '%s': %v",
+	"check.file.directory":              "'%s' ist ein Verzeichnis, keine Datei",
+	"check.file.unreadable":             "Datei '%s' ist nicht lesbar: %v",
+	"check.file.ok":                     "Datei '%s' existiert und ist lesbar",
+	"check.config.missing":              "Konfigurationsdatei unter '%s' nicht gefunden",
+	"check.config.invalid_toml":         "Ungültige TOML-Syntax: %v",
+	"check.config.no_beads_db_path":     "Pflichtfeld fehlt: core.beads_db_path",
+	"check.config.no_mcp_agent_mail":    "Konfiguration services.mcp_agent_mail fehlt",
+	"check.config.ok":                   "Konfigurationsdatei ist gültig",
+	"check.env.missing":                 ".env-Datei unter '%s' nicht gefunden",
+	"check.env.unreadable":              ".env-Datei kann nicht gelesen werden: %v",
+	"check.env.missing_keys":            "Fehlende API-Schlüssel: %v",
+	"check.env.ok":                      "Alle erforderlichen API-Schlüssel vorhanden",
+	"check.env.ok_from":                 "Alle erforderlichen API-Schlüssel vorhanden (%s)",
+	"check.env.injected":                "eingefügt aus %s",
+	"check.env.resolved":                "beim Start aus [secrets.env] aufgelöst",
+	"check.timed_out":                   "Zeitüberschreitung der Prüfung nach %s",
+	"check.custom.not_run":              "Befehl '%s' konnte nicht ausgeführt werden: %v",
+	"check.custom.exit_code":            "Befehl '%s' endete mit %d, erwartet %d",
+	"check.custom.ok":                   "Befehl '%s' erfolgreich",
+	"check.proxy.invalid_override":      "Ungültiges services.mcp_agent_mail.proxy: %v",
+	"check.proxy.invalid_override_hint": "Setzen Sie proxy auf eine URL wie http://proxy:3128, oder auf \"direct\", um den Proxy zu umgehen",
+	"check.proxy.invalid_env":           "Ungültige Proxy-Umgebung: %v",
+	"check.proxy.invalid_env_hint":      "Korrigieren oder entfernen Sie HTTP_PROXY/HTTPS_PROXY",
+	"check.proxy.ok":                    "Proxy: %s",
+	"check.proxy.via":                   "; MCP-Server erreichbar über %s",
+	"check.proxy.direct":                "; MCP-Server direkt erreichbar (NO_PROXY oder Loopback)",
+	"check.version.invalid":             "Ungültige Anforderung für '%s': %v",
+	"check.version.unknown":             "Version von '%s' konnte nicht ermittelt werden: %v",
+	"check.version.unsatisfied":         "Programm '%s' Version %s erfüllt %s nicht",
+	"check.version.ok":                  "Programm '%s' Version %s erfüllt %s",
+	"check.install.nothing":             "Nichts zu installieren.",
+	"check.install.no_package_manager":  "⚠ Kein unterstützter Paketmanager gefunden (brew, apt, dnf, winget); nur Python-Werkzeuge können installiert werden",
+	"check.install.no_installer":        "  ? %s: kein Installationsprogramm verfügbar, bitte manuell installieren",
+	"check.dry_run.install":             "anbieten, %s mit '%s' zu installieren",
+	"check.install.prompt":              "%s mit '%s' installieren? (y/N): ",
+	"check.install.skipped":             "  - %s: übersprungen",
+	"check.install.failed":              "  ✗ %s: Installation fehlgeschlagen: %v",
+	"check.install.installed":           "  ✓ %s: installiert",
+	"check.install.summary":             "Zusammenfassung der Installation:",
+
+	// asc cleanup
+	"cleanup.dry_run.none":   "Probelauf: keine Protokolle älter als %d Tage in %s",
+	"cleanup.dry_run.remove": "%s entfernen",
+	"cleanup.cleaning":       "Protokolle älter als %d Tage werden aus %s entfernt...",
+	"cleanup.done":           "✓ Protokollbereinigung abgeschlossen",
+
+	// asc config
+	"config.dry_run.set":     "%s = %s in %s setzen",
+	"config.set":             "✓ %s = %s in %s gesetzt",
+	"config.valid":           "✓ %s ist gültig",
+	"config.dry_run.migrate": "%s von Konfigurationsversion %d auf %d migrieren",
+	"config.current_layout":  "%s hat bereits das Format dieser Version",
+	"config.migrated":        "✓ %s von Konfigurationsversion %d auf %d migriert (Sicherung in %s)",
+	"config.no_differences":  "Keine Unterschiede zwischen %s und %s",
+
+	// asc crashes
+	"crashes.none":          "Keine Abstürze",
+	"crashes.column.id":     "ID",
+	"crashes.column.agent":  "AGENT",
+	"crashes.column.exited": "BEENDET",
+	"crashes.column.exit":   "ENDE",
+	"crashes.column.ran":    "LAUFZEIT",
+	"crashes.exit_status":   "Status %d",
+	"crashes.crash":         "Absturz %s",
+	"crashes.agent":         "  Agent:      %s (PID %d)",
+	"crashes.command":       "  Befehl:     %s",
+	"crashes.exit":          "  Ende:       %s",
+	"crashes.started":       "  Gestartet:  %s",
+	"crashes.exited":        "  Beendet:    %s (lief %s)",
+	"crashes.restarts":      "  Neustarts:  %d",
+	"crashes.log":           "  Protokoll:  %s",
+	"crashes.no_output":     "Keine Ausgabe im Protokoll",
+	"crashes.last_output":   "Letzte Ausgabe:",
+
+	// asc daemon
+	"daemon.already_running":   "asc daemon läuft bereits (PID %d)",
+	"daemon.dry_run.start":     "den Agent-Stack im Hintergrund starten, mit Protokoll in %s",
+	"daemon.starting":          "Agent-Stack wird im Hintergrund gestartet...",
+	"daemon.started":           "✓ asc daemon gestartet (PID %d)",
+	"daemon.log":               "  Protokoll: %s",
+	"daemon.started_hint":      "  Mit 'asc daemon status' prüfen, mit 'asc daemon stop' beenden",
+	"daemon.not_running":       "asc daemon läuft nicht",
+	"daemon.dry_run.stop":      "asc daemon (PID %d) und den Agent-Stack beenden",
+	"daemon.stopping":          "asc daemon (PID %d) wird beendet...",
+	"daemon.stopped":           "✓ asc daemon beendet",
+	"daemon.status.stopped":    "asc daemon: ○ beendet",
+	"daemon.status.running":    "asc daemon: ● läuft (PID %d)",
+	"daemon.status.started":    "  Gestartet: %s (läuft seit %s)",
+	"daemon.status.directory":  "  Verzeichnis: %s",
+	"daemon.status.starting":   "  Agent-Stack wird gestartet...",
+	"daemon.status.processes":  "  Prozesse:",
+	"daemon.process.stopped":   "○ beendet",
+	"daemon.process.running":   "● läuft (PID %d)",
+	"daemon.process.restarted": ", %d-mal neu gestartet",
+
+	// asc doctor
+	"doctor.since_last_run":          "Seit dem letzten Lauf (%s): %d neu, %d wiederkehrend, %d behoben; Details mit 'asc doctor diff'",
+	"doctor.watching":                "Diagnose läuft alle %s, Berichte werden in %s gespeichert; mit Strg+C beenden",
+	"doctor.new_critical":            "⚠ Neues kritisches Problem: %s (%s)",
+	"doctor.dry_run.fix":             "%s (behebt %s)",
+	"doctor.dry_run.none":            "Probelauf: keine Probleme können automatisch behoben werden",
+	"doctor.fix_unmatched":           "⚠ Kein Problem passt zu %s; die IDs der gefundenen Probleme zeigt 'asc doctor --json'",
+	"doctor.confirm_fix":             "%s\n  Behebung: %s\nDiese Behebung anwenden? [y/N] ",
+	"doctor.history.none":            "Keine Doctor-Berichte gespeichert\n  Mit 'asc doctor' wird einer gespeichert",
+	"doctor.history.column.id":       "ID",
+	"doctor.history.column.run_at":   "AUSGEFÜHRT",
+	"doctor.history.column.issues":   "PROBLEME",
+	"doctor.history.column.critical": "KRITISCH",
+	"doctor.history.column.new":      "NEU",
+	"doctor.history.column.fixed":    "BEHOBEN",
+	"doctor.history.steady":          "unverändert",
+	"doctor.history.improving":       "besser",
+	"doctor.history.worsening":       "schlechter",
+	"doctor.history.trend":           "Trend über %d Berichte: %s (Probleme %d → %d, kritisch %d → %d)",
+	"doctor.diff.comparing":          "Vergleich %s → %s",
+	"doctor.diff.new":                "Neu",
+	"doctor.diff.recurring":          "Wiederkehrend",
+	"doctor.diff.fixed":              "Behoben",
+	"doctor.diff.none":               "  keine",
+
+	// asc events, asc exec and asc init
+	"events.none":                  "Keine Ereignisse",
+	"exec.dry_run.run":             "%s mit der Umgebung von %s ausführen",
+	"init.templates.available":     "Verfügbare Vorlagen:",
+	"init.templates.builtin":       "Eingebaute Vorlagen:",
+	"init.templates.custom":        "Eigene Vorlagen:",
+	"init.templates.custom_failed": "Eigene Vorlagen konnten nicht geladen werden: %v",
+	"init.templates.default":       "Standard %s",
+	"init.template_saved":          "Vorlage '%s' gespeichert",
+
+	// asc logs, asc notify, asc pipeline and asc prompts
+	"logs.none":                      "Keine Agentenprotokolle",
+	"logs.no_records":                "Keine aktuellen Protokolleinträge",
+	"notify.none":                    "Keine Benachrichtigungen konfiguriert; setze url in [notify.webhook], [notify.slack] oder [notify.discord]",
+	"notify.dry_run.send":            "eine Testbenachrichtigung an %s senden",
+	"notify.sent":                    "✓ %s: Testbenachrichtigung gesendet",
+	"notify.disabled":                "Warnung: Benachrichtigungen deaktiviert: %v",
+	"pipeline.dry_run.complete_last": "Phase %s abschließen, die letzte Phase der Pipeline",
+	"pipeline.dry_run.advance":       "Phase %s abschließen und zu Phase %s wechseln",
+	"pipeline.dry_run.reset":         "die Pipeline auf Phase %s zurücksetzen",
+	"pipeline.completed":             "✓ Alle Phasen der Pipeline abgeschlossen",
+	"pipeline.advanced":              "✓ Zu Phase %s gewechselt",
+	"pipeline.reset":                 "✓ Pipeline auf Phase %s zurückgesetzt",
+	"pipeline.status.complete":       "Pipeline abgeschlossen",
+	"pipeline.status.current":        "Aktuelle Phase: %s",
+	"pipeline.status.since":          " (seit %s)",
+	"pipeline.status.needs_done":     "benötigt %d erledigt",
+	"pipeline.status.needs_tasks":    "benötigt mindestens %d Aufgabe(n)",
+	"pipeline.status.gate_met":       "Schwelle erreicht",
+	"pipeline.status.tasks_done":     "%d/%d Aufgaben erledigt",
+	"pipeline.status.active_agents":  "Aktive Agenten: %s",
+	"pipeline.disabled":              "Warnung: Phasen-Pipeline deaktiviert: %v",
+	"prompts.none":                   "Keine Prompts gespeichert",
+	"prompts.none_hint":              "Füge einen hinzu mit: asc prompts add <name> <file>",
+	"prompts.versions":               "%d Versionen",
+	"prompts.dry_run.unchanged":      "Prompt %s ist unverändert; keine neue Version würde hinzugefügt",
+	"prompts.dry_run.add":            "%s als %s Version %d hinzufügen",
+	"prompts.dry_run.restore":        "%s Version %d als Version %d wiederherstellen",
+	"prompts.unchanged":              "Prompt %s ist unverändert; keine neue Version hinzugefügt",
+	"prompts.added":                  "✓ %s Version %d hinzugefügt",
+	"prompts.one_version":            "Prompt %s hat nur eine Version",
+	"prompts.identical":              "Die Versionen %d und %d von %s sind identisch",
+	"prompts.already_current":        "Version %d von %s ist bereits aktuell",
+	"prompts.restored":               "✓ %s Version %d als Version %d wiederhergestellt",
+
+	// asc reload, asc restart and asc scale
+	"reload.dry_run.reload":   "den laufenden Agenten-Stack bitten, %s neu zu laden",
+	"reload.reloading":        "%s wird neu geladen...",
+	"reload.unchanged":        "✓ %s neu geladen; keine Agenten geändert",
+	"reload.reloaded":         "✓ %s neu geladen",
+	"reload.added":            "Hinzugefügt",
+	"reload.removed":          "Entfernt",
+	"reload.changed":          "Geändert",
+	"restart.none":            "Keine laufenden Agenten zum Neustarten",
+	"restart.dry_run.restart": "%s neu starten",
+	"restart.stopping":        "%s wird gestoppt...",
+	"restart.restarting":      "%s wird neu gestartet...",
+	"restart.started":         "✓ %s gestartet (PID %d)",
+	"scale.dry_run.stop":      "%s stoppen",
+	"scale.dry_run.start":     "%s starten (Modell: %s): %s",
+	"scale.waits_for_phase":   "  Agent %s startet mit seiner Pipeline-Phase",
+	"scale.scaled":            "✓ %s auf %d skaliert",
+
+	// asc secrets
+	"secrets.keychain":                 "dem Schlüsselbund des Systems",
+	"secrets.dry_run.replace_key":      "den age-Schlüssel in %s durch einen in %s ersetzen",
+	"secrets.dry_run.generate_key":     "einen age-Schlüssel in %s erzeugen",
+	"secrets.dry_run.encrypt":          "%s nach %s.age für %d Empfänger verschlüsseln",
+	"secrets.dry_run.decrypt":          "%s.age nach %s entschlüsseln",
+	"secrets.dry_run.decrypt_replace":  "%s.age nach %s entschlüsseln und die Datei ersetzen",
+	"secrets.dry_run.back_up_key":      "%s nach %s.old sichern",
+	"secrets.dry_run.new_key":          "einen neuen age-Schlüssel in %s erzeugen",
+	"secrets.dry_run.reencrypt":        "%s mit dem neuen Schlüssel neu verschlüsseln",
+	"secrets.dry_run.inject":           "%s im Speicher entschlüsseln und %s mit seinen Variablen ausführen",
+	"secrets.key_exists":               "⚠ Ein age-Schlüssel existiert bereits in %s",
+	"secrets.overwrite_prompt":         "Überschreiben? (y/N): ",
+	"secrets.continue_anyway_prompt":   "Trotzdem fortfahren? (y/N): ",
+	"secrets.continue_prompt":          "Fortfahren? (y/N): ",
+	"secrets.aborted":                  "Abgebrochen.",
+	"secrets.generating":               "age-Schlüssel wird erzeugt...",
+	"secrets.generated":                "✓ age-Schlüssel erzeugt",
+	"secrets.key_location":             "Speicherort des Schlüssels: %s",
+	"secrets.public_key":               "Öffentlicher Schlüssel: %s",
+	"secrets.keep_safe":                "⚠ WICHTIG: Bewahre deinen Schlüssel sicher auf und committe ihn NIEMALS in git!",
+	"secrets.protected_keychain":       "✓ Der Schlüssel ist durch den Schlüsselbund des Systems geschützt",
+	"secrets.protected_passphrase":     "✓ Die Schlüsseldatei ist mit deiner Passphrase verschlüsselt und nur für dich lesbar",
+	"secrets.passphrase_unrecoverable": "⚠ Vergisst du die Passphrase, lässt sich der Schlüssel nicht wiederherstellen",
+	"secrets.protected_file":           "✓ Die Schlüsseldatei ist nur für dich lesbar",
+	"secrets.encrypting":               "%s wird verschlüsselt...",
+	"secrets.decrypting":               "%s.age wird entschlüsselt...",
+	"secrets.rotation_due":             "Der Schlüssel ist %d Tage alt und damit älter als die Rotationsfrist von %d Tagen",
+	"secrets.rotation_not_due":         "✓ Der Schlüssel ist %d Tage alt; die Rotation ist nach %d Tagen fällig",
+	"secrets.rotate_warning":           "⚠ Dies erzeugt einen neuen Schlüssel und verschlüsselt alle Dateien neu",
+	"secrets.nothing_to_reencrypt":     "Keine verschlüsselten Dateien zum Neuverschlüsseln gefunden",
+	"secrets.plaintext_hint":           "  Führe 'asc doctor --fix' aus, um die Klartextdateien in .gitignore aufzunehmen",
+	"secrets.status.title":             "Status der Geheimnisverwaltung",
+	"secrets.status.builtin":           "✓ age-Verschlüsselung ist eingebaut",
+	"secrets.status.key_keychain":      "✓ age-Schlüssel liegt im Schlüsselbund des Systems (vermerkt in %s)",
+	"secrets.status.key_protected":     "✓ age-Schlüssel liegt in %s (durch eine Passphrase geschützt)",
+	"secrets.status.key_exists":        "✓ age-Schlüssel liegt in %s",
+	"secrets.status.key_missing":       "✗ age-Schlüssel NICHT gefunden",
+	"secrets.status.public_key":        "  Öffentlicher Schlüssel: %s",
+	"secrets.status.run":               "  Ausführen: %s",
+	"secrets.status.key_age":           "  Alter des Schlüssels: %d Tage (Rotation nach %d Tagen)",
+	"secrets.status.recipients":        "Empfänger:",
+	"secrets.status.own_key_protected": "  ✓ dein Schlüssel (durch eine Passphrase geschützt)",
+	"secrets.status.own_key":           "(dein Schlüssel)",
+	"secrets.status.encrypted":         "Verschlüsselte Dateien:",
+	"secrets.status.unencrypted":       "Unverschlüsselte Dateien:",
+	"secrets.status.should_encrypt":    "(sollte verschlüsselt und in .gitignore sein)",
+	"secrets.status.none_found":        "  (keine gefunden)",
+
+	// asc services
+	"services.already_running": "mcp_agent_mail läuft bereits (PID %d)",
+	"services.dry_run.start":   "mcp_agent_mail starten: %s",
+	"services.dry_run.stop":    "mcp_agent_mail stoppen (PID %d)",
+	"services.started":         "✓ mcp_agent_mail gestartet (PID %d)",
+	"services.url":             "  URL: %s",
+	"services.stopping":        "mcp_agent_mail wird gestoppt (PID %d)...",
+	"services.stopped":         "✓ mcp_agent_mail gestoppt",
+	"services.status.stopped":  "mcp_agent_mail: ○ gestoppt",
+	"services.status.running":  "mcp_agent_mail: ● läuft (PID %d)",
+	"services.status.started":  "  Gestartet: %s",
+	"services.status.stale":    "mcp_agent_mail: ○ gestoppt (veraltete PID-Datei)",
+
+	// asc status
+	"status.none":                "Keine verwalteten Prozesse",
+	"status.column.name":         "NAME",
+	"status.column.status":       "STATUS",
+	"status.column.health":       "ZUSTAND",
+	"status.column.memory":       "SPEICHER",
+	"status.column.limits":       "GRENZEN",
+	"status.limits.none":         "keine",
+	"status.stopped":             "gestoppt",
+	"status.running":             "läuft",
+	"status.health_failures":     "%s: %d fehlgeschlagene Zustandsprüfung(en) in Folge: %s",
+	"status.stream.connected":    "MCP-Ereignisstrom: ● seit %s verbunden",
+	"status.stream.degraded":     "MCP-Ereignisstrom: ◐ seit %s beeinträchtigt, neue Verbindung wird aufgebaut (%d fehlgeschlagene(r) Versuch(e)): %s",
+	"status.stream.disconnected": "MCP-Ereignisstrom: ○ seit %s getrennt",
+	"status.stream.polling":      "MCP-Ereignisstrom: ○ seit %s getrennt, die TUI fragt ab: %s",
+
+	// asc sync
+	"sync.in_sync": "Bereits synchron",
+	"sync.summary": "%d verknüpfte Aufgabe(n), %d Änderung(en), %d Konflikt(e)",
+
+	// asc tasks and asc telemetry
+	"tasks.none":                      "Keine Aufgaben",
+	"tasks.column.priority":           "PRI",
+	"tasks.column.phase":              "PHASE",
+	"tasks.column.assignee":           "ZUSTÄNDIG",
+	"tasks.column.title":              "TITEL",
+	"tasks.column.time":               "ZEIT",
+	"tasks.column.actor":              "AKTEUR",
+	"tasks.column.change":             "ÄNDERUNG",
+	"tasks.history.none":              "Kein Verlauf für %s aufgezeichnet",
+	"tasks.history.unknown":           "unbekannt",
+	"tasks.history.seen":              "* mit bd vorgenommen und von asc bemerkt; der zuständigen Person der Aufgabe zugeordnet",
+	"tasks.dry_run.create":            "Aufgabe %q anlegen",
+	"tasks.dry_run.update":            "Aufgabe %s aktualisieren",
+	"tasks.dry_run.close":             "Aufgabe %s schließen",
+	"tasks.created":                   "✓ %d Aufgabe(n) angelegt",
+	"tasks.updated":                   "✓ %d Aufgabe(n) aktualisiert",
+	"tasks.closed":                    "✓ %d Aufgabe(n) geschlossen",
+	"tasks.watching":                  "%d Aufgaben in %s werden beobachtet (Strg+C zum Beenden)",
+	"tasktemplate.dry_run.save":       "Aufgabenvorlage %s mit %d Aufgabe(n) in %s speichern",
+	"tasktemplate.dry_run.delete":     "Aufgabenvorlage %s löschen",
+	"tasktemplate.dry_run.run":        "die %d Aufgabe(n) von %s anlegen, fällig %s",
+	"tasktemplate.saved":              "✓ Aufgabenvorlage %s mit %d Aufgabe(n) gespeichert",
+	"tasktemplate.recurs":             "  Wiederholt sich %s; nächster Lauf %s (asc daemon oder asc tasks template run-due per cron)",
+	"tasktemplate.created":            "✓ %d Aufgabe(n) aus %s angelegt",
+	"tasktemplate.none":               "Keine Aufgabenvorlagen (lege eine mit asc tasks template create an)",
+	"tasktemplate.column.tasks":       "AUFG.",
+	"tasktemplate.column.params":      "PARAMETER",
+	"tasktemplate.column.next_run":    "NÄCHSTER LAUF",
+	"tasktemplate.column.description": "BESCHREIBUNG",
+	"tasktemplate.deleted":            "✓ Aufgabenvorlage %s gelöscht",
+	"tasktemplate.run_failed":         "✗ %s (fällig %s): %v",
+	"telemetry.disabled":              "Warnung: Telemetrie-Export deaktiviert: %v",
+
+	// asc test, asc top, asc upgrade and asc worktree
+	"test.running":                   "Test des Agenten-Stacks läuft...",
+	"test.dry_run.create":            "eine Testaufgabe in %s anlegen",
+	"test.dry_run.send":              "eine Testnachricht an %s senden",
+	"test.dry_run.delete":            "die Testaufgabe löschen",
+	"test.step.create":               "Testaufgabe in beads wird angelegt... ",
+	"test.step.send":                 "Testnachricht wird an den MCP-Server gesendet... ",
+	"test.step.verify_task":          "Abruf der beads-Aufgabe wird geprüft... ",
+	"test.step.verify_message":       "Abruf der MCP-Nachricht wird geprüft... ",
+	"test.step.cleanup":              "Testartefakte werden entfernt... ",
+	"test.cleaning_task":             "   Testaufgabe wird entfernt... ",
+	"test.cleanup_failed":            "✗ (fehlgeschlagen: %v)",
+	"test.ok":                        "✓ OK",
+	"test.ok_id":                     "✓ OK (ID: %s)",
+	"test.failed":                    "✗ FEHLGESCHLAGEN",
+	"test.interrupted":               "✗ UNTERBROCHEN",
+	"test.timeout":                   "✗ FEHLGESCHLAGEN (Zeitüberschreitung)",
+	"test.healthy":                   "✓ Der Stack ist gesund",
+	"test.summary":                   "Alle Komponenten kommunizieren korrekt:",
+	"test.summary.beads":             "  • die beads-Aufgabendatenbank ist erreichbar",
+	"test.summary.mcp":               "  • der mcp_agent_mail-Server antwortet",
+	"test.summary.messages":          "  • der Nachrichtenaustausch funktioniert",
+	"top.title":                      "%s, %d von %d Prozess(en) laufen",
+	"top.column.uptime":              "LAUFZEIT",
+	"top.column.restarts":            "NEUSTARTS",
+	"top.column.log_rate":            "LOG/S",
+	"upgrade.available":              "asc %s ist verfügbar (installiert: %s)",
+	"upgrade.available_hint":         "  Führe 'asc upgrade --channel %s' aus, um es zu installieren",
+	"upgrade.up_to_date":             "asc %s ist aktuell (neueste %s-Version: %s)",
+	"upgrade.dry_run.download":       "asc %s für %s/%s herunterladen und prüfen",
+	"upgrade.dry_run.replace":        "%s ersetzen",
+	"upgrade.downloading":            "asc %s wird heruntergeladen...",
+	"upgrade.unsigned":               "dieser Build hat keinen Signaturschlüssel für Versionen; nur die Prüfsumme wurde geprüft",
+	"upgrade.upgraded":               "✓ asc von %s auf %s aktualisiert",
+	"upgrade.restart_hint":           "  Starte laufende Stacks neu (asc down, dann asc up), um es zu verwenden",
+	"worktree.none":                  "Keine Agenten-Worktrees",
+	"worktree.state":                 "%d voraus, %d hinter %s",
+	"worktree.state.uncommitted":     ", %d nicht committet",
+	"worktree.missing":               "(fehlt)",
+	"worktree.nothing_to_merge":      "Agent %s hat nichts zum Zusammenführen",
+	"worktree.merged":                "✓ %d Commit(s) von Agent %s zusammengeführt",
+	"worktree.nothing_to_prune":      "Keine Worktrees zu entfernen",
+	"worktree.removed_branch":        "✓ Worktree und Branch von Agent %s entfernt",
+	"worktree.removed":               "✓ Worktree von Agent %s entfernt (sein Branch hat nicht zusammengeführte Arbeit und wurde behalten)",
+	"worktree.skipping_running":      "Agent %s wird übersprungen: er läuft (stoppe ihn zuerst oder verwende --force)",
+	"worktree.dry_run.uncommitted":   "Agent %s hat %d nicht committete Änderung(en) und würde nicht zusammengeführt",
+	"worktree.dry_run.merge":         "%d Commit(s) von %s in %s zusammenführen",
+	"worktree.dry_run.kept":          "Worktree von Agent %s hat %d nicht committete Änderung(en) und würde behalten; verwende --force, um sie zu verwerfen",
+	"worktree.dry_run.remove":        "Worktree %s von Agent %s entfernen",
+	"worktree.dry_run.delete_branch": "Branch %s löschen",
+	"worktree.dry_run.keep_branch":   "Branch %s behalten, der %d nicht zusammengeführte(n) Commit(s) hat",
+
+	// asc up and asc down
+	"plan.title":                  "Plan:",
+	"plan.keep":                   "%s beibehalten (PID %d, läuft bereits)",
+	"plan.stop":                   "%s beenden (PID %d)",
+	"plan.clean":                  "%s aufräumen (veraltete PID-Datei, %s)",
+	"plan.start_stale":            "%s starten (ersetzt veraltete PID-Datei, %s)",
+	"plan.start":                  "%s starten",
+	"plan.pid_not_running":        "PID %d läuft nicht",
+	"plan.pid_reused":             "PID %d gehört jetzt zu einem anderen Prozess",
+	"up.dry_run.fetch_secret":     "%s aus %s abrufen",
+	"up.dry_run.start_service":    "%s: %s",
+	"up.dry_run.wait_ready":       "warten, bis %s bereit ist (%s)",
+	"up.dry_run.start_with_phase": "%s mit seiner Pipeline-Phase (Modell: %s): %s",
+	"up.dry_run.start_in_docker":  "%s (Modell: %s) in einem %s-Container: %s",
+	"up.dry_run.start_agent":      "%s (Modell: %s): %s",
+	"up.dry_run.open_tui":         "das TUI-Dashboard öffnen",
+	"up.decrypting_secrets":       "🔐 Geheimnisse werden entschlüsselt...",
+	"up.secrets_decrypted":        "✓ Geheimnisse entschlüsselt",
+	"up.unknown_key":              "⚠ %s wird ignoriert",
+	"up.source_stale":             "⚠ config_source %s konnte nicht abgerufen werden; die am %s abgerufene Kopie wird verwendet",
+	"up.service_running":          "✓ %s läuft bereits (PID %d)",
+	"up.service_starting":         "Dienst %s wird gestartet...",
+	"up.service_waiting":          "Warten, bis %s bereit ist (%s)...",
+	"up.service_ready":            "✓ %s bereit",
+	"up.ship_logs_disabled":       "Warnung: Protokollversand deaktiviert: %v",
+	"up.ship_logs_failed":         "Warnung: verbleibende Protokolle konnten nicht versendet werden: %v",
+	"up.launching":                "%d Agent(en) werden gestartet...",
+	"up.agent_pipeline":           "  Agent %s startet mit seiner Pipeline-Phase",
+	"up.agent_paused":             "  Agent %s ist wegen Budgetüberschreitung pausiert (siehe asc budget)",
+	"up.agent_running":            "  ✓ Agent %s läuft bereits (PID %d)",
+	"up.agent_starting":           "  Agent wird gestartet: %s (Modell: %s)...",
+	"up.agent_started":            "  ✓ Agent %s gestartet",
+	"up.agents_started":           "Alle Agenten erfolgreich gestartet",
+	"up.dependency_not_started":   "  ⚠ Agent %s hängt von %s ab, das jetzt nicht gestartet wird",
+	"up.agent_waiting":            "  Warten, bis Agent %s bereit ist (%s)...",
+	"up.agent_ready":              "  ✓ Agent %s bereit",
+	"up.shutting_down":            "Agent-Stack wird heruntergefahren...",
+	"down.dry_run.stop_daemon":    "asc daemon (PID %d) beenden",
+	"down.stop_failed":            "Warnung: Einige Prozesse wurden nicht sauber beendet: %v",
+}
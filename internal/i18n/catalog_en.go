@@ -0,0 +1,502 @@
+package i18n
+
+// catalogEN is the English catalog. Every key has an English message,
+// which other catalogs fall back to.
+var catalogEN = map[string]string{
+	// asc up and asc down
+	"up.dependency_check_failed": "Dependency check failed. Run 'asc check' for details.",
+	"down.no_processes":          "No running processes found",
+	"down.offline":               "Agent stack is offline",
+	"down.shutting_down":         "Shutting down %d process(es)...",
+
+	// asc doctor reports
+	"doctor.title":             "ASC DOCTOR - DIAGNOSTIC REPORT",
+	"doctor.run_at":            "Run at: %s",
+	"doctor.status":            "Status: %s",
+	"doctor.no_issues":         "✓ No issues detected",
+	"doctor.severity_heading":  "─── %s SEVERITY (%d) ───",
+	"doctor.category":          "  Category: %s",
+	"doctor.description":       "  Description: %s",
+	"doctor.impact":            "  Impact: %s",
+	"doctor.remediation":       "  Remediation: %s",
+	"doctor.auto_fixable":      "  ✓ Auto-fixable with --fix flag",
+	"doctor.fixes_applied":     "─── FIXES APPLIED ───",
+	"doctor.recent_logs":       "─── RECENT ASC LOGS (last %d) ───",
+	"doctor.more_logs":         "Run 'asc logs --self' for more.",
+	"doctor.healthy":           "✓ All checks passed - system is healthy",
+	"doctor.found_issues":      "Found %d issue(s): ",
+	"doctor.count.critical":    "%d critical",
+	"doctor.count.high":        "%d high",
+	"doctor.count.medium":      "%d medium",
+	"doctor.count.low":         "%d low",
+	"doctor.severity.critical": "critical",
+	"doctor.severity.high":     "high",
+	"doctor.severity.medium":   "medium",
+	"doctor.severity.low":      "low",
+	"doctor.severity.info":     "info",
+
+	// TUI dashboard
+	"tui.agents.title":             "Agent Status",
+	"tui.agents.hint":              "1-9:select p:pause k:kill R:restart l:logs",
+	"tui.agents.loading":           "Loading agent status...",
+	"tui.agents.none":              "No agents configured",
+	"tui.agents.pipeline_complete": " · pipeline complete",
+	"tui.agents.phase":             " · phase: %s",
+	"tui.tasks.title":              "Task Stream",
+	"tui.tasks.hint":               "↑↓:select c:claim v:view h:history n:new f:filter",
+	"tui.tasks.loading":            "Loading tasks...",
+	"tui.tasks.none":               "No open or in-progress tasks",
+	"tui.tasks.blocked_by":         "(blocked by %s)",
+	"tui.logs.title":               "MCP Interaction Log",
+	"tui.logs.hint":                "/:search a:agent m:type x:clear e:export PgUp/PgDn/End:scroll",
+	"tui.logs.none":                "No messages yet",
+	"tui.logs.newer":               " (↑%d newer)",
+	"tui.footer.quit":              " quit | ",
+	"tui.footer.refresh":           " refresh | ",
+	"tui.footer.test":              " test",
+
+	// Messages of every command
+	"dry_run":                    "Dry run: would %s",
+	"dry_run.action":             "%s",
+	"warning":                    "Warning: %v",
+	"root.dry_run.migrate_state": "migrate %s from state schema %d to %d",
+	"root.migrated_state":        "Migrated %s to the state format of this release (backup in %s)",
+	"root.source_stale":          "⚠ %s could not be fetched; using the copy fetched %s",
+	"root.migrated_config":       "Migrated %s to the config layout of this release (backup in %s)",
+	"root.syslog_disabled":       "Warning: syslog logging disabled: %v",
+	"root.journald_disabled":     "Warning: journald logging disabled: %v",
+
+	// asc attach
+	"attach.not_running": "%s is not running",
+
+	// asc audit
+	"audit.none": "No audited actions",
+
+	// asc backup
+	"backup.env_not_backed_up": "Warning: .env is not backed up; run 'asc secrets encrypt' to back up its secrets as .env.age",
+	"backup.created":           "✓ Backed up %d file(s), %.1f MB, to %s",
+	"backup.dry_run.create":    "write %s with asc.toml, .env.age, %s, and %s",
+	"backup.dry_run.restore":   "restore %s",
+	"backup.key_not_included":  "  The age key is not included; copy %s separately to decrypt .env.age elsewhere",
+	"backup.restore_unchanged": "Dry run: every file is already as archived",
+	"backup.restored":          "✓ Restored %d file(s) from the backup of %s (asc %s)",
+	"backup.no_key":            "  No age key in %s; copy it from the original machine to decrypt .env.age",
+
+	// asc budget
+	"budget.dry_run.override":     "let agent %s exceed its budget until the period ends",
+	"budget.dry_run.resume":       "resume agent %s",
+	"budget.overridden":           "✓ Agent %s may exceed its budget until the period ends",
+	"budget.resumed":              "✓ Agent %s resumed",
+	"budget.spend_to_date":        "Spend to date: $%.2f",
+	"budget.spend_since":          "Spend since %s (%s): $%.2f",
+	"budget.limit":                "%s %-16s $%8.2f of $%8.2f  %3.0f%%",
+	"budget.paused":               "Paused agents:",
+	"budget.paused_agent":         "  %s (%s budget, since %s)",
+	"budget.resume_hint":          "Run 'asc budget resume <agent>' to resume an agent",
+	"budget.enforcement_disabled": "Warning: budget enforcement disabled: %v",
+	"budget.ignored":              "Budgets are not enforced (--ignore-budget); warnings are still sent",
+	"budget.check_failed":         "Warning: budget check failed: %v",
+
+	// asc check
+	"check.title":                       "Dependency Checks",
+	"check.column.component":            "Component",
+	"check.column.status":               "Status",
+	"check.column.message":              "Message",
+	"check.status.pass":                 "✓ PASS",
+	"check.status.fail":                 "✗ FAIL",
+	"check.status.warn":                 "⚠ WARN",
+	"check.binary.missing":              "Binary '%s' not found in PATH",
+	"check.binary.found":                "Binary '%s' found",
+	"check.file.missing":                "File '%s' does not exist",
+	"check.file.inaccessible":           "Cannot access file '%s': %v",
+	"check.file.directory":              "'%s' is a directory, not a file",
+	"check.file.unreadable":             "File '%s' is not readable: %v",
+	"check.file.ok":                     "File '%s' exists and is readable",
+	"check.config.missing":              "Config file not found at '%s'",
+	"check.config.invalid_toml":         "Invalid TOML syntax: %v",
+	"check.config.no_beads_db_path":     "Missing required field: core.beads_db_path",
+	"check.config.no_mcp_agent_mail":    "Missing services.mcp_agent_mail configuration",
+	"check.config.ok":                   "Configuration file is valid",
+	"check.env.missing":                 ".env file not found at '%s'",
+	"check.env.unreadable":              "Cannot read .env file: %v",
+	"check.env.missing_keys":            "Missing API keys: %v",
+	"check.env.ok":                      "All required API keys present",
+	"check.env.ok_from":                 "All required API keys present (%s)",
+	"check.env.injected":                "injected from %s",
+	"check.env.resolved":                "resolved from [secrets.env] at startup",
+	"check.timed_out":                   "Check timed out after %s",
+	"check.custom.not_run":              "Command '%s' could not be run: %v",
+	"check.custom.exit_code":            "Command '%s' exited with %d, expected %d",
+	"check.custom.ok":                   "Command '%s' succeeded",
+	"check.proxy.invalid_override":      "Invalid services.mcp_agent_mail.proxy: %v",
+	"check.proxy.invalid_override_hint": "Set proxy to a URL such as http://proxy:3128, or \"direct\" to bypass the proxy",
+	"check.proxy.invalid_env":           "Invalid proxy environment: %v",
+	"check.proxy.invalid_env_hint":      "Fix or unset HTTP_PROXY/HTTPS_PROXY",
+	"check.proxy.ok":                    "Proxy: %s",
+	"check.proxy.via":                   "; MCP server reached via %s",
+	"check.proxy.direct":                "; MCP server reached directly (NO_PROXY or loopback)",
+	"check.version.invalid":             "Invalid requirement for '%s': %v",
+	"check.version.unknown":             "Could not determine version of '%s': %v",
+	"check.version.unsatisfied":         "Binary '%s' version %s does not satisfy %s",
+	"check.version.ok":                  "Binary '%s' version %s satisfies %s",
+	"check.install.nothing":             "Nothing to install.",
+	"check.install.no_package_manager":  "⚠ No supported package manager found (brew, apt, dnf, winget); only Python tools can be installed",
+	"check.install.no_installer":        "  ? %s: no installer available, install it manually",
+	"check.dry_run.install":             "offer to install %s with '%s'",
+	"check.install.prompt":              "Install %s with '%s'? (y/N): ",
+	"check.install.skipped":             "  - %s: skipped",
+	"check.install.failed":              "  ✗ %s: install failed: %v",
+	"check.install.installed":           "  ✓ %s: installed",
+	"check.install.summary":             "Install summary:",
+
+	// asc cleanup
+	"cleanup.dry_run.none":   "Dry run: no logs older than %d days in %s",
+	"cleanup.dry_run.remove": "remove %s",
+	"cleanup.cleaning":       "Cleaning up logs older than %d days from %s...",
+	"cleanup.done":           "✓ Log cleanup completed",
+
+	// asc config
+	"config.dry_run.set":     "set %s = %s in %s",
+	"config.set":             "✓ Set %s = %s in %s",
+	"config.valid":           "✓ %s is valid",
+	"config.dry_run.migrate": "migrate %s from config version %d to %d",
+	"config.current_layout":  "%s is in the layout of this release",
+	"config.migrated":        "✓ Migrated %s from config version %d to %d (backup in %s)",
+	"config.no_differences":  "No differences between %s and %s",
+
+	// asc crashes
+	"crashes.none":          "No crashes",
+	"crashes.column.id":     "ID",
+	"crashes.column.agent":  "AGENT",
+	"crashes.column.exited": "EXITED",
+	"crashes.column.exit":   "EXIT",
+	"crashes.column.ran":    "RAN",
+	"crashes.exit_status":   "status %d",
+	"crashes.crash":         "Crash %s",
+	"crashes.agent":         "  Agent:    %s (PID %d)",
+	"crashes.command":       "  Command:  %s",
+	"crashes.exit":          "  Exit:     %s",
+	"crashes.started":       "  Started:  %s",
+	"crashes.exited":        "  Exited:   %s (ran %s)",
+	"crashes.restarts":      "  Restarts: %d",
+	"crashes.log":           "  Log:      %s",
+	"crashes.no_output":     "No output in the log",
+	"crashes.last_output":   "Last output:",
+
+	// asc daemon
+	"daemon.already_running":   "asc daemon is already running (PID %d)",
+	"daemon.dry_run.start":     "start the agent stack in the background, logging to %s",
+	"daemon.starting":          "Starting agent stack in the background...",
+	"daemon.started":           "✓ asc daemon started (PID %d)",
+	"daemon.log":               "  Log: %s",
+	"daemon.started_hint":      "  Run 'asc daemon status' to check on it, or 'asc daemon stop' to stop it",
+	"daemon.not_running":       "asc daemon is not running",
+	"daemon.dry_run.stop":      "stop asc daemon (PID %d) and the agent stack",
+	"daemon.stopping":          "Stopping asc daemon (PID %d)...",
+	"daemon.stopped":           "✓ asc daemon stopped",
+	"daemon.status.stopped":    "asc daemon: ○ stopped",
+	"daemon.status.running":    "asc daemon: ● running (PID %d)",
+	"daemon.status.started":    "  Started: %s (up %s)",
+	"daemon.status.directory":  "  Directory: %s",
+	"daemon.status.starting":   "  Starting the agent stack...",
+	"daemon.status.processes":  "  Processes:",
+	"daemon.process.stopped":   "○ stopped",
+	"daemon.process.running":   "● running (PID %d)",
+	"daemon.process.restarted": ", restarted %d time(s)",
+
+	// asc doctor
+	"doctor.since_last_run":          "Since the last run (%s): %d new, %d recurring, %d fixed; run 'asc doctor diff' for details",
+	"doctor.watching":                "Running diagnostics every %s, saving reports to %s; press Ctrl+C to stop",
+	"doctor.new_critical":            "⚠ New critical issue: %s (%s)",
+	"doctor.dry_run.fix":             "%s (fixes %s)",
+	"doctor.dry_run.none":            "Dry run: no issues can be fixed automatically",
+	"doctor.fix_unmatched":           "⚠ No issue matches %s; run 'asc doctor --json' for the IDs of the issues found",
+	"doctor.confirm_fix":             "%s\n  Fix: %s\nApply this fix? [y/N] ",
+	"doctor.history.none":            "No doctor reports saved\n  Run 'asc doctor' to save one",
+	"doctor.history.column.id":       "ID",
+	"doctor.history.column.run_at":   "RUN AT",
+	"doctor.history.column.issues":   "ISSUES",
+	"doctor.history.column.critical": "CRITICAL",
+	"doctor.history.column.new":      "NEW",
+	"doctor.history.column.fixed":    "FIXED",
+	"doctor.history.steady":          "steady",
+	"doctor.history.improving":       "improving",
+	"doctor.history.worsening":       "worsening",
+	"doctor.history.trend":           "Trend over %d reports: %s (issues %d → %d, critical %d → %d)",
+	"doctor.diff.comparing":          "Comparing %s → %s",
+	"doctor.diff.new":                "New",
+	"doctor.diff.recurring":          "Recurring",
+	"doctor.diff.fixed":              "Fixed",
+	"doctor.diff.none":               "  none",
+
+	// asc events, asc exec and asc init
+	"events.none":                  "No events",
+	"exec.dry_run.run":             "run %s with the environment of %s",
+	"init.templates.available":     "Available templates:",
+	"init.templates.builtin":       "Built-in templates:",
+	"init.templates.custom":        "Custom templates:",
+	"init.templates.custom_failed": "Failed to load custom templates: %v",
+	"init.templates.default":       "default %s",
+	"init.template_saved":          "Template '%s' saved successfully",
+
+	// asc logs, asc notify, asc pipeline and asc prompts
+	"logs.none":                      "No agent logs",
+	"logs.no_records":                "No recent log records",
+	"notify.none":                    "No notifications are configured; set url in [notify.webhook], [notify.slack], or [notify.discord]",
+	"notify.dry_run.send":            "send a test notification to %s",
+	"notify.sent":                    "✓ %s: test notification sent",
+	"notify.disabled":                "Warning: notifications disabled: %v",
+	"pipeline.dry_run.complete_last": "complete phase %s, the last phase of the pipeline",
+	"pipeline.dry_run.advance":       "complete phase %s and advance to phase %s",
+	"pipeline.dry_run.reset":         "reset the pipeline to phase %s",
+	"pipeline.completed":             "✓ All pipeline phases completed",
+	"pipeline.advanced":              "✓ Advanced to phase %s",
+	"pipeline.reset":                 "✓ Pipeline reset to phase %s",
+	"pipeline.status.complete":       "Pipeline complete",
+	"pipeline.status.current":        "Current phase: %s",
+	"pipeline.status.since":          " (since %s)",
+	"pipeline.status.needs_done":     "needs %d done",
+	"pipeline.status.needs_tasks":    "needs at least %d task(s)",
+	"pipeline.status.gate_met":       "gate met",
+	"pipeline.status.tasks_done":     "%d/%d tasks done",
+	"pipeline.status.active_agents":  "Active agents: %s",
+	"pipeline.disabled":              "Warning: phase pipeline disabled: %v",
+	"prompts.none":                   "No prompts stored",
+	"prompts.none_hint":              "Add one with: asc prompts add <name> <file>",
+	"prompts.versions":               "%d versions",
+	"prompts.dry_run.unchanged":      "Prompt %s is unchanged; no new version would be added",
+	"prompts.dry_run.add":            "add %s as %s version %d",
+	"prompts.dry_run.restore":        "restore %s version %d as version %d",
+	"prompts.unchanged":              "Prompt %s is unchanged; no new version added",
+	"prompts.added":                  "✓ Added %s version %d",
+	"prompts.one_version":            "Prompt %s has only one version",
+	"prompts.identical":              "Versions %d and %d of %s are identical",
+	"prompts.already_current":        "Version %d of %s is already current",
+	"prompts.restored":               "✓ Restored %s version %d as version %d",
+
+	// asc reload, asc restart and asc scale
+	"reload.dry_run.reload":   "ask the running agent stack to reload %s",
+	"reload.reloading":        "Reloading %s...",
+	"reload.unchanged":        "✓ Reloaded %s; no agents changed",
+	"reload.reloaded":         "✓ Reloaded %s",
+	"reload.added":            "Added",
+	"reload.removed":          "Removed",
+	"reload.changed":          "Changed",
+	"restart.none":            "No running agents to restart",
+	"restart.dry_run.restart": "restart %s",
+	"restart.stopping":        "Stopping %s...",
+	"restart.restarting":      "Restarting %s...",
+	"restart.started":         "✓ %s started (PID %d)",
+	"scale.dry_run.stop":      "stop %s",
+	"scale.dry_run.start":     "start %s (model: %s): %s",
+	"scale.waits_for_phase":   "  Agent %s starts with its pipeline phase",
+	"scale.scaled":            "✓ %s scaled to %d",
+
+	// asc secrets
+	"secrets.keychain":                 "the OS keychain",
+	"secrets.dry_run.replace_key":      "replace the age key at %s with one in %s",
+	"secrets.dry_run.generate_key":     "generate an age key in %s",
+	"secrets.dry_run.encrypt":          "encrypt %s to %s.age for %d recipient(s)",
+	"secrets.dry_run.decrypt":          "decrypt %s.age to %s",
+	"secrets.dry_run.decrypt_replace":  "decrypt %s.age to %s, replacing it",
+	"secrets.dry_run.back_up_key":      "back up %s to %s.old",
+	"secrets.dry_run.new_key":          "generate a new age key at %s",
+	"secrets.dry_run.reencrypt":        "re-encrypt %s with the new key",
+	"secrets.dry_run.inject":           "decrypt %s in memory and run %s with its variables",
+	"secrets.key_exists":               "⚠ Age key already exists at %s",
+	"secrets.overwrite_prompt":         "Do you want to overwrite it? (y/N): ",
+	"secrets.continue_anyway_prompt":   "Continue anyway? (y/N): ",
+	"secrets.continue_prompt":          "Continue? (y/N): ",
+	"secrets.aborted":                  "Aborted.",
+	"secrets.generating":               "Generating age key...",
+	"secrets.generated":                "✓ Age key generated successfully",
+	"secrets.key_location":             "Key location: %s",
+	"secrets.public_key":               "Public key: %s",
+	"secrets.keep_safe":                "⚠ IMPORTANT: Keep your key safe and NEVER commit it to git!",
+	"secrets.protected_keychain":       "✓ The key is protected by the OS keychain",
+	"secrets.protected_passphrase":     "✓ The key file is encrypted with your passphrase and readable only by you",
+	"secrets.passphrase_unrecoverable": "⚠ There is no way to recover the key if you forget the passphrase",
+	"secrets.protected_file":           "✓ The key file is readable only by you",
+	"secrets.encrypting":               "Encrypting %s...",
+	"secrets.decrypting":               "Decrypting %s.age...",
+	"secrets.rotation_due":             "Key is %d days old, past the %d-day rotation policy",
+	"secrets.rotation_not_due":         "✓ Key is %d days old; rotation is due after %d days",
+	"secrets.rotate_warning":           "⚠ This will generate a new key and re-encrypt all files",
+	"secrets.nothing_to_reencrypt":     "No encrypted files found to re-encrypt",
+	"secrets.plaintext_hint":           "  Run 'asc doctor --fix' to add the plaintext files to .gitignore",
+	"secrets.status.title":             "Secrets Management Status",
+	"secrets.status.builtin":           "✓ age encryption is built in",
+	"secrets.status.key_keychain":      "✓ Age key exists in the OS keychain (recorded in %s)",
+	"secrets.status.key_protected":     "✓ Age key exists at %s (protected by a passphrase)",
+	"secrets.status.key_exists":        "✓ Age key exists at %s",
+	"secrets.status.key_missing":       "✗ Age key NOT found",
+	"secrets.status.public_key":        "  Public key: %s",
+	"secrets.status.run":               "  Run: %s",
+	"secrets.status.key_age":           "  Key age: %d days (rotation after %d days)",
+	"secrets.status.recipients":        "Recipients:",
+	"secrets.status.own_key_protected": "  ✓ your key (protected by a passphrase)",
+	"secrets.status.own_key":           "(your key)",
+	"secrets.status.encrypted":         "Encrypted Files:",
+	"secrets.status.unencrypted":       "Unencrypted Files:",
+	"secrets.status.should_encrypt":    "(should be encrypted and gitignored)",
+	"secrets.status.none_found":        "  (none found)",
+
+	// asc services
+	"services.already_running": "mcp_agent_mail is already running (PID %d)",
+	"services.dry_run.start":   "start mcp_agent_mail: %s",
+	"services.dry_run.stop":    "stop mcp_agent_mail (PID %d)",
+	"services.started":         "✓ mcp_agent_mail started (PID %d)",
+	"services.url":             "  URL: %s",
+	"services.stopping":        "Stopping mcp_agent_mail (PID %d)...",
+	"services.stopped":         "✓ mcp_agent_mail stopped",
+	"services.status.stopped":  "mcp_agent_mail: ○ stopped",
+	"services.status.running":  "mcp_agent_mail: ● running (PID %d)",
+	"services.status.started":  "  Started: %s",
+	"services.status.stale":    "mcp_agent_mail: ○ stopped (stale PID file)",
+
+	// asc status
+	"status.none":                "No managed processes",
+	"status.column.name":         "NAME",
+	"status.column.status":       "STATUS",
+	"status.column.health":       "HEALTH",
+	"status.column.memory":       "MEMORY",
+	"status.column.limits":       "LIMITS",
+	"status.limits.none":         "none",
+	"status.stopped":             "stopped",
+	"status.running":             "running",
+	"status.health_failures":     "%s: %d failed health check(s) in a row: %s",
+	"status.stream.connected":    "MCP event stream: ● connected for %s",
+	"status.stream.degraded":     "MCP event stream: ◐ degraded for %s, reconnecting (%d failed attempt(s)): %s",
+	"status.stream.disconnected": "MCP event stream: ○ disconnected for %s",
+	"status.stream.polling":      "MCP event stream: ○ disconnected for %s, TUI polling: %s",
+
+	// asc sync
+	"sync.in_sync": "Already in sync",
+	"sync.summary": "%d linked task(s), %d change(s), %d conflict(s)",
+
+	// asc tasks and asc telemetry
+	"tasks.none":                      "No tasks",
+	"tasks.column.priority":           "PRI",
+	"tasks.column.phase":              "PHASE",
+	"tasks.column.assignee":           "ASSIGNEE",
+	"tasks.column.title":              "TITLE",
+	"tasks.column.time":               "TIME",
+	"tasks.column.actor":              "ACTOR",
+	"tasks.column.change":             "CHANGE",
+	"tasks.history.none":              "No recorded history for %s",
+	"tasks.history.unknown":           "unknown",
+	"tasks.history.seen":              "* made with bd and seen by asc; attributed to the task's assignee",
+	"tasks.dry_run.create":            "create task %q",
+	"tasks.dry_run.update":            "update task %s",
+	"tasks.dry_run.close":             "close task %s",
+	"tasks.created":                   "✓ Created %d task(s)",
+	"tasks.updated":                   "✓ Updated %d task(s)",
+	"tasks.closed":                    "✓ Closed %d task(s)",
+	"tasks.watching":                  "Watching %d tasks in %s (Ctrl+C to stop)",
+	"tasktemplate.dry_run.save":       "save task template %s with %d task(s) in %s",
+	"tasktemplate.dry_run.delete":     "delete task template %s",
+	"tasktemplate.dry_run.run":        "create the %d task(s) of %s, due %s",
+	"tasktemplate.saved":              "✓ Saved task template %s with %d task(s)",
+	"tasktemplate.recurs":             "  Recurs %s; next run %s (asc daemon, or asc tasks template run-due from cron)",
+	"tasktemplate.created":            "✓ Created %d task(s) from %s",
+	"tasktemplate.none":               "No task templates (create one with asc tasks template create)",
+	"tasktemplate.column.tasks":       "TASKS",
+	"tasktemplate.column.params":      "PARAMS",
+	"tasktemplate.column.next_run":    "NEXT RUN",
+	"tasktemplate.column.description": "DESCRIPTION",
+	"tasktemplate.deleted":            "✓ Deleted task template %s",
+	"tasktemplate.run_failed":         "✗ %s (due %s): %v",
+	"telemetry.disabled":              "Warning: telemetry export disabled: %v",
+
+	// asc test, asc top, asc upgrade and asc worktree
+	"test.running":                   "Running agent stack test...",
+	"test.dry_run.create":            "create a test task in %s",
+	"test.dry_run.send":              "send a test message to %s",
+	"test.dry_run.delete":            "delete the test task",
+	"test.step.create":               "Creating test beads task... ",
+	"test.step.send":                 "Sending test message to MCP server... ",
+	"test.step.verify_task":          "Verifying beads task retrieval... ",
+	"test.step.verify_message":       "Verifying MCP message retrieval... ",
+	"test.step.cleanup":              "Cleaning up test artifacts... ",
+	"test.cleaning_task":             "   Cleaning up test task... ",
+	"test.cleanup_failed":            "✗ (failed: %v)",
+	"test.ok":                        "✓ OK",
+	"test.ok_id":                     "✓ OK (ID: %s)",
+	"test.failed":                    "✗ FAILED",
+	"test.interrupted":               "✗ INTERRUPTED",
+	"test.timeout":                   "✗ FAILED (timeout)",
+	"test.healthy":                   "✓ Stack is healthy",
+	"test.summary":                   "All components are communicating correctly:",
+	"test.summary.beads":             "  • beads task database is accessible",
+	"test.summary.mcp":               "  • mcp_agent_mail server is responding",
+	"test.summary.messages":          "  • Message passing is working",
+	"top.title":                      "%s, %d of %d process(es) running",
+	"top.column.uptime":              "UPTIME",
+	"top.column.restarts":            "RESTARTS",
+	"top.column.log_rate":            "LOG/S",
+	"upgrade.available":              "asc %s is available (installed: %s)",
+	"upgrade.available_hint":         "  Run 'asc upgrade --channel %s' to install it",
+	"upgrade.up_to_date":             "asc %s is up to date (newest %s release: %s)",
+	"upgrade.dry_run.download":       "download asc %s for %s/%s and verify it",
+	"upgrade.dry_run.replace":        "replace %s",
+	"upgrade.downloading":            "Downloading asc %s...",
+	"upgrade.unsigned":               "this build has no release signing key; only the checksum was verified",
+	"upgrade.upgraded":               "✓ asc upgraded from %s to %s",
+	"upgrade.restart_hint":           "  Restart running stacks (asc down, then asc up) to use it",
+	"worktree.none":                  "No agent worktrees",
+	"worktree.state":                 "%d ahead, %d behind %s",
+	"worktree.state.uncommitted":     ", %d uncommitted",
+	"worktree.missing":               "(missing)",
+	"worktree.nothing_to_merge":      "Agent %s has nothing to merge",
+	"worktree.merged":                "✓ Merged %d commit(s) from agent %s",
+	"worktree.nothing_to_prune":      "No worktrees to prune",
+	"worktree.removed_branch":        "✓ Removed worktree and branch of agent %s",
+	"worktree.removed":               "✓ Removed worktree of agent %s (its branch has unmerged work and was kept)",
+	"worktree.skipping_running":      "Skipping agent %s: it is running (stop it first or use --force)",
+	"worktree.dry_run.uncommitted":   "Agent %s has %d uncommitted change(s) and would not be merged",
+	"worktree.dry_run.merge":         "merge %d commit(s) from %s into %s",
+	"worktree.dry_run.kept":          "Worktree of agent %s has %d uncommitted change(s) and would be kept; use --force to discard them",
+	"worktree.dry_run.remove":        "remove worktree %s of agent %s",
+	"worktree.dry_run.delete_branch": "delete branch %s",
+	"worktree.dry_run.keep_branch":   "keep branch %s, which has %d unmerged commit(s)",
+
+	// asc up and asc down
+	"plan.title":                  "Plan:",
+	"plan.keep":                   "keep %s (PID %d, already running)",
+	"plan.stop":                   "stop %s (PID %d)",
+	"plan.clean":                  "clean %s (stale PID file, %s)",
+	"plan.start_stale":            "start %s (replacing stale PID file, %s)",
+	"plan.start":                  "start %s",
+	"plan.pid_not_running":        "PID %d not running",
+	"plan.pid_reused":             "PID %d belongs to another process now",
+	"up.dry_run.fetch_secret":     "fetch %s from %s",
+	"up.dry_run.start_service":    "%s: %s",
+	"up.dry_run.wait_ready":       "wait until %s is ready (%s)",
+	"up.dry_run.start_with_phase": "%s with its pipeline phase (model: %s): %s",
+	"up.dry_run.start_in_docker":  "%s (model: %s) in a %s container: %s",
+	"up.dry_run.start_agent":      "%s (model: %s): %s",
+	"up.dry_run.open_tui":         "open the TUI dashboard",
+	"up.decrypting_secrets":       "🔐 Decrypting secrets...",
+	"up.secrets_decrypted":        "✓ Secrets decrypted",
+	"up.unknown_key":              "⚠ %s is ignored",
+	"up.source_stale":             "⚠ config_source %s could not be fetched; using the copy fetched %s",
+	"up.service_running":          "✓ %s already running (PID %d)",
+	"up.service_starting":         "Starting %s service...",
+	"up.service_waiting":          "Waiting for %s to be ready (%s)...",
+	"up.service_ready":            "✓ %s ready",
+	"up.ship_logs_disabled":       "Warning: log shipping disabled: %v",
+	"up.ship_logs_failed":         "Warning: failed to ship remaining logs: %v",
+	"up.launching":                "Launching %d agent(s)...",
+	"up.agent_pipeline":           "  Agent %s starts with its pipeline phase",
+	"up.agent_paused":             "  Agent %s is paused for exceeding its budget (see asc budget)",
+	"up.agent_running":            "  ✓ Agent %s already running (PID %d)",
+	"up.agent_starting":           "  Starting agent: %s (model: %s)...",
+	"up.agent_started":            "  ✓ Agent %s started",
+	"up.agents_started":           "All agents started successfully",
+	"up.dependency_not_started":   "  ⚠ Agent %s depends on %s, which is not started now",
+	"up.agent_waiting":            "  Waiting for agent %s to be ready (%s)...",
+	"up.agent_ready":              "  ✓ Agent %s ready",
+	"up.shutting_down":            "Shutting down agent stack...",
+	"down.dry_run.stop_daemon":    "stop asc daemon (PID %d)",
+	"down.stop_failed":            "Warning: Some processes failed to stop cleanly: %v",
+}
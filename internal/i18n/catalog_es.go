@@ -0,0 +1,501 @@
+package i18n
+
+// catalogES is the Spanish catalog
+var catalogES = map[string]string{
+	// asc up and asc down
+	"up.dependency_check_failed": "La comprobación de dependencias falló. Ejecute 'asc check' para ver los detalles.",
+	"down.no_processes":          "No se encontraron procesos en ejecución",
+	"down.offline":               "La pila de agentes está detenida",
+	"down.shutting_down":         "Deteniendo %d proceso(s)...",
+
+	// asc doctor reports
+	"doctor.title":             "ASC DOCTOR - INFORME DE DIAGNÓSTICO",
+	"doctor.run_at":            "Ejecutado: %s",
+	"doctor.status":            "Estado: %s",
+	"doctor.no_issues":         "✓ No se detectaron problemas",
+	"doctor.severity_heading":  "─── GRAVEDAD %s (%d) ───",
+	"doctor.category":          "  Categoría: %s",
+	"doctor.description":       "  Descripción: %s",
+	"doctor.impact":            "  Impacto: %s",
+	"doctor.remediation":       "  Solución: %s",
+	"doctor.auto_fixable":      "  ✓ Se corrige automáticamente con --fix",
+	"doctor.fixes_applied":     "─── CORRECCIONES APLICADAS ───",
+	"doctor.recent_logs":       "─── REGISTROS RECIENTES DE ASC (últimos %d) ───",
+	"doctor.more_logs":         "Ejecute 'asc logs --self' para ver más.",
+	"doctor.healthy":           "✓ Todas las comprobaciones pasaron - el sistema está sano",
+	"doctor.found_issues":      "Se encontraron %d problema(s): ",
+	"doctor.count.critical":    "%d crítico(s)",
+	"doctor.count.high":        "%d alto(s)",
+	"doctor.count.medium":      "%d medio(s)",
+	"doctor.count.low":         "%d bajo(s)",
+	"doctor.severity.critical": "crítica",
+	"doctor.severity.high":     "alta",
+	"doctor.severity.medium":   "media",
+	"doctor.severity.low":      "baja",
+	"doctor.severity.info":     "informativa",
+
+	// TUI dashboard
+	"tui.agents.title":             "Estado de los agentes",
+	"tui.agents.hint":              "1-9:elegir p:pausar k:detener R:reiniciar l:registros",
+	"tui.agents.loading":           "Cargando el estado de los agentes...",
+	"tui.agents.none":              "No hay agentes configurados",
+	"tui.agents.pipeline_complete": " · canalización completa",
+	"tui.agents.phase":             " · fase: %s",
+	"tui.tasks.title":              "Tareas",
+	"tui.tasks.hint":               "↑↓:elegir c:tomar v:ver h:historial n:nueva f:filtrar",
+	"tui.tasks.loading":            "Cargando tareas...",
+	"tui.tasks.none":               "No hay tareas abiertas ni en curso",
+	"tui.tasks.blocked_by":         "(bloqueada por %s)",
+	"tui.logs.title":               "Registro de MCP",
+	"tui.logs.hint":                "/:buscar a:agente m:tipo x:limpiar e:exportar PgUp/PgDn/End:desplazar",
+	"tui.logs.none":                "Aún no hay mensajes",
+	"tui.logs.newer":               " (↑%d más recientes)",
+	"tui.footer.quit":              " salir | ",
+	"tui.footer.refresh":           " actualizar | ",
+	"tui.footer.test":              " probar",
+
+	// Messages of every command
+	"dry_run":                    "Simulación: se haría lo siguiente: %s",
+	"dry_run.action":             "%s",
+	"warning":                    "Advertencia: %v",
+	"root.dry_run.migrate_state": "migrar %s del esquema de estado %d al %d",
+	"root.migrated_state":        "%s migrado al formato de estado de esta versión (copia en %s)",
+	"root.source_stale":          "⚠ No se pudo obtener %s; se usa la copia obtenida el %s",
+	"root.migrated_config":       "%s migrado al formato de configuración de esta versión (copia en %s)",
+	"root.syslog_disabled":       "Advertencia: registro en syslog desactivado: %v",
+	"root.journald_disabled":     "Advertencia: registro en journald desactivado: %v",
+
+	// asc attach
+	"attach.not_running": "%s no está en ejecución",
+
+	// asc audit
+	"audit.none": "No hay acciones auditadas",
+
+	// asc backup
+	"backup.env_not_backed_up": "Advertencia: no se hace copia de .env; ejecute 'asc secrets encrypt' para respaldar sus secretos como .env.age",
+	"backup.created":           "✓ Copia de %d archivo(s), %.1f MB, guardada en %s",
+	"backup.dry_run.create":    "escribir %s con asc.toml, .env.age, %s y %s",
+	"backup.dry_run.restore":   "restaurar %s",
+	"backup.key_not_included":  "  La clave age no está incluida; copie %s aparte para descifrar .env.age en otro equipo",
+	"backup.restore_unchanged": "Simulación: todos los archivos ya están como en el archivo",
+	"backup.restored":          "✓ %d archivo(s) restaurado(s) de la copia del %s (asc %s)",
+	"backup.no_key":            "  No hay clave age en %s; cópiela desde el equipo original para descifrar .env.age",
+
+	// asc budget
+	"budget.dry_run.override":     "permitir que el agente %s supere su presupuesto hasta el fin del periodo",
+	"budget.dry_run.resume":       "reanudar el agente %s",
+	"budget.overridden":           "✓ El agente %s puede superar su presupuesto hasta el fin del periodo",
+	"budget.resumed":              "✓ Agente %s reanudado",
+	"budget.spend_to_date":        "Gasto hasta la fecha: $%.2f",
+	"budget.spend_since":          "Gasto desde %s (%s): $%.2f",
+	"budget.limit":                "%s %-16s $%8.2f de $%8.2f  %3.0f%%",
+	"budget.paused":               "Agentes en pausa:",
+	"budget.paused_agent":         "  %s (presupuesto %s, desde %s)",
+	"budget.resume_hint":          "Ejecute 'asc budget resume <agente>' para reanudar un agente",
+	"budget.enforcement_disabled": "Advertencia: control de presupuesto desactivado: %v",
+	"budget.ignored":              "No se aplican los presupuestos (--ignore-budget); las advertencias se siguen enviando",
+	"budget.check_failed":         "Advertencia: falló la comprobación del presupuesto: %v",
+
+	// asc check
+	"check.title":                       "Comprobación de dependencias",
+	"check.column.component":            "Componente",
+	"check.column.status":               "Estado",
+	"check.column.message":              "Mensaje",
+	"check.status.pass":                 "✓ OK",
+	"check.status.fail":                 "✗ FALLO",
+	"check.status.warn":                 "⚠ AVISO",
+	"check.binary.missing":              "No se encontró el programa '%s' en el PATH",
+	"check.binary.found":                "Programa '%s' encontrado",
+	"check.file.missing":                "El archivo '%s' no existe",
+	"check.file.inaccessible":           "No se puede acceder al archivo '%s': %v",
+	"check.file.directory":              "'%s' es un directorio, no un archivo",
+	"check.file.unreadable":             "El archivo '%s' no se puede leer: %v",
+	"check.file.ok":                     "El archivo '%s' existe y se puede leer",
+	"check.config.missing":              "No se encontró el archivo de configuración en '%s'",
+	"check.config.invalid_toml":         "Sintaxis TOML no válida: %v",
+	"check.config.no_beads_db_path":     "Falta el campo obligatorio: core.beads_db_path",
+	"check.config.no_mcp_agent_mail":    "Falta la configuración de services.mcp_agent_mail",
+	"check.config.ok":                   "El archivo de configuración es válido",
+	"check.env.missing":                 "No se encontró el archivo .env en '%s'",
+	"check.env.unreadable":              "No se puede leer el archivo .env: %v",
+	"check.env.missing_keys":            "Faltan claves de API: %v",
+	"check.env.ok":                      "Están todas las claves de API necesarias",
+	"check.env.ok_from":                 "Están todas las claves de API necesarias (%s)",
+	"check.env.injected":                "inyectadas desde %s",
+	"check.env.resolved":                "resueltas desde [secrets.env] al iniciar",
+	"check.timed_out":                   "La comprobación excedió el tiempo tras %s",
+	"check.custom.not_run":              "No se pudo ejecutar el comando '%s': %v",
+	"check.custom.exit_code":            "El comando '%s' terminó con %d, se esperaba %d",
+	"check.custom.ok":                   "El comando '%s' tuvo éxito",
+	"check.proxy.invalid_override":      "services.mcp_agent_mail.proxy no válido: %v",
+	"check.proxy.invalid_override_hint": "Establezca proxy en una URL como http://proxy:3128, o en \"direct\" para no usar el proxy",
+	"check.proxy.invalid_env":           "Entorno de proxy no válido: %v",
+	"check.proxy.invalid_env_hint":      "Corrija o elimine HTTP_PROXY/HTTPS_PROXY",
+	"check.proxy.ok":                    "Proxy: %s",
+	"check.proxy.via":                   "; servidor MCP accesible a través de %s",
+	"check.proxy.direct":                "; servidor MCP accesible directamente (NO_PROXY o loopback)",
+	"check.version.invalid":             "Requisito no válido para '%s': %v",
+	"check.version.unknown":             "No se pudo determinar la versión de '%s': %v",
+	"check.version.unsatisfied":         "El programa '%s' versión %s no cumple %s",
+	"check.version.ok":                  "El programa '%s' versión %s cumple %s",
+	"check.install.nothing":             "No hay nada que instalar.",
+	"check.install.no_package_manager":  "⚠ No se encontró ningún gestor de paquetes compatible (brew, apt, dnf, winget); solo se pueden instalar herramientas de Python",
+	"check.install.no_installer":        "  ? %s: no hay instalador disponible, instálelo manualmente",
+	"check.dry_run.install":             "ofrecer instalar %s con '%s'",
+	"check.install.prompt":              "¿Instalar %s con '%s'? (y/N): ",
+	"check.install.skipped":             "  - %s: omitido",
+	"check.install.failed":              "  ✗ %s: falló la instalación: %v",
+	"check.install.installed":           "  ✓ %s: instalado",
+	"check.install.summary":             "Resumen de la instalación:",
+
+	// asc cleanup
+	"cleanup.dry_run.none":   "Simulación: no hay registros de más de %d días en %s",
+	"cleanup.dry_run.remove": "eliminar %s",
+	"cleanup.cleaning":       "Limpiando los registros de más de %d días de %s...",
+	"cleanup.done":           "✓ Limpieza de registros completada",
+
+	// asc config
+	"config.dry_run.set":     "establecer %s = %s en %s",
+	"config.set":             "✓ %s = %s establecido en %s",
+	"config.valid":           "✓ %s es válido",
+	"config.dry_run.migrate": "migrar %s de la versión de configuración %d a la %d",
+	"config.current_layout":  "%s ya tiene el formato de esta versión",
+	"config.migrated":        "✓ %s migrado de la versión de configuración %d a la %d (copia en %s)",
+	"config.no_differences":  "No hay diferencias entre %s y %s",
+
+	// asc crashes
+	"crashes.none":          "No hay fallos",
+	"crashes.column.id":     "ID",
+	"crashes.column.agent":  "AGENTE",
+	"crashes.column.exited": "TERMINÓ",
+	"crashes.column.exit":   "SALIDA",
+	"crashes.column.ran":    "DURACIÓN",
+	"crashes.exit_status":   "estado %d",
+	"crashes.crash":         "Fallo %s",
+	"crashes.agent":         "  Agente:    %s (PID %d)",
+	"crashes.command":       "  Comando:   %s",
+	"crashes.exit":          "  Salida:    %s",
+	"crashes.started":       "  Inicio:    %s",
+	"crashes.exited":        "  Terminó:   %s (duró %s)",
+	"crashes.restarts":      "  Reinicios: %d",
+	"crashes.log":           "  Registro:  %s",
+	"crashes.no_output":     "No hay salida en el registro",
+	"crashes.last_output":   "Última salida:",
+
+	// asc daemon
+	"daemon.already_running":   "asc daemon ya está en ejecución (PID %d)",
+	"daemon.dry_run.start":     "iniciar la pila de agentes en segundo plano, con registro en %s",
+	"daemon.starting":          "Iniciando la pila de agentes en segundo plano...",
+	"daemon.started":           "✓ asc daemon iniciado (PID %d)",
+	"daemon.log":               "  Registro: %s",
+	"daemon.started_hint":      "  Ejecute 'asc daemon status' para comprobarlo, o 'asc daemon stop' para detenerlo",
+	"daemon.not_running":       "asc daemon no está en ejecución",
+	"daemon.dry_run.stop":      "detener asc daemon (PID %d) y la pila de agentes",
+	"daemon.stopping":          "Deteniendo asc daemon (PID %d)...",
+	"daemon.stopped":           "✓ asc daemon detenido",
+	"daemon.status.stopped":    "asc daemon: ○ detenido",
+	"daemon.status.running":    "asc daemon: ● en ejecución (PID %d)",
+	"daemon.status.started":    "  Iniciado: %s (activo desde hace %s)",
+	"daemon.status.directory":  "  Directorio: %s",
+	"daemon.status.starting":   "  Iniciando la pila de agentes...",
+	"daemon.status.processes":  "  Procesos:",
+	"daemon.process.stopped":   "○ detenido",
+	"daemon.process.running":   "● en ejecución (PID %d)",
+	"daemon.process.restarted": ", reiniciado %d vez/veces",
+
+	// asc doctor
+	"doctor.since_last_run":          "Desde la última ejecución (%s): %d nuevos, %d recurrentes, %d corregidos; ejecute 'asc doctor diff' para ver los detalles",
+	"doctor.watching":                "Ejecutando el diagnóstico cada %s, con los informes en %s; pulse Ctrl+C para detenerlo",
+	"doctor.new_critical":            "⚠ Nuevo problema crítico: %s (%s)",
+	"doctor.dry_run.fix":             "%s (corrige %s)",
+	"doctor.dry_run.none":            "Simulación: ningún problema se puede corregir automáticamente",
+	"doctor.fix_unmatched":           "⚠ Ningún problema coincide con %s; ejecute 'asc doctor --json' para ver los ID de los problemas encontrados",
+	"doctor.confirm_fix":             "%s\n  Corrección: %s\n¿Aplicar esta corrección? [y/N] ",
+	"doctor.history.none":            "No hay informes de doctor guardados\n  Ejecute 'asc doctor' para guardar uno",
+	"doctor.history.column.id":       "ID",
+	"doctor.history.column.run_at":   "EJECUTADO",
+	"doctor.history.column.issues":   "PROBLEMAS",
+	"doctor.history.column.critical": "CRÍTICOS",
+	"doctor.history.column.new":      "NUEVOS",
+	"doctor.history.column.fixed":    "CORREGIDOS",
+	"doctor.history.steady":          "estable",
+	"doctor.history.improving":       "mejorando",
+	"doctor.history.worsening":       "empeorando",
+	"doctor.history.trend":           "Tendencia en %d informes: %s (problemas %d → %d, críticos %d → %d)",
+	"doctor.diff.comparing":          "Comparando %s → %s",
+	"doctor.diff.new":                "Nuevos",
+	"doctor.diff.recurring":          "Recurrentes",
+	"doctor.diff.fixed":              "Corregidos",
+	"doctor.diff.none":               "  ninguno",
+
+	// asc events, asc exec and asc init
+	"events.none":                  "No hay eventos",
+	"exec.dry_run.run":             "ejecutar %s con el entorno de %s",
+	"init.templates.available":     "Plantillas disponibles:",
+	"init.templates.builtin":       "Plantillas integradas:",
+	"init.templates.custom":        "Plantillas propias:",
+	"init.templates.custom_failed": "No se pudieron cargar las plantillas propias: %v",
+	"init.templates.default":       "por defecto %s",
+	"init.template_saved":          "Plantilla '%s' guardada",
+
+	// asc logs, asc notify, asc pipeline and asc prompts
+	"logs.none":                      "No hay registros de agentes",
+	"logs.no_records":                "No hay entradas de registro recientes",
+	"notify.none":                    "No hay notificaciones configuradas; define url en [notify.webhook], [notify.slack] o [notify.discord]",
+	"notify.dry_run.send":            "enviar una notificación de prueba a %s",
+	"notify.sent":                    "✓ %s: notificación de prueba enviada",
+	"notify.disabled":                "Advertencia: notificaciones desactivadas: %v",
+	"pipeline.dry_run.complete_last": "completar la fase %s, la última fase de la canalización",
+	"pipeline.dry_run.advance":       "completar la fase %s y avanzar a la fase %s",
+	"pipeline.dry_run.reset":         "restablecer la canalización a la fase %s",
+	"pipeline.completed":             "✓ Todas las fases de la canalización completadas",
+	"pipeline.advanced":              "✓ Avanzado a la fase %s",
+	"pipeline.reset":                 "✓ Canalización restablecida a la fase %s",
+	"pipeline.status.complete":       "Canalización completa",
+	"pipeline.status.current":        "Fase actual: %s",
+	"pipeline.status.since":          " (desde %s)",
+	"pipeline.status.needs_done":     "necesita %d hechas",
+	"pipeline.status.needs_tasks":    "necesita al menos %d tarea(s)",
+	"pipeline.status.gate_met":       "umbral alcanzado",
+	"pipeline.status.tasks_done":     "%d/%d tareas hechas",
+	"pipeline.status.active_agents":  "Agentes activos: %s",
+	"pipeline.disabled":              "Advertencia: canalización de fases desactivada: %v",
+	"prompts.none":                   "No hay prompts guardados",
+	"prompts.none_hint":              "Añade uno con: asc prompts add <name> <file>",
+	"prompts.versions":               "%d versiones",
+	"prompts.dry_run.unchanged":      "El prompt %s no ha cambiado; no se añadiría ninguna versión",
+	"prompts.dry_run.add":            "añadir %s como %s versión %d",
+	"prompts.dry_run.restore":        "restaurar %s versión %d como versión %d",
+	"prompts.unchanged":              "El prompt %s no ha cambiado; no se añadió ninguna versión",
+	"prompts.added":                  "✓ Añadida %s versión %d",
+	"prompts.one_version":            "El prompt %s solo tiene una versión",
+	"prompts.identical":              "Las versiones %d y %d de %s son idénticas",
+	"prompts.already_current":        "La versión %d de %s ya es la actual",
+	"prompts.restored":               "✓ Restaurada %s versión %d como versión %d",
+
+	// asc reload, asc restart and asc scale
+	"reload.dry_run.reload":   "pedir a la pila de agentes en ejecución que recargue %s",
+	"reload.reloading":        "Recargando %s...",
+	"reload.unchanged":        "✓ %s recargado; ningún agente cambió",
+	"reload.reloaded":         "✓ %s recargado",
+	"reload.added":            "Añadidos",
+	"reload.removed":          "Eliminados",
+	"reload.changed":          "Cambiados",
+	"restart.none":            "No hay agentes en ejecución que reiniciar",
+	"restart.dry_run.restart": "reiniciar %s",
+	"restart.stopping":        "Deteniendo %s...",
+	"restart.restarting":      "Reiniciando %s...",
+	"restart.started":         "✓ %s iniciado (PID %d)",
+	"scale.dry_run.stop":      "detener %s",
+	"scale.dry_run.start":     "iniciar %s (modelo: %s): %s",
+	"scale.waits_for_phase":   "  El agente %s se inicia con su fase de la canalización",
+	"scale.scaled":            "✓ %s escalado a %d",
+
+	// asc secrets
+	"secrets.keychain":                 "el llavero del sistema",
+	"secrets.dry_run.replace_key":      "reemplazar la clave age de %s por una en %s",
+	"secrets.dry_run.generate_key":     "generar una clave age en %s",
+	"secrets.dry_run.encrypt":          "cifrar %s en %s.age para %d destinatario(s)",
+	"secrets.dry_run.decrypt":          "descifrar %s.age en %s",
+	"secrets.dry_run.decrypt_replace":  "descifrar %s.age en %s, reemplazándolo",
+	"secrets.dry_run.back_up_key":      "copiar %s a %s.old",
+	"secrets.dry_run.new_key":          "generar una nueva clave age en %s",
+	"secrets.dry_run.reencrypt":        "volver a cifrar %s con la nueva clave",
+	"secrets.dry_run.inject":           "descifrar %s en memoria y ejecutar %s con sus variables",
+	"secrets.key_exists":               "⚠ Ya existe una clave age en %s",
+	"secrets.overwrite_prompt":         "¿Quieres sobrescribirla? (y/N): ",
+	"secrets.continue_anyway_prompt":   "¿Continuar de todos modos? (y/N): ",
+	"secrets.continue_prompt":          "¿Continuar? (y/N): ",
+	"secrets.aborted":                  "Cancelado.",
+	"secrets.generating":               "Generando la clave age...",
+	"secrets.generated":                "✓ Clave age generada",
+	"secrets.key_location":             "Ubicación de la clave: %s",
+	"secrets.public_key":               "Clave pública: %s",
+	"secrets.keep_safe":                "⚠ IMPORTANTE: ¡Guarda tu clave en un lugar seguro y NUNCA la incluyas en git!",
+	"secrets.protected_keychain":       "✓ La clave está protegida por el llavero del sistema",
+	"secrets.protected_passphrase":     "✓ El archivo de la clave está cifrado con tu frase de contraseña y solo tú puedes leerlo",
+	"secrets.passphrase_unrecoverable": "⚠ No hay forma de recuperar la clave si olvidas la frase de contraseña",
+	"secrets.protected_file":           "✓ Solo tú puedes leer el archivo de la clave",
+	"secrets.encrypting":               "Cifrando %s...",
+	"secrets.decrypting":               "Descifrando %s.age...",
+	"secrets.rotation_due":             "La clave tiene %d días, más que la política de rotación de %d días",
+	"secrets.rotation_not_due":         "✓ La clave tiene %d días; la rotación corresponde a los %d días",
+	"secrets.rotate_warning":           "⚠ Esto generará una nueva clave y volverá a cifrar todos los archivos",
+	"secrets.nothing_to_reencrypt":     "No se encontraron archivos cifrados que volver a cifrar",
+	"secrets.plaintext_hint":           "  Ejecuta 'asc doctor --fix' para añadir los archivos en texto plano a .gitignore",
+	"secrets.status.title":             "Estado de la gestión de secretos",
+	"secrets.status.builtin":           "✓ El cifrado age está integrado",
+	"secrets.status.key_keychain":      "✓ La clave age está en el llavero del sistema (registrada en %s)",
+	"secrets.status.key_protected":     "✓ La clave age está en %s (protegida por una frase de contraseña)",
+	"secrets.status.key_exists":        "✓ La clave age está en %s",
+	"secrets.status.key_missing":       "✗ Clave age NO encontrada",
+	"secrets.status.public_key":        "  Clave pública: %s",
+	"secrets.status.run":               "  Ejecuta: %s",
+	"secrets.status.key_age":           "  Antigüedad de la clave: %d días (rotación a los %d días)",
+	"secrets.status.recipients":        "Destinatarios:",
+	"secrets.status.own_key_protected": "  ✓ tu clave (protegida por una frase de contraseña)",
+	"secrets.status.own_key":           "(tu clave)",
+	"secrets.status.encrypted":         "Archivos cifrados:",
+	"secrets.status.unencrypted":       "Archivos sin cifrar:",
+	"secrets.status.should_encrypt":    "(debería estar cifrado e ignorado por git)",
+	"secrets.status.none_found":        "  (ninguno encontrado)",
+
+	// asc services
+	"services.already_running": "mcp_agent_mail ya está en ejecución (PID %d)",
+	"services.dry_run.start":   "iniciar mcp_agent_mail: %s",
+	"services.dry_run.stop":    "detener mcp_agent_mail (PID %d)",
+	"services.started":         "✓ mcp_agent_mail iniciado (PID %d)",
+	"services.url":             "  URL: %s",
+	"services.stopping":        "Deteniendo mcp_agent_mail (PID %d)...",
+	"services.stopped":         "✓ mcp_agent_mail detenido",
+	"services.status.stopped":  "mcp_agent_mail: ○ detenido",
+	"services.status.running":  "mcp_agent_mail: ● en ejecución (PID %d)",
+	"services.status.started":  "  Iniciado: %s",
+	"services.status.stale":    "mcp_agent_mail: ○ detenido (archivo PID obsoleto)",
+
+	// asc status
+	"status.none":                "No hay procesos gestionados",
+	"status.column.name":         "NOMBRE",
+	"status.column.status":       "ESTADO",
+	"status.column.health":       "SALUD",
+	"status.column.memory":       "MEMORIA",
+	"status.column.limits":       "LÍMITES",
+	"status.limits.none":         "ninguno",
+	"status.stopped":             "detenido",
+	"status.running":             "activo",
+	"status.health_failures":     "%s: %d comprobación(es) de salud fallida(s) seguidas: %s",
+	"status.stream.connected":    "Flujo de eventos MCP: ● conectado desde hace %s",
+	"status.stream.degraded":     "Flujo de eventos MCP: ◐ degradado desde hace %s, reconectando (%d intento(s) fallido(s)): %s",
+	"status.stream.disconnected": "Flujo de eventos MCP: ○ desconectado desde hace %s",
+	"status.stream.polling":      "Flujo de eventos MCP: ○ desconectado desde hace %s, la TUI consulta periódicamente: %s",
+
+	// asc sync
+	"sync.in_sync": "Ya está sincronizado",
+	"sync.summary": "%d tarea(s) vinculada(s), %d cambio(s), %d conflicto(s)",
+
+	// asc tasks and asc telemetry
+	"tasks.none":                      "No hay tareas",
+	"tasks.column.priority":           "PRI",
+	"tasks.column.phase":              "FASE",
+	"tasks.column.assignee":           "ASIGNADO",
+	"tasks.column.title":              "TÍTULO",
+	"tasks.column.time":               "HORA",
+	"tasks.column.actor":              "AUTOR",
+	"tasks.column.change":             "CAMBIO",
+	"tasks.history.none":              "No hay historial registrado para %s",
+	"tasks.history.unknown":           "desconocido",
+	"tasks.history.seen":              "* hecho con bd y detectado por asc; atribuido a la persona asignada a la tarea",
+	"tasks.dry_run.create":            "crear la tarea %q",
+	"tasks.dry_run.update":            "actualizar la tarea %s",
+	"tasks.dry_run.close":             "cerrar la tarea %s",
+	"tasks.created":                   "✓ %d tarea(s) creada(s)",
+	"tasks.updated":                   "✓ %d tarea(s) actualizada(s)",
+	"tasks.closed":                    "✓ %d tarea(s) cerrada(s)",
+	"tasks.watching":                  "Observando %d tareas en %s (Ctrl+C para detener)",
+	"tasktemplate.dry_run.save":       "guardar la plantilla de tareas %s con %d tarea(s) en %s",
+	"tasktemplate.dry_run.delete":     "eliminar la plantilla de tareas %s",
+	"tasktemplate.dry_run.run":        "crear las %d tarea(s) de %s, prevista(s) para %s",
+	"tasktemplate.saved":              "✓ Plantilla de tareas %s guardada con %d tarea(s)",
+	"tasktemplate.recurs":             "  Se repite %s; próxima ejecución %s (asc daemon, o asc tasks template run-due desde cron)",
+	"tasktemplate.created":            "✓ %d tarea(s) creada(s) a partir de %s",
+	"tasktemplate.none":               "No hay plantillas de tareas (crea una con asc tasks template create)",
+	"tasktemplate.column.tasks":       "TAREAS",
+	"tasktemplate.column.params":      "PARÁMETROS",
+	"tasktemplate.column.next_run":    "PRÓXIMA",
+	"tasktemplate.column.description": "DESCRIPCIÓN",
+	"tasktemplate.deleted":            "✓ Plantilla de tareas %s eliminada",
+	"tasktemplate.run_failed":         "✗ %s (prevista para %s): %v",
+	"telemetry.disabled":              "Advertencia: exportación de telemetría desactivada: %v",
+
+	// asc test, asc top, asc upgrade and asc worktree
+	"test.running":                   "Ejecutando la prueba de la pila de agentes...",
+	"test.dry_run.create":            "crear una tarea de prueba en %s",
+	"test.dry_run.send":              "enviar un mensaje de prueba a %s",
+	"test.dry_run.delete":            "eliminar la tarea de prueba",
+	"test.step.create":               "Creando la tarea de prueba en beads... ",
+	"test.step.send":                 "Enviando el mensaje de prueba al servidor MCP... ",
+	"test.step.verify_task":          "Verificando la lectura de la tarea de beads... ",
+	"test.step.verify_message":       "Verificando la lectura del mensaje MCP... ",
+	"test.step.cleanup":              "Limpiando los artefactos de prueba... ",
+	"test.cleaning_task":             "   Limpiando la tarea de prueba... ",
+	"test.cleanup_failed":            "✗ (falló: %v)",
+	"test.ok":                        "✓ OK",
+	"test.ok_id":                     "✓ OK (ID: %s)",
+	"test.failed":                    "✗ FALLÓ",
+	"test.interrupted":               "✗ INTERRUMPIDO",
+	"test.timeout":                   "✗ FALLÓ (tiempo agotado)",
+	"test.healthy":                   "✓ La pila está sana",
+	"test.summary":                   "Todos los componentes se comunican correctamente:",
+	"test.summary.beads":             "  • la base de datos de tareas de beads es accesible",
+	"test.summary.mcp":               "  • el servidor mcp_agent_mail responde",
+	"test.summary.messages":          "  • el intercambio de mensajes funciona",
+	"top.title":                      "%s, %d de %d proceso(s) en ejecución",
+	"top.column.uptime":              "ACTIVO",
+	"top.column.restarts":            "REINICIOS",
+	"top.column.log_rate":            "LOG/S",
+	"upgrade.available":              "asc %s está disponible (instalado: %s)",
+	"upgrade.available_hint":         "  Ejecuta 'asc upgrade --channel %s' para instalarlo",
+	"upgrade.up_to_date":             "asc %s está actualizado (versión %s más reciente: %s)",
+	"upgrade.dry_run.download":       "descargar asc %s para %s/%s y verificarlo",
+	"upgrade.dry_run.replace":        "reemplazar %s",
+	"upgrade.downloading":            "Descargando asc %s...",
+	"upgrade.unsigned":               "esta compilación no tiene clave de firma de versiones; solo se verificó la suma de comprobación",
+	"upgrade.upgraded":               "✓ asc actualizado de %s a %s",
+	"upgrade.restart_hint":           "  Reinicia las pilas en ejecución (asc down y luego asc up) para usarlo",
+	"worktree.none":                  "No hay worktrees de agentes",
+	"worktree.state":                 "%d por delante, %d por detrás de %s",
+	"worktree.state.uncommitted":     ", %d sin confirmar",
+	"worktree.missing":               "(falta)",
+	"worktree.nothing_to_merge":      "El agente %s no tiene nada que fusionar",
+	"worktree.merged":                "✓ Fusionado(s) %d commit(s) del agente %s",
+	"worktree.nothing_to_prune":      "No hay worktrees que podar",
+	"worktree.removed_branch":        "✓ Eliminados el worktree y la rama del agente %s",
+	"worktree.removed":               "✓ Eliminado el worktree del agente %s (su rama tiene trabajo sin fusionar y se conservó)",
+	"worktree.skipping_running":      "Omitiendo el agente %s: está en ejecución (detenlo primero o usa --force)",
+	"worktree.dry_run.uncommitted":   "El agente %s tiene %d cambio(s) sin confirmar y no se fusionaría",
+	"worktree.dry_run.merge":         "fusionar %d commit(s) de %s en %s",
+	"worktree.dry_run.kept":          "El worktree del agente %s tiene %d cambio(s) sin confirmar y se conservaría; usa --force para descartarlos",
+	"worktree.dry_run.remove":        "eliminar el worktree %s del agente %s",
+	"worktree.dry_run.delete_branch": "eliminar la rama %s",
+	"worktree.dry_run.keep_branch":   "conservar la rama %s, que tiene %d commit(s) sin fusionar",
+
+	// asc up and asc down
+	"plan.title":                  "Plan:",
+	"plan.keep":                   "mantener %s (PID %d, ya en ejecución)",
+	"plan.stop":                   "detener %s (PID %d)",
+	"plan.clean":                  "limpiar %s (archivo PID obsoleto, %s)",
+	"plan.start_stale":            "iniciar %s (sustituye el archivo PID obsoleto, %s)",
+	"plan.start":                  "iniciar %s",
+	"plan.pid_not_running":        "el PID %d no está en ejecución",
+	"plan.pid_reused":             "el PID %d pertenece ahora a otro proceso",
+	"up.dry_run.fetch_secret":     "obtener %s de %s",
+	"up.dry_run.start_service":    "%s: %s",
+	"up.dry_run.wait_ready":       "esperar a que %s esté listo (%s)",
+	"up.dry_run.start_with_phase": "%s con su fase del pipeline (modelo: %s): %s",
+	"up.dry_run.start_in_docker":  "%s (modelo: %s) en un contenedor %s: %s",
+	"up.dry_run.start_agent":      "%s (modelo: %s): %s",
+	"up.dry_run.open_tui":         "abrir el panel de la TUI",
+	"up.decrypting_secrets":       "🔐 Descifrando secretos...",
+	"up.secrets_decrypted":        "✓ Secretos descifrados",
+	"up.unknown_key":              "⚠ %s se ignora",
+	"up.source_stale":             "⚠ No se pudo obtener config_source %s; se usa la copia obtenida el %s",
+	"up.service_running":          "✓ %s ya está en ejecución (PID %d)",
+	"up.service_starting":         "Iniciando el servicio %s...",
+	"up.service_waiting":          "Esperando a que %s esté listo (%s)...",
+	"up.service_ready":            "✓ %s listo",
+	"up.ship_logs_disabled":       "Advertencia: envío de registros desactivado: %v",
+	"up.ship_logs_failed":         "Advertencia: no se pudieron enviar los registros restantes: %v",
+	"up.launching":                "Iniciando %d agente(s)...",
+	"up.agent_pipeline":           "  El agente %s se inicia con su fase del pipeline",
+	"up.agent_paused":             "  El agente %s está en pausa por superar su presupuesto (vea asc budget)",
+	"up.agent_running":            "  ✓ El agente %s ya está en ejecución (PID %d)",
+	"up.agent_starting":           "  Iniciando el agente: %s (modelo: %s)...",
+	"up.agent_started":            "  ✓ Agente %s iniciado",
+	"up.agents_started":           "Todos los agentes se iniciaron correctamente",
+	"up.dependency_not_started":   "  ⚠ El agente %s depende de %s, que no se inicia ahora",
+	"up.agent_waiting":            "  Esperando a que el agente %s esté listo (%s)...",
+	"up.agent_ready":              "  ✓ Agente %s listo",
+	"up.shutting_down":            "Apagando la pila de agentes...",
+	"down.dry_run.stop_daemon":    "detener asc daemon (PID %d)",
+	"down.stop_failed":            "Advertencia: algunos procesos no se detuvieron correctamente: %v",
+}
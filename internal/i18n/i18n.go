@@ -0,0 +1,123 @@
+// Package i18n translates asc's user-facing output. Messages are looked up
+// by key in the catalog of the current language, falling back to English
+// and then to the key itself, so a catalog that lags behind never hides a
+// message. The language is set once at startup from the --lang flag, the
+// ASC_LANG environment variable, or the locale (LC_ALL, LC_MESSAGES,
+// LANG). Only human-readable text is translated; JSON output keys and
+// machine-readable values stay the same in every language.
+//
+// Example usage:
+//
+//	if err := i18n.SetLanguage(i18n.Detect()); err != nil {
+//	    return err
+//	}
+//	fmt.Println(i18n.T("down.shutting_down", len(processes)))
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnvVar is the environment variable that overrides the locale's language
+const EnvVar = "ASC_LANG"
+
+// DefaultLanguage is used when the locale names a language without a
+// catalog
+const DefaultLanguage = "en"
+
+// localeEnvVars are the locale variables consulted by Detect, in order of
+// precedence
+var localeEnvVars = []string{EnvVar, "LC_ALL", "LC_MESSAGES", "LANG"}
+
+// catalogs maps each supported language to its messages
+var catalogs = map[string]map[string]string{
+	"en": catalogEN,
+	"de": catalogDE,
+	"es": catalogES,
+}
+
+var (
+	mu       sync.RWMutex
+	language = DefaultLanguage
+)
+
+// Detect returns the language named by ASC_LANG or the locale, or
+// DefaultLanguage if none is set or it has no catalog
+func Detect() string {
+	for _, name := range localeEnvVars {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		// The first variable that is set wins, as in the C library
+		if lang := normalize(value); catalogs[lang] != nil {
+			return lang
+		}
+		return DefaultLanguage
+	}
+	return DefaultLanguage
+}
+
+// SetLanguage switches the language of T. It accepts a language code or a
+// locale name such as de_DE.UTF-8, and returns an error for languages
+// without a catalog.
+func SetLanguage(lang string) error {
+	code := normalize(lang)
+	if catalogs[code] == nil {
+		return fmt.Errorf("unsupported language '%s'\n  Supported languages: %s\n  Suggestion: Use one of the supported languages or leave --lang unset to use your locale",
+			lang, strings.Join(Languages(), ", "))
+	}
+	mu.Lock()
+	language = code
+	mu.Unlock()
+	return nil
+}
+
+// Language returns the current language code
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return language
+}
+
+// Languages returns the supported language codes, sorted
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// T returns the message for key in the current language, formatted with
+// args as by fmt.Sprintf when any are given
+func T(key string, args ...interface{}) string {
+	msg, ok := catalogs[Language()][key]
+	if !ok {
+		if msg, ok = catalogEN[key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// normalize reduces a locale name such as de_DE.UTF-8 or pt-BR to its
+// language code. C and POSIX name the default language.
+func normalize(locale string) string {
+	code := strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	if code == "c" || code == "posix" {
+		return DefaultLanguage
+	}
+	return code
+}
@@ -0,0 +1,125 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"unset", nil, "en"},
+		{"LANG", map[string]string{"LANG": "de_DE.UTF-8"}, "de"},
+		{"LC_ALL overrides LANG", map[string]string{"LC_ALL": "es_ES.UTF-8", "LANG": "de_DE.UTF-8"}, "es"},
+		{"ASC_LANG overrides the locale", map[string]string{EnvVar: "de", "LC_ALL": "es_ES"}, "de"},
+		{"C locale", map[string]string{"LANG": "C"}, "en"},
+		{"no catalog", map[string]string{"LANG": "ja_JP.UTF-8"}, "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range localeEnvVars {
+				t.Setenv(name, tt.env[name])
+			}
+			if got := Detect(); got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	defer SetLanguage(DefaultLanguage)
+
+	if err := SetLanguage("de_DE.UTF-8"); err != nil {
+		t.Fatalf("SetLanguage() error = %v", err)
+	}
+	if Language() != "de" {
+		t.Errorf("Language() = %q, want de", Language())
+	}
+	if got := T("down.shutting_down", 3); got != "3 Prozess(e) werden beendet..." {
+		t.Errorf("T() = %q", got)
+	}
+
+	err := SetLanguage("xx")
+	if err == nil || !strings.Contains(err.Error(), "Supported languages: de, en, es") {
+		t.Errorf("Expected an unsupported language error, got %v", err)
+	}
+	if Language() != "de" {
+		t.Errorf("Expected a rejected language to keep the current one, got %q", Language())
+	}
+}
+
+func TestTFallsBack(t *testing.T) {
+	defer SetLanguage(DefaultLanguage)
+	SetLanguage("es")
+
+	catalogEN["test.only_english"] = "English %s"
+	defer delete(catalogEN, "test.only_english")
+
+	if got := T("test.only_english", "text"); got != "English text" {
+		t.Errorf("Expected the English message, got %q", got)
+	}
+	if got := T("test.missing"); got != "test.missing" {
+		t.Errorf("Expected the key for a missing message, got %q", got)
+	}
+}
+
+// TestCatalogsMatchEnglish checks that every translation has an English
+// message with the same format verbs, so T formats them alike
+func TestCatalogsMatchEnglish(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+	for lang, catalog := range catalogs {
+		for key, msg := range catalog {
+			english, ok := catalogEN[key]
+			if !ok {
+				t.Errorf("%s: %s has no English message", lang, key)
+				continue
+			}
+			got := strings.Join(verbs.FindAllString(msg, -1), " ")
+			want := strings.Join(verbs.FindAllString(english, -1), " ")
+			if got != want {
+				t.Errorf("%s: %s has verbs %q, want %q", lang, key, got, want)
+			}
+		}
+	}
+}
+
+// TestCallersUseKnownKeys checks that every key asc's commands and
+// packages look up has an English message, as T otherwise prints the key
+func TestCallersUseKnownKeys(t *testing.T) {
+	lookup := regexp.MustCompile(`(?:i18n\.T|printDryRun|fprintDryRun)\((?:[^,()"]+, )?"([^"]+)"[,)]`)
+	files, err := filepath.Glob("../../cmd/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	internal, err := filepath.Glob("../*/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, file := range append(files, internal...) {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range lookup.FindAllStringSubmatch(string(data), -1) {
+			found++
+			if _, ok := catalogEN[match[1]]; !ok {
+				t.Errorf("%s: %q has no English message", file, match[1])
+			}
+		}
+	}
+	if found == 0 {
+		t.Error("Expected to find lookups in cmd")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +29,8 @@ type AggregatedEntry struct {
 	Message       string
 	Source        string // Agent name or "asc" for main logs
 	CorrelationID string
+	Component     string
+	Caller        string
 	Agent         string
 	Task          string
 	Phase         string
@@ -126,13 +129,15 @@ func (a *LogAggregator) parseLogLine(line string, source string) (AggregatedEntr
 	if strings.HasPrefix(line, "{") {
 		var logEntry LogEntry
 		if err := json.Unmarshal([]byte(line), &logEntry); err == nil {
-			timestamp, _ := time.Parse("2006-01-02 15:04:05.000", logEntry.Timestamp)
+			timestamp, _ := time.Parse(TimestampLayout, logEntry.Timestamp)
 			return AggregatedEntry{
 				Timestamp:     timestamp,
 				Level:         logEntry.Level,
 				Message:       logEntry.Message,
 				Source:        source,
 				CorrelationID: logEntry.CorrelationID,
+				Component:     logEntry.Component,
+				Caller:        logEntry.Caller,
 				Agent:         logEntry.Agent,
 				Task:          logEntry.Task,
 				Phase:         logEntry.Phase,
@@ -141,6 +146,11 @@ func (a *LogAggregator) parseLogLine(line string, source string) (AggregatedEntr
 		}
 	}
 
+	// Try logfmt format: ts="..." level=info msg="..."
+	if strings.HasPrefix(line, "ts=") {
+		return parseLogfmtLine(line, source)
+	}
+
 	// Try text format: [timestamp] [level] message
 	parts := strings.SplitN(line, "]", 3)
 	if len(parts) < 3 {
@@ -151,7 +161,7 @@ func (a *LogAggregator) parseLogLine(line string, source string) (AggregatedEntr
 	level := strings.TrimSpace(strings.TrimPrefix(parts[1], "["))
 	message := strings.TrimSpace(parts[2])
 
-	timestamp, err := time.Parse("2006-01-02 15:04:05.000", timestampStr)
+	timestamp, err := time.Parse(TimestampLayout, timestampStr)
 	if err != nil {
 		return AggregatedEntry{}, err
 	}
@@ -164,6 +174,88 @@ func (a *LogAggregator) parseLogLine(line string, source string) (AggregatedEntr
 	}, nil
 }
 
+// parseLogfmtLine parses a line written in FormatLogfmt
+func parseLogfmtLine(line string, source string) (AggregatedEntry, error) {
+	pairs, err := splitLogfmt(line)
+	if err != nil {
+		return AggregatedEntry{}, err
+	}
+
+	timestamp, err := time.Parse(TimestampLayout, pairs["ts"])
+	if err != nil {
+		return AggregatedEntry{}, err
+	}
+
+	entry := AggregatedEntry{
+		Timestamp:     timestamp,
+		Level:         strings.ToUpper(pairs["level"]),
+		Message:       pairs["msg"],
+		Source:        source,
+		CorrelationID: pairs["correlation_id"],
+		Component:     pairs["component"],
+		Caller:        pairs["caller"],
+		Agent:         pairs["agent"],
+		Task:          pairs["task"],
+		Phase:         pairs["phase"],
+		Fields:        make(map[string]interface{}),
+	}
+	for k, v := range pairs {
+		switch k {
+		case "ts", "level", "msg", "correlation_id", "component", "caller", "agent", "task", "phase":
+		default:
+			entry.Fields[k] = v
+		}
+	}
+
+	return entry, nil
+}
+
+// splitLogfmt tokenizes a logfmt line into key/value pairs, unquoting
+// values that were written with Go-style quoting
+func splitLogfmt(line string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	rest := strings.TrimSpace(line)
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid logfmt pair: %s", rest)
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, "\"") {
+			// Find the closing quote, skipping escaped characters
+			end := 1
+			for end < len(rest) && rest[end] != '"' {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(rest) {
+				return nil, fmt.Errorf("unterminated quoted value for key %s", key)
+			}
+			unquoted, err := strconv.Unquote(rest[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value for key %s: %w", key, err)
+			}
+			value = unquoted
+			rest = rest[end+1:]
+		} else if sp := strings.IndexByte(rest, ' '); sp >= 0 {
+			value = rest[:sp]
+			rest = rest[sp:]
+		} else {
+			value = rest
+			rest = ""
+		}
+
+		pairs[key] = value
+		rest = strings.TrimLeft(rest, " ")
+	}
+	return pairs, nil
+}
+
 // GetFilteredLogs returns logs matching the current filters
 func (a *LogAggregator) GetFilteredLogs(filters LogFilters) []AggregatedEntry {
 	a.mu.RLock()
@@ -214,6 +306,8 @@ func (a *LogAggregator) matchesFilters(entry AggregatedEntry, filters LogFilters
 // parseLogLevel converts a string level to LogLevel
 func parseLogLevel(level string) LogLevel {
 	switch strings.ToUpper(level) {
+	case "TRACE":
+		return TRACE
 	case "DEBUG":
 		return DEBUG
 	case "INFO":
@@ -245,7 +339,7 @@ func (a *LogAggregator) ExportToFile(path string, filters LogFilters) error {
 			entry.Timestamp.Format("2006-01-02 15:04:05.000"),
 			entry.Level,
 			entry.Source,
-			entry.Message,
+			Redact(entry.Message),
 		)
 		if _, err := writer.WriteString(line); err != nil {
 			return fmt.Errorf("failed to write log entry: %w", err)
@@ -290,15 +384,41 @@ type LogStats struct {
 
 // CleanupOldLogs removes log files older than the specified duration
 func CleanupOldLogs(logsDir string, maxAge time.Duration) error {
+	logPaths, err := OldLogs(logsDir, maxAge)
+	if err != nil {
+		return err
+	}
+
+	for _, logPath := range logPaths {
+		if err := os.Remove(logPath); err != nil {
+			Error("Failed to remove old log file %s: %v", logPath, err)
+		} else {
+			Info("Removed old log file: %s", filepath.Base(logPath))
+		}
+	}
+
+	return nil
+}
+
+// IsLogFile reports whether a file name is that of a log, such as
+// agent.log, or of a rotated copy of one, such as agent.log.1 or
+// agent.log.2.gz
+func IsLogFile(name string) bool {
+	return strings.HasSuffix(name, ".log") || strings.Contains(name, ".log.")
+}
+
+// OldLogs returns the paths of the log files CleanupOldLogs would remove
+func OldLogs(logsDir string, maxAge time.Duration) ([]string, error) {
 	files, err := os.ReadDir(logsDir)
 	if err != nil {
-		return fmt.Errorf("failed to read logs directory: %w", err)
+		return nil, fmt.Errorf("failed to read logs directory: %w", err)
 	}
 
 	cutoff := time.Now().Add(-maxAge)
 
+	var logPaths []string
 	for _, file := range files {
-		if file.IsDir() || !strings.HasSuffix(file.Name(), ".log") {
+		if file.IsDir() || !IsLogFile(file.Name()) {
 			continue
 		}
 
@@ -308,14 +428,9 @@ func CleanupOldLogs(logsDir string, maxAge time.Duration) error {
 		}
 
 		if info.ModTime().Before(cutoff) {
-			logPath := filepath.Join(logsDir, file.Name())
-			if err := os.Remove(logPath); err != nil {
-				Error("Failed to remove old log file %s: %v", logPath, err)
-			} else {
-				Info("Removed old log file: %s", file.Name())
-			}
+			logPaths = append(logPaths, filepath.Join(logsDir, file.Name()))
 		}
 	}
 
-	return nil
+	return logPaths, nil
 }
@@ -198,6 +198,28 @@ func TestCleanupOldLogs(t *testing.T) {
 	}
 }
 
+func TestOldLogs_RotatedCopies(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldTime := time.Now().Add(-31 * 24 * time.Hour)
+	for _, name := range []string{"agent.log.1", "agent.log.2.gz", "notes.txt"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, oldTime, oldTime); err != nil {
+			t.Fatalf("Failed to set old time: %v", err)
+		}
+	}
+
+	logs, err := OldLogs(tmpDir, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("OldLogs failed: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("Expected the two rotated copies, got %v", logs)
+	}
+}
+
 // Helper function to create test log files
 func createTestLogFile(t *testing.T, dir string, filename string, lines []string) {
 	path := filepath.Join(dir, filename)
@@ -213,3 +235,33 @@ func createTestLogFile(t *testing.T, dir string, filename string, lines []string
 		}
 	}
 }
+
+func TestLogAggregatorLogfmt(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	createTestLogFile(t, tmpDir, "asc.log", []string{
+		`ts="2024-01-01 10:00:00.000" level=info msg="Fetching messages" component=mcp caller=client.go:42 url=http://localhost:8765`,
+		`ts="2024-01-01 10:00:01.000" level=error msg="Request failed" component=mcp attempt=3`,
+	})
+
+	aggregator := NewLogAggregator(tmpDir, 100)
+	if err := aggregator.CollectLogs(); err != nil {
+		t.Fatalf("Failed to collect logs: %v", err)
+	}
+
+	logs := aggregator.GetFilteredLogs(LogFilters{Level: DEBUG})
+	if len(logs) != 2 {
+		t.Fatalf("Expected 2 logs, got %d", len(logs))
+	}
+
+	// Newest first
+	if logs[0].Level != "ERROR" || logs[0].Message != "Request failed" {
+		t.Errorf("Unexpected first entry: %+v", logs[0])
+	}
+	if logs[1].Component != "mcp" || logs[1].Caller != "client.go:42" {
+		t.Errorf("Expected component and caller to be parsed, got %+v", logs[1])
+	}
+	if logs[1].Fields["url"] != "http://localhost:8765" {
+		t.Errorf("Expected url field, got %v", logs[1].Fields["url"])
+	}
+}
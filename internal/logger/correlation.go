@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// CorrelationIDEnvVar passes the current correlation ID to child processes
+// (bd invocations, agents) so their output can be joined with asc's logs
+const CorrelationIDEnvVar = "ASC_CORRELATION_ID"
+
+// CorrelationIDHeader carries the current correlation ID on HTTP requests
+const CorrelationIDHeader = "X-Correlation-ID"
+
+var (
+	correlationMu        sync.RWMutex
+	currentCorrelationID string
+)
+
+// NewCorrelationID returns a fresh random correlation ID
+func NewCorrelationID() string {
+	return uuid.New().String()
+}
+
+// SetCorrelationID sets the correlation ID of the action in progress, i.e.
+// the current user command or reconcile cycle. Records written by the
+// default logger, bd invocations, and MCP requests are tagged with it until
+// it is replaced. It is safe to call before the logger is initialized.
+func SetCorrelationID(id string) {
+	correlationMu.Lock()
+	currentCorrelationID = id
+	correlationMu.Unlock()
+
+	if defaultLogger != nil {
+		defaultLogger.WithCorrelationID(id)
+	}
+}
+
+// CorrelationID returns the correlation ID of the action in progress. If
+// none was set, it falls back to the default logger's session ID, and to ""
+// when the logger has not been initialized either.
+func CorrelationID() string {
+	id := currentCorrelationIDValue()
+	if id == "" && defaultLogger != nil {
+		return defaultLogger.CorrelationID()
+	}
+	return id
+}
+
+func currentCorrelationIDValue() string {
+	correlationMu.RLock()
+	defer correlationMu.RUnlock()
+	return currentCorrelationID
+}
+
+// StartCorrelation generates a new correlation ID, makes it current, and
+// returns it. Call it at the start of each command or reconcile cycle.
+func StartCorrelation() string {
+	id := NewCorrelationID()
+	SetCorrelationID(id)
+	return id
+}
+
+// CorrelationID returns the logger's correlation ID
+func (l *Logger) CorrelationID() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.correlationID
+}
+
+// WithCorrelationID returns a copy of the entry that records the given
+// correlation ID instead of the logger's. Use it for work that runs
+// concurrently with the current action, such as a background health cycle.
+func (e *Entry) WithCorrelationID(id string) *Entry {
+	return e.WithFields(Fields{"correlation_id": id})
+}
@@ -0,0 +1,116 @@
+//go:build linux
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// journalSocketPath is the systemd-journald native protocol socket
+var journalSocketPath = "/run/systemd/journal/socket"
+
+// journaldSink writes records to systemd-journald using the native protocol,
+// so structured fields are preserved as journal fields
+type journaldSink struct {
+	conn       *net.UnixConn
+	identifier string
+}
+
+// NewJournaldSink connects to the local systemd-journald socket. identifier
+// is recorded as SYSLOG_IDENTIFIER (e.g. "asc").
+func NewJournaldSink(identifier string) (Sink, error) {
+	addr := &net.UnixAddr{Name: journalSocketPath, Net: "unixgram"}
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return &journaldSink{conn: conn, identifier: identifier}, nil
+}
+
+// Write sends the entry as a single journal record. Well-known entry fields
+// map to journal fields (MESSAGE, PRIORITY, CODE_FILE, ...) and the rest are
+// prefixed with ASC_.
+func (s *journaldSink) Write(entry LogEntry) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", entry.Message)
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(journalPriority(entry.Level)))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", s.identifier)
+
+	if file, line, ok := strings.Cut(entry.Caller, ":"); ok {
+		writeJournalField(&buf, "CODE_FILE", file)
+		writeJournalField(&buf, "CODE_LINE", line)
+	}
+
+	optional := []struct{ key, value string }{
+		{"ASC_COMPONENT", entry.Component},
+		{"ASC_AGENT", entry.Agent},
+		{"ASC_TASK", entry.Task},
+		{"ASC_PHASE", entry.Phase},
+		{"ASC_CORRELATION_ID", entry.CorrelationID},
+	}
+	for _, kv := range optional {
+		if kv.value != "" {
+			writeJournalField(&buf, kv.key, kv.value)
+		}
+	}
+	for _, k := range sortedKeys(entry.Fields) {
+		writeJournalField(&buf, "ASC_"+journalFieldName(k), fmt.Sprintf("%v", entry.Fields[k]))
+	}
+
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the journald socket
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
+
+// writeJournalField appends one field in the native protocol encoding.
+// Values containing newlines use the length-prefixed binary form.
+func writeJournalField(buf *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteString(key)
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalFieldName converts a field key to a valid journal field name:
+// uppercase letters, digits, and underscores
+func journalFieldName(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, key)
+}
+
+// journalPriority maps a level name to a syslog priority (0=emerg .. 7=debug)
+func journalPriority(level string) int {
+	switch parseLogLevel(level) {
+	case TRACE, DEBUG:
+		return 7
+	case WARN:
+		return 4
+	case ERROR:
+		return 3
+	default:
+		return 6
+	}
+}
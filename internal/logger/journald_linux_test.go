@@ -0,0 +1,64 @@
+//go:build linux
+
+package logger
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournaldSink(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "journal.sock")
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets not available: %v", err)
+	}
+	defer listener.Close()
+
+	originalPath := journalSocketPath
+	journalSocketPath = socketPath
+	defer func() { journalSocketPath = originalPath }()
+
+	sink, err := NewJournaldSink("asc")
+	if err != nil {
+		t.Fatalf("Failed to create journald sink: %v", err)
+	}
+	defer sink.Close()
+
+	err = sink.Write(LogEntry{
+		Level:     "ERROR",
+		Message:   "Agent crashed\nexit status 1",
+		Component: "process",
+		Caller:    "manager.go:120",
+		Fields:    map[string]interface{}{"exit-code": 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to write to journald sink: %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	listener.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := listener.Read(buf)
+	if err != nil {
+		t.Fatalf("Failed to read journal datagram: %v", err)
+	}
+
+	record := string(buf[:n])
+	for _, want := range []string{
+		"PRIORITY=3\n",
+		"SYSLOG_IDENTIFIER=asc\n",
+		"CODE_FILE=manager.go\n",
+		"CODE_LINE=120\n",
+		"ASC_COMPONENT=process\n",
+		"ASC_EXIT_CODE=1\n",
+		"MESSAGE\n", // multi-line values use the binary encoding
+		"Agent crashed\nexit status 1\n",
+	} {
+		if !strings.Contains(record, want) {
+			t.Errorf("Expected journal record to contain %q, got %q", want, record)
+		}
+	}
+}
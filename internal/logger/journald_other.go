@@ -0,0 +1,10 @@
+//go:build !linux
+
+package logger
+
+import "fmt"
+
+// NewJournaldSink is not supported on this platform
+func NewJournaldSink(identifier string) (Sink, error) {
+	return nil, fmt.Errorf("journald is only supported on Linux")
+}
@@ -1,6 +1,11 @@
 // Package logger provides structured logging with automatic file rotation
 // for the Agent Stack Controller. It supports multiple log levels and
-// thread-safe concurrent logging with JSON formatting for machine-parseable logs.
+// thread-safe concurrent logging with JSON or logfmt formatting for
+// machine-parseable logs. Structured records carry a timestamp, level,
+// component, and the caller's file and line.
+//
+// Code that prefers log/slog can use Slog, whose records go through the
+// same pipeline with their attributes as fields.
 //
 // Example usage:
 //
@@ -11,26 +16,34 @@
 //
 //	logger.Info("Starting agent stack")
 //	logger.WithFields(Fields{"agent": "planner", "task": "123"}).Info("Processing task")
+//	logger.WithComponent("mcp").Debug("Fetching messages")
 //	logger.Error("Failed to start agent: %v", err)
+//	logger.Slog("process").Info("Started process", "agent", "planner", "pid", 4242)
 package logger
 
 import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rand/asc/internal/statedir"
 )
 
 // LogLevel represents the severity of a log message.
 type LogLevel int
 
 const (
-	DEBUG LogLevel = iota
+	TRACE LogLevel = iota - 1 // Very verbose diagnostics such as request and response bodies
+	DEBUG
 	INFO
 	WARN
 	ERROR
@@ -39,6 +52,8 @@ const (
 // String returns the string representation of the log level
 func (l LogLevel) String() string {
 	switch l {
+	case TRACE:
+		return "TRACE"
 	case DEBUG:
 		return "DEBUG"
 	case INFO:
@@ -52,6 +67,61 @@ func (l LogLevel) String() string {
 	}
 }
 
+// ParseLevel converts a level name ("trace", "debug", "info", "warn",
+// "error") to a LogLevel. Matching is case-insensitive and "warning" is
+// accepted as an alias for "warn".
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "trace":
+		return TRACE, nil
+	case "debug":
+		return DEBUG, nil
+	case "info":
+		return INFO, nil
+	case "warn", "warning":
+		return WARN, nil
+	case "error":
+		return ERROR, nil
+	default:
+		return INFO, fmt.Errorf("unknown log level '%s' (valid levels: trace, debug, info, warn, error)", name)
+	}
+}
+
+// ParseLevelSpec parses a level specification such as "info", "mcp=debug",
+// or "warn,mcp=debug,process=trace": an optional global level and
+// per-component levels, separated by commas. hasLevel is false when the
+// spec sets no global level.
+func ParseLevelSpec(spec string) (level LogLevel, hasLevel bool, components map[string]LogLevel, err error) {
+	level = INFO
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		component, name, found := strings.Cut(part, "=")
+		if !found {
+			if level, err = ParseLevel(part); err != nil {
+				return INFO, false, nil, err
+			}
+			hasLevel = true
+			continue
+		}
+		component = strings.ToLower(strings.TrimSpace(component))
+		if component == "" {
+			return INFO, false, nil, fmt.Errorf("missing component name in '%s'", part)
+		}
+		componentLevel, err := ParseLevel(name)
+		if err != nil {
+			return INFO, false, nil, fmt.Errorf("component '%s': %w", component, err)
+		}
+		if components == nil {
+			components = make(map[string]LogLevel)
+		}
+		components[component] = componentLevel
+	}
+	return level, hasLevel, components, nil
+}
+
 // Fields represents structured context fields for logging
 type Fields map[string]interface{}
 
@@ -61,14 +131,49 @@ type LogFormat int
 const (
 	FormatText LogFormat = iota
 	FormatJSON
+	FormatLogfmt
 )
 
+// String returns the configuration name of the log format
+func (f LogFormat) String() string {
+	switch f {
+	case FormatText:
+		return "text"
+	case FormatJSON:
+		return "json"
+	case FormatLogfmt:
+		return "logfmt"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseFormat converts a format name ("text", "json", "logfmt") to a LogFormat.
+// Matching is case-insensitive. Returns an error for unknown names.
+func ParseFormat(name string) (LogFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	case "logfmt":
+		return FormatLogfmt, nil
+	default:
+		return FormatText, fmt.Errorf("unknown log format '%s' (valid formats: text, json, logfmt)", name)
+	}
+}
+
+// TimestampLayout is the layout used for timestamps in every log format
+const TimestampLayout = "2006-01-02 15:04:05.000"
+
 // LogEntry represents a structured log entry
 type LogEntry struct {
 	Timestamp     string                 `json:"timestamp"`
 	Level         string                 `json:"level"`
 	Message       string                 `json:"message"`
 	CorrelationID string                 `json:"correlation_id,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Caller        string                 `json:"caller,omitempty"`
 	Agent         string                 `json:"agent,omitempty"`
 	Task          string                 `json:"task,omitempty"`
 	Phase         string                 `json:"phase,omitempty"`
@@ -88,6 +193,10 @@ type Logger struct {
 	format        LogFormat
 	correlationID string
 	contextFields Fields
+	sinks         []Sink
+
+	// componentLevels overrides minLevel for entries tagged with a component
+	componentLevels map[string]LogLevel
 }
 
 var (
@@ -113,13 +222,12 @@ func Init() error {
 func InitWithFormat(format LogFormat) error {
 	var err error
 	once.Do(func() {
-		homeDir, e := os.UserHomeDir()
+		logDir, e := statedir.Path("logs")
 		if e != nil {
-			err = fmt.Errorf("failed to get home directory: %w", e)
+			err = e
 			return
 		}
 
-		logDir := filepath.Join(homeDir, ".asc", "logs")
 		// Create log directory with secure permissions (0700)
 		if e := os.MkdirAll(logDir, 0700); e != nil {
 			err = fmt.Errorf("failed to create log directory: %w", e)
@@ -130,6 +238,11 @@ func InitWithFormat(format LogFormat) error {
 		defaultLogger, err = NewLogger(logPath, 10*1024*1024, 5, INFO)
 		if err == nil {
 			defaultLogger.SetFormat(format)
+			if id := currentCorrelationIDValue(); id != "" {
+				defaultLogger.WithCorrelationID(id)
+			}
+			// Code using log/slog writes to asc's log as well
+			slog.SetDefault(Slog(""))
 		}
 	})
 	return err
@@ -175,6 +288,10 @@ func NewLogger(logPath string, maxSize int64, maxBackups int, minLevel LogLevel)
 func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	for _, sink := range l.sinks {
+		sink.Close()
+	}
+	l.sinks = nil
 	if l.file != nil {
 		return l.file.Close()
 	}
@@ -221,76 +338,33 @@ func (l *Logger) log(level LogLevel, fields Fields, format string, args ...inter
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if level < l.minLevel {
+	if level < l.levelFor(fields) {
 		return
 	}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
-	message := fmt.Sprintf(format, args...)
+	// Mask secrets before anything reaches the log file
+	message := Redact(fmt.Sprintf(format, args...))
+	fields = redactFields(fields)
+	contextFields := redactFields(l.contextFields)
 
-	var logLine string
-	if l.format == FormatJSON {
-		entry := LogEntry{
-			Timestamp:     timestamp,
-			Level:         level.String(),
-			Message:       message,
-			CorrelationID: l.correlationID,
-			Fields:        make(map[string]interface{}),
-		}
-
-		// Merge context fields and provided fields
-		for k, v := range l.contextFields {
-			entry.Fields[k] = v
-		}
-		for k, v := range fields {
-			// Extract special fields
-			switch k {
-			case "agent":
-				if s, ok := v.(string); ok {
-					entry.Agent = s
-				}
-			case "task":
-				if s, ok := v.(string); ok {
-					entry.Task = s
-				}
-			case "phase":
-				if s, ok := v.(string); ok {
-					entry.Phase = s
-				}
-			default:
-				entry.Fields[k] = v
-			}
-		}
+	entry := buildEntry(level, contextFields, fields, message, l.correlationID)
+	if l == defaultLogger {
+		recent.Add(entry)
+	}
 
+	var logLine string
+	switch l.format {
+	case FormatJSON:
 		jsonBytes, err := json.Marshal(entry)
 		if err != nil {
-			logLine = fmt.Sprintf("[%s] [%s] %s (JSON marshal error: %v)\n", timestamp, level.String(), message, err)
+			logLine = fmt.Sprintf("[%s] [%s] %s (JSON marshal error: %v)\n", entry.Timestamp, entry.Level, entry.Message, err)
 		} else {
 			logLine = string(jsonBytes) + "\n"
 		}
-	} else {
-		// Text format
-		logLine = fmt.Sprintf("[%s] [%s] %s", timestamp, level.String(), message)
-		if len(fields) > 0 || len(l.contextFields) > 0 {
-			logLine += " {"
-			first := true
-			for k, v := range l.contextFields {
-				if !first {
-					logLine += ", "
-				}
-				logLine += fmt.Sprintf("%s=%v", k, v)
-				first = false
-			}
-			for k, v := range fields {
-				if !first {
-					logLine += ", "
-				}
-				logLine += fmt.Sprintf("%s=%v", k, v)
-				first = false
-			}
-			logLine += "}"
-		}
-		logLine += "\n"
+	case FormatLogfmt:
+		logLine = formatLogfmt(entry) + "\n"
+	default:
+		logLine = formatText(level, contextFields, fields, entry) + "\n"
 	}
 
 	// Check if rotation is needed
@@ -306,6 +380,156 @@ func (l *Logger) log(level LogLevel, fields Fields, format string, args ...inter
 	}
 
 	l.currentSize += int64(n)
+
+	l.writeSinks(entry)
+}
+
+// buildEntry assembles a structured record from the message, the logger's
+// context fields, and the per-call fields. The well-known keys agent, task,
+// phase, component, and correlation_id are lifted out of the field map onto
+// the record.
+func buildEntry(level LogLevel, contextFields, fields Fields, message, correlationID string) LogEntry {
+	entry := LogEntry{
+		Timestamp:     time.Now().Format(TimestampLayout),
+		Level:         level.String(),
+		Message:       message,
+		CorrelationID: correlationID,
+		Caller:        callerLocation(),
+		Fields:        make(map[string]interface{}),
+	}
+
+	// Merge context fields and provided fields
+	for k, v := range contextFields {
+		entry.Fields[k] = v
+	}
+	for k, v := range fields {
+		// Extract special fields
+		switch k {
+		case "agent":
+			if s, ok := v.(string); ok {
+				entry.Agent = s
+			}
+		case "task":
+			if s, ok := v.(string); ok {
+				entry.Task = s
+			}
+		case "phase":
+			if s, ok := v.(string); ok {
+				entry.Phase = s
+			}
+		case "component":
+			if s, ok := v.(string); ok {
+				entry.Component = s
+			}
+		case "correlation_id":
+			if s, ok := v.(string); ok && s != "" {
+				entry.CorrelationID = s
+			}
+		default:
+			entry.Fields[k] = v
+		}
+	}
+
+	return entry
+}
+
+// formatText renders the human-readable text format:
+// [timestamp] [LEVEL] message {key=value, ...}
+func formatText(level LogLevel, contextFields, fields Fields, entry LogEntry) string {
+	logLine := fmt.Sprintf("[%s] [%s] %s", entry.Timestamp, level.String(), entry.Message)
+	if len(fields) > 0 || len(contextFields) > 0 {
+		pairs := make([]string, 0, len(fields)+len(contextFields))
+		for _, k := range sortedKeys(contextFields) {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, contextFields[k]))
+		}
+		for _, k := range sortedKeys(fields) {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, fields[k]))
+		}
+		logLine += " {" + strings.Join(pairs, ", ") + "}"
+	}
+	return logLine
+}
+
+// formatLogfmt renders an entry as a single logfmt line. Fixed keys come
+// first in a stable order, followed by the remaining fields sorted by key.
+// The ts key is omitted when the entry has no timestamp.
+func formatLogfmt(entry LogEntry) string {
+	var b strings.Builder
+	writePair := func(key string, value interface{}) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(fmt.Sprintf("%v", value)))
+	}
+
+	if entry.Timestamp != "" {
+		writePair("ts", entry.Timestamp)
+	}
+	writePair("level", strings.ToLower(entry.Level))
+	writePair("msg", entry.Message)
+	optional := []struct{ key, value string }{
+		{"component", entry.Component},
+		{"agent", entry.Agent},
+		{"task", entry.Task},
+		{"phase", entry.Phase},
+		{"correlation_id", entry.CorrelationID},
+		{"caller", entry.Caller},
+	}
+	for _, kv := range optional {
+		if kv.value != "" {
+			writePair(kv.key, kv.value)
+		}
+	}
+	for _, k := range sortedKeys(entry.Fields) {
+		writePair(k, entry.Fields[k])
+	}
+	return b.String()
+}
+
+// logfmtValue quotes a value when it is empty or contains characters that
+// would otherwise break logfmt tokenization
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\t\r\n") {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
+
+// sortedKeys returns the keys of a field map in lexical order so that
+// rendered output is deterministic
+func sortedKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// callerLocation returns "file.go:line" for the first stack frame outside
+// of this package's logging code, i.e. the code that invoked the logging call
+func callerLocation() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasSuffix(frame.File, "/internal/logger/logger.go") && !strings.HasSuffix(frame.File, "/internal/logger/ring.go") &&
+			!strings.HasSuffix(frame.File, "/internal/logger/slog.go") && !strings.Contains(frame.File, "/log/slog/") {
+			return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// Trace logs a trace message with printf-style formatting.
+// Only logged if the logger's level is TRACE.
+func (l *Logger) Trace(format string, args ...interface{}) {
+	l.log(TRACE, nil, format, args...)
 }
 
 // Debug logs a debug message with printf-style formatting.
@@ -340,6 +564,12 @@ func (l *Logger) WithFields(fields Fields) *Entry {
 	}
 }
 
+// WithComponent creates a new Entry tagged with the given component name
+// (e.g. "mcp", "process", "beads")
+func (l *Logger) WithComponent(component string) *Entry {
+	return l.WithFields(Fields{"component": component})
+}
+
 // WithCorrelationID sets a correlation ID for tracing requests across components
 func (l *Logger) WithCorrelationID(id string) *Logger {
 	l.mu.Lock()
@@ -370,6 +600,47 @@ func (l *Logger) SetLevel(level LogLevel) {
 	l.minLevel = level
 }
 
+// SetComponentLevel overrides the minimum level for entries tagged with the
+// given component (see WithComponent). Thread-safe.
+func (l *Logger) SetComponentLevel(component string, level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.componentLevels == nil {
+		l.componentLevels = make(map[string]LogLevel)
+	}
+	l.componentLevels[strings.ToLower(component)] = level
+}
+
+// SetComponentLevels replaces all component level overrides. Thread-safe.
+func (l *Logger) SetComponentLevels(levels map[string]LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.componentLevels = make(map[string]LogLevel, len(levels))
+	for component, level := range levels {
+		l.componentLevels[strings.ToLower(component)] = level
+	}
+}
+
+// levelFor returns the minimum level that applies to an entry with the
+// given fields. Must be called with l.mu held.
+func (l *Logger) levelFor(fields Fields) LogLevel {
+	if len(l.componentLevels) > 0 {
+		if component, ok := fields["component"].(string); ok {
+			if level, ok := l.componentLevels[strings.ToLower(component)]; ok {
+				return level
+			}
+		}
+	}
+	return l.minLevel
+}
+
+// Level returns the current minimum log level. Thread-safe.
+func (l *Logger) Level() LogLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.minLevel
+}
+
 // SetFormat sets the log output format (text or JSON)
 func (l *Logger) SetFormat(format LogFormat) {
 	l.mu.Lock()
@@ -379,40 +650,91 @@ func (l *Logger) SetFormat(format LogFormat) {
 
 // Entry methods for structured logging
 
+// target returns the logger the entry writes to. Entries created before the
+// default logger is initialized resolve it at call time, which allows
+// packages to hold component-scoped entries in package-level variables.
+func (e *Entry) target() *Logger {
+	if e.logger != nil {
+		return e.logger
+	}
+	return defaultLogger
+}
+
+// WithFields returns a new Entry that carries the entry's fields merged with
+// the given fields. Keys in fields take precedence.
+func (e *Entry) WithFields(fields Fields) *Entry {
+	merged := make(Fields, len(e.fields)+len(fields))
+	for k, v := range e.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Entry{logger: e.logger, fields: merged}
+}
+
+// Trace logs a trace message with the entry's fields
+func (e *Entry) Trace(format string, args ...interface{}) {
+	if l := e.target(); l != nil {
+		l.log(TRACE, e.fields, format, args...)
+	} else {
+		recordWithoutLogger(TRACE, e.fields, format, args...)
+	}
+}
+
 // Debug logs a debug message with the entry's fields
 func (e *Entry) Debug(format string, args ...interface{}) {
-	if e.logger != nil {
-		e.logger.log(DEBUG, e.fields, format, args...)
+	if l := e.target(); l != nil {
+		l.log(DEBUG, e.fields, format, args...)
+	} else {
+		recordWithoutLogger(DEBUG, e.fields, format, args...)
 	}
 }
 
 // Info logs an info message with the entry's fields
 func (e *Entry) Info(format string, args ...interface{}) {
-	if e.logger != nil {
-		e.logger.log(INFO, e.fields, format, args...)
+	if l := e.target(); l != nil {
+		l.log(INFO, e.fields, format, args...)
+	} else {
+		recordWithoutLogger(INFO, e.fields, format, args...)
 	}
 }
 
 // Warn logs a warning message with the entry's fields
 func (e *Entry) Warn(format string, args ...interface{}) {
-	if e.logger != nil {
-		e.logger.log(WARN, e.fields, format, args...)
+	if l := e.target(); l != nil {
+		l.log(WARN, e.fields, format, args...)
+	} else {
+		recordWithoutLogger(WARN, e.fields, format, args...)
 	}
 }
 
 // Error logs an error message with the entry's fields
 func (e *Entry) Error(format string, args ...interface{}) {
-	if e.logger != nil {
-		e.logger.log(ERROR, e.fields, format, args...)
+	if l := e.target(); l != nil {
+		l.log(ERROR, e.fields, format, args...)
+	} else {
+		recordWithoutLogger(ERROR, e.fields, format, args...)
 	}
 }
 
 // Global logging functions using the default logger
 
+// Trace logs a trace message using the default logger
+func Trace(format string, args ...interface{}) {
+	if defaultLogger != nil {
+		defaultLogger.Trace(format, args...)
+	} else {
+		recordWithoutLogger(TRACE, nil, format, args...)
+	}
+}
+
 // Debug logs a debug message using the default logger
 func Debug(format string, args ...interface{}) {
 	if defaultLogger != nil {
 		defaultLogger.Debug(format, args...)
+	} else {
+		recordWithoutLogger(DEBUG, nil, format, args...)
 	}
 }
 
@@ -420,6 +742,8 @@ func Debug(format string, args ...interface{}) {
 func Info(format string, args ...interface{}) {
 	if defaultLogger != nil {
 		defaultLogger.Info(format, args...)
+	} else {
+		recordWithoutLogger(INFO, nil, format, args...)
 	}
 }
 
@@ -427,6 +751,8 @@ func Info(format string, args ...interface{}) {
 func Warn(format string, args ...interface{}) {
 	if defaultLogger != nil {
 		defaultLogger.Warn(format, args...)
+	} else {
+		recordWithoutLogger(WARN, nil, format, args...)
 	}
 }
 
@@ -434,6 +760,8 @@ func Warn(format string, args ...interface{}) {
 func Error(format string, args ...interface{}) {
 	if defaultLogger != nil {
 		defaultLogger.Error(format, args...)
+	} else {
+		recordWithoutLogger(ERROR, nil, format, args...)
 	}
 }
 
@@ -442,8 +770,14 @@ func WithFields(fields Fields) *Entry {
 	if defaultLogger != nil {
 		return defaultLogger.WithFields(fields)
 	}
-	// Return a no-op entry if logger is not initialized
-	return &Entry{logger: &Logger{minLevel: ERROR + 1}, fields: fields}
+	// The entry resolves the default logger when it is used, and is a
+	// no-op if the logger has still not been initialized by then
+	return &Entry{fields: fields}
+}
+
+// WithComponent creates a new Entry tagged with the given component name using the default logger
+func WithComponent(component string) *Entry {
+	return WithFields(Fields{"component": component})
 }
 
 // WithCorrelationID sets a correlation ID for the default logger
@@ -475,6 +809,22 @@ func SetLevel(level LogLevel) {
 	}
 }
 
+// SetComponentLevels replaces the component level overrides of the default logger
+func SetComponentLevels(levels map[string]LogLevel) {
+	if defaultLogger != nil {
+		defaultLogger.SetComponentLevels(levels)
+	}
+}
+
+// GetLevel returns the minimum log level of the default logger, or INFO if
+// the default logger has not been initialized
+func GetLevel() LogLevel {
+	if defaultLogger != nil {
+		return defaultLogger.Level()
+	}
+	return INFO
+}
+
 // SetFormat sets the log output format for the default logger
 func SetFormat(format LogFormat) {
 	if defaultLogger != nil {
@@ -482,8 +832,12 @@ func SetFormat(format LogFormat) {
 	}
 }
 
-// Close closes the default logger
+// Close saves asc's recent log records (see SaveRecent) and closes the
+// default logger. Call it before exiting, including on error paths.
 func Close() error {
+	if err := SaveRecent(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 	if defaultLogger != nil {
 		return defaultLogger.Close()
 	}
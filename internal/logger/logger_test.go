@@ -791,6 +791,7 @@ func TestLogLevelString(t *testing.T) {
 		level    LogLevel
 		expected string
 	}{
+		{TRACE, "TRACE"},
 		{DEBUG, "DEBUG"},
 		{INFO, "INFO"},
 		{WARN, "WARN"},
@@ -965,3 +966,293 @@ func TestJSONMarshalError(t *testing.T) {
 		t.Error("Expected JSON marshal error message in log")
 	}
 }
+
+func TestLogfmtLogging(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "test.log")
+
+	logger, err := NewLogger(logPath, 1024*1024, 2, DEBUG)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.SetFormat(FormatLogfmt)
+	logger.WithComponent("mcp").WithFields(Fields{
+		"agent": "test-agent",
+		"url":   "http://localhost:8765",
+		"count": 3,
+	}).Warn("Request failed after retries")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	line := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(line, "ts=") {
+		t.Errorf("Expected logfmt line to start with ts=, got %s", line)
+	}
+	for _, want := range []string{
+		"level=warn",
+		`msg="Request failed after retries"`,
+		"component=mcp",
+		"agent=test-agent",
+		"count=3",
+		"url=http://localhost:8765",
+		"caller=logger_test.go:",
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected logfmt line to contain %q, got %s", want, line)
+		}
+	}
+
+	// Extra fields are sorted by key for deterministic output
+	if strings.Index(line, "count=") > strings.Index(line, "url=") {
+		t.Errorf("Expected fields in sorted order, got %s", line)
+	}
+}
+
+func TestCallerAndComponent(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "test.log")
+
+	logger, err := NewLogger(logPath, 1024*1024, 2, DEBUG)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.SetFormat(FormatJSON)
+	logger.WithComponent("process").Info("Started agent")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	var entry LogEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		t.Fatalf("Failed to parse JSON log: %v", err)
+	}
+
+	if entry.Component != "process" {
+		t.Errorf("Expected component 'process', got '%s'", entry.Component)
+	}
+	if !strings.HasPrefix(entry.Caller, "logger_test.go:") {
+		t.Errorf("Expected caller in logger_test.go, got '%s'", entry.Caller)
+	}
+	if _, ok := entry.Fields["component"]; ok {
+		t.Error("Component should be extracted from the Fields map")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    LogFormat
+		wantErr bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{"JSON", FormatJSON, false},
+		{"logfmt", FormatLogfmt, false},
+		{"xml", FormatText, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseFormat(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if FormatLogfmt.String() != "logfmt" {
+		t.Errorf("FormatLogfmt.String() = %s, want logfmt", FormatLogfmt.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    LogLevel
+		wantErr bool
+	}{
+		{"trace", TRACE, false},
+		{"DEBUG", DEBUG, false},
+		{"info", INFO, false},
+		{"warn", WARN, false},
+		{"warning", WARN, false},
+		{" error ", ERROR, false},
+		{"verbose", INFO, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTraceLevel(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "test.log")
+
+	logger, err := NewLogger(logPath, 1024*1024, 2, DEBUG)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Trace("Hidden trace message")
+	logger.SetLevel(TRACE)
+	logger.WithFields(Fields{"url": "http://localhost"}).Trace("Visible trace message")
+
+	if logger.Level() != TRACE {
+		t.Errorf("Level() = %v, want TRACE", logger.Level())
+	}
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	logContent := string(content)
+	if strings.Contains(logContent, "Hidden trace message") {
+		t.Error("Trace message should not be logged at DEBUG level")
+	}
+	if !strings.Contains(logContent, "[TRACE] Visible trace message") {
+		t.Errorf("Expected trace message to be logged, got %s", logContent)
+	}
+}
+
+func TestComponentLevelOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "test.log")
+
+	logger, err := NewLogger(logPath, 1024*1024, 2, INFO)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.SetComponentLevels(map[string]LogLevel{"mcp": DEBUG, "process": WARN})
+
+	logger.WithComponent("mcp").Debug("mcp debug message")
+	logger.WithComponent("process").Info("process info message")
+	logger.WithComponent("process").Warn("process warn message")
+	logger.Debug("untagged debug message")
+	logger.Info("untagged info message")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	logContent := string(content)
+
+	for _, want := range []string{"mcp debug message", "process warn message", "untagged info message"} {
+		if !strings.Contains(logContent, want) {
+			t.Errorf("Expected %q to be logged", want)
+		}
+	}
+	for _, hidden := range []string{"process info message", "untagged debug message"} {
+		if strings.Contains(logContent, hidden) {
+			t.Errorf("Expected %q to be filtered", hidden)
+		}
+	}
+}
+
+func TestEntryCorrelationID(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "test.log")
+
+	logger, err := NewLogger(logPath, 1024*1024, 2, DEBUG)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.SetFormat(FormatJSON)
+	logger.WithCorrelationID("command-id")
+	logger.WithComponent("health").WithCorrelationID("cycle-id").Info("Cycle message")
+	logger.Info("Command message")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d", len(lines))
+	}
+
+	want := []string{"cycle-id", "command-id"}
+	for i, line := range lines {
+		var entry LogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to parse JSON log: %v", err)
+		}
+		if entry.CorrelationID != want[i] {
+			t.Errorf("Line %d: expected correlation ID %s, got %s", i, want[i], entry.CorrelationID)
+		}
+		if _, ok := entry.Fields["correlation_id"]; ok {
+			t.Errorf("Line %d: correlation_id should not be duplicated in fields", i)
+		}
+	}
+}
+
+func TestStartCorrelation(t *testing.T) {
+	defer SetCorrelationID("")
+
+	first := StartCorrelation()
+	if first == "" || CorrelationID() != first {
+		t.Errorf("CorrelationID() = %q, want %q", CorrelationID(), first)
+	}
+
+	second := StartCorrelation()
+	if second == first {
+		t.Error("Expected a new correlation ID for each call")
+	}
+	if CorrelationID() != second {
+		t.Errorf("CorrelationID() = %q, want %q", CorrelationID(), second)
+	}
+}
+
+func TestParseLevelSpec(t *testing.T) {
+	tests := []struct {
+		spec           string
+		wantLevel      LogLevel
+		wantHasLevel   bool
+		wantComponents map[string]LogLevel
+		wantErr        bool
+	}{
+		{spec: "debug", wantLevel: DEBUG, wantHasLevel: true},
+		{spec: "mcp=trace", wantLevel: INFO, wantComponents: map[string]LogLevel{"mcp": TRACE}},
+		{spec: "warn, MCP=debug ,process=error", wantLevel: WARN, wantHasLevel: true, wantComponents: map[string]LogLevel{"mcp": DEBUG, "process": ERROR}},
+		{spec: "", wantLevel: INFO},
+		{spec: "mcp=loud", wantErr: true},
+		{spec: "=debug", wantErr: true},
+		{spec: "loud", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		level, hasLevel, components, err := ParseLevelSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevelSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if level != tt.wantLevel || hasLevel != tt.wantHasLevel || fmt.Sprint(components) != fmt.Sprint(tt.wantComponents) {
+			t.Errorf("ParseLevelSpec(%q) = (%v, %v, %v), want (%v, %v, %v)", tt.spec,
+				level, hasLevel, components, tt.wantLevel, tt.wantHasLevel, tt.wantComponents)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedPlaceholder replaces secret values in log output
+const RedactedPlaceholder = "[REDACTED]"
+
+// minSecretLength is the shortest registered value that will be redacted.
+// Shorter values (e.g. "1", "true") would mask ordinary words in log lines.
+const minSecretLength = 8
+
+// secretPatterns match well-known API key and token formats
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]{20,}`),       // Anthropic
+	regexp.MustCompile(`sk-(?:proj-)?[A-Za-z0-9_\-]{20,}`), // OpenAI
+	regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}`),           // Google
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{36,}`),       // GitHub
+	regexp.MustCompile(`xox[abprs]-[A-Za-z0-9\-]{10,}`),    // Slack
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),                 // AWS access key ID
+	regexp.MustCompile(`AGE-SECRET-KEY-1[0-9A-Z]{58}`),     // age identity
+}
+
+// sensitiveKeySuffixes identify environment variable names whose values are secrets
+var sensitiveKeySuffixes = []string{"_KEY", "TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "CREDENTIALS"}
+
+// redactor holds the set of known secret values to mask
+type redactor struct {
+	mu      sync.RWMutex
+	secrets []string // sorted longest first so overlapping values are fully masked
+}
+
+var defaultRedactor = &redactor{}
+
+// IsSensitiveKey reports whether an environment variable or field name
+// conventionally holds a secret (e.g. CLAUDE_API_KEY, GITHUB_TOKEN)
+func IsSensitiveKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, suffix := range sensitiveKeySuffixes {
+		if strings.HasSuffix(upper, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterSecret adds a value that will be masked wherever it appears in
+// log output. Values shorter than 8 characters are ignored.
+func RegisterSecret(value string) {
+	defaultRedactor.add(value)
+}
+
+// RegisterSecretsFromEnv registers the values of all environment variables
+// whose names look sensitive (see IsSensitiveKey). Call it again after
+// loading a .env file so newly set keys are covered.
+func RegisterSecretsFromEnv() {
+	for _, kv := range os.Environ() {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 && IsSensitiveKey(parts[0]) {
+			defaultRedactor.add(parts[1])
+		}
+	}
+}
+
+// Redact masks registered secret values and strings matching known API key
+// patterns in s. It is safe to call before the logger is initialized.
+func Redact(s string) string {
+	return defaultRedactor.redact(s)
+}
+
+// ContainsSecretPattern reports whether s contains a string matching a
+// known API key or token format
+func ContainsSecretPattern(s string) bool {
+	for _, pattern := range secretPatterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) add(value string) {
+	value = strings.TrimSpace(value)
+	if len(value) < minSecretLength {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.secrets {
+		if existing == value {
+			return
+		}
+	}
+	r.secrets = append(r.secrets, value)
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+func (r *redactor) redact(s string) string {
+	if s == "" {
+		return s
+	}
+
+	r.mu.RLock()
+	for _, secret := range r.secrets {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, RedactedPlaceholder)
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, RedactedPlaceholder)
+	}
+	return s
+}
+
+// redactFields returns a copy of fields with secrets masked. Values under
+// sensitive keys are replaced entirely; strings, errors, and string slices
+// are scanned for secrets. Other values are left untouched so they keep
+// their JSON encoding.
+func redactFields(fields Fields) Fields {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	redacted := make(Fields, len(fields))
+	for k, v := range fields {
+		if IsSensitiveKey(k) {
+			redacted[k] = RedactedPlaceholder
+			continue
+		}
+		redacted[k] = redactValue(v)
+	}
+	return redacted
+}
+
+// redactValue masks secrets in the string forms of a field value
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return Redact(val)
+	case error:
+		return Redact(val.Error())
+	case []string:
+		out := make([]string, len(val))
+		for i, s := range val {
+			out[i] = Redact(s)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rand/asc/internal/statedir"
+)
+
+// DefaultRecentSize is the number of asc's own log records kept in memory
+const DefaultRecentSize = 500
+
+// RingBuffer holds the most recent log records, discarding the oldest once
+// it is full. It is safe for concurrent use.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int
+	full    bool
+}
+
+// NewRingBuffer creates a ring buffer that keeps up to size records
+func NewRingBuffer(size int) *RingBuffer {
+	if size < 1 {
+		size = 1
+	}
+	return &RingBuffer{entries: make([]LogEntry, size)}
+}
+
+// Add appends a record, overwriting the oldest one when the buffer is full
+func (r *RingBuffer) Add(entry LogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Entries returns a copy of the buffered records, oldest first
+func (r *RingBuffer) Entries() []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]LogEntry(nil), r.entries[:r.next]...)
+	}
+	out := make([]LogEntry, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+// recent captures every record asc logs through the package-level
+// functions, whether or not the default logger could be initialized
+var recent = NewRingBuffer(DefaultRecentSize)
+
+// RecentEntries returns asc's own recent log records from this process,
+// oldest first
+func RecentEntries() []LogEntry {
+	return recent.Entries()
+}
+
+// recordWithoutLogger keeps an INFO or higher record in the ring buffer
+// when the default logger is not available, so that failures before or
+// during logger initialization can still be inspected
+func recordWithoutLogger(level LogLevel, fields Fields, format string, args ...interface{}) {
+	if level < INFO {
+		return
+	}
+	message := Redact(fmt.Sprintf(format, args...))
+	recent.Add(buildEntry(level, nil, redactFields(fields), message, CorrelationID()))
+}
+
+// recentFileName is the name of the file the ring buffer is saved to
+const recentFileName = "recent.jsonl"
+
+// RecentPaths returns the locations asc saves its recent log records to:
+// logs/recent.jsonl in the state directory, and a per-user file in the
+// temp directory used when the state directory is not writable
+func RecentPaths() []string {
+	paths := []string{}
+	if path, err := statedir.Path("logs", recentFileName); err == nil {
+		paths = append(paths, path)
+	}
+	return append(paths, filepath.Join(os.TempDir(), fmt.Sprintf("asc-%d-%s", os.Getuid(), recentFileName)))
+}
+
+// SaveRecent appends this process's buffered records to the history saved
+// by earlier asc processes, keeping the newest DefaultRecentSize records,
+// and writes it to the first writable location from RecentPaths. It does
+// nothing when no records were captured.
+func SaveRecent() error {
+	if len(recent.Entries()) == 0 {
+		return nil
+	}
+	entries := RecentHistory()
+
+	var data strings.Builder
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		data.Write(line)
+		data.WriteByte('\n')
+	}
+
+	var lastErr error
+	for _, path := range RecentPaths() {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			lastErr = err
+			continue
+		}
+		if err := os.WriteFile(path, []byte(data.String()), 0600); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to save recent log records: %w", lastErr)
+}
+
+// LoadRecent reads the records saved by the most recent asc process. If
+// records were saved to more than one location, the newest file wins.
+func LoadRecent() ([]LogEntry, error) {
+	var newest string
+	var newestInfo os.FileInfo
+	for _, path := range RecentPaths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if newestInfo == nil || info.ModTime().After(newestInfo.ModTime()) {
+			newest, newestInfo = path, info
+		}
+	}
+	if newest == "" {
+		return nil, nil
+	}
+	return readRecentFile(newest)
+}
+
+// readRecentFile parses a JSON Lines file of log records, skipping lines
+// that cannot be decoded
+func readRecentFile(path string) ([]LogEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recent log records: %w", err)
+	}
+	defer file.Close()
+
+	entries := []LogEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read recent log records: %w", err)
+	}
+	return entries, nil
+}
+
+// RecentHistory returns the saved records of earlier asc processes followed
+// by this process's records, limited to the newest DefaultRecentSize
+func RecentHistory() []LogEntry {
+	saved, _ := LoadRecent()
+	entries := append(saved, recent.Entries()...)
+	if len(entries) > DefaultRecentSize {
+		entries = entries[len(entries)-DefaultRecentSize:]
+	}
+	return entries
+}
+
+// FormatEntryText renders a record in the text log format:
+// [timestamp] [LEVEL] message {key=value, ...}
+func FormatEntryText(entry LogEntry) string {
+	line := fmt.Sprintf("[%s] [%s] %s", entry.Timestamp, entry.Level, entry.Message)
+
+	pairs := []string{}
+	for _, kv := range [][2]string{
+		{"component", entry.Component},
+		{"agent", entry.Agent},
+		{"task", entry.Task},
+		{"phase", entry.Phase},
+		{"correlation_id", entry.CorrelationID},
+		{"caller", entry.Caller},
+	} {
+		if kv[1] != "" {
+			pairs = append(pairs, kv[0]+"="+kv[1])
+		}
+	}
+	for _, k := range sortedKeys(entry.Fields) {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, entry.Fields[k]))
+	}
+	if len(pairs) > 0 {
+		line += " {" + strings.Join(pairs, ", ") + "}"
+	}
+	return line
+}
@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer(t *testing.T) {
+	ring := NewRingBuffer(3)
+	if got := ring.Entries(); len(got) != 0 {
+		t.Fatalf("Entries() on an empty buffer = %v, want none", got)
+	}
+
+	for _, msg := range []string{"one", "two"} {
+		ring.Add(LogEntry{Message: msg})
+	}
+	assertMessages(t, ring.Entries(), "one", "two")
+
+	for _, msg := range []string{"three", "four", "five"} {
+		ring.Add(LogEntry{Message: msg})
+	}
+	assertMessages(t, ring.Entries(), "three", "four", "five")
+}
+
+func TestRecordWithoutLogger(t *testing.T) {
+	orig := recent
+	recent = NewRingBuffer(10)
+	defer func() { recent = orig }()
+
+	RegisterSecret("ring-secret-value-123")
+	Debug("debug records are dropped without a logger")
+	Warn("could not open log file: ring-secret-value-123")
+	WithComponent("beads").Error("refresh failed")
+
+	entries := RecentEntries()
+	assertMessages(t, entries, "could not open log file: "+RedactedPlaceholder, "refresh failed")
+	if entries[1].Component != "beads" {
+		t.Errorf("Component = %q, want beads", entries[1].Component)
+	}
+	if entries[0].Caller == "" || strings.HasPrefix(entries[0].Caller, "ring.go") {
+		t.Errorf("Caller = %q, want the calling file", entries[0].Caller)
+	}
+}
+
+func TestSaveAndLoadRecent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TMPDIR", home)
+
+	orig := recent
+	recent = NewRingBuffer(10)
+	defer func() { recent = orig }()
+
+	if entries, err := LoadRecent(); err != nil || len(entries) != 0 {
+		t.Fatalf("LoadRecent() with nothing saved = %v, %v", entries, err)
+	}
+
+	recent.Add(LogEntry{Level: "INFO", Message: "first run"})
+	if err := SaveRecent(); err != nil {
+		t.Fatalf("SaveRecent() error = %v", err)
+	}
+
+	path := filepath.Join(home, ".asc", "logs", recentFileName)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected records saved to %s: %v", path, err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("recent records mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A later process appends to the saved history
+	recent = NewRingBuffer(10)
+	recent.Add(LogEntry{Level: "ERROR", Message: "second run"})
+	assertMessages(t, RecentHistory(), "first run", "second run")
+	if err := SaveRecent(); err != nil {
+		t.Fatalf("SaveRecent() error = %v", err)
+	}
+
+	entries, err := LoadRecent()
+	if err != nil {
+		t.Fatalf("LoadRecent() error = %v", err)
+	}
+	assertMessages(t, entries, "first run", "second run")
+}
+
+func TestFormatEntryText(t *testing.T) {
+	entry := LogEntry{
+		Timestamp: "2025-01-02T03:04:05.000Z",
+		Level:     "WARN",
+		Message:   "agent stalled",
+		Component: "process",
+		Agent:     "planner",
+		Fields:    map[string]interface{}{"pid": 42},
+	}
+
+	want := "[2025-01-02T03:04:05.000Z] [WARN] agent stalled {component=process, agent=planner, pid=42}"
+	if got := FormatEntryText(entry); got != want {
+		t.Errorf("FormatEntryText() = %q, want %q", got, want)
+	}
+}
+
+func assertMessages(t *testing.T, entries []LogEntry, want ...string) {
+	t.Helper()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %v", len(entries), len(want), entries)
+	}
+	for i, msg := range want {
+		if entries[i].Message != msg {
+			t.Errorf("entry %d message = %q, want %q", i, entries[i].Message, msg)
+		}
+	}
+}
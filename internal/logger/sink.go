@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Sink receives every structured record the logger writes, in addition to
+// the log file. Records are already level-filtered and redacted.
+type Sink interface {
+	Write(entry LogEntry) error
+	Close() error
+}
+
+// AddSink registers an additional output for the logger's records
+func (l *Logger) AddSink(sink Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, sink)
+}
+
+// writeSinks forwards an entry to all registered sinks. Must be called with l.mu held.
+func (l *Logger) writeSinks(entry LogEntry) {
+	for _, sink := range l.sinks {
+		if err := sink.Write(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write log to sink: %v\n", err)
+		}
+	}
+}
+
+// AddSink registers an additional output for the default logger. The sink
+// is closed immediately if the default logger has not been initialized.
+func AddSink(sink Sink) {
+	if defaultLogger != nil {
+		defaultLogger.AddSink(sink)
+		return
+	}
+	sink.Close()
+}
+
+// WriterSink writes records at or above a minimum level to a writer, such
+// as stderr, in one of the log formats. It can be muted while something
+// else owns the writer, e.g. the TUI the terminal.
+type WriterSink struct {
+	mu       sync.Mutex
+	w        io.Writer
+	format   LogFormat
+	minLevel LogLevel
+	muted    bool
+}
+
+// NewWriterSink returns a sink writing records at or above minLevel to w
+func NewWriterSink(w io.Writer, format LogFormat, minLevel LogLevel) *WriterSink {
+	return &WriterSink{w: w, format: format, minLevel: minLevel}
+}
+
+// NewStderrSink returns a sink writing records at or above minLevel to stderr
+func NewStderrSink(format LogFormat, minLevel LogLevel) *WriterSink {
+	return NewWriterSink(os.Stderr, format, minLevel)
+}
+
+// Configure changes the format and minimum level of the sink
+func (s *WriterSink) Configure(format LogFormat, minLevel LogLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.format = format
+	s.minLevel = minLevel
+}
+
+// SetMuted stops or resumes writing records
+func (s *WriterSink) SetMuted(muted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.muted = muted
+}
+
+// Write writes the entry unless it is below the minimum level or the sink
+// is muted
+func (s *WriterSink) Write(entry LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.muted || parseLogLevel(entry.Level) < s.minLevel {
+		return nil
+	}
+	var line string
+	switch s.format {
+	case FormatJSON:
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		line = string(data)
+	case FormatLogfmt:
+		line = formatLogfmt(entry)
+	default:
+		line = formatEntryText(entry)
+	}
+	_, err := io.WriteString(s.w, line+"\n")
+	return err
+}
+
+// Close does nothing; the writer belongs to the caller
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// formatEntryText renders an entry in the text format, with the
+// well-known fields first
+func formatEntryText(entry LogEntry) string {
+	line := fmt.Sprintf("[%s] [%s] %s", entry.Timestamp, entry.Level, entry.Message)
+	pairs := []string{}
+	for _, kv := range []struct{ key, value string }{
+		{"component", entry.Component},
+		{"agent", entry.Agent},
+		{"task", entry.Task},
+		{"phase", entry.Phase},
+	} {
+		if kv.value != "" {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", kv.key, kv.value))
+		}
+	}
+	for _, k := range sortedKeys(entry.Fields) {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, entry.Fields[k]))
+	}
+	if len(pairs) > 0 {
+		line += " {" + strings.Join(pairs, ", ") + "}"
+	}
+	return line
+}
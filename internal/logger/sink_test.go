@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memorySink records entries in memory for assertions
+type memorySink struct {
+	entries []LogEntry
+	closed  bool
+}
+
+func (s *memorySink) Write(entry LogEntry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestLoggerSinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "test.log")
+
+	logger, err := NewLogger(logPath, 1024*1024, 2, INFO)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	sink := &memorySink{}
+	logger.AddSink(sink)
+
+	logger.Debug("Filtered by level")
+	logger.WithComponent("process").Info("Agent started")
+
+	if len(sink.entries) != 1 {
+		t.Fatalf("Expected 1 entry in sink, got %d", len(sink.entries))
+	}
+	if sink.entries[0].Message != "Agent started" || sink.entries[0].Component != "process" {
+		t.Errorf("Unexpected sink entry: %+v", sink.entries[0])
+	}
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close logger: %v", err)
+	}
+	if !sink.closed {
+		t.Error("Sink should be closed when the logger is closed")
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(filepath.Join(tmpDir, "test.log"), 1024*1024, 2, DEBUG)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	var out strings.Builder
+	sink := NewWriterSink(&out, FormatText, WARN)
+	logger.AddSink(sink)
+
+	logger.WithFields(Fields{"agent": "planner", "pid": 4242}).Warn("Process exited")
+	logger.Info("Below the sink's level")
+	sink.SetMuted(true)
+	logger.Error("While muted")
+	sink.SetMuted(false)
+	sink.Configure(FormatLogfmt, ERROR)
+	logger.Error("As logfmt")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", out.String())
+	}
+	if !strings.Contains(lines[0], "[WARN] Process exited {agent=planner, pid=4242}") {
+		t.Errorf("Unexpected text line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `level=error msg="As logfmt"`) {
+		t.Errorf("Unexpected logfmt line %q", lines[1])
+	}
+}
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// LevelTrace is the slog level records are logged at TRACE with
+const LevelTrace = slog.LevelDebug - 4
+
+// Handler is a slog.Handler that writes records through a Logger, so code
+// using log/slog gets the same formats, levels, redaction, and sinks as
+// the rest of asc. Attributes become record fields, with the keys of groups
+// joined by dots ("request.method").
+type Handler struct {
+	logger *Logger // nil for the default logger, resolved when a record is handled
+	fields Fields
+	group  string
+}
+
+// Handler returns a slog.Handler that writes to the logger
+func (l *Logger) Handler() *Handler {
+	return &Handler{logger: l}
+}
+
+// Slog returns a slog.Logger that writes to the logger
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(l.Handler())
+}
+
+// Slog returns a slog.Logger that writes to the default logger, tagged
+// with the given component if it is not empty. Like the entries of
+// WithComponent it can be created before the default logger is
+// initialized.
+func Slog(component string) *slog.Logger {
+	h := &Handler{}
+	if component != "" {
+		h.fields = Fields{"component": component}
+	}
+	return slog.New(h)
+}
+
+// logLevel maps a slog level to the nearest LogLevel at or below it
+func logLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return TRACE
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARN
+	default:
+		return ERROR
+	}
+}
+
+// target returns the logger the handler writes to, or nil if it writes to
+// the default logger and that has not been initialized
+func (h *Handler) target() *Logger {
+	if h.logger != nil {
+		return h.logger
+	}
+	return defaultLogger
+}
+
+// Enabled reports whether records at level are written, taking the level
+// of the handler's component into account
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	l := h.target()
+	if l == nil {
+		return logLevel(level) >= INFO
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return logLevel(level) >= l.levelFor(h.fields)
+}
+
+// Handle writes a record with the handler's fields and the record's
+// attributes
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.group, a)
+		return true
+	})
+	if l := h.target(); l != nil {
+		l.log(logLevel(r.Level), fields, "%s", r.Message)
+	} else {
+		recordWithoutLogger(logLevel(r.Level), fields, "%s", r.Message)
+	}
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(fields, h.group, a)
+	}
+	return &Handler{logger: h.logger, fields: fields, group: h.group}
+}
+
+// WithGroup returns a handler that puts the attributes added after it
+// under name
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{logger: h.logger, fields: h.fields, group: joinKey(h.group, name)}
+}
+
+// addAttr adds an attribute to fields, flattening groups. Errors are
+// recorded by their message, as they would marshal to {} otherwise.
+func addAttr(fields Fields, group string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		prefix := group
+		if a.Key != "" {
+			prefix = joinKey(group, a.Key)
+		}
+		for _, member := range a.Value.Group() {
+			addAttr(fields, prefix, member)
+		}
+		return
+	}
+	value := a.Value.Any()
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	// Only top-level agent, task, phase, and component keys are lifted
+	// onto the record (see buildEntry)
+	fields[joinKey(group, a.Key)] = value
+}
+
+// joinKey joins a group and a key with a dot
+func joinKey(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return strings.Join([]string{group, key}, ".")
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	l, err := NewLogger(logPath, 1024*1024, 2, INFO)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer l.Close()
+	l.SetFormat(FormatJSON)
+	l.SetComponentLevels(map[string]LogLevel{"mcp": TRACE})
+
+	log := l.Slog().With("component", "process")
+	log.Info("Process started", "agent", "planner", "pid", 4242, "command", "python")
+	log.WithGroup("request").Warn("Request failed", "method", "POST", slog.Group("retry", "count", 2), "error", errors.New("timeout"))
+	log.Debug("Filtered by level")
+	l.Slog().With("component", "mcp").Log(context.Background(), LevelTrace, "Kept by the component level")
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 records, got %d:\n%s", len(lines), data)
+	}
+
+	var started LogEntry
+	if err := json.Unmarshal([]byte(lines[0]), &started); err != nil {
+		t.Fatalf("Failed to parse record: %v", err)
+	}
+	if started.Message != "Process started" || started.Level != "INFO" || started.Component != "process" || started.Agent != "planner" {
+		t.Errorf("Unexpected record %+v", started)
+	}
+	if started.Fields["pid"] != float64(4242) || started.Fields["command"] != "python" {
+		t.Errorf("Expected pid and command fields, got %v", started.Fields)
+	}
+	if !strings.HasPrefix(started.Caller, "slog_test.go:") {
+		t.Errorf("Expected the caller to be the test, got %q", started.Caller)
+	}
+
+	var failed LogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &failed); err != nil {
+		t.Fatalf("Failed to parse record: %v", err)
+	}
+	want := map[string]interface{}{"request.method": "POST", "request.retry.count": float64(2), "request.error": "timeout"}
+	for k, v := range want {
+		if failed.Fields[k] != v {
+			t.Errorf("Field %s = %v, want %v", k, failed.Fields[k], v)
+		}
+	}
+	if failed.Level != "WARN" {
+		t.Errorf("Level = %s, want WARN", failed.Level)
+	}
+	if !strings.Contains(lines[2], `"level":"TRACE"`) {
+		t.Errorf("Expected a trace record for mcp, got %s", lines[2])
+	}
+}
+
+func TestLogLevelOfSlog(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  LogLevel
+	}{
+		{LevelTrace, TRACE},
+		{slog.LevelDebug, DEBUG},
+		{slog.LevelInfo, INFO},
+		{slog.LevelInfo + 2, INFO},
+		{slog.LevelWarn, WARN},
+		{slog.LevelError, ERROR},
+		{slog.LevelError + 4, ERROR},
+	}
+	for _, tt := range tests {
+		if got := logLevel(tt.level); got != tt.want {
+			t.Errorf("logLevel(%v) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
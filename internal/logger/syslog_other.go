@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package logger
+
+import "fmt"
+
+// NewSyslogSink is not supported on this platform
+func NewSyslogSink(network, address, tag string) (Sink, error) {
+	return nil, fmt.Errorf("syslog is not supported on this platform")
+}
@@ -0,0 +1,47 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// syslogSink writes records to a syslog daemon as logfmt messages
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogSink connects to a syslog daemon. An empty network and address
+// use the local syslog socket; otherwise network is "udp" or "tcp" and
+// address is "host:port". tag is the program name attached to each message.
+func NewSyslogSink(network, address, tag string) (Sink, error) {
+	writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+// Write sends the entry at the syslog priority matching its level. The
+// timestamp is omitted because syslog adds its own.
+func (s *syslogSink) Write(entry LogEntry) error {
+	entry.Timestamp = ""
+	line := formatLogfmt(entry)
+
+	switch parseLogLevel(entry.Level) {
+	case TRACE, DEBUG:
+		return s.writer.Debug(line)
+	case WARN:
+		return s.writer.Warning(line)
+	case ERROR:
+		return s.writer.Err(line)
+	default:
+		return s.writer.Info(line)
+	}
+}
+
+// Close closes the connection to the syslog daemon
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
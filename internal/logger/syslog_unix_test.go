@@ -0,0 +1,55 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP not available: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink("udp", conn.LocalAddr().String(), "asc-test")
+	if err != nil {
+		t.Fatalf("Failed to create syslog sink: %v", err)
+	}
+	defer sink.Close()
+
+	err = sink.Write(LogEntry{
+		Timestamp: "2024-01-01 10:00:00.000",
+		Level:     "WARN",
+		Message:   "Agent unresponsive",
+		Component: "health",
+	})
+	if err != nil {
+		t.Fatalf("Failed to write to syslog sink: %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to read syslog packet: %v", err)
+	}
+
+	packet := string(buf[:n])
+	// LOG_DAEMON (3<<3) | LOG_WARNING (4) = 28
+	if !strings.HasPrefix(packet, "<28>") {
+		t.Errorf("Expected warning priority <28>, got %q", packet)
+	}
+	for _, want := range []string{"asc-test", `msg="Agent unresponsive"`, "component=health"} {
+		if !strings.Contains(packet, want) {
+			t.Errorf("Expected packet to contain %q, got %q", want, packet)
+		}
+	}
+	if strings.Contains(packet, "ts=") {
+		t.Errorf("Syslog messages should not repeat the timestamp, got %q", packet)
+	}
+}
@@ -0,0 +1,94 @@
+package logship
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// elasticsearchBackend indexes records through the Elasticsearch bulk API
+type elasticsearchBackend struct {
+	url    string
+	index  string
+	token  string
+	client *http.Client
+}
+
+// bulkResponse is the subset of the bulk API response needed to detect
+// per-document failures, which are reported with HTTP 200
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// Push sends records as one NDJSON bulk request. Labels and fields are
+// stored as top-level document properties.
+func (b *elasticsearchBackend) Push(records []Record) error {
+	var body bytes.Buffer
+	action, _ := json.Marshal(map[string]interface{}{
+		"index": map[string]string{"_index": b.index},
+	})
+
+	for _, r := range records {
+		doc := make(map[string]interface{}, len(r.Fields)+len(r.Labels)+3)
+		for k, v := range r.Fields {
+			doc[k] = v
+		}
+		for k, v := range r.Labels {
+			doc[k] = v
+		}
+		doc["@timestamp"] = r.Timestamp.UTC().Format(time.RFC3339Nano)
+		doc["message"] = r.Message
+		if r.Level != "" {
+			doc["level"] = strings.ToLower(r.Level)
+		}
+
+		line, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("failed to encode elasticsearch document: %w", err)
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(line)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimRight(b.url, "/")+"/_bulk", &body)
+	if err != nil {
+		return fmt.Errorf("failed to create elasticsearch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("elasticsearch push failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("elasticsearch push failed: HTTP %d", resp.StatusCode)
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Errors {
+		for _, item := range result.Items {
+			for _, op := range item {
+				if op.Status >= 300 {
+					return fmt.Errorf("elasticsearch rejected documents: %s", op.Error.Reason)
+				}
+			}
+		}
+	}
+	return nil
+}
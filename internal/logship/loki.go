@@ -0,0 +1,137 @@
+package logship
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lokiBackend pushes records to the Loki HTTP push API
+type lokiBackend struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// lokiPush is the JSON body of POST /loki/api/v1/push
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// Push groups records into streams by label set and sends them in one request.
+// The level becomes a stream label; other fields are appended to the line as
+// logfmt so they stay queryable with Loki's logfmt parser.
+func (b *lokiBackend) Push(records []Record) error {
+	streams := make(map[string]*lokiStream)
+	var order []string
+
+	for _, r := range records {
+		labels := make(map[string]string, len(r.Labels)+1)
+		for k, v := range r.Labels {
+			labels[k] = v
+		}
+		if r.Level != "" {
+			labels["level"] = strings.ToLower(r.Level)
+		}
+
+		key := labelKey(labels)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+			order = append(order, key)
+		}
+		stream.Values = append(stream.Values, [2]string{
+			strconv.FormatInt(r.Timestamp.UnixNano(), 10),
+			lokiLine(r),
+		})
+	}
+
+	push := lokiPush{Streams: make([]lokiStream, 0, len(order))}
+	for _, key := range order {
+		push.Streams = append(push.Streams, *streams[key])
+	}
+
+	body, err := json.Marshal(push)
+	if err != nil {
+		return fmt.Errorf("failed to encode loki push: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimRight(b.url, "/")+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create loki request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	return doPush(b.client, req, "loki")
+}
+
+// lokiLine renders the message with its fields appended as key=value pairs
+func lokiLine(r Record) string {
+	if len(r.Fields) == 0 {
+		return r.Message
+	}
+
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(r.Message)
+	for _, k := range keys {
+		value := fmt.Sprintf("%v", r.Fields[k])
+		if strings.ContainsAny(value, " =\"") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, value)
+	}
+	return b.String()
+}
+
+// labelKey returns a stable identifier for a label set
+func labelKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// doPush sends a request and converts non-2xx responses into errors
+func doPush(client *http.Client, req *http.Request, backend string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s push failed: %w", backend, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s push failed: HTTP %d: %s", backend, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Package logship forwards asc and agent logs to a central log store
+// (Grafana Loki or Elasticsearch) so agent fleets can be debugged without
+// logging into each host.
+//
+// Records are buffered in memory and pushed in batches, either when a batch
+// fills up or when the flush interval elapses. The shipper never blocks the
+// caller: if the backend is unreachable and the buffer is full, the oldest
+// records are dropped and counted in Stats.
+//
+// Example usage:
+//
+//	shipper, err := logship.New(logship.Options{
+//	    Backend: "loki",
+//	    URL:     "http://loki:3100",
+//	    Labels:  map[string]string{"workspace": "my-project"},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer shipper.Close()
+//
+//	logger.AddSink(shipper.Sink())
+//	shipper.TailFile("/home/me/.asc/logs/planner.log", "planner")
+package logship
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// Default batching settings
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 5 * time.Second
+	maxBufferedBatches   = 10
+)
+
+// Record is a single log line to ship along with its labels
+type Record struct {
+	Timestamp time.Time
+	Level     string
+	Message   string
+	Labels    map[string]string // Stream labels, e.g. source, agent, workspace
+	Fields    map[string]interface{}
+}
+
+// Backend delivers a batch of records to a log store
+type Backend interface {
+	Push(records []Record) error
+}
+
+// Options configures a Shipper
+type Options struct {
+	Backend       string            // "loki" or "elasticsearch"
+	URL           string            // Base URL of the log store
+	Index         string            // Elasticsearch index (default: "asc-logs")
+	AuthToken     string            // Optional bearer token
+	Labels        map[string]string // Labels attached to every record (e.g. workspace)
+	BatchSize     int               // Records per push (default: 100)
+	FlushInterval time.Duration     // Maximum time a record waits before being pushed (default: 5s)
+	HTTPClient    *http.Client      // Optional HTTP client (default: 10s timeout)
+}
+
+// Stats reports shipping activity
+type Stats struct {
+	Sent      int
+	Dropped   int
+	Failures  int
+	LastError error
+}
+
+// Shipper batches records and pushes them to a Backend
+type Shipper struct {
+	mu            sync.Mutex
+	backend       Backend
+	labels        map[string]string
+	buffer        []Record
+	batchSize     int
+	flushInterval time.Duration
+	stats         Stats
+
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	closed  bool
+}
+
+// New creates a Shipper for the configured backend and starts its flush loop
+func New(opts Options) (*Shipper, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("log shipping URL is required")
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	var backend Backend
+	switch opts.Backend {
+	case "loki":
+		backend = &lokiBackend{url: opts.URL, token: opts.AuthToken, client: client}
+	case "elasticsearch":
+		index := opts.Index
+		if index == "" {
+			index = "asc-logs"
+		}
+		backend = &elasticsearchBackend{url: opts.URL, index: index, token: opts.AuthToken, client: client}
+	default:
+		return nil, fmt.Errorf("unsupported log shipping backend '%s' (valid backends: loki, elasticsearch)", opts.Backend)
+	}
+
+	return NewWithBackend(backend, opts), nil
+}
+
+// NewWithBackend creates a Shipper that pushes to the given backend. The
+// Backend, URL, Index, AuthToken, and HTTPClient options are ignored.
+func NewWithBackend(backend Backend, opts Options) *Shipper {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	flushInterval := opts.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = DefaultFlushInterval
+	}
+
+	labels := make(map[string]string, len(opts.Labels))
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+
+	s := &Shipper{
+		backend:       backend,
+		labels:        labels,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		flushCh:       make(chan struct{}, 1),
+		stopCh:        make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// Add queues a record for shipping. Shipper labels are merged into the
+// record's labels, with record labels taking precedence.
+func (s *Shipper) Add(record Record) {
+	labels := make(map[string]string, len(s.labels)+len(record.Labels))
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+	for k, v := range record.Labels {
+		labels[k] = v
+	}
+	record.Labels = labels
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.buffer = append(s.buffer, record)
+	if overflow := len(s.buffer) - s.batchSize*maxBufferedBatches; overflow > 0 {
+		s.buffer = s.buffer[overflow:]
+		s.stats.Dropped += overflow
+	}
+	full := len(s.buffer) >= s.batchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush pushes all buffered records now
+func (s *Shipper) Flush() error {
+	var lastErr error
+	for {
+		s.mu.Lock()
+		if len(s.buffer) == 0 {
+			s.mu.Unlock()
+			return lastErr
+		}
+		n := len(s.buffer)
+		if n > s.batchSize {
+			n = s.batchSize
+		}
+		batch := make([]Record, n)
+		copy(batch, s.buffer[:n])
+		s.mu.Unlock()
+
+		err := s.backend.Push(batch)
+
+		s.mu.Lock()
+		if err != nil {
+			s.stats.Failures++
+			s.stats.LastError = err
+			s.mu.Unlock()
+			// Keep the records for the next attempt
+			return err
+		}
+		s.buffer = s.buffer[n:]
+		s.stats.Sent += n
+		s.mu.Unlock()
+	}
+}
+
+// Stats returns a snapshot of shipping counters
+func (s *Shipper) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Close stops tailing, flushes remaining records, and stops the flush loop
+func (s *Shipper) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.stopCh)
+	s.wg.Wait()
+	return s.Flush()
+}
+
+// run flushes on a timer or when a batch fills up
+func (s *Shipper) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.Flush()
+		case <-s.flushCh:
+			s.Flush()
+		}
+	}
+}
+
+// Sink returns a logger.Sink that ships asc's own log records. Closing the
+// sink does not close the shipper, which may still be tailing agent logs.
+func (s *Shipper) Sink() logger.Sink {
+	return &shipperSink{shipper: s}
+}
+
+// shipperSink adapts a Shipper to the logger.Sink interface
+type shipperSink struct {
+	shipper *Shipper
+}
+
+func (k *shipperSink) Write(entry logger.LogEntry) error {
+	ts, err := time.ParseInLocation(logger.TimestampLayout, entry.Timestamp, time.Local)
+	if err != nil {
+		ts = time.Now()
+	}
+
+	labels := map[string]string{"source": "asc"}
+	if entry.Component != "" {
+		labels["component"] = entry.Component
+	}
+	if entry.Agent != "" {
+		labels["agent"] = entry.Agent
+	}
+
+	fields := make(map[string]interface{}, len(entry.Fields)+4)
+	for k, v := range entry.Fields {
+		fields[k] = v
+	}
+	for k, v := range map[string]string{
+		"correlation_id": entry.CorrelationID,
+		"caller":         entry.Caller,
+		"task":           entry.Task,
+		"phase":          entry.Phase,
+	} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+
+	k.shipper.Add(Record{
+		Timestamp: ts,
+		Level:     entry.Level,
+		Message:   entry.Message,
+		Labels:    labels,
+		Fields:    fields,
+	})
+	return nil
+}
+
+func (k *shipperSink) Close() error {
+	return nil
+}
@@ -0,0 +1,301 @@
+package logship
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// recordingBackend collects pushed batches
+type recordingBackend struct {
+	mu      sync.Mutex
+	batches [][]Record
+	fail    bool
+}
+
+func (b *recordingBackend) Push(records []Record) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fail {
+		return errors.New("backend unavailable")
+	}
+	b.batches = append(b.batches, records)
+	return nil
+}
+
+func (b *recordingBackend) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, batch := range b.batches {
+		n += len(batch)
+	}
+	return n
+}
+
+func TestShipperBatching(t *testing.T) {
+	backend := &recordingBackend{}
+	shipper := NewWithBackend(backend, Options{
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Labels:        map[string]string{"workspace": "demo"},
+	})
+
+	shipper.Add(Record{Message: "one", Labels: map[string]string{"agent": "planner"}})
+	shipper.Add(Record{Message: "two"})
+	shipper.Add(Record{Message: "three"})
+
+	if err := shipper.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if backend.count() != 3 {
+		t.Fatalf("Expected 3 shipped records, got %d", backend.count())
+	}
+	first := backend.batches[0][0]
+	if first.Labels["workspace"] != "demo" || first.Labels["agent"] != "planner" {
+		t.Errorf("Expected shipper and record labels to be merged, got %v", first.Labels)
+	}
+	if first.Timestamp.IsZero() {
+		t.Error("Expected timestamp to be defaulted")
+	}
+	if stats := shipper.Stats(); stats.Sent != 3 {
+		t.Errorf("Stats.Sent = %d, want 3", stats.Sent)
+	}
+}
+
+func TestShipperRetainsRecordsOnFailure(t *testing.T) {
+	backend := &recordingBackend{fail: true}
+	shipper := NewWithBackend(backend, Options{BatchSize: 10, FlushInterval: time.Hour})
+	defer shipper.Close()
+
+	shipper.Add(Record{Message: "kept"})
+	if err := shipper.Flush(); err == nil {
+		t.Fatal("Expected flush to fail")
+	}
+
+	backend.mu.Lock()
+	backend.fail = false
+	backend.mu.Unlock()
+
+	if err := shipper.Flush(); err != nil {
+		t.Fatalf("Flush failed after recovery: %v", err)
+	}
+	if backend.count() != 1 {
+		t.Errorf("Expected the failed record to be retried, got %d records", backend.count())
+	}
+	if stats := shipper.Stats(); stats.Failures != 1 || stats.LastError == nil {
+		t.Errorf("Expected one recorded failure, got %+v", stats)
+	}
+}
+
+func TestShipperDropsOldestWhenFull(t *testing.T) {
+	backend := &recordingBackend{fail: true}
+	shipper := NewWithBackend(backend, Options{BatchSize: 1, FlushInterval: time.Hour})
+	defer shipper.Close()
+
+	for i := 0; i < maxBufferedBatches+5; i++ {
+		shipper.Add(Record{Message: "line"})
+	}
+
+	if stats := shipper.Stats(); stats.Dropped != 5 {
+		t.Errorf("Stats.Dropped = %d, want 5", stats.Dropped)
+	}
+}
+
+func TestLokiBackend(t *testing.T) {
+	var received lokiPush
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	shipper, err := New(Options{Backend: "loki", URL: server.URL, AuthToken: "secret-token", FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	ts := time.Unix(1700000000, 0)
+	shipper.Add(Record{Timestamp: ts, Level: "INFO", Message: "a", Labels: map[string]string{"agent": "planner"}})
+	shipper.Add(Record{Timestamp: ts, Level: "INFO", Message: "b", Labels: map[string]string{"agent": "planner"}, Fields: map[string]interface{}{"task": "t-1"}})
+	shipper.Add(Record{Timestamp: ts, Level: "ERROR", Message: "c", Labels: map[string]string{"agent": "coder"}})
+	if err := shipper.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if auth != "Bearer secret-token" {
+		t.Errorf("Expected bearer token, got %q", auth)
+	}
+	if len(received.Streams) != 2 {
+		t.Fatalf("Expected 2 streams, got %d", len(received.Streams))
+	}
+	planner := received.Streams[0]
+	if planner.Stream["agent"] != "planner" || planner.Stream["level"] != "info" {
+		t.Errorf("Unexpected stream labels: %v", planner.Stream)
+	}
+	if len(planner.Values) != 2 || planner.Values[1][1] != "b task=t-1" {
+		t.Errorf("Unexpected stream values: %v", planner.Values)
+	}
+	if planner.Values[0][0] != "1700000000000000000" {
+		t.Errorf("Expected nanosecond timestamp, got %s", planner.Values[0][0])
+	}
+}
+
+func TestElasticsearchBackend(t *testing.T) {
+	var lines []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Unexpected content type %s", ct)
+		}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	defer server.Close()
+
+	shipper, err := New(Options{Backend: "elasticsearch", URL: server.URL, Index: "fleet-logs", FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	shipper.Add(Record{Level: "WARN", Message: "slow response", Labels: map[string]string{"agent": "tester"}})
+	if err := shipper.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected action and document lines, got %v", lines)
+	}
+	if !strings.Contains(lines[0], `"_index":"fleet-logs"`) {
+		t.Errorf("Unexpected action line: %s", lines[0])
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &doc); err != nil {
+		t.Fatalf("Invalid document: %v", err)
+	}
+	if doc["message"] != "slow response" || doc["agent"] != "tester" || doc["level"] != "warn" {
+		t.Errorf("Unexpected document: %v", doc)
+	}
+	if _, ok := doc["@timestamp"]; !ok {
+		t.Error("Expected @timestamp in document")
+	}
+}
+
+func TestElasticsearchDocumentErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"reason":"mapping conflict"}}}]}`))
+	}))
+	defer server.Close()
+
+	backend := &elasticsearchBackend{url: server.URL, index: "asc-logs", client: server.Client()}
+	err := backend.Push([]Record{{Message: "x", Timestamp: time.Now()}})
+	if err == nil || !strings.Contains(err.Error(), "mapping conflict") {
+		t.Errorf("Expected document error to be reported, got %v", err)
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	if _, err := New(Options{Backend: "loki"}); err == nil {
+		t.Error("Expected error for missing URL")
+	}
+	if _, err := New(Options{Backend: "splunk", URL: "http://x"}); err == nil {
+		t.Error("Expected error for unsupported backend")
+	}
+}
+
+func TestTailFile(t *testing.T) {
+	originalInterval := tailPollInterval
+	tailPollInterval = 10 * time.Millisecond
+	defer func() { tailPollInterval = originalInterval }()
+
+	logPath := filepath.Join(t.TempDir(), "planner.log")
+	if err := os.WriteFile(logPath, []byte("old line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &recordingBackend{}
+	shipper := NewWithBackend(backend, Options{BatchSize: 100, FlushInterval: time.Hour})
+	shipper.TailFile(logPath, "planner")
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("new line\npartial")
+	f.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		shipper.mu.Lock()
+		n := len(shipper.buffer)
+		shipper.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := shipper.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if backend.count() != 1 {
+		t.Fatalf("Expected only the new complete line to be shipped, got %d records", backend.count())
+	}
+	record := backend.batches[0][0]
+	if record.Message != "new line" || record.Labels["agent"] != "planner" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+}
+
+func TestSinkShipsLoggerEntries(t *testing.T) {
+	backend := &recordingBackend{}
+	shipper := NewWithBackend(backend, Options{FlushInterval: time.Hour})
+
+	sink := shipper.Sink()
+	sink.Write(logger.LogEntry{
+		Timestamp:     "2024-01-01 10:00:00.000",
+		Level:         "INFO",
+		Message:       "Agent started",
+		Component:     "process",
+		CorrelationID: "abc",
+		Fields:        map[string]interface{}{"pid": 42},
+	})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Sink close failed: %v", err)
+	}
+	shipper.Close()
+
+	if backend.count() != 1 {
+		t.Fatalf("Expected 1 record, got %d", backend.count())
+	}
+	record := backend.batches[0][0]
+	if record.Labels["source"] != "asc" || record.Labels["component"] != "process" {
+		t.Errorf("Unexpected labels: %v", record.Labels)
+	}
+	if record.Fields["correlation_id"] != "abc" || record.Fields["pid"] != 42 {
+		t.Errorf("Unexpected fields: %v", record.Fields)
+	}
+	if record.Timestamp.Year() != 2024 {
+		t.Errorf("Expected entry timestamp to be parsed, got %v", record.Timestamp)
+	}
+}
@@ -0,0 +1,82 @@
+package logship
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// tailPollInterval is how often tailed files are checked for new lines
+var tailPollInterval = time.Second
+
+// TailFile follows a log file and ships each new line with source and agent
+// labels set to the given agent name. Lines are redacted before shipping.
+// Only lines written after the call are
+// shipped; if the file is truncated or rotated, tailing restarts from the
+// beginning. Tailing stops when the shipper is closed.
+func (s *Shipper) TailFile(path, agent string) {
+	offset := int64(0)
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(tailPollInterval)
+		defer ticker.Stop()
+
+		for {
+			offset = s.readNewLines(path, agent, offset)
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// readNewLines ships complete lines written since offset and returns the new offset
+func (s *Shipper) readNewLines(path, agent string, offset int64) int64 {
+	file, err := os.Open(path)
+	if err != nil {
+		return offset
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return offset
+	}
+	if info.Size() < offset {
+		// Truncated or replaced by rotation
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// Leave partial lines for the next poll
+			return offset
+		}
+		offset += int64(len(line))
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			continue
+		}
+		s.Add(Record{
+			Message: logger.Redact(line),
+			Labels:  map[string]string{"source": agent, "agent": agent},
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultBatchSize is the most messages a batch holds when
+// Options.BatchSize is not set
+const DefaultBatchSize = 100
+
+// batcher collects the messages sent within a window and posts them
+// together, so a swarm's agents share requests instead of each paying a
+// round trip per message
+type batcher struct {
+	window time.Duration
+	size   int
+	post   func(ctx context.Context, msgs []Message) error
+
+	mu      sync.Mutex
+	pending []pendingMessage
+	ctx     context.Context // Context of the first pending message
+	timer   *time.Timer     // Flushes the pending messages once the window closes
+
+	// Held while a batch is posted, so batches arrive in the order their
+	// messages were sent
+	flushMu sync.Mutex
+}
+
+// pendingMessage is a message waiting for its batch to be posted
+type pendingMessage struct {
+	msg  Message
+	done chan error
+}
+
+// newBatcher creates a batcher posting at most size messages at a time
+// with post, window after the first message of a batch was sent
+func newBatcher(window time.Duration, size int, post func(ctx context.Context, msgs []Message) error) *batcher {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	return &batcher{window: window, size: size, post: post}
+}
+
+// send adds msg to the next batch and returns once the batch was posted,
+// with the batch's error. A caller that gives up first gets ctx's error,
+// though the message may still be sent.
+func (b *batcher) send(ctx context.Context, msg Message) error {
+	p := pendingMessage{msg: msg, done: make(chan error, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, p)
+	if len(b.pending) == 1 {
+		// The batch is posted on behalf of all its senders, so it outlives
+		// the first one's cancellation but keeps its trace and values
+		b.ctx = context.WithoutCancel(ctx)
+	}
+	switch {
+	case len(b.pending) >= b.size:
+		if b.timer != nil {
+			b.timer.Stop()
+			b.timer = nil
+		}
+		go b.flush()
+	case b.timer == nil:
+		b.timer = time.AfterFunc(b.window, b.flush)
+	}
+	b.mu.Unlock()
+
+	select {
+	case err := <-p.done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("request interrupted: %w", ctx.Err())
+	}
+}
+
+// flush posts the pending messages, a batch of at most size at a time
+func (b *batcher) flush() {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	for {
+		b.mu.Lock()
+		n := min(len(b.pending), b.size)
+		if n == 0 {
+			b.mu.Unlock()
+			return
+		}
+		batch := b.pending[:n:n]
+		b.pending = b.pending[n:]
+		ctx := b.ctx
+		if len(b.pending) == 0 && b.timer != nil {
+			b.timer.Stop()
+			b.timer = nil
+		}
+		b.mu.Unlock()
+
+		msgs := make([]Message, len(batch))
+		for i, p := range batch {
+			msgs[i] = p.msg
+		}
+		err := b.post(ctx, msgs)
+		for _, p := range batch {
+			p.done <- err
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mailServer is an agent mail server that receives messages singly and in
+// batches, decoding compressed bodies
+type mailServer struct {
+	mu        sync.Mutex
+	received  []Message
+	requests  int
+	encodings []string
+	wireBytes int64
+
+	noBatch    bool          // Answers /messages/batch with 404
+	noEncoding bool          // Answers compressed bodies with 415
+	cost       time.Duration // Time a request takes, one at a time, like a single worker
+}
+
+func (s *mailServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	time.Sleep(s.cost)
+	s.requests++
+	encoding := r.Header.Get("Content-Encoding")
+	s.encodings = append(s.encodings, encoding)
+	if encoding != "" && s.noEncoding {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	if r.URL.Path == "/messages/batch" && s.noBatch {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	s.wireBytes += int64(len(body))
+	if encoding != "" {
+		var err error
+		if body, err = decompressBody(encoding, body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	switch r.URL.Path {
+	case "/messages":
+		var msg Message
+		json.Unmarshal(body, &msg)
+		s.received = append(s.received, msg)
+	case "/messages/batch":
+		var msgs []Message
+		json.Unmarshal(body, &msgs)
+		s.received = append(s.received, msgs...)
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// sendFromAgents sends count messages from each of agents agents at once,
+// returning the first failure
+func sendFromAgents(client *HTTPClient, agents, count int, content string) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, agents)
+	for i := 0; i < agents; i++ {
+		wg.Add(1)
+		go func(agent string) {
+			defer wg.Done()
+			for n := 0; n < count; n++ {
+				if err := client.SendMessage(context.Background(), Message{Type: TypeMessage, Source: agent, Content: fmt.Sprintf("%d %s", n, content)}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(fmt.Sprintf("agent-%d", i))
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+func TestHTTPClientBatchesMessages(t *testing.T) {
+	server := &mailServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := NewHTTPClientWithOptions(ts.URL, Options{BatchWindow: 20 * time.Millisecond, BatchSize: 25})
+	if err := sendFromAgents(client, 50, 2, "hello"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	if len(server.received) != 100 {
+		t.Fatalf("Server received %d messages, want 100", len(server.received))
+	}
+	if server.requests > 20 {
+		t.Errorf("Server received %d requests for 100 messages, want them batched", server.requests)
+	}
+
+	// Each agent's messages arrive in the order it sent them
+	next := make(map[string]int)
+	for _, msg := range server.received {
+		if want := fmt.Sprintf("%d hello", next[msg.Source]); msg.Content != want {
+			t.Fatalf("Received %q from %s, want %q", msg.Content, msg.Source, want)
+		}
+		next[msg.Source]++
+	}
+}
+
+func TestHTTPClientBatchFallsBackToSingleMessages(t *testing.T) {
+	server := &mailServer{noBatch: true}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := NewHTTPClientWithOptions(ts.URL, Options{BatchWindow: 10 * time.Millisecond})
+	if err := sendFromAgents(client, 5, 2, "hello"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if len(server.received) != 10 {
+		t.Errorf("Server received %d messages, want 10", len(server.received))
+	}
+
+	// The batch endpoint is asked only once
+	requests := server.requests
+	if err := client.SendMessage(context.Background(), Message{Content: "later"}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if server.requests != requests+1 {
+		t.Errorf("Expected one request for a message once batches were refused, got %d", server.requests-requests)
+	}
+}
+
+func TestBatcherFailsEveryMessage(t *testing.T) {
+	var posts atomic.Int32
+	b := newBatcher(10*time.Millisecond, 2, func(ctx context.Context, msgs []Message) error {
+		posts.Add(1)
+		return fmt.Errorf("server down")
+	})
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- b.send(context.Background(), Message{}) }()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), "server down") {
+			t.Errorf("send() error = %v, want the batch's failure", err)
+		}
+	}
+	if posts.Load() != 1 {
+		t.Errorf("Batch posted %d times, want once", posts.Load())
+	}
+
+	// A caller that gives up does not wait for the window
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.send(ctx, Message{}); err == nil {
+		t.Error("Expected a cancelled send to fail")
+	}
+}
+
+// BenchmarkSwarmSend measures sending a message from each of 50 agents at
+// once to a server that takes 1ms a request, one request at a time, as a
+// single-worker server does. Batching shares the requests, and compression
+// cuts the bytes sent (wire-B/msg).
+func BenchmarkSwarmSend(b *testing.B) {
+	const agents = 50
+	content := strings.Repeat("Implemented the task; tests pass and the diff is ready for review. ", 20)
+
+	for _, bench := range []struct {
+		name string
+		opts Options
+	}{
+		{"unbatched", Options{}},
+		{"batched", Options{BatchWindow: 2 * time.Millisecond}},
+		{"batched-gzip", Options{BatchWindow: 2 * time.Millisecond, Compression: CompressionGzip}},
+		{"batched-zstd", Options{BatchWindow: 2 * time.Millisecond, Compression: CompressionZstd}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			server := &mailServer{cost: time.Millisecond}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+			client := NewHTTPClientWithOptions(ts.URL, bench.opts)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := sendFromAgents(client, agents, 1, content); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(b.N*agents)/b.Elapsed().Seconds(), "msgs/s")
+			b.ReportMetric(float64(server.wireBytes)/float64(b.N*agents), "wire-B/msg")
+		})
+	}
+}
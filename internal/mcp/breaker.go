@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+	"github.com/rand/asc/internal/logger"
+)
+
+// Circuit states of an HTTPClient
+const (
+	CircuitClosed   = "closed"    // Requests go to the server
+	CircuitOpen     = "open"      // The server is degraded; requests fail fast until the cooldown ends
+	CircuitHalfOpen = "half-open" // The cooldown ended; the next request decides
+)
+
+// ErrCircuitOpen is the error of requests that were not sent because the
+// MCP server is degraded
+var ErrCircuitOpen = errors.New("MCP server is degraded")
+
+// hintDegraded is attached to requests that failed fast
+const hintDegraded = "mcp_agent_mail failed several requests in a row, so asc stops sending it requests for a while. Check it with 'asc services status', or tune breaker_threshold and breaker_cooldown under [services.mcp_agent_mail]"
+
+// CircuitStatus is the state of an HTTPClient's circuit breaker
+type CircuitStatus struct {
+	State    string
+	Failures int       // Failed requests in a row
+	Since    time.Time // When the circuit last opened
+	RetryAt  time.Time // When an open circuit lets a request through
+	LastErr  error     // The last failure
+}
+
+// Degraded reports whether requests fail fast, or are being let through
+// one at a time to test the server
+func (s CircuitStatus) Degraded() bool {
+	return s.State == CircuitOpen || s.State == CircuitHalfOpen
+}
+
+// CircuitReporter is implemented by MCP clients with a circuit breaker,
+// so the TUI can show a degraded server
+type CircuitReporter interface {
+	Circuit() CircuitStatus
+}
+
+// breaker is a circuit breaker: after threshold failed requests in a row
+// it opens, failing requests fast for cooldown, then lets one request
+// through, closing again if it succeeds. A zero threshold disables it.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	since    time.Time
+	lastErr  error
+	trial    bool // A half-open request is in flight
+}
+
+// newBreaker creates a closed breaker
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, now: time.Now, state: CircuitClosed}
+}
+
+// status returns the state of the breaker, opening to half-open once the
+// cooldown has passed
+func (b *breaker) status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	status := CircuitStatus{State: b.state, Failures: b.failures, Since: b.since, LastErr: b.lastErr}
+	if b.state == CircuitOpen {
+		status.RetryAt = b.since.Add(b.cooldown)
+	}
+	return status
+}
+
+// advance moves an open breaker whose cooldown has passed to half-open
+func (b *breaker) advance() {
+	if b.state == CircuitOpen && !b.now().Before(b.since.Add(b.cooldown)) {
+		b.state = CircuitHalfOpen
+	}
+}
+
+// allow returns an error if a request must fail fast. While half-open,
+// one request at a time is let through.
+func (b *breaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	switch {
+	case b.state == CircuitClosed:
+		return nil
+	case b.state == CircuitHalfOpen && !b.trial:
+		b.trial = true
+		return nil
+	}
+	retryAt := b.since.Add(b.cooldown)
+	if b.state == CircuitHalfOpen {
+		retryAt = b.now()
+	}
+	return ascerrors.WithHint(fmt.Errorf("%w after %d failed requests, retrying after %s: %v",
+		ErrCircuitOpen, b.failures, retryAt.Format("15:04:05"), b.lastErr), hintDegraded)
+}
+
+// record counts the outcome of a request let through by allow. Requests
+// that were interrupted, or rejected by the server with a 4xx or the
+// gRPC equivalent, say nothing about its health beyond that it answered.
+func (b *breaker) record(ctx context.Context, err error) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasTrial := b.trial
+	b.trial = false
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	var httpErr *HTTPError
+	var rpcErr *RPCError
+	if err == nil || (errors.As(err, &httpErr) && httpErr.StatusCode < 500) || (errors.As(err, &rpcErr) && !rpcErr.serverFailure()) {
+		if b.state != CircuitClosed {
+			mcpLog.WithFields(logger.Fields{"failures": b.failures}).Info("MCP server recovered")
+		}
+		b.state, b.failures, b.lastErr = CircuitClosed, 0, nil
+		return
+	}
+	b.failures++
+	b.lastErr = err
+	if (wasTrial && b.state == CircuitHalfOpen) || (b.state == CircuitClosed && b.failures >= b.threshold) {
+		if b.state == CircuitClosed {
+			mcpLog.WithFields(logger.Fields{"failures": b.failures, "cooldown": b.cooldown.String()}).Warn("MCP server degraded, failing requests fast: %v", err)
+		}
+		b.state = CircuitOpen
+		b.since = b.now()
+	}
+}
+
+// backoff returns the wait before retry n (from 1): base doubling with
+// each retry, capped at max, of which a random half is jitter so that
+// clients failing together do not retry together
+func backoff(n int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < n && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{5, 250 * time.Millisecond, 500 * time.Millisecond}, // Capped
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if d := backoff(tt.retry, 100*time.Millisecond, 500*time.Millisecond); d < tt.min || d > tt.max {
+				t.Errorf("backoff(%d) = %v, want %v to %v", tt.retry, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var requests, failing int32
+	atomic.StoreInt32(&failing, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClientWithOptions(server.URL, Options{MaxRetries: 0, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+	now := time.Now()
+	client.breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetMessages(ctx, now); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Request %d: expected the server's error, got %v", i+1, err)
+		}
+	}
+	status := client.Circuit()
+	if status.State != CircuitOpen || !status.Degraded() || status.Failures != 2 || !status.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("Expected the circuit open after 2 failures, got %+v", status)
+	}
+
+	// Open: requests fail fast without reaching the server
+	_, err := client.GetMessages(ctx, now)
+	if !errors.Is(err, ErrCircuitOpen) || ascerrors.Hint(err) != hintDegraded {
+		t.Errorf("Expected a fast failure with a hint, got %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected no request while open, got %d requests", n)
+	}
+
+	// Half-open after the cooldown: a failed trial opens it again
+	now = now.Add(time.Minute)
+	if state := client.Circuit().State; state != CircuitHalfOpen {
+		t.Errorf("Expected half-open after the cooldown, got %s", state)
+	}
+	if _, err := client.GetMessages(ctx, now); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the trial request to reach the server, got %v", err)
+	}
+	if state := client.Circuit().State; state != CircuitOpen {
+		t.Errorf("Expected a failed trial to open the circuit again, got %s", state)
+	}
+
+	// A successful trial closes it
+	now = now.Add(time.Minute)
+	atomic.StoreInt32(&failing, 0)
+	if _, err := client.GetMessages(ctx, now); err != nil {
+		t.Fatalf("Expected the trial request to succeed, got %v", err)
+	}
+	if status := client.Circuit(); status.State != CircuitClosed || status.Degraded() || status.Failures != 0 {
+		t.Errorf("Expected the circuit closed after a success, got %+v", status)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClientWithOptions(server.URL, Options{BreakerThreshold: 1})
+	for i := 0; i < 3; i++ {
+		client.GetMessages(context.Background(), time.Now())
+	}
+	if state := client.Circuit().State; state != CircuitClosed {
+		t.Errorf("Expected 4xx responses not to open the circuit, got %s", state)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.GetMessages(ctx, time.Now())
+	if state := client.Circuit().State; state != CircuitClosed {
+		t.Errorf("Expected interrupted requests not to open the circuit, got %s", state)
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClientWithOptions(server.URL, Options{BreakerThreshold: -1})
+	client.retryDelay, client.maxDelay = time.Millisecond, time.Millisecond
+	for i := 0; i < 6; i++ {
+		if _, err := client.GetMessages(context.Background(), time.Now()); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected a disabled breaker never to fail fast, got %v", err)
+		}
+	}
+}
@@ -1,11 +1,11 @@
 // Package mcp provides a client for interacting with the MCP agent mail server.
 // It handles HTTP communication for sending messages, retrieving agent status,
-// and tracking heartbeats, with support for retries and error handling.
+// and tracking heartbeats, with retries, a circuit breaker, and error handling.
 //
 // Example usage:
 //
 //	client := mcp.NewHTTPClient("http://localhost:8765")
-//	messages, err := client.GetMessages(time.Now().Add(-5 * time.Minute))
+//	messages, err := client.GetMessages(ctx, time.Now().Add(-5 * time.Minute))
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
@@ -16,15 +16,29 @@ package mcp
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"google.golang.org/grpc/codes"
+
+	ascerrors "github.com/rand/asc/internal/errors"
 	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/proxy"
+	"github.com/rand/asc/internal/telemetry"
 )
 
+// mcpLog tags every record written by this package with the mcp component
+var mcpLog = logger.WithComponent("mcp")
+
 // MessageType represents the type of MCP message.
 type MessageType string
 
@@ -51,72 +65,224 @@ type Message struct {
 	Type      MessageType `json:"type"`
 	Source    string      `json:"source"`
 	Content   string      `json:"content"`
+	
+	// CorrelationID links the message to the asc action that sent it
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 // AgentStatus represents the status of an agent including its current state,
-// task, and last seen timestamp.
+// task, last seen timestamp, and number of unread messages.
 type AgentStatus struct {
 	Name        string     `json:"name"`
 	State       AgentState `json:"state"`
 	CurrentTask string     `json:"current_task"`
 	LastSeen    time.Time  `json:"last_seen"`
+	UnreadCount int        `json:"unread_count,omitempty"`
 }
 
-// MCPClient defines the interface for interacting with the MCP server
+// MCPClient defines the interface for interacting with the MCP server.
+// Requests, and the waits between their retries, stop when ctx is done.
 type MCPClient interface {
-	GetMessages(since time.Time) ([]Message, error)
-	SendMessage(msg Message) error
-	GetAgentStatus(agentName string) (AgentStatus, error)
-	GetAllAgentStatuses(offlineThreshold time.Duration) ([]AgentStatus, error)
-	ReleaseAgentLeases(agentName string) error
+	GetMessages(ctx context.Context, since time.Time) ([]Message, error)
+	SendMessage(ctx context.Context, msg Message) error
+	GetAgentStatus(ctx context.Context, agentName string) (AgentStatus, error)
+	GetAllAgentStatuses(ctx context.Context, offlineThreshold time.Duration) ([]AgentStatus, error)
+	ReleaseAgentLeases(ctx context.Context, agentName string) error
 }
 
 // HTTPClient implements the MCPClient interface using HTTP requests.
-// It includes retry logic and configurable timeouts.
+// It includes retry logic, configurable timeouts, and a circuit breaker
+// that fails requests fast while the server is degraded.
 type HTTPClient struct {
-	baseURL    string        // Base URL of the MCP server
-	httpClient *http.Client  // HTTP client with timeout
-	maxRetries int           // Maximum number of retry attempts
-	retryDelay time.Duration // Base delay between retries
+	baseURL     string        // Base URL of the MCP server
+	httpClient  *http.Client  // HTTP client whose transport bounds connecting
+	readTimeout time.Duration // Limit for a response, headers and body, per attempt
+	maxRetries  int           // Maximum number of retry attempts
+	retryDelay  time.Duration // Base delay between retries
+	maxDelay    time.Duration // Cap of the delay between retries
+	breaker     *breaker      // Opens after failed requests in a row
+	token       string        // Bearer token sent with every request, if set
+	outbox      *Outbox       // Queue of messages sent while the server is unavailable, if set
+	compression string        // Encoding of request bodies, CompressionGzip or CompressionZstd; "" for none
+	batcher     *batcher      // Collects messages to send together, if batching
+
+	// Set once the server has answered the batched status endpoint with
+	// 404, so later calls go straight to the heartbeats
+	noBatchStatus atomic.Bool
+
+	// Set once the server has answered the batched message endpoint with
+	// 404, so later batches are sent a message at a time
+	noBatchSend atomic.Bool
+
+	// Set once the server has refused a compressed body with 415, so later
+	// bodies are sent uncompressed
+	noCompression atomic.Bool
+}
+
+// Options configures the timeouts and retries of an HTTPClient
+type Options struct {
+	ConnectTimeout  time.Duration  // Limit for establishing a connection, including TLS
+	ReadTimeout     time.Duration  // Limit for the server's full response once connected
+	MaxRetries      int            // Retries after a failed attempt; 0 for none
+	RetryBackoff    time.Duration  // Delay before the first retry, doubling with each retry, with jitter
+	MaxRetryBackoff time.Duration  // Cap of the delay between retries
+	Proxy           proxy.Settings // Proxy to connect through
+	TLS             *tls.Config    // TLS of https URLs, e.g. from NewTLSConfig; nil for Go's defaults
+	Token           string         // Bearer token to authenticate with; "" for none
+	Outbox          *Outbox        // Queue of messages sent while the server is unavailable; nil to fail them
+	StreamStatePath string         // Where a WebSocketClient records its StreamStatus; "" for nowhere
+	Compression     string         // CompressionGzip or CompressionZstd to compress requests; "" for none
+
+	// BatchWindow is how long a sent message waits for others to be sent
+	// with it in one request, of at most BatchSize messages (default:
+	// DefaultBatchSize); 0 sends each message at once
+	BatchWindow time.Duration
+	BatchSize   int
+
+	// BreakerThreshold is how many failed requests in a row open the
+	// circuit, failing requests fast for BreakerCooldown; negative
+	// disables the breaker
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// DefaultOptions returns the options NewHTTPClient uses: a 2-second
+// connect timeout, a 5-second read timeout, and 3 retries about 1, 2, and
+// 4 seconds apart, at most 10, through the proxy from HTTP_PROXY,
+// HTTPS_PROXY, and NO_PROXY, failing fast for 30 seconds after 5 failed
+// requests in a row
+func DefaultOptions() Options {
+	return Options{
+		ConnectTimeout:   2 * time.Second,
+		ReadTimeout:      5 * time.Second,
+		MaxRetries:       3,
+		RetryBackoff:     1 * time.Second,
+		MaxRetryBackoff:  10 * time.Second,
+		Proxy:            proxy.FromEnvironment(),
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// withDefaults returns the options with their zero timeouts, backoffs,
+// breaker threshold, and cooldown set to the default values
+func (opts Options) withDefaults() Options {
+	defaults := DefaultOptions()
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = defaults.ConnectTimeout
+	}
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = defaults.ReadTimeout
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaults.RetryBackoff
+	}
+	if opts.MaxRetryBackoff <= 0 {
+		opts.MaxRetryBackoff = defaults.MaxRetryBackoff
+	}
+	if opts.MaxRetryBackoff < opts.RetryBackoff {
+		opts.MaxRetryBackoff = opts.RetryBackoff
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.BreakerThreshold == 0 {
+		opts.BreakerThreshold = defaults.BreakerThreshold
+	}
+	if opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = defaults.BreakerCooldown
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	return opts
 }
 
-// NewHTTPClient creates a new HTTP-based MCP client with the specified base URL.
-// The client is configured with a 10-second timeout and 3 retry attempts.
+// NewHTTPClient creates a new HTTP-based MCP client with the specified base URL
+// and DefaultOptions.
 //
 // Example:
 //
 //	client := mcp.NewHTTPClient("http://localhost:8765")
 func NewHTTPClient(baseURL string) *HTTPClient {
-	return &HTTPClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		maxRetries: 3,
-		retryDelay: 1 * time.Second,
+	return NewHTTPClientWithOptions(baseURL, DefaultOptions())
+}
+
+// NewHTTPClientWithProxy creates an MCP client that connects through the
+// given proxy settings, e.g. those returned by proxy.ForService for the
+// services.mcp_agent_mail.proxy override.
+func NewHTTPClientWithProxy(baseURL string, proxySettings proxy.Settings) *HTTPClient {
+	opts := DefaultOptions()
+	opts.Proxy = proxySettings
+	return NewHTTPClientWithOptions(baseURL, opts)
+}
+
+// NewHTTPClientWithOptions creates an MCP client with the given timeouts,
+// retries, proxy, TLS, token, circuit breaker, compression, and batching.
+// Zero timeouts, backoffs, breaker threshold, cooldown, and batch size take
+// their default values.
+func NewHTTPClientWithOptions(baseURL string, opts Options) *HTTPClient {
+	opts = opts.withDefaults()
+
+	transport := opts.Proxy.Transport()
+	dialer := &net.Dialer{Timeout: opts.ConnectTimeout}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, &TimeoutError{Phase: "connect", Limit: opts.ConnectTimeout, Err: err}
+		}
+		return conn, err
 	}
+	transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	transport.ResponseHeaderTimeout = opts.ReadTimeout
+	if opts.TLS != nil {
+		transport.TLSClientConfig = opts.TLS.Clone()
+	}
+
+	c := &HTTPClient{
+		baseURL:     baseURL,
+		httpClient:  &http.Client{Transport: transport},
+		readTimeout: opts.ReadTimeout,
+		maxRetries:  opts.MaxRetries,
+		retryDelay:  opts.RetryBackoff,
+		maxDelay:    opts.MaxRetryBackoff,
+		breaker:     newBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+		token:       opts.Token,
+		outbox:      opts.Outbox,
+		compression: opts.Compression,
+	}
+	if opts.BatchWindow > 0 {
+		c.batcher = newBatcher(opts.BatchWindow, opts.BatchSize, c.postBatch)
+	}
+	return c
+}
+
+// Circuit returns the state of the client's circuit breaker
+func (c *HTTPClient) Circuit() CircuitStatus {
+	return c.breaker.status()
 }
 
 // GetMessages retrieves messages from the MCP server since the given timestamp.
 // Returns an empty slice if no messages are available. Retries on network errors.
-func (c *HTTPClient) GetMessages(since time.Time) ([]Message, error) {
+func (c *HTTPClient) GetMessages(ctx context.Context, since time.Time) ([]Message, error) {
 	url := fmt.Sprintf("%s/messages?since=%d", c.baseURL, since.Unix())
 	
-	logger.WithFields(logger.Fields{
+	mcpLog.WithFields(logger.Fields{
 		"url":   url,
 		"since": since,
 	}).Debug("Fetching messages from MCP server")
 	
 	var messages []Message
-	err := c.doRequestWithRetry("GET", url, nil, &messages)
+	err := c.doRequestWithRetry(ctx, "GET", url, nil, &messages)
 	if err != nil {
-		logger.WithFields(logger.Fields{
+		mcpLog.WithFields(logger.Fields{
 			"url": url,
 		}).Error("Failed to get messages from MCP: %v", err)
 		return nil, fmt.Errorf("failed to get messages: %w", err)
 	}
 	
-	logger.WithFields(logger.Fields{
+	mcpLog.WithFields(logger.Fields{
 		"message_count": len(messages),
 	}).Debug("Successfully fetched messages from MCP")
 	
@@ -125,10 +291,106 @@ func (c *HTTPClient) GetMessages(since time.Time) ([]Message, error) {
 
 // SendMessage sends a message to the MCP server.
 // The message is serialized to JSON and sent via HTTP POST. Retries on network errors.
-func (c *HTTPClient) SendMessage(msg Message) error {
+// With batching, it waits for the batch the message is sent in.
+// With an outbox, the messages queued earlier are sent first, and a message
+// that cannot be sent while the server is unavailable is queued after them,
+// failing with an error wrapping ErrQueued.
+func (c *HTTPClient) SendMessage(ctx context.Context, msg Message) error {
+	if msg.CorrelationID == "" {
+		msg.CorrelationID = logger.CorrelationID()
+	}
+	if c.outbox == nil {
+		return c.postMessage(ctx, msg)
+	}
+
+	// Keep the order: nothing overtakes the messages already queued
+	err := func() error {
+		if n, err := c.outbox.Len(); err != nil || n == 0 {
+			return err
+		}
+		_, err := c.FlushOutbox(ctx)
+		return err
+	}()
+	if err == nil {
+		err = c.postMessage(ctx, msg)
+	}
+	if !unavailable(ctx, err) {
+		return err
+	}
+	if queueErr := c.outbox.Enqueue(msg); queueErr != nil {
+		mcpLog.Error("Failed to queue MCP message: %v", queueErr)
+		return fmt.Errorf("%w (and could not queue it: %v)", err, queueErr)
+	}
+	mcpLog.WithFields(logger.Fields{"type": msg.Type, "source": msg.Source}).Info("Queued message until the MCP server is available")
+	return queued(err)
+}
+
+// FlushOutbox sends the messages queued in the outbox, oldest first, and
+// returns how many were sent. It stops at a message the server is
+// unavailable for. A queued message the server rejects is dropped, as
+// sending it again would fail again. Without an outbox it does nothing.
+func (c *HTTPClient) FlushOutbox(ctx context.Context) (int, error) {
+	if c.outbox == nil {
+		return 0, nil
+	}
+	return c.outbox.Flush(ctx, func(ctx context.Context, msg Message) error {
+		// Queued messages are sent one by one, not batched, so each can
+		// be dropped or kept on its own
+		err := c.postOne(ctx, msg)
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode < 500 {
+			mcpLog.WithFields(logger.Fields{"type": msg.Type, "source": msg.Source}).Warn("Dropped a queued MCP message the server rejected: %v", err)
+			return nil
+		}
+		return err
+	})
+}
+
+// postMessage sends a message to the server, without the outbox, in the
+// next batch if batching
+func (c *HTTPClient) postMessage(ctx context.Context, msg Message) error {
+	if c.batcher != nil {
+		return c.batcher.send(ctx, msg)
+	}
+	return c.postOne(ctx, msg)
+}
+
+// postBatch sends messages to the server in one request to
+// /messages/batch. Servers without that endpoint are sent the messages one
+// at a time.
+func (c *HTTPClient) postBatch(ctx context.Context, msgs []Message) error {
+	if !c.noBatchSend.Load() {
+		jsonData, err := json.Marshal(msgs)
+		if err != nil {
+			return fmt.Errorf("failed to marshal messages: %w", err)
+		}
+		err = c.doRequestWithRetry(ctx, "POST", c.baseURL+"/messages/batch", jsonData, nil)
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			if err != nil {
+				mcpLog.WithFields(logger.Fields{"messages": len(msgs)}).Error("Failed to send message batch to MCP: %v", err)
+				return fmt.Errorf("failed to send message: %w", err)
+			}
+			mcpLog.WithFields(logger.Fields{"messages": len(msgs)}).Debug("Successfully sent message batch to MCP")
+			return nil
+		}
+		mcpLog.Debug("MCP server has no batched message endpoint, sending messages one at a time")
+		c.noBatchSend.Store(true)
+	}
+
+	for _, msg := range msgs {
+		if err := c.postOne(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postOne sends a single message to the server
+func (c *HTTPClient) postOne(ctx context.Context, msg Message) error {
 	url := fmt.Sprintf("%s/messages", c.baseURL)
 	
-	logger.WithFields(logger.Fields{
+	mcpLog.WithFields(logger.Fields{
 		"url":    url,
 		"type":   msg.Type,
 		"source": msg.Source,
@@ -136,13 +398,13 @@ func (c *HTTPClient) SendMessage(msg Message) error {
 	
 	jsonData, err := json.Marshal(msg)
 	if err != nil {
-		logger.Error("Failed to marshal message: %v", err)
+		mcpLog.Error("Failed to marshal message: %v", err)
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 	
-	err = c.doRequestWithRetry("POST", url, jsonData, nil)
+	err = c.doRequestWithRetry(ctx, "POST", url, jsonData, nil)
 	if err != nil {
-		logger.WithFields(logger.Fields{
+		mcpLog.WithFields(logger.Fields{
 			"url":    url,
 			"type":   msg.Type,
 			"source": msg.Source,
@@ -150,7 +412,7 @@ func (c *HTTPClient) SendMessage(msg Message) error {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 	
-	logger.WithFields(logger.Fields{
+	mcpLog.WithFields(logger.Fields{
 		"type":   msg.Type,
 		"source": msg.Source,
 	}).Debug("Successfully sent message to MCP")
@@ -160,11 +422,11 @@ func (c *HTTPClient) SendMessage(msg Message) error {
 
 // GetAgentStatus retrieves the status of a specific agent by name.
 // Returns an error if the agent is not found or the request fails.
-func (c *HTTPClient) GetAgentStatus(agentName string) (AgentStatus, error) {
+func (c *HTTPClient) GetAgentStatus(ctx context.Context, agentName string) (AgentStatus, error) {
 	url := fmt.Sprintf("%s/agents/%s/status", c.baseURL, agentName)
 	
 	var status AgentStatus
-	err := c.doRequestWithRetry("GET", url, nil, &status)
+	err := c.doRequestWithRetry(ctx, "GET", url, nil, &status)
 	if err != nil {
 		return AgentStatus{}, fmt.Errorf("failed to get agent status: %w", err)
 	}
@@ -172,39 +434,185 @@ func (c *HTTPClient) GetAgentStatus(agentName string) (AgentStatus, error) {
 	return status, nil
 }
 
-// doRequestWithRetry performs an HTTP request with retry logic
-func (c *HTTPClient) doRequestWithRetry(method, url string, body []byte, result interface{}) error {
+// doRequestWithRetry performs an HTTP request with retry logic, failing
+// fast while the circuit breaker is open. It gives up as soon as ctx is
+// done, including while waiting to retry.
+func (c *HTTPClient) doRequestWithRetry(ctx context.Context, method, url string, body []byte, result interface{}) (err error) {
+	ctx, span := telemetry.StartKind(ctx, "mcp.request", telemetry.KindClient, telemetry.Attrs{
+		"http.request.method": method,
+		"url.full":            url,
+	})
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if err := c.breaker.allow(); err != nil {
+		span.SetAttributes(telemetry.Attrs{"mcp.circuit": CircuitOpen})
+		return err
+	}
+	defer func() { c.breaker.record(ctx, err) }()
+
+	data, encoding := c.encode(body)
 	var lastErr error
 	
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		span.SetAttributes(telemetry.Attrs{"mcp.attempts": attempt + 1})
 		if attempt > 0 {
-			time.Sleep(c.retryDelay * time.Duration(attempt))
+			timer := time.NewTimer(backoff(attempt, c.retryDelay, c.maxDelay))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("request interrupted: %w", ctx.Err())
+			case <-timer.C:
+			}
 		}
 		
-		err := c.doRequest(method, url, body, result)
+		err := c.doAttempt(ctx, method, url, data, encoding, result)
+		if encoding != "" && isHTTPStatus(err, http.StatusUnsupportedMediaType) {
+			// The server cannot decode the body; send it as is, now and
+			// from then on
+			mcpLog.Debug("MCP server does not accept %s request bodies, sending them uncompressed", encoding)
+			c.noCompression.Store(true)
+			data, encoding = body, ""
+			err = c.doAttempt(ctx, method, url, data, encoding, result)
+		}
 		if err == nil {
 			return nil
 		}
 		
 		lastErr = err
 		
+		// Don't retry once the caller has given up
+		if ctx.Err() != nil {
+			return fmt.Errorf("request interrupted: %w", ctx.Err())
+		}
+		
 		// Don't retry on client errors (4xx)
 		if httpErr, ok := err.(*HTTPError); ok && httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 {
-			return lastErr
+			return withHint(lastErr)
+		}
+		
+		// Don't retry a certificate that is not trusted; it will not be
+		// on the next attempt either
+		if isCertificateError(err) {
+			return withHint(lastErr)
+		}
+		
+		// Don't retry a server that stopped responding; it would stall
+		// the caller for the read timeout again on every attempt
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) && timeoutErr.Phase == "read" {
+			return withHint(lastErr)
 		}
 	}
 	
-	return fmt.Errorf("request failed after %d retries: %w", c.maxRetries, lastErr)
+	return withHint(fmt.Errorf("request failed after %d retries: %w", c.maxRetries, lastErr))
+}
+
+// encode compresses a request body with the client's compression,
+// returning it with its Content-Encoding. Short bodies, and every body once
+// the server has refused a compressed one, are returned as is.
+func (c *HTTPClient) encode(body []byte) ([]byte, string) {
+	if c.compression == "" || len(body) < minCompressSize || c.noCompression.Load() {
+		return body, ""
+	}
+	compressed, err := compressBody(c.compression, body)
+	if err != nil {
+		mcpLog.Warn("Failed to compress MCP request, sending it uncompressed: %v", err)
+		return body, ""
+	}
+	return compressed, c.compression
+}
+
+// isHTTPStatus reports whether err is the server's answer with status
+func isHTTPStatus(err error, status int) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == status
 }
 
-// doRequest performs a single HTTP request
-func (c *HTTPClient) doRequest(method, url string, body []byte, result interface{}) error {
+// Hints attached to failed requests, so the CLI and TUI can say how to
+// fix them
+const (
+	hintUnreachable   = "Is mcp_agent_mail running? Start it with 'asc services start', or check url under [services.mcp_agent_mail] in asc.toml"
+	hintNotResponding = "mcp_agent_mail is not responding. Restart it with 'asc services stop' and 'asc services start', or raise read_timeout under [services.mcp_agent_mail]"
+	hintServerError   = "mcp_agent_mail reported an error. Check its log in ~/.asc/logs/mcp_agent_mail.log"
+	hintUnauthorized  = "mcp_agent_mail rejected asc's credentials. Set its token in the variable named by token_env under [services.mcp_agent_mail] (default MCP_AGENT_MAIL_TOKEN), or check cert_file and key_file"
+	hintUntrusted     = "asc does not trust mcp_agent_mail's certificate. Set ca_file under [services.mcp_agent_mail] to the CA bundle that signed it"
+)
+
+// withHint attaches how to fix a failed request to its error
+func withHint(err error) error {
+	var httpErr *HTTPError
+	var rpcErr *RPCError
+	var timeoutErr *TimeoutError
+	var netErr *net.OpError
+	switch {
+	case err == nil:
+		return nil
+	case isCertificateError(err):
+		return ascerrors.WithHint(err, hintUntrusted)
+	case errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden):
+		return ascerrors.WithHint(err, hintUnauthorized)
+	case errors.As(err, &rpcErr) && (rpcErr.Code == codes.Unauthenticated || rpcErr.Code == codes.PermissionDenied):
+		return ascerrors.WithHint(err, hintUnauthorized)
+	case errors.As(err, &rpcErr) && rpcErr.Code == codes.Unavailable:
+		return ascerrors.WithHint(err, hintUnreachable)
+	case errors.As(err, &rpcErr) && rpcErr.serverFailure():
+		return ascerrors.WithHint(err, hintServerError)
+	case errors.As(err, &timeoutErr) && timeoutErr.Phase == "read":
+		return ascerrors.WithHint(err, hintNotResponding)
+	case errors.As(err, &timeoutErr), errors.As(err, &netErr):
+		return ascerrors.WithHint(err, hintUnreachable)
+	case errors.As(err, &httpErr) && httpErr.StatusCode >= 500:
+		return ascerrors.WithHint(err, hintServerError)
+	}
+	return err
+}
+
+// isCertificateError reports whether err is the failure to verify the
+// server's certificate
+func isCertificateError(err error) bool {
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	return errors.As(err, &certErr) || errors.As(err, &authorityErr)
+}
+
+// doAttempt performs one attempt of a request, bounded by the read
+// timeout. An attempt that runs out of time, whether waiting for the
+// response or reading a partial body, fails with a read TimeoutError.
+func (c *HTTPClient) doAttempt(ctx context.Context, method, url string, body []byte, encoding string, result interface{}) error {
+	attemptCtx := ctx
+	if c.readTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.readTimeout)
+		defer cancel()
+	}
+	
+	err := c.doRequest(attemptCtx, method, url, body, encoding, result)
+	if err == nil {
+		return nil
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr
+	}
+	var netErr net.Error
+	if ctx.Err() == nil && (attemptCtx.Err() != nil || (errors.As(err, &netErr) && netErr.Timeout())) {
+		return &TimeoutError{Phase: "read", Limit: c.readTimeout, Err: err}
+	}
+	return err
+}
+
+// doRequest performs a single HTTP request, with a body compressed with
+// encoding unless it is ""
+func (c *HTTPClient) doRequest(ctx context.Context, method, url string, body []byte, encoding string, result interface{}) error {
 	var reqBody io.Reader
 	if body != nil {
 		reqBody = bytes.NewReader(body)
 	}
 	
-	req, err := http.NewRequest(method, url, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -212,6 +620,26 @@ func (c *HTTPClient) doRequest(method, url string, body []byte, result interface
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if id := logger.CorrelationID(); id != "" {
+		req.Header.Set(logger.CorrelationIDHeader, id)
+	}
+	telemetry.Inject(ctx, req.Header)
+	
+	logBody := string(body)
+	if encoding != "" {
+		logBody = fmt.Sprintf("(%d bytes, %s)", len(body), encoding)
+	}
+	mcpLog.WithFields(logger.Fields{
+		"method": method,
+		"url":    url,
+		"body":   logBody,
+	}).Trace("Sending MCP request")
 	
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -219,6 +647,12 @@ func (c *HTTPClient) doRequest(method, url string, body []byte, result interface
 	}
 	defer resp.Body.Close()
 	
+	mcpLog.WithFields(logger.Fields{
+		"method": method,
+		"url":    url,
+		"status": resp.StatusCode,
+	}).Trace("Received MCP response")
+	
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return &HTTPError{
@@ -246,6 +680,26 @@ func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
 }
 
+// TimeoutError reports that the MCP server could not be reached, or did
+// not send its full response, within the configured limit. Phase is
+// "connect" or "read".
+type TimeoutError struct {
+	Phase string
+	Limit time.Duration
+	Err   error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Phase == "connect" {
+		return fmt.Sprintf("could not connect to MCP server within %v", e.Limit)
+	}
+	return fmt.Sprintf("MCP server did not respond within %v", e.Limit)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
 // Heartbeat represents an agent heartbeat message used to track agent liveness.
 type Heartbeat struct {
 	AgentName   string     `json:"agent_name"`
@@ -256,11 +710,11 @@ type Heartbeat struct {
 
 // GetHeartbeats retrieves agent heartbeats from the MCP server.
 // Heartbeats are used to determine agent liveness and current state.
-func (c *HTTPClient) GetHeartbeats() ([]Heartbeat, error) {
+func (c *HTTPClient) GetHeartbeats(ctx context.Context) ([]Heartbeat, error) {
 	url := fmt.Sprintf("%s/heartbeats", c.baseURL)
 	
 	var heartbeats []Heartbeat
-	err := c.doRequestWithRetry("GET", url, nil, &heartbeats)
+	err := c.doRequestWithRetry(ctx, "GET", url, nil, &heartbeats)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get heartbeats: %w", err)
 	}
@@ -268,17 +722,39 @@ func (c *HTTPClient) GetHeartbeats() ([]Heartbeat, error) {
 	return heartbeats, nil
 }
 
-// GetAllAgentStatuses retrieves the status of all agents based on heartbeats.
-// Agents that haven't sent a heartbeat within the offlineThreshold are marked as offline.
-func (c *HTTPClient) GetAllAgentStatuses(offlineThreshold time.Duration) ([]AgentStatus, error) {
-	heartbeats, err := c.GetHeartbeats()
+// GetAllAgentStatuses retrieves the status of every agent, with its count
+// of unread messages, in one request to /agents/status. Servers without
+// that endpoint are asked for heartbeats instead, which carry no unread
+// counts. Agents that haven't been seen within the offlineThreshold are
+// marked as offline.
+func (c *HTTPClient) GetAllAgentStatuses(ctx context.Context, offlineThreshold time.Duration) ([]AgentStatus, error) {
+	now := time.Now()
+
+	if !c.noBatchStatus.Load() {
+		var statuses []AgentStatus
+		err := c.doRequestWithRetry(ctx, "GET", c.baseURL+"/agents/status", nil, &statuses)
+		if err == nil {
+			for i := range statuses {
+				if now.Sub(statuses[i].LastSeen) > offlineThreshold {
+					statuses[i].State = StateOffline
+				}
+			}
+			return statuses, nil
+		}
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			return nil, fmt.Errorf("failed to get agent statuses: %w", err)
+		}
+		mcpLog.Debug("MCP server has no batched status endpoint, using heartbeats")
+		c.noBatchStatus.Store(true)
+	}
+
+	heartbeats, err := c.GetHeartbeats(ctx)
 	if err != nil {
 		return nil, err
 	}
 	
 	statuses := make([]AgentStatus, 0, len(heartbeats))
-	now := time.Now()
-	
 	for _, hb := range heartbeats {
 		status := c.heartbeatToStatus(hb, now, offlineThreshold)
 		statuses = append(statuses, status)
@@ -307,8 +783,8 @@ func (c *HTTPClient) heartbeatToStatus(hb Heartbeat, now time.Time, offlineThres
 // TrackAgentStatus polls the MCP server for a specific agent's status.
 // Returns the agent status based on its most recent heartbeat, or marks it
 // as offline if no heartbeat is found or it exceeds the offline threshold.
-func (c *HTTPClient) TrackAgentStatus(agentName string, offlineThreshold time.Duration) (AgentStatus, error) {
-	heartbeats, err := c.GetHeartbeats()
+func (c *HTTPClient) TrackAgentStatus(ctx context.Context, agentName string, offlineThreshold time.Duration) (AgentStatus, error) {
+	heartbeats, err := c.GetHeartbeats(ctx)
 	if err != nil {
 		return AgentStatus{}, err
 	}
@@ -333,23 +809,23 @@ func (c *HTTPClient) TrackAgentStatus(agentName string, offlineThreshold time.Du
 // ReleaseAgentLeases releases all file leases held by a specific agent.
 // This is typically used during recovery when an agent is stuck or unresponsive.
 // Returns an error if the request fails.
-func (c *HTTPClient) ReleaseAgentLeases(agentName string) error {
+func (c *HTTPClient) ReleaseAgentLeases(ctx context.Context, agentName string) error {
 	url := fmt.Sprintf("%s/leases/release/%s", c.baseURL, agentName)
 	
-	logger.WithFields(logger.Fields{
+	mcpLog.WithFields(logger.Fields{
 		"agent": agentName,
 		"url":   url,
 	}).Debug("Releasing file leases for agent")
 	
-	err := c.doRequestWithRetry("POST", url, nil, nil)
+	err := c.doRequestWithRetry(ctx, "POST", url, nil, nil)
 	if err != nil {
-		logger.WithFields(logger.Fields{
+		mcpLog.WithFields(logger.Fields{
 			"agent": agentName,
 		}).Error("Failed to release leases: %v", err)
 		return fmt.Errorf("failed to release leases for agent %s: %w", agentName, err)
 	}
 	
-	logger.WithFields(logger.Fields{
+	mcpLog.WithFields(logger.Fields{
 		"agent": agentName,
 	}).Info("Successfully released file leases for agent")
 	
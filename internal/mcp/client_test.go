@@ -1,8 +1,18 @@
 package mcp
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/proxy"
+	"github.com/rand/asc/internal/telemetry"
 )
 
 func TestNewHTTPClient(t *testing.T) {
@@ -278,7 +288,7 @@ func TestReleaseAgentLeases(t *testing.T) {
 	client := NewHTTPClient("http://localhost:8765")
 	
 	// Verify the method exists and returns an error (expected since no server is running)
-	err := client.ReleaseAgentLeases("test-agent")
+	err := client.ReleaseAgentLeases(context.Background(), "test-agent")
 	
 	// We expect an error since there's no actual server running
 	if err == nil {
@@ -288,3 +298,225 @@ func TestReleaseAgentLeases(t *testing.T) {
 	// The important thing is that the method exists and can be called
 	// The actual functionality would be tested in integration tests
 }
+
+func TestCorrelationIDPropagation(t *testing.T) {
+	var gotHeader string
+	var gotMessage Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get(logger.CorrelationIDHeader)
+		json.NewDecoder(r.Body).Decode(&gotMessage)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	logger.SetCorrelationID("test-correlation-id")
+	defer logger.SetCorrelationID("")
+
+	client := NewHTTPClient(server.URL)
+	if err := client.SendMessage(context.Background(), Message{Type: TypeMessage, Source: "test", Content: "hello"}); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	if gotHeader != "test-correlation-id" {
+		t.Errorf("Expected %s header test-correlation-id, got %q", logger.CorrelationIDHeader, gotHeader)
+	}
+	if gotMessage.CorrelationID != "test-correlation-id" {
+		t.Errorf("Expected message correlation ID test-correlation-id, got %q", gotMessage.CorrelationID)
+	}
+}
+
+func TestTraceparentPropagation(t *testing.T) {
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get(telemetry.TraceparentHeader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, span := telemetry.Start(context.Background(), "test", nil)
+	defer span.End()
+	wantTrace, parentID := span.IDs()
+
+	client := NewHTTPClient(server.URL)
+	if err := client.SendMessage(ctx, Message{Type: TypeMessage, Source: "test", Content: "hello"}); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	// The request is a span of its own in the caller's trace
+	traceID, spanID, ok := telemetry.ParseTraceparent(gotHeader)
+	if !ok || traceID != wantTrace || spanID == parentID {
+		t.Errorf("Expected a traceparent of a request span in trace %s, got %q", wantTrace, gotHeader)
+	}
+}
+
+func TestHTTPClientUsesProxy(t *testing.T) {
+	var proxiedURL string
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute target URL
+		proxiedURL = r.URL.String()
+		json.NewEncoder(w).Encode([]Message{})
+	}))
+	defer proxyServer.Close()
+
+	settings := proxy.Settings{HTTPProxy: proxyServer.URL}
+	client := NewHTTPClientWithProxy("http://mcp.example.invalid:8765", settings)
+
+	if _, err := client.GetMessages(context.Background(), time.Unix(0, 0)); err != nil {
+		t.Fatalf("GetMessages through proxy failed: %v", err)
+	}
+	if proxiedURL != "http://mcp.example.invalid:8765/messages?since=0" {
+		t.Errorf("Expected request to go through the proxy, proxy saw %q", proxiedURL)
+	}
+}
+
+func TestHTTPClientHonorsContext(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// A stuck server that only lets go when the client does
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetMessages(ctx, time.Now())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetMessages took %v after its context expired", elapsed)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected no retries after cancellation, got %d requests", n)
+	}
+}
+
+func TestHTTPClientReadTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"no response", func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}},
+		{"partial response", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"type": "lease", "source": "cod`))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			client := NewHTTPClientWithOptions(server.URL, Options{
+				ReadTimeout:  100 * time.Millisecond,
+				MaxRetries:   3,
+				RetryBackoff: 10 * time.Millisecond,
+			})
+
+			start := time.Now()
+			_, err := client.GetMessages(context.Background(), time.Now())
+			var timeoutErr *TimeoutError
+			if !errors.As(err, &timeoutErr) || timeoutErr.Phase != "read" {
+				t.Fatalf("Expected a read TimeoutError, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("GetMessages took %v with a 100ms read timeout", elapsed)
+			}
+			if n := atomic.LoadInt32(&requests); n != 1 {
+				t.Errorf("Expected a timed out request not to be retried, got %d requests", n)
+			}
+		})
+	}
+}
+
+func TestHTTPClientOptions(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClientWithOptions(server.URL, Options{MaxRetries: 1, RetryBackoff: 10 * time.Millisecond})
+	if client.readTimeout != DefaultOptions().ReadTimeout {
+		t.Errorf("readTimeout = %v, want the default", client.readTimeout)
+	}
+	if _, err := client.GetMessages(context.Background(), time.Now()); err == nil {
+		t.Fatal("Expected an error from a failing server")
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected one retry, got %d requests", n)
+	}
+}
+
+func TestGetAllAgentStatusesBatched(t *testing.T) {
+	var requests int32
+	now := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/agents/status" {
+			t.Errorf("Unexpected request for %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode([]AgentStatus{
+			{Name: "planner", State: StateWorking, CurrentTask: "12", LastSeen: now, UnreadCount: 2},
+			{Name: "tester", State: StateIdle, LastSeen: now.Add(-time.Hour)},
+		})
+	}))
+	defer server.Close()
+
+	statuses, err := NewHTTPClient(server.URL).GetAllAgentStatuses(context.Background(), time.Minute)
+	if err != nil {
+		t.Fatalf("GetAllAgentStatuses failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected one request for all agents, got %d", n)
+	}
+	if len(statuses) != 2 || statuses[0].UnreadCount != 2 || statuses[0].State != StateWorking {
+		t.Fatalf("Unexpected statuses: %+v", statuses)
+	}
+	if statuses[1].State != StateOffline {
+		t.Errorf("Expected an agent not seen for an hour to be offline, got %s", statuses[1].State)
+	}
+}
+
+func TestGetAllAgentStatusesFallsBackToHeartbeats(t *testing.T) {
+	var batched, heartbeats int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/agents/status":
+			atomic.AddInt32(&batched, 1)
+			http.NotFound(w, r)
+		case "/heartbeats":
+			atomic.AddInt32(&heartbeats, 1)
+			json.NewEncoder(w).Encode([]Heartbeat{{AgentName: "planner", State: StateIdle, Timestamp: time.Now()}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL)
+	for i := 0; i < 2; i++ {
+		statuses, err := client.GetAllAgentStatuses(context.Background(), time.Minute)
+		if err != nil {
+			t.Fatalf("GetAllAgentStatuses failed: %v", err)
+		}
+		if len(statuses) != 1 || statuses[0].Name != "planner" || statuses[0].State != StateIdle {
+			t.Fatalf("Unexpected statuses: %+v", statuses)
+		}
+	}
+	if atomic.LoadInt32(&batched) != 1 || atomic.LoadInt32(&heartbeats) != 2 {
+		t.Errorf("Expected the batched endpoint to be tried once, got %d batched and %d heartbeat requests", batched, heartbeats)
+	}
+}
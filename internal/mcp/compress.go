@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // Registers the gzip compressor of gRPC calls
+)
+
+// Compressions of request bodies, named as in Content-Encoding
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// minCompressSize is the smallest request body worth compressing; the
+// encoding costs more than it saves on a single short message
+const minCompressSize = 512
+
+var (
+	gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+	// zstdEncoder encodes whole bodies, and is safe for concurrent use
+	// with EncodeAll
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+)
+
+func init() {
+	encoding.RegisterCompressor(zstdCompressor{})
+}
+
+// compressBody encodes body with compression, which is CompressionGzip or
+// CompressionZstd
+func compressBody(compression string, body []byte) ([]byte, error) {
+	switch compression {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(w)
+		w.Reset(&buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		return zstdEncoder.EncodeAll(body, make([]byte, 0, len(body)/2)), nil
+	}
+	return nil, fmt.Errorf("unknown compression %q", compression)
+}
+
+// zstdCompressor compresses gRPC calls with zstd
+type zstdCompressor struct{}
+
+func (zstdCompressor) Name() string { return CompressionZstd }
+
+func (zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+}
+
+func (zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	// A synchronous decoder starts no goroutines to leak when the caller
+	// stops reading
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
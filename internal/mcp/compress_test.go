@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// decompressBody decodes a request body compressed with encoding
+func decompressBody(encoding string, body []byte) ([]byte, error) {
+	switch encoding {
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	case CompressionZstd:
+		d, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer d.Close()
+		return d.DecodeAll(body, nil)
+	}
+	return nil, fmt.Errorf("unknown encoding %q", encoding)
+}
+
+func TestCompressBody(t *testing.T) {
+	body := []byte(strings.Repeat(`{"type":"message","content":"status update"}`, 50))
+	for _, compression := range []string{CompressionGzip, CompressionZstd} {
+		compressed, err := compressBody(compression, body)
+		if err != nil {
+			t.Fatalf("compressBody(%s) error = %v", compression, err)
+		}
+		if len(compressed) >= len(body) {
+			t.Errorf("compressBody(%s) = %d bytes, want fewer than %d", compression, len(compressed), len(body))
+		}
+		if decoded, err := decompressBody(compression, compressed); err != nil || !bytes.Equal(decoded, body) {
+			t.Errorf("compressBody(%s) does not round-trip: %v", compression, err)
+		}
+	}
+	if _, err := compressBody("brotli", body); err == nil {
+		t.Error("Expected an unknown compression to fail")
+	}
+}
+
+func TestHTTPClientCompressesRequests(t *testing.T) {
+	server := &mailServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client := NewHTTPClientWithOptions(ts.URL, Options{Compression: CompressionZstd})
+	ctx := context.Background()
+
+	long := strings.Repeat("a long report ", 100)
+	if err := client.SendMessage(ctx, Message{Content: long}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if err := client.SendMessage(ctx, Message{Content: "short"}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if server.encodings[0] != CompressionZstd || server.encodings[1] != "" {
+		t.Errorf("Request encodings = %q, want the long message compressed and the short one not", server.encodings)
+	}
+	if len(server.received) != 2 || server.received[0].Content != long {
+		t.Errorf("Server received %d messages, want both intact", len(server.received))
+	}
+}
+
+func TestHTTPClientSendsUncompressedWhenRefused(t *testing.T) {
+	server := &mailServer{noEncoding: true}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client := NewHTTPClientWithOptions(ts.URL, Options{Compression: CompressionGzip, MaxRetries: -1})
+	ctx := context.Background()
+
+	long := strings.Repeat("a long report ", 100)
+	for i := 0; i < 2; i++ {
+		if err := client.SendMessage(ctx, Message{Content: long}); err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+	}
+	want := []string{CompressionGzip, "", ""}
+	if fmt.Sprint(server.encodings) != fmt.Sprint(want) {
+		t.Errorf("Request encodings = %q, want %q", server.encodings, want)
+	}
+	if len(server.received) != 2 {
+		t.Errorf("Server received %d messages, want 2", len(server.received))
+	}
+}
+
+func TestGRPCClientCompresses(t *testing.T) {
+	server := &grpcServer{}
+	client, err := NewGRPCClient(server.start(t), Options{Compression: CompressionZstd, ReadTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("NewGRPCClient() error = %v", err)
+	}
+	defer client.Close()
+
+	long := strings.Repeat("a long report ", 100)
+	if err := client.SendMessage(context.Background(), Message{Content: long}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if len(server.sent) != 1 || server.sent[0].Content != long {
+		t.Errorf("Server received %d messages, want the message intact", len(server.sent))
+	}
+}
@@ -1,11 +1,15 @@
 package mcp
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	ascerrors "github.com/rand/asc/internal/errors"
 )
 
 // TestNewClient_ErrorPaths tests error handling in client creation
@@ -122,7 +126,7 @@ func TestGetMessages_ErrorPaths(t *testing.T) {
 
 			client := NewHTTPClient(server.URL)
 
-			messages, err := client.GetMessages(time.Now().Add(-1*time.Hour))
+			messages, err := client.GetMessages(context.Background(), time.Now().Add(-1*time.Hour))
 
 			if tt.expectError {
 				if err == nil {
@@ -204,7 +208,7 @@ func TestSendMessage_ErrorPaths(t *testing.T) {
 
 			client := NewHTTPClient(server.URL)
 
-			err := client.SendMessage(tt.message)
+			err := client.SendMessage(context.Background(), tt.message)
 
 			if tt.expectError {
 				if err == nil {
@@ -268,7 +272,7 @@ func TestGetAgentStatus_ErrorPaths(t *testing.T) {
 
 			client := NewHTTPClient(server.URL)
 
-			status, err := client.GetAgentStatus(tt.agentName)
+			status, err := client.GetAgentStatus(context.Background(), tt.agentName)
 
 			if tt.expectError {
 				if err == nil {
@@ -294,19 +298,19 @@ func TestConnectionFailure(t *testing.T) {
 	client := NewHTTPClient("http://localhost:99999")
 
 	// Try to get messages - should fail
-	_, err := client.GetMessages(time.Now())
+	_, err := client.GetMessages(context.Background(), time.Now())
 	if err == nil {
 		t.Error("Expected error for connection failure")
 	}
 
 	// Try to send message - should fail
-	err = client.SendMessage(Message{Type: "test", Source: "test", Content: "test"})
+	err = client.SendMessage(context.Background(), Message{Type: "test", Source: "test", Content: "test"})
 	if err == nil {
 		t.Error("Expected error for connection failure")
 	}
 
 	// Try to get agent status - should fail
-	_, err = client.GetAgentStatus("test")
+	_, err = client.GetAgentStatus(context.Background(), "test")
 	if err == nil {
 		t.Error("Expected error for connection failure")
 	}
@@ -330,7 +334,7 @@ func TestRetryLogic(t *testing.T) {
 	client := NewHTTPClient(server.URL)
 
 	// Client retries internally, so first call should succeed after 3 attempts
-	messages, err := client.GetMessages(time.Now())
+	messages, err := client.GetMessages(context.Background(), time.Now())
 	if err != nil {
 		t.Errorf("Expected success after retries, got: %v", err)
 	}
@@ -356,7 +360,7 @@ func TestConcurrentRequests(t *testing.T) {
 	done := make(chan error, 10)
 	for i := 0; i < 10; i++ {
 		go func() {
-			_, err := client.GetMessages(time.Now())
+			_, err := client.GetMessages(context.Background(), time.Now())
 			done <- err
 		}()
 	}
@@ -386,7 +390,7 @@ func TestInvalidInput(t *testing.T) {
 		{
 			name: "send message with null bytes",
 			fn: func() error {
-				return client.SendMessage(Message{
+				return client.SendMessage(context.Background(), Message{
 					Type:    "test\x00type",
 					Source:  "test",
 					Content: "test",
@@ -396,14 +400,14 @@ func TestInvalidInput(t *testing.T) {
 		{
 			name: "get status with special characters",
 			fn: func() error {
-				_, err := client.GetAgentStatus("test/../../../etc/passwd")
+				_, err := client.GetAgentStatus(context.Background(), "test/../../../etc/passwd")
 				return err
 			},
 		},
 		{
 			name: "get messages with far future time",
 			fn: func() error {
-				_, err := client.GetMessages(time.Now().Add(100 * 365 * 24 * time.Hour))
+				_, err := client.GetMessages(context.Background(), time.Now().Add(100 * 365 * 24 * time.Hour))
 				return err
 			},
 		},
@@ -436,7 +440,7 @@ func TestErrorWrapping(t *testing.T) {
 
 	client := NewHTTPClient(server.URL)
 
-	_, err := client.GetMessages(time.Now())
+	_, err := client.GetMessages(context.Background(), time.Now())
 	if err == nil {
 		t.Fatal("Expected error")
 	}
@@ -453,6 +457,28 @@ func TestErrorWrapping(t *testing.T) {
 	}
 }
 
+// TestErrorHints tests that failed requests say how to fix them
+func TestErrorHints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	client := NewHTTPClientWithOptions(server.URL, Options{MaxRetries: 0})
+	_, err := client.GetMessages(context.Background(), time.Now())
+	if hint := ascerrors.Hint(err); hint != hintServerError {
+		t.Errorf("Hint() = %q, want the server error hint", hint)
+	}
+
+	// Nothing listens once the server is closed
+	server.Close()
+	_, err = client.GetMessages(context.Background(), time.Now())
+	if hint := ascerrors.Hint(err); hint != hintUnreachable {
+		t.Errorf("Hint() = %q, want the unreachable hint", hint)
+	}
+	if !strings.Contains(err.Error(), "failed to get messages") {
+		t.Errorf("Expected the hint to keep the message, got %v", err)
+	}
+}
+
 // TestPanicRecovery tests that panics are handled gracefully
 func TestPanicRecovery(t *testing.T) {
 	defer func() {
@@ -468,9 +494,9 @@ func TestPanicRecovery(t *testing.T) {
 	client := NewHTTPClient("http://localhost:8765")
 
 	// Try operations that might panic
-	client.GetMessages(time.Time{})
-	client.SendMessage(Message{})
-	client.GetAgentStatus("")
+	client.GetMessages(context.Background(), time.Time{})
+	client.SendMessage(context.Background(), Message{})
+	client.GetAgentStatus(context.Background(), "")
 }
 
 // Helper function
@@ -0,0 +1,557 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/telemetry"
+)
+
+// GRPCService is the gRPC service of the MCP agent mail server. Its
+// messages are the JSON of the HTTP API's, sent with the "json" content
+// subtype (application/grpc+json):
+//
+//	GetMessages(MessageQuery) returns MessagePage
+//	SendMessage(Message) returns {}
+//	GetAgentStatus({"name"}) returns AgentStatus
+//	ListAgentStatuses({}) returns {"statuses": [AgentStatus]}
+//	ReleaseAgentLeases({"name"}) returns {}
+//	Watch({"events", "last_event_id"}) returns stream Event
+const GRPCService = "asc.mcp.v1.AgentMail"
+
+// jsonCodec encodes gRPC messages as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                       { return "json" }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// grpcQuery is a MessageQuery as sent to GetMessages
+type grpcQuery struct {
+	Sources []string      `json:"sources,omitempty"`
+	Types   []MessageType `json:"types,omitempty"`
+	Since   *time.Time    `json:"since,omitempty"`
+	Until   *time.Time    `json:"until,omitempty"`
+	Cursor  string        `json:"cursor,omitempty"`
+	Limit   int           `json:"limit,omitempty"`
+}
+
+// grpcAgent names the agent of GetAgentStatus and ReleaseAgentLeases
+type grpcAgent struct {
+	Name string `json:"name"`
+}
+
+// grpcStatuses is the answer of ListAgentStatuses
+type grpcStatuses struct {
+	Statuses []AgentStatus `json:"statuses"`
+}
+
+// grpcWatch is the request of Watch
+type grpcWatch struct {
+	Events      []EventType `json:"events"`
+	LastEventID string      `json:"last_event_id,omitempty"`
+}
+
+// RPCError is an error status the MCP server answered a gRPC call with
+type RPCError struct {
+	Code    codes.Code
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("gRPC %s: %s", e.Code, e.Message)
+}
+
+// serverFailure reports whether the server failed the call, rather than
+// rejecting it, as an HTTP 5xx does
+func (e *RPCError) serverFailure() bool {
+	switch e.Code {
+	case codes.Unavailable, codes.Internal, codes.Unknown, codes.DataLoss, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// GRPCClient implements the MCPClient interface over gRPC, for servers
+// that expose GRPCService, with the retries, circuit breaker, and outbox
+// of the HTTPClient. Its Watch streams messages and agent statuses.
+type GRPCClient struct {
+	target      string // Address of the server, host:port
+	conn        *grpc.ClientConn
+	readTimeout time.Duration // Limit for a unary call, per attempt
+	maxRetries  int
+	retryDelay  time.Duration
+	maxDelay    time.Duration
+	breaker     *breaker
+	outbox      *Outbox
+}
+
+// NewGRPCClient creates a gRPC client of the MCP server at rawURL, whose
+// host and port are those of the gRPC server: an https:// URL connects
+// with TLS, an http:// one without. The options apply as to
+// NewHTTPClientWithOptions, except batching: the token is sent with every
+// call, and calls are compressed with the Compression. The connection is
+// made on the first call.
+//
+// Example:
+//
+//	client, err := mcp.NewGRPCClient("http://localhost:8765", mcp.DefaultOptions())
+func NewGRPCClient(rawURL string, opts Options) (*GRPCClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid gRPC server URL %q", rawURL)
+	}
+	secure := u.Scheme == "https"
+	target := u.Host
+	if u.Port() == "" && secure {
+		target = net.JoinHostPort(u.Hostname(), "443")
+	} else if u.Port() == "" {
+		target = net.JoinHostPort(u.Hostname(), "80")
+	}
+
+	opts = opts.withDefaults()
+
+	creds := insecure.NewCredentials()
+	if secure {
+		tlsConfig := opts.TLS
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		creds = credentials.NewTLS(tlsConfig.Clone())
+	}
+	callOpts := []grpc.CallOption{grpc.CallContentSubtype(jsonCodec{}.Name())}
+	if opts.Compression != "" {
+		callOpts = append(callOpts, grpc.UseCompressor(opts.Compression))
+	}
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(callOpts...),
+		grpc.WithNoProxy(), // Proxied by the dialer, per the Options
+		grpc.WithContextDialer(proxyDialer(opts, secure)),
+	}
+	if opts.Token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenCredentials{token: opts.Token, secure: secure}))
+	}
+	conn, err := grpc.NewClient("passthrough:///"+target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+
+	return &GRPCClient{
+		target:      target,
+		conn:        conn,
+		readTimeout: opts.ReadTimeout,
+		maxRetries:  opts.MaxRetries,
+		retryDelay:  opts.RetryBackoff,
+		maxDelay:    opts.MaxRetryBackoff,
+		breaker:     newBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+		outbox:      opts.Outbox,
+	}, nil
+}
+
+// tokenCredentials sends the bearer token with every call
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity is false for plain connections, as the
+// HTTPClient also sends the token over http:// URLs
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
+
+// proxyDialer dials the server within the connect timeout, through the
+// HTTP proxy of opts with a CONNECT request when one applies to it
+func proxyDialer(opts Options, secure bool) func(ctx context.Context, addr string) (net.Conn, error) {
+	timeout := opts.ConnectTimeout
+	dialer := &net.Dialer{Timeout: timeout}
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		proxyURL, err := opts.Proxy.ProxyURL(&url.URL{Scheme: scheme, Host: addr})
+		if err != nil {
+			return nil, err
+		}
+		dialAddr := addr
+		if proxyURL != nil {
+			dialAddr = proxyURL.Host
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", dialAddr)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, &TimeoutError{Phase: "connect", Limit: timeout, Err: err}
+		}
+		if err != nil || proxyURL == nil {
+			return conn, err
+		}
+
+		// Tunnel through the proxy
+		req := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: addr}, Host: addr, Header: http.Header{}}
+		if user := proxyURL.User; user != nil {
+			password, _ := user.Password()
+			req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password)))
+		}
+		conn.SetDeadline(time.Now().Add(timeout))
+		defer conn.SetDeadline(time.Time{})
+		if err := req.Write(conn); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy %s: %w", proxyURL.Host, err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy %s: %w", proxyURL.Host, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			conn.Close()
+			return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", proxyURL.Host, addr, resp.Status)
+		}
+		return conn, nil
+	}
+}
+
+// Close closes the connection to the server
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+// Circuit returns the state of the client's circuit breaker
+func (c *GRPCClient) Circuit() CircuitStatus {
+	return c.breaker.status()
+}
+
+// invoke calls a unary method with retry logic, failing fast while the
+// circuit breaker is open, as the HTTPClient's requests do. Only calls
+// the server is unavailable for are retried.
+func (c *GRPCClient) invoke(ctx context.Context, method string, req, resp any) (err error) {
+	ctx, span := telemetry.StartKind(ctx, "mcp.request", telemetry.KindClient, telemetry.Attrs{
+		"rpc.system": "grpc",
+		"rpc.method": method,
+	})
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if err := c.breaker.allow(); err != nil {
+		span.SetAttributes(telemetry.Attrs{"mcp.circuit": CircuitOpen})
+		return err
+	}
+	defer func() { c.breaker.record(ctx, err) }()
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		span.SetAttributes(telemetry.Attrs{"mcp.attempts": attempt + 1})
+		if attempt > 0 {
+			timer := time.NewTimer(backoff(attempt, c.retryDelay, c.maxDelay))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("request interrupted: %w", ctx.Err())
+			case <-timer.C:
+			}
+		}
+
+		err := c.invokeAttempt(ctx, method, req, resp)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		// Don't retry once the caller has given up
+		if ctx.Err() != nil {
+			return fmt.Errorf("request interrupted: %w", ctx.Err())
+		}
+
+		// Retry only a server that could not be reached
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) || rpcErr.Code != codes.Unavailable {
+			return withHint(lastErr)
+		}
+	}
+
+	return withHint(fmt.Errorf("request failed after %d retries: %w", c.maxRetries, lastErr))
+}
+
+// invokeAttempt performs one call, bounded by the read timeout
+func (c *GRPCClient) invokeAttempt(ctx context.Context, method string, req, resp any) error {
+	attemptCtx, cancel := context.WithTimeout(c.outgoing(ctx), c.readTimeout)
+	defer cancel()
+
+	mcpLog.WithFields(logger.Fields{
+		"method": method,
+		"target": c.target,
+	}).Trace("Sending MCP gRPC call")
+
+	err := c.conn.Invoke(attemptCtx, "/"+GRPCService+"/"+method, req, resp)
+	return c.convert(ctx, attemptCtx, err)
+}
+
+// outgoing adds the correlation ID and trace context to the metadata of
+// the calls made with ctx
+func (c *GRPCClient) outgoing(ctx context.Context) context.Context {
+	header := http.Header{}
+	if id := logger.CorrelationID(); id != "" {
+		header.Set(logger.CorrelationIDHeader, id)
+	}
+	telemetry.Inject(ctx, header)
+	var pairs []string
+	for key, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, strings.ToLower(key), value)
+		}
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// convert turns the error of a call into the errors of the package: a
+// TimeoutError for a call that ran out of time or could not connect in
+// time, and an RPCError for the server's other answers
+func (c *GRPCClient) convert(ctx, attemptCtx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	if st.Code() == codes.DeadlineExceeded && ctx.Err() == nil && attemptCtx.Err() != nil {
+		return &TimeoutError{Phase: "read", Limit: c.readTimeout, Err: err}
+	}
+	return &RPCError{Code: st.Code(), Message: st.Message()}
+}
+
+// GetMessages retrieves messages from the MCP server since the given
+// timestamp, every page of them. Retries on network errors.
+func (c *GRPCClient) GetMessages(ctx context.Context, since time.Time) ([]Message, error) {
+	var messages []Message
+	query := MessageQuery{Since: since}
+	for {
+		page, err := c.QueryMessages(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, page.Messages...)
+		if !page.More || page.NextCursor == "" {
+			return messages, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// QueryMessages retrieves a page of the messages matching query, filtered
+// by the server. Retries on network errors.
+func (c *GRPCClient) QueryMessages(ctx context.Context, query MessageQuery) (MessagePage, error) {
+	req := grpcQuery{Sources: query.Sources, Types: query.Types, Cursor: query.Cursor, Limit: query.Limit}
+	if !query.Since.IsZero() {
+		req.Since = &query.Since
+	}
+	if !query.Until.IsZero() {
+		req.Until = &query.Until
+	}
+
+	var page MessagePage
+	if err := c.invoke(ctx, "GetMessages", req, &page); err != nil {
+		mcpLog.WithFields(logger.Fields{
+			"target": c.target,
+		}).Error("Failed to get messages from MCP: %v", err)
+		return MessagePage{}, fmt.Errorf("failed to get messages: %w", err)
+	}
+	return page, nil
+}
+
+// SendMessage sends a message to the MCP server. With an outbox, it
+// queues messages as the HTTPClient's SendMessage does.
+func (c *GRPCClient) SendMessage(ctx context.Context, msg Message) error {
+	if msg.CorrelationID == "" {
+		msg.CorrelationID = logger.CorrelationID()
+	}
+	if c.outbox == nil {
+		return c.sendMessage(ctx, msg)
+	}
+
+	// Keep the order: nothing overtakes the messages already queued
+	err := func() error {
+		if n, err := c.outbox.Len(); err != nil || n == 0 {
+			return err
+		}
+		_, err := c.FlushOutbox(ctx)
+		return err
+	}()
+	if err == nil {
+		err = c.sendMessage(ctx, msg)
+	}
+	if !unavailable(ctx, err) {
+		return err
+	}
+	if queueErr := c.outbox.Enqueue(msg); queueErr != nil {
+		mcpLog.Error("Failed to queue MCP message: %v", queueErr)
+		return fmt.Errorf("%w (and could not queue it: %v)", err, queueErr)
+	}
+	mcpLog.WithFields(logger.Fields{"type": msg.Type, "source": msg.Source}).Info("Queued message until the MCP server is available")
+	return queued(err)
+}
+
+// FlushOutbox sends the messages queued in the outbox, oldest first, and
+// returns how many were sent, as the HTTPClient's FlushOutbox does
+func (c *GRPCClient) FlushOutbox(ctx context.Context) (int, error) {
+	if c.outbox == nil {
+		return 0, nil
+	}
+	return c.outbox.Flush(ctx, func(ctx context.Context, msg Message) error {
+		err := c.sendMessage(ctx, msg)
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) && !rpcErr.serverFailure() {
+			mcpLog.WithFields(logger.Fields{"type": msg.Type, "source": msg.Source}).Warn("Dropped a queued MCP message the server rejected: %v", err)
+			return nil
+		}
+		return err
+	})
+}
+
+// sendMessage sends a message to the server, without the outbox
+func (c *GRPCClient) sendMessage(ctx context.Context, msg Message) error {
+	if err := c.invoke(ctx, "SendMessage", msg, &struct{}{}); err != nil {
+		mcpLog.WithFields(logger.Fields{
+			"target": c.target,
+			"type":   msg.Type,
+			"source": msg.Source,
+		}).Error("Failed to send message to MCP: %v", err)
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// GetAgentStatus retrieves the status of a specific agent by name.
+func (c *GRPCClient) GetAgentStatus(ctx context.Context, agentName string) (AgentStatus, error) {
+	var status AgentStatus
+	if err := c.invoke(ctx, "GetAgentStatus", grpcAgent{Name: agentName}, &status); err != nil {
+		return AgentStatus{}, fmt.Errorf("failed to get agent status: %w", err)
+	}
+	return status, nil
+}
+
+// GetAllAgentStatuses retrieves the status of every agent in one call.
+// Agents that haven't been seen within the offlineThreshold are marked
+// as offline.
+func (c *GRPCClient) GetAllAgentStatuses(ctx context.Context, offlineThreshold time.Duration) ([]AgentStatus, error) {
+	var resp grpcStatuses
+	if err := c.invoke(ctx, "ListAgentStatuses", struct{}{}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get agent statuses: %w", err)
+	}
+	now := time.Now()
+	for i := range resp.Statuses {
+		if now.Sub(resp.Statuses[i].LastSeen) > offlineThreshold {
+			resp.Statuses[i].State = StateOffline
+		}
+	}
+	return resp.Statuses, nil
+}
+
+// ReleaseAgentLeases releases all file leases held by a specific agent.
+func (c *GRPCClient) ReleaseAgentLeases(ctx context.Context, agentName string) error {
+	if err := c.invoke(ctx, "ReleaseAgentLeases", grpcAgent{Name: agentName}, &struct{}{}); err != nil {
+		mcpLog.WithFields(logger.Fields{
+			"agent": agentName,
+		}).Error("Failed to release leases: %v", err)
+		return fmt.Errorf("failed to release leases for agent %s: %w", agentName, err)
+	}
+	mcpLog.WithFields(logger.Fields{
+		"agent": agentName,
+	}).Info("Successfully released file leases for agent")
+	return nil
+}
+
+// EventWatcher is implemented by MCP clients that stream events over
+// their own transport rather than the server's WebSocket
+type EventWatcher interface {
+	// Watch returns the client's event stream, recording its status at
+	// statePath ("" for nowhere). It must be connected with Connect.
+	Watch(statePath string) EventSource
+}
+
+// Watch returns a stream of the server's new messages and agent status
+// changes over the Watch method, resuming after the last event received
+// when it reconnects
+func (c *GRPCClient) Watch(statePath string) EventSource {
+	return newEventStream("grpc://"+c.target, statePath, c.openWatch)
+}
+
+// openWatch opens a Watch call, resuming after the event lastEventID if
+// it is set
+func (c *GRPCClient) openWatch(lastEventID string) (eventConn, error) {
+	ctx, cancel := context.WithCancel(c.outgoing(context.Background()))
+	if lastEventID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "last-event-id", lastEventID)
+	}
+
+	stream, err := c.conn.NewStream(ctx, &grpc.StreamDesc{StreamName: "Watch", ServerStreams: true}, "/"+GRPCService+"/Watch")
+	if err == nil {
+		err = stream.SendMsg(grpcWatch{Events: []EventType{EventAgentStatus, EventNewMessage}, LastEventID: lastEventID})
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	if err != nil {
+		cancel()
+		return nil, c.convert(context.Background(), ctx, err)
+	}
+	return &grpcWatchConn{stream: stream, cancel: cancel}, nil
+}
+
+// grpcWatchConn is a Watch call of the event stream
+type grpcWatchConn struct {
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+func (w *grpcWatchConn) Next() (Event, error) {
+	var event Event
+	if err := w.stream.RecvMsg(&event); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+func (w *grpcWatchConn) Close() error {
+	w.cancel()
+	return nil
+}
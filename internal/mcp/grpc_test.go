@@ -0,0 +1,244 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer is an in-process agent mail server exposing GRPCService
+type grpcServer struct {
+	mu       sync.Mutex
+	messages []Message
+	statuses []AgentStatus
+	sent     []Message
+	released []string
+	tokens   []string
+	watches  []grpcWatch
+}
+
+// start serves GRPCService on a local port and returns its URL
+func (s *grpcServer) start(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	unary := func(name string, handle func(ctx context.Context, dec func(any) error) (any, error)) grpc.MethodDesc {
+		return grpc.MethodDesc{MethodName: name, Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			s.mu.Lock()
+			s.tokens = append(s.tokens, md.Get("authorization")...)
+			s.mu.Unlock()
+			return handle(ctx, dec)
+		}}
+	}
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: GRPCService,
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{
+			unary("GetMessages", func(ctx context.Context, dec func(any) error) (any, error) {
+				var query grpcQuery
+				if err := dec(&query); err != nil {
+					return nil, err
+				}
+				// One message a page
+				start := len(query.Cursor)
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				if start >= len(s.messages) {
+					return MessagePage{}, nil
+				}
+				return MessagePage{Messages: s.messages[start : start+1], NextCursor: query.Cursor + "x", More: start+1 < len(s.messages)}, nil
+			}),
+			unary("SendMessage", func(ctx context.Context, dec func(any) error) (any, error) {
+				var msg Message
+				if err := dec(&msg); err != nil {
+					return nil, err
+				}
+				s.mu.Lock()
+				s.sent = append(s.sent, msg)
+				s.mu.Unlock()
+				return struct{}{}, nil
+			}),
+			unary("GetAgentStatus", func(ctx context.Context, dec func(any) error) (any, error) {
+				var agent grpcAgent
+				if err := dec(&agent); err != nil {
+					return nil, err
+				}
+				for _, status := range s.statuses {
+					if status.Name == agent.Name {
+						return status, nil
+					}
+				}
+				return nil, status.Errorf(codes.NotFound, "no agent %s", agent.Name)
+			}),
+			unary("ListAgentStatuses", func(ctx context.Context, dec func(any) error) (any, error) {
+				return grpcStatuses{Statuses: s.statuses}, nil
+			}),
+			unary("ReleaseAgentLeases", func(ctx context.Context, dec func(any) error) (any, error) {
+				var agent grpcAgent
+				if err := dec(&agent); err != nil {
+					return nil, err
+				}
+				s.mu.Lock()
+				s.released = append(s.released, agent.Name)
+				s.mu.Unlock()
+				return struct{}{}, nil
+			}),
+		},
+		Streams: []grpc.StreamDesc{{StreamName: "Watch", ServerStreams: true, Handler: func(_ any, stream grpc.ServerStream) error {
+			var watch grpcWatch
+			if err := stream.RecvMsg(&watch); err != nil {
+				return err
+			}
+			s.mu.Lock()
+			s.watches = append(s.watches, watch)
+			first := len(s.watches) == 1
+			s.mu.Unlock()
+			if !first {
+				<-stream.Context().Done()
+				return nil
+			}
+			stream.SendMsg(Event{ID: "7", Type: EventAgentStatus, AgentStatus: &AgentStatus{Name: "planner", State: StateWorking}})
+			stream.SendMsg(Event{ID: "8", Type: EventNewMessage, Message: &Message{Content: "hello"}})
+			return status.Error(codes.Unavailable, "server restarting")
+		}}},
+	}, nil)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return "http://" + listener.Addr().String()
+}
+
+func TestGRPCClient(t *testing.T) {
+	now := time.Now()
+	server := &grpcServer{
+		messages: []Message{{Content: "first"}, {Content: "second"}, {Content: "third"}},
+		statuses: []AgentStatus{{Name: "planner", State: StateWorking, LastSeen: now}, {Name: "tester", State: StateIdle, LastSeen: now.Add(-time.Hour)}},
+	}
+	client, err := NewGRPCClient(server.start(t), Options{Token: "secret", MaxRetries: 1, RetryBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewGRPCClient() error = %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	messages, err := client.GetMessages(ctx, now.Add(-time.Minute))
+	if err != nil || len(messages) != 3 || messages[2].Content != "third" {
+		t.Errorf("GetMessages() = %v, %v; want every page", messages, err)
+	}
+
+	if err := client.SendMessage(ctx, Message{Type: TypeMessage, Source: "planner", Content: "hi"}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if len(server.sent) != 1 || server.sent[0].Content != "hi" {
+		t.Errorf("Server received %v, want the message", server.sent)
+	}
+
+	statuses, err := client.GetAllAgentStatuses(ctx, time.Minute)
+	if err != nil || len(statuses) != 2 || statuses[0].State != StateWorking || statuses[1].State != StateOffline {
+		t.Errorf("GetAllAgentStatuses() = %+v, %v; want tester offline", statuses, err)
+	}
+
+	if err := client.ReleaseAgentLeases(ctx, "tester"); err != nil || len(server.released) != 1 {
+		t.Errorf("ReleaseAgentLeases() error = %v, released %v", err, server.released)
+	}
+
+	// A rejected call is not retried and leaves the circuit closed
+	_, err = client.GetAgentStatus(ctx, "nobody")
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != codes.NotFound {
+		t.Errorf("GetAgentStatus(nobody) error = %v, want NotFound", err)
+	}
+	if circuit := client.Circuit(); circuit.State != CircuitClosed || circuit.Failures != 0 {
+		t.Errorf("Circuit() = %+v, want closed", circuit)
+	}
+
+	for _, token := range server.tokens {
+		if token != "Bearer secret" {
+			t.Fatalf("Server received authorization %q, want the token", token)
+		}
+	}
+	if len(server.tokens) == 0 {
+		t.Error("Expected the token to be sent")
+	}
+}
+
+func TestGRPCClientQueuesWhileUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	outbox := NewOutbox(filepath.Join(t.TempDir(), "outbox.json"), 10)
+	client, err := NewGRPCClient("http://"+addr, Options{MaxRetries: -1, Outbox: outbox})
+	if err != nil {
+		t.Fatalf("NewGRPCClient() error = %v", err)
+	}
+	defer client.Close()
+
+	err = client.SendMessage(context.Background(), Message{Content: "later"})
+	if !errors.Is(err, ErrQueued) {
+		t.Errorf("SendMessage() error = %v, want it queued", err)
+	}
+	if n, _ := outbox.Len(); n != 1 {
+		t.Errorf("Outbox holds %d messages, want 1", n)
+	}
+}
+
+func TestGRPCClientWatch(t *testing.T) {
+	server := &grpcServer{}
+	client, err := NewGRPCClient(server.start(t), Options{})
+	if err != nil {
+		t.Fatalf("NewGRPCClient() error = %v", err)
+	}
+	defer client.Close()
+
+	stream := client.Watch("")
+	stream.(*eventStream).reconnectDelay = 10 * time.Millisecond
+	defer stream.Close()
+	if err := stream.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	var types []EventType
+	for len(types) < 5 {
+		select {
+		case event := <-stream.Events():
+			types = append(types, event.Type)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timeout waiting for events, got %v", types)
+		}
+	}
+	want := []EventType{EventConnected, EventAgentStatus, EventNewMessage, EventDegraded, EventConnected}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("Events = %v, want %v", types, want)
+		}
+	}
+
+	// The server records the second watch once the stream's request arrives
+	deadline := time.Now().Add(5 * time.Second)
+	server.mu.Lock()
+	for len(server.watches) < 2 && time.Now().Before(deadline) {
+		server.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		server.mu.Lock()
+	}
+	defer server.mu.Unlock()
+	if len(server.watches) != 2 || server.watches[1].LastEventID != "8" || len(server.watches[1].Events) != 2 {
+		t.Errorf("Server received watches %+v, want the second to resume after 8", server.watches)
+	}
+}
@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// History keeps messages that no longer fit in memory in a JSON Lines
+// file, so a long session's message log can be read back without holding
+// all of it. Messages are numbered in the order they were spilled, from 0
+// for the oldest. Only each message's file offset stays in memory.
+//
+// Example usage:
+//
+//	history, err := mcp.NewHistory(path)
+//	if err != nil {
+//	    return err
+//	}
+//	defer history.Close()
+//	history.Spill(oldest)
+//	older, err := history.Load(history.Len()-50, history.Len())
+type History struct {
+	mu      sync.Mutex
+	file    *os.File
+	offsets []int64 // Offset of each message in the file
+	size    int64   // Offset the next message is written at
+}
+
+// NewHistory creates the history file at path, replacing one left by an
+// earlier session. The file is private to the user, since messages can
+// contain anything agents sent.
+func NewHistory(path string) (*History, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message history: %w", err)
+	}
+	return &History{file: file}, nil
+}
+
+// Spill appends messages to the file, oldest first
+func (h *History) Spill(messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var buf []byte
+	offsets := make([]int64, 0, len(messages))
+	for _, msg := range messages {
+		line, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to encode message: %w", err)
+		}
+		offsets = append(offsets, h.size+int64(len(buf)))
+		buf = append(append(buf, line...), '\n')
+	}
+	if _, err := h.file.WriteAt(buf, h.size); err != nil {
+		return fmt.Errorf("failed to write message history: %w", err)
+	}
+	h.offsets = append(h.offsets, offsets...)
+	h.size += int64(len(buf))
+	return nil
+}
+
+// Len returns the number of messages in the file
+func (h *History) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.offsets)
+}
+
+// Load reads the messages numbered start up to, but not including, end.
+// The range is clamped to the messages in the file.
+func (h *History) Load(start, end int) ([]Message, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if start < 0 {
+		start = 0
+	}
+	if end > len(h.offsets) {
+		end = len(h.offsets)
+	}
+	if start >= end {
+		return nil, nil
+	}
+
+	limit := h.size
+	if end < len(h.offsets) {
+		limit = h.offsets[end]
+	}
+	section := io.NewSectionReader(h.file, h.offsets[start], limit-h.offsets[start])
+	scanner := bufio.NewScanner(section)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	messages := make([]Message, 0, end-start)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return nil, fmt.Errorf("failed to read message history: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read message history: %w", err)
+	}
+	return messages, nil
+}
+
+// Close closes the file. It is kept on disk until the next session.
+func (h *History) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.file.Close()
+}
@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistorySpillAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "messages.jsonl")
+	history, err := NewHistory(path)
+	if err != nil {
+		t.Fatalf("NewHistory() error = %v", err)
+	}
+	defer history.Close()
+
+	now := time.Now()
+	var batch []Message
+	for i := 0; i < 10; i++ {
+		batch = append(batch, Message{Timestamp: now, Type: TypeMessage, Source: "planner", Content: fmt.Sprintf("message %d", i)})
+	}
+	if err := history.Spill(batch[:4]); err != nil {
+		t.Fatalf("Spill() error = %v", err)
+	}
+	if err := history.Spill(batch[4:]); err != nil {
+		t.Fatalf("Spill() error = %v", err)
+	}
+	if history.Len() != 10 {
+		t.Fatalf("Len() = %d, want 10", history.Len())
+	}
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       []string
+	}{
+		{"middle", 3, 6, []string{"message 3", "message 4", "message 5"}},
+		{"last", 9, 10, []string{"message 9"}},
+		{"clamped", -5, 2, []string{"message 0", "message 1"}},
+		{"past the end", 8, 20, []string{"message 8", "message 9"}},
+		{"empty", 5, 5, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := history.Load(tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Load() returned %d messages, want %d", len(got), len(tt.want))
+			}
+			for i, msg := range got {
+				if msg.Content != tt.want[i] {
+					t.Errorf("Message %d = %q, want %q", i, msg.Content, tt.want[i])
+				}
+			}
+		})
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a private history file, got %v, %v", info.Mode().Perm(), err)
+	}
+}
+
+func TestNewHistoryReplacesEarlierSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.jsonl")
+	first, err := NewHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Spill([]Message{{Content: "old session"}})
+	first.Close()
+
+	second, err := NewHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	if second.Len() != 0 {
+		t.Errorf("Len() = %d, want an empty history", second.Len())
+	}
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("Expected the earlier session's messages to be removed, got %q", data)
+	}
+}
@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// ErrQueued is wrapped by the errors of messages that were not sent
+// because the MCP server is unavailable, and were queued in the outbox
+var ErrQueued = errors.New("MCP server unavailable, message queued")
+
+// hintQueued is attached to the errors of queued messages
+const hintQueued = "asc sends queued messages, in order, once mcp_agent_mail answers again. Start it with 'asc services start' if it is not running"
+
+// Outbox keeps the messages an HTTPClient could not send while the MCP
+// server was unavailable in a file, so they survive restarts of asc and
+// are sent in order once the server is back. asc processes sharing
+// ~/.asc share the outbox; a lock next to the file serializes them.
+// Delivery is at least once: a message whose send timed out after the
+// server received it is sent again.
+//
+// Example usage:
+//
+//	path, _ := mcp.DefaultOutboxPath()
+//	opts := mcp.DefaultOptions()
+//	opts.Outbox = mcp.NewOutbox(path, 1000)
+//	client := mcp.NewHTTPClientWithOptions(url, opts)
+//	err := client.SendMessage(ctx, msg) // errors.Is(err, mcp.ErrQueued) while the server is down
+type Outbox struct {
+	path  string
+	limit int // Most messages kept; more fail to queue
+}
+
+// outboxState is the file of an Outbox
+type outboxState struct {
+	Messages []Message `json:"messages"`
+}
+
+// OutboxFlusher is implemented by MCP clients with an outbox, so the TUI
+// can send the queued messages once the server answers again
+type OutboxFlusher interface {
+	FlushOutbox(ctx context.Context) (int, error)
+}
+
+// DefaultOutboxPath returns where the outbox is kept, ~/.asc/mcp/outbox.json
+func DefaultOutboxPath() (string, error) {
+	return statedir.Path("mcp", "outbox.json")
+}
+
+// ServerOutboxPath returns where the outbox of another MCP server than the
+// default is kept, ~/.asc/mcp/<name>/outbox.json
+func ServerOutboxPath(name string) (string, error) {
+	return statedir.Path("mcp", name, "outbox.json")
+}
+
+// NewOutbox creates an outbox kept at path holding at most limit messages
+func NewOutbox(path string, limit int) *Outbox {
+	return &Outbox{path: path, limit: limit}
+}
+
+// lock takes the outbox's lock, held across reading and rewriting it
+func (o *Outbox) lock() (func(), error) {
+	return statefile.Lock(o.path + ".lock")
+}
+
+// read returns the queued messages, oldest first
+func (o *Outbox) read() ([]Message, error) {
+	var state outboxState
+	if err := statefile.ReadJSON(o.path, &state); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		if errors.Is(err, statefile.ErrCorrupt) {
+			mcpLog.Warn("Discarded a corrupt MCP outbox: %v", err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read MCP outbox: %w", err)
+	}
+	return state.Messages, nil
+}
+
+// write replaces the queued messages
+func (o *Outbox) write(messages []Message) error {
+	if err := statefile.WriteJSON(o.path, outboxState{Messages: messages}, 0600); err != nil {
+		return fmt.Errorf("failed to write MCP outbox: %w", err)
+	}
+	return nil
+}
+
+// Enqueue adds msg after the queued messages. It fails when the outbox
+// is full, rather than dropping an older message.
+func (o *Outbox) Enqueue(msg Message) error {
+	unlock, err := o.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	messages, err := o.read()
+	if err != nil {
+		return err
+	}
+	if len(messages) >= o.limit {
+		return fmt.Errorf("MCP outbox is full with %d messages", len(messages))
+	}
+	return o.write(append(messages, msg))
+}
+
+// Len returns the number of queued messages
+func (o *Outbox) Len() (int, error) {
+	messages, err := o.read()
+	return len(messages), err
+}
+
+// Flush sends the queued messages with send, oldest first, stopping at
+// the first that fails, and returns how many were sent. The messages not
+// sent stay queued.
+func (o *Outbox) Flush(ctx context.Context, send func(context.Context, Message) error) (int, error) {
+	unlock, err := o.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	messages, err := o.read()
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+	sent := 0
+	var sendErr error
+	for _, msg := range messages {
+		if sendErr = send(ctx, msg); sendErr != nil {
+			break
+		}
+		sent++
+	}
+	if sent > 0 {
+		if err := o.write(messages[sent:]); err != nil {
+			return sent, err
+		}
+		mcpLog.WithFields(logger.Fields{"sent": sent, "queued": len(messages) - sent}).Info("Sent queued MCP messages")
+	}
+	return sent, sendErr
+}
+
+// unavailable reports whether err is a failure of the server, rather
+// than a rejection of the request or the caller giving up, so a message
+// is worth queueing until the server is back
+func unavailable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var httpErr *HTTPError
+	var rpcErr *RPCError
+	var timeoutErr *TimeoutError
+	var netErr *net.OpError
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return true
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode >= 500
+	case errors.As(err, &rpcErr):
+		return rpcErr.serverFailure()
+	case isCertificateError(err):
+		return false
+	}
+	return errors.As(err, &timeoutErr) || errors.As(err, &netErr)
+}
+
+// queued returns the error of a message queued after failing with err
+func queued(err error) error {
+	return ascerrors.WithHint(fmt.Errorf("%w: %v", ErrQueued, err), hintQueued)
+}
@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+)
+
+func TestOutbox(t *testing.T) {
+	outbox := NewOutbox(filepath.Join(t.TempDir(), "outbox.json"), 3)
+	for _, content := range []string{"one", "two", "three"} {
+		if err := outbox.Enqueue(Message{Content: content}); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", content, err)
+		}
+	}
+	if err := outbox.Enqueue(Message{Content: "four"}); err == nil {
+		t.Error("Expected a full outbox to refuse a message")
+	}
+
+	// Flush stops at the first failure and keeps the rest queued
+	var sent []string
+	failure := errors.New("server down")
+	n, err := outbox.Flush(context.Background(), func(ctx context.Context, msg Message) error {
+		if msg.Content == "two" {
+			return failure
+		}
+		sent = append(sent, msg.Content)
+		return nil
+	})
+	if n != 1 || !errors.Is(err, failure) || len(sent) != 1 || sent[0] != "one" {
+		t.Errorf("Flush() = %d, %v, sent %v; want one sent before the failure", n, err, sent)
+	}
+	if n, _ := outbox.Len(); n != 2 {
+		t.Errorf("Len() = %d, want 2 still queued", n)
+	}
+
+	sent = nil
+	n, err = outbox.Flush(context.Background(), func(ctx context.Context, msg Message) error {
+		sent = append(sent, msg.Content)
+		return nil
+	})
+	if n != 2 || err != nil || len(sent) != 2 || sent[0] != "two" || sent[1] != "three" {
+		t.Errorf("Flush() = %d, %v, sent %v; want two and three in order", n, err, sent)
+	}
+	if n, _ := outbox.Len(); n != 0 {
+		t.Errorf("Len() = %d, want an empty outbox", n)
+	}
+}
+
+func TestSendMessageQueuesWhileUnavailable(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	var status int32 = http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code := int(atomic.LoadInt32(&status)); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		var msg Message
+		json.NewDecoder(r.Body).Decode(&msg)
+		if msg.Content == "rejected" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, msg.Content)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	outbox := NewOutbox(filepath.Join(t.TempDir(), "outbox.json"), 10)
+	client := NewHTTPClientWithOptions(server.URL, Options{MaxRetries: 0, Outbox: outbox})
+	ctx := context.Background()
+
+	for _, content := range []string{"first", "rejected", "second"} {
+		err := client.SendMessage(ctx, Message{Type: TypeMessage, Content: content})
+		if !errors.Is(err, ErrQueued) || ascerrors.Hint(err) != hintQueued {
+			t.Fatalf("SendMessage(%s) error = %v, want it queued", content, err)
+		}
+	}
+	if n, _ := outbox.Len(); n != 3 {
+		t.Fatalf("Len() = %d, want 3 queued", n)
+	}
+
+	// Once the server is back, the queued messages go first, and the one
+	// it rejects is dropped
+	atomic.StoreInt32(&status, http.StatusOK)
+	if err := client.SendMessage(ctx, Message{Type: TypeMessage, Content: "third"}); err != nil {
+		t.Fatalf("SendMessage(third) error = %v", err)
+	}
+	mu.Lock()
+	got := append([]string(nil), received...)
+	mu.Unlock()
+	if len(got) != 3 || got[0] != "first" || got[1] != "second" || got[2] != "third" {
+		t.Errorf("Server received %v, want first, second, third", got)
+	}
+	if n, _ := outbox.Len(); n != 0 {
+		t.Errorf("Len() = %d, want an empty outbox", n)
+	}
+
+	// Rejections and interrupted sends are not queued
+	if err := client.SendMessage(ctx, Message{Content: "rejected"}); err == nil || errors.Is(err, ErrQueued) {
+		t.Errorf("Expected a rejected message to fail without queueing, got %v", err)
+	}
+	atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := client.SendMessage(cancelled, Message{Content: "late"}); errors.Is(err, ErrQueued) {
+		t.Errorf("Expected an interrupted send not to be queued, got %v", err)
+	}
+	if n, _ := outbox.Len(); n != 0 {
+		t.Errorf("Len() = %d, want nothing queued", n)
+	}
+}
+
+func TestFlushOutbox(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	outbox := NewOutbox(filepath.Join(t.TempDir(), "outbox.json"), 10)
+	outbox.Enqueue(Message{Content: "queued", Timestamp: time.Now()})
+	var client MCPClient = NewHTTPClientWithOptions(server.URL, Options{Outbox: outbox})
+	flusher, ok := client.(OutboxFlusher)
+	if !ok {
+		t.Fatal("Expected HTTPClient to implement OutboxFlusher")
+	}
+	if n, err := flusher.FlushOutbox(context.Background()); n != 1 || err != nil {
+		t.Errorf("FlushOutbox() = %d, %v, want 1 sent", n, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected 1 request, got %d", n)
+	}
+
+	if n, err := NewHTTPClient(server.URL).FlushOutbox(context.Background()); n != 0 || err != nil {
+		t.Errorf("FlushOutbox() without an outbox = %d, %v, want nothing", n, err)
+	}
+}
@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// MessageQuery selects the messages QueryMessages returns. Zero fields
+// select every message.
+type MessageQuery struct {
+	Sources []string      // Agents or services that sent the messages
+	Types   []MessageType // Types of the messages
+	Since   time.Time     // Messages sent at or after this time
+	Until   time.Time     // Messages sent before this time
+
+	// Cursor resumes after the last message of an earlier page, from its
+	// NextCursor; "" starts at Since
+	Cursor string
+
+	// Limit is the most messages in a page; 0 for the server's default
+	Limit int
+}
+
+// MessagePage is one page of the messages matching a MessageQuery, oldest
+// first
+type MessagePage struct {
+	Messages []Message `json:"messages"`
+
+	// NextCursor is passed as MessageQuery.Cursor to get the messages after
+	// this page, including those sent later. It is "" from servers that
+	// do not page messages.
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// More reports whether matching messages beyond this page are
+	// already waiting
+	More bool `json:"more,omitempty"`
+}
+
+// MessageQuerier is implemented by MCP clients that filter and page
+// messages on the server, so the TUI can fetch only the new messages
+// rather than everything since its last refresh
+type MessageQuerier interface {
+	QueryMessages(ctx context.Context, query MessageQuery) (MessagePage, error)
+}
+
+// Matches reports whether msg is selected by the query's sources, types,
+// and time window
+func (q MessageQuery) Matches(msg Message) bool {
+	if !q.Since.IsZero() && msg.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !msg.Timestamp.Before(q.Until) {
+		return false
+	}
+	if len(q.Sources) > 0 && !containsString(q.Sources, msg.Source) {
+		return false
+	}
+	if len(q.Types) > 0 {
+		found := false
+		for _, t := range q.Types {
+			if t == msg.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// values encodes the query as the parameters of GET /messages
+func (q MessageQuery) values() url.Values {
+	values := url.Values{}
+	if !q.Since.IsZero() {
+		values.Set("since", strconv.FormatInt(q.Since.Unix(), 10))
+	}
+	if !q.Until.IsZero() {
+		values.Set("until", strconv.FormatInt(q.Until.Unix(), 10))
+	}
+	for _, source := range q.Sources {
+		values.Add("source", source)
+	}
+	for _, t := range q.Types {
+		values.Add("type", string(t))
+	}
+	if q.Cursor != "" {
+		values.Set("cursor", q.Cursor)
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return values
+}
+
+// QueryMessages retrieves a page of the messages matching query, filtered
+// by the server. Servers that do not filter or page messages answer with
+// every message since query.Since; those are filtered here and returned
+// as a single page without a cursor. Retries on network errors.
+func (c *HTTPClient) QueryMessages(ctx context.Context, query MessageQuery) (MessagePage, error) {
+	url := c.baseURL + "/messages"
+	if params := query.values().Encode(); params != "" {
+		url += "?" + params
+	}
+
+	mcpLog.WithFields(logger.Fields{
+		"url":    url,
+		"cursor": query.Cursor,
+	}).Debug("Querying messages from MCP server")
+
+	var raw json.RawMessage
+	if err := c.doRequestWithRetry(ctx, "GET", url, nil, &raw); err != nil {
+		mcpLog.WithFields(logger.Fields{
+			"url": url,
+		}).Error("Failed to query messages from MCP: %v", err)
+		return MessagePage{}, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	var page MessagePage
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		var messages []Message
+		if err := json.Unmarshal(trimmed, &messages); err != nil {
+			return MessagePage{}, fmt.Errorf("failed to get messages: failed to decode response: %w", err)
+		}
+		for _, msg := range messages {
+			if query.Matches(msg) {
+				page.Messages = append(page.Messages, msg)
+			}
+		}
+	} else if len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+		if err := json.Unmarshal(trimmed, &page); err != nil {
+			return MessagePage{}, fmt.Errorf("failed to get messages: failed to decode response: %w", err)
+		}
+	}
+
+	mcpLog.WithFields(logger.Fields{
+		"message_count": len(page.Messages),
+		"more":          page.More,
+	}).Debug("Successfully queried messages from MCP")
+
+	return page, nil
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
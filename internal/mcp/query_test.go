@@ -0,0 +1,112 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueryMessages(t *testing.T) {
+	since := time.Unix(1700000000, 0)
+	var got [][2]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		got = append(got, [2]string{q.Encode(), q.Get("cursor")})
+		page := MessagePage{Messages: []Message{{Source: "agent-1", Type: TypeLease, Content: "first"}}, NextCursor: "c1", More: true}
+		if q.Get("cursor") == "c1" {
+			page = MessagePage{Messages: []Message{{Source: "agent-1", Type: TypeLease, Content: "second"}}, NextCursor: "c2"}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL)
+	query := MessageQuery{
+		Sources: []string{"agent-1", "agent-2"},
+		Types:   []MessageType{TypeLease},
+		Since:   since,
+		Until:   since.Add(time.Hour),
+		Limit:   1,
+	}
+	page, err := client.QueryMessages(context.Background(), query)
+	if err != nil {
+		t.Fatalf("QueryMessages() error = %v", err)
+	}
+	if len(page.Messages) != 1 || page.Messages[0].Content != "first" || page.NextCursor != "c1" || !page.More {
+		t.Errorf("First page = %+v, want first with more after c1", page)
+	}
+
+	query.Cursor = page.NextCursor
+	page, err = client.QueryMessages(context.Background(), query)
+	if err != nil {
+		t.Fatalf("QueryMessages() error = %v", err)
+	}
+	if len(page.Messages) != 1 || page.Messages[0].Content != "second" || page.NextCursor != "c2" || page.More {
+		t.Errorf("Second page = %+v, want the last page, second", page)
+	}
+
+	want := "limit=1&since=1700000000&source=agent-1&source=agent-2&type=lease&until=1700003600"
+	if len(got) != 2 || got[0][0] != want || got[1][1] != "c1" {
+		t.Errorf("Server received %v, want %s then cursor c1", got, want)
+	}
+}
+
+func TestQueryMessagesFiltersUnpagedServer(t *testing.T) {
+	now := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// An older server ignores everything but since
+		json.NewEncoder(w).Encode([]Message{
+			{Timestamp: now, Source: "agent-1", Type: TypeLease, Content: "match"},
+			{Timestamp: now, Source: "agent-2", Type: TypeLease, Content: "other agent"},
+			{Timestamp: now, Source: "agent-1", Type: TypeError, Content: "other type"},
+			{Timestamp: now.Add(time.Hour), Source: "agent-1", Type: TypeLease, Content: "too late"},
+		})
+	}))
+	defer server.Close()
+
+	page, err := NewHTTPClient(server.URL).QueryMessages(context.Background(), MessageQuery{
+		Sources: []string{"agent-1"},
+		Types:   []MessageType{TypeLease},
+		Until:   now.Add(time.Minute),
+		Limit:   10,
+	})
+	if err != nil {
+		t.Fatalf("QueryMessages() error = %v", err)
+	}
+	var contents []string
+	for _, msg := range page.Messages {
+		contents = append(contents, msg.Content)
+	}
+	if !reflect.DeepEqual(contents, []string{"match"}) || page.NextCursor != "" || page.More {
+		t.Errorf("QueryMessages() = %v, cursor %q, more %v; want only match, without a cursor", contents, page.NextCursor, page.More)
+	}
+}
+
+func TestMessageQueryMatches(t *testing.T) {
+	now := time.Now()
+	msg := Message{Timestamp: now, Source: "agent-1", Type: TypeBeads}
+	tests := []struct {
+		name  string
+		query MessageQuery
+		want  bool
+	}{
+		{"empty query", MessageQuery{}, true},
+		{"matching source and type", MessageQuery{Sources: []string{"agent-2", "agent-1"}, Types: []MessageType{TypeBeads}}, true},
+		{"other source", MessageQuery{Sources: []string{"agent-2"}}, false},
+		{"other type", MessageQuery{Types: []MessageType{TypeError}}, false},
+		{"since is inclusive", MessageQuery{Since: now}, true},
+		{"until is exclusive", MessageQuery{Until: now}, false},
+		{"inside the window", MessageQuery{Since: now.Add(-time.Second), Until: now.Add(time.Second)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.Matches(msg); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Server is one of the MCP servers a Router spreads agents across
+type Server struct {
+	Name   string
+	Client MCPClient
+}
+
+// Router is an MCPClient over several MCP servers, for deployments that
+// shard agents' mailboxes across them. Requests about an agent, and the
+// messages it sends, go to the server it is routed to, or the first
+// server; listings are gathered from every server and merged. With one
+// server it passes every call through.
+//
+// Example usage:
+//
+//	router := mcp.NewRouter([]mcp.Server{
+//	    {Name: "mcp_agent_mail", Client: mcp.NewHTTPClient("http://localhost:8765")},
+//	    {Name: "mcp_shard_b", Client: mcp.NewHTTPClient("http://localhost:8766")},
+//	}, map[string]string{"tester": "mcp_shard_b"})
+//	statuses, err := router.GetAllAgentStatuses(ctx, 30*time.Second)
+type Router struct {
+	servers []Server
+	routes  map[string]string // Server of each agent routed away from the first
+}
+
+// NewRouter creates a router over servers, the first of which serves the
+// agents routes does not name a server for
+func NewRouter(servers []Server, routes map[string]string) *Router {
+	return &Router{servers: servers, routes: routes}
+}
+
+// Servers returns the servers of the router, the default first
+func (r *Router) Servers() []Server {
+	return r.servers
+}
+
+// ServerFor returns the server an agent's mailbox is on
+func (r *Router) ServerFor(agentName string) Server {
+	if name, ok := r.routes[agentName]; ok {
+		for _, server := range r.servers {
+			if server.Name == name {
+				return server
+			}
+		}
+	}
+	return r.servers[0]
+}
+
+// wrap names the server that failed, once there is more than one
+func (r *Router) wrap(server Server, err error) error {
+	if err == nil || len(r.servers) == 1 {
+		return err
+	}
+	return fmt.Errorf("%s: %w", server.Name, err)
+}
+
+// each calls fn on every server concurrently and returns the failures,
+// each naming its server
+func (r *Router) each(fn func(i int, server Server) error) error {
+	errs := make([]error, len(r.servers))
+	var wg sync.WaitGroup
+	for i, server := range r.servers {
+		wg.Add(1)
+		go func(i int, server Server) {
+			defer wg.Done()
+			errs[i] = r.wrap(server, fn(i, server))
+		}(i, server)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// sortMessages orders messages from several servers by when they were
+// sent, keeping each server's order for messages sent at the same time
+func sortMessages(messages []Message) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+}
+
+// GetMessages retrieves the messages of every server since the given
+// timestamp, oldest first. The messages of the servers that answered are
+// returned with the failures of the others.
+func (r *Router) GetMessages(ctx context.Context, since time.Time) ([]Message, error) {
+	if len(r.servers) == 1 {
+		return r.servers[0].Client.GetMessages(ctx, since)
+	}
+	results := make([][]Message, len(r.servers))
+	err := r.each(func(i int, server Server) error {
+		var err error
+		results[i], err = server.Client.GetMessages(ctx, since)
+		return err
+	})
+	var messages []Message
+	for _, result := range results {
+		messages = append(messages, result...)
+	}
+	sortMessages(messages)
+	return messages, err
+}
+
+// SendMessage sends a message to the server of the agent that sent it
+func (r *Router) SendMessage(ctx context.Context, msg Message) error {
+	server := r.ServerFor(msg.Source)
+	return r.wrap(server, server.Client.SendMessage(ctx, msg))
+}
+
+// GetAgentStatus retrieves the status of an agent from its server
+func (r *Router) GetAgentStatus(ctx context.Context, agentName string) (AgentStatus, error) {
+	server := r.ServerFor(agentName)
+	status, err := server.Client.GetAgentStatus(ctx, agentName)
+	return status, r.wrap(server, err)
+}
+
+// GetAllAgentStatuses retrieves the status of every agent from every
+// server. An agent reported by several servers takes its status from the
+// server it is routed to. The statuses of the servers that answered are
+// returned with the failures of the others.
+func (r *Router) GetAllAgentStatuses(ctx context.Context, offlineThreshold time.Duration) ([]AgentStatus, error) {
+	if len(r.servers) == 1 {
+		return r.servers[0].Client.GetAllAgentStatuses(ctx, offlineThreshold)
+	}
+	results := make([][]AgentStatus, len(r.servers))
+	err := r.each(func(i int, server Server) error {
+		var err error
+		results[i], err = server.Client.GetAllAgentStatuses(ctx, offlineThreshold)
+		return err
+	})
+
+	var statuses []AgentStatus
+	index := make(map[string]int)
+	for i, result := range results {
+		for _, status := range result {
+			j, seen := index[status.Name]
+			switch {
+			case !seen:
+				index[status.Name] = len(statuses)
+				statuses = append(statuses, status)
+			case r.ServerFor(status.Name).Name == r.servers[i].Name:
+				statuses[j] = status
+			}
+		}
+	}
+	return statuses, err
+}
+
+// ReleaseAgentLeases releases the file leases an agent holds on its server
+func (r *Router) ReleaseAgentLeases(ctx context.Context, agentName string) error {
+	server := r.ServerFor(agentName)
+	return r.wrap(server, server.Client.ReleaseAgentLeases(ctx, agentName))
+}
+
+// QueryMessages retrieves a page of the messages matching query from
+// every server, oldest first. Each server returns up to query.Limit
+// messages, so a page holds up to that many per server. The cursor
+// combines the positions of the servers: the cursor of those that page
+// messages, and for the others when they were last asked, so the next
+// query asks them only for the messages since. The messages of the
+// servers that answered are returned with the failures of the others,
+// whose position the cursor keeps.
+func (r *Router) QueryMessages(ctx context.Context, query MessageQuery) (MessagePage, error) {
+	if querier, ok := r.servers[0].Client.(MessageQuerier); ok && len(r.servers) == 1 {
+		return querier.QueryMessages(ctx, query)
+	}
+	cursors, err := url.ParseQuery(query.Cursor)
+	if err != nil {
+		return MessagePage{}, fmt.Errorf("invalid message cursor %q: %w", query.Cursor, err)
+	}
+
+	pages := make([]MessagePage, len(r.servers))
+	positions := make([]url.Values, len(r.servers))
+	err = r.each(func(i int, server Server) error {
+		cursor, since := cursors.Get(server.Name), cursors.Get(server.Name+sinceSuffix)
+		serverQuery := query
+		serverQuery.Cursor = cursor
+		if t, err := time.Parse(time.RFC3339Nano, since); err == nil {
+			serverQuery.Since = t
+		}
+
+		asked := time.Now()
+		page, err := queryServer(ctx, server.Client, serverQuery)
+		positions[i] = url.Values{}
+		switch {
+		case err != nil && cursor != "":
+			positions[i].Set(server.Name, cursor)
+		case err != nil && since != "":
+			positions[i].Set(server.Name+sinceSuffix, since)
+		case err != nil && !query.Since.IsZero():
+			positions[i].Set(server.Name+sinceSuffix, query.Since.Format(time.RFC3339Nano))
+		case page.NextCursor != "":
+			positions[i].Set(server.Name, page.NextCursor)
+		default:
+			positions[i].Set(server.Name+sinceSuffix, asked.Format(time.RFC3339Nano))
+		}
+		pages[i] = page
+		return err
+	})
+
+	var result MessagePage
+	next := url.Values{}
+	for i, page := range pages {
+		result.Messages = append(result.Messages, page.Messages...)
+		result.More = result.More || page.More
+		for key, values := range positions[i] {
+			next[key] = values
+		}
+	}
+	sortMessages(result.Messages)
+	result.NextCursor = next.Encode()
+	return result, err
+}
+
+// sinceSuffix marks the position in a Router's cursor of a server that
+// does not page messages: when it was last asked for them
+const sinceSuffix = ".since"
+
+// queryServer retrieves a page of messages from a server, filtering
+// those of a server without a MessageQuerier here
+func queryServer(ctx context.Context, client MCPClient, query MessageQuery) (MessagePage, error) {
+	if querier, ok := client.(MessageQuerier); ok {
+		return querier.QueryMessages(ctx, query)
+	}
+	messages, err := client.GetMessages(ctx, query.Since)
+	if err != nil {
+		return MessagePage{}, err
+	}
+	var page MessagePage
+	for _, msg := range messages {
+		if query.Matches(msg) {
+			page.Messages = append(page.Messages, msg)
+		}
+	}
+	return page, nil
+}
+
+// Circuit returns the state of the most degraded server's circuit
+// breaker, naming the server in its error
+func (r *Router) Circuit() CircuitStatus {
+	worst := CircuitStatus{State: CircuitClosed}
+	rank := map[string]int{CircuitClosed: 0, CircuitHalfOpen: 1, CircuitOpen: 2}
+	for _, server := range r.servers {
+		reporter, ok := server.Client.(CircuitReporter)
+		if !ok {
+			continue
+		}
+		status := reporter.Circuit()
+		if rank[status.State] > rank[worst.State] || (status.State == worst.State && status.Failures > worst.Failures) {
+			status.LastErr = r.wrap(server, status.LastErr)
+			worst = status
+		}
+	}
+	return worst
+}
+
+// FlushOutbox sends the messages queued for every server with an outbox,
+// and returns how many were sent
+func (r *Router) FlushOutbox(ctx context.Context) (int, error) {
+	sent := make([]int, len(r.servers))
+	err := r.each(func(i int, server Server) error {
+		flusher, ok := server.Client.(OutboxFlusher)
+		if !ok {
+			return nil
+		}
+		var err error
+		sent[i], err = flusher.FlushOutbox(ctx)
+		return err
+	})
+	total := 0
+	for _, n := range sent {
+		total += n
+	}
+	return total, err
+}
@@ -0,0 +1,195 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer is an MCP server of a Router test
+type fakeServer struct {
+	messages []Message
+	statuses []AgentStatus
+	sent     []Message
+	released []string
+	err      error
+}
+
+func (f *fakeServer) GetMessages(ctx context.Context, since time.Time) ([]Message, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var messages []Message
+	for _, msg := range f.messages {
+		if !msg.Timestamp.Before(since) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+func (f *fakeServer) SendMessage(ctx context.Context, msg Message) error {
+	f.sent = append(f.sent, msg)
+	return f.err
+}
+
+func (f *fakeServer) GetAgentStatus(ctx context.Context, agentName string) (AgentStatus, error) {
+	for _, status := range f.statuses {
+		if status.Name == agentName {
+			return status, f.err
+		}
+	}
+	return AgentStatus{Name: agentName, State: StateOffline}, f.err
+}
+
+func (f *fakeServer) GetAllAgentStatuses(ctx context.Context, offlineThreshold time.Duration) ([]AgentStatus, error) {
+	return f.statuses, f.err
+}
+
+func (f *fakeServer) ReleaseAgentLeases(ctx context.Context, agentName string) error {
+	f.released = append(f.released, agentName)
+	return f.err
+}
+
+// pagingServer pages its messages by their index
+type pagingServer struct {
+	fakeServer
+}
+
+func (p *pagingServer) QueryMessages(ctx context.Context, query MessageQuery) (MessagePage, error) {
+	start := 0
+	if query.Cursor != "" {
+		start = len(strings.TrimPrefix(query.Cursor, "#"))
+	}
+	end := len(p.messages)
+	if query.Limit > 0 && start+query.Limit < end {
+		end = start + query.Limit
+	}
+	return MessagePage{Messages: p.messages[start:end], NextCursor: "#" + strings.Repeat("x", end), More: end < len(p.messages)}, nil
+}
+
+func TestRouterRoutesAgents(t *testing.T) {
+	primary, shard := &fakeServer{}, &fakeServer{statuses: []AgentStatus{{Name: "tester", State: StateWorking}}}
+	router := NewRouter([]Server{{"mcp_agent_mail", primary}, {"mcp_shard", shard}}, map[string]string{"tester": "mcp_shard"})
+	ctx := context.Background()
+
+	router.SendMessage(ctx, Message{Source: "tester", Content: "routed"})
+	router.SendMessage(ctx, Message{Source: "planner", Content: "default"})
+	if len(shard.sent) != 1 || shard.sent[0].Content != "routed" || len(primary.sent) != 1 || primary.sent[0].Content != "default" {
+		t.Errorf("Expected each message on its sender's server, got %v and %v", primary.sent, shard.sent)
+	}
+
+	if status, err := router.GetAgentStatus(ctx, "tester"); err != nil || status.State != StateWorking {
+		t.Errorf("GetAgentStatus(tester) = %+v, %v, want it from its server", status, err)
+	}
+	router.ReleaseAgentLeases(ctx, "tester")
+	if len(shard.released) != 1 || len(primary.released) != 0 {
+		t.Errorf("Expected the leases released on the agent's server, got %v and %v", primary.released, shard.released)
+	}
+
+	shard.err = errors.New("connection refused")
+	if err := router.ReleaseAgentLeases(ctx, "tester"); err == nil || !strings.HasPrefix(err.Error(), "mcp_shard: ") {
+		t.Errorf("Expected the failure to name the server, got %v", err)
+	}
+}
+
+func TestRouterAggregates(t *testing.T) {
+	now := time.Now()
+	primary := &fakeServer{
+		messages: []Message{{Timestamp: now, Content: "second"}, {Timestamp: now.Add(2 * time.Second), Content: "fourth"}},
+		statuses: []AgentStatus{{Name: "planner", State: StateIdle}, {Name: "tester", State: StateOffline}},
+	}
+	shard := &fakeServer{
+		messages: []Message{{Timestamp: now.Add(-time.Second), Content: "first"}, {Timestamp: now.Add(time.Second), Content: "third"}},
+		statuses: []AgentStatus{{Name: "tester", State: StateWorking}},
+	}
+	router := NewRouter([]Server{{"mcp_agent_mail", primary}, {"mcp_shard", shard}}, map[string]string{"tester": "mcp_shard"})
+	ctx := context.Background()
+
+	messages, err := router.GetMessages(ctx, now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("GetMessages() error = %v", err)
+	}
+	var contents []string
+	for _, msg := range messages {
+		contents = append(contents, msg.Content)
+	}
+	if !reflect.DeepEqual(contents, []string{"first", "second", "third", "fourth"}) {
+		t.Errorf("GetMessages() = %v, want the messages of both servers in order", contents)
+	}
+
+	statuses, err := router.GetAllAgentStatuses(ctx, time.Minute)
+	if err != nil {
+		t.Fatalf("GetAllAgentStatuses() error = %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].Name != "planner" || statuses[1].State != StateWorking {
+		t.Errorf("GetAllAgentStatuses() = %+v, want tester's status from its server", statuses)
+	}
+
+	// A server that fails leaves the others' data
+	shard.err = errors.New("connection refused")
+	messages, err = router.GetMessages(ctx, now.Add(-time.Minute))
+	if err == nil || !strings.Contains(err.Error(), "mcp_shard") || len(messages) != 2 {
+		t.Errorf("GetMessages() = %d messages, %v; want the primary's with the shard's failure", len(messages), err)
+	}
+}
+
+func TestRouterQueryMessages(t *testing.T) {
+	now := time.Now().Add(-time.Minute)
+	paging := &pagingServer{fakeServer{messages: []Message{
+		{Timestamp: now, Content: "p1"}, {Timestamp: now.Add(2 * time.Second), Content: "p2"}, {Timestamp: now.Add(4 * time.Second), Content: "p3"},
+	}}}
+	plain := &fakeServer{messages: []Message{{Timestamp: now.Add(time.Second), Content: "s1"}}}
+	router := NewRouter([]Server{{"mcp_agent_mail", paging}, {"mcp_shard", plain}}, nil)
+	ctx := context.Background()
+
+	page, err := router.QueryMessages(ctx, MessageQuery{Since: now.Add(-time.Second), Limit: 2})
+	if err != nil {
+		t.Fatalf("QueryMessages() error = %v", err)
+	}
+	var contents []string
+	for _, msg := range page.Messages {
+		contents = append(contents, msg.Content)
+	}
+	if !reflect.DeepEqual(contents, []string{"p1", "s1", "p2"}) || !page.More {
+		t.Errorf("QueryMessages() = %v, more %v; want p1, s1, p2 with more", contents, page.More)
+	}
+	cursor, _ := url.ParseQuery(page.NextCursor)
+	if cursor.Get("mcp_agent_mail") != "#xx" || cursor.Get("mcp_shard.since") == "" {
+		t.Errorf("Expected the cursor to hold each server's position, got %q", page.NextCursor)
+	}
+
+	// The next query resumes each server at its position
+	plain.messages = append(plain.messages, Message{Timestamp: time.Now().Add(time.Second), Content: "s2"})
+	page, err = router.QueryMessages(ctx, MessageQuery{Cursor: page.NextCursor, Limit: 2})
+	if err != nil {
+		t.Fatalf("QueryMessages() error = %v", err)
+	}
+	contents = nil
+	for _, msg := range page.Messages {
+		contents = append(contents, msg.Content)
+	}
+	if !reflect.DeepEqual(contents, []string{"p3", "s2"}) || page.More {
+		t.Errorf("QueryMessages() = %v, more %v; want p3 and s2, the last page", contents, page.More)
+	}
+
+	if _, err := router.QueryMessages(ctx, MessageQuery{Cursor: "%zz"}); err == nil {
+		t.Error("Expected an invalid cursor to fail")
+	}
+}
+
+func TestRouterCircuit(t *testing.T) {
+	closed := NewHTTPClientWithOptions("http://localhost:1", Options{BreakerThreshold: 1})
+	open := NewHTTPClientWithOptions("http://localhost:1", Options{BreakerThreshold: 1})
+	open.breaker.record(context.Background(), errors.New("connection refused"))
+	router := NewRouter([]Server{{"mcp_agent_mail", closed}, {"mcp_shard", open}}, nil)
+
+	status := router.Circuit()
+	if status.State != CircuitOpen || status.LastErr == nil || !strings.HasPrefix(status.LastErr.Error(), "mcp_shard: ") {
+		t.Errorf("Circuit() = %+v, want the shard's open circuit", status)
+	}
+}
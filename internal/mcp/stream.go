@@ -0,0 +1,353 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// StreamState is the state of an event stream
+type StreamState string
+
+const (
+	StreamConnected StreamState = "connected"
+
+	// StreamDegraded is a stream that dropped and is reconnecting; events
+	// are delayed, not lost, if the server resumes the stream
+	StreamDegraded StreamState = "degraded"
+
+	// StreamDisconnected is a stream that never connected, was closed, or
+	// has failed to reconnect for a while; reconnects go on until Close
+	StreamDisconnected StreamState = "disconnected"
+)
+
+// StreamStatus is the state of an event stream, as recorded for
+// asc status
+type StreamStatus struct {
+	State       StreamState `json:"state"`
+	Since       time.Time   `json:"since,omitempty"`         // When the stream entered State
+	Attempts    int         `json:"attempts,omitempty"`      // Failed reconnects since the stream dropped
+	LastErr     string      `json:"last_error,omitempty"`    // Why the stream dropped or the last reconnect failed
+	LastEventID string      `json:"last_event_id,omitempty"` // Where a reconnect resumes
+	PID         int         `json:"pid"`                     // Process of the client
+	URL         string      `json:"url"`
+}
+
+// DefaultStreamStatePath returns where the TUI's event stream records its
+// StreamStatus, ~/.asc/mcp/stream.json
+func DefaultStreamStatePath() (string, error) {
+	return statedir.Path("mcp", "stream.json")
+}
+
+// ReadStreamStatus reads the StreamStatus recorded at path
+func ReadStreamStatus(path string) (StreamStatus, error) {
+	var status StreamStatus
+	err := statefile.ReadJSON(path, &status)
+	return status, err
+}
+
+// EventSource is a stream of events from the MCP server that reconnects
+// whenever it drops, resuming after the last event received: a
+// WebSocketClient, or the Watch stream of a GRPCClient
+type EventSource interface {
+	// Connect opens the stream. If it cannot, it returns why and keeps
+	// trying in the background until Close.
+	Connect() error
+
+	// Events returns the channel the stream's events, and its
+	// EventConnected, EventDegraded, EventDisconnected, and EventResync,
+	// are delivered on
+	Events() <-chan Event
+
+	IsConnected() bool
+	Status() StreamStatus
+	Close() error
+}
+
+// eventConn is one connection of an event stream
+type eventConn interface {
+	// Next returns the next event, or why the connection failed
+	Next() (Event, error)
+	Close() error
+}
+
+// eventStream is the reconnecting core of the EventSources: it opens a
+// connection with open, resuming after the last event received, reads
+// its events, and when it fails reconnects with exponential backoff and
+// jitter, tracking and recording the StreamStatus
+type eventStream struct {
+	open   func(lastEventID string) (eventConn, error)
+	events chan Event
+	done   chan struct{}
+
+	closeOnce         sync.Once
+	reconnectDelay    time.Duration
+	maxReconnectDelay time.Duration
+	degradedFor       time.Duration // How long reconnects fail before the stream is disconnected
+	statePath         string        // Where the StreamStatus is recorded; "" for nowhere
+
+	connMutex sync.Mutex
+	conn      eventConn
+
+	statusMutex   sync.RWMutex
+	status        StreamStatus
+	everConnected bool
+}
+
+// errClosed is returned by connect once the stream is closed
+var errClosed = errors.New("event stream closed")
+
+// newEventStream creates a disconnected stream of the server at url,
+// recording its status at statePath
+func newEventStream(url, statePath string, open func(lastEventID string) (eventConn, error)) *eventStream {
+	return &eventStream{
+		open:              open,
+		events:            make(chan Event, 100), // Buffer events to prevent blocking
+		done:              make(chan struct{}),
+		reconnectDelay:    1 * time.Second,
+		maxReconnectDelay: 30 * time.Second,
+		degradedFor:       1 * time.Minute,
+		statePath:         statePath,
+		status:            StreamStatus{State: StreamDisconnected, PID: os.Getpid(), URL: url},
+	}
+}
+
+// Connect opens the stream and starts reading it, reconnecting when it
+// drops. Returns an error if the initial connection fails, in which case
+// the stream keeps trying to connect in the background until Close.
+func (s *eventStream) Connect() error {
+	err := s.connect()
+	if err != nil {
+		s.setState(StreamDisconnected, err)
+	}
+
+	// Start event reader, reconnecting when the stream drops
+	go s.run(err == nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	return nil
+}
+
+// connect opens a connection, resuming after the last event received
+func (s *eventStream) connect() error {
+	resumeFrom := s.Status().LastEventID
+	conn, err := s.open(resumeFrom)
+	if err != nil {
+		return err
+	}
+
+	s.connMutex.Lock()
+	select {
+	case <-s.done:
+		s.connMutex.Unlock()
+		conn.Close()
+		return errClosed
+	default:
+	}
+	s.conn = conn
+	s.connMutex.Unlock()
+
+	s.statusMutex.Lock()
+	reconnected := s.everConnected
+	s.everConnected = true
+	s.statusMutex.Unlock()
+	s.setState(StreamConnected, nil)
+
+	// Send connected event
+	s.emit(Event{Type: EventConnected})
+
+	// Events sent while reconnecting cannot be replayed without an event
+	// to resume after
+	if reconnected && resumeFrom == "" {
+		s.emit(Event{Type: EventResync})
+	}
+
+	return nil
+}
+
+// run reads events, and reconnects whenever the stream drops, until the
+// stream is closed
+func (s *eventStream) run(connected bool) {
+	for {
+		if !connected && !s.reconnect() {
+			return
+		}
+		err := s.readLoop()
+		if err == nil {
+			return
+		}
+		connected = false
+
+		// Connection error - trigger reconnection
+		s.setState(StreamDegraded, err)
+		if !s.emit(Event{Type: EventDegraded, Error: err.Error()}) {
+			return
+		}
+	}
+}
+
+// readLoop reads events from the connection until it fails, returning
+// why, or the stream is closed, returning nil
+func (s *eventStream) readLoop() error {
+	s.connMutex.Lock()
+	conn := s.conn
+	s.connMutex.Unlock()
+	if conn == nil {
+		return errClosed
+	}
+
+	for {
+		event, err := conn.Next()
+		if err != nil {
+			conn.Close()
+			select {
+			case <-s.done:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		if event.ID != "" {
+			s.statusMutex.Lock()
+			s.status.LastEventID = event.ID
+			s.statusMutex.Unlock()
+		}
+
+		// Send event to channel
+		if !s.emit(event) {
+			return nil
+		}
+	}
+}
+
+// reconnect tries to reconnect with exponential backoff and jitter,
+// reporting false once the stream is closed. A stream degraded for
+// degradedFor becomes disconnected.
+func (s *eventStream) reconnect() bool {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-s.done:
+			return false
+		case <-time.After(backoff(attempt, s.reconnectDelay, s.maxReconnectDelay)):
+		}
+
+		err := s.connect()
+		if err == nil {
+			return true
+		}
+		if errors.Is(err, errClosed) {
+			return false
+		}
+
+		status := s.Status()
+		if status.State == StreamDegraded && time.Since(status.Since) >= s.degradedFor {
+			s.setState(StreamDisconnected, err)
+			if !s.emit(Event{Type: EventDisconnected, Error: err.Error()}) {
+				return false
+			}
+		}
+		s.recordAttempt(attempt, err)
+	}
+}
+
+// emit sends an event to the channel, reporting false if the stream was
+// closed first
+func (s *eventStream) emit(event Event) bool {
+	select {
+	case s.events <- event:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// Events returns the channel for receiving the stream's events.
+// The channel is buffered and will not block unless the buffer is full.
+func (s *eventStream) Events() <-chan Event {
+	return s.events
+}
+
+// IsConnected returns true if the stream is connected.
+func (s *eventStream) IsConnected() bool {
+	return s.Status().State == StreamConnected
+}
+
+// Status returns the state of the stream
+func (s *eventStream) Status() StreamStatus {
+	s.statusMutex.RLock()
+	defer s.statusMutex.RUnlock()
+	return s.status
+}
+
+// setState moves the stream to state, for the reason err, and records it
+func (s *eventStream) setState(state StreamState, err error) {
+	s.statusMutex.Lock()
+	select {
+	case <-s.done:
+		// A closed stream stays disconnected
+		if state != StreamDisconnected {
+			s.statusMutex.Unlock()
+			return
+		}
+	default:
+	}
+	if s.status.State != state || s.status.Since.IsZero() {
+		s.status.State = state
+		s.status.Since = time.Now()
+		s.status.Attempts = 0
+	}
+	s.status.LastErr = ""
+	if err != nil {
+		s.status.LastErr = err.Error()
+	}
+	status := s.status
+	s.statusMutex.Unlock()
+	s.save(status)
+}
+
+// recordAttempt records a failed reconnect
+func (s *eventStream) recordAttempt(attempt int, err error) {
+	s.statusMutex.Lock()
+	s.status.Attempts = attempt
+	s.status.LastErr = err.Error()
+	status := s.status
+	s.statusMutex.Unlock()
+	s.save(status)
+}
+
+// save records status for asc status. The stream works without it, so a
+// failure is only logged.
+func (s *eventStream) save(status StreamStatus) {
+	if s.statePath == "" {
+		return
+	}
+	if err := statefile.WriteJSON(s.statePath, status, 0600); err != nil {
+		mcpLog.Warn("Failed to record MCP event stream state: %v", err)
+	}
+}
+
+// Close closes the connection and stops all background goroutines.
+// After calling Close, the stream cannot be reused.
+func (s *eventStream) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+
+	s.connMutex.Lock()
+	conn := s.conn
+	s.conn = nil
+	s.connMutex.Unlock()
+
+	s.setState(StreamDisconnected, nil)
+	if conn != nil {
+		return conn.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package mcp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ascerrors "github.com/rand/asc/internal/errors"
+)
+
+// testCertificate creates a certificate for name signed by parent, or
+// self-signed if parent is nil, and writes it and its key as PEM files
+// to dir
+func testCertificate(t *testing.T, dir, name string, parent *tls.Certificate, usage x509.ExtKeyUsage) (tls.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := template, any(key)
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	_, caFile, caKey := testCertificate(t, dir, "ca", nil, x509.ExtKeyUsageAny)
+
+	if config, err := NewTLSConfig("", "", ""); config != nil || err != nil {
+		t.Errorf("NewTLSConfig() with no files = %v, %v, want nil", config, err)
+	}
+	config, err := NewTLSConfig(caFile, "", "")
+	if err != nil || config.RootCAs == nil {
+		t.Errorf("NewTLSConfig(ca) = %v, %v, want the CA trusted", config, err)
+	}
+
+	tests := []struct {
+		name                      string
+		caFile, certFile, keyFile string
+		want                      string
+	}{
+		{"missing CA", filepath.Join(dir, "missing.pem"), "", "", "failed to read CA bundle"},
+		{"CA without certificates", caKey, "", "", "has no PEM certificates"},
+		{"certificate without key", "", caFile, "", "needs both"},
+		{"mismatched key", "", caFile, caFile, "failed to load client certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTLSConfig(tt.caFile, tt.certFile, tt.keyFile); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewTLSConfig() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPClientMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caFile, _ := testCertificate(t, dir, "ca", nil, x509.ExtKeyUsageAny)
+	serverCert, _, _ := testCertificate(t, dir, "server", &ca, x509.ExtKeyUsageServerAuth)
+	_, certFile, keyFile := testCertificate(t, dir, "client", &ca, x509.ExtKeyUsageClientAuth)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert}
+	server.StartTLS()
+	defer server.Close()
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	ctx := context.Background()
+
+	// Without the CA, the server's certificate is not trusted, and that
+	// is not retried
+	client := NewHTTPClientWithOptions(url, Options{MaxRetries: 3, RetryBackoff: time.Second})
+	start := time.Now()
+	_, err := client.GetMessages(ctx, time.Now())
+	if ascerrors.Hint(err) != hintUntrusted {
+		t.Errorf("Expected an untrusted certificate hint, got %v (hint %q)", err, ascerrors.Hint(err))
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("Expected no retries of an untrusted certificate, took %v", elapsed)
+	}
+
+	tlsConfig, err := NewTLSConfig(caFile, certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewTLSConfig() error = %v", err)
+	}
+
+	// Without the token, the server rejects the request
+	client = NewHTTPClientWithOptions(url, Options{TLS: tlsConfig})
+	_, err = client.GetMessages(ctx, time.Now())
+	if ascerrors.Hint(err) != hintUnauthorized {
+		t.Errorf("Expected an unauthorized hint, got %v (hint %q)", err, ascerrors.Hint(err))
+	}
+
+	client = NewHTTPClientWithOptions(url, Options{TLS: tlsConfig, Token: "s3cret-token"})
+	if _, err := client.GetMessages(ctx, time.Now()); err != nil {
+		t.Errorf("GetMessages() with the CA, client certificate, and token error = %v", err)
+	}
+}
+
+func TestWebSocketClientToken(t *testing.T) {
+	authorization := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	client := NewWebSocketClientWithOptions("ws"+strings.TrimPrefix(server.URL, "http"), Options{Token: "s3cret-token"})
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if got := <-authorization; got != "Bearer s3cret-token" {
+		t.Errorf("Authorization = %q, want the bearer token", got)
+	}
+}
@@ -8,7 +8,7 @@
 //	eventChan := wsClient.Events()
 //	
 //	if err := wsClient.Connect(); err != nil {
-//	    log.Fatal(err)
+//	    log.Printf("WebSocket unavailable, reconnecting in the background: %v", err)
 //	}
 //	defer wsClient.Close()
 //	
@@ -18,16 +18,21 @@
 //	        fmt.Printf("Agent %s status changed\n", event.AgentStatus.Name)
 //	    case mcp.EventNewMessage:
 //	        fmt.Printf("New message: %s\n", event.Message.Content)
+//	    case mcp.EventResync:
+//	        // Events were missed while reconnecting; fetch everything again
 //	    }
 //	}
 package mcp
 
 import (
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/rand/asc/internal/proxy"
 )
 
 // EventType represents the type of WebSocket event received from the MCP server.
@@ -39,28 +44,46 @@ const (
 	EventError       EventType = "error"
 	EventConnected   EventType = "connected"
 	EventDisconnected EventType = "disconnected"
+
+	// EventDegraded is sent when the stream drops; the client reconnects
+	// and resumes after the last event it received
+	EventDegraded EventType = "degraded"
+
+	// EventResync is sent, by the server or the client, when events were
+	// missed while reconnecting and cannot be replayed, so the receiver
+	// must fetch the current state
+	EventResync EventType = "resync"
 )
 
-// Event represents a WebSocket event from the MCP server.
+// Event represents an event from the MCP server's event stream.
 type Event struct {
+	// ID identifies the event in the server's stream, to resume after it
+	// on reconnect; "" from servers that cannot resume
+	ID string `json:"id,omitempty"`
+
 	Type        EventType    `json:"type"`
 	AgentStatus *AgentStatus `json:"agent_status,omitempty"`
 	Message     *Message     `json:"message,omitempty"`
 	Error       string       `json:"error,omitempty"`
+	
+	// CorrelationID is set by the server when the event was caused by a
+	// request that carried a correlation ID
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 // WebSocketClient manages a WebSocket connection to the MCP server
-// with automatic reconnection and event distribution.
+// with automatic reconnection and event distribution. A reconnect resumes
+// the stream after the last event received, sending its ID as the
+// Last-Event-ID header and the last_event_id of the subscriptions; a
+// server that cannot replay from there sends EventResync.
 type WebSocketClient struct {
-	url            string
-	conn           *websocket.Conn
-	connMutex      sync.RWMutex
-	events         chan Event
-	done           chan struct{}
-	reconnectDelay time.Duration
-	maxReconnectDelay time.Duration
-	connected      bool
-	connectedMutex sync.RWMutex
+	*eventStream
+	url          string
+	conn         *websocket.Conn
+	connMutex    sync.RWMutex
+	pingInterval time.Duration // Between pings; a connection without a pong for two is dead
+	dialer       *websocket.Dialer
+	header       http.Header // Sent with the handshake, e.g. the bearer token
 }
 
 // NewWebSocketClient creates a new WebSocket client for the MCP server.
@@ -70,147 +93,123 @@ type WebSocketClient struct {
 //
 //	client := mcp.NewWebSocketClient("ws://localhost:8765/ws")
 func NewWebSocketClient(url string) *WebSocketClient {
-	return &WebSocketClient{
-		url:               url,
-		events:            make(chan Event, 100), // Buffer events to prevent blocking
-		done:              make(chan struct{}),
-		reconnectDelay:    1 * time.Second,
-		maxReconnectDelay: 30 * time.Second,
-		connected:         false,
+	return NewWebSocketClientWithProxy(url, proxy.FromEnvironment())
+}
+
+// NewWebSocketClientWithProxy creates a WebSocket client that connects
+// through the given proxy settings.
+func NewWebSocketClientWithProxy(url string, proxySettings proxy.Settings) *WebSocketClient {
+	return NewWebSocketClientWithOptions(url, Options{Proxy: proxySettings})
+}
+
+// NewWebSocketClientWithOptions creates a WebSocket client that connects
+// with the proxy, TLS, and token of opts, as the HTTPClient of the same
+// options does, and records its StreamStatus at opts.StreamStatePath. Its
+// other options do not apply to WebSockets.
+func NewWebSocketClientWithOptions(url string, opts Options) *WebSocketClient {
+	dialer := *websocket.DefaultDialer
+	dialer.Proxy = opts.Proxy.ProxyFunc()
+	if opts.TLS != nil {
+		dialer.TLSClientConfig = opts.TLS.Clone()
+	}
+	header := http.Header{}
+	if opts.Token != "" {
+		header.Set("Authorization", "Bearer "+opts.Token)
 	}
+
+	c := &WebSocketClient{
+		url:          url,
+		pingInterval: 10 * time.Second,
+		dialer:       &dialer,
+		header:       header,
+	}
+	c.eventStream = newEventStream(url, opts.StreamStatePath, c.dial)
+	return c
 }
 
 // Connect establishes a WebSocket connection to the MCP server.
 // It starts background goroutines for reading messages and handling reconnection.
-// Returns an error if the initial connection fails.
+// Returns an error if the initial connection fails, in which case the
+// client keeps trying to connect in the background until Close.
 func (c *WebSocketClient) Connect() error {
-	if err := c.connect(); err != nil {
-		return fmt.Errorf("failed to connect: %w", err)
-	}
-
-	// Start message reader
-	go c.readLoop()
-
 	// Start connection health monitor
 	go c.healthMonitor()
 
-	return nil
+	return c.eventStream.Connect()
 }
 
-// connect establishes the WebSocket connection
-func (c *WebSocketClient) connect() error {
-	c.connMutex.Lock()
-	defer c.connMutex.Unlock()
+// dial establishes the WebSocket connection, resuming after the event
+// lastEventID if it is set
+func (c *WebSocketClient) dial(lastEventID string) (eventConn, error) {
+	header := c.header.Clone()
+	if lastEventID != "" {
+		header.Set("Last-Event-ID", lastEventID)
+	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
+	conn, _, err := c.dialer.Dial(c.url, header)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	c.conn = conn
-	c.setConnected(true)
-
-	// Send connected event
-	c.events <- Event{Type: EventConnected}
-
-	// Subscribe to agent status changes
-	if err := c.subscribe("agent_status"); err != nil {
-		c.conn.Close()
-		return fmt.Errorf("failed to subscribe to agent_status: %w", err)
+	// Subscribe to agent status changes and new messages
+	for _, eventType := range []EventType{EventAgentStatus, EventNewMessage} {
+		if err := subscribe(conn, eventType, lastEventID); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", eventType, err)
+		}
 	}
 
-	// Subscribe to new messages
-	if err := c.subscribe("new_message"); err != nil {
-		c.conn.Close()
-		return fmt.Errorf("failed to subscribe to new_message: %w", err)
-	}
+	// A connection that answers neither events nor pings is dead
+	conn.SetReadDeadline(time.Now().Add(2 * c.pingInterval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * c.pingInterval))
+	})
 
-	return nil
+	c.connMutex.Lock()
+	c.conn = conn
+	c.connMutex.Unlock()
+
+	return &wsConn{conn: conn, pingInterval: c.pingInterval}, nil
 }
 
-// subscribe sends a subscription message for a specific event type
-func (c *WebSocketClient) subscribe(eventType string) error {
+// subscribe sends a subscription message for a specific event type,
+// resuming after the event lastEventID if it is set
+func subscribe(conn *websocket.Conn, eventType EventType, lastEventID string) error {
 	subscribeMsg := map[string]interface{}{
 		"action": "subscribe",
 		"event":  eventType,
 	}
+	if lastEventID != "" {
+		subscribeMsg["last_event_id"] = lastEventID
+	}
 
-	return c.conn.WriteJSON(subscribeMsg)
+	return conn.WriteJSON(subscribeMsg)
 }
 
-// readLoop continuously reads messages from the WebSocket connection
-func (c *WebSocketClient) readLoop() {
-	for {
-		select {
-		case <-c.done:
-			return
-		default:
-			c.connMutex.RLock()
-			conn := c.conn
-			c.connMutex.RUnlock()
-
-			if conn == nil {
-				time.Sleep(100 * time.Millisecond)
-				continue
-			}
-
-			var event Event
-			err := conn.ReadJSON(&event)
-			if err != nil {
-				// Connection error - trigger reconnection
-				c.setConnected(false)
-				c.events <- Event{
-					Type:  EventDisconnected,
-					Error: err.Error(),
-				}
-
-				// Wait before attempting reconnection
-				time.Sleep(c.reconnectDelay)
-				c.attemptReconnect()
-				continue
-			}
+// wsConn is a WebSocket connection of the event stream
+type wsConn struct {
+	conn         *websocket.Conn
+	pingInterval time.Duration
+}
 
-			// Send event to channel
-			select {
-			case c.events <- event:
-			case <-c.done:
-				return
-			}
-		}
+// Next reads the next event, extending the deadline of the next
+func (w *wsConn) Next() (Event, error) {
+	var event Event
+	if err := w.conn.ReadJSON(&event); err != nil {
+		return Event{}, err
 	}
+	w.conn.SetReadDeadline(time.Now().Add(2 * w.pingInterval))
+	return event, nil
 }
 
-// attemptReconnect tries to reconnect with exponential backoff
-func (c *WebSocketClient) attemptReconnect() {
-	delay := c.reconnectDelay
-
-	for {
-		select {
-		case <-c.done:
-			return
-		default:
-			if c.IsConnected() {
-				return
-			}
-
-			if err := c.connect(); err == nil {
-				// Successfully reconnected
-				return
-			}
-
-			// Exponential backoff
-			time.Sleep(delay)
-			delay *= 2
-			if delay > c.maxReconnectDelay {
-				delay = c.maxReconnectDelay
-			}
-		}
-	}
+func (w *wsConn) Close() error {
+	return w.conn.Close()
 }
 
-// healthMonitor periodically checks connection health
+// healthMonitor periodically pings the server; a failed ping closes the
+// connection so that the reader reconnects
 func (c *WebSocketClient) healthMonitor() {
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(c.pingInterval)
 	defer ticker.Stop()
 
 	for {
@@ -228,52 +227,10 @@ func (c *WebSocketClient) healthMonitor() {
 			c.connMutex.RUnlock()
 
 			if conn != nil {
-				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-					c.setConnected(false)
-					c.events <- Event{
-						Type:  EventDisconnected,
-						Error: "ping failed",
-					}
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.pingInterval)); err != nil {
+					conn.Close()
 				}
 			}
 		}
 	}
 }
-
-// Events returns the channel for receiving WebSocket events.
-// The channel is buffered and will not block unless the buffer is full.
-func (c *WebSocketClient) Events() <-chan Event {
-	return c.events
-}
-
-// IsConnected returns true if the WebSocket connection is active.
-func (c *WebSocketClient) IsConnected() bool {
-	c.connectedMutex.RLock()
-	defer c.connectedMutex.RUnlock()
-	return c.connected
-}
-
-// setConnected updates the connection status
-func (c *WebSocketClient) setConnected(connected bool) {
-	c.connectedMutex.Lock()
-	defer c.connectedMutex.Unlock()
-	c.connected = connected
-}
-
-// Close closes the WebSocket connection and stops all background goroutines.
-// After calling Close, the client cannot be reused.
-func (c *WebSocketClient) Close() error {
-	close(c.done)
-
-	c.connMutex.Lock()
-	defer c.connMutex.Unlock()
-
-	if c.conn != nil {
-		err := c.conn.Close()
-		c.conn = nil
-		c.setConnected(false)
-		return err
-	}
-
-	return nil
-}
@@ -3,7 +3,10 @@ package mcp
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -184,9 +187,9 @@ func TestWebSocketClient_ReceiveNewMessageEvent(t *testing.T) {
 
 func TestWebSocketClient_Reconnection(t *testing.T) {
 	// Create a server that closes connection after first message
-	connectionCount := 0
+	var connectionCount int32
 	server := mockWebSocketServer(t, func(conn *websocket.Conn) {
-		connectionCount++
+		connection := atomic.AddInt32(&connectionCount, 1)
 		
 		// Read subscription messages
 		for i := 0; i < 2; i++ {
@@ -196,7 +199,7 @@ func TestWebSocketClient_Reconnection(t *testing.T) {
 			}
 		}
 
-		if connectionCount == 1 {
+		if connection == 1 {
 			// Close connection immediately to trigger reconnection
 			conn.Close()
 		} else {
@@ -217,11 +220,11 @@ func TestWebSocketClient_Reconnection(t *testing.T) {
 	// Wait for initial connected event
 	<-client.Events()
 
-	// Wait for disconnected event
+	// Wait for degraded event
 	select {
 	case event := <-client.Events():
-		if event.Type != EventDisconnected {
-			t.Errorf("Expected disconnected event, got %v", event.Type)
+		if event.Type != EventDegraded {
+			t.Errorf("Expected degraded event, got %v", event.Type)
 		}
 	case <-time.After(2 * time.Second):
 		t.Fatal("Timeout waiting for disconnected event")
@@ -237,8 +240,123 @@ func TestWebSocketClient_Reconnection(t *testing.T) {
 		t.Fatal("Timeout waiting for reconnected event")
 	}
 
-	if connectionCount < 2 {
-		t.Errorf("Expected at least 2 connections, got %d", connectionCount)
+	// Without an event ID to resume after, missed events must be fetched
+	select {
+	case event := <-client.Events():
+		if event.Type != EventResync {
+			t.Errorf("Expected resync event, got %v", event.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for resync event")
+	}
+
+	if n := atomic.LoadInt32(&connectionCount); n < 2 {
+		t.Errorf("Expected at least 2 connections, got %d", n)
+	}
+}
+
+func TestWebSocketClient_Resume(t *testing.T) {
+	resumed := make(chan [2]string, 1)
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connection := atomic.AddInt32(&connections, 1)
+		header := r.Header.Get("Last-Event-ID")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var msg map[string]interface{}
+		for i := 0; i < 2; i++ {
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+		}
+		if connection == 1 {
+			conn.WriteJSON(Event{ID: "41", Type: EventNewMessage, Message: &Message{Content: "first"}})
+			conn.WriteJSON(Event{ID: "42", Type: EventNewMessage, Message: &Message{Content: "second"}})
+			return
+		}
+		lastEventID, _ := msg["last_event_id"].(string)
+		resumed <- [2]string{header, lastEventID}
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewWebSocketClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	client.reconnectDelay = 10 * time.Millisecond
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	select {
+	case got := <-resumed:
+		if got != [2]string{"42", "42"} {
+			t.Errorf("Reconnect resumed after %q (header) and %q (subscription), want 42", got[0], got[1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for reconnect")
+	}
+
+	var types []EventType
+	for len(types) < 5 {
+		select {
+		case event := <-client.Events():
+			types = append(types, event.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for events, got %v", types)
+		}
+	}
+	want := []EventType{EventConnected, EventNewMessage, EventNewMessage, EventDegraded, EventConnected}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("Events = %v, want %v without a resync", types, want)
+		}
+	}
+}
+
+func TestWebSocketClient_StreamStatus(t *testing.T) {
+	stop := make(chan struct{})
+	server := mockWebSocketServer(t, func(conn *websocket.Conn) {
+		for i := 0; i < 2; i++ {
+			var msg map[string]interface{}
+			conn.ReadJSON(&msg)
+		}
+		<-stop
+	})
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	statePath := filepath.Join(t.TempDir(), "stream.json")
+	client := NewWebSocketClientWithOptions(wsURL, Options{StreamStatePath: statePath})
+	client.reconnectDelay = 10 * time.Millisecond
+	client.maxReconnectDelay = 20 * time.Millisecond
+	client.degradedFor = 100 * time.Millisecond
+	defer client.Close()
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	<-client.Events()
+	if status, err := ReadStreamStatus(statePath); err != nil || status.State != StreamConnected || status.PID != os.Getpid() {
+		t.Errorf("ReadStreamStatus() = %+v, %v; want connected", status, err)
+	}
+
+	// The server goes away: degraded while reconnecting, then disconnected
+	close(stop)
+	server.Close()
+	for _, want := range []EventType{EventDegraded, EventDisconnected} {
+		select {
+		case event := <-client.Events():
+			if event.Type != want {
+				t.Fatalf("Expected %s event, got %v", want, event.Type)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timeout waiting for %s event", want)
+		}
+	}
+	status, err := ReadStreamStatus(statePath)
+	if err != nil || status.State != StreamDisconnected || status.LastErr == "" {
+		t.Errorf("ReadStreamStatus() = %+v, %v; want disconnected with the reconnect's failure", status, err)
 	}
 }
 
@@ -0,0 +1,292 @@
+// Package migrate versions the layout of the state directory and upgrades
+// directories written by older releases of asc. The version is kept in
+// schema.json at the top of the directory. A directory without one was
+// written before versioning began and is version 0, unless it is empty.
+// Before migrating, the state is copied to the backups subdirectory, so
+// an upgrade that goes wrong can be undone by hand.
+//
+// Example usage:
+//
+//	result, err := migrate.Run(dir)
+//	if err != nil {
+//	    return err // errors.Is(err, migrate.ErrNewerSchema) after a downgrade
+//	}
+//	if result.Migrated() {
+//	    fmt.Printf("Migrated state from schema %d to %d\n", result.From, result.To)
+//	}
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// SchemaFileName is the file the schema version is kept in
+const SchemaFileName = "schema.json"
+
+// BackupDirName is the subdirectory state is copied to before migrating
+const BackupDirName = "backups"
+
+// CurrentVersion is the schema version this release reads and writes
+const CurrentVersion = 1
+
+// lockFileName serializes migrations of concurrent asc commands
+const lockFileName = ".migrate.lock"
+
+// notBackedUp are the subdirectories left out of backups: they are large
+// and migrations do not change them
+var notBackedUp = map[string]bool{
+	BackupDirName:  true,
+	"logs":         true,
+	"history":      true,
+	"worktrees":    true,
+	"config-cache": true,
+}
+
+// ErrNewerSchema is wrapped by the error of Run when the directory was
+// written by a newer release of asc
+var ErrNewerSchema = errors.New("state directory was written by a newer version of asc")
+
+// Migration upgrades the state directory to Version from the version
+// before it
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(dir string) error
+}
+
+// migrations are applied in order; each one's Version is one more than
+// the last
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Quarantine unreadable PID files and remove files left by interrupted writes",
+		Apply:       cleanInterruptedWrites,
+	},
+}
+
+// schema is the contents of schema.json
+type schema struct {
+	Version int `json:"version"`
+}
+
+// Result describes what Run did
+type Result struct {
+	From    int         // Version found
+	To      int         // Version after migrating
+	Applied []Migration // Migrations applied, oldest first
+	Backup  string      // Directory the state was copied to, if it was migrated
+}
+
+// Migrated reports whether any migration was applied
+func (r *Result) Migrated() bool {
+	return len(r.Applied) > 0
+}
+
+// Version returns the schema version of the state directory. It returns
+// CurrentVersion for a new, empty directory.
+func Version(dir string) (int, error) {
+	var s schema
+	err := statefile.ReadJSON(filepath.Join(dir, SchemaFileName), &s)
+	if err == nil {
+		return s.Version, nil
+	}
+	if !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read state schema version: %w", err)
+	}
+
+	empty, err := isEmpty(dir)
+	if err != nil {
+		return 0, err
+	}
+	if empty {
+		return CurrentVersion, nil
+	}
+	return 0, nil
+}
+
+// Check returns the schema version of the state directory without
+// migrating it. For a directory written by a newer release it returns the
+// error Run would, wrapping ErrNewerSchema.
+func Check(dir string) (int, error) {
+	version, err := Version(dir)
+	if err != nil {
+		return 0, err
+	}
+	if version > CurrentVersion {
+		return version, fmt.Errorf("%w: %s has schema %d, this release supports up to %d\n  Suggestion: Upgrade asc, or set %s to use a separate state directory with this release",
+			ErrNewerSchema, dir, version, CurrentVersion, statedir.EnvVar)
+	}
+	return version, nil
+}
+
+// Run brings the state directory up to CurrentVersion, backing it up
+// first if any migration is needed
+func Run(dir string) (*Result, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	unlock, err := statefile.Lock(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	from, err := Check(dir)
+	result := &Result{From: from, To: from}
+	if err != nil {
+		return result, err
+	}
+
+	if from < CurrentVersion {
+		result.Backup = filepath.Join(dir, BackupDirName, fmt.Sprintf("%s-schema-%d", time.Now().Format("20060102T150405"), from))
+		if err := backup(dir, result.Backup); err != nil {
+			return result, err
+		}
+		for _, m := range migrations {
+			if m.Version <= from {
+				continue
+			}
+			if err := m.Apply(dir); err != nil {
+				return result, fmt.Errorf("failed to migrate state to schema %d (%s): %w\n  Suggestion: Restore the backup in %s, or report the error",
+					m.Version, m.Description, err, result.Backup)
+			}
+			if err := writeVersion(dir, m.Version); err != nil {
+				return result, err
+			}
+			result.To = m.Version
+			result.Applied = append(result.Applied, m)
+		}
+	}
+
+	// New directories, and directories versioning began in, record the
+	// version so later releases know what they hold
+	if _, err := os.Stat(filepath.Join(dir, SchemaFileName)); os.IsNotExist(err) {
+		if err := writeVersion(dir, result.To); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// writeVersion records the schema version of dir
+func writeVersion(dir string, version int) error {
+	if err := statefile.WriteJSON(filepath.Join(dir, SchemaFileName), schema{Version: version}, 0600); err != nil {
+		return fmt.Errorf("failed to record state schema version: %w", err)
+	}
+	return nil
+}
+
+// isEmpty reports whether dir holds no state. Hidden files, such as lock
+// files, do not count.
+func isEmpty(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read state directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), ".") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// backup copies the state in dir to target, leaving out notBackedUp
+func backup(dir, target string) error {
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && notBackedUp[rel] {
+			return filepath.SkipDir
+		}
+		dest := filepath.Join(target, rel)
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(dest, 0700)
+		case entry.Type().IsRegular():
+			return copyFile(path, dest)
+		}
+		// Sockets, symlinks, and the like are not state
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to back up state directory to %s: %w", target, err)
+	}
+	return nil
+}
+
+// copyFile copies a regular file, keeping its permissions
+func copyFile(src, dest string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// cleanInterruptedWrites quarantines PID files that cannot be read, which
+// would otherwise be reported by asc doctor, and removes the temporary
+// files of state writes that were interrupted before their rename
+func cleanInterruptedWrites(dir string) error {
+	pidDir := filepath.Join(dir, "pids")
+	entries, err := os.ReadDir(pidDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		var contents map[string]interface{}
+		if err := statefile.ReadJSON(filepath.Join(pidDir, entry.Name()), &contents); err != nil && !errors.Is(err, statefile.ErrCorrupt) {
+			return err
+		}
+	}
+
+	return filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if rel, _ := filepath.Rel(dir, path); notBackedUp[rel] || entry.Name() == statefile.QuarantineDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name := entry.Name(); strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".tmp") {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rand/asc/internal/statefile"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunNewDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".asc")
+
+	result, err := Run(dir)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Migrated() || result.Backup != "" {
+		t.Errorf("Expected nothing to migrate in a new directory, got %+v", result)
+	}
+	if version, err := Version(dir); err != nil || version != CurrentVersion {
+		t.Errorf("Version() = %d, %v, want %d", version, err, CurrentVersion)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SchemaFileName)); err != nil {
+		t.Errorf("Expected the schema version to be recorded: %v", err)
+	}
+}
+
+func TestRunMigratesUnversionedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	validPID := filepath.Join(dir, "pids", "planner.json")
+	corruptPID := filepath.Join(dir, "pids", "coder.json")
+	leftover := filepath.Join(dir, "pids", ".planner.json.123.tmp")
+	writeFile(t, validPID, `{"name": "planner", "pid": 42}`)
+	writeFile(t, corruptPID, `{"name": "cod`)
+	writeFile(t, leftover, `{"name": "plan`)
+	writeFile(t, filepath.Join(dir, "logs", "planner.log"), "log output\n")
+
+	if version, err := Version(dir); err != nil || version != 0 {
+		t.Fatalf("Version() = %d, %v, want 0 for an unversioned directory", version, err)
+	}
+
+	result, err := Run(dir)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.From != 0 || result.To != CurrentVersion || !result.Migrated() {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if _, err := os.Stat(validPID); err != nil {
+		t.Errorf("Expected the valid PID file to be kept: %v", err)
+	}
+	if _, err := os.Stat(corruptPID); !os.IsNotExist(err) {
+		t.Errorf("Expected the corrupt PID file to be quarantined, got %v", err)
+	}
+	if entries, _ := os.ReadDir(statefile.QuarantineDir(filepath.Join(dir, "pids"))); len(entries) != 1 {
+		t.Errorf("Expected 1 quarantined file, got %d", len(entries))
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("Expected the leftover temporary file to be removed, got %v", err)
+	}
+
+	// The backup holds the state as it was, without logs
+	if data, err := os.ReadFile(filepath.Join(result.Backup, "pids", "coder.json")); err != nil || string(data) != `{"name": "cod` {
+		t.Errorf("Expected the backup to hold the original PID file, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(result.Backup, "logs")); !os.IsNotExist(err) {
+		t.Errorf("Expected logs to be left out of the backup, got %v", err)
+	}
+
+	// Migrating again does nothing
+	result, err = Run(dir)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Migrated() {
+		t.Errorf("Expected a migrated directory to stay as is, got %+v", result)
+	}
+}
+
+func TestRunNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, SchemaFileName), `{"version": 99}`)
+
+	_, err := Run(dir)
+	if !errors.Is(err, ErrNewerSchema) {
+		t.Fatalf("Expected ErrNewerSchema, got %v", err)
+	}
+	if version, _ := Version(dir); version != 99 {
+		t.Errorf("Expected the schema version to be left alone, got %d", version)
+	}
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/rand/asc/internal/config"
+)
+
+// TestEvent is the type of the notification asc notify test sends
+const TestEvent = "notify.test"
+
+// DefaultTemplates are the chat messages of the event types, keyed by the
+// type with underscores for dots. Default is used for the other types.
+var DefaultTemplates = map[string]string{
+	"agent_crashed":   "🚨 Agent `{{.Agent}}` crashed on {{.Host}}{{with .Error}}: {{.}}{{end}}",
+	"agent_restarted": "🔁 Agent `{{.Agent}}` {{if .Error}}could not be restarted on {{.Host}}: {{.Error}}{{else}}restarted on {{.Host}} ({{.Message}}){{end}}",
+	"task_stuck":      "⏳ Agent `{{.Agent}}` is stuck on task `{{.Task}}` on {{.Host}}{{with .Message}}: {{.}}{{end}}",
+	"doctor_critical": "❌ asc doctor found a critical issue on {{.Host}}: {{.Message}}",
+	"stack_started":   "▶️ Agent stack started on {{.Host}}{{with .Message}} ({{.}}){{end}}",
+	"stack_stopped":   "⏹️ Agent stack stopped on {{.Host}}{{with .Error}}: {{.}}{{end}}",
+	"notify_test":     "✅ Test notification from asc on {{.Host}}",
+	"default":         "{{.Event}}{{with .Agent}} `{{.}}`{{end}}{{with .Task}} `{{.}}`{{end}} on {{.Host}}{{with .Message}}: {{.}}{{end}}{{with .Error}} ({{.}}){{end}}",
+}
+
+// Chat posts a message rendered from a template for each notification to
+// a Slack or Discord webhook
+type Chat struct {
+	name      string
+	url       string
+	username  string
+	templates map[string]*template.Template
+	client    *http.Client
+	maxLength int                                     // Longest message the service accepts, in characters
+	body      func(text, username string) interface{} // JSON body of a message
+}
+
+// NewSlack creates the sender of [notify.slack]
+func NewSlack(cfg config.ChatConfig) (*Chat, error) {
+	return newChat("slack", cfg, 4000, func(text, username string) interface{} {
+		return map[string]string{"text": text, "username": username}
+	})
+}
+
+// NewDiscord creates the sender of [notify.discord]
+func NewDiscord(cfg config.ChatConfig) (*Chat, error) {
+	return newChat("discord", cfg, 2000, func(text, username string) interface{} {
+		return map[string]string{"content": text, "username": username}
+	})
+}
+
+func newChat(name string, cfg config.ChatConfig, maxLength int, body func(text, username string) interface{}) (*Chat, error) {
+	section := "notify." + name
+	endpoint, err := resolveURL(section, cfg.URL, cfg.URLEnv)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClient(section, cfg.Timeout, cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	templates := make(map[string]*template.Template, len(DefaultTemplates)+len(cfg.Templates))
+	for _, source := range []map[string]string{DefaultTemplates, cfg.Templates} {
+		for key, text := range source {
+			tmpl, err := template.New(key).Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("%s.templates.%s: %w", section, key, err)
+			}
+			templates[key] = tmpl
+		}
+	}
+	return &Chat{
+		name:      name,
+		url:       endpoint,
+		username:  cfg.Username,
+		templates: templates,
+		client:    client,
+		maxLength: maxLength,
+		body:      body,
+	}, nil
+}
+
+// Name returns "slack" or "discord"
+func (c *Chat) Name() string {
+	return c.name
+}
+
+// Send posts the message of the payload
+func (c *Chat) Send(payload Payload) error {
+	text, err := c.Text(payload)
+	if err != nil {
+		return err
+	}
+	return postJSON(c.client, c.url, nil, c.body(text, c.username))
+}
+
+// Text renders the message of the payload from the template of its event
+// type, noting the notifications the rate limit dropped before it
+func (c *Chat) Text(payload Payload) (string, error) {
+	tmpl, ok := c.templates[strings.ReplaceAll(payload.Event, ".", "_")]
+	if !ok {
+		tmpl = c.templates["default"]
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, payload); err != nil {
+		return "", fmt.Errorf("notify.%s.templates.%s: %w", c.name, tmpl.Name(), err)
+	}
+	text := buf.String()
+	if payload.Suppressed > 0 {
+		text += fmt.Sprintf("\n_(%d more notifications were dropped by the rate limit)_", payload.Suppressed)
+	}
+	if runes := []rune(text); len(runes) > c.maxLength {
+		text = string(runes[:c.maxLength-1]) + "…"
+	}
+	return text, nil
+}
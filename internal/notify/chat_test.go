@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/events"
+)
+
+func TestChatText(t *testing.T) {
+	slack, err := NewSlack(config.ChatConfig{
+		URL:       "https://hooks.slack.com/services/T/B/x",
+		Templates: map[string]string{"doctor_critical": "doctor: {{.Message}}"},
+	})
+	if err != nil {
+		t.Fatalf("NewSlack failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		payload Payload
+		want    string
+	}{
+		{"crash", Payload{Event: events.AgentCrashed, Agent: "coder", Error: "exit status 2", Host: "vm"}, "🚨 Agent `coder` crashed on vm: exit status 2"},
+		{"stuck", Payload{Event: events.TaskStuck, Agent: "coder", Task: "bd-7", Message: "working on it for 45m0s", Host: "vm"}, "⏳ Agent `coder` is stuck on task `bd-7` on vm: working on it for 45m0s"},
+		{"override", Payload{Event: events.DoctorCritical, Message: "missing-config: asc.toml not found"}, "doctor: missing-config: asc.toml not found"},
+		{"default", Payload{Event: events.TaskDeleted, Task: "bd-1", Host: "vm"}, "task.deleted `bd-1` on vm"},
+		{"suppressed", Payload{Event: events.StackStopped, Host: "vm", Suppressed: 3}, "⏹️ Agent stack stopped on vm\n_(3 more notifications were dropped by the rate limit)_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := slack.Text(tt.payload)
+			if err != nil {
+				t.Fatalf("Text failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatSend(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	slack, err := NewSlack(config.ChatConfig{URL: server.URL, Username: "asc", Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewSlack failed: %v", err)
+	}
+	discord, err := NewDiscord(config.ChatConfig{URL: server.URL, Username: "asc-ci", Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewDiscord failed: %v", err)
+	}
+
+	crash := NewPayload(events.Event{Type: events.AgentCrashed, Agent: "coder", Err: errors.New("exit status 2")})
+	if err := slack.Send(crash); err != nil {
+		t.Fatalf("Slack Send failed: %v", err)
+	}
+	long := NewPayload(events.Event{Type: events.DoctorCritical, Message: strings.Repeat("x", 3000)})
+	if err := discord.Send(long); err != nil {
+		t.Fatalf("Discord Send failed: %v", err)
+	}
+
+	got := rec.received()
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(got))
+	}
+	if text, _ := got[0]["text"].(string); !strings.Contains(text, "Agent `coder` crashed") || got[0]["username"] != "asc" {
+		t.Errorf("Unexpected Slack message %v", got[0])
+	}
+	content, _ := got[1]["content"].(string)
+	if len([]rune(content)) != 2000 || !strings.HasSuffix(content, "…") || got[1]["username"] != "asc-ci" {
+		t.Errorf("Expected a Discord message truncated to 2000 characters, got %d", len([]rune(content)))
+	}
+}
+
+func TestNewChatInvalidTemplate(t *testing.T) {
+	_, err := NewDiscord(config.ChatConfig{URL: "https://discord.com/api/webhooks/1/abc", Templates: map[string]string{"default": "{{.Agent"}})
+	if err == nil || !strings.Contains(err.Error(), "notify.discord.templates.default") {
+		t.Errorf("Expected an error naming the template, got %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+// Package notify sends the events published on the event bus to external
+// endpoints, so asc can raise alerts about agent crashes, stuck tasks,
+// critical issues found by asc doctor, and the stack starting and
+// stopping. A generic webhook receives a JSON payload for each event of
+// the configured types; Slack and Discord receive messages rendered from
+// templates. Each notifier posts in the order of the events, without
+// blocking the publishers, and drops events beyond its rate limit.
+//
+// Example usage:
+//
+//	notifiers, err := notify.FromConfig(cfg.Notify)
+//	if err != nil {
+//	    return err
+//	}
+//	for _, n := range notifiers {
+//	    n.Start()
+//	    defer n.Close()
+//	}
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/proxy"
+)
+
+// notifyLog tags every record written by this package with the notify component
+var notifyLog = logger.WithComponent("notify")
+
+// queueSize is the number of notifications waiting to be sent beyond which
+// new events are dropped
+const queueSize = 64
+
+// closeTimeout bounds how long Close waits for queued notifications to be
+// sent
+const closeTimeout = 10 * time.Second
+
+// Payload is what is sent for an event: the JSON body of the generic
+// webhook, and the data of chat message templates
+type Payload struct {
+	Event      string    `json:"event"`                // Event type, e.g. "agent.crashed"
+	Agent      string    `json:"agent,omitempty"`      // Agent the event is about
+	Task       string    `json:"task,omitempty"`       // Beads task the event is about
+	Message    string    `json:"message,omitempty"`    // What happened, e.g. "PID 4242"
+	Error      string    `json:"error,omitempty"`      // Why the action failed
+	Host       string    `json:"host"`                 // Host asc runs on
+	Timestamp  time.Time `json:"timestamp"`            // When it happened
+	Suppressed int       `json:"suppressed,omitempty"` // Events dropped by the rate limit since the last notification
+}
+
+// NewPayload returns the payload of an event
+func NewPayload(event events.Event) Payload {
+	host, _ := os.Hostname()
+	payload := Payload{
+		Event:     event.Type,
+		Agent:     event.Agent,
+		Task:      event.Task,
+		Message:   event.Message,
+		Host:      host,
+		Timestamp: event.Time,
+	}
+	if event.Err != nil {
+		payload.Error = event.Err.Error()
+	}
+	return payload
+}
+
+// Sender delivers notifications to one endpoint
+type Sender interface {
+	// Name identifies the endpoint in messages, e.g. "slack"
+	Name() string
+	// Send delivers one notification
+	Send(payload Payload) error
+}
+
+// Notifier sends the events of the selected types to a sender, one at a
+// time and in order, without blocking the publishers
+type Notifier struct {
+	sender Sender
+	types  []string
+	limit  *rateLimiter
+
+	mu          sync.Mutex
+	queue       chan Payload
+	closed      bool
+	suppressed  int
+	unsubscribe func()
+	done        chan struct{}
+}
+
+// New creates a notifier sending the events of types to sender, at most
+// perMinute a minute; -1 or 0 for no limit
+func New(sender Sender, types []string, perMinute int) *Notifier {
+	n := &Notifier{
+		sender: sender,
+		types:  types,
+		queue:  make(chan Payload, queueSize),
+		done:   make(chan struct{}),
+	}
+	if perMinute > 0 {
+		n.limit = newRateLimiter(perMinute, time.Minute)
+	}
+	return n
+}
+
+// FromConfig creates a notifier for each endpoint configured in [notify].
+// Endpoints that cannot be set up are reported in the error, joined, and
+// the others are returned anyway.
+func FromConfig(cfg config.NotifyConfig) ([]*Notifier, error) {
+	var notifiers []*Notifier
+	var errs []error
+	if cfg.Webhook.URL != "" || cfg.Webhook.URLEnv != "" {
+		if webhook, err := NewWebhook(cfg.Webhook); err != nil {
+			errs = append(errs, err)
+		} else {
+			notifiers = append(notifiers, New(webhook, cfg.Webhook.Events, cfg.Webhook.RateLimit))
+		}
+	}
+	for _, chat := range []struct {
+		cfg config.ChatConfig
+		new func(config.ChatConfig) (*Chat, error)
+	}{{cfg.Slack, NewSlack}, {cfg.Discord, NewDiscord}} {
+		if chat.cfg.URL == "" && chat.cfg.URLEnv == "" {
+			continue
+		}
+		if sender, err := chat.new(chat.cfg); err != nil {
+			errs = append(errs, err)
+		} else {
+			notifiers = append(notifiers, New(sender, chat.cfg.Events, chat.cfg.RateLimit))
+		}
+	}
+	return notifiers, errors.Join(errs...)
+}
+
+// Sender returns the sender of the notifier
+func (n *Notifier) Sender() Sender {
+	return n.sender
+}
+
+// Start sends the selected events published on the default bus from now
+// on, until Close
+func (n *Notifier) Start() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.unsubscribe != nil || n.closed {
+		return
+	}
+	n.unsubscribe = events.Subscribe(func(event events.Event) { n.Notify(event) })
+	go n.run()
+}
+
+// Notify queues the notification of an event if its type is selected.
+// Events are dropped while the rate limit is reached, and counted in the
+// next notification; they are dropped with a warning while the queue is
+// full, and silently once the notifier is closed.
+func (n *Notifier) Notify(event events.Event) {
+	if !events.MatchType(n.types, event.Type) {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	if n.limit != nil && !n.limit.allow(time.Now()) {
+		n.suppressed++
+		notifyLog.WithFields(logger.Fields{"event": event.Type, "sender": n.sender.Name()}).Debug("Notification dropped by the rate limit")
+		return
+	}
+	payload := NewPayload(event)
+	payload.Suppressed = n.suppressed
+	select {
+	case n.queue <- payload:
+		n.suppressed = 0
+	default:
+		notifyLog.Warn("Dropped %s notification to %s: %d notifications are already waiting", event.Type, n.sender.Name(), queueSize)
+	}
+}
+
+// Close stops notifying and waits for the queued notifications to be
+// sent, giving up after closeTimeout
+func (n *Notifier) Close() error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed = true
+	started := n.unsubscribe != nil
+	if started {
+		n.unsubscribe()
+	}
+	close(n.queue)
+	n.mu.Unlock()
+
+	if !started {
+		return nil
+	}
+	select {
+	case <-n.done:
+		return nil
+	case <-time.After(closeTimeout):
+		return fmt.Errorf("gave up sending %d notifications to %s after %s", len(n.queue), n.sender.Name(), closeTimeout)
+	}
+}
+
+// run sends queued notifications until the queue is closed
+func (n *Notifier) run() {
+	defer close(n.done)
+	for payload := range n.queue {
+		if err := n.sender.Send(payload); err != nil {
+			notifyLog.WithFields(logger.Fields{"event": payload.Event, "sender": n.sender.Name()}).Warn("Notification failed: %v", err)
+		}
+	}
+}
+
+// rateLimiter is a token bucket allowing bursts of up to max events and
+// max events per period on average
+type rateLimiter struct {
+	max    float64
+	period time.Duration
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(max int, period time.Duration) *rateLimiter {
+	return &rateLimiter{max: float64(max), period: period, tokens: float64(max)}
+}
+
+// allow reports whether an event at now is within the limit, and counts it
+func (r *rateLimiter) allow(now time.Time) bool {
+	if !r.last.IsZero() {
+		r.tokens += now.Sub(r.last).Seconds() / r.period.Seconds() * r.max
+		if r.tokens > r.max {
+			r.tokens = r.max
+		}
+	}
+	r.last = now
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// resolveURL returns the URL of a [notify.*] section, read from the urlEnv
+// variable when it is set, which is then masked in logs like other secrets
+func resolveURL(name, endpoint, urlEnv string) (string, error) {
+	if urlEnv != "" {
+		endpoint = os.Getenv(urlEnv)
+		if endpoint == "" {
+			return "", fmt.Errorf("%s.url_env: %s is not set", name, urlEnv)
+		}
+		logger.RegisterSecret(endpoint)
+	}
+	if endpoint == "" {
+		return "", fmt.Errorf("%s.url is required", name)
+	}
+	return endpoint, nil
+}
+
+// newClient returns the HTTP client of a [notify.*] section
+func newClient(name string, timeout time.Duration, proxyOverride string) (*http.Client, error) {
+	proxySettings, err := proxy.ForService(proxyOverride)
+	if err != nil {
+		return nil, fmt.Errorf("%s.proxy: %w", name, err)
+	}
+	return &http.Client{Timeout: timeout, Transport: proxySettings.Transport()}, nil
+}
+
+// postJSON posts body as JSON to endpoint. Any status other than 2xx is an
+// error; the URL is left out of errors as it often embeds a token.
+func postJSON(client *http.Client, endpoint string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return errors.New("invalid URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "asc")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
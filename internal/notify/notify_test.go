@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/events"
+)
+
+// fakeSender keeps the notifications it is sent
+type fakeSender struct {
+	mu       sync.Mutex
+	payloads []Payload
+	err      error
+}
+
+func (s *fakeSender) Name() string { return "fake" }
+
+func (s *fakeSender) Send(payload Payload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads = append(s.payloads, payload)
+	return s.err
+}
+
+func (s *fakeSender) sent() []Payload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Payload(nil), s.payloads...)
+}
+
+func TestNotifier(t *testing.T) {
+	sender := &fakeSender{err: errors.New("unreachable")}
+	n := New(sender, []string{events.AgentCrashed, "stack"}, -1)
+	n.Start()
+
+	events.Publish(events.Event{Type: events.StackStarted, Message: "2 agents"})
+	events.Publish(events.Event{Type: events.AgentStarted, Agent: "coder"})
+	events.Publish(events.Event{Type: events.AgentCrashed, Agent: "coder"})
+	events.Publish(events.Event{Type: events.AgentCrashed, Agent: "reviewer"})
+	if err := n.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	events.Publish(events.Event{Type: events.StackStopped})
+
+	got := sender.sent()
+	if len(got) != 3 {
+		t.Fatalf("Expected the 3 selected events, each sent despite failures, got %+v", got)
+	}
+	if got[0].Event != events.StackStarted || got[1].Agent != "coder" || got[2].Agent != "reviewer" {
+		t.Errorf("Expected the events in order, got %+v", got)
+	}
+}
+
+func TestNotifierRateLimit(t *testing.T) {
+	sender := &fakeSender{}
+	n := New(sender, []string{"agent"}, 2)
+	n.Start()
+	for _, agent := range []string{"a", "b", "c", "d"} {
+		events.Publish(events.Event{Type: events.AgentCrashed, Agent: agent})
+	}
+
+	// A minute later the limit allows more, and the next notification
+	// counts what was dropped
+	n.mu.Lock()
+	n.limit.last = n.limit.last.Add(-time.Minute)
+	n.mu.Unlock()
+	events.Publish(events.Event{Type: events.AgentCrashed, Agent: "e"})
+	if err := n.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	got := sender.sent()
+	if len(got) != 3 || got[0].Agent != "a" || got[1].Agent != "b" || got[2].Agent != "e" {
+		t.Fatalf("Expected a, b, and e to be sent, got %+v", got)
+	}
+	if got[2].Suppressed != 2 || got[0].Suppressed != 0 {
+		t.Errorf("Expected the 2 dropped events to be counted in the next, got %d", got[2].Suppressed)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(3, time.Minute)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if !limiter.allow(now) {
+			t.Fatalf("Expected a burst of 3 to be allowed, %d was not", i+1)
+		}
+	}
+	if limiter.allow(now) {
+		t.Error("Expected a 4th event to be dropped")
+	}
+	if !limiter.allow(now.Add(20 * time.Second)) {
+		t.Error("Expected an event to be allowed once a third of the period passed")
+	}
+	if limiter.allow(now.Add(21 * time.Second)) {
+		t.Error("Expected the next to be dropped again")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NotifyConfig{
+		Webhook: config.WebhookConfig{URL: "https://hooks.example.com"},
+		Slack:   config.ChatConfig{URLEnv: "ASC_TEST_SLACK_UNSET"},
+		Discord: config.ChatConfig{URL: "https://discord.com/api/webhooks/1/abc"},
+	}
+	notifiers, err := FromConfig(cfg)
+	if err == nil {
+		t.Error("Expected an error for the unset Slack url_env")
+	}
+	if len(notifiers) != 2 || notifiers[0].Sender().Name() != "webhook" || notifiers[1].Sender().Name() != "discord" {
+		t.Errorf("Expected the webhook and Discord notifiers, got %d", len(notifiers))
+	}
+
+	notifiers, err = FromConfig(config.NotifyConfig{})
+	if err != nil || len(notifiers) != 0 {
+		t.Errorf("Expected no notifiers without URLs, got %d, %v", len(notifiers), err)
+	}
+}
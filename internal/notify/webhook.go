@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"net/http"
+
+	"github.com/rand/asc/internal/config"
+)
+
+// Webhook posts the payload of each notification as JSON to a URL
+type Webhook struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhook creates the sender of [notify.webhook]
+func NewWebhook(cfg config.WebhookConfig) (*Webhook, error) {
+	endpoint, err := resolveURL("notify.webhook", cfg.URL, cfg.URLEnv)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClient("notify.webhook", cfg.Timeout, cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	return &Webhook{url: endpoint, headers: cfg.Headers, client: client}, nil
+}
+
+// Name returns "webhook"
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Send posts the payload
+func (w *Webhook) Send(payload Payload) error {
+	return postJSON(w.client, w.url, w.headers, payload)
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/events"
+)
+
+// recorder is a webhook endpoint that keeps the JSON bodies it receives
+type recorder struct {
+	mu      sync.Mutex
+	bodies  []map[string]interface{}
+	headers []http.Header
+	status  int
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, body)
+	r.headers = append(r.headers, req.Header.Clone())
+	if r.status != 0 {
+		w.WriteHeader(r.status)
+	}
+}
+
+func (r *recorder) received() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]interface{}(nil), r.bodies...)
+}
+
+func TestWebhook(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	webhook, err := NewWebhook(config.WebhookConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"X-Team": "platform"},
+		Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewWebhook failed: %v", err)
+	}
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	payload := NewPayload(events.Event{Type: events.AgentCrashed, Agent: "coder", Message: "PID 7", Err: errors.New("exit status 2"), Time: at})
+	if err := webhook.Send(payload); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	got := rec.received()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 payload, got %d", len(got))
+	}
+	body := got[0]
+	if body["event"] != events.AgentCrashed || body["agent"] != "coder" || body["error"] != "exit status 2" ||
+		body["timestamp"] != "2026-03-01T12:00:00Z" || body["host"] == "" {
+		t.Errorf("Unexpected payload %v", body)
+	}
+	if h := rec.headers[0]; h.Get("X-Team") != "platform" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the configured headers and a JSON content type, got %v", h)
+	}
+
+	rec.mu.Lock()
+	rec.status = http.StatusInternalServerError
+	rec.mu.Unlock()
+	if err := webhook.Send(payload); err == nil {
+		t.Error("Expected an error for a 500 response")
+	}
+}
+
+func TestNewWebhook(t *testing.T) {
+	t.Setenv("ASC_TEST_WEBHOOK_URL", "https://hooks.example.com/T000/secret-token")
+
+	webhook, err := NewWebhook(config.WebhookConfig{URLEnv: "ASC_TEST_WEBHOOK_URL"})
+	if err != nil {
+		t.Fatalf("NewWebhook failed: %v", err)
+	}
+	if webhook.url != "https://hooks.example.com/T000/secret-token" {
+		t.Errorf("Expected the URL of url_env, got %s", webhook.url)
+	}
+
+	if _, err := NewWebhook(config.WebhookConfig{URLEnv: "ASC_TEST_WEBHOOK_UNSET"}); err == nil {
+		t.Error("Expected an error for an unset url_env")
+	}
+	if _, err := NewWebhook(config.WebhookConfig{}); err == nil {
+		t.Error("Expected an error without a URL")
+	}
+}
@@ -0,0 +1,525 @@
+// Package pipeline advances a project through the phases configured in
+// [pipeline] (for example planning → implementation → testing → review).
+// A phase completes when its gate is met by the beads tasks in that phase,
+// and only the agents that handle the current phase are kept running.
+// Every transition is logged and emitted as an Event.
+//
+// Example usage:
+//
+//	orch, err := pipeline.NewOrchestrator(cfg, beadsClient, controller)
+//	if err != nil {
+//	    return err
+//	}
+//	orch.Start()
+//	defer orch.Stop()
+//
+//	for event := range orch.Events() {
+//	    fmt.Printf("%s: %s\n", event.Type, event.Message)
+//	}
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/logger"
+)
+
+// pipelineLog tags every record written by this package with the pipeline component
+var pipelineLog = logger.WithComponent("pipeline")
+
+// errComplete is returned when advancing a pipeline that is already complete
+var errComplete = errors.New("the pipeline is already complete\n  Suggestion: Run 'asc pipeline reset' to start over")
+
+// EventType identifies a pipeline transition
+type EventType string
+
+const (
+	EventPhaseStarted      EventType = "phase_started"      // A phase became current
+	EventPhaseCompleted    EventType = "phase_completed"    // A phase's gate was met (or it was advanced by hand)
+	EventPipelineCompleted EventType = "pipeline_completed" // The last phase completed
+	EventAgentActivated    EventType = "agent_activated"    // An agent was started for the current phase
+	EventAgentDeactivated  EventType = "agent_deactivated"  // An agent was stopped because its phases are not current
+	EventError             EventType = "error"              // A reconcile step failed
+)
+
+// Event describes a pipeline transition
+type Event struct {
+	Type      EventType `json:"type"`
+	Phase     string    `json:"phase,omitempty"`
+	Agent     string    `json:"agent,omitempty"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// doneStatuses are the task statuses that count towards a phase's gate.
+// Agents and bd use different words for a finished task.
+var doneStatuses = map[string]bool{
+	"closed":    true,
+	"done":      true,
+	"complete":  true,
+	"completed": true,
+}
+
+// IsDone reports whether a task status means the task is finished
+func IsDone(status string) bool {
+	return doneStatuses[strings.ToLower(status)]
+}
+
+// AgentController starts and stops agent processes by name
+type AgentController interface {
+	StartAgent(name string) error
+	StopAgent(name string) error
+	IsAgentRunning(name string) bool
+}
+
+// PhaseProgress summarizes the tasks of one phase against its gate
+type PhaseProgress struct {
+	Phase    string
+	Total    int
+	Done     int
+	GateMet  bool
+	MinTasks int
+	Required int // Done tasks needed to meet the gate with the current total
+}
+
+// Status is a snapshot of the pipeline
+type Status struct {
+	Phase        string // Current phase; empty once finished
+	Finished     bool
+	StartedAt    time.Time
+	Progress     []PhaseProgress
+	ActiveAgents []string
+	Completed    []PhaseRecord
+}
+
+// eventBufferSize is how many events are kept for a slow reader before new
+// ones are dropped (they are still logged)
+const eventBufferSize = 100
+
+// Orchestrator advances the pipeline and activates agents per phase
+type Orchestrator struct {
+	cfg       config.PipelineConfig
+	agents    map[string]config.AgentConfig
+	tasks     beads.BeadsClient
+	control   AgentController
+	statePath string
+
+	mu     sync.RWMutex
+	status Status
+
+	events   chan Event
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	// ctx is cancelled by Stop so an in-flight bd call is abandoned
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewOrchestrator creates an orchestrator that keeps its state in
+// ~/.asc/pipeline.json
+func NewOrchestrator(cfg config.Config, tasks beads.BeadsClient, control AgentController) (*Orchestrator, error) {
+	path, err := DefaultStatePath()
+	if err != nil {
+		return nil, err
+	}
+	return NewOrchestratorWithStatePath(cfg, tasks, control, path), nil
+}
+
+// NewOrchestratorWithStatePath creates an orchestrator that keeps its state
+// in path. A nil control only tracks phases and does not start or stop
+// agents, which is how the asc pipeline command uses it.
+func NewOrchestratorWithStatePath(cfg config.Config, tasks beads.BeadsClient, control AgentController, path string) *Orchestrator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Orchestrator{
+		cfg:       cfg.Pipeline,
+		agents:    cfg.Agents,
+		tasks:     tasks,
+		control:   control,
+		statePath: path,
+		events:    make(chan Event, eventBufferSize),
+		stopChan:  make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Events returns the channel on which transitions are delivered
+func (o *Orchestrator) Events() <-chan Event {
+	return o.events
+}
+
+// Start begins evaluating gates every pipeline.interval, starting now
+func (o *Orchestrator) Start() {
+	o.wg.Add(1)
+	go o.loop()
+	pipelineLog.Info("Pipeline started with phases: %s", strings.Join(o.cfg.Phases, " → "))
+}
+
+// Stop stops the reconcile loop. Running agents are left to the caller.
+func (o *Orchestrator) Stop() {
+	o.cancel()
+	close(o.stopChan)
+	o.wg.Wait()
+	pipelineLog.Info("Pipeline stopped")
+}
+
+func (o *Orchestrator) loop() {
+	defer o.wg.Done()
+
+	interval := o.interval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	o.reconcileCycle()
+	for {
+		select {
+		case <-ticker.C:
+			o.reconcileCycle()
+		case <-o.stopChan:
+			return
+		}
+	}
+}
+
+// interval returns pipeline.interval, defaulting to 30 seconds
+func (o *Orchestrator) interval() time.Duration {
+	if o.cfg.Interval <= 0 {
+		return 30 * time.Second
+	}
+	return o.cfg.Interval
+}
+
+// reconcileCycle runs Reconcile as its own correlated cycle. A cycle may
+// take at most one interval, so a hung bd cannot stall the pipeline.
+func (o *Orchestrator) reconcileCycle() {
+	logger.StartCorrelation()
+	ctx, cancel := context.WithTimeout(o.ctx, o.interval())
+	defer cancel()
+	if err := o.Reconcile(ctx); err != nil {
+		if o.ctx.Err() != nil {
+			return // Stopping
+		}
+		pipelineLog.Warn("Pipeline reconcile failed: %v", err)
+		o.emit(Event{Type: EventError, Message: err.Error()})
+	}
+}
+
+// Reconcile evaluates the current phase's gate, advances through every
+// phase whose gate is met, and starts or stops agents to match the
+// current phase. The state file is re-read first so changes made with
+// asc pipeline take effect on the next cycle.
+func (o *Orchestrator) Reconcile(ctx context.Context) error {
+	state, err := LoadState(o.statePath)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := o.tasks.GetTasks(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get tasks: %w", err)
+	}
+	progress := o.progress(tasks)
+
+	changed := o.begin(&state)
+	for !state.Finished {
+		current := progressOf(progress, state.Phase)
+		if !current.GateMet {
+			break
+		}
+		o.complete(&state, fmt.Sprintf("%d of %d tasks done", current.Done, current.Total), false)
+		changed = true
+	}
+	if changed {
+		if err := SaveState(o.statePath, state); err != nil {
+			return err
+		}
+	}
+
+	active := o.applyAgents(state)
+
+	o.mu.Lock()
+	o.status = Status{
+		Phase:        state.Phase,
+		Finished:     state.Finished,
+		StartedAt:    state.StartedAt,
+		Progress:     progress,
+		ActiveAgents: active,
+		Completed:    state.Completed,
+	}
+	o.mu.Unlock()
+	return nil
+}
+
+// Evaluate reports the pipeline's state and gate progress without
+// advancing it or touching agents
+func (o *Orchestrator) Evaluate(ctx context.Context) (Status, error) {
+	state, err := LoadState(o.statePath)
+	if err != nil {
+		return Status{}, err
+	}
+	tasks, err := o.tasks.GetTasks(ctx, nil)
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	status := Status{
+		Phase:     state.Phase,
+		Finished:  state.Finished,
+		StartedAt: state.StartedAt,
+		Progress:  o.progress(tasks),
+		Completed: state.Completed,
+	}
+	if !state.Finished && o.indexOf(state.Phase) < 0 {
+		status.Phase = o.cfg.Phases[0]
+	}
+	for _, name := range o.agentNames() {
+		agent := o.agents[name]
+		if !Manages(o.cfg, agent) || (!status.Finished && handles(agent, status.Phase)) {
+			status.ActiveAgents = append(status.ActiveAgents, name)
+		}
+	}
+	return status, nil
+}
+
+// Advance completes the current phase without waiting for its gate
+func (o *Orchestrator) Advance() (State, error) {
+	state, err := LoadState(o.statePath)
+	if err != nil {
+		return State{}, err
+	}
+	o.begin(&state)
+	if state.Finished {
+		return state, errComplete
+	}
+	o.complete(&state, "advanced by hand", true)
+	return state, SaveState(o.statePath, state)
+}
+
+// PlanAdvance returns the phase Advance would complete and the phase it
+// would move to, which is empty if advancing completes the pipeline. It
+// changes nothing and fails as Advance would on a completed pipeline.
+func (o *Orchestrator) PlanAdvance() (from, to string, err error) {
+	state, err := LoadState(o.statePath)
+	if err != nil {
+		return "", "", err
+	}
+	if state.Finished {
+		return "", "", errComplete
+	}
+	from = state.Phase
+	if o.indexOf(from) < 0 {
+		from = o.cfg.Phases[0]
+	}
+	if next := o.indexOf(from) + 1; next < len(o.cfg.Phases) {
+		to = o.cfg.Phases[next]
+	}
+	return from, to, nil
+}
+
+// FirstPhase returns the phase Reset returns the pipeline to
+func (o *Orchestrator) FirstPhase() string {
+	return o.cfg.Phases[0]
+}
+
+// Reset returns the pipeline to its first phase
+func (o *Orchestrator) Reset() (State, error) {
+	state := State{}
+	o.begin(&state)
+	return state, SaveState(o.statePath, state)
+}
+
+// Status returns the pipeline as of the last Reconcile
+func (o *Orchestrator) Status() Status {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.status
+}
+
+// IsAgentActive reports whether an agent should be running in the current
+// phase. Agents that handle none of the pipeline's phases are not managed
+// by the pipeline and are always active.
+func (o *Orchestrator) IsAgentActive(name string) bool {
+	agent, ok := o.agents[name]
+	if !ok || !Manages(o.cfg, agent) {
+		return true
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return !o.status.Finished && handles(agent, o.status.Phase)
+}
+
+// Manages reports whether an agent handles any of the pipeline's phases,
+// so that it runs only while one of them is current
+func Manages(cfg config.PipelineConfig, agent config.AgentConfig) bool {
+	for _, phase := range cfg.Phases {
+		if handles(agent, phase) {
+			return true
+		}
+	}
+	return false
+}
+
+// begin moves a fresh or stale state (one whose phase is no longer
+// configured) to the first phase. Returns true if the state changed.
+func (o *Orchestrator) begin(state *State) bool {
+	if state.Finished || o.indexOf(state.Phase) >= 0 {
+		return false
+	}
+	if state.Phase != "" {
+		pipelineLog.Warn("Phase %s is no longer in the pipeline; restarting from %s", state.Phase, o.cfg.Phases[0])
+	}
+	state.Phase = o.cfg.Phases[0]
+	state.StartedAt = time.Now()
+	o.emit(Event{Type: EventPhaseStarted, Phase: state.Phase, Message: fmt.Sprintf("Phase %s started", state.Phase)})
+	return true
+}
+
+// complete records the current phase as done and moves to the next one
+func (o *Orchestrator) complete(state *State, reason string, forced bool) {
+	now := time.Now()
+	state.Completed = append(state.Completed, PhaseRecord{
+		Phase:       state.Phase,
+		StartedAt:   state.StartedAt,
+		CompletedAt: now,
+		Forced:      forced,
+	})
+	o.emit(Event{Type: EventPhaseCompleted, Phase: state.Phase, Message: fmt.Sprintf("Phase %s completed (%s)", state.Phase, reason)})
+
+	next := o.indexOf(state.Phase) + 1
+	if next >= len(o.cfg.Phases) {
+		state.Phase = ""
+		state.Finished = true
+		o.emit(Event{Type: EventPipelineCompleted, Message: "All pipeline phases completed"})
+		return
+	}
+	state.Phase = o.cfg.Phases[next]
+	state.StartedAt = now
+	o.emit(Event{Type: EventPhaseStarted, Phase: state.Phase, Message: fmt.Sprintf("Phase %s started", state.Phase)})
+}
+
+// applyAgents starts the agents of the current phase and stops the
+// managed agents of other phases. Returns the active agents, sorted.
+func (o *Orchestrator) applyAgents(state State) []string {
+	active := []string{}
+	for _, name := range o.agentNames() {
+		agent := o.agents[name]
+		if !Manages(o.cfg, agent) {
+			active = append(active, name)
+			continue
+		}
+		want := !state.Finished && handles(agent, state.Phase)
+		if want {
+			active = append(active, name)
+		}
+		if o.control == nil {
+			continue
+		}
+
+		running := o.control.IsAgentRunning(name)
+		switch {
+		case want && !running:
+			if err := o.control.StartAgent(name); err != nil {
+				pipelineLog.WithFields(logger.Fields{"agent": name}).Error("Failed to activate agent: %v", err)
+				o.emit(Event{Type: EventError, Agent: name, Phase: state.Phase, Message: fmt.Sprintf("Failed to start %s: %v", name, err)})
+				continue
+			}
+			o.emit(Event{Type: EventAgentActivated, Agent: name, Phase: state.Phase, Message: fmt.Sprintf("Agent %s activated for %s", name, state.Phase)})
+		case !want && running:
+			if err := o.control.StopAgent(name); err != nil {
+				pipelineLog.WithFields(logger.Fields{"agent": name}).Error("Failed to deactivate agent: %v", err)
+				o.emit(Event{Type: EventError, Agent: name, Phase: state.Phase, Message: fmt.Sprintf("Failed to stop %s: %v", name, err)})
+				continue
+			}
+			o.emit(Event{Type: EventAgentDeactivated, Agent: name, Phase: state.Phase, Message: fmt.Sprintf("Agent %s deactivated", name)})
+		}
+	}
+	return active
+}
+
+// progress counts each pipeline phase's tasks against its gate
+func (o *Orchestrator) progress(tasks []beads.Task) []PhaseProgress {
+	progress := make([]PhaseProgress, len(o.cfg.Phases))
+	for i, phase := range o.cfg.Phases {
+		progress[i].Phase = phase
+		for _, task := range tasks {
+			if !strings.EqualFold(task.Phase, phase) {
+				continue
+			}
+			progress[i].Total++
+			if IsDone(task.Status) {
+				progress[i].Done++
+			}
+		}
+
+		gate := o.cfg.Gate(phase)
+		required := int(math.Ceil(gate.Completion * float64(progress[i].Total)))
+		progress[i].MinTasks = gate.MinTasks
+		progress[i].Required = required
+		progress[i].GateMet = progress[i].Total >= gate.MinTasks && progress[i].Done >= required
+	}
+	return progress
+}
+
+// emit logs an event and delivers it without blocking the pipeline
+func (o *Orchestrator) emit(event Event) {
+	event.Timestamp = time.Now()
+	pipelineLog.WithFields(logger.Fields{
+		"event": string(event.Type),
+		"phase": event.Phase,
+		"agent": event.Agent,
+	}).Info("%s", event.Message)
+
+	select {
+	case o.events <- event:
+	default:
+	}
+}
+
+// agentNames returns the configured agent names, sorted
+func (o *Orchestrator) agentNames() []string {
+	names := make([]string, 0, len(o.agents))
+	for name := range o.agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (o *Orchestrator) indexOf(phase string) int {
+	for i, p := range o.cfg.Phases {
+		if phase != "" && strings.EqualFold(p, phase) {
+			return i
+		}
+	}
+	return -1
+}
+
+// progressOf returns the progress of phase, or a zero value if unknown
+func progressOf(progress []PhaseProgress, phase string) PhaseProgress {
+	for _, p := range progress {
+		if strings.EqualFold(p.Phase, phase) {
+			return p
+		}
+	}
+	return PhaseProgress{Phase: phase}
+}
+
+// handles reports whether an agent is configured for phase
+func handles(agent config.AgentConfig, phase string) bool {
+	for _, p := range agent.Phases {
+		if strings.EqualFold(p, phase) {
+			return true
+		}
+	}
+	return false
+}
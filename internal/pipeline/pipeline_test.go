@@ -0,0 +1,199 @@
+package pipeline
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+)
+
+// mockTasks is a beads client that returns a fixed task list
+type mockTasks struct {
+	tasks []beads.Task
+}
+
+func (m *mockTasks) GetTasks(ctx context.Context, statuses []string) ([]beads.Task, error) {
+	return m.tasks, nil
+}
+func (m *mockTasks) CreateTask(ctx context.Context, title string) (beads.Task, error) {
+	return beads.Task{}, nil
+}
+func (m *mockTasks) UpdateTask(ctx context.Context, id string, updates beads.TaskUpdate) error {
+	return nil
+}
+func (m *mockTasks) DeleteTask(ctx context.Context, id string) error { return nil }
+func (m *mockTasks) Refresh(ctx context.Context) error               { return nil }
+
+// mockController records which agents are running
+type mockController struct {
+	running map[string]bool
+}
+
+func (m *mockController) StartAgent(name string) error    { m.running[name] = true; return nil }
+func (m *mockController) StopAgent(name string) error     { delete(m.running, name); return nil }
+func (m *mockController) IsAgentRunning(name string) bool { return m.running[name] }
+
+func testConfig() config.Config {
+	return config.Config{
+		Pipeline: config.PipelineConfig{
+			Phases: []string{"planning", "implementation", "testing"},
+			Gates: map[string]config.GateConfig{
+				"implementation": {Completion: 0.5},
+			},
+		},
+		Agents: map[string]config.AgentConfig{
+			"planner": {Phases: []string{"planning"}},
+			"coder":   {Phases: []string{"implementation", "testing"}},
+			"writer":  {Phases: []string{"documentation"}},
+		},
+	}
+}
+
+func drain(o *Orchestrator) []EventType {
+	var types []EventType
+	for {
+		select {
+		case event := <-o.events:
+			types = append(types, event.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestReconcileAdvancesThroughGates(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "pipeline.json")
+	tasks := &mockTasks{}
+	control := &mockController{running: map[string]bool{"coder": true}}
+	orch := NewOrchestratorWithStatePath(testConfig(), tasks, control, statePath)
+
+	// No tasks yet: planning stays current and only its agents run
+	if err := orch.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if status := orch.Status(); status.Phase != "planning" {
+		t.Fatalf("Expected planning phase, got %+v", status)
+	}
+	if !control.running["planner"] || control.running["coder"] {
+		t.Errorf("Expected only planner to run, got %v", control.running)
+	}
+	if !orch.IsAgentActive("writer") || orch.IsAgentActive("coder") {
+		t.Error("Expected writer (unmanaged) active and coder inactive")
+	}
+	events := drain(orch)
+	want := []EventType{EventPhaseStarted, EventAgentActivated, EventAgentDeactivated}
+	if len(events) != len(want) {
+		t.Fatalf("Events = %v, want %v", events, want)
+	}
+
+	// Planning done; half of implementation done meets its 50% gate too
+	tasks.tasks = []beads.Task{
+		{ID: "1", Phase: "planning", Status: "closed"},
+		{ID: "2", Phase: "implementation", Status: "done"},
+		{ID: "3", Phase: "implementation", Status: "open"},
+	}
+	if err := orch.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	status := orch.Status()
+	if status.Phase != "testing" || len(status.Completed) != 2 {
+		t.Fatalf("Expected to reach testing with 2 completed phases, got %+v", status)
+	}
+	if control.running["planner"] || !control.running["coder"] {
+		t.Errorf("Expected only coder to run, got %v", control.running)
+	}
+
+	// The state survives a new orchestrator
+	state, err := LoadState(statePath)
+	if err != nil || state.Phase != "testing" {
+		t.Errorf("LoadState() = %+v, %v", state, err)
+	}
+
+	// Finishing the last phase completes the pipeline and stops managed agents
+	tasks.tasks = append(tasks.tasks, beads.Task{ID: "4", Phase: "testing", Status: "completed"})
+	drain(orch)
+	if err := orch.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if status := orch.Status(); !status.Finished {
+		t.Errorf("Expected the pipeline to finish, got %+v", status)
+	}
+	if control.running["coder"] {
+		t.Error("Expected coder to stop once the pipeline finished")
+	}
+	found := false
+	for _, event := range drain(orch) {
+		found = found || event == EventPipelineCompleted
+	}
+	if !found {
+		t.Error("Expected a pipeline_completed event")
+	}
+}
+
+func TestAdvanceAndReset(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "pipeline.json")
+	orch := NewOrchestratorWithStatePath(testConfig(), &mockTasks{}, nil, statePath)
+
+	// Planning an advance changes nothing
+	for i := 0; i < 2; i++ {
+		if from, to, err := orch.PlanAdvance(); err != nil || from != "planning" || to != "implementation" {
+			t.Fatalf("PlanAdvance() = %q, %q, %v", from, to, err)
+		}
+	}
+
+	state, err := orch.Advance()
+	if err != nil {
+		t.Fatalf("Advance() error = %v", err)
+	}
+	if state.Phase != "implementation" || len(state.Completed) != 1 || !state.Completed[0].Forced {
+		t.Errorf("Unexpected state after Advance(): %+v", state)
+	}
+
+	// Evaluate reports without changing the state
+	status, err := orch.Evaluate(context.Background())
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if status.Phase != "implementation" || len(status.Progress) != 3 || status.Progress[1].GateMet {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	if len(status.ActiveAgents) != 2 || status.ActiveAgents[0] != "coder" {
+		t.Errorf("Expected coder and writer active, got %v", status.ActiveAgents)
+	}
+
+	orch.Advance()
+	if _, err := orch.Advance(); err != nil {
+		t.Fatalf("Advance() error = %v", err)
+	}
+	if _, err := orch.Advance(); err == nil {
+		t.Error("Advance() past the last phase should fail")
+	}
+	if _, _, err := orch.PlanAdvance(); err == nil {
+		t.Error("PlanAdvance() past the last phase should fail")
+	}
+
+	state, err = orch.Reset()
+	if err != nil || state.Phase != "planning" || state.Finished || len(state.Completed) != 0 {
+		t.Errorf("Reset() = %+v, %v", state, err)
+	}
+}
+
+func TestProgressGate(t *testing.T) {
+	cfg := testConfig()
+	cfg.Pipeline.Gates["testing"] = config.GateConfig{MinTasks: 3}
+	orch := NewOrchestratorWithStatePath(cfg, &mockTasks{}, nil, filepath.Join(t.TempDir(), "pipeline.json"))
+
+	progress := orch.progress([]beads.Task{
+		{Phase: "testing", Status: "closed"},
+		{Phase: "Testing", Status: "closed"},
+	})
+	phase := progress[2]
+	if phase.Total != 2 || phase.Done != 2 || phase.GateMet {
+		t.Errorf("Expected testing gate unmet below min_tasks, got %+v", phase)
+	}
+	if progress[0].GateMet {
+		t.Error("Expected a phase without tasks not to meet its gate")
+	}
+}
@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// State is the pipeline's progress, saved between runs so a restarted
+// stack resumes in the same phase
+type State struct {
+	Phase     string        `json:"phase"`      // Current phase; empty before the first reconcile
+	StartedAt time.Time     `json:"started_at"` // When the current phase started
+	Finished  bool          `json:"finished"`   // Every phase is complete
+	Completed []PhaseRecord `json:"completed,omitempty"`
+}
+
+// PhaseRecord records a completed phase
+type PhaseRecord struct {
+	Phase       string    `json:"phase"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Forced      bool      `json:"forced,omitempty"` // Advanced by hand before its gate was met
+}
+
+// DefaultStatePath returns ~/.asc/pipeline.json
+func DefaultStatePath() (string, error) {
+	return statedir.Path("pipeline.json")
+}
+
+// LoadState reads the pipeline state from path. A missing file is not an
+// error and yields the zero State.
+func LoadState(path string) (State, error) {
+	var state State
+	err := statefile.ReadJSON(path, &state)
+	if os.IsNotExist(err) {
+		return State{}, nil
+	}
+	if errors.Is(err, statefile.ErrCorrupt) {
+		return State{}, fmt.Errorf("%v\n  Suggestion: The pipeline starts over from its first phase; use 'asc pipeline advance' to skip finished phases", err)
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("failed to read pipeline state: %w", err)
+	}
+	return state, nil
+}
+
+// SaveState writes the pipeline state to path, replacing it atomically
+func SaveState(path string, state State) error {
+	if err := statefile.WriteJSON(path, state, 0600); err != nil {
+		return fmt.Errorf("failed to write pipeline state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package process
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// Crash is what is kept of a process that exited with a failure on its
+// own: how and when it exited, and the last words it wrote to its log.
+// The Manager saves one to <crash dir>/<name>-<time>.json each time.
+type Crash struct {
+	ID        string    `json:"id"` // <name>-<time>, the file name without .json
+	Name      string    `json:"name"`
+	PID       int       `json:"pid"`
+	Command   string    `json:"command"`
+	Args      []string  `json:"args,omitempty"`
+	ExitCode  int       `json:"exit_code"`        // -1 if killed by a signal
+	Signal    string    `json:"signal,omitempty"` // The signal that killed it, e.g. "SIGSEGV"
+	StartedAt time.Time `json:"started_at"`
+	ExitedAt  time.Time `json:"exited_at"`
+	Restarts  int       `json:"restarts"` // Restarts before this run
+	LogFile   string    `json:"log_file"`
+	LogTail   string    `json:"log_tail"` // The end of what this run wrote to the log
+}
+
+// CrashCapture says where and how much the Manager keeps of the processes
+// it started that fail
+type CrashCapture struct {
+	Dir      string // Directory crashes are saved in (default: crashes beside the PID directory)
+	LogBytes int64  // How much of the end of the log is kept (default: DefaultCrashLogBytes)
+	MaxFiles int    // Crashes kept per process name, the oldest removed first (default: DefaultCrashesKept)
+}
+
+// Crash capture defaults
+const (
+	DefaultCrashLogBytes = 64 * 1024
+	DefaultCrashesKept   = 20
+)
+
+// SetCrashCapture sets how the processes this Manager starts are captured
+// when they fail
+func (m *Manager) SetCrashCapture(capture CrashCapture) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.crashes = capture
+}
+
+// crashCapture returns the crash capture with its unset fields defaulted
+func (m *Manager) crashCapture() CrashCapture {
+	m.mu.Lock()
+	capture := m.crashes
+	m.mu.Unlock()
+	if capture.Dir == "" {
+		capture.Dir = filepath.Join(filepath.Dir(m.pidDir), "crashes")
+	}
+	if capture.LogBytes <= 0 {
+		capture.LogBytes = DefaultCrashLogBytes
+	}
+	if capture.MaxFiles <= 0 {
+		capture.MaxFiles = DefaultCrashesKept
+	}
+	return capture
+}
+
+// CrashDir returns the directory this Manager saves crashes in
+func (m *Manager) CrashDir() string {
+	return m.crashCapture().Dir
+}
+
+// recordCrash saves the crash of a process this Manager started that
+// exited with a failure. A crash that cannot be saved is logged.
+func (m *Manager) recordCrash(name string, pid int, state *os.ProcessState, command string, args []string, started, exited time.Time) {
+	capture := m.crashCapture()
+	crash := &Crash{
+		ID:        fmt.Sprintf("%s-%s", name, exited.UTC().Format("20060102T150405.000Z")),
+		Name:      name,
+		PID:       pid,
+		Command:   command,
+		Args:      args,
+		ExitCode:  state.ExitCode(),
+		Signal:    exitSignal(state),
+		StartedAt: started,
+		ExitedAt:  exited,
+		LogFile:   filepath.Join(m.logDir, fmt.Sprintf("%s.log", name)),
+	}
+	var logOffset int64
+	if info, err := m.GetProcessInfo(name); err == nil && info.PID == pid {
+		crash.Restarts = info.Restarts
+		crash.LogFile = info.LogFile
+		logOffset = info.LogOffset
+	}
+	fields := logger.Fields{"name": name, "pid": pid}
+	tail, err := readLogTail(crash.LogFile, logOffset, capture.LogBytes)
+	if err != nil {
+		processLog.WithFields(fields).Warn("Failed to read the log of the crashed process: %v", err)
+	}
+	crash.LogTail = tail
+
+	if err := saveCrash(capture.Dir, crash); err != nil {
+		processLog.WithFields(fields).Warn("Failed to save crash: %v", err)
+		return
+	}
+	processLog.WithFields(fields).Info("Saved crash %s", crash.ID)
+	if err := pruneCrashes(capture.Dir, name, capture.MaxFiles); err != nil {
+		processLog.WithFields(fields).Warn("Failed to remove old crashes: %v", err)
+	}
+}
+
+// exitSignal returns the name of the signal that killed a process, or ""
+// if it exited by itself
+func exitSignal(state *os.ProcessState) string {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+	return signalName(status.Signal())
+}
+
+// readLogTail returns the last max bytes of the log at path that were
+// written from offset on, where the run that crashed started writing, or
+// of the whole log if it was rotated since. A partial first line is
+// dropped.
+func readLogTail(path string, offset, max int64) (string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := stat.Size()
+	if offset > size {
+		offset = 0
+	}
+	start := size - max
+	if start < offset {
+		start = offset
+	}
+	// The byte before the tail tells whether it starts a line
+	from := start
+	if start > offset {
+		from--
+	}
+	data := make([]byte, size-from)
+	if _, err := file.ReadAt(data, from); err != nil && err != io.EOF {
+		return "", err
+	}
+	if from < start {
+		if data[0] == '\n' {
+			data = data[1:]
+		} else if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		} else {
+			data = nil
+		}
+	}
+	return string(data), nil
+}
+
+// saveCrash writes a crash to dir, readable by its owner only as the log
+// it holds may be
+func saveCrash(dir string, crash *Crash) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create crash directory: %w", err)
+	}
+	data, err := json.MarshalIndent(crash, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, crash.ID+".json"), data, 0600)
+}
+
+// ListCrashes returns the crashes saved in dir, the newest first. A
+// missing directory holds none, and files that are not crashes are
+// skipped.
+func ListCrashes(dir string) ([]*Crash, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crash directory: %w", err)
+	}
+	var crashes []*Crash
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		crash, err := loadCrashFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		crashes = append(crashes, crash)
+	}
+	sort.Slice(crashes, func(i, j int) bool {
+		return crashes[i].ExitedAt.After(crashes[j].ExitedAt)
+	})
+	return crashes, nil
+}
+
+// LoadCrash returns the crash with the given ID saved in dir
+func LoadCrash(dir, id string) (*Crash, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid crash ID '%s'", id)
+	}
+	crash, err := loadCrashFile(filepath.Join(dir, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no crash '%s'", id)
+	}
+	return crash, err
+}
+
+// loadCrashFile reads a saved crash
+func loadCrashFile(path string) (*Crash, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var crash Crash
+	if err := json.Unmarshal(data, &crash); err != nil {
+		return nil, fmt.Errorf("failed to parse crash %s: %w", path, err)
+	}
+	if crash.ID == "" {
+		crash.ID = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	return &crash, nil
+}
+
+// pruneCrashes removes the oldest crashes of the named process beyond the
+// newest keep
+func pruneCrashes(dir, name string, keep int) error {
+	crashes, err := ListCrashes(dir)
+	if err != nil {
+		return err
+	}
+	kept := 0
+	for _, crash := range crashes {
+		if crash.Name != name {
+			continue
+		}
+		if kept++; kept <= keep {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, crash.ID+".json")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForCrashes waits until dir holds n crashes and returns them
+func waitForCrashes(t *testing.T, dir string, n int) []*Crash {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		crashes, err := ListCrashes(dir)
+		if err != nil {
+			t.Fatalf("ListCrashes failed: %v", err)
+		}
+		if len(crashes) >= n {
+			return crashes
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d crash(es), got %d", n, len(crashes))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCrash_ExitStatus(t *testing.T) {
+	manager := newRestartTestManager(t)
+	pid, err := manager.Start("failing", "sh", []string{"-c", "echo starting; echo last words >&2; exit 3"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	crash := waitForCrashes(t, manager.CrashDir(), 1)[0]
+	if crash.Name != "failing" || crash.PID != pid || crash.ExitCode != 3 || crash.Signal != "" {
+		t.Errorf("Unexpected crash %+v", crash)
+	}
+	if !strings.Contains(crash.LogTail, "starting\nlast words\n") {
+		t.Errorf("Expected the last output in the crash, got %q", crash.LogTail)
+	}
+	if crash.ExitedAt.Before(crash.StartedAt) {
+		t.Errorf("Exited at %v, before starting at %v", crash.ExitedAt, crash.StartedAt)
+	}
+
+	loaded, err := LoadCrash(manager.CrashDir(), crash.ID)
+	if err != nil {
+		t.Fatalf("LoadCrash failed: %v", err)
+	}
+	if loaded.ID != crash.ID || loaded.LogTail != crash.LogTail {
+		t.Errorf("LoadCrash returned %+v, want %+v", loaded, crash)
+	}
+	stat, err := os.Stat(filepath.Join(manager.CrashDir(), crash.ID+".json"))
+	if err != nil {
+		t.Fatalf("Expected the crash file: %v", err)
+	}
+	if perm := stat.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected the crash file to be readable by its owner only, got %v", perm)
+	}
+	if _, err := LoadCrash(manager.CrashDir(), "../pids/failing"); err == nil {
+		t.Error("Expected an ID with a path to be rejected")
+	}
+}
+
+func TestCrash_Signal(t *testing.T) {
+	manager := newRestartTestManager(t)
+	if _, err := manager.Start("segfault", "sh", []string{"-c", "kill -SEGV $$"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	crash := waitForCrashes(t, manager.CrashDir(), 1)[0]
+	if crash.Signal != "SIGSEGV" || crash.ExitCode != -1 {
+		t.Errorf("Expected a crash by SIGSEGV, got signal %q and exit code %d", crash.Signal, crash.ExitCode)
+	}
+}
+
+func TestCrash_NotForStopOrSuccess(t *testing.T) {
+	manager := newRestartTestManager(t)
+	pid, err := manager.Start("stopped", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := manager.Stop(context.Background(), pid); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if _, err := manager.Start("done", "true", nil, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	// A crash after them shows their exits were seen and not recorded
+	if _, err := manager.Start("failing", "false", nil, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	crashes := waitForCrashes(t, manager.CrashDir(), 1)
+	if len(crashes) != 1 || crashes[0].Name != "failing" {
+		t.Errorf("Expected only the failing process to crash, got %d crash(es)", len(crashes))
+	}
+}
+
+func TestCrash_Pruned(t *testing.T) {
+	manager := newRestartTestManager(t)
+	manager.SetCrashCapture(CrashCapture{MaxFiles: 2, LogBytes: 16})
+	recorder := recordRestarts(manager)
+	manager.SetRestartPolicy("flaky", RestartPolicy{Mode: RestartOnFailure, MaxRetries: 2, Backoff: 10 * time.Millisecond})
+	if _, err := manager.Start("flaky", "sh", []string{"-c", "echo one two three four five six; exit 1"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	recorder.wait(t, 2)
+
+	deadline := time.Now().Add(5 * time.Second)
+	var crashes []*Crash
+	for {
+		crashes, _ = ListCrashes(manager.CrashDir())
+		// The third crash is in once its restart count is
+		if len(crashes) == 2 && crashes[0].Restarts == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the 2 newest crashes to be kept, got %d", len(crashes))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, crash := range crashes {
+		// 16 bytes of the line reach back into it, so it is left out
+		if crash.LogTail != "" {
+			t.Errorf("Expected a partial line to be left out, got %q", crash.LogTail)
+		}
+	}
+}
+
+func TestReadLogTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	if err := os.WriteFile(path, []byte("earlier run\nfirst\nsecond\nthird\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	offset := int64(len("earlier run\n"))
+
+	tests := []struct {
+		name   string
+		offset int64
+		max    int64
+		want   string
+	}{
+		{"this run", offset, 1024, "first\nsecond\nthird\n"},
+		{"partial line dropped", offset, 10, "third\n"},
+		{"rotated since", 1024, 13, "second\nthird\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readLogTail(path, tt.offset, tt.max)
+			if err != nil {
+				t.Fatalf("readLogTail failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readLogTail = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got, err := readLogTail(filepath.Join(t.TempDir(), "missing.log"), 0, 1024); err != nil || got != "" {
+		t.Errorf("Expected a missing log to be empty, got %q, %v", got, err)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !windows
+
 package process
 
 import (
@@ -75,7 +77,7 @@ func TestStart_ErrorPaths(t *testing.T) {
 					t.Error("Expected error but got nil")
 					if pid > 0 {
 						// Clean up
-						mgr.Stop(pid)
+						mgr.Stop(context.Background(), pid)
 					}
 				} else if tt.errorMsg != "" && !contains(err.Error(), tt.errorMsg) {
 					t.Errorf("Expected error to contain %q, got: %v", tt.errorMsg, err)
@@ -86,7 +88,7 @@ func TestStart_ErrorPaths(t *testing.T) {
 				}
 				if pid > 0 {
 					// Clean up
-					mgr.Stop(pid)
+					mgr.Stop(context.Background(), pid)
 				}
 			}
 		})
@@ -156,7 +158,7 @@ func TestStop_ErrorPaths(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			pid := tt.setupFunc()
-			err := mgr.Stop(pid)
+			err := mgr.Stop(context.Background(), pid)
 
 			if tt.expectError {
 				if err == nil {
@@ -213,7 +215,7 @@ func TestIsRunning_ErrorPaths(t *testing.T) {
 				time.Sleep(200 * time.Millisecond) // Give more time for process to exit
 				return pid
 			},
-			expectedState: true, // IsRunning may not detect quick exits immediately
+			expectedState: false, // The Manager reaps the processes it starts
 		},
 	}
 
@@ -257,7 +259,7 @@ func TestStopAll_ErrorPaths(t *testing.T) {
 	}
 
 	// StopAll should handle the already-dead process gracefully
-	err = mgr.StopAll()
+	err = mgr.StopAll(context.Background())
 	
 	// StopAll may return an error if some processes were already dead
 	if err != nil {
@@ -285,6 +287,8 @@ func TestTimeout_ErrorPaths(t *testing.T) {
 
 	// Start a process that ignores SIGTERM
 	name := "stubborn-process"
+	// Its grace period is well under the limit the test gives Stop
+	mgr.SetStopPolicy(name, StopPolicy{GracePeriod: 500 * time.Millisecond})
 	pid, err := mgr.Start(name, "sh", []string{"-c", "trap '' TERM; sleep 100"}, nil)
 	if err != nil {
 		t.Fatal(err)
@@ -296,7 +300,7 @@ func TestTimeout_ErrorPaths(t *testing.T) {
 
 	done := make(chan error, 1)
 	go func() {
-		done <- mgr.Stop(pid)
+		done <- mgr.Stop(context.Background(), pid)
 	}()
 
 	select {
@@ -398,7 +402,7 @@ func TestPanicRecovery(t *testing.T) {
 
 	// Try operations that might panic
 	mgr.IsRunning(0)
-	mgr.Stop(0)
+	mgr.Stop(context.Background(), 0)
 	mgr.GetStatus(0)
 }
 
@@ -423,7 +427,7 @@ func TestConcurrentOperations(t *testing.T) {
 	done := make(chan error, 3)
 	for i := 0; i < 3; i++ {
 		go func() {
-			done <- mgr.Stop(pid)
+			done <- mgr.Stop(context.Background(), pid)
 		}()
 	}
 
@@ -477,7 +481,7 @@ func TestInvalidInput(t *testing.T) {
 		{
 			name: "stop with invalid PID",
 			fn: func() error {
-				return mgr.Stop(-1)
+				return mgr.Stop(context.Background(), -1)
 			},
 		},
 	}
@@ -518,7 +522,7 @@ func TestCommandInjection(t *testing.T) {
 			if err == nil {
 				t.Error("Expected error for malicious input")
 				if pid > 0 {
-					mgr.Stop(pid)
+					mgr.Stop(context.Background(), pid)
 				}
 			}
 			// Verify no actual command execution occurred
@@ -0,0 +1,284 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// HealthAction is what the Manager does once a process failed its health
+// check Retries times in a row
+type HealthAction string
+
+const (
+	// HealthRestart stops the process and starts it again
+	HealthRestart HealthAction = "restart"
+	// HealthNotify marks the process degraded and runs NotifyCommand
+	HealthNotify HealthAction = "notify"
+	// HealthDegrade only marks the process degraded
+	HealthDegrade HealthAction = "degrade"
+)
+
+// HealthStatus is the outcome of a process's health checks so far
+type HealthStatus string
+
+const (
+	HealthStarting  HealthStatus = "starting"  // Not checked yet
+	HealthHealthy   HealthStatus = "healthy"   // Passed its last check, or failed fewer than Retries in a row
+	HealthDegraded  HealthStatus = "degraded"  // Failing, and left running
+	HealthUnhealthy HealthStatus = "unhealthy" // Failing, and being restarted
+)
+
+// Health is the health of a process, saved with it so asc status and the
+// TUI of any asc can show it
+type Health struct {
+	Status    HealthStatus `json:"status"`
+	Failures  int          `json:"failures,omitempty"` // Failed checks in a row
+	CheckedAt time.Time    `json:"checked_at,omitempty"`
+	Error     string       `json:"error,omitempty"` // Why the last check failed
+}
+
+// Probe checks whether a running process is healthy, returning why not
+type Probe func(ctx context.Context, info *ProcessInfo) error
+
+// HealthCheck probes a process every Interval while it runs. After
+// Retries failures in a row the Manager takes Action; a passing check
+// makes the process healthy again.
+type HealthCheck struct {
+	Probe         Probe
+	Interval      time.Duration // Time between checks (default: 30s)
+	Timeout       time.Duration // Limit for one check (default: 10s)
+	Retries       int           // Failures in a row before Action (default: 3)
+	Action        HealthAction  // (default: HealthRestart)
+	NotifyCommand string        // Shell command run by HealthNotify, with ASC_HEALTH_* set
+}
+
+// Health check defaults
+const (
+	defaultHealthInterval = 30 * time.Second
+	defaultHealthTimeout  = 10 * time.Second
+	defaultHealthRetries  = 3
+)
+
+// withDefaults returns the check with its unset fields defaulted
+func (c HealthCheck) withDefaults() HealthCheck {
+	if c.Interval <= 0 {
+		c.Interval = defaultHealthInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultHealthTimeout
+	}
+	if c.Retries <= 0 {
+		c.Retries = defaultHealthRetries
+	}
+	if c.Action == "" {
+		c.Action = HealthRestart
+	}
+	return c
+}
+
+// ExecProbe passes while command, run with the shell and AGENT_NAME and
+// AGENT_PID set, exits with status 0
+func ExecProbe(command string) Probe {
+	return func(ctx context.Context, info *ProcessInfo) error {
+		cmd := shellCommand(ctx, command)
+		cmd.Env = append(os.Environ(),
+			fmt.Sprintf("AGENT_NAME=%s", info.Name),
+			fmt.Sprintf("AGENT_PID=%d", info.PID),
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			if out := strings.TrimSpace(string(output)); out != "" {
+				return fmt.Errorf("%w: %s", err, out)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// HTTPProbe passes while url answers GET with a 2xx or 3xx status
+func HTTPProbe(url string) Probe {
+	client := &http.Client{Transport: &http.Transport{Proxy: nil}}
+	return func(ctx context.Context, info *ProcessInfo) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("%s answered %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// SetHealthCheck sets the health check of the named process, applied to
+// the processes of that name this Manager starts from then on
+func (m *Manager) SetHealthCheck(name string, check HealthCheck) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.healthChecks == nil {
+		m.healthChecks = make(map[string]HealthCheck)
+	}
+	m.healthChecks[name] = check
+}
+
+// SetOnHealthChange sets a function called when the health status of a
+// process this Manager checks changes
+func (m *Manager) SetOnHealthChange(onChange func(name string, health Health)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onHealthChange = onChange
+}
+
+// healthCheckFor returns the health check of the named process and
+// whether it has one
+func (m *Manager) healthCheckFor(name string) (HealthCheck, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	check, ok := m.healthChecks[name]
+	if !ok || check.Probe == nil {
+		return HealthCheck{}, false
+	}
+	return check.withDefaults(), true
+}
+
+// checkHealthWhileRunning checks the health of a process this Manager
+// started until it exits or is replaced. command, args, and env are what
+// it was started with, for HealthRestart.
+func (m *Manager) checkHealthWhileRunning(name string, pid int, check HealthCheck, command string, args, env []string) {
+	health := Health{Status: HealthStarting}
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		info, err := m.GetProcessInfo(name)
+		if err != nil || info.PID != pid || !m.IsRunning(pid) {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), check.Timeout)
+		err = check.Probe(ctx, info)
+		cancel()
+
+		previous := health
+		health.CheckedAt = time.Now()
+		fields := logger.Fields{"name": name, "pid": pid}
+		if err == nil {
+			health = Health{Status: HealthHealthy, CheckedAt: health.CheckedAt}
+			if previous.Failures > 0 {
+				processLog.WithFields(fields).Info("Health check passed again")
+			}
+		} else {
+			health.Failures++
+			health.Error = err.Error()
+			processLog.WithFields(fields).Warn("Health check failed (%d in a row): %v", health.Failures, err)
+			switch {
+			case health.Failures < check.Retries:
+				// Not yet failing; a process not checked yet stays starting
+			case check.Action == HealthRestart:
+				health.Status = HealthUnhealthy
+			default:
+				health.Status = HealthDegraded
+			}
+		}
+		if health.Status != previous.Status || health.Failures != previous.Failures {
+			m.saveHealth(name, pid, health)
+		}
+		if health.Status == previous.Status {
+			continue
+		}
+
+		m.mu.Lock()
+		onChange := m.onHealthChange
+		m.mu.Unlock()
+		if onChange != nil {
+			onChange(name, health)
+		}
+
+		switch health.Status {
+		case HealthUnhealthy:
+			processLog.WithFields(fields).Error("Process unhealthy, restarting it")
+			if err := m.restartUnhealthy(name, pid, command, args, env); err != nil {
+				processLog.WithFields(fields).Error("Failed to restart unhealthy process: %v", err)
+			}
+			return
+		case HealthDegraded:
+			processLog.WithFields(fields).Error("Process degraded after %d failed health checks", health.Failures)
+			if check.Action == HealthNotify && check.NotifyCommand != "" {
+				notifyHealth(name, pid, health, check)
+			}
+		}
+	}
+}
+
+// saveHealth records the health of a process in its PID file, unless the
+// process was replaced in the meantime
+func (m *Manager) saveHealth(name string, pid int, health Health) {
+	unlock, err := m.lockName(name)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	info, err := m.GetProcessInfo(name)
+	if err != nil || info.PID != pid {
+		return
+	}
+	info.Health = &health
+	if err := m.saveProcessInfo(info); err != nil {
+		processLog.WithFields(logger.Fields{"name": name}).Warn("Failed to save health: %v", err)
+	}
+}
+
+// restartUnhealthy stops a process that failed its health check and
+// starts it again with the same command, unless it was replaced in the
+// meantime
+func (m *Manager) restartUnhealthy(name string, pid int, command string, args, env []string) error {
+	unlock, err := m.lockName(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	info, err := m.GetProcessInfo(name)
+	if err != nil || info.PID != pid {
+		return nil
+	}
+	if err := m.Stop(context.Background(), pid); err != nil {
+		return err
+	}
+	newPID, err := m.start(name, command, args, env)
+	if err != nil {
+		return err
+	}
+	m.countRestart(name, newPID, info.Restarts+1)
+	processLog.WithFields(logger.Fields{"name": name, "pid": newPID}).Info("Restarted unhealthy process")
+	return nil
+}
+
+// notifyHealth runs the notify command of a health check for a process
+// that became degraded. A command that fails is logged.
+func notifyHealth(name string, pid int, health Health, check HealthCheck) {
+	ctx, cancel := context.WithTimeout(context.Background(), check.Timeout)
+	defer cancel()
+	cmd := shellCommand(ctx, check.NotifyCommand)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("ASC_HEALTH_AGENT=%s", name),
+		fmt.Sprintf("ASC_HEALTH_PID=%d", pid),
+		fmt.Sprintf("ASC_HEALTH_STATUS=%s", health.Status),
+		fmt.Sprintf("ASC_HEALTH_FAILURES=%d", health.Failures),
+		fmt.Sprintf("ASC_HEALTH_ERROR=%s", health.Error),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		processLog.WithFields(logger.Fields{"name": name}).Warn("Health notify_command failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+}
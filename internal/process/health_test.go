@@ -0,0 +1,128 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForHealth waits until the saved health of a process has want status
+func waitForHealth(t *testing.T, manager *Manager, name string, want HealthStatus) *ProcessInfo {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info, err := manager.GetProcessInfo(name)
+		if err == nil && info.Health != nil && info.Health.Status == want {
+			return info
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to become %s, got %+v", name, want, info)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExecProbe(t *testing.T) {
+	info := &ProcessInfo{Name: "agent", PID: 42}
+	if err := ExecProbe(`test "$AGENT_NAME $AGENT_PID" = "agent 42"`)(context.Background(), info); err != nil {
+		t.Errorf("ExecProbe() error = %v", err)
+	}
+	err := ExecProbe("echo not ready; exit 1")(context.Background(), info)
+	if err == nil || !strings.Contains(err.Error(), "not ready") {
+		t.Errorf("ExecProbe() error = %v, want the command's output", err)
+	}
+}
+
+func TestHTTPProbe(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	probe := HTTPProbe(server.URL)
+	if err := probe(context.Background(), &ProcessInfo{}); err != nil {
+		t.Errorf("HTTPProbe() error = %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := probe(context.Background(), &ProcessInfo{}); err == nil {
+		t.Error("Expected HTTPProbe() to fail on 503")
+	}
+}
+
+func TestHealthCheck_Degrade(t *testing.T) {
+	manager := newRestartTestManager(t)
+	marker := filepath.Join(t.TempDir(), "healthy")
+	manager.SetHealthCheck("agent", HealthCheck{
+		Probe:    ExecProbe("test -f " + marker),
+		Interval: 20 * time.Millisecond,
+		Retries:  2,
+		Action:   HealthDegrade,
+	})
+
+	pid, err := manager.Start("agent", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	info, err := manager.GetProcessInfo("agent")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	if info.Health == nil || info.Health.Status != HealthStarting {
+		t.Errorf("Expected a new process to be starting, got %+v", info.Health)
+	}
+
+	info = waitForHealth(t, manager, "agent", HealthDegraded)
+	if info.PID != pid {
+		t.Error("Expected a degraded process to be left running")
+	}
+	if info.Health.Failures < 2 || info.Health.Error == "" {
+		t.Errorf("Expected the failures to be recorded, got %+v", info.Health)
+	}
+}
+
+func TestHealthCheck_Restart(t *testing.T) {
+	manager := newRestartTestManager(t)
+	changes := make(chan Health, 10)
+	manager.SetOnHealthChange(func(name string, health Health) { changes <- health })
+	manager.SetHealthCheck("agent", HealthCheck{
+		Probe:    ExecProbe("exit 1"),
+		Interval: 20 * time.Millisecond,
+		Retries:  1,
+	})
+
+	pid, err := manager.Start("agent", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	select {
+	case health := <-changes:
+		if health.Status != HealthUnhealthy {
+			t.Errorf("Expected the process to become unhealthy, got %+v", health)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a health change")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info, err := manager.GetProcessInfo("agent")
+		if err == nil && info.PID != pid {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the unhealthy process to be restarted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if manager.IsRunning(pid) {
+		t.Error("Expected the unhealthy process to be stopped")
+	}
+}
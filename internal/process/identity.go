@@ -0,0 +1,147 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// Identity tells a process apart from a later one the OS gives the same
+// PID. It is recorded when a process starts, so a process found under a
+// recorded PID after asc restarts is only adopted, or stopped, if it is
+// the process that was started.
+type Identity struct {
+	StartTime   string `json:"start_time"`             // When the OS started the process, in a form of its own
+	CommandLine string `json:"command_line,omitempty"` // The command line the OS reports for it
+}
+
+// errZombie is returned by readIdentity for a process that exited but was
+// not reaped by its parent yet
+var errZombie = errors.New("process exited and is a zombie")
+
+// errNotAlive is returned by waitAdopted when asked about an adopted
+// process that is gone, since how it exited cannot be known
+var errNotAlive = errors.New("adopted process exited, exit status unknown")
+
+// SameProcess reports whether the process running under the PID info
+// records is the process info was recorded for, by its start time and
+// command line. A process recorded without an identity, by an earlier
+// version of asc, is taken to be the same, and one recorded without a
+// command line is told apart by its start time alone.
+func SameProcess(info *ProcessInfo) bool {
+	if info.Identity == nil {
+		return true
+	}
+	current, err := readIdentity(info.PID)
+	if err != nil {
+		return false
+	}
+	if current.StartTime != info.Identity.StartTime {
+		return false
+	}
+	return info.Identity.CommandLine == "" || current.CommandLine == info.Identity.CommandLine
+}
+
+// Live reports whether the process info records is running: pm reports its
+// PID running and the PID still belongs to it, not to a later process.
+func Live(pm ProcessManager, info *ProcessInfo) bool {
+	return pm.IsRunning(info.PID) && SameProcess(info)
+}
+
+// Adopt takes over the running named process, started by an earlier asc:
+// its restart policy, health check, and log rotation apply as if this
+// Manager had started it. A process whose PID now belongs to another
+// process is not adopted.
+func (m *Manager) Adopt(name string) (*ProcessInfo, error) {
+	unlock, err := m.lockName(name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	info, err := m.GetProcessInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	if !m.IsRunning(info.PID) {
+		return nil, fmt.Errorf("process %s (PID %d) is not running", name, info.PID)
+	}
+	if !SameProcess(info) {
+		return nil, fmt.Errorf("PID %d of process %s belongs to another process now", info.PID, name)
+	}
+
+	m.mu.Lock()
+	if c, ok := m.children[name]; ok && c.pid == info.PID {
+		m.mu.Unlock()
+		return info, nil // Started or adopted by this Manager already
+	}
+	c := &child{pid: info.PID, done: make(chan struct{})}
+	if m.children == nil {
+		m.children = make(map[string]*child)
+	}
+	m.children[name] = c
+	m.mu.Unlock()
+
+	env := make([]string, 0, len(info.Env))
+	for key, value := range info.Env {
+		env = append(env, key+"="+value)
+	}
+	go func() {
+		waitAdopted(info)
+		close(c.done)
+		m.exited(name, c, info.Command, info.Args, env, errNotAlive, time.Since(info.StartedAt))
+	}()
+	if m.logRotationFor(name).MaxSize > 0 {
+		go m.rotateWhileRunning(name, info.LogFile, info.PID)
+	}
+	if check, ok := m.healthCheckFor(name); ok {
+		go m.checkHealthWhileRunning(name, info.PID, check, info.Command, info.Args, env)
+	}
+
+	processLog.WithFields(logger.Fields{"name": name, "pid": info.PID}).Info("Adopted running process")
+	return info, nil
+}
+
+// waitAdopted waits for an adopted process, which is not a child that can
+// be waited for, to exit
+func waitAdopted(info *ProcessInfo) {
+	for Alive(info.PID) && SameProcess(info) {
+		time.Sleep(stopPollInterval)
+	}
+}
+
+// identityExecWait bounds how long recordIdentity waits for a process just
+// started to exec its command
+const identityExecWait = 100 * time.Millisecond
+
+// recordIdentity reads the identity of a process just started. Until it
+// execs its command, the child still has the command line of asc, so that
+// is waited out for a moment and a command line not read after it is left
+// out. A process whose identity cannot be read is recorded without one.
+func recordIdentity(name string, pid int) *Identity {
+	fields := logger.Fields{"name": name, "pid": pid}
+	self, err := readIdentity(os.Getpid())
+	if err != nil {
+		processLog.WithFields(fields).Debug("Process identity not recorded: %v", err)
+		return nil
+	}
+	deadline := time.Now().Add(identityExecWait)
+	for {
+		identity, err := readIdentity(pid)
+		if err != nil {
+			processLog.WithFields(fields).Debug("Process identity not recorded: %v", err)
+			return nil
+		}
+		if identity.CommandLine != self.CommandLine {
+			return identity
+		}
+		if time.Now().After(deadline) {
+			identity.CommandLine = ""
+			return identity
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
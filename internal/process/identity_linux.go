@@ -0,0 +1,40 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readIdentity reads the start time of a process, in clock ticks since
+// boot, and its command line from /proc
+func readIdentity(pid int) (*Identity, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, err
+	}
+	// The command name in parentheses may hold spaces; the fields after
+	// it start with the state and are numbered from 3
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return nil, fmt.Errorf("unexpected /proc/%d/stat", pid)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("unexpected /proc/%d/stat", pid)
+	}
+	if fields[0] == "Z" {
+		return nil, errZombie
+	}
+
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return nil, err
+	}
+	cmdline = bytes.TrimRight(cmdline, "\x00")
+	return &Identity{
+		StartTime:   fields[19],
+		CommandLine: string(bytes.ReplaceAll(cmdline, []byte{0}, []byte{' '})),
+	}, nil
+}
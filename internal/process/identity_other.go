@@ -0,0 +1,33 @@
+//go:build !linux && !windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// readIdentity reads the start time and command line of a process with ps
+func readIdentity(pid int) (*Identity, error) {
+	cmd := exec.Command("ps", "-ww", "-o", "stat=", "-o", "lstart=", "-o", "command=", "-p", strconv.Itoa(pid))
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process identity: %w", err)
+	}
+	// The state, then a start time such as "Mon Oct 14 10:00:00 2026"
+	fields := strings.Fields(string(out))
+	if len(fields) < 7 {
+		return nil, fmt.Errorf("unexpected ps output %q", out)
+	}
+	if strings.HasPrefix(fields[0], "Z") {
+		return nil, errZombie
+	}
+	return &Identity{
+		StartTime:   strings.Join(fields[1:6], " "),
+		CommandLine: strings.Join(fields[6:], " "),
+	}, nil
+}
@@ -0,0 +1,125 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSameProcess(t *testing.T) {
+	manager := newRestartTestManager(t)
+	if _, err := manager.Start("agent", "sleep", []string{"10"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	info, err := manager.GetProcessInfo("agent")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	if info.Identity == nil || info.Identity.StartTime == "" || !strings.Contains(info.Identity.CommandLine, "sleep 10") {
+		t.Fatalf("Expected the identity to be recorded, got %+v", info.Identity)
+	}
+	if !SameProcess(info) || !Live(manager, info) {
+		t.Error("Expected the started process to be the recorded one")
+	}
+
+	// A later process given the same PID differs in start time or command
+	reused := *info
+	reused.Identity = &Identity{StartTime: "1", CommandLine: info.Identity.CommandLine}
+	if SameProcess(&reused) || Live(manager, &reused) {
+		t.Error("Expected a process started at another time not to be the recorded one")
+	}
+	reused.Identity = &Identity{StartTime: info.Identity.StartTime, CommandLine: "python agent.py"}
+	if SameProcess(&reused) {
+		t.Error("Expected a process with another command line not to be the recorded one")
+	}
+
+	// PID files written before identities were recorded trust the PID
+	reused.Identity = nil
+	if !SameProcess(&reused) {
+		t.Error("Expected a process recorded without identity to be trusted")
+	}
+}
+
+func TestPlan_ReusedPID(t *testing.T) {
+	manager := newRestartTestManager(t)
+	// This test's PID stands in for one the OS gave to another process
+	stale := &ProcessInfo{
+		Name:      "agent",
+		PID:       os.Getpid(),
+		Command:   "python",
+		StartedAt: time.Now(),
+		Identity:  &Identity{StartTime: "1", CommandLine: "python agent.py"},
+	}
+	if err := manager.saveProcessInfo(stale); err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := Plan(manager, []string{"agent"})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	want := Step{Name: "agent", Action: ActionStart, PID: os.Getpid(), Reused: true}
+	if len(steps) != 1 || steps[0] != want {
+		t.Fatalf("Plan returned %+v, want %+v", steps, want)
+	}
+	if s := steps[0].String(); !strings.Contains(s, "belongs to another process now") {
+		t.Errorf("Unexpected step description %q", s)
+	}
+	if _, ok := Running(manager, "agent"); ok {
+		t.Error("Expected a reused PID not to count as running")
+	}
+	if _, err := manager.Adopt("agent"); err == nil {
+		t.Error("Expected Adopt to refuse a reused PID")
+	}
+
+	// Cleaning it up leaves the process given the PID alone
+	if err := manager.StopNamed(context.Background(), "agent"); err != nil {
+		t.Fatalf("StopNamed failed: %v", err)
+	}
+	if _, err := manager.GetProcessInfo("agent"); err == nil {
+		t.Error("Expected the stale PID file to be removed")
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	// The asc that started the agent is replaced by another, as when asc
+	// up is run again
+	previous := newRestartTestManager(t)
+	pid, err := previous.Start("agent", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	manager, err := NewManager(previous.pidDir, previous.logDir)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.StopAll(context.Background()) })
+	recorder := recordRestarts(manager)
+	manager.SetRestartPolicy("agent", RestartPolicy{Mode: RestartOnFailure, MaxRetries: 1, Backoff: 10 * time.Millisecond})
+
+	info, err := manager.Adopt("agent")
+	if err != nil {
+		t.Fatalf("Adopt failed: %v", err)
+	}
+	if info.PID != pid || !manager.Supervises("agent") {
+		t.Errorf("Expected PID %d to be adopted and supervised, got %d", pid, info.PID)
+	}
+
+	// An adopted process that exits is restarted by its policy
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	recorder.wait(t, 1)
+	info, err = manager.GetProcessInfo("agent")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	if info.PID == pid || !manager.IsRunning(info.PID) {
+		t.Errorf("Expected the adopted process to be restarted, got PID %d", info.PID)
+	}
+}
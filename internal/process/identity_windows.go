@@ -0,0 +1,32 @@
+package process
+
+import (
+	"fmt"
+	"strconv"
+
+	"golang.org/x/sys/windows"
+)
+
+// readIdentity reads the creation time of a process and the path of its
+// executable, which stands in for its command line
+func readIdentity(pid int) (*Identity, error) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open process: %w", err)
+	}
+	defer windows.CloseHandle(handle)
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return nil, fmt.Errorf("failed to read process times: %w", err)
+	}
+	path := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(path))
+	if err := windows.QueryFullProcessImageName(handle, 0, &path[0], &size); err != nil {
+		return nil, fmt.Errorf("failed to read process image: %w", err)
+	}
+	return &Identity{
+		StartTime:   strconv.FormatInt(creation.Nanoseconds(), 10),
+		CommandLine: windows.UTF16ToString(path[:size]),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Launch is where a process runs and the permissions of the files it
+// creates
+type Launch struct {
+	Dir   string       // Working directory, which must exist; "" for that of asc
+	Umask *os.FileMode // Mask of the permissions of the files it creates; nil for that of asc
+}
+
+// SetLaunch sets where the named process runs when this Manager starts it
+func (m *Manager) SetLaunch(name string, launch Launch) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.launches == nil {
+		m.launches = make(map[string]Launch)
+	}
+	m.launches[name] = launch
+}
+
+// launchFor returns where the named process runs
+func (m *Manager) launchFor(name string) Launch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.launches[name]
+}
+
+// prepare checks that the working directory of launch exists and sets it
+// as that of cmd
+func (launch Launch) prepare(name string, cmd *exec.Cmd) error {
+	if launch.Dir == "" {
+		return nil
+	}
+	stat, err := os.Stat(launch.Dir)
+	if err != nil {
+		return fmt.Errorf("working directory of %s: %w", name, err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("working directory of %s: %s is not a directory", name, launch.Dir)
+	}
+	cmd.Dir = launch.Dir
+	return nil
+}
+
+// start starts cmd with the umask of launch
+func (launch Launch) start(cmd *exec.Cmd) error {
+	if launch.Umask == nil {
+		return cmd.Start()
+	}
+	return startWithUmask(cmd, *launch.Umask)
+}
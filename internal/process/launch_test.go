@@ -0,0 +1,47 @@
+//go:build !windows
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestLaunch(t *testing.T) {
+	manager := newRestartTestManager(t)
+	dir := t.TempDir()
+	umask := os.FileMode(0077)
+	manager.SetLaunch("checkout", Launch{Dir: dir, Umask: &umask})
+
+	if _, err := manager.Start("checkout", "sh", []string{"-c", "touch created; pwd"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	info, err := manager.GetProcessInfo("checkout")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	resolved, _ := filepath.EvalSymlinks(dir)
+	waitForLog(t, info.LogFile, resolved)
+	stat, err := os.Stat(filepath.Join(dir, "created"))
+	if err != nil {
+		t.Fatalf("Expected the process to create a file in its working directory: %v", err)
+	}
+	if stat.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file created with umask 0077, got %v", stat.Mode().Perm())
+	}
+
+	// The umask of asc is left as it was
+	previous := syscall.Umask(0022)
+	syscall.Umask(previous)
+	if previous == 0077 {
+		t.Error("Expected the umask of asc restored")
+	}
+
+	manager.SetLaunch("gone", Launch{Dir: filepath.Join(dir, "missing")})
+	if _, err := manager.Start("gone", "true", nil, nil); err == nil || !strings.Contains(err.Error(), "working directory of gone") {
+		t.Errorf("Expected a missing working directory to fail the start, got %v", err)
+	}
+}
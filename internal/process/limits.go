@@ -0,0 +1,65 @@
+package process
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limits bounds the resources of a process, enforced with cgroups v2 on
+// Linux and job objects on Windows. A process over its memory limit is
+// killed; one over its CPU limit is throttled.
+type Limits struct {
+	MaxMemoryMB int     `json:"max_memory_mb,omitempty"` // Memory limit in MiB; 0 for none
+	CPULimit    float64 `json:"cpu_limit,omitempty"`     // CPU limit in cores, e.g. 0.5; 0 for none
+}
+
+// IsZero reports whether no limit is set
+func (l Limits) IsZero() bool {
+	return l.MaxMemoryMB <= 0 && l.CPULimit <= 0
+}
+
+// String describes the limits, e.g. "512 MB, 1.5 CPU"
+func (l Limits) String() string {
+	switch {
+	case l.IsZero():
+		return "none"
+	case l.CPULimit <= 0:
+		return fmt.Sprintf("%d MB", l.MaxMemoryMB)
+	case l.MaxMemoryMB <= 0:
+		return fmt.Sprintf("%g CPU", l.CPULimit)
+	}
+	return fmt.Sprintf("%d MB, %g CPU", l.MaxMemoryMB, l.CPULimit)
+}
+
+// Usage is the resources a process, with the processes it started, uses
+type Usage struct {
+	MemoryBytes uint64        // Resident memory
+	CPUTime     time.Duration // CPU time used since it started
+}
+
+// SetLimits sets the resource limits of the named process, applied to the
+// processes of that name this Manager starts from then on
+func (m *Manager) SetLimits(name string, limits Limits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.limits == nil {
+		m.limits = make(map[string]Limits)
+	}
+	m.limits[name] = limits
+}
+
+// Usage returns the current resource usage of a managed process. Where
+// its limits are enforced it includes the processes the process started.
+func (m *Manager) Usage(info *ProcessInfo) (Usage, error) {
+	if !m.IsRunning(info.PID) {
+		return Usage{}, fmt.Errorf("process %s is not running", info.Name)
+	}
+	return readUsage(info)
+}
+
+// limitsFor returns the resource limits of the named process
+func (m *Manager) limitsFor(name string) Limits {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.limits[name]
+}
@@ -0,0 +1,301 @@
+//go:build linux
+
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// cgroupFS is where the cgroup v2 hierarchy is mounted. A variable so
+// tests can use a directory of their own.
+var cgroupFS = "/sys/fs/cgroup"
+
+// selfCgroupFile lists the cgroups of this process
+var selfCgroupFile = "/proc/self/cgroup"
+
+// cgroupParentName is the cgroup holding the cgroups of limited
+// processes, created next to the cgroup of asc itself
+const cgroupParentName = "asc"
+
+// cpuPeriod is the cpu.max period, in microseconds
+const cpuPeriod = 100000
+
+// clockTicks is the unit of the CPU times in /proc/<pid>/stat, USER_HZ,
+// which is 100 on every architecture Go supports
+const clockTicks = 100
+
+// limiter enforces the limits of one process with a cgroup of its own,
+// which the process is started in
+type limiter struct {
+	dir string
+	fd  *os.File
+}
+
+// limitEveryProcess is unset, as only processes with limits get a cgroup
+const limitEveryProcess = false
+
+// applyLimits creates the cgroup enforcing limits for the named process
+// and makes cmd start in it
+func applyLimits(cmd *exec.Cmd, name string, limits Limits) (*limiter, error) {
+	dir, err := createCgroup(name, limits)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := os.Open(dir)
+	if err != nil {
+		_ = os.Remove(dir)
+		return nil, fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(fd.Fd())
+	return &limiter{dir: dir, fd: fd}, nil
+}
+
+// createCgroup creates the cgroup of the named process, with limits set
+func createCgroup(name string, limits Limits) (string, error) {
+	parent, err := cgroupParent()
+	if err != nil {
+		return "", err
+	}
+	var controllers []string
+	if limits.MaxMemoryMB > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if limits.CPULimit > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if err := enableControllers(parent, controllers); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(parent, name)
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return "", fmt.Errorf("failed to create cgroup: %w", err)
+	}
+
+	// A cgroup left behind by an earlier run may hold limits since removed,
+	// so unused limits are reset where their files exist
+	settings := map[string]string{"memory.max": "max", "cpu.max": fmt.Sprintf("max %d", cpuPeriod)}
+	required := map[string]bool{}
+	if limits.MaxMemoryMB > 0 {
+		settings["memory.max"] = strconv.FormatInt(int64(limits.MaxMemoryMB)*1024*1024, 10)
+		// Kill the whole agent, not just its largest process, and do not
+		// let it swap instead. memory.swap.max is missing without swap
+		// accounting.
+		settings["memory.oom.group"] = "1"
+		settings["memory.swap.max"] = "0"
+		required["memory.max"] = true
+		required["memory.oom.group"] = true
+	}
+	if limits.CPULimit > 0 {
+		settings["cpu.max"] = fmt.Sprintf("%d %d", int(limits.CPULimit*cpuPeriod), cpuPeriod)
+		required["cpu.max"] = true
+	}
+	for file, value := range settings {
+		err := writeCgroupFile(filepath.Join(dir, file), value)
+		if err != nil && (required[file] || !os.IsNotExist(err)) {
+			_ = os.Remove(dir)
+			return "", fmt.Errorf("failed to set %s: %w", file, err)
+		}
+	}
+	return dir, nil
+}
+
+// cgroupParent returns the cgroup the cgroups of limited processes are
+// created in: asc next to the cgroup of this process, so a user session
+// that delegates its cgroup to the user can create it. It is created if
+// needed.
+func cgroupParent() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupFS, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s", cgroupFS)
+	}
+	data, err := os.ReadFile(selfCgroupFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read own cgroup: %w", err)
+	}
+	own := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			own = strings.TrimPrefix(line, "0::")
+		}
+	}
+	if own == "" {
+		return "", fmt.Errorf("asc is not in a cgroup v2 hierarchy")
+	}
+
+	parent := filepath.Join(cgroupFS, path.Dir(own), cgroupParentName)
+	if err := os.Mkdir(parent, 0755); err != nil && !os.IsExist(err) {
+		return "", fmt.Errorf("failed to create cgroup %s: %w", parent, err)
+	}
+	return parent, nil
+}
+
+// enableControllers makes controllers available to the cgroups in parent
+func enableControllers(parent string, controllers []string) error {
+	available, err := os.ReadFile(filepath.Join(parent, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("failed to read cgroup controllers: %w", err)
+	}
+	var enable []string
+	for _, controller := range controllers {
+		if !containsField(string(available), controller) {
+			return fmt.Errorf("cgroup controller %s is not delegated to %s", controller, parent)
+		}
+		enable = append(enable, "+"+controller)
+	}
+	if len(enable) == 0 {
+		return nil
+	}
+	if err := writeCgroupFile(filepath.Join(parent, "cgroup.subtree_control"), strings.Join(enable, " ")); err != nil {
+		return fmt.Errorf("failed to enable cgroup controllers: %w", err)
+	}
+	return nil
+}
+
+// writeCgroupFile writes an existing cgroup interface file, which cannot
+// be created or truncated
+func writeCgroupFile(path, value string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(value); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// kill kills every process in the cgroup. cgroup.kill needs Linux 5.14.
+func (l *limiter) kill() error {
+	if l == nil {
+		return errors.New("no cgroup")
+	}
+	return writeCgroupFile(filepath.Join(l.dir, "cgroup.kill"), "1")
+}
+
+// started is called once the process started in the cgroup
+func (l *limiter) started(p *os.Process) error {
+	if l != nil {
+		_ = l.fd.Close()
+	}
+	return nil
+}
+
+// release removes the cgroup once the process exited. A cgroup that
+// processes the agent started still run in is left behind.
+func (l *limiter) release() {
+	if l != nil {
+		_ = l.fd.Close()
+		_ = os.Remove(l.dir)
+	}
+}
+
+// cgroup returns the cgroup directory enforcing the limits, if any
+func (l *limiter) cgroup() string {
+	if l == nil {
+		return ""
+	}
+	return l.dir
+}
+
+// readUsage reads the usage of the process's cgroup, or of the process
+// alone if it has none
+func readUsage(info *ProcessInfo) (Usage, error) {
+	if info.Cgroup != "" {
+		if usage, err := readCgroupUsage(info.Cgroup); err == nil {
+			return usage, nil
+		}
+	}
+	return readProcUsage(info.PID)
+}
+
+// readCgroupUsage reads memory.current and the usage_usec of cpu.stat
+func readCgroupUsage(dir string) (Usage, error) {
+	var usage Usage
+	memory, err := os.ReadFile(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return usage, err
+	}
+	if usage.MemoryBytes, err = strconv.ParseUint(strings.TrimSpace(string(memory)), 10, 64); err != nil {
+		return usage, fmt.Errorf("invalid memory.current: %w", err)
+	}
+
+	stat, err := os.ReadFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return usage, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(stat))
+	for scanner.Scan() {
+		if field, value, ok := strings.Cut(scanner.Text(), " "); ok && field == "usage_usec" {
+			usec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return usage, fmt.Errorf("invalid cpu.stat: %w", err)
+			}
+			usage.CPUTime = time.Duration(usec) * time.Microsecond
+		}
+	}
+	return usage, nil
+}
+
+// readProcUsage reads the resident memory and CPU time of a process from
+// /proc
+func readProcUsage(pid int) (Usage, error) {
+	var usage Usage
+	statm, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return usage, fmt.Errorf("failed to read process usage: %w", err)
+	}
+	fields := strings.Fields(string(statm))
+	if len(fields) < 2 {
+		return usage, errors.New("invalid /proc statm")
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return usage, fmt.Errorf("invalid /proc statm: %w", err)
+	}
+	usage.MemoryBytes = pages * uint64(os.Getpagesize())
+
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return usage, fmt.Errorf("failed to read process usage: %w", err)
+	}
+	// The command name may hold spaces; the fields after it do not.
+	// utime and stime are fields 14 and 15, the 12th and 13th after it.
+	i := bytes.LastIndexByte(stat, ')')
+	fields = strings.Fields(string(stat[i+1:]))
+	if i < 0 || len(fields) < 13 {
+		return usage, errors.New("invalid /proc stat")
+	}
+	utime, err1 := strconv.ParseInt(fields[11], 10, 64)
+	stime, err2 := strconv.ParseInt(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return usage, errors.New("invalid /proc stat")
+	}
+	usage.CPUTime = time.Duration(utime+stime) * time.Second / clockTicks
+	return usage, nil
+}
+
+// containsField reports whether the whitespace-separated list s holds f
+func containsField(s, f string) bool {
+	for _, field := range strings.Fields(s) {
+		if field == f {
+			return true
+		}
+	}
+	return false
+}
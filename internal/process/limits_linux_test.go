@@ -0,0 +1,175 @@
+//go:build linux
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeCgroupFS points the cgroup functions at a directory laid out like a
+// cgroup v2 hierarchy with asc in the cgroup user.slice/session.scope
+func fakeCgroupFS(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	oldFS, oldSelf := cgroupFS, selfCgroupFile
+	cgroupFS = root
+	selfCgroupFile = filepath.Join(root, "self")
+	t.Cleanup(func() { cgroupFS, selfCgroupFile = oldFS, oldSelf })
+
+	writeFile(t, selfCgroupFile, "0::/user.slice/session.scope\n")
+	writeFile(t, filepath.Join(root, "cgroup.controllers"), "cpu memory pids\n")
+	parent := filepath.Join(root, "user.slice", cgroupParentName)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(parent, "cgroup.controllers"), "cpu memory pids\n")
+	writeFile(t, filepath.Join(parent, "cgroup.subtree_control"), "")
+	return parent
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCreateCgroup(t *testing.T) {
+	parent := fakeCgroupFS(t)
+
+	// A real cgroup has its interface files as soon as it is created
+	dir := filepath.Join(parent, "agent-1")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"memory.max", "memory.oom.group", "cpu.max"} {
+		writeFile(t, filepath.Join(dir, file), "")
+	}
+
+	got, err := createCgroup("agent-1", Limits{MaxMemoryMB: 256, CPULimit: 0.5})
+	if err != nil {
+		t.Fatalf("createCgroup() error = %v", err)
+	}
+	if got != dir {
+		t.Errorf("createCgroup() = %s, want %s", got, dir)
+	}
+
+	want := map[string]string{
+		filepath.Join(parent, "cgroup.subtree_control"): "+memory +cpu",
+		filepath.Join(dir, "memory.max"):                "268435456",
+		filepath.Join(dir, "memory.oom.group"):          "1",
+		filepath.Join(dir, "cpu.max"):                   "50000 100000",
+	}
+	for path, value := range want {
+		if got := readFile(t, path); got != value {
+			t.Errorf("%s = %q, want %q", path, got, value)
+		}
+	}
+}
+
+func TestCreateCgroup_ResetsUnusedLimits(t *testing.T) {
+	parent := fakeCgroupFS(t)
+	dir := filepath.Join(parent, "agent-1")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "memory.max"), "")
+	writeFile(t, filepath.Join(dir, "cpu.max"), "")
+
+	if _, err := createCgroup("agent-1", Limits{CPULimit: 2}); err != nil {
+		t.Fatalf("createCgroup() error = %v", err)
+	}
+	if got := readFile(t, filepath.Join(dir, "memory.max")); got != "max" {
+		t.Errorf("memory.max = %q, want max", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "cpu.max")); got != "200000 100000" {
+		t.Errorf("cpu.max = %q, want 200000 100000", got)
+	}
+}
+
+func TestCreateCgroup_Errors(t *testing.T) {
+	t.Run("no cgroup v2", func(t *testing.T) {
+		fakeCgroupFS(t)
+		if err := os.Remove(filepath.Join(cgroupFS, "cgroup.controllers")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := createCgroup("agent-1", Limits{MaxMemoryMB: 256}); err == nil {
+			t.Error("Expected error without cgroup v2")
+		}
+	})
+
+	t.Run("controller not delegated", func(t *testing.T) {
+		parent := fakeCgroupFS(t)
+		writeFile(t, filepath.Join(parent, "cgroup.controllers"), "pids\n")
+		if _, err := createCgroup("agent-1", Limits{MaxMemoryMB: 256}); err == nil {
+			t.Error("Expected error without the memory controller")
+		}
+	})
+
+	t.Run("limit file missing", func(t *testing.T) {
+		parent := fakeCgroupFS(t)
+		if _, err := createCgroup("agent-1", Limits{MaxMemoryMB: 256}); err == nil {
+			t.Error("Expected error without memory.max")
+		}
+		if _, err := os.Stat(filepath.Join(parent, "agent-1")); !os.IsNotExist(err) {
+			t.Error("Expected the cgroup to be removed after the error")
+		}
+	})
+}
+
+func TestReadCgroupUsage(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "memory.current"), "52428800\n")
+	writeFile(t, filepath.Join(dir, "cpu.stat"), "usage_usec 1500000\nuser_usec 1000000\nsystem_usec 500000\n")
+
+	usage, err := readCgroupUsage(dir)
+	if err != nil {
+		t.Fatalf("readCgroupUsage() error = %v", err)
+	}
+	if usage.MemoryBytes != 52428800 || usage.CPUTime != 1500*time.Millisecond {
+		t.Errorf("readCgroupUsage() = %+v", usage)
+	}
+}
+
+func TestReadUsage_Process(t *testing.T) {
+	// A cgroup that is gone falls back to the process's own usage
+	info := &ProcessInfo{Name: "self", PID: os.Getpid(), Cgroup: filepath.Join(t.TempDir(), "gone")}
+	usage, err := readUsage(info)
+	if err != nil {
+		t.Fatalf("readUsage() error = %v", err)
+	}
+	if usage.MemoryBytes == 0 {
+		t.Error("Expected resident memory of the test process")
+	}
+}
+
+func TestLimiterKill(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "cgroup.kill"), "")
+
+	l := &limiter{dir: dir}
+	if err := l.kill(); err != nil {
+		t.Fatalf("kill() error = %v", err)
+	}
+	if got := readFile(t, filepath.Join(dir, "cgroup.kill")); got != "1" {
+		t.Errorf("cgroup.kill = %q, want 1", got)
+	}
+
+	// Without a cgroup the caller kills the process alone
+	var none *limiter
+	if err := none.kill(); err == nil {
+		t.Error("Expected kill() without a cgroup to fail")
+	}
+}
@@ -0,0 +1,86 @@
+//go:build !linux && !windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// limiter would enforce the limits of one process; there is no way to on
+// this platform
+type limiter struct{}
+
+// limitEveryProcess is unset, as no process can be limited
+const limitEveryProcess = false
+
+// applyLimits fails, as resource limits are not supported here
+func applyLimits(cmd *exec.Cmd, name string, limits Limits) (*limiter, error) {
+	return nil, fmt.Errorf("resource limits are not supported on %s", runtime.GOOS)
+}
+
+func (l *limiter) started(p *os.Process) error { return nil }
+
+func (l *limiter) release() {}
+
+func (l *limiter) kill() error {
+	return fmt.Errorf("resource limits are not supported on %s", runtime.GOOS)
+}
+
+func (l *limiter) cgroup() string { return "" }
+
+// readUsage reads the resident memory and CPU time of the process with ps
+func readUsage(info *ProcessInfo) (Usage, error) {
+	var usage Usage
+	out, err := exec.Command("ps", "-o", "rss=", "-o", "time=", "-p", strconv.Itoa(info.PID)).Output()
+	if err != nil {
+		return usage, fmt.Errorf("failed to read process usage: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return usage, fmt.Errorf("unexpected ps output %q", out)
+	}
+	kb, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return usage, fmt.Errorf("unexpected ps output %q", out)
+	}
+	usage.MemoryBytes = kb * 1024
+	if usage.CPUTime, err = parsePSTime(fields[1]); err != nil {
+		return usage, err
+	}
+	return usage, nil
+}
+
+// parsePSTime parses a CPU time of ps, [[dd-]hh:]mm:ss[.cc]
+func parsePSTime(s string) (time.Duration, error) {
+	var total time.Duration
+	if days, rest, ok := strings.Cut(s, "-"); ok {
+		d, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid CPU time %q", s)
+		}
+		total += time.Duration(d) * 24 * time.Hour
+		s = rest
+	}
+	parts := strings.Split(s, ":")
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid CPU time %q", s)
+	}
+	total += time.Duration(seconds * float64(time.Second))
+	unit := time.Minute
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid CPU time %q", s)
+		}
+		total += time.Duration(n) * unit
+		unit *= 60
+	}
+	return total, nil
+}
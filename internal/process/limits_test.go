@@ -0,0 +1,54 @@
+package process
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLimitsString(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		want   string
+	}{
+		{Limits{}, "none"},
+		{Limits{MaxMemoryMB: 512}, "512 MB"},
+		{Limits{CPULimit: 0.5}, "0.5 CPU"},
+		{Limits{MaxMemoryMB: 512, CPULimit: 1.5}, "512 MB, 1.5 CPU"},
+	}
+	for _, tt := range tests {
+		if got := tt.limits.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.limits, got, tt.want)
+		}
+	}
+}
+
+func TestStartWithLimits(t *testing.T) {
+	manager := newRestartTestManager(t)
+	limits := Limits{MaxMemoryMB: 256, CPULimit: 0.5}
+	manager.SetLimits("limited", limits)
+
+	// Where the limits cannot be enforced the process still starts
+	pid, err := manager.Start("limited", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Stop(context.Background(), pid)
+
+	info, err := manager.GetProcessInfo("limited")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	if info.Limits == nil || *info.Limits != limits {
+		t.Errorf("Expected limits %+v to be recorded, got %+v", limits, info.Limits)
+	}
+	if _, err := manager.Usage(info); err != nil {
+		t.Errorf("Usage failed: %v", err)
+	}
+
+	if err := manager.Stop(context.Background(), pid); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if _, err := manager.Usage(info); err == nil {
+		t.Error("Expected Usage of a stopped process to fail")
+	}
+}
@@ -0,0 +1,172 @@
+//go:build windows
+
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Job object CPU rate control, which golang.org/x/sys/windows does not
+// define
+const (
+	jobObjectCPURateControlEnable  = 0x1
+	jobObjectCPURateControlHardCap = 0x4
+)
+
+// jobObjectCPURateControlInformation is JOBOBJECT_CPU_RATE_CONTROL_INFORMATION
+// with its CpuRate member
+type jobObjectCPURateControlInformation struct {
+	ControlFlags uint32
+	CPURate      uint32 // Share of all CPUs in hundredths of a percent
+}
+
+// processMemoryCounters is PROCESS_MEMORY_COUNTERS
+type processMemoryCounters struct {
+	CB                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+var procGetProcessMemoryInfo = windows.NewLazySystemDLL("kernel32.dll").NewProc("K32GetProcessMemoryInfo")
+
+// limiter enforces the limits of one process with a job object of its
+// own, which the process is assigned to once started
+type limiter struct {
+	mu       sync.Mutex // Keeps kill from using the handle once released
+	job      windows.Handle
+	released bool
+}
+
+// limitEveryProcess is set because every process is started in a job
+// object, limited or not, so that killing it kills the processes it
+// started too, even those whose parent already exited
+const limitEveryProcess = true
+
+// applyLimits creates the job object enforcing limits for the named
+// process
+func applyLimits(cmd *exec.Cmd, name string, limits Limits) (*limiter, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job object: %w", err)
+	}
+
+	if limits.MaxMemoryMB > 0 {
+		var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+		info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_JOB_MEMORY
+		info.JobMemoryLimit = uintptr(limits.MaxMemoryMB) * 1024 * 1024
+		if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+			uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+			windows.CloseHandle(job)
+			return nil, fmt.Errorf("failed to set memory limit: %w", err)
+		}
+	}
+	if limits.CPULimit > 0 {
+		rate := uint32(limits.CPULimit / float64(runtime.NumCPU()) * 10000)
+		if rate < 1 {
+			rate = 1
+		}
+		if rate > 10000 {
+			rate = 10000
+		}
+		info := jobObjectCPURateControlInformation{
+			ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+			CPURate:      rate,
+		}
+		if _, err := windows.SetInformationJobObject(job, windows.JobObjectCpuRateControlInformation,
+			uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+			windows.CloseHandle(job)
+			return nil, fmt.Errorf("failed to set CPU limit: %w", err)
+		}
+	}
+	return &limiter{job: job}, nil
+}
+
+// started assigns the started process to the job object. Processes it
+// starts from then on join the job too.
+func (l *limiter) started(p *os.Process) error {
+	if l == nil {
+		return nil
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(p.Pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process: %w", err)
+	}
+	defer windows.CloseHandle(handle)
+	if err := windows.AssignProcessToJobObject(l.job, handle); err != nil {
+		return fmt.Errorf("failed to assign process to job object: %w", err)
+	}
+	return nil
+}
+
+// kill terminates every process in the job object
+func (l *limiter) kill() error {
+	if l == nil {
+		return errors.New("no job object")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		return errors.New("job object already closed")
+	}
+	if err := windows.TerminateJobObject(l.job, 1); err != nil {
+		return fmt.Errorf("failed to terminate job object: %w", err)
+	}
+	return nil
+}
+
+// release closes the job object once the process exited
+func (l *limiter) release() {
+	if l != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.released = true
+		windows.CloseHandle(l.job)
+	}
+}
+
+// cgroup returns "", as Windows has no cgroups
+func (l *limiter) cgroup() string {
+	return ""
+}
+
+// readUsage reads the working set and CPU time of the process
+func readUsage(info *ProcessInfo) (Usage, error) {
+	var usage Usage
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(info.PID))
+	if err != nil {
+		return usage, fmt.Errorf("failed to open process: %w", err)
+	}
+	defer windows.CloseHandle(handle)
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return usage, fmt.Errorf("failed to read process times: %w", err)
+	}
+	// Filetimes count 100-nanosecond intervals
+	ticks := int64(kernel.HighDateTime)<<32 | int64(kernel.LowDateTime)
+	ticks += int64(user.HighDateTime)<<32 | int64(user.LowDateTime)
+	usage.CPUTime = time.Duration(ticks * 100)
+
+	counters := processMemoryCounters{CB: uint32(unsafe.Sizeof(processMemoryCounters{}))}
+	if ok, _, err := procGetProcessMemoryInfo.Call(uintptr(handle), uintptr(unsafe.Pointer(&counters)), uintptr(counters.CB)); ok == 0 {
+		return usage, fmt.Errorf("failed to read process memory: %w", err)
+	}
+	usage.MemoryBytes = uint64(counters.WorkingSetSize)
+	return usage, nil
+}
@@ -0,0 +1,211 @@
+package process
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// LogRotation bounds the log file a process's output is appended to. A
+// log larger than MaxSize is copied to <name>.log.1, or <name>.log.1.gz
+// when compressed, and emptied; older copies move up to .2 and so on.
+// Copying rather than renaming lets the process keep appending to the
+// file it opened, even after the asc that started it exited.
+type LogRotation struct {
+	MaxSize  int64         // Size in bytes a log is rotated at; 0 for no rotation
+	MaxFiles int           // Rotated copies kept
+	MaxAge   time.Duration // Age after which rotated copies are removed; 0 for no limit
+	Compress bool          // gzip rotated copies
+}
+
+// logRotateInterval is how often the logs of running processes are
+// checked. It is a variable so tests do not wait.
+var logRotateInterval = 10 * time.Second
+
+// SetLogRotation sets the log rotation of the named process, applied when
+// a process of that name starts and while it runs
+func (m *Manager) SetLogRotation(name string, rotation LogRotation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rotations == nil {
+		m.rotations = make(map[string]LogRotation)
+	}
+	m.rotations[name] = rotation
+}
+
+// logRotationFor returns the log rotation of the named process
+func (m *Manager) logRotationFor(name string) LogRotation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rotations[name]
+}
+
+// rotateWhileRunning rotates the log of a process this Manager started
+// until the process exits
+func (m *Manager) rotateWhileRunning(name, logPath string, pid int) {
+	ticker := time.NewTicker(logRotateInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !m.IsRunning(pid) {
+			return
+		}
+		if err := rotateLog(logPath, m.logRotationFor(name)); err != nil {
+			processLog.WithFields(logger.Fields{"name": name}).Warn("Failed to rotate log: %v", err)
+		}
+	}
+}
+
+// rotatedLog is a rotated copy of a log, path.<index> or path.<index>.gz
+type rotatedLog struct {
+	path  string
+	index int
+	gz    bool
+}
+
+// rotateLog rotates the log at path if it reached rotation.MaxSize, and
+// removes rotated copies beyond rotation.MaxFiles or older than
+// rotation.MaxAge
+func rotateLog(path string, rotation LogRotation) error {
+	if rotation.MaxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Size() >= rotation.MaxSize {
+		if err := shiftRotatedLogs(path, rotation.MaxFiles); err != nil {
+			return err
+		}
+		if rotation.MaxFiles > 0 {
+			if err := copyLog(path, rotation.Compress); err != nil {
+				return err
+			}
+		}
+		if err := os.Truncate(path, 0); err != nil {
+			return fmt.Errorf("failed to empty log: %w", err)
+		}
+	}
+	return pruneRotatedLogs(path, rotation.MaxAge)
+}
+
+// rotatedLogs returns the rotated copies of the log at path, oldest last
+func rotatedLogs(path string) ([]rotatedLog, error) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(path) + "."
+	var logs []rotatedLog
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		gz := strings.HasSuffix(suffix, ".gz")
+		index, err := strconv.Atoi(strings.TrimSuffix(suffix, ".gz"))
+		if err != nil || index < 1 {
+			continue // Not a rotated copy
+		}
+		logs = append(logs, rotatedLog{path: filepath.Join(filepath.Dir(path), entry.Name()), index: index, gz: gz})
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].index < logs[j].index })
+	return logs, nil
+}
+
+// shiftRotatedLogs makes room for a new .1 by moving each rotated copy up
+// one, removing those that would then be beyond maxFiles
+func shiftRotatedLogs(path string, maxFiles int) error {
+	logs, err := rotatedLogs(path)
+	if err != nil {
+		return err
+	}
+	for i := len(logs) - 1; i >= 0; i-- {
+		log := logs[i]
+		if log.index >= maxFiles {
+			if err := os.Remove(log.path); err != nil {
+				return fmt.Errorf("failed to remove old log: %w", err)
+			}
+			continue
+		}
+		next := fmt.Sprintf("%s.%d", path, log.index+1)
+		if log.gz {
+			next += ".gz"
+		}
+		if err := os.Rename(log.path, next); err != nil {
+			return fmt.Errorf("failed to rotate log: %w", err)
+		}
+	}
+	return nil
+}
+
+// copyLog copies the log at path to path.1, or gzips it to path.1.gz
+func copyLog(path string, compress bool) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst := path + ".1"
+	if compress {
+		dst += ".gz"
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create rotated log: %w", err)
+	}
+
+	var w io.Writer = out
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(out)
+		w = gz
+	}
+	_, err = io.Copy(w, src)
+	if gz != nil && err == nil {
+		err = gz.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to write rotated log: %w", err)
+	}
+	return nil
+}
+
+// pruneRotatedLogs removes rotated copies of the log at path last written
+// more than maxAge ago
+func pruneRotatedLogs(path string, maxAge time.Duration) error {
+	if maxAge <= 0 {
+		return nil
+	}
+	logs, err := rotatedLogs(path)
+	if err != nil {
+		return err
+	}
+	for _, log := range logs {
+		info, err := os.Stat(log.path)
+		if err != nil || time.Since(info.ModTime()) <= maxAge {
+			continue
+		}
+		if err := os.Remove(log.path); err != nil {
+			return fmt.Errorf("failed to remove old log: %w", err)
+		}
+	}
+	return nil
+}
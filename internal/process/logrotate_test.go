@@ -0,0 +1,137 @@
+package process
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeLog(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotateLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	rotation := LogRotation{MaxSize: 10, MaxFiles: 2}
+
+	// Below the size limit nothing happens
+	writeLog(t, path, "short\n")
+	if err := rotateLog(path, rotation); err != nil {
+		t.Fatalf("rotateLog() error = %v", err)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Error("Expected no rotation below the size limit")
+	}
+
+	// Each rotation moves older copies up and drops those beyond MaxFiles
+	for _, content := range []string{"first log line\n", "second log line\n", "third log line\n"} {
+		writeLog(t, path, content)
+		if err := rotateLog(path, rotation); err != nil {
+			t.Fatalf("rotateLog() error = %v", err)
+		}
+	}
+	if got := readLog(t, path); got != "" {
+		t.Errorf("Expected the log to be emptied, got %q", got)
+	}
+	if got := readLog(t, path+".1"); got != "third log line\n" {
+		t.Errorf("agent.log.1 = %q", got)
+	}
+	if got := readLog(t, path+".2"); got != "second log line\n" {
+		t.Errorf("agent.log.2 = %q", got)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Expected copies beyond MaxFiles to be removed")
+	}
+}
+
+func TestRotateLog_Compress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	writeLog(t, path, strings.Repeat("compressed line\n", 10))
+
+	if err := rotateLog(path, LogRotation{MaxSize: 10, MaxFiles: 3, Compress: true}); err != nil {
+		t.Fatalf("rotateLog() error = %v", err)
+	}
+
+	file, err := os.Open(path + ".1.gz")
+	if err != nil {
+		t.Fatalf("Expected a compressed copy: %v", err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil || string(data) != strings.Repeat("compressed line\n", 10) {
+		t.Errorf("Unexpected compressed copy %q, %v", data, err)
+	}
+}
+
+func TestRotateLog_MaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	writeLog(t, path, "current\n")
+	writeLog(t, path+".1", "recent\n")
+	writeLog(t, path+".2.gz", "old\n")
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(path+".2.gz", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rotateLog(path, LogRotation{MaxSize: 1024, MaxFiles: 5, MaxAge: 24 * time.Hour}); err != nil {
+		t.Fatalf("rotateLog() error = %v", err)
+	}
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Error("Expected the recent copy to be kept")
+	}
+	if _, err := os.Stat(path + ".2.gz"); !os.IsNotExist(err) {
+		t.Error("Expected the old copy to be removed")
+	}
+}
+
+func TestStartRotatesLog(t *testing.T) {
+	manager := newRestartTestManager(t)
+	oldInterval := logRotateInterval
+	logRotateInterval = 10 * time.Millisecond
+	t.Cleanup(func() { logRotateInterval = oldInterval })
+	manager.SetLogRotation("chatty", LogRotation{MaxSize: 100, MaxFiles: 1})
+
+	pid, err := manager.Start("chatty", "sh", []string{"-c", "while true; do echo 'a line of agent output'; sleep 0.01; done"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	info, err := manager.GetProcessInfo("chatty")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(info.LogFile + ".1"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the log of the running process to be rotated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := manager.Stop(context.Background(), pid); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+}
@@ -15,21 +15,33 @@
 //	}
 //
 //	// Later...
-//	if err := manager.Stop(pid); err != nil {
+//	if err := manager.Stop(ctx, pid); err != nil {
 //	    log.Fatal(err)
 //	}
 package process
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"syscall"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/fswatch"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+	"github.com/rand/asc/internal/telemetry"
 )
 
+// processLog tags every record written by this package with the process component
+var processLog = logger.WithComponent("process")
+
 // ProcessStatus represents the current state of a process.
 type ProcessStatus string
 
@@ -48,7 +60,15 @@ type ProcessInfo struct {
 	Args      []string          `json:"args"`
 	Env       map[string]string `json:"env"`
 	StartedAt time.Time         `json:"started_at"`
+	Identity  *Identity         `json:"identity,omitempty"` // Tells it apart from a later process given its PID
 	LogFile   string            `json:"log_file"`
+	LogOffset int64             `json:"log_offset,omitempty"` // Size of the log file when the process started
+	Stop      *StopPolicy       `json:"stop,omitempty"`       // How it is stopped, if not the default
+	Health    *Health           `json:"health,omitempty"`     // Outcome of its health checks, if it has any
+	StdinPipe string            `json:"stdin_pipe,omitempty"` // Named pipe it reads its input from, see OpenStdin
+	Restarts  int               `json:"restarts,omitempty"`   // Times it was restarted by its restart policy or health check
+	Limits    *Limits           `json:"limits,omitempty"` // Resource limits it was started with
+	Cgroup    string            `json:"cgroup,omitempty"` // Linux cgroup enforcing them
 }
 
 // Getter methods for ProcessInfo to satisfy config.ProcessInfoGetter interface
@@ -58,16 +78,28 @@ func (p *ProcessInfo) GetCommand() string             { return p.Command }
 func (p *ProcessInfo) GetArgs() []string              { return p.Args }
 func (p *ProcessInfo) GetEnv() map[string]string      { return p.Env }
 
+// Validate rejects PID files that decode but cannot describe a process,
+// such as an empty object left behind by an interrupted write
+func (p *ProcessInfo) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("missing process name")
+	}
+	if p.PID <= 0 {
+		return fmt.Errorf("invalid PID %d", p.PID)
+	}
+	return nil
+}
+
 // ProcessManager defines the interface for managing background processes
 type ProcessManager interface {
 	// Start launches a new process with the given name, command, and environment
 	Start(name string, command string, args []string, env []string) (int, error)
 
-	// Stop terminates a process by PID
-	Stop(pid int) error
+	// Stop terminates a process by PID by its stop policy, skipping the grace period once ctx is done
+	Stop(ctx context.Context, pid int) error
 
 	// StopAll terminates all managed processes
-	StopAll() error
+	StopAll(ctx context.Context) error
 
 	// IsRunning checks if a process with the given PID is running
 	IsRunning(pid int) bool
@@ -85,11 +117,44 @@ type ProcessManager interface {
 // Manager implements the ProcessManager interface.
 // It stores process metadata in JSON files in the PID directory
 // and redirects process output to log files in the log directory.
+//
+// Operations on one process name are serialized, within this process by a
+// mutex and across asc processes (CLI, TUI, supervisor) by a lock file in
+// the PID directory's .locks subdirectory, so a Start cannot interleave
+// with a StopAll or another Start of the same name.
 type Manager struct {
 	pidDir string // Directory for storing PID files
 	logDir string // Directory for storing log files
+
+	mu    sync.Mutex
+	names map[string]*keyLock // Per-name locks, while held or awaited
+	pids  map[int]*keyLock    // Per-PID locks held while stopping
+
+	// Restart policies and the state they need (see restart.go)
+	policies  map[string]RestartPolicy
+	children  map[string]*child // Latest process of each name started by this Manager
+	restarts  map[string]*restartState
+	onRestart func(name string, err error)
+
+	limits       map[string]Limits      // Resource limits (see limits.go)
+	rotations    map[string]LogRotation // Log rotation (see logrotate.go)
+	stopPolicies map[string]StopPolicy  // Stop signals, grace periods, and hooks (see stop.go)
+	stdin        map[string]bool        // Processes reading input from a pipe (see stdin.go)
+	launches     map[string]Launch      // Working directories and umasks (see launch.go)
+
+	healthChecks   map[string]HealthCheck // Health checks (see health.go)
+	onHealthChange func(name string, health Health)
+
+	crashes CrashCapture // Crash dumps of failed processes (see crash.go)
 }
 
+// watchDebounce is how long PID file changes must settle before Watch
+// reports them
+const watchDebounce = 100 * time.Millisecond
+
+// lockDirName is the subdirectory of the PID directory holding lock files
+const lockDirName = ".locks"
+
 // NewManager creates a new process manager with the specified directories.
 // The directories will be created if they don't exist. Returns an error
 // if directory creation fails.
@@ -116,6 +181,16 @@ func NewManager(pidDir, logDir string) (*Manager, error) {
 	}, nil
 }
 
+// NewDefaultManager creates a process manager that keeps PID files and logs
+// in the pids and logs directories of the asc state directory
+func NewDefaultManager() (*Manager, error) {
+	stateDir, err := statedir.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return NewManager(filepath.Join(stateDir, "pids"), filepath.Join(stateDir, "logs"))
+}
+
 // Start launches a new process with the given name, command, arguments, and environment.
 // The process runs in its own process group for proper cleanup. Output is redirected
 // to a log file in the log directory. Returns the process PID on success.
@@ -124,32 +199,99 @@ func NewManager(pidDir, logDir string) (*Manager, error) {
 //
 //	pid, err := manager.Start("my-agent", "python", []string{"agent.py"}, []string{"API_KEY=secret"})
 func (m *Manager) Start(name string, command string, args []string, env []string) (int, error) {
+	unlock, err := m.lockName(name)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	pid, err := m.start(name, command, args, env)
+	if err == nil {
+		events.Publish(events.Event{Type: events.AgentStarted, Agent: name, Message: fmt.Sprintf("PID %d", pid)})
+	}
+	return pid, err
+}
+
+// start is Start for a caller holding the lock of name
+func (m *Manager) start(name string, command string, args []string, env []string) (int, error) {
+	// Another Start of this name may have won the race
+	if existing, err := m.GetProcessInfo(name); err == nil && Live(m, existing) {
+		return 0, fmt.Errorf("process %s is already running (PID %d)", name, existing.PID)
+	}
+
 	// Create log file with secure permissions (0600)
 	// This ensures only the owner can read/write the log file
 	logPath := filepath.Join(m.logDir, fmt.Sprintf("%s.log", name))
+	if err := rotateLog(logPath, m.logRotationFor(name)); err != nil {
+		processLog.WithFields(logger.Fields{"name": name}).Warn("Failed to rotate log: %v", err)
+	}
 	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create log file: %w", err)
 	}
 	defer logFile.Close()
+	var logOffset int64
+	if stat, err := logFile.Stat(); err == nil {
+		logOffset = stat.Size()
+	}
+
+	// The span of this run, ended when the process exits, is the parent
+	// of what the process traces
+	_, span := telemetry.Start(context.Background(), "process.run", telemetry.Attrs{
+		"process.name":    name,
+		"process.command": command,
+	})
 
 	// Create command
 	cmd := exec.Command(command, args...)
+	launch := m.launchFor(name)
+	if err := launch.prepare(name, cmd); err != nil {
+		span.SetError(err)
+		span.End()
+		return 0, err
+	}
 	cmd.Env = append(os.Environ(), env...)
+	if id := logger.CorrelationID(); id != "" {
+		cmd.Env = append(cmd.Env, logger.CorrelationIDEnvVar+"="+id)
+	}
+	cmd.Env = append(cmd.Env, telemetry.TraceparentEnvVar+"="+span.Traceparent())
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
+	var stdinPipe string
+	if m.stdinFor(name) {
+		if pipe, err := openStdinPipe(m.stdinPath(name)); err != nil {
+			processLog.WithFields(logger.Fields{"name": name}).Warn("Input forwarding not available: %v", err)
+		} else {
+			defer pipe.Close()
+			cmd.Stdin = pipe
+			stdinPipe = m.stdinPath(name)
+		}
+	}
 
 	// Set process group for proper cleanup
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
+	setProcessGroup(cmd)
+
+	// Enforce resource limits; without them the process still runs
+	limits := m.limitsFor(name)
+	var lim *limiter
+	if !limits.IsZero() || limitEveryProcess {
+		if lim, err = applyLimits(cmd, name, limits); err != nil {
+			processLog.WithFields(logger.Fields{"name": name}).Warn("Resource limits not enforced: %v", err)
+		}
 	}
 
 	// Start the process
-	if err := cmd.Start(); err != nil {
+	if err := launch.start(cmd); err != nil {
+		lim.release()
+		span.SetError(err)
+		span.End()
 		return 0, fmt.Errorf("failed to start process: %w", err)
 	}
+	if err := lim.started(cmd.Process); err != nil {
+		processLog.WithFields(logger.Fields{"name": name}).Warn("Resource limits not enforced: %v", err)
+	}
 
 	pid := cmd.Process.Pid
+	span.SetAttributes(telemetry.Attrs{"process.pid": pid})
 
 	// Convert env slice to map for storage
 	envMap := make(map[string]string)
@@ -171,49 +313,137 @@ func (m *Manager) Start(name string, command string, args []string, env []string
 		Args:      args,
 		Env:       envMap,
 		StartedAt: time.Now(),
+		Identity:  recordIdentity(name, pid),
 		LogFile:   logPath,
+		LogOffset: logOffset,
+		StdinPipe: stdinPipe,
+		Cgroup:    lim.cgroup(),
+	}
+	if !limits.IsZero() {
+		info.Limits = &limits
+	}
+	if policy := m.stopPolicyFor(name); !policy.IsZero() {
+		info.Stop = &policy
+	}
+	check, checked := m.healthCheckFor(name)
+	if checked {
+		info.Health = &Health{Status: HealthStarting}
 	}
 
 	if err := m.saveProcessInfo(info); err != nil {
 		// Try to kill the process if we can't save its info
 		_ = cmd.Process.Kill()
+		_, _ = cmd.Process.Wait()
+		lim.release()
+		span.SetError(err)
+		span.End()
 		return 0, fmt.Errorf("failed to save process info: %w", err)
 	}
 
+	processLog.WithFields(logger.Fields{
+		"name":    name,
+		"pid":     pid,
+		"command": command,
+		"log":     logPath,
+	}).Info("Process started")
+
+	// Reap the process when it exits and apply its restart policy
+	m.watch(name, cmd, lim, command, args, env, info.StartedAt, span)
+	if m.logRotationFor(name).MaxSize > 0 {
+		go m.rotateWhileRunning(name, logPath, pid)
+	}
+	if checked {
+		go m.checkHealthWhileRunning(name, pid, check, command, args, env)
+	}
+
 	return pid, nil
 }
 
 // Stop terminates a process by PID using graceful shutdown.
-// It sends SIGTERM and waits up to 5 seconds for the process to exit.
-// If the timeout is exceeded, it sends SIGKILL to force termination.
-func (m *Manager) Stop(pid int) error {
+// It runs the pre_stop hook of the process's StopPolicy, sends its stop
+// signal (SIGTERM by default), and waits up to its grace period (5 seconds
+// by default) for the process to exit. If the grace period is exceeded,
+// or ctx is cancelled first (e.g. by a second Ctrl-C), it sends SIGKILL
+// to force termination. Windows has no signals; terminate and kill in
+// proc_windows.go stand in for them.
+func (m *Manager) Stop(ctx context.Context, pid int) error {
+	ctx, span := telemetry.Start(ctx, "process.stop", telemetry.Attrs{"process.pid": pid})
+	defer span.End()
+	name, _ := m.stopPolicyOf(pid)
+	err := m.stop(ctx, pid)
+	span.SetError(err)
+	events.Publish(events.Event{Type: events.AgentStopped, Agent: name, Message: fmt.Sprintf("PID %d", pid), Err: err})
+	return err
+}
+
+// stop is Stop within its span
+func (m *Manager) stop(ctx context.Context, pid int) error {
+	// Concurrent stops of one process wait for the first rather than
+	// racing it to signal and reap the process
+	unlock := m.lockPID(pid)
+	defer unlock()
+
 	process, err := os.FindProcess(pid)
 	if err != nil {
 		return fmt.Errorf("failed to find process: %w", err)
 	}
 
-	// Send SIGTERM for graceful shutdown
-	if err := process.Signal(syscall.SIGTERM); err != nil {
-		return fmt.Errorf("failed to send SIGTERM: %w", err)
+	// A process this Manager started is already being waited for, and
+	// must not be restarted by its policy once it exits
+	c := m.stopping(pid)
+	var exited chan struct{}
+	if c != nil {
+		exited = c.done
+		select {
+		case <-exited:
+			return nil // Already exited and reaped
+		default:
+		}
+	}
+
+	// Let the process finish what it is doing, unless already told to hurry
+	name, policy := m.stopPolicyOf(pid)
+	if policy.PreStop != "" && ctx.Err() == nil {
+		runPreStop(ctx, name, pid, policy)
+	}
+
+	// Send the stop signal for graceful shutdown
+	signal := policy.signalName()
+	processLog.WithFields(logger.Fields{"pid": pid}).Debug("Sending %s", signal)
+	if err := terminate(process, signal); err != nil {
+		return fmt.Errorf("failed to send %s: %w", signal, err)
 	}
 
 	// Wait for graceful shutdown with timeout
 	done := make(chan error, 1)
 	go func() {
-		_, err := process.Wait()
-		done <- err
+		if exited != nil {
+			<-exited
+			done <- nil
+			return
+		}
+		done <- waitExit(process)
 	}()
 
 	select {
-	case <-time.After(5 * time.Second):
+	case <-time.After(policy.gracePeriod()):
 		// Timeout - send SIGKILL
-		if err := process.Signal(syscall.SIGKILL); err != nil {
+		processLog.WithFields(logger.Fields{"pid": pid}).Warn("Process did not exit after %s, sending SIGKILL", signal)
+		// It may have exited since
+		if err := c.kill(process); err != nil && !errors.Is(err, os.ErrProcessDone) {
 			return fmt.Errorf("failed to send SIGKILL: %w", err)
 		}
 		// Wait for SIGKILL to complete
 		<-done
+	case <-ctx.Done():
+		processLog.WithFields(logger.Fields{"pid": pid}).Warn("Stop interrupted, sending SIGKILL")
+		// It may have exited since
+		if err := c.kill(process); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return fmt.Errorf("failed to send SIGKILL: %w", err)
+		}
+		<-done
 	case err := <-done:
-		if err != nil && err.Error() != "signal: terminated" && err.Error() != "signal: killed" {
+		if err != nil && !strings.HasPrefix(err.Error(), "signal: ") {
 			return fmt.Errorf("process wait error: %w", err)
 		}
 	}
@@ -224,7 +454,7 @@ func (m *Manager) Stop(pid int) error {
 // StopAll terminates all managed processes and cleans up their PID files.
 // It attempts to stop each process gracefully and collects any errors that occur.
 // Returns an error if any processes fail to stop.
-func (m *Manager) StopAll() error {
+func (m *Manager) StopAll(ctx context.Context) error {
 	processes, err := m.ListProcesses()
 	if err != nil {
 		return fmt.Errorf("failed to list processes: %w", err)
@@ -232,14 +462,8 @@ func (m *Manager) StopAll() error {
 
 	var errors []error
 	for _, info := range processes {
-		if m.IsRunning(info.PID) {
-			if err := m.Stop(info.PID); err != nil {
-				errors = append(errors, fmt.Errorf("failed to stop %s (PID %d): %w", info.Name, info.PID, err))
-			}
-		}
-		// Clean up PID file
-		if err := m.deleteProcessInfo(info.Name); err != nil {
-			errors = append(errors, fmt.Errorf("failed to delete PID file for %s: %w", info.Name, err))
+		if err := m.stopNamed(ctx, info); err != nil {
+			errors = append(errors, err)
 		}
 	}
 
@@ -250,17 +474,113 @@ func (m *Manager) StopAll() error {
 	return nil
 }
 
-// IsRunning checks if a process with the given PID is running.
-// It uses signal 0 to test process existence without affecting the process.
-func (m *Manager) IsRunning(pid int) bool {
-	process, err := os.FindProcess(pid)
+// stopNamed stops a listed process and removes its PID file while holding
+// the lock of its name. A process restarted under the same name since it
+// was listed is left alone.
+func (m *Manager) stopNamed(ctx context.Context, listed *ProcessInfo) error {
+	unlock, err := m.lockName(listed.Name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	info, err := m.GetProcessInfo(listed.Name)
+	if err != nil || info.PID != listed.PID {
+		return nil
+	}
+
+	// An exited process waiting out its restart backoff stays stopped
+	m.mu.Lock()
+	m.cancelRestart(info.Name)
+	m.mu.Unlock()
+
+	// A PID given to another process since is not ours to stop
+	if Live(m, info) {
+		if err := m.Stop(ctx, info.PID); err != nil {
+			return fmt.Errorf("failed to stop %s (PID %d): %w", info.Name, info.PID, err)
+		}
+	}
+	// Clean up PID file
+	if err := m.deleteProcessInfo(info.Name); err != nil {
+		return fmt.Errorf("failed to delete PID file for %s: %w", info.Name, err)
+	}
+	return nil
+}
+
+// keyLock is the in-process lock of a process name or PID. It is
+// removed from its map once no one holds or waits for it, so the maps do
+// not grow with every process started and reaped.
+type keyLock struct {
+	sync.Mutex
+	users int // Holders and waiters, guarded by Manager.mu
+}
+
+// lockName takes the in-process and cross-process locks of a process name
+// and returns the function that releases them
+func (m *Manager) lockName(name string) (func(), error) {
+	m.mu.Lock()
+	if m.names == nil {
+		m.names = make(map[string]*keyLock)
+	}
+	mu, ok := m.names[name]
+	if !ok {
+		mu = &keyLock{}
+		m.names[name] = mu
+	}
+	mu.users++
+	m.mu.Unlock()
+
+	release := func() {
+		mu.Unlock()
+		m.mu.Lock()
+		if mu.users--; mu.users == 0 {
+			delete(m.names, name)
+		}
+		m.mu.Unlock()
+	}
+	mu.Lock()
+	unlockFile, err := statefile.Lock(filepath.Join(m.pidDir, lockDirName, name+".lock"))
 	if err != nil {
-		return false
+		release()
+		return nil, fmt.Errorf("failed to lock process %s: %w", name, err)
+	}
+	return func() {
+		unlockFile()
+		release()
+	}, nil
+}
+
+// lockPID takes the in-process lock of a PID and returns the function that
+// releases it. Stop holds it until the process is reaped, so the last
+// release deletes the PID's entry.
+func (m *Manager) lockPID(pid int) func() {
+	m.mu.Lock()
+	if m.pids == nil {
+		m.pids = make(map[int]*keyLock)
+	}
+	mu, ok := m.pids[pid]
+	if !ok {
+		mu = &keyLock{}
+		m.pids[pid] = mu
+	}
+	mu.users++
+	m.mu.Unlock()
+
+	mu.Lock()
+	return func() {
+		mu.Unlock()
+		m.mu.Lock()
+		if mu.users--; mu.users == 0 {
+			delete(m.pids, pid)
+		}
+		m.mu.Unlock()
 	}
+}
 
-	// Send signal 0 to check if process exists
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
+// IsRunning checks if a process with the given PID is running.
+// It tests process existence without affecting the process; see Alive.
+func (m *Manager) IsRunning(pid int) bool {
+	return Alive(pid)
 }
 
 // GetStatus returns the status of a process by PID.
@@ -277,19 +597,18 @@ func (m *Manager) GetStatus(pid int) ProcessStatus {
 // if the process is not found or the PID file is invalid.
 func (m *Manager) GetProcessInfo(name string) (*ProcessInfo, error) {
 	pidFile := filepath.Join(m.pidDir, fmt.Sprintf("%s.json", name))
-	data, err := os.ReadFile(pidFile)
-	if err != nil {
+	var info ProcessInfo
+	if err := statefile.ReadJSON(pidFile, &info); err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("process %s not found", name)
 		}
+		if errors.Is(err, statefile.ErrCorrupt) {
+			processLog.WithFields(logger.Fields{"name": name}).Warn("Quarantined corrupt PID file: %v", err)
+			return nil, fmt.Errorf("failed to parse PID file: %w", err)
+		}
 		return nil, fmt.Errorf("failed to read PID file: %w", err)
 	}
 
-	var info ProcessInfo
-	if err := json.Unmarshal(data, &info); err != nil {
-		return nil, fmt.Errorf("failed to parse PID file: %w", err)
-	}
-
 	return &info, nil
 }
 
@@ -318,23 +637,30 @@ func (m *Manager) ListProcesses() ([]*ProcessInfo, error) {
 	return processes, nil
 }
 
-// saveProcessInfo saves process metadata to a JSON file
+// Watch reports processes being started and removed by StopAll, by this
+// or any other asc process, as their PID files are written and removed.
+// A process that exits on its own leaves its PID file and is not reported.
+func (m *Manager) Watch() (*fswatch.Watcher, error) {
+	return fswatch.NewWithFilter(watchDebounce, func(path string) bool {
+		name := filepath.Base(path)
+		return filepath.Ext(name) == ".json" && !strings.HasPrefix(name, ".")
+	}, m.pidDir)
+}
+
+// saveProcessInfo saves process metadata to a JSON file, replacing it
+// atomically so a crash never leaves a truncated PID file behind
 func (m *Manager) saveProcessInfo(info *ProcessInfo) error {
 	pidFile := filepath.Join(m.pidDir, fmt.Sprintf("%s.json", info.Name))
-	data, err := json.MarshalIndent(info, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal process info: %w", err)
-	}
-
-	if err := os.WriteFile(pidFile, data, 0644); err != nil {
+	if err := statefile.WriteJSON(pidFile, info, 0644); err != nil {
 		return fmt.Errorf("failed to write PID file: %w", err)
 	}
 
 	return nil
 }
 
-// deleteProcessInfo removes the PID file for a process
+// deleteProcessInfo removes the PID file and input pipe for a process
 func (m *Manager) deleteProcessInfo(name string) error {
+	_ = os.Remove(m.stdinPath(name))
 	pidFile := filepath.Join(m.pidDir, fmt.Sprintf("%s.json", name))
 	if err := os.Remove(pidFile); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete PID file: %w", err)
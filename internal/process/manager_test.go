@@ -1,10 +1,16 @@
 package process
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/rand/asc/internal/telemetry"
 )
 
 func TestNewManager(t *testing.T) {
@@ -62,7 +68,7 @@ func TestStartAndStopProcess(t *testing.T) {
 	}
 
 	// Stop the process
-	err = manager.Stop(pid)
+	err = manager.Stop(context.Background(), pid)
 	if err != nil {
 		t.Errorf("Stop failed: %v", err)
 	}
@@ -91,7 +97,7 @@ func TestProcessInfo(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Start failed: %v", err)
 	}
-	defer manager.Stop(pid)
+	defer manager.Stop(context.Background(), pid)
 
 	// Get process info
 	info, err := manager.GetProcessInfo("info-test")
@@ -131,13 +137,13 @@ func TestListProcesses(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Start proc1 failed: %v", err)
 	}
-	defer manager.Stop(pid1)
+	defer manager.Stop(context.Background(), pid1)
 
 	pid2, err := manager.Start("proc2", "sleep", []string{"10"}, nil)
 	if err != nil {
 		t.Fatalf("Start proc2 failed: %v", err)
 	}
-	defer manager.Stop(pid2)
+	defer manager.Stop(context.Background(), pid2)
 
 	// List processes
 	processes, err := manager.ListProcesses()
@@ -174,7 +180,7 @@ func TestStopAll(t *testing.T) {
 	pid2, _ := manager.Start("stop-all-2", "sleep", []string{"30"}, nil)
 
 	// Stop all
-	err = manager.StopAll()
+	err = manager.StopAll(context.Background())
 	if err != nil {
 		t.Errorf("StopAll failed: %v", err)
 	}
@@ -197,6 +203,116 @@ func TestStopAll(t *testing.T) {
 	}
 }
 
+// TestConcurrentStartSameName checks concurrent starts of one name run a
+// single process, and two managers on one PID directory (as the CLI and
+// the TUI would have) see each other's locks
+func TestConcurrentStartSameName(t *testing.T) {
+	tmpDir := t.TempDir()
+	pidDir := filepath.Join(tmpDir, "pids")
+	logDir := filepath.Join(tmpDir, "logs")
+
+	managers := make([]*Manager, 2)
+	for i := range managers {
+		manager, err := NewManager(pidDir, logDir)
+		if err != nil {
+			t.Fatalf("NewManager failed: %v", err)
+		}
+		managers[i] = manager
+	}
+	defer managers[0].StopAll(context.Background())
+
+	var wg sync.WaitGroup
+	var started atomic.Int32
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(manager *Manager) {
+			defer wg.Done()
+			if _, err := manager.Start("agent", "sleep", []string{"30"}, nil); err == nil {
+				started.Add(1)
+			} else if !strings.Contains(err.Error(), "already running") {
+				t.Errorf("Unexpected Start error: %v", err)
+			}
+		}(managers[i%2])
+	}
+	wg.Wait()
+
+	if started.Load() != 1 {
+		t.Errorf("Expected exactly one Start to succeed, got %d", started.Load())
+	}
+	processes, err := managers[1].ListProcesses()
+	if err != nil || len(processes) != 1 || !managers[1].IsRunning(processes[0].PID) {
+		t.Errorf("Expected one running process, got %+v, %v", processes, err)
+	}
+}
+
+// TestLocksDeletedOnceReleased checks the locks of a PID and a name are
+// forgotten once the process is reaped and no one waits for them
+func TestLocksDeletedOnceReleased(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(filepath.Join(tmpDir, "pids"), filepath.Join(tmpDir, "logs"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	pid, err := manager.Start("agent", "sleep", []string{"30"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// Concurrent stops share the lock of the PID while it is reaped
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := manager.Stop(context.Background(), pid); err != nil {
+				t.Errorf("Stop failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := manager.StopAll(context.Background()); err != nil {
+		t.Fatalf("StopAll failed: %v", err)
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if len(manager.pids) != 0 || len(manager.names) != 0 {
+		t.Errorf("Expected no locks left, got PIDs %v and names %v", manager.pids, manager.names)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(filepath.Join(tmpDir, "pids"), filepath.Join(tmpDir, "logs"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	watcher, err := manager.Watch()
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer watcher.Close()
+
+	waitForChange := func(what string) {
+		t.Helper()
+		select {
+		case <-watcher.Changes():
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected %s to be reported", what)
+		}
+	}
+
+	if _, err := manager.Start("watched", "sleep", []string{"10"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	waitForChange("the start")
+
+	if err := manager.StopAll(context.Background()); err != nil {
+		t.Fatalf("StopAll failed: %v", err)
+	}
+	waitForChange("the stop")
+}
+
 func TestLogFileCreation(t *testing.T) {
 	tmpDir := t.TempDir()
 	pidDir := filepath.Join(tmpDir, "pids")
@@ -233,7 +349,36 @@ func TestLogFileCreation(t *testing.T) {
 		t.Errorf("Log file is empty")
 	}
 
-	manager.Stop(pid)
+	manager.Stop(context.Background(), pid)
+}
+
+func TestStartPassesTraceparent(t *testing.T) {
+	tmpDir := t.TempDir()
+	logDir := filepath.Join(tmpDir, "logs")
+
+	manager, err := NewManager(filepath.Join(tmpDir, "pids"), logDir)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	pid, err := manager.Start("trace-test", "sh", []string{"-c", "echo $TRACEPARENT"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Stop(context.Background(), pid)
+
+	deadline := time.Now().Add(2 * time.Second)
+	var content []byte
+	for time.Now().Before(deadline) {
+		content, _ = os.ReadFile(filepath.Join(logDir, "trace-test.log"))
+		if len(content) > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, _, ok := telemetry.ParseTraceparent(string(content)); !ok {
+		t.Errorf("Expected the process to get a traceparent, got %q", content)
+	}
 }
 
 func TestIsRunningNonExistentProcess(t *testing.T) {
@@ -269,6 +414,35 @@ func TestGetProcessInfoNotFound(t *testing.T) {
 	}
 }
 
+func TestGetProcessInfoCorrupted(t *testing.T) {
+	tmpDir := t.TempDir()
+	pidDir := filepath.Join(tmpDir, "pids")
+	logDir := filepath.Join(tmpDir, "logs")
+
+	manager, err := NewManager(pidDir, logDir)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	// A truncated write and an empty object are both rejected
+	os.WriteFile(filepath.Join(pidDir, "truncated.json"), []byte(`{"name": "trunc`), 0644)
+	os.WriteFile(filepath.Join(pidDir, "empty.json"), []byte(`{}`), 0644)
+
+	for _, name := range []string{"truncated", "empty"} {
+		if _, err := manager.GetProcessInfo(name); err == nil {
+			t.Errorf("Expected error for corrupted PID file %s", name)
+		}
+		if _, err := os.Stat(filepath.Join(pidDir, name+".json")); !os.IsNotExist(err) {
+			t.Errorf("Expected corrupted PID file %s to be quarantined", name)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(pidDir, ".quarantine"))
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Expected 2 quarantined files, got %d (%v)", len(entries), err)
+	}
+}
+
 func TestProcessStatus(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -0,0 +1,117 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in its own process group for proper cleanup
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+}
+
+// umaskMu serializes starting processes with a umask of their own, since
+// the umask is that of asc until the process starts
+var umaskMu sync.Mutex
+
+// startWithUmask starts cmd with umask as the mask of the files it creates
+func startWithUmask(cmd *exec.Cmd, umask os.FileMode) error {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+	previous := syscall.Umask(int(umask))
+	defer syscall.Umask(previous)
+	return cmd.Start()
+}
+
+// stopSignals are the signals a StopPolicy may name
+var stopSignals = map[string]syscall.Signal{
+	"SIGTERM": syscall.SIGTERM,
+	"SIGINT":  syscall.SIGINT,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// crashSignals name the other signals a process commonly dies of
+var crashSignals = map[syscall.Signal]string{
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGXCPU: "SIGXCPU",
+}
+
+// signalName returns the name of a signal, e.g. "SIGSEGV"
+func signalName(sig syscall.Signal) string {
+	if name, ok := crashSignals[sig]; ok {
+		return name
+	}
+	for name, s := range stopSignals {
+		if s == sig {
+			return name
+		}
+	}
+	return fmt.Sprintf("signal %d (%s)", int(sig), sig)
+}
+
+// terminate asks a process to exit by sending it the named signal
+func terminate(p *os.Process, signal string) error {
+	sig, ok := stopSignals[signal]
+	if !ok {
+		return fmt.Errorf("unsupported stop signal %s", signal)
+	}
+	return p.Signal(sig)
+}
+
+// shellCommand returns a command running command with sh
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// openStdinPipe creates a named pipe at path, replacing one left by an
+// earlier process, and opens it for a process to read its input from. It
+// is opened for writing too, so the open does not wait for a writer and
+// the process does not read end of file when one goes away.
+func openStdinPipe(path string) (*os.File, error) {
+	_ = os.Remove(path)
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_RDWR, 0)
+}
+
+// openStdinWriter opens the named pipe a process reads its input from. It
+// fails rather than waits when the process is gone.
+func openStdinWriter(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+}
+
+// kill forces a process to exit by sending it SIGKILL
+func kill(p *os.Process) error {
+	return p.Kill()
+}
+
+// Alive reports whether a process with the given PID exists. It sends
+// signal 0, which tests existence without affecting the process.
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
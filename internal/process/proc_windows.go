@@ -0,0 +1,118 @@
+//go:build windows
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code GetExitCodeProcess reports for a process
+// that has not exited
+const stillActive = 259
+
+// setProcessGroup starts cmd in a new process group
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
+
+// terminate asks a process to exit. Windows has no SIGTERM: a console
+// process sharing this console is sent CTRL_BREAK_EVENT, which its
+// process group of its own lets it receive alone, and any other is asked
+// to close its windows, and those of the processes it started, by
+// taskkill. A process neither reaches is killed. The signal named by a
+// StopPolicy has no equivalent and is ignored.
+func terminate(p *os.Process, _ string) error {
+	if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(p.Pid)); err == nil {
+		return nil
+	}
+	if err := taskkill(p.Pid, false); err == nil {
+		return nil
+	}
+	return kill(p)
+}
+
+// signalName returns the name of a signal. Windows processes are not
+// killed by signals, so crashes never name one.
+func signalName(sig syscall.Signal) string {
+	return sig.String()
+}
+
+// errNoStdinPipe is returned on Windows, where processes cannot be given
+// input by another asc
+var errNoStdinPipe = fmt.Errorf("forwarding input is not supported on Windows")
+
+// openStdinPipe is not supported on Windows
+func openStdinPipe(string) (*os.File, error) {
+	return nil, errNoStdinPipe
+}
+
+// openStdinWriter is not supported on Windows
+func openStdinWriter(string) (*os.File, error) {
+	return nil, errNoStdinPipe
+}
+
+// startWithUmask starts cmd; Windows has no umask, so files get the
+// permissions of the directory they are created in
+func startWithUmask(cmd *exec.Cmd, _ os.FileMode) error {
+	return cmd.Start()
+}
+
+// kill forces a process and the processes it started to exit with
+// taskkill /F, or the process alone if taskkill fails
+func kill(p *os.Process) error {
+	if err := taskkill(p.Pid, true); err == nil {
+		return nil
+	}
+	return p.Kill()
+}
+
+// shellCommand returns a command running command with cmd.exe
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd", "/C", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	return cmd
+}
+
+// taskkill runs taskkill on the tree of processes rooted at pid
+func taskkill(pid int, force bool) error {
+	args := []string{"/PID", strconv.Itoa(pid), "/T"}
+	if force {
+		args = append(args, "/F")
+	}
+	cmd := exec.Command("taskkill", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("taskkill failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Alive reports whether a process with the given PID exists and has not
+// exited. Signal 0 is not supported on Windows, so it opens the process
+// and checks its exit code.
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
@@ -0,0 +1,139 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Action is what reconciling does with one process
+type Action string
+
+const (
+	// ActionStart starts a desired process that is not running
+	ActionStart Action = "start"
+	// ActionKeep adopts a desired process that is already running
+	ActionKeep Action = "keep"
+	// ActionStop stops a running process that is not desired
+	ActionStop Action = "stop"
+	// ActionClean removes the PID file of a process that is neither
+	// running nor desired
+	ActionClean Action = "clean"
+)
+
+// Step is one action of a reconcile plan
+type Step struct {
+	Name   string
+	Action Action
+	// PID is the recorded PID, or 0 if the process has no PID file. A
+	// start step with a PID replaces the stale PID file of a process that
+	// exited.
+	PID int
+	// Reused is set when the PID belongs to a later process now, so the
+	// recorded process is not running even though its PID is
+	Reused bool
+}
+
+// String describes the step for the plan printed by asc up and asc down
+func (s Step) String() string {
+	switch s.Action {
+	case ActionKeep:
+		return fmt.Sprintf("keep %s (PID %d, already running)", s.Name, s.PID)
+	case ActionStop:
+		return fmt.Sprintf("stop %s (PID %d)", s.Name, s.PID)
+	case ActionClean:
+		return fmt.Sprintf("clean %s (stale PID file, %s)", s.Name, s.notRunning())
+	case ActionStart:
+		if s.PID != 0 {
+			return fmt.Sprintf("start %s (replacing stale PID file, %s)", s.Name, s.notRunning())
+		}
+	}
+	return fmt.Sprintf("%s %s", s.Action, s.Name)
+}
+
+// notRunning explains why the recorded process of a step is not running
+func (s Step) notRunning() string {
+	if s.Reused {
+		return fmt.Sprintf("PID %d belongs to another process now", s.PID)
+	}
+	return fmt.Sprintf("PID %d not running", s.PID)
+}
+
+// Plan compares the desired processes with those recorded in PID files
+// and returns the steps that make them match: desired processes are kept
+// if running and started otherwise, and other recorded processes are
+// stopped, or cleaned up if they already exited. Desired processes come
+// first, in the given order, then the others sorted by name. Running the
+// steps again after a partial failure is safe, since each one is planned
+// from the current state.
+func Plan(pm ProcessManager, desired []string) ([]Step, error) {
+	processes, err := pm.ListProcesses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	recorded := make(map[string]*ProcessInfo, len(processes))
+	for _, info := range processes {
+		recorded[info.Name] = info
+	}
+
+	var steps []Step
+	wanted := make(map[string]bool, len(desired))
+	for _, name := range desired {
+		if wanted[name] {
+			continue
+		}
+		wanted[name] = true
+
+		step := Step{Name: name, Action: ActionStart}
+		if info, ok := recorded[name]; ok {
+			step.PID = info.PID
+			step.Action, step.Reused = planRecorded(pm, info, ActionKeep, ActionStart)
+		}
+		steps = append(steps, step)
+	}
+
+	var others []Step
+	for name, info := range recorded {
+		if wanted[name] {
+			continue
+		}
+		step := Step{Name: name, PID: info.PID}
+		step.Action, step.Reused = planRecorded(pm, info, ActionStop, ActionClean)
+		others = append(others, step)
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].Name < others[j].Name })
+
+	return append(steps, others...), nil
+}
+
+// planRecorded returns the action for a recorded process, running if it
+// is live and gone otherwise, and whether its PID was reused
+func planRecorded(pm ProcessManager, info *ProcessInfo, running, gone Action) (Action, bool) {
+	if !pm.IsRunning(info.PID) {
+		return gone, false
+	}
+	if !SameProcess(info) {
+		return gone, true
+	}
+	return running, false
+}
+
+// Running returns the PID of the named process if it is recorded and
+// running, and its PID was not given to another process since
+func Running(pm ProcessManager, name string) (int, bool) {
+	info, err := pm.GetProcessInfo(name)
+	if err != nil || !Live(pm, info) {
+		return 0, false
+	}
+	return info.PID, true
+}
+
+// StopNamed stops the named process, if it is running, and removes its
+// PID file. A process without a PID file is already stopped.
+func (m *Manager) StopNamed(ctx context.Context, name string) error {
+	info, err := m.GetProcessInfo(name)
+	if err != nil {
+		return nil
+	}
+	return m.stopNamed(ctx, info)
+}
@@ -0,0 +1,96 @@
+package process
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPlan(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(filepath.Join(tmpDir, "pids"), filepath.Join(tmpDir, "logs"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	defer manager.StopAll(context.Background())
+
+	keeperPID, err := manager.Start("keeper", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	extraPID, err := manager.Start("extra", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	// PID files of processes that exited without cleaning up
+	const deadPID = 999999
+	for _, name := range []string{"stale", "ghost"} {
+		if err := manager.saveProcessInfo(&ProcessInfo{Name: name, PID: deadPID, Command: "sleep", StartedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps, err := Plan(manager, []string{"keeper", "stale", "new"})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	want := []Step{
+		{Name: "keeper", Action: ActionKeep, PID: keeperPID},
+		{Name: "stale", Action: ActionStart, PID: deadPID},
+		{Name: "new", Action: ActionStart},
+		{Name: "extra", Action: ActionStop, PID: extraPID},
+		{Name: "ghost", Action: ActionClean, PID: deadPID},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("Plan returned %v, want %v", steps, want)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Errorf("Step %d = %+v, want %+v", i, steps[i], want[i])
+		}
+	}
+
+	// Stopping what is not wanted converges: a second plan has nothing
+	// left to stop or clean
+	for _, name := range []string{"extra", "ghost", "never-started"} {
+		if err := manager.StopNamed(context.Background(), name); err != nil {
+			t.Errorf("StopNamed(%s) failed: %v", name, err)
+		}
+	}
+	steps, err = Plan(manager, []string{"keeper", "stale", "new"})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(steps) != 3 {
+		t.Errorf("Expected only the desired processes after stopping the others, got %v", steps)
+	}
+	if manager.IsRunning(extraPID) {
+		t.Error("Expected the extra process to be stopped")
+	}
+
+	if pid, ok := Running(manager, "keeper"); !ok || pid != keeperPID {
+		t.Errorf("Running(keeper) = %d, %v", pid, ok)
+	}
+	if _, ok := Running(manager, "stale"); ok {
+		t.Error("Expected a stale PID file not to count as running")
+	}
+}
+
+func TestStepString(t *testing.T) {
+	tests := []struct {
+		step Step
+		want string
+	}{
+		{Step{Name: "planner", Action: ActionStart}, "start planner"},
+		{Step{Name: "planner", Action: ActionStart, PID: 12}, "start planner (replacing stale PID file, PID 12 not running)"},
+		{Step{Name: "planner", Action: ActionKeep, PID: 12}, "keep planner (PID 12, already running)"},
+		{Step{Name: "old", Action: ActionStop, PID: 12}, "stop old (PID 12)"},
+		{Step{Name: "old", Action: ActionClean, PID: 12}, "clean old (stale PID file, PID 12 not running)"},
+	}
+	for _, tt := range tests {
+		if got := tt.step.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/telemetry"
+)
+
+// RestartMode says which exits of a process are followed by a restart
+type RestartMode string
+
+const (
+	// RestartAlways restarts a process however it exits
+	RestartAlways RestartMode = "always"
+	// RestartOnFailure restarts a process that exits with a non-zero
+	// status or is killed by a signal
+	RestartOnFailure RestartMode = "on-failure"
+	// RestartNever leaves a process that exits stopped
+	RestartNever RestartMode = "never"
+)
+
+// RestartPolicy says whether and how soon the Manager restarts a process
+// it started that exits on its own. Processes stopped with Stop or
+// StopAll are never restarted.
+type RestartPolicy struct {
+	Mode RestartMode
+	// MaxRetries is how many restarts in a row are attempted before giving
+	// up; negative for no limit. A process that ran for a minute before
+	// exiting starts counting again.
+	MaxRetries int
+	// Backoff is the delay before the first restart, doubled for each
+	// restart in a row up to maxRestartBackoff
+	Backoff time.Duration
+}
+
+// maxRestartBackoff bounds the delay between restarts of a process that
+// keeps exiting
+const maxRestartBackoff = 5 * time.Minute
+
+// restartResetAfter is how long a process must run for its next exit to
+// count as the first in a row
+const restartResetAfter = time.Minute
+
+// shouldRestart reports whether an exit with the given error from
+// exec.Cmd.Wait calls for a restart
+func (p RestartPolicy) shouldRestart(exitErr error) bool {
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exitErr != nil
+	}
+	return false
+}
+
+// delay returns how long to wait before restart number n in a row,
+// counting from 0
+func (p RestartPolicy) delay(n int) time.Duration {
+	delay := p.Backoff
+	for i := 0; i < n && delay < maxRestartBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRestartBackoff {
+		delay = maxRestartBackoff
+	}
+	return delay
+}
+
+// child is a process started by this Manager, which waits for it to exit
+type child struct {
+	pid     int
+	lim     *limiter      // Enforces its resource limits, if any
+	done    chan struct{} // Closed once the process exited and was reaped
+	stopped bool          // Stopped with Stop, so not restarted
+}
+
+// kill forces the process to exit, along with the processes it started
+// where its limiter contains them. c may be nil for a process this
+// Manager did not start.
+func (c *child) kill(p *os.Process) error {
+	if c != nil && c.lim.kill() == nil {
+		return nil
+	}
+	return kill(p)
+}
+
+// restartState tracks the restarts in a row of one process name
+type restartState struct {
+	count int
+	// pending is the restart waiting out its backoff. Stopping the
+	// process cancels it.
+	pending *time.Timer
+	// generation is increased by cancellations, so a restart that already
+	// fired can tell it was cancelled
+	generation int
+}
+
+// SetRestartPolicy sets the restart policy of the named process, applied
+// to the processes of that name this Manager starts from then on
+func (m *Manager) SetRestartPolicy(name string, policy RestartPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.policies == nil {
+		m.policies = make(map[string]RestartPolicy)
+	}
+	m.policies[name] = policy
+}
+
+// SetOnRestart sets a function called after each restart attempt by a
+// restart policy, with the error of a restart that failed
+func (m *Manager) SetOnRestart(onRestart func(name string, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onRestart = onRestart
+}
+
+// Supervises reports whether the named process was started by this
+// Manager under a restart policy and not stopped since, so that restarting
+// it, or leaving it stopped, is up to the policy. Other means of recovery,
+// such as the health monitor and the daemon, leave it alone.
+func (m *Manager) Supervises(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, hasPolicy := m.policies[name]
+	c, ok := m.children[name]
+	return hasPolicy && ok && !c.stopped
+}
+
+// watch waits for a process started by Start to exit, reaping it and
+// removing what enforced its resource limits, ends the span of its run,
+// and restarts it if its restart policy says to
+func (m *Manager) watch(name string, cmd *exec.Cmd, lim *limiter, command string, args, env []string, started time.Time, span *telemetry.Span) {
+	c := &child{pid: cmd.Process.Pid, lim: lim, done: make(chan struct{})}
+	m.mu.Lock()
+	if m.children == nil {
+		m.children = make(map[string]*child)
+	}
+	m.children[name] = c
+	m.mu.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		exitedAt := time.Now()
+		lim.release()
+		close(c.done)
+		stopped := m.wasStopped(c)
+		if cmd.ProcessState != nil {
+			span.SetAttributes(telemetry.Attrs{"process.exit_code": cmd.ProcessState.ExitCode(), "process.stopped": stopped})
+		}
+		if cmd.ProcessState != nil && !cmd.ProcessState.Success() && !stopped {
+			span.SetError(err)
+			m.recordCrash(name, c.pid, cmd.ProcessState, command, args, started, exitedAt)
+			events.Publish(events.Event{Type: events.AgentCrashed, Agent: name, Message: fmt.Sprintf("PID %d", c.pid), Err: err, Time: exitedAt})
+		}
+		span.EndAt(exitedAt)
+		m.exited(name, c, command, args, env, err, exitedAt.Sub(started))
+	}()
+}
+
+// wasStopped reports whether a child exited because it was stopped
+func (m *Manager) wasStopped(c *child) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return c.stopped
+}
+
+// exited schedules the restart of a process that exited on its own, if its
+// restart policy calls for one
+func (m *Manager) exited(name string, c *child, command string, args, env []string, exitErr error, ran time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	policy, ok := m.policies[name]
+	if c.stopped || !ok || m.children[name] != c {
+		return
+	}
+
+	fields := logger.Fields{"name": name, "pid": c.pid}
+	if exitErr != nil {
+		fields["error"] = exitErr.Error()
+	}
+	if !policy.shouldRestart(exitErr) {
+		processLog.WithFields(fields).Info("Process exited, not restarting it (restart = %s)", policy.Mode)
+		return
+	}
+
+	state := m.restartState(name)
+	if ran >= restartResetAfter {
+		state.count = 0
+	}
+	if policy.MaxRetries >= 0 && state.count >= policy.MaxRetries {
+		processLog.WithFields(fields).Error("Process exited, giving up after %d restart(s) in a row", state.count)
+		return
+	}
+
+	delay := policy.delay(state.count)
+	state.count++
+	generation := state.generation
+	processLog.WithFields(fields).Warn("Process exited, restarting it in %s", delay)
+	state.pending = time.AfterFunc(delay, func() {
+		m.restart(name, generation, c.pid, command, args, env)
+	})
+}
+
+// restart starts the process that ran as pid again after its backoff,
+// unless it was stopped in the meantime, by this Manager or another asc
+func (m *Manager) restart(name string, generation, pid int, command string, args, env []string) {
+	unlock, err := m.lockName(name)
+	if err != nil {
+		processLog.WithFields(logger.Fields{"name": name}).Error("Failed to restart process: %v", err)
+		return
+	}
+	m.mu.Lock()
+	state := m.restartState(name)
+	cancelled := state.generation != generation
+	state.pending = nil
+	onRestart := m.onRestart
+	m.mu.Unlock()
+	if cancelled {
+		unlock()
+		return
+	}
+	// Another asc removes the PID file when it stops the process, and
+	// replaces it when it starts the process again
+	if info, err := m.GetProcessInfo(name); err != nil || info.PID != pid {
+		unlock()
+		processLog.WithFields(logger.Fields{"name": name, "pid": pid}).Info("Process was stopped or restarted by another asc, not restarting it")
+		return
+	}
+
+	restarts := m.restartsOf(name)
+	pid, err = m.start(name, command, args, env)
+	if err == nil {
+		m.countRestart(name, pid, restarts+1)
+	}
+	unlock()
+	if err != nil {
+		processLog.WithFields(logger.Fields{"name": name}).Error("Failed to restart process: %v", err)
+		events.Publish(events.Event{Type: events.AgentRestarted, Agent: name, Err: err})
+	} else {
+		processLog.WithFields(logger.Fields{"name": name, "pid": pid}).Info("Restarted process")
+		events.Publish(events.Event{Type: events.AgentRestarted, Agent: name, Message: fmt.Sprintf("PID %d, restart %d", pid, restarts+1)})
+	}
+	if onRestart != nil {
+		onRestart(name, err)
+	}
+}
+
+// restartsOf returns how many times the recorded process of the given
+// name was restarted. The caller holds the lock of name.
+func (m *Manager) restartsOf(name string) int {
+	info, err := m.GetProcessInfo(name)
+	if err != nil {
+		return 0
+	}
+	return info.Restarts
+}
+
+// countRestart records the restart count of a process just restarted, so
+// asc top can show it. The caller holds the lock of name.
+func (m *Manager) countRestart(name string, pid, restarts int) {
+	info, err := m.GetProcessInfo(name)
+	if err != nil || info.PID != pid {
+		return
+	}
+	info.Restarts = restarts
+	if err := m.saveProcessInfo(info); err != nil {
+		processLog.WithFields(logger.Fields{"name": name}).Warn("Failed to save restart count: %v", err)
+	}
+}
+
+// cancelRestart cancels the pending restart of the named process. The
+// caller holds m.mu.
+func (m *Manager) cancelRestart(name string) {
+	state := m.restartState(name)
+	if state.pending != nil {
+		state.pending.Stop()
+		state.pending = nil
+	}
+	state.generation++
+	state.count = 0
+}
+
+// stopping marks the child with the given PID as stopped, so it is not
+// restarted, and returns it, or nil if this Manager did not start it
+func (m *Manager) stopping(pid int) *child {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, c := range m.children {
+		if c.pid == pid {
+			c.stopped = true
+			m.cancelRestart(name)
+			return c
+		}
+	}
+	return nil
+}
+
+// restartState returns the restart state of the named process, creating
+// it on first use. The caller holds m.mu.
+func (m *Manager) restartState(name string) *restartState {
+	if m.restarts == nil {
+		m.restarts = make(map[string]*restartState)
+	}
+	state, ok := m.restarts[name]
+	if !ok {
+		state = &restartState{}
+		m.restarts[name] = state
+	}
+	return state
+}
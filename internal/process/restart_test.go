@@ -0,0 +1,230 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newRestartTestManager(t *testing.T) *Manager {
+	t.Helper()
+	tmpDir := t.TempDir()
+	manager, err := NewManager(filepath.Join(tmpDir, "pids"), filepath.Join(tmpDir, "logs"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.StopAll(context.Background()) })
+	return manager
+}
+
+// restartRecorder collects the restarts reported by a Manager
+type restartRecorder struct {
+	mu       sync.Mutex
+	restarts []string
+	signal   chan struct{}
+}
+
+func recordRestarts(manager *Manager) *restartRecorder {
+	r := &restartRecorder{signal: make(chan struct{}, 100)}
+	manager.SetOnRestart(func(name string, err error) {
+		r.mu.Lock()
+		r.restarts = append(r.restarts, name)
+		r.mu.Unlock()
+		r.signal <- struct{}{}
+	})
+	return r
+}
+
+func (r *restartRecorder) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.signal:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %d restart(s), got %d", n, i)
+		}
+	}
+}
+
+func (r *restartRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.restarts)
+}
+
+func TestRestartOnFailure(t *testing.T) {
+	manager := newRestartTestManager(t)
+	recorder := recordRestarts(manager)
+	manager.SetRestartPolicy("crasher", RestartPolicy{Mode: RestartOnFailure, MaxRetries: 2, Backoff: 10 * time.Millisecond})
+
+	pid, err := manager.Start("crasher", "sh", []string{"-c", "exit 1"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	recorder.wait(t, 2)
+
+	// Gives up after MaxRetries restarts in a row
+	time.Sleep(200 * time.Millisecond)
+	if got := recorder.count(); got != 2 {
+		t.Errorf("Expected 2 restarts, got %d", got)
+	}
+	info, err := manager.GetProcessInfo("crasher")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	if info.PID == pid {
+		t.Error("Expected the restarted process to have a new PID")
+	}
+	if info.Restarts != 2 {
+		t.Errorf("Expected the restart count to be recorded, got %d", info.Restarts)
+	}
+	if !manager.Supervises("crasher") {
+		t.Error("Expected a process that was given up on to stay supervised")
+	}
+}
+
+func TestRestartOnFailureIgnoresCleanExit(t *testing.T) {
+	manager := newRestartTestManager(t)
+	recorder := recordRestarts(manager)
+	manager.SetRestartPolicy("worker", RestartPolicy{Mode: RestartOnFailure, MaxRetries: -1, Backoff: 10 * time.Millisecond})
+
+	if _, err := manager.Start("worker", "sh", []string{"-c", "exit 0"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := recorder.count(); got != 0 {
+		t.Errorf("Expected no restarts after a clean exit, got %d", got)
+	}
+}
+
+func TestRestartAlways(t *testing.T) {
+	manager := newRestartTestManager(t)
+	recorder := recordRestarts(manager)
+	manager.SetRestartPolicy("worker", RestartPolicy{Mode: RestartAlways, MaxRetries: 1, Backoff: 10 * time.Millisecond})
+
+	if _, err := manager.Start("worker", "sh", []string{"-c", "exit 0"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	recorder.wait(t, 1)
+}
+
+func TestRestartNever(t *testing.T) {
+	manager := newRestartTestManager(t)
+	recorder := recordRestarts(manager)
+	manager.SetRestartPolicy("worker", RestartPolicy{Mode: RestartNever})
+
+	if _, err := manager.Start("worker", "sh", []string{"-c", "exit 1"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := recorder.count(); got != 0 {
+		t.Errorf("Expected no restarts, got %d", got)
+	}
+	if !manager.Supervises("worker") {
+		t.Error("Expected the process to stay supervised, so no one else restarts it")
+	}
+}
+
+func TestStopCancelsRestart(t *testing.T) {
+	manager := newRestartTestManager(t)
+	recorder := recordRestarts(manager)
+	manager.SetRestartPolicy("worker", RestartPolicy{Mode: RestartAlways, MaxRetries: -1, Backoff: 10 * time.Millisecond})
+
+	pid, err := manager.Start("worker", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := manager.Stop(context.Background(), pid); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if manager.IsRunning(pid) {
+		t.Error("Expected the stopped process to be reaped")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := recorder.count(); got != 0 {
+		t.Errorf("Expected a stopped process not to be restarted, got %d restarts", got)
+	}
+	if manager.Supervises("worker") {
+		t.Error("Expected a stopped process not to be supervised")
+	}
+}
+
+func TestStopByAnotherManagerCancelsRestart(t *testing.T) {
+	tmpDir := t.TempDir()
+	pidDir, logDir := filepath.Join(tmpDir, "pids"), filepath.Join(tmpDir, "logs")
+	manager, err := NewManager(pidDir, logDir)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.StopAll(context.Background()) })
+	other, err := NewManager(pidDir, logDir)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	recorder := recordRestarts(manager)
+	manager.SetRestartPolicy("worker", RestartPolicy{Mode: RestartAlways, MaxRetries: -1, Backoff: 10 * time.Millisecond})
+
+	if _, err := manager.Start("worker", "sleep", []string{"10"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	// As asc scale stops an instance that asc up started
+	if err := other.StopNamed(context.Background(), "worker"); err != nil {
+		t.Fatalf("StopNamed failed: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if got := recorder.count(); got != 0 {
+		t.Errorf("Expected a process stopped by another manager not to be restarted, got %d restarts", got)
+	}
+	if _, err := manager.GetProcessInfo("worker"); err == nil {
+		t.Error("Expected no PID file for the stopped process")
+	}
+}
+
+func TestStopAllCancelsPendingRestart(t *testing.T) {
+	manager := newRestartTestManager(t)
+	recorder := recordRestarts(manager)
+	manager.SetRestartPolicy("worker", RestartPolicy{Mode: RestartAlways, MaxRetries: -1, Backoff: 300 * time.Millisecond})
+
+	if _, err := manager.Start("worker", "sh", []string{"-c", "exit 1"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond) // Exited, waiting out the backoff
+	if err := manager.StopAll(context.Background()); err != nil {
+		t.Fatalf("StopAll failed: %v", err)
+	}
+	time.Sleep(400 * time.Millisecond)
+	if got := recorder.count(); got != 0 {
+		t.Errorf("Expected the pending restart to be cancelled, got %d restarts", got)
+	}
+	if _, err := manager.GetProcessInfo("worker"); err == nil {
+		t.Error("Expected no PID file after StopAll")
+	}
+}
+
+func TestRestartPolicyDelay(t *testing.T) {
+	policy := RestartPolicy{Mode: RestartAlways, Backoff: time.Second}
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{20, maxRestartBackoff},
+	}
+	for _, tt := range tests {
+		if got := policy.delay(tt.n); got != tt.want {
+			t.Errorf("delay(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+
+	if policy.shouldRestart(nil) != true || (RestartPolicy{Mode: RestartOnFailure}).shouldRestart(nil) {
+		t.Error("Unexpected restart decision for a clean exit")
+	}
+	if !(RestartPolicy{Mode: RestartOnFailure}).shouldRestart(errors.New("exit status 1")) {
+		t.Error("Expected on-failure to restart a failed process")
+	}
+}
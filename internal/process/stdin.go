@@ -0,0 +1,44 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SetStdin sets whether the processes of the named process this Manager
+// starts from then on read their input from a pipe that OpenStdin writes
+// to, as for asc attach --stdin. Other processes read no input.
+func (m *Manager) SetStdin(name string, enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stdin == nil {
+		m.stdin = make(map[string]bool)
+	}
+	m.stdin[name] = enabled
+}
+
+// stdinFor reports whether the named process reads its input from a pipe
+func (m *Manager) stdinFor(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stdin[name]
+}
+
+// stdinPath returns where the input pipe of the named process is created
+func (m *Manager) stdinPath(name string) string {
+	return filepath.Join(m.pidDir, fmt.Sprintf("%s.stdin", name))
+}
+
+// OpenStdin opens the input of a running process that was started to read
+// its input from a pipe. What is written to it is read by the process.
+func OpenStdin(info *ProcessInfo) (*os.File, error) {
+	if info.StdinPipe == "" {
+		return nil, fmt.Errorf("process %s does not read input from asc", info.Name)
+	}
+	file, err := openStdinWriter(info.StdinPipe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the input of process %s: %w", info.Name, err)
+	}
+	return file, nil
+}
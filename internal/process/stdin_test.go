@@ -0,0 +1,41 @@
+//go:build !windows
+
+package process
+
+import (
+	"testing"
+)
+
+func TestOpenStdin(t *testing.T) {
+	manager := newRestartTestManager(t)
+	manager.SetStdin("interactive", true)
+
+	if _, err := manager.Start("interactive", "sh", []string{"-c", `read line; echo "got $line"`}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	info, err := manager.GetProcessInfo("interactive")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	stdin, err := OpenStdin(info)
+	if err != nil {
+		t.Fatalf("OpenStdin failed: %v", err)
+	}
+	if _, err := stdin.WriteString("hello\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	stdin.Close()
+	waitForLog(t, info.LogFile, "got hello")
+
+	// Processes not started to read input cannot be sent any
+	if _, err := manager.Start("quiet", "sleep", []string{"10"}, nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	info, err = manager.GetProcessInfo("quiet")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	if _, err := OpenStdin(info); err == nil {
+		t.Error("Expected OpenStdin to fail for a process without an input pipe")
+	}
+}
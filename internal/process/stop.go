@@ -0,0 +1,127 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// DefaultStopGracePeriod is how long Stop waits for a process to exit
+// after signalling it, unless its StopPolicy says otherwise
+const DefaultStopGracePeriod = 5 * time.Second
+
+// StopPolicy says how Stop shuts a process down: it runs PreStop, sends
+// Signal, and kills the process if it has not exited after GracePeriod.
+// It is saved with the process, so asc down uses the policy the process
+// was started with.
+type StopPolicy struct {
+	Signal      string        `json:"signal,omitempty"`       // Signal asking the process to exit, e.g. "SIGINT" (default: SIGTERM); unused on Windows
+	GracePeriod time.Duration `json:"grace_period,omitempty"` // How long the process may take to exit (default: DefaultStopGracePeriod)
+	PreStop     string        `json:"pre_stop,omitempty"`     // Shell command run before the signal, for at most GracePeriod
+}
+
+// IsZero reports whether the policy is the default one
+func (p StopPolicy) IsZero() bool {
+	return p == StopPolicy{}
+}
+
+// gracePeriod returns the grace period, or the default
+func (p StopPolicy) gracePeriod() time.Duration {
+	if p.GracePeriod > 0 {
+		return p.GracePeriod
+	}
+	return DefaultStopGracePeriod
+}
+
+// signalName returns the name of the signal, or SIGTERM
+func (p StopPolicy) signalName() string {
+	if p.Signal == "" {
+		return "SIGTERM"
+	}
+	return strings.ToUpper(p.Signal)
+}
+
+// SetStopPolicy sets the stop policy of the named process, applied to the
+// processes of that name this Manager starts from then on
+func (m *Manager) SetStopPolicy(name string, policy StopPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopPolicies == nil {
+		m.stopPolicies = make(map[string]StopPolicy)
+	}
+	m.stopPolicies[name] = policy
+}
+
+// stopPolicyFor returns the stop policy of the named process
+func (m *Manager) stopPolicyFor(name string) StopPolicy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stopPolicies[name]
+}
+
+// stopPolicyOf returns the name of the managed process with the given PID
+// and the stop policy it was started with. A process without a PID file
+// has no name and the default policy.
+func (m *Manager) stopPolicyOf(pid int) (string, StopPolicy) {
+	processes, err := m.ListProcesses()
+	if err != nil {
+		return "", StopPolicy{}
+	}
+	for _, info := range processes {
+		if info.PID != pid {
+			continue
+		}
+		if info.Stop != nil {
+			return info.Name, *info.Stop
+		}
+		return info.Name, StopPolicy{}
+	}
+	return "", StopPolicy{}
+}
+
+// stopPollInterval is how often waitExit checks whether a process it
+// cannot wait for has exited
+const stopPollInterval = 50 * time.Millisecond
+
+// waitExit waits for a process to exit. A process started by another asc,
+// as when asc down stops the stack asc up started, is not a child that
+// can be waited for, so it is polled until it is gone, or a zombie its
+// parent has yet to reap.
+func waitExit(p *os.Process) error {
+	_, err := p.Wait()
+	if !errors.Is(err, syscall.ECHILD) {
+		return err
+	}
+	for Alive(p.Pid) {
+		if _, err := readIdentity(p.Pid); errors.Is(err, errZombie) {
+			return nil
+		}
+		time.Sleep(stopPollInterval)
+	}
+	return nil
+}
+
+// runPreStop runs the pre-stop hook of a process, with AGENT_NAME and
+// AGENT_PID set, for at most its grace period. A hook that fails is
+// logged and the process is stopped anyway.
+func runPreStop(ctx context.Context, name string, pid int, policy StopPolicy) {
+	ctx, cancel := context.WithTimeout(ctx, policy.gracePeriod())
+	defer cancel()
+
+	fields := logger.Fields{"name": name, "pid": pid}
+	processLog.WithFields(fields).Debug("Running pre_stop hook")
+	cmd := shellCommand(ctx, policy.PreStop)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("AGENT_NAME=%s", name),
+		fmt.Sprintf("AGENT_PID=%d", pid),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		processLog.WithFields(fields).Warn("pre_stop hook failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+}
@@ -0,0 +1,115 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForLog waits until the log of a process contains want
+func waitForLog(t *testing.T, path, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, _ := os.ReadFile(path); strings.Contains(string(data), want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to contain %q", path, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStop_Signal(t *testing.T) {
+	manager := newRestartTestManager(t)
+	manager.SetStopPolicy("agent", StopPolicy{Signal: "sigint"})
+
+	pid, err := manager.Start("agent", "sh", []string{"-c", "trap 'echo got INT; exit 0' INT; echo ready; while true; do sleep 0.05; done"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	info, err := manager.GetProcessInfo("agent")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	if info.Stop == nil || info.Stop.Signal != "sigint" {
+		t.Errorf("Expected the stop policy to be recorded, got %+v", info.Stop)
+	}
+	waitForLog(t, info.LogFile, "ready")
+
+	if err := manager.Stop(context.Background(), pid); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	waitForLog(t, info.LogFile, "got INT")
+}
+
+func TestStop_GracePeriod(t *testing.T) {
+	manager := newRestartTestManager(t)
+	manager.SetStopPolicy("stubborn", StopPolicy{GracePeriod: 200 * time.Millisecond})
+
+	pid, err := manager.Start("stubborn", "sh", []string{"-c", "trap '' TERM; echo ready; while true; do sleep 0.05; done"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	info, err := manager.GetProcessInfo("stubborn")
+	if err != nil {
+		t.Fatalf("GetProcessInfo failed: %v", err)
+	}
+	waitForLog(t, info.LogFile, "ready")
+
+	started := time.Now()
+	if err := manager.Stop(context.Background(), pid); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	elapsed := time.Since(started)
+	if elapsed < 200*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("Expected the process to be killed after its grace period, took %v", elapsed)
+	}
+	if manager.IsRunning(pid) {
+		t.Error("Expected the process to be killed")
+	}
+}
+
+func TestStop_PreStop(t *testing.T) {
+	manager := newRestartTestManager(t)
+	marker := filepath.Join(t.TempDir(), "pre-stop")
+	manager.SetStopPolicy("leasing", StopPolicy{PreStop: fmt.Sprintf(`echo "$AGENT_NAME $AGENT_PID" > %s`, marker)})
+
+	pid, err := manager.Start("leasing", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := manager.Stop(context.Background(), pid); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	data, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("Expected the pre_stop hook to run: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(data)), fmt.Sprintf("leasing %d", pid); got != want {
+		t.Errorf("pre_stop hook saw %q, want %q", got, want)
+	}
+
+	// A cancelled stop skips the hook and the grace period
+	os.Remove(marker)
+	pid, err = manager.Start("leasing", "sleep", []string{"10"}, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := manager.Stop(ctx, pid); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("Expected the pre_stop hook not to run once the stop was cancelled")
+	}
+}
@@ -0,0 +1,149 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// diffOp is one line of an edit script
+type diffOp struct {
+	kind byte // ' ', '-', or '+'
+	line string
+}
+
+// Diff returns a unified diff of two prompt versions labelled from and to,
+// or "" if they are identical
+func Diff(from, to, a, b string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", from, to)
+
+	// Walk the edit script, emitting hunks of changes with context
+	aLine, bLine := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		// Extend the hunk while changes are within 2*context lines of each other
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*diffContext {
+				break
+			}
+		}
+		end += diffContext
+		if end >= len(ops) {
+			end = len(ops) - 1
+		}
+
+		// Line numbers at the start of the hunk
+		hunkA, hunkB := aLine, bLine
+		for k := start; k < i; k++ {
+			hunkA--
+			hunkB--
+		}
+		countA, countB := 0, 0
+		for k := start; k <= end; k++ {
+			if ops[k].kind != '+' {
+				countA++
+			}
+			if ops[k].kind != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", hunkA, countA, hunkB, countB)
+		for k := start; k <= end; k++ {
+			out.WriteByte(ops[k].kind)
+			out.WriteString(ops[k].line)
+			out.WriteByte('\n')
+		}
+
+		for k := i; k <= end; k++ {
+			if ops[k].kind != '+' {
+				aLine++
+			}
+			if ops[k].kind != '-' {
+				bLine++
+			}
+		}
+		i = end + 1
+	}
+	return out.String()
+}
+
+// diffLines computes a line edit script from a to b using the longest
+// common subsequence. Prompts are small, so the quadratic table is fine.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// splitLines splits content into lines without a trailing empty line
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -0,0 +1,173 @@
+package prompts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoreVersions(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	if _, _, err := store.Get("coder", 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() of a missing prompt error = %v, want ErrNotFound", err)
+	}
+
+	v1, err := store.Add("coder", []byte("You are {{.AgentName}}.\n"), "Initial prompt")
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if v1.Number != 1 || v1.Author == "" || v1.Checksum == "" {
+		t.Errorf("Unexpected first version: %+v", v1)
+	}
+
+	if _, err := store.Add("coder", []byte("You are {{.AgentName}}.\n"), "No-op"); !errors.Is(err, ErrUnchanged) {
+		t.Errorf("Add() of identical content error = %v, want ErrUnchanged", err)
+	}
+
+	if _, err := store.Add("coder", []byte("You are {{.AgentName}}. Write tests.\n"), "Ask for tests"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	content, version, err := store.Get("coder", 0)
+	if err != nil || version != 2 || !strings.Contains(content, "Write tests") {
+		t.Errorf("Get(current) = %q, %d, %v", content, version, err)
+	}
+	if content, _, _ := store.Get("coder", 1); strings.Contains(content, "Write tests") {
+		t.Errorf("Get(1) returned the wrong version: %q", content)
+	}
+	if _, _, err := store.Get("coder", 5); err == nil {
+		t.Error("Get() of a missing version should fail")
+	}
+
+	// Rollback appends a copy of the old version
+	rolled, err := store.Rollback("coder", 1)
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if rolled.Number != 3 || !strings.Contains(rolled.Message, "version 1") {
+		t.Errorf("Unexpected rollback version: %+v", rolled)
+	}
+
+	history, current, err := store.History("coder")
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(history) != 3 || current != 3 || history[2].Checksum != history[0].Checksum {
+		t.Errorf("Unexpected history: current=%d %+v", current, history)
+	}
+
+	names, err := store.List()
+	if err != nil || len(names) != 1 || names[0] != "coder" {
+		t.Errorf("List() = %v, %v", names, err)
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref         string
+		wantName    string
+		wantVersion int
+		wantErr     bool
+	}{
+		{"coder", "coder", 0, false},
+		{"coder@3", "coder", 3, false},
+		{"coder@v2", "coder", 2, false},
+		{"coder@0", "", 0, true},
+		{"coder@latest", "", 0, true},
+		{"../etc", "", 0, true},
+		{"", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			name, version, err := ParseRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRef(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if name != tt.wantName || version != tt.wantVersion {
+				t.Errorf("ParseRef(%q) = %q, %d, want %q, %d", tt.ref, name, version, tt.wantName, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := TemplateData{AgentName: "planner", Model: "claude", Phases: []string{"planning", "design"}}
+
+	got, err := Render("{{.AgentName}} ({{upper .Model}}) handles {{join .Phases \", \"}}", data)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "planner (CLAUDE) handles planning, design"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if _, err := Render("{{.Unknown}}", data); err == nil {
+		t.Error("Render() with an unknown field should fail")
+	}
+	if _, err := Render("{{.AgentName", data); err == nil {
+		t.Error("Render() with invalid syntax should fail")
+	}
+}
+
+func TestAgentEnv(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	if _, err := store.Add("coder", []byte("v1 for {{.AgentName}}"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Add("coder", []byte("v2 for {{.AgentName}}"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := store.AgentEnv("coder@1", TemplateData{AgentName: "alice"})
+	if err != nil {
+		t.Fatalf("AgentEnv() error = %v", err)
+	}
+
+	path := filepath.Join(dir, ".rendered", "alice.md")
+	want := []string{PromptFileEnvVar + "=" + path, PromptRefEnvVar + "=coder@1"}
+	if len(env) != 2 || env[0] != want[0] || env[1] != want[1] {
+		t.Errorf("AgentEnv() = %v, want %v", env, want)
+	}
+	if content, err := os.ReadFile(path); err != nil || string(content) != "v1 for alice" {
+		t.Errorf("Rendered prompt = %q, %v", content, err)
+	}
+
+	if _, err := store.AgentEnv("missing", TemplateData{AgentName: "alice"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AgentEnv() of a missing prompt error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	b := "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+
+	got := Diff("coder@1", "coder@2", a, b)
+	want := `--- coder@1
++++ coder@2
+@@ -1,6 +1,6 @@
+ one
+ two
+-three
++THREE
+ four
+ five
+ six
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+`
+	if got != want {
+		t.Errorf("Diff() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Diff("a", "b", a, a); got != "" {
+		t.Errorf("Diff() of identical content = %q, want empty", got)
+	}
+}
@@ -0,0 +1,111 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Environment variables that tell an agent which prompt to use
+const (
+	PromptFileEnvVar = "AGENT_PROMPT_FILE" // Path of the rendered prompt
+	PromptRefEnvVar  = "AGENT_PROMPT"      // Prompt name and version, e.g. "coder@3"
+)
+
+// TemplateData is available to prompt templates, e.g. {{.AgentName}} or
+// {{join .Phases ", "}}
+type TemplateData struct {
+	AgentName   string
+	Model       string
+	Phases      []string
+	BeadsDBPath string
+	MCPURL      string
+}
+
+// templateFuncs are the helper functions available to prompt templates
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Validate checks that content parses as a prompt template
+func Validate(content string) error {
+	if _, err := template.New("prompt").Funcs(templateFuncs).Parse(content); err != nil {
+		return fmt.Errorf("invalid prompt template: %w", err)
+	}
+	return nil
+}
+
+// Render executes content as a text/template with data. Referencing an
+// unknown field is an error rather than rendering "<no value>".
+func Render(content string, data TemplateData) (string, error) {
+	tmpl, err := template.New("prompt").Funcs(templateFuncs).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template: %w", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return out.String(), nil
+}
+
+// RenderRef renders the prompt a reference ("name" or "name@version")
+// points to and returns it with the resolved version number
+func (s *Store) RenderRef(ref string, data TemplateData) (string, int, error) {
+	name, version, err := ParseRef(ref)
+	if err != nil {
+		return "", 0, err
+	}
+	content, version, err := s.Get(name, version)
+	if err != nil {
+		return "", 0, err
+	}
+	rendered, err := Render(content, data)
+	if err != nil {
+		return "", 0, fmt.Errorf("prompt '%s' version %d: %w", name, version, err)
+	}
+	return rendered, version, nil
+}
+
+// AgentEnv renders the prompt an agent references, writes it to
+// <store>/.rendered/<agent>.md, and returns the environment variables
+// that point the agent at it
+func (s *Store) AgentEnv(ref string, data TemplateData) ([]string, error) {
+	rendered, version, err := s.RenderRef(ref, data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(s.dir, ".rendered")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create rendered prompt directory: %w", err)
+	}
+	path := filepath.Join(dir, data.AgentName+".md")
+	if err := os.WriteFile(path, []byte(rendered), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write rendered prompt: %w", err)
+	}
+
+	name, _, _ := ParseRef(ref)
+	return []string{
+		fmt.Sprintf("%s=%s", PromptFileEnvVar, path),
+		fmt.Sprintf("%s=%s@%d", PromptRefEnvVar, name, version),
+	}, nil
+}
+
+// AgentEnv renders an agent's prompt from the default store (see
+// Store.AgentEnv). It returns no variables when ref is empty.
+func AgentEnv(ref string, data TemplateData) ([]string, error) {
+	if ref == "" {
+		return nil, nil
+	}
+	store, err := NewDefaultStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.AgentEnv(ref, data)
+}
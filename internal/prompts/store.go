@@ -0,0 +1,281 @@
+// Package prompts stores, versions, and renders the system prompts given
+// to agents. Each prompt has a linear history of immutable versions kept
+// under ~/.asc/prompts/<name>/; agents reference a prompt by name (the
+// current version) or pin one with name@version in asc.toml.
+//
+// Example usage:
+//
+//	store, err := prompts.NewDefaultStore()
+//	if err != nil {
+//	    return err
+//	}
+//	version, err := store.Add("coder", content, "Ask for tests with every change")
+package prompts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/statedir"
+)
+
+var (
+	// ErrNotFound is returned for prompts that have not been added
+	ErrNotFound = errors.New("prompt not found")
+
+	// ErrUnchanged is returned by Add when the content matches the current version
+	ErrUnchanged = errors.New("prompt content is unchanged")
+)
+
+// namePattern restricts prompt names to characters safe in file names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_\-]*$`)
+
+// Version describes one stored version of a prompt
+type Version struct {
+	Number    int       `json:"number"`
+	CreatedAt time.Time `json:"created_at"`
+	Author    string    `json:"author"`
+	Message   string    `json:"message,omitempty"`
+	Checksum  string    `json:"checksum"` // sha256 of the content
+}
+
+// index is the per-prompt metadata file
+type index struct {
+	Current  int       `json:"current"`
+	Versions []Version `json:"versions"`
+}
+
+// Store keeps versioned prompts in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns ~/.asc/prompts
+func DefaultDir() (string, error) {
+	return statedir.Path("prompts")
+}
+
+// NewDefaultStore creates a store rooted at ~/.asc/prompts
+func NewDefaultStore() (*Store, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(dir), nil
+}
+
+// ValidateName checks that name can be used as a prompt name
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid prompt name '%s'\n  Suggestion: Use letters, digits, '-' and '_' only", name)
+	}
+	return nil
+}
+
+// ParseRef splits a prompt reference of the form "name" or "name@version".
+// A version of 0 means the current version.
+func ParseRef(ref string) (string, int, error) {
+	name, versionStr, pinned := strings.Cut(strings.TrimSpace(ref), "@")
+	if err := ValidateName(name); err != nil {
+		return "", 0, err
+	}
+	if !pinned {
+		return name, 0, nil
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(versionStr, "v"))
+	if err != nil || version < 1 {
+		return "", 0, fmt.Errorf("invalid prompt version '%s' in '%s'\n  Suggestion: Use name@N with N >= 1, e.g. %s@2", versionStr, ref, name)
+	}
+	return name, version, nil
+}
+
+// List returns the names of all stored prompts, sorted
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt directory: %w", err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() || !namePattern.MatchString(entry.Name()) {
+			continue
+		}
+		if _, err := os.Stat(s.indexPath(entry.Name())); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Add stores content as a new version of the named prompt, creating the
+// prompt if it does not exist, and makes it current. Returns ErrUnchanged
+// if content is identical to the current version.
+func (s *Store) Add(name string, content []byte, message string) (Version, error) {
+	if err := ValidateName(name); err != nil {
+		return Version{}, err
+	}
+
+	idx, err := s.loadIndex(name)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return Version{}, err
+	}
+
+	checksum := checksumOf(content)
+	if current, ok := idx.version(idx.Current); ok && current.Checksum == checksum {
+		return Version{}, ErrUnchanged
+	}
+
+	version := Version{
+		Number:    len(idx.Versions) + 1,
+		CreatedAt: time.Now(),
+		Author:    currentUser(),
+		Message:   message,
+		Checksum:  checksum,
+	}
+
+	if err := os.MkdirAll(filepath.Join(s.dir, name), 0700); err != nil {
+		return Version{}, fmt.Errorf("failed to create prompt directory: %w", err)
+	}
+	if err := os.WriteFile(s.versionPath(name, version.Number), content, 0600); err != nil {
+		return Version{}, fmt.Errorf("failed to write prompt version: %w", err)
+	}
+
+	idx.Versions = append(idx.Versions, version)
+	idx.Current = version.Number
+	if err := s.saveIndex(name, idx); err != nil {
+		return Version{}, err
+	}
+	return version, nil
+}
+
+// History returns the versions of the named prompt, oldest first, and the
+// number of the current version
+func (s *Store) History(name string) ([]Version, int, error) {
+	idx, err := s.loadIndex(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return idx.Versions, idx.Current, nil
+}
+
+// Get returns the content of a version of the named prompt, or of the
+// current version if version is 0, along with the version number
+func (s *Store) Get(name string, version int) (string, int, error) {
+	idx, err := s.loadIndex(name)
+	if err != nil {
+		return "", 0, err
+	}
+	if version == 0 {
+		version = idx.Current
+	}
+	if _, ok := idx.version(version); !ok {
+		return "", 0, fmt.Errorf("prompt '%s' has no version %d (latest is %d)", name, version, len(idx.Versions))
+	}
+
+	content, err := os.ReadFile(s.versionPath(name, version))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read prompt '%s' version %d: %w", name, version, err)
+	}
+	return string(content), version, nil
+}
+
+// Rollback restores an earlier version of the named prompt by adding its
+// content as a new current version, so the history itself is never
+// rewritten
+func (s *Store) Rollback(name string, version int) (Version, error) {
+	content, _, err := s.Get(name, version)
+	if err != nil {
+		return Version{}, err
+	}
+	return s.Add(name, []byte(content), fmt.Sprintf("Rollback to version %d", version))
+}
+
+func (s *Store) indexPath(name string) string {
+	return filepath.Join(s.dir, name, "index.json")
+}
+
+func (s *Store) versionPath(name string, version int) string {
+	return filepath.Join(s.dir, name, fmt.Sprintf("v%d.md", version))
+}
+
+// loadIndex reads the metadata of the named prompt, returning an error
+// wrapping ErrNotFound if it does not exist
+func (s *Store) loadIndex(name string) (index, error) {
+	if err := ValidateName(name); err != nil {
+		return index{}, err
+	}
+	data, err := os.ReadFile(s.indexPath(name))
+	if os.IsNotExist(err) {
+		return index{}, fmt.Errorf("%w: '%s'\n  Suggestion: Add it with 'asc prompts add %s <file>'", ErrNotFound, name, name)
+	}
+	if err != nil {
+		return index{}, fmt.Errorf("failed to read prompt '%s': %w", name, err)
+	}
+
+	var idx index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return index{}, fmt.Errorf("corrupt index for prompt '%s': %v", name, err)
+	}
+	return idx, nil
+}
+
+func (s *Store) saveIndex(name string, idx index) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode prompt index: %w", err)
+	}
+	tmp := s.indexPath(name) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write prompt index: %w", err)
+	}
+	if err := os.Rename(tmp, s.indexPath(name)); err != nil {
+		return fmt.Errorf("failed to write prompt index: %w", err)
+	}
+	return nil
+}
+
+// version returns the metadata of a version number
+func (idx index) version(number int) (Version, bool) {
+	if number < 1 || number > len(idx.Versions) {
+		return Version{}, false
+	}
+	return idx.Versions[number-1], true
+}
+
+func checksumOf(content []byte) string {
+	sum := sha256.Sum256(bytes.TrimSpace(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// currentUser returns the name of the user running asc
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,159 @@
+// Package readiness waits for a started process to be ready for the
+// processes that depend on it: to accept connections on a port, answer a
+// URL, or write a line matching a pattern to its log.
+package readiness
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+)
+
+// DefaultTimeout is how long Wait waits when a Check has no Timeout
+const DefaultTimeout = 30 * time.Second
+
+// pollInterval is how often a check is tried. It is a variable so tests do
+// not wait.
+var pollInterval = 200 * time.Millisecond
+
+// attemptTimeout bounds a single connection or request
+const attemptTimeout = 2 * time.Second
+
+// ErrExited is returned by Wait when the process exits before it is ready
+var ErrExited = errors.New("exited before it was ready")
+
+// Check is how to tell a process is ready. Exactly one of Address, URL,
+// and LogPattern is set.
+type Check struct {
+	Address string // TCP "host:port" that accepts connections once ready
+	URL     string // HTTP URL that answers with a status below 500 once ready
+
+	LogPattern *regexp.Regexp // Matched against the lines of LogFile
+	LogFile    string         // Log the process writes its output to
+	LogOffset  int64          // Where the process's output begins in LogFile
+
+	Timeout time.Duration // Limit for becoming ready (default: DefaultTimeout)
+}
+
+// String describes the check for messages, e.g. "port localhost:8765"
+func (c Check) String() string {
+	switch {
+	case c.Address != "":
+		return "port " + c.Address
+	case c.URL != "":
+		return "URL " + c.URL
+	case c.LogPattern != nil:
+		return fmt.Sprintf("log line matching %q", c.LogPattern.String())
+	}
+	return "no check"
+}
+
+// Wait tries check until it passes, alive reports that the process exited,
+// ctx is done, or check.Timeout passes. alive may be nil.
+func Wait(ctx context.Context, check Check, alive func() bool) error {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		err := check.try(ctx)
+		if err == nil {
+			return nil
+		}
+		if alive != nil && !alive() {
+			return ErrExited
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s not ready after %s: %w", check, timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// try tries the check once
+func (c Check) try(ctx context.Context) error {
+	switch {
+	case c.Address != "":
+		return dial(ctx, c.Address)
+	case c.URL != "":
+		return get(ctx, c.URL)
+	case c.LogPattern != nil:
+		return matchLog(c.LogFile, c.LogOffset, c.LogPattern)
+	}
+	return nil
+}
+
+// dial connects to address and closes the connection
+func dial(ctx context.Context, address string) error {
+	dialer := net.Dialer{Timeout: attemptTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// client requests readiness URLs directly; they are local to the stack
+var client = &http.Client{
+	Timeout:   attemptTimeout,
+	Transport: &http.Transport{Proxy: nil},
+}
+
+// get requests url and checks the server answered without a server error
+func get(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+// matchLog looks for a line matching pattern in the log at path from
+// offset on. A log emptied by rotation since is read from the start.
+func matchLog(path string, offset int64, pattern *regexp.Regexp) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil && info.Size() < offset {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if pattern.Match(scanner.Bytes()) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("no matching log line yet")
+}
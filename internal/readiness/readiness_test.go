@@ -0,0 +1,130 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fastPoll(t *testing.T) {
+	t.Helper()
+	old := pollInterval
+	pollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { pollInterval = old })
+}
+
+func TestWait_Port(t *testing.T) {
+	fastPoll(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+
+	if err := Wait(context.Background(), Check{Address: address, Timeout: time.Second}, nil); err != nil {
+		t.Errorf("Wait() on a listening port error = %v", err)
+	}
+
+	listener.Close()
+	err = Wait(context.Background(), Check{Address: address, Timeout: 50 * time.Millisecond}, nil)
+	if err == nil || !strings.Contains(err.Error(), "not ready after") {
+		t.Errorf("Wait() on a closed port error = %v", err)
+	}
+}
+
+func TestWait_URL(t *testing.T) {
+	fastPoll(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Unavailable for the first two requests, as while starting up
+		requests++
+		if requests <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if err := Wait(context.Background(), Check{URL: server.URL, Timeout: time.Second}, nil); err != nil {
+		t.Errorf("Wait() error = %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+}
+
+func TestWait_LogPattern(t *testing.T) {
+	fastPoll(t)
+	path := filepath.Join(t.TempDir(), "agent.log")
+	old := "Listening on :8080\n"
+	if err := os.WriteFile(path, []byte(old), 0600); err != nil {
+		t.Fatal(err)
+	}
+	check := Check{
+		LogPattern: regexp.MustCompile(`Listening on`),
+		LogFile:    path,
+		LogOffset:  int64(len(old)),
+		Timeout:    time.Second,
+	}
+
+	// Output of an earlier run does not count
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		file.WriteString("starting\nListening on :8080\n")
+	}()
+	started := time.Now()
+	if err := Wait(context.Background(), check, nil); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if time.Since(started) < 30*time.Millisecond {
+		t.Error("Expected Wait() to ignore the log before LogOffset")
+	}
+
+	// A log emptied by rotation is read from the start
+	if err := os.WriteFile(path, []byte("Listening on :8080\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	check.LogOffset = 1024
+	if err := Wait(context.Background(), check, nil); err != nil {
+		t.Errorf("Wait() after rotation error = %v", err)
+	}
+}
+
+func TestWait_Exited(t *testing.T) {
+	fastPoll(t)
+	check := Check{Address: "127.0.0.1:1", Timeout: time.Second}
+	err := Wait(context.Background(), check, func() bool { return false })
+	if !errors.Is(err, ErrExited) {
+		t.Errorf("Wait() error = %v, want ErrExited", err)
+	}
+}
+
+func TestCheckString(t *testing.T) {
+	tests := []struct {
+		check Check
+		want  string
+	}{
+		{Check{Address: "localhost:8765"}, "port localhost:8765"},
+		{Check{URL: "http://localhost:8765/health"}, "URL http://localhost:8765/health"},
+		{Check{LogPattern: regexp.MustCompile(`ready`)}, `log line matching "ready"`},
+	}
+	for _, tt := range tests {
+		if got := tt.check.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
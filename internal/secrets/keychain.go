@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/rand/asc/internal/fsperm"
+	"github.com/zalando/go-keyring"
+)
+
+// KeychainService is the service name age identities are stored under in
+// the OS keychain: the macOS Keychain, the Secret Service on Linux, or the
+// Windows Credential Manager
+const KeychainService = "asc"
+
+// keychainPrefix marks the line of a key file naming the keychain account
+// that holds its identity
+const keychainPrefix = "# keychain: "
+
+// GenerateKeyInKeychain generates a new age key and stores its identity in
+// the OS keychain instead of the key file. The key file still records the
+// public key and the keychain account, so the other methods find the
+// identity without being told where it is.
+func (m *Manager) GenerateKeyInKeychain() error {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return fmt.Errorf("failed to generate age key: %w", err)
+	}
+	return m.storeInKeychain(identity.String(), identity.Recipient().String(), m.keyPath)
+}
+
+// InKeychain reports whether the identity of the key file is stored in
+// the OS keychain
+func (m *Manager) InKeychain() bool {
+	account, err := m.keychainAccount()
+	return err == nil && account != ""
+}
+
+// storeInKeychain stores identity in the keychain under account and
+// points the key file at it
+func (m *Manager) storeInKeychain(identity, pubKey, account string) error {
+	if err := keyring.Set(KeychainService, account, identity); err != nil {
+		return fmt.Errorf("failed to store age key in the OS keychain: %w\n  Suggestion: Unlock the keychain, or run 'asc secrets init' without --keychain to keep the key in a file", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.keyPath), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	contents := fmt.Sprintf("# created: %s\n# public key: %s\n%s%s\n",
+		time.Now().Format(time.RFC3339), pubKey, keychainPrefix, account)
+	if err := os.WriteFile(m.keyPath, []byte(contents), 0600); err != nil {
+		return fmt.Errorf("failed to write age key: %w", err)
+	}
+	if err := fsperm.MakePrivate(m.keyPath); err != nil {
+		return fmt.Errorf("failed to set key permissions: %w", err)
+	}
+	return nil
+}
+
+// keychainAccount returns the keychain account named by the key file, or
+// "" if the identity is in the file itself
+func (m *Manager) keychainAccount() (string, error) {
+	file, err := os.Open(m.keyPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, keychainPrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, keychainPrefix)), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// keychainIdentity reads the identity stored under account
+func keychainIdentity(account string) (string, error) {
+	identity, err := keyring.Get(KeychainService, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("age key %s not found in the OS keychain\n  Suggestion: Run 'asc secrets init --keychain' to generate a new key", account)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read age key from the OS keychain: %w", err)
+	}
+	return identity, nil
+}
+
+// backUpKeychainKey copies the keychain identity to oldKeyPath, whose key
+// file points at a keychain account of its own, before a rotation
+// replaces it
+func (m *Manager) backUpKeychainKey(oldKeyPath string) error {
+	account, err := m.keychainAccount()
+	if err != nil {
+		return err
+	}
+	identity, err := keychainIdentity(account)
+	if err != nil {
+		return err
+	}
+	pubKey, err := m.GetPublicKey()
+	if err != nil {
+		return err
+	}
+	return NewManagerWithKeyPath(oldKeyPath).storeInKeychain(identity, pubKey, oldKeyPath)
+}
@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestGenerateKeyInKeychain(t *testing.T) {
+	keyring.MockInit()
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "age.key")
+	manager := NewManagerWithKeyPath(keyPath)
+
+	if err := manager.GenerateKeyInKeychain(); err != nil {
+		t.Fatalf("GenerateKeyInKeychain() error = %v", err)
+	}
+	if !manager.KeyExists() || !manager.InKeychain() {
+		t.Fatal("Expected the key file to point at the keychain")
+	}
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "AGE-SECRET-KEY-") {
+		t.Error("Expected the private key to stay out of the key file")
+	}
+	if identity, err := keyring.Get(KeychainService, keyPath); err != nil || !strings.HasPrefix(identity, "AGE-SECRET-KEY-") {
+		t.Errorf("Expected the identity in the keychain, got %q, %v", identity, err)
+	}
+
+	// The keychain key encrypts and decrypts like a file key
+	inputPath := filepath.Join(tmpDir, ".env")
+	os.WriteFile(inputPath, []byte("CLAUDE_API_KEY=secret\n"), 0600)
+	if err := manager.Encrypt(inputPath, inputPath+".age"); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	plaintext, err := manager.DecryptToMemory(inputPath + ".age")
+	if err != nil || string(plaintext) != "CLAUDE_API_KEY=secret\n" {
+		t.Errorf("DecryptToMemory() = %q, %v", plaintext, err)
+	}
+
+	// Rotation keeps the new and the old key in the keychain
+	if err := manager.RotateKey([]string{inputPath + ".age"}); err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+	if !manager.InKeychain() || !NewManagerWithKeyPath(keyPath+".old").InKeychain() {
+		t.Error("Expected both keys to be kept in the keychain")
+	}
+	if plaintext, err := manager.DecryptToMemory(inputPath + ".age"); err != nil || string(plaintext) != "CLAUDE_API_KEY=secret\n" {
+		t.Errorf("DecryptToMemory() after rotation = %q, %v", plaintext, err)
+	}
+
+	// A keychain entry that was removed is reported
+	keyring.Delete(KeychainService, keyPath)
+	if _, err := manager.DecryptToMemory(inputPath + ".age"); err == nil || !strings.Contains(err.Error(), "not found in the OS keychain") {
+		t.Errorf("Expected a missing keychain entry to be reported, got %v", err)
+	}
+}
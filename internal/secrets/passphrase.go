@@ -0,0 +1,186 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/charmbracelet/x/term"
+	"github.com/rand/asc/internal/fsperm"
+)
+
+// PassphraseEnvVar holds the passphrase of a protected key file, for
+// scripts and CI runners where asc cannot prompt for it
+const PassphraseEnvVar = "ASC_KEY_PASSPHRASE"
+
+// ErrNoPassphrase is returned when the key file is protected by a
+// passphrase that can neither be read from PassphraseEnvVar nor asked for
+var ErrNoPassphrase = errors.New("age key is protected by a passphrase, but there is no terminal to ask for it")
+
+// scryptWorkFactor is the log2 of the scrypt work factor protecting key
+// files, the default of the age command line tool. Tests lower it.
+var scryptWorkFactor = 18
+
+// readPassphrase asks for a passphrase on the terminal without echoing
+// it. A variable so tests can answer the prompt.
+var readPassphrase = func(prompt string) (string, error) {
+	fd := os.Stdin.Fd()
+	if !term.IsTerminal(fd) {
+		return "", ErrNoPassphrase
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
+
+// NewPassphrase returns the passphrase to protect a new key file with:
+// PassphraseEnvVar if it is set, or one typed twice on the terminal
+func NewPassphrase() (string, error) {
+	if passphrase := os.Getenv(PassphraseEnvVar); passphrase != "" {
+		return passphrase, nil
+	}
+	passphrase, err := readPassphrase("Enter passphrase for the age key: ")
+	if err != nil {
+		return "", passphraseError(err)
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+	confirm, err := readPassphrase("Confirm passphrase: ")
+	if err != nil {
+		return "", passphraseError(err)
+	}
+	if confirm != passphrase {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// GenerateProtectedKey generates a new age key and writes it to the key
+// file encrypted with passphrase, as 'age-keygen | age -p -a' would, so
+// the age command line tool can still use it
+func (m *Manager) GenerateProtectedKey(passphrase string) error {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return fmt.Errorf("failed to generate age key: %w", err)
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to protect age key: %w", err)
+	}
+	recipient.SetWorkFactor(scryptWorkFactor)
+
+	if err := os.MkdirAll(filepath.Dir(m.keyPath), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	contents := fmt.Sprintf("# created: %s\n# public key: %s\n%s\n",
+		time.Now().Format(time.RFC3339), identity.Recipient(), identity)
+	err = writeFile(m.keyPath, func(out io.Writer) error {
+		a := armor.NewWriter(out)
+		w, err := age.Encrypt(a, recipient)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, contents); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		return a.Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write age key: %w", err)
+	}
+	if err := fsperm.MakePrivate(m.keyPath); err != nil {
+		return fmt.Errorf("failed to set key permissions: %w", err)
+	}
+
+	m.passphrase = passphrase
+	return nil
+}
+
+// IsProtected reports whether the key file is encrypted with a passphrase
+func (m *Manager) IsProtected() bool {
+	file, err := os.Open(m.keyPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	// age-encrypted identity files are recognized by their header, as the
+	// age command line tool does
+	header, _ := bufio.NewReader(file).Peek(len(armor.Header))
+	return bytes.HasPrefix(header, []byte("age-encryption.org/")) || string(header) == armor.Header
+}
+
+// unlock decrypts a protected key file, asking for its passphrase once
+// per Manager if PassphraseEnvVar is not set
+func (m *Manager) unlock() ([]byte, error) {
+	passphrase, err := m.keyPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(m.keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key file: %w", err)
+	}
+	defer file.Close()
+
+	in := bufio.NewReader(file)
+	var encrypted io.Reader = in
+	if header, _ := in.Peek(len(armor.Header)); string(header) == armor.Header {
+		encrypted = armor.NewReader(in)
+	}
+	r, err := age.Decrypt(encrypted, identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock age key %s: %w\n  Suggestion: Check the passphrase, or the value of %s", m.keyPath, err, PassphraseEnvVar)
+	}
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock age key %s: %w", m.keyPath, err)
+	}
+
+	m.passphrase = passphrase
+	return contents, nil
+}
+
+// keyPassphrase returns the passphrase of the key file
+func (m *Manager) keyPassphrase() (string, error) {
+	if m.passphrase != "" {
+		return m.passphrase, nil
+	}
+	if passphrase := os.Getenv(PassphraseEnvVar); passphrase != "" {
+		return passphrase, nil
+	}
+	passphrase, err := readPassphrase(fmt.Sprintf("Enter passphrase for %s: ", m.keyPath))
+	if err != nil {
+		return "", passphraseError(err)
+	}
+	return passphrase, nil
+}
+
+// passphraseError adds a suggestion to the error of a passphrase prompt
+// that could not be shown
+func passphraseError(err error) error {
+	if errors.Is(err, ErrNoPassphrase) {
+		return fmt.Errorf("%w\n  Suggestion: Set %s, or run the command in a terminal", err, PassphraseEnvVar)
+	}
+	return err
+}
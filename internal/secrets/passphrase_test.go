@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age/armor"
+)
+
+func init() {
+	// Keep the key derivation fast; the work factor is not under test
+	scryptWorkFactor = 10
+}
+
+// answerPassphrase answers passphrase prompts with answer, counting them
+func answerPassphrase(t *testing.T, answer string) *int {
+	t.Helper()
+	prompts := 0
+	orig := readPassphrase
+	readPassphrase = func(prompt string) (string, error) {
+		prompts++
+		return answer, nil
+	}
+	t.Cleanup(func() { readPassphrase = orig })
+	return &prompts
+}
+
+func TestProtectedKey(t *testing.T) {
+	t.Setenv(PassphraseEnvVar, "")
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "age.key")
+	if err := NewManagerWithKeyPath(keyPath).GenerateProtectedKey("correct horse"); err != nil {
+		t.Fatalf("GenerateProtectedKey() error = %v", err)
+	}
+
+	contents, _ := os.ReadFile(keyPath)
+	if !strings.HasPrefix(string(contents), armor.Header) || strings.Contains(string(contents), "AGE-SECRET-KEY-") {
+		t.Fatalf("Expected an armored, encrypted key file, got %q", contents)
+	}
+
+	manager := NewManagerWithKeyPath(keyPath)
+	if !manager.IsProtected() {
+		t.Fatal("Expected the key to be reported as protected")
+	}
+
+	prompts := answerPassphrase(t, "correct horse")
+	envFile := filepath.Join(tmpDir, ".env")
+	os.WriteFile(envFile, []byte("CLAUDE_API_KEY=test\n"), 0600)
+	if err := manager.Encrypt(envFile, envFile+".age"); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	decrypted, err := manager.DecryptToMemory(envFile + ".age")
+	if err != nil || string(decrypted) != "CLAUDE_API_KEY=test\n" {
+		t.Fatalf("DecryptToMemory() = %q, %v", decrypted, err)
+	}
+	if *prompts != 1 {
+		t.Errorf("Expected one passphrase prompt per manager, got %d", *prompts)
+	}
+
+	answerPassphrase(t, "wrong")
+	if _, err := NewManagerWithKeyPath(keyPath).DecryptToMemory(envFile + ".age"); err == nil || !strings.Contains(err.Error(), PassphraseEnvVar) {
+		t.Errorf("Expected a wrong passphrase error, got %v", err)
+	}
+
+	// The environment variable takes the place of the prompt
+	prompts = answerPassphrase(t, "wrong")
+	t.Setenv(PassphraseEnvVar, "correct horse")
+	if _, err := NewManagerWithKeyPath(keyPath).DecryptToMemory(envFile + ".age"); err != nil || *prompts != 0 {
+		t.Errorf("DecryptToMemory() error = %v after %d prompts", err, *prompts)
+	}
+}
+
+func TestProtectedKeyWithoutTerminal(t *testing.T) {
+	t.Setenv(PassphraseEnvVar, "")
+	keyPath := filepath.Join(t.TempDir(), "age.key")
+	if err := NewManagerWithKeyPath(keyPath).GenerateProtectedKey("correct horse"); err != nil {
+		t.Fatalf("GenerateProtectedKey() error = %v", err)
+	}
+
+	orig := readPassphrase
+	readPassphrase = func(prompt string) (string, error) { return "", ErrNoPassphrase }
+	defer func() { readPassphrase = orig }()
+
+	_, err := NewManagerWithKeyPath(keyPath).GetPublicKey()
+	if !errors.Is(err, ErrNoPassphrase) || !strings.Contains(err.Error(), "Suggestion: Set "+PassphraseEnvVar) {
+		t.Errorf("Expected a suggestion to set %s, got %v", PassphraseEnvVar, err)
+	}
+}
+
+func TestRotateProtectedKey(t *testing.T) {
+	t.Setenv(PassphraseEnvVar, "correct horse")
+	keyPath := filepath.Join(t.TempDir(), "age.key")
+	manager := NewManagerWithKeyPath(keyPath)
+	if err := manager.GenerateProtectedKey("correct horse"); err != nil {
+		t.Fatalf("GenerateProtectedKey() error = %v", err)
+	}
+	oldPubKey, _ := manager.GetPublicKey()
+
+	if err := manager.RotateKey(nil); err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+	newPubKey, err := NewManagerWithKeyPath(keyPath).GetPublicKey()
+	if err != nil || newPubKey == oldPubKey {
+		t.Errorf("GetPublicKey() = %s, %v after rotation", newPubKey, err)
+	}
+	if !manager.IsProtected() {
+		t.Error("Expected the new key to be protected by the same passphrase")
+	}
+}
+
+func TestNewPassphrase(t *testing.T) {
+	t.Setenv(PassphraseEnvVar, "")
+	answers := []string{"one", "two"}
+	orig := readPassphrase
+	readPassphrase = func(prompt string) (string, error) {
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}
+	defer func() { readPassphrase = orig }()
+
+	if _, err := NewPassphrase(); err == nil || !strings.Contains(err.Error(), "do not match") {
+		t.Errorf("Expected a mismatch error, got %v", err)
+	}
+
+	t.Setenv(PassphraseEnvVar, "from-env")
+	if passphrase, err := NewPassphrase(); err != nil || passphrase != "from-env" {
+		t.Errorf("NewPassphrase() = %q, %v", passphrase, err)
+	}
+}
@@ -0,0 +1,149 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Provider fetches secrets kept outside asc, such as in a cloud secret
+// manager. Secrets are referenced as <scheme>://<path>, optionally
+// followed by #<field> to pick one field of a secret holding a JSON
+// object.
+type Provider interface {
+	// Fetch returns the value of the secret at path
+	Fetch(ctx context.Context, path string) (string, error)
+}
+
+// Providers are the secret providers by reference scheme. The cloud
+// providers use the credentials of the aws and gcloud command line tools,
+// so CI runners resolve secrets with the identity they already have.
+var Providers = map[string]Provider{
+	"aws-sm": awsSecretsManager{},
+	"gcp-sm": gcpSecretManager{},
+}
+
+// runProviderCommand runs a provider's command line tool and returns its
+// standard output. A variable so tests can fake the tools.
+var runProviderCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// IsReference reports whether value refers to a secret of one of the
+// Providers
+func IsReference(value string) bool {
+	scheme, _, ok := strings.Cut(value, "://")
+	if !ok {
+		return false
+	}
+	_, known := Providers[scheme]
+	return known
+}
+
+// Resolve returns the secret value refers to. Values that are not
+// references are returned as they are.
+func Resolve(ctx context.Context, value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+	scheme, rest, _ := strings.Cut(value, "://")
+	path, field, hasField := strings.Cut(rest, "#")
+	if path == "" {
+		return "", fmt.Errorf("secret reference %s has no path", value)
+	}
+
+	secret, err := Providers[scheme].Fetch(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", value, err)
+	}
+	if !hasField {
+		return secret, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, so field %s cannot be read", scheme+"://"+path, field)
+	}
+	v, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no field %s", scheme+"://"+path, field)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// ResolveEnv resolves the references among env, keyed by variable name,
+// and returns the variables in the KEY=VALUE form of os.Environ, sorted
+// by name
+func ResolveEnv(ctx context.Context, env map[string]string) ([]string, error) {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := make([]string, 0, len(names))
+	for _, name := range names {
+		value, err := Resolve(ctx, env[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		vars = append(vars, name+"="+value)
+	}
+	return vars, nil
+}
+
+// awsSecretsManager reads secrets from AWS Secrets Manager, by name or
+// ARN, with the aws command line tool
+type awsSecretsManager struct{}
+
+func (awsSecretsManager) Fetch(ctx context.Context, path string) (string, error) {
+	out, err := runProviderCommand(ctx, "aws", "secretsmanager", "get-secret-value",
+		"--secret-id", path, "--query", "SecretString", "--output", "text")
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("%w\n  Suggestion: Install the AWS CLI (https://aws.amazon.com/cli/) and configure its credentials", err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// gcpSecretManager reads the latest version of secrets from Google Cloud
+// Secret Manager, referenced as project/name, with the gcloud command
+// line tool
+type gcpSecretManager struct{}
+
+func (gcpSecretManager) Fetch(ctx context.Context, path string) (string, error) {
+	project, name, ok := strings.Cut(path, "/")
+	if !ok || project == "" || name == "" {
+		return "", fmt.Errorf("expected gcp-sm://<project>/<secret>, got gcp-sm://%s", path)
+	}
+	out, err := runProviderCommand(ctx, "gcloud", "secrets", "versions", "access", "latest",
+		"--secret", name, "--project", project)
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("%w\n  Suggestion: Install the Google Cloud CLI (https://cloud.google.com/sdk/docs/install) and run 'gcloud auth login'", err)
+	}
+	if err != nil {
+		return "", err
+	}
+	// gcloud prints the payload as it is stored
+	return string(out), nil
+}
@@ -0,0 +1,108 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeProviderCommand replaces the provider tools with canned secrets,
+// keyed by the command line
+func fakeProviderCommand(t *testing.T, outputs map[string]string) *[]string {
+	t.Helper()
+	var calls []string
+	orig := runProviderCommand
+	runProviderCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		line := name + " " + strings.Join(args, " ")
+		calls = append(calls, line)
+		out, ok := outputs[line]
+		if !ok {
+			return nil, fmt.Errorf("exit status 254: secret not found")
+		}
+		return []byte(out), nil
+	}
+	t.Cleanup(func() { runProviderCommand = orig })
+	return &calls
+}
+
+func TestResolve(t *testing.T) {
+	calls := fakeProviderCommand(t, map[string]string{
+		"aws secretsmanager get-secret-value --secret-id prod/asc/claude --query SecretString --output text": "sk-ant-123\n",
+		"aws secretsmanager get-secret-value --secret-id prod/asc --query SecretString --output text":        `{"OPENAI_API_KEY": "sk-456", "port": 8080}` + "\n",
+		"gcloud secrets versions access latest --secret google-key --project my-project":                     "gcp-789",
+	})
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr string
+	}{
+		{"plain-value", "plain-value", ""},
+		{"https://example.com", "https://example.com", ""},
+		{"aws-sm://prod/asc/claude", "sk-ant-123", ""},
+		{"aws-sm://prod/asc#OPENAI_API_KEY", "sk-456", ""},
+		{"aws-sm://prod/asc#port", "8080", ""},
+		{"aws-sm://prod/asc#missing", "", "has no field missing"},
+		{"aws-sm://prod/asc/claude#field", "", "not a JSON object"},
+		{"aws-sm://unknown", "", "secret not found"},
+		{"aws-sm://", "", "has no path"},
+		{"gcp-sm://my-project/google-key", "gcp-789", ""},
+		{"gcp-sm://google-key", "", "expected gcp-sm://<project>/<secret>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Resolve(context.Background(), tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if len(*calls) == 0 {
+		t.Error("Expected the provider tools to be run")
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	fakeProviderCommand(t, map[string]string{
+		"gcloud secrets versions access latest --secret claude --project ci": "sk-ant-ci",
+	})
+
+	vars, err := ResolveEnv(context.Background(), map[string]string{
+		"CLAUDE_API_KEY": "gcp-sm://ci/claude",
+		"AGENT_LABEL":    "ci",
+	})
+	if err != nil {
+		t.Fatalf("ResolveEnv() error = %v", err)
+	}
+	if len(vars) != 2 || vars[0] != "AGENT_LABEL=ci" || vars[1] != "CLAUDE_API_KEY=sk-ant-ci" {
+		t.Errorf("ResolveEnv() = %v", vars)
+	}
+
+	_, err = ResolveEnv(context.Background(), map[string]string{"OPENAI_API_KEY": "gcp-sm://ci/missing"})
+	if err == nil || !strings.HasPrefix(err.Error(), "OPENAI_API_KEY: ") {
+		t.Errorf("Expected the error to name the variable, got %v", err)
+	}
+}
+
+func TestResolveMissingTool(t *testing.T) {
+	orig := runProviderCommand
+	runProviderCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return nil, &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	defer func() { runProviderCommand = orig }()
+
+	_, err := Resolve(context.Background(), "aws-sm://prod/asc/claude")
+	if !errors.Is(err, exec.ErrNotFound) || !strings.Contains(err.Error(), "Install the AWS CLI") {
+		t.Errorf("Expected a suggestion to install the AWS CLI, got %v", err)
+	}
+}
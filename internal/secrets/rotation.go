@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/statefile"
+)
+
+// HistoryFileName is the file, next to the key file, that records key
+// rotations
+const HistoryFileName = "secrets-history.json"
+
+// createdPrefix starts the line of a key file recording when the key was
+// generated, as age-keygen writes it
+const createdPrefix = "# created: "
+
+// Rotation records one rotation of the age key
+type Rotation struct {
+	RotatedAt    time.Time `json:"rotated_at"`
+	Automatic    bool      `json:"automatic"`      // Rotated by asc secrets rotate --auto
+	OldPublicKey string    `json:"old_public_key"` // Key the files were encrypted to before
+	NewPublicKey string    `json:"new_public_key"`
+	Files        []string  `json:"files"` // Files re-encrypted to the new key
+}
+
+// history is the contents of the history file
+type history struct {
+	Rotations []Rotation `json:"rotations"`
+}
+
+// KeyCreated returns when the age key was generated: the time recorded
+// in the key file, or the file's modification time for keys written
+// without one
+func (m *Manager) KeyCreated() (time.Time, error) {
+	file, err := os.Open(m.keyPath)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read age key: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, createdPrefix) {
+			if created, err := time.Parse(time.RFC3339, strings.TrimPrefix(line, createdPrefix)); err == nil {
+				return created, nil
+			}
+		}
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read age key: %w", err)
+	}
+	return info.ModTime(), nil
+}
+
+// RotationDue reports whether the age key is older than rotationDays and
+// returns its age. A policy of 0 days never makes rotation due.
+func (m *Manager) RotationDue(rotationDays int) (bool, time.Duration, error) {
+	created, err := m.KeyCreated()
+	if err != nil {
+		return false, 0, err
+	}
+	age := time.Since(created)
+	return rotationDays > 0 && age >= time.Duration(rotationDays)*24*time.Hour, age, nil
+}
+
+// RotationHistory returns the recorded key rotations, oldest first
+func (m *Manager) RotationHistory() ([]Rotation, error) {
+	var h history
+	err := statefile.ReadJSON(m.historyPath(), &h)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key rotation history: %w", err)
+	}
+	return h.Rotations, nil
+}
+
+// recordRotation appends r to the rotation history
+func (m *Manager) recordRotation(r Rotation) error {
+	rotations, err := m.RotationHistory()
+	if err != nil {
+		return err
+	}
+	h := history{Rotations: append(rotations, r)}
+	if err := statefile.WriteJSON(m.historyPath(), h, 0600); err != nil {
+		return fmt.Errorf("failed to record key rotation: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) historyPath() string {
+	return filepath.Join(filepath.Dir(m.keyPath), HistoryFileName)
+}
+
+// EncryptedFiles returns the age-encrypted files of the project in dir:
+// those git tracks, and any at the top of dir
+func EncryptedFiles(dir string) []string {
+	seen := make(map[string]bool)
+	tracked, _ := trackedFiles(dir)
+	for path := range tracked {
+		if strings.HasSuffix(path, ".age") {
+			seen[filepath.FromSlash(path)] = true
+		}
+	}
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".age") {
+				seen[entry.Name()] = true
+			}
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for path := range seen {
+		// A tracked file may have been deleted from the working tree
+		if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+			files = append(files, filepath.Join(dir, path))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
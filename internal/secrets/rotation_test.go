@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotationDue(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "age.key")
+	manager := NewManagerWithKeyPath(keyPath)
+	if err := manager.GenerateKey(); err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	due, age, err := manager.RotationDue(30)
+	if err != nil || due || age > time.Minute {
+		t.Errorf("RotationDue() = %v, %v, %v for a new key", due, age, err)
+	}
+
+	// Keys written without a creation time fall back to the file's
+	data, _ := os.ReadFile(keyPath)
+	lines := strings.SplitN(string(data), "\n", 2)
+	os.WriteFile(keyPath, []byte(lines[1]), 0600)
+	old := time.Now().Add(-31 * 24 * time.Hour)
+	os.Chtimes(keyPath, old, old)
+	if due, _, err := manager.RotationDue(30); err != nil || !due {
+		t.Errorf("RotationDue() = %v, %v for a 31-day-old key", due, err)
+	}
+	if due, _, _ := manager.RotationDue(0); due {
+		t.Error("Expected no rotation to be due without a policy")
+	}
+}
+
+func TestRotateKeyRecordsHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManagerWithKeyPath(filepath.Join(tmpDir, "age.key"))
+	if err := manager.GenerateKey(); err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	oldPubKey, _ := manager.GetPublicKey()
+
+	envFile := filepath.Join(tmpDir, ".env")
+	os.WriteFile(envFile, []byte("CLAUDE_API_KEY=test\n"), 0600)
+	if err := manager.Encrypt(envFile, envFile+".age"); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if err := manager.RotateKeyAutomatically([]string{envFile + ".age"}); err != nil {
+		t.Fatalf("RotateKeyAutomatically() error = %v", err)
+	}
+	if err := manager.RotateKey(nil); err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+
+	rotations, err := manager.RotationHistory()
+	if err != nil {
+		t.Fatalf("RotationHistory() error = %v", err)
+	}
+	if len(rotations) != 2 {
+		t.Fatalf("Expected 2 rotations, got %+v", rotations)
+	}
+	first, second := rotations[0], rotations[1]
+	if !first.Automatic || first.OldPublicKey != oldPubKey || len(first.Files) != 1 {
+		t.Errorf("Unexpected first rotation: %+v", first)
+	}
+	newPubKey, _ := manager.GetPublicKey()
+	if second.Automatic || second.OldPublicKey != first.NewPublicKey || second.NewPublicKey != newPubKey {
+		t.Errorf("Unexpected second rotation: %+v", second)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, HistoryFileName)); err != nil {
+		t.Errorf("Expected the history next to the key: %v", err)
+	}
+}
+
+func TestEncryptedFiles(t *testing.T) {
+	dir := gitRepo(t, map[string]string{
+		".env.age":              "encrypted",
+		".env.local.age":        "encrypted",
+		"deploy/.env.prod.age":  "encrypted",
+		"deploy/.env.stage.age": "encrypted",
+		"deploy/notes.txt":      "not encrypted",
+	}, "deploy/.env.prod.age", "deploy/notes.txt")
+
+	files := EncryptedFiles(dir)
+	want := []string{
+		filepath.Join(dir, ".env.age"),
+		filepath.Join(dir, ".env.local.age"),
+		filepath.Join(dir, "deploy", ".env.prod.age"),
+	}
+	if len(files) != len(want) {
+		t.Fatalf("EncryptedFiles() = %v, want %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("EncryptedFiles()[%d] = %s, want %s", i, files[i], want[i])
+		}
+	}
+}
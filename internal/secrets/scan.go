@@ -0,0 +1,239 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// Finding is a plaintext secret that could end up in, or already is in,
+// the project's git history
+type Finding struct {
+	Path   string // File, relative to the scanned directory
+	Line   int    // Line the secret is on, or 0 for a whole file
+	Reason string // What was found
+	// Ignore is the .gitignore entry that keeps the file out of git, or
+	// "" if ignoring it does not help, as for keys pasted into asc.toml
+	Ignore string
+	// Tracked reports whether git already tracks the file, in which case
+	// its history holds the secret
+	Tracked bool
+}
+
+// String describes the finding, e.g. "asc.toml:12: contains what looks
+// like an API key"
+func (f Finding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", f.Path, f.Line, f.Reason)
+	}
+	return fmt.Sprintf("%s: %s", f.Path, f.Reason)
+}
+
+// maxScanSize is the largest file Scan reads; larger files are not
+// configuration
+const maxScanSize = 1 << 20
+
+// gitCommand runs git in dir and returns its standard output. A variable
+// so tests can run without git.
+var gitCommand = func(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	return cmd.Output()
+}
+
+// Scan looks for plaintext secrets in the project in dir: .env files that
+// git tracks or does not ignore, and API keys in asc.toml and the other
+// files git tracks. Outside a git repository only asc.toml is scanned.
+// Encrypted files, examples, and Markdown are skipped.
+func Scan(dir string) ([]Finding, error) {
+	tracked, inRepo := trackedFiles(dir)
+
+	var findings []Finding
+	for _, path := range envFiles(dir, tracked) {
+		switch {
+		case tracked[path]:
+			findings = append(findings, Finding{
+				Path:    path,
+				Reason:  "holds plaintext secrets and is tracked by git",
+				Ignore:  "/" + path,
+				Tracked: true,
+			})
+		case inRepo && !isIgnored(dir, path):
+			findings = append(findings, Finding{
+				Path:   path,
+				Reason: "holds plaintext secrets and is not ignored by git",
+				Ignore: "/" + path,
+			})
+		}
+	}
+
+	files := []string{"asc.toml"}
+	for path := range tracked {
+		if path != "asc.toml" && !isEnvFile(path) && !skipScan(path) {
+			files = append(files, path)
+		}
+	}
+	for _, path := range files {
+		lines, err := secretLines(filepath.Join(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			findings = append(findings, Finding{
+				Path:    path,
+				Line:    line,
+				Reason:  "contains what looks like an API key",
+				Tracked: tracked[path],
+			})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
+
+// AddToGitignore appends the entries missing from the .gitignore file in
+// dir, creating it if needed
+func AddToGitignore(dir string, entries ...string) error {
+	path := filepath.Join(dir, ".gitignore")
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(existing), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+
+	var add strings.Builder
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		add.WriteString("\n")
+	}
+	for _, entry := range entries {
+		if !present[entry] {
+			present[entry] = true
+			add.WriteString(entry + "\n")
+		}
+	}
+	if add.Len() == 0 || add.String() == "\n" {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to update .gitignore: %w", err)
+	}
+	if _, err := file.WriteString(add.String()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to update .gitignore: %w", err)
+	}
+	return file.Close()
+}
+
+// trackedFiles returns the files git tracks in dir, relative to dir with
+// forward slashes, and whether dir is in a git repository at all
+func trackedFiles(dir string) (map[string]bool, bool) {
+	out, err := gitCommand(dir, "ls-files", "-z")
+	if err != nil {
+		return map[string]bool{}, false
+	}
+	tracked := make(map[string]bool)
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			tracked[path] = true
+		}
+	}
+	return tracked, true
+}
+
+// isIgnored reports whether git ignores path in dir
+func isIgnored(dir, path string) bool {
+	_, err := gitCommand(dir, "check-ignore", "-q", path)
+	return err == nil
+}
+
+// envFiles returns the .env files at the top of dir and those git tracks
+// anywhere in it
+func envFiles(dir string, tracked map[string]bool) []string {
+	seen := make(map[string]bool)
+	var files []string
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() && isEnvFile(entry.Name()) {
+				seen[entry.Name()] = true
+				files = append(files, entry.Name())
+			}
+		}
+	}
+	for path := range tracked {
+		if isEnvFile(path) && !seen[path] {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// isEnvFile reports whether path is a plaintext .env file: .env or
+// .env.<name>, but not an encrypted file or a template
+func isEnvFile(path string) bool {
+	name := filepath.Base(path)
+	if name != ".env" && !strings.HasPrefix(name, ".env.") {
+		return false
+	}
+	for _, suffix := range []string{".age", ".example", ".sample", ".template", ".dist"} {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// skipScan reports whether a tracked file is left out of the API key
+// scan: encrypted files, examples, and documentation hold no real keys
+func skipScan(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".age", ".md", ".example", ".sample", ".template":
+		return true
+	}
+	return false
+}
+
+// secretLines returns the numbers of the lines of a text file that match
+// a known API key format. Missing, large, and binary files have none.
+func secretLines(path string) ([]int, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) || (err == nil && (!info.Mode().IsRegular() || info.Size() > maxScanSize)) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", path, err)
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, nil
+	}
+
+	var lines []int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxScanSize)
+	for n := 1; scanner.Scan(); n++ {
+		if logger.ContainsSecretPattern(scanner.Text()) {
+			lines = append(lines, n)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeKey looks like an Anthropic API key; it is built at run time so
+// this file does not turn up in scans of the repository
+var fakeKey = "sk-ant-" + strings.Repeat("x1", 16)
+
+// gitRepo creates a git repository holding files, tracking those in track
+func gitRepo(t *testing.T, files map[string]string, track ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, append([]string{"add", "--"}, track...)} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestScan(t *testing.T) {
+	dir := gitRepo(t, map[string]string{
+		".gitignore":        "/.env.local\n",
+		".env":              "CLAUDE_API_KEY=" + fakeKey + "\n",
+		".env.local":        "OPENAI_API_KEY=" + fakeKey + "\n",
+		".env.production":   "GOOGLE_API_KEY=" + fakeKey + "\n",
+		".env.age":          "encrypted",
+		".env.example":      "CLAUDE_API_KEY=" + fakeKey + "\n",
+		"asc.toml":          "[core]\n\n[agent.a]\ncommand = \"claude --key " + fakeKey + "\"\n",
+		"scripts/deploy.sh": "#!/bin/sh\n\nexport KEY=" + fakeKey + "\n",
+		"README.md":         "Set CLAUDE_API_KEY=" + fakeKey + "\n",
+		"main.go":           "package main\n",
+	}, ".gitignore", ".env.production", ".env.age", ".env.example", "scripts/deploy.sh", "README.md", "main.go")
+
+	findings, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	want := []Finding{
+		{Path: ".env", Reason: "holds plaintext secrets and is not ignored by git", Ignore: "/.env"},
+		{Path: ".env.production", Reason: "holds plaintext secrets and is tracked by git", Ignore: "/.env.production", Tracked: true},
+		{Path: "asc.toml", Line: 4, Reason: "contains what looks like an API key"},
+		{Path: "scripts/deploy.sh", Line: 3, Reason: "contains what looks like an API key", Tracked: true},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("Scan() = %v, want %v", findings, want)
+	}
+	for i := range want {
+		if findings[i] != want[i] {
+			t.Errorf("finding %d = %+v, want %+v", i, findings[i], want[i])
+		}
+	}
+}
+
+func TestScanOutsideRepository(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("CLAUDE_API_KEY="+fakeKey+"\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "asc.toml"), []byte("key = \""+fakeKey+"\"\n"), 0644)
+
+	orig := gitCommand
+	gitCommand = func(dir string, args ...string) ([]byte, error) {
+		return nil, &exec.ExitError{}
+	}
+	defer func() { gitCommand = orig }()
+
+	findings, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(findings) != 1 || findings[0].String() != "asc.toml:1: contains what looks like an API key" {
+		t.Errorf("Expected only the key in asc.toml, got %v", findings)
+	}
+}
+
+func TestAddToGitignore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+
+	if err := AddToGitignore(dir, "/.env"); err != nil {
+		t.Fatalf("AddToGitignore() error = %v", err)
+	}
+	os.WriteFile(path, []byte("node_modules\n/.env"), 0644)
+	if err := AddToGitignore(dir, "/.env", "/.env.local", "/.env.local"); err != nil {
+		t.Fatalf("AddToGitignore() error = %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != "node_modules\n/.env\n/.env.local\n" {
+		t.Errorf(".gitignore = %q", got)
+	}
+}
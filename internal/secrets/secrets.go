@@ -1,5 +1,11 @@
 // Package secrets provides secure secrets management using age encryption.
-// It supports encrypting/decrypting .env files and managing age keys.
+// It supports encrypting/decrypting .env files and managing age keys. The
+// age format is implemented in-process by filippo.io/age, so no external
+// binaries are needed; keys and encrypted files stay compatible with the
+// age and age-keygen command line tools. Files can be encrypted to team
+// members' public keys as well as the local key, so a team can share them.
+// The local identity is kept in the key file, or in the OS keychain with
+// only a pointer to it in the key file.
 //
 // Example usage:
 //
@@ -23,24 +29,37 @@ package secrets
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/rand/asc/internal/events"
+	"github.com/rand/asc/internal/fsperm"
+	"github.com/rand/asc/internal/statedir"
 )
 
+// InjectedEnvVar is set, to the encrypted file, in the environment of
+// commands run by asc secrets inject, so asc commands among them know the
+// secrets are in their environment rather than in a .env file
+const InjectedEnvVar = "ASC_SECRETS_INJECTED"
+
 // Manager handles secrets encryption and decryption using age
 type Manager struct {
-	keyPath string // Path to age key file
+	keyPath    string   // Path to age key file
+	recipients []string // Public keys files are encrypted to besides the local key
+	passphrase string   // Passphrase of a protected key file, once it is known
 }
 
 // NewManager creates a new secrets manager with the default key path
 func NewManager() *Manager {
-	home, _ := os.UserHomeDir()
+	keyPath, _ := statedir.Path("age.key")
 	return &Manager{
-		keyPath: filepath.Join(home, ".asc", "age.key"),
+		keyPath: keyPath,
 	}
 }
 
@@ -51,13 +70,18 @@ func NewManagerWithKeyPath(keyPath string) *Manager {
 	}
 }
 
-// GenerateKey generates a new age key and saves it to the key file
-func (m *Manager) GenerateKey() error {
-	// Check if age is installed
-	if !m.IsAgeInstalled() {
-		return fmt.Errorf("age is not installed. Install with: brew install age (macOS) or see https://github.com/FiloSottile/age")
-	}
+// NewManagerWithRecipients creates a secrets manager with the default key
+// path that encrypts to the given age public keys as well as the local
+// key, so that each of their owners can decrypt the files
+func NewManagerWithRecipients(recipients []string) *Manager {
+	m := NewManager()
+	m.recipients = recipients
+	return m
+}
 
+// GenerateKey generates a new age key and saves it to the key file, in
+// the format written by age-keygen
+func (m *Manager) GenerateKey() error {
 	// Create directory if it doesn't exist
 	keyDir := filepath.Dir(m.keyPath)
 	if err := os.MkdirAll(keyDir, 0700); err != nil {
@@ -65,14 +89,18 @@ func (m *Manager) GenerateKey() error {
 	}
 
 	// Generate key
-	cmd := exec.Command("age-keygen", "-o", m.keyPath)
-	output, err := cmd.CombinedOutput()
+	identity, err := age.GenerateX25519Identity()
 	if err != nil {
-		return fmt.Errorf("failed to generate age key: %w (output: %s)", err, output)
+		return fmt.Errorf("failed to generate age key: %w", err)
+	}
+	contents := fmt.Sprintf("# created: %s\n# public key: %s\n%s\n",
+		time.Now().Format(time.RFC3339), identity.Recipient(), identity)
+	if err := os.WriteFile(m.keyPath, []byte(contents), 0600); err != nil {
+		return fmt.Errorf("failed to write age key: %w", err)
 	}
 
 	// Set restrictive permissions
-	if err := os.Chmod(m.keyPath, 0600); err != nil {
+	if err := fsperm.MakePrivate(m.keyPath); err != nil {
 		return fmt.Errorf("failed to set key permissions: %w", err)
 	}
 
@@ -99,60 +127,118 @@ func (m *Manager) GetPublicKey() (string, error) {
 		}
 	}
 
+	// Key files written by other tools may leave out the comment, and in
+	// protected key files it is encrypted, so fall back to deriving the
+	// public key from the identity
+	identities, err := m.identities()
+	if err != nil {
+		return "", fmt.Errorf("public key not found in key file: %w", err)
+	}
+	for _, identity := range identities {
+		if x, ok := identity.(*age.X25519Identity); ok {
+			return x.Recipient().String(), nil
+		}
+	}
+
 	return "", fmt.Errorf("public key not found in key file")
 }
 
 // Encrypt encrypts a file using age encryption
 func (m *Manager) Encrypt(inputPath, outputPath string) error {
-	if !m.IsAgeInstalled() {
-		return fmt.Errorf("age is not installed")
-	}
-
 	if !m.KeyExists() {
 		return fmt.Errorf("age key not found. Run 'asc secrets init' first")
 	}
 
-	// Get public key
-	pubKey, err := m.GetPublicKey()
+	recipients, err := m.parseRecipients()
 	if err != nil {
-		return fmt.Errorf("failed to get public key: %w", err)
+		return err
 	}
 
 	// Encrypt file
-	cmd := exec.Command("age", "-r", pubKey, "-o", outputPath, inputPath)
-	output, err := cmd.CombinedOutput()
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt file: %w", err)
+	}
+	defer in.Close()
+
+	err = writeFile(outputPath, func(out io.Writer) error {
+		w, err := age.Encrypt(out, recipients...)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, in); err != nil {
+			return err
+		}
+		return w.Close()
+	})
 	if err != nil {
-		return fmt.Errorf("failed to encrypt file: %w (output: %s)", err, output)
+		return fmt.Errorf("failed to encrypt file: %w", err)
 	}
 
+	events.Publish(events.Event{Type: events.SecretsEncrypted, Message: fmt.Sprintf("%s → %s", inputPath, outputPath)})
 	return nil
 }
 
 // Decrypt decrypts a file using age decryption
 func (m *Manager) Decrypt(inputPath, outputPath string) error {
-	if !m.IsAgeInstalled() {
-		return fmt.Errorf("age is not installed")
-	}
-
 	if !m.KeyExists() {
 		return fmt.Errorf("age key not found at %s", m.keyPath)
 	}
 
 	// Decrypt file
-	cmd := exec.Command("age", "-d", "-i", m.keyPath, "-o", outputPath, inputPath)
-	output, err := cmd.CombinedOutput()
+	err := writeFile(outputPath, func(out io.Writer) error {
+		return m.decrypt(inputPath, out)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to decrypt file: %w (output: %s)", err, output)
+		return fmt.Errorf("failed to decrypt file: %w", err)
 	}
 
 	// Set restrictive permissions on decrypted file
-	if err := os.Chmod(outputPath, 0600); err != nil {
+	if err := fsperm.MakePrivate(outputPath); err != nil {
 		return fmt.Errorf("failed to set permissions on decrypted file: %w", err)
 	}
 
+	events.Publish(events.Event{Type: events.SecretsDecrypted, Message: fmt.Sprintf("%s → %s", inputPath, outputPath)})
 	return nil
 }
 
+// DecryptToMemory decrypts a file and returns its contents without
+// writing them to disk
+func (m *Manager) DecryptToMemory(inputPath string) ([]byte, error) {
+	if !m.KeyExists() {
+		return nil, fmt.Errorf("age key not found at %s", m.keyPath)
+	}
+
+	var out bytes.Buffer
+	if err := m.decrypt(inputPath, &out); err != nil {
+		return nil, fmt.Errorf("failed to decrypt file: %w", err)
+	}
+	events.Publish(events.Event{Type: events.SecretsDecrypted, Message: inputPath + " (in memory)"})
+	return out.Bytes(), nil
+}
+
+// decrypt decrypts inputPath with the local key, writing the plaintext
+// to out
+func (m *Manager) decrypt(inputPath string, out io.Writer) error {
+	identities, err := m.identities()
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	r, err := age.Decrypt(in, identities...)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	return err
+}
+
 // EncryptEnv encrypts the .env file to .env.age
 func (m *Manager) EncryptEnv(envPath string) error {
 	if envPath == "" {
@@ -200,14 +286,106 @@ func (m *Manager) KeyExists() bool {
 	return err == nil
 }
 
-// IsAgeInstalled checks if age is installed and available in PATH
+// Recipients returns the public keys files are encrypted to: the local
+// key's followed by the configured ones, without duplicates
+func (m *Manager) Recipients() ([]string, error) {
+	pubKey, err := m.GetPublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key: %w", err)
+	}
+
+	recipients := []string{pubKey}
+	seen := map[string]bool{pubKey: true}
+	for _, recipient := range m.recipients {
+		if !seen[recipient] {
+			seen[recipient] = true
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients, nil
+}
+
+// parseRecipients parses the public keys returned by Recipients
+func (m *Manager) parseRecipients() ([]age.Recipient, error) {
+	keys, err := m.Recipients()
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]age.Recipient, 0, len(keys))
+	for _, key := range keys {
+		recipient, err := age.ParseX25519Recipient(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient '%s': %w", key, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// IsAgeInstalled reports whether age encryption is available. It always
+// is, since age is built in.
+//
+// Deprecated: age no longer needs to be installed.
 func (m *Manager) IsAgeInstalled() bool {
-	_, err := exec.LookPath("age")
+	return true
+}
+
+// identities reads the identities in the key file, or in the OS keychain
+// if the key file points there
+func (m *Manager) identities() ([]age.Identity, error) {
+	account, err := m.keychainAccount()
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("failed to open key file: %w", err)
 	}
-	_, err = exec.LookPath("age-keygen")
-	return err == nil
+
+	var keys io.Reader
+	if account != "" {
+		identity, err := keychainIdentity(account)
+		if err != nil {
+			return nil, err
+		}
+		keys = strings.NewReader(identity)
+	} else if m.IsProtected() {
+		contents, err := m.unlock()
+		if err != nil {
+			return nil, err
+		}
+		keys = bytes.NewReader(contents)
+	} else {
+		file, err := os.Open(m.keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open key file: %w", err)
+		}
+		defer file.Close()
+		keys = file
+	}
+
+	identities, err := age.ParseIdentities(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read age key %s: %w", m.keyPath, err)
+	}
+	return identities, nil
+}
+
+// writeFile creates path with owner-only permissions and fills it with
+// write. A partly written file is removed if write fails, so no truncated
+// secrets are left behind.
+func writeFile(path string, write func(io.Writer) error) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := write(out); err != nil {
+		out.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
 }
 
 // GetKeyPath returns the path to the age key file
@@ -265,10 +443,32 @@ func (m *Manager) ValidateEnvFile(path string) error {
 
 // RotateKey generates a new age key and re-encrypts all encrypted files
 func (m *Manager) RotateKey(encryptedFiles []string) error {
-	// Backup old key
+	return m.rotateKey(encryptedFiles, false)
+}
+
+// RotateKeyAutomatically rotates the key like RotateKey, recording the
+// rotation as one made by the rotation policy rather than by hand
+func (m *Manager) RotateKeyAutomatically(encryptedFiles []string) error {
+	return m.rotateKey(encryptedFiles, true)
+}
+
+func (m *Manager) rotateKey(encryptedFiles []string, automatic bool) error {
+	protected := m.IsProtected()
+	oldPubKey, err := m.GetPublicKey()
+	if protected && err != nil {
+		// The passphrase is needed to protect the new key as well
+		return err
+	}
+
+	// Backup old key; a key kept in the keychain stays there
 	oldKeyPath := m.keyPath + ".old"
+	inKeychain := m.InKeychain()
 	if m.KeyExists() {
-		if err := copyFile(m.keyPath, oldKeyPath); err != nil {
+		backup := func() error { return copyFile(m.keyPath, oldKeyPath) }
+		if inKeychain {
+			backup = func() error { return m.backUpKeychainKey(oldKeyPath) }
+		}
+		if err := backup(); err != nil {
 			return fmt.Errorf("failed to backup old key: %w", err)
 		}
 		fmt.Printf("✓ Backed up old key to %s\n", oldKeyPath)
@@ -280,7 +480,15 @@ func (m *Manager) RotateKey(encryptedFiles []string) error {
 	}
 
 	// Generate new key
-	if err := m.GenerateKey(); err != nil {
+	generate := m.GenerateKey
+	if inKeychain {
+		generate = m.GenerateKeyInKeychain
+	} else if protected {
+		// The new key is protected by the passphrase of the old one
+		passphrase := m.passphrase
+		generate = func() error { return m.GenerateProtectedKey(passphrase) }
+	}
+	if err := generate(); err != nil {
 		return fmt.Errorf("failed to generate new key: %w", err)
 	}
 	fmt.Printf("✓ Generated new age key\n")
@@ -290,6 +498,7 @@ func (m *Manager) RotateKey(encryptedFiles []string) error {
 		// Decrypt with old key
 		tempFile := encFile + ".temp"
 		oldManager := NewManagerWithKeyPath(oldKeyPath)
+		oldManager.passphrase = m.passphrase
 		if err := oldManager.Decrypt(encFile, tempFile); err != nil {
 			return fmt.Errorf("failed to decrypt %s with old key: %w", encFile, err)
 		}
@@ -304,6 +513,20 @@ func (m *Manager) RotateKey(encryptedFiles []string) error {
 		fmt.Printf("✓ Re-encrypted %s\n", encFile)
 	}
 
+	newPubKey, err := m.GetPublicKey()
+	if err != nil {
+		return err
+	}
+	if err := m.recordRotation(Rotation{
+		RotatedAt:    time.Now(),
+		Automatic:    automatic,
+		OldPublicKey: oldPubKey,
+		NewPublicKey: newPubKey,
+		Files:        encryptedFiles,
+	}); err != nil {
+		return err
+	}
+
 	fmt.Printf("✓ Key rotation complete\n")
 	fmt.Printf("⚠ Keep %s in a safe place in case you need to recover old encrypted files\n", oldKeyPath)
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -53,10 +54,10 @@ func TestKeyExists(t *testing.T) {
 func TestIsAgeInstalled(t *testing.T) {
 	manager := NewManager()
 	
-	// This test will pass or fail depending on whether age is installed
-	// We just verify the method doesn't panic
-	installed := manager.IsAgeInstalled()
-	t.Logf("age installed: %v", installed)
+	// age is built in, so it is always available
+	if !manager.IsAgeInstalled() {
+		t.Error("Expected age to be available")
+	}
 }
 
 func TestValidateEnvFile(t *testing.T) {
@@ -136,11 +137,7 @@ func TestGetKeyPath(t *testing.T) {
 }
 
 func TestEncryptDecryptFlow(t *testing.T) {
-	// Skip if age is not installed
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping encryption test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -199,9 +196,6 @@ GOOGLE_API_KEY=test-key-789
 
 func TestGetPublicKey(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping public key test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -236,9 +230,6 @@ func TestGetPublicKey(t *testing.T) {
 
 func TestEncryptEnvHelperMethod(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -278,9 +269,6 @@ GOOGLE_API_KEY=test
 
 func TestDecryptEnvHelperMethod(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -342,23 +330,6 @@ func TestDecryptEnvMissingFile(t *testing.T) {
 
 // Additional comprehensive tests for better coverage
 
-func TestGenerateKey_NoAge(t *testing.T) {
-	// Test when age is not installed
-	tmpDir := t.TempDir()
-	keyPath := filepath.Join(tmpDir, "age.key")
-	manager := NewManagerWithKeyPath(keyPath)
-	
-	// If age is installed, skip this test
-	if manager.IsAgeInstalled() {
-		t.Skip("age is installed, skipping no-age test")
-	}
-	
-	err := manager.GenerateKey()
-	if err == nil {
-		t.Error("Expected error when age is not installed")
-	}
-}
-
 func TestEncrypt_NoKey(t *testing.T) {
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "nonexistent.key")
@@ -586,9 +557,6 @@ func TestCopyFile_InvalidDestination(t *testing.T) {
 
 func TestRotateKey(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping key rotation test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -658,9 +626,6 @@ func TestRotateKey(t *testing.T) {
 
 func TestRotateKey_NoExistingKey(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -677,51 +642,8 @@ func TestRotateKey_NoExistingKey(t *testing.T) {
 	}
 }
 
-func TestEncrypt_NoAge(t *testing.T) {
-	tmpDir := t.TempDir()
-	keyPath := filepath.Join(tmpDir, "age.key")
-	manager := NewManagerWithKeyPath(keyPath)
-	
-	// If age is installed, skip this test
-	if manager.IsAgeInstalled() {
-		t.Skip("age is installed, skipping no-age test")
-	}
-	
-	inputPath := filepath.Join(tmpDir, "input.txt")
-	outputPath := filepath.Join(tmpDir, "output.age")
-	
-	os.WriteFile(inputPath, []byte("test"), 0600)
-	
-	err := manager.Encrypt(inputPath, outputPath)
-	if err == nil {
-		t.Error("Expected error when age is not installed")
-	}
-}
-
-func TestDecrypt_NoAge(t *testing.T) {
-	tmpDir := t.TempDir()
-	keyPath := filepath.Join(tmpDir, "age.key")
-	manager := NewManagerWithKeyPath(keyPath)
-	
-	// If age is installed, skip this test
-	if manager.IsAgeInstalled() {
-		t.Skip("age is installed, skipping no-age test")
-	}
-	
-	inputPath := filepath.Join(tmpDir, "input.age")
-	outputPath := filepath.Join(tmpDir, "output.txt")
-	
-	err := manager.Decrypt(inputPath, outputPath)
-	if err == nil {
-		t.Error("Expected error when age is not installed")
-	}
-}
-
 func TestEncryptEnv_EmptyPath(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -756,9 +678,6 @@ GOOGLE_API_KEY=test
 
 func TestDecryptEnv_EmptyPath(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -801,9 +720,6 @@ GOOGLE_API_KEY=test
 
 func TestManager_MultipleOperations(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -902,9 +818,6 @@ func TestValidateEnvFile_OnlyComments(t *testing.T) {
 
 func TestRotateKey_MultipleFiles(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -962,9 +875,6 @@ func TestRotateKey_MultipleFiles(t *testing.T) {
 
 func TestRotateKey_FailedDecryption(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1059,9 +969,6 @@ AGE-SECRET-KEY-1234567890ABCDEF
 
 func TestEncrypt_InvalidInputFile(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1084,9 +991,6 @@ func TestEncrypt_InvalidInputFile(t *testing.T) {
 
 func TestDecrypt_InvalidInputFile(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1109,9 +1013,6 @@ func TestDecrypt_InvalidInputFile(t *testing.T) {
 
 func TestDecrypt_CorruptedFile(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1135,6 +1036,9 @@ func TestDecrypt_CorruptedFile(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error when decrypting corrupted file")
 	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("Expected no output file to be left after a failed decryption")
+	}
 }
 
 func TestEncrypt_WrongKeyFormat(t *testing.T) {
@@ -1167,9 +1071,6 @@ func TestEncrypt_WrongKeyFormat(t *testing.T) {
 
 func TestGenerateKey_DirectoryCreation(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "nested", "dir", "age.key")
@@ -1194,9 +1095,6 @@ func TestGenerateKey_DirectoryCreation(t *testing.T) {
 
 func TestGenerateKey_Permissions(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1221,9 +1119,6 @@ func TestGenerateKey_Permissions(t *testing.T) {
 
 func TestDecrypt_OutputPermissions(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1269,9 +1164,6 @@ func TestDecrypt_OutputPermissions(t *testing.T) {
 
 func TestConcurrentEncryption(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1326,9 +1218,6 @@ func TestConcurrentEncryption(t *testing.T) {
 
 func TestConcurrentDecryption(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1397,9 +1286,6 @@ func TestConcurrentDecryption(t *testing.T) {
 
 func TestConcurrentMixedOperations(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1468,9 +1354,6 @@ func TestConcurrentMixedOperations(t *testing.T) {
 
 func TestEncryptEnv_NonexistentFile(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1546,9 +1429,6 @@ func TestKeyExists_SymbolicLink(t *testing.T) {
 
 func TestGenerateKey_ExistingFile(t *testing.T) {
 	manager := NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping test")
-	}
 	
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -1581,3 +1461,90 @@ func TestGenerateKey_ExistingFile(t *testing.T) {
 	}
 	// If it failed, that's also acceptable behavior
 }
+
+func TestGetPublicKey_WithoutComment(t *testing.T) {
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "age.key")
+	manager := NewManagerWithKeyPath(keyPath)
+	if err := manager.GenerateKey(); err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	pubKey, err := manager.GetPublicKey()
+	if err != nil {
+		t.Fatalf("Failed to get public key: %v", err)
+	}
+
+	// Key files written by other tools may only hold the identity
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var identity string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "AGE-SECRET-KEY-") {
+			identity = line
+		}
+	}
+	bareKeyPath := filepath.Join(tmpDir, "bare.key")
+	if err := os.WriteFile(bareKeyPath, []byte(identity+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewManagerWithKeyPath(bareKeyPath).GetPublicKey()
+	if err != nil {
+		t.Fatalf("GetPublicKey() error = %v", err)
+	}
+	if got != pubKey {
+		t.Errorf("GetPublicKey() = %s, want %s", got, pubKey)
+	}
+}
+
+func TestEncrypt_MultipleRecipients(t *testing.T) {
+	tmpDir := t.TempDir()
+	teammate := NewManagerWithKeyPath(filepath.Join(tmpDir, "teammate.key"))
+	if err := teammate.GenerateKey(); err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	teammateKey, err := teammate.GetPublicKey()
+	if err != nil {
+		t.Fatalf("Failed to get public key: %v", err)
+	}
+
+	manager := NewManagerWithKeyPath(filepath.Join(tmpDir, "age.key"))
+	manager.recipients = []string{teammateKey, teammateKey}
+	if err := manager.GenerateKey(); err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	recipients, err := manager.Recipients()
+	if err != nil {
+		t.Fatalf("Recipients() error = %v", err)
+	}
+	if len(recipients) != 2 || recipients[1] != teammateKey {
+		t.Errorf("Expected the local key and the teammate's, got %v", recipients)
+	}
+
+	inputPath := filepath.Join(tmpDir, ".env")
+	encryptedPath := filepath.Join(tmpDir, ".env.age")
+	if err := os.WriteFile(inputPath, []byte("CLAUDE_API_KEY=secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Encrypt(inputPath, encryptedPath); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	// Each recipient can decrypt the file with their own key
+	for i, m := range []*Manager{manager, teammate} {
+		outputPath := filepath.Join(tmpDir, fmt.Sprintf("decrypted-%d", i))
+		if err := m.Decrypt(encryptedPath, outputPath); err != nil {
+			t.Fatalf("Decrypt() with key %d error = %v", i, err)
+		}
+		if data, _ := os.ReadFile(outputPath); string(data) != "CLAUDE_API_KEY=secret\n" {
+			t.Errorf("Decrypted with key %d: %q", i, data)
+		}
+	}
+
+	manager.recipients = []string{"not-a-key"}
+	if err := manager.Encrypt(inputPath, encryptedPath); err == nil {
+		t.Error("Expected an invalid recipient to be rejected")
+	}
+}
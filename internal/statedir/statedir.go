@@ -0,0 +1,116 @@
+// Package statedir resolves the directory asc keeps its state in: PID
+// files, logs, the usage ledger, prompts, templates, and the pipeline,
+// budget, and worktree state. It is ~/.asc by default. ASC_STATE_DIR
+// overrides it, and when there is no usable home directory, as in minimal
+// containers that run without HOME or with a read-only one, asc falls back
+// to .asc in the working directory instead of failing.
+//
+// Example usage:
+//
+//	pidDir, err := statedir.Path("pids")
+//	if err != nil {
+//	    return err
+//	}
+package statedir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// EnvVar is the environment variable that overrides the state directory
+const EnvVar = "ASC_STATE_DIR"
+
+// DirName is the name of the state directory in the home or working
+// directory
+const DirName = ".asc"
+
+// Source says where the state directory was resolved from
+type Source string
+
+const (
+	// SourceEnv is a directory set with ASC_STATE_DIR
+	SourceEnv Source = "env"
+	// SourceHome is ~/.asc
+	SourceHome Source = "home"
+	// SourceWorkDir is .asc in the working directory, used when the home
+	// directory is unset or not writable
+	SourceWorkDir Source = "workdir"
+)
+
+// The last resolution is cached, keyed by the inputs it was made from, so
+// the home directory is only probed again when they change
+var (
+	mu       sync.Mutex
+	cacheKey string
+	cacheDir string
+	cacheSrc Source
+)
+
+// Dir returns the state directory
+func Dir() (string, error) {
+	dir, _, err := Resolve()
+	return dir, err
+}
+
+// Path returns a path inside the state directory
+func Path(elem ...string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{dir}, elem...)...), nil
+}
+
+// Resolve returns the state directory and where it came from. ~/.asc is
+// only used if it exists as a writable directory or can be created; it
+// is created if needed.
+func Resolve() (string, Source, error) {
+	env := os.Getenv(EnvVar)
+	home, _ := os.UserHomeDir()
+	wd, wdErr := os.Getwd()
+	key := env + "\x00" + home + "\x00" + wd
+
+	mu.Lock()
+	defer mu.Unlock()
+	if cacheKey == key && cacheDir != "" {
+		return cacheDir, cacheSrc, nil
+	}
+
+	var dir string
+	var source Source
+	switch {
+	case env != "":
+		abs, err := filepath.Abs(env)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid %s %q: %w", EnvVar, env, err)
+		}
+		dir, source = abs, SourceEnv
+	case home != "" && writable(filepath.Join(home, DirName)):
+		dir, source = filepath.Join(home, DirName), SourceHome
+	case wdErr == nil:
+		dir, source = filepath.Join(wd, DirName), SourceWorkDir
+	default:
+		return "", "", fmt.Errorf("failed to resolve state directory: no usable home directory and %v\n  Suggestion: Set %s to a writable directory", wdErr, EnvVar)
+	}
+
+	cacheKey, cacheDir, cacheSrc = key, dir, source
+	return dir, source, nil
+}
+
+// writable reports whether dir is, or can be created as, a directory
+// files can be written to
+func writable(dir string) bool {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false
+	}
+	probe, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return false
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return true
+}
@@ -0,0 +1,67 @@
+package statedir
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	home := t.TempDir()
+	work := t.TempDir()
+	readOnly := t.TempDir()
+	if err := os.Chmod(readOnly, 0500); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(readOnly, 0700)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(work); err != nil {
+		t.Fatal(err)
+	}
+	work, _ = os.Getwd()
+
+	tests := []struct {
+		name       string
+		env        string
+		home       string
+		wantDir    string
+		wantSource Source
+		skipRoot   bool
+	}{
+		{"override", filepath.Join(home, "state"), home, filepath.Join(home, "state"), SourceEnv, false},
+		{"home", "", home, filepath.Join(home, DirName), SourceHome, false},
+		{"no home", "", "", filepath.Join(work, DirName), SourceWorkDir, false},
+		{"read-only home", "", readOnly, filepath.Join(work, DirName), SourceWorkDir, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.skipRoot && (os.Geteuid() == 0 || runtime.GOOS == "windows") {
+				t.Skip("permissions are not enforced")
+			}
+			t.Setenv(EnvVar, tt.env)
+			t.Setenv("HOME", tt.home)
+
+			dir, source, err := Resolve()
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if dir != tt.wantDir || source != tt.wantSource {
+				t.Errorf("Resolve() = %s, %s, want %s, %s", dir, source, tt.wantDir, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvVar, dir)
+
+	got, err := Path("pids", "coder.json")
+	if err != nil || got != filepath.Join(dir, "pids", "coder.json") {
+		t.Errorf("Path() = %s, %v", got, err)
+	}
+}
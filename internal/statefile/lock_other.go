@@ -0,0 +1,15 @@
+//go:build plan9
+
+package statefile
+
+import "os"
+
+// lockFile is a no-op on this platform; writes are still atomic renames
+func lockFile(f *os.File, exclusive bool) error {
+	return nil
+}
+
+// unlockFile is a no-op on this platform
+func unlockFile(f *os.File) error {
+	return nil
+}
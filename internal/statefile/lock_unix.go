@@ -0,0 +1,27 @@
+//go:build !windows && !plan9
+
+package statefile
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes a flock(2) lock on f, waiting until it is available
+func lockFile(f *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// unlockFile releases the lock taken by lockFile
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,216 @@
+// Package statefile reads and writes asc's JSON state files: PID files and
+// the pipeline, budget, and worktree state. Writes go to a temporary file
+// that is synced and renamed over the target, so a crash or power loss
+// leaves either the old or the new contents and never a torn file. Reads
+// and writes hold an advisory lock on the directory's .lock file. A file
+// that fails to parse or validate is moved to the directory's .quarantine
+// subdirectory, so it is kept for inspection and stops tripping later
+// reads.
+//
+// Example usage:
+//
+//	var info ProcessInfo
+//	if err := statefile.ReadJSON(path, &info); err != nil {
+//	    if errors.Is(err, statefile.ErrCorrupt) {
+//	        // The file was quarantined; treat it as missing
+//	    }
+//	    return err
+//	}
+package statefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// QuarantineDirName is the subdirectory corrupt files are moved to
+const QuarantineDirName = ".quarantine"
+
+// lockFileName is the per-directory advisory lock file
+const lockFileName = ".lock"
+
+// ErrCorrupt is wrapped by the errors of reads that found a corrupt file
+var ErrCorrupt = errors.New("corrupt state file")
+
+// Validator is implemented by state types that can check their contents
+// after decoding
+type Validator interface {
+	Validate() error
+}
+
+// WriteJSON encodes v and atomically replaces path with it. The data is
+// written to a temporary file in the same directory, synced to disk, and
+// renamed over path, and the directory is synced so the rename survives a
+// power loss.
+func WriteJSON(path string, v interface{}, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	unlock, err := lock(dir, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+	return syncDir(dir)
+}
+
+// ReadJSON decodes path into v and validates it if v implements
+// Validator. A missing file returns an error satisfying os.IsNotExist. A
+// file that cannot be decoded or fails validation is quarantined and an
+// error wrapping ErrCorrupt is returned.
+func ReadJSON(path string, v interface{}) error {
+	// Reading does not need the lock to be safe, since writes are atomic
+	// renames, so a directory that cannot be locked is still read
+	unlock, err := lock(filepath.Dir(path), false)
+	data, readErr := os.ReadFile(path)
+	if err == nil {
+		unlock()
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	problem := json.Unmarshal(data, v)
+	if problem == nil {
+		if validator, ok := v.(Validator); ok {
+			problem = validator.Validate()
+		}
+	}
+	if problem == nil {
+		return nil
+	}
+
+	moved, err := quarantine(path, data)
+	if err != nil {
+		return fmt.Errorf("%w %s: %v (could not quarantine it: %v)", ErrCorrupt, path, problem, err)
+	}
+	if moved == "" {
+		// Replaced by a writer since it was read; the new contents are
+		// for the next read
+		return fmt.Errorf("%w %s: %v", ErrCorrupt, path, problem)
+	}
+	return fmt.Errorf("%w %s: %v; moved to %s", ErrCorrupt, path, problem, moved)
+}
+
+// Quarantine moves a corrupt file into the .quarantine subdirectory of its
+// directory, with a timestamp suffix, and returns its new path
+func Quarantine(path string) (string, error) {
+	return quarantine(path, nil)
+}
+
+// quarantine moves path into quarantine. If expect is not nil the file is
+// only moved while it still has those contents, so a file a writer has
+// just replaced is left alone; it then returns an empty path.
+func quarantine(path string, expect []byte) (string, error) {
+	dir := QuarantineDir(filepath.Dir(path))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	unlock, err := lock(filepath.Dir(path), true)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	if expect != nil {
+		current, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(current, expect) {
+			return "", nil
+		}
+	}
+
+	target := filepath.Join(dir, fmt.Sprintf("%s.%s", filepath.Base(path), time.Now().Format("20060102T150405.000000000")))
+	if err := os.Rename(path, target); err != nil {
+		return "", fmt.Errorf("failed to quarantine %s: %w", filepath.Base(path), err)
+	}
+	return target, nil
+}
+
+// QuarantineDir returns the quarantine directory of a state directory
+func QuarantineDir(dir string) string {
+	return filepath.Join(dir, QuarantineDirName)
+}
+
+// Lock takes an exclusive advisory lock on the lock file at path,
+// creating it and its directory if needed, and returns the function that
+// releases it. Callers hold it across a read-modify-write of related
+// state files, which the lock WriteJSON takes for a single write does not
+// cover.
+func Lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+	return lockPath(path, true)
+}
+
+// lock takes the advisory lock of dir, exclusive for writers and shared
+// for readers, and returns the function that releases it
+func lock(dir string, exclusive bool) (func(), error) {
+	return lockPath(filepath.Join(dir, lockFileName), exclusive)
+}
+
+// lockPath takes an advisory lock on the file at path
+func lockPath(path string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// syncDir flushes a directory entry change, such as a rename, to disk
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to sync %s: %w", dir, err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return fmt.Errorf("failed to sync %s: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package statefile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type record struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (r *record) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	return nil
+}
+
+func TestWriteReadJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state", "agent.json")
+
+	if err := WriteJSON(path, record{Name: "coder", Count: 3}, 0644); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var got record
+	if err := ReadJSON(path, &got); err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+	if got.Name != "coder" || got.Count != 3 {
+		t.Errorf("ReadJSON() = %+v", got)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %v, %v", info.Mode().Perm(), err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("Temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestReadJSONMissing(t *testing.T) {
+	var got record
+	err := ReadJSON(filepath.Join(t.TempDir(), "missing.json"), &got)
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+}
+
+func TestReadJSONQuarantinesCorruptFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"truncated JSON", `{"name": "cod`},
+		{"empty file", ``},
+		{"fails validation", `{"count": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "agent.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var got record
+			err := ReadJSON(path, &got)
+			if !errors.Is(err, ErrCorrupt) {
+				t.Fatalf("Expected ErrCorrupt, got %v", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Error("Expected the corrupt file to be moved away")
+			}
+
+			entries, err := os.ReadDir(QuarantineDir(dir))
+			if err != nil || len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), "agent.json.") {
+				t.Fatalf("Expected one quarantined file, got %v, %v", entries, err)
+			}
+			data, _ := os.ReadFile(filepath.Join(QuarantineDir(dir), entries[0].Name()))
+			if string(data) != tt.content {
+				t.Errorf("Quarantined contents = %q, want %q", data, tt.content)
+			}
+
+			// The next read sees no file at all
+			if err := ReadJSON(path, &got); !os.IsNotExist(err) {
+				t.Errorf("Expected a not-exist error after quarantine, got %v", err)
+			}
+		})
+	}
+}
+
+// TestConcurrentWrites checks readers never see a partial file while
+// writers replace it
+func TestConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	if err := WriteJSON(path, record{Name: "coder"}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 25; n++ {
+				if err := WriteJSON(path, record{Name: "coder", Count: i*100 + n}, 0644); err != nil {
+					t.Errorf("WriteJSON() error = %v", err)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 25; n++ {
+				var got record
+				if err := ReadJSON(path, &got); err != nil {
+					t.Errorf("ReadJSON() error = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := os.Stat(QuarantineDir(filepath.Dir(path))); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be quarantined")
+	}
+}
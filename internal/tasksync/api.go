@@ -0,0 +1,113 @@
+package tasksync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/proxy"
+)
+
+// api is a client of a tracker's JSON REST API
+type api struct {
+	name   string // e.g. "GitHub API", for errors
+	client *http.Client
+	header http.Header // Sent with every request, e.g. the credentials
+}
+
+// newHTTPClient returns the HTTP client of a [sync.*] section
+func newHTTPClient(section string, timeout time.Duration, proxyOverride string) (*http.Client, error) {
+	settings, err := proxy.ForService(proxyOverride)
+	if err != nil {
+		return nil, fmt.Errorf("%s.proxy: %w", section, err)
+	}
+	return &http.Client{Timeout: timeout, Transport: settings.Transport()}, nil
+}
+
+// do sends a request to the API, decoding the response into out. Any
+// status other than 2xx is an error with the API's message; the
+// credentials are left out of errors.
+func (a *api) do(ctx context.Context, method, endpoint string, body, out interface{}) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range a.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", "asc")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("%s %s %s: %w", a.name, method, endpointPath(endpoint), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if message := errorMessage(resp.Body); message != "" {
+			return nil, fmt.Errorf("%s %s %s: %s: %s", a.name, method, endpointPath(endpoint), resp.Status, message)
+		}
+		return nil, fmt.Errorf("%s %s %s: %s", a.name, method, endpointPath(endpoint), resp.Status)
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("failed to parse the %s response: %w", a.name, err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// errorMessage returns the message of an error response: GitHub's
+// message, or Jira's errorMessages and field errors
+func errorMessage(body io.Reader) string {
+	var apiErr struct {
+		Message       string            `json:"message"`
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	if json.NewDecoder(io.LimitReader(body, 64*1024)).Decode(&apiErr) != nil {
+		return ""
+	}
+	messages := apiErr.ErrorMessages
+	if apiErr.Message != "" {
+		messages = append([]string{apiErr.Message}, messages...)
+	}
+	fields := make([]string, 0, len(apiErr.Errors))
+	for field := range apiErr.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		messages = append(messages, field+": "+apiErr.Errors[field])
+	}
+	return strings.Join(messages, "; ")
+}
+
+// endpointPath returns the path of an endpoint, for errors
+func endpointPath(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil {
+		return u.Path
+	}
+	return endpoint
+}
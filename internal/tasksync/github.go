@@ -0,0 +1,183 @@
+package tasksync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+)
+
+// taskMarker is the comment in the body of an exported issue that names
+// its task, so the link survives losing the state file
+var taskMarker = regexp.MustCompile(`<!-- asc:task (\S+) -->`)
+
+// nextPage is the URL of the next page in a Link header
+var nextPage = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// GitHub is the Tracker of the issues of a GitHub repository, used
+// through the REST API
+type GitHub struct {
+	repo   string
+	apiURL string
+	api    *api
+}
+
+// NewGitHub creates the tracker of repo (owner/name), or of cfg.Repo if
+// repo is empty, authenticating with the token in the env var
+// cfg.TokenEnv
+func NewGitHub(cfg config.GitHubSyncConfig, repo string) (*GitHub, error) {
+	if repo == "" {
+		repo = cfg.Repo
+	}
+	if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("the repository must be owner/name, got '%s' (set --repo or sync.github.repo)", repo)
+	}
+	tokenEnv := cfg.TokenEnv
+	if tokenEnv == "" {
+		tokenEnv = "GITHUB_TOKEN"
+	}
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("%s is not set; set it to a GitHub token that can read and write the issues of %s", tokenEnv, repo)
+	}
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	client, err := newHTTPClient("sync.github", cfg.Timeout, cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	header.Set("Authorization", "Bearer "+token)
+	header.Set("X-GitHub-Api-Version", "2022-11-28")
+	return &GitHub{
+		repo:   repo,
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		api:    &api{name: "GitHub API", client: client, header: header},
+	}, nil
+}
+
+// Name returns "github-<owner>-<name>"
+func (g *GitHub) Name() string {
+	return "github-" + strings.ReplaceAll(g.repo, "/", "-")
+}
+
+// githubIssue is an issue of the REST API
+type githubIssue struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	Body      string `json:"body"`
+	HTMLURL   string `json:"html_url"`
+	Assignees []struct {
+		Login string `json:"login"`
+	} `json:"assignees"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	PullRequest *json.RawMessage `json:"pull_request"`
+}
+
+func (i githubIssue) issue() Issue {
+	issue := Issue{Key: fmt.Sprintf("#%d", i.Number), Title: i.Title, Status: "open", URL: i.HTMLURL}
+	if i.State == "closed" {
+		issue.Status = beads.StatusClosed
+	}
+	if len(i.Assignees) > 0 {
+		issue.Assignee = i.Assignees[0].Login
+	}
+	for _, label := range i.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	if match := taskMarker.FindStringSubmatch(i.Body); match != nil {
+		issue.Task = match[1]
+	}
+	return issue
+}
+
+// Statuses returns open and closed, the states of GitHub issues
+func (g *GitHub) Statuses() []string {
+	return []string{"open", beads.StatusClosed}
+}
+
+// Issues returns all the open and closed issues of the repository,
+// without its pull requests, which include the linked ones
+func (g *GitHub) Issues(ctx context.Context, linked []string) ([]Issue, error) {
+	var issues []Issue
+	next := g.apiURL + "/repos/" + g.repo + "/issues?state=all&per_page=100"
+	for next != "" {
+		var page []githubIssue
+		header, err := g.api.do(ctx, http.MethodGet, next, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page {
+			if item.PullRequest == nil {
+				issues = append(issues, item.issue())
+			}
+		}
+		next = ""
+		if match := nextPage.FindStringSubmatch(header.Get("Link")); match != nil {
+			next = match[1]
+		}
+	}
+	return issues, nil
+}
+
+// CreateIssue creates an issue, naming its task in the body
+func (g *GitHub) CreateIssue(ctx context.Context, issue Issue) (Issue, error) {
+	body := map[string]interface{}{
+		"title":     issue.Title,
+		"body":      fmt.Sprintf("Synced with beads task %s by asc.\n\n<!-- asc:task %s -->", issue.Task, issue.Task),
+		"assignees": assignees(issue.Assignee),
+		"labels":    nonNil(issue.Labels),
+	}
+	var created githubIssue
+	if _, err := g.api.do(ctx, http.MethodPost, g.apiURL+"/repos/"+g.repo+"/issues", body, &created); err != nil {
+		return Issue{}, err
+	}
+	return created.issue(), nil
+}
+
+// UpdateIssue sets the title, state, assignee, and labels of an issue
+func (g *GitHub) UpdateIssue(ctx context.Context, issue Issue) error {
+	number, err := strconv.Atoi(strings.TrimPrefix(issue.Key, "#"))
+	if err != nil {
+		return fmt.Errorf("invalid GitHub issue key '%s'", issue.Key)
+	}
+	state := "open"
+	if issue.Status == beads.StatusClosed {
+		state = "closed"
+	}
+	body := map[string]interface{}{
+		"title":     issue.Title,
+		"state":     state,
+		"assignees": assignees(issue.Assignee),
+		"labels":    nonNil(issue.Labels),
+	}
+	_, err = g.api.do(ctx, http.MethodPatch, fmt.Sprintf("%s/repos/%s/issues/%d", g.apiURL, g.repo, number), body, nil)
+	return err
+}
+
+func assignees(login string) []string {
+	if login == "" {
+		return []string{}
+	}
+	return []string{login}
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
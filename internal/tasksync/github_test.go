@@ -0,0 +1,120 @@
+package tasksync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+)
+
+func TestGitHub(t *testing.T) {
+	var requests []string
+	var bodies []map[string]interface{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"Bad credentials"}`)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if r.Body != nil {
+			var body map[string]interface{}
+			if json.NewDecoder(r.Body).Decode(&body) == nil {
+				bodies = append(bodies, body)
+			}
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("page") == "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/acme/web/issues?state=all&per_page=100&page=2>; rel="next", <%s/x>; rel="last"`, server.URL, server.URL))
+			fmt.Fprint(w, `[{"number":1,"title":"Bug","state":"open","assignees":[{"login":"octocat"}],"labels":[{"name":"bug"}]},
+				{"number":2,"title":"A PR","state":"open","pull_request":{}}]`)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `[{"number":3,"title":"Schema","state":"closed","body":"Synced.\n\n<!-- asc:task bd-4 -->"}]`)
+		case r.Method == http.MethodPost:
+			fmt.Fprint(w, `{"number":4,"title":"API","state":"open","body":"<!-- asc:task bd-5 -->"}`)
+		case r.Method == http.MethodPatch && strings.HasSuffix(r.URL.Path, "/9"):
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"message":"Validation Failed"}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.GitHubSyncConfig{Repo: "acme/web", TokenEnv: "TEST_GITHUB_TOKEN", APIURL: server.URL + "/", Timeout: 5 * time.Second}
+	t.Setenv("TEST_GITHUB_TOKEN", "secret")
+	github, err := NewGitHub(cfg, "")
+	if err != nil {
+		t.Fatalf("NewGitHub() error = %v", err)
+	}
+	if github.Name() != "github-acme-web" {
+		t.Errorf("Name() = %q", github.Name())
+	}
+	ctx := context.Background()
+
+	issues, err := github.Issues(ctx, nil)
+	if err != nil {
+		t.Fatalf("Issues() error = %v", err)
+	}
+	want := []Issue{
+		{Key: "#1", Title: "Bug", Status: "open", Assignee: "octocat", Labels: []string{"bug"}},
+		{Key: "#3", Title: "Schema", Status: "closed", Task: "bd-4"},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("Issues() = %+v, want %+v", issues, want)
+	}
+
+	created, err := github.CreateIssue(ctx, Issue{Title: "API", Task: "bd-5", Labels: []string{"asc"}})
+	if err != nil || created.Key != "#4" || created.Task != "bd-5" {
+		t.Fatalf("CreateIssue() = %+v, %v", created, err)
+	}
+	if body := bodies[0]; !strings.Contains(body["body"].(string), "<!-- asc:task bd-5 -->") || len(body["assignees"].([]interface{})) != 0 {
+		t.Errorf("Unexpected create payload %v", body)
+	}
+	if err := github.UpdateIssue(ctx, Issue{Key: "#4", Title: "API", Status: "closed", Assignee: "octocat"}); err != nil {
+		t.Fatalf("UpdateIssue() error = %v", err)
+	}
+	if body := bodies[1]; body["state"] != "closed" || !reflect.DeepEqual(body["assignees"], []interface{}{"octocat"}) || body["labels"] == nil {
+		t.Errorf("Unexpected update payload %v", body)
+	}
+	err = github.UpdateIssue(ctx, Issue{Key: "#9"})
+	if err == nil || !strings.Contains(err.Error(), "PATCH /repos/acme/web/issues/9: 422 Unprocessable Entity: Validation Failed") {
+		t.Errorf("Expected GitHub's message, got %v", err)
+	}
+
+	wantRequests := []string{
+		"GET /repos/acme/web/issues?state=all&per_page=100",
+		"GET /repos/acme/web/issues?state=all&per_page=100&page=2",
+		"POST /repos/acme/web/issues",
+		"PATCH /repos/acme/web/issues/4",
+		"PATCH /repos/acme/web/issues/9",
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("Requests = %v, want %v", requests, wantRequests)
+	}
+
+	t.Setenv("TEST_GITHUB_TOKEN", "wrong")
+	github, _ = NewGitHub(cfg, "")
+	if _, err := github.Issues(ctx, nil); err == nil || !strings.Contains(err.Error(), "Bad credentials") || strings.Contains(err.Error(), "wrong") {
+		t.Errorf("Expected an auth error without the token, got %v", err)
+	}
+}
+
+func TestNewGitHub_Errors(t *testing.T) {
+	t.Setenv("TEST_GITHUB_TOKEN", "")
+	cfg := config.GitHubSyncConfig{TokenEnv: "TEST_GITHUB_TOKEN"}
+	if _, err := NewGitHub(cfg, "acme"); err == nil || !strings.Contains(err.Error(), "owner/name") {
+		t.Errorf("Expected an invalid repository to fail, got %v", err)
+	}
+	if _, err := NewGitHub(cfg, "acme/web"); err == nil || !strings.Contains(err.Error(), "TEST_GITHUB_TOKEN is not set") {
+		t.Errorf("Expected a missing token to fail, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package tasksync
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/config"
+)
+
+// jiraPageSize is the number of issues asked for per search request, and
+// of linked keys per "key in (...)" query
+const jiraPageSize = 100
+
+// Jira is the Tracker of the issues of a JQL query on a Jira site, used
+// through the REST API (v2, served by both Jira Cloud and Data Center).
+// asc only transitions its issues: it does not create them, and their
+// titles, assignees, and labels are read-only.
+type Jira struct {
+	baseURL  string
+	host     string
+	jql      string
+	statuses map[string]string // Jira status names by beads status
+	api      *api
+}
+
+// NewJira creates the tracker of the issues of jql, or of cfg.JQL if jql
+// is empty, authenticating with the token in the env var cfg.TokenEnv
+func NewJira(cfg config.JiraSyncConfig, jql string) (*Jira, error) {
+	if jql == "" {
+		jql = cfg.JQL
+	}
+	if strings.TrimSpace(jql) == "" {
+		return nil, fmt.Errorf("no JQL query to import; set --jql or sync.jira.jql")
+	}
+	site, err := url.Parse(cfg.URL)
+	if err != nil || (site.Scheme != "http" && site.Scheme != "https") || site.Host == "" {
+		return nil, fmt.Errorf("sync.jira.url must be the http:// or https:// URL of the Jira site, got '%s'", cfg.URL)
+	}
+	tokenEnv := cfg.TokenEnv
+	if tokenEnv == "" {
+		tokenEnv = "JIRA_API_TOKEN"
+	}
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("%s is not set; set it to a Jira API token that can read and transition the issues of the query", tokenEnv)
+	}
+	client, err := newHTTPClient("sync.jira", cfg.Timeout, cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	if cfg.Email != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(cfg.Email+":"+token)))
+	} else {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	statuses := cfg.Statuses
+	if len(statuses) == 0 {
+		statuses = map[string]string{"open": "To Do", "in_progress": "In Progress", beads.StatusClosed: "Done"}
+	}
+	return &Jira{
+		baseURL:  strings.TrimSuffix(cfg.URL, "/"),
+		host:     site.Host,
+		jql:      jql,
+		statuses: statuses,
+		api:      &api{name: "Jira API", client: client, header: header},
+	}, nil
+}
+
+// Name returns "jira-<host>", with a port after a dash
+func (j *Jira) Name() string {
+	return "jira-" + strings.ReplaceAll(j.host, ":", "-")
+}
+
+// Writes returns the status alone: asc transitions Jira issues, and
+// leaves the rest of them, and creating them, to Jira
+func (j *Jira) Writes() []string {
+	return []string{FieldStatus}
+}
+
+// Statuses returns the beads statuses mapped to a Jira status, with open
+// and closed
+func (j *Jira) Statuses() []string {
+	statuses := []string{"open", beads.StatusClosed}
+	for status := range j.statuses {
+		if status != "open" && status != beads.StatusClosed {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Strings(statuses[2:])
+	return statuses
+}
+
+// jiraIssue is an issue of the search API, in the fields asked for
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary string `json:"summary"`
+		Status  struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"` // "new", "indeterminate", or "done"
+			} `json:"statusCategory"`
+		} `json:"status"`
+		Assignee *struct {
+			DisplayName string `json:"displayName"`
+		} `json:"assignee"`
+		Labels []string `json:"labels"`
+	} `json:"fields"`
+}
+
+func (j *Jira) issue(i jiraIssue) Issue {
+	issue := Issue{
+		Key:    i.Key,
+		Title:  i.Fields.Summary,
+		Status: j.beadsStatus(i.Fields.Status.Name, i.Fields.Status.StatusCategory.Key),
+		Labels: i.Fields.Labels,
+		URL:    j.baseURL + "/browse/" + i.Key,
+	}
+	if i.Fields.Assignee != nil {
+		issue.Assignee = i.Fields.Assignee.DisplayName
+	}
+	return issue
+}
+
+// beadsStatus maps a Jira status to the beads status it is mapped from,
+// or else by its category: done is closed, and the others are open
+func (j *Jira) beadsStatus(name, category string) string {
+	for _, status := range j.Statuses() {
+		if strings.EqualFold(j.statuses[status], name) {
+			return status
+		}
+	}
+	if category == "done" {
+		return beads.StatusClosed
+	}
+	return "open"
+}
+
+// Issues returns the issues of the query, then the linked ones it no
+// longer matches, so tasks keep syncing after their issue leaves it
+func (j *Jira) Issues(ctx context.Context, linked []string) ([]Issue, error) {
+	issues, err := j.search(ctx, j.jql)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		found[issue.Key] = true
+	}
+	var missing []string
+	for _, key := range linked {
+		if !found[key] {
+			missing = append(missing, `"`+strings.ReplaceAll(key, `"`, `\"`)+`"`)
+		}
+	}
+	for len(missing) > 0 {
+		batch := missing
+		if len(batch) > jiraPageSize {
+			batch = batch[:jiraPageSize]
+		}
+		missing = missing[len(batch):]
+		more, err := j.search(ctx, "key in ("+strings.Join(batch, ", ")+")")
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, more...)
+	}
+	return issues, nil
+}
+
+// search returns all the issues of a JQL query, page by page. Keys of
+// deleted issues are warnings rather than errors, so they come back
+// missing and their links are dropped.
+func (j *Jira) search(ctx context.Context, jql string) ([]Issue, error) {
+	var issues []Issue
+	for {
+		body := map[string]interface{}{
+			"jql":           jql,
+			"startAt":       len(issues),
+			"maxResults":    jiraPageSize,
+			"fields":        []string{"summary", "status", "assignee", "labels"},
+			"validateQuery": "warn",
+		}
+		var page struct {
+			Issues []jiraIssue `json:"issues"`
+			Total  int         `json:"total"`
+		}
+		if _, err := j.api.do(ctx, http.MethodPost, j.baseURL+"/rest/api/2/search", body, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Issues {
+			issues = append(issues, j.issue(item))
+		}
+		if len(page.Issues) == 0 || len(issues) >= page.Total {
+			return issues, nil
+		}
+	}
+}
+
+// CreateIssue fails: Jira issues are created in Jira, not by asc
+func (j *Jira) CreateIssue(ctx context.Context, issue Issue) (Issue, error) {
+	return Issue{}, errors.New("asc does not create Jira issues")
+}
+
+// UpdateIssue transitions an issue to the Jira status of issue.Status,
+// using the transition of its workflow that leads there
+func (j *Jira) UpdateIssue(ctx context.Context, issue Issue) error {
+	target := j.statuses[issue.Status]
+	if target == "" {
+		return fmt.Errorf("no Jira status for %s (set sync.jira.statuses.%s)", issue.Status, issue.Status)
+	}
+	endpoint := j.baseURL + "/rest/api/2/issue/" + url.PathEscape(issue.Key) + "/transitions"
+	var available struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if _, err := j.api.do(ctx, http.MethodGet, endpoint, nil, &available); err != nil {
+		return err
+	}
+	var names []string
+	for _, transition := range available.Transitions {
+		if strings.EqualFold(transition.To.Name, target) || strings.EqualFold(transition.Name, target) {
+			body := map[string]interface{}{"transition": map[string]string{"id": transition.ID}}
+			_, err := j.api.do(ctx, http.MethodPost, endpoint, body, nil)
+			return err
+		}
+		names = append(names, transition.To.Name)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%s has no transitions to '%s'", issue.Key, target)
+	}
+	return fmt.Errorf("%s has no transition to '%s' (it can move to: %s)", issue.Key, target, strings.Join(names, ", "))
+}
@@ -0,0 +1,140 @@
+package tasksync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/config"
+)
+
+func TestJira(t *testing.T) {
+	var requests []string
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("ci@acme.dev:secret")) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errorMessages":["You are not authenticated"]}`)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		var body map[string]interface{}
+		if r.Method == http.MethodPost {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			bodies = append(bodies, body)
+		}
+		switch {
+		case r.URL.Path == "/rest/api/2/search" && body["jql"] == "project = WEB" && body["startAt"] == 0.0:
+			fmt.Fprint(w, `{"total":3,"issues":[
+				{"key":"WEB-1","fields":{"summary":"Login","status":{"name":"To Do","statusCategory":{"key":"new"}},"assignee":{"displayName":"Ada"},"labels":["auth"]}},
+				{"key":"WEB-2","fields":{"summary":"Cache","status":{"name":"In Review","statusCategory":{"key":"indeterminate"}},"labels":[]}}]}`)
+		case r.URL.Path == "/rest/api/2/search" && body["jql"] == "project = WEB":
+			fmt.Fprint(w, `{"total":3,"issues":[
+				{"key":"WEB-3","fields":{"summary":"Docs","status":{"name":"Shipped","statusCategory":{"key":"done"}}}}]}`)
+		case r.URL.Path == "/rest/api/2/search":
+			fmt.Fprint(w, `{"total":1,"issues":[
+				{"key":"OPS-4","fields":{"summary":"Deploy","status":{"name":"in progress","statusCategory":{"key":"indeterminate"}}}}]}`)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/rest/api/2/issue/WEB-1/"):
+			fmt.Fprint(w, `{"transitions":[{"id":"11","name":"Start","to":{"name":"In Progress"}},{"id":"31","name":"Finish","to":{"name":"Done"}}]}`)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"transitions":[{"id":"11","name":"Start","to":{"name":"In Progress"}}]}`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.JiraSyncConfig{
+		URL:      server.URL + "/",
+		JQL:      "project = WEB",
+		Email:    "ci@acme.dev",
+		TokenEnv: "TEST_JIRA_TOKEN",
+		Statuses: map[string]string{"open": "To Do", "in_progress": "In Progress", "closed": "Done"},
+		Timeout:  5 * time.Second,
+	}
+	t.Setenv("TEST_JIRA_TOKEN", "secret")
+	jira, err := NewJira(cfg, "")
+	if err != nil {
+		t.Fatalf("NewJira() error = %v", err)
+	}
+	if !strings.HasPrefix(jira.Name(), "jira-127.0.0.1-") {
+		t.Errorf("Name() = %q", jira.Name())
+	}
+	if got := jira.Statuses(); !reflect.DeepEqual(got, []string{"open", "closed", "in_progress"}) {
+		t.Errorf("Statuses() = %v", got)
+	}
+	ctx := context.Background()
+
+	issues, err := jira.Issues(ctx, []string{"WEB-1", "OPS-4"})
+	if err != nil {
+		t.Fatalf("Issues() error = %v", err)
+	}
+	want := []Issue{
+		{Key: "WEB-1", Title: "Login", Status: "open", Assignee: "Ada", Labels: []string{"auth"}, URL: server.URL + "/browse/WEB-1"},
+		{Key: "WEB-2", Title: "Cache", Status: "open", Labels: []string{}, URL: server.URL + "/browse/WEB-2"},
+		{Key: "WEB-3", Title: "Docs", Status: "closed", URL: server.URL + "/browse/WEB-3"},
+		{Key: "OPS-4", Title: "Deploy", Status: "in_progress", URL: server.URL + "/browse/OPS-4"},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("Issues() = %+v, want %+v", issues, want)
+	}
+	if jql := bodies[2]["jql"]; jql != `key in ("OPS-4")` {
+		t.Errorf("Linked issues queried with %v", jql)
+	}
+
+	if err := jira.UpdateIssue(ctx, Issue{Key: "WEB-1", Status: "closed"}); err != nil {
+		t.Fatalf("UpdateIssue() error = %v", err)
+	}
+	if transition := bodies[3]["transition"]; !reflect.DeepEqual(transition, map[string]interface{}{"id": "31"}) {
+		t.Errorf("Unexpected transition %v", transition)
+	}
+	err = jira.UpdateIssue(ctx, Issue{Key: "WEB-2", Status: "closed"})
+	if err == nil || !strings.Contains(err.Error(), "WEB-2 has no transition to 'Done' (it can move to: In Progress)") {
+		t.Errorf("Expected a missing transition to fail, got %v", err)
+	}
+	if err := jira.UpdateIssue(ctx, Issue{Key: "WEB-2", Status: "blocked"}); err == nil || !strings.Contains(err.Error(), "sync.jira.statuses.blocked") {
+		t.Errorf("Expected an unmapped status to fail, got %v", err)
+	}
+	if _, err := jira.CreateIssue(ctx, Issue{Title: "New"}); err == nil {
+		t.Error("Expected CreateIssue() to fail")
+	}
+
+	wantRequests := []string{
+		"POST /rest/api/2/search",
+		"POST /rest/api/2/search",
+		"POST /rest/api/2/search",
+		"GET /rest/api/2/issue/WEB-1/transitions",
+		"POST /rest/api/2/issue/WEB-1/transitions",
+		"GET /rest/api/2/issue/WEB-2/transitions",
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("Requests = %v, want %v", requests, wantRequests)
+	}
+
+	t.Setenv("TEST_JIRA_TOKEN", "wrong")
+	jira, _ = NewJira(cfg, "")
+	if _, err := jira.Issues(ctx, nil); err == nil || !strings.Contains(err.Error(), "You are not authenticated") || strings.Contains(err.Error(), "wrong") {
+		t.Errorf("Expected an auth error without the token, got %v", err)
+	}
+}
+
+func TestNewJira_Errors(t *testing.T) {
+	t.Setenv("TEST_JIRA_TOKEN", "")
+	cfg := config.JiraSyncConfig{URL: "https://acme.atlassian.net", TokenEnv: "TEST_JIRA_TOKEN"}
+	if _, err := NewJira(cfg, ""); err == nil || !strings.Contains(err.Error(), "--jql") {
+		t.Errorf("Expected a missing query to fail, got %v", err)
+	}
+	if _, err := NewJira(config.JiraSyncConfig{URL: "acme.atlassian.net"}, "project = WEB"); err == nil || !strings.Contains(err.Error(), "sync.jira.url") {
+		t.Errorf("Expected an invalid URL to fail, got %v", err)
+	}
+	if _, err := NewJira(cfg, "project = WEB"); err == nil || !strings.Contains(err.Error(), "TEST_JIRA_TOKEN is not set") {
+		t.Errorf("Expected a missing token to fail, got %v", err)
+	}
+}
@@ -0,0 +1,654 @@
+// Package tasksync mirrors beads tasks to the issues of a tracker, GitHub
+// Issues or Jira, and back, so teams that triage in the tracker keep a
+// single source of truth. Each run merges the title, status, assignee,
+// and labels of every linked task and issue: a change made on one side
+// since the last run is applied to the other, and a field changed on both
+// is a conflict, resolved by Options.Conflict. Open tasks without an issue
+// are exported as new issues, open issues without a task are imported as
+// new tasks, and the links and values last synced are kept in a state
+// file per tracker under ~/.asc/sync.
+//
+// Example usage:
+//
+//	tracker, err := tasksync.NewGitHub(cfg.Sync.GitHub, "acme/web")
+//	if err != nil {
+//	    return err
+//	}
+//	report, err := tasksync.Sync(ctx, client, tracker, tasksync.Options{Conflict: tasksync.ConflictIssue})
+package tasksync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// Conflict rules: whose change a field changed on both sides keeps
+const (
+	ConflictIssue = "issue" // The issue's
+	ConflictTask  = "task"  // The task's
+	ConflictSkip  = "skip"  // Neither; both sides are left unchanged and the conflict is reported
+)
+
+// Kinds of actions
+const (
+	CreateIssue = "create issue"
+	UpdateIssue = "update issue"
+	CreateTask  = "create task"
+	UpdateTask  = "update task"
+	LinkIssue   = "link"
+	Unlink      = "unlink"
+)
+
+// Synced fields, and what a tracker may write (see Writes)
+const (
+	FieldTitle    = "title"
+	FieldStatus   = "status"
+	FieldAssignee = "assignee"
+	FieldLabels   = "labels"
+	WriteCreate   = "create" // Creating issues for tasks
+)
+
+// Issue is an issue of a tracker, in the fields synced with tasks
+type Issue struct {
+	Key      string // Identifies the issue in the tracker, e.g. "#12" or "ASC-7"
+	Title    string
+	Status   string   // The beads status it maps to, one of the tracker's Statuses
+	Assignee string   // Tracker user of the assignee; "" for none
+	Labels   []string // Label names
+	Task     string   // ID of the task the issue was exported from, if asc created it
+	URL      string
+}
+
+// Tracker is an issue tracker tasks are synced with
+type Tracker interface {
+	// Name identifies the tracker's issues in the state file, e.g. "github-acme-web"
+	Name() string
+	// Statuses returns the beads statuses its issues distinguish; the
+	// others are synced as open
+	Statuses() []string
+	// Issues returns the issues to sync, open and closed: all of them, or
+	// those of the tracker's query, and the linked ones
+	Issues(ctx context.Context, linked []string) ([]Issue, error)
+	// CreateIssue creates an open issue of the fields of issue, recording
+	// issue.Task in it, and returns it with its key
+	CreateIssue(ctx context.Context, issue Issue) (Issue, error)
+	// UpdateIssue sets the fields of the issue of issue.Key to those of
+	// issue
+	UpdateIssue(ctx context.Context, issue Issue) error
+}
+
+// Writer is implemented by trackers that change only some of their
+// issues, such as Jira, whose issues asc imports and only transitions.
+// The fields they do not write are synced from the tracker alone, its
+// values replacing those of the task.
+type Writer interface {
+	// Writes returns the fields UpdateIssue sets, and WriteCreate if
+	// CreateIssue can be used
+	Writes() []string
+}
+
+// Options configure a sync
+type Options struct {
+	Conflict  string            // Conflict rule (default: ConflictIssue)
+	Label     string            // Only import issues with this label, and add it to exported issues
+	Assignees map[string]string // Tracker users by beads assignee; others are synced as they are
+	DryRun    bool              // Plan the changes without making them or saving the state
+	StatePath string            // State file (default: StatePath of the tracker's name)
+}
+
+// Fields are the synced values of a task or an issue, in beads terms
+type Fields struct {
+	Title    string   `json:"title"`
+	Status   string   `json:"status"`
+	Assignee string   `json:"assignee,omitempty"`
+	Labels   []string `json:"labels,omitempty"` // Sorted, without Options.Label
+}
+
+// Link is a task and the issue it is synced with
+type Link struct {
+	Task   string `json:"task"`
+	Issue  string `json:"issue"`
+	Synced Fields `json:"synced"`         // The values both had after the last sync
+	Gone   string `json:"gone,omitempty"` // "task" or "issue" once that side was deleted; the other is no longer synced
+}
+
+// State is what a sync leaves for the next one
+type State struct {
+	Links []Link `json:"links"`
+}
+
+// Action is a change a sync made, or would make in a dry run
+type Action struct {
+	Kind   string // One of the kinds above
+	Task   string // Task ID; "" for a task not created yet
+	Issue  string // Issue key; "" for an issue not created yet
+	Detail string // The title of what is created, the fields updated, or why a link is dropped
+}
+
+// String describes the action, e.g. "update issue #12 from bd-3: closed"
+func (a Action) String() string {
+	switch a.Kind {
+	case CreateIssue:
+		return fmt.Sprintf("create issue for %s: %s", a.Task, a.Detail)
+	case UpdateIssue:
+		return fmt.Sprintf("update issue %s from %s: %s", a.Issue, a.Task, a.Detail)
+	case CreateTask:
+		return fmt.Sprintf("create task for issue %s: %s", a.Issue, a.Detail)
+	case UpdateTask:
+		return fmt.Sprintf("update %s from issue %s: %s", a.Task, a.Issue, a.Detail)
+	case LinkIssue:
+		return fmt.Sprintf("link %s and issue %s: %s", a.Task, a.Issue, a.Detail)
+	}
+	return fmt.Sprintf("unlink %s and issue %s: %s", a.Task, a.Issue, a.Detail)
+}
+
+// Conflict is a field changed on both sides of a link since the last sync
+type Conflict struct {
+	Task       string
+	Issue      string
+	Field      string // title, status, or assignee
+	TaskValue  string
+	IssueValue string
+	Rule       string // The rule that resolved it
+}
+
+// String describes the conflict and how it was resolved
+func (c Conflict) String() string {
+	resolution := "kept the issue's"
+	switch c.Rule {
+	case ConflictTask:
+		resolution = "kept the task's"
+	case ConflictSkip:
+		resolution = "left both unchanged"
+	}
+	return fmt.Sprintf("%s and issue %s both changed %s (%q and %q); %s", c.Task, c.Issue, c.Field, c.TaskValue, c.IssueValue, resolution)
+}
+
+// Report is what a sync did
+type Report struct {
+	Actions   []Action
+	Conflicts []Conflict
+	Linked    int // Links after the sync
+}
+
+// StatePath returns the state file of a tracker
+func StatePath(name string) (string, error) {
+	return statedir.Path("sync", name+".json")
+}
+
+// LoadState reads a state file; a missing one is an empty state
+func LoadState(path string) (*State, error) {
+	var state State
+	if err := statefile.ReadJSON(path, &state); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	return &state, nil
+}
+
+// syncer is the state of a sync in progress
+type syncer struct {
+	client   beads.BeadsClient
+	tracker  Tracker
+	opts     Options
+	statuses []string          // Statuses of the tracker
+	writes   map[string]bool   // What the tracker writes
+	users    map[string]string // Beads assignees by tracker user
+	report   *Report
+	errs     []error
+}
+
+// Sync syncs the tasks of client with the issues of tracker. Failing to
+// change a task or issue does not stop the sync; the link is left as it
+// was, to be synced again by the next run, and the errors are returned
+// with the report.
+func Sync(ctx context.Context, client beads.BeadsClient, tracker Tracker, opts Options) (*Report, error) {
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictIssue
+	}
+	if opts.StatePath == "" {
+		path, err := StatePath(tracker.Name())
+		if err != nil {
+			return nil, err
+		}
+		opts.StatePath = path
+	}
+	state, err := LoadState(opts.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := client.GetTasks(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	var linked []string
+	for _, link := range state.Links {
+		linked = append(linked, link.Issue)
+	}
+	issues, err := tracker.Issues(ctx, linked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issues: %w", err)
+	}
+	sort.Slice(issues, func(i, j int) bool { return keyLess(issues[i].Key, issues[j].Key) })
+
+	s := &syncer{client: client, tracker: tracker, opts: opts, statuses: tracker.Statuses(), users: make(map[string]string), report: &Report{}}
+	writes := []string{FieldTitle, FieldStatus, FieldAssignee, FieldLabels, WriteCreate}
+	if writer, ok := tracker.(Writer); ok {
+		writes = writer.Writes()
+	}
+	s.writes = make(map[string]bool, len(writes))
+	for _, write := range writes {
+		s.writes[write] = true
+	}
+	for name, user := range opts.Assignees {
+		s.users[user] = name
+	}
+	tasksByID := make(map[string]beads.Task, len(tasks))
+	for _, task := range tasks {
+		tasksByID[task.ID] = task
+	}
+	issuesByKey := make(map[string]Issue, len(issues))
+	for _, issue := range issues {
+		issuesByKey[issue.Key] = issue
+	}
+	linkedTasks := make(map[string]bool)
+	linkedIssues := make(map[string]bool)
+
+	// Merge the linked tasks and issues. The link of a deleted task or
+	// issue is kept while the other side exists, so it is neither synced
+	// nor imported or exported again.
+	var links []Link
+	for _, link := range state.Links {
+		task, taskOK := tasksByID[link.Task]
+		issue, issueOK := issuesByKey[link.Issue]
+		if !taskOK && !issueOK {
+			continue
+		}
+		linkedTasks[link.Task] = true
+		linkedIssues[link.Issue] = true
+		if link.Gone == "" && (!taskOK || !issueOK) {
+			link.Gone, link.Synced = "task", Fields{}
+			reason := "the task was deleted; the issue is no longer synced"
+			if !issueOK {
+				link.Gone = "issue"
+				reason = "the issue was deleted or moved; the task is no longer synced"
+			}
+			s.report.Actions = append(s.report.Actions, Action{Kind: Unlink, Task: link.Task, Issue: link.Issue, Detail: reason})
+		}
+		if link.Gone != "" {
+			links = append(links, link)
+			continue
+		}
+		links = append(links, s.merge(ctx, link, task, issue))
+	}
+
+	// Link the issues asc exported again, as after losing the state
+	// file, and import the other open issues
+	for _, issue := range issues {
+		if linkedIssues[issue.Key] {
+			continue
+		}
+		if issue.Task != "" {
+			// An issue exported from a deleted task is not imported back
+			task, ok := tasksByID[issue.Task]
+			if !ok || linkedTasks[task.ID] {
+				continue
+			}
+			s.report.Actions = append(s.report.Actions, Action{Kind: LinkIssue, Task: task.ID, Issue: issue.Key, Detail: "the issue names the task"})
+			links = append(links, s.merge(ctx, Link{Task: task.ID, Issue: issue.Key, Synced: s.taskFields(task)}, task, issue))
+			linkedTasks[task.ID] = true
+			linkedIssues[issue.Key] = true
+			continue
+		}
+		if issue.Status == beads.StatusClosed || (opts.Label != "" && !contains(issue.Labels, opts.Label)) {
+			continue
+		}
+		if link, ok := s.importIssue(ctx, issue); ok {
+			links = append(links, link)
+		}
+	}
+
+	// Export the open tasks without an issue
+	for _, task := range tasks {
+		if !s.writes[WriteCreate] || linkedTasks[task.ID] || task.Status == beads.StatusClosed {
+			continue
+		}
+		if link, ok := s.exportTask(ctx, task); ok {
+			links = append(links, link)
+		}
+	}
+
+	for _, link := range links {
+		if link.Gone == "" {
+			s.report.Linked++
+		}
+	}
+	if !opts.DryRun {
+		if err := statefile.WriteJSON(opts.StatePath, State{Links: links}, 0600); err != nil {
+			s.errs = append(s.errs, fmt.Errorf("failed to save sync state: %w", err))
+		}
+	}
+	return s.report, errors.Join(s.errs...)
+}
+
+// merge syncs a linked task and issue, returning the link to keep
+func (s *syncer) merge(ctx context.Context, link Link, task beads.Task, issue Issue) Link {
+	base, current, remote := link.Synced, s.taskFields(task), s.issueFields(issue)
+	toTask, toIssue, synced := current, remote, base
+
+	title := s.resolve(link, FieldTitle, base.Title, current.Title, remote.Title)
+	toTask.Title, toIssue.Title, synced.Title = title.task, title.issue, title.synced
+	status := s.resolve(link, FieldStatus, base.Status, current.Status, remote.Status)
+	toTask.Status, toIssue.Status, synced.Status = status.task, status.issue, status.synced
+	assignee := s.resolve(link, FieldAssignee, base.Assignee, current.Assignee, remote.Assignee)
+	toTask.Assignee, toIssue.Assignee, synced.Assignee = assignee.task, assignee.issue, assignee.synced
+
+	// Labels merge as sets: those added or removed on either side are
+	// added or removed on both
+	labels := remote.Labels
+	if s.writes[FieldLabels] {
+		labels = mergeLabels(base.Labels, current.Labels, remote.Labels)
+	}
+	toTask.Labels, toIssue.Labels, synced.Labels = labels, labels, labels
+
+	ok := true
+	if !reflect.DeepEqual(toTask, current) {
+		ok = s.updateTask(ctx, task, issue.Key, current, toTask) && ok
+	}
+	if !reflect.DeepEqual(toIssue, remote) {
+		ok = s.updateIssue(ctx, task.ID, issue, remote, toIssue) && ok
+	}
+	if !ok {
+		return link
+	}
+	return Link{Task: link.Task, Issue: link.Issue, Synced: synced}
+}
+
+// resolution is the value a field takes on the task and the issue, and
+// the value recorded as synced
+type resolution struct {
+	task, issue, synced string
+}
+
+// resolve merges a field of a link
+func (s *syncer) resolve(link Link, name, base, task, issue string) resolution {
+	switch {
+	case task == issue:
+		return resolution{task, task, task}
+	case !s.writes[name] || task == base:
+		return resolution{issue, issue, issue}
+	case issue == base:
+		return resolution{task, task, task}
+	}
+	s.report.Conflicts = append(s.report.Conflicts, Conflict{Task: link.Task, Issue: link.Issue, Field: name, TaskValue: task, IssueValue: issue, Rule: s.opts.Conflict})
+	switch s.opts.Conflict {
+	case ConflictTask:
+		return resolution{task, task, task}
+	case ConflictSkip:
+		// Keeping the base leaves the conflict for the next run
+		return resolution{task, issue, base}
+	}
+	return resolution{issue, issue, issue}
+}
+
+// updateTask changes the fields of task from current to target
+func (s *syncer) updateTask(ctx context.Context, task beads.Task, issue string, current, target Fields) bool {
+	update, changes := taskUpdate(current, target)
+	s.report.Actions = append(s.report.Actions, Action{Kind: UpdateTask, Task: task.ID, Issue: issue, Detail: strings.Join(changes, ", ")})
+	if s.opts.DryRun {
+		return true
+	}
+	if err := s.client.UpdateTask(ctx, task.ID, update); err != nil {
+		s.errs = append(s.errs, fmt.Errorf("failed to update %s from issue %s: %w", task.ID, issue, err))
+		return false
+	}
+	return true
+}
+
+// taskUpdate returns the update of a task from current to target, and
+// its description
+func taskUpdate(current, target Fields) (beads.TaskUpdate, []string) {
+	var update beads.TaskUpdate
+	var changes []string
+	if target.Title != current.Title {
+		update.Title = &target.Title
+		changes = append(changes, fmt.Sprintf("title %q", target.Title))
+	}
+	if target.Status != current.Status {
+		update.Status = &target.Status
+		changes = append(changes, target.Status)
+	}
+	if target.Assignee != current.Assignee {
+		update.Assignee = &target.Assignee
+		changes = append(changes, describeAssignee(target.Assignee))
+	}
+	if !reflect.DeepEqual(target.Labels, current.Labels) {
+		labels := append([]string{}, target.Labels...)
+		update.Labels = &labels
+		changes = append(changes, describeLabels(target.Labels))
+	}
+	return update, changes
+}
+
+// updateIssue changes the fields of issue from current to target
+func (s *syncer) updateIssue(ctx context.Context, taskID string, issue Issue, current, target Fields) bool {
+	var changes []string
+	if target.Title != current.Title {
+		changes = append(changes, fmt.Sprintf("title %q", target.Title))
+	}
+	if target.Status != current.Status {
+		switch {
+		case target.Status == beads.StatusClosed:
+			changes = append(changes, "closed")
+		case current.Status == beads.StatusClosed:
+			changes = append(changes, "reopened")
+		default:
+			changes = append(changes, target.Status)
+		}
+	}
+	if target.Assignee != current.Assignee {
+		changes = append(changes, describeAssignee(s.user(target.Assignee)))
+	}
+	if !reflect.DeepEqual(target.Labels, current.Labels) {
+		changes = append(changes, describeLabels(target.Labels))
+	}
+	s.report.Actions = append(s.report.Actions, Action{Kind: UpdateIssue, Task: taskID, Issue: issue.Key, Detail: strings.Join(changes, ", ")})
+	if s.opts.DryRun {
+		return true
+	}
+	updated := s.issue(target)
+	updated.Key, updated.Task, updated.URL = issue.Key, issue.Task, issue.URL
+	if err := s.tracker.UpdateIssue(ctx, updated); err != nil {
+		s.errs = append(s.errs, fmt.Errorf("failed to update issue %s from %s: %w", issue.Key, taskID, err))
+		return false
+	}
+	return true
+}
+
+// importIssue creates the task of an issue
+func (s *syncer) importIssue(ctx context.Context, issue Issue) (Link, bool) {
+	fields := s.issueFields(issue)
+	s.report.Actions = append(s.report.Actions, Action{Kind: CreateTask, Issue: issue.Key, Detail: issue.Title})
+	if s.opts.DryRun {
+		return Link{Issue: issue.Key, Synced: fields}, true
+	}
+	spec := beads.NewTask{Title: fields.Title, Labels: fields.Labels, Assignee: fields.Assignee}
+	task, err := createTask(ctx, s.client, spec)
+	if err == nil && fields.Status != "open" {
+		// New tasks are open; an issue in progress keeps its status
+		err = s.client.UpdateTask(ctx, task.ID, beads.TaskUpdate{Status: &fields.Status})
+	}
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("failed to create a task for issue %s: %w", issue.Key, err))
+		return Link{}, false
+	}
+	s.report.Actions[len(s.report.Actions)-1].Task = task.ID
+	return Link{Task: task.ID, Issue: issue.Key, Synced: fields}, true
+}
+
+// exportTask creates the issue of a task
+func (s *syncer) exportTask(ctx context.Context, task beads.Task) (Link, bool) {
+	fields := s.taskFields(task)
+	s.report.Actions = append(s.report.Actions, Action{Kind: CreateIssue, Task: task.ID, Detail: task.Title})
+	if s.opts.DryRun {
+		return Link{Task: task.ID, Synced: fields}, true
+	}
+	issue := s.issue(fields)
+	issue.Task = task.ID
+	created, err := s.tracker.CreateIssue(ctx, issue)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("failed to create an issue for %s: %w", task.ID, err))
+		return Link{}, false
+	}
+	s.report.Actions[len(s.report.Actions)-1].Issue = created.Key
+	return Link{Task: task.ID, Issue: created.Key, Synced: fields}, true
+}
+
+// createTask creates a task of spec, in one call if client can set its
+// labels and assignee at once
+func createTask(ctx context.Context, client beads.BeadsClient, spec beads.NewTask) (beads.Task, error) {
+	if bulk, ok := client.(beads.BulkClient); ok {
+		created, err := bulk.CreateTasks(ctx, []beads.NewTask{spec})
+		if err != nil {
+			return beads.Task{}, err
+		}
+		return created[0], nil
+	}
+	task, err := client.CreateTask(ctx, spec.Title)
+	if err != nil {
+		return beads.Task{}, err
+	}
+	if spec.Assignee == "" && len(spec.Labels) == 0 {
+		return task, nil
+	}
+	update := beads.TaskUpdate{}
+	if spec.Assignee != "" {
+		update.Assignee = &spec.Assignee
+	}
+	if len(spec.Labels) > 0 {
+		update.Labels = &spec.Labels
+	}
+	return task, client.UpdateTask(ctx, task.ID, update)
+}
+
+// taskFields returns the synced fields of a task
+func (s *syncer) taskFields(task beads.Task) Fields {
+	status := task.Status
+	if !contains(s.statuses, status) {
+		status = "open"
+		if task.Status == beads.StatusClosed {
+			status = beads.StatusClosed
+		}
+	}
+	return Fields{Title: task.Title, Status: status, Assignee: task.Assignee, Labels: s.labels(task.Labels)}
+}
+
+// issueFields returns the synced fields of an issue, in beads terms
+func (s *syncer) issueFields(issue Issue) Fields {
+	assignee := issue.Assignee
+	if name, ok := s.users[assignee]; ok {
+		assignee = name
+	}
+	return Fields{Title: issue.Title, Status: issue.Status, Assignee: assignee, Labels: s.labels(issue.Labels)}
+}
+
+// issue returns the issue of fields, in tracker terms
+func (s *syncer) issue(fields Fields) Issue {
+	labels := append([]string{}, fields.Labels...)
+	if s.opts.Label != "" {
+		labels = append(labels, s.opts.Label)
+	}
+	return Issue{Title: fields.Title, Status: fields.Status, Assignee: s.user(fields.Assignee), Labels: labels}
+}
+
+// user returns the tracker user of a beads assignee
+func (s *syncer) user(assignee string) string {
+	if user, ok := s.opts.Assignees[assignee]; ok {
+		return user
+	}
+	return assignee
+}
+
+// labels returns labels sorted, without the sync label, or nil for none
+func (s *syncer) labels(labels []string) []string {
+	var sorted []string
+	for _, label := range labels {
+		if label != s.opts.Label && !contains(sorted, label) {
+			sorted = append(sorted, label)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// mergeLabels returns the labels of base with those added to task or
+// issue since, and without those removed from either
+func mergeLabels(base, task, issue []string) []string {
+	var merged []string
+	for _, label := range append(append(append([]string{}, base...), task...), issue...) {
+		if contains(merged, label) {
+			continue
+		}
+		if contains(base, label) {
+			if !contains(task, label) || !contains(issue, label) {
+				continue
+			}
+		}
+		merged = append(merged, label)
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// keyLess orders issue keys by their prefix, then their number, so #9
+// comes before #10 and ASC-2 before ASC-10
+func keyLess(a, b string) bool {
+	prefixA, numberA := splitKey(a)
+	prefixB, numberB := splitKey(b)
+	if prefixA != prefixB || numberA == numberB {
+		return a < b
+	}
+	return numberA < numberB
+}
+
+// splitKey splits the trailing number off an issue key
+func splitKey(key string) (string, int) {
+	i := len(key)
+	for i > 0 && key[i-1] >= '0' && key[i-1] <= '9' {
+		i--
+	}
+	number, _ := strconv.Atoi(key[i:])
+	return key[:i], number
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func describeAssignee(assignee string) string {
+	if assignee == "" {
+		return "unassigned"
+	}
+	return "assigned to " + assignee
+}
+
+func describeLabels(labels []string) string {
+	if len(labels) == 0 {
+		return "no labels"
+	}
+	return "labels " + strings.Join(labels, ", ")
+}
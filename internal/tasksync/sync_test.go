@@ -0,0 +1,369 @@
+package tasksync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/rand/asc/internal/beads"
+)
+
+// fakeTracker keeps issues in memory
+type fakeTracker struct {
+	issues map[string]*Issue
+	next   int
+	fail   error // Fails every update
+}
+
+func newFakeTracker(issues ...Issue) *fakeTracker {
+	tracker := &fakeTracker{issues: map[string]*Issue{}, next: 100}
+	for i := range issues {
+		tracker.issues[issues[i].Key] = &issues[i]
+	}
+	return tracker
+}
+
+func (t *fakeTracker) Name() string { return "fake" }
+
+func (t *fakeTracker) Statuses() []string { return []string{"open", "in_progress", beads.StatusClosed} }
+
+func (t *fakeTracker) Issues(ctx context.Context, linked []string) ([]Issue, error) {
+	var issues []Issue
+	for _, issue := range t.issues {
+		issues = append(issues, *issue)
+	}
+	return issues, nil
+}
+
+func (t *fakeTracker) CreateIssue(ctx context.Context, issue Issue) (Issue, error) {
+	t.next++
+	issue.Key = fmt.Sprintf("T-%d", t.next)
+	t.issues[issue.Key] = &issue
+	return issue, nil
+}
+
+func (t *fakeTracker) UpdateIssue(ctx context.Context, issue Issue) error {
+	if t.fail != nil {
+		return t.fail
+	}
+	if _, ok := t.issues[issue.Key]; !ok {
+		return fmt.Errorf("no issue %s", issue.Key)
+	}
+	t.issues[issue.Key] = &issue
+	return nil
+}
+
+// fakeClient keeps tasks in memory; it is not a BulkClient
+type fakeClient struct {
+	tasks map[string]*beads.Task
+	order []string
+	next  int
+}
+
+func newFakeClient(tasks ...beads.Task) *fakeClient {
+	client := &fakeClient{tasks: map[string]*beads.Task{}}
+	for i := range tasks {
+		client.tasks[tasks[i].ID] = &tasks[i]
+		client.order = append(client.order, tasks[i].ID)
+	}
+	return client
+}
+
+func (c *fakeClient) GetTasks(ctx context.Context, statuses []string) ([]beads.Task, error) {
+	var tasks []beads.Task
+	for _, id := range c.order {
+		if task, ok := c.tasks[id]; ok {
+			tasks = append(tasks, *task)
+		}
+	}
+	return tasks, nil
+}
+
+func (c *fakeClient) CreateTask(ctx context.Context, title string) (beads.Task, error) {
+	c.next++
+	task := beads.Task{ID: fmt.Sprintf("new-%d", c.next), Title: title, Status: "open"}
+	c.tasks[task.ID] = &task
+	c.order = append(c.order, task.ID)
+	return task, nil
+}
+
+func (c *fakeClient) UpdateTask(ctx context.Context, id string, updates beads.TaskUpdate) error {
+	task, ok := c.tasks[id]
+	if !ok {
+		return beads.ErrTaskNotFound
+	}
+	if updates.Title != nil {
+		task.Title = *updates.Title
+	}
+	if updates.Status != nil {
+		task.Status = *updates.Status
+	}
+	if updates.Assignee != nil {
+		task.Assignee = *updates.Assignee
+	}
+	if updates.Labels != nil {
+		task.Labels = *updates.Labels
+	}
+	return nil
+}
+
+func (c *fakeClient) DeleteTask(ctx context.Context, id string) error {
+	delete(c.tasks, id)
+	return nil
+}
+
+func (c *fakeClient) Refresh(ctx context.Context) error { return nil }
+
+func kinds(report *Report) []string {
+	var kinds []string
+	for _, action := range report.Actions {
+		kinds = append(kinds, action.Kind)
+	}
+	return kinds
+}
+
+func TestSync(t *testing.T) {
+	client := newFakeClient(
+		beads.Task{ID: "bd-1", Title: "Schema", Status: "in_progress", Assignee: "coder", Labels: []string{"backend"}},
+		beads.Task{ID: "bd-2", Title: "Old", Status: beads.StatusClosed},
+	)
+	tracker := newFakeTracker(
+		Issue{Key: "T-1", Status: "open", Title: "Login fails", Assignee: "octocat", Labels: []string{"bug", "asc"}},
+		Issue{Key: "T-2", Status: beads.StatusClosed, Title: "Fixed long ago", Labels: []string{"asc"}},
+		Issue{Key: "T-3", Status: "open", Title: "Not for agents"},
+	)
+	opts := Options{Label: "asc", Assignees: map[string]string{"coder": "octo-coder"}, StatePath: filepath.Join(t.TempDir(), "state.json")}
+	ctx := context.Background()
+
+	// The first sync imports the open labeled issue and exports the open task
+	report, err := Sync(ctx, client, tracker, opts)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got := kinds(report); !reflect.DeepEqual(got, []string{CreateTask, CreateIssue}) || report.Linked != 2 {
+		t.Fatalf("Expected a task and an issue to be created, got %v", report.Actions)
+	}
+	imported := client.tasks["new-1"]
+	if imported == nil || imported.Title != "Login fails" || imported.Assignee != "octocat" || !reflect.DeepEqual(imported.Labels, []string{"bug"}) {
+		t.Errorf("Expected issue #1 as a task, got %+v", imported)
+	}
+	exported := tracker.issues["T-101"]
+	if exported == nil || exported.Task != "bd-1" || exported.Assignee != "octo-coder" || !reflect.DeepEqual(exported.Labels, []string{"backend", "asc"}) || exported.Status != "in_progress" {
+		t.Errorf("Expected bd-1 as an issue in progress, got %+v", exported)
+	}
+
+	// Nothing changed
+	report, err = Sync(ctx, client, tracker, opts)
+	if err != nil || len(report.Actions) != 0 || len(report.Conflicts) != 0 {
+		t.Fatalf("Expected nothing to sync, got %v, %v", report.Actions, err)
+	}
+
+	// Changes on either side reach the other, and labels merge
+	tracker.issues["T-101"].Status = beads.StatusClosed
+	tracker.issues["T-101"].Labels = append(tracker.issues["T-101"].Labels, "urgent")
+	client.tasks["bd-1"].Title = "Schema v2"
+	client.tasks["bd-1"].Labels = nil
+	tracker.issues["T-1"].Assignee = "octo-coder"
+	report, err = Sync(ctx, client, tracker, opts)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if task := client.tasks["bd-1"]; task.Status != beads.StatusClosed || !reflect.DeepEqual(task.Labels, []string{"urgent"}) || task.Title != "Schema v2" {
+		t.Errorf("Expected bd-1 closed with the labels merged, got %+v", task)
+	}
+	if issue := tracker.issues["T-101"]; issue.Title != "Schema v2" || !reflect.DeepEqual(issue.Labels, []string{"urgent", "asc"}) || issue.Status != beads.StatusClosed {
+		t.Errorf("Expected issue #101 retitled with the labels merged, got %+v", issue)
+	}
+	if client.tasks["new-1"].Assignee != "coder" {
+		t.Errorf("Expected the mapped login to assign the agent, got %q", client.tasks["new-1"].Assignee)
+	}
+	if len(report.Conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %v", report.Conflicts)
+	}
+}
+
+func TestSync_Conflicts(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T) (*fakeClient, *fakeTracker, Options) {
+		client := newFakeClient(beads.Task{ID: "bd-1", Title: "Schema", Status: "open"})
+		tracker := newFakeTracker()
+		opts := Options{StatePath: filepath.Join(t.TempDir(), "state.json")}
+		if _, err := Sync(ctx, client, tracker, opts); err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		client.tasks["bd-1"].Title = "Schema (beads)"
+		tracker.issues["T-101"].Title = "Schema (GitHub)"
+		return client, tracker, opts
+	}
+
+	tests := []struct {
+		rule      string
+		wantTask  string
+		wantIssue string
+	}{
+		{"", "Schema (GitHub)", "Schema (GitHub)"},
+		{ConflictTask, "Schema (beads)", "Schema (beads)"},
+		{ConflictSkip, "Schema (beads)", "Schema (GitHub)"},
+	}
+	for _, tt := range tests {
+		t.Run("rule "+tt.rule, func(t *testing.T) {
+			client, tracker, opts := setup(t)
+			opts.Conflict = tt.rule
+			report, err := Sync(ctx, client, tracker, opts)
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			if len(report.Conflicts) != 1 || report.Conflicts[0].Field != "title" {
+				t.Fatalf("Expected a title conflict, got %v", report.Conflicts)
+			}
+			if client.tasks["bd-1"].Title != tt.wantTask || tracker.issues["T-101"].Title != tt.wantIssue {
+				t.Errorf("Got task %q and issue %q", client.tasks["bd-1"].Title, tracker.issues["T-101"].Title)
+			}
+			// A skipped conflict is reported again; a resolved one is not
+			report, _ = Sync(ctx, client, tracker, opts)
+			if want := map[bool]int{true: 1, false: 0}[tt.rule == ConflictSkip]; len(report.Conflicts) != want {
+				t.Errorf("Expected %d conflicts on the next run, got %v", want, report.Conflicts)
+			}
+		})
+	}
+}
+
+func TestSync_Deleted(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient(beads.Task{ID: "bd-1", Title: "Schema", Status: "open"}, beads.Task{ID: "bd-2", Title: "API", Status: "open"})
+	tracker := newFakeTracker()
+	opts := Options{StatePath: filepath.Join(t.TempDir(), "state.json")}
+	if _, err := Sync(ctx, client, tracker, opts); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	// The issue of a deleted task is neither synced nor imported, and a
+	// task whose issue was deleted is not exported again
+	delete(client.tasks, "bd-1")
+	delete(tracker.issues, "T-102")
+	report, err := Sync(ctx, client, tracker, opts)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got := kinds(report); !reflect.DeepEqual(got, []string{Unlink, Unlink}) || report.Linked != 0 {
+		t.Errorf("Expected both links to be dropped, got %v", report.Actions)
+	}
+	report, err = Sync(ctx, client, tracker, opts)
+	if err != nil || len(report.Actions) != 0 || len(client.order) != 2 || len(tracker.issues) != 1 {
+		t.Errorf("Expected nothing more to sync, got %v, %v", report.Actions, err)
+	}
+}
+
+func TestSync_LostState(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient(beads.Task{ID: "bd-1", Title: "Schema", Status: "open"})
+	tracker := newFakeTracker(Issue{Key: "T-7", Status: "open", Title: "Schema, renamed", Task: "bd-1"}, Issue{Key: "T-8", Status: "open", Title: "From a deleted task", Task: "bd-9"})
+	report, err := Sync(ctx, client, tracker, Options{StatePath: filepath.Join(t.TempDir(), "state.json")})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got := kinds(report); !reflect.DeepEqual(got, []string{LinkIssue, UpdateTask}) {
+		t.Errorf("Expected issue #7 to be linked back, got %v", report.Actions)
+	}
+	if client.tasks["bd-1"].Title != "Schema, renamed" || len(client.order) != 1 || len(tracker.issues) != 2 {
+		t.Errorf("Expected no duplicates, got tasks %v and %d issues", client.order, len(tracker.issues))
+	}
+}
+
+func TestSync_DryRunAndErrors(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient(beads.Task{ID: "bd-1", Title: "Schema", Status: "open"})
+	tracker := newFakeTracker(Issue{Key: "T-1", Status: "open", Title: "Bug"})
+	opts := Options{DryRun: true, StatePath: filepath.Join(t.TempDir(), "state.json")}
+
+	report, err := Sync(ctx, client, tracker, opts)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(report.Actions) != 2 || len(client.order) != 1 || len(tracker.issues) != 1 {
+		t.Errorf("Expected a dry run to plan 2 actions and make none, got %v", report.Actions)
+	}
+	if state, err := LoadState(opts.StatePath); err != nil || len(state.Links) != 0 {
+		t.Errorf("Expected a dry run not to save the state, got %+v, %v", state, err)
+	}
+
+	opts.DryRun = false
+	if _, err := Sync(ctx, client, tracker, opts); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	tracker.fail = errors.New("API rate limit exceeded")
+	client.tasks["bd-1"].Status = beads.StatusClosed
+	if _, err := Sync(ctx, client, tracker, opts); err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Fatalf("Expected the failed update, got %v", err)
+	}
+	// The failed change is retried
+	tracker.fail = nil
+	if _, err := Sync(ctx, client, tracker, opts); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if tracker.issues["T-101"].Status != beads.StatusClosed {
+		t.Error("Expected the issue to be closed once the API works again")
+	}
+}
+
+// partialTracker writes only the statuses of its issues, and creates none
+type partialTracker struct {
+	*fakeTracker
+}
+
+func (t partialTracker) Statuses() []string { return []string{"open", beads.StatusClosed} }
+
+func (t partialTracker) Writes() []string { return []string{FieldStatus} }
+
+func TestSync_PartialTracker(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient(beads.Task{ID: "bd-1", Title: "Local only", Status: "open"})
+	tracker := partialTracker{newFakeTracker(Issue{Key: "T-1", Status: "open", Title: "Imported", Labels: []string{"backend"}})}
+	opts := Options{StatePath: filepath.Join(t.TempDir(), "state.json")}
+	report, err := Sync(ctx, client, tracker, opts)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got := kinds(report); !reflect.DeepEqual(got, []string{CreateTask}) {
+		t.Fatalf("Expected only the import, got %v", report.Actions)
+	}
+
+	// Statuses are pushed, and the tracker's other fields replace the task's
+	task := client.tasks["new-1"]
+	task.Status, task.Title, task.Labels = "in_progress", "Renamed locally", nil
+	tracker.issues["T-1"].Title = "Renamed in the tracker"
+	if _, err := Sync(ctx, client, tracker, opts); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if task.Title != "Renamed in the tracker" || !reflect.DeepEqual(task.Labels, []string{"backend"}) || task.Status != "in_progress" {
+		t.Errorf("Expected the tracker's title and labels, got %+v", task)
+	}
+	if issue := tracker.issues["T-1"]; issue.Status != "open" {
+		t.Errorf("Expected in_progress to be synced as open, got %q", issue.Status)
+	}
+	task.Status = beads.StatusClosed
+	report, err = Sync(ctx, client, tracker, opts)
+	if err != nil || tracker.issues["T-1"].Status != beads.StatusClosed || len(report.Conflicts) != 0 {
+		t.Errorf("Expected the issue to be closed, got %+v, %v", tracker.issues["T-1"], err)
+	}
+}
+
+func TestKeyLess(t *testing.T) {
+	keys := []string{"ASC-10", "#10", "ASC-2", "#9", "ABC-1"}
+	sort.Slice(keys, func(i, j int) bool { return keyLess(keys[i], keys[j]) })
+	if want := []string{"#9", "#10", "ABC-1", "ASC-2", "ASC-10"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Sorted keys = %v, want %v", keys, want)
+	}
+}
+
+func TestMergeLabels(t *testing.T) {
+	got := mergeLabels([]string{"a", "b", "c"}, []string{"a", "c", "d"}, []string{"a", "b", "e"})
+	if want := []string{"a", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeLabels() = %v, want %v", got, want)
+	}
+}
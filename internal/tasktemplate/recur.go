@@ -0,0 +1,185 @@
+package tasktemplate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/logger"
+	"github.com/rand/asc/internal/statefile"
+)
+
+var schedulerLog = logger.WithComponent("tasktemplate")
+
+// recurState is when each recurring template last created its tasks
+type recurState struct {
+	LastRun map[string]time.Time `json:"last_run"`
+}
+
+// Run is the creation of the tasks of a recurring template that fell due
+type Run struct {
+	Template string
+	Due      time.Time    // When the run was scheduled
+	Tasks    []beads.Task // The tasks created
+	Err      error        // Why the tasks were not all created
+}
+
+// NextRun returns when the recurring template t next falls due after
+// last, or the zero time if it does not recur
+func (t *Template) NextRun(last time.Time) time.Time {
+	schedule, err := ParseSchedule(t.Schedule)
+	if t.Schedule == "" || err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(last)
+}
+
+// dueAt returns the latest run of the template after last and by now, if
+// there is one: of several missed runs, the tasks are those of the latest
+func (t *Template) dueAt(last, now time.Time) (time.Time, bool) {
+	due := t.NextRun(last)
+	if due.IsZero() || due.After(now) {
+		return time.Time{}, false
+	}
+	for next := t.NextRun(due); !next.IsZero() && !next.After(now); next = t.NextRun(due) {
+		due = next
+	}
+	return due, true
+}
+
+// readRecurState reads when the recurring templates of dir last ran
+func readRecurState(dir string) (recurState, error) {
+	var state recurState
+	if err := statefile.ReadJSON(filepath.Join(dir, ".schedule.json"), &state); err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, statefile.ErrCorrupt) {
+		return state, err
+	}
+	if state.LastRun == nil {
+		state.LastRun = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// Pending returns the runs RunDue would make by now, with the tasks they
+// would create as their Tasks, without creating or recording anything
+func Pending(dir string, now time.Time) ([]Run, error) {
+	templates, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	state, err := readRecurState(dir)
+	if err != nil {
+		return nil, err
+	}
+	var runs []Run
+	for _, t := range templates {
+		last, seen := state.LastRun[t.Name]
+		if t.Schedule == "" || !seen {
+			continue
+		}
+		due, ok := t.dueAt(last, now)
+		if !ok {
+			continue
+		}
+		run := Run{Template: t.Name, Due: due}
+		tasks, err := t.Render(nil, due)
+		for _, task := range tasks {
+			run.Tasks = append(run.Tasks, beads.Task{Title: task.Title, Status: "open", Phase: task.Phase, Labels: task.Labels, Assignee: task.Assignee})
+		}
+		run.Err = err
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// RunDue creates the tasks of the recurring templates of dir that have
+// fallen due by now, with their default parameters. A template is due
+// once its schedule has passed since its last run; runs missed while no
+// one checked are made up by one run, not one each. A template seen for
+// the first time is not due until its schedule next passes. When each
+// template last ran is kept in dir, under a lock, so schedulers on
+// several machines sharing ~/.asc, or overlapping runs of cron, create
+// each run's tasks once.
+func RunDue(ctx context.Context, client beads.BulkClient, dir string, now time.Time) ([]Run, error) {
+	templates, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := statefile.Lock(filepath.Join(dir, ".schedule.lock"))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	state, err := readRecurState(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []Run
+	changed := false
+	for _, t := range templates {
+		if t.Schedule == "" {
+			continue
+		}
+		if _, seen := state.LastRun[t.Name]; !seen {
+			state.LastRun[t.Name] = now
+			changed = true
+			continue
+		}
+		due, ok := t.dueAt(state.LastRun[t.Name], now)
+		if !ok {
+			continue
+		}
+		run := Run{Template: t.Name, Due: due}
+		tasks, err := t.Render(nil, due)
+		if err == nil {
+			run.Tasks, err = client.CreateTasks(ctx, tasks)
+		}
+		run.Err = err
+		if err != nil && len(run.Tasks) == 0 && ctx.Err() != nil {
+			// Interrupted before creating anything; the run stays due
+			break
+		}
+		// A run that failed part way is not retried, as retrying would
+		// duplicate the tasks it created
+		state.LastRun[t.Name] = now
+		changed = true
+		runs = append(runs, run)
+		fields := logger.Fields{"template": t.Name, "due": due.Format(time.RFC3339), "tasks": len(run.Tasks)}
+		if err != nil {
+			schedulerLog.WithFields(fields).Warn("Recurring tasks not all created: %v", err)
+		} else {
+			schedulerLog.WithFields(fields).Info("Created recurring tasks")
+		}
+	}
+	if changed {
+		if err := statefile.WriteJSON(filepath.Join(dir, ".schedule.json"), state, 0600); err != nil {
+			return runs, fmt.Errorf("failed to record recurring task runs: %w", err)
+		}
+	}
+	return runs, nil
+}
+
+// Scheduler creates the tasks of recurring templates as they fall due,
+// checking every interval until its context is done. It is the hook a
+// long-running asc process, such as the daemon, uses to run recurring
+// templates; without one, asc tasks template run-due can be run from
+// cron instead.
+func Scheduler(ctx context.Context, client beads.BulkClient, dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := RunDue(ctx, client, dir, time.Now()); err != nil && ctx.Err() == nil {
+			schedulerLog.Warn("Failed to run recurring task templates: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
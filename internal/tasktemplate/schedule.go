@@ -0,0 +1,148 @@
+package tasktemplate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleAliases are the shorthands of common schedules
+var scheduleAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@nightly": "0 2 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Schedule is a parsed cron expression: minute, hour, day of month,
+// month, and day of week, in local time
+type Schedule struct {
+	minute, hour, dom, month, dow []bool
+	anyDOM, anyDOW                bool // The day fields are "*"
+}
+
+// scheduleField is the range of one field of a cron expression
+type scheduleField struct {
+	name     string
+	min, max int
+}
+
+var scheduleFields = []scheduleField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 7 is Sunday, as 0 is
+}
+
+// ParseSchedule parses a cron expression of five fields, e.g. "0 2 * * *"
+// for 02:00 every day, or one of @hourly, @daily, @nightly (02:00),
+// @weekly, and @monthly. Each field is "*", a value, a range "a-b", or a
+// list of them separated by commas, each optionally followed by a step
+// "/n".
+func ParseSchedule(spec string) (*Schedule, error) {
+	expr := strings.TrimSpace(spec)
+	if alias, ok := scheduleAliases[expr]; ok {
+		expr = alias
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("invalid schedule '%s': want 5 fields (minute hour day-of-month month day-of-week) or an @alias", spec)
+	}
+	sets := make([][]bool, len(fields))
+	for i, field := range fields {
+		set, err := parseScheduleField(field, scheduleFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule '%s': %w", spec, err)
+		}
+		sets[i] = set
+	}
+	dow := sets[4]
+	dow[0] = dow[0] || dow[7]
+	return &Schedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    dow[:7],
+		anyDOM: fields[2] == "*",
+		anyDOW: fields[4] == "*",
+	}, nil
+}
+
+// parseScheduleField returns the values a field of a cron expression
+// matches, indexed by value
+func parseScheduleField(field string, f scheduleField) ([]bool, error) {
+	set := make([]bool, f.max+1)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, stepText, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid step '%s' of the %s", stepText, f.name)
+			}
+			part, step = base, n
+		}
+		low, high := f.min, f.max
+		if part != "*" {
+			lowText, highText, isRange := strings.Cut(part, "-")
+			var err error
+			if low, err = strconv.Atoi(lowText); err != nil {
+				return nil, fmt.Errorf("invalid %s '%s'", f.name, part)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highText); err != nil {
+					return nil, fmt.Errorf("invalid %s '%s'", f.name, part)
+				}
+			}
+			if low < f.min || high > f.max || low > high {
+				return nil, fmt.Errorf("%s '%s' is out of range %d-%d", f.name, part, f.min, f.max)
+			}
+		}
+		for value := low; value <= high; value += step {
+			set[value] = true
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first minute after after the schedule matches, or the
+// zero time if it matches none in the next five years (such as on
+// February 30)
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.month[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the schedule matches the day of t. As in
+// cron, when both day fields are restricted, either matching is enough.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom, dow := s.dom[t.Day()], s.dow[t.Weekday()]
+	switch {
+	case s.anyDOM && s.anyDOW:
+		return true
+	case s.anyDOM:
+		return dow
+	case s.anyDOW:
+		return dom
+	}
+	return dom || dow
+}
@@ -0,0 +1,63 @@
+package tasktemplate
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	tests := []struct {
+		spec, after, want string
+	}{
+		{"0 2 * * *", "2026-03-01 01:59", "2026-03-01 02:00"},
+		{"0 2 * * *", "2026-03-01 02:00", "2026-03-02 02:00"},
+		{"@nightly", "2026-03-01 10:30", "2026-03-02 02:00"},
+		{"*/15 * * * *", "2026-03-01 10:01", "2026-03-01 10:15"},
+		{"30 9 * * 1-5", "2026-03-06 10:00", "2026-03-09 09:30"}, // Friday to Monday
+		{"0 0 1,15 * *", "2026-03-02 00:00", "2026-03-15 00:00"},
+		{"0 12 * 6 7", "2026-03-01 00:00", "2026-06-07 12:00"}, // Sundays of June
+		{"0 0 13 * 5", "2026-03-01 00:00", "2026-03-06 00:00"}, // The 13th or a Friday
+		{"@monthly", "2026-12-15 08:00", "2027-01-01 00:00"},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) error = %v", tt.spec, err)
+		}
+		if got := schedule.Next(at(tt.after)); !got.Equal(at(tt.want)) {
+			t.Errorf("ParseSchedule(%q).Next(%s) = %s, want %s", tt.spec, tt.after, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+
+	schedule, _ := ParseSchedule("0 0 30 2 *")
+	if got := schedule.Next(at("2026-01-01 00:00")); !got.IsZero() {
+		t.Errorf("Expected no run on February 30, got %s", got)
+	}
+}
+
+func TestParseSchedule_Errors(t *testing.T) {
+	tests := []struct {
+		spec, want string
+	}{
+		{"0 2 * *", "want 5 fields"},
+		{"@yearly", "want 5 fields"},
+		{"60 * * * *", "minute '60' is out of range 0-59"},
+		{"0 5-3 * * *", "hour '5-3' is out of range"},
+		{"*/0 * * * *", "invalid step"},
+		{"0 x * * *", "invalid hour 'x'"},
+		{"0 0 0 * *", "day of month '0' is out of range 1-31"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseSchedule(tt.spec); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseSchedule(%q) error = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package tasktemplate keeps parameterized templates of beads tasks, such
+// as the tasks of a release checklist or a nightly triage, and creates
+// their tasks on demand or, for templates with a schedule, when they
+// fall due. Templates are JSON files in ~/.asc/templates/tasks, next to
+// the configuration templates of asc init.
+//
+// Example usage:
+//
+//	tmpl, err := tasktemplate.Load(dir, "release")
+//	if err != nil {
+//	    return err
+//	}
+//	tasks, err := tmpl.Render(map[string]string{"version": "1.4"}, time.Now())
+//	if err != nil {
+//	    return err // A required parameter is missing
+//	}
+//	created, err := bulk.CreateTasks(ctx, tasks)
+package tasktemplate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// Built-in parameters, set when a template is rendered
+const (
+	ParamDate = "date" // The day of creation, e.g. 2026-03-01
+	ParamTime = "time" // The time of creation, e.g. 02:00
+)
+
+// validName matches the names of templates, which are file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Template is a named set of tasks whose fields may refer to parameters
+// as {{.name}}
+type Template struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Params are the parameters of the template with their default
+	// values; an empty default makes the parameter required
+	Params map[string]string `json:"params,omitempty"`
+	Tasks  []beads.NewTask   `json:"tasks"`
+	// Schedule is the cron expression of when the tasks recur, if they do
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// Dir returns the directory task templates are kept in,
+// ~/.asc/templates/tasks
+func Dir() (string, error) {
+	return statedir.Path("templates", "tasks")
+}
+
+// Validate checks the name, tasks, and schedule of the template, and that
+// every parameter its tasks use is declared. Recurring templates are
+// created unattended, so their parameters need defaults.
+func (t *Template) Validate() error {
+	if !validName.MatchString(t.Name) {
+		return fmt.Errorf("invalid template name '%s': use letters, digits, '.', '_', and '-'", t.Name)
+	}
+	if len(t.Tasks) == 0 {
+		return fmt.Errorf("template %s has no tasks", t.Name)
+	}
+	for i, task := range t.Tasks {
+		if task.Priority != nil && (*task.Priority < 0 || *task.Priority > 4) {
+			return fmt.Errorf("template %s: task %d: priority must be 0 to 4, got %d", t.Name, i+1, *task.Priority)
+		}
+	}
+	for name := range t.Params {
+		if name == ParamDate || name == ParamTime {
+			return fmt.Errorf("template %s: parameter '%s' is built in", t.Name, name)
+		}
+	}
+	if t.Schedule != "" {
+		if _, err := ParseSchedule(t.Schedule); err != nil {
+			return fmt.Errorf("template %s: %w", t.Name, err)
+		}
+		for name, value := range t.Params {
+			if value == "" {
+				return fmt.Errorf("template %s recurs, so its parameter '%s' needs a default", t.Name, name)
+			}
+		}
+	}
+	// Render with every parameter set to catch bad syntax and undeclared
+	// parameters now rather than when the template is applied
+	values := make(map[string]string, len(t.Params))
+	for name := range t.Params {
+		values[name] = "x"
+	}
+	_, err := t.Render(values, time.Time{})
+	return err
+}
+
+// Render returns the tasks of the template with its parameters replaced
+// by values, or their defaults, and the built-in date and time of now
+func (t *Template) Render(values map[string]string, now time.Time) ([]beads.NewTask, error) {
+	data := map[string]string{ParamDate: now.Format("2006-01-02"), ParamTime: now.Format("15:04")}
+	for name, value := range t.Params {
+		data[name] = value
+	}
+	for name, value := range values {
+		if _, ok := t.Params[name]; !ok {
+			return nil, fmt.Errorf("template %s has no parameter '%s'%s", t.Name, name, t.paramList())
+		}
+		data[name] = value
+	}
+	var missing []string
+	for name := range t.Params {
+		if data[name] == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("template %s needs a value for %s (set --param %s=...)", t.Name, strings.Join(missing, ", "), missing[0])
+	}
+
+	render := func(field, text string) (string, error) {
+		if !strings.Contains(text, "{{") {
+			return text, nil
+		}
+		tmpl, err := template.New(field).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("template %s: invalid %s: %w", t.Name, field, err)
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, data); err != nil {
+			return "", fmt.Errorf("template %s: %s uses an undeclared parameter: %w", t.Name, field, err)
+		}
+		return out.String(), nil
+	}
+	tasks := make([]beads.NewTask, len(t.Tasks))
+	for i, spec := range t.Tasks {
+		task := spec
+		var err error
+		if task.Title, err = render("title", spec.Title); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(task.Title) == "" {
+			return nil, fmt.Errorf("template %s: task %d has no title", t.Name, i+1)
+		}
+		if task.Phase, err = render("phase", spec.Phase); err != nil {
+			return nil, err
+		}
+		if task.Assignee, err = render("assignee", spec.Assignee); err != nil {
+			return nil, err
+		}
+		task.Labels = nil
+		for _, label := range spec.Labels {
+			rendered, err := render("label", label)
+			if err != nil {
+				return nil, err
+			}
+			task.Labels = append(task.Labels, rendered)
+		}
+		tasks[i] = task
+	}
+	return tasks, nil
+}
+
+// paramList returns " (it has: a, b)" listing the template's parameters,
+// or "" if it has none
+func (t *Template) paramList() string {
+	if len(t.Params) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(t.Params))
+	for name := range t.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return " (it has: " + strings.Join(names, ", ") + ")"
+}
+
+// path returns the file of the template name in dir
+func path(dir, name string) string {
+	return filepath.Join(dir, name+".json")
+}
+
+// Save validates the template and writes it to dir, replacing the one of
+// the same name
+func Save(dir string, t *Template) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	return statefile.WriteJSON(path(dir, t.Name), t, 0600)
+}
+
+// Load reads the template name from dir
+func Load(dir, name string) (*Template, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid template name '%s'", name)
+	}
+	var t Template
+	if err := statefile.ReadJSON(path(dir, name), &t); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no task template named '%s' (see asc tasks template list)", name)
+		}
+		return nil, err
+	}
+	t.Name = name
+	return &t, nil
+}
+
+// List returns the templates in dir by name. Files that cannot be read
+// are skipped.
+func List(dir string) ([]*Template, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []*Template{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task templates: %w", err)
+	}
+	templates := []*Template{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || name == entry.Name() {
+			continue
+		}
+		if t, err := Load(dir, name); err == nil {
+			templates = append(templates, t)
+		}
+	}
+	return templates, nil
+}
+
+// Delete removes the template name from dir
+func Delete(dir, name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid template name '%s'", name)
+	}
+	if err := os.Remove(path(dir, name)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("no task template named '%s'", name)
+		}
+		return err
+	}
+	return nil
+}
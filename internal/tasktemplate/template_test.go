@@ -0,0 +1,184 @@
+package tasktemplate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/beads"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	priority := 1
+	tmpl := &Template{
+		Name:   "release",
+		Params: map[string]string{"version": "", "owner": "release-bot"},
+		Tasks: []beads.NewTask{
+			{Title: "Tag v{{.version}}", Priority: &priority, Labels: []string{"release", "v{{.version}}"}, Assignee: "{{.owner}}"},
+			{Title: "Announce v{{.version}} on {{.date}}", Phase: "review"},
+		},
+	}
+	if err := tmpl.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	tasks, err := tmpl.Render(map[string]string{"version": "1.4"}, now)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := []beads.NewTask{
+		{Title: "Tag v1.4", Priority: &priority, Labels: []string{"release", "v1.4"}, Assignee: "release-bot"},
+		{Title: "Announce v1.4 on 2026-03-01", Phase: "review"},
+	}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("Render() = %+v, want %+v", tasks, want)
+	}
+	if tmpl.Tasks[0].Labels[1] != "v{{.version}}" {
+		t.Error("Expected Render to leave the template unchanged")
+	}
+
+	if _, err := tmpl.Render(nil, now); err == nil || !strings.Contains(err.Error(), "needs a value for version") {
+		t.Errorf("Expected a missing parameter error, got %v", err)
+	}
+	if _, err := tmpl.Render(map[string]string{"version": "1.4", "colour": "red"}, now); err == nil || !strings.Contains(err.Error(), "no parameter 'colour' (it has: owner, version)") {
+		t.Errorf("Expected an unknown parameter error, got %v", err)
+	}
+}
+
+func TestTemplate_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl Template
+		want string
+	}{
+		{"bad name", Template{Name: "../x", Tasks: []beads.NewTask{{Title: "x"}}}, "invalid template name"},
+		{"no tasks", Template{Name: "x"}, "has no tasks"},
+		{"builtin", Template{Name: "x", Params: map[string]string{"date": "today"}, Tasks: []beads.NewTask{{Title: "x"}}}, "is built in"},
+		{"undeclared", Template{Name: "x", Tasks: []beads.NewTask{{Title: "Fix {{.area}}"}}}, "undeclared parameter"},
+		{"syntax", Template{Name: "x", Tasks: []beads.NewTask{{Title: "Fix {{.area"}}}, "invalid title"},
+		{"schedule", Template{Name: "x", Schedule: "nightly", Tasks: []beads.NewTask{{Title: "x"}}}, "invalid schedule"},
+		{"recurring required", Template{Name: "x", Schedule: "@daily", Params: map[string]string{"area": ""}, Tasks: []beads.NewTask{{Title: "{{.area}}"}}}, "needs a default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tmpl.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveLoadList(t *testing.T) {
+	dir := t.TempDir()
+	if templates, err := List(dir + "/missing"); err != nil || len(templates) != 0 {
+		t.Fatalf("List() of a missing directory = %v, %v", templates, err)
+	}
+	triage := &Template{Name: "triage", Schedule: "@nightly", Tasks: []beads.NewTask{{Title: "Triage flaky tests {{.date}}"}}}
+	release := &Template{Name: "release", Params: map[string]string{"version": ""}, Tasks: []beads.NewTask{{Title: "Tag v{{.version}}"}}}
+	for _, tmpl := range []*Template{triage, release} {
+		if err := Save(dir, tmpl); err != nil {
+			t.Fatalf("Save(%s) error = %v", tmpl.Name, err)
+		}
+	}
+	if err := Save(dir, &Template{Name: "empty"}); err == nil {
+		t.Error("Expected Save to reject an invalid template")
+	}
+
+	loaded, err := Load(dir, "release")
+	if err != nil || !reflect.DeepEqual(loaded, release) {
+		t.Errorf("Load() = %+v, %v, want %+v", loaded, err, release)
+	}
+	templates, err := List(dir)
+	if err != nil || len(templates) != 2 || templates[0].Name != "release" || templates[1].Name != "triage" {
+		t.Errorf("List() = %+v, %v", templates, err)
+	}
+
+	if err := Delete(dir, "release"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := Load(dir, "release"); err == nil || !strings.Contains(err.Error(), "no task template named 'release'") {
+		t.Errorf("Expected the deleted template to be gone, got %v", err)
+	}
+	if err := Delete(dir, "release"); err == nil {
+		t.Error("Expected an error deleting a missing template")
+	}
+}
+
+// fakeBulk records the tasks it is asked to create
+type fakeBulk struct {
+	created []beads.NewTask
+	err     error
+}
+
+func (f *fakeBulk) CreateTasks(ctx context.Context, tasks []beads.NewTask) ([]beads.Task, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var created []beads.Task
+	for _, task := range tasks {
+		f.created = append(f.created, task)
+		created = append(created, beads.Task{ID: "bd-" + string(rune('0'+len(f.created))), Title: task.Title, Status: "open"})
+	}
+	return created, nil
+}
+
+func (f *fakeBulk) UpdateTasks(ctx context.Context, updates []beads.BulkUpdate) error { return nil }
+func (f *fakeBulk) CloseTasks(ctx context.Context, ids []string) error                { return nil }
+
+func TestRunDue(t *testing.T) {
+	dir := t.TempDir()
+	for _, tmpl := range []*Template{
+		{Name: "triage", Schedule: "0 2 * * *", Tasks: []beads.NewTask{{Title: "Triage flaky tests {{.date}}"}}},
+		{Name: "release", Params: map[string]string{"version": ""}, Tasks: []beads.NewTask{{Title: "Tag v{{.version}}"}}},
+	} {
+		if err := Save(dir, tmpl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := &fakeBulk{}
+	ctx := context.Background()
+	day := func(d, hour, minute int) time.Time { return time.Date(2026, 3, d, hour, minute, 0, 0, time.Local) }
+
+	// A template first seen is not due until its schedule next passes
+	if runs, err := RunDue(ctx, client, dir, day(1, 1, 0)); err != nil || len(runs) != 0 {
+		t.Fatalf("RunDue() = %+v, %v, want no runs", runs, err)
+	}
+	if runs, _ := RunDue(ctx, client, dir, day(1, 1, 59)); len(runs) != 0 {
+		t.Fatalf("Expected no run before 02:00, got %+v", runs)
+	}
+	if pending, err := Pending(dir, day(1, 2, 0)); err != nil || len(pending) != 1 || pending[0].Tasks[0].Title != "Triage flaky tests 2026-03-01" {
+		t.Fatalf("Pending() = %+v, %v, want the 02:00 run of triage", pending, err)
+	}
+	runs, err := RunDue(ctx, client, dir, day(1, 2, 0))
+	if err != nil || len(runs) != 1 || runs[0].Template != "triage" || !runs[0].Due.Equal(day(1, 2, 0)) || runs[0].Err != nil {
+		t.Fatalf("RunDue() = %+v, %v, want the 02:00 run of triage", runs, err)
+	}
+	if len(runs[0].Tasks) != 1 || runs[0].Tasks[0].Title != "Triage flaky tests 2026-03-01" {
+		t.Errorf("Expected the rendered task, got %+v", runs[0].Tasks)
+	}
+	if pending, _ := Pending(dir, day(1, 2, 1)); len(pending) != 0 {
+		t.Errorf("Expected nothing pending after the run, got %+v", pending)
+	}
+	if runs, _ := RunDue(ctx, client, dir, day(1, 2, 1)); len(runs) != 0 {
+		t.Errorf("Expected the run not to repeat, got %+v", runs)
+	}
+
+	// Three missed nights are made up by one run
+	runs, _ = RunDue(ctx, client, dir, day(5, 9, 0))
+	if len(runs) != 1 || !runs[0].Due.Equal(day(5, 2, 0)) || len(client.created) != 2 {
+		t.Errorf("Expected one run for the missed nights, got %+v (%d created)", runs, len(client.created))
+	}
+
+	// A failed run is reported and not retried
+	client.err = errors.New("bd create failed")
+	runs, err = RunDue(ctx, client, dir, day(6, 2, 0))
+	if err != nil || len(runs) != 1 || runs[0].Err == nil {
+		t.Errorf("Expected the failed run to be reported, got %+v, %v", runs, err)
+	}
+	if runs, _ := RunDue(ctx, client, dir, day(6, 2, 5)); len(runs) != 0 {
+		t.Errorf("Expected the failed run not to be retried, got %+v", runs)
+	}
+}
@@ -0,0 +1,58 @@
+package telemetry
+
+import (
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// LogSink returns a logger.Sink that exports asc's own log records as OTLP
+// logs, in the trace of the command that wrote them. Closing the sink does
+// not close the exporter.
+func (e *Exporter) LogSink() logger.Sink {
+	return &logSink{exporter: e}
+}
+
+// logSink adapts an Exporter to the logger.Sink interface
+type logSink struct {
+	exporter *Exporter
+}
+
+func (k *logSink) Write(entry logger.LogEntry) error {
+	ts, err := time.ParseInLocation(logger.TimestampLayout, entry.Timestamp, time.Local)
+	if err != nil {
+		ts = time.Now()
+	}
+
+	attrs := make(Attrs, len(entry.Fields)+6)
+	for k, v := range entry.Fields {
+		attrs[k] = v
+	}
+	for k, v := range map[string]string{
+		"correlation_id": entry.CorrelationID,
+		"component":      entry.Component,
+		"caller":         entry.Caller,
+		"agent":          entry.Agent,
+		"task":           entry.Task,
+		"phase":          entry.Phase,
+	} {
+		if v != "" {
+			attrs[k] = v
+		}
+	}
+
+	traceID, spanID := commandSpan().IDs()
+	k.exporter.AddLog(LogData{
+		Time:       ts,
+		Severity:   entry.Level,
+		Body:       entry.Message,
+		Attributes: attrs,
+		TraceID:    traceID,
+		SpanID:     spanID,
+	})
+	return nil
+}
+
+func (k *logSink) Close() error {
+	return nil
+}
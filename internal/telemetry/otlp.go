@@ -0,0 +1,497 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default batching settings
+const (
+	DefaultEndpoint      = "http://localhost:4318"
+	DefaultServiceName   = "asc"
+	DefaultBatchSize     = 256
+	DefaultFlushInterval = 5 * time.Second
+	maxBufferedBatches   = 10
+)
+
+// Options configures an Exporter
+type Options struct {
+	Endpoint      string            // Base URL of the OTLP/HTTP receiver (default: DefaultEndpoint)
+	ServiceName   string            // service.name of the resource (default: "asc")
+	Resource      Attrs             // Further resource attributes, e.g. the workspace
+	Headers       map[string]string // Headers sent with every export, e.g. an API key
+	BatchSize     int               // Spans or log records per export (default: 256)
+	FlushInterval time.Duration     // Maximum time a span waits before it is exported (default: 5s)
+	HTTPClient    *http.Client      // Optional HTTP client (default: 10s timeout)
+}
+
+// LogData is a log record, as exported
+type LogData struct {
+	Time       time.Time
+	Severity   string // TRACE, DEBUG, INFO, WARN, or ERROR
+	Body       string
+	Attributes Attrs
+	TraceID    TraceID // The span the record was written in, if known
+	SpanID     SpanID
+}
+
+// Stats reports export activity
+type Stats struct {
+	Spans     int // Spans exported
+	Logs      int // Log records exported
+	Dropped   int
+	Failures  int
+	LastError error
+}
+
+// Exporter batches spans and log records and sends them to an OTLP/HTTP
+// receiver, at /v1/traces and /v1/logs. It never blocks the caller: when
+// the receiver is unreachable and the buffer is full, the oldest are
+// dropped and counted in Stats.
+type Exporter struct {
+	mu            sync.Mutex
+	endpoint      string
+	resource      Attrs
+	headers       map[string]string
+	client        *http.Client
+	batchSize     int
+	flushInterval time.Duration
+	spans         []SpanData
+	logs          []LogData
+	stats         Stats
+
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	closed  bool
+}
+
+// NewExporter creates an Exporter and starts its flush loop
+func NewExporter(opts Options) (*Exporter, error) {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("OTLP endpoint '%s' must be an http:// or https:// URL", endpoint)
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	flushInterval := opts.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = DefaultFlushInterval
+	}
+
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	resource := Attrs{"service.name": serviceName, "process.pid": os.Getpid()}
+	if host, err := os.Hostname(); err == nil {
+		resource["host.name"] = host
+	}
+	for k, v := range opts.Resource {
+		resource[k] = v
+	}
+
+	e := &Exporter{
+		endpoint:      strings.TrimRight(endpoint, "/"),
+		resource:      resource,
+		headers:       opts.Headers,
+		client:        client,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		flushCh:       make(chan struct{}, 1),
+		stopCh:        make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e, nil
+}
+
+// AddSpan queues an ended span for export
+func (e *Exporter) AddSpan(data SpanData) {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.spans = append(e.spans, data)
+	if overflow := len(e.spans) - e.batchSize*maxBufferedBatches; overflow > 0 {
+		e.spans = e.spans[overflow:]
+		e.stats.Dropped += overflow
+	}
+	full := len(e.spans) >= e.batchSize
+	e.mu.Unlock()
+	if full {
+		e.signalFlush()
+	}
+}
+
+// AddLog queues a log record for export
+func (e *Exporter) AddLog(data LogData) {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.logs = append(e.logs, data)
+	if overflow := len(e.logs) - e.batchSize*maxBufferedBatches; overflow > 0 {
+		e.logs = e.logs[overflow:]
+		e.stats.Dropped += overflow
+	}
+	full := len(e.logs) >= e.batchSize
+	e.mu.Unlock()
+	if full {
+		e.signalFlush()
+	}
+}
+
+func (e *Exporter) signalFlush() {
+	select {
+	case e.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+// Flush exports everything queued now. Spans and records that fail to
+// export are kept for the next attempt.
+func (e *Exporter) Flush() error {
+	var lastErr error
+	for {
+		e.mu.Lock()
+		spans := e.spans[:min(len(e.spans), e.batchSize)]
+		logs := e.logs[:min(len(e.logs), e.batchSize)]
+		e.mu.Unlock()
+		if len(spans) == 0 && len(logs) == 0 {
+			return lastErr
+		}
+
+		var spanErr, logErr error
+		if len(spans) > 0 {
+			spanErr = e.post("/v1/traces", encodeSpans(e.resource, spans))
+		}
+		if len(logs) > 0 {
+			logErr = e.post("/v1/logs", encodeLogs(e.resource, logs))
+		}
+
+		e.mu.Lock()
+		if spanErr == nil {
+			e.spans = e.spans[len(spans):]
+			e.stats.Spans += len(spans)
+		}
+		if logErr == nil {
+			e.logs = e.logs[len(logs):]
+			e.stats.Logs += len(logs)
+		}
+		for _, err := range []error{spanErr, logErr} {
+			if err != nil {
+				e.stats.Failures++
+				e.stats.LastError = err
+				lastErr = err
+			}
+		}
+		e.mu.Unlock()
+		if lastErr != nil {
+			return lastErr
+		}
+	}
+}
+
+// Stats returns a snapshot of export counters
+func (e *Exporter) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
+
+// Close stops the flush loop and exports what is left
+func (e *Exporter) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	e.mu.Unlock()
+
+	close(e.stopCh)
+	e.wg.Wait()
+	return e.Flush()
+}
+
+// run flushes on a timer or when a batch fills up
+func (e *Exporter) run() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stopCh:
+			return
+		case <-ticker.C:
+			e.Flush()
+		case <-e.flushCh:
+			e.Flush()
+		}
+	}
+}
+
+// post sends an OTLP JSON request body to path
+func (e *Exporter) post(path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode OTLP request: %w", err)
+	}
+	req, err := http.NewRequest("POST", e.endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OTLP export failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("OTLP export to %s failed: HTTP %d: %s", path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// The OTLP JSON encoding: IDs in hex, 64-bit integers as strings, and
+// attributes as a list of typed values
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpTraces struct {
+	ResourceSpans []struct {
+		Resource   otlpResource `json:"resource"`
+		ScopeSpans []struct {
+			Scope otlpScope  `json:"scope"`
+			Spans []otlpSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpValue      `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+	TraceID        string         `json:"traceId,omitempty"`
+	SpanID         string         `json:"spanId,omitempty"`
+}
+
+type otlpLogs struct {
+	ResourceLogs []struct {
+		Resource  otlpResource `json:"resource"`
+		ScopeLogs []struct {
+			Scope      otlpScope       `json:"scope"`
+			LogRecords []otlpLogRecord `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+// OTLP status codes
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// encodeSpans builds the body of an export to /v1/traces
+func encodeSpans(resource Attrs, spans []SpanData) *otlpTraces {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              int(s.Kind),
+			StartTimeUnixNano: unixNano(s.Start),
+			EndTimeUnixNano:   unixNano(s.End),
+			Attributes:        encodeAttrs(s.Attributes),
+			Status:            otlpStatus{Code: statusUnset},
+		}
+		if s.ParentID != (SpanID{}) {
+			span.ParentSpanID = s.ParentID.String()
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: statusError, Message: s.Error}
+		}
+		encoded = append(encoded, span)
+	}
+
+	var body otlpTraces
+	body.ResourceSpans = make([]struct {
+		Resource   otlpResource `json:"resource"`
+		ScopeSpans []struct {
+			Scope otlpScope  `json:"scope"`
+			Spans []otlpSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	}, 1)
+	body.ResourceSpans[0].Resource = otlpResource{Attributes: encodeAttrs(resource)}
+	body.ResourceSpans[0].ScopeSpans = []struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}{{Scope: otlpScope{Name: DefaultServiceName}, Spans: encoded}}
+	return &body
+}
+
+// encodeLogs builds the body of an export to /v1/logs
+func encodeLogs(resource Attrs, logs []LogData) *otlpLogs {
+	encoded := make([]otlpLogRecord, 0, len(logs))
+	for _, l := range logs {
+		body := l.Body
+		record := otlpLogRecord{
+			TimeUnixNano:   unixNano(l.Time),
+			SeverityNumber: severityNumber(l.Severity),
+			SeverityText:   l.Severity,
+			Body:           otlpValue{StringValue: &body},
+			Attributes:     encodeAttrs(l.Attributes),
+		}
+		if l.TraceID != (TraceID{}) {
+			record.TraceID = l.TraceID.String()
+			record.SpanID = l.SpanID.String()
+		}
+		encoded = append(encoded, record)
+	}
+
+	var body otlpLogs
+	body.ResourceLogs = make([]struct {
+		Resource  otlpResource `json:"resource"`
+		ScopeLogs []struct {
+			Scope      otlpScope       `json:"scope"`
+			LogRecords []otlpLogRecord `json:"logRecords"`
+		} `json:"scopeLogs"`
+	}, 1)
+	body.ResourceLogs[0].Resource = otlpResource{Attributes: encodeAttrs(resource)}
+	body.ResourceLogs[0].ScopeLogs = []struct {
+		Scope      otlpScope       `json:"scope"`
+		LogRecords []otlpLogRecord `json:"logRecords"`
+	}{{Scope: otlpScope{Name: DefaultServiceName}, LogRecords: encoded}}
+	return &body
+}
+
+// severityNumber maps a log level to the OTLP severity number of its range
+func severityNumber(level string) int {
+	switch strings.ToUpper(level) {
+	case "TRACE":
+		return 1
+	case "DEBUG":
+		return 5
+	case "WARN", "WARNING":
+		return 13
+	case "ERROR":
+		return 17
+	default:
+		return 9
+	}
+}
+
+// encodeAttrs converts attributes to OTLP key-values, sorted by key.
+// Values other than strings, booleans, and numbers are formatted as
+// strings.
+func encodeAttrs(attrs Attrs) []otlpKeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	encoded := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		encoded = append(encoded, otlpKeyValue{Key: k, Value: encodeValue(attrs[k])})
+	}
+	return encoded
+}
+
+// encodeValue converts a value to an OTLP typed value
+func encodeValue(v interface{}) otlpValue {
+	switch v := v.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int:
+		s := strconv.FormatInt(int64(v), 10)
+		return otlpValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpValue{IntValue: &s}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	case time.Duration:
+		s := strconv.FormatInt(v.Milliseconds(), 10)
+		return otlpValue{IntValue: &s}
+	case []string:
+		s := strings.Join(v, " ")
+		return otlpValue{StringValue: &s}
+	default:
+		s := fmt.Sprintf("%v", v)
+		return otlpValue{StringValue: &s}
+	}
+}
+
+// unixNano formats a time as OTLP expects, nanoseconds since the epoch as
+// a string
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
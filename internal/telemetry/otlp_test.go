@@ -0,0 +1,256 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// collector is an OTLP/HTTP receiver that keeps what it receives
+type collector struct {
+	mu      sync.Mutex
+	traces  []map[string]interface{}
+	logs    []map[string]interface{}
+	headers http.Header
+	status  int
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	t.Helper()
+	c := &collector{status: http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.headers = r.Header.Clone()
+		if c.status != http.StatusOK {
+			http.Error(w, "unavailable", c.status)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("Invalid OTLP JSON: %v", err)
+		}
+		switch r.URL.Path {
+		case "/v1/traces":
+			c.traces = append(c.traces, body)
+		case "/v1/logs":
+			c.logs = append(c.logs, body)
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+// path follows keys and list indexes through decoded JSON
+func path(v interface{}, keys ...interface{}) interface{} {
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			m, _ := v.(map[string]interface{})
+			v = m[k]
+		case int:
+			l, _ := v.([]interface{})
+			if k >= len(l) {
+				return nil
+			}
+			v = l[k]
+		}
+	}
+	return v
+}
+
+// attr returns the value of the OTLP attribute key in a list of attributes
+func attr(attrs interface{}, key string) map[string]interface{} {
+	list, _ := attrs.([]interface{})
+	for _, a := range list {
+		if path(a, "key") == key {
+			value, _ := path(a, "value").(map[string]interface{})
+			return value
+		}
+	}
+	return nil
+}
+
+func TestExporterSpans(t *testing.T) {
+	reset(t)
+	c, server := newCollector(t)
+
+	exporter, err := NewExporter(Options{
+		Endpoint:      server.URL + "/",
+		ServiceName:   "asc-test",
+		Resource:      Attrs{"asc.workspace": "demo"},
+		Headers:       map[string]string{"Authorization": "Bearer secret"},
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewExporter failed: %v", err)
+	}
+
+	// Spans ended before the exporter was configured are not lost
+	ctx, root := StartCommand(context.Background(), "asc up", nil)
+	root.End()
+	Configure(exporter)
+	_, span := StartKind(ctx, "mcp.request", KindClient, Attrs{"http.method": "GET", "retries": 2, "ok": true, "ratio": 0.5})
+	span.SetError(errors.New("connection refused"))
+	span.End()
+
+	if err := Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if stats := exporter.Stats(); stats.Spans != 2 || stats.Failures != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.headers.Get("Authorization") != "Bearer secret" || c.headers.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected headers %v", c.headers)
+	}
+	if len(c.traces) != 1 {
+		t.Fatalf("Expected 1 export, got %d", len(c.traces))
+	}
+	resource := path(c.traces[0], "resourceSpans", 0, "resource", "attributes")
+	if v := attr(resource, "service.name"); v["stringValue"] != "asc-test" {
+		t.Errorf("Unexpected service.name %v", v)
+	}
+	if v := attr(resource, "asc.workspace"); v["stringValue"] != "demo" {
+		t.Errorf("Unexpected asc.workspace %v", v)
+	}
+	spans, _ := path(c.traces[0], "resourceSpans", 0, "scopeSpans", 0, "spans").([]interface{})
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	traceID, rootID := root.IDs()
+	request := spans[1]
+	if path(request, "traceId") != traceID.String() || path(request, "parentSpanId") != rootID.String() {
+		t.Errorf("Expected the request span in the command's trace, got %v", request)
+	}
+	if path(request, "kind") != float64(KindClient) || path(request, "status", "code") != float64(statusError) ||
+		path(request, "status", "message") != "connection refused" {
+		t.Errorf("Unexpected span %v", request)
+	}
+	attrs := path(request, "attributes")
+	if attr(attrs, "http.method")["stringValue"] != "GET" || attr(attrs, "retries")["intValue"] != "2" ||
+		attr(attrs, "ok")["boolValue"] != true || attr(attrs, "ratio")["doubleValue"] != 0.5 {
+		t.Errorf("Unexpected attributes %v", attrs)
+	}
+	if _, ok := path(spans[0], "startTimeUnixNano").(string); !ok {
+		t.Error("Expected times as strings")
+	}
+	if path(spans[0], "parentSpanId") != nil || path(spans[0], "status", "code") != float64(statusUnset) {
+		t.Errorf("Unexpected root span %v", spans[0])
+	}
+}
+
+func TestExporterRetainsSpansOnFailure(t *testing.T) {
+	c, server := newCollector(t)
+	c.status = http.StatusServiceUnavailable
+
+	exporter, err := NewExporter(Options{Endpoint: server.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewExporter failed: %v", err)
+	}
+	defer exporter.Close()
+	exporter.AddSpan(SpanData{Name: "span", Start: time.Now(), End: time.Now()})
+
+	if err := exporter.Flush(); err == nil || !strings.Contains(err.Error(), "HTTP 503") {
+		t.Fatalf("Expected an HTTP 503 error, got %v", err)
+	}
+	if stats := exporter.Stats(); stats.Failures != 1 || stats.Spans != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	c.mu.Lock()
+	c.status = http.StatusOK
+	c.mu.Unlock()
+	if err := exporter.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if stats := exporter.Stats(); stats.Spans != 1 {
+		t.Errorf("Expected the span to be exported on retry, got %+v", stats)
+	}
+}
+
+func TestExporterDropsOldest(t *testing.T) {
+	exporter, err := NewExporter(Options{Endpoint: "http://127.0.0.1:1", BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewExporter failed: %v", err)
+	}
+	defer exporter.Close()
+
+	// Nothing listens on the endpoint, so spans pile up
+	for i := 0; i < 2*maxBufferedBatches+5; i++ {
+		exporter.AddSpan(SpanData{Name: "span"})
+	}
+	if stats := exporter.Stats(); stats.Dropped != 5 {
+		t.Errorf("Expected 5 dropped spans, got %+v", stats)
+	}
+}
+
+func TestNewExporterInvalidEndpoint(t *testing.T) {
+	if _, err := NewExporter(Options{Endpoint: "localhost:4318"}); err == nil {
+		t.Error("Expected an error for an endpoint without a scheme")
+	}
+}
+
+func TestLogSink(t *testing.T) {
+	reset(t)
+	c, server := newCollector(t)
+
+	exporter, err := NewExporter(Options{Endpoint: server.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewExporter failed: %v", err)
+	}
+	_, root := StartCommand(context.Background(), "asc up", nil)
+
+	sink := exporter.LogSink()
+	sink.Write(logger.LogEntry{
+		Timestamp: "2026-03-01 12:00:00.000",
+		Level:     "WARN",
+		Message:   "Agent coder crashed",
+		Component: "process",
+		Agent:     "coder",
+		Fields:    map[string]interface{}{"pid": 4242},
+	})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("Exporter Close failed: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.logs) != 1 {
+		t.Fatalf("Expected 1 log export, got %d", len(c.logs))
+	}
+	record := path(c.logs[0], "resourceLogs", 0, "scopeLogs", 0, "logRecords", 0)
+	traceID, spanID := root.IDs()
+	if path(record, "traceId") != traceID.String() || path(record, "spanId") != spanID.String() {
+		t.Errorf("Expected the record in the command's trace, got %v", record)
+	}
+	if path(record, "severityNumber") != float64(13) || path(record, "severityText") != "WARN" ||
+		path(record, "body", "stringValue") != "Agent coder crashed" {
+		t.Errorf("Unexpected record %v", record)
+	}
+	ts, _ := time.ParseInLocation(logger.TimestampLayout, "2026-03-01 12:00:00.000", time.Local)
+	if path(record, "timeUnixNano") != unixNano(ts) {
+		t.Errorf("Expected the entry's time, got %v", path(record, "timeUnixNano"))
+	}
+	attrs := path(record, "attributes")
+	if attr(attrs, "agent")["stringValue"] != "coder" || attr(attrs, "pid")["intValue"] != "4242" {
+		t.Errorf("Unexpected attributes %v", attrs)
+	}
+}
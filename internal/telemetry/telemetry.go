@@ -0,0 +1,318 @@
+// Package telemetry traces what asc does as OpenTelemetry spans, so time
+// spent in asc up and agent failures show up in an existing observability
+// stack next to the services asc talks to.
+//
+// Spans are exported over OTLP/HTTP in its JSON encoding by an Exporter,
+// which batches them like logship batches log records. Until an Exporter is
+// configured, ended spans are held back (up to a limit), so the spans of a
+// command that ran before asc.toml was loaded are not lost. A process runs
+// one asc command, whose span (see StartCommand) is the parent of spans
+// started without one in their context.
+//
+// Example usage:
+//
+//	ctx, span := telemetry.Start(ctx, "beads.list", telemetry.Attrs{"beads.db_path": path})
+//	defer span.End()
+//	if err := run(ctx); err != nil {
+//	    span.SetError(err)
+//	}
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentEnvVar passes the current span to child processes, as the
+// W3C traceparent of the environment carriers of OpenTelemetry
+const TraceparentEnvVar = "TRACEPARENT"
+
+// TraceparentHeader carries the current span on HTTP requests
+const TraceparentHeader = "traceparent"
+
+// maxPendingSpans bounds the spans held back until an Exporter is configured
+const maxPendingSpans = 1000
+
+// Attrs are the attributes of a span, e.g. "process.pid"
+type Attrs map[string]interface{}
+
+// SpanKind says what a span stands for
+type SpanKind int
+
+// Span kinds, numbered as in OTLP
+const (
+	KindInternal SpanKind = 1 // Work within asc
+	KindClient   SpanKind = 3 // A request to another service, e.g. MCP or bd
+)
+
+// TraceID and SpanID identify spans, as in W3C trace context
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+// String returns the trace ID in hex
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// String returns the span ID in hex
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// SpanData is an ended span, as exported
+type SpanData struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	ParentID   SpanID // Zero for the root of a trace
+	Name       string
+	Kind       SpanKind
+	Start      time.Time
+	End        time.Time
+	Attributes Attrs
+	Error      string // Why the span failed, "" if it did not
+}
+
+// Span is an operation in progress. Its methods are safe for concurrent
+// use and do nothing on a nil Span.
+type Span struct {
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// spanKey is the context key of the current span
+type spanKey struct{}
+
+var (
+	mu       sync.Mutex
+	exporter *Exporter
+	pending  []SpanData
+	command  *Span
+)
+
+// Start starts a span named name, a child of the span in ctx or else of
+// the command's span, and returns a context carrying it
+func Start(ctx context.Context, name string, attrs Attrs) (context.Context, *Span) {
+	return StartKind(ctx, name, KindInternal, attrs)
+}
+
+// StartKind is Start for a span of the given kind
+func StartKind(ctx context.Context, name string, kind SpanKind, attrs Attrs) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := FromContext(ctx)
+	if parent == nil {
+		parent = commandSpan()
+	}
+	span := newSpan(name, kind, attrs)
+	if parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentID = parent.data.SpanID
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// StartCommand starts the span of the asc command this process runs, the
+// parent of spans started without one. It continues the trace of the
+// TRACEPARENT environment variable, set when an agent runs asc.
+func StartCommand(ctx context.Context, name string, attrs Attrs) (context.Context, *Span) {
+	span := newSpan(name, KindInternal, attrs)
+	if traceID, parentID, ok := ParseTraceparent(os.Getenv(TraceparentEnvVar)); ok {
+		span.data.TraceID = traceID
+		span.data.ParentID = parentID
+	}
+	mu.Lock()
+	command = span
+	mu.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// commandSpan returns the span of the command this process runs, or nil
+func commandSpan() *Span {
+	mu.Lock()
+	defer mu.Unlock()
+	return command
+}
+
+// FromContext returns the span ctx carries, or nil
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Current returns the span ctx carries, or else the command's span, or nil
+func Current(ctx context.Context) *Span {
+	if span := FromContext(ctx); span != nil {
+		return span
+	}
+	return commandSpan()
+}
+
+// newSpan starts a span of a new trace
+func newSpan(name string, kind SpanKind, attrs Attrs) *Span {
+	span := &Span{data: SpanData{Name: name, Kind: kind, Start: time.Now(), Attributes: Attrs{}}}
+	rand.Read(span.data.TraceID[:])
+	rand.Read(span.data.SpanID[:])
+	for k, v := range attrs {
+		span.data.Attributes[k] = v
+	}
+	return span
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs Attrs) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range attrs {
+		s.data.Attributes[k] = v
+	}
+}
+
+// SetError marks the span failed with err. A nil err does nothing.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Error = err.Error()
+}
+
+// End ends the span and hands it to the exporter. Only the first call
+// counts.
+func (s *Span) End() {
+	s.EndAt(time.Now())
+}
+
+// EndAt ends the span at the given time, for operations that were only
+// seen to end afterwards, such as a process that was reaped
+func (s *Span) EndAt(end time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = end
+	data := s.data
+	data.Attributes = make(Attrs, len(s.data.Attributes))
+	for k, v := range s.data.Attributes {
+		data.Attributes[k] = v
+	}
+	s.mu.Unlock()
+	export(data)
+}
+
+// Traceparent returns the W3C traceparent of the span, or "" for nil
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", s.data.TraceID, s.data.SpanID)
+}
+
+// IDs returns the trace and span IDs of the span
+func (s *Span) IDs() (TraceID, SpanID) {
+	if s == nil {
+		return TraceID{}, SpanID{}
+	}
+	return s.data.TraceID, s.data.SpanID
+}
+
+// Inject sets the traceparent header of an HTTP request to the span in
+// ctx, or the command's span
+func Inject(ctx context.Context, header http.Header) {
+	if span := Current(ctx); span != nil {
+		header.Set(TraceparentHeader, span.Traceparent())
+	}
+}
+
+// Env returns the TRACEPARENT environment variable for a child process
+// started within the span in ctx, or the command's span, or nil
+func Env(ctx context.Context) []string {
+	if span := Current(ctx); span != nil {
+		return []string{TraceparentEnvVar + "=" + span.Traceparent()}
+	}
+	return nil
+}
+
+// ParseTraceparent parses a W3C traceparent, "00-<trace ID>-<span ID>-<flags>"
+func ParseTraceparent(value string) (TraceID, SpanID, bool) {
+	var traceID TraceID
+	var spanID SpanID
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return traceID, spanID, false
+	}
+	if n, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || n != len(traceID) || len(parts[1]) != 32 {
+		return TraceID{}, SpanID{}, false
+	}
+	if n, err := hex.Decode(spanID[:], []byte(parts[2])); err != nil || n != len(spanID) || len(parts[2]) != 16 {
+		return TraceID{}, SpanID{}, false
+	}
+	if traceID == (TraceID{}) || spanID == (SpanID{}) {
+		return TraceID{}, SpanID{}, false
+	}
+	return traceID, spanID, true
+}
+
+// export hands an ended span to the exporter, or holds it back until one
+// is configured
+func export(data SpanData) {
+	mu.Lock()
+	e := exporter
+	if e == nil {
+		if len(pending) < maxPendingSpans {
+			pending = append(pending, data)
+		}
+		mu.Unlock()
+		return
+	}
+	mu.Unlock()
+	e.AddSpan(data)
+}
+
+// Configure makes e the exporter of ended spans, handing it those held
+// back so far
+func Configure(e *Exporter) {
+	mu.Lock()
+	exporter = e
+	held := pending
+	pending = nil
+	mu.Unlock()
+	for _, data := range held {
+		e.AddSpan(data)
+	}
+}
+
+// Shutdown closes the configured exporter, if any, flushing what it holds,
+// and drops the spans held back for one
+func Shutdown() error {
+	mu.Lock()
+	e := exporter
+	exporter = nil
+	pending = nil
+	mu.Unlock()
+	if e == nil {
+		return nil
+	}
+	return e.Close()
+}
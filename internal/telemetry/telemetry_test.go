@@ -0,0 +1,164 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// reset clears the global command span and exporter
+func reset(t *testing.T) {
+	t.Helper()
+	Shutdown()
+	mu.Lock()
+	command = nil
+	mu.Unlock()
+	t.Cleanup(func() {
+		Shutdown()
+		mu.Lock()
+		command = nil
+		mu.Unlock()
+	})
+}
+
+// heldSpans returns the spans held back for an exporter
+func heldSpans() []SpanData {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]SpanData(nil), pending...)
+}
+
+func TestSpanParents(t *testing.T) {
+	reset(t)
+	t.Setenv(TraceparentEnvVar, "")
+
+	ctx, root := StartCommand(context.Background(), "asc up", nil)
+	_, orphan := Start(context.Background(), "process.start", Attrs{"process.name": "coder"})
+	childCtx, child := Start(ctx, "up.launch_agents", nil)
+	_, grandchild := Start(childCtx, "beads.list", nil)
+	grandchild.SetError(errors.New("bd failed"))
+
+	grandchild.End()
+	child.End()
+	orphan.End()
+	root.End()
+	root.End()
+
+	spans := heldSpans()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans held back, got %d", len(spans))
+	}
+	rootTrace, rootID := root.IDs()
+	_, childID := child.IDs()
+	byName := map[string]SpanData{}
+	for _, s := range spans {
+		if s.TraceID != rootTrace {
+			t.Errorf("Span %s is not in the command's trace", s.Name)
+		}
+		byName[s.Name] = s
+	}
+	if byName["asc up"].ParentID != (SpanID{}) {
+		t.Error("Expected the command span to be a root")
+	}
+	if byName["process.start"].ParentID != rootID || byName["up.launch_agents"].ParentID != rootID {
+		t.Error("Expected spans without a parent in their context to be children of the command span")
+	}
+	if byName["beads.list"].ParentID != childID {
+		t.Error("Expected a span to be the child of the span in its context")
+	}
+	if byName["beads.list"].Error != "bd failed" || byName["up.launch_agents"].Error != "" {
+		t.Error("Expected only the failed span to carry an error")
+	}
+	if byName["process.start"].Attributes["process.name"] != "coder" {
+		t.Errorf("Expected attributes, got %v", byName["process.start"].Attributes)
+	}
+}
+
+func TestStartCommandContinuesTrace(t *testing.T) {
+	reset(t)
+	t.Setenv(TraceparentEnvVar, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	_, span := StartCommand(context.Background(), "asc check", nil)
+	traceID, _ := span.IDs()
+	if traceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the inherited trace, got %s", traceID)
+	}
+	span.End()
+	if parent := heldSpans()[0].ParentID.String(); parent != "00f067aa0ba902b7" {
+		t.Errorf("Expected the inherited parent, got %s", parent)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	reset(t)
+
+	header := http.Header{}
+	Inject(context.Background(), header)
+	if header.Get(TraceparentHeader) != "" || Env(context.Background()) != nil {
+		t.Error("Expected nothing to propagate without a span")
+	}
+
+	ctx, span := Start(context.Background(), "mcp.request", nil)
+	Inject(ctx, header)
+	want := span.Traceparent()
+	if header.Get(TraceparentHeader) != want {
+		t.Errorf("traceparent = %q, want %q", header.Get(TraceparentHeader), want)
+	}
+	if env := Env(ctx); len(env) != 1 || env[0] != TraceparentEnvVar+"="+want {
+		t.Errorf("Unexpected environment %v", env)
+	}
+
+	traceID, spanID, ok := ParseTraceparent(want)
+	if wantTrace, wantSpan := span.IDs(); !ok || traceID != wantTrace || spanID != wantSpan {
+		t.Errorf("ParseTraceparent(%q) did not round-trip", want)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{" 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00 ", true},
+		{"", false},
+		{"garbage", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-zzf067aa0ba902b7-01", false},
+	}
+	for _, tt := range tests {
+		if _, _, ok := ParseTraceparent(tt.value); ok != tt.ok {
+			t.Errorf("ParseTraceparent(%q) ok = %v, want %v", tt.value, ok, tt.ok)
+		}
+	}
+}
+
+func TestPendingSpansBounded(t *testing.T) {
+	reset(t)
+
+	for i := 0; i < maxPendingSpans+10; i++ {
+		_, span := Start(context.Background(), "span", nil)
+		span.End()
+	}
+	if n := len(heldSpans()); n != maxPendingSpans {
+		t.Errorf("Expected %d spans held back, got %d", maxPendingSpans, n)
+	}
+	Shutdown()
+	if n := len(heldSpans()); n != 0 {
+		t.Errorf("Expected Shutdown to drop held spans, got %d", n)
+	}
+}
+
+func TestNilSpan(t *testing.T) {
+	var span *Span
+	span.SetAttributes(Attrs{"k": "v"})
+	span.SetError(errors.New("failed"))
+	span.End()
+	if span.Traceparent() != "" {
+		t.Error("Expected no traceparent for a nil span")
+	}
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/rand/asc/internal/i18n"
 	"github.com/rand/asc/internal/mcp"
+	"github.com/rand/asc/internal/process"
 )
 
 // Agent status icons
@@ -53,6 +55,12 @@ func (m Model) renderAgentPane(width, height int) string {
 			healthIssueMap[issue.AgentName] = string(issue.Type)
 		}
 	}
+	// Failing health checks show unless the monitor found something worse
+	for name, status := range m.processHealth {
+		if _, exists := healthIssueMap[name]; !exists && (status == process.HealthDegraded || status == process.HealthUnhealthy) {
+			healthIssueMap[name] = string(status)
+		}
+	}
 	
 	// Get sorted agent names for consistent ordering
 	agentNames := m.getAgentNames()
@@ -73,9 +81,14 @@ func (m Model) renderAgentPane(width, height int) string {
 		lines = append(lines, line)
 	}
 	
+	// Until the first status arrives every agent would read as offline
+	if !m.agentsLoaded && len(m.agents) == 0 && len(lines) > 0 {
+		lines = []string{styleOffline.Render(i18n.T("tui.agents.loading"))}
+	}
+
 	// If no agents configured, show a message
 	if len(lines) == 0 {
-		lines = append(lines, styleOffline.Render("No agents configured"))
+		lines = append(lines, styleOffline.Render(i18n.T("tui.agents.none")))
 	}
 	
 	// Pad or truncate to fit height
@@ -92,11 +105,20 @@ func (m Model) renderAgentPane(width, height int) string {
 	}
 	
 	// Add keybindings hint
-	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("1-9:select p:pause k:kill R:restart l:logs")
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(i18n.T("tui.agents.hint"))
+	
+	title := i18n.T("tui.agents.title")
+	if m.pipeline != nil {
+		if status := m.pipeline.Status(); status.Finished {
+			title += i18n.T("tui.agents.pipeline_complete")
+		} else if status.Phase != "" {
+			title += i18n.T("tui.agents.phase", status.Phase)
+		}
+	}
 	
 	header := lipgloss.JoinVertical(
 		lipgloss.Left,
-		lipgloss.NewStyle().Bold(true).Render("Agent Status"),
+		lipgloss.NewStyle().Bold(true).Render(title),
 		hint,
 	)
 	
@@ -131,6 +153,11 @@ func (m Model) formatAgentLine(status mcp.AgentStatus, healthIssue string, maxWi
 			style = styleError // Override with error style
 		case "stuck":
 			healthIndicator = " ⏱"
+		case "unhealthy":
+			healthIndicator = " ⚠"
+			style = styleError // Override with error style
+		case "degraded":
+			healthIndicator = " ◐"
 		}
 	}
 	
@@ -153,6 +180,11 @@ func (m Model) formatAgentLine(status mcp.AgentStatus, healthIssue string, maxWi
 		statusText = "Unknown"
 	}
 	
+	// Show unread messages waiting for the agent
+	if status.UnreadCount > 0 {
+		statusText += fmt.Sprintf(" ✉%d", status.UnreadCount)
+	}
+	
 	// Add selection indicator
 	prefix := fmt.Sprintf("%d ", number)
 	if selected {
@@ -160,8 +192,14 @@ func (m Model) formatAgentLine(status mcp.AgentStatus, healthIssue string, maxWi
 		style = style.Background(lipgloss.Color("237")) // Highlight background
 	}
 	
-	// Build the line: number + icon + name + status + health indicator
-	line := fmt.Sprintf("%s %s %s - %s%s", prefix, icon, status.Name, statusText, healthIndicator)
+	// Mark agents that run in a container
+	runtimeTag := ""
+	if agent, ok := m.config.Agents[status.Name]; ok && agent.UsesDocker() {
+		runtimeTag = " [docker]"
+	}
+
+	// Build the line: number + icon + name + runtime + status + health indicator
+	line := fmt.Sprintf("%s %s %s%s - %s%s", prefix, icon, status.Name, runtimeTag, statusText, healthIndicator)
 	
 	// Truncate if too long
 	if len(line) > maxWidth {
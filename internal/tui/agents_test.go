@@ -242,6 +242,8 @@ func TestFormatAgentLine_WithHealthIssue(t *testing.T) {
 		{"Crashed", "crashed", "⚠"},
 		{"Unresponsive", "unresponsive", "⚠"},
 		{"Stuck", "stuck", "⏱"},
+		{"Unhealthy", "unhealthy", "⚠"},
+		{"Degraded", "degraded", "◐"},
 	}
 
 	for _, tt := range tests {
@@ -274,6 +276,28 @@ func TestFormatAgentLine_Selected(t *testing.T) {
 	}
 }
 
+// TestFormatAgentLine_Unread tests that unread messages are shown
+func TestFormatAgentLine_Unread(t *testing.T) {
+	tf := NewTestFramework()
+	model := tf.GetModel()
+
+	status := mcp.AgentStatus{
+		Name:        "test-agent",
+		State:       mcp.StateIdle,
+		LastSeen:    time.Now(),
+		UnreadCount: 3,
+	}
+
+	if line := model.formatAgentLine(status, "", 50, 1, false); !strings.Contains(line, "✉3") {
+		t.Errorf("Line should show the unread count, got %q", line)
+	}
+
+	status.UnreadCount = 0
+	if line := model.formatAgentLine(status, "", 50, 1, false); strings.Contains(line, "✉") {
+		t.Errorf("Line should not show an unread count of zero, got %q", line)
+	}
+}
+
 // TestFormatAgentLine_Truncation tests line truncation
 func TestFormatAgentLine_Truncation(t *testing.T) {
 	tf := NewTestFramework()
@@ -414,10 +438,28 @@ func TestRenderAgentPane_WithKeybindingHints(t *testing.T) {
 	}
 }
 
+// TestRenderAgentPane_Loading tests the placeholder shown before the
+// first refresh
+func TestRenderAgentPane_Loading(t *testing.T) {
+	tf := NewTestFramework()
+	model := tf.GetModel()
+
+	pane := model.renderAgentPane(40, 20)
+	if !strings.Contains(pane, "Loading agent status") {
+		t.Error("Agent pane should show a loading placeholder before the first refresh")
+	}
+
+	model.applyRefresh(refreshResult{refreshTime: time.Now(), mcpFetched: true})
+	if pane := model.renderAgentPane(40, 20); strings.Contains(pane, "Loading") {
+		t.Error("Agent pane should drop the placeholder once a refresh arrives")
+	}
+}
+
 // TestRenderAgentPane_OfflineAgent tests rendering offline agent
 func TestRenderAgentPane_OfflineAgent(t *testing.T) {
 	tf := NewTestFramework()
 	model := tf.GetModel()
+	model.agentsLoaded = true
 
 	// Agent in config but no status update (offline)
 	pane := model.renderAgentPane(40, 20)
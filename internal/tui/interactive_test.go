@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,11 +20,11 @@ type mockBeadsClient struct {
 	tasks []beads.Task
 }
 
-func (m *mockBeadsClient) GetTasks(statuses []string) ([]beads.Task, error) {
+func (m *mockBeadsClient) GetTasks(ctx context.Context, statuses []string) ([]beads.Task, error) {
 	return m.tasks, nil
 }
 
-func (m *mockBeadsClient) CreateTask(title string) (beads.Task, error) {
+func (m *mockBeadsClient) CreateTask(ctx context.Context, title string) (beads.Task, error) {
 	task := beads.Task{
 		ID:     "test-123",
 		Title:  title,
@@ -32,7 +34,7 @@ func (m *mockBeadsClient) CreateTask(title string) (beads.Task, error) {
 	return task, nil
 }
 
-func (m *mockBeadsClient) UpdateTask(id string, updates beads.TaskUpdate) error {
+func (m *mockBeadsClient) UpdateTask(ctx context.Context, id string, updates beads.TaskUpdate) error {
 	for i, task := range m.tasks {
 		if task.ID == id {
 			if updates.Assignee != nil {
@@ -46,7 +48,7 @@ func (m *mockBeadsClient) UpdateTask(id string, updates beads.TaskUpdate) error
 	return nil
 }
 
-func (m *mockBeadsClient) DeleteTask(id string) error {
+func (m *mockBeadsClient) DeleteTask(ctx context.Context, id string) error {
 	for i, task := range m.tasks {
 		if task.ID == id {
 			m.tasks = append(m.tasks[:i], m.tasks[i+1:]...)
@@ -56,7 +58,7 @@ func (m *mockBeadsClient) DeleteTask(id string) error {
 	return nil
 }
 
-func (m *mockBeadsClient) Refresh() error {
+func (m *mockBeadsClient) Refresh(ctx context.Context) error {
 	return nil
 }
 
@@ -65,27 +67,27 @@ type mockMCPClient struct {
 	messages []mcp.Message
 }
 
-func (m *mockMCPClient) GetMessages(since time.Time) ([]mcp.Message, error) {
+func (m *mockMCPClient) GetMessages(ctx context.Context, since time.Time) ([]mcp.Message, error) {
 	return m.messages, nil
 }
 
-func (m *mockMCPClient) SendMessage(msg mcp.Message) error {
+func (m *mockMCPClient) SendMessage(ctx context.Context, msg mcp.Message) error {
 	m.messages = append(m.messages, msg)
 	return nil
 }
 
-func (m *mockMCPClient) GetAgentStatus(agentName string) (mcp.AgentStatus, error) {
+func (m *mockMCPClient) GetAgentStatus(ctx context.Context, agentName string) (mcp.AgentStatus, error) {
 	return mcp.AgentStatus{
 		Name:  agentName,
 		State: mcp.StateIdle,
 	}, nil
 }
 
-func (m *mockMCPClient) GetAllAgentStatuses(offlineThreshold time.Duration) ([]mcp.AgentStatus, error) {
+func (m *mockMCPClient) GetAllAgentStatuses(ctx context.Context, offlineThreshold time.Duration) ([]mcp.AgentStatus, error) {
 	return []mcp.AgentStatus{}, nil
 }
 
-func (m *mockMCPClient) ReleaseAgentLeases(agentName string) error {
+func (m *mockMCPClient) ReleaseAgentLeases(ctx context.Context, agentName string) error {
 	return nil
 }
 
@@ -96,11 +98,11 @@ func (m *mockProcessManager) Start(name string, command string, args []string, e
 	return 12345, nil
 }
 
-func (m *mockProcessManager) Stop(pid int) error {
+func (m *mockProcessManager) Stop(ctx context.Context, pid int) error {
 	return nil
 }
 
-func (m *mockProcessManager) StopAll() error {
+func (m *mockProcessManager) StopAll(ctx context.Context) error {
 	return nil
 }
 
@@ -357,6 +359,63 @@ func TestSearchMode(t *testing.T) {
 	}
 }
 
+// TestTaskQuery tests filtering the task pane with a query
+func TestTaskQuery(t *testing.T) {
+	m := createTestModel()
+	m.width = 100
+	m.height = 40
+	m.tasks = []beads.Task{
+		{ID: "task-1", Title: "API", Status: "open", Priority: 1, Labels: []string{"backend"}},
+		{ID: "task-2", Title: "UI", Status: "open", Priority: 3, Labels: []string{"frontend"}},
+		{ID: "task-3", Title: "Schema", Status: "in_progress", Priority: 0, Labels: []string{"backend"}},
+	}
+
+	// Press 'f' to edit the task query in the search bar
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	m = newModel.(Model)
+	if !m.searchMode || !m.searchTasks {
+		t.Fatal("Expected the search bar to edit the task query after pressing 'f'")
+	}
+
+	typeQuery := func(text string) {
+		for _, r := range text {
+			newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			m = newModel.(Model)
+		}
+	}
+	typeQuery("label:backend")
+	if got := m.filterTasksByStatus([]string{"open", "in_progress"}); len(got) != 2 {
+		t.Errorf("Expected the backend tasks while typing, got %+v", got)
+	}
+	// An incomplete query does not parse, so the last one that did applies
+	typeQuery(` "`)
+	if m.taskQuery != `label:backend "` || len(m.filterTasksByStatus([]string{"open", "in_progress"})) != 2 {
+		t.Errorf("Expected the last valid query to apply, got %q", m.taskQuery)
+	}
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = newModel.(Model)
+
+	typeQuery("priority>0")
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(Model)
+	got := m.filterTasksByStatus([]string{"open", "in_progress"})
+	if m.searchMode || len(got) != 1 || got[0].ID != "task-1" {
+		t.Errorf("Expected the query to filter the task pane, got %+v", got)
+	}
+	if pane := m.renderTaskPane(60, 20); !strings.Contains(pane, "[label:backend priority>0]") {
+		t.Error("Expected the task pane title to show the query")
+	}
+
+	// Press 'f' then 'esc' to clear the filter
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	m = newModel.(Model)
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(Model)
+	if m.taskQuery != "" || len(m.filterTasksByStatus([]string{"open", "in_progress"})) != 3 {
+		t.Error("Expected 'esc' to clear the task query")
+	}
+}
+
 // TestFilterCycling tests cycling through filters
 func TestFilterCycling(t *testing.T) {
 	m := createTestModel()
@@ -1004,6 +1063,34 @@ func TestWSEventHandling(t *testing.T) {
 		}
 	})
 
+	t.Run("handle degraded event", func(t *testing.T) {
+		m.wsConnected = true
+		event := wsEventMsg(mcp.Event{Type: mcp.EventDegraded, Error: "EOF"})
+		newModel, _ := m.Update(event)
+		m = newModel.(Model)
+
+		if m.wsConnected || m.wsState != mcp.StreamDegraded {
+			t.Errorf("Expected a degraded stream, got connected %v, state %q", m.wsConnected, m.wsState)
+		}
+		if status := m.getMCPConnectionStatus(); !strings.Contains(status, "reconnecting") {
+			t.Errorf("Expected the status to show the reconnect, got %q", status)
+		}
+	})
+
+	t.Run("handle resync event", func(t *testing.T) {
+		m.wsConnected = true
+		event := wsEventMsg(mcp.Event{Type: mcp.EventResync})
+		newModel, cmd := m.Update(event)
+		m = newModel.(Model)
+
+		if cmd == nil {
+			t.Error("Expected a resync to refresh MCP data")
+		}
+		if !m.wsConnected {
+			t.Error("Expected a resync to leave the WebSocket connected")
+		}
+	})
+
 	t.Run("handle agent status event", func(t *testing.T) {
 		status := mcp.AgentStatus{
 			Name:  "test-agent",
@@ -6,6 +6,8 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/logger"
 	"github.com/rand/asc/internal/mcp"
 )
 
@@ -26,6 +28,12 @@ var logPaneBorder = lipgloss.NewStyle().
 // Maximum number of messages to display
 const maxLogMessages = 100
 
+// logScrollStep is how many messages PgUp and PgDn scroll the log by
+const logScrollStep = 10
+
+// historyChunk is how many spilled messages are read back at a time
+const historyChunk = 200
+
 // renderLogPane renders the MCP interaction log pane
 func (m Model) renderLogPane(width, height int) string {
 	// Calculate content dimensions (accounting for border and padding)
@@ -37,6 +45,15 @@ func (m Model) renderLogPane(width, height int) string {
 	// Get filtered messages
 	messages := m.getFilteredMessages()
 	
+	// Drop the messages scrolled past
+	if m.logScroll > 0 {
+		end := len(messages) - m.logScroll
+		if end < 0 {
+			end = 0
+		}
+		messages = messages[:end]
+	}
+	
 	// Limit to recent messages
 	if len(messages) > maxLogMessages {
 		messages = messages[len(messages)-maxLogMessages:]
@@ -50,7 +67,7 @@ func (m Model) renderLogPane(width, height int) string {
 	
 	// If no messages, show a message
 	if len(lines) == 0 {
-		lines = append(lines, styleMessage.Render("No messages yet"))
+		lines = append(lines, styleMessage.Render(i18n.T("tui.logs.none")))
 	}
 	
 	// Auto-scroll to bottom: take the last contentHeight lines
@@ -67,7 +84,7 @@ func (m Model) renderLogPane(width, height int) string {
 	contentStr := strings.Join(lines, "\n")
 	
 	// Build title with active filters
-	title := "MCP Interaction Log"
+	title := i18n.T("tui.logs.title")
 	var filterParts []string
 	if m.searchInput != "" {
 		filterParts = append(filterParts, fmt.Sprintf("search:%s", m.searchInput))
@@ -81,9 +98,12 @@ func (m Model) renderLogPane(width, height int) string {
 	if len(filterParts) > 0 {
 		title += " [" + strings.Join(filterParts, " ") + "]"
 	}
+	if m.logScroll > 0 {
+		title += i18n.T("tui.logs.newer", m.logScroll)
+	}
 	
 	// Add keybindings hint
-	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("/:search a:agent m:type x:clear e:export")
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(i18n.T("tui.logs.hint"))
 	
 	return logPaneBorder.
 		Width(width - 2).
@@ -96,6 +116,57 @@ func (m Model) renderLogPane(width, height int) string {
 		))
 }
 
+// scrollLog scrolls the log pane up by n messages, or down for a negative
+// n. Scrolling up past the messages in memory reads older ones back from
+// the message history; scrolling back to the newest message drops them
+// again.
+func (m *Model) scrollLog(n int) {
+	m.logScroll += n
+	if m.logScroll <= 0 {
+		m.logScroll = 0
+		m.olderMessages = nil
+		return
+	}
+
+	available := len(m.getFilteredMessages())
+	if m.logScroll >= available {
+		m.loadOlderMessages()
+		available = len(m.getFilteredMessages())
+	}
+	if m.logScroll > available-1 {
+		m.logScroll = available - 1
+	}
+	if m.logScroll < 0 {
+		m.logScroll = 0
+	}
+}
+
+// loadOlderMessages reads the next chunk of spilled messages, older than
+// the ones already read back, from the message history
+func (m *Model) loadOlderMessages() {
+	if m.history == nil {
+		return
+	}
+	if m.olderMessages == nil {
+		m.olderStart = m.history.Len()
+	}
+	if m.olderStart == 0 {
+		return
+	}
+
+	start := m.olderStart - historyChunk
+	if start < 0 {
+		start = 0
+	}
+	older, err := m.history.Load(start, m.olderStart)
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.olderMessages = append(older, m.olderMessages...)
+	m.olderStart = start
+}
+
 // getRecentMessages returns the last N messages from the message list
 func (m Model) getRecentMessages(limit int) []mcp.Message {
 	if len(m.messages) <= limit {
@@ -113,8 +184,8 @@ func (m Model) formatMessageLine(msg mcp.Message, maxWidth int) string {
 	// Format the message type
 	msgType := string(msg.Type)
 	
-	// Build the line
-	line := fmt.Sprintf("[%s] [%s] [%s] → %s", timestamp, msgType, msg.Source, msg.Content)
+	// Build the line, masking any secrets an agent may have echoed
+	line := fmt.Sprintf("[%s] [%s] [%s] → %s", timestamp, msgType, msg.Source, logger.Redact(msg.Content))
 	
 	// Truncate if too long
 	if len(line) > maxWidth {
@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -533,3 +535,85 @@ func TestRenderLogPane_ChronologicalOrder(t *testing.T) {
 		t.Error("Log pane should contain third message")
 	}
 }
+
+// TestFormatMessageLine_RedactsSecrets tests that API keys in message content are masked
+func TestFormatMessageLine_RedactsSecrets(t *testing.T) {
+	tf := NewTestFramework()
+	model := tf.GetModel()
+
+	line := model.formatMessageLine(mcp.Message{
+		Timestamp: time.Now(),
+		Type:      mcp.TypeError,
+		Source:    "test-agent",
+		Content:   "auth failed with sk-ant-REDACTED",
+	}, 200)
+
+	if strings.Contains(line, "sk-ant-api03") {
+		t.Errorf("Message line should not contain the API key: %s", line)
+	}
+	if !strings.Contains(line, "[REDACTED]") {
+		t.Errorf("Message line should contain the redaction placeholder: %s", line)
+	}
+}
+
+// TestMessageHistorySpillAndScrollBack tests that messages beyond the
+// in-memory limit are spilled to the history and read back when scrolling
+func TestMessageHistorySpillAndScrollBack(t *testing.T) {
+	tf := NewTestFramework()
+	model := tf.GetModel()
+	history, err := mcp.NewHistory(filepath.Join(t.TempDir(), "messages.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+	model.history = history
+	model.historyLimit = 20
+
+	for i := 0; i < 250; i++ {
+		model.appendMessages(mcp.Message{
+			Timestamp: time.Now(),
+			Type:      mcp.TypeMessage,
+			Source:    "test-agent",
+			Content:   fmt.Sprintf("Message %d", i),
+		})
+	}
+	if len(model.messages) != 20 || history.Len() != 230 {
+		t.Fatalf("Expected 20 messages in memory and 230 spilled, got %d and %d", len(model.messages), history.Len())
+	}
+
+	// Scrolling past the messages in memory reads older ones back
+	model.scrollLog(25)
+	if len(model.olderMessages) != historyChunk {
+		t.Fatalf("Expected %d messages read back, got %d", historyChunk, len(model.olderMessages))
+	}
+	if model.olderMessages[0].Content != "Message 30" {
+		t.Errorf("Expected the newest spilled messages to be read back first, got %q", model.olderMessages[0].Content)
+	}
+	pane := model.renderLogPane(80, 10)
+	if !strings.Contains(pane, "Message 224") || strings.Contains(pane, "Message 249") {
+		t.Errorf("Expected the pane to show older messages, got:\n%s", pane)
+	}
+
+	// New messages keep the scrolled-up view in place
+	model.appendMessages(mcp.Message{Timestamp: time.Now(), Type: mcp.TypeMessage, Source: "test-agent", Content: "Message 250"})
+	if model.logScroll != 26 {
+		t.Errorf("logScroll = %d, want 26", model.logScroll)
+	}
+	if got := model.getFilteredMessages(); got[len(got)-1-model.logScroll].Content != "Message 224" {
+		t.Errorf("Expected the view to stay on Message 224, got %q", got[len(got)-1-model.logScroll].Content)
+	}
+
+	// Scrolling up to the oldest message stops there
+	for i := 0; i < 30; i++ {
+		model.scrollLog(logScrollStep)
+	}
+	if all := model.getFilteredMessages(); all[0].Content != "Message 0" || model.logScroll != len(all)-1 {
+		t.Errorf("Expected to reach Message 0, got %q with logScroll %d of %d", all[0].Content, model.logScroll, len(all))
+	}
+
+	// Returning to the newest message drops what was read back
+	model.scrollLog(-model.logScroll)
+	if model.logScroll != 0 || model.olderMessages != nil {
+		t.Errorf("Expected the read-back messages to be dropped, got %d", len(model.olderMessages))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/rand/asc/internal/beads"
 )
 
 // Modal styles
@@ -55,12 +56,79 @@ func (m Model) renderTaskDetailModal() string {
 	content.WriteString(modalLabelStyle.Render("Phase: "))
 	content.WriteString(task.Phase)
 	content.WriteString("\n\n")
+	content.WriteString(modalLabelStyle.Render("Priority: "))
+	content.WriteString(fmt.Sprintf("P%d", task.Priority))
+	content.WriteString("\n\n")
+	if len(task.Labels) > 0 {
+		content.WriteString(modalLabelStyle.Render("Labels: "))
+		content.WriteString(strings.Join(task.Labels, ", "))
+		content.WriteString("\n\n")
+	}
 	if task.Assignee != "" {
 		content.WriteString(modalLabelStyle.Render("Assignee: "))
 		content.WriteString(task.Assignee)
 		content.WriteString("\n\n")
 	}
-	content.WriteString(modalLabelStyle.Render("Press 'v' or 'esc' to close"))
+	if blockers := beads.Blockers(task, m.tasks); len(blockers) > 0 {
+		content.WriteString(modalLabelStyle.Render("Blocked by: "))
+		content.WriteString(formatTaskIDs(blockers))
+		content.WriteString("\n\n")
+	}
+	content.WriteString(modalLabelStyle.Render("Press 'h' for its history, 'v' or 'esc' to close"))
+
+	// Render modal box
+	modalContent := modalBoxStyle.Render(content.String())
+
+	// Center the modal
+	return m.centerModal(modalContent)
+}
+
+// historyModalEvents is the number of the latest events the task history
+// modal shows
+const historyModalEvents = 15
+
+// renderTaskHistoryModal renders a modal showing who changed a task and
+// when, latest last
+func (m Model) renderTaskHistoryModal() string {
+	history := m.taskHistory
+	var content strings.Builder
+	content.WriteString(modalTitleStyle.Render(fmt.Sprintf("History of #%s", history.task)))
+	content.WriteString("\n\n")
+	events := history.events
+	switch {
+	case history.err != nil:
+		content.WriteString(fmt.Sprintf("Failed to read the history: %v", history.err))
+		content.WriteString("\n\n")
+	case len(events) == 0:
+		content.WriteString("No recorded changes")
+		content.WriteString("\n\n")
+	default:
+		if len(events) > historyModalEvents {
+			content.WriteString(modalLabelStyle.Render(fmt.Sprintf("… %d earlier (asc tasks history %s)", len(events)-historyModalEvents, history.task)))
+			content.WriteString("\n")
+			events = events[len(events)-historyModalEvents:]
+		}
+		seen := false
+		for _, event := range events {
+			actor := event.Actor
+			if actor == "" {
+				actor = "unknown"
+			}
+			if event.Source == beads.SourceBeads {
+				actor += "*"
+				seen = true
+			}
+			content.WriteString(modalLabelStyle.Render(event.Time.Local().Format("01-02 15:04") + "  "))
+			content.WriteString(fmt.Sprintf("%-12s %s", actor, event.Change()))
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+		if seen {
+			content.WriteString(modalLabelStyle.Render("* made with bd, attributed to the assignee"))
+			content.WriteString("\n\n")
+		}
+	}
+	content.WriteString(modalLabelStyle.Render("Press 'h' or 'esc' to close"))
 
 	// Render modal box
 	modalContent := modalBoxStyle.Render(content.String())
@@ -125,8 +193,13 @@ func (m Model) renderConfirmModal() string {
 func (m Model) renderSearchInput() string {
 	// Build search bar
 	var content strings.Builder
-	content.WriteString(modalLabelStyle.Render("Search: "))
-	content.WriteString(modalInputStyle.Render(m.searchInput + "█"))
+	if m.searchTasks {
+		content.WriteString(modalLabelStyle.Render("Filter tasks: "))
+		content.WriteString(modalInputStyle.Render(m.taskQuery + "█"))
+	} else {
+		content.WriteString(modalLabelStyle.Render("Search: "))
+		content.WriteString(modalInputStyle.Render(m.searchInput + "█"))
+	}
 	content.WriteString("  ")
 	content.WriteString(modalLabelStyle.Render("Press 'enter' to apply, 'esc' to cancel"))
 
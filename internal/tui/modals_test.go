@@ -1,8 +1,11 @@
 package tui
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -47,6 +50,24 @@ func TestRenderTaskDetailModal(t *testing.T) {
 	}
 }
 
+// TestRenderTaskDetailModalBlocked tests the blockers in the task detail modal
+func TestRenderTaskDetailModalBlocked(t *testing.T) {
+	m := createTestModel()
+	m.width = 100
+	m.height = 40
+	m.tasks = []beads.Task{
+		{ID: "task-1", Title: "Schema", Status: "open"},
+		{ID: "task-2", Title: "API", Status: "open", Dependencies: []string{"task-1"}},
+	}
+	m.selectedTaskIndex = 1
+	m.showTaskModal = true
+
+	output := m.renderTaskDetailModal()
+	if !strings.Contains(output, "Blocked by") || !strings.Contains(output, "#task-1") {
+		t.Error("Expected modal to list the blockers")
+	}
+}
+
 // TestRenderTaskDetailModalNoSelection tests modal with no task selected
 func TestRenderTaskDetailModalNoSelection(t *testing.T) {
 	m := createTestModel()
@@ -383,3 +404,57 @@ func TestModalPriority(t *testing.T) {
 		}
 	})
 }
+
+// TestTaskHistoryModal tests opening the history of the selected task
+func TestTaskHistoryModal(t *testing.T) {
+	m := createTestModel()
+	m.width = 100
+	m.height = 40
+	m.tasks = []beads.Task{{ID: "task-1", Title: "Schema", Status: "in_progress", Assignee: "coder"}}
+	m.selectedTaskIndex = 0
+
+	// The mock client does not record history
+	msg := taskHistoryCmd(m)()
+	updated, _ := m.Update(msg)
+	if output := updated.(Model).renderTaskHistoryModal(); !strings.Contains(output, "not recorded") {
+		t.Errorf("Expected an error without a history, got:\n%s", output)
+	}
+
+	dir := t.TempDir()
+	log := `{"time":"2026-03-01T10:00:00Z","task":"task-1","kind":"created","to":"open","actor":"alice","source":"asc"}
+{"time":"2026-03-01T10:05:00Z","task":"task-2","kind":"deleted","actor":"alice","source":"asc"}
+{"time":"2026-03-01T10:10:00Z","task":"task-1","kind":"status","from":"open","to":"in_progress","actor":"coder","source":"beads"}
+`
+	if err := os.WriteFile(filepath.Join(dir, "tasks.jsonl"), []byte(log), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client := beads.NewClient("", time.Second)
+	client.SetHistory(beads.NewHistory(dir))
+	m.beadsClient = client
+	m.showTaskModal = true
+
+	// h switches from the task details to the history
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if cmd == nil {
+		t.Fatal("Expected h to read the history")
+	}
+	updated, _ = updated.(Model).Update(cmd())
+	model := updated.(Model)
+	if !model.showHistoryModal || model.showTaskModal {
+		t.Fatal("Expected the history modal to replace the task modal")
+	}
+	output := model.renderTaskHistoryModal()
+	for _, want := range []string{"History of #task-1", "alice", "created (open)", "coder*", "status open → in_progress", "made with bd"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in the modal, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "deleted") {
+		t.Errorf("Expected only the events of task-1, got:\n%s", output)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).showHistoryModal {
+		t.Error("Expected esc to close the history modal")
+	}
+}
@@ -1,18 +1,23 @@
 package tui
 
 import (
+	"context"
 	"os"
-	"path/filepath"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/budget"
 	"github.com/rand/asc/internal/config"
+	"github.com/rand/asc/internal/fswatch"
 	"github.com/rand/asc/internal/health"
 	"github.com/rand/asc/internal/logger"
 	"github.com/rand/asc/internal/mcp"
+	"github.com/rand/asc/internal/pipeline"
 	"github.com/rand/asc/internal/process"
+	"github.com/rand/asc/internal/proxy"
+	"github.com/rand/asc/internal/statedir"
 )
 
 // Model represents the TUI application state
@@ -21,31 +26,44 @@ type Model struct {
 	config        config.Config
 	configWatcher *config.Watcher       // Watches for config file changes
 	reloadManager *config.ReloadManager // Manages config reload and agent lifecycle
+	startAgent    func(name string, cfg *config.Config) error // Starts agents added by a reload, as asc up does
 
 	// Data sources
 	beadsClient   beads.BeadsClient
 	mcpClient     mcp.MCPClient
-	wsClient      *mcp.WebSocketClient // WebSocket client for real-time updates
+	wsClient      mcp.EventSource // WebSocket or gRPC event stream for real-time updates
 	procManager   process.ProcessManager
 	healthMonitor *health.Monitor // Health monitoring system
 	logAggregator *logger.LogAggregator // Log aggregation system
+	pipeline      *pipeline.Orchestrator // Phase pipeline, nil if not configured
+	budget        *budget.Enforcer       // Budget enforcement, nil if no budget is configured
+	taskChanges   <-chan []beads.TaskChange // Reports task changes, nil if beads must be polled
+	stopTaskWatch context.CancelFunc        // Stops reporting task changes
+	procWatcher   *fswatch.Watcher          // Reports agent processes starting and exiting
 
 	// State
 	agents       []mcp.AgentStatus
 	tasks        []beads.Task
 	messages     []mcp.Message
+	messageCursor string // Where fetching messages resumes, from servers that page them
 	healthIssues []health.HealthIssue
+	processHealth map[string]process.HealthStatus // Health check status per agent
 
 	// UI state
 	width         int
 	height        int
 	lastRefresh   time.Time
 	wsConnected   bool // WebSocket connection status
+	wsState       mcp.StreamState // State of the WebSocket event stream; "" until reported
 	beadsConnected bool // Beads connection status
+	agentsLoaded  bool // Whether a first full refresh has arrived
+	tasksLoaded   bool // Whether tasks have been fetched once
 
 	// Task interaction state
 	selectedTaskIndex int    // Index of selected task in filtered list
 	showTaskModal     bool   // Whether to show task detail modal
+	showHistoryModal  bool   // Whether to show the task history modal
+	taskHistory       taskHistoryMsg // History shown in the task history modal
 	showCreateModal   bool   // Whether to show create task modal
 	createTaskInput   string // Input for new task title
 
@@ -54,12 +72,24 @@ type Model struct {
 	showConfirmModal   bool // Whether to show confirmation dialog
 	confirmAction      string // Action to confirm (kill, restart)
 
+	// Message history state
+	history       *mcp.History  // Messages spilled from memory, nil if they are dropped
+	historyLimit  int           // Messages kept in memory
+	olderMessages []mcp.Message // Spilled messages read back while the log is scrolled up
+	olderStart    int           // History number of olderMessages[0]
+	logScroll     int           // Messages the log pane is scrolled up by
+
 	// Log filtering state
 	searchMode      bool   // Whether in search mode
 	searchInput     string // Search input text
 	logFilterAgent  string // Filter logs by agent name
 	logFilterType   string // Filter logs by message type
 
+	// Task filtering state
+	searchTasks bool        // Whether the search bar edits the task query
+	taskQuery   string      // Task query text, in the beads query language
+	taskFilter  beads.Query // Last valid taskQuery, filtering the task pane
+
 	// Reload notification state
 	reloadNotification string    // Message to display for config reload
 	reloadNotificationTime time.Time // When the notification was shown
@@ -69,6 +99,27 @@ type Model struct {
 
 	// Error state
 	err error
+
+	// Cancelled on quit so in-flight beads, MCP, and process calls return
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// defaultMessageHistory is how many messages are kept in memory when
+// tui.message_history is not set
+const defaultMessageHistory = 1000
+
+// callTimeout bounds each beads, MCP, or process call made by the TUI
+const callTimeout = 10 * time.Second
+
+// callContext returns the context for one call, cancelled on quit or
+// after callTimeout so a hung bd or MCP server cannot wedge an action
+func (m Model) callContext() (context.Context, context.CancelFunc) {
+	parent := m.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	return context.WithTimeout(parent, callTimeout)
 }
 
 // NewModel creates a new TUI model with the given configuration and clients
@@ -79,9 +130,9 @@ func NewModel(
 	procManager process.ProcessManager,
 ) Model {
 	// Initialize log aggregator
-	homeDir, _ := os.UserHomeDir()
-	logsDir := filepath.Join(homeDir, ".asc", "logs")
+	logsDir, _ := statedir.Path("logs")
 	logAggregator := logger.NewLogAggregator(logsDir, 1000) // Keep last 1000 entries
+	ctx, cancel := context.WithCancel(context.Background())
 
 	return Model{
 		config:         cfg,
@@ -97,92 +148,256 @@ func NewModel(
 		tasks:          []beads.Task{},
 		messages:       []mcp.Message{},
 		healthIssues:   []health.HealthIssue{},
+		historyLimit:   cfg.TUI.MessageHistory,
 		lastRefresh:    time.Now(),
 		wsConnected:    false,
 		beadsConnected: false,
+		ctx:            ctx,
+		cancel:         cancel,
 	}
 }
 
-// tickMsg is sent periodically to trigger data refresh (for beads polling)
+// Data normally arrives as events: MCP updates over the WebSocket, task
+// changes from the beads watcher, and process starts and exits from the
+// PID directory watcher. A slow poll backs them up; it is fast only when
+// task changes cannot be watched.
+const (
+	fallbackPollInterval = 30 * time.Second
+	fastPollInterval     = 5 * time.Second
+)
+
+// tickMsg is sent by the fallback poll to trigger a data refresh
 type tickMsg time.Time
 
+// tasksChangedMsg is sent when the beads watcher reports task changes
+type tasksChangedMsg struct {
+	changes []beads.TaskChange
+}
+
+// processesChangedMsg is sent when an agent process starts or exits
+type processesChangedMsg struct{}
+
+// sourcesMsg carries what Init started back to the model. Init has a
+// value receiver, so fields it sets on the model are lost; Update stores
+// these and starts listening to them.
+type sourcesMsg struct {
+	healthMonitor *health.Monitor
+	configWatcher *config.Watcher
+	reloadManager *config.ReloadManager
+	wsClient      mcp.EventSource
+	taskChanges   <-chan []beads.TaskChange
+	stopTaskWatch context.CancelFunc
+	procWatcher   *fswatch.Watcher
+	history       *mcp.History
+}
+
+// watchable is implemented by the process manager that can report agent
+// processes starting and exiting as it happens
+type watchable interface {
+	Watch() (*fswatch.Watcher, error)
+}
+
 // wsEventMsg wraps a WebSocket event for the TUI
 type wsEventMsg mcp.Event
 
-// Init initializes the TUI model and starts the ticker
+// Init initializes the TUI model and starts its event sources
 func (m Model) Init() tea.Cmd {
+	// The first frame is drawn as soon as Init returns, so the data load
+	// and the event sources are started in the background and the panes
+	// show loading placeholders until they arrive
 	cmds := []tea.Cmd{
 		refreshDataCmd(m), // Initial data load
+		startSourcesCmd(m),
 	}
 
+	// Show phase pipeline transitions as they happen
+	if m.pipeline != nil {
+		cmds = append(cmds, waitForPipelineEventCmd(m.pipeline))
+	}
+
+	// Show budget warnings and pauses as they happen
+	if m.budget != nil {
+		cmds = append(cmds, waitForBudgetEventCmd(m.budget))
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// startSourcesCmd starts the health monitor, config hot-reload, WebSocket
+// client, change watchers, and message history, and sends them to Update
+func startSourcesCmd(m Model) tea.Cmd {
+	return func() tea.Msg {
+		return m.startSources()
+	}
+}
+
+// startSources creates the event sources of the model
+func (m Model) startSources() sourcesMsg {
+	var sources sourcesMsg
+
 	// Initialize health monitor
 	if monitor, err := health.NewMonitor(m.mcpClient, m.procManager, m.config); err == nil {
-		m.healthMonitor = monitor
+		sources.healthMonitor = monitor
 		// Apply auto-recovery configuration from config file
 		// If not specified (nil), it defaults to true (enabled)
 		if m.config.Core.AutoRecovery != nil {
-			m.healthMonitor.SetAutoRecovery(*m.config.Core.AutoRecovery)
+			monitor.SetAutoRecovery(*m.config.Core.AutoRecovery)
 		}
 		// Otherwise, keep the default (true) from NewMonitor
-		m.healthMonitor.Start()
+		// Agents stopped by the phase pipeline or paused by their budget
+		// have not crashed
+		if m.pipeline != nil || m.budget != nil {
+			orch, enforcer := m.pipeline, m.budget
+			monitor.SetAgentFilter(func(name string) bool {
+				if orch != nil && !orch.IsAgentActive(name) {
+					return false
+				}
+				return enforcer == nil || !enforcer.IsPaused(name)
+			})
+		}
+		monitor.Start()
 	}
 
 	// Initialize configuration hot-reload
 	if watcher, err := config.NewWatcher(config.DefaultConfigPath()); err == nil {
-		m.configWatcher = watcher
-		
 		// Create reload manager with environment variables
 		envVars := m.getEnvVars()
 		// Wrap the process manager to adapt the interface
 		adaptedProcManager := newProcessManagerAdapter(m.procManager)
-		m.reloadManager = config.NewReloadManager(&m.config, adaptedProcManager, envVars)
+		sources.reloadManager = config.NewReloadManager(&m.config, adaptedProcManager, envVars)
+		if m.startAgent != nil {
+			sources.reloadManager.SetStartAgent(m.startAgent)
+		}
+
+		// asc reload asks for a reload through the request file
+		if path, err := config.ReloadRequestPath(); err == nil {
+			watcher.WatchRequests(path)
+		}
 		
 		// Register reload callback
-		m.configWatcher.OnReload(func(newConfig *config.Config) error {
+		watcher.OnReload(func(newConfig *config.Config) error {
 			// This will be called in a goroutine, so we need to send a message to the TUI
 			// We'll handle the actual reload in the Update function
 			return nil
 		})
 		
-		// Start watching
-		if err := m.configWatcher.Start(); err == nil {
-			// Start listening for config reload events
-			cmds = append(cmds, waitForConfigReloadCmd(m.configWatcher))
+		// Start watching; reload events are handled once Update has the watcher
+		if err := watcher.Start(); err == nil {
+			sources.configWatcher = watcher
 		}
 	}
 
-	// Try to initialize WebSocket connection for real-time MCP updates
-	if m.config.Services.MCPAgentMail.URL != "" {
+	// Try to initialize WebSocket connection for real-time MCP updates;
+	// with several MCP servers the TUI polls them all instead. The
+	// stream's state is recorded for asc status.
+	statePath, _ := mcp.DefaultStreamStatePath()
+	if watcher, ok := eventWatcher(m.mcpClient); ok && len(m.config.Services.MCPServers) == 0 {
+		// A gRPC server streams its events itself
+		sources.wsClient = watcher.Watch(statePath)
+	} else if m.config.Services.MCPAgentMail.URL != "" && len(m.config.Services.MCPServers) == 0 {
 		// Convert HTTP URL to WebSocket URL
 		wsURL := convertToWebSocketURL(m.config.Services.MCPAgentMail.URL)
-		m.wsClient = mcp.NewWebSocketClient(wsURL)
-		
-		// Attempt to connect (non-blocking)
-		cmds = append(cmds, connectWebSocketCmd(m.wsClient))
-		
-		// Start listening for WebSocket events
-		cmds = append(cmds, waitForWSEventCmd(m.wsClient))
+		mcpCfg := m.config.Services.MCPAgentMail
+		proxySettings, err := proxy.ForService(mcpCfg.Proxy)
+		if err != nil {
+			proxySettings = proxy.FromEnvironment()
+		}
+		// Connect with the TLS files and token of the HTTP client; files
+		// that cannot be loaded leave the TUI to poll
+		if tlsConfig, err := mcp.NewTLSConfig(mcpCfg.CAFile, mcpCfg.CertFile, mcpCfg.KeyFile); err == nil {
+			sources.wsClient = mcp.NewWebSocketClientWithOptions(wsURL, mcp.Options{Proxy: proxySettings, TLS: tlsConfig, Token: mcpCfg.Token(), StreamStatePath: statePath})
+		}
 	}
 
-	// Start periodic refresh ticker for beads (git-based, cannot be real-time)
-	cmds = append(cmds, tickCmd())
+	// Watch for task changes and agent processes starting and exiting; a
+	// source that cannot be watched is left to the poll
+	if w, ok := m.beadsClient.(beads.TaskWatcher); ok {
+		parent := m.ctx
+		if parent == nil {
+			parent = context.Background()
+		}
+		ctx, cancel := context.WithCancel(parent)
+		if changes, err := w.Watch(ctx); err == nil {
+			sources.taskChanges, sources.stopTaskWatch = changes, cancel
+		} else {
+			cancel()
+			logger.Debug("Polling beads, task changes cannot be watched: %v", err)
+		}
+	}
+	if w, ok := m.procManager.(watchable); ok {
+		if watcher, err := w.Watch(); err == nil {
+			sources.procWatcher = watcher
+		}
+	}
 
-	return tea.Batch(cmds...)
+	// Spill old messages to disk rather than dropping them
+	if path, err := statedir.Path("history", "messages.jsonl"); err == nil {
+		if history, err := mcp.NewHistory(path); err == nil {
+			sources.history = history
+		} else {
+			logger.Debug("Dropping old messages, message history unavailable: %v", err)
+		}
+	}
+
+	return sources
+}
+
+// pollInterval returns the interval of the fallback poll
+func (m Model) pollInterval() time.Duration {
+	if m.taskChanges == nil {
+		return fastPollInterval
+	}
+	return fallbackPollInterval
 }
 
 // tickCmd returns a command that sends a tick message after a delay
-// This is used for polling beads, which is git-based and cannot be real-time
-func tickCmd() tea.Cmd {
-	return tea.Tick(5*time.Second, func(t time.Time) tea.Msg {
+func tickCmd(interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
-// connectWebSocketCmd attempts to connect the WebSocket client
-func connectWebSocketCmd(wsClient *mcp.WebSocketClient) tea.Cmd {
+// waitForChangeCmd waits for the next change reported by a watcher and
+// sends msg. It sends nothing once the watcher is closed.
+func waitForChangeCmd(w *fswatch.Watcher, msg tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-w.Changes(); !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// waitForTaskChangesCmd waits for the next batch of task changes and
+// sends it. It sends nothing once the watch has stopped.
+func waitForTaskChangesCmd(changes <-chan []beads.TaskChange) tea.Cmd {
+	return func() tea.Msg {
+		batch, ok := <-changes
+		if !ok {
+			return nil
+		}
+		return tasksChangedMsg{changes: batch}
+	}
+}
+
+// eventWatcher returns the MCP client as an EventWatcher if it streams
+// its own events, looking through a Router of a single server
+func eventWatcher(client mcp.MCPClient) (mcp.EventWatcher, bool) {
+	if router, ok := client.(*mcp.Router); ok {
+		if servers := router.Servers(); len(servers) == 1 {
+			client = servers[0].Client
+		}
+	}
+	watcher, ok := client.(mcp.EventWatcher)
+	return watcher, ok
+}
+
+// connectWebSocketCmd attempts to connect the event stream
+func connectWebSocketCmd(wsClient mcp.EventSource) tea.Cmd {
 	return func() tea.Msg {
 		if err := wsClient.Connect(); err != nil {
 			// Connection failed, but we'll continue with polling fallback
+			// while the client keeps trying to connect
 			return wsEventMsg{
 				Type:  mcp.EventError,
 				Error: err.Error(),
@@ -192,8 +407,8 @@ func connectWebSocketCmd(wsClient *mcp.WebSocketClient) tea.Cmd {
 	}
 }
 
-// waitForWSEventCmd waits for the next WebSocket event
-func waitForWSEventCmd(wsClient *mcp.WebSocketClient) tea.Cmd {
+// waitForWSEventCmd waits for the next event of the stream
+func waitForWSEventCmd(wsClient mcp.EventSource) tea.Cmd {
 	return func() tea.Msg {
 		event := <-wsClient.Events()
 		return wsEventMsg(event)
@@ -221,6 +436,24 @@ func (m *Model) SetDebugMode(debug bool) {
 	m.debugMode = debug
 }
 
+// SetPipeline attaches the phase pipeline so the TUI can show its events
+// and health recovery leaves deactivated agents alone
+func (m *Model) SetPipeline(orch *pipeline.Orchestrator) {
+	m.pipeline = orch
+}
+
+// SetBudget attaches the budget enforcer so the TUI can show its warnings
+// and health recovery leaves paused agents alone
+func (m *Model) SetBudget(enforcer *budget.Enforcer) {
+	m.budget = enforcer
+}
+
+// SetStartAgent sets how agents a config reload adds or changes are
+// started, so they get the resource limits and stop policies of asc up
+func (m *Model) SetStartAgent(start func(name string, cfg *config.Config) error) {
+	m.startAgent = start
+}
+
 // Cleanup closes any open connections and performs cleanup
 func (m *Model) Cleanup() {
 	if m.wsClient != nil {
@@ -232,6 +465,15 @@ func (m *Model) Cleanup() {
 	if m.configWatcher != nil {
 		m.configWatcher.Stop()
 	}
+	if m.stopTaskWatch != nil {
+		m.stopTaskWatch()
+	}
+	if m.procWatcher != nil {
+		m.procWatcher.Close()
+	}
+	if m.history != nil {
+		m.history.Close()
+	}
 }
 
 // getEnvVars returns environment variables needed for agents (API keys, etc.)
@@ -257,12 +499,37 @@ func (m Model) getEnvVars() map[string]string {
 // configReloadMsg is sent when the configuration file changes
 type configReloadMsg struct {
 	newConfig *config.Config
+	err       error // Error of loading the changed file, which is not reloaded
+}
+
+// pipelineEventMsg wraps a phase pipeline event for the TUI
+type pipelineEventMsg pipeline.Event
+
+// waitForPipelineEventCmd waits for the next phase pipeline event
+func waitForPipelineEventCmd(orch *pipeline.Orchestrator) tea.Cmd {
+	return func() tea.Msg {
+		return pipelineEventMsg(<-orch.Events())
+	}
+}
+
+// budgetEventMsg wraps a budget event for the TUI
+type budgetEventMsg budget.Event
+
+// waitForBudgetEventCmd waits for the next budget event
+func waitForBudgetEventCmd(enforcer *budget.Enforcer) tea.Cmd {
+	return func() tea.Msg {
+		return budgetEventMsg(<-enforcer.Events())
+	}
 }
 
 // waitForConfigReloadCmd waits for configuration reload events
 func waitForConfigReloadCmd(watcher *config.Watcher) tea.Cmd {
 	return func() tea.Msg {
-		newConfig := <-watcher.Events()
-		return configReloadMsg{newConfig: newConfig}
+		select {
+		case newConfig := <-watcher.Events():
+			return configReloadMsg{newConfig: newConfig}
+		case err := <-watcher.Errors():
+			return configReloadMsg{err: err}
+		}
 	}
 }
@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"context"
 	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -565,6 +567,150 @@ func TestRefreshDataCmd(t *testing.T) {
 	}
 }
 
+// TestRefreshDataCmdAppliesResult tests that data fetched by a refresh
+// command reaches the model once Update receives it
+func TestRefreshDataCmdAppliesResult(t *testing.T) {
+	tf := NewTestFramework()
+	tf.AddTask(beads.Task{ID: "task-1", Title: "Test Task", Status: "open"})
+	model := *tf.GetModel()
+
+	msg := refreshDataCmd(model)()
+	if len(model.tasks) != 0 {
+		t.Fatal("The command should not change the model it was created from")
+	}
+
+	updated, _ := model.Update(msg)
+	m := updated.(Model)
+	if len(m.tasks) != 1 || m.tasks[0].ID != "task-1" {
+		t.Errorf("Expected the refreshed task in the model, got %+v", m.tasks)
+	}
+	if !m.beadsConnected {
+		t.Error("beadsConnected should be true after successful refresh")
+	}
+}
+
+// pagingMCPClient pages its messages by cursor, as a server that filters
+// and pages messages does
+type pagingMCPClient struct {
+	*MockMCPClient
+	queries []mcp.MessageQuery
+	failAt  string // Cursor whose page fails
+}
+
+func (c *pagingMCPClient) QueryMessages(ctx context.Context, query mcp.MessageQuery) (mcp.MessagePage, error) {
+	c.queries = append(c.queries, query)
+	if query.Cursor != "" && query.Cursor == c.failAt {
+		return mcp.MessagePage{}, errors.New("server down")
+	}
+	start := 0
+	if query.Cursor != "" {
+		start, _ = strconv.Atoi(query.Cursor)
+	}
+	end := start + query.Limit
+	if end > len(c.messages) {
+		end = len(c.messages)
+	}
+	return mcp.MessagePage{
+		Messages:   c.messages[start:end],
+		NextCursor: strconv.Itoa(end),
+		More:       end < len(c.messages),
+	}, nil
+}
+
+// TestFetchMessagesPages tests that messages are fetched a page at a time
+// after the cursor of the last refresh, from servers that page them
+func TestFetchMessagesPages(t *testing.T) {
+	tf := NewTestFramework()
+	client := &pagingMCPClient{MockMCPClient: tf.mcpClient}
+	for i := 0; i < messagePageSize+10; i++ {
+		client.AddMessage(mcp.Message{Source: "agent", Content: strconv.Itoa(i)})
+	}
+	model := *tf.GetModel()
+	model.mcpClient = client
+	model.historyLimit = 4 * messagePageSize
+
+	model.applyRefresh(model.fetchData())
+	if len(model.messages) != messagePageSize+10 || model.messageCursor != strconv.Itoa(messagePageSize+10) {
+		t.Fatalf("Expected every message and the cursor after them, got %d messages, cursor %q", len(model.messages), model.messageCursor)
+	}
+	if len(client.queries) != 2 || client.queries[0].Since.IsZero() || client.queries[1].Cursor != strconv.Itoa(messagePageSize) {
+		t.Errorf("Expected a query since the last refresh, then one after the first page, got %+v", client.queries)
+	}
+
+	// The next refresh resumes at the cursor, and keeps the pages fetched
+	// before a failure
+	client.queries = nil
+	for i := 0; i < messagePageSize+10; i++ {
+		client.AddMessage(mcp.Message{Source: "agent", Content: "new"})
+	}
+	client.failAt = strconv.Itoa(2*messagePageSize + 10)
+	model.applyRefresh(model.fetchData())
+	if len(client.queries) != 2 || !client.queries[0].Since.IsZero() || client.queries[0].Cursor != strconv.Itoa(messagePageSize+10) {
+		t.Errorf("Expected the refresh to resume at the cursor, got %+v", client.queries)
+	}
+	if model.err == nil || model.messageCursor != client.failAt {
+		t.Errorf("Expected the failure, with the cursor after the page fetched, got %v, cursor %q", model.err, model.messageCursor)
+	}
+}
+
+// TestTaskChangesUpdateTasks tests that the task changes reported by the
+// beads watcher are applied to the task list without listing the tasks
+func TestTaskChangesUpdateTasks(t *testing.T) {
+	tf := NewTestFramework()
+	model := *tf.GetModel()
+	changes := make(chan []beads.TaskChange, 1)
+
+	updated, _ := model.Update(sourcesMsg{taskChanges: changes})
+	model = updated.(Model)
+	if model.pollInterval() != fallbackPollInterval {
+		t.Errorf("pollInterval() = %v, want the slow fallback while tasks are watched", model.pollInterval())
+	}
+	if result := model.fetchData(); result.tasksFetched {
+		t.Error("Expected the poll not to list tasks while they are watched")
+	}
+
+	updated, _ = model.Update(tasksChangedMsg{changes: []beads.TaskChange{
+		{Kind: beads.ChangeCreated, Task: beads.Task{ID: "task-1", Title: "First", Status: "open"}},
+		{Kind: beads.ChangeCreated, Task: beads.Task{ID: "task-2", Title: "Second", Status: "in_progress"}},
+		{Kind: beads.ChangeCreated, Task: beads.Task{ID: "task-3", Title: "Done", Status: "closed"}},
+	}})
+	model = updated.(Model)
+	if len(model.tasks) != 2 || model.tasks[0].ID != "task-1" || model.tasks[1].ID != "task-2" || !model.tasksLoaded {
+		t.Fatalf("Expected the open and in-progress tasks, got %+v", model.tasks)
+	}
+
+	changes <- []beads.TaskChange{
+		{Kind: beads.ChangeUpdated, Task: beads.Task{ID: "task-1", Title: "First", Status: "closed"}},
+		{Kind: beads.ChangeUpdated, Task: beads.Task{ID: "task-2", Title: "Second", Status: "in_progress", Assignee: "coder"}},
+		{Kind: beads.ChangeUpdated, Task: beads.Task{ID: "task-3", Title: "Done", Status: "open"}},
+	}
+	updated, cmd := model.Update(tasksChangedMsg{})
+	model = updated.(Model)
+	if cmd == nil {
+		t.Fatal("Expected a task change to wait for the next one")
+	}
+	updated, _ = model.Update(cmd())
+	model = updated.(Model)
+	if len(model.tasks) != 2 || model.tasks[0].Assignee != "coder" || model.tasks[1].ID != "task-3" {
+		t.Errorf("Expected task-1 closed, task-2 reassigned, and task-3 reopened, got %+v", model.tasks)
+	}
+
+	updated, _ = model.Update(tasksChangedMsg{changes: []beads.TaskChange{{Kind: beads.ChangeDeleted, Task: beads.Task{ID: "task-2", Status: "in_progress"}}}})
+	model = updated.(Model)
+	if len(model.tasks) != 1 || model.tasks[0].ID != "task-3" {
+		t.Errorf("Expected task-2 deleted, got %+v", model.tasks)
+	}
+}
+
+// TestPollIntervalWithoutWatcher tests that beads is polled quickly when
+// task changes cannot be watched
+func TestPollIntervalWithoutWatcher(t *testing.T) {
+	tf := NewTestFramework()
+	if got := tf.GetModel().pollInterval(); got != fastPollInterval {
+		t.Errorf("pollInterval() = %v, want %v", got, fastPollInterval)
+	}
+}
+
 // TestRefreshBeadsCmd tests the refreshBeadsCmd function
 func TestRefreshBeadsCmd(t *testing.T) {
 	tf := NewTestFramework()
@@ -586,7 +732,7 @@ func TestRefreshBeadsCmd(t *testing.T) {
 
 // TestTickCmd tests the tickCmd function
 func TestTickCmd(t *testing.T) {
-	cmd := tickCmd()
+	cmd := tickCmd(fallbackPollInterval)
 	if cmd == nil {
 		t.Fatal("tickCmd should return a command")
 	}
@@ -630,6 +776,22 @@ func TestWaitForWSEventCmd(t *testing.T) {
 	// but we can verify the command is valid
 }
 
+// TestEventWatcher tests that a gRPC server streams the TUI's events
+func TestEventWatcher(t *testing.T) {
+	grpcClient, err := mcp.NewGRPCClient("http://localhost:8765", mcp.Options{})
+	if err != nil {
+		t.Fatalf("NewGRPCClient() error = %v", err)
+	}
+	defer grpcClient.Close()
+
+	if _, ok := eventWatcher(mcp.NewRouter([]mcp.Server{{Name: "mcp_agent_mail", Client: grpcClient}}, nil)); !ok {
+		t.Error("Expected the gRPC client of a single server to watch its events")
+	}
+	if _, ok := eventWatcher(mcp.NewHTTPClient("http://localhost:8765")); ok {
+		t.Error("Expected an HTTP client to leave events to the WebSocket")
+	}
+}
+
 // TestConfigReloadMsg tests the configReloadMsg type
 func TestConfigReloadMsg(t *testing.T) {
 	cfg := &config.Config{
@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"context"
+
 	"github.com/rand/asc/internal/config"
 	"github.com/rand/asc/internal/process"
 )
@@ -18,8 +20,9 @@ func (a *processManagerAdapter) Start(name, command string, args []string, env [
 	return a.pm.Start(name, command, args, env)
 }
 
+// Stop gives the process its full grace period; reloads are not cancelled
 func (a *processManagerAdapter) Stop(pid int) error {
-	return a.pm.Stop(pid)
+	return a.pm.Stop(context.Background(), pid)
 }
 
 func (a *processManagerAdapter) IsRunning(pid int) bool {
@@ -1,106 +1,145 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/health"
+	"github.com/rand/asc/internal/logger"
 	"github.com/rand/asc/internal/mcp"
+	"github.com/rand/asc/internal/process"
 )
 
 // refreshDataCmd returns a command that refreshes all data sources
-// This is used for initial load and manual refresh
+// This is used for initial load, manual refresh, and the fallback poll
 func refreshDataCmd(m Model) tea.Cmd {
 	return func() tea.Msg {
-		// Perform data refresh
-		if err := m.refreshData(); err != nil {
-			return refreshDataMsg{err: err}
-		}
-		return refreshDataMsg{err: nil}
+		// Commands run on a copy of the model, so the fetched data is
+		// carried back to Update to be applied
+		result := m.fetchData()
+		return refreshDataMsg{result: &result}
 	}
 }
 
 // refreshBeadsCmd returns a command that refreshes only beads data
-// This is used for periodic polling since beads is git-based and cannot be real-time
+// This is used when the beads watcher reports a task change
 func refreshBeadsCmd(m Model) tea.Cmd {
 	return func() tea.Msg {
-		// Perform beads refresh only
-		if err := m.refreshBeadsData(); err != nil {
-			return refreshDataMsg{err: err}
+		result := m.fetchBeadsData()
+		return refreshDataMsg{result: &result}
+	}
+}
+
+// refreshProcessesCmd returns a command that re-checks agent health and
+// refreshes all data sources. It is used when an agent process starts or
+// exits, so a crash shows up without waiting for the next health check.
+func refreshProcessesCmd(m Model) tea.Cmd {
+	return func() tea.Msg {
+		if m.healthMonitor != nil {
+			m.healthMonitor.CheckNow()
 		}
-		return refreshDataMsg{err: nil}
+		result := m.fetchData()
+		return refreshDataMsg{result: &result}
 	}
 }
 
-// refreshData fetches fresh data from all sources
-// Used for initial load and manual refresh
+// refreshResult is the data fetched by one refresh
+type refreshResult struct {
+	refreshTime time.Time // When the refresh started; zero for beads-only refreshes
+
+	agents      []mcp.AgentStatus // Agent statuses, nil if not fetched
+	agentsErr   error
+	messages    []mcp.Message // Messages since the last refresh
+	messageCursor string      // Where the next refresh resumes fetching messages
+	messagesErr error
+	mcpFetched  bool // Whether MCP was polled (it is not while the WebSocket is connected)
+
+	tasks        []beads.Task
+	tasksErr     error
+	tasksFetched bool // Whether beads was listed (it is not while task changes are watched)
+
+	healthIssues  []health.HealthIssue
+	recovery      []mcp.Message // Recovery actions since the last refresh
+	healthFetched bool
+
+	processHealth map[string]process.HealthStatus // Health check status per agent, nil if not fetched
+
+	logsErr error
+}
+
+// refreshData fetches fresh data from all sources and applies it
 func (m *Model) refreshData() error {
+	m.applyRefresh(m.fetchData())
+	return nil
+}
+
+// refreshBeadsData fetches fresh data from beads only and applies it
+func (m *Model) refreshBeadsData() error {
+	m.applyRefresh(m.fetchBeadsData())
+	return nil
+}
+
+// fetchData fetches fresh data from all sources without changing the model
+func (m Model) fetchData() refreshResult {
 	// Track when this refresh started
-	refreshTime := time.Now()
+	result := refreshResult{refreshTime: time.Now()}
+	logger.StartCorrelation()
+	ctx, cancel := m.callContext()
+	defer cancel()
+
+	// MCP, beads, and the health monitor are independent, so they are
+	// fetched concurrently and a refresh takes as long as the slowest
+	// rather than their sum
+	var wg sync.WaitGroup
 
 	// Fetch agent statuses from MCP client (only if WebSocket is not connected)
 	if !m.wsConnected {
-		var agents []mcp.AgentStatus
-		var err error
-		
-		// Check if the client supports GetAllAgentStatuses
-		if httpClient, ok := m.mcpClient.(*mcp.HTTPClient); ok {
-			agents, err = httpClient.GetAllAgentStatuses(30 * time.Second)
-		} else {
-			// Fallback: build agent list from config and query each individually
-			agents = make([]mcp.AgentStatus, 0, len(m.config.Agents))
-			for agentName := range m.config.Agents {
-				status, statusErr := m.mcpClient.GetAgentStatus(agentName)
-				if statusErr != nil {
-					// Agent not found or error - mark as offline
-					agents = append(agents, mcp.AgentStatus{
-						Name:  agentName,
-						State: mcp.StateOffline,
-					})
-				} else {
-					agents = append(agents, status)
-				}
-			}
-		}
-		
-		if err != nil {
-			// Don't fail completely on agent status errors - just log and continue
-			// This allows the TUI to remain functional even if MCP is temporarily unavailable
-			m.err = err
-		} else {
-			m.agents = agents
-			m.err = nil
-		}
+		result.mcpFetched = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// One request covers every agent; agents the server does not
+			// know are shown as offline
+			result.agents, result.agentsErr = m.mcpClient.GetAllAgentStatuses(ctx, 30 * time.Second)
 
-		// Fetch messages from MCP client since last refresh (only if WebSocket is not connected)
-		messages, err := m.mcpClient.GetMessages(m.lastRefresh)
-		if err != nil {
-			// Don't fail completely on message fetch errors
-			m.err = err
-		} else {
-			// Append new messages to existing messages
-			m.messages = append(m.messages, messages...)
-			
-			// Limit message buffer to last 100 messages
-			if len(m.messages) > 100 {
-				m.messages = m.messages[len(m.messages)-100:]
-			}
-		}
+			// Fetch messages from MCP client since last refresh
+			result.messages, result.messageCursor, result.messagesErr = m.fetchMessages(ctx)
+		}()
+	}
+
+	// Send the messages queued while mcp_agent_mail was unavailable, once
+	// it answers again
+	if flusher, ok := m.mcpClient.(mcp.OutboxFlusher); ok {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			flusher.FlushOutbox(ctx)
+		}()
 	}
 
 	// Fetch tasks from beads client with statuses "open" and "in_progress"
-	if err := m.refreshBeadsData(); err != nil {
-		return err
+	// (only if task changes are not watched)
+	if m.taskChanges == nil {
+		result.tasksFetched = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.tasks, result.tasksErr = m.beadsClient.GetTasks(ctx, []string{"open", "in_progress"})
+		}()
 	}
 
 	// Fetch health issues from health monitor
 	if m.healthMonitor != nil {
-		m.healthIssues = m.healthMonitor.GetHealthIssues()
+		result.healthFetched = true
+		result.healthIssues = m.healthMonitor.GetHealthIssues()
 		
 		// Get recent recovery actions and add them as messages
-		recoveryActions := m.healthMonitor.GetRecoveryActions()
-		for _, action := range recoveryActions {
+		for _, action := range m.healthMonitor.GetRecoveryActions() {
 			// Only add actions that occurred since last refresh
 			if action.Timestamp.After(m.lastRefresh) {
 				msgType := mcp.TypeMessage
@@ -110,49 +149,192 @@ func (m *Model) refreshData() error {
 					content += fmt.Sprintf(" - FAILED: %s", action.ErrorMsg)
 				}
 				
-				msg := mcp.Message{
+				result.recovery = append(result.recovery, mcp.Message{
 					Timestamp: action.Timestamp,
 					Type:      msgType,
 					Source:    "health-monitor",
 					Content:   content,
-				}
-				m.messages = append(m.messages, msg)
+				})
 			}
 		}
-		
-		// Limit message buffer to last 100 messages
-		if len(m.messages) > 100 {
-			m.messages = m.messages[len(m.messages)-100:]
+	}
+
+	// Read the health check status the process manager saved per agent
+	if m.procManager != nil {
+		result.processHealth = make(map[string]process.HealthStatus)
+		for _, name := range m.getAgentNames() {
+			if info, err := m.procManager.GetProcessInfo(name); err == nil && info != nil && info.Health != nil {
+				result.processHealth[name] = info.Health.Status
+			}
 		}
 	}
 
 	// Collect aggregated logs from all agents
 	if m.logAggregator != nil {
-		if err := m.logAggregator.CollectLogs(); err != nil {
-			// Don't fail completely on log collection errors
-			m.err = err
+		result.logsErr = m.logAggregator.CollectLogs()
+	}
+
+	wg.Wait()
+	return result
+}
+
+// Pages of messages fetched by one refresh from servers that page them.
+// Messages beyond them are fetched by the next refresh.
+const (
+	messagePageSize = 500
+	maxMessagePages = 10
+)
+
+// fetchMessages fetches the messages sent since the last refresh. Servers
+// that filter and page messages are asked only for those after the
+// cursor of the last refresh, a page at a time; it returns the cursor
+// the next refresh resumes at, with the pages fetched before a failure.
+func (m Model) fetchMessages(ctx context.Context) ([]mcp.Message, string, error) {
+	querier, ok := m.mcpClient.(mcp.MessageQuerier)
+	if !ok {
+		messages, err := m.mcpClient.GetMessages(ctx, m.lastRefresh)
+		return messages, "", err
+	}
+
+	query := mcp.MessageQuery{Cursor: m.messageCursor, Limit: messagePageSize}
+	if query.Cursor == "" {
+		query.Since = m.lastRefresh
+	}
+	var messages []mcp.Message
+	for i := 0; i < maxMessagePages; i++ {
+		page, err := querier.QueryMessages(ctx, query)
+		if err != nil {
+			return messages, query.Cursor, err
+		}
+		messages = append(messages, page.Messages...)
+		if page.NextCursor != "" {
+			query.Cursor = page.NextCursor
+		}
+		if !page.More || page.NextCursor == "" {
+			break
 		}
 	}
+	return messages, query.Cursor, nil
+}
 
-	// Update last refresh time
-	m.lastRefresh = refreshTime
+// fetchBeadsData fetches fresh data from beads only
+func (m Model) fetchBeadsData() refreshResult {
+	// Each poll is a reconcile cycle with its own correlation ID
+	logger.StartCorrelation()
+	ctx, cancel := m.callContext()
+	defer cancel()
+	
+	result := refreshResult{tasksFetched: true}
+	result.tasks, result.tasksErr = m.beadsClient.GetTasks(ctx, []string{"open", "in_progress"})
+	return result
+}
 
-	return nil
+// applyTaskChanges returns tasks with changes applied, keeping the open
+// and in-progress tasks the TUI shows. Changed tasks keep their place and
+// new ones are appended, as they are in a listing, oldest first.
+func applyTaskChanges(tasks []beads.Task, changes []beads.TaskChange) []beads.Task {
+	tasks = append([]beads.Task(nil), tasks...)
+	for _, change := range changes {
+		index := -1
+		for i, task := range tasks {
+			if task.ID == change.Task.ID {
+				index = i
+				break
+			}
+		}
+		shown := change.Kind != beads.ChangeDeleted && (change.Task.Status == "open" || change.Task.Status == "in_progress")
+		switch {
+		case shown && index >= 0:
+			tasks[index] = change.Task
+		case shown:
+			tasks = append(tasks, change.Task)
+		case index >= 0:
+			tasks = append(tasks[:index], tasks[index+1:]...)
+		}
+	}
+	return tasks
 }
 
-// refreshBeadsData fetches fresh data from beads only
-// Used for periodic polling since beads is git-based
-func (m *Model) refreshBeadsData() error {
-	// Fetch tasks from beads client with statuses "open" and "in_progress"
-	tasks, err := m.beadsClient.GetTasks([]string{"open", "in_progress"})
-	if err != nil {
-		// Don't fail completely on task fetch errors
-		m.err = err
-		m.beadsConnected = false
-	} else {
-		m.tasks = tasks
-		m.beadsConnected = true
+// applyRefresh updates the model with the data of a refresh. Errors from
+// individual sources are recorded but do not discard the data of the
+// others, so the TUI stays usable while MCP or beads is unavailable.
+func (m *Model) applyRefresh(result refreshResult) {
+	if result.mcpFetched {
+		if result.agentsErr != nil {
+			m.err = result.agentsErr
+		} else {
+			m.agents = result.agents
+			m.err = nil
+		}
+		// Pages fetched before a failure are kept, as the cursor is past them
+		m.appendMessages(result.messages...)
+		m.messageCursor = result.messageCursor
+		if result.messagesErr != nil {
+			m.err = result.messagesErr
+		}
 	}
 
-	return nil
+	if result.tasksFetched {
+		m.tasksLoaded = true
+		if result.tasksErr != nil {
+			m.err = result.tasksErr
+			m.beadsConnected = false
+		} else {
+			m.tasks = result.tasks
+			m.beadsConnected = true
+		}
+	}
+
+	if result.healthFetched {
+		m.healthIssues = result.healthIssues
+		m.appendMessages(result.recovery...)
+	}
+
+	if result.processHealth != nil {
+		m.processHealth = result.processHealth
+	}
+
+	if result.logsErr != nil {
+		m.err = result.logsErr
+	}
+
+	// Update last refresh time
+	if !result.refreshTime.IsZero() {
+		m.lastRefresh = result.refreshTime
+		m.agentsLoaded = true
+	}
+}
+
+// appendMessages adds messages to the log, keeping the newest
+// historyLimit in memory. Older ones are spilled to the message history,
+// or dropped if there is none.
+func (m *Model) appendMessages(messages ...mcp.Message) {
+	// Keep a scrolled-up log on the messages it shows
+	if m.logScroll > 0 {
+		for _, msg := range messages {
+			if m.matchesLogFilter(msg) {
+				m.logScroll++
+			}
+		}
+	}
+	m.messages = append(m.messages, messages...)
+	limit := m.historyLimit
+	if limit <= 0 {
+		limit = defaultMessageHistory
+	}
+	if len(m.messages) <= limit {
+		return
+	}
+
+	overflow := m.messages[:len(m.messages)-limit]
+	if m.history != nil {
+		if err := m.history.Spill(overflow); err != nil {
+			m.err = err
+		} else if m.olderMessages != nil {
+			// Keep the messages read back contiguous with those in memory
+			// while the log is scrolled up
+			m.olderMessages = append(m.olderMessages, overflow...)
+		}
+	}
+	m.messages = m.messages[len(m.messages)-limit:]
 }
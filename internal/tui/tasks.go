@@ -7,18 +7,21 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/i18n"
 )
 
 // Task status icons
 const (
 	iconOpen       = "○" // Empty circle for open tasks
 	iconInProgress = "◉" // Filled circle with dot for in-progress tasks
+	iconBlocked    = "✗" // Cross for tasks waiting for others
 )
 
 // Color styles for task states
 var (
 	styleOpen       = lipgloss.NewStyle().Foreground(lipgloss.Color("245")) // Gray
 	styleInProgress = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true) // Yellow/Bold
+	styleBlocked    = lipgloss.NewStyle().Foreground(lipgloss.Color("9")) // Red
 )
 
 // Border style for the task pane
@@ -46,7 +49,11 @@ func (m Model) renderTaskPane(width, height int) string {
 	
 	// If no tasks, show a message
 	if len(lines) == 0 {
-		lines = append(lines, styleOpen.Render("No open or in-progress tasks"))
+		if m.tasksLoaded {
+			lines = append(lines, styleOpen.Render(i18n.T("tui.tasks.none")))
+		} else {
+			lines = append(lines, styleOpen.Render(i18n.T("tui.tasks.loading")))
+		}
 	}
 	
 	// Pad or truncate to fit height
@@ -55,15 +62,21 @@ func (m Model) renderTaskPane(width, height int) string {
 	// Join lines and apply border with title
 	contentStr := strings.Join(content, "\n")
 	
+	// Build title with the active query
+	title := i18n.T("tui.tasks.title")
+	if !m.taskFilter.Empty() {
+		title += " [" + m.taskFilter.String() + "]"
+	}
+	
 	// Add keybindings hint
-	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("↑↓:select c:claim v:view n:new")
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(i18n.T("tui.tasks.hint"))
 	
 	return taskPaneBorder.
 		Width(width - 2).
 		Height(height - 2).
 		Render(lipgloss.JoinVertical(
 			lipgloss.Left,
-			lipgloss.NewStyle().Bold(true).Render("Task Stream"),
+			lipgloss.NewStyle().Bold(true).Render(title),
 			hint,
 			contentStr,
 		))
@@ -73,6 +86,10 @@ func (m Model) renderTaskPane(width, height int) string {
 func (m Model) formatTaskLine(task beads.Task, maxWidth int, selected bool) string {
 	// Get icon and style based on status
 	icon, style := m.getTaskIconAndStyle(task.Status)
+	blockers := beads.Blockers(task, m.tasks)
+	if len(blockers) > 0 {
+		icon, style = iconBlocked, styleBlocked
+	}
 	
 	// Build the line: icon + ID + title
 	prefix := "  "
@@ -82,6 +99,9 @@ func (m Model) formatTaskLine(task beads.Task, maxWidth int, selected bool) stri
 	}
 	
 	line := fmt.Sprintf("%s%s #%s %s", prefix, icon, task.ID, task.Title)
+	if len(blockers) > 0 {
+		line += " " + i18n.T("tui.tasks.blocked_by", formatTaskIDs(blockers))
+	}
 	
 	// Truncate if too long
 	if len(line) > maxWidth {
@@ -103,12 +123,20 @@ func (m Model) getTaskIconAndStyle(status string) (string, lipgloss.Style) {
 		return iconInProgress, styleInProgress
 	case "open":
 		return iconOpen, styleOpen
+	case beads.StatusBlocked:
+		return iconBlocked, styleBlocked
 	default:
 		return iconOpen, styleOpen
 	}
 }
 
+// formatTaskIDs renders task IDs as a list, e.g. "#bd-1, #bd-2"
+func formatTaskIDs(ids []string) string {
+	return "#" + strings.Join(ids, ", #")
+}
+
 // filterTasksByStatus filters tasks by the given statuses (moved from inline to reusable)
+// and by the task query of the search bar
 func (m Model) filterTasksByStatus(statuses []string) []beads.Task {
 	statusMap := make(map[string]bool)
 	for _, status := range statuses {
@@ -117,7 +145,7 @@ func (m Model) filterTasksByStatus(statuses []string) []beads.Task {
 	
 	var filtered []beads.Task
 	for _, task := range m.tasks {
-		if statusMap[task.Status] {
+		if statusMap[task.Status] && m.taskFilter.Match(task) {
 			filtered = append(filtered, task)
 		}
 	}
@@ -133,10 +133,22 @@ func TestRenderTaskPane_FilteredStatuses(t *testing.T) {
 	}
 }
 
+// TestRenderTaskPane_Loading tests the placeholder shown before tasks are
+// first fetched
+func TestRenderTaskPane_Loading(t *testing.T) {
+	tf := NewTestFramework()
+	model := tf.GetModel()
+
+	if pane := model.renderTaskPane(80, 20); !strings.Contains(pane, "Loading tasks") {
+		t.Error("Task pane should show a loading placeholder before tasks are fetched")
+	}
+}
+
 // TestRenderTaskPane_NoTasks tests rendering with no tasks
 func TestRenderTaskPane_NoTasks(t *testing.T) {
 	tf := NewTestFramework()
 	model := tf.GetModel()
+	model.tasksLoaded = true
 
 	pane := model.renderTaskPane(80, 20)
 
@@ -214,6 +226,48 @@ func TestFormatTaskLine_InProgress(t *testing.T) {
 	}
 }
 
+// TestFormatTaskLine_Blocked tests formatting a task waiting for another
+func TestFormatTaskLine_Blocked(t *testing.T) {
+	tf := NewTestFramework()
+	model := tf.GetModel()
+	model.tasks = []beads.Task{
+		{ID: "task-1", Title: "Schema", Status: "in_progress"},
+		{ID: "task-2", Title: "API", Status: "open", Dependencies: []string{"task-1", "task-0"}},
+	}
+
+	line := model.formatTaskLine(model.tasks[1], 80, false)
+	if !strings.Contains(line, iconBlocked) {
+		t.Error("Blocked task should contain blocked icon")
+	}
+	if !strings.Contains(line, "#task-1") || strings.Contains(line, "task-0") {
+		t.Errorf("Blocked task should name its open blockers only, got %q", line)
+	}
+
+	line = model.formatTaskLine(model.tasks[0], 80, false)
+	if strings.Contains(line, iconBlocked) {
+		t.Error("Task without blockers should not contain blocked icon")
+	}
+}
+
+// TestClaimBlockedTask tests that blocked tasks cannot be claimed
+func TestClaimBlockedTask(t *testing.T) {
+	tf := NewTestFramework()
+	model := tf.GetModel()
+	model.tasks = []beads.Task{
+		{ID: "task-1", Title: "Schema", Status: "open"},
+		{ID: "task-2", Title: "API", Status: "open", Dependencies: []string{"task-1"}},
+	}
+	model.selectedTaskIndex = 1
+
+	msg, ok := claimTaskCmd(*model)().(taskActionMsg)
+	if !ok {
+		t.Fatal("Expected a task action message")
+	}
+	if msg.success || !strings.Contains(msg.message, "blocked by #task-1") {
+		t.Errorf("Expected claiming a blocked task to fail, got %+v", msg)
+	}
+}
+
 // TestFormatTaskLine_Selected tests formatting selected task
 func TestFormatTaskLine_Selected(t *testing.T) {
 	tf := NewTestFramework()
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -37,6 +38,9 @@ func NewTestFramework() *TestFramework {
 				URL:          "http://localhost:8765",
 			},
 		},
+		TUI: config.TUIConfig{
+			MessageHistory: 100,
+		},
 		Agents: map[string]config.AgentConfig{
 			"test-agent-1": {
 				Command: "python",
@@ -135,9 +139,9 @@ func (tf *TestFramework) SetAgentStatus(status mcp.AgentStatus) {
 // RefreshData forces a data refresh
 func (tf *TestFramework) RefreshData() Model {
 	// Manually trigger refresh
-	tf.model.agents, _ = tf.mcpClient.GetAllAgentStatuses(2 * time.Minute)
-	tf.model.tasks, _ = tf.beadsClient.GetTasks([]string{"open", "in_progress"})
-	tf.model.messages, _ = tf.mcpClient.GetMessages(tf.model.lastRefresh)
+	tf.model.agents, _ = tf.mcpClient.GetAllAgentStatuses(context.Background(), 2 * time.Minute)
+	tf.model.tasks, _ = tf.beadsClient.GetTasks(context.Background(), []string{"open", "in_progress"})
+	tf.model.messages, _ = tf.mcpClient.GetMessages(context.Background(), tf.model.lastRefresh)
 	tf.model.lastRefresh = time.Now()
 	return tf.model
 }
@@ -160,7 +164,7 @@ func (m *MockBeadsClient) AddTask(task beads.Task) {
 }
 
 // GetTasks returns tasks matching the given statuses
-func (m *MockBeadsClient) GetTasks(statuses []string) ([]beads.Task, error) {
+func (m *MockBeadsClient) GetTasks(ctx context.Context, statuses []string) ([]beads.Task, error) {
 	if len(statuses) == 0 {
 		return m.tasks, nil
 	}
@@ -178,7 +182,7 @@ func (m *MockBeadsClient) GetTasks(statuses []string) ([]beads.Task, error) {
 }
 
 // CreateTask creates a new task
-func (m *MockBeadsClient) CreateTask(title string) (beads.Task, error) {
+func (m *MockBeadsClient) CreateTask(ctx context.Context, title string) (beads.Task, error) {
 	task := beads.Task{
 		ID:     "mock-task-id",
 		Title:  title,
@@ -189,7 +193,7 @@ func (m *MockBeadsClient) CreateTask(title string) (beads.Task, error) {
 }
 
 // UpdateTask updates an existing task
-func (m *MockBeadsClient) UpdateTask(id string, updates beads.TaskUpdate) error {
+func (m *MockBeadsClient) UpdateTask(ctx context.Context, id string, updates beads.TaskUpdate) error {
 	for i, task := range m.tasks {
 		if task.ID == id {
 			if updates.Assignee != nil {
@@ -204,7 +208,7 @@ func (m *MockBeadsClient) UpdateTask(id string, updates beads.TaskUpdate) error
 }
 
 // DeleteTask deletes a task
-func (m *MockBeadsClient) DeleteTask(id string) error {
+func (m *MockBeadsClient) DeleteTask(ctx context.Context, id string) error {
 	for i, task := range m.tasks {
 		if task.ID == id {
 			m.tasks = append(m.tasks[:i], m.tasks[i+1:]...)
@@ -215,7 +219,7 @@ func (m *MockBeadsClient) DeleteTask(id string) error {
 }
 
 // Refresh refreshes the task list
-func (m *MockBeadsClient) Refresh() error {
+func (m *MockBeadsClient) Refresh(ctx context.Context) error {
 	return nil
 }
 
@@ -244,7 +248,7 @@ func (m *MockMCPClient) SetAgentStatus(status mcp.AgentStatus) {
 }
 
 // GetMessages returns messages since the given time
-func (m *MockMCPClient) GetMessages(since time.Time) ([]mcp.Message, error) {
+func (m *MockMCPClient) GetMessages(ctx context.Context, since time.Time) ([]mcp.Message, error) {
 	filtered := []mcp.Message{}
 	for _, msg := range m.messages {
 		if msg.Timestamp.After(since) || msg.Timestamp.Equal(since) {
@@ -255,13 +259,13 @@ func (m *MockMCPClient) GetMessages(since time.Time) ([]mcp.Message, error) {
 }
 
 // SendMessage sends a message
-func (m *MockMCPClient) SendMessage(msg mcp.Message) error {
+func (m *MockMCPClient) SendMessage(ctx context.Context, msg mcp.Message) error {
 	m.messages = append(m.messages, msg)
 	return nil
 }
 
 // GetAgentStatus returns an agent's status
-func (m *MockMCPClient) GetAgentStatus(agentName string) (mcp.AgentStatus, error) {
+func (m *MockMCPClient) GetAgentStatus(ctx context.Context, agentName string) (mcp.AgentStatus, error) {
 	if status, ok := m.statuses[agentName]; ok {
 		return status, nil
 	}
@@ -272,7 +276,7 @@ func (m *MockMCPClient) GetAgentStatus(agentName string) (mcp.AgentStatus, error
 }
 
 // GetAllAgentStatuses returns all agent statuses
-func (m *MockMCPClient) GetAllAgentStatuses(offlineThreshold time.Duration) ([]mcp.AgentStatus, error) {
+func (m *MockMCPClient) GetAllAgentStatuses(ctx context.Context, offlineThreshold time.Duration) ([]mcp.AgentStatus, error) {
 	statuses := []mcp.AgentStatus{}
 	for _, status := range m.statuses {
 		statuses = append(statuses, status)
@@ -281,7 +285,7 @@ func (m *MockMCPClient) GetAllAgentStatuses(offlineThreshold time.Duration) ([]m
 }
 
 // ReleaseAgentLeases releases all leases for an agent
-func (m *MockMCPClient) ReleaseAgentLeases(agentName string) error {
+func (m *MockMCPClient) ReleaseAgentLeases(ctx context.Context, agentName string) error {
 	return nil
 }
 
@@ -312,7 +316,7 @@ func (m *MockProcessManager) Start(name string, command string, args []string, e
 }
 
 // Stop stops a process
-func (m *MockProcessManager) Stop(pid int) error {
+func (m *MockProcessManager) Stop(ctx context.Context, pid int) error {
 	for name, info := range m.processes {
 		if info.PID == pid {
 			delete(m.processes, name)
@@ -323,7 +327,7 @@ func (m *MockProcessManager) Stop(pid int) error {
 }
 
 // StopAll stops all processes
-func (m *MockProcessManager) StopAll() error {
+func (m *MockProcessManager) StopAll(ctx context.Context) error {
 	m.processes = make(map[string]*process.ProcessInfo)
 	return nil
 }
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -92,7 +93,7 @@ func TestMockBeadsClient(t *testing.T) {
 	task1 := beads.Task{ID: "1", Title: "Task 1", Status: "open"}
 	client.AddTask(task1)
 
-	tasks, err := client.GetTasks([]string{"open"})
+	tasks, err := client.GetTasks(context.Background(), []string{"open"})
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -102,7 +103,7 @@ func TestMockBeadsClient(t *testing.T) {
 	}
 
 	// Test creating tasks
-	newTask, err := client.CreateTask("New Task")
+	newTask, err := client.CreateTask(context.Background(), "New Task")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -113,23 +114,23 @@ func TestMockBeadsClient(t *testing.T) {
 
 	// Test updating tasks
 	assignee := "test-user"
-	err = client.UpdateTask("1", beads.TaskUpdate{Assignee: &assignee})
+	err = client.UpdateTask(context.Background(), "1", beads.TaskUpdate{Assignee: &assignee})
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	tasks, _ = client.GetTasks([]string{"open"})
+	tasks, _ = client.GetTasks(context.Background(), []string{"open"})
 	if tasks[0].Assignee != "test-user" {
 		t.Errorf("Expected assignee 'test-user', got %s", tasks[0].Assignee)
 	}
 
 	// Test deleting tasks
-	err = client.DeleteTask("1")
+	err = client.DeleteTask(context.Background(), "1")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	tasks, _ = client.GetTasks([]string{"open"})
+	tasks, _ = client.GetTasks(context.Background(), []string{"open"})
 	if len(tasks) != 1 { // Only the created task should remain
 		t.Errorf("Expected 1 task after deletion, got %d", len(tasks))
 	}
@@ -148,7 +149,7 @@ func TestMockMCPClient(t *testing.T) {
 	}
 	client.AddMessage(msg)
 
-	messages, err := client.GetMessages(time.Now().Add(-1 * time.Minute))
+	messages, err := client.GetMessages(context.Background(), time.Now().Add(-1*time.Minute))
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -158,12 +159,12 @@ func TestMockMCPClient(t *testing.T) {
 	}
 
 	// Test sending messages
-	err = client.SendMessage(msg)
+	err = client.SendMessage(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	messages, _ = client.GetMessages(time.Now().Add(-1 * time.Minute))
+	messages, _ = client.GetMessages(context.Background(), time.Now().Add(-1*time.Minute))
 	if len(messages) != 2 {
 		t.Errorf("Expected 2 messages, got %d", len(messages))
 	}
@@ -175,7 +176,7 @@ func TestMockMCPClient(t *testing.T) {
 	}
 	client.SetAgentStatus(status)
 
-	retrievedStatus, err := client.GetAgentStatus("test-agent")
+	retrievedStatus, err := client.GetAgentStatus(context.Background(), "test-agent")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -185,7 +186,7 @@ func TestMockMCPClient(t *testing.T) {
 	}
 
 	// Test getting all statuses
-	statuses, err := client.GetAllAgentStatuses(2 * time.Minute)
+	statuses, err := client.GetAllAgentStatuses(context.Background(), 2*time.Minute)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -241,7 +242,7 @@ func TestMockProcessManager(t *testing.T) {
 	}
 
 	// Test stopping a process
-	err = pm.Stop(pid)
+	err = pm.Stop(context.Background(), pid)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -254,7 +255,7 @@ func TestMockProcessManager(t *testing.T) {
 	pm.Start("agent1", "python", []string{}, []string{})
 	pm.Start("agent2", "python", []string{}, []string{})
 
-	err = pm.StopAll()
+	err = pm.StopAll(context.Background())
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
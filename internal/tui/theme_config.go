@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/rand/asc/internal/statedir"
 )
 
 // ThemeConfig represents a theme configuration file
@@ -24,9 +25,9 @@ type ThemeManager struct {
 
 // NewThemeManager creates a new theme manager
 func NewThemeManager() *ThemeManager {
-	homeDir, _ := os.UserHomeDir()
-	themesDir := filepath.Join(homeDir, ".asc", "themes")
-	configPath := filepath.Join(homeDir, ".asc", "theme.json")
+	stateDir, _ := statedir.Dir()
+	themesDir := filepath.Join(stateDir, "themes")
+	configPath := filepath.Join(stateDir, "theme.json")
 	
 	// Ensure themes directory exists
 	os.MkdirAll(themesDir, 0755)
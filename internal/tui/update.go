@@ -8,14 +8,20 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/rand/asc/internal/audit"
 	"github.com/rand/asc/internal/beads"
+	"github.com/rand/asc/internal/budget"
+	"github.com/rand/asc/internal/config"
 	"github.com/rand/asc/internal/logger"
 	"github.com/rand/asc/internal/mcp"
+	"github.com/rand/asc/internal/pipeline"
+	"github.com/rand/asc/internal/process"
 )
 
 // refreshDataMsg is sent when data refresh is complete
 type refreshDataMsg struct {
-	err error
+	err    error
+	result *refreshResult // The fetched data, applied by Update
 }
 
 // testResultMsg is sent when test command completes
@@ -39,6 +45,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case refreshDataMsg:
 		return m.handleRefresh(msg)
 
+	case sourcesMsg:
+		return m.handleSources(msg)
+
+	case tasksChangedMsg:
+		// The changes are applied as they are, without listing the tasks
+		m.tasks = applyTaskChanges(m.tasks, msg.changes)
+		m.tasksLoaded = true
+		m.beadsConnected = true
+		return m, waitForTaskChangesCmd(m.taskChanges)
+
+	case processesChangedMsg:
+		return m, tea.Batch(waitForChangeCmd(m.procWatcher, processesChangedMsg{}), refreshProcessesCmd(m))
+
 	case testResultMsg:
 		return m.handleTestResult(msg)
 
@@ -48,6 +67,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case taskActionMsg:
 		return m.handleTaskAction(msg)
 		
+	case taskHistoryMsg:
+		m.taskHistory = msg
+		m.showTaskModal = false
+		m.showHistoryModal = true
+		return m, nil
+		
 	case agentActionMsg:
 		return m.handleAgentAction(msg)
 		
@@ -56,6 +81,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		
 	case configReloadMsg:
 		return m.handleConfigReload(msg)
+
+	case pipelineEventMsg:
+		return m.handlePipelineEvent(msg)
+
+	case budgetEventMsg:
+		return m.handleBudgetEvent(msg)
 	}
 
 	return m, nil
@@ -83,6 +114,18 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			// Close modal
 			m.showTaskModal = false
 			return m, nil
+		case "h":
+			// Switch to the task's history
+			return m, taskHistoryCmd(m)
+		}
+		return m, nil
+	}
+	
+	// Handle task history modal
+	if m.showHistoryModal {
+		switch msg.String() {
+		case "esc", "h":
+			m.showHistoryModal = false
 		}
 		return m, nil
 	}
@@ -90,7 +133,10 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Normal key handling
 	switch msg.String() {
 	case "q", "ctrl+c":
-		// Quit - trigger shutdown sequence
+		// Quit - abandon in-flight calls and trigger shutdown sequence
+		if m.cancel != nil {
+			m.cancel()
+		}
 		return m, tea.Quit
 
 	case "r":
@@ -119,19 +165,29 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		
 	case "c":
 		// Claim selected task
-		return m, claimTaskCmd(m)
+		return m, auditedCmd("tui claim task", nil, claimTaskCmd(m))
 		
 	case "v":
 		// View task details
 		m.showTaskModal = true
 		return m, nil
 		
+	case "h":
+		// View who changed the selected task
+		return m, taskHistoryCmd(m)
+		
 	case "n":
 		// Create new task
 		m.showCreateModal = true
 		m.createTaskInput = ""
 		return m, nil
 		
+	case "f":
+		// Filter tasks with a query in the search bar
+		m.searchMode = true
+		m.searchTasks = true
+		return m, nil
+		
 	// Agent control keys
 	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
 		// Select agent by number
@@ -165,6 +221,7 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "/":
 		// Enter search mode
 		m.searchMode = true
+		m.searchTasks = false
 		m.searchInput = ""
 		return m, nil
 		
@@ -186,6 +243,19 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.logFilterAgent = ""
 		m.logFilterType = ""
 		return m, nil
+		
+	// Log scrolling keys
+	case "pgup":
+		m.scrollLog(logScrollStep)
+		return m, nil
+		
+	case "pgdown":
+		m.scrollLog(-logScrollStep)
+		return m, nil
+		
+	case "end":
+		m.scrollLog(-m.logScroll)
+		return m, nil
 	}
 
 	return m, nil
@@ -198,24 +268,56 @@ func (m Model) handleResize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// handleTick processes periodic tick events
+// handleTick processes the fallback poll. Tasks, processes, and MCP data
+// normally arrive as events; the poll catches anything a watcher or the
+// WebSocket missed, and polls MCP while the WebSocket is down.
 func (m Model) handleTick() (tea.Model, tea.Cmd) {
-	// Schedule next tick and refresh beads data only
-	// MCP data is updated via WebSocket events
 	return m, tea.Batch(
-		tickCmd(),
-		refreshBeadsCmd(m),
+		tickCmd(m.pollInterval()),
+		refreshDataCmd(m),
 	)
 }
 
 // handleRefresh processes data refresh completion
 func (m Model) handleRefresh(msg refreshDataMsg) (tea.Model, tea.Cmd) {
+	if msg.result != nil {
+		m.applyRefresh(*msg.result)
+	}
 	if msg.err != nil {
 		m.err = msg.err
 	}
 	return m, nil
 }
 
+// handleSources stores the monitors, watchers, and clients started by
+// Init and starts listening to them
+func (m Model) handleSources(msg sourcesMsg) (tea.Model, tea.Cmd) {
+	m.healthMonitor = msg.healthMonitor
+	m.configWatcher = msg.configWatcher
+	m.reloadManager = msg.reloadManager
+	m.wsClient = msg.wsClient
+	m.taskChanges = msg.taskChanges
+	m.stopTaskWatch = msg.stopTaskWatch
+	m.procWatcher = msg.procWatcher
+	m.history = msg.history
+
+	cmds := []tea.Cmd{tickCmd(m.pollInterval())}
+	if m.configWatcher != nil {
+		cmds = append(cmds, waitForConfigReloadCmd(m.configWatcher))
+	}
+	if m.wsClient != nil {
+		// Attempt to connect (non-blocking) and listen for events
+		cmds = append(cmds, connectWebSocketCmd(m.wsClient), waitForWSEventCmd(m.wsClient))
+	}
+	if m.taskChanges != nil {
+		cmds = append(cmds, waitForTaskChangesCmd(m.taskChanges))
+	}
+	if m.procWatcher != nil {
+		cmds = append(cmds, waitForChangeCmd(m.procWatcher, processesChangedMsg{}))
+	}
+	return m, tea.Batch(cmds...)
+}
+
 // handleTestResult processes test command results
 func (m Model) handleTestResult(msg testResultMsg) (tea.Model, tea.Cmd) {
 	// Update error state based on test result
@@ -236,11 +338,25 @@ func (m Model) handleWSEvent(msg wsEventMsg) (tea.Model, tea.Cmd) {
 	case mcp.EventConnected:
 		// WebSocket connected successfully
 		m.wsConnected = true
+		m.wsState = mcp.StreamConnected
 		m.err = nil
 		
+	case mcp.EventDegraded:
+		// WebSocket dropped - reconnecting and resuming; poll meanwhile
+		m.wsConnected = false
+		m.wsState = mcp.StreamDegraded
+		
 	case mcp.EventDisconnected:
-		// WebSocket disconnected - will auto-reconnect
+		// WebSocket could not reconnect for a while - still retrying
 		m.wsConnected = false
+		m.wsState = mcp.StreamDisconnected
+		
+	case mcp.EventResync:
+		// Events were missed while reconnecting: fetch MCP data, which
+		// a refresh skips while the WebSocket is connected
+		stale := m
+		stale.wsConnected = false
+		return m, tea.Batch(refreshDataCmd(stale), waitForWSEventCmd(m.wsClient))
 		
 	case mcp.EventAgentStatus:
 		// Agent status changed - update the agent in our list
@@ -251,12 +367,10 @@ func (m Model) handleWSEvent(msg wsEventMsg) (tea.Model, tea.Cmd) {
 	case mcp.EventNewMessage:
 		// New message received - add to message list
 		if event.Message != nil {
-			m.messages = append(m.messages, *event.Message)
-			
-			// Limit message buffer to last 100 messages
-			if len(m.messages) > 100 {
-				m.messages = m.messages[len(m.messages)-100:]
+			if event.Message.CorrelationID == "" {
+				event.Message.CorrelationID = event.CorrelationID
 			}
+			m.appendMessages(*event.Message)
 		}
 		
 	case mcp.EventError:
@@ -300,9 +414,11 @@ func (m Model) handleTaskAction(msg taskActionMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleAgentAction(msg agentActionMsg) (tea.Model, tea.Cmd) {
 	if !msg.success {
 		m.err = fmt.Errorf("%s", msg.message)
+		return m, nil
 	}
-	// Note: Agent status will be updated via WebSocket events
-	return m, nil
+	// Agent status will be updated via WebSocket events; re-check health
+	// now so a killed agent does not show as healthy until the next check
+	return m, refreshProcessesCmd(m)
 }
 
 // handleLogAction processes log action results
@@ -316,8 +432,13 @@ func (m Model) handleLogAction(msg logActionMsg) (tea.Model, tea.Cmd) {
 // runTestCmd executes the test command asynchronously
 func runTestCmd(m Model) tea.Cmd {
 	return func() tea.Msg {
+		// Each user action is traced under its own correlation ID
+		logger.StartCorrelation()
+		ctx, cancel := m.callContext()
+		defer cancel()
+		
 		// Create test task
-		task, err := m.beadsClient.CreateTask("asc test task")
+		task, err := m.beadsClient.CreateTask(ctx, "asc test task")
 		if err != nil {
 			return testResultMsg{
 				success: false,
@@ -331,9 +452,9 @@ func runTestCmd(m Model) tea.Cmd {
 			Source:  "asc-test",
 			Content: "test message",
 		}
-		if err := m.mcpClient.SendMessage(testMsg); err != nil {
+		if err := m.mcpClient.SendMessage(ctx, testMsg); err != nil {
 			// Clean up test task
-			_ = m.beadsClient.DeleteTask(task.ID)
+			_ = m.beadsClient.DeleteTask(ctx, task.ID)
 			return testResultMsg{
 				success: false,
 				message: fmt.Sprintf("Failed to send test message: %v", err),
@@ -341,7 +462,7 @@ func runTestCmd(m Model) tea.Cmd {
 		}
 
 		// Clean up test task
-		if err := m.beadsClient.DeleteTask(task.ID); err != nil {
+		if err := m.beadsClient.DeleteTask(ctx, task.ID); err != nil {
 			return testResultMsg{
 				success: false,
 				message: fmt.Sprintf("Failed to delete test task: %v", err),
@@ -368,7 +489,7 @@ func (m Model) handleCreateModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Create task
 		if m.createTaskInput != "" {
 			m.showCreateModal = false
-			return m, createTaskCmd(m, m.createTaskInput)
+			return m, auditedCmd("tui create task", []string{m.createTaskInput}, createTaskCmd(m, m.createTaskInput))
 		}
 		return m, nil
 		
@@ -396,9 +517,9 @@ func (m Model) handleConfirmModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.showConfirmModal = false
 		switch m.confirmAction {
 		case "kill":
-			return m, killAgentCmd(m)
+			return m, auditedCmd("tui kill agent", nil, killAgentCmd(m))
 		case "restart":
-			return m, restartAgentCmd(m)
+			return m, auditedCmd("tui restart agent", nil, restartAgentCmd(m))
 		}
 		return m, nil
 		
@@ -414,6 +535,9 @@ func (m Model) handleConfirmModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // handleSearchInput handles input when in search mode
 func (m Model) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.searchTasks {
+		return m.handleTaskQueryInput(msg)
+	}
 	switch msg.String() {
 	case "esc":
 		// Exit search mode
@@ -443,9 +567,72 @@ func (m Model) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// handleTaskQueryInput handles input when the search bar edits the task
+// query. The task pane is filtered as the query is typed, by the last
+// version of it that parses.
+func (m Model) handleTaskQueryInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		// Exit and clear the filter
+		m.searchMode = false
+		m.searchTasks = false
+		m.taskQuery = ""
+		m.taskFilter = beads.Query{}
+		m.selectedTaskIndex = 0
+		return m, nil
+		
+	case "enter":
+		m.searchMode = false
+		m.searchTasks = false
+		if _, err := beads.ParseQuery(m.taskQuery); err != nil {
+			m.err = err
+		}
+		return m, nil
+		
+	case "backspace":
+		if len(m.taskQuery) > 0 {
+			m.taskQuery = m.taskQuery[:len(m.taskQuery)-1]
+		}
+		
+	default:
+		if len(msg.String()) == 1 {
+			m.taskQuery += msg.String()
+		}
+	}
+	if q, err := beads.ParseQuery(m.taskQuery); err == nil {
+		m.taskFilter = q
+		m.selectedTaskIndex = 0
+	}
+	return m, nil
+}
+
 // claimTaskCmd claims the selected task for the current user
+// taskHistoryCmd reads the history of the selected task, if the beads
+// client records it
+func taskHistoryCmd(m Model) tea.Cmd {
+	return func() tea.Msg {
+		filteredTasks := m.filterTasksByStatus([]string{"open", "in_progress"})
+		if m.selectedTaskIndex < 0 || m.selectedTaskIndex >= len(filteredTasks) {
+			return taskActionMsg{success: false, message: "No task selected"}
+		}
+		task := filteredTasks[m.selectedTaskIndex]
+		historyClient, ok := m.beadsClient.(beads.HistoryClient)
+		if !ok {
+			return taskHistoryMsg{task: task.ID, err: fmt.Errorf("task history is not recorded")}
+		}
+		ctx, cancel := m.callContext()
+		defer cancel()
+		events, err := historyClient.TaskHistory(ctx, task.ID)
+		return taskHistoryMsg{task: task.ID, events: events, err: err}
+	}
+}
+
 func claimTaskCmd(m Model) tea.Cmd {
 	return func() tea.Msg {
+		logger.StartCorrelation()
+		ctx, cancel := m.callContext()
+		defer cancel()
+		
 		filteredTasks := m.filterTasksByStatus([]string{"open", "in_progress"})
 		if m.selectedTaskIndex < 0 || m.selectedTaskIndex >= len(filteredTasks) {
 			return taskActionMsg{
@@ -456,6 +643,14 @@ func claimTaskCmd(m Model) tea.Cmd {
 		
 		task := filteredTasks[m.selectedTaskIndex]
 		
+		// Work whose prerequisites aren't done cannot be picked up yet
+		if blockers := beads.Blockers(task, m.tasks); len(blockers) > 0 {
+			return taskActionMsg{
+				success: false,
+				message: fmt.Sprintf("Task #%s is blocked by %s", task.ID, formatTaskIDs(blockers)),
+			}
+		}
+		
 		// Get current user from environment
 		user := "current-user" // TODO: Get from environment or config
 		
@@ -464,7 +659,7 @@ func claimTaskCmd(m Model) tea.Cmd {
 			Assignee: &user,
 		}
 		
-		if err := m.beadsClient.UpdateTask(task.ID, updates); err != nil {
+		if err := m.beadsClient.UpdateTask(ctx, task.ID, updates); err != nil {
 			return taskActionMsg{
 				success: false,
 				message: fmt.Sprintf("Failed to claim task: %v", err),
@@ -481,7 +676,11 @@ func claimTaskCmd(m Model) tea.Cmd {
 // createTaskCmd creates a new task with the given title
 func createTaskCmd(m Model, title string) tea.Cmd {
 	return func() tea.Msg {
-		task, err := m.beadsClient.CreateTask(title)
+		logger.StartCorrelation()
+		ctx, cancel := m.callContext()
+		defer cancel()
+		
+		task, err := m.beadsClient.CreateTask(ctx, title)
 		if err != nil {
 			return taskActionMsg{
 				success: false,
@@ -499,6 +698,8 @@ func createTaskCmd(m Model, title string) tea.Cmd {
 // pauseAgentCmd pauses or resumes the selected agent
 func pauseAgentCmd(m Model) tea.Cmd {
 	return func() tea.Msg {
+		logger.StartCorrelation()
+		
 		// Get selected agent name
 		agentNames := m.getAgentNames()
 		if m.selectedAgentIndex < 0 || m.selectedAgentIndex >= len(agentNames) {
@@ -520,7 +721,7 @@ func pauseAgentCmd(m Model) tea.Cmd {
 		}
 		
 		// Check if running
-		if !m.procManager.IsRunning(info.PID) {
+		if !process.Live(m.procManager, info) {
 			return agentActionMsg{
 				success: false,
 				message: fmt.Sprintf("Agent %s is not running", agentName),
@@ -539,6 +740,10 @@ func pauseAgentCmd(m Model) tea.Cmd {
 // killAgentCmd kills the selected agent
 func killAgentCmd(m Model) tea.Cmd {
 	return func() tea.Msg {
+		logger.StartCorrelation()
+		ctx, cancel := m.callContext()
+		defer cancel()
+		
 		// Get selected agent name
 		agentNames := m.getAgentNames()
 		if m.selectedAgentIndex < 0 || m.selectedAgentIndex >= len(agentNames) {
@@ -560,7 +765,7 @@ func killAgentCmd(m Model) tea.Cmd {
 		}
 		
 		// Stop the process
-		if err := m.procManager.Stop(info.PID); err != nil {
+		if err := m.procManager.Stop(ctx, info.PID); err != nil {
 			return agentActionMsg{
 				success: false,
 				message: fmt.Sprintf("Failed to kill agent: %v", err),
@@ -577,6 +782,10 @@ func killAgentCmd(m Model) tea.Cmd {
 // restartAgentCmd restarts the selected agent
 func restartAgentCmd(m Model) tea.Cmd {
 	return func() tea.Msg {
+		logger.StartCorrelation()
+		ctx, cancel := m.callContext()
+		defer cancel()
+		
 		// Get selected agent name
 		agentNames := m.getAgentNames()
 		if m.selectedAgentIndex < 0 || m.selectedAgentIndex >= len(agentNames) {
@@ -598,8 +807,8 @@ func restartAgentCmd(m Model) tea.Cmd {
 		}
 		
 		// Stop the process
-		if m.procManager.IsRunning(info.PID) {
-			if err := m.procManager.Stop(info.PID); err != nil {
+		if process.Live(m.procManager, info) {
+			if err := m.procManager.Stop(ctx, info.PID); err != nil {
 				return agentActionMsg{
 					success: false,
 					message: fmt.Sprintf("Failed to stop agent: %v", err),
@@ -628,6 +837,37 @@ func restartAgentCmd(m Model) tea.Cmd {
 	}
 }
 
+// auditedCmd runs a task or agent action and records its outcome in the
+// audit log. The result message names the task or agent acted on.
+func auditedCmd(action string, args []string, cmd tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		entry := audit.NewEntry(action, args...)
+		msg := cmd()
+		
+		var success bool
+		var message string
+		switch result := msg.(type) {
+		case taskActionMsg:
+			success, message = result.success, result.message
+		case agentActionMsg:
+			success, message = result.success, result.message
+		default:
+			return msg
+		}
+		
+		if success {
+			entry = entry.Finish(nil)
+			entry.Message = message
+		} else {
+			entry = entry.Finish(fmt.Errorf("%s", message))
+		}
+		if err := audit.Write(entry); err != nil {
+			logger.Warn("Failed to write audit log: %v", err)
+		}
+		return msg
+	}
+}
+
 // viewAgentLogsCmd opens the log file for the selected agent
 func viewAgentLogsCmd(m Model) tea.Cmd {
 	return func() tea.Msg {
@@ -708,7 +948,7 @@ func exportLogsCmd(m Model) tea.Cmd {
 				msg.Timestamp.Format("15:04:05"),
 				msg.Type,
 				msg.Source,
-				msg.Content)
+				logger.Redact(msg.Content))
 			if _, err := file.WriteString(line); err != nil {
 				return logActionMsg{
 					success: false,
@@ -730,6 +970,13 @@ type taskActionMsg struct {
 	message string
 }
 
+// taskHistoryMsg carries the recorded history of a task
+type taskHistoryMsg struct {
+	task   string
+	events []beads.TaskEvent
+	err    error
+}
+
 // agentActionMsg is sent when an agent action completes
 type agentActionMsg struct {
 	success bool
@@ -746,34 +993,44 @@ type logActionMsg struct {
 func (m Model) getFilteredMessages() []mcp.Message {
 	var filtered []mcp.Message
 	
-	for _, msg := range m.messages {
-		// Filter by agent name
-		if m.logFilterAgent != "" && msg.Source != m.logFilterAgent {
-			continue
-		}
-		
-		// Filter by message type
-		if m.logFilterType != "" && string(msg.Type) != m.logFilterType {
-			continue
-		}
-		
-		// Filter by search input
-		if m.searchInput != "" {
-			searchLower := strings.ToLower(m.searchInput)
-			contentLower := strings.ToLower(msg.Content)
-			sourceLower := strings.ToLower(msg.Source)
-			
-			if !strings.Contains(contentLower, searchLower) && !strings.Contains(sourceLower, searchLower) {
-				continue
+	// Spilled messages read back while scrolling come first
+	for _, messages := range [][]mcp.Message{m.olderMessages, m.messages} {
+		for _, msg := range messages {
+			if m.matchesLogFilter(msg) {
+				filtered = append(filtered, msg)
 			}
 		}
-		
-		filtered = append(filtered, msg)
 	}
 	
 	return filtered
 }
 
+// matchesLogFilter reports whether a message passes the log filters
+func (m Model) matchesLogFilter(msg mcp.Message) bool {
+	// Filter by agent name
+	if m.logFilterAgent != "" && msg.Source != m.logFilterAgent {
+		return false
+	}
+	
+	// Filter by message type
+	if m.logFilterType != "" && string(msg.Type) != m.logFilterType {
+		return false
+	}
+	
+	// Filter by search input
+	if m.searchInput != "" {
+		searchLower := strings.ToLower(m.searchInput)
+		contentLower := strings.ToLower(msg.Content)
+		sourceLower := strings.ToLower(msg.Source)
+		
+		if !strings.Contains(contentLower, searchLower) && !strings.Contains(sourceLower, searchLower) {
+			return false
+		}
+	}
+	
+	return true
+}
+
 // cycleAgentFilter cycles through agent name filters
 func (m Model) cycleAgentFilter() Model {
 	agentNames := m.getAgentNames()
@@ -838,14 +1095,54 @@ func (m Model) cycleMessageTypeFilter() Model {
 	return m
 }
 
+// handlePipelineEvent shows phase transitions in the notification bar
+func (m Model) handlePipelineEvent(msg pipelineEventMsg) (tea.Model, tea.Cmd) {
+	switch pipeline.EventType(msg.Type) {
+	case pipeline.EventPhaseStarted, pipeline.EventPipelineCompleted:
+		m.reloadNotification = "▶ " + msg.Message
+		m.reloadNotificationTime = time.Now()
+	case pipeline.EventError:
+		m.reloadNotification = "❌ Pipeline: " + msg.Message
+		m.reloadNotificationTime = time.Now()
+	}
+	return m, waitForPipelineEventCmd(m.pipeline)
+}
+
+// handleBudgetEvent shows budget warnings and pauses in the notification bar
+func (m Model) handleBudgetEvent(msg budgetEventMsg) (tea.Model, tea.Cmd) {
+	switch budget.EventType(msg.Type) {
+	case budget.EventWarning, budget.EventExceeded:
+		m.reloadNotification = "⚠ " + msg.Message
+	case budget.EventPaused:
+		m.reloadNotification = "⏸ " + msg.Message
+	case budget.EventError:
+		m.reloadNotification = "❌ Budget: " + msg.Message
+	}
+	m.reloadNotificationTime = time.Now()
+	return m, waitForBudgetEventCmd(m.budget)
+}
+
 // handleConfigReload processes configuration reload events
 func (m Model) handleConfigReload(msg configReloadMsg) (tea.Model, tea.Cmd) {
-	if m.reloadManager == nil || msg.newConfig == nil {
+	if m.reloadManager == nil {
+		return m, nil
+	}
+	statusPath, _ := config.ReloadStatusPath()
+
+	// A file that fails to load leaves the agents alone
+	if msg.err != nil {
+		m.reloadNotification = fmt.Sprintf("❌ Config reload failed: %v", msg.err)
+		m.reloadNotificationTime = time.Now()
+		recordReload(statusPath, nil, msg.err)
+		return m, waitForConfigReloadCmd(m.configWatcher)
+	}
+	if msg.newConfig == nil {
 		return m, nil
 	}
 
 	// Perform the reload
 	result, err := m.reloadManager.Reload(msg.newConfig)
+	recordReload(statusPath, result, err)
 	if err != nil {
 		m.err = fmt.Errorf("config reload failed: %w", err)
 		m.reloadNotification = fmt.Sprintf("❌ Config reload failed: %v", err)
@@ -896,4 +1193,12 @@ func (m Model) handleConfigReload(msg configReloadMsg) (tea.Model, tea.Cmd) {
 	return m, waitForConfigReloadCmd(m.configWatcher)
 }
 
-
+// recordReload records the outcome of a reload for asc reload to report
+func recordReload(path string, result *config.ReloadResult, err error) {
+	if path == "" {
+		return
+	}
+	if recordErr := config.RecordReload(path, result, err); recordErr != nil {
+		logger.Warn("Failed to record config reload: %v", recordErr)
+	}
+}
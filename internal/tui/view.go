@@ -1,9 +1,13 @@
 package tui
 
 import (
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	ascerrors "github.com/rand/asc/internal/errors"
+	"github.com/rand/asc/internal/i18n"
+	"github.com/rand/asc/internal/mcp"
 )
 
 // View renders the complete TUI layout
@@ -63,6 +67,11 @@ func (m Model) View() string {
 		return m.overlayModal(baseView, modal)
 	}
 	
+	if m.showHistoryModal {
+		modal := m.renderTaskHistoryModal()
+		return m.overlayModal(baseView, modal)
+	}
+	
 	if m.showCreateModal {
 		modal := m.renderCreateTaskModal()
 		return m.overlayModal(baseView, modal)
@@ -109,15 +118,18 @@ func (m Model) renderFooter(width int) string {
 		Foreground(lipgloss.Color("10")).
 		Bold(true)
 	
+	errorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("9"))
+	
 	// Build keybindings section
 	keybindings := lipgloss.JoinHorizontal(
 		lipgloss.Left,
 		keyStyle.Render("(q)"),
-		" quit | ",
+		i18n.T("tui.footer.quit"),
 		keyStyle.Render("(r)"),
-		" refresh | ",
+		i18n.T("tui.footer.refresh"),
 		keyStyle.Render("(t)"),
-		" test",
+		i18n.T("tui.footer.test"),
 	)
 	
 	// Add debug indicator if debug mode is enabled
@@ -169,6 +181,28 @@ func (m Model) renderFooter(width int) string {
 			spacer,
 			connectionStatus,
 		)
+	} else if m.err != nil {
+		// Show what failed and how to fix it in place of the keybindings
+		connectionWidth := lipgloss.Width(connectionStatus)
+		errorWidth := width - connectionWidth - 5
+		if errorWidth < 1 {
+			errorWidth = 1
+		}
+		errorLine := errorStyle.MaxWidth(errorWidth).Render(formatFooterError(m.err))
+		
+		spacerWidth := width - lipgloss.Width(errorLine) - connectionWidth - 4
+		if spacerWidth < 1 {
+			spacerWidth = 1
+		}
+		
+		spacer := lipgloss.NewStyle().Width(spacerWidth).Render("")
+		
+		footerContent = lipgloss.JoinHorizontal(
+			lipgloss.Left,
+			errorLine,
+			spacer,
+			connectionStatus,
+		)
 	} else {
 		// Show normal keybindings
 		keybindingsWidth := lipgloss.Width(keybindings)
@@ -192,6 +226,16 @@ func (m Model) renderFooter(width int) string {
 	return footerStyle.Width(width - 2).Render(footerContent)
 }
 
+// formatFooterError renders an error and its hint on one line, marking
+// the hint as FormatTUI does in the log pane
+func formatFooterError(err error) string {
+	line := "✗ " + strings.Join(strings.Split(ascerrors.Summary(err), "\n"), " ")
+	if hint := ascerrors.Hint(err); hint != "" {
+		line += "  ✓ " + hint
+	}
+	return line
+}
+
 // getBeadsConnectionStatus returns a styled connection status for beads
 func (m Model) getBeadsConnectionStatus() string {
 	connectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
@@ -210,13 +254,30 @@ func (m Model) getMCPConnectionStatus() string {
 	warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
 	disconnectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
 	
+	// A server failing requests in a row is degraded, whichever way the
+	// TUI hears from it, as its requests fail fast
+	if reporter, ok := m.mcpClient.(mcp.CircuitReporter); ok {
+		if circuit := reporter.Circuit(); circuit.Degraded() {
+			if circuit.State == mcp.CircuitOpen {
+				return disconnectedStyle.Render("◐ degraded")
+			}
+			return warningStyle.Render("◐ degraded (retrying)")
+		}
+	}
+	
 	// Check WebSocket connection status first (preferred)
 	if m.wsConnected {
 		return connectedStyle.Render("● ws")
 	}
 	
-	// Fallback to HTTP polling
+	// Fallback to HTTP polling, while a dropped WebSocket reconnects
 	if m.mcpClient != nil {
+		switch m.wsState {
+		case mcp.StreamDegraded:
+			return warningStyle.Render("◐ ws reconnecting")
+		case mcp.StreamDisconnected:
+			return warningStyle.Render("● http (ws down)")
+		}
 		return warningStyle.Render("● http")
 	}
 	
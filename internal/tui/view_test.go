@@ -1,11 +1,13 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/rand/asc/internal/beads"
+	ascerrors "github.com/rand/asc/internal/errors"
 	"github.com/rand/asc/internal/mcp"
 )
 
@@ -255,6 +257,23 @@ func TestRenderFooter_ExpiredNotification(t *testing.T) {
 	}
 }
 
+// TestRenderFooter_WithError tests that a failure and its hint replace
+// the keybindings
+func TestRenderFooter_WithError(t *testing.T) {
+	tf := NewTestFramework()
+	model := tf.GetModel()
+
+	model.err = ascerrors.WithHint(fmt.Errorf("failed to get messages: connection refused"), "Start it with 'asc services start'")
+	footer := model.renderFooter(200)
+
+	if !strings.Contains(footer, "failed to get messages") || !strings.Contains(footer, "asc services start") {
+		t.Errorf("Footer should show the error and its hint, got %q", footer)
+	}
+	if strings.Contains(footer, "(q)") {
+		t.Error("Footer should show the error in place of the keybindings")
+	}
+}
+
 // TestGetBeadsConnectionStatus tests beads connection status
 func TestGetBeadsConnectionStatus(t *testing.T) {
 	tf := NewTestFramework()
@@ -303,6 +322,29 @@ func TestGetMCPConnectionStatus(t *testing.T) {
 	if !strings.Contains(status, "http") {
 		t.Error("HTTP fallback status should contain 'http'")
 	}
+
+	// Test a degraded server, even over WebSocket
+	model.wsConnected = true
+	model.mcpClient = &degradedMCPClient{MockMCPClient: mockClient, state: mcp.CircuitOpen}
+	status = model.getMCPConnectionStatus()
+	if !strings.Contains(status, "degraded") {
+		t.Errorf("Degraded status should contain 'degraded', got %q", status)
+	}
+	model.mcpClient = &degradedMCPClient{MockMCPClient: mockClient, state: mcp.CircuitClosed}
+	status = model.getMCPConnectionStatus()
+	if !strings.Contains(status, "ws") {
+		t.Errorf("A closed circuit should not be degraded, got %q", status)
+	}
+}
+
+// degradedMCPClient is a mock MCP client reporting a circuit breaker state
+type degradedMCPClient struct {
+	*MockMCPClient
+	state string
+}
+
+func (c *degradedMCPClient) Circuit() mcp.CircuitStatus {
+	return mcp.CircuitStatus{State: c.state}
 }
 
 // TestOverlayModal tests the overlayModal method
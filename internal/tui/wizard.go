@@ -21,6 +21,7 @@ type wizardStep int
 const (
 	stepWelcome wizardStep = iota
 	stepTemplateSelection
+	stepTemplateVars
 	stepChecking
 	stepCheckResults
 	stepInstallPrompt
@@ -39,6 +40,7 @@ type Wizard struct {
 	checkResults   []check.CheckResult
 	secretsManager *secrets.Manager
 	templateName   string
+	templateValues map[string]string
 }
 
 // wizardModel is the bubbletea model for the wizard
@@ -55,6 +57,12 @@ type wizardModel struct {
 	templates       []config.Template
 	customTemplates []config.Template
 	selectedTemplate int
+
+	// Variables of the template, those --var did not set asked for in turn
+	templateValues map[string]string
+	varsToPrompt   []config.TemplateVar
+	varIndex       int
+	varInput       textinput.Model
 	
 	// API key inputs
 	claudeInput  textinput.Model
@@ -86,6 +94,12 @@ func (w *Wizard) SetTemplate(templateName string) {
 	w.templateName = templateName
 }
 
+// SetTemplateValues sets variables of the template, which are then not
+// asked for
+func (w *Wizard) SetTemplateValues(values map[string]string) {
+	w.templateValues = values
+}
+
 // Run starts the wizard
 func (w *Wizard) Run() error {
 	// Initialize the model
@@ -135,6 +149,10 @@ func (w *Wizard) initialModel() wizardModel {
 	// Load templates
 	templates := config.ListTemplates()
 	customTemplates, _ := config.ListCustomTemplates()
+	templateValues := make(map[string]string)
+	for name, value := range w.templateValues {
+		templateValues[name] = value
+	}
 	
 	return wizardModel{
 		step:            stepWelcome,
@@ -143,6 +161,7 @@ func (w *Wizard) initialModel() wizardModel {
 		templates:       templates,
 		customTemplates: customTemplates,
 		selectedTemplate: 0,
+		templateValues:  templateValues,
 		claudeInput:     claudeInput,
 		openaiInput:     openaiInput,
 		googleInput:     googleInput,
@@ -197,6 +216,11 @@ func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.step == stepAPIKeys {
 			return m.updateInputs(msg)
 		}
+		if m.step == stepTemplateVars {
+			var cmd tea.Cmd
+			m.varInput, cmd = m.varInput.Update(msg)
+			return m, cmd
+		}
 		
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -244,8 +268,7 @@ func (m wizardModel) handleEnter() (tea.Model, tea.Cmd) {
 	case stepWelcome:
 		// If template was specified via flag, skip selection
 		if m.templateName != "" {
-			m.step = stepChecking
-			return m, runChecks(m.checker)
+			return m.startTemplateVars()
 		}
 		m.step = stepTemplateSelection
 		return m, nil
@@ -256,6 +279,15 @@ func (m wizardModel) handleEnter() (tea.Model, tea.Cmd) {
 		if m.selectedTemplate >= 0 && m.selectedTemplate < len(allTemplates) {
 			m.templateName = allTemplates[m.selectedTemplate].Name
 		}
+		return m.startTemplateVars()
+
+	case stepTemplateVars:
+		m.templateValues[m.varsToPrompt[m.varIndex].Name] = strings.TrimSpace(m.varInput.Value())
+		m.varIndex++
+		if m.varIndex < len(m.varsToPrompt) {
+			m.varInput = newTemplateVarInput(m.varsToPrompt[m.varIndex])
+			return m, textinput.Blink
+		}
 		m.step = stepChecking
 		return m, runChecks(m.checker)
 		
@@ -373,7 +405,7 @@ func (m wizardModel) handleEnter() (tea.Model, tea.Cmd) {
 		}
 		
 		m.step = stepGenerating
-		return m, generateConfigFiles(m.apiKeys, m.templateName)
+		return m, generateConfigFiles(m.apiKeys, m.templateName, m.templateValues)
 		
 	case stepGenerating:
 		// After generating config, encrypt if enabled
@@ -419,6 +451,38 @@ func (m wizardModel) handleTab(reverse bool) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// startTemplateVars asks for the variables of the chosen template that
+// were not set, if any, and then checks the system
+func (m wizardModel) startTemplateVars() (tea.Model, tea.Cmd) {
+	m.varsToPrompt = nil
+	m.varIndex = 0
+	if tmpl, err := config.FindTemplate(m.templateName); err == nil {
+		for _, v := range tmpl.Vars {
+			if _, ok := m.templateValues[v.Name]; !ok {
+				m.varsToPrompt = append(m.varsToPrompt, v)
+			}
+		}
+	}
+	if len(m.varsToPrompt) == 0 {
+		m.step = stepChecking
+		return m, runChecks(m.checker)
+	}
+	m.varInput = newTemplateVarInput(m.varsToPrompt[0])
+	m.step = stepTemplateVars
+	return m, textinput.Blink
+}
+
+// newTemplateVarInput returns the input for a template variable, showing
+// its default
+func newTemplateVarInput(v config.TemplateVar) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = v.Default
+	input.CharLimit = 200
+	input.Width = 50
+	input.Focus()
+	return input
+}
+
 func (m wizardModel) handleTemplateNav(delta int) (tea.Model, tea.Cmd) {
 	allTemplates := append(m.templates, m.customTemplates...)
 	m.selectedTemplate += delta
@@ -465,6 +529,8 @@ func (m wizardModel) View() string {
 		return m.viewWelcome()
 	case stepTemplateSelection:
 		return m.viewTemplateSelection()
+	case stepTemplateVars:
+		return m.viewTemplateVars()
 	case stepChecking:
 		return m.viewChecking()
 	case stepCheckResults:
@@ -522,6 +588,32 @@ func (m wizardModel) viewWelcome() string {
 	return s.String()
 }
 
+func (m wizardModel) viewTemplateVars() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("12")).
+		MarginTop(2).
+		MarginBottom(1)
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		MarginTop(2)
+
+	v := m.varsToPrompt[m.varIndex]
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(fmt.Sprintf("📝 Template %s (%d/%d)", m.templateName, m.varIndex+1, len(m.varsToPrompt))))
+	s.WriteString("\n\n")
+	s.WriteString(v.Prompt + ":\n")
+	s.WriteString(m.varInput.View())
+	s.WriteString("\n")
+	help := "Press Enter to continue"
+	if v.Default != "" {
+		help = fmt.Sprintf("Press Enter to continue; leave empty for %s", v.Default)
+	}
+	s.WriteString(helpStyle.Render(help))
+	return s.String()
+}
+
 func (m wizardModel) viewTemplateSelection() string {
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
@@ -788,10 +880,10 @@ func runChecks(checker check.Checker) tea.Cmd {
 	}
 }
 
-func generateConfigFiles(apiKeys map[string]string, templateName string) tea.Cmd {
+func generateConfigFiles(apiKeys map[string]string, templateName string, values map[string]string) tea.Cmd {
 	return func() tea.Msg {
 		// Generate asc.toml
-		if err := generateConfigFromTemplate(templateName); err != nil {
+		if err := generateConfigFromTemplate(templateName, values); err != nil {
 			return generateCompleteMsg{err: err}
 		}
 		
@@ -883,39 +975,29 @@ func validateAPIKey(keyName, value string) bool {
 	return true
 }
 
-func generateConfigFromTemplate(templateName string) error {
+func generateConfigFromTemplate(templateName string, values map[string]string) error {
 	// If no template specified, use default team template
 	if templateName == "" {
 		templateName = "team"
 	}
 	
-	// Try to get built-in template
-	var template *config.Template
-	var err error
-	
-	switch templateName {
-	case "solo":
-		template, err = config.GetTemplate(config.TemplateSolo)
-	case "team":
-		template, err = config.GetTemplate(config.TemplateTeam)
-	case "swarm":
-		template, err = config.GetTemplate(config.TemplateSwarm)
-	default:
-		// Try to load custom template
-		template, err = config.LoadCustomTemplateByName(templateName)
-	}
-	
+	// Built-in templates first, then custom ones
+	template, err := config.FindTemplate(templateName)
 	if err != nil {
 		return fmt.Errorf("failed to load template '%s': %w", templateName, err)
 	}
+	content, err := template.Render(values)
+	if err != nil {
+		return err
+	}
 	
 	// Save template to asc.toml
-	return config.SaveTemplate(template, "asc.toml")
+	return config.SaveTemplate(&config.Template{Name: template.Name, Content: content}, "asc.toml")
 }
 
 func generateDefaultConfig() error {
 	// Use team template as default
-	return generateConfigFromTemplate("team")
+	return generateConfigFromTemplate("team", nil)
 }
 
 func generateEnvFile(apiKeys map[string]string) error {
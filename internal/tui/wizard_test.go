@@ -312,7 +312,7 @@ func TestGenerateConfigFiles(t *testing.T) {
 		"GOOGLE_API_KEY": "AIzatest789",
 	}
 
-	cmd := generateConfigFiles(apiKeys, "team")
+	cmd := generateConfigFiles(apiKeys, "team", nil)
 	if cmd == nil {
 		t.Fatal("generateConfigFiles should return a command")
 	}
@@ -399,8 +399,7 @@ func TestBackupConfigFiles(t *testing.T) {
 
 	// Override home directory for test
 	home := filepath.Join(tmpDir, "home")
-	os.Setenv("HOME", home)
-	defer os.Unsetenv("HOME")
+	t.Setenv("HOME", home)
 
 	err := backupConfigFiles()
 	if err != nil {
@@ -520,7 +519,7 @@ func TestGenerateConfigFromTemplate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := generateConfigFromTemplate(tt.templateName)
+			err := generateConfigFromTemplate(tt.templateName, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("generateConfigFromTemplate(%q) error = %v, wantErr %v", tt.templateName, err, tt.wantErr)
 			}
@@ -911,12 +910,7 @@ func TestGenerateDefaultConfig(t *testing.T) {
 
 // TestEncryptSecrets tests the encryptSecrets command
 func TestEncryptSecrets(t *testing.T) {
-	// Skip if age is not installed
 	manager := secrets.NewManager()
-	if !manager.IsAgeInstalled() {
-		t.Skip("age not installed, skipping encryption test")
-	}
-
 	// Create temp directory for test
 	tmpDir := t.TempDir()
 	oldWd, _ := os.Getwd()
@@ -964,3 +958,47 @@ func TestWizardModel_Init(t *testing.T) {
 	}
 }
 
+
+// TestWizardModel_TemplateVars tests asking for the variables of a custom
+// template that --var did not set
+func TestWizardModel_TemplateVars(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(t.TempDir())
+	templatesDir := filepath.Join(home, ".asc", "templates")
+	os.MkdirAll(templatesDir, 0755)
+	template := "#! var = [{ name = \"team\", prompt = \"Team name\" }, { name = \"model\", default = \"claude\" }]\n" +
+		"[core]\nbeads_db_path = \"./repo\"\n\n[agent.{{.team}}]\ncommand = \"echo\"\nmodel = \"{{.model}}\"\nphases = [\"planning\"]\n"
+	if err := os.WriteFile(filepath.Join(templatesDir, "acme.toml"), []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWizard()
+	w.SetTemplate("acme")
+	w.SetTemplateValues(map[string]string{"model": "gemini"})
+	m := w.initialModel()
+
+	newModel, _ := m.handleEnter()
+	m = newModel.(wizardModel)
+	if m.step != stepTemplateVars || len(m.varsToPrompt) != 1 || m.varsToPrompt[0].Name != "team" {
+		t.Fatalf("Expected to be asked for team only, got step %v, %+v", m.step, m.varsToPrompt)
+	}
+	if view := m.View(); !strings.Contains(view, "Team name") {
+		t.Errorf("Expected the prompt in the view:\n%s", view)
+	}
+
+	m.varInput.SetValue("web")
+	newModel, _ = m.handleEnter()
+	m = newModel.(wizardModel)
+	if m.step != stepChecking {
+		t.Errorf("Expected to check the system next, got step %v", m.step)
+	}
+
+	if err := generateConfigFromTemplate(m.templateName, m.templateValues); err != nil {
+		t.Fatalf("generateConfigFromTemplate() error = %v", err)
+	}
+	data, _ := os.ReadFile("asc.toml")
+	if !strings.Contains(string(data), "[agent.web]") || !strings.Contains(string(data), `model = "gemini"`) || strings.Contains(string(data), "#!") {
+		t.Errorf("Unexpected asc.toml:\n%s", data)
+	}
+}
@@ -0,0 +1,63 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// CheckInterval is how long a recorded release check is reused before
+// GitHub is asked again
+const CheckInterval = 24 * time.Hour
+
+// Check records the newest release of a channel when it was last looked
+// up, so asc doctor does not ask GitHub on every run
+type Check struct {
+	Channel   string    `json:"channel"`
+	Latest    string    `json:"latest"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// CheckPath returns where the last release check is recorded,
+// ~/.asc/upgrade.json
+func CheckPath() (string, error) {
+	return statedir.Path("upgrade.json")
+}
+
+// LoadCheck reads the release check recorded at path
+func LoadCheck(path string) (*Check, error) {
+	var check Check
+	if err := statefile.ReadJSON(path, &check); err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+// SaveCheck records check at path
+func SaveCheck(path string, check *Check) error {
+	if err := statefile.WriteJSON(path, check, 0600); err != nil {
+		return fmt.Errorf("failed to record release check: %w", err)
+	}
+	return nil
+}
+
+// Refresh returns the newest release of channel recorded at path, looking
+// it up and recording it again if it is older than CheckInterval or was
+// for another channel
+func (c *Client) Refresh(ctx context.Context, path, channel string) (*Check, error) {
+	if check, err := LoadCheck(path); err == nil && check.Channel == channel && time.Since(check.CheckedAt) < CheckInterval {
+		return check, nil
+	}
+	release, err := c.Latest(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	check := &Check{Channel: channel, Latest: release.Version, CheckedAt: time.Now()}
+	if err := SaveCheck(path, check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
@@ -0,0 +1,453 @@
+// Package upgrade replaces the asc binary with a newer release from
+// GitHub. A release carries a binary per platform, named asc-<os>-<arch>,
+// a checksums.txt in sha256sum format, and checksums.txt.sig, the base64
+// ed25519 signature of checksums.txt by the key in PublicKey. A download
+// is installed only if its checksum, and the signature of the checksums,
+// match; a build without PublicKey downloads nothing unless the client
+// allows unsigned releases. The download is written next to the running
+// binary and renamed over it, so an interrupted upgrade leaves the old
+// binary in place.
+//
+// Example usage:
+//
+//	client, err := upgrade.NewClient("")
+//	if err != nil {
+//	    return err
+//	}
+//	release, err := client.Latest(ctx, upgrade.ChannelStable)
+//	if err != nil {
+//	    return err
+//	}
+//	if upgrade.Compare(release.Version, upgrade.Current()) > 0 {
+//	    binary, _, err := client.Download(ctx, release)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    err = upgrade.Replace(executable, binary)
+//	}
+package upgrade
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/proxy"
+)
+
+// Version is the release of this binary, set when building one with
+// -ldflags "-X github.com/rand/asc/internal/upgrade.Version=v1.2.3"
+var Version = ""
+
+// PublicKey is the base64 ed25519 key releases are signed with, set when
+// building a release with -ldflags "-X github.com/rand/asc/internal/upgrade.PublicKey=..."
+var PublicKey = ""
+
+// ErrNoPublicKey is returned by Download when this build has no PublicKey
+// to verify the release with and the client does not allow unsigned
+// releases
+var ErrNoPublicKey = errors.New("this build has no release signing key to verify the download with")
+
+// DevVersion is the version of a binary built from source
+const DevVersion = "dev"
+
+// Release channels
+const (
+	ChannelStable = "stable" // Releases only
+	ChannelBeta   = "beta"   // Pre-releases too
+)
+
+// DefaultAPIURL is the GitHub API releases are looked up with
+const DefaultAPIURL = "https://api.github.com"
+
+// Repo is the GitHub repository asc is released from
+const Repo = "rand/asc"
+
+// ChecksumsAsset is the release asset listing the sha256 of the others,
+// and SignatureAsset its signature
+const (
+	ChecksumsAsset = "checksums.txt"
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// requestTimeout bounds the release lookup; downloads are bounded by ctx
+const requestTimeout = 30 * time.Second
+
+// Current returns the version of this binary: Version, the module version
+// go install recorded, or DevVersion
+func Current() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return DevVersion
+}
+
+// ValidateChannel returns an error for a channel other than stable or beta
+func ValidateChannel(channel string) error {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return fmt.Errorf("invalid channel '%s' (must be %s or %s)", channel, ChannelStable, ChannelBeta)
+	}
+	return nil
+}
+
+// AssetName returns the name of the release binary for a platform
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("asc-%s-%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Release is a GitHub release of asc
+type Release struct {
+	Version    string  `json:"tag_name"`
+	Prerelease bool    `json:"prerelease"`
+	Draft      bool    `json:"draft"`
+	URL        string  `json:"html_url"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// asset returns the URL of the named asset of r
+func (r *Release) asset(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL, true
+		}
+	}
+	return "", false
+}
+
+// Client looks up and downloads releases
+type Client struct {
+	// AllowUnsigned makes Download verify only the checksum of a release
+	// when this build has no PublicKey, rather than refuse it
+	AllowUnsigned bool
+
+	apiURL string
+	http   *http.Client
+}
+
+// NewClient creates a client of the GitHub API at apiURL, or of
+// DefaultAPIURL if it is empty, through the proxy of the environment
+func NewClient(apiURL string) (*Client, error) {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	settings, err := proxy.ForService("")
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		http:   &http.Client{Transport: settings.Transport()},
+	}, nil
+}
+
+// Latest returns the newest release of the channel. Drafts are skipped,
+// and pre-releases unless the channel is beta.
+func (c *Client) Latest(ctx context.Context, channel string) (*Release, error) {
+	if err := ValidateChannel(channel); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	body, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/releases?per_page=30", c.apiURL, Repo), "application/vnd.github+json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+	var releases []Release
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse releases: %w", err)
+	}
+
+	var latest *Release
+	for i := range releases {
+		release := &releases[i]
+		if release.Draft || (release.Prerelease && channel != ChannelBeta) {
+			continue
+		}
+		if _, ok := parseVersion(release.Version); !ok {
+			continue
+		}
+		if latest == nil || Compare(release.Version, latest.Version) > 0 {
+			latest = release
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no %s release of %s found", channel, Repo)
+	}
+	return latest, nil
+}
+
+// Download fetches the binary of release for this platform and verifies it
+// against the release's checksums, and their signature by PublicKey. It
+// returns the verified binary and whether the signature was checked, which
+// it is not only without PublicKey and with AllowUnsigned set; without
+// either it returns ErrNoPublicKey.
+func (c *Client) Download(ctx context.Context, release *Release) (binary []byte, signed bool, err error) {
+	if PublicKey == "" && !c.AllowUnsigned {
+		return nil, false, ErrNoPublicKey
+	}
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	binaryURL, ok := release.asset(name)
+	if !ok {
+		return nil, false, fmt.Errorf("release %s has no binary for %s/%s (%s)", release.Version, runtime.GOOS, runtime.GOARCH, name)
+	}
+	checksumsURL, ok := release.asset(ChecksumsAsset)
+	if !ok {
+		return nil, false, fmt.Errorf("release %s has no %s to verify the binary with", release.Version, ChecksumsAsset)
+	}
+
+	checksums, err := c.get(ctx, checksumsURL, "application/octet-stream")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to download %s: %w", ChecksumsAsset, err)
+	}
+	if PublicKey != "" {
+		signatureURL, ok := release.asset(SignatureAsset)
+		if !ok {
+			return nil, false, fmt.Errorf("release %s is not signed (no %s)", release.Version, SignatureAsset)
+		}
+		signature, err := c.get(ctx, signatureURL, "application/octet-stream")
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to download %s: %w", SignatureAsset, err)
+		}
+		if err := VerifySignature(PublicKey, checksums, signature); err != nil {
+			return nil, false, err
+		}
+		signed = true
+	}
+
+	binary, err = c.get(ctx, binaryURL, "application/octet-stream")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if err := VerifyChecksum(checksums, name, binary); err != nil {
+		return nil, false, err
+	}
+	return binary, signed, nil
+}
+
+// get returns the body of a GET of url, an error for a status other than
+// 200. GITHUB_TOKEN, if set, raises the API's rate limit.
+func (c *Client) get(ctx context.Context, url, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.HasPrefix(url, c.apiURL) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// VerifyChecksum checks that binary has the sha256 that checksums, in
+// sha256sum format, lists for name
+func VerifyChecksum(checksums []byte, name string, binary []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		want, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid checksum of %s in %s", name, ChecksumsAsset)
+		}
+		got := sha256.Sum256(binary)
+		if !bytes.Equal(got[:], want) {
+			return fmt.Errorf("checksum mismatch for %s: the download is corrupt or was tampered with", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s lists no checksum for %s", ChecksumsAsset, name)
+}
+
+// VerifySignature checks signature, base64, is the ed25519 signature of
+// message by publicKey, base64
+func VerifySignature(publicKey string, message, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release signing key in this build")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(key, message, sig) {
+		return fmt.Errorf("signature of %s does not match the release signing key", ChecksumsAsset)
+	}
+	return nil
+}
+
+// Replace atomically replaces the binary at path with binary, keeping its
+// permissions. The new binary is written to a temporary file next to it
+// and renamed over it. On Windows, where a running binary cannot be
+// replaced, it is first renamed to path.old.
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return fmt.Errorf("failed to write next to %s: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", path, err)
+		}
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// Compare compares two semantic versions, with or without a leading v,
+// returning -1, 0, or 1. DevVersion and versions that do not parse are
+// older than any release.
+func Compare(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := 0; i < 3; i++ {
+		if va.core[i] != vb.core[i] {
+			return compareInts(va.core[i], vb.core[i])
+		}
+	}
+	return comparePrerelease(va.pre, vb.pre)
+}
+
+// version is a parsed MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]
+type version struct {
+	core [3]int
+	pre  []string
+}
+
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, hasPre := strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return version{}, false
+	}
+	var v version
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		v.core[i] = n
+	}
+	if hasPre {
+		if pre == "" {
+			return version{}, false
+		}
+		v.pre = strings.Split(pre, ".")
+	}
+	return v, true
+}
+
+// comparePrerelease orders pre-release identifiers as semver does: a
+// release is newer than its pre-releases, numeric identifiers compare
+// numerically and are older than alphanumeric ones
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return compareInts(na, nb)
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,213 @@
+package upgrade
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.10.0", "v1.9.9", 1},
+		{"v2.0.0", "v10.0.0", -1},
+		{"v1.0.0", "v1.0.0-rc.1", 1},
+		{"v1.0.0-beta.2", "v1.0.0-beta.10", -1},
+		{"v1.0.0-alpha", "v1.0.0-beta", -1},
+		{"v1.0.0-rc.1", "v1.0.0-rc", 1},
+		{"v1.0.0+20250111", "v1.0.0", 0},
+		{DevVersion, "v0.0.1", -1},
+		{"v0.0.1", "garbage", 1},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// releaseServer serves the releases API and assets of a release whose
+// binary is binary, signed by key if it is set
+func releaseServer(t *testing.T, binary []byte, key ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256(binary)
+	checksums := fmt.Sprintf("%s  %s\n%s  asc-plan9-386\n", hex.EncodeToString(sum[:]), name, strings.Repeat("0", 64))
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/" + Repo + "/releases":
+			asset := func(name string) Asset { return Asset{Name: name, URL: server.URL + "/download/" + name} }
+			assets := []Asset{asset(name), asset(ChecksumsAsset)}
+			if key != nil {
+				assets = append(assets, asset(SignatureAsset))
+			}
+			json.NewEncoder(w).Encode([]Release{
+				{Version: "v1.1.0", Assets: assets},
+				{Version: "v1.2.0-beta.1", Prerelease: true, Assets: assets},
+				{Version: "v2.0.0", Draft: true},
+				{Version: "v1.0.0", Assets: assets},
+			})
+		case "/download/" + name:
+			w.Write(binary)
+		case "/download/" + ChecksumsAsset:
+			w.Write([]byte(checksums))
+		case "/download/" + SignatureAsset:
+			w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(checksums)))))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLatest(t *testing.T) {
+	client, err := NewClient(releaseServer(t, []byte("binary"), nil).URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for channel, want := range map[string]string{ChannelStable: "v1.1.0", ChannelBeta: "v1.2.0-beta.1"} {
+		release, err := client.Latest(context.Background(), channel)
+		if err != nil {
+			t.Fatalf("Latest(%s) error = %v", channel, err)
+		}
+		if release.Version != want {
+			t.Errorf("Latest(%s) = %s, want %s", channel, release.Version, want)
+		}
+	}
+	if _, err := client.Latest(context.Background(), "nightly"); err == nil {
+		t.Error("Expected an unknown channel to fail")
+	}
+}
+
+func TestDownload(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKey := PublicKey
+	t.Cleanup(func() { PublicKey = oldKey })
+	ctx := context.Background()
+
+	client, err := NewClient(releaseServer(t, []byte("new binary"), private).URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := client.Latest(ctx, ChannelStable)
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+
+	// Without a key, a release is refused unless unsigned ones are
+	// allowed, and then only the checksum is verified
+	PublicKey = ""
+	if _, _, err := client.Download(ctx, release); !errors.Is(err, ErrNoPublicKey) {
+		t.Errorf("Expected ErrNoPublicKey without a key, got %v", err)
+	}
+	client.AllowUnsigned = true
+	binary, signed, err := client.Download(ctx, release)
+	if err != nil || signed || string(binary) != "new binary" {
+		t.Errorf("Download() = %q, %v, %v", binary, signed, err)
+	}
+
+	PublicKey = base64.StdEncoding.EncodeToString(public)
+	if _, signed, err := client.Download(ctx, release); err != nil || !signed {
+		t.Errorf("Expected the signature verified, got %v, %v", signed, err)
+	}
+
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	PublicKey = base64.StdEncoding.EncodeToString(other)
+	if _, _, err := client.Download(ctx, release); err == nil || !strings.Contains(err.Error(), "signing key") {
+		t.Errorf("Expected a signature by another key to fail, got %v", err)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("binary"))
+	checksums := []byte(hex.EncodeToString(sum[:]) + " *asc-linux-amd64\n")
+
+	if err := VerifyChecksum(checksums, "asc-linux-amd64", []byte("binary")); err != nil {
+		t.Errorf("VerifyChecksum() error = %v", err)
+	}
+	if err := VerifyChecksum(checksums, "asc-linux-amd64", []byte("tampered")); err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Errorf("Expected a tampered binary to fail, got %v", err)
+	}
+	if err := VerifyChecksum(checksums, "asc-darwin-arm64", []byte("binary")); err == nil {
+		t.Error("Expected a binary without a checksum to fail")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asc")
+	if err := os.WriteFile(path, []byte("old"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("Expected the new binary, got %q, %v", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0750 {
+		t.Errorf("Expected the binary's permissions kept, got %v", info.Mode())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary file left, got %d entries", len(entries))
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode([]Release{{Version: "v1.4.0"}})
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "upgrade.json")
+
+	for i := 0; i < 2; i++ {
+		check, err := client.Refresh(context.Background(), path, ChannelStable)
+		if err != nil || check.Latest != "v1.4.0" {
+			t.Fatalf("Refresh() = %+v, %v", check, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected a recent check to be reused, got %d requests", requests)
+	}
+
+	stale := &Check{Channel: ChannelStable, Latest: "v1.3.0", CheckedAt: time.Now().Add(-2 * CheckInterval)}
+	if err := SaveCheck(path, stale); err != nil {
+		t.Fatal(err)
+	}
+	if check, err := client.Refresh(context.Background(), path, ChannelStable); err != nil || check.Latest != "v1.4.0" || requests != 2 {
+		t.Errorf("Expected a stale check to be refreshed, got %+v, %v after %d requests", check, err, requests)
+	}
+}
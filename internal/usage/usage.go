@@ -0,0 +1,121 @@
+// Package usage reads and writes the LLM usage ledger. Each agent appends
+// one JSON record per completion, with the tokens used and the estimated
+// cost, to ~/.asc/usage/<agent>.jsonl; asc sums the ledger to enforce
+// spend budgets.
+//
+// Example usage:
+//
+//	spend, err := usage.ReadSpend(dir, periodStart)
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Printf("main-coder spent $%.2f\n", spend["main-coder"])
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rand/asc/internal/statedir"
+)
+
+// FileEnvVar is the environment variable that tells an agent where to
+// append its usage records
+const FileEnvVar = "ASC_USAGE_FILE"
+
+// Record is one LLM completion in the ledger, as written by the agents
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	Agent     string    `json:"agent"`
+	Model     string    `json:"model"`
+	Tokens    int       `json:"tokens"`
+	CostUSD   float64   `json:"cost_usd"`
+}
+
+// Dir returns ~/.asc/usage
+func Dir() (string, error) {
+	return statedir.Path("usage")
+}
+
+// Env returns the environment variable pointing an agent at its ledger
+// file, or nil if the state directory is unknown
+func Env(agentName string) []string {
+	dir, err := Dir()
+	if err != nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s=%s", FileEnvVar, filepath.Join(dir, agentName+".jsonl"))}
+}
+
+// Append writes a record to the ledger file at path
+func Append(path string, record Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create usage directory: %w", err)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage record: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write usage ledger: %w", err)
+	}
+	return nil
+}
+
+// ReadSpend sums the cost of every record at or after since, per agent.
+// Records without an agent name are attributed to the ledger file's name.
+// Unparseable lines are skipped, so a record torn by a crash does not hide
+// the rest of the ledger.
+func ReadSpend(dir string, since time.Time) (map[string]float64, error) {
+	spend := make(map[string]float64)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage ledgers: %w", err)
+	}
+	for _, path := range files {
+		if err := readFile(path, since, spend); err != nil {
+			return nil, err
+		}
+	}
+	return spend, nil
+}
+
+func readFile(path string, since time.Time, spend map[string]float64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	fallback := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if record.Timestamp.Before(since) {
+			continue
+		}
+		agent := record.Agent
+		if agent == "" {
+			agent = fallback
+		}
+		spend[agent] += record.CostUSD
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read usage ledger %s: %w", path, err)
+	}
+	return nil
+}
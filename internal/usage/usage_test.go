@@ -0,0 +1,52 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadSpend(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	records := []Record{
+		{Timestamp: now, Agent: "coder", CostUSD: 0.25},
+		{Timestamp: now, Agent: "coder", CostUSD: 0.50},
+		{Timestamp: now.Add(-48 * time.Hour), Agent: "coder", CostUSD: 10},
+		{Timestamp: now, CostUSD: 1},
+	}
+	for _, record := range records {
+		if err := Append(filepath.Join(dir, "coder.jsonl"), record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if err := Append(filepath.Join(dir, "tester.jsonl"), Record{Timestamp: now, Agent: "tester", CostUSD: 2}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// A torn line is skipped
+	f, err := os.OpenFile(filepath.Join(dir, "tester.jsonl"), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"agent": "tester", "cost_u`)
+	f.Close()
+
+	spend, err := ReadSpend(dir, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("ReadSpend() error = %v", err)
+	}
+	if spend["coder"] != 1.75 {
+		t.Errorf("coder spend = %v, want 1.75", spend["coder"])
+	}
+	if spend["tester"] != 2 {
+		t.Errorf("tester spend = %v, want 2", spend["tester"])
+	}
+
+	spend, err = ReadSpend(filepath.Join(dir, "missing"), time.Time{})
+	if err != nil || len(spend) != 0 {
+		t.Errorf("ReadSpend() of a missing directory = %v, %v", spend, err)
+	}
+}
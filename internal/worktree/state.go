@@ -0,0 +1,86 @@
+package worktree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rand/asc/internal/statedir"
+	"github.com/rand/asc/internal/statefile"
+)
+
+// Record describes the worktree provisioned for an agent
+type Record struct {
+	Agent     string    `json:"agent"`
+	Repo      string    `json:"repo"`   // Project repository the worktree belongs to
+	Path      string    `json:"path"`   // Worktree directory
+	Branch    string    `json:"branch"` // Branch checked out in the worktree
+	Base      string    `json:"base"`   // Branch the agent's work is merged into
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// State lists the provisioned worktrees by agent name
+type State struct {
+	Worktrees map[string]Record `json:"worktrees"`
+}
+
+// DefaultStatePath returns ~/.asc/worktrees.json
+func DefaultStatePath() (string, error) {
+	return statedir.Path("worktrees.json")
+}
+
+// DefaultDir returns ~/.asc/worktrees
+func DefaultDir() (string, error) {
+	return statedir.Path("worktrees")
+}
+
+// LoadState reads the worktree state from path. A missing file is not an
+// error and yields an empty State.
+func LoadState(path string) (State, error) {
+	state := State{Worktrees: make(map[string]Record)}
+	err := statefile.ReadJSON(path, &state)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if errors.Is(err, statefile.ErrCorrupt) {
+		return State{Worktrees: make(map[string]Record)}, fmt.Errorf("%v\n  Suggestion: Run 'git worktree prune' in the project repository; worktrees will be provisioned again", err)
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read worktree state: %w", err)
+	}
+	if state.Worktrees == nil {
+		state.Worktrees = make(map[string]Record)
+	}
+	return state, nil
+}
+
+// SaveState writes the worktree state to path, replacing it atomically
+func SaveState(path string, state State) error {
+	if err := statefile.WriteJSON(path, state, 0600); err != nil {
+		return fmt.Errorf("failed to write worktree state: %w", err)
+	}
+	return nil
+}
+
+// Env returns the environment variable pointing an agent at its recorded
+// worktree of repo, or nil if it has none. Used when an agent is restarted
+// outside asc up, which is where worktrees are provisioned.
+func Env(agentName, repo string) []string {
+	path, err := DefaultStatePath()
+	if err != nil {
+		return nil
+	}
+	state, err := LoadState(path)
+	if err != nil {
+		return nil
+	}
+	record, ok := state.Worktrees[agentName]
+	if !ok || record.Repo != repo {
+		return nil
+	}
+	if _, err := os.Stat(record.Path); err != nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s=%s", EnvVar, record.Path)}
+}
@@ -0,0 +1,391 @@
+// Package worktree gives each agent an isolated git worktree of the project
+// repository, on a branch of its own, so parallel agents do not edit the
+// same checkout. Worktrees are provisioned when an agent starts, recorded
+// in ~/.asc/worktrees.json, and merged back and pruned with asc worktree.
+//
+// Example usage:
+//
+//	manager, err := worktree.NewManager(repo, "", "asc/", "")
+//	if err != nil {
+//	    return err
+//	}
+//	record, err := manager.Provision("main-coder")
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Printf("main-coder works in %s on %s\n", record.Path, record.Branch)
+package worktree
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rand/asc/internal/logger"
+)
+
+// worktreeLog tags every record written by this package with the worktree component
+var worktreeLog = logger.WithComponent("worktree")
+
+// EnvVar is the environment variable that tells an agent where its
+// worktree is
+const EnvVar = "AGENT_WORKTREE"
+
+// stateMu serializes read-modify-write cycles of the state file within
+// this process
+var stateMu sync.Mutex
+
+// Status is a provisioned worktree with its current state
+type Status struct {
+	Record
+	Exists  bool // The worktree directory is present
+	Changes int  // Uncommitted changed files
+	Ahead   int  // Commits on the agent branch that are not on the base branch
+	Behind  int  // Commits on the base branch that are not on the agent branch
+}
+
+// PruneResult reports what was removed for one agent
+type PruneResult struct {
+	Agent         string
+	BranchDeleted bool // False if the branch has unmerged work and was kept
+}
+
+// Manager provisions, merges, and prunes agent worktrees of one repository
+type Manager struct {
+	repo         string
+	dir          string
+	branchPrefix string
+	base         string
+	statePath    string
+}
+
+// NewManager creates a manager for repo that keeps its state in
+// ~/.asc/worktrees.json. An empty dir means ~/.asc/worktrees; an empty
+// base means the repository's current branch at provisioning time.
+func NewManager(repo, dir, branchPrefix, base string) (*Manager, error) {
+	statePath, err := DefaultStatePath()
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		if dir, err = DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	return NewManagerWithStatePath(repo, dir, branchPrefix, base, statePath), nil
+}
+
+// NewManagerWithStatePath creates a manager with an explicit worktree
+// directory and state file
+func NewManagerWithStatePath(repo, dir, branchPrefix, base, statePath string) *Manager {
+	return &Manager{
+		repo:         repo,
+		dir:          dir,
+		branchPrefix: branchPrefix,
+		base:         base,
+		statePath:    statePath,
+	}
+}
+
+// Provision returns the agent's worktree, creating it if needed. An
+// existing agent branch is checked out again, so work from an earlier run
+// is kept; otherwise the branch is created from the base branch.
+func (m *Manager) Provision(agent string) (Record, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := LoadState(m.statePath)
+	if err != nil {
+		return Record{}, err
+	}
+	if record, ok := state.Worktrees[agent]; ok && record.Repo == m.repo {
+		if _, err := os.Stat(record.Path); err == nil {
+			return record, nil
+		}
+		worktreeLog.WithFields(logger.Fields{"agent": agent, "branch": record.Branch}).Warn("Worktree of agent is gone; recreating it from its branch")
+	}
+
+	base := m.base
+	if base == "" {
+		if base, err = currentBranch(m.repo); err != nil {
+			return Record{}, err
+		}
+	}
+	record := Record{
+		Agent:     agent,
+		Repo:      m.repo,
+		Path:      filepath.Join(m.dir, filepath.Base(m.repo), agent),
+		Branch:    m.branchPrefix + agent,
+		Base:      base,
+		CreatedAt: time.Now(),
+	}
+
+	// Forget worktrees whose directories were deleted, so their branches
+	// can be checked out again
+	if _, err := git(m.repo, "worktree", "prune"); err != nil {
+		return Record{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(record.Path), 0755); err != nil {
+		return Record{}, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+	if branchExists(m.repo, record.Branch) {
+		_, err = git(m.repo, "worktree", "add", record.Path, record.Branch)
+	} else {
+		_, err = git(m.repo, "worktree", "add", "-b", record.Branch, record.Path, base)
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to create worktree for agent '%s': %w\n  Suggestion: Run 'asc worktree prune %s' and start the agent again", agent, err, agent)
+	}
+
+	state.Worktrees[agent] = record
+	if err := SaveState(m.statePath, state); err != nil {
+		return Record{}, err
+	}
+	worktreeLog.WithFields(logger.Fields{
+		"agent":  agent,
+		"branch": record.Branch,
+		"path":   record.Path,
+	}).Info("Provisioned worktree")
+	return record, nil
+}
+
+// Status reports every worktree of the repository, sorted by agent
+func (m *Manager) Status() ([]Status, error) {
+	state, err := LoadState(m.statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, record := range m.records(state) {
+		status := Status{Record: record}
+		if _, err := os.Stat(record.Path); err == nil {
+			status.Exists = true
+			if status.Changes, err = changes(record.Path); err != nil {
+				return nil, err
+			}
+		}
+		if branchExists(m.repo, record.Branch) {
+			if status.Behind, status.Ahead, err = divergence(m.repo, record.Base, record.Branch); err != nil {
+				return nil, err
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Merge merges an agent's branch into its base branch in the project
+// repository and returns the number of commits merged. The worktree must
+// have no uncommitted changes and the repository must have the base
+// branch checked out. A conflicting merge is aborted.
+func (m *Manager) Merge(agent string) (int, error) {
+	state, err := LoadState(m.statePath)
+	if err != nil {
+		return 0, err
+	}
+	record, ok := state.Worktrees[agent]
+	if !ok || record.Repo != m.repo {
+		return 0, fmt.Errorf("agent '%s' has no worktree\n  Suggestion: Run 'asc worktree status' to list worktrees", agent)
+	}
+
+	if _, err := os.Stat(record.Path); err == nil {
+		n, err := changes(record.Path)
+		if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			return 0, fmt.Errorf("worktree of agent '%s' has %d uncommitted change(s)\n  Suggestion: Wait for the agent to finish its task, or commit the changes in %s", agent, n, record.Path)
+		}
+	}
+
+	current, err := currentBranch(m.repo)
+	if err != nil {
+		return 0, err
+	}
+	if current != record.Base {
+		return 0, fmt.Errorf("the project repository has '%s' checked out, not '%s'\n  Suggestion: Run 'git -C %s checkout %s' first", current, record.Base, m.repo, record.Base)
+	}
+
+	_, ahead, err := divergence(m.repo, record.Base, record.Branch)
+	if err != nil || ahead == 0 {
+		return 0, err
+	}
+
+	message := fmt.Sprintf("Merge work of agent %s", agent)
+	if _, err := git(m.repo, "merge", "--no-ff", "-m", message, record.Branch); err != nil {
+		git(m.repo, "merge", "--abort")
+		return 0, fmt.Errorf("failed to merge %s into %s: %w\n  Suggestion: Merge the branch by hand and resolve the conflicts", record.Branch, record.Base, err)
+	}
+	worktreeLog.WithFields(logger.Fields{
+		"agent":   agent,
+		"branch":  record.Branch,
+		"commits": ahead,
+	}).Info("Merged agent branch into %s", record.Base)
+	return ahead, nil
+}
+
+// Prune removes the worktrees of the given agents, or of every agent if
+// none are given, and deletes their branches once merged. Worktrees with
+// uncommitted changes are kept unless force is set; force also deletes
+// unmerged branches. Records of worktrees whose directories were deleted
+// are always dropped.
+func (m *Manager) Prune(agents []string, force bool) ([]PruneResult, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := LoadState(m.statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	if len(agents) == 0 {
+		records = m.records(state)
+	} else {
+		for _, agent := range agents {
+			record, ok := state.Worktrees[agent]
+			if !ok || record.Repo != m.repo {
+				return nil, fmt.Errorf("agent '%s' has no worktree", agent)
+			}
+			records = append(records, record)
+		}
+	}
+
+	results := []PruneResult{}
+	var failures []string
+	for _, record := range records {
+		result, err := m.prune(record, force)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		delete(state.Worktrees, record.Agent)
+		results = append(results, result)
+	}
+
+	if _, err := git(m.repo, "worktree", "prune"); err != nil {
+		worktreeLog.Warn("git worktree prune failed: %v", err)
+	}
+	if err := SaveState(m.statePath, state); err != nil {
+		return results, err
+	}
+	if len(failures) > 0 {
+		return results, fmt.Errorf("%s", strings.Join(failures, "\n"))
+	}
+	return results, nil
+}
+
+// prune removes one worktree and its branch
+func (m *Manager) prune(record Record, force bool) (PruneResult, error) {
+	result := PruneResult{Agent: record.Agent}
+
+	if _, err := os.Stat(record.Path); err == nil {
+		n, err := changes(record.Path)
+		if err != nil {
+			return result, err
+		}
+		if n > 0 && !force {
+			return result, fmt.Errorf("worktree of agent '%s' has %d uncommitted change(s); use --force to discard them", record.Agent, n)
+		}
+		args := []string{"worktree", "remove", record.Path}
+		if force {
+			args = []string{"worktree", "remove", "--force", record.Path}
+		}
+		if _, err := git(m.repo, args...); err != nil {
+			return result, fmt.Errorf("failed to remove worktree of agent '%s': %w", record.Agent, err)
+		}
+	}
+
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	if branchExists(m.repo, record.Branch) {
+		if _, err := git(m.repo, "branch", flag, record.Branch); err != nil {
+			worktreeLog.Info("Kept unmerged branch %s: %v", record.Branch, err)
+		} else {
+			result.BranchDeleted = true
+		}
+	}
+	worktreeLog.WithFields(logger.Fields{
+		"agent":          record.Agent,
+		"branch_deleted": result.BranchDeleted,
+	}).Info("Pruned worktree")
+	return result, nil
+}
+
+// records returns the repository's worktree records, sorted by agent
+func (m *Manager) records(state State) []Record {
+	records := []Record{}
+	for _, record := range state.Worktrees {
+		if record.Repo == m.repo {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Agent < records[j].Agent })
+	return records
+}
+
+// git runs a git command in dir and returns its trimmed output. Failures
+// carry git's error output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// currentBranch returns the branch checked out in repo
+func currentBranch(repo string) (string, error) {
+	branch, err := git(repo, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("cannot determine the current branch of %s: %w\n  Suggestion: Check out a branch or set base in [worktree]", repo, err)
+	}
+	return branch, nil
+}
+
+// branchExists reports whether a local branch exists in repo
+func branchExists(repo, branch string) bool {
+	_, err := git(repo, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// changes counts the uncommitted changed files in a worktree
+func changes(path string) (int, error) {
+	output, err := git(path, "status", "--porcelain")
+	if err != nil || output == "" {
+		return 0, err
+	}
+	return len(strings.Split(output, "\n")), nil
+}
+
+// divergence returns how many commits base and branch each have that the
+// other does not
+func divergence(repo, base, branch string) (behind, ahead int, err error) {
+	output, err := git(repo, "rev-list", "--left-right", "--count", base+"..."+branch)
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected git rev-list output: %q", output)
+	}
+	behind, _ = strconv.Atoi(fields[0])
+	ahead, _ = strconv.Atoi(fields[1])
+	return behind, ahead, nil
+}
@@ -0,0 +1,139 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// initRepo creates a git repository on branch main with one commit
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "asc test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "asc test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	repo := filepath.Join(t.TempDir(), "project")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	run(t, repo, "init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(repo, "README.md"), "hello\n")
+	run(t, repo, "add", "-A")
+	run(t, repo, "commit", "-q", "-m", "Initial commit")
+	return repo
+}
+
+func run(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if _, err := git(dir, args...); err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProvisionMergePrune(t *testing.T) {
+	repo := initRepo(t)
+	tmp := t.TempDir()
+	m := NewManagerWithStatePath(repo, filepath.Join(tmp, "worktrees"), "asc/", "", filepath.Join(tmp, "worktrees.json"))
+
+	record, err := m.Provision("coder")
+	if err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+	if record.Branch != "asc/coder" || record.Base != "main" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+	if _, err := os.Stat(filepath.Join(record.Path, "README.md")); err != nil {
+		t.Fatalf("Expected a checkout in %s: %v", record.Path, err)
+	}
+
+	// Provisioning again reuses the worktree
+	if again, err := m.Provision("coder"); err != nil || again.Path != record.Path {
+		t.Errorf("Provision() again = %+v, %v", again, err)
+	}
+
+	// Uncommitted work blocks the merge
+	writeFile(t, filepath.Join(record.Path, "feature.go"), "package feature\n")
+	if _, err := m.Merge("coder"); err == nil {
+		t.Error("Merge() with uncommitted changes should fail")
+	}
+	statuses, err := m.Status()
+	if err != nil || len(statuses) != 1 || statuses[0].Changes != 1 || !statuses[0].Exists {
+		t.Fatalf("Status() = %+v, %v", statuses, err)
+	}
+
+	run(t, record.Path, "add", "-A")
+	run(t, record.Path, "commit", "-q", "-m", "Add feature")
+	if statuses, _ := m.Status(); statuses[0].Ahead != 1 || statuses[0].Changes != 0 {
+		t.Errorf("Expected one commit ahead, got %+v", statuses[0])
+	}
+
+	merged, err := m.Merge("coder")
+	if err != nil || merged != 1 {
+		t.Fatalf("Merge() = %d, %v", merged, err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "feature.go")); err != nil {
+		t.Error("Expected the agent's work in the project repository")
+	}
+
+	results, err := m.Prune(nil, false)
+	if err != nil || len(results) != 1 || !results[0].BranchDeleted {
+		t.Fatalf("Prune() = %+v, %v", results, err)
+	}
+	if _, err := os.Stat(record.Path); !os.IsNotExist(err) {
+		t.Error("Expected the worktree directory to be removed")
+	}
+	if branchExists(repo, "asc/coder") {
+		t.Error("Expected the merged branch to be deleted")
+	}
+	if statuses, _ := m.Status(); len(statuses) != 0 {
+		t.Errorf("Expected no worktrees after prune, got %+v", statuses)
+	}
+}
+
+func TestPruneKeepsUnmergedWork(t *testing.T) {
+	repo := initRepo(t)
+	tmp := t.TempDir()
+	m := NewManagerWithStatePath(repo, filepath.Join(tmp, "worktrees"), "asc/", "main", filepath.Join(tmp, "worktrees.json"))
+
+	record, err := m.Provision("tester")
+	if err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+	writeFile(t, filepath.Join(record.Path, "test.go"), "package test\n")
+
+	if _, err := m.Prune([]string{"tester"}, false); err == nil {
+		t.Error("Prune() of a dirty worktree should fail without force")
+	}
+
+	run(t, record.Path, "add", "-A")
+	run(t, record.Path, "commit", "-q", "-m", "Add test")
+	results, err := m.Prune([]string{"tester"}, false)
+	if err != nil || len(results) != 1 || results[0].BranchDeleted {
+		t.Fatalf("Prune() = %+v, %v", results, err)
+	}
+	if !branchExists(repo, "asc/tester") {
+		t.Error("Expected the unmerged branch to be kept")
+	}
+
+	// A new worktree picks the kept branch up again
+	record, err = m.Provision("tester")
+	if err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(record.Path, "test.go")); err != nil {
+		t.Error("Expected the earlier work to be checked out again")
+	}
+}
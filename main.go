@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/rand/asc/cmd"
+	ascerrors "github.com/rand/asc/internal/errors"
 	"github.com/rand/asc/internal/logger"
 )
 
@@ -16,7 +17,9 @@ func main() {
 	defer logger.Close()
 
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprint(os.Stderr, ascerrors.FormatCLI(err))
+		// os.Exit skips deferred calls
+		logger.Close()
 		os.Exit(1)
 	}
 }
@@ -3,6 +3,7 @@
 package test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -64,7 +65,7 @@ func TestProcessManagerIntegration(t *testing.T) {
 	}
 
 	// Stop all
-	if err := manager.StopAll(); err != nil {
+	if err := manager.StopAll(context.Background()); err != nil {
 		t.Errorf("StopAll failed: %v", err)
 	}
 
@@ -213,7 +214,7 @@ func TestMultiAgentScenario(t *testing.T) {
 	}
 
 	// Stop all agents
-	if err := manager.StopAll(); err != nil {
+	if err := manager.StopAll(context.Background()); err != nil {
 		t.Errorf("Failed to stop all agents: %v", err)
 	}
 }
@@ -379,7 +380,7 @@ func TestConcurrentProcessManagement(t *testing.T) {
 	}
 
 	// Clean up
-	manager.StopAll()
+	manager.StopAll(context.Background())
 }
 
 // TestConfigHotReload tests configuration hot-reload functionality
@@ -479,7 +480,7 @@ func TestProcessLifecycleWithMonitoring(t *testing.T) {
 	}
 
 	// Stop process gracefully
-	err = manager.Stop(pid)
+	err = manager.Stop(context.Background(), pid)
 	if err != nil {
 		t.Errorf("Failed to stop process: %v", err)
 	}
@@ -644,7 +645,7 @@ func TestProcessLogCapture(t *testing.T) {
 	}
 
 	// Clean up
-	manager.Stop(pid)
+	manager.Stop(context.Background(), pid)
 }
 
 // TestConfigEnvIntegration tests config and env file integration
@@ -765,7 +766,7 @@ func TestHealthMonitoringIntegration(t *testing.T) {
 	}
 
 	// Stop process
-	manager.Stop(pid)
+	manager.Stop(context.Background(), pid)
 
 	// Verify health check fails
 	time.Sleep(200 * time.Millisecond)
@@ -898,7 +899,7 @@ func TestProcessEnvironmentVariables(t *testing.T) {
 		}
 	}
 
-	manager.Stop(pid)
+	manager.Stop(context.Background(), pid)
 }
 
 // TestMultipleAgentCoordination tests multiple agents working together
@@ -954,7 +955,7 @@ func TestMultipleAgentCoordination(t *testing.T) {
 	}
 
 	// Stop all agents
-	manager.StopAll()
+	manager.StopAll(context.Background())
 
 	// Verify all stopped
 	time.Sleep(200 * time.Millisecond)
@@ -1052,7 +1053,7 @@ func TestProcessGracefulShutdown(t *testing.T) {
 
 	// Stop with graceful shutdown
 	start := time.Now()
-	err = manager.Stop(pid)
+	err = manager.Stop(context.Background(), pid)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -1091,7 +1092,7 @@ func TestResourceCleanup(t *testing.T) {
 	}
 
 	// Stop all
-	err = manager.StopAll()
+	err = manager.StopAll(context.Background())
 	if err != nil {
 		t.Errorf("StopAll failed: %v", err)
 	}
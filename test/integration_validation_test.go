@@ -3,6 +3,7 @@
 package test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -164,7 +165,7 @@ phases = ["testing"]
 	}
 
 	// Simulate "down" - stop agent
-	err = manager.Stop(pid)
+	err = manager.Stop(context.Background(), pid)
 	if err != nil {
 		t.Errorf("Failed to stop agent: %v", err)
 	}
@@ -176,7 +177,7 @@ phases = ["testing"]
 	}
 
 	// Verify cleanup
-	err = manager.StopAll()
+	err = manager.StopAll(context.Background())
 	if err != nil {
 		t.Errorf("StopAll failed: %v", err)
 	}
@@ -283,11 +284,6 @@ phases = ["implementation"]
 
 // TestIntegrationValidation_SecretsEncryptionDecryption tests secrets encryption/decryption
 func TestIntegrationValidation_SecretsEncryptionDecryption(t *testing.T) {
-	// Skip if age not installed
-	if _, err := exec.LookPath("age"); err != nil {
-		t.Skip("age not installed, skipping secrets test")
-	}
-
 	tmpDir := t.TempDir()
 
 	// Create secrets manager
@@ -603,7 +599,7 @@ func TestIntegrationValidation_MultiAgentCoordination(t *testing.T) {
 	}
 
 	// Stop all agents
-	err = manager.StopAll()
+	err = manager.StopAll(context.Background())
 	if err != nil {
 		t.Errorf("Failed to stop all agents: %v", err)
 	}
@@ -711,7 +707,7 @@ GOOGLE_API_KEY=test-key
 	time.Sleep(3 * time.Second)
 
 	// Step 6: Stop agents (simulating asc down)
-	err = manager.StopAll()
+	err = manager.StopAll(context.Background())
 	if err != nil {
 		t.Errorf("Failed to stop agents: %v", err)
 	}
@@ -762,7 +758,7 @@ func TestIntegrationValidation_ErrorRecovery(t *testing.T) {
 	}
 
 	// Test 3: Cleanup after errors
-	err = manager.StopAll()
+	err = manager.StopAll(context.Background())
 	if err != nil {
 		t.Errorf("StopAll should handle errors gracefully: %v", err)
 	}
@@ -897,7 +893,7 @@ func TestIntegrationValidation_StressTest(t *testing.T) {
 	time.Sleep(5 * time.Second)
 
 	// Stop all
-	err = manager.StopAll()
+	err = manager.StopAll(context.Background())
 	if err != nil {
 		t.Errorf("Failed to stop all agents: %v", err)
 	}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
@@ -148,7 +149,7 @@ func TestShutdownTime(t *testing.T) {
 	}
 
 	start := time.Now()
-	err = pm.StopAll()
+	err = pm.StopAll(context.Background())
 	elapsed := time.Since(start)
 
 	if err != nil {
@@ -183,7 +184,7 @@ func BenchmarkProcessOperations(b *testing.B) {
 			}
 			
 			b.StopTimer()
-			_ = pm.Stop(pid)
+			_ = pm.Stop(context.Background(), pid)
 			b.StartTimer()
 		}
 	})
@@ -194,7 +195,7 @@ func BenchmarkProcessOperations(b *testing.B) {
 		if err != nil {
 			b.Fatalf("Start failed: %v", err)
 		}
-		defer pm.Stop(pid)
+		defer pm.Stop(context.Background(), pid)
 		
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
@@ -296,7 +297,7 @@ func TestPerformanceRegression(t *testing.T) {
 			t.Errorf("Process start time %v exceeds baseline %v", elapsed, baselines["process_start"])
 		}
 		
-		_ = pm.StopAll()
+		_ = pm.StopAll(context.Background())
 	})
 }
 
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
@@ -62,7 +63,7 @@ func TestPerformanceValidation_StartupTime(t *testing.T) {
 			}
 
 			// Cleanup
-			_ = pm.StopAll()
+			_ = pm.StopAll(context.Background())
 		})
 	}
 }
@@ -109,7 +110,7 @@ func TestPerformanceValidation_ShutdownTime(t *testing.T) {
 			time.Sleep(100 * time.Millisecond)
 
 			start := time.Now()
-			err = pm.StopAll()
+			err = pm.StopAll(context.Background())
 			elapsed := time.Since(start)
 
 			if err != nil {
@@ -287,7 +288,7 @@ func TestPerformanceValidation_TaskProcessingThroughput(t *testing.T) {
 			}
 
 			// Cleanup
-			_ = pm.StopAll()
+			_ = pm.StopAll(context.Background())
 		})
 	}
 }